@@ -30,6 +30,7 @@ var dryRunExpectation = map[string]bool{
 	"session delete": true, "session stop": true, "session tool-approval resolve": true,
 	"agent create": true, "agent update": true, "agent delete": true,
 	"profile add": true, "profile use": true, "profile remove": true,
+	"ratelimit set": true, "ratelimit delete": true,
 	"skills install": true, // writes skill files to a local dir (state change)
 	"auth logout": true, "auth refresh": true,
 	"link": true, "unlink": true,
@@ -46,6 +47,8 @@ var dryRunExpectation = map[string]bool{
 	"session list": false, "session view": false,
 	"agent list": false, "agent view": false, "agent status": false, "agent check": false,
 	"model list": false, "model view": false,
+	"usage report": false, "usage budgets": false,
+	"ratelimit list": false,
 	"search chunks": false, "search docs": false, "search kb": false, "search sessions": false,
 	"auth list": false, "auth status": false, "auth token": false,
 	"profile list": false,
@@ -0,0 +1,94 @@
+package ratelimitcmd
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Tencent/WeKnora/cli/internal/cmdutil"
+	"github.com/Tencent/WeKnora/cli/internal/iostreams"
+	sdk "github.com/Tencent/WeKnora/client"
+)
+
+// DeleteService is the narrow SDK surface this command depends on.
+type DeleteService interface {
+	DeleteRateLimitPolicy(ctx context.Context, policyID uint64) error
+}
+
+type deleteOptions struct {
+	Yes    bool
+	DryRun bool
+}
+
+// NewCmdDelete builds `weknora ratelimit delete <policy-id>`.
+func NewCmdDelete(f *cmdutil.Factory) *cobra.Command {
+	opts := &deleteOptions{}
+	cmd := &cobra.Command{
+		Use:   "delete <policy-id>",
+		Short: "Delete a rate limit policy",
+		Long: `Delete a rate limit policy by id (see 'weknora ratelimit list'). The scope
+falls back to its per-type default, or becomes unlimited. Without -y/--yes in
+a non-TTY / JSON context it exits 10 (input.confirmation_required) without
+deleting.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(c *cobra.Command, args []string) error {
+			fopts, err := cmdutil.CheckFormatFlag(c)
+			if err != nil {
+				return err
+			}
+			fopts.ResolveDefault(iostreams.IO.IsStdoutTTY())
+			opts.Yes, _ = c.Flags().GetBool("yes")
+			id, err := strconv.ParseUint(args[0], 10, 64)
+			if err != nil || id == 0 {
+				return cmdutil.NewError(cmdutil.CodeInputInvalidArgument,
+					fmt.Sprintf("invalid policy id %q: want a positive integer", args[0]))
+			}
+			if handled, err := cmdutil.HandleDryRun(c, opts.DryRun, cmdutil.DryRunPlan{
+				Action: "ratelimit.delete",
+				Args:   map[string]any{"policy": id},
+			}); handled {
+				return err
+			}
+			cli, err := f.Client()
+			if err != nil {
+				return err
+			}
+			if err := cmdutil.ConfirmDestructive(f.Prompter(), opts.Yes, fopts.WantsJSON(),
+				"delete", "rate limit policy", args[0], "ratelimit.delete",
+				[]string{"weknora", "ratelimit", "delete", args[0], "-y"}); err != nil {
+				return err
+			}
+			return runDelete(c.Context(), fopts, cli, id)
+		},
+	}
+	cmdutil.AddFormatFlag(cmd, "id", "deleted")
+	cmdutil.AddDryRunFlag(cmd, &opts.DryRun)
+	cmdutil.SetRisk(cmd, "ratelimit.delete")
+	cmdutil.SetAgentHelp(cmd, cmdutil.AgentHelp{
+		UsedFor:       "remove a rate limit policy by id",
+		RequiredFlags: []string{"<policy-id> (positional)"},
+		Examples:      []string{"weknora ratelimit delete 3 -y"},
+		Output:        "envelope.data is {id, deleted:true}",
+		Warnings: []string{
+			"Requires explicit user approval (exit 10 / input.confirmation_required); never auto-add -y.",
+			"The scope falls back to its per-type default policy, or becomes unlimited.",
+		},
+	})
+	return cmd
+}
+
+func runDelete(ctx context.Context, fopts *cmdutil.FormatOptions, svc DeleteService, id uint64) error {
+	if err := svc.DeleteRateLimitPolicy(ctx, id); err != nil {
+		return cmdutil.WrapHTTP(err, "delete rate limit policy %d", id)
+	}
+	if fopts.WantsJSON() {
+		return fopts.Emit(iostreams.IO.Out, map[string]any{"id": id, "deleted": true}, nil)
+	}
+	fmt.Fprintf(iostreams.IO.Out, "✓ Deleted rate limit policy %d\n", id)
+	return nil
+}
+
+// compile-time check: the production SDK client implements DeleteService.
+var _ DeleteService = (*sdk.Client)(nil)
@@ -0,0 +1,46 @@
+package ratelimitcmd
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Tencent/WeKnora/cli/internal/cmdutil"
+	"github.com/Tencent/WeKnora/cli/internal/iostreams"
+)
+
+type fakeDeleteSvc struct {
+	gotID uint64
+}
+
+func (f *fakeDeleteSvc) DeleteRateLimitPolicy(_ context.Context, id uint64) error {
+	f.gotID = id
+	return nil
+}
+
+func TestRateLimitDelete_CallsSDKAndEmits(t *testing.T) {
+	out, _ := iostreams.SetForTest(t)
+	svc := &fakeDeleteSvc{}
+	require.NoError(t, runDelete(context.Background(), &cmdutil.FormatOptions{Mode: cmdutil.FormatJSON}, svc, 3))
+	assert.Equal(t, uint64(3), svc.gotID)
+	var env struct {
+		Data struct {
+			ID      uint64 `json:"id"`
+			Deleted bool   `json:"deleted"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(out.Bytes(), &env))
+	assert.Equal(t, uint64(3), env.Data.ID)
+	assert.True(t, env.Data.Deleted)
+}
+
+func TestRateLimitDelete_RejectsNonNumericID(t *testing.T) {
+	iostreams.SetForTest(t)
+	err := withRootHarness(NewCmdDelete(noSDKFactory(t)), "abc").Execute()
+	var ce *cmdutil.Error
+	require.ErrorAs(t, err, &ce)
+	assert.Equal(t, cmdutil.CodeInputInvalidArgument, ce.Code)
+}
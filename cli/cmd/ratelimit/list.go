@@ -0,0 +1,81 @@
+package ratelimitcmd
+
+import (
+	"context"
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Tencent/WeKnora/cli/internal/cmdutil"
+	"github.com/Tencent/WeKnora/cli/internal/iostreams"
+	"github.com/Tencent/WeKnora/cli/internal/output"
+	sdk "github.com/Tencent/WeKnora/client"
+)
+
+// ListService is the narrow SDK surface this command depends on.
+type ListService interface {
+	ListRateLimitPolicies(ctx context.Context) ([]sdk.RateLimitPolicy, error)
+}
+
+// NewCmdList builds `weknora ratelimit list`.
+func NewCmdList(f *cmdutil.Factory) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List rate limit policies",
+		Long: `List the workspace's rate limit policies: the workspace policy, the per-type
+defaults and the policies of individual API keys, members and embed channels.
+"-" means unlimited.`,
+		Args: cobra.NoArgs,
+		RunE: func(c *cobra.Command, _ []string) error {
+			fopts, err := cmdutil.CheckFormatFlag(c)
+			if err != nil {
+				return err
+			}
+			fopts.ResolveDefault(iostreams.IO.IsStdoutTTY())
+			cli, err := f.Client()
+			if err != nil {
+				return err
+			}
+			return runList(c.Context(), fopts, cli)
+		},
+	}
+	cmdutil.AddFormatFlag(cmd, policyFields...)
+	cmdutil.SetAgentHelp(cmd, cmdutil.AgentHelp{
+		UsedFor: "see which request / concurrency / upload / token limits apply to the workspace, its API keys, members and embed channels",
+		Examples: []string{
+			"weknora ratelimit list",
+			"weknora ratelimit list --format json",
+		},
+		Output: "envelope.data is an array of RateLimitPolicy objects (id, scope_type=tenant|api_key|user|embed_channel, scope_id — empty is the per-type default, requests_per_minute, concurrent_streams, ingest_mb_per_day, tokens_per_minute; 0 is unlimited); meta.count is the number of policies",
+	})
+	return cmd
+}
+
+func runList(ctx context.Context, fopts *cmdutil.FormatOptions, svc ListService) error {
+	items, err := svc.ListRateLimitPolicies(ctx)
+	if err != nil {
+		return cmdutil.WrapHTTP(err, "list rate limit policies")
+	}
+	if items == nil {
+		items = []sdk.RateLimitPolicy{} // ensure JSON [] not null
+	}
+	if fopts.WantsJSON() {
+		return fopts.Emit(iostreams.IO.Out, items, &output.Meta{Count: output.IntPtr(len(items))})
+	}
+	if len(items) == 0 {
+		fmt.Fprintln(iostreams.IO.Out, "(no rate limits)")
+		return nil
+	}
+	tw := tabwriter.NewWriter(iostreams.IO.Out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tSCOPE\tREQ/MIN\tSTREAMS\tMB/DAY\tTOKENS/MIN")
+	for _, p := range items {
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t%s\n", p.ID, scopeLabel(p),
+			limitLabel(int64(p.RequestsPerMinute)), limitLabel(int64(p.ConcurrentStreams)),
+			limitLabel(int64(p.IngestMBPerDay)), limitLabel(p.TokensPerMinute))
+	}
+	return tw.Flush()
+}
+
+// compile-time check: the production SDK client implements ListService.
+var _ ListService = (*sdk.Client)(nil)
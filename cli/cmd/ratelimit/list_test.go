@@ -0,0 +1,55 @@
+package ratelimitcmd
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/Tencent/WeKnora/cli/internal/cmdutil"
+	"github.com/Tencent/WeKnora/cli/internal/iostreams"
+	sdk "github.com/Tencent/WeKnora/client"
+)
+
+type fakeListSvc struct {
+	policies []sdk.RateLimitPolicy
+	err      error
+}
+
+func (f *fakeListSvc) ListRateLimitPolicies(_ context.Context) ([]sdk.RateLimitPolicy, error) {
+	return f.policies, f.err
+}
+
+func TestRateLimitList_Text(t *testing.T) {
+	out, _ := iostreams.SetForTest(t)
+	svc := &fakeListSvc{policies: []sdk.RateLimitPolicy{
+		{ID: 1, ScopeType: sdk.RateLimitScopeTenant, RequestsPerMinute: 600, ConcurrentStreams: 20},
+		{ID: 2, ScopeType: sdk.RateLimitScopeAPIKey, RequestsPerMinute: 60},
+		{ID: 3, ScopeType: sdk.RateLimitScopeAPIKey, ScopeID: "7", TokensPerMinute: 100000},
+	}}
+	if err := runList(context.Background(), &cmdutil.FormatOptions{Mode: cmdutil.FormatText}, svc); err != nil {
+		t.Fatalf("runList: %v", err)
+	}
+	got := out.String()
+	for _, want := range []string{"REQ/MIN", "workspace", "600", "api_key (default)", "api_key 7", "100000"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("missing %q in:\n%s", want, got)
+		}
+	}
+}
+
+func TestRateLimitList_EmptyJSON(t *testing.T) {
+	out, _ := iostreams.SetForTest(t)
+	if err := runList(context.Background(), &cmdutil.FormatOptions{Mode: cmdutil.FormatJSON}, &fakeListSvc{}); err != nil {
+		t.Fatalf("runList: %v", err)
+	}
+	var env struct {
+		Data []sdk.RateLimitPolicy `json:"data"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &env); err != nil {
+		t.Fatalf("parse: %v\n%s", err, out.String())
+	}
+	if env.Data == nil || len(env.Data) != 0 {
+		t.Errorf("expected an empty array, got %s", out.String())
+	}
+}
@@ -0,0 +1,63 @@
+// Package ratelimitcmd holds the `weknora ratelimit` command tree:
+// list / set / delete.
+//
+// A policy caps requests per minute, concurrent chat streams, uploaded MB per
+// day and chat tokens per minute for one scope: the workspace, an API key, a
+// member or an embed channel. An empty scope id is the default for every
+// caller of that type. Requests over a limit get HTTP 429, which the CLI
+// surfaces as server.rate_limited.
+package ratelimitcmd
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Tencent/WeKnora/cli/internal/cmdutil"
+	sdk "github.com/Tencent/WeKnora/client"
+)
+
+// NewCmd builds the `weknora ratelimit` parent and registers leaves. Called
+// from cli/cmd/root.go.
+func NewCmd(f *cmdutil.Factory) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "ratelimit",
+		Short: "Manage rate limits and concurrency quotas (list / set / delete)",
+		Long: `Inspect and manage the workspace's rate limit policies: requests per minute,
+concurrent chat streams, uploaded MB per day and chat tokens per minute, for
+the whole workspace, per API key, per member or per embed channel. Requires an
+admin account or a full-access API key.`,
+	}
+	cmd.AddCommand(NewCmdList(f))
+	cmd.AddCommand(NewCmdSet(f))
+	cmd.AddCommand(NewCmdDelete(f))
+	return cmd
+}
+
+// policyFields enumerates the fields surfaced for `--format json` discovery.
+// Matches sdk.RateLimitPolicy.
+var policyFields = []string{
+	"id", "scope_type", "scope_id", "requests_per_minute", "concurrent_streams",
+	"ingest_mb_per_day", "tokens_per_minute", "created_at", "updated_at",
+}
+
+// scopeLabel renders a policy's scope for the text table.
+func scopeLabel(p sdk.RateLimitPolicy) string {
+	switch {
+	case p.ScopeType == sdk.RateLimitScopeTenant:
+		return "workspace"
+	case p.ScopeID == "":
+		return fmt.Sprintf("%s (default)", p.ScopeType)
+	default:
+		return fmt.Sprintf("%s %s", p.ScopeType, p.ScopeID)
+	}
+}
+
+// limitLabel renders 0 as "-" (unlimited).
+func limitLabel(n int64) string {
+	if n <= 0 {
+		return "-"
+	}
+	return strconv.FormatInt(n, 10)
+}
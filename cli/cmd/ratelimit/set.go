@@ -0,0 +1,205 @@
+package ratelimitcmd
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Tencent/WeKnora/cli/internal/cmdutil"
+	"github.com/Tencent/WeKnora/cli/internal/iostreams"
+	sdk "github.com/Tencent/WeKnora/client"
+)
+
+// SetOptions captures the flag state for `ratelimit set`. The *Set bits tell
+// an explicit 0 (unlimited) apart from a limit the caller did not touch.
+type SetOptions struct {
+	Scope     string
+	ID        string
+	RPM       int
+	Streams   int
+	IngestMB  int
+	TPM       int64
+	DryRun    bool
+	flagsSeen setFlags
+}
+
+type setFlags struct{ rpm, streams, ingestMB, tpm bool }
+
+// SetService is the narrow SDK surface. SetRateLimitPolicy replaces the whole
+// policy, so the current policy of the scope is fetched first and only the
+// limits given as flags are changed.
+type SetService interface {
+	ListRateLimitPolicies(ctx context.Context) ([]sdk.RateLimitPolicy, error)
+	SetRateLimitPolicy(ctx context.Context, req *sdk.RateLimitPolicyRequest) (*sdk.RateLimitPolicy, error)
+}
+
+// NewCmdSet builds `weknora ratelimit set`.
+func NewCmdSet(f *cmdutil.Factory) *cobra.Command {
+	opts := &SetOptions{}
+	cmd := &cobra.Command{
+		Use:   "set",
+		Short: "Create or change the rate limit policy of a scope",
+		Long: `Create or change the rate limit policy of a scope. --scope is tenant (the
+whole workspace), api_key, user or embed_channel; --id names the API key,
+member or channel, and leaving it out sets the default for every caller of
+that type. Only the limits passed as flags change; 0 means unlimited.
+
+Reversible write: without -y/--yes in a non-TTY / JSON context it exits 10
+(input.confirmation_required) without applying the change.`,
+		Args: cobra.NoArgs,
+		RunE: func(c *cobra.Command, _ []string) error {
+			fopts, err := cmdutil.CheckFormatFlag(c)
+			if err != nil {
+				return err
+			}
+			fopts.ResolveDefault(iostreams.IO.IsStdoutTTY())
+			opts.flagsSeen = setFlags{
+				rpm:      c.Flags().Changed("rpm"),
+				streams:  c.Flags().Changed("streams"),
+				ingestMB: c.Flags().Changed("ingest-mb"),
+				tpm:      c.Flags().Changed("tpm"),
+			}
+			if err := validateSet(opts); err != nil {
+				return err
+			}
+			if handled, err := cmdutil.HandleDryRun(c, opts.DryRun, cmdutil.DryRunPlan{
+				Action: "ratelimit.set",
+				Args:   setPlanArgs(opts),
+			}); handled {
+				return err
+			}
+			yes, _ := c.Flags().GetBool("yes")
+			retry := cmdutil.BuildRetryArgv(c, []string{"weknora", "ratelimit", "set"},
+				"scope", "id", "rpm", "streams", "ingest-mb", "tpm", "format")
+			target := opts.Scope
+			if opts.ID != "" {
+				target += " " + opts.ID
+			}
+			if err := cmdutil.ConfirmWrite(f.Prompter(), yes, fopts.WantsJSON(), "set", "rate limit", target, "ratelimit.set", retry); err != nil {
+				return err
+			}
+			cli, err := f.Client()
+			if err != nil {
+				return err
+			}
+			return runSet(c.Context(), opts, fopts, cli)
+		},
+	}
+	cmd.Flags().StringVar(&opts.Scope, "scope", "", "Scope type: tenant, api_key, user or embed_channel")
+	cmd.Flags().StringVar(&opts.ID, "id", "", "API key id, user id or embed channel id (omit for the per-type default)")
+	cmd.Flags().IntVar(&opts.RPM, "rpm", 0, "Requests per minute (0 = unlimited)")
+	cmd.Flags().IntVar(&opts.Streams, "streams", 0, "Concurrent chat streams (0 = unlimited)")
+	cmd.Flags().IntVar(&opts.IngestMB, "ingest-mb", 0, "Uploaded MB per UTC day (0 = unlimited)")
+	cmd.Flags().Int64Var(&opts.TPM, "tpm", 0, "Chat tokens per minute (0 = unlimited)")
+	cmdutil.AddFormatFlag(cmd, policyFields...)
+	cmdutil.AddDryRunFlag(cmd, &opts.DryRun)
+	cmdutil.SetWriteRisk(cmd, "ratelimit.set")
+	cmdutil.SetAgentHelp(cmd, cmdutil.AgentHelp{
+		UsedFor:       "cap requests per minute (--rpm), concurrent chat streams (--streams), uploaded MB per day (--ingest-mb) or chat tokens per minute (--tpm) for the workspace, an API key, a member or an embed channel",
+		RequiredFlags: []string{"--scope", "at least one of --rpm / --streams / --ingest-mb / --tpm"},
+		Examples: []string{
+			"weknora ratelimit set --scope tenant --rpm 600 --streams 20 -y",
+			"weknora ratelimit set --scope api_key --rpm 60 -y",
+			"weknora ratelimit set --scope api_key --id 7 --tpm 100000 -y",
+			"weknora ratelimit set --scope embed_channel --id ch_abc --streams 0 -y",
+		},
+		Output: "envelope.data is the saved RateLimitPolicy object",
+		Warnings: []string{
+			"Reversible write: requires explicit approval (exit 10 / input.confirmation_required) unless -y; never auto-add -y.",
+			"Limits not passed keep their current value; pass 0 to lift a limit.",
+		},
+	})
+	return cmd
+}
+
+func validateSet(o *SetOptions) error {
+	if o.Scope == "" {
+		return &cmdutil.Error{
+			Code:    cmdutil.CodeInputMissingFlag,
+			Message: "ratelimit set requires --scope",
+			Hint:    "pass --scope tenant, api_key, user or embed_channel",
+		}
+	}
+	if !slices.Contains(sdk.AllRateLimitScopeTypes(), sdk.RateLimitScopeType(o.Scope)) {
+		return cmdutil.NewError(cmdutil.CodeInputInvalidArgument,
+			fmt.Sprintf("invalid --scope %q: want tenant, api_key, user or embed_channel", o.Scope))
+	}
+	if o.Scope == string(sdk.RateLimitScopeTenant) && o.ID != "" {
+		return cmdutil.NewError(cmdutil.CodeInputInvalidArgument, "--id is not allowed with --scope tenant")
+	}
+	if !o.flagsSeen.rpm && !o.flagsSeen.streams && !o.flagsSeen.ingestMB && !o.flagsSeen.tpm {
+		return &cmdutil.Error{
+			Code:    cmdutil.CodeInputInvalidArgument,
+			Message: "ratelimit set requires at least one limit flag",
+			Hint:    "pass e.g. --rpm, --streams, --ingest-mb or --tpm",
+		}
+	}
+	if o.RPM < 0 || o.Streams < 0 || o.IngestMB < 0 || o.TPM < 0 {
+		return cmdutil.NewError(cmdutil.CodeInputInvalidArgument, "limits must not be negative")
+	}
+	return nil
+}
+
+// setPlanArgs lists the scope and only the limits being changed.
+func setPlanArgs(o *SetOptions) map[string]any {
+	args := map[string]any{"scope_type": o.Scope, "scope_id": o.ID}
+	if o.flagsSeen.rpm {
+		args["requests_per_minute"] = o.RPM
+	}
+	if o.flagsSeen.streams {
+		args["concurrent_streams"] = o.Streams
+	}
+	if o.flagsSeen.ingestMB {
+		args["ingest_mb_per_day"] = o.IngestMB
+	}
+	if o.flagsSeen.tpm {
+		args["tokens_per_minute"] = o.TPM
+	}
+	return args
+}
+
+func runSet(ctx context.Context, opts *SetOptions, fopts *cmdutil.FormatOptions, svc SetService) error {
+	// Fetch-then-set: the PUT replaces the policy, so start from the scope's
+	// current limits and overlay only what the user changed.
+	current, err := svc.ListRateLimitPolicies(ctx)
+	if err != nil {
+		return cmdutil.WrapHTTP(err, "list rate limit policies")
+	}
+	req := &sdk.RateLimitPolicyRequest{ScopeType: sdk.RateLimitScopeType(opts.Scope), ScopeID: opts.ID}
+	for _, p := range current {
+		if p.ScopeType == req.ScopeType && p.ScopeID == req.ScopeID {
+			req.RequestsPerMinute = p.RequestsPerMinute
+			req.ConcurrentStreams = p.ConcurrentStreams
+			req.IngestMBPerDay = p.IngestMBPerDay
+			req.TokensPerMinute = p.TokensPerMinute
+			break
+		}
+	}
+	if opts.flagsSeen.rpm {
+		req.RequestsPerMinute = opts.RPM
+	}
+	if opts.flagsSeen.streams {
+		req.ConcurrentStreams = opts.Streams
+	}
+	if opts.flagsSeen.ingestMB {
+		req.IngestMBPerDay = opts.IngestMB
+	}
+	if opts.flagsSeen.tpm {
+		req.TokensPerMinute = opts.TPM
+	}
+
+	saved, err := svc.SetRateLimitPolicy(ctx, req)
+	if err != nil {
+		return cmdutil.WrapHTTP(err, "set rate limit policy")
+	}
+	if fopts.WantsJSON() {
+		return fopts.Emit(iostreams.IO.Out, saved, nil)
+	}
+	fmt.Fprintf(iostreams.IO.Out, "✓ Set rate limit for %s (id: %d)\n", scopeLabel(*saved), saved.ID)
+	return nil
+}
+
+// compile-time check: the production SDK client implements SetService.
+var _ SetService = (*sdk.Client)(nil)
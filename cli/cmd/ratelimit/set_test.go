@@ -0,0 +1,106 @@
+package ratelimitcmd
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Tencent/WeKnora/cli/internal/cmdutil"
+	"github.com/Tencent/WeKnora/cli/internal/iostreams"
+	"github.com/Tencent/WeKnora/cli/internal/prompt"
+	sdk "github.com/Tencent/WeKnora/client"
+)
+
+// noSDKFactory fails the test if a path that must stay offline (flag
+// validation, dry-run) reaches the SDK or the prompter.
+func noSDKFactory(t *testing.T) *cmdutil.Factory {
+	t.Helper()
+	return &cmdutil.Factory{
+		Client: func() (*sdk.Client, error) {
+			t.Fatal("path must not call Factory.Client()")
+			return nil, nil
+		},
+		Prompter: func() prompt.Prompter {
+			t.Fatal("path must not call Factory.Prompter()")
+			return nil
+		},
+	}
+}
+
+// withRootHarness mounts sub under a minimal `weknora ratelimit` tree that
+// carries the persistent flags the real root registers.
+func withRootHarness(sub *cobra.Command, args ...string) *cobra.Command {
+	root := &cobra.Command{Use: "weknora"}
+	pf := root.PersistentFlags()
+	pf.BoolP("yes", "y", false, "")
+	pf.String("format", "", "")
+	pf.StringP("jq", "q", "", "")
+	parent := &cobra.Command{Use: "ratelimit"}
+	parent.AddCommand(sub)
+	root.AddCommand(parent)
+	root.SetArgs(append([]string{"ratelimit", sub.Name()}, args...))
+	root.SetContext(context.Background())
+	root.SilenceErrors = true
+	root.SilenceUsage = true
+	return root
+}
+
+type fakeSetSvc struct {
+	fakeListSvc
+	got *sdk.RateLimitPolicyRequest
+}
+
+func (f *fakeSetSvc) SetRateLimitPolicy(_ context.Context, req *sdk.RateLimitPolicyRequest) (*sdk.RateLimitPolicy, error) {
+	f.got = req
+	return &sdk.RateLimitPolicy{
+		ID: 9, ScopeType: req.ScopeType, ScopeID: req.ScopeID,
+		RequestsPerMinute: req.RequestsPerMinute, ConcurrentStreams: req.ConcurrentStreams,
+		IngestMBPerDay: req.IngestMBPerDay, TokensPerMinute: req.TokensPerMinute,
+	}, nil
+}
+
+func TestRateLimitSet_KeepsUntouchedLimits(t *testing.T) {
+	iostreams.SetForTest(t)
+	svc := &fakeSetSvc{fakeListSvc: fakeListSvc{policies: []sdk.RateLimitPolicy{
+		{ID: 1, ScopeType: sdk.RateLimitScopeAPIKey, RequestsPerMinute: 60},
+		{ID: 2, ScopeType: sdk.RateLimitScopeAPIKey, ScopeID: "7", RequestsPerMinute: 120, ConcurrentStreams: 4},
+	}}}
+	opts := &SetOptions{Scope: "api_key", ID: "7", Streams: 0, TPM: 5000, flagsSeen: setFlags{streams: true, tpm: true}}
+	require.NoError(t, runSet(context.Background(), opts, &cmdutil.FormatOptions{Mode: cmdutil.FormatJSON}, svc))
+	assert.Equal(t, &sdk.RateLimitPolicyRequest{
+		ScopeType: sdk.RateLimitScopeAPIKey, ScopeID: "7",
+		RequestsPerMinute: 120, ConcurrentStreams: 0, TokensPerMinute: 5000,
+	}, svc.got)
+}
+
+func TestRateLimitSet_ValidatesOffline(t *testing.T) {
+	cases := map[string][]string{
+		"missing scope":  {"--rpm", "10"},
+		"unknown scope":  {"--scope", "team", "--rpm", "10"},
+		"tenant with id": {"--scope", "tenant", "--id", "1", "--rpm", "10"},
+		"no limit flags": {"--scope", "api_key"},
+		"negative limit": {"--scope", "tenant", "--rpm", "-1"},
+	}
+	for name, args := range cases {
+		t.Run(name, func(t *testing.T) {
+			iostreams.SetForTest(t)
+			err := withRootHarness(NewCmdSet(noSDKFactory(t)), args...).Execute()
+			var ce *cmdutil.Error
+			require.True(t, errors.As(err, &ce), "want *cmdutil.Error, got %v", err)
+		})
+	}
+}
+
+func TestRateLimitSet_DryRunSkipsSDK(t *testing.T) {
+	out, _ := iostreams.SetForTest(t)
+	root := withRootHarness(NewCmdSet(noSDKFactory(t)),
+		"--scope", "user", "--id", "u1", "--streams", "2", "--dry-run", "--format", "json")
+	require.NoError(t, root.Execute())
+	assert.Contains(t, out.String(), `"action":"ratelimit.set"`)
+	assert.Contains(t, out.String(), `"concurrent_streams":2`)
+	assert.NotContains(t, out.String(), "requests_per_minute")
+}
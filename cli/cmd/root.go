@@ -24,9 +24,11 @@ import (
 	mcpcmd "github.com/Tencent/WeKnora/cli/cmd/mcp"
 	modelcmd "github.com/Tencent/WeKnora/cli/cmd/model"
 	profilecmd "github.com/Tencent/WeKnora/cli/cmd/profile"
+	ratelimitcmd "github.com/Tencent/WeKnora/cli/cmd/ratelimit"
 	"github.com/Tencent/WeKnora/cli/cmd/search"
 	sessioncmd "github.com/Tencent/WeKnora/cli/cmd/session"
 	skillscmd "github.com/Tencent/WeKnora/cli/cmd/skills"
+	usagecmd "github.com/Tencent/WeKnora/cli/cmd/usage"
 	"github.com/Tencent/WeKnora/cli/internal/build"
 	"github.com/Tencent/WeKnora/cli/internal/cmdutil"
 	"github.com/Tencent/WeKnora/cli/internal/iostreams"
@@ -214,6 +216,8 @@ a curated read-only MCP tool surface for AI agents.`,
 	cmd.AddCommand(chunkcmd.NewCmdChunk(f))
 	cmd.AddCommand(mcpcmd.NewCmd(f))
 	cmd.AddCommand(skillscmd.NewCmd(f))
+	cmd.AddCommand(usagecmd.NewCmd(f))
+	cmd.AddCommand(ratelimitcmd.NewCmd(f))
 	cmd.AddCommand(newCmdExitCodes())
 	cmd.AddCommand(newCmdSchema())
 	installUnknownSubcommandGuard(cmd)
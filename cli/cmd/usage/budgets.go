@@ -0,0 +1,91 @@
+package usagecmd
+
+import (
+	"context"
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Tencent/WeKnora/cli/internal/cmdutil"
+	"github.com/Tencent/WeKnora/cli/internal/iostreams"
+	"github.com/Tencent/WeKnora/cli/internal/output"
+	sdk "github.com/Tencent/WeKnora/client"
+)
+
+// budgetFields enumerates the fields surfaced for `--format json` discovery on
+// `usage budgets`. Matches sdk.UsageBudget.
+var budgetFields = []string{
+	"id", "api_key_id", "monthly_token_limit", "warn_percent", "hard_stop",
+	"period", "used_tokens", "state", "created_at", "updated_at",
+}
+
+// BudgetsService is the narrow SDK surface this command depends on.
+type BudgetsService interface {
+	ListUsageBudgets(ctx context.Context) ([]sdk.UsageBudget, error)
+}
+
+// NewCmdBudgets builds `weknora usage budgets`.
+func NewCmdBudgets(f *cmdutil.Factory) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "budgets",
+		Short: "List monthly token budgets and this month's consumption",
+		Long: `List the workspace's monthly token budgets (the workspace-wide budget and any
+per-API-key budgets) with the tokens used so far this UTC month and their
+state: ok, warning (past the warning threshold) or exceeded.`,
+		Args: cobra.NoArgs,
+		RunE: func(c *cobra.Command, _ []string) error {
+			fopts, err := cmdutil.CheckFormatFlag(c)
+			if err != nil {
+				return err
+			}
+			fopts.ResolveDefault(iostreams.IO.IsStdoutTTY())
+			cli, err := f.Client()
+			if err != nil {
+				return err
+			}
+			return runBudgets(c.Context(), fopts, cli)
+		},
+	}
+	cmdutil.AddFormatFlag(cmd, budgetFields...)
+	cmdutil.SetAgentHelp(cmd, cmdutil.AgentHelp{
+		UsedFor: "check whether the workspace or an API key is close to or over its monthly token budget",
+		Examples: []string{
+			"weknora usage budgets",
+			"weknora usage budgets --format json",
+		},
+		Output: "envelope.data is an array of UsageBudget objects (id, api_key_id — 0 is the workspace budget, monthly_token_limit, warn_percent, hard_stop, period, used_tokens, state=ok|warning|exceeded); meta.count is the number of budgets",
+	})
+	return cmd
+}
+
+func runBudgets(ctx context.Context, fopts *cmdutil.FormatOptions, svc BudgetsService) error {
+	items, err := svc.ListUsageBudgets(ctx)
+	if err != nil {
+		return cmdutil.WrapHTTP(err, "list usage budgets")
+	}
+	if items == nil {
+		items = []sdk.UsageBudget{} // ensure JSON [] not null
+	}
+	if fopts.WantsJSON() {
+		return fopts.Emit(iostreams.IO.Out, items, &output.Meta{Count: output.IntPtr(len(items))})
+	}
+	if len(items) == 0 {
+		fmt.Fprintln(iostreams.IO.Out, "(no budgets)")
+		return nil
+	}
+	tw := tabwriter.NewWriter(iostreams.IO.Out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tSCOPE\tLIMIT\tUSED\tSTATE\tMODE")
+	for _, b := range items {
+		scope := "workspace"
+		if b.APIKeyID != 0 {
+			scope = fmt.Sprintf("api-key %d", b.APIKeyID)
+		}
+		mode := "warn"
+		if b.HardStop {
+			mode = "hard-stop"
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%d\t%d\t%s\t%s\n", b.ID, scope, b.MonthlyTokenLimit, b.UsedTokens, b.State, mode)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,54 @@
+package usagecmd
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/Tencent/WeKnora/cli/internal/cmdutil"
+	"github.com/Tencent/WeKnora/cli/internal/iostreams"
+	sdk "github.com/Tencent/WeKnora/client"
+)
+
+type fakeBudgetsSvc struct {
+	budgets []sdk.UsageBudget
+	err     error
+}
+
+func (f *fakeBudgetsSvc) ListUsageBudgets(_ context.Context) ([]sdk.UsageBudget, error) {
+	return f.budgets, f.err
+}
+
+func TestUsageBudgets_Text(t *testing.T) {
+	out, _ := iostreams.SetForTest(t)
+	svc := &fakeBudgetsSvc{budgets: []sdk.UsageBudget{
+		{ID: 1, MonthlyTokenLimit: 1000000, UsedTokens: 850000, State: "warning"},
+		{ID: 2, APIKeyID: 7, MonthlyTokenLimit: 5000, UsedTokens: 5001, State: "exceeded", HardStop: true},
+	}}
+	if err := runBudgets(context.Background(), &cmdutil.FormatOptions{Mode: cmdutil.FormatText}, svc); err != nil {
+		t.Fatalf("runBudgets: %v", err)
+	}
+	got := out.String()
+	for _, want := range []string{"SCOPE", "workspace", "warning", "api-key 7", "exceeded", "hard-stop"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("missing %q in:\n%s", want, got)
+		}
+	}
+}
+
+func TestUsageBudgets_EmptyJSON(t *testing.T) {
+	out, _ := iostreams.SetForTest(t)
+	if err := runBudgets(context.Background(), &cmdutil.FormatOptions{Mode: cmdutil.FormatJSON}, &fakeBudgetsSvc{}); err != nil {
+		t.Fatalf("runBudgets: %v", err)
+	}
+	var env struct {
+		Data []sdk.UsageBudget `json:"data"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &env); err != nil {
+		t.Fatalf("parse: %v\n%s", err, out.String())
+	}
+	if env.Data == nil || len(env.Data) != 0 {
+		t.Errorf("expected an empty array, got %s", out.String())
+	}
+}
@@ -0,0 +1,239 @@
+package usagecmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Tencent/WeKnora/cli/internal/cmdutil"
+	"github.com/Tencent/WeKnora/cli/internal/iostreams"
+	"github.com/Tencent/WeKnora/cli/internal/text"
+	sdk "github.com/Tencent/WeKnora/client"
+)
+
+// reportFields enumerates the fields surfaced for `--format json` discovery on
+// `usage report`. Matches sdk.UsageReport.
+var reportFields = []string{"query", "rows", "total"}
+
+var (
+	usageGroupByValues = cmdutil.EnumStrings(sdk.AllUsageGroupBys())
+	usageKindValues    = cmdutil.EnumStrings(sdk.AllUsageKinds())
+)
+
+// ReportOptions captures `usage report` flag state.
+type ReportOptions struct {
+	From    string
+	To      string
+	GroupBy string
+	Kind    string
+	Model   string
+	User    string
+	APIKey  uint64
+	Agent   string
+	KB      string
+	Session string
+	CSV     bool
+	Output  string // --output / -O: CSV destination, "-" (default) for stdout
+	Clobber bool
+}
+
+// ReportService is the narrow SDK surface this command depends on.
+type ReportService interface {
+	GetUsageReport(ctx context.Context, query *sdk.UsageReportQuery) (*sdk.UsageReport, error)
+	ExportUsageReportCSV(ctx context.Context, query *sdk.UsageReportQuery) (io.ReadCloser, error)
+}
+
+// NewCmdReport builds `weknora usage report`.
+func NewCmdReport(f *cmdutil.Factory) *cobra.Command {
+	opts := &ReportOptions{}
+	cmd := &cobra.Command{
+		Use:   "report",
+		Short: "Aggregate metered usage over a date range",
+		Long: `Aggregate the workspace's metered usage between --from and --to (UTC days,
+inclusive; default: the current month so far), grouped by --group-by (day,
+month, kind, model, user, api_key, agent, knowledge_base, session). The filter
+flags narrow the records before grouping.
+
+Pass --csv to export the report as CSV (UTF-8 with BOM, one row per group plus
+a final total row) to stdout, or to a file with --output FILE.`,
+		Example: `  weknora usage report
+  weknora usage report --from 2026-01-01 --to 2026-03-31 --group-by month
+  weknora usage report --group-by model --kind chat
+  weknora usage report --group-by api_key --csv -O usage.csv`,
+		Args: cobra.NoArgs,
+		RunE: func(c *cobra.Command, _ []string) error {
+			fopts, err := cmdutil.CheckFormatFlag(c)
+			if err != nil {
+				return err
+			}
+			fopts.ResolveDefault(iostreams.IO.IsStdoutTTY())
+			if err := validateReportOpts(opts); err != nil {
+				return err
+			}
+			cli, err := f.Client()
+			if err != nil {
+				return err
+			}
+			return runReport(c.Context(), opts, fopts, cli)
+		},
+	}
+	cmd.Flags().StringVar(&opts.From, "from", "", "First UTC day to include (YYYY-MM-DD); defaults to the first day of --to's month")
+	cmd.Flags().StringVar(&opts.To, "to", "", "Last UTC day to include (YYYY-MM-DD); defaults to today")
+	cmd.Flags().StringVar(&opts.GroupBy, "group-by", "", "Group by day (default), month, kind, model, user, api_key, agent, knowledge_base or session")
+	cmd.Flags().StringVar(&opts.Kind, "kind", "", "Only count one kind: chat, embedding, rerank, web_search")
+	cmd.Flags().StringVar(&opts.Model, "model", "", "Only count calls to this model id")
+	cmd.Flags().StringVar(&opts.User, "user", "", "Only count calls made by this user id")
+	cmd.Flags().Uint64Var(&opts.APIKey, "api-key", 0, "Only count calls made with this API key id")
+	cmd.Flags().StringVar(&opts.Agent, "agent", "", "Only count calls made by this agent id")
+	cmd.Flags().StringVar(&opts.KB, "kb", "", "Only count calls attributed to this knowledge base id")
+	cmd.Flags().StringVar(&opts.Session, "session", "", "Only count calls made in this session id")
+	cmd.Flags().BoolVar(&opts.CSV, "csv", false, "Export the report as CSV instead of a table / JSON envelope")
+	cmd.Flags().StringVarP(&opts.Output, "output", "O", "-", `CSV destination with --csv; "-" for stdout`)
+	cmd.Flags().BoolVar(&opts.Clobber, "clobber", false, "Overwrite an existing --output file")
+	cmdutil.AddFormatFlag(cmd, reportFields...)
+	cmdutil.SetAgentHelp(cmd, cmdutil.AgentHelp{
+		UsedFor: "answer how many tokens / calls a workspace, API key, agent, model or knowledge base consumed, or export usage for billing",
+		Examples: []string{
+			"weknora usage report --format json",
+			"weknora usage report --group-by model --kind chat --format json",
+			"weknora usage report --from 2026-01-01 --to 2026-01-31 --group-by api_key --csv -O usage.csv",
+		},
+		Output: "envelope.data is a UsageReport: query (the normalized range and grouping), rows[] (key, label, records, calls, prompt_tokens, completion_tokens, cached_tokens, embedding_tokens, total_tokens) and total (the same counters summed); with --csv the raw CSV goes to stdout or --output instead of an envelope",
+	})
+	return cmd
+}
+
+// validateReportOpts checks the static flag values. Called from RunE before
+// the client is built (so a typo surfaces as exit 5, not an auth error) and
+// at runReport's top for direct callers; idempotent.
+func validateReportOpts(opts *ReportOptions) error {
+	for _, d := range []struct{ flag, value string }{{"from", opts.From}, {"to", opts.To}} {
+		if d.value == "" {
+			continue
+		}
+		if _, err := time.Parse("2006-01-02", d.value); err != nil {
+			return &cmdutil.Error{
+				Code:    cmdutil.CodeInputInvalidArgument,
+				Message: fmt.Sprintf("--%s must be a date in YYYY-MM-DD format, got %q", d.flag, d.value),
+			}
+		}
+	}
+	if _, err := cmdutil.ValidateEnum("group-by", opts.GroupBy, usageGroupByValues); err != nil {
+		return err
+	}
+	if _, err := cmdutil.ValidateEnum("kind", opts.Kind, usageKindValues); err != nil {
+		return err
+	}
+	if !opts.CSV && opts.Output != "-" && opts.Output != "" {
+		return &cmdutil.Error{
+			Code:    cmdutil.CodeInputInvalidArgument,
+			Message: "--output only applies to --csv exports",
+			Hint:    "add --csv, or use --format json for a machine-readable report",
+		}
+	}
+	return nil
+}
+
+func (opts *ReportOptions) query() *sdk.UsageReportQuery {
+	return &sdk.UsageReportQuery{
+		From:            opts.From,
+		To:              opts.To,
+		GroupBy:         sdk.UsageGroupBy(opts.GroupBy),
+		Kind:            sdk.UsageKind(opts.Kind),
+		ModelID:         opts.Model,
+		UserID:          opts.User,
+		APIKeyID:        opts.APIKey,
+		AgentID:         opts.Agent,
+		KnowledgeBaseID: opts.KB,
+		SessionID:       opts.Session,
+	}
+}
+
+func runReport(ctx context.Context, opts *ReportOptions, fopts *cmdutil.FormatOptions, svc ReportService) error {
+	if err := validateReportOpts(opts); err != nil {
+		return err
+	}
+	if opts.CSV {
+		return runReportCSV(ctx, opts, svc)
+	}
+	report, err := svc.GetUsageReport(ctx, opts.query())
+	if err != nil {
+		return cmdutil.WrapHTTP(err, "usage report")
+	}
+	if report.Rows == nil {
+		report.Rows = []sdk.UsageReportRow{} // ensure JSON [] not null
+	}
+	if fopts.WantsJSON() {
+		return fopts.Emit(iostreams.IO.Out, report, nil)
+	}
+
+	fmt.Fprintf(iostreams.IO.Out, "%s .. %s, by %s\n", report.Query.From, report.Query.To, report.Query.GroupBy)
+	if len(report.Rows) == 0 {
+		fmt.Fprintln(iostreams.IO.Out, "(no usage recorded)")
+		return nil
+	}
+	tw := tabwriter.NewWriter(iostreams.IO.Out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "KEY\tCALLS\tPROMPT\tCOMPLETION\tCACHED\tEMBEDDING\tTOTAL")
+	for _, r := range report.Rows {
+		key := r.Key
+		if key == "" || key == "0" {
+			key = "-"
+		}
+		if r.Label != "" && r.Label != r.Key {
+			key += " (" + r.Label + ")"
+		}
+		writeReportRow(tw, text.Truncate(48, key), r)
+	}
+	writeReportRow(tw, "total", report.Total)
+	return tw.Flush()
+}
+
+func writeReportRow(w io.Writer, key string, r sdk.UsageReportRow) {
+	fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\t%d\t%d\n",
+		key, r.Calls, r.PromptTokens, r.CompletionTokens, r.CachedTokens, r.EmbeddingTokens, r.TotalTokens)
+}
+
+// runReportCSV streams the server-rendered CSV to stdout or --output. A
+// partial file is removed on error so no truncated export is left behind.
+func runReportCSV(ctx context.Context, opts *ReportOptions, svc ReportService) error {
+	dest := opts.Output
+	if dest != "" && dest != "-" && !opts.Clobber {
+		if _, err := os.Stat(dest); err == nil {
+			return &cmdutil.Error{
+				Code:    cmdutil.CodeInputInvalidArgument,
+				Message: fmt.Sprintf("%s already exists", dest),
+				Hint:    "pass --clobber to overwrite",
+			}
+		}
+	}
+	body, err := svc.ExportUsageReportCSV(ctx, opts.query())
+	if err != nil {
+		return cmdutil.WrapHTTP(err, "export usage report")
+	}
+	defer body.Close()
+
+	if dest == "" || dest == "-" {
+		_, err := io.Copy(iostreams.IO.Out, body)
+		return err
+	}
+	f, err := os.Create(dest)
+	if err != nil {
+		return cmdutil.Wrapf(cmdutil.CodeLocalFileIO, err, "create %s", dest)
+	}
+	if _, err := io.Copy(f, body); err != nil {
+		_ = f.Close()
+		_ = os.Remove(dest)
+		return cmdutil.Wrapf(cmdutil.CodeLocalFileIO, err, "write %s", dest)
+	}
+	if err := f.Close(); err != nil {
+		_ = os.Remove(dest)
+		return cmdutil.Wrapf(cmdutil.CodeLocalFileIO, err, "close %s", dest)
+	}
+	fmt.Fprintf(iostreams.IO.Err, "✓ Saved %s\n", dest)
+	return nil
+}
@@ -0,0 +1,132 @@
+package usagecmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Tencent/WeKnora/cli/internal/cmdutil"
+	"github.com/Tencent/WeKnora/cli/internal/iostreams"
+	sdk "github.com/Tencent/WeKnora/client"
+)
+
+type fakeReportSvc struct {
+	report *sdk.UsageReport
+	csv    string
+	err    error
+	gotQ   *sdk.UsageReportQuery
+}
+
+func (f *fakeReportSvc) GetUsageReport(_ context.Context, q *sdk.UsageReportQuery) (*sdk.UsageReport, error) {
+	f.gotQ = q
+	return f.report, f.err
+}
+
+func (f *fakeReportSvc) ExportUsageReportCSV(_ context.Context, q *sdk.UsageReportQuery) (io.ReadCloser, error) {
+	f.gotQ = q
+	if f.err != nil {
+		return nil, f.err
+	}
+	return io.NopCloser(strings.NewReader(f.csv)), nil
+}
+
+func sampleReport() *sdk.UsageReport {
+	return &sdk.UsageReport{
+		Query: sdk.UsageReportQuery{From: "2026-03-01", To: "2026-03-31", GroupBy: sdk.UsageGroupByModel},
+		Rows: []sdk.UsageReportRow{
+			{Key: "m1", Label: "gpt-x", Calls: 3, PromptTokens: 100, CompletionTokens: 20, TotalTokens: 120},
+			{Key: "m2", Label: "bge", Calls: 1, EmbeddingTokens: 50, TotalTokens: 50},
+		},
+		Total: sdk.UsageReportRow{Calls: 4, PromptTokens: 100, CompletionTokens: 20, EmbeddingTokens: 50, TotalTokens: 170},
+	}
+}
+
+func TestUsageReport_Text(t *testing.T) {
+	out, _ := iostreams.SetForTest(t)
+	svc := &fakeReportSvc{report: sampleReport()}
+	opts := &ReportOptions{GroupBy: "model", Kind: "chat", APIKey: 7, Output: "-"}
+	if err := runReport(context.Background(), opts, &cmdutil.FormatOptions{Mode: cmdutil.FormatText}, svc); err != nil {
+		t.Fatalf("runReport: %v", err)
+	}
+	got := out.String()
+	for _, want := range []string{"2026-03-01 .. 2026-03-31, by model", "KEY", "TOTAL", "m1 (gpt-x)", "120", "total", "170"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("missing %q in:\n%s", want, got)
+		}
+	}
+	if svc.gotQ.GroupBy != sdk.UsageGroupByModel || svc.gotQ.Kind != sdk.UsageKindChat || svc.gotQ.APIKeyID != 7 {
+		t.Errorf("filters not forwarded: %+v", svc.gotQ)
+	}
+}
+
+func TestUsageReport_JSON(t *testing.T) {
+	out, _ := iostreams.SetForTest(t)
+	svc := &fakeReportSvc{report: sampleReport()}
+	if err := runReport(context.Background(), &ReportOptions{Output: "-"}, &cmdutil.FormatOptions{Mode: cmdutil.FormatJSON}, svc); err != nil {
+		t.Fatalf("runReport: %v", err)
+	}
+	var env struct {
+		Data sdk.UsageReport `json:"data"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &env); err != nil {
+		t.Fatalf("parse: %v\n%s", err, out.String())
+	}
+	if len(env.Data.Rows) != 2 || env.Data.Total.TotalTokens != 170 {
+		t.Errorf("unexpected report: %+v", env.Data)
+	}
+}
+
+func TestUsageReport_CSVToStdout(t *testing.T) {
+	out, _ := iostreams.SetForTest(t)
+	svc := &fakeReportSvc{csv: "day,calls\n2026-03-01,3\ntotal,3\n"}
+	if err := runReport(context.Background(), &ReportOptions{CSV: true, Output: "-"}, &cmdutil.FormatOptions{Mode: cmdutil.FormatJSON}, svc); err != nil {
+		t.Fatalf("runReport: %v", err)
+	}
+	if out.String() != svc.csv {
+		t.Errorf("CSV should be streamed verbatim, got %q", out.String())
+	}
+}
+
+func TestUsageReport_CSVToFileRefusesOverwrite(t *testing.T) {
+	iostreams.SetForTest(t)
+	dest := filepath.Join(t.TempDir(), "usage.csv")
+	svc := &fakeReportSvc{csv: "day,calls\n"}
+	opts := &ReportOptions{CSV: true, Output: dest}
+	if err := runReport(context.Background(), opts, &cmdutil.FormatOptions{Mode: cmdutil.FormatText}, svc); err != nil {
+		t.Fatalf("runReport: %v", err)
+	}
+	data, err := os.ReadFile(dest)
+	if err != nil || string(data) != svc.csv {
+		t.Fatalf("file not written: %q %v", data, err)
+	}
+
+	err = runReport(context.Background(), opts, &cmdutil.FormatOptions{Mode: cmdutil.FormatText}, svc)
+	var cerr *cmdutil.Error
+	if !errors.As(err, &cerr) || cerr.Code != cmdutil.CodeInputInvalidArgument {
+		t.Fatalf("expected input.invalid_argument for an existing file, got %v", err)
+	}
+	opts.Clobber = true
+	if err := runReport(context.Background(), opts, &cmdutil.FormatOptions{Mode: cmdutil.FormatText}, svc); err != nil {
+		t.Fatalf("--clobber should overwrite: %v", err)
+	}
+}
+
+func TestUsageReport_InvalidFlags(t *testing.T) {
+	for name, opts := range map[string]*ReportOptions{
+		"bad date":        {From: "2026-3-1", Output: "-"},
+		"bad group-by":    {GroupBy: "tenant", Output: "-"},
+		"bad kind":        {Kind: "image", Output: "-"},
+		"output sans csv": {Output: "usage.csv"},
+	} {
+		err := validateReportOpts(opts)
+		var cerr *cmdutil.Error
+		if !errors.As(err, &cerr) || cerr.Code != cmdutil.CodeInputInvalidArgument {
+			t.Errorf("%s: expected input.invalid_argument, got %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+// Package usagecmd holds the `weknora usage` command tree: report / budgets.
+//
+// Both are read-only. The server meters chat, embedding, rerank and web
+// search calls per workspace; these commands surface the aggregated ledger
+// (optionally as CSV for spreadsheets and billing exports) and the monthly
+// token budgets with their month-to-date consumption. Budgets are managed
+// through the REST API, e.g. `weknora api /api/v1/usage/budgets -X PUT`.
+package usagecmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/Tencent/WeKnora/cli/internal/cmdutil"
+)
+
+// NewCmd builds the `weknora usage` parent and registers leaves. Called from
+// cli/cmd/root.go.
+func NewCmd(f *cmdutil.Factory) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "usage",
+		Short: "Report metered token usage and monthly budgets",
+		Long: `Aggregate the workspace's metered usage (chat / embedding tokens, rerank and
+web search calls) by day, model, API key, agent, knowledge base and more, and
+inspect the monthly token budgets. Requires an admin account or a full-access
+API key.`,
+	}
+	cmd.AddCommand(NewCmdReport(f))
+	cmd.AddCommand(NewCmdBudgets(f))
+	return cmd
+}
@@ -0,0 +1,98 @@
+// Package client provides the implementation for interacting with the WeKnora API
+// The RateLimit related interfaces manage the request, concurrency, upload
+// and token limits of a workspace
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// RateLimitScopeType names what a rate limit policy is attached to
+type RateLimitScopeType string
+
+const (
+	RateLimitScopeTenant       RateLimitScopeType = "tenant"        // The workspace as a whole
+	RateLimitScopeAPIKey       RateLimitScopeType = "api_key"       // Each API key
+	RateLimitScopeUser         RateLimitScopeType = "user"          // Each signed-in member
+	RateLimitScopeEmbedChannel RateLimitScopeType = "embed_channel" // Each embed channel
+)
+
+// AllRateLimitScopeTypes returns every scope type the server accepts, in a
+// stable order.
+func AllRateLimitScopeTypes() []RateLimitScopeType {
+	return []RateLimitScopeType{
+		RateLimitScopeTenant, RateLimitScopeAPIKey, RateLimitScopeUser, RateLimitScopeEmbedChannel,
+	}
+}
+
+// RateLimitPolicy is the set of limits of one scope. A zero limit is
+// unlimited; an empty ScopeID is the default for every caller of the type.
+type RateLimitPolicy struct {
+	ID                uint64             `json:"id"`
+	TenantID          uint64             `json:"tenant_id"`
+	ScopeType         RateLimitScopeType `json:"scope_type"`
+	ScopeID           string             `json:"scope_id"`
+	RequestsPerMinute int                `json:"requests_per_minute"`
+	ConcurrentStreams int                `json:"concurrent_streams"`
+	IngestMBPerDay    int                `json:"ingest_mb_per_day"`
+	TokensPerMinute   int64              `json:"tokens_per_minute"`
+	CreatedAt         time.Time          `json:"created_at"`
+	UpdatedAt         time.Time          `json:"updated_at"`
+}
+
+// RateLimitPolicyRequest creates or replaces the policy of a scope
+type RateLimitPolicyRequest struct {
+	ScopeType         RateLimitScopeType `json:"scope_type"`
+	ScopeID           string             `json:"scope_id"`
+	RequestsPerMinute int                `json:"requests_per_minute"`
+	ConcurrentStreams int                `json:"concurrent_streams"`
+	IngestMBPerDay    int                `json:"ingest_mb_per_day"`
+	TokensPerMinute   int64              `json:"tokens_per_minute"`
+}
+
+// ListRateLimitPolicies returns the rate limit policies of the current workspace
+func (c *Client) ListRateLimitPolicies(ctx context.Context) ([]RateLimitPolicy, error) {
+	resp, err := c.doRequest(ctx, http.MethodGet, "/api/v1/rate-limits", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Success bool              `json:"success"`
+		Data    []RateLimitPolicy `json:"data"`
+	}
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	return response.Data, nil
+}
+
+// SetRateLimitPolicy creates or replaces the rate limit policy of a scope
+func (c *Client) SetRateLimitPolicy(ctx context.Context, request *RateLimitPolicyRequest) (*RateLimitPolicy, error) {
+	resp, err := c.doRequest(ctx, http.MethodPut, "/api/v1/rate-limits", request, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Success bool             `json:"success"`
+		Data    *RateLimitPolicy `json:"data"`
+	}
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	return response.Data, nil
+}
+
+// DeleteRateLimitPolicy removes a rate limit policy
+func (c *Client) DeleteRateLimitPolicy(ctx context.Context, policyID uint64) error {
+	path := fmt.Sprintf("/api/v1/rate-limits/%d", policyID)
+	resp, err := c.doRequest(ctx, http.MethodDelete, path, nil, nil)
+	if err != nil {
+		return err
+	}
+	return parseResponse(resp, nil)
+}
@@ -0,0 +1,215 @@
+// Package client provides the implementation for interacting with the WeKnora API
+// The Usage related interfaces report metered model usage and manage the
+// monthly token budgets of a workspace
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// UsageGroupBy is the dimension a usage report aggregates on
+type UsageGroupBy string
+
+const (
+	UsageGroupByDay           UsageGroupBy = "day"
+	UsageGroupByMonth         UsageGroupBy = "month"
+	UsageGroupByKind          UsageGroupBy = "kind"
+	UsageGroupByModel         UsageGroupBy = "model"
+	UsageGroupByUser          UsageGroupBy = "user"
+	UsageGroupByAPIKey        UsageGroupBy = "api_key"
+	UsageGroupByAgent         UsageGroupBy = "agent"
+	UsageGroupByKnowledgeBase UsageGroupBy = "knowledge_base"
+	UsageGroupBySession       UsageGroupBy = "session"
+)
+
+// AllUsageGroupBys returns every group-by dimension the server accepts, in a
+// stable order.
+func AllUsageGroupBys() []UsageGroupBy {
+	return []UsageGroupBy{
+		UsageGroupByDay, UsageGroupByMonth, UsageGroupByKind, UsageGroupByModel, UsageGroupByUser,
+		UsageGroupByAPIKey, UsageGroupByAgent, UsageGroupByKnowledgeBase, UsageGroupBySession,
+	}
+}
+
+// UsageKind is the kind of metered call
+type UsageKind string
+
+const (
+	UsageKindChat      UsageKind = "chat"       // Chat completion tokens
+	UsageKindEmbedding UsageKind = "embedding"  // Embedding tokens
+	UsageKindRerank    UsageKind = "rerank"     // Rerank calls
+	UsageKindWebSearch UsageKind = "web_search" // Web search calls
+)
+
+// AllUsageKinds returns every usage kind the server records, in a stable order.
+func AllUsageKinds() []UsageKind {
+	return []UsageKind{UsageKindChat, UsageKindEmbedding, UsageKindRerank, UsageKindWebSearch}
+}
+
+// UsageReportQuery selects and groups usage records. Dates are UTC days in
+// YYYY-MM-DD format; empty From/To default to the current month so far.
+type UsageReportQuery struct {
+	From            string       `json:"from"`
+	To              string       `json:"to"`
+	GroupBy         UsageGroupBy `json:"group_by"`
+	Kind            UsageKind    `json:"kind,omitempty"`
+	ModelID         string       `json:"model_id,omitempty"`
+	UserID          string       `json:"user_id,omitempty"`
+	APIKeyID        uint64       `json:"api_key_id,omitempty"`
+	AgentID         string       `json:"agent_id,omitempty"`
+	KnowledgeBaseID string       `json:"knowledge_base_id,omitempty"`
+	SessionID       string       `json:"session_id,omitempty"`
+}
+
+func (q *UsageReportQuery) values() url.Values {
+	v := url.Values{}
+	if q == nil {
+		return v
+	}
+	set := func(k, val string) {
+		if val != "" {
+			v.Set(k, val)
+		}
+	}
+	set("from", q.From)
+	set("to", q.To)
+	set("group_by", string(q.GroupBy))
+	set("kind", string(q.Kind))
+	set("model_id", q.ModelID)
+	set("user_id", q.UserID)
+	set("agent_id", q.AgentID)
+	set("knowledge_base_id", q.KnowledgeBaseID)
+	set("session_id", q.SessionID)
+	if q.APIKeyID != 0 {
+		v.Set("api_key_id", strconv.FormatUint(q.APIKeyID, 10))
+	}
+	return v
+}
+
+// UsageReportRow aggregates the usage sharing one group key
+type UsageReportRow struct {
+	Key              string `json:"key"`
+	Label            string `json:"label,omitempty"`
+	Records          int64  `json:"records"`
+	Calls            int64  `json:"calls"`
+	PromptTokens     int64  `json:"prompt_tokens"`
+	CompletionTokens int64  `json:"completion_tokens"`
+	CachedTokens     int64  `json:"cached_tokens"`
+	EmbeddingTokens  int64  `json:"embedding_tokens"`
+	TotalTokens      int64  `json:"total_tokens"`
+}
+
+// UsageReport is the result of a usage report query
+type UsageReport struct {
+	Query UsageReportQuery `json:"query"`
+	Rows  []UsageReportRow `json:"rows"`
+	Total UsageReportRow   `json:"total"`
+}
+
+// UsageBudget is a monthly token budget with this month's consumption.
+// APIKeyID 0 is the workspace-wide budget.
+type UsageBudget struct {
+	ID                uint64    `json:"id"`
+	TenantID          uint64    `json:"tenant_id"`
+	APIKeyID          uint64    `json:"api_key_id"`
+	MonthlyTokenLimit int64     `json:"monthly_token_limit"`
+	WarnPercent       int       `json:"warn_percent"`
+	HardStop          bool      `json:"hard_stop"`
+	Period            string    `json:"period"`
+	UsedTokens        int64     `json:"used_tokens"`
+	State             string    `json:"state"` // ok, warning or exceeded
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+// UsageBudgetRequest creates or replaces the budget of a scope
+type UsageBudgetRequest struct {
+	APIKeyID          uint64 `json:"api_key_id"`
+	MonthlyTokenLimit int64  `json:"monthly_token_limit"`
+	WarnPercent       int    `json:"warn_percent"`
+	HardStop          bool   `json:"hard_stop"`
+}
+
+// GetUsageReport aggregates the usage of the current workspace
+func (c *Client) GetUsageReport(ctx context.Context, query *UsageReportQuery) (*UsageReport, error) {
+	resp, err := c.doRequest(ctx, http.MethodGet, "/api/v1/usage/report", nil, query.values())
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Success bool         `json:"success"`
+		Data    *UsageReport `json:"data"`
+	}
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	return response.Data, nil
+}
+
+// ExportUsageReportCSV returns the usage report as CSV (UTF-8 with BOM).
+// Callers MUST Close the returned reader.
+func (c *Client) ExportUsageReportCSV(ctx context.Context, query *UsageReportQuery) (io.ReadCloser, error) {
+	values := query.values()
+	values.Set("format", "csv")
+	resp, err := c.doRequest(ctx, http.MethodGet, "/api/v1/usage/report", nil, values)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		return nil, newAPIError(resp.StatusCode, body)
+	}
+	return resp.Body, nil
+}
+
+// ListUsageBudgets returns the budgets of the current workspace
+func (c *Client) ListUsageBudgets(ctx context.Context) ([]UsageBudget, error) {
+	resp, err := c.doRequest(ctx, http.MethodGet, "/api/v1/usage/budgets", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Success bool          `json:"success"`
+		Data    []UsageBudget `json:"data"`
+	}
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	return response.Data, nil
+}
+
+// SetUsageBudget creates or replaces a monthly token budget
+func (c *Client) SetUsageBudget(ctx context.Context, request *UsageBudgetRequest) (*UsageBudget, error) {
+	resp, err := c.doRequest(ctx, http.MethodPut, "/api/v1/usage/budgets", request, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Success bool         `json:"success"`
+		Data    *UsageBudget `json:"data"`
+	}
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	return response.Data, nil
+}
+
+// DeleteUsageBudget removes a monthly token budget
+func (c *Client) DeleteUsageBudget(ctx context.Context, budgetID uint64) error {
+	path := fmt.Sprintf("/api/v1/usage/budgets/%d", budgetID)
+	resp, err := c.doRequest(ctx, http.MethodDelete, path, nil, nil)
+	if err != nil {
+		return err
+	}
+	return parseResponse(resp, nil)
+}
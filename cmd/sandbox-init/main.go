@@ -0,0 +1,10 @@
+// Command weknora-sandbox-init is the first process of every namespace
+// sandbox execution. WeKnora starts it inside fresh Linux namespaces; it is
+// not meant to be run by hand. See internal/sandbox/nsinit.
+package main
+
+import "github.com/Tencent/WeKnora/internal/sandbox/nsinit"
+
+func main() {
+	nsinit.Main()
+}
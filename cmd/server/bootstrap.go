@@ -13,6 +13,7 @@ import (
 
 	"go.uber.org/dig"
 
+	"github.com/Tencent/WeKnora/internal/config"
 	"github.com/Tencent/WeKnora/internal/logger"
 	"github.com/Tencent/WeKnora/internal/types/interfaces"
 )
@@ -33,7 +34,7 @@ import (
 const bootstrapEnvVar = "WEKNORA_BOOTSTRAP_SYSTEM_ADMIN_EMAIL"
 
 // runStartupBootstrap consults the env and applies any one-shot
-// bootstrap actions. Currently it only handles system-admin promotion;
+// bootstrap actions: legacy data backfills and system-admin promotion;
 // future bootstrap steps (default model seeding, etc.) can be added
 // here as additional dig.Invoke calls.
 func runStartupBootstrap(c *dig.Container) {
@@ -52,6 +53,24 @@ func runStartupBootstrap(c *dig.Container) {
 		logger.Warnf(ctx, "[bootstrap] failed to resolve TenantAPIKeyService: %v", err)
 	}
 
+	// Knowledge versions are immutable snapshots, so rows written before
+	// chunk content encryption was turned on would otherwise stay plaintext.
+	// Once every row is sealed this is a single query that finds nothing.
+	if err := c.Invoke(func(
+		cfg *config.Config, keys interfaces.TenantDataKeyService, versions interfaces.KnowledgeVersionRepository,
+	) {
+		if !keys.Configured() || !cfg.Encryption.ChunkContent {
+			return
+		}
+		if n, err := versions.SealPlaintext(ctx); err != nil {
+			logger.Warnf(ctx, "[bootstrap] knowledge version encryption backfill failed: %v", err)
+		} else if n > 0 {
+			logger.Infof(ctx, "[bootstrap] encrypted %d plaintext knowledge version(s)", n)
+		}
+	}); err != nil {
+		logger.Warnf(ctx, "[bootstrap] failed to resolve knowledge version encryption backfill: %v", err)
+	}
+
 	email := strings.TrimSpace(os.Getenv(bootstrapEnvVar))
 	if email == "" {
 		return
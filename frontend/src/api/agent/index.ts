@@ -80,6 +80,8 @@ export interface CustomAgentConfig {
   image_storage_provider?: string;   // 图片存储提供商
   audio_upload_enabled?: boolean;    // 是否启用音频上传/ASR转录（默认: false）
   asr_model_id?: string;            // ASR模型ID（音频转录用）
+  voice_reply_enabled?: boolean;    // IM 语音消息是否附带语音回复（默认: false）
+  tts_model_id?: string;            // TTS模型ID（语音回复用）
   // 附件图片理解 / 扫描件 OCR 开关（默认: false，开启会增加解析耗时）
   attachment_image_understanding?: boolean;
   // 扫描件 OCR 最大页数（0 = 使用全局默认 WEKNORA_CHAT_ATTACHMENT_OCR_MAX_PAGES）
@@ -101,6 +103,14 @@ export interface CustomAgentConfig {
   web_search_provider_id?: string;
   web_search_max_results?: number;
 
+  // ===== 外部数据库 =====
+  // Agent 模式下可查询的外部 SQL 连接（/sql-connections），为空则不注入 sql 工具。
+  sql_connection_ids?: string[];
+
+  // ===== 浏览器自动化 =====
+  // Agent 模式下驱动的浏览器服务（/browser-configs），为空则不注入 browser_* 工具。
+  browser_config_id?: string;
+
   // ===== 多轮对话设置 =====
   multi_turn_enabled?: boolean;     // 是否启用多轮对话
   history_turns?: number;           // 保留历史轮数
@@ -302,18 +312,27 @@ export interface IMChannel {
   tenant_id?: number;
   agent_id: string;
   // 'lark' is Feishu's international edition; it shares Feishu's credentials and modes.
-  platform: 'wecom' | 'feishu' | 'lark' | 'slack' | 'telegram' | 'dingtalk' | 'mattermost' | 'wechat' | 'qqbot' | 'yunzhijia';
+  platform: 'wecom' | 'feishu' | 'lark' | 'slack' | 'telegram' | 'dingtalk' | 'mattermost' | 'wechat' | 'qqbot' | 'yunzhijia' | 'discord' | 'teams' | 'matrix';
   name: string;
   enabled: boolean;
   mode: 'webhook' | 'websocket' | 'longpoll';
   output_mode: 'stream' | 'full';
   session_mode?: 'user' | 'thread';
   knowledge_base_id?: string;
+  thread_archive?: IMThreadArchiveConfig;
   credentials: Record<string, any>;
   created_at?: string;
   updated_at?: string;
 }
 
+// 群聊话题归档配置：话题被表情回应或 /archive 命令触发后存入知识库
+export interface IMThreadArchiveConfig {
+  enabled: boolean;
+  knowledge_base_id?: string;
+  reaction?: string;
+  faq_knowledge_base_id?: string;
+}
+
 export function listIMChannels(agentId: string) {
   return get<{ data: IMChannel[] }>(`/api/v1/agents/${agentId}/im-channels`);
 }
@@ -356,6 +375,28 @@ export function toggleIMChannel(id: string) {
   return post<{ data: IMChannel }>(`/api/v1/im-channels/${id}/toggle`);
 }
 
+// IM 用户与租户成员的绑定：IM 指令（/agent、/save、/approve 等）按成员角色判断权限
+export interface IMUserBinding {
+  id: string;
+  im_channel_id: string;
+  im_user_id: string;
+  user_id: string;
+  created_by?: string;
+  created_at: string;
+}
+
+export function listIMUserBindings(channelId: string) {
+  return get<{ data: IMUserBinding[] }>(`/api/v1/im-channels/${channelId}/user-bindings`);
+}
+
+export function createIMUserBinding(channelId: string, data: { im_user_id: string; user_id: string }) {
+  return post<{ data: IMUserBinding }>(`/api/v1/im-channels/${channelId}/user-bindings`, data);
+}
+
+export function deleteIMUserBinding(channelId: string, bindingId: string) {
+  return del<{ success: boolean }>(`/api/v1/im-channels/${channelId}/user-bindings/${bindingId}`);
+}
+
 // ===== 推荐问题 =====
 
 // 推荐问题
@@ -0,0 +1,86 @@
+import { get, post, put, del } from '@/utils/request'
+
+// BrowserConfigEntity is a headless browser service (Chromium with remote
+// debugging, browserless, ...) the agent drives through the browser_* tools.
+export interface BrowserConfigEntity {
+  id?: string
+  tenant_id?: number
+  name: string
+  description?: string
+  parameters: {
+    // http(s)://host:9222 or a browser WebSocket URL ws(s)://...
+    endpoint_url: string
+    // Never returned by the server; accepted on create only. Later changes
+    // go through the /credentials subresource.
+    token?: string
+    allow_private_endpoint?: boolean
+  }
+  options: {
+    timeout_sec?: number
+    viewport_width?: number
+    viewport_height?: number
+    max_text_chars?: number
+    allowed_domains?: string[]
+  }
+  credentials?: Record<BrowserConfigCredentialField, { configured: boolean }>
+  created_at?: string
+  updated_at?: string
+}
+
+export function listBrowserConfigs() {
+  return get('/api/v1/browser-configs')
+}
+
+export function getBrowserConfig(id: string) {
+  return get(`/api/v1/browser-configs/${id}`)
+}
+
+export function createBrowserConfig(data: Partial<BrowserConfigEntity>) {
+  return post('/api/v1/browser-configs', data)
+}
+
+export function updateBrowserConfig(id: string, data: Partial<BrowserConfigEntity>) {
+  return put(`/api/v1/browser-configs/${id}`, data)
+}
+
+export function deleteBrowserConfig(id: string) {
+  return del(`/api/v1/browser-configs/${id}`)
+}
+
+// Test a browser service by opening a blank page. With an id the saved
+// settings (and stored token) are used; otherwise the unsaved form values
+// are tested without persisting.
+export function testBrowserConfig(
+  id?: string,
+  data?: Pick<BrowserConfigEntity, 'parameters' | 'options'>,
+): Promise<any> {
+  if (id) {
+    return post(`/api/v1/browser-configs/${id}/test`, {})
+  }
+  return post('/api/v1/browser-configs/test', data || {})
+}
+
+// ----------------------------------------------------------------------------
+// Browser config credential subresource.
+// ----------------------------------------------------------------------------
+
+export type BrowserConfigCredentialField = 'token'
+
+export interface BrowserConfigCredentialsResponse {
+  fields: Record<BrowserConfigCredentialField, { configured: boolean }>
+}
+
+export async function putBrowserConfigCredentials(
+  id: string,
+  body: Partial<Record<BrowserConfigCredentialField, string>>,
+): Promise<BrowserConfigCredentialsResponse> {
+  const response: any = await put(`/api/v1/browser-configs/${id}/credentials`, body)
+  return (response.data ?? response) as BrowserConfigCredentialsResponse
+}
+
+export async function deleteBrowserConfigCredentialField(
+  id: string,
+  field: BrowserConfigCredentialField,
+): Promise<void> {
+  await del(`/api/v1/browser-configs/${id}/credentials/${field}`)
+}
@@ -129,3 +129,13 @@ export async function downloadArtifact(
     `/api/v1/sessions/${session_id}/messages/${message_id}/artifacts/${index}/download`,
   );
 }
+
+// downloadAnalysisNotebook exports the data analysis steps of a session as a
+// reproducible DuckDB SQL script or Jupyter notebook. Same blob transport as
+// downloadArtifact so the Bearer token stays attached.
+export async function downloadAnalysisNotebook(
+  session_id: string,
+  format: 'sql' | 'ipynb',
+): Promise<Blob> {
+  return getDown(`/api/v1/sessions/${session_id}/analysis/notebook?format=${format}`);
+}
@@ -405,6 +405,28 @@ export function checkASRModel(modelConfig: {
     });
 }
 
+export function checkTTSModel(modelConfig: {
+    modelName: string;
+    baseUrl: string;
+    apiKey?: string;
+    provider?: string;
+    modelId?: string;
+} & BaseModelTestPayload): Promise<{
+    available: boolean;
+    message?: string;
+}> {
+    return new Promise((resolve, reject) => {
+        post('/api/v1/initialization/tts/check', modelConfig)
+            .then((response: any) => {
+                resolve(response.data || {});
+            })
+            .catch((error: any) => {
+                console.error('Failed to check TTS model:', error);
+                reject(error);
+            });
+    });
+}
+
 export function testMultimodalFunction(testData: {
     image: File;
     vlm_model: string;
@@ -5,7 +5,11 @@ import { get, put, post, del } from '@/utils/request'
 // current question matches them.
 export type MemoryKind = 'profile' | 'preference' | 'fact' | 'task' | 'interest'
 export type MemoryStatus = 'active' | 'superseded' | 'archived' | 'pending'
-export type MemoryOrigin = 'explicit' | 'extracted' | 'manual'
+export type MemoryOrigin = 'explicit' | 'extracted' | 'manual' | 'promoted'
+// Where a memory lives: one person's own space, the workspace's shared space,
+// or one agent's shared space. Only the last two are curated by admins.
+export type MemoryProvenance = 'personal' | 'workspace' | 'agent'
+export type SharedMemoryScope = Exclude<MemoryProvenance, 'personal'>
 
 export interface MemoryItem {
   id: string
@@ -22,6 +26,14 @@ export interface MemoryItem {
   superseded_by: string
   last_used_at: string | null
   use_count: number
+  expires_at?: string | null
+  /** Set on shared notes only; absent on personal memory. */
+  provenance?: MemoryProvenance
+  agent_id?: string
+  promoted_by?: string
+  promoted_from?: string
+  reviewed_by?: string
+  reviewed_at?: string | null
   created_at: string
   updated_at: string
 }
@@ -106,6 +118,17 @@ export function deleteMemoryItem(id: string) {
   return del<{ success: boolean }>(`/api/v1/memory/items/${encodeURIComponent(id)}`)
 }
 
+/**
+ * Propose one of your memories for a shared space. It stays pending, and is
+ * not used in anyone's conversations, until an admin approves it.
+ */
+export function shareMemoryItem(id: string, payload: { scope: SharedMemoryScope; agent_id?: string }) {
+  return post<{ success: boolean; data: MemoryItem }>(
+    `/api/v1/memory/items/${encodeURIComponent(id)}/share`,
+    payload,
+  )
+}
+
 export function clearMemoryItems() {
   return del<{ success: boolean; removed: number }>('/api/v1/memory/items')
 }
@@ -187,6 +210,63 @@ export function deleteMemoryDocument(id: string) {
   return del<{ success: boolean }>(`/api/v1/memory/documents/${encodeURIComponent(id)}`)
 }
 
+// ---------------------------------------------------------------------------
+// Shared memory curation. Admin only: these notes reach every member's
+// conversations (or every conversation with one agent), so members propose
+// and admins decide.
+// ---------------------------------------------------------------------------
+
+export function listSharedMemoryItems(
+  params: { scope?: SharedMemoryScope; agent_id?: string; status?: MemoryStatus; limit?: number; offset?: number } = {},
+) {
+  const query = new URLSearchParams()
+  if (params.scope) query.set('scope', params.scope)
+  if (params.agent_id) query.set('agent_id', params.agent_id)
+  if (params.status) query.set('status', params.status)
+  if (params.limit != null) query.set('limit', String(params.limit))
+  if (params.offset != null) query.set('offset', String(params.offset))
+  const suffix = query.toString() ? `?${query.toString()}` : ''
+  return get<{ success: boolean; data: MemoryItem[]; total: number }>(`/api/v1/memory/shared/items${suffix}`)
+}
+
+export function createSharedMemoryItem(payload: {
+  scope: SharedMemoryScope
+  agent_id?: string
+  kind: MemoryKind
+  content: string
+  importance?: number
+  expires_at?: string | null
+}) {
+  return post<{ success: boolean; data: MemoryItem }>('/api/v1/memory/shared/items', payload)
+}
+
+/** expires_at replaces the expiry: null means never, a past time expires the note now. */
+export function updateSharedMemoryItem(
+  id: string,
+  payload: { content: string; importance: number; expires_at: string | null },
+) {
+  return put<{ success: boolean; data: MemoryItem }>(
+    `/api/v1/memory/shared/items/${encodeURIComponent(id)}`,
+    payload,
+  )
+}
+
+export function deleteSharedMemoryItem(id: string) {
+  return del<{ success: boolean }>(`/api/v1/memory/shared/items/${encodeURIComponent(id)}`)
+}
+
+export function approveSharedMemoryItem(id: string) {
+  return post<{ success: boolean; data: MemoryItem }>(
+    `/api/v1/memory/shared/items/${encodeURIComponent(id)}/approve`,
+    {},
+  )
+}
+
+/** Drop a proposal. The member's own copy of the memory is left alone. */
+export function rejectSharedMemoryItem(id: string) {
+  return post<{ success: boolean }>(`/api/v1/memory/shared/items/${encodeURIComponent(id)}/reject`, {})
+}
+
 // ---------------------------------------------------------------------------
 // Workspace configuration, stored on the tenant like the other KV configs.
 // ---------------------------------------------------------------------------
@@ -0,0 +1,117 @@
+import { get, post, put, del } from '@/utils/request'
+
+export type SQLDialect = 'postgres' | 'mysql' | 'sqlite' | 'duckdb'
+
+// SQLConnectionEntity is an external read-only database the agent can query
+// through the sql_schema / sql_query tools.
+export interface SQLConnectionEntity {
+  id?: string
+  tenant_id?: number
+  name: string
+  description?: string
+  dialect: SQLDialect
+  parameters: {
+    host?: string
+    port?: number
+    database?: string
+    username?: string
+    // Never returned by the server; accepted on create only. Later changes
+    // go through the /credentials subresource.
+    password?: string
+    ssl_mode?: string
+    // Absolute path under one of the server's WEKNORA_SQL_FILE_DIRS roots.
+    file_path?: string
+  }
+  options: {
+    max_rows?: number
+    timeout_sec?: number
+    include_tables?: string[]
+    table_descriptions?: Record<string, string>
+    // Keyed by "table.column".
+    column_descriptions?: Record<string, string>
+  }
+  credentials?: Record<SQLConnectionCredentialField, { configured: boolean }>
+  created_at?: string
+  updated_at?: string
+}
+
+export interface SQLColumn {
+  name: string
+  type: string
+  nullable: boolean
+  description?: string
+}
+
+export interface SQLTable {
+  name: string
+  description?: string
+  columns: SQLColumn[]
+}
+
+export interface SQLSchema {
+  dialect: SQLDialect
+  tables: SQLTable[]
+  truncated?: boolean
+}
+
+export function listSQLConnections() {
+  return get('/api/v1/sql-connections')
+}
+
+export function getSQLConnection(id: string) {
+  return get(`/api/v1/sql-connections/${id}`)
+}
+
+export function createSQLConnection(data: Partial<SQLConnectionEntity>) {
+  return post('/api/v1/sql-connections', data)
+}
+
+export function updateSQLConnection(id: string, data: Partial<SQLConnectionEntity>) {
+  return put(`/api/v1/sql-connections/${id}`, data)
+}
+
+export function deleteSQLConnection(id: string) {
+  return del(`/api/v1/sql-connections/${id}`)
+}
+
+// Test a connection. With an id the saved settings (and stored password) are
+// used; otherwise the unsaved form values are tested without persisting.
+export function testSQLConnection(
+  id?: string,
+  data?: { dialect: SQLDialect; parameters: SQLConnectionEntity['parameters'] },
+): Promise<any> {
+  if (id) {
+    return post(`/api/v1/sql-connections/${id}/test`, {})
+  }
+  return post('/api/v1/sql-connections/test', data || {})
+}
+
+export function getSQLConnectionSchema(id: string, tables?: string[]): Promise<any> {
+  const query = tables && tables.length > 0 ? `?tables=${encodeURIComponent(tables.join(','))}` : ''
+  return get(`/api/v1/sql-connections/${id}/schema${query}`)
+}
+
+// ----------------------------------------------------------------------------
+// SQL connection credential subresource.
+// ----------------------------------------------------------------------------
+
+export type SQLConnectionCredentialField = 'password'
+
+export interface SQLConnectionCredentialsResponse {
+  fields: Record<SQLConnectionCredentialField, { configured: boolean }>
+}
+
+export async function putSQLConnectionCredentials(
+  id: string,
+  body: Partial<Record<SQLConnectionCredentialField, string>>,
+): Promise<SQLConnectionCredentialsResponse> {
+  const response: any = await put(`/api/v1/sql-connections/${id}/credentials`, body)
+  return (response.data ?? response) as SQLConnectionCredentialsResponse
+}
+
+export async function deleteSQLConnectionCredentialField(
+  id: string,
+  field: SQLConnectionCredentialField,
+): Promise<void> {
+  await del(`/api/v1/sql-connections/${id}/credentials/${field}`)
+}
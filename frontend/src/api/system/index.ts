@@ -715,6 +715,26 @@ export interface SandboxE2BConfig {
   e2b_sandbox_ttl_seconds?: number
 }
 
+/**
+ * Host-local Linux namespace sandbox. Directories and the cgroup root are host
+ * settings, so only per-sandbox limits are configurable here.
+ */
+export interface SandboxNamespaceConfig {
+  allow_network?: boolean
+  memory_mb?: number
+  cpu_cores?: number
+  pids_limit?: number
+  sandbox_ttl_seconds?: number
+}
+
+/**
+ * In-process WebAssembly sandbox. The interpreter runtimes are a host setting,
+ * so only the per-execution memory cap is configurable here.
+ */
+export interface SandboxWasmConfig {
+  memory_mb?: number
+}
+
 export interface SandboxConfig {
   sandbox_type?: string
   default_timeout_sec?: number
@@ -724,6 +744,8 @@ export interface SandboxConfig {
   cube?: SandboxCubeConfig
   e2b?: SandboxE2BConfig
   docker?: { image?: string }
+  namespace?: SandboxNamespaceConfig
+  wasm?: SandboxWasmConfig
 }
 
 /** `ok: null` means the probe was not executed in this run. */
@@ -796,7 +818,7 @@ export interface SandboxInventory {
 }
 
 /** Sandbox backends managed as named workspace configurations. */
-export const NAMED_SANDBOX_BACKEND_TYPES = ['cube', 'e2b', 'docker', 'local'] as const
+export const NAMED_SANDBOX_BACKEND_TYPES = ['cube', 'e2b', 'docker', 'namespace', 'wasm', 'local'] as const
 
 export function isNamedSandboxBackend(type: string): boolean {
   return (NAMED_SANDBOX_BACKEND_TYPES as readonly string[]).includes(type)
@@ -35,6 +35,7 @@ test('智能体中允许继承或关闭的可选模型可以恢复为空', () =>
   assert.match(rerank, /:clearable="!needsRerankModel"/)
   assertClearable(modelSelectorTag(agentEditor, 'formData.config.query_understand_model_id'))
   assertClearable(modelSelectorTag(agentEditor, 'formData.config.asr_model_id'))
+  assertClearable(modelSelectorTag(agentEditor, 'formData.config.tts_model_id'))
   assertClearable(modelSelectorTag(agentEditor, 'formData.config.question_suggestions.follow_ups.model_id'))
 })
 
@@ -3,7 +3,8 @@ import { MessagePlugin } from 'tdesign-vue-next'
 import { useI18n } from 'vue-i18n'
 import { deleteMemoryItem } from '@/api/memory'
 
-export type UsedMemory = { id: string; kind: string; content: string }
+// provenance is set for shared team notes and absent for the person's own.
+export type UsedMemory = { id: string; kind: string; content: string; provenance?: 'workspace' | 'agent' }
 
 /**
  * State for the "memories used by this turn" timeline row.
@@ -13,6 +13,8 @@ export const SETTINGS_SECTION_MIN_ROLE: Record<string, SettingsRoleKey> = {
   weknoracloud: 'admin',
   models: 'viewer',
   websearch: 'admin',
+  sqlconnections: 'admin',
+  browserconfigs: 'admin',
   chathistory: 'admin',
   vectorstore: 'admin',
   parser: 'admin',
@@ -1051,6 +1051,8 @@ export default {
   settings: {
     modelManagement: 'Model Management',
     webSearchConfig: 'Web Search',
+    sqlConnections: 'External Databases',
+    browserConfigs: 'Browser Services',
     autoCheckUpdate: 'Auto Download Updates',
     autoCheckUpdateDesc: 'When enabled, automatically check and download the latest version in the background.',
     vectorStoreEngine: 'Vector DB Engine',
@@ -1082,6 +1084,8 @@ export default {
         cube: 'Self-hosted MicroVM cluster for private or on-premises deployments',
         e2b: 'Managed MicroVM service or an E2B-compatible deployment',
         docker: 'Run every script in a short-lived container on this WeKnora host',
+        namespace: 'Run scripts on this WeKnora host inside Linux namespaces and seccomp, without a Docker daemon',
+        wasm: 'Run Python and JavaScript scripts inside the WeKnora process with WebAssembly; needs neither Docker nor Linux',
         local: 'Run scripts directly in the WeKnora server process environment',
       },
       addConfig: 'Add sandbox backend',
@@ -1147,6 +1151,18 @@ export default {
       templateNotConfigured: 'Template not configured',
       imageNotConfigured: 'Image not configured',
       localRuntimeSummary: 'WeKnora server process',
+      namespaceRuntimeSummary: 'Isolated namespace on the WeKnora host',
+      namespaceHostHint: 'Sandboxes run on the WeKnora host itself. The rootfs, state directory and cgroup are host settings (WEKNORA_NS_SANDBOX_* environment variables) and need the weknora-sandbox-init helper next to the server binary.',
+      namespaceAllowNetwork: 'Allow network access',
+      namespaceAllowNetworkHint: 'Off by default: scripts only see a private loopback interface. When on they share the host network.',
+      namespaceTtlHelp: 'How long an idle sandbox keeps its workspace files before it is reclaimed. Background processes do not outlive a single execution.',
+      namespaceMemory: 'Memory limit (MB)',
+      namespaceCpu: 'CPU limit (cores)',
+      namespacePids: 'Process limit',
+      namespaceLimitsHelp: 'Enforced through cgroup v2 only when the host configured WEKNORA_NS_SANDBOX_CGROUP_ROOT; otherwise only the per-process resource limits apply. Leave empty for the defaults.',
+      wasmRuntimeSummary: 'WebAssembly runtime in the WeKnora process',
+      wasmHostHint: 'Scripts run in the WeKnora process under WebAssembly (WASI) interpreters: only Python and JavaScript are supported, the skill directory is read-only, /tmp is a per-run scratch directory and there is no network access. The interpreter runtimes are a host setting (WEKNORA_WASM_SANDBOX_DIR or wasm-runtimes next to the server binary).',
+      wasmLimitsHelp: 'Caps the memory of each script; the default timeout below also bounds CPU time. Leave empty for the default.',
       templateApplied: 'Applied',
       refreshTemplates: 'Refresh templates',
       templateSelectHelp: 'Templates are loaded from this cluster. The saved configuration stores the ID automatically.',
@@ -1193,6 +1209,8 @@ export default {
         docker: 'Docker',
         cube: 'CubeSandbox',
         e2b: 'E2B',
+        namespace: 'Linux namespace',
+        wasm: 'WebAssembly',
       },
       apiUrl: 'API endpoint',
       proxyUrl: 'Proxy endpoint',
@@ -1483,6 +1501,109 @@ export default {
       requestFailed: 'Request failed'
     }
   },
+  browserConfigSettings: {
+    title: 'Browser Services',
+    description: 'Register headless browsers reachable over the Chrome DevTools Protocol (a Chromium remote debugging port, browserless, ...). Agents can then open pages, click, fill forms, read content and take screenshots during a conversation. Private and metadata addresses are always blocked.',
+    listTitle: 'Browser services',
+    add: 'Add browser service',
+    edit: 'Edit browser service',
+    empty: 'No browser services yet.',
+    deleteConfirm: 'Delete this browser service? Agents using it will no longer be able to browse, and open pages will be closed.',
+    basicSection: 'Basics',
+    endpointSection: 'Browser service',
+    limitsSection: 'Page limits',
+    nameLabel: 'Name',
+    namePlaceholder: 'e.g. Shared Chromium',
+    descriptionLabel: 'Description',
+    descriptionPlaceholder: 'What this browser service is used for',
+    endpointLabel: 'DevTools endpoint',
+    endpointHelp: 'http(s)://host:9222 (resolved via /json/version) or a browser WebSocket URL ws(s)://...',
+    tokenLabel: 'Access token',
+    tokenPlaceholder: 'Optional, sent to the browser service as a Bearer token',
+    allowPrivateLabel: 'Allow private address',
+    allowPrivateHelp: 'Only for a browser container on the same network. This applies to the browser service address only; pages opened by the agent still cannot reach private addresses.',
+    allowedDomainsLabel: 'Allowed sites',
+    allowedDomainsPlaceholder: 'Type a domain and press Enter, e.g. example.com',
+    allowedDomainsHelp: 'Subdomains are included. Leave empty to allow any public site.',
+    anyPublicSite: 'Any public site',
+    timeoutLabel: 'Action timeout (s)',
+    maxTextCharsLabel: 'Max characters per read',
+    viewportWidthLabel: 'Viewport width',
+    viewportHeightLabel: 'Viewport height',
+    test: 'Test connection',
+    testing: 'Testing...',
+    validation: {
+      nameRequired: 'Name is required',
+      endpointRequired: 'DevTools endpoint is required'
+    },
+    toasts: {
+      created: 'Browser service created',
+      updated: 'Browser service updated',
+      deleted: 'Browser service deleted',
+      saveFailed: 'Save failed',
+      deleteFailed: 'Delete failed',
+      testSuccess: 'Connection test succeeded',
+      testFailed: 'Connection test failed'
+    }
+  },
+  sqlConnectionSettings: {
+    title: 'External Databases',
+    description: 'Register read-only external databases (PostgreSQL, MySQL, SQLite, DuckDB). Agents can read their schema and write SQL; results come back as tables with chart suggestions.',
+    listTitle: 'Database Connections',
+    add: 'Add Database',
+    edit: 'Edit Database',
+    empty: 'No external database connections yet.',
+    deleteConfirm: 'Delete this database connection? Agents using it will no longer be able to query it.',
+    basicSection: 'Basic Info',
+    connectionSection: 'Connection',
+    limitsSection: 'Query Limits',
+    schemaSection: 'Schema & Column Descriptions',
+    dialectLabel: 'Database Type',
+    nameLabel: 'Name',
+    namePlaceholder: 'e.g. Sales warehouse',
+    descriptionLabel: 'Description',
+    descriptionPlaceholder: 'What data lives here and which questions it answers',
+    descriptionHelp: 'Shown to the agent to help it decide when to query this database.',
+    hostLabel: 'Host',
+    portLabel: 'Port',
+    databaseLabel: 'Database',
+    usernameLabel: 'Username',
+    usernameHelp: 'Use an account that is only granted SELECT; it is the most reliable read-only guarantee.',
+    passwordLabel: 'Password',
+    passwordPlaceholder: 'Enter the database password',
+    sslModeLabel: 'SSL Mode',
+    filePathLabel: 'File Path',
+    filePathHelp: 'Absolute path on the server inside a directory listed in WEKNORA_SQL_FILE_DIRS. DuckDB can also point directly at a CSV / Parquet file.',
+    maxRowsLabel: 'Max Rows',
+    timeoutLabel: 'Query Timeout (s)',
+    includeTablesLabel: 'Tables Shown to the Agent',
+    includeTablesPlaceholder: 'Type a table name and press Enter; leave empty for all',
+    includeTablesHelp: 'Only limits the schema the agent sees and is not an access boundary; restrict sensitive data with database grants.',
+    schemaHelp: 'After loading the schema you can describe tables and columns in business terms; the agent uses these when writing SQL. Takes effect after saving.',
+    loadSchema: 'Load Schema',
+    reloadSchema: 'Reload',
+    noTables: 'No tables found.',
+    columnCount: '{count} columns',
+    tableDescriptionLabel: 'Table Description',
+    tableDescriptionPlaceholder: 'e.g. One row per order',
+    columnDescriptionPlaceholder: 'Column description (optional)',
+    schemaTruncated: 'Only the first 200 tables are shown.',
+    test: 'Test Connection',
+    testing: 'Testing...',
+    validation: {
+      nameRequired: 'Name is required'
+    },
+    toasts: {
+      created: 'Database connection created',
+      updated: 'Database connection updated',
+      deleted: 'Database connection deleted',
+      saveFailed: 'Failed to save',
+      deleteFailed: 'Failed to delete',
+      testSuccess: 'Connection test succeeded',
+      testFailed: 'Connection test failed',
+      schemaFailed: 'Failed to load schema'
+    }
+  },
   webSearchSettings: {
     title: 'Web Search Configuration',
     description: 'Configure web search so answers can include up-to-date information from the internet.',
@@ -1563,6 +1684,12 @@ export default {
     enableLabel: 'Use long-term memory for me',
     enableDescription: 'When off, the assistant neither reads nor adds your memories. Existing ones are kept and resume when you turn it back on.',
     agentDisabledHint: 'An individual agent can also turn long-term memory off for itself. In a conversation with such an agent your memories are neither read nor added to; other agents are unaffected.',
+    share: 'Share with team',
+    shareTitle: 'Propose for shared memory',
+    shareHint: 'A copy is proposed. It is used in other members\' conversations only after an admin approves it; your own memory stays as it is.',
+    shareToWorkspace: 'Whole workspace',
+    shareToAgent: 'One agent',
+    shareAgentPlaceholder: 'Choose an agent',
     usage: {
       title: 'When memories are used',
       iconHint: 'See which memories are used in conversation',
@@ -1683,7 +1810,8 @@ export default {
     origins: {
       explicit: 'You asked',
       extracted: 'Distilled',
-      manual: 'Added by hand'
+      manual: 'Added by hand',
+      promoted: 'Proposed by a member'
     },
     toasts: {
       enabled: 'Long-term memory enabled for you',
@@ -1692,6 +1820,7 @@ export default {
       updated: 'Updated',
       deleted: 'Deleted',
       cleared: 'Deleted {count} memories',
+      shared: 'Proposed. Waiting for an admin to review it',
       saveFailed: 'Operation failed: {message}'
     }
   },
@@ -1732,6 +1861,39 @@ export default {
       saveFailed: 'Failed to save: {message}'
     }
   },
+  sharedMemorySettings: {
+    title: 'Shared memory',
+    description: 'Memories that apply to every member of this workspace, or to every conversation with one agent. Members can propose their own memories here for your review, or you can add notes directly.',
+    add: 'Add',
+    addTitle: 'Add shared memory',
+    addPlaceholder: 'e.g. Production changes need a ticket first',
+    expiresPlaceholder: 'Expires at (leave blank to keep it)',
+    scopeWorkspace: 'Whole workspace',
+    scopeAgent: 'One agent',
+    agentPlaceholder: 'Choose an agent',
+    pickAgent: 'Choose an agent first.',
+    statusPending: 'Pending review',
+    statusActive: 'Active',
+    pendingEmpty: 'No proposals waiting for review.',
+    activeEmpty: 'No shared memories yet.',
+    proposedBy: 'Proposed by {who}',
+    reviewedBy: 'Reviewed by {who}',
+    expired: 'Expired',
+    expiresAt: 'Expires {time}',
+    approve: 'Approve',
+    reject: 'Reject',
+    expireNow: 'Expire now',
+    deleteConfirm: 'Once deleted, no member\'s conversations will use this memory. Delete it?',
+    toasts: {
+      added: 'Added',
+      updated: 'Updated',
+      approved: 'Approved',
+      rejected: 'Rejected',
+      expired: 'Marked as expired',
+      deleted: 'Deleted',
+      failed: 'Operation failed: {message}'
+    }
+  },
   chatHistorySettings: {
     title: 'Message Management',
     description: 'Configure chat history knowledge base to automatically index conversation messages for semantic search',
@@ -3020,6 +3182,10 @@ export default {
     memoryForgotten: 'Memory deleted',
     memoryForgetFailed: 'Failed to delete',
     memoryHint: 'These are the long-term memories this answer saw. Deleting one stops it from being used again.',
+    memoryProvenance: {
+      workspace: 'Team',
+      agent: 'Agent'
+    },
     suggestedQuestions: 'You can ask me',
     followUpQuestions: 'Keep asking',
     followUpQuestionsLoading: 'Loading suggested questions',
@@ -3103,6 +3269,24 @@ export default {
     lengthTenThousands: '{value} ten-thousand characters',
     noDatabaseRecords: 'No matching records found',
     nullValuePlaceholder: '<NULL>',
+    dataChart: {
+      rows: '{rows} data points',
+      attached: 'Attached: {files}',
+      noPreview: 'The chart is too large to preview here; open it from the attachments',
+      exportSql: 'Export SQL',
+      exportNotebook: 'Export Notebook',
+      exportFailed: 'Export failed'
+    },
+    sqlQuery: {
+      rowsIn: '{rows} rows · {ms} ms',
+      truncated: 'Result truncated',
+      chart: {
+        line: 'Line',
+        bar: 'Bar',
+        pie: 'Pie',
+        scatter: 'Scatter'
+      }
+    },
     chunkCountValue: '{count} chunks',
     documentDescriptionLabel: 'Description:',
     documentSourceLabel: 'Source:',
@@ -3882,6 +4066,7 @@ export default {
         rerank: 'Configure models for result re-ranking',
         vllm: 'Configure vision-language models for multimodal understanding',
         asr: 'Configure speech-to-text models for audio transcription',
+        tts: 'Configure text-to-speech models for voice replies',
         default: 'Configure model information'
       },
       modelNamePlaceholder: {
@@ -3889,7 +4074,8 @@ export default {
         remote: 'e.g. gpt-4, claude-3-opus',
         localVllm: 'e.g. llava:latest',
         remoteVllm: 'e.g. gpt-4-vision-preview',
-        remoteAsr: 'e.g. whisper-1'
+        remoteAsr: 'e.g. whisper-1',
+        remoteTts: 'e.g. tts-1'
       },
       baseUrlLabel: 'Base URL',
       displayNameLabel: 'Display name (optional)',
@@ -3898,6 +4084,9 @@ export default {
       baseUrlPlaceholder: 'e.g. https://api.openai.com/v1',
       baseUrlPlaceholderVllm: 'e.g. http://localhost:11434/v1',
       baseUrlPlaceholderAsr: 'e.g. https://api.openai.com/v1',
+      ttsVoiceLabel: 'Voice (optional)',
+      ttsVoicePlaceholder: 'e.g. alloy',
+      ttsVoiceDesc: 'The voice parameter of /v1/audio/speech; defaults to alloy when empty',
       apiKeyOptional: 'API Key (optional)',
       apiKeyPlaceholder: 'Enter API Key',
       lkeap: {
@@ -4309,7 +4498,8 @@ export default {
       embedding: 'Embedding',
       rerank: 'ReRank',
       vllm: 'Vision',
-      asr: 'Speech'
+      asr: 'Speech',
+      tts: 'TTS'
     },
     actions: {
       addModel: 'Add Model',
@@ -4345,6 +4535,11 @@ export default {
       desc: 'Configure speech-to-text models for audio transcription (e.g. OpenAI Whisper)',
       empty: 'No ASR models'
     },
+    tts: {
+      title: 'TTS Speech Synthesis Models',
+      desc: 'Configure text-to-speech models that turn answers into audio (e.g. OpenAI TTS)',
+      empty: 'No TTS models'
+    },
     toasts: {
       nameRequired: 'Model name cannot be empty',
       nameTooLong: 'Model name cannot exceed 100 characters',
@@ -4393,6 +4588,8 @@ export default {
       documentsHint: 'Each non-empty line is sent as a separate document to the ReRank model',
       imageFile: 'Image file',
       audioFile: 'Audio file',
+      ttsInput: 'Text to synthesize',
+      ttsPlaceholder: 'Enter the text to turn into speech',
       chooseFile: 'Choose file',
       parameters: 'Request parameters',
       thinking: 'Thinking mode',
@@ -4417,7 +4614,8 @@ export default {
         reasoningChars: 'Reasoning chars',
         reasoningReturned: 'Reasoning returned',
         textChars: 'Transcript chars',
-        segmentCount: 'Segment count'
+        segmentCount: 'Segment count',
+        audioBytes: 'Audio size'
       }
     }
   },
@@ -5068,7 +5266,15 @@ export default {
       executeSkillScript: 'Execute Skill Script',
       dataAnalysis: 'Data Analysis',
       dataSchema: 'Data Schema',
-      databaseQuery: 'Database Query'
+      dataChart: 'Draw Chart',
+      databaseQuery: 'Database Query',
+      sqlSchema: 'Read Schema',
+      sqlQuery: 'SQL Query',
+      browserNavigate: 'Open Page',
+      browserClick: 'Click Element',
+      browserFill: 'Fill Form',
+      browserExtract: 'Read Page',
+      browserScreenshot: 'Screenshot'
     },
     citation: {
       notFound: 'Content not found',
@@ -5286,6 +5492,8 @@ export default {
       dataAnalysisDesc: 'Understand data files and perform data analysis',
       dataSchema: 'View Data Schema',
       dataSchemaDesc: 'Get metadata of tabular files',
+      dataChart: 'Data Chart',
+      dataChartDesc: 'Draw charts from data analysis results',
       requiresKb: '(requires knowledge base configuration)',
       requiresRagKb: '(requires a KB with vector/keyword indexing enabled)',
       requiresWikiKb: '(requires a Wiki-enabled knowledge base)',
@@ -5344,6 +5552,9 @@ export default {
       wechat: 'WeChat',
       qqbot: 'QQBot',
       yunzhijia: 'Yunzhijia',
+      discord: 'Discord',
+      teams: 'Microsoft Teams',
+      matrix: 'Matrix',
       addChannel: 'Add Channel',
       channelsTitle: 'IM Channels',
       disabled: 'Disabled',
@@ -5366,6 +5577,8 @@ export default {
       larkConsole: 'Lark Open Platform',
       slackConsole: 'Slack API Console',
       telegramConsole: 'Telegram BotFather',
+      discordConsole: 'Discord Developer Portal',
+      teamsConsole: 'Azure Bot / Bot Framework portal',
       dingtalkConsole: 'DingTalk Open Platform',
       dingtalkCardTemplateId: 'Card Template ID (optional)',
       dingtalkCardTemplateIdHint: 'Create an AI Card template at open-dev.dingtalk.com/fe/card to enable streaming output with typewriter effect',
@@ -5393,6 +5606,12 @@ export default {
       yunzhijiaAllowedHostSuffixHint: 'Restrict Send Message URL host to this suffix for security (e.g. yunzhijia.com)',
       yunzhijiaSendMsgUrlRequired: 'Enter the Send Message URL from Yunzhijia robot settings',
       mattermostModeHint: 'Mattermost only supports Webhook mode (outgoing webhook + bot token).',
+      discordModeHint: 'WebSocket receives channel messages over the Gateway (enable the Message Content intent); Webhook receives the /ask slash command on the Interactions endpoint.',
+      teamsModeHint: 'Microsoft Teams only supports Webhook: set the Azure Bot messaging endpoint to the callback URL.',
+      matrixModeHint: 'Matrix only supports WebSocket (/sync long polling) and needs no public callback URL; end-to-end encrypted rooms are not supported yet.',
+      discordPublicKeyHint: 'The Public Key from the application\'s General Information page, used to verify Interactions request signatures.',
+      teamsTenantIdHint: 'Azure AD tenant ID for single-tenant bots; leave empty for multi-tenant bots.',
+      matrixUserIdHint: 'Full Matrix ID of the bot account, used to recognise mentions and its own messages.',
       mattermostPostToMain: 'Post replies in channel timeline',
       mattermostPostToMainHint: 'When on, bot replies are new top-level posts in the channel. When off (default), they stay in the thread and the main view only shows “N replies”.',
       modeHint: 'WebSocket is recommended for easier setup',
@@ -5403,6 +5622,23 @@ export default {
       fileKnowledgeBase: 'File Storage Knowledge Base',
       fileKnowledgeBasePlaceholder: 'Select a knowledge base (optional)',
       fileKnowledgeBaseHint: 'When configured, files sent by users will be automatically saved to this knowledge base',
+      sectionThreadArchive: 'Group Thread Archive',
+      threadArchiveEnabled: 'Archive resolved threads',
+      threadArchiveEnabledHint: 'Send /archive in a group thread or add the configured reaction to store the whole thread as one knowledge item, with participants, timestamps and a link back to the chat',
+      threadArchiveKnowledgeBase: 'Archive knowledge base',
+      threadArchiveKnowledgeBasePlaceholder: 'Select a document knowledge base',
+      threadArchiveKnowledgeBaseRequired: 'Select an archive knowledge base to enable thread archiving',
+      threadArchiveReaction: 'Archive reaction',
+      threadArchiveReactionHint: 'Optional. Use the emoji name on Slack (e.g. white_check_mark) and the emoji type on Feishu/Lark (e.g. DONE); leave empty to archive with /archive only',
+      threadArchiveFAQKnowledgeBase: 'FAQ knowledge base',
+      threadArchiveFAQKnowledgeBaseHint: "Optional. When set, the agent's chat model condenses the thread into one FAQ entry in this knowledge base",
+      sectionUserBindings: "User bindings & permissions",
+      userBindingsHint: "Bind IM users to tenant members so commands such as /agent, /kb, /save and /approve are checked against that member's role. Unbound users are treated as viewers. The IM user ID is shown when the bot rejects a command.",
+      userBindingIMUserPlaceholder: "IM user ID",
+      userBindingMemberPlaceholder: "Select a tenant member",
+      userBindingAdd: "Bind",
+      userBindingLoadFailed: "Failed to load user bindings",
+      userBindingSaveFailed: "Binding failed. Make sure the user is an active tenant member",
       sessionMode: 'Session Mode',
       sessionModeUser: 'Per User (default)',
       sessionModeThread: 'Per Thread',
@@ -5444,6 +5680,22 @@ export default {
       },
       kbIncompatibleWarn: '{count} selected KB(s) are not compatible with this type, please adjust manually.'
     },
+    sqlConnections: {
+      label: 'External Databases',
+      desc: 'Choose the external databases this agent may query. Selecting any enables the schema and read-only SQL query tools.',
+      selectLabel: 'Queryable Databases',
+      selectDesc: 'Register connections under Settings → External Databases',
+      selectPlaceholder: 'No external databases',
+      empty: 'This workspace has no external databases registered yet.'
+    },
+    browser: {
+      label: 'Browser Automation',
+      desc: 'Choose the headless browser service this agent drives. When set, the agent can open pages, click, fill forms, read page content and take screenshots, staying logged in for the conversation.',
+      selectLabel: 'Browser service',
+      selectDesc: 'Register services under Settings → Browser Services',
+      selectPlaceholder: 'No browser',
+      empty: 'No browser services are registered in this workspace yet.'
+    },
     mcp: {
       label: 'MCP Services',
       desc: 'Select MCP services available to the Agent',
@@ -5488,7 +5740,12 @@ export default {
       desc: 'When enabled, users can upload audio files in conversations. The system will automatically transcribe them using the ASR model.',
       asrModel: 'ASR Model',
       asrModelDesc: 'Speech recognition model for audio transcription. If not configured, audio files will be passed as placeholders.',
-      asrModelPlaceholder: 'Select ASR Model'
+      asrModelPlaceholder: 'Select ASR Model',
+      voiceReply: 'Voice Reply',
+      voiceReplyDesc: 'When a voice message arrives over IM, also send a spoken reply synthesized by the TTS model alongside the text answer (Telegram, Feishu)',
+      ttsModel: 'TTS Model',
+      ttsModelDesc: 'Text-to-speech model used to synthesize voice replies',
+      ttsModelPlaceholder: 'Select TTS Model'
     },
     chatParser: {
       label: 'Chat Attachment Parsing Policy',
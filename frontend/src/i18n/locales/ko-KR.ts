@@ -872,7 +872,12 @@ export default {
       desc: '활성화하면 사용자가 대화에서 오디오 파일을 업로드할 수 있으며, ASR 모델로 자동 변환됩니다',
       asrModel: 'ASR 모델',
       asrModelDesc: '음성 인식 모델입니다. 설정하지 않으면 오디오 파일이 플레이스홀더로 전달됩니다',
-      asrModelPlaceholder: 'ASR 모델 선택'
+      asrModelPlaceholder: 'ASR 모델 선택',
+      voiceReply: '음성 응답',
+      voiceReplyDesc: 'IM 채널에서 음성 메시지를 받으면 텍스트 답변과 함께 TTS 모델로 합성한 음성 응답도 보냅니다 (Telegram, Feishu)',
+      ttsModel: 'TTS 모델',
+      ttsModelDesc: '음성 응답 합성에 사용할 음성 합성 모델',
+      ttsModelPlaceholder: 'TTS 모델 선택'
     },
     imageUpload: {
       navLabel: '첨부 업로드',
@@ -902,6 +907,22 @@ export default {
       hint: '비워두거나 0이면 기본값(120초)을 사용합니다',
       placeholder: '초 단위로 입력하세요. 권장 범위 60-1800'
     },
+    sqlConnections: {
+      label: 'External Databases',
+      desc: 'Choose the external databases this agent may query. Selecting any enables the schema and read-only SQL query tools.',
+      selectLabel: 'Queryable Databases',
+      selectDesc: 'Register connections under Settings → External Databases',
+      selectPlaceholder: 'No external databases',
+      empty: 'This workspace has no external databases registered yet.'
+    },
+    browser: {
+      label: '브라우저 자동화',
+      desc: '에이전트가 조작할 헤드리스 브라우저 서비스를 선택합니다. 선택하면 에이전트가 페이지 열기, 클릭, 양식 입력, 페이지 내용 읽기, 스크린샷을 할 수 있으며 대화 중 로그인 상태가 유지됩니다.',
+      selectLabel: '브라우저 서비스',
+      selectDesc: '설정 → 브라우저 서비스에서 등록하세요',
+      selectPlaceholder: '브라우저 사용 안 함',
+      empty: '이 공간에 등록된 브라우저 서비스가 없습니다.'
+    },
     mcp: {
       label: 'MCP 서비스',
       desc: 'Agent가 호출할 수 있는 MCP 서비스를 선택하세요',
@@ -940,6 +961,9 @@ export default {
       wechat: 'WeChat',
       qqbot: 'QQBot',
       yunzhijia: 'Yunzhijia',
+      discord: 'Discord',
+      teams: 'Microsoft Teams',
+      matrix: 'Matrix',
       addChannel: '채널 추가',
       channelsTitle: 'IM 채널',
       disabled: '비활성',
@@ -962,6 +986,8 @@ export default {
       larkConsole: 'Lark 개발 플랫폼',
       slackConsole: 'Slack API 콘솔',
       telegramConsole: 'Telegram BotFather',
+      discordConsole: 'Discord 개발자 포털',
+      teamsConsole: 'Azure Bot / Bot Framework 포털',
       dingtalkConsole: 'DingTalk 개발 플랫폼',
       dingtalkCardTemplateId: '카드 템플릿 ID (선택 사항)',
       dingtalkCardTemplateIdHint: 'open-dev.dingtalk.com/fe/card에서 AI 카드 템플릿을 만들면 타자기 효과 스트리밍 출력이 활성화됩니다',
@@ -989,6 +1015,12 @@ export default {
       yunzhijiaAllowedHostSuffixHint: '보안을 위해 메시지 전송 URL 호스트를 이 접미사로 제한 (예: yunzhijia.com)',
       yunzhijiaSendMsgUrlRequired: 'Yunzhijia 로봇 설정의 메시지 전송 URL을 입력하세요',
       mattermostModeHint: 'Mattermost는 Webhook(아웃고잉 웹훅 + 봇 토큰)만 지원합니다.',
+      discordModeHint: 'WebSocket은 Gateway로 채널 메시지를 수신합니다(Message Content 인텐트 필요). Webhook은 Interactions 엔드포인트로 /ask 슬래시 명령을 수신합니다.',
+      teamsModeHint: 'Microsoft Teams는 Webhook만 지원합니다. Azure Bot의 메시징 엔드포인트를 콜백 주소로 설정하세요.',
+      matrixModeHint: 'Matrix는 WebSocket(/sync 롱 폴링)만 지원하며 공개 콜백 주소가 필요 없습니다. 종단 간 암호화 방은 아직 지원하지 않습니다.',
+      discordPublicKeyHint: '애플리케이션 General Information 페이지의 Public Key로, Interactions 요청 서명 검증에 사용됩니다.',
+      teamsTenantIdHint: '단일 테넌트 봇은 Azure AD 테넌트 ID를 입력하고, 멀티 테넌트 봇은 비워 두세요.',
+      matrixUserIdHint: '봇 계정의 전체 Matrix ID로, 멘션과 자신의 메시지를 식별하는 데 사용됩니다.',
       mattermostPostToMain: '답변을 채널 메인 타임라인에 표시',
       mattermostPostToMainHint: '켜면 봇 답변이 채널의 새 최상위 게시물로 올라갑니다. 끄면(기본) 스레드 답변이며 메인 화면에는 「N개 답변」만 보입니다.',
       modeHint: 'WebSocket 방식이 설정이 더 간편하여 권장됩니다',
@@ -999,6 +1031,23 @@ export default {
       fileKnowledgeBase: '파일 저장 지식 베이스',
       fileKnowledgeBasePlaceholder: '지식 베이스 선택 (선택 사항)',
       fileKnowledgeBaseHint: '설정 시 사용자가 보낸 파일이 자동으로 해당 지식 베이스에 저장됩니다',
+      sectionThreadArchive: '그룹 스레드 보관',
+      threadArchiveEnabled: '해결된 스레드 보관',
+      threadArchiveEnabledHint: '그룹 스레드에서 /archive를 보내거나 지정한 이모지 반응을 추가하면 스레드 전체가 참여자, 시간, 원본 링크와 함께 하나의 지식으로 저장됩니다',
+      threadArchiveKnowledgeBase: '보관 지식 베이스',
+      threadArchiveKnowledgeBasePlaceholder: '문서형 지식 베이스 선택',
+      threadArchiveKnowledgeBaseRequired: '스레드 보관을 사용하려면 보관 지식 베이스를 선택하세요',
+      threadArchiveReaction: '보관 트리거 이모지',
+      threadArchiveReactionHint: '선택 사항. Slack은 이모지 이름(예: white_check_mark), Feishu/Lark는 이모지 유형(예: DONE)을 입력하세요. 비워 두면 /archive 명령만 사용합니다',
+      threadArchiveFAQKnowledgeBase: 'FAQ 지식 베이스',
+      threadArchiveFAQKnowledgeBaseHint: '선택 사항. 설정하면 에이전트의 대화 모델이 스레드를 하나의 FAQ 항목으로 요약하여 이 지식 베이스에 저장합니다',
+      sectionUserBindings: '사용자 바인딩 및 권한',
+      userBindingsHint: 'IM 사용자를 테넌트 멤버에 바인딩하면 /agent, /kb, /save, /approve 등의 명령이 해당 멤버의 역할로 권한을 확인합니다. 바인딩되지 않은 사용자는 방문자로 처리됩니다. IM 사용자 ID는 봇이 명령을 거부할 때 안내에 표시됩니다.',
+      userBindingIMUserPlaceholder: 'IM 사용자 ID',
+      userBindingMemberPlaceholder: '테넌트 멤버 선택',
+      userBindingAdd: '바인딩',
+      userBindingLoadFailed: '사용자 바인딩을 불러오지 못했습니다',
+      userBindingSaveFailed: '바인딩에 실패했습니다. 사용자가 활성 테넌트 멤버인지 확인하세요',
       sessionMode: '세션 모드',
       sessionModeUser: '사용자별 (기본)',
       sessionModeThread: '스레드별',
@@ -1051,6 +1100,8 @@ export default {
       dataAnalysisDesc: '데이터 파일을 이해하고 데이터 분석 수행',
       dataSchema: '데이터 스키마 보기',
       dataSchemaDesc: '테이블 파일의 메타 정보 조회',
+      dataChart: '데이터 차트',
+      dataChartDesc: '데이터 분석 결과로 차트 그리기',
       requiresKb: '(지식베이스 설정 필요)',
       requiresRagKb: '(벡터/키워드 인덱싱이 활성화된 지식베이스가 필요합니다)',
       requiresWikiKb: '(Wiki 기능이 활성화된 지식베이스가 필요합니다)',
@@ -1307,7 +1358,15 @@ export default {
       executeSkillScript: '스킬 스크립트 실행',
       dataAnalysis: '데이터 분석',
       dataSchema: '데이터 구조',
-      databaseQuery: '데이터베이스 조회'
+      dataChart: '차트 그리기',
+      databaseQuery: '데이터베이스 조회',
+      sqlSchema: 'Read Schema',
+      sqlQuery: 'SQL Query',
+      browserNavigate: '페이지 열기',
+      browserClick: '요소 클릭',
+      browserFill: '양식 입력',
+      browserExtract: '페이지 읽기',
+      browserScreenshot: '스크린샷'
     },
     mcpOAuth: {
       waiting: '인증 대기 · {target}',
@@ -1962,6 +2021,8 @@ export default {
       documentsHint: '비어 있지 않은 각 줄은 ReRank 모델에 별도 문서로 전송됩니다',
       imageFile: '이미지 파일',
       audioFile: '오디오 파일',
+      ttsInput: '합성할 텍스트',
+      ttsPlaceholder: '음성으로 합성할 텍스트 입력',
       chooseFile: '파일 선택',
       parameters: '요청 매개변수',
       thinking: '사고 모드',
@@ -1986,7 +2047,8 @@ export default {
         reasoningChars: '추론 문자 수',
         reasoningReturned: '추론 내용 반환',
         textChars: '전사 문자 수',
-        segmentCount: '세그먼트 수'
+        segmentCount: '세그먼트 수',
+        audioBytes: '오디오 크기'
       }
     },
     builtinModels: {
@@ -2018,6 +2080,11 @@ export default {
       desc: '음성 인식 및 오디오 전사를 위한 음성 인식 모델 설정 (예: OpenAI Whisper)',
       empty: 'ASR 음성 모델 없음'
     },
+    tts: {
+      title: 'TTS 음성 합성 모델',
+      desc: '답변을 음성으로 합성하는 텍스트 음성 변환 모델 설정 (예: OpenAI TTS)',
+      empty: 'TTS 음성 합성 모델 없음'
+    },
     vllm: {
       title: 'VLLM 비전 모델',
       desc: '시각 이해 및 멀티모달용 비전 언어 모델 설정',
@@ -2052,7 +2119,8 @@ export default {
       embedding: 'Embedding',
       rerank: 'ReRank',
       vllm: '비전',
-      asr: '음성'
+      asr: '음성',
+      tts: '음성 합성'
     }
   },
   mcpSettings: {
@@ -2292,6 +2360,9 @@ export default {
       baseUrlPlaceholder: '예: https://api.openai.com/v1',
       baseUrlPlaceholderVllm: '예: http://localhost:11434/v1',
       baseUrlPlaceholderAsr: '예: https://api.openai.com/v1',
+      ttsVoiceLabel: '음성 (선택)',
+      ttsVoicePlaceholder: '예: alloy',
+      ttsVoiceDesc: '/v1/audio/speech의 voice 파라미터, 비워 두면 alloy 사용',
       apiKeyOptional: 'API 키 (선택)',
       apiKeyPlaceholder: 'API 키 입력',
       customHeadersLabel: '사용자 정의 요청 헤더 (선택)',
@@ -2486,7 +2557,8 @@ export default {
         remote: '예: gpt-4, claude-3-opus',
         localVllm: '예: llava:latest',
         remoteVllm: '예: gpt-4-vision-preview',
-        remoteAsr: '예: whisper-1'
+        remoteAsr: '예: whisper-1',
+        remoteTts: '예: tts-1'
       },
       description: {
         chat: '대화용 대규모 언어 모델 설정',
@@ -2494,6 +2566,7 @@ export default {
         rerank: '결과 재정렬용 모델 설정',
         vllm: '시각 이해 및 멀티모달용 비전 언어 모델 설정',
         asr: '음성 인식 및 오디오 전사를 위한 음성 인식 모델 설정',
+        tts: '음성 응답을 위한 텍스트 음성 변환 모델 설정',
         default: '모델 정보 설정'
       }
     }
@@ -3205,6 +3278,10 @@ export default {
     memoryForgotten: '기억을 삭제했습니다',
     memoryForgetFailed: '삭제 실패',
     memoryHint: '이 답변이 참고한 장기 기억입니다. 삭제하면 다시 사용되지 않습니다.',
+    memoryProvenance: {
+      workspace: '팀',
+      agent: '에이전트'
+    },
     suggestedQuestions: '이렇게 물어보세요',
     followUpQuestions: '이어서 질문',
     followUpQuestionsLoading: '추천 질문 로딩 중',
@@ -3288,6 +3365,24 @@ export default {
     lengthTenThousands: '{value}만 자',
     noDatabaseRecords: '일치하는 레코드를 찾을 수 없습니다',
     nullValuePlaceholder: '<NULL>',
+    dataChart: {
+      rows: '데이터 포인트 {rows}개',
+      attached: '첨부됨: {files}',
+      noPreview: '차트가 너무 커서 여기서 미리 볼 수 없습니다. 첨부 파일에서 확인하세요',
+      exportSql: 'SQL 내보내기',
+      exportNotebook: 'Notebook 내보내기',
+      exportFailed: '내보내기 실패'
+    },
+    sqlQuery: {
+      rowsIn: '{rows} rows · {ms} ms',
+      truncated: 'Result truncated',
+      chart: {
+        line: 'Line',
+        bar: 'Bar',
+        pie: 'Pie',
+        scatter: 'Scatter'
+      }
+    },
     chunkCountValue: '{count}개 청크',
     documentDescriptionLabel: '문서 설명:',
     documentSourceLabel: '출처:',
@@ -4614,6 +4709,12 @@ export default {
     enableLabel: '내 장기 기억 사용',
     enableDescription: '끄면 어시스턴트가 기억을 읽거나 추가하지 않습니다. 기존 기억은 유지되며 다시 켜면 계속 사용됩니다.',
     agentDisabledHint: '개별 에이전트도 장기 기억을 따로 끌 수 있습니다. 꺼 둔 에이전트와의 대화에서는 기억을 읽지도 추가하지도 않으며, 다른 에이전트는 영향을 받지 않습니다.',
+    share: '팀과 공유',
+    shareTitle: '공유 기억으로 제안',
+    shareHint: '사본이 제안되며, 관리자가 승인한 뒤에만 다른 구성원의 대화에서 사용됩니다. 내 기억은 그대로 유지됩니다.',
+    shareToWorkspace: '워크스페이스 전체',
+    shareToAgent: '특정 에이전트',
+    shareAgentPlaceholder: '에이전트 선택',
     usage: {
       title: '기억이 사용되는 시점',
       iconHint: '어떤 기억이 대화에 쓰이는지 보기',
@@ -4734,7 +4835,8 @@ export default {
     origins: {
       explicit: '직접 요청',
       extracted: '자동 정리',
-      manual: '수동 추가'
+      manual: '수동 추가',
+      promoted: '구성원 제안'
     },
     toasts: {
       enabled: '장기 기억을 켰습니다',
@@ -4743,6 +4845,7 @@ export default {
       updated: '수정했습니다',
       deleted: '삭제했습니다',
       cleared: '{count}개의 기억을 삭제했습니다',
+      shared: '제안했습니다. 관리자 검토를 기다립니다',
       saveFailed: '작업 실패: {message}'
     }
   },
@@ -4783,6 +4886,39 @@ export default {
       saveFailed: '저장 실패: {message}'
     }
   },
+  sharedMemorySettings: {
+    title: '공유 기억',
+    description: '워크스페이스의 모든 구성원 또는 특정 에이전트와의 모든 대화에 적용되는 기억입니다. 구성원이 자신의 기억을 제안하면 검토 후 적용되며, 직접 추가할 수도 있습니다.',
+    add: '추가',
+    addTitle: '공유 기억 추가',
+    addPlaceholder: '예: 운영 환경 변경은 먼저 티켓을 등록합니다',
+    expiresPlaceholder: '만료 시각 (비워 두면 만료되지 않음)',
+    scopeWorkspace: '워크스페이스 전체',
+    scopeAgent: '특정 에이전트',
+    agentPlaceholder: '에이전트 선택',
+    pickAgent: '먼저 에이전트를 선택하세요.',
+    statusPending: '검토 대기',
+    statusActive: '사용 중',
+    pendingEmpty: '검토를 기다리는 제안이 없습니다.',
+    activeEmpty: '아직 공유 기억이 없습니다.',
+    proposedBy: '제안자 {who}',
+    reviewedBy: '검토자 {who}',
+    expired: '만료됨',
+    expiresAt: '{time} 만료',
+    approve: '승인',
+    reject: '반려',
+    expireNow: '지금 만료',
+    deleteConfirm: '삭제하면 모든 구성원의 대화에서 이 기억을 더 이상 사용하지 않습니다. 삭제할까요?',
+    toasts: {
+      added: '추가했습니다',
+      updated: '수정했습니다',
+      approved: '승인했습니다',
+      rejected: '반려했습니다',
+      expired: '만료 처리했습니다',
+      deleted: '삭제했습니다',
+      failed: '작업 실패: {message}'
+    }
+  },
   chatHistorySettings: {
     title: '메시지 관리',
     description: '채팅 기록 지식베이스를 구성하여 대화 메시지를 자동으로 벡터화 인덱싱하여 시맨틱 검색을 지원합니다',
@@ -4835,6 +4971,109 @@ export default {
       indexNamePattern: '영문자로 시작해야 합니다. 영문, 숫자, 밑줄, 하이픈만 허용 (최대 128자)'
     }
   },
+  browserConfigSettings: {
+    title: '브라우저 서비스',
+    description: 'Chrome DevTools 프로토콜로 접근하는 헤드리스 브라우저(Chromium 원격 디버깅 포트, browserless 등)를 등록합니다. 에이전트는 대화 중 페이지 열기, 클릭, 양식 입력, 내용 읽기, 스크린샷을 할 수 있습니다. 사설 및 메타데이터 주소는 항상 차단됩니다.',
+    listTitle: '브라우저 서비스',
+    add: '브라우저 서비스 추가',
+    edit: '브라우저 서비스 편집',
+    empty: '아직 브라우저 서비스가 없습니다.',
+    deleteConfirm: '이 브라우저 서비스를 삭제하시겠습니까? 이를 사용하는 에이전트는 더 이상 브라우저를 조작할 수 없으며 열린 페이지는 닫힙니다.',
+    basicSection: '기본 정보',
+    endpointSection: '브라우저 서비스',
+    limitsSection: '페이지 제한',
+    nameLabel: '이름',
+    namePlaceholder: '예: 공유 Chromium',
+    descriptionLabel: '설명',
+    descriptionPlaceholder: '이 브라우저 서비스의 용도',
+    endpointLabel: 'DevTools 주소',
+    endpointHelp: 'http(s)://호스트:9222 (/json/version 으로 확인) 또는 브라우저 WebSocket 주소 ws(s)://...',
+    tokenLabel: '액세스 토큰',
+    tokenPlaceholder: '선택 사항, Bearer 토큰으로 브라우저 서비스에 전송',
+    allowPrivateLabel: '사설 주소 허용',
+    allowPrivateHelp: '같은 네트워크의 브라우저 컨테이너에만 사용하세요. 브라우저 서비스 주소에만 적용되며, 에이전트가 여는 페이지는 여전히 사설 주소에 접근할 수 없습니다.',
+    allowedDomainsLabel: '허용 사이트',
+    allowedDomainsPlaceholder: '도메인 입력 후 Enter, 예: example.com',
+    allowedDomainsHelp: '하위 도메인이 포함됩니다. 비워 두면 모든 공개 사이트를 허용합니다.',
+    anyPublicSite: '모든 공개 사이트',
+    timeoutLabel: '작업 제한 시간(초)',
+    maxTextCharsLabel: '읽기당 최대 문자 수',
+    viewportWidthLabel: '뷰포트 너비',
+    viewportHeightLabel: '뷰포트 높이',
+    test: '연결 테스트',
+    testing: '테스트 중...',
+    validation: {
+      nameRequired: '이름은 필수입니다',
+      endpointRequired: 'DevTools 주소는 필수입니다'
+    },
+    toasts: {
+      created: '브라우저 서비스가 생성되었습니다',
+      updated: '브라우저 서비스가 업데이트되었습니다',
+      deleted: '브라우저 서비스가 삭제되었습니다',
+      saveFailed: '저장 실패',
+      deleteFailed: '삭제 실패',
+      testSuccess: '연결 테스트 성공',
+      testFailed: '연결 테스트 실패'
+    }
+  },
+  sqlConnectionSettings: {
+    title: 'External Databases',
+    description: 'Register read-only external databases (PostgreSQL, MySQL, SQLite, DuckDB). Agents can read their schema and write SQL; results come back as tables with chart suggestions.',
+    listTitle: 'Database Connections',
+    add: 'Add Database',
+    edit: 'Edit Database',
+    empty: 'No external database connections yet.',
+    deleteConfirm: 'Delete this database connection? Agents using it will no longer be able to query it.',
+    basicSection: 'Basic Info',
+    connectionSection: 'Connection',
+    limitsSection: 'Query Limits',
+    schemaSection: 'Schema & Column Descriptions',
+    dialectLabel: 'Database Type',
+    nameLabel: 'Name',
+    namePlaceholder: 'e.g. Sales warehouse',
+    descriptionLabel: 'Description',
+    descriptionPlaceholder: 'What data lives here and which questions it answers',
+    descriptionHelp: 'Shown to the agent to help it decide when to query this database.',
+    hostLabel: 'Host',
+    portLabel: 'Port',
+    databaseLabel: 'Database',
+    usernameLabel: 'Username',
+    usernameHelp: 'Use an account that is only granted SELECT; it is the most reliable read-only guarantee.',
+    passwordLabel: 'Password',
+    passwordPlaceholder: 'Enter the database password',
+    sslModeLabel: 'SSL Mode',
+    filePathLabel: 'File Path',
+    filePathHelp: 'Absolute path on the server inside a directory listed in WEKNORA_SQL_FILE_DIRS. DuckDB can also point directly at a CSV / Parquet file.',
+    maxRowsLabel: 'Max Rows',
+    timeoutLabel: 'Query Timeout (s)',
+    includeTablesLabel: 'Tables Shown to the Agent',
+    includeTablesPlaceholder: 'Type a table name and press Enter; leave empty for all',
+    includeTablesHelp: 'Only limits the schema the agent sees and is not an access boundary; restrict sensitive data with database grants.',
+    schemaHelp: 'After loading the schema you can describe tables and columns in business terms; the agent uses these when writing SQL. Takes effect after saving.',
+    loadSchema: 'Load Schema',
+    reloadSchema: 'Reload',
+    noTables: 'No tables found.',
+    columnCount: '{count} columns',
+    tableDescriptionLabel: 'Table Description',
+    tableDescriptionPlaceholder: 'e.g. One row per order',
+    columnDescriptionPlaceholder: 'Column description (optional)',
+    schemaTruncated: 'Only the first 200 tables are shown.',
+    test: 'Test Connection',
+    testing: 'Testing...',
+    validation: {
+      nameRequired: 'Name is required'
+    },
+    toasts: {
+      created: 'Database connection created',
+      updated: 'Database connection updated',
+      deleted: 'Database connection deleted',
+      saveFailed: 'Failed to save',
+      deleteFailed: 'Failed to delete',
+      testSuccess: 'Connection test succeeded',
+      testFailed: 'Connection test failed',
+      schemaFailed: 'Failed to load schema'
+    }
+  },
   webSearchSettings: {
     title: '웹 검색 설정',
     description: '웹 검색 기능을 구성하여 질문에 답변할 때 인터넷에서 실시간 정보를 가져와 지식베이스 내용을 보완합니다',
@@ -4876,6 +5115,8 @@ export default {
   settings: {
     modelManagement: '모델 관리',
     webSearchConfig: '웹 검색',
+    sqlConnections: '외부 데이터베이스',
+    browserConfigs: '브라우저 서비스',
     autoCheckUpdate: '업데이트 자동 다운로드',
     autoCheckUpdateDesc: '활성화하면 시작 시 최신 버전을 자동으로 확인하고 백그라운드에서 다운로드합니다.',
     vectorStoreEngine: '벡터 DB 엔진',
@@ -4907,6 +5148,8 @@ export default {
         cube: 'Self-hosted MicroVM cluster for private or on-premises deployments',
         e2b: 'Managed MicroVM service or an E2B-compatible deployment',
         docker: 'Run every script in a short-lived container on this WeKnora host',
+        namespace: 'Run scripts on this WeKnora host inside Linux namespaces and seccomp, without a Docker daemon',
+        wasm: 'Run Python and JavaScript scripts inside the WeKnora process with WebAssembly; needs neither Docker nor Linux',
         local: 'Run scripts directly in the WeKnora server process environment',
       },
       addConfig: 'Add sandbox backend',
@@ -4972,6 +5215,18 @@ export default {
       cardPrivateEndpoints: '사설망 접근 허용',
       imageNotConfigured: 'Image not configured',
       localRuntimeSummary: 'WeKnora server process',
+      namespaceRuntimeSummary: 'Isolated namespace on the WeKnora host',
+      namespaceHostHint: 'Sandboxes run on the WeKnora host itself. The rootfs, state directory and cgroup are host settings (WEKNORA_NS_SANDBOX_* environment variables) and need the weknora-sandbox-init helper next to the server binary.',
+      namespaceAllowNetwork: 'Allow network access',
+      namespaceAllowNetworkHint: 'Off by default: scripts only see a private loopback interface. When on they share the host network.',
+      namespaceTtlHelp: 'How long an idle sandbox keeps its workspace files before it is reclaimed. Background processes do not outlive a single execution.',
+      namespaceMemory: 'Memory limit (MB)',
+      namespaceCpu: 'CPU limit (cores)',
+      namespacePids: 'Process limit',
+      namespaceLimitsHelp: 'Enforced through cgroup v2 only when the host configured WEKNORA_NS_SANDBOX_CGROUP_ROOT; otherwise only the per-process resource limits apply. Leave empty for the defaults.',
+      wasmRuntimeSummary: 'WebAssembly runtime in the WeKnora process',
+      wasmHostHint: 'Scripts run in the WeKnora process under WebAssembly (WASI) interpreters: only Python and JavaScript are supported, the skill directory is read-only, /tmp is a per-run scratch directory and there is no network access. The interpreter runtimes are a host setting (WEKNORA_WASM_SANDBOX_DIR or wasm-runtimes next to the server binary).',
+      wasmLimitsHelp: 'Caps the memory of each script; the default timeout below also bounds CPU time. Leave empty for the default.',
       templateApplied: 'Applied',
       refreshTemplates: 'Refresh templates',
       templateSelectHelp: 'Templates are loaded from this cluster. The saved configuration stores the ID automatically.',
@@ -5018,6 +5273,8 @@ export default {
         docker: 'Docker',
         cube: 'CubeSandbox',
         e2b: 'E2B',
+        namespace: 'Linux namespace',
+        wasm: 'WebAssembly',
       },
       apiUrl: 'API endpoint',
       proxyUrl: 'Proxy endpoint',
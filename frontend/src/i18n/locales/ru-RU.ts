@@ -872,7 +872,12 @@ export default {
       desc: 'Позволяет пользователям загружать аудиофайлы в чате. Система автоматически транскрибирует их с помощью ASR-модели.',
       asrModel: 'ASR-модель',
       asrModelDesc: 'Модель распознавания речи. Если не настроена, аудиофайлы передаются как заглушки.',
-      asrModelPlaceholder: 'Выберите ASR-модель'
+      asrModelPlaceholder: 'Выберите ASR-модель',
+      voiceReply: 'Голосовой ответ',
+      voiceReplyDesc: 'При получении голосового сообщения в IM помимо текстового ответа отправлять голосовой ответ, синтезированный TTS-моделью (Telegram, Feishu)',
+      ttsModel: 'TTS-модель',
+      ttsModelDesc: 'Модель синтеза речи для голосовых ответов',
+      ttsModelPlaceholder: 'Выберите TTS-модель'
     },
     imageUpload: {
       navLabel: 'Загрузка вложений',
@@ -902,6 +907,22 @@ export default {
       hint: 'Оставьте пустым или 0, чтобы использовать значение по умолчанию (120 секунд)',
       placeholder: 'Введите количество секунд, рекомендуемый диапазон 60-1800'
     },
+    sqlConnections: {
+      label: 'External Databases',
+      desc: 'Choose the external databases this agent may query. Selecting any enables the schema and read-only SQL query tools.',
+      selectLabel: 'Queryable Databases',
+      selectDesc: 'Register connections under Settings → External Databases',
+      selectPlaceholder: 'No external databases',
+      empty: 'This workspace has no external databases registered yet.'
+    },
+    browser: {
+      label: 'Автоматизация браузера',
+      desc: 'Выберите headless-браузер, которым управляет агент. Тогда агент сможет открывать страницы, нажимать, заполнять формы, читать содержимое и делать снимки экрана, сохраняя вход на протяжении беседы.',
+      selectLabel: 'Браузерный сервис',
+      selectDesc: 'Регистрируется в Настройки → Браузерные сервисы',
+      selectPlaceholder: 'Без браузера',
+      empty: 'В этом пространстве ещё нет браузерных сервисов.'
+    },
     mcp: {
       label: 'MCP-сервисы',
       desc: 'Выберите MCP-сервисы, доступные агенту',
@@ -940,6 +961,9 @@ export default {
       wechat: 'WeChat',
       qqbot: 'QQBot',
       yunzhijia: 'Yunzhijia',
+      discord: 'Discord',
+      teams: 'Microsoft Teams',
+      matrix: 'Matrix',
       addChannel: 'Добавить канал',
       channelsTitle: 'IM-каналы',
       disabled: 'Отключено',
@@ -962,6 +986,8 @@ export default {
       larkConsole: 'Платформа Lark',
       slackConsole: 'Консоль Slack API',
       telegramConsole: 'Telegram BotFather',
+      discordConsole: 'Discord Developer Portal',
+      teamsConsole: 'Портал Azure Bot / Bot Framework',
       dingtalkConsole: 'Платформа DingTalk',
       dingtalkCardTemplateId: 'ID шаблона карточки (необязательно)',
       dingtalkCardTemplateIdHint: 'Создайте шаблон AI-карточки на open-dev.dingtalk.com/fe/card для потоковой передачи с эффектом печатной машинки',
@@ -989,6 +1015,12 @@ export default {
       yunzhijiaAllowedHostSuffixHint: 'Ограничить хост URL отправки сообщений этим суффиксом для безопасности (напр. yunzhijia.com)',
       yunzhijiaSendMsgUrlRequired: 'Укажите URL отправки сообщений из настроек робота Yunzhijia',
       mattermostModeHint: 'Mattermost поддерживает только режим Webhook (исходящий вебхук + токен бота).',
+      discordModeHint: 'WebSocket получает сообщения каналов через Gateway (включите интент Message Content); Webhook получает слэш-команду /ask через эндпоинт Interactions.',
+      teamsModeHint: 'Microsoft Teams поддерживает только Webhook: укажите адрес обратного вызова как messaging endpoint в Azure Bot.',
+      matrixModeHint: 'Matrix поддерживает только WebSocket (long polling /sync), публичный адрес не нужен; комнаты со сквозным шифрованием пока не поддерживаются.',
+      discordPublicKeyHint: 'Public Key со страницы General Information приложения, используется для проверки подписи запросов Interactions.',
+      teamsTenantIdHint: 'ID тенанта Azure AD для однотенантного бота; для мультитенантного оставьте пустым.',
+      matrixUserIdHint: 'Полный Matrix ID аккаунта бота, нужен для распознавания упоминаний и собственных сообщений.',
       mattermostPostToMain: 'Ответы в основной ленте канала',
       mattermostPostToMainHint: 'Включено — ответы бота как новые сообщения в канале. Выключено (по умолчанию) — ответы в ветке, в основном окне только «N ответов».',
       modeHint: 'Рекомендуется WebSocket — проще настроить',
@@ -999,6 +1031,23 @@ export default {
       fileKnowledgeBase: 'База знаний для файлов',
       fileKnowledgeBasePlaceholder: 'Выберите базу знаний (необязательно)',
       fileKnowledgeBaseHint: 'При настройке файлы, отправленные пользователями, автоматически сохраняются в эту базу знаний',
+      sectionThreadArchive: 'Архив групповых обсуждений',
+      threadArchiveEnabled: 'Архивировать решённые обсуждения',
+      threadArchiveEnabledHint: 'Отправьте /archive в обсуждении группы или поставьте заданную реакцию — всё обсуждение сохранится как одна запись базы знаний с участниками, временем и ссылкой на чат',
+      threadArchiveKnowledgeBase: 'База знаний для архива',
+      threadArchiveKnowledgeBasePlaceholder: 'Выберите базу знаний документов',
+      threadArchiveKnowledgeBaseRequired: 'Выберите базу знаний для архива, чтобы включить архивирование',
+      threadArchiveReaction: 'Реакция для архивирования',
+      threadArchiveReactionHint: 'Необязательно. Для Slack укажите имя эмодзи (например, white_check_mark), для Feishu/Lark — тип эмодзи (например, DONE); если пусто, работает только команда /archive',
+      threadArchiveFAQKnowledgeBase: 'База знаний FAQ',
+      threadArchiveFAQKnowledgeBaseHint: 'Необязательно. Если задано, модель агента сжимает обсуждение в одну запись FAQ в этой базе знаний',
+      sectionUserBindings: 'Привязка пользователей и права',
+      userBindingsHint: 'Привяжите пользователей IM к участникам тенанта, чтобы команды /agent, /kb, /save, /approve проверялись по роли участника. Непривязанные пользователи считаются гостями. ID пользователя IM показывается, когда бот отклоняет команду.',
+      userBindingIMUserPlaceholder: 'ID пользователя IM',
+      userBindingMemberPlaceholder: 'Выберите участника тенанта',
+      userBindingAdd: 'Привязать',
+      userBindingLoadFailed: 'Не удалось загрузить привязки пользователей',
+      userBindingSaveFailed: 'Не удалось привязать. Убедитесь, что пользователь — активный участник тенанта',
       sessionMode: 'Режим сессии',
       sessionModeUser: 'По пользователю (по умолчанию)',
       sessionModeThread: 'По потоку',
@@ -1051,6 +1100,8 @@ export default {
       dataAnalysisDesc: 'Понимание файлов данных и проведение анализа',
       dataSchema: 'Схема данных',
       dataSchemaDesc: 'Получение метаинформации табличных файлов',
+      dataChart: 'Диаграмма данных',
+      dataChartDesc: 'Построение диаграмм по результатам анализа данных',
       requiresKb: '(требуется настройка базы знаний)',
       requiresRagKb: '(требуется база знаний с включённым векторным/ключевым индексом)',
       requiresWikiKb: '(требуется база знаний с включённой Wiki)',
@@ -1307,7 +1358,15 @@ export default {
       executeSkillScript: 'Выполнение скрипта навыка',
       dataAnalysis: 'Анализ данных',
       dataSchema: 'Структура данных',
-      databaseQuery: 'Запрос к базе данных'
+      dataChart: 'Построение диаграммы',
+      databaseQuery: 'Запрос к базе данных',
+      sqlSchema: 'Read Schema',
+      sqlQuery: 'SQL Query',
+      browserNavigate: 'Открыть страницу',
+      browserClick: 'Нажать элемент',
+      browserFill: 'Заполнить форму',
+      browserExtract: 'Прочитать страницу',
+      browserScreenshot: 'Снимок экрана'
     },
     mcpOAuth: {
       waiting: 'Ожидание авторизации · {target}',
@@ -1962,6 +2021,8 @@ export default {
       documentsHint: 'Каждая непустая строка отправляется в модель ReRank как отдельный документ',
       imageFile: 'Файл изображения',
       audioFile: 'Аудиофайл',
+      ttsInput: 'Текст для синтеза',
+      ttsPlaceholder: 'Введите текст для озвучивания',
       chooseFile: 'Выбрать файл',
       parameters: 'Параметры запроса',
       thinking: 'Режим размышления',
@@ -1986,7 +2047,8 @@ export default {
         reasoningChars: 'Символов рассуждения',
         reasoningReturned: 'Рассуждение возвращено',
         textChars: 'Символов транскрипции',
-        segmentCount: 'Количество сегментов'
+        segmentCount: 'Количество сегментов',
+        audioBytes: 'Размер аудио'
       }
     },
     builtinModels: {
@@ -2018,6 +2080,11 @@ export default {
       desc: 'Модели распознавания речи для транскрибации аудио (например, OpenAI Whisper)',
       empty: 'Нет ASR моделей'
     },
+    tts: {
+      title: 'TTS модели синтеза речи',
+      desc: 'Модели преобразования текста в речь для озвучивания ответов (например, OpenAI TTS)',
+      empty: 'Нет TTS моделей'
+    },
     vllm: {
       title: 'VLLM модели зрения',
       desc: 'Визуально-языковые модели для мультимодального понимания',
@@ -2052,7 +2119,8 @@ export default {
       embedding: 'Embedding',
       rerank: 'ReRank',
       vllm: 'Зрение',
-      asr: 'Речь'
+      asr: 'Речь',
+      tts: 'Синтез речи'
     }
   },
   mcpSettings: {
@@ -2292,6 +2360,9 @@ export default {
       baseUrlPlaceholder: 'например: https://api.openai.com/v1',
       baseUrlPlaceholderVllm: 'например: http://localhost:11434/v1',
       baseUrlPlaceholderAsr: 'например: https://api.openai.com/v1',
+      ttsVoiceLabel: 'Голос (необязательно)',
+      ttsVoicePlaceholder: 'например: alloy',
+      ttsVoiceDesc: 'Параметр voice для /v1/audio/speech; по умолчанию alloy',
       apiKeyOptional: 'API Key (опционально)',
       apiKeyPlaceholder: 'Введите API Key',
       customHeadersLabel: 'Пользовательские заголовки запроса (опционально)',
@@ -2486,7 +2557,8 @@ export default {
         remote: 'например: gpt-4, claude-3-opus',
         localVllm: 'например: llava:latest',
         remoteVllm: 'например: gpt-4-vision-preview',
-        remoteAsr: 'например: whisper-1'
+        remoteAsr: 'например: whisper-1',
+        remoteTts: 'например: tts-1'
       },
       description: {
         chat: 'Настройте языковую модель для диалогов',
@@ -2494,6 +2566,7 @@ export default {
         rerank: 'Настройте модель для повторного ранжирования результатов',
         vllm: 'Настройте визуально-языковую модель для мультимодального понимания',
         asr: 'Настройте модель распознавания речи для транскрибации аудио',
+        tts: 'Настройте модель синтеза речи для голосовых ответов',
         default: 'Настройте информацию о модели'
       }
     }
@@ -3205,6 +3278,10 @@ export default {
     memoryForgotten: 'Запись удалена',
     memoryForgetFailed: 'Не удалось удалить',
     memoryHint: 'Это записи долговременной памяти, которые видел этот ответ. Удалённая запись больше не используется.',
+    memoryProvenance: {
+      workspace: 'Команда',
+      agent: 'Агент'
+    },
     suggestedQuestions: 'Вы можете спросить меня',
     followUpQuestions: 'Спрашивайте дальше',
     followUpQuestionsLoading: 'Загрузка рекомендуемых вопросов',
@@ -3288,6 +3365,24 @@ export default {
     lengthTenThousands: '{value} ×10⁴ символов',
     noDatabaseRecords: 'Совпадающих записей не найдено',
     nullValuePlaceholder: '<NULL>',
+    dataChart: {
+      rows: 'Точек данных: {rows}',
+      attached: 'Прикреплено: {files}',
+      noPreview: 'Диаграмма слишком велика для предпросмотра, откройте её во вложениях',
+      exportSql: 'Экспорт SQL',
+      exportNotebook: 'Экспорт Notebook',
+      exportFailed: 'Ошибка экспорта'
+    },
+    sqlQuery: {
+      rowsIn: '{rows} rows · {ms} ms',
+      truncated: 'Result truncated',
+      chart: {
+        line: 'Line',
+        bar: 'Bar',
+        pie: 'Pie',
+        scatter: 'Scatter'
+      }
+    },
     chunkCountValue: '{count} фрагментов',
     documentDescriptionLabel: 'Описание:',
     documentSourceLabel: 'Источник:',
@@ -4614,6 +4709,12 @@ export default {
     enableLabel: 'Использовать долговременную память',
     enableDescription: 'При выключении ассистент не читает и не добавляет ваши записи. Существующие сохраняются и снова заработают после включения.',
     agentDisabledHint: 'Отдельный агент тоже может отключить долговременную память для себя. В разговоре с таким агентом ваши записи не читаются и не пополняются; на других агентов это не влияет.',
+    share: 'Поделиться с командой',
+    shareTitle: 'Предложить в общую память',
+    shareHint: 'Предлагается копия. В разговорах других участников она используется только после одобрения администратором; ваша запись остаётся без изменений.',
+    shareToWorkspace: 'Всё рабочее пространство',
+    shareToAgent: 'Один агент',
+    shareAgentPlaceholder: 'Выберите агента',
     usage: {
       title: 'Когда записи используются',
       iconHint: 'Посмотреть, какие записи попадают в разговор',
@@ -4734,7 +4835,8 @@ export default {
     origins: {
       explicit: 'По вашей просьбе',
       extracted: 'Извлечено',
-      manual: 'Добавлено вручную'
+      manual: 'Добавлено вручную',
+      promoted: 'Предложено участником'
     },
     toasts: {
       enabled: 'Долговременная память включена',
@@ -4743,6 +4845,7 @@ export default {
       updated: 'Обновлено',
       deleted: 'Удалено',
       cleared: 'Удалено записей: {count}',
+      shared: 'Предложено. Ожидает проверки администратором',
       saveFailed: 'Не удалось выполнить: {message}'
     }
   },
@@ -4783,6 +4886,39 @@ export default {
       saveFailed: 'Не удалось сохранить: {message}'
     }
   },
+  sharedMemorySettings: {
+    title: 'Общая память',
+    description: 'Записи, которые действуют для всех участников рабочего пространства или для всех разговоров с одним агентом. Участники могут предлагать сюда свои записи на вашу проверку, или вы можете добавить их сами.',
+    add: 'Добавить',
+    addTitle: 'Добавить общую запись',
+    addPlaceholder: 'Например: изменения в продакшене — только по заявке',
+    expiresPlaceholder: 'Срок действия (пусто — бессрочно)',
+    scopeWorkspace: 'Всё рабочее пространство',
+    scopeAgent: 'Один агент',
+    agentPlaceholder: 'Выберите агента',
+    pickAgent: 'Сначала выберите агента.',
+    statusPending: 'На проверке',
+    statusActive: 'Действует',
+    pendingEmpty: 'Нет предложений на проверке.',
+    activeEmpty: 'Общих записей пока нет.',
+    proposedBy: 'Предложил(а) {who}',
+    reviewedBy: 'Проверил(а) {who}',
+    expired: 'Истекла',
+    expiresAt: 'Истекает {time}',
+    approve: 'Одобрить',
+    reject: 'Отклонить',
+    expireNow: 'Завершить сейчас',
+    deleteConfirm: 'После удаления ни один разговор участников не будет использовать эту запись. Удалить?',
+    toasts: {
+      added: 'Добавлено',
+      updated: 'Обновлено',
+      approved: 'Одобрено',
+      rejected: 'Отклонено',
+      expired: 'Срок действия завершён',
+      deleted: 'Удалено',
+      failed: 'Ошибка: {message}'
+    }
+  },
   chatHistorySettings: {
     title: 'Управление сообщениями',
     description: 'Настройте базу знаний истории чата для автоматической индексации сообщений и семантического поиска',
@@ -4835,6 +4971,109 @@ export default {
       indexNamePattern: 'Должно начинаться с буквы. Допускаются только буквы, цифры, подчёркивание и дефис (макс. 128)'
     }
   },
+  browserConfigSettings: {
+    title: 'Браузерные сервисы',
+    description: 'Регистрация headless-браузеров, доступных по протоколу Chrome DevTools (порт удалённой отладки Chromium, browserless и т. п.). Агенты смогут открывать страницы, нажимать, заполнять формы, читать содержимое и делать снимки экрана в беседе. Частные адреса и адреса метаданных всегда блокируются.',
+    listTitle: 'Браузерные сервисы',
+    add: 'Добавить браузерный сервис',
+    edit: 'Изменить браузерный сервис',
+    empty: 'Браузерных сервисов пока нет.',
+    deleteConfirm: 'Удалить этот браузерный сервис? Агенты, использующие его, больше не смогут работать с браузером, открытые страницы будут закрыты.',
+    basicSection: 'Основное',
+    endpointSection: 'Браузерный сервис',
+    limitsSection: 'Ограничения страниц',
+    nameLabel: 'Название',
+    namePlaceholder: 'например, Общий Chromium',
+    descriptionLabel: 'Описание',
+    descriptionPlaceholder: 'Для чего используется этот браузерный сервис',
+    endpointLabel: 'Адрес DevTools',
+    endpointHelp: 'http(s)://хост:9222 (через /json/version) или WebSocket-адрес браузера ws(s)://...',
+    tokenLabel: 'Токен доступа',
+    tokenPlaceholder: 'Необязательно, отправляется сервису как Bearer-токен',
+    allowPrivateLabel: 'Разрешить частный адрес',
+    allowPrivateHelp: 'Только для браузерного контейнера в той же сети. Касается лишь адреса сервиса; страницы, открытые агентом, по-прежнему не имеют доступа к частным адресам.',
+    allowedDomainsLabel: 'Разрешённые сайты',
+    allowedDomainsPlaceholder: 'Введите домен и нажмите Enter, например example.com',
+    allowedDomainsHelp: 'Поддомены включены. Пусто — разрешён любой публичный сайт.',
+    anyPublicSite: 'Любой публичный сайт',
+    timeoutLabel: 'Тайм-аут действия (с)',
+    maxTextCharsLabel: 'Макс. символов за чтение',
+    viewportWidthLabel: 'Ширина окна',
+    viewportHeightLabel: 'Высота окна',
+    test: 'Проверить подключение',
+    testing: 'Проверка...',
+    validation: {
+      nameRequired: 'Название обязательно',
+      endpointRequired: 'Адрес DevTools обязателен'
+    },
+    toasts: {
+      created: 'Браузерный сервис создан',
+      updated: 'Браузерный сервис обновлён',
+      deleted: 'Браузерный сервис удалён',
+      saveFailed: 'Не удалось сохранить',
+      deleteFailed: 'Не удалось удалить',
+      testSuccess: 'Подключение успешно',
+      testFailed: 'Не удалось подключиться'
+    }
+  },
+  sqlConnectionSettings: {
+    title: 'External Databases',
+    description: 'Register read-only external databases (PostgreSQL, MySQL, SQLite, DuckDB). Agents can read their schema and write SQL; results come back as tables with chart suggestions.',
+    listTitle: 'Database Connections',
+    add: 'Add Database',
+    edit: 'Edit Database',
+    empty: 'No external database connections yet.',
+    deleteConfirm: 'Delete this database connection? Agents using it will no longer be able to query it.',
+    basicSection: 'Basic Info',
+    connectionSection: 'Connection',
+    limitsSection: 'Query Limits',
+    schemaSection: 'Schema & Column Descriptions',
+    dialectLabel: 'Database Type',
+    nameLabel: 'Name',
+    namePlaceholder: 'e.g. Sales warehouse',
+    descriptionLabel: 'Description',
+    descriptionPlaceholder: 'What data lives here and which questions it answers',
+    descriptionHelp: 'Shown to the agent to help it decide when to query this database.',
+    hostLabel: 'Host',
+    portLabel: 'Port',
+    databaseLabel: 'Database',
+    usernameLabel: 'Username',
+    usernameHelp: 'Use an account that is only granted SELECT; it is the most reliable read-only guarantee.',
+    passwordLabel: 'Password',
+    passwordPlaceholder: 'Enter the database password',
+    sslModeLabel: 'SSL Mode',
+    filePathLabel: 'File Path',
+    filePathHelp: 'Absolute path on the server inside a directory listed in WEKNORA_SQL_FILE_DIRS. DuckDB can also point directly at a CSV / Parquet file.',
+    maxRowsLabel: 'Max Rows',
+    timeoutLabel: 'Query Timeout (s)',
+    includeTablesLabel: 'Tables Shown to the Agent',
+    includeTablesPlaceholder: 'Type a table name and press Enter; leave empty for all',
+    includeTablesHelp: 'Only limits the schema the agent sees and is not an access boundary; restrict sensitive data with database grants.',
+    schemaHelp: 'After loading the schema you can describe tables and columns in business terms; the agent uses these when writing SQL. Takes effect after saving.',
+    loadSchema: 'Load Schema',
+    reloadSchema: 'Reload',
+    noTables: 'No tables found.',
+    columnCount: '{count} columns',
+    tableDescriptionLabel: 'Table Description',
+    tableDescriptionPlaceholder: 'e.g. One row per order',
+    columnDescriptionPlaceholder: 'Column description (optional)',
+    schemaTruncated: 'Only the first 200 tables are shown.',
+    test: 'Test Connection',
+    testing: 'Testing...',
+    validation: {
+      nameRequired: 'Name is required'
+    },
+    toasts: {
+      created: 'Database connection created',
+      updated: 'Database connection updated',
+      deleted: 'Database connection deleted',
+      saveFailed: 'Failed to save',
+      deleteFailed: 'Failed to delete',
+      testSuccess: 'Connection test succeeded',
+      testFailed: 'Connection test failed',
+      schemaFailed: 'Failed to load schema'
+    }
+  },
   webSearchSettings: {
     title: 'Настройки веб-поиска',
     description: 'Настройте веб-поиск, чтобы ответы могли включать актуальную информацию из интернета.',
@@ -4876,6 +5115,8 @@ export default {
   settings: {
     modelManagement: 'Управление моделями',
     webSearchConfig: 'Сетевой поиск',
+    sqlConnections: 'Внешние базы данных',
+    browserConfigs: 'Браузерные сервисы',
     autoCheckUpdate: 'Автоматическая загрузка обновлений',
     autoCheckUpdateDesc: 'При включении автоматически проверять и скачивать последнюю версию в фоновом режиме при запуске.',
     vectorStoreEngine: 'Движок векторной БД',
@@ -4907,6 +5148,8 @@ export default {
         cube: 'Self-hosted MicroVM cluster for private or on-premises deployments',
         e2b: 'Managed MicroVM service or an E2B-compatible deployment',
         docker: 'Run every script in a short-lived container on this WeKnora host',
+        namespace: 'Run scripts on this WeKnora host inside Linux namespaces and seccomp, without a Docker daemon',
+        wasm: 'Run Python and JavaScript scripts inside the WeKnora process with WebAssembly; needs neither Docker nor Linux',
         local: 'Run scripts directly in the WeKnora server process environment',
       },
       addConfig: 'Add sandbox backend',
@@ -4972,6 +5215,18 @@ export default {
       cardPrivateEndpoints: 'Разрешён доступ к частной сети',
       imageNotConfigured: 'Image not configured',
       localRuntimeSummary: 'WeKnora server process',
+      namespaceRuntimeSummary: 'Isolated namespace on the WeKnora host',
+      namespaceHostHint: 'Sandboxes run on the WeKnora host itself. The rootfs, state directory and cgroup are host settings (WEKNORA_NS_SANDBOX_* environment variables) and need the weknora-sandbox-init helper next to the server binary.',
+      namespaceAllowNetwork: 'Allow network access',
+      namespaceAllowNetworkHint: 'Off by default: scripts only see a private loopback interface. When on they share the host network.',
+      namespaceTtlHelp: 'How long an idle sandbox keeps its workspace files before it is reclaimed. Background processes do not outlive a single execution.',
+      namespaceMemory: 'Memory limit (MB)',
+      namespaceCpu: 'CPU limit (cores)',
+      namespacePids: 'Process limit',
+      namespaceLimitsHelp: 'Enforced through cgroup v2 only when the host configured WEKNORA_NS_SANDBOX_CGROUP_ROOT; otherwise only the per-process resource limits apply. Leave empty for the defaults.',
+      wasmRuntimeSummary: 'WebAssembly runtime in the WeKnora process',
+      wasmHostHint: 'Scripts run in the WeKnora process under WebAssembly (WASI) interpreters: only Python and JavaScript are supported, the skill directory is read-only, /tmp is a per-run scratch directory and there is no network access. The interpreter runtimes are a host setting (WEKNORA_WASM_SANDBOX_DIR or wasm-runtimes next to the server binary).',
+      wasmLimitsHelp: 'Caps the memory of each script; the default timeout below also bounds CPU time. Leave empty for the default.',
       templateApplied: 'Applied',
       refreshTemplates: 'Refresh templates',
       templateSelectHelp: 'Templates are loaded from this cluster. The saved configuration stores the ID automatically.',
@@ -5018,6 +5273,8 @@ export default {
         docker: 'Docker',
         cube: 'CubeSandbox',
         e2b: 'E2B',
+        namespace: 'Linux namespace',
+        wasm: 'WebAssembly',
       },
       apiUrl: 'API endpoint',
       proxyUrl: 'Proxy endpoint',
@@ -874,7 +874,12 @@ export default {
       desc: '启用后用户可在对话中上传音频文件，系统将使用 ASR 模型自动转录为文字',
       asrModel: 'ASR 模型',
       asrModelDesc: '用于音频转录的语音识别模型，未配置时音频文件将以占位符形式传递',
-      asrModelPlaceholder: '请选择 ASR 模型'
+      asrModelPlaceholder: '请选择 ASR 模型',
+      voiceReply: '语音回复',
+      voiceReplyDesc: '在 IM 渠道中收到语音消息时，除文字回答外再用 TTS 模型合成一条语音回复（Telegram、飞书）',
+      ttsModel: 'TTS 模型',
+      ttsModelDesc: '用于合成语音回复的语音合成模型',
+      ttsModelPlaceholder: '请选择 TTS 模型'
     },
     imageUpload: {
       navLabel: '附件上传',
@@ -904,6 +909,22 @@ export default {
       hint: '留空或填 0 表示使用默认值（120 秒）',
       placeholder: '输入秒数，建议范围 60-1800'
     },
+    sqlConnections: {
+      label: '外部数据库',
+      desc: '选择智能体可以查询的外部数据库。选中后会启用读取表结构和只读 SQL 查询两个工具。',
+      selectLabel: '可查询的数据库',
+      selectDesc: '在「设置 → 外部数据库」中登记连接',
+      selectPlaceholder: '不使用外部数据库',
+      empty: '当前空间还没有登记外部数据库。'
+    },
+    browser: {
+      label: '浏览器自动化',
+      desc: '选择智能体驱动的无头浏览器服务。选中后智能体可以打开网页、点击、填写表单、读取页面内容和截图，会话期间保持登录状态。',
+      selectLabel: '浏览器服务',
+      selectDesc: '在「设置 → 浏览器服务」中登记',
+      selectPlaceholder: '不使用浏览器',
+      empty: '当前空间还没有登记浏览器服务。'
+    },
     mcp: {
       label: 'MCP 服务',
       desc: '选择 Agent 可以调用的 MCP 服务',
@@ -942,6 +963,9 @@ export default {
       wechat: '微信',
       qqbot: 'QQBot',
       yunzhijia: '云之家',
+      discord: 'Discord',
+      teams: 'Microsoft Teams',
+      matrix: 'Matrix',
       addChannel: '添加渠道',
       channelsTitle: 'IM 渠道',
       disabled: '已停用',
@@ -964,6 +988,8 @@ export default {
       larkConsole: 'Lark 开放平台',
       slackConsole: 'Slack API 控制台',
       telegramConsole: 'Telegram BotFather',
+      discordConsole: 'Discord 开发者后台',
+      teamsConsole: 'Azure Bot / Bot Framework 门户',
       dingtalkConsole: '钉钉开放平台',
       dingtalkCardTemplateId: '卡片模板 ID（可选）',
       dingtalkCardTemplateIdHint: '在 open-dev.dingtalk.com/fe/card 创建 AI 卡片模板，启用后支持打字机效果的流式输出',
@@ -991,6 +1017,12 @@ export default {
       yunzhijiaAllowedHostSuffixHint: '限制发送消息地址的目标域名后缀，提升安全性（如 yunzhijia.com）',
       yunzhijiaSendMsgUrlRequired: '请填写云之家机器人设置中的发送消息地址',
       mattermostModeHint: 'Mattermost 仅支持 Webhook（出站 Webhook + Bot Token）。',
+      discordModeHint: 'WebSocket 通过 Gateway 接收频道消息（需开启 Message Content Intent）；Webhook 通过 Interactions 端点接收斜杠命令 /ask。',
+      teamsModeHint: 'Microsoft Teams 仅支持 Webhook：将 Azure Bot 的消息端点设置为回调地址。',
+      matrixModeHint: 'Matrix 仅支持 WebSocket（/sync 长轮询），无需公网回调地址；暂不支持端到端加密房间。',
+      discordPublicKeyHint: '应用 General Information 页面中的 Public Key，用于校验 Interactions 请求签名。',
+      teamsTenantIdHint: '单租户机器人填写所属 Azure AD 租户 ID；多租户机器人留空。',
+      matrixUserIdHint: '机器人账号的完整 Matrix ID，用于识别 @提及和自身消息。',
       mattermostPostToMain: '回复显示在频道主时间线',
       mattermostPostToMainHint: '开启后 Bot 回复作为频道内新帖子；关闭（默认）则作为原消息的线程回复，主窗口仅显示「N 条回复」。',
       modeHint: '推荐使用 WebSocket 方式接入，配置更简单',
@@ -1001,6 +1033,23 @@ export default {
       fileKnowledgeBase: '文件保存知识库',
       fileKnowledgeBasePlaceholder: '选择知识库（可选）',
       fileKnowledgeBaseHint: '配置后，用户发送的文件将自动保存到该知识库中',
+      sectionThreadArchive: '群聊话题归档',
+      threadArchiveEnabled: '归档已解决的话题',
+      threadArchiveEnabledHint: '在群聊话题中发送 /archive 或添加指定表情回应后，整个话题会作为一条知识存入知识库，并附带参与者、时间和原始链接',
+      threadArchiveKnowledgeBase: '归档知识库',
+      threadArchiveKnowledgeBasePlaceholder: '选择文档型知识库',
+      threadArchiveKnowledgeBaseRequired: '开启话题归档时请选择归档知识库',
+      threadArchiveReaction: '触发归档的表情',
+      threadArchiveReactionHint: '可选。Slack 填写表情名（如 white_check_mark），飞书填写表情类型（如 DONE）；留空则仅支持 /archive 命令',
+      threadArchiveFAQKnowledgeBase: 'FAQ 知识库',
+      threadArchiveFAQKnowledgeBaseHint: '可选。配置后会使用智能体的对话模型将话题提炼为一条问答，写入该 FAQ 知识库',
+      sectionUserBindings: '用户绑定与权限',
+      userBindingsHint: '将 IM 用户绑定到租户成员后，/agent、/kb、/save、/approve 等指令按该成员的角色判断权限；未绑定的用户按访客处理。IM 用户 ID 可在机器人拒绝指令时的提示中找到。',
+      userBindingIMUserPlaceholder: 'IM 用户 ID',
+      userBindingMemberPlaceholder: '选择租户成员',
+      userBindingAdd: '绑定',
+      userBindingLoadFailed: '加载用户绑定失败',
+      userBindingSaveFailed: '绑定失败，请确认该用户是租户的有效成员',
       sessionMode: '会话模式',
       sessionModeUser: '按用户（默认）',
       sessionModeThread: '按话题',
@@ -1053,6 +1102,8 @@ export default {
       dataAnalysisDesc: '理解数据文件并进行数据分析',
       dataSchema: '查看数据元信息',
       dataSchemaDesc: '获取表格文件的元信息',
+      dataChart: '数据图表',
+      dataChartDesc: '根据数据分析结果绘制图表',
       requiresKb: '（需要配置知识库）',
       requiresRagKb: '（需要关联启用向量/关键词索引的知识库）',
       requiresWikiKb: '（需要关联启用 Wiki 能力的知识库）',
@@ -1309,7 +1360,15 @@ export default {
       executeSkillScript: '执行技能脚本',
       dataAnalysis: '数据分析',
       dataSchema: '数据结构',
-      databaseQuery: '数据库查询'
+      dataChart: '绘制图表',
+      databaseQuery: '数据库查询',
+      sqlSchema: '读取表结构',
+      sqlQuery: 'SQL 查询',
+      browserNavigate: '打开网页',
+      browserClick: '点击页面元素',
+      browserFill: '填写表单',
+      browserExtract: '读取页面内容',
+      browserScreenshot: '页面截图'
     },
     mcpOAuth: {
       waiting: '等待授权 · {target}',
@@ -1964,6 +2023,8 @@ export default {
       documentsHint: '每个非空行会作为一个独立文档发送给 ReRank 模型',
       imageFile: '图片文件',
       audioFile: '音频文件',
+      ttsInput: '待合成文本',
+      ttsPlaceholder: '输入要合成为语音的文本',
       chooseFile: '选择文件',
       parameters: '请求参数',
       thinking: '思考模式',
@@ -1988,7 +2049,8 @@ export default {
         reasoningChars: '推理字符数',
         reasoningReturned: '返回推理内容',
         textChars: '转写字符数',
-        segmentCount: '分段数量'
+        segmentCount: '分段数量',
+        audioBytes: '音频大小'
       }
     },
     builtinModels: {
@@ -2020,6 +2082,11 @@ export default {
       desc: '配置用于语音识别和音频转录的语音转文本模型（如 OpenAI Whisper）',
       empty: '暂无 ASR 语音模型'
     },
+    tts: {
+      title: 'TTS 语音合成模型',
+      desc: '配置用于将文字回答合成为语音的文本转语音模型（如 OpenAI TTS）',
+      empty: '暂无 TTS 语音合成模型'
+    },
     vllm: {
       title: 'VLLM 视觉模型',
       desc: '配置用于视觉理解和多模态的视觉语言模型',
@@ -2054,7 +2121,8 @@ export default {
       embedding: 'Embedding',
       rerank: 'ReRank',
       vllm: '视觉',
-      asr: '语音'
+      asr: '语音',
+      tts: '语音合成'
     }
   },
   mcpSettings: {
@@ -2294,6 +2362,9 @@ export default {
       baseUrlPlaceholder: '例如：https://api.openai.com/v1',
       baseUrlPlaceholderVllm: '例如：http://localhost:11434/v1',
       baseUrlPlaceholderAsr: '例如：https://api.openai.com/v1',
+      ttsVoiceLabel: '发音人（可选）',
+      ttsVoicePlaceholder: '例如：alloy',
+      ttsVoiceDesc: '对应 /v1/audio/speech 的 voice 参数，留空时使用 alloy',
       apiKeyOptional: 'API Key（可选）',
       apiKeyPlaceholder: '输入 API Key',
       customHeadersLabel: '自定义请求头（可选）',
@@ -2488,7 +2559,8 @@ export default {
         remote: '例如：gpt-4, claude-3-opus',
         localVllm: '例如：llava:latest',
         remoteVllm: '例如：gpt-4-vision-preview',
-        remoteAsr: '例如：whisper-1'
+        remoteAsr: '例如：whisper-1',
+        remoteTts: '例如：tts-1'
       },
       description: {
         chat: '配置用于对话的大语言模型',
@@ -2496,6 +2568,7 @@ export default {
         rerank: '配置用于结果重排序的模型',
         vllm: '配置用于视觉理解和多模态的视觉语言模型',
         asr: '配置用于语音识别和音频转录的语音转文本模型',
+        tts: '配置用于语音回复的文本转语音模型',
         default: '配置模型信息'
       }
     }
@@ -3207,6 +3280,10 @@ export default {
     memoryForgotten: '已删除这条记忆',
     memoryForgetFailed: '删除失败',
     memoryHint: '这些是助手在回答时看到的长期记忆，删除后不会再被使用。',
+    memoryProvenance: {
+      workspace: '团队',
+      agent: '智能体'
+    },
     suggestedQuestions: '你可以这样问我',
     followUpQuestions: '继续问',
     followUpQuestionsLoading: '加载推荐问题',
@@ -3290,6 +3367,24 @@ export default {
     lengthTenThousands: '{value} 万字',
     noDatabaseRecords: '未找到匹配的记录',
     nullValuePlaceholder: '<NULL>',
+    dataChart: {
+      rows: '{rows} 个数据点',
+      attached: '已附加：{files}',
+      noPreview: '图表过大，无法在此预览，请在附件中查看',
+      exportSql: '导出 SQL',
+      exportNotebook: '导出 Notebook',
+      exportFailed: '导出失败'
+    },
+    sqlQuery: {
+      rowsIn: '{rows} 行 · {ms} ms',
+      truncated: '结果已截断',
+      chart: {
+        line: '折线图',
+        bar: '柱状图',
+        pie: '饼图',
+        scatter: '散点图'
+      }
+    },
     chunkCountValue: '{count} 个片段',
     documentDescriptionLabel: '文档描述:',
     documentSourceLabel: '来源:',
@@ -4616,6 +4711,12 @@ export default {
     enableLabel: '为我启用长期记忆',
     enableDescription: '关闭后助手不再读取或新增你的记忆，已有记忆会保留，重新开启即可继续使用。',
     agentDisabledHint: '单个智能体也可以单独关闭长期记忆。被关闭的智能体在对话中既不会读取你的记忆，也不会新增记忆；换用其他智能体不受影响。',
+    share: '共享给团队',
+    shareTitle: '提交到共享记忆',
+    shareHint: '提交的是一份副本，管理员审核通过后才会在其他成员的对话中使用；你自己的这条记忆不受影响。',
+    shareToWorkspace: '整个空间',
+    shareToAgent: '指定智能体',
+    shareAgentPlaceholder: '选择智能体',
     usage: {
       title: '记忆何时会被使用',
       iconHint: '查看哪些记忆会在对话里被使用',
@@ -4736,7 +4837,8 @@ export default {
     origins: {
       explicit: '你要求记住',
       extracted: '自动提炼',
-      manual: '手动添加'
+      manual: '手动添加',
+      promoted: '成员提交'
     },
     toasts: {
       enabled: '已为你开启长期记忆',
@@ -4745,6 +4847,7 @@ export default {
       updated: '已更新',
       deleted: '已删除',
       cleared: '已删除 {count} 条记忆',
+      shared: '已提交，等待管理员审核',
       saveFailed: '操作失败：{message}'
     }
   },
@@ -4785,6 +4888,39 @@ export default {
       saveFailed: '保存失败：{message}'
     }
   },
+  sharedMemorySettings: {
+    title: '共享记忆',
+    description: '对空间内所有成员（或某个智能体的所有对话）生效的记忆。成员可以把自己的记忆提交到这里，经你审核后生效；你也可以直接添加。',
+    add: '添加',
+    addTitle: '添加共享记忆',
+    addPlaceholder: '例如：线上变更需要先提工单',
+    expiresPlaceholder: '过期时间（留空表示永不过期）',
+    scopeWorkspace: '整个空间',
+    scopeAgent: '指定智能体',
+    agentPlaceholder: '选择智能体',
+    pickAgent: '先选择一个智能体。',
+    statusPending: '待审核',
+    statusActive: '生效中',
+    pendingEmpty: '没有待审核的提交。',
+    activeEmpty: '还没有共享记忆。',
+    proposedBy: '提交人 {who}',
+    reviewedBy: '审核人 {who}',
+    expired: '已过期',
+    expiresAt: '{time} 过期',
+    approve: '通过',
+    reject: '驳回',
+    expireNow: '立即过期',
+    deleteConfirm: '删除后所有成员的对话都不再使用这条记忆，确定删除？',
+    toasts: {
+      added: '已添加',
+      updated: '已更新',
+      approved: '已通过',
+      rejected: '已驳回',
+      expired: '已设为过期',
+      deleted: '已删除',
+      failed: '操作失败：{message}'
+    }
+  },
   chatHistorySettings: {
     title: '消息管理',
     description: '配置聊天历史知识库，将对话消息自动向量化索引，实现语义搜索',
@@ -4837,6 +4973,109 @@ export default {
       indexNamePattern: '必须以字母开头，仅允许字母、数字、下划线和连字符（最多128个字符）'
     }
   },
+  browserConfigSettings: {
+    title: '浏览器服务',
+    description: '登记通过 Chrome DevTools 协议访问的无头浏览器（如 Chromium 远程调试端口、browserless），智能体可以在对话中打开网页、点击、填写表单、读取内容和截图。内网与元数据地址始终会被拦截。',
+    listTitle: '浏览器服务',
+    add: '添加浏览器服务',
+    edit: '编辑浏览器服务',
+    empty: '还没有浏览器服务。',
+    deleteConfirm: '确定要删除此浏览器服务吗？使用它的智能体将不再能操作浏览器，已打开的页面会被关闭。',
+    basicSection: '基本信息',
+    endpointSection: '浏览器服务',
+    limitsSection: '页面限制',
+    nameLabel: '名称',
+    namePlaceholder: '例如：共享 Chromium',
+    descriptionLabel: '说明',
+    descriptionPlaceholder: '这个浏览器服务的用途',
+    endpointLabel: 'DevTools 地址',
+    endpointHelp: 'http(s)://主机:9222（自动读取 /json/version）或浏览器 WebSocket 地址 ws(s)://...',
+    tokenLabel: '访问令牌',
+    tokenPlaceholder: '可选，以 Bearer 令牌发送给浏览器服务',
+    allowPrivateLabel: '允许内网地址',
+    allowPrivateHelp: '仅用于部署在同一网络内的浏览器容器；只影响浏览器服务本身的地址，智能体打开的网页仍不能访问内网。',
+    allowedDomainsLabel: '允许访问的网站',
+    allowedDomainsPlaceholder: '输入域名后回车，如 example.com',
+    allowedDomainsHelp: '包含子域名；留空表示允许任意公网网站。',
+    anyPublicSite: '任意公网网站',
+    timeoutLabel: '操作超时（秒）',
+    maxTextCharsLabel: '单次读取最大字符数',
+    viewportWidthLabel: '视口宽度',
+    viewportHeightLabel: '视口高度',
+    test: '测试连接',
+    testing: '测试中...',
+    validation: {
+      nameRequired: '名称为必填项',
+      endpointRequired: 'DevTools 地址为必填项'
+    },
+    toasts: {
+      created: '浏览器服务已创建',
+      updated: '浏览器服务已更新',
+      deleted: '浏览器服务已删除',
+      saveFailed: '保存失败',
+      deleteFailed: '删除失败',
+      testSuccess: '连接测试成功',
+      testFailed: '连接测试失败'
+    }
+  },
+  sqlConnectionSettings: {
+    title: '外部数据库',
+    description: '登记只读的外部数据库（PostgreSQL、MySQL、SQLite、DuckDB），智能体可以读取表结构并编写 SQL 查询，结果以表格和图表建议的形式返回。',
+    listTitle: '数据库连接',
+    add: '添加数据库',
+    edit: '编辑数据库',
+    empty: '还没有外部数据库连接。',
+    deleteConfirm: '确定要删除此数据库连接吗？使用它的智能体将不再能查询该数据库。',
+    basicSection: '基本信息',
+    connectionSection: '连接配置',
+    limitsSection: '查询限制',
+    schemaSection: '表结构与字段说明',
+    dialectLabel: '数据库类型',
+    nameLabel: '名称',
+    namePlaceholder: '例如：销售数据仓库',
+    descriptionLabel: '说明',
+    descriptionPlaceholder: '这个库里有什么数据，适合回答哪些问题',
+    descriptionHelp: '说明会展示给智能体，帮助它判断何时查询这个数据库。',
+    hostLabel: '主机',
+    portLabel: '端口',
+    databaseLabel: '数据库名',
+    usernameLabel: '用户名',
+    usernameHelp: '请使用只授予 SELECT 权限的账号，这是最可靠的只读保障。',
+    passwordLabel: '密码',
+    passwordPlaceholder: '请输入数据库密码',
+    sslModeLabel: 'SSL 模式',
+    filePathLabel: '文件路径',
+    filePathHelp: '服务器上的绝对路径，必须位于 WEKNORA_SQL_FILE_DIRS 配置的目录内。DuckDB 也可以直接指向 CSV / Parquet 文件。',
+    maxRowsLabel: '最大返回行数',
+    timeoutLabel: '查询超时（秒）',
+    includeTablesLabel: '展示给智能体的表',
+    includeTablesPlaceholder: '输入表名后回车，留空表示全部',
+    includeTablesHelp: '只影响智能体看到的表结构，不是权限边界；需要隔离的数据请通过数据库账号授权控制。',
+    schemaHelp: '读取表结构后可以为表和字段补充业务说明，智能体写 SQL 时会参考这些说明。保存后生效。',
+    loadSchema: '读取表结构',
+    reloadSchema: '重新读取',
+    noTables: '没有找到可用的表。',
+    columnCount: '{count} 个字段',
+    tableDescriptionLabel: '表说明',
+    tableDescriptionPlaceholder: '例如：每行是一笔订单',
+    columnDescriptionPlaceholder: '字段说明（可选）',
+    schemaTruncated: '表数量较多，仅显示前 200 张表。',
+    test: '测试连接',
+    testing: '测试中...',
+    validation: {
+      nameRequired: '名称为必填项'
+    },
+    toasts: {
+      created: '数据库连接已创建',
+      updated: '数据库连接已更新',
+      deleted: '数据库连接已删除',
+      saveFailed: '保存失败',
+      deleteFailed: '删除失败',
+      testSuccess: '连接测试成功',
+      testFailed: '连接测试失败',
+      schemaFailed: '读取表结构失败'
+    }
+  },
   webSearchSettings: {
     title: '网络搜索配置',
     description: '配置网络搜索功能，在回答问题时可以从互联网获取实时信息补充知识库内容',
@@ -4878,6 +5117,8 @@ export default {
   settings: {
     modelManagement: '模型管理',
     webSearchConfig: '网络搜索',
+    sqlConnections: '外部数据库',
+    browserConfigs: '浏览器服务',
     autoCheckUpdate: '自动下载更新',
     autoCheckUpdateDesc: '开启后自动检查并在后台下载最新版本安装包。',
     vectorStoreEngine: '向量数据库引擎',
@@ -4909,6 +5150,8 @@ export default {
         cube: '适合私有化或内网部署的自建 MicroVM 集群',
         e2b: 'E2B 托管服务或兼容 E2B 的集群',
         docker: '每次在当前 WeKnora 主机启动一个短生命周期容器执行脚本',
+        namespace: '在当前 WeKnora 主机上通过 Linux 命名空间与 seccomp 隔离执行脚本，无需 Docker 守护进程',
+        wasm: '在 WeKnora 进程内用 WebAssembly 运行 Python 与 JavaScript 脚本，无需 Docker，也不限于 Linux',
         local: '直接在 WeKnora 服务进程所在环境执行脚本',
       },
       addConfig: '添加沙箱后端',
@@ -4974,6 +5217,18 @@ export default {
       cardPrivateEndpoints: '允许访问私网',
       imageNotConfigured: '未配置镜像',
       localRuntimeSummary: 'WeKnora 服务器本地进程',
+      namespaceRuntimeSummary: 'WeKnora 主机上的隔离命名空间',
+      namespaceHostHint: '沙箱直接运行在 WeKnora 主机上。根文件系统、状态目录和 cgroup 属于主机配置（WEKNORA_NS_SANDBOX_* 环境变量），并且服务二进制旁需要有 weknora-sandbox-init 辅助程序。',
+      namespaceAllowNetwork: '允许访问网络',
+      namespaceAllowNetworkHint: '默认关闭：脚本只能看到私有的回环网卡。开启后与主机共享网络。',
+      namespaceTtlHelp: '沙箱空闲多久后回收其工作区文件。后台进程不会在单次执行结束后继续存活。',
+      namespaceMemory: '内存上限（MB）',
+      namespaceCpu: 'CPU 上限（核）',
+      namespacePids: '进程数上限',
+      namespaceLimitsHelp: '仅当主机配置了 WEKNORA_NS_SANDBOX_CGROUP_ROOT 时通过 cgroup v2 强制生效，否则只应用单进程资源限制。留空使用默认值。',
+      wasmRuntimeSummary: 'WeKnora 进程内的 WebAssembly 运行时',
+      wasmHostHint: '脚本在 WeKnora 进程内由 WebAssembly（WASI）解释器执行：仅支持 Python 和 JavaScript，Skill 目录只读，/tmp 为每次执行独立的临时目录，且无法访问网络。解释器运行时属于主机配置（WEKNORA_WASM_SANDBOX_DIR，或服务二进制旁的 wasm-runtimes 目录）。',
+      wasmLimitsHelp: '限制每个脚本可用的内存；下方的默认超时同时约束 CPU 时间。留空使用默认值。',
       templateApplied: '已使用',
       refreshTemplates: '刷新模板',
       templateSelectHelp: '模板由当前集群实时返回，保存时仅记录模板 ID，无需手工复制。',
@@ -5020,6 +5275,8 @@ export default {
         docker: 'Docker',
         cube: 'CubeSandbox',
         e2b: 'E2B',
+        namespace: 'Linux 命名空间',
+        wasm: 'WebAssembly',
       },
       apiUrl: 'API 端点',
       proxyUrl: 'Proxy 端点',
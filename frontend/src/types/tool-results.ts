@@ -18,6 +18,8 @@ export type DisplayType =
     | 'thinking'
     | 'plan'
     | 'database_query'
+    | 'sql_query'
+    | 'data_chart'
     | 'web_search_results'
     | 'web_fetch_results'
     | 'grep_results'
@@ -175,6 +177,42 @@ export interface DatabaseQueryData {
     row_count: number;
 }
 
+// Chart suggested by the sql_query tool from the shape of its result
+export interface SQLChartSuggestion {
+    type: 'line' | 'bar' | 'pie' | 'scatter';
+    x: string;
+    y: string[];
+    reason: string;
+}
+
+// External SQL connection query data
+export interface SQLQueryData {
+    display_type: 'sql_query';
+    connection_id: string;
+    connection_name: string;
+    sql: string;
+    columns: string[];
+    rows: Array<Record<string, any>>;
+    row_count: number;
+    truncated: boolean;
+    duration_ms: number;
+    chart_suggestions?: SQLChartSuggestion[];
+}
+
+// Chart rendered by the data_chart tool. svg is omitted when the rendering
+// is too large to inline; the files in artifacts are attached to the answer.
+export interface DataChartData {
+    display_type: 'data_chart';
+    chart_type: 'bar' | 'line' | 'area' | 'scatter' | 'pie';
+    title: string;
+    query: string;
+    row_count: number;
+    spec: Record<string, any>;
+    artifacts?: string[];
+    session_id?: string;
+    svg?: string;
+}
+
 // Web search result item
 export interface WebSearchResultItem {
     result_index: number;
@@ -335,6 +373,7 @@ export type ToolResultData =
     | ThinkingData
     | PlanData
     | DatabaseQueryData
+    | DataChartData
     | WebSearchResultsData
     | WebFetchResultsData
     | GrepResultsData
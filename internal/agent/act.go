@@ -15,7 +15,11 @@ import (
 	"github.com/Tencent/WeKnora/internal/logger"
 	"github.com/Tencent/WeKnora/internal/modelcontext"
 	"github.com/Tencent/WeKnora/internal/tracing/langfuse"
+	"github.com/Tencent/WeKnora/internal/tracing/otlp"
 	"github.com/Tencent/WeKnora/internal/types"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 	"golang.org/x/sync/errgroup"
 )
 
@@ -168,14 +172,21 @@ var toolDisplayNames = map[string]string{
 	agenttools.ToolKnowledgeSearch:     "知识搜索",
 	agenttools.ToolListKnowledgeChunks: "查看文档分块",
 	agenttools.ToolQueryKnowledgeGraph: "查询知识图谱",
+	agenttools.ToolGraphGlobalSearch:   "图谱全局检索",
 	agenttools.ToolGetDocumentInfo:     "获取文档信息",
 	agenttools.ToolSearchConversations: "回顾历史对话",
 	agenttools.ToolSearchMemory:        "查询长期记忆",
 	agenttools.ToolDatabaseQuery:       "查询数据",
 	agenttools.ToolDataAnalysis:        "数据分析",
 	agenttools.ToolDataSchema:          "查看数据结构",
+	agenttools.ToolDataChart:           "绘制图表",
 	agenttools.ToolWebSearch:           "搜索网页",
 	agenttools.ToolWebFetch:            "获取网页",
+	agenttools.ToolBrowserNavigate:     "打开网页",
+	agenttools.ToolBrowserClick:        "点击页面元素",
+	agenttools.ToolBrowserFill:         "填写表单",
+	agenttools.ToolBrowserExtract:      "读取页面内容",
+	agenttools.ToolBrowserScreenshot:   "页面截图",
 	agenttools.ToolExecuteSkillScript:  "执行技能脚本",
 	agenttools.ToolReadSkill:           "读取技能",
 	agenttools.ToolListSandboxFiles:    "列出沙箱文件",
@@ -187,6 +198,8 @@ var toolDisplayNames = map[string]string{
 // (e.g., database_query exposes raw SQL which leaks implementation details).
 var toolHintSensitiveArgs = map[string]bool{
 	agenttools.ToolDatabaseQuery: true,
+	// browser_fill values are often passwords or other credentials.
+	agenttools.ToolBrowserFill: true,
 }
 
 // formatToolHint returns a concise human-readable hint for a tool call, e.g. `搜索网页("query text")`.
@@ -459,6 +472,11 @@ func (e *AgentEngine) runToolCall(
 		},
 	})
 
+	toolCtx, otlpTool := otlp.Start(toolCtx, "agent.tool "+tc.Function.Name, trace.WithAttributes(
+		attribute.String("weknora.agent.tool", tc.Function.Name),
+		attribute.Int("weknora.agent.round", round),
+	))
+
 	principal, _ := types.PrincipalFromContext(ctx)
 	execTimeout := toolExecutionTimeout(tc.Function.Name)
 	toolExecCtx := agenttools.WithToolExecContext(toolCtx, &agenttools.ToolExecContext{
@@ -515,7 +533,15 @@ func (e *AgentEngine) runToolCall(
 			toolTag, duration, success, outputLen)
 	}
 
+	if e.toolAuditor != nil {
+		e.toolAuditor(ctx, sessionID, &toolCall)
+	}
+
 	finishToolSpan(toolSpan, toolCall, err, duration)
+	if err == nil && toolCall.Result != nil && !toolCall.Result.Success {
+		otlpTool.SetStatus(codes.Error, toolCall.Result.Error)
+	}
+	otlp.End(otlpTool, err)
 
 	// Pipeline event for monitoring
 	toolSuccess := toolCall.Result != nil && toolCall.Result.Success
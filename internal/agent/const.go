@@ -28,6 +28,10 @@ const (
 	// 600-second command timeout so the tool can return a structured timeout
 	// result instead of being cancelled first by the generic agent wrapper.
 	shellExecToolTimeout = 10*time.Minute + 5*time.Second
+	// browserToolTimeout covers a browser action at the longest per-config
+	// timeout (120s) plus opening the page, which the browser session bounds
+	// at twice that.
+	browserToolTimeout = 4*time.Minute + 5*time.Second
 
 	// maxLLMRetries is the maximum number of retries for transient LLM errors.
 	maxLLMRetries = 2
@@ -50,6 +54,9 @@ func toolExecutionTimeout(toolName string) time.Duration {
 	if toolName == "shell_exec" {
 		return shellExecToolTimeout
 	}
+	if strings.HasPrefix(toolName, "browser_") {
+		return browserToolTimeout
+	}
 	return defaultToolExecTimeout
 }
 
@@ -18,7 +18,10 @@ import (
 	"github.com/Tencent/WeKnora/internal/modelcontext"
 	"github.com/Tencent/WeKnora/internal/models/chat"
 	"github.com/Tencent/WeKnora/internal/tracing/langfuse"
+	"github.com/Tencent/WeKnora/internal/tracing/otlp"
 	"github.com/Tencent/WeKnora/internal/types"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // langfuseQueryPreview caps the query length we ship as the agent.execute
@@ -47,6 +50,7 @@ type AgentEngine struct {
 	skillsManager        *skills.Manager           // Skills manager for Progressive Disclosure (optional)
 	appConfig            *appconfig.Config         // Application config for prompt template resolution (optional)
 	imageDescriber       ImageDescriberFunc        // VLM function for describing images in tool results (optional)
+	toolAuditor          ToolAuditFunc             // Records each finished tool execution (optional)
 	tokenEstimator       *agenttoken.Estimator     // Token estimator for context window management
 	memoryConsolidator   *agentmemory.Consolidator // Memory consolidator for LLM-powered summarization (optional)
 	lastUsage            types.TokenUsage          // Token usage from the most recent LLM call
@@ -58,6 +62,11 @@ type AgentEngine struct {
 // Signature matches vlm.VLM.Predict so it can be injected without importing the vlm package.
 type ImageDescriberFunc func(ctx context.Context, imgBytes []byte, prompt string) (string, error)
 
+// ToolAuditFunc records one finished tool execution. call.Result is always
+// set, carrying the error text when the tool failed. It runs on the agent's
+// goroutine, so implementations must be quick and must not fail the turn.
+type ToolAuditFunc func(ctx context.Context, sessionID string, call *types.ToolCall)
+
 // NewAgentEngine creates a new agent engine
 func NewAgentEngine(
 	config *types.AgentConfig,
@@ -179,6 +188,11 @@ func (e *AgentEngine) SetImageDescriber(fn ImageDescriberFunc) {
 	e.imageDescriber = fn
 }
 
+// SetToolAuditor sets the hook that writes each tool execution to the audit log.
+func (e *AgentEngine) SetToolAuditor(fn ToolAuditFunc) {
+	e.toolAuditor = fn
+}
+
 // SetSkillsManager sets the skills manager for the engine
 func (e *AgentEngine) SetSkillsManager(manager *skills.Manager) {
 	e.skillsManager = manager
@@ -255,6 +269,11 @@ func (e *AgentEngine) Execute(
 		},
 	})
 	ctx = spanCtx
+	ctx, execSpan := otlp.Start(ctx, "agent.execute", trace.WithAttributes(
+		attribute.String("weknora.session_id", sessionID),
+		attribute.String("weknora.message_id", messageID),
+		attribute.Int("weknora.agent.max_iterations", e.config.MaxIterations),
+	))
 
 	// Initialize state
 	state := &types.AgentState{
@@ -301,6 +320,7 @@ func (e *AgentEngine) Execute(
 			},
 		})
 		finishAgentSpan(agentSpan, state, err)
+		otlp.End(execSpan, err)
 		return nil, err
 	}
 
@@ -313,6 +333,8 @@ func (e *AgentEngine) Execute(
 		"complete":   state.IsComplete,
 	})
 	finishAgentSpan(agentSpan, state, nil)
+	execSpan.SetAttributes(attribute.Int("weknora.agent.rounds", state.CurrentRound))
+	otlp.End(execSpan, nil)
 	return state, nil
 }
 
@@ -498,6 +520,14 @@ func (e *AgentEngine) runReActIteration(
 		response      *types.ChatResponse
 		toolCallCount int
 	)
+	ctx, otlpRound := otlp.Start(ctx, "agent.round", trace.WithAttributes(attribute.Int("weknora.agent.round", round)))
+	defer func() {
+		otlpRound.SetAttributes(
+			attribute.String("weknora.agent.outcome", outcome.String()),
+			attribute.Int("weknora.agent.tool_calls", toolCallCount),
+		)
+		otlp.End(otlpRound, retErr)
+	}()
 	defer func() {
 		if roundSpan == nil {
 			return
@@ -674,6 +674,7 @@ var kbToolNames = map[string]bool{
 	agenttools.ToolGrepChunks:          true,
 	agenttools.ToolListKnowledgeChunks: true,
 	agenttools.ToolQueryKnowledgeGraph: true,
+	agenttools.ToolGraphGlobalSearch:   true,
 	agenttools.ToolGetDocumentInfo:     true,
 	agenttools.ToolWikiSearch:          true,
 	agenttools.ToolWikiReadPage:        true,
@@ -0,0 +1,389 @@
+package tools
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/infrastructure/browser"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/utils"
+)
+
+// maxInlineScreenshotBytes caps the screenshot handed back to the model as an
+// image. Larger captures are still attached as artifacts.
+const maxInlineScreenshotBytes = 2 * 1024 * 1024
+
+var browserNavigateTool = BaseTool{
+	name: ToolBrowserNavigate,
+	description: `Open a web page in an interactive browser that keeps its state (cookies, login, history) for the rest of this conversation.
+
+## Usage
+- Use this instead of web_fetch when the page needs interaction: logging in, paging through results, clicking tabs or filling forms
+- After navigating, call browser_extract to read the page and get selectors for its links, buttons and inputs
+- Only http(s) URLs on public addresses are allowed; requests to private or internal addresses are blocked`,
+	schema: utils.GenerateSchema[BrowserNavigateInput](),
+}
+
+var browserClickTool = BaseTool{
+	name: ToolBrowserClick,
+	description: `Click an element on the current browser page.
+
+## Usage
+- Prefer the selectors returned by browser_extract (e.g. [data-weknora-ref="12"]); any CSS selector works
+- Returns the page URL and title after the click; call browser_extract again to see what changed`,
+	schema: utils.GenerateSchema[BrowserClickInput](),
+}
+
+var browserFillTool = BaseTool{
+	name: ToolBrowserFill,
+	description: `Type a value into an input, textarea or select on the current browser page.
+
+## Usage
+- Use the selectors returned by browser_extract
+- Set submit to true to submit the surrounding form after filling, e.g. for a search box or the last field of a login form
+- Never invent credentials; only enter values the user provided`,
+	schema: utils.GenerateSchema[BrowserFillInput](),
+}
+
+var browserExtractTool = BaseTool{
+	name: ToolBrowserExtract,
+	description: `Read the visible text of the current browser page, or of the element matching a selector, together with its links, buttons and inputs.
+
+## Usage
+- Every interactive element comes with a selector that browser_click and browser_fill accept
+- Long pages are truncated; pass a selector to read a specific section`,
+	schema: utils.GenerateSchema[BrowserExtractInput](),
+}
+
+var browserScreenshotTool = BaseTool{
+	name: ToolBrowserScreenshot,
+	description: `Take a PNG screenshot of the current browser page.
+
+## Usage
+- Use it when the layout matters or the user asks to see the page; browser_extract is cheaper for reading text
+- The screenshot is attached to the answer, so do not describe how to take it yourself`,
+	schema: utils.GenerateSchema[BrowserScreenshotInput](),
+}
+
+// BrowserNavigateInput defines the input parameters for browser_navigate
+type BrowserNavigateInput struct {
+	URL string `json:"url" jsonschema:"http(s) URL to open"`
+}
+
+// BrowserClickInput defines the input parameters for browser_click
+type BrowserClickInput struct {
+	Selector string `json:"selector" jsonschema:"CSS selector of the element to click, preferably one returned by browser_extract"`
+}
+
+// BrowserFillInput defines the input parameters for browser_fill
+type BrowserFillInput struct {
+	Selector string `json:"selector" jsonschema:"CSS selector of the input, textarea or select"`
+	Value    string `json:"value" jsonschema:"value to enter; for a select, the option value or its visible text"`
+	Submit   bool   `json:"submit,omitempty" jsonschema:"submit the surrounding form after filling"`
+}
+
+// BrowserExtractInput defines the input parameters for browser_extract
+type BrowserExtractInput struct {
+	Selector string `json:"selector,omitempty" jsonschema:"optional CSS selector to read only part of the page"`
+}
+
+// BrowserScreenshotInput defines the input parameters for browser_screenshot
+type BrowserScreenshotInput struct {
+	FullPage bool `json:"full_page,omitempty" jsonschema:"capture the whole scrollable page instead of the visible viewport"`
+}
+
+// browserTool holds what every browser_* tool shares: the page registry, the
+// resolved browser config and the chat session whose page is driven.
+type browserTool struct {
+	BaseTool
+	sessions  *browser.Sessions
+	config    *types.BrowserConfig
+	sessionID string
+}
+
+func newBrowserTool(base BaseTool, sessions *browser.Sessions, cfg *types.BrowserConfig, sessionID string) browserTool {
+	return browserTool{BaseTool: base, sessions: sessions, config: cfg, sessionID: sessionID}
+}
+
+// do runs fn on the chat session's page for the configured browser.
+func (t *browserTool) do(ctx context.Context, fn func(context.Context, *browser.Page) error) error {
+	if t.sessions == nil || t.config == nil {
+		return fmt.Errorf("no browser service is configured for this agent")
+	}
+	return t.sessions.Do(ctx, browser.SessionKey(t.config.ID, t.sessionID), browser.OptionsFromConfig(t.config), fn)
+}
+
+// failure converts an error into a failed tool result.
+func (t *browserTool) failure(ctx context.Context, err error) (*types.ToolResult, error) {
+	logger.Warnf(ctx, "[Tool][%s] Failed for session %s: %v", t.name, t.sessionID, err)
+	return &types.ToolResult{Success: false, Error: err.Error()}, err
+}
+
+// stateResult renders the page state after navigate, click or fill.
+func (t *browserTool) stateResult(action string, state *browser.PageState) *types.ToolResult {
+	var output strings.Builder
+	output.WriteString(fmt.Sprintf("=== %s ===\n\n", action))
+	output.WriteString(fmt.Sprintf("URL: %s\nTitle: %s\n", state.URL, state.Title))
+	if !state.Loaded {
+		output.WriteString("The page had not finished loading when the timeout expired; it may still be usable.\n")
+	}
+	if len(state.Blocked) > 0 {
+		output.WriteString(fmt.Sprintf("Blocked %d request(s) to disallowed addresses, e.g. %s\n", len(state.Blocked), state.Blocked[0]))
+	}
+	output.WriteString("\nCall browser_extract to read the page.\n")
+	return &types.ToolResult{
+		Success: true,
+		Output:  output.String(),
+		Data: map[string]interface{}{
+			"display_type": t.name,
+			"url":          state.URL,
+			"title":        state.Title,
+			"loaded":       state.Loaded,
+			"blocked":      state.Blocked,
+		},
+	}
+}
+
+func parseBrowserArgs(args json.RawMessage, input interface{}) (*types.ToolResult, error) {
+	if err := json.Unmarshal(args, input); err != nil {
+		return &types.ToolResult{
+			Success: false,
+			Error:   fmt.Sprintf("Failed to parse input args: %v", err),
+		}, err
+	}
+	return nil, nil
+}
+
+// BrowserNavigateTool opens a URL in the session's browser page.
+type BrowserNavigateTool struct{ browserTool }
+
+// NewBrowserNavigateTool creates a browser_navigate tool. cfg is resolved
+// under the agent's tenant by the caller.
+func NewBrowserNavigateTool(sessions *browser.Sessions, cfg *types.BrowserConfig, sessionID string) *BrowserNavigateTool {
+	return &BrowserNavigateTool{newBrowserTool(browserNavigateTool, sessions, cfg, sessionID)}
+}
+
+// Execute executes the tool logic
+func (t *BrowserNavigateTool) Execute(ctx context.Context, args json.RawMessage) (*types.ToolResult, error) {
+	var input BrowserNavigateInput
+	if res, err := parseBrowserArgs(args, &input); err != nil {
+		return res, err
+	}
+	rawURL := strings.TrimSpace(input.URL)
+	if rawURL == "" {
+		return t.failure(ctx, fmt.Errorf("url is required"))
+	}
+	var state *browser.PageState
+	err := t.do(ctx, func(ctx context.Context, page *browser.Page) error {
+		var err error
+		state, err = page.Navigate(ctx, rawURL)
+		return err
+	})
+	if err != nil {
+		return t.failure(ctx, err)
+	}
+	logger.Infof(ctx, "[Tool][BrowserNavigate] Session %s opened %s", t.sessionID, state.URL)
+	return t.stateResult("Page Opened", state), nil
+}
+
+// BrowserClickTool clicks an element on the session's browser page.
+type BrowserClickTool struct{ browserTool }
+
+// NewBrowserClickTool creates a browser_click tool.
+func NewBrowserClickTool(sessions *browser.Sessions, cfg *types.BrowserConfig, sessionID string) *BrowserClickTool {
+	return &BrowserClickTool{newBrowserTool(browserClickTool, sessions, cfg, sessionID)}
+}
+
+// Execute executes the tool logic
+func (t *BrowserClickTool) Execute(ctx context.Context, args json.RawMessage) (*types.ToolResult, error) {
+	var input BrowserClickInput
+	if res, err := parseBrowserArgs(args, &input); err != nil {
+		return res, err
+	}
+	if strings.TrimSpace(input.Selector) == "" {
+		return t.failure(ctx, fmt.Errorf("selector is required"))
+	}
+	var state *browser.PageState
+	err := t.do(ctx, func(ctx context.Context, page *browser.Page) error {
+		var err error
+		state, err = page.Click(ctx, input.Selector)
+		return err
+	})
+	if err != nil {
+		return t.failure(ctx, err)
+	}
+	return t.stateResult("Clicked", state), nil
+}
+
+// BrowserFillTool enters a value into a form field on the session's page.
+type BrowserFillTool struct{ browserTool }
+
+// NewBrowserFillTool creates a browser_fill tool.
+func NewBrowserFillTool(sessions *browser.Sessions, cfg *types.BrowserConfig, sessionID string) *BrowserFillTool {
+	return &BrowserFillTool{newBrowserTool(browserFillTool, sessions, cfg, sessionID)}
+}
+
+// Execute executes the tool logic
+func (t *BrowserFillTool) Execute(ctx context.Context, args json.RawMessage) (*types.ToolResult, error) {
+	var input BrowserFillInput
+	if res, err := parseBrowserArgs(args, &input); err != nil {
+		return res, err
+	}
+	if strings.TrimSpace(input.Selector) == "" {
+		return t.failure(ctx, fmt.Errorf("selector is required"))
+	}
+	var state *browser.PageState
+	err := t.do(ctx, func(ctx context.Context, page *browser.Page) error {
+		var err error
+		state, err = page.Fill(ctx, input.Selector, input.Value, input.Submit)
+		return err
+	})
+	if err != nil {
+		return t.failure(ctx, err)
+	}
+	action := "Filled"
+	if input.Submit {
+		action = "Filled and Submitted"
+	}
+	return t.stateResult(action, state), nil
+}
+
+// BrowserExtractTool reads text and interactive elements from the page.
+type BrowserExtractTool struct{ browserTool }
+
+// NewBrowserExtractTool creates a browser_extract tool.
+func NewBrowserExtractTool(sessions *browser.Sessions, cfg *types.BrowserConfig, sessionID string) *BrowserExtractTool {
+	return &BrowserExtractTool{newBrowserTool(browserExtractTool, sessions, cfg, sessionID)}
+}
+
+// Execute executes the tool logic
+func (t *BrowserExtractTool) Execute(ctx context.Context, args json.RawMessage) (*types.ToolResult, error) {
+	var input BrowserExtractInput
+	if res, err := parseBrowserArgs(args, &input); err != nil {
+		return res, err
+	}
+	var content *browser.PageContent
+	err := t.do(ctx, func(ctx context.Context, page *browser.Page) error {
+		var err error
+		content, err = page.Extract(ctx, input.Selector, t.config.Options.EffectiveMaxTextChars())
+		return err
+	})
+	if err != nil {
+		return t.failure(ctx, err)
+	}
+
+	var output strings.Builder
+	output.WriteString("=== Page Content ===\n\n")
+	output.WriteString(fmt.Sprintf("URL: %s\nTitle: %s\n\n", content.URL, content.Title))
+	output.WriteString(content.Text)
+	if content.Truncated {
+		output.WriteString("\n\n[Text truncated; pass a selector to read a specific section.]")
+	}
+	output.WriteString("\n\n=== Interactive Elements ===\n\n")
+	if len(content.Elements) == 0 {
+		output.WriteString("None found.\n")
+	}
+	for _, el := range content.Elements {
+		output.WriteString(fmt.Sprintf("- %s <%s", el.Selector, el.Tag))
+		if el.Type != "" {
+			output.WriteString(fmt.Sprintf(" type=%s", el.Type))
+		}
+		if el.Name != "" {
+			output.WriteString(fmt.Sprintf(" name=%s", el.Name))
+		}
+		output.WriteString(">")
+		if el.Text != "" {
+			output.WriteString(fmt.Sprintf(" %q", el.Text))
+		}
+		if el.Href != "" {
+			output.WriteString(fmt.Sprintf(" -> %s", el.Href))
+		}
+		output.WriteString("\n")
+	}
+	return &types.ToolResult{
+		Success: true,
+		Output:  output.String(),
+		Data: map[string]interface{}{
+			"display_type":  ToolBrowserExtract,
+			"url":           content.URL,
+			"title":         content.Title,
+			"truncated":     content.Truncated,
+			"element_count": len(content.Elements),
+		},
+	}, nil
+}
+
+// BrowserScreenshotTool captures the page and stages it as an artifact.
+type BrowserScreenshotTool struct {
+	browserTool
+	stager ArtifactStager
+}
+
+// NewBrowserScreenshotTool creates a browser_screenshot tool. stager may be
+// nil, in which case the screenshot is only returned to the model.
+func NewBrowserScreenshotTool(
+	sessions *browser.Sessions, cfg *types.BrowserConfig, sessionID string, stager ArtifactStager,
+) *BrowserScreenshotTool {
+	return &BrowserScreenshotTool{
+		browserTool: newBrowserTool(browserScreenshotTool, sessions, cfg, sessionID),
+		stager:      stager,
+	}
+}
+
+// Execute executes the tool logic
+func (t *BrowserScreenshotTool) Execute(ctx context.Context, args json.RawMessage) (*types.ToolResult, error) {
+	var input BrowserScreenshotInput
+	if res, err := parseBrowserArgs(args, &input); err != nil {
+		return res, err
+	}
+	var png []byte
+	var state *browser.PageState
+	err := t.do(ctx, func(ctx context.Context, page *browser.Page) error {
+		var err error
+		if state, err = page.State(ctx); err != nil {
+			return err
+		}
+		png, err = page.Screenshot(ctx, input.FullPage)
+		return err
+	})
+	if err != nil {
+		return t.failure(ctx, err)
+	}
+
+	fileName := fmt.Sprintf("screenshot_%s.png", time.Now().Format("20060102_150405"))
+	var attached []string
+	if t.stager != nil {
+		if err := t.stager.StageArtifact(ctx, t.sessionID, fileName, png); err != nil {
+			logger.Warnf(ctx, "[Tool][BrowserScreenshot] Failed to stage %s for session %s: %v", fileName, t.sessionID, err)
+		} else {
+			attached = append(attached, fileName)
+		}
+	}
+
+	var output strings.Builder
+	output.WriteString("=== Screenshot Taken ===\n\n")
+	output.WriteString(fmt.Sprintf("URL: %s\nTitle: %s\nSize: %d bytes\n", state.URL, state.Title, len(png)))
+	if len(attached) > 0 {
+		output.WriteString(fmt.Sprintf("Attached to the answer: %s.\n", fileName))
+	}
+	result := &types.ToolResult{
+		Success: true,
+		Output:  output.String(),
+		Data: map[string]interface{}{
+			"display_type": ToolBrowserScreenshot,
+			"url":          state.URL,
+			"title":        state.Title,
+			"artifacts":    attached,
+			"session_id":   t.sessionID,
+		},
+	}
+	if len(png) <= maxInlineScreenshotBytes {
+		result.Images = []string{"data:image/png;base64," + base64.StdEncoding.EncodeToString(png)}
+	}
+	return result, nil
+}
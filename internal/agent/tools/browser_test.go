@@ -0,0 +1,56 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/infrastructure/browser"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBrowserTools_RejectMissingArguments(t *testing.T) {
+	sessions := browser.NewSessions()
+	cfg := &types.BrowserConfig{ID: "cfg", Parameters: types.BrowserConfigParameters{EndpointURL: "http://127.0.0.1:1"}}
+
+	cases := []struct {
+		name string
+		tool types.Tool
+		args string
+		want string
+	}{
+		{"navigate", NewBrowserNavigateTool(sessions, cfg, "s1"), `{"url":"  "}`, "url is required"},
+		{"click", NewBrowserClickTool(sessions, cfg, "s1"), `{}`, "selector is required"},
+		{"fill", NewBrowserFillTool(sessions, cfg, "s1"), `{"value":"x"}`, "selector is required"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := tc.tool.Execute(context.Background(), json.RawMessage(tc.args))
+			require.Error(t, err)
+			require.False(t, res.Success)
+			require.Contains(t, res.Error, tc.want)
+		})
+	}
+}
+
+func TestBrowserTools_WithoutConfigFailCleanly(t *testing.T) {
+	for _, tool := range []types.Tool{
+		NewBrowserNavigateTool(browser.NewSessions(), nil, "s1"),
+		NewBrowserExtractTool(nil, &types.BrowserConfig{ID: "cfg"}, "s1"),
+		NewBrowserScreenshotTool(nil, nil, "s1", nil),
+	} {
+		res, err := tool.Execute(context.Background(), json.RawMessage(`{"url":"https://example.com"}`))
+		require.Error(t, err, tool.Name())
+		require.False(t, res.Success)
+		require.Contains(t, res.Error, "no browser service is configured")
+	}
+}
+
+func TestBrowserTools_Names(t *testing.T) {
+	require.Equal(t, ToolBrowserNavigate, NewBrowserNavigateTool(nil, nil, "").Name())
+	require.Equal(t, ToolBrowserClick, NewBrowserClickTool(nil, nil, "").Name())
+	require.Equal(t, ToolBrowserFill, NewBrowserFillTool(nil, nil, "").Name())
+	require.Equal(t, ToolBrowserExtract, NewBrowserExtractTool(nil, nil, "").Name())
+	require.Equal(t, ToolBrowserScreenshot, NewBrowserScreenshotTool(nil, nil, "", nil).Name())
+}
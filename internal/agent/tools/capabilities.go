@@ -78,6 +78,7 @@ var ToolCapabilityRequirements = map[string]ToolRequirement{
 	// ---- Data analysis (reads table summary/column chunks from RAG ingest) ----
 	"data_analysis": {AnyOf: []KBCapability{CapVector, CapKeyword}, ConsumesFiles: true},
 	"data_schema":   {AnyOf: []KBCapability{CapVector, CapKeyword}, ConsumesFiles: true},
+	"data_chart":    {AnyOf: []KBCapability{CapVector, CapKeyword}, ConsumesFiles: true},
 }
 
 func hasCap(caps types.KBCapabilities, c KBCapability) bool {
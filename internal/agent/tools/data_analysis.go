@@ -103,15 +103,25 @@ func buildMissingColumnSuggestion(sqlErr error, schema *TableSchema) string {
 }
 
 type DataAnalysisInput struct {
-	KnowledgeID string `json:"knowledge_id" jsonschema:"short dN document ID to query"`
+	KnowledgeID string `json:"knowledge_id,omitempty" jsonschema:"short dN document ID to query; may be omitted when the SQL only uses files or views already loaded in this conversation"`
 	Sql         string `json:"sql" jsonschema:"SQL to be executed on knowledge"`
 }
 
+// duckQuerier is satisfied by both *sql.DB and *sql.Conn, so the loaders run
+// either on the shared database (per-call tables) or on a connection pinned
+// to an analysis session's schema.
+type duckQuerier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
 type DataAnalysisTool struct {
 	BaseTool
 	knowledgeBaseService interfaces.KnowledgeBaseService
 	knowledgeService     interfaces.KnowledgeService
 	fileService          interfaces.FileService
+	fileEncryption       *filesvc.Encryption
 	tenantService        interfaces.TenantService
 	db                   *sql.DB
 	sessionID            string
@@ -126,6 +136,10 @@ type DataAnalysisTool struct {
 	storageResolver interfaces.StorageBackendResolver
 	searchTargets   types.SearchTargets
 	scopeEnforced   bool
+	// sessions keeps tables and derived views alive across the turns of the
+	// chat session. Nil keeps the original per-call behaviour where every
+	// table is dropped by Cleanup.
+	sessions *AnalysisSessions
 }
 
 // WithSearchTargets enables the Agent-only authorization boundary. Other
@@ -139,11 +153,23 @@ func (t *DataAnalysisTool) WithSearchTargets(searchTargets types.SearchTargets)
 	return t
 }
 
+// WithAnalysisSessions makes the tool load files into the chat session's
+// analysis schema, where they stay queryable in later turns together with any
+// view or table the model derives from them.
+func (t *DataAnalysisTool) WithAnalysisSessions(sessions *AnalysisSessions) *DataAnalysisTool {
+	if sessions != nil && t.sessionID != "" {
+		t.sessions = sessions
+		t.description = dataAnalysisSessionDescription
+	}
+	return t
+}
+
 func NewDataAnalysisTool(
 	knowledgeBaseService interfaces.KnowledgeBaseService,
 	knowledgeService interfaces.KnowledgeService,
 	tenantService interfaces.TenantService,
 	fileService interfaces.FileService,
+	fileEncryption *filesvc.Encryption,
 	db *sql.DB,
 	sessionID string,
 	storageResolvers ...interfaces.StorageBackendResolver,
@@ -153,6 +179,7 @@ func NewDataAnalysisTool(
 		knowledgeBaseService: knowledgeBaseService,
 		knowledgeService:     knowledgeService,
 		fileService:          fileService,
+		fileEncryption:       fileEncryption,
 		tenantService:        tenantService,
 		db:                   db,
 		sessionID:            sessionID,
@@ -214,6 +241,9 @@ func (t *DataAnalysisTool) Execute(ctx context.Context, args json.RawMessage) (*
 			Error:   fmt.Sprintf("Failed to parse input args: %v", err),
 		}, err
 	}
+	if t.sessions != nil {
+		return t.executeInSession(ctx, input)
+	}
 	if t.scopeEnforced {
 		if _, err := authorizeKnowledgeInSearchTargets(ctx, t.searchTargets, input.KnowledgeID, t.knowledgeService); err != nil {
 			return &types.ToolResult{Success: false, Error: err.Error()}, err
@@ -270,7 +300,7 @@ func (t *DataAnalysisTool) Execute(ctx context.Context, args json.RawMessage) (*
 
 	logger.Infof(ctx, "[Tool][DataAnalysis] Received SQL query for session %s: %s", t.sessionID, input.Sql)
 	// Execute single query and get results
-	results, err := t.executeSingleQuery(ctx, input.Sql)
+	results, err := t.executeSingleQuery(ctx, t.db, input.Sql)
 	if err != nil {
 		if suggestion := buildMissingColumnSuggestion(err, schema); suggestion != "" {
 			return &types.ToolResult{
@@ -302,18 +332,24 @@ func (t *DataAnalysisTool) Execute(ctx context.Context, args json.RawMessage) (*
 // executeSingleQuery executes a single SQL query and returns columns and results
 // Parameters:
 //   - ctx: context for cancellation and timeout
+//   - q: the database or session connection to run on
 //   - sqlQuery: the SQL query to execute
-//   - existingColumns: existing column names to merge with (can be nil or empty)
 //
 // Returns:
-//   - []string: merged column names (existing + new columns, deduplicated)
 //   - []map[string]string: query results
 //   - error: any error that occurred during execution
-func (t *DataAnalysisTool) executeSingleQuery(ctx context.Context, sqlQuery string) ([]map[string]string, error) {
-	rows, err := t.db.QueryContext(ctx, sqlQuery)
+func (t *DataAnalysisTool) executeSingleQuery(ctx context.Context, q duckQuerier, sqlQuery string) ([]map[string]string, error) {
+	_, results, err := t.queryRows(ctx, q, sqlQuery)
+	return results, err
+}
+
+// queryRows is executeSingleQuery that also returns the column order, which
+// the result maps lose and charts need.
+func (t *DataAnalysisTool) queryRows(ctx context.Context, q duckQuerier, sqlQuery string) ([]string, []map[string]string, error) {
+	rows, err := q.QueryContext(ctx, sqlQuery)
 	if err != nil {
 		logger.Errorf(ctx, "[Tool][DataAnalysis] Query execution failed: %v", err)
-		return nil, fmt.Errorf("query execution failed: %w", err)
+		return nil, nil, fmt.Errorf("query execution failed: %w", err)
 	}
 	defer rows.Close()
 
@@ -321,7 +357,7 @@ func (t *DataAnalysisTool) executeSingleQuery(ctx context.Context, sqlQuery stri
 	columns, err := rows.Columns()
 	if err != nil {
 		logger.Errorf(ctx, "[Tool][DataAnalysis] Failed to get columns: %v", err)
-		return nil, fmt.Errorf("failed to get columns: %w", err)
+		return nil, nil, fmt.Errorf("failed to get columns: %w", err)
 	}
 
 	// Process results
@@ -335,7 +371,7 @@ func (t *DataAnalysisTool) executeSingleQuery(ctx context.Context, sqlQuery stri
 
 		if err := rows.Scan(columnPointers...); err != nil {
 			logger.Errorf(ctx, "[Tool][DataAnalysis] Failed to scan row: %v", err)
-			return nil, fmt.Errorf("failed to scan row: %w", err)
+			return nil, nil, fmt.Errorf("failed to scan row: %w", err)
 		}
 
 		rowMap := make(map[string]string)
@@ -353,10 +389,10 @@ func (t *DataAnalysisTool) executeSingleQuery(ctx context.Context, sqlQuery stri
 
 	if err := rows.Err(); err != nil {
 		logger.Errorf(ctx, "[Tool][DataAnalysis] Error iterating rows: %v", err)
-		return nil, fmt.Errorf("error iterating rows: %w", err)
+		return nil, nil, fmt.Errorf("error iterating rows: %w", err)
 	}
 
-	return results, nil
+	return columns, results, nil
 }
 
 // formatQueryResults formats query results into JSONL format (one JSON object per line)
@@ -418,28 +454,41 @@ func (t *DataAnalysisTool) LoadFromCSV(ctx context.Context, filename string, tab
 
 	// Record the created table for cleanup. If already exists, skip creation
 	if t.recordCreatedTable(tableName) {
-		// Create table from CSV using DuckDB's read_csv_auto function
-		// with explicit header detection and VARCHAR coercion to align with
-		// Excel loading behavior.
-		// Table will be created in the session schema
-		createTableSQL := fmt.Sprintf(
-			"CREATE TABLE \"%s\" AS SELECT * FROM read_csv_auto('%s', header=true, all_varchar=true)",
-			tableName, sqlSingleQuoteEscape(filename),
-		)
-
-		_, err := t.db.ExecContext(ctx, createTableSQL)
-		if err != nil {
-			logger.Errorf(ctx, "[Tool][DataAnalysis] Failed to create table from CSV: %v", err)
-			return nil, fmt.Errorf("failed to create table from CSV: %w", err)
+		if err := t.createTableFromCSV(ctx, t.db, filename, tableName); err != nil {
+			return nil, err
 		}
-
-		logger.Infof(ctx, "[Tool][DataAnalysis] Successfully created table '%s' from CSV file in session %s", tableName, t.sessionID)
 	}
 
 	// Get and return the table schema
 	return t.LoadFromTable(ctx, tableName)
 }
 
+// createTableFromCSV creates tableName from a CSV file on q, which is the
+// shared database for per-call tables and a session connection otherwise.
+func (t *DataAnalysisTool) createTableFromCSV(ctx context.Context, q duckQuerier, filename string, tableName string) error {
+	// Create table from CSV using DuckDB's read_csv_auto function
+	// with explicit header detection and VARCHAR coercion to align with
+	// Excel loading behavior.
+	createTableSQL := buildCSVCreateTableSQL(tableName, filename)
+
+	if _, err := q.ExecContext(ctx, createTableSQL); err != nil {
+		logger.Errorf(ctx, "[Tool][DataAnalysis] Failed to create table from CSV: %v", err)
+		return fmt.Errorf("failed to create table from CSV: %w", err)
+	}
+
+	logger.Infof(ctx, "[Tool][DataAnalysis] Successfully created table '%s' from CSV file in session %s", tableName, t.sessionID)
+	return nil
+}
+
+// buildCSVCreateTableSQL assembles the CREATE TABLE statement used for CSV
+// sources. The exported notebook replays the same statement.
+func buildCSVCreateTableSQL(tableName, filename string) string {
+	return fmt.Sprintf(
+		"CREATE TABLE \"%s\" AS SELECT * FROM read_csv_auto('%s', header=true, all_varchar=true)",
+		tableName, sqlSingleQuoteEscape(filename),
+	)
+}
+
 // LoadFromExcel loads data from an Excel file into a DuckDB table and returns the table schema.
 //
 // Multi-sheet workbooks are fully supported: every sheet in the workbook is
@@ -464,29 +513,39 @@ func (t *DataAnalysisTool) LoadFromExcel(ctx context.Context, filename string, t
 
 	// Record the created table for cleanup. If already exists, skip creation.
 	if t.recordCreatedTable(tableName) {
-		sheetNames, enumErr := t.listExcelSheets(ctx, filename)
-		if enumErr != nil {
-			logger.Warnf(ctx,
-				"[Tool][DataAnalysis] Could not enumerate sheets for '%s' (session=%s): %v. Falling back to first sheet only.",
-				filename, t.sessionID, enumErr,
-			)
+		if _, err := t.createTableFromExcel(ctx, t.db, filename, tableName); err != nil {
+			return nil, err
 		}
+	}
 
-		createTableSQL := buildExcelCreateTableSQL(tableName, filename, sheetNames)
-
-		if _, err := t.db.ExecContext(ctx, createTableSQL); err != nil {
-			logger.Errorf(ctx, "[Tool][DataAnalysis] Failed to create table from Excel (sheets=%v): %v", sheetNames, err)
-			return nil, fmt.Errorf("failed to create table from Excel file (sheets=%v): %w", sheetNames, err)
-		}
+	// Get and return the table schema
+	return t.LoadFromTable(ctx, tableName)
+}
 
-		logger.Infof(ctx,
-			"[Tool][DataAnalysis] Successfully created table '%s' from Excel file in session %s (sheets=%v)",
-			tableName, t.sessionID, sheetNames,
+// createTableFromExcel creates tableName from every sheet of an Excel
+// workbook on q and returns the sheets it read (nil for the first-sheet
+// fallback).
+func (t *DataAnalysisTool) createTableFromExcel(ctx context.Context, q duckQuerier, filename string, tableName string) ([]string, error) {
+	sheetNames, enumErr := t.listExcelSheets(ctx, q, filename)
+	if enumErr != nil {
+		logger.Warnf(ctx,
+			"[Tool][DataAnalysis] Could not enumerate sheets for '%s' (session=%s): %v. Falling back to first sheet only.",
+			filename, t.sessionID, enumErr,
 		)
 	}
 
-	// Get and return the table schema
-	return t.LoadFromTable(ctx, tableName)
+	createTableSQL := buildExcelCreateTableSQL(tableName, filename, sheetNames)
+
+	if _, err := q.ExecContext(ctx, createTableSQL); err != nil {
+		logger.Errorf(ctx, "[Tool][DataAnalysis] Failed to create table from Excel (sheets=%v): %v", sheetNames, err)
+		return nil, fmt.Errorf("failed to create table from Excel file (sheets=%v): %w", sheetNames, err)
+	}
+
+	logger.Infof(ctx,
+		"[Tool][DataAnalysis] Successfully created table '%s' from Excel file in session %s (sheets=%v)",
+		tableName, t.sessionID, sheetNames,
+	)
+	return sheetNames, nil
 }
 
 // listExcelSheets returns the names of every sheet (layer) inside the given
@@ -496,13 +555,13 @@ func (t *DataAnalysisTool) LoadFromExcel(ctx context.Context, filename string, t
 // st_read_meta returns a single row whose `layers` column is a LIST of
 // STRUCTs (one per layer / sheet). We UNNEST that list and project the
 // struct's `name` field to get a flat list of sheet names.
-func (t *DataAnalysisTool) listExcelSheets(ctx context.Context, filename string) ([]string, error) {
+func (t *DataAnalysisTool) listExcelSheets(ctx context.Context, q duckQuerier, filename string) ([]string, error) {
 	metaSQL := fmt.Sprintf(
 		"SELECT UNNEST(layers).name FROM st_read_meta('%s')",
 		sqlSingleQuoteEscape(filename),
 	)
 
-	rows, err := t.db.QueryContext(ctx, metaSQL)
+	rows, err := q.QueryContext(ctx, metaSQL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query sheet metadata: %w", err)
 	}
@@ -699,12 +758,17 @@ func (t *DataAnalysisTool) LoadFromKnowledgeID(ctx context.Context, knowledgeID
 //
 // Note: This function does NOT create the table, it only retrieves schema information
 func (t *DataAnalysisTool) LoadFromTable(ctx context.Context, tableName string) (*TableSchema, error) {
+	return t.describeTable(ctx, t.db, tableName)
+}
+
+// describeTable reads the columns and row count of tableName on q.
+func (t *DataAnalysisTool) describeTable(ctx context.Context, q duckQuerier, tableName string) (*TableSchema, error) {
 	logger.Infof(ctx, "[Tool][DataAnalysis] Getting schema for table '%s' in session %s", tableName, t.sessionID)
 
 	// Query to get column information using PRAGMA table_info or DESCRIBE
 	schemaSQL := fmt.Sprintf("DESCRIBE \"%s\"", tableName)
 
-	rows, err := t.db.QueryContext(ctx, schemaSQL)
+	rows, err := q.QueryContext(ctx, schemaSQL)
 	if err != nil {
 		logger.Errorf(ctx, "[Tool][DataAnalysis] Failed to get table schema: %v", err)
 		return nil, fmt.Errorf("failed to get table schema: %w", err)
@@ -743,7 +807,7 @@ func (t *DataAnalysisTool) LoadFromTable(ctx context.Context, tableName string)
 	// Get row count
 	countSQL := fmt.Sprintf("SELECT COUNT(*) FROM \"%s\"", tableName)
 	var rowCount int64
-	if err := t.db.QueryRowContext(ctx, countSQL).Scan(&rowCount); err != nil {
+	if err := q.QueryRowContext(ctx, countSQL).Scan(&rowCount); err != nil {
 		logger.Errorf(ctx, "[Tool][DataAnalysis] Failed to get row count: %v", err)
 		return nil, fmt.Errorf("failed to get row count: %w", err)
 	}
@@ -870,7 +934,7 @@ func (t *DataAnalysisTool) resolveFileServiceForKnowledge(ctx context.Context, k
 	// instead of the configured path and therefore fail to locate files (#1040).
 	baseDir := t.localBaseDir
 
-	resolvedSvc, resolvedProvider, err := filesvc.NewFileServiceFromStorageConfig(provider, storageConfig, baseDir)
+	resolvedSvc, resolvedProvider, err := filesvc.NewFileServiceFromStorageConfig(provider, storageConfig, baseDir, t.fileEncryption)
 	if err != nil {
 		logger.Warnf(ctx, "[Tool][DataAnalysis][storage] create file service failed, fallback default: session_id=%s knowledge_id=%s kb_id=%s provider=%s err=%v",
 			t.sessionID, knowledge.ID, kbID, provider, err)
@@ -0,0 +1,327 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// Formats accepted by BuildAnalysisNotebook.
+const (
+	AnalysisNotebookFormatSQL   = "sql"
+	AnalysisNotebookFormatIPynb = "ipynb"
+)
+
+// analysisLogEntry is one successful statement of the session's query log.
+type analysisLogEntry struct {
+	question string
+	tool     string
+	sql      string
+	kind     string
+	chart    *DataChartInput
+}
+
+// analysisLog is the query log of a chat session, rebuilt from the tool calls
+// persisted on its assistant messages.
+type analysisLog struct {
+	sources []AnalysisSource
+	entries []analysisLogEntry
+}
+
+// BuildAnalysisNotebook turns the data_analysis and data_chart calls of a
+// chat session into a script that reproduces them: the source files are
+// loaded under the table names the model used, then every statement runs in
+// its original order. Failed calls are left out. The format is either a
+// DuckDB SQL script or a Jupyter notebook using the duckdb Python package.
+//
+// It returns false when the session has no analysis to export.
+func BuildAnalysisNotebook(title string, messages []*types.Message, format string, now time.Time) ([]byte, bool, error) {
+	log := collectAnalysisLog(messages)
+	if len(log.entries) == 0 {
+		return nil, false, nil
+	}
+	switch format {
+	case "", AnalysisNotebookFormatSQL:
+		return []byte(renderAnalysisSQLScript(title, log, now)), true, nil
+	case AnalysisNotebookFormatIPynb:
+		data, err := renderAnalysisIPynb(title, log, now)
+		return data, true, err
+	default:
+		return nil, false, fmt.Errorf("unsupported notebook format %q (supported: sql, ipynb)", format)
+	}
+}
+
+func collectAnalysisLog(messages []*types.Message) *analysisLog {
+	log := &analysisLog{}
+	seenSources := map[string]bool{}
+	question := ""
+	for _, msg := range messages {
+		if msg == nil {
+			continue
+		}
+		if msg.Role == "user" {
+			question = strings.TrimSpace(msg.Content)
+			continue
+		}
+		for _, step := range msg.AgentSteps {
+			for _, call := range step.ToolCalls {
+				if call.Name != ToolDataAnalysis && call.Name != ToolDataChart {
+					continue
+				}
+				if call.Result == nil || !call.Result.Success || call.Result.Data == nil {
+					continue
+				}
+				query, _ := call.Result.Data["query"].(string)
+				if strings.TrimSpace(query) == "" {
+					continue
+				}
+				for _, src := range analysisSourcesFromData(call.Result.Data["sources"]) {
+					if !seenSources[src.Table] {
+						seenSources[src.Table] = true
+						log.sources = append(log.sources, src)
+					}
+				}
+				kind, _ := call.Result.Data["statement_kind"].(string)
+				if kind == "" {
+					kind = analysisStatementKindQuery
+				}
+				entry := analysisLogEntry{question: question, tool: call.Name, sql: query, kind: kind}
+				if call.Name == ToolDataChart {
+					entry.chart = chartInputFromArgs(call.Args)
+				}
+				log.entries = append(log.entries, entry)
+			}
+		}
+	}
+	sort.SliceStable(log.sources, func(i, j int) bool { return log.sources[i].Table < log.sources[j].Table })
+	return log
+}
+
+// analysisSourcesFromData reads the "sources" value of a tool result, which
+// is []AnalysisSource in memory and generic JSON once loaded from storage.
+func analysisSourcesFromData(v interface{}) []AnalysisSource {
+	if v == nil {
+		return nil
+	}
+	if sources, ok := v.([]AnalysisSource); ok {
+		return sources
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	var sources []AnalysisSource
+	if err := json.Unmarshal(raw, &sources); err != nil {
+		return nil
+	}
+	return sources
+}
+
+func chartInputFromArgs(args map[string]interface{}) *DataChartInput {
+	raw, err := json.Marshal(args)
+	if err != nil {
+		return nil
+	}
+	var input DataChartInput
+	if err := json.Unmarshal(raw, &input); err != nil {
+		return nil
+	}
+	if validateChartInput(&input) != nil {
+		return nil
+	}
+	return &input
+}
+
+// sourceLoadSQL is the statement that recreates a source table from a local
+// copy of the original file, named as it was uploaded.
+func sourceLoadSQL(src AnalysisSource) string {
+	fileName := src.FileName
+	if fileName == "" {
+		fileName = src.Table + "." + src.FileType
+	}
+	if src.FileType == "csv" {
+		return buildCSVCreateTableSQL(src.Table, fileName)
+	}
+	return buildExcelCreateTableSQL(src.Table, fileName, src.Sheets)
+}
+
+func hasExcelSource(sources []AnalysisSource) bool {
+	for _, src := range sources {
+		if src.FileType != "csv" {
+			return true
+		}
+	}
+	return false
+}
+
+// sqlStatement terminates a statement for a script.
+func sqlStatement(s string) string {
+	s = strings.TrimSpace(s)
+	s = strings.TrimRight(s, ";")
+	return s + ";"
+}
+
+// sqlComment turns free text into "-- " lines.
+func sqlComment(s string) string {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight("-- "+line, " ")
+	}
+	return strings.Join(lines, "\n")
+}
+
+func chartSummary(chart *DataChartInput) string {
+	title := chart.Title
+	if title == "" {
+		title = strings.Join(chart.Y, ", ") + " by " + chart.X
+	}
+	return fmt.Sprintf("%s chart %q (x=%s, y=%s)", chart.ChartType, title, chart.X, strings.Join(chart.Y, ", "))
+}
+
+func renderAnalysisSQLScript(title string, log *analysisLog, now time.Time) string {
+	var b strings.Builder
+	b.WriteString(sqlComment(fmt.Sprintf("Data analysis: %s\nExported %s. Run with the DuckDB CLI: duckdb < this_file.sql",
+		analysisNotebookTitle(title), now.UTC().Format(time.RFC3339))))
+	b.WriteString("\n\n")
+
+	if len(log.sources) > 0 {
+		b.WriteString(sqlComment("Source files: place copies next to this script under the names below."))
+		b.WriteString("\n")
+		if hasExcelSource(log.sources) {
+			b.WriteString("INSTALL excel;\nLOAD excel;\n")
+		}
+		for _, src := range log.sources {
+			b.WriteString(sqlStatement(sourceLoadSQL(src)))
+			b.WriteString("\n")
+		}
+		b.WriteString("\n")
+	}
+
+	lastQuestion := ""
+	for i, entry := range log.entries {
+		if entry.question != "" && entry.question != lastQuestion {
+			b.WriteString(sqlComment("Question: " + entry.question))
+			b.WriteString("\n")
+			lastQuestion = entry.question
+		}
+		label := fmt.Sprintf("Step %d", i+1)
+		if entry.chart != nil {
+			label += ": " + chartSummary(entry.chart)
+		}
+		b.WriteString(sqlComment(label))
+		b.WriteString("\n")
+		b.WriteString(sqlStatement(entry.sql))
+		b.WriteString("\n\n")
+	}
+	return b.String()
+}
+
+// ipynbCell is a cell of an nbformat 4 notebook.
+type ipynbCell struct {
+	CellType       string                 `json:"cell_type"`
+	Metadata       map[string]interface{} `json:"metadata"`
+	Source         []string               `json:"source"`
+	ExecutionCount *int                   `json:"execution_count,omitempty"`
+	Outputs        []interface{}          `json:"outputs,omitempty"`
+}
+
+func markdownCell(text string) ipynbCell {
+	return ipynbCell{CellType: "markdown", Metadata: map[string]interface{}{}, Source: notebookLines(text)}
+}
+
+func codeCell(code string) ipynbCell {
+	return ipynbCell{CellType: "code", Metadata: map[string]interface{}{}, Source: notebookLines(code), Outputs: []interface{}{}}
+}
+
+// notebookLines splits text the way nbformat stores sources: every line but
+// the last keeps its newline.
+func notebookLines(text string) []string {
+	lines := strings.SplitAfter(strings.TrimRight(text, "\n"), "\n")
+	return lines
+}
+
+// pythonString quotes s as a Python triple-quoted string literal.
+func pythonString(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return `"""` + s + `"""`
+}
+
+func renderAnalysisIPynb(title string, log *analysisLog, now time.Time) ([]byte, error) {
+	cells := []ipynbCell{
+		markdownCell(fmt.Sprintf("# %s\n\nData analysis exported %s. Place copies of the source files next to this notebook, then run all cells.",
+			analysisNotebookTitle(title), now.UTC().Format(time.RFC3339))),
+		codeCell("import duckdb\n\ncon = duckdb.connect()"),
+	}
+	if len(log.sources) > 0 {
+		var load strings.Builder
+		if hasExcelSource(log.sources) {
+			load.WriteString("con.execute(\"INSTALL excel; LOAD excel;\")\n")
+		}
+		for _, src := range log.sources {
+			load.WriteString(fmt.Sprintf("con.execute(%s)\n", pythonString(sourceLoadSQL(src))))
+		}
+		cells = append(cells, codeCell(load.String()))
+	}
+
+	lastQuestion := ""
+	for _, entry := range log.entries {
+		if entry.question != "" && entry.question != lastQuestion {
+			cells = append(cells, markdownCell("> "+strings.ReplaceAll(entry.question, "\n", "\n> ")))
+			lastQuestion = entry.question
+		}
+		switch {
+		case entry.chart != nil:
+			cells = append(cells, codeCell(fmt.Sprintf("df = con.sql(%s).df()\n%s", pythonString(entry.sql), pandasPlot(entry.chart))))
+		case entry.kind == analysisStatementKindQuery:
+			cells = append(cells, codeCell(fmt.Sprintf("con.sql(%s).df()", pythonString(entry.sql))))
+		default:
+			cells = append(cells, codeCell(fmt.Sprintf("con.execute(%s)", pythonString(entry.sql))))
+		}
+	}
+
+	notebook := map[string]interface{}{
+		"nbformat":       4,
+		"nbformat_minor": 5,
+		"metadata": map[string]interface{}{
+			"kernelspec":    map[string]string{"name": "python3", "display_name": "Python 3", "language": "python"},
+			"language_info": map[string]string{"name": "python"},
+		},
+		"cells": cells,
+	}
+	return json.MarshalIndent(notebook, "", " ")
+}
+
+// pandasPlot is the DataFrame.plot call matching a data_chart call.
+func pandasPlot(chart *DataChartInput) string {
+	title := chart.Title
+	if title == "" {
+		title = strings.Join(chart.Y, ", ") + " by " + chart.X
+	}
+	switch chart.ChartType {
+	case chartTypePie:
+		return fmt.Sprintf("df.set_index(%s).plot(kind=\"pie\", y=%s, title=%s)",
+			pythonString(chart.X), pythonString(chart.Y[0]), pythonString(title))
+	case chartTypeScatter:
+		return fmt.Sprintf("df.plot(kind=\"scatter\", x=%s, y=%s, title=%s)",
+			pythonString(chart.X), pythonString(chart.Y[0]), pythonString(title))
+	default:
+		ys := make([]string, 0, len(chart.Y))
+		for _, y := range chart.Y {
+			ys = append(ys, pythonString(y))
+		}
+		return fmt.Sprintf("df.plot(kind=%q, x=%s, y=[%s], title=%s)",
+			chart.ChartType, pythonString(chart.X), strings.Join(ys, ", "), pythonString(title))
+	}
+}
+
+func analysisNotebookTitle(title string) string {
+	if title = strings.TrimSpace(title); title != "" {
+		return title
+	}
+	return "WeKnora session"
+}
@@ -0,0 +1,130 @@
+package tools
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// analysisTestMessages mimics a session loaded from storage: tool result data
+// has been through a JSON round trip, so sources are generic maps.
+func analysisTestMessages(t *testing.T) []*types.Message {
+	t.Helper()
+	roundTrip := func(v interface{}) map[string]interface{} {
+		raw, err := json.Marshal(v)
+		if err != nil {
+			t.Fatalf("marshal: %v", err)
+		}
+		var out map[string]interface{}
+		if err := json.Unmarshal(raw, &out); err != nil {
+			t.Fatalf("unmarshal: %v", err)
+		}
+		return out
+	}
+	sources := []AnalysisSource{{Table: "k_doc_1", KnowledgeID: "doc-1", FileName: "sales.csv", FileType: "csv"}}
+	return []*types.Message{
+		{Role: "user", Content: "Which region sells most?"},
+		{
+			Role: "assistant",
+			AgentSteps: types.AgentSteps{{
+				ToolCalls: []types.ToolCall{
+					{
+						Name: ToolDataAnalysis,
+						Result: &types.ToolResult{Success: true, Data: roundTrip(map[string]interface{}{
+							"query":          "CREATE VIEW by_region AS SELECT region, SUM(amount) AS total FROM k_doc_1 GROUP BY region",
+							"statement_kind": analysisStatementKindCreate,
+							"sources":        sources,
+						})},
+					},
+					{
+						Name:   ToolDataAnalysis,
+						Result: &types.ToolResult{Success: false, Error: "boom"},
+					},
+					{
+						Name: ToolDataChart,
+						Args: map[string]interface{}{"chart_type": "bar", "x": "region", "y": []interface{}{"total"}, "title": "By region"},
+						Result: &types.ToolResult{Success: true, Data: roundTrip(map[string]interface{}{
+							"query":          "SELECT * FROM by_region",
+							"statement_kind": analysisStatementKindQuery,
+							"sources":        sources,
+						})},
+					},
+				},
+			}},
+		},
+	}
+}
+
+func TestBuildAnalysisNotebook_SQLScript(t *testing.T) {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	data, found, err := BuildAnalysisNotebook("Sales", analysisTestMessages(t), AnalysisNotebookFormatSQL, now)
+	if err != nil || !found {
+		t.Fatalf("BuildAnalysisNotebook: found=%v err=%v", found, err)
+	}
+	script := string(data)
+	for _, want := range []string{
+		"-- Data analysis: Sales",
+		"CREATE TABLE \"k_doc_1\" AS SELECT * FROM read_csv_auto('sales.csv', header=true, all_varchar=true)",
+		"-- Question: Which region sells most?",
+		"CREATE VIEW by_region AS SELECT region, SUM(amount) AS total FROM k_doc_1 GROUP BY region;",
+		"-- Step 2: bar chart \"By region\" (x=region, y=total)",
+		"SELECT * FROM by_region;",
+	} {
+		if !strings.Contains(script, want) {
+			t.Errorf("script missing %q:\n%s", want, script)
+		}
+	}
+	if strings.Contains(script, "INSTALL excel") {
+		t.Error("CSV-only analysis should not load the excel extension")
+	}
+}
+
+func TestBuildAnalysisNotebook_IPynb(t *testing.T) {
+	data, found, err := BuildAnalysisNotebook("", analysisTestMessages(t), AnalysisNotebookFormatIPynb, time.Now())
+	if err != nil || !found {
+		t.Fatalf("BuildAnalysisNotebook: found=%v err=%v", found, err)
+	}
+	var notebook struct {
+		NBFormat int `json:"nbformat"`
+		Cells    []struct {
+			CellType string   `json:"cell_type"`
+			Source   []string `json:"source"`
+		} `json:"cells"`
+	}
+	if err := json.Unmarshal(data, &notebook); err != nil {
+		t.Fatalf("notebook is not JSON: %v", err)
+	}
+	if notebook.NBFormat != 4 {
+		t.Errorf("nbformat = %d, want 4", notebook.NBFormat)
+	}
+	var code []string
+	for _, cell := range notebook.Cells {
+		if cell.CellType == "code" {
+			code = append(code, strings.Join(cell.Source, ""))
+		}
+	}
+	all := strings.Join(code, "\n")
+	for _, want := range []string{"import duckdb", "read_csv_auto('sales.csv', header=true, all_varchar=true)", "df.plot(kind=\"bar\""} {
+		if !strings.Contains(all, want) {
+			t.Errorf("notebook code missing %q:\n%s", want, all)
+		}
+	}
+}
+
+func TestBuildAnalysisNotebook_NothingToExport(t *testing.T) {
+	_, found, err := BuildAnalysisNotebook("", []*types.Message{{Role: "user", Content: "hi"}}, AnalysisNotebookFormatSQL, time.Now())
+	if err != nil || found {
+		t.Fatalf("expected nothing to export, found=%v err=%v", found, err)
+	}
+}
+
+func TestPythonStringEscapesQuotes(t *testing.T) {
+	got := pythonString(`SELECT "a" FROM t WHERE b = '\'`)
+	want := `"""SELECT \"a\" FROM t WHERE b = '\\'"""`
+	if got != want {
+		t.Errorf("pythonString = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,646 @@
+package tools
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/utils"
+	pg_query "github.com/pganalyze/pg_query_go/v6"
+)
+
+// Analysis sessions keep DuckDB tables and the views the model derives from
+// them alive across the turns of one chat session, so "now break that down by
+// month" can build on the view created two questions ago instead of reloading
+// the file and repeating every step.
+//
+// Each chat session owns a DuckDB schema. Statements run on a connection whose
+// search path is set to that schema, so the model writes unqualified names and
+// can never see another session's objects (schema-qualified names are refused
+// by the SQL validator). Sessions are reaped after an idle period and the
+// number of live sessions is capped, because every table lives in the
+// process's memory.
+const (
+	defaultAnalysisSessionIdleTTL  = 30 * time.Minute
+	defaultMaxAnalysisSessions     = 64
+	maxDerivedObjectsPerSession    = 32
+	analysisSessionSchemaPrefix    = "analysis_"
+	analysisDerivedKindView        = "view"
+	analysisDerivedKindTable       = "table"
+	analysisStatementKindQuery     = "query"
+	analysisStatementKindCreate    = "create"
+	analysisStatementKindDrop      = "drop"
+	analysisSessionSchemaHashBytes = 8
+)
+
+// AnalysisSource describes a knowledge file loaded into a session. The file
+// name and type are kept so the exported notebook can reload the same data.
+type AnalysisSource struct {
+	Table       string `json:"table"`
+	KnowledgeID string `json:"knowledge_id"`
+	FileName    string `json:"file_name"`
+	FileType    string `json:"file_type"`
+	// Sheets lists the Excel sheets unioned into the table; empty for CSV
+	// and for the first-sheet fallback.
+	Sheets []string `json:"sheets,omitempty"`
+}
+
+// AnalysisSession is the per-chat-session state. Its mutex serialises every
+// statement of the session: DuckDB DDL on one schema from two parallel tool
+// calls would otherwise race on the source bookkeeping.
+type AnalysisSession struct {
+	id       string
+	schema   string
+	mu       sync.Mutex
+	sources  map[string]AnalysisSource
+	derived  map[string]string
+	lastUsed time.Time
+	created  bool
+	dropped  bool
+}
+
+// AnalysisSessions is the process-wide registry of analysis sessions, one
+// DuckDB schema per chat session.
+type AnalysisSessions struct {
+	db          *sql.DB
+	mu          sync.Mutex
+	sessions    map[string]*AnalysisSession
+	idleTTL     time.Duration
+	maxSessions int
+	now         func() time.Time
+}
+
+// NewAnalysisSessions creates the registry on top of the shared DuckDB
+// instance. A nil db yields a nil registry, which callers treat as "sessions
+// disabled" and fall back to per-call tables.
+func NewAnalysisSessions(db *sql.DB) *AnalysisSessions {
+	if db == nil {
+		return nil
+	}
+	return &AnalysisSessions{
+		db:          db,
+		sessions:    make(map[string]*AnalysisSession),
+		idleTTL:     defaultAnalysisSessionIdleTTL,
+		maxSessions: defaultMaxAnalysisSessions,
+		now:         time.Now,
+	}
+}
+
+// analysisSchemaName derives the schema from the chat session ID. Hashing
+// keeps the identifier short and free of characters that would need quoting
+// rules beyond the plain double quotes used everywhere else.
+func analysisSchemaName(sessionID string) string {
+	sum := sha256.Sum256([]byte(sessionID))
+	return analysisSessionSchemaPrefix + hex.EncodeToString(sum[:analysisSessionSchemaHashBytes])
+}
+
+// open returns the session for sessionID, registering it on first use.
+// Idle sessions are reaped here rather than by a background goroutine so the
+// registry needs no lifecycle of its own.
+func (s *AnalysisSessions) open(ctx context.Context, sessionID string) (*AnalysisSession, error) {
+	if sessionID == "" {
+		return nil, fmt.Errorf("analysis session requires a chat session ID")
+	}
+	now := s.now()
+
+	s.mu.Lock()
+	var expired []*AnalysisSession
+	for id, sess := range s.sessions {
+		if id != sessionID && now.Sub(sess.lastUsed) > s.idleTTL {
+			expired = append(expired, sess)
+			delete(s.sessions, id)
+		}
+	}
+	sess, ok := s.sessions[sessionID]
+	if !ok {
+		if len(s.sessions) >= s.maxSessions {
+			if oldest := s.leastRecentlyUsedLocked(); oldest != nil {
+				expired = append(expired, oldest)
+				delete(s.sessions, oldest.id)
+			}
+		}
+		sess = &AnalysisSession{
+			id:      sessionID,
+			schema:  analysisSchemaName(sessionID),
+			sources: make(map[string]AnalysisSource),
+			derived: make(map[string]string),
+		}
+		s.sessions[sessionID] = sess
+	}
+	sess.lastUsed = now
+	s.mu.Unlock()
+
+	for _, old := range expired {
+		s.dropSession(ctx, old)
+	}
+	return sess, nil
+}
+
+func (s *AnalysisSessions) leastRecentlyUsedLocked() *AnalysisSession {
+	var oldest *AnalysisSession
+	for _, sess := range s.sessions {
+		if oldest == nil || sess.lastUsed.Before(oldest.lastUsed) {
+			oldest = sess
+		}
+	}
+	return oldest
+}
+
+// Drop discards a chat session's tables and views ahead of the idle reaping.
+// Unknown sessions are a no-op.
+func (s *AnalysisSessions) Drop(ctx context.Context, sessionID string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	sess, ok := s.sessions[sessionID]
+	delete(s.sessions, sessionID)
+	s.mu.Unlock()
+	if ok {
+		s.dropSession(ctx, sess)
+	}
+}
+
+func (s *AnalysisSessions) dropSession(ctx context.Context, sess *AnalysisSession) {
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	sess.dropped = true
+	if _, err := s.db.ExecContext(ctx, fmt.Sprintf(`DROP SCHEMA IF EXISTS "%s" CASCADE`, sess.schema)); err != nil {
+		logger.Warnf(ctx, "[Tool][DataAnalysis] Failed to drop analysis schema %s for session %s: %v", sess.schema, sess.id, err)
+		return
+	}
+	logger.Infof(ctx, "[Tool][DataAnalysis] Dropped analysis session %s (schema=%s)", sess.id, sess.schema)
+}
+
+// run executes fn with the session locked and a connection whose default
+// schema is the session's. The connection is handed back with the default
+// restored; if that fails it is discarded so the next borrower cannot end up
+// inside this session's schema.
+func (s *AnalysisSessions) run(ctx context.Context, sessionID string, fn func(q duckQuerier, sess *AnalysisSession) error) error {
+	sess, err := s.open(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	if sess.dropped {
+		return fmt.Errorf("analysis session %s was closed, please retry", sessionID)
+	}
+	if !sess.created {
+		if _, err := s.db.ExecContext(ctx, fmt.Sprintf(`CREATE SCHEMA IF NOT EXISTS "%s"`, sess.schema)); err != nil {
+			return fmt.Errorf("failed to create analysis schema: %w", err)
+		}
+		sess.created = true
+		logger.Infof(ctx, "[Tool][DataAnalysis] Opened analysis session %s (schema=%s)", sessionID, sess.schema)
+	}
+
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to acquire DuckDB connection: %w", err)
+	}
+	defer func() {
+		if _, resetErr := conn.ExecContext(context.WithoutCancel(ctx), "RESET schema"); resetErr != nil {
+			logger.Warnf(ctx, "[Tool][DataAnalysis] Failed to reset schema, discarding connection: %v", resetErr)
+			_ = conn.Raw(func(any) error { return driver.ErrBadConn })
+		}
+		_ = conn.Close()
+	}()
+	if _, err := conn.ExecContext(ctx, fmt.Sprintf(`SET schema = '%s'`, sess.schema)); err != nil {
+		return fmt.Errorf("failed to enter analysis schema: %w", err)
+	}
+	return fn(conn, sess)
+}
+
+// source returns the loaded source for a table, if any.
+func (a *AnalysisSession) source(table string) (AnalysisSource, bool) {
+	src, ok := a.sources[table]
+	return src, ok
+}
+
+// sourceList returns the session's sources ordered by table name, so tool
+// output and the exported notebook are stable.
+func (a *AnalysisSession) sourceList() []AnalysisSource {
+	out := make([]AnalysisSource, 0, len(a.sources))
+	for _, src := range a.sources {
+		out = append(out, src)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Table < out[j].Table })
+	return out
+}
+
+// objectNames lists every name the model may query: sources and derived
+// objects alike.
+func (a *AnalysisSession) objectNames() []string {
+	names := make([]string, 0, len(a.sources)+len(a.derived))
+	for name := range a.sources {
+		names = append(names, name)
+	}
+	for name := range a.derived {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// derivedList returns "name (kind)" entries for the tool output.
+func (a *AnalysisSession) derivedList() []string {
+	out := make([]string, 0, len(a.derived))
+	for name, kind := range a.derived {
+		out = append(out, fmt.Sprintf("%s (%s)", name, kind))
+	}
+	sort.Strings(out)
+	return out
+}
+
+// dataAnalysisSessionDescription replaces the per-call description when the
+// tool runs inside an analysis session.
+const dataAnalysisSessionDescription = "Use this tool when the knowledge is CSV or Excel files. It loads the data into an analysis session that lasts for the whole conversation and executes SQL for data analysis. " +
+	"Pass knowledge_id the first time a file is used; afterwards refer to the file by its knowledge ID inside the SQL and knowledge_id may be omitted. " +
+	"For Excel files with multiple sheets, every sheet is loaded into the same table and the source sheet name is exposed as a '__sheet_name' column so you can filter/aggregate per sheet. " +
+	"Besides read-only queries you may keep intermediate results for later steps with 'CREATE [OR REPLACE] VIEW name AS SELECT ...' or 'CREATE TABLE name AS SELECT ...', and remove them with 'DROP VIEW name' or 'DROP TABLE name'. " +
+	"Views and tables you create stay available in later turns of this conversation under their plain name; build on them instead of repeating earlier steps. " +
+	"If the user's question requires data statistics, convert the question into SQL and execute it."
+
+// derivedObjectNamePattern restricts the names the model may give its views
+// and tables to plain identifiers.
+var derivedObjectNamePattern = regexp.MustCompile(`^[a-z_][a-z0-9_]{0,62}$`)
+
+// analysisStatement is the classified form of one statement run in a session.
+type analysisStatement struct {
+	kind       string
+	object     string
+	objectKind string
+	missingOK  bool
+	// query is the SELECT that must pass validation: the statement itself for
+	// queries, the defining query for CREATE ... AS.
+	query string
+}
+
+// analysisResult is what a session statement produced.
+type analysisResult struct {
+	statement *analysisStatement
+	sql       string
+	columns   []string
+	rows      []map[string]string
+	sources   []AnalysisSource
+	derived   []string
+}
+
+// classifyAnalysisStatement tells queries apart from the DDL a session
+// accepts. Anything it does not recognise is treated as a query so the
+// read-only checks and the validator report the problem.
+func classifyAnalysisStatement(sqlText string) (*analysisStatement, error) {
+	query := &analysisStatement{kind: analysisStatementKindQuery, query: sqlText}
+	parsed, err := pg_query.Parse(sqlText)
+	if err != nil || len(parsed.Stmts) != 1 {
+		return query, nil
+	}
+	node := parsed.Stmts[0].Stmt
+	switch {
+	case node.GetViewStmt() != nil:
+		view := node.GetViewStmt()
+		name, err := derivedObjectName(view.View)
+		if err != nil {
+			return nil, err
+		}
+		inner, err := deparseAnalysisQuery(view.Query)
+		if err != nil {
+			return nil, err
+		}
+		return &analysisStatement{kind: analysisStatementKindCreate, object: name, objectKind: analysisDerivedKindView, query: inner}, nil
+	case node.GetCreateTableAsStmt() != nil:
+		ctas := node.GetCreateTableAsStmt()
+		if ctas.Objtype != pg_query.ObjectType_OBJECT_TABLE || ctas.Into == nil || ctas.IsSelectInto {
+			return nil, fmt.Errorf("only CREATE TABLE name AS SELECT ... is supported")
+		}
+		name, err := derivedObjectName(ctas.Into.Rel)
+		if err != nil {
+			return nil, err
+		}
+		inner, err := deparseAnalysisQuery(ctas.Query)
+		if err != nil {
+			return nil, err
+		}
+		return &analysisStatement{kind: analysisStatementKindCreate, object: name, objectKind: analysisDerivedKindTable, query: inner}, nil
+	case node.GetDropStmt() != nil:
+		drop := node.GetDropStmt()
+		kind := ""
+		switch drop.RemoveType {
+		case pg_query.ObjectType_OBJECT_VIEW:
+			kind = analysisDerivedKindView
+		case pg_query.ObjectType_OBJECT_TABLE:
+			kind = analysisDerivedKindTable
+		default:
+			return nil, fmt.Errorf("only DROP VIEW and DROP TABLE are supported")
+		}
+		if len(drop.Objects) != 1 {
+			return nil, fmt.Errorf("drop one view or table at a time")
+		}
+		items := drop.Objects[0].GetList().GetItems()
+		if len(items) != 1 || items[0].GetString_() == nil {
+			return nil, fmt.Errorf("views and tables must be referenced by their plain name, without a schema")
+		}
+		return &analysisStatement{
+			kind:       analysisStatementKindDrop,
+			object:     strings.ToLower(items[0].GetString_().Sval),
+			objectKind: kind,
+			missingOK:  drop.MissingOk,
+		}, nil
+	}
+	return query, nil
+}
+
+func derivedObjectName(rel *pg_query.RangeVar) (string, error) {
+	if rel == nil {
+		return "", fmt.Errorf("missing view or table name")
+	}
+	if rel.Schemaname != "" || rel.Catalogname != "" {
+		return "", fmt.Errorf("views and tables are created in this conversation's workspace; do not qualify %q with a schema", rel.Relname)
+	}
+	if rel.Relpersistence != "p" {
+		return "", fmt.Errorf("temporary or unlogged objects are not supported; create a plain view or table")
+	}
+	name := strings.ToLower(rel.Relname)
+	if !derivedObjectNamePattern.MatchString(name) {
+		return "", fmt.Errorf("invalid name %q: use letters, digits and underscores only", rel.Relname)
+	}
+	if strings.HasPrefix(name, "k_") {
+		return "", fmt.Errorf("names starting with 'k_' are reserved for loaded files")
+	}
+	return name, nil
+}
+
+func deparseAnalysisQuery(query *pg_query.Node) (string, error) {
+	if query == nil || query.GetSelectStmt() == nil {
+		return "", fmt.Errorf("views and tables must be defined by a SELECT query")
+	}
+	text, err := pg_query.Deparse(&pg_query.ParseResult{Stmts: []*pg_query.RawStmt{{Stmt: query}}})
+	if err != nil {
+		return "", fmt.Errorf("failed to read the defining query: %w", err)
+	}
+	return text, nil
+}
+
+// isReadOnlyAnalysisSQL mirrors the prefix check of the per-call path.
+func isReadOnlyAnalysisSQL(sqlText string) bool {
+	normalizedSQL := strings.TrimSpace(strings.ToLower(sqlText))
+	for _, prefix := range []string{"select", "show", "describe", "explain", "pragma"} {
+		if strings.HasPrefix(normalizedSQL, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// executeInSession is Execute for tools attached to an analysis session.
+func (t *DataAnalysisTool) executeInSession(ctx context.Context, input DataAnalysisInput) (*types.ToolResult, error) {
+	var result *analysisResult
+	err := t.sessions.run(ctx, t.sessionID, func(q duckQuerier, sess *AnalysisSession) error {
+		var runErr error
+		result, runErr = t.runAnalysisStatement(ctx, q, sess, input, false)
+		return runErr
+	})
+	if err != nil {
+		logger.Warnf(ctx, "[Tool][DataAnalysis] Session statement failed for session %s: %v", t.sessionID, err)
+		return &types.ToolResult{Success: false, Error: err.Error()}, err
+	}
+
+	var output string
+	if result.statement.kind == analysisStatementKindQuery {
+		output = t.formatQueryResults(result.rows, result.sql)
+		logger.Infof(ctx, "[Tool][DataAnalysis] Completed execution query, total %d rows for session %s", len(result.rows), t.sessionID)
+	} else {
+		output = formatAnalysisDDLResult(result)
+	}
+	output += formatAnalysisSessionObjects(result)
+
+	data := map[string]interface{}{
+		"query":          result.sql,
+		"statement_kind": result.statement.kind,
+		"sources":        result.sources,
+		"display_type":   ToolDataAnalysis,
+		"session_id":     t.sessionID,
+	}
+	if result.statement.kind == analysisStatementKindQuery {
+		data["rows"] = result.rows
+		data["row_count"] = len(result.rows)
+	} else {
+		data["object"] = result.statement.object
+		data["object_kind"] = result.statement.objectKind
+	}
+	return &types.ToolResult{Success: true, Output: output, Data: data}, nil
+}
+
+// runAnalysisStatement loads the requested file if needed, classifies and
+// validates the SQL against the objects this turn may see, and runs it. With
+// queryOnly set, DDL is refused (the chart tool only reads).
+func (t *DataAnalysisTool) runAnalysisStatement(
+	ctx context.Context,
+	q duckQuerier,
+	sess *AnalysisSession,
+	input DataAnalysisInput,
+	queryOnly bool,
+) (*analysisResult, error) {
+	var schema *TableSchema
+	if knowledgeID := strings.TrimSpace(input.KnowledgeID); knowledgeID != "" {
+		loaded, err := t.loadSessionSource(ctx, q, sess, knowledgeID)
+		if err != nil {
+			return nil, err
+		}
+		schema = loaded
+	}
+	if strings.TrimSpace(input.Sql) == "" {
+		return nil, fmt.Errorf("sql is required")
+	}
+	if len(sess.sources) == 0 {
+		return nil, fmt.Errorf("no file is loaded in this conversation yet; pass knowledge_id of a CSV or Excel document")
+	}
+
+	sqlText := input.Sql
+	for _, src := range sess.sourceList() {
+		sqlText = strings.ReplaceAll(sqlText, src.KnowledgeID, src.Table)
+	}
+	if schema != nil {
+		if rewrittenSQL, fixes := reconcileSQLColumnsWithSchema(sqlText, schema); len(fixes) > 0 {
+			logger.Infof(ctx, "[Tool][DataAnalysis] Auto-rewrote SQL identifiers for session %s: %v", t.sessionID, fixes)
+			sqlText = rewrittenSQL
+		}
+	}
+
+	stmt, err := classifyAnalysisStatement(sqlText)
+	if err != nil {
+		return nil, err
+	}
+	if queryOnly && stmt.kind != analysisStatementKindQuery {
+		return nil, fmt.Errorf("only read-only SELECT queries are allowed here")
+	}
+	if stmt.kind == analysisStatementKindQuery && !isReadOnlyAnalysisSQL(sqlText) {
+		return nil, fmt.Errorf("only read-only queries (SELECT, SHOW, DESCRIBE, EXPLAIN, PRAGMA), CREATE VIEW/TABLE ... AS SELECT and DROP VIEW/TABLE are allowed")
+	}
+
+	result := &analysisResult{statement: stmt, sql: sqlText}
+	switch stmt.kind {
+	case analysisStatementKindQuery, analysisStatementKindCreate:
+		if stmt.kind == analysisStatementKindCreate {
+			if _, isSource := sess.sources[stmt.object]; isSource {
+				return nil, fmt.Errorf("%q is a loaded file and cannot be replaced", stmt.object)
+			}
+			if _, exists := sess.derived[stmt.object]; !exists && len(sess.derived) >= maxDerivedObjectsPerSession {
+				return nil, fmt.Errorf("this conversation already keeps %d views/tables; drop one you no longer need first", len(sess.derived))
+			}
+		}
+		_, validation := utils.ValidateSQL(stmt.query,
+			utils.WithAllowedTables(t.sessionObjects(ctx, sess)...),
+			utils.WithSingleStatement(),
+			utils.WithNoDangerousFunctions(),
+			utils.WithNoSchemaAccess(),
+		)
+		if !validation.Valid {
+			logger.Warnf(ctx, "[Tool][DataAnalysis] SQL validation failed for session %s: %v", t.sessionID, validation.Errors)
+			return nil, fmt.Errorf("SQL validation failed: %v", validation.Errors)
+		}
+		if stmt.kind == analysisStatementKindCreate {
+			if _, err := q.ExecContext(ctx, sqlText); err != nil {
+				return nil, fmt.Errorf("failed to create %s %q: %w", stmt.objectKind, stmt.object, err)
+			}
+			sess.derived[stmt.object] = stmt.objectKind
+			break
+		}
+		columns, rows, err := t.queryRows(ctx, q, sqlText)
+		if err != nil {
+			if suggestion := buildMissingColumnSuggestion(err, schema); suggestion != "" {
+				return nil, fmt.Errorf("query execution failed: %v. %s", err, suggestion)
+			}
+			return nil, fmt.Errorf("query execution failed: %v", err)
+		}
+		result.columns, result.rows = columns, rows
+	case analysisStatementKindDrop:
+		kind, ok := sess.derived[stmt.object]
+		if !ok {
+			if _, isSource := sess.sources[stmt.object]; isSource {
+				return nil, fmt.Errorf("%q is a loaded file and cannot be dropped", stmt.object)
+			}
+			if !stmt.missingOK {
+				return nil, fmt.Errorf("no view or table named %q was created in this conversation", stmt.object)
+			}
+			break
+		}
+		if kind != stmt.objectKind {
+			return nil, fmt.Errorf("%q is a %s, use DROP %s", stmt.object, kind, strings.ToUpper(kind))
+		}
+		if _, err := q.ExecContext(ctx, sqlText); err != nil {
+			return nil, fmt.Errorf("failed to drop %s %q: %w", kind, stmt.object, err)
+		}
+		delete(sess.derived, stmt.object)
+	}
+
+	result.sources = sess.sourceList()
+	result.derived = sess.derivedList()
+	return result, nil
+}
+
+// loadSessionSource loads a knowledge file into the session once; later calls
+// only re-check that the document is still within this turn's scope.
+func (t *DataAnalysisTool) loadSessionSource(ctx context.Context, q duckQuerier, sess *AnalysisSession, knowledgeID string) (*TableSchema, error) {
+	var knowledge *types.Knowledge
+	var err error
+	if t.scopeEnforced {
+		knowledge, err = authorizeKnowledgeInSearchTargets(ctx, t.searchTargets, knowledgeID, t.knowledgeService)
+	} else {
+		knowledge, err = t.knowledgeService.GetKnowledgeByIDOnly(ctx, knowledgeID)
+		if err == nil && knowledge == nil {
+			err = fmt.Errorf("knowledge service returned an empty result")
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load knowledge ID '%s': %w", knowledgeID, err)
+	}
+
+	tableName := t.TableName(knowledge)
+	if _, loaded := sess.source(tableName); !loaded {
+		localPath, cleanup, err := t.materializeKnowledgeFile(ctx, knowledge)
+		if err != nil {
+			return nil, fmt.Errorf("failed to materialize knowledge '%s' for DuckDB: %w", knowledge.ID, err)
+		}
+		defer cleanup()
+
+		fileType := strings.ToLower(knowledge.FileType)
+		var sheets []string
+		switch fileType {
+		case "csv":
+			err = t.createTableFromCSV(ctx, q, localPath, tableName)
+		case "xlsx", "xls":
+			sheets, err = t.createTableFromExcel(ctx, q, localPath, tableName)
+		default:
+			err = fmt.Errorf("unsupported file type: %s (supported types: csv, xlsx, xls)", fileType)
+		}
+		if err != nil {
+			return nil, err
+		}
+		sess.sources[tableName] = AnalysisSource{
+			Table:       tableName,
+			KnowledgeID: knowledge.ID,
+			FileName:    knowledge.FileName,
+			FileType:    fileType,
+			Sheets:      sheets,
+		}
+	}
+	return t.describeTable(ctx, q, tableName)
+}
+
+// sessionObjects returns the names this turn may reference. A file that has
+// left the Agent's scope since it was loaded stays in the session but cannot
+// be queried; derived objects are hidden too in that case, because any of
+// them may read from the file.
+func (t *DataAnalysisTool) sessionObjects(ctx context.Context, sess *AnalysisSession) []string {
+	names := make([]string, 0, len(sess.sources)+len(sess.derived))
+	allInScope := true
+	for _, src := range sess.sourceList() {
+		if t.scopeEnforced {
+			if _, err := authorizeKnowledgeInSearchTargets(ctx, t.searchTargets, src.KnowledgeID, t.knowledgeService); err != nil {
+				allInScope = false
+				continue
+			}
+		}
+		names = append(names, src.Table)
+	}
+	if allInScope {
+		for name := range sess.derived {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func formatAnalysisDDLResult(result *analysisResult) string {
+	stmt := result.statement
+	switch stmt.kind {
+	case analysisStatementKindCreate:
+		return fmt.Sprintf("=== Analysis Session ===\n\nExecuted SQL: %s\n\nCreated %s %q. It stays available in later turns of this conversation.\n", result.sql, stmt.objectKind, stmt.object)
+	default:
+		return fmt.Sprintf("=== Analysis Session ===\n\nExecuted SQL: %s\n\nDropped %s %q.\n", result.sql, stmt.objectKind, stmt.object)
+	}
+}
+
+// formatAnalysisSessionObjects tells the model what it can build on next.
+func formatAnalysisSessionObjects(result *analysisResult) string {
+	var b strings.Builder
+	b.WriteString("\n=== Session Objects ===\n")
+	for _, src := range result.sources {
+		b.WriteString(fmt.Sprintf("- file %s (%s), query it by its knowledge ID\n", src.KnowledgeID, src.FileName))
+	}
+	for _, name := range result.derived {
+		b.WriteString(fmt.Sprintf("- %s\n", name))
+	}
+	return b.String()
+}
@@ -0,0 +1,193 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	_ "github.com/duckdb/duckdb-go/v2"
+)
+
+const testSalesCSV = "region,amount\nnorth,10\nsouth,20\nnorth,5\n"
+
+// newSessionTestTool builds a data_analysis tool for one chat turn. Every
+// turn gets a fresh tool, as the agent service does, while sessions is shared.
+func newSessionTestTool(sessions *AnalysisSessions, db *sql.DB, sessionID string) *DataAnalysisTool {
+	knowledge := &types.Knowledge{ID: "doc-1", FileName: "sales.csv", FileType: "csv", FilePath: "tenants/1/sales.csv"}
+	tool := &DataAnalysisTool{
+		BaseTool:         dataAnalysisTool,
+		knowledgeService: &scopeKnowledgeService{knowledge: knowledge},
+		fileService: &fakeFileService{readers: map[string]func() (io.ReadCloser, error){
+			knowledge.FilePath: func() (io.ReadCloser, error) {
+				return io.NopCloser(bytes.NewReader([]byte(testSalesCSV))), nil
+			},
+		}},
+		db:        db,
+		sessionID: sessionID,
+	}
+	return tool.WithAnalysisSessions(sessions)
+}
+
+func newSessionTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("duckdb", ":memory:")
+	if err != nil {
+		t.Fatalf("open duckdb: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	return db
+}
+
+func runSessionSQL(t *testing.T, tool *DataAnalysisTool, input DataAnalysisInput) *types.ToolResult {
+	t.Helper()
+	result, err := tool.Execute(context.Background(), mustTestArgs(t, input))
+	if err != nil {
+		t.Fatalf("execute %q: %v", input.Sql, err)
+	}
+	return result
+}
+
+func TestAnalysisSession_ViewPersistsAcrossTurns(t *testing.T) {
+	db := newSessionTestDB(t)
+	sessions := NewAnalysisSessions(db)
+	ctx := context.Background()
+	t.Cleanup(func() { sessions.Drop(ctx, "s1") })
+
+	first := newSessionTestTool(sessions, db, "s1")
+	created := runSessionSQL(t, first, DataAnalysisInput{
+		KnowledgeID: "doc-1",
+		Sql:         "CREATE VIEW by_region AS SELECT region, SUM(CAST(amount AS DOUBLE)) AS total FROM doc-1 GROUP BY region",
+	})
+	if created.Data["statement_kind"] != analysisStatementKindCreate || created.Data["object"] != "by_region" {
+		t.Fatalf("unexpected create result: %+v", created.Data)
+	}
+
+	// A later turn queries the view by name without passing knowledge_id.
+	second := newSessionTestTool(sessions, db, "s1")
+	queried := runSessionSQL(t, second, DataAnalysisInput{Sql: "SELECT region, total FROM by_region ORDER BY region"})
+	rows, _ := queried.Data["rows"].([]map[string]string)
+	if len(rows) != 2 || rows[0]["region"] != "north" || rows[0]["total"] != "15" {
+		t.Fatalf("unexpected view rows: %+v", queried.Data["rows"])
+	}
+	sources, _ := queried.Data["sources"].([]AnalysisSource)
+	if len(sources) != 1 || sources[0].FileName != "sales.csv" || sources[0].Table != "k_doc_1" {
+		t.Fatalf("unexpected sources: %+v", sources)
+	}
+
+	dropped := runSessionSQL(t, second, DataAnalysisInput{Sql: "DROP VIEW by_region"})
+	if dropped.Data["statement_kind"] != analysisStatementKindDrop {
+		t.Fatalf("unexpected drop result: %+v", dropped.Data)
+	}
+	if _, err := second.Execute(ctx, mustTestArgs(t, DataAnalysisInput{Sql: "SELECT * FROM by_region"})); err == nil {
+		t.Fatal("expected dropped view to be rejected")
+	}
+}
+
+func TestAnalysisSession_SessionsAreIsolated(t *testing.T) {
+	db := newSessionTestDB(t)
+	sessions := NewAnalysisSessions(db)
+	ctx := context.Background()
+	t.Cleanup(func() {
+		sessions.Drop(ctx, "a")
+		sessions.Drop(ctx, "b")
+	})
+
+	runSessionSQL(t, newSessionTestTool(sessions, db, "a"), DataAnalysisInput{
+		KnowledgeID: "doc-1",
+		Sql:         "CREATE TABLE north AS SELECT * FROM doc-1 WHERE region = 'north'",
+	})
+
+	other := newSessionTestTool(sessions, db, "b")
+	_, err := other.Execute(ctx, mustTestArgs(t, DataAnalysisInput{KnowledgeID: "doc-1", Sql: "SELECT * FROM north"}))
+	if err == nil {
+		t.Fatal("expected a table of another session to be invisible")
+	}
+}
+
+func TestAnalysisSession_RejectsUnsafeStatements(t *testing.T) {
+	db := newSessionTestDB(t)
+	sessions := NewAnalysisSessions(db)
+	ctx := context.Background()
+	t.Cleanup(func() { sessions.Drop(ctx, "s1") })
+	tool := newSessionTestTool(sessions, db, "s1")
+
+	for _, sqlText := range []string{
+		"CREATE VIEW main.v AS SELECT * FROM doc-1",
+		"CREATE VIEW k_other AS SELECT * FROM doc-1",
+		"CREATE TABLE doc-1 AS SELECT 1",
+		"DROP TABLE doc-1",
+		"DELETE FROM doc-1",
+		"SELECT * FROM main.k_doc_1",
+	} {
+		if _, err := tool.Execute(ctx, mustTestArgs(t, DataAnalysisInput{KnowledgeID: "doc-1", Sql: sqlText})); err == nil {
+			t.Errorf("expected %q to be rejected", sqlText)
+		}
+	}
+}
+
+func TestAnalysisSessions_DropRemovesSchema(t *testing.T) {
+	db := newSessionTestDB(t)
+	sessions := NewAnalysisSessions(db)
+	ctx := context.Background()
+
+	runSessionSQL(t, newSessionTestTool(sessions, db, "s1"), DataAnalysisInput{KnowledgeID: "doc-1", Sql: "SELECT COUNT(*) AS n FROM doc-1"})
+	sessions.Drop(ctx, "s1")
+
+	var n int
+	if err := db.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM information_schema.schemata WHERE schema_name = ?", analysisSchemaName("s1"),
+	).Scan(&n); err != nil {
+		t.Fatalf("query schemata: %v", err)
+	}
+	if n != 0 {
+		t.Fatalf("expected session schema to be dropped, found %d", n)
+	}
+}
+
+func TestClassifyAnalysisStatement(t *testing.T) {
+	cases := []struct {
+		sql        string
+		kind       string
+		object     string
+		objectKind string
+		wantErr    bool
+	}{
+		{sql: "SELECT 1", kind: analysisStatementKindQuery},
+		{sql: "CREATE VIEW v AS SELECT 1", kind: analysisStatementKindCreate, object: "v", objectKind: analysisDerivedKindView},
+		{sql: "CREATE OR REPLACE VIEW v AS SELECT 1", kind: analysisStatementKindCreate, object: "v", objectKind: analysisDerivedKindView},
+		{sql: "CREATE TABLE t AS SELECT 1", kind: analysisStatementKindCreate, object: "t", objectKind: analysisDerivedKindTable},
+		{sql: "DROP VIEW IF EXISTS v", kind: analysisStatementKindDrop, object: "v", objectKind: analysisDerivedKindView},
+		{sql: "DROP TABLE a, b", wantErr: true},
+		{sql: "CREATE TEMP VIEW v AS SELECT 1", wantErr: true},
+		{sql: "CREATE VIEW \"Bad Name\" AS SELECT 1", wantErr: true},
+	}
+	for _, tc := range cases {
+		stmt, err := classifyAnalysisStatement(tc.sql)
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("%q: expected error, got %+v", tc.sql, stmt)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: unexpected error %v", tc.sql, err)
+			continue
+		}
+		if stmt.kind != tc.kind || stmt.object != tc.object || stmt.objectKind != tc.objectKind {
+			t.Errorf("%q: got kind=%s object=%s objectKind=%s", tc.sql, stmt.kind, stmt.object, stmt.objectKind)
+		}
+	}
+}
+
+func mustTestArgs(t *testing.T, input interface{}) json.RawMessage {
+	t.Helper()
+	args, err := json.Marshal(input)
+	if err != nil {
+		t.Fatalf("marshal args: %v", err)
+	}
+	return args
+}
@@ -0,0 +1,393 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/utils"
+)
+
+var dataChartTool = BaseTool{
+	name: ToolDataChart,
+	description: "Use this tool to draw a chart from CSV or Excel data, or from views and tables created with data_analysis in this conversation. " +
+		"Give a read-only SELECT that already aggregates the data (one row per point or category), the chart type, the x column and the numeric y column(s). " +
+		"The chart is shown to the user and attached to the answer as an SVG image and a Vega-Lite spec; do not describe how to draw it yourself.",
+	schema: utils.GenerateSchema[DataChartInput](),
+}
+
+// Chart types accepted by data_chart.
+const (
+	chartTypeBar     = "bar"
+	chartTypeLine    = "line"
+	chartTypeArea    = "area"
+	chartTypeScatter = "scatter"
+	chartTypePie     = "pie"
+)
+
+// maxChartRows bounds the points sent to the renderer and inlined into the
+// spec; a chart with more points is unreadable anyway.
+const maxChartRows = 500
+
+// maxInlineChartSVGBytes caps the SVG kept in the tool result for inline
+// display. Larger charts are still attached as artifacts.
+const maxInlineChartSVGBytes = 256 * 1024
+
+// vegaLiteSchemaURL pins the spec to Vega-Lite v5.
+const vegaLiteSchemaURL = "https://vega.github.io/schema/vega-lite/v5.json"
+
+// ArtifactStager accepts files produced in-process during a turn, such as
+// rendered charts, so they are attached to the assistant message together
+// with sandbox artifacts when the turn completes.
+type ArtifactStager interface {
+	StageArtifact(ctx context.Context, sessionID, fileName string, data []byte) error
+}
+
+type DataChartInput struct {
+	KnowledgeID string   `json:"knowledge_id,omitempty" jsonschema:"short dN document ID to load; may be omitted when the SQL only uses files or views already loaded in this conversation"`
+	Sql         string   `json:"sql" jsonschema:"read-only SELECT whose result is plotted, already aggregated to one row per point"`
+	ChartType   string   `json:"chart_type" jsonschema:"bar, line, area, scatter or pie"`
+	X           string   `json:"x" jsonschema:"result column for the x axis (the category column for pie)"`
+	Y           []string `json:"y" jsonschema:"numeric result column(s) to plot; pie takes exactly one"`
+	Title       string   `json:"title,omitempty" jsonschema:"chart title shown to the user"`
+}
+
+// DataChartTool plots the result of a query run in the chat session's
+// analysis session.
+type DataChartTool struct {
+	BaseTool
+	analysis *DataAnalysisTool
+	stager   ArtifactStager
+}
+
+// NewDataChartTool builds the chart tool on top of a data analysis tool that
+// is attached to an analysis session. stager may be nil, in which case the
+// chart is only shown inline.
+func NewDataChartTool(analysis *DataAnalysisTool, stager ArtifactStager) *DataChartTool {
+	return &DataChartTool{
+		BaseTool: dataChartTool,
+		analysis: analysis,
+		stager:   stager,
+	}
+}
+
+// Execute runs the query, renders the chart and stages it as artifacts.
+func (t *DataChartTool) Execute(ctx context.Context, args json.RawMessage) (*types.ToolResult, error) {
+	var input DataChartInput
+	if err := json.Unmarshal(args, &input); err != nil {
+		return &types.ToolResult{
+			Success: false,
+			Error:   fmt.Sprintf("Failed to parse input args: %v", err),
+		}, err
+	}
+	if err := validateChartInput(&input); err != nil {
+		return &types.ToolResult{Success: false, Error: err.Error()}, err
+	}
+	if t.analysis == nil || t.analysis.sessions == nil {
+		err := fmt.Errorf("charts need an analysis session, which is not available here")
+		return &types.ToolResult{Success: false, Error: err.Error()}, err
+	}
+
+	var result *analysisResult
+	err := t.analysis.sessions.run(ctx, t.analysis.sessionID, func(q duckQuerier, sess *AnalysisSession) error {
+		var runErr error
+		result, runErr = t.analysis.runAnalysisStatement(ctx, q,
+			sess, DataAnalysisInput{KnowledgeID: input.KnowledgeID, Sql: input.Sql}, true)
+		return runErr
+	})
+	if err != nil {
+		logger.Warnf(ctx, "[Tool][DataChart] Query failed for session %s: %v", t.analysis.sessionID, err)
+		return &types.ToolResult{Success: false, Error: err.Error()}, err
+	}
+
+	chart, err := buildChartData(&input, result.columns, result.rows)
+	if err != nil {
+		return &types.ToolResult{Success: false, Error: err.Error()}, err
+	}
+	spec := buildVegaLiteSpec(chart)
+	specJSON, err := json.MarshalIndent(spec, "", "  ")
+	if err != nil {
+		return &types.ToolResult{Success: false, Error: fmt.Sprintf("failed to encode chart spec: %v", err)}, err
+	}
+	svg := renderChartSVG(chart)
+
+	baseName := chartFileBaseName(chart.title)
+	var attached []string
+	if t.stager != nil {
+		for _, file := range []struct {
+			name string
+			data []byte
+		}{
+			{baseName + ".svg", svg},
+			{baseName + ".vl.json", specJSON},
+		} {
+			if err := t.stager.StageArtifact(ctx, t.analysis.sessionID, file.name, file.data); err != nil {
+				logger.Warnf(ctx, "[Tool][DataChart] Failed to stage %s for session %s: %v", file.name, t.analysis.sessionID, err)
+				continue
+			}
+			attached = append(attached, file.name)
+		}
+	}
+
+	var output strings.Builder
+	output.WriteString("=== Chart Created ===\n\n")
+	output.WriteString(fmt.Sprintf("Executed SQL: %s\n\n", result.sql))
+	output.WriteString(fmt.Sprintf("Rendered a %s chart %q with %d points (x=%s, y=%s).\n",
+		chart.kind, chart.title, len(chart.categories), chart.x, strings.Join(input.Y, ", ")))
+	if chart.truncated {
+		output.WriteString(fmt.Sprintf("Only the first %d rows were plotted; aggregate further for a complete chart.\n", maxChartRows))
+	}
+	if len(attached) > 0 {
+		output.WriteString(fmt.Sprintf("Attached to the answer: %s. The user already sees the chart; do not redraw it in text.\n", strings.Join(attached, ", ")))
+	} else {
+		output.WriteString("The chart is shown to the user inline; do not redraw it in text.\n")
+	}
+
+	data := map[string]interface{}{
+		"display_type":   ToolDataChart,
+		"chart_type":     chart.kind,
+		"title":          chart.title,
+		"query":          result.sql,
+		"statement_kind": analysisStatementKindQuery,
+		"sources":        result.sources,
+		"row_count":      len(chart.categories),
+		"spec":           spec,
+		"artifacts":      attached,
+		"session_id":     t.analysis.sessionID,
+	}
+	if len(svg) <= maxInlineChartSVGBytes {
+		data["svg"] = string(svg)
+	}
+	logger.Infof(ctx, "[Tool][DataChart] Rendered %s chart with %d points for session %s", chart.kind, len(chart.categories), t.analysis.sessionID)
+	return &types.ToolResult{Success: true, Output: output.String(), Data: data}, nil
+}
+
+func validateChartInput(input *DataChartInput) error {
+	input.ChartType = strings.ToLower(strings.TrimSpace(input.ChartType))
+	switch input.ChartType {
+	case chartTypeBar, chartTypeLine, chartTypeArea, chartTypeScatter, chartTypePie:
+	default:
+		return fmt.Errorf("unsupported chart_type %q (supported: bar, line, area, scatter, pie)", input.ChartType)
+	}
+	input.X = strings.TrimSpace(input.X)
+	if input.X == "" {
+		return fmt.Errorf("x is required")
+	}
+	ys := make([]string, 0, len(input.Y))
+	for _, y := range input.Y {
+		if y = strings.TrimSpace(y); y != "" {
+			ys = append(ys, y)
+		}
+	}
+	if len(ys) == 0 {
+		return fmt.Errorf("y needs at least one numeric column")
+	}
+	if input.ChartType == chartTypePie && len(ys) != 1 {
+		return fmt.Errorf("a pie chart takes exactly one y column")
+	}
+	input.Y = ys
+	return nil
+}
+
+// chartSeries is one plotted y column. valid marks the rows whose value
+// parsed as a number; the others are gaps.
+type chartSeries struct {
+	name   string
+	values []float64
+	valid  []bool
+}
+
+// chartData is the renderer-neutral form shared by the SVG renderer and the
+// Vega-Lite spec.
+type chartData struct {
+	kind       string
+	title      string
+	x          string
+	categories []string
+	// xValues holds the parsed x values when every category is numeric, so
+	// line, area and scatter charts can use a linear axis.
+	xValues   []float64
+	xNumeric  bool
+	series    []chartSeries
+	truncated bool
+}
+
+func buildChartData(input *DataChartInput, columns []string, rows []map[string]string) (*chartData, error) {
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("the query returned no rows to plot")
+	}
+	known := make(map[string]bool, len(columns))
+	for _, col := range columns {
+		known[col] = true
+	}
+	for _, col := range append([]string{input.X}, input.Y...) {
+		if !known[col] {
+			return nil, fmt.Errorf("column %q is not in the query result (columns: %s)", col, strings.Join(columns, ", "))
+		}
+	}
+
+	chart := &chartData{kind: input.ChartType, title: strings.TrimSpace(input.Title), x: input.X}
+	if chart.title == "" {
+		chart.title = strings.Join(input.Y, ", ") + " by " + input.X
+	}
+	if len(rows) > maxChartRows {
+		rows = rows[:maxChartRows]
+		chart.truncated = true
+	}
+
+	chart.xNumeric = true
+	for _, row := range rows {
+		category := row[input.X]
+		chart.categories = append(chart.categories, category)
+		v, ok := parseChartNumber(category)
+		chart.xValues = append(chart.xValues, v)
+		if !ok {
+			chart.xNumeric = false
+		}
+	}
+	for _, y := range input.Y {
+		series := chartSeries{name: y}
+		anyValid := false
+		for _, row := range rows {
+			v, ok := parseChartNumber(row[y])
+			series.values = append(series.values, v)
+			series.valid = append(series.valid, ok)
+			anyValid = anyValid || ok
+		}
+		if !anyValid {
+			return nil, fmt.Errorf("column %q has no numeric values; cast it or pick another column", y)
+		}
+		chart.series = append(chart.series, series)
+	}
+	if chart.kind == chartTypePie {
+		total := 0.0
+		for i, v := range chart.series[0].values {
+			if chart.series[0].valid[i] && v > 0 {
+				total += v
+			}
+		}
+		if total <= 0 {
+			return nil, fmt.Errorf("a pie chart needs positive values in %q", input.Y[0])
+		}
+	}
+	return chart, nil
+}
+
+// parseChartNumber reads the string values DuckDB rows come back as. NULLs
+// are rendered as "<nil>" by the row scanner and count as gaps.
+func parseChartNumber(s string) (float64, bool) {
+	s = strings.TrimSpace(s)
+	if s == "" || s == "<nil>" {
+		return 0, false
+	}
+	v, err := strconv.ParseFloat(strings.ReplaceAll(s, ",", ""), 64)
+	if err != nil {
+		return 0, false
+	}
+	return v, true
+}
+
+// buildVegaLiteSpec emits a self-contained Vega-Lite v5 spec with the data
+// inlined, so the attachment renders without access to WeKnora.
+func buildVegaLiteSpec(chart *chartData) map[string]interface{} {
+	values := make([]map[string]interface{}, 0, len(chart.categories))
+	for i, category := range chart.categories {
+		row := map[string]interface{}{}
+		if chart.xNumeric && chart.kind != chartTypeBar && chart.kind != chartTypePie {
+			row[chart.x] = chart.xValues[i]
+		} else {
+			row[chart.x] = category
+		}
+		for _, s := range chart.series {
+			if s.valid[i] {
+				row[s.name] = s.values[i]
+			} else {
+				row[s.name] = nil
+			}
+		}
+		values = append(values, row)
+	}
+
+	spec := map[string]interface{}{
+		"$schema": vegaLiteSchemaURL,
+		"title":   chart.title,
+		"width":   chartWidth - chartMarginLeft - chartMarginRight,
+		"height":  chartHeight - chartMarginTop - chartMarginBottom,
+		"data":    map[string]interface{}{"values": values},
+	}
+
+	if chart.kind == chartTypePie {
+		spec["mark"] = map[string]interface{}{"type": "arc", "tooltip": true}
+		spec["encoding"] = map[string]interface{}{
+			"theta": map[string]interface{}{"field": chart.series[0].name, "type": "quantitative"},
+			"color": map[string]interface{}{"field": chart.x, "type": "nominal"},
+		}
+		return spec
+	}
+
+	mark := map[string]string{
+		chartTypeBar:     "bar",
+		chartTypeLine:    "line",
+		chartTypeArea:    "area",
+		chartTypeScatter: "point",
+	}[chart.kind]
+	xType := "nominal"
+	if chart.kind != chartTypeBar {
+		xType = "ordinal"
+		if chart.xNumeric {
+			xType = "quantitative"
+		}
+	}
+	xEncoding := map[string]interface{}{"field": chart.x, "type": xType}
+	if xType != "quantitative" {
+		// Keep the order of the SQL result instead of sorting alphabetically.
+		xEncoding["sort"] = nil
+	}
+
+	if len(chart.series) == 1 {
+		spec["mark"] = map[string]interface{}{"type": mark, "tooltip": true}
+		spec["encoding"] = map[string]interface{}{
+			"x": xEncoding,
+			"y": map[string]interface{}{"field": chart.series[0].name, "type": "quantitative"},
+		}
+		return spec
+	}
+
+	folded := make([]string, 0, len(chart.series))
+	for _, s := range chart.series {
+		folded = append(folded, s.name)
+	}
+	spec["transform"] = []map[string]interface{}{
+		{"fold": folded, "as": []string{"series", "value"}},
+	}
+	spec["mark"] = map[string]interface{}{"type": mark, "tooltip": true}
+	encoding := map[string]interface{}{
+		"x":     xEncoding,
+		"y":     map[string]interface{}{"field": "value", "type": "quantitative"},
+		"color": map[string]interface{}{"field": "series", "type": "nominal"},
+	}
+	if chart.kind == chartTypeBar {
+		encoding["xOffset"] = map[string]interface{}{"field": "series"}
+	}
+	spec["encoding"] = encoding
+	return spec
+}
+
+var chartFileNameUnsafe = regexp.MustCompile(`[^\p{L}\p{N}_-]+`)
+
+// chartFileBaseName turns the chart title into a file name stem.
+func chartFileBaseName(title string) string {
+	name := strings.Trim(chartFileNameUnsafe.ReplaceAllString(strings.TrimSpace(title), "_"), "_")
+	if runes := []rune(name); len(runes) > 60 {
+		name = string(runes[:60])
+	}
+	if name == "" {
+		return "chart"
+	}
+	return "chart_" + name
+}
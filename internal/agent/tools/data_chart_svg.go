@@ -0,0 +1,266 @@
+package tools
+
+import (
+	"fmt"
+	"html"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Fixed canvas for server-side rendering. The Vega-Lite spec uses the same
+// plot area so both attachments look alike.
+const (
+	chartWidth        = 720
+	chartHeight       = 420
+	chartMarginTop    = 48
+	chartMarginRight  = 24
+	chartMarginBottom = 72
+	chartMarginLeft   = 72
+	chartYTicks       = 5
+	chartMaxXLabels   = 12
+)
+
+// chartPalette is the categorical palette of the renderer (Tableau 10).
+var chartPalette = []string{
+	"#4e79a7", "#f28e2b", "#e15759", "#76b7b2", "#59a14f",
+	"#edc948", "#b07aa1", "#ff9da7", "#9c755f", "#bab0ac",
+}
+
+// renderChartSVG draws chart as a standalone SVG document. It covers the
+// chart types data_chart accepts with plain shapes, so no browser or
+// rendering service is needed on the server.
+func renderChartSVG(chart *chartData) []byte {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="sans-serif" font-size="12">`,
+		chartWidth, chartHeight, chartWidth, chartHeight))
+	b.WriteString(fmt.Sprintf(`<rect width="%d" height="%d" fill="#ffffff"/>`, chartWidth, chartHeight))
+	b.WriteString(fmt.Sprintf(`<text x="%d" y="28" text-anchor="middle" font-size="16" font-weight="bold">%s</text>`,
+		chartWidth/2, html.EscapeString(chart.title)))
+
+	if chart.kind == chartTypePie {
+		renderPieSVG(&b, chart)
+	} else {
+		renderCartesianSVG(&b, chart)
+	}
+	b.WriteString(`</svg>`)
+	return []byte(b.String())
+}
+
+func renderPieSVG(b *strings.Builder, chart *chartData) {
+	series := chart.series[0]
+	total := 0.0
+	for i, v := range series.values {
+		if series.valid[i] && v > 0 {
+			total += v
+		}
+	}
+	plotHeight := chartHeight - chartMarginTop - chartMarginBottom/2
+	radius := float64(plotHeight) / 2
+	cx := float64(chartMarginLeft) + radius
+	cy := float64(chartMarginTop) + radius
+
+	angle := -math.Pi / 2
+	legend := 0
+	for i, v := range series.values {
+		if !series.valid[i] || v <= 0 {
+			continue
+		}
+		color := chartPalette[legend%len(chartPalette)]
+		sweep := v / total * 2 * math.Pi
+		if sweep >= 2*math.Pi-1e-9 {
+			b.WriteString(fmt.Sprintf(`<circle cx="%s" cy="%s" r="%s" fill="%s"/>`,
+				svgNum(cx), svgNum(cy), svgNum(radius), color))
+		} else {
+			x1, y1 := cx+radius*math.Cos(angle), cy+radius*math.Sin(angle)
+			x2, y2 := cx+radius*math.Cos(angle+sweep), cy+radius*math.Sin(angle+sweep)
+			largeArc := 0
+			if sweep > math.Pi {
+				largeArc = 1
+			}
+			b.WriteString(fmt.Sprintf(`<path d="M%s,%s L%s,%s A%s,%s 0 %d 1 %s,%s Z" fill="%s" stroke="#ffffff"><title>%s: %s</title></path>`,
+				svgNum(cx), svgNum(cy), svgNum(x1), svgNum(y1), svgNum(radius), svgNum(radius),
+				largeArc, svgNum(x2), svgNum(y2), color,
+				html.EscapeString(chart.categories[i]), html.EscapeString(formatChartValue(v))))
+		}
+		angle += sweep
+
+		if legend < chartMaxXLabels*2 {
+			lx := int(cx+radius) + 40
+			ly := chartMarginTop + legend*18
+			b.WriteString(fmt.Sprintf(`<rect x="%d" y="%d" width="12" height="12" fill="%s"/>`, lx, ly, color))
+			b.WriteString(fmt.Sprintf(`<text x="%d" y="%d">%s (%.1f%%)</text>`, lx+18, ly+10,
+				html.EscapeString(truncateChartLabel(chart.categories[i], 32)), v/total*100))
+		}
+		legend++
+	}
+}
+
+func renderCartesianSVG(b *strings.Builder, chart *chartData) {
+	left, top := float64(chartMarginLeft), float64(chartMarginTop)
+	right, bottom := float64(chartWidth-chartMarginRight), float64(chartHeight-chartMarginBottom)
+	n := len(chart.categories)
+
+	minY, maxY := 0.0, 0.0
+	first := true
+	for _, s := range chart.series {
+		for i, v := range s.values {
+			if !s.valid[i] {
+				continue
+			}
+			if first {
+				minY, maxY, first = v, v, false
+				continue
+			}
+			minY, maxY = math.Min(minY, v), math.Max(maxY, v)
+		}
+	}
+	// Bars and areas grow from zero; lines and points may zoom in.
+	if chart.kind == chartTypeBar || chart.kind == chartTypeArea || minY > 0 && minY < maxY/2 {
+		minY = math.Min(minY, 0)
+		maxY = math.Max(maxY, 0)
+	}
+	step := niceChartStep((maxY - minY) / chartYTicks)
+	minY = math.Floor(minY/step) * step
+	maxY = math.Ceil(maxY/step) * step
+	if maxY == minY {
+		maxY = minY + step
+	}
+	yPos := func(v float64) float64 { return bottom - (v-minY)/(maxY-minY)*(bottom-top) }
+
+	// Numeric x is placed on a linear axis for line, area and scatter; every
+	// other combination uses evenly spaced bands in result order.
+	linearX := chart.xNumeric && chart.kind != chartTypeBar && n > 1
+	minX, maxX := 0.0, 0.0
+	if linearX {
+		minX, maxX = chart.xValues[0], chart.xValues[0]
+		for _, v := range chart.xValues {
+			minX, maxX = math.Min(minX, v), math.Max(maxX, v)
+		}
+		if minX == maxX {
+			linearX = false
+		}
+	}
+	band := (right - left) / float64(n)
+	xPos := func(i int) float64 {
+		if linearX {
+			return left + (chart.xValues[i]-minX)/(maxX-minX)*(right-left)
+		}
+		return left + band*(float64(i)+0.5)
+	}
+
+	// Grid and y axis labels.
+	for v := minY; v <= maxY+step/2; v += step {
+		y := yPos(v)
+		b.WriteString(fmt.Sprintf(`<line x1="%s" y1="%s" x2="%s" y2="%s" stroke="#e5e5e5"/>`,
+			svgNum(left), svgNum(y), svgNum(right), svgNum(y)))
+		b.WriteString(fmt.Sprintf(`<text x="%s" y="%s" text-anchor="end">%s</text>`,
+			svgNum(left-8), svgNum(y+4), html.EscapeString(formatChartValue(v))))
+	}
+	b.WriteString(fmt.Sprintf(`<line x1="%s" y1="%s" x2="%s" y2="%s" stroke="#666666"/>`,
+		svgNum(left), svgNum(bottom), svgNum(right), svgNum(bottom)))
+
+	// X axis labels, thinned out so they stay legible.
+	every := 1
+	if n > chartMaxXLabels {
+		every = int(math.Ceil(float64(n) / chartMaxXLabels))
+	}
+	for i := 0; i < n; i += every {
+		x := xPos(i)
+		b.WriteString(fmt.Sprintf(`<text x="%s" y="%s" text-anchor="end" transform="rotate(-35 %s %s)">%s</text>`,
+			svgNum(x), svgNum(bottom+16), svgNum(x), svgNum(bottom+16),
+			html.EscapeString(truncateChartLabel(chart.categories[i], 18))))
+	}
+	b.WriteString(fmt.Sprintf(`<text x="%s" y="%d" text-anchor="middle" fill="#666666">%s</text>`,
+		svgNum((left+right)/2), chartHeight-6, html.EscapeString(chart.x)))
+
+	for si, s := range chart.series {
+		color := chartPalette[si%len(chartPalette)]
+		switch chart.kind {
+		case chartTypeBar:
+			groupWidth := band * 0.8
+			barWidth := groupWidth / float64(len(chart.series))
+			zero := yPos(math.Max(minY, 0))
+			for i, v := range s.values {
+				if !s.valid[i] {
+					continue
+				}
+				x := left + band*float64(i) + (band-groupWidth)/2 + barWidth*float64(si)
+				y := yPos(v)
+				barTop, height := math.Min(y, zero), math.Abs(zero-y)
+				b.WriteString(fmt.Sprintf(`<rect x="%s" y="%s" width="%s" height="%s" fill="%s"><title>%s: %s</title></rect>`,
+					svgNum(x), svgNum(barTop), svgNum(barWidth), svgNum(height), color,
+					html.EscapeString(chart.categories[i]), html.EscapeString(formatChartValue(v))))
+			}
+		case chartTypeScatter:
+			for i, v := range s.values {
+				if !s.valid[i] {
+					continue
+				}
+				b.WriteString(fmt.Sprintf(`<circle cx="%s" cy="%s" r="4" fill="%s" fill-opacity="0.8"><title>%s: %s</title></circle>`,
+					svgNum(xPos(i)), svgNum(yPos(v)), color,
+					html.EscapeString(chart.categories[i]), html.EscapeString(formatChartValue(v))))
+			}
+		default:
+			var points []string
+			for i, v := range s.values {
+				if s.valid[i] {
+					points = append(points, svgNum(xPos(i))+","+svgNum(yPos(v)))
+				}
+			}
+			if len(points) == 0 {
+				continue
+			}
+			if chart.kind == chartTypeArea {
+				baseline := svgNum(yPos(math.Max(minY, 0)))
+				firstX := strings.SplitN(points[0], ",", 2)[0]
+				lastX := strings.SplitN(points[len(points)-1], ",", 2)[0]
+				b.WriteString(fmt.Sprintf(`<polygon points="%s,%s %s %s,%s" fill="%s" fill-opacity="0.35"/>`,
+					firstX, baseline, strings.Join(points, " "), lastX, baseline, color))
+			}
+			b.WriteString(fmt.Sprintf(`<polyline points="%s" fill="none" stroke="%s" stroke-width="2"/>`,
+				strings.Join(points, " "), color))
+		}
+	}
+
+	if len(chart.series) > 1 {
+		for si, s := range chart.series {
+			lx := int(left) + si*140
+			b.WriteString(fmt.Sprintf(`<rect x="%d" y="36" width="12" height="12" fill="%s"/>`, lx, chartPalette[si%len(chartPalette)]))
+			b.WriteString(fmt.Sprintf(`<text x="%d" y="46">%s</text>`, lx+18, html.EscapeString(truncateChartLabel(s.name, 16))))
+		}
+	}
+}
+
+// niceChartStep rounds a raw tick step to 1, 2 or 5 times a power of ten.
+func niceChartStep(raw float64) float64 {
+	if raw <= 0 || math.IsNaN(raw) || math.IsInf(raw, 0) {
+		return 1
+	}
+	magnitude := math.Pow(10, math.Floor(math.Log10(raw)))
+	for _, m := range []float64{1, 2, 5, 10} {
+		if raw <= m*magnitude {
+			return m * magnitude
+		}
+	}
+	return 10 * magnitude
+}
+
+func formatChartValue(v float64) string {
+	if v == math.Trunc(v) && math.Abs(v) < 1e15 {
+		return strconv.FormatFloat(v, 'f', 0, 64)
+	}
+	return strconv.FormatFloat(v, 'g', 6, 64)
+}
+
+func svgNum(v float64) string {
+	return strconv.FormatFloat(v, 'f', 1, 64)
+}
+
+func truncateChartLabel(s string, max int) string {
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	return string(runes[:max-1]) + "…"
+}
@@ -0,0 +1,182 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+type recordingStager struct {
+	files map[string][]byte
+}
+
+func (r *recordingStager) StageArtifact(_ context.Context, _ string, fileName string, data []byte) error {
+	if r.files == nil {
+		r.files = map[string][]byte{}
+	}
+	r.files[fileName] = data
+	return nil
+}
+
+func TestDataChartTool_StagesSVGAndSpec(t *testing.T) {
+	db := newSessionTestDB(t)
+	sessions := NewAnalysisSessions(db)
+	ctx := context.Background()
+	t.Cleanup(func() { sessions.Drop(ctx, "s1") })
+
+	stager := &recordingStager{}
+	tool := NewDataChartTool(newSessionTestTool(sessions, db, "s1"), stager)
+	result, err := tool.Execute(ctx, mustTestArgs(t, DataChartInput{
+		KnowledgeID: "doc-1",
+		Sql:         "SELECT region, SUM(CAST(amount AS DOUBLE)) AS total FROM doc-1 GROUP BY region ORDER BY region",
+		ChartType:   "bar",
+		X:           "region",
+		Y:           []string{"total"},
+		Title:       "Sales by region",
+	}))
+	if err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+
+	svg, ok := stager.files["chart_Sales_by_region.svg"]
+	if !ok {
+		t.Fatalf("svg not staged, got %v", result.Data["artifacts"])
+	}
+	if err := xml.Unmarshal(svg, new(struct{})); err != nil {
+		t.Fatalf("staged svg is not well-formed XML: %v", err)
+	}
+	if result.Data["svg"] != string(svg) {
+		t.Error("expected the svg to be returned inline as well")
+	}
+
+	var spec map[string]interface{}
+	if err := json.Unmarshal(stager.files["chart_Sales_by_region.vl.json"], &spec); err != nil {
+		t.Fatalf("staged spec is not JSON: %v", err)
+	}
+	values := spec["data"].(map[string]interface{})["values"].([]interface{})
+	if len(values) != 2 || values[0].(map[string]interface{})["total"] != float64(15) {
+		t.Fatalf("unexpected spec values: %v", values)
+	}
+}
+
+func TestDataChartTool_RejectsDDL(t *testing.T) {
+	db := newSessionTestDB(t)
+	sessions := NewAnalysisSessions(db)
+	ctx := context.Background()
+	t.Cleanup(func() { sessions.Drop(ctx, "s1") })
+
+	tool := NewDataChartTool(newSessionTestTool(sessions, db, "s1"), nil)
+	_, err := tool.Execute(ctx, mustTestArgs(t, DataChartInput{
+		KnowledgeID: "doc-1",
+		Sql:         "CREATE VIEW v AS SELECT region, amount FROM doc-1",
+		ChartType:   "bar",
+		X:           "region",
+		Y:           []string{"amount"},
+	}))
+	if err == nil {
+		t.Fatal("expected the chart tool to refuse DDL")
+	}
+}
+
+func TestBuildChartData_Validation(t *testing.T) {
+	columns := []string{"month", "revenue", "label"}
+	rows := []map[string]string{
+		{"month": "1", "revenue": "10.5", "label": "a"},
+		{"month": "2", "revenue": "<nil>", "label": "b"},
+	}
+
+	chart, err := buildChartData(&DataChartInput{ChartType: chartTypeLine, X: "month", Y: []string{"revenue"}}, columns, rows)
+	if err != nil {
+		t.Fatalf("buildChartData: %v", err)
+	}
+	if !chart.xNumeric || chart.series[0].valid[1] {
+		t.Errorf("expected numeric x and a gap for NULL, got %+v", chart)
+	}
+	if chart.title != "revenue by month" {
+		t.Errorf("unexpected default title %q", chart.title)
+	}
+
+	if _, err := buildChartData(&DataChartInput{ChartType: chartTypeBar, X: "month", Y: []string{"missing"}}, columns, rows); err == nil {
+		t.Error("expected unknown column to be rejected")
+	}
+	if _, err := buildChartData(&DataChartInput{ChartType: chartTypeBar, X: "month", Y: []string{"label"}}, columns, rows); err == nil {
+		t.Error("expected non-numeric y to be rejected")
+	}
+}
+
+func TestValidateChartInput(t *testing.T) {
+	input := &DataChartInput{ChartType: " Pie ", X: "region", Y: []string{"total", " "}}
+	if err := validateChartInput(input); err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	if input.ChartType != chartTypePie || len(input.Y) != 1 {
+		t.Errorf("input not normalized: %+v", input)
+	}
+	if err := validateChartInput(&DataChartInput{ChartType: "pie", X: "r", Y: []string{"a", "b"}}); err == nil {
+		t.Error("expected pie with two series to be rejected")
+	}
+	if err := validateChartInput(&DataChartInput{ChartType: "radar", X: "r", Y: []string{"a"}}); err == nil {
+		t.Error("expected unsupported chart type to be rejected")
+	}
+}
+
+func TestRenderChartSVG_AllTypesWellFormed(t *testing.T) {
+	columns := []string{"name", "a", "b"}
+	rows := []map[string]string{
+		{"name": "x<1>", "a": "3", "b": "-1"},
+		{"name": "y & z", "a": "5", "b": "2"},
+		{"name": "w", "a": "0", "b": "4"},
+	}
+	for _, kind := range []string{chartTypeBar, chartTypeLine, chartTypeArea, chartTypeScatter, chartTypePie} {
+		ys := []string{"a", "b"}
+		if kind == chartTypePie {
+			ys = ys[:1]
+		}
+		chart, err := buildChartData(&DataChartInput{ChartType: kind, X: "name", Y: ys}, columns, rows)
+		if err != nil {
+			t.Fatalf("%s: buildChartData: %v", kind, err)
+		}
+		svg := renderChartSVG(chart)
+		if err := xml.Unmarshal(svg, new(struct{})); err != nil {
+			t.Errorf("%s: svg is not well-formed: %v", kind, err)
+		}
+		if !strings.Contains(string(svg), "y &amp; z") {
+			t.Errorf("%s: expected category labels to be escaped", kind)
+		}
+	}
+}
+
+func TestBuildVegaLiteSpec_MultiSeriesBarFolds(t *testing.T) {
+	chart, err := buildChartData(&DataChartInput{ChartType: chartTypeBar, X: "q", Y: []string{"a", "b"}},
+		[]string{"q", "a", "b"}, []map[string]string{{"q": "Q1", "a": "1", "b": "2"}})
+	if err != nil {
+		t.Fatalf("buildChartData: %v", err)
+	}
+	spec := buildVegaLiteSpec(chart)
+	if spec["$schema"] != vegaLiteSchemaURL {
+		t.Errorf("unexpected schema %v", spec["$schema"])
+	}
+	if _, ok := spec["transform"]; !ok {
+		t.Error("expected a fold transform for multiple series")
+	}
+	encoding := spec["encoding"].(map[string]interface{})
+	if _, ok := encoding["xOffset"]; !ok {
+		t.Error("expected grouped bars via xOffset")
+	}
+}
+
+func TestChartFileBaseName(t *testing.T) {
+	cases := map[string]string{
+		"":               "chart",
+		"Sales / Region": "chart_Sales_Region",
+		"月度 销量":          "chart_月度_销量",
+		"../../etc":      "chart_etc",
+	}
+	for title, want := range cases {
+		if got := chartFileBaseName(title); got != want {
+			t.Errorf("chartFileBaseName(%q) = %q, want %q", title, got, want)
+		}
+	}
+}
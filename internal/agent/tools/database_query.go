@@ -54,7 +54,7 @@ var databaseQueryTool = BaseTool{
 - tenant_id (INTEGER): Owner tenant ID
 - knowledge_base_id (VARCHAR): Parent knowledge base ID
 - knowledge_id (VARCHAR): Parent document ID
-- content (TEXT): Chunk content
+- content (TEXT): Chunk content. It may be stored encrypted: it is returned as plain text, but SQL filters and functions on it (LIKE, LENGTH, ...) do not see the text; use grep_chunks to search chunk text
 - chunk_index (INTEGER): Index in document
 - is_enabled (BOOLEAN): Enable status
 - chunk_type (VARCHAR): Type (text/image/table)
@@ -205,10 +205,14 @@ func (t *DatabaseQueryTool) Execute(ctx context.Context, args json.RawMessage) (
 			val := columnValues[i]
 			// Convert []byte to string for better readability
 			if b, ok := val.([]byte); ok {
-				rowMap[colName] = string(b)
-			} else {
-				rowMap[colName] = val
+				val = string(b)
+			}
+			// Open encrypted chunk text like Chunk.AfterFind does, so the
+			// model never sees ciphertext.
+			if str, ok := val.(string); ok {
+				val = t.openSealedValue(ctx, tenantID, str)
 			}
+			rowMap[colName] = val
 		}
 		results = append(results, rowMap)
 	}
@@ -248,6 +252,25 @@ func (t *DatabaseQueryTool) Execute(ctx context.Context, args json.RawMessage) (
 	}, nil
 }
 
+// openSealedValue opens a value sealed with the tenant's data key. Every row
+// the tool reads is filtered to tenantID, so that is the key to open it with.
+// A value that cannot be opened is replaced rather than returned as
+// ciphertext.
+func (t *DatabaseQueryTool) openSealedValue(ctx context.Context, tenantID uint64, value string) string {
+	if !strings.HasPrefix(value, utils.EnvelopeContentPrefix) {
+		return value
+	}
+	plain, err := types.OpenChunkContent(ctx, types.ContentCipherOf(t.db), tenantID, value)
+	if err != nil {
+		logger.Warnf(ctx, "[Tool][DatabaseQuery] Failed to open encrypted value: %v", err)
+		return sealedValuePlaceholder
+	}
+	return plain
+}
+
+// sealedValuePlaceholder stands in for encrypted text that could not be opened.
+const sealedValuePlaceholder = "<encrypted>"
+
 // validateAndSecureSQL validates the SQL query and injects tenant_id conditions
 func (t *DatabaseQueryTool) validateAndSecureSQL(sqlQuery string, tenantID uint64) (string, error) {
 	searchScopes := searchScopesFromTargets(t.searchTargets)
@@ -289,9 +312,10 @@ func searchScopesFromTargets(searchTargets types.SearchTargets) []utils.SearchSc
 			continue
 		}
 		scopes = append(scopes, utils.SearchScope{
-			KnowledgeBaseID: target.KnowledgeBaseID,
-			KnowledgeIDs:    knowledgeIDs,
-			TagIDs:          tagIDs,
+			KnowledgeBaseID:     target.KnowledgeBaseID,
+			KnowledgeIDs:        knowledgeIDs,
+			TagIDs:              tagIDs,
+			ExcludeKnowledgeIDs: target.ExcludeKnowledgeIDs,
 		})
 	}
 	return scopes
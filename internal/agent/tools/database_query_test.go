@@ -1,10 +1,17 @@
 package tools
 
 import (
+	"context"
+	"encoding/json"
 	"strings"
 	"testing"
 
 	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
 )
 
 func TestDatabaseQueryInjectsEnabledChunkFilter(t *testing.T) {
@@ -24,3 +31,43 @@ func TestDatabaseQueryInjectsEnabledChunkFilter(t *testing.T) {
 		t.Fatalf("Agent SQL must exclude disabled chunks:\n%s", securedSQL)
 	}
 }
+
+func TestDatabaseQueryOpensSealedChunkContent(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.Use(grepMarkerCipher{}))
+	require.NoError(t, db.AutoMigrate(&types.Chunk{}))
+	require.NoError(t, db.Create(&types.Chunk{
+		ID: "c1", TenantID: 7, KnowledgeBaseID: "kb-1", KnowledgeID: "doc-1",
+		Content: "the stardust engine", IsEnabled: true, ChunkType: types.ChunkTypeText,
+	}).Error)
+	var raw string
+	require.NoError(t, db.Raw("SELECT content FROM chunks WHERE id = 'c1'").Scan(&raw).Error)
+	require.True(t, strings.HasPrefix(raw, utils.EnvelopeContentPrefix), "content stored as %q", raw)
+
+	ctx := context.WithValue(context.Background(), types.TenantIDContextKey, uint64(7))
+	args := json.RawMessage(`{"sql": "SELECT id, content FROM chunks"}`)
+	targets := types.SearchTargets{{Type: types.SearchTargetTypeKnowledgeBase, KnowledgeBaseID: "kb-1", TenantID: 7}}
+
+	result, err := NewDatabaseQueryTool(db, targets).Execute(ctx, args)
+	require.NoError(t, err)
+	rows := result.Data["rows"].([]map[string]interface{})
+	require.Len(t, rows, 1)
+	assert.Equal(t, "the stardust engine", rows[0]["content"])
+	assert.Contains(t, result.Output, "the stardust engine")
+	assert.NotContains(t, result.Output, utils.EnvelopeContentPrefix)
+
+	// Without a key provider the ciphertext is withheld, not returned.
+	plain, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, plain.AutoMigrate(&types.Chunk{}))
+	require.NoError(t, plain.Exec(
+		"INSERT INTO chunks (id, tenant_id, knowledge_base_id, knowledge_id, content, is_enabled) VALUES ('c1', 7, 'kb-1', 'doc-1', ?, true)",
+		raw).Error)
+	result, err = NewDatabaseQueryTool(plain, targets).Execute(ctx, args)
+	require.NoError(t, err)
+	rows = result.Data["rows"].([]map[string]interface{})
+	require.Len(t, rows, 1)
+	assert.Equal(t, sealedValuePlaceholder, rows[0]["content"])
+	assert.NotContains(t, result.Output, utils.EnvelopeContentPrefix)
+}
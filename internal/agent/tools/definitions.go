@@ -12,14 +12,28 @@ const (
 	ToolKnowledgeSearch     = "knowledge_search"
 	ToolListKnowledgeChunks = "list_knowledge_chunks"
 	ToolQueryKnowledgeGraph = "query_knowledge_graph"
+	ToolGraphGlobalSearch   = "graph_global_search"
 	ToolGetDocumentInfo     = "get_document_info"
 	ToolSearchConversations = "search_conversations"
 	ToolSearchMemory        = "search_memory"
 	ToolDatabaseQuery       = "database_query"
 	ToolDataAnalysis        = "data_analysis"
 	ToolDataSchema          = "data_schema"
+	ToolDataChart           = "data_chart"
 	ToolWebSearch           = "web_search"
 	ToolWebFetch            = "web_fetch"
+	// External SQL connection tools. Like search_memory they are not picked
+	// from the tool list: registerTools injects them when the agent has SQL
+	// connections selected, and strips them otherwise.
+	ToolSQLSchema = "sql_schema"
+	ToolSQLQuery  = "sql_query"
+	// Interactive browser tools. Injected by registerTools when the agent has
+	// a browser config selected, stripped otherwise.
+	ToolBrowserNavigate   = "browser_navigate"
+	ToolBrowserClick      = "browser_click"
+	ToolBrowserFill       = "browser_fill"
+	ToolBrowserExtract    = "browser_extract"
+	ToolBrowserScreenshot = "browser_screenshot"
 	// Skills-related tools (only available when skills are enabled)
 	ToolExecuteSkillScript = "execute_skill_script"
 	ToolReadSkill          = "read_skill"
@@ -66,6 +80,7 @@ func AvailableToolDefinitions() []AvailableTool {
 		{Name: ToolKnowledgeSearch, Label: "语义搜索", Description: "理解问题并查找语义相关内容"},
 		{Name: ToolListKnowledgeChunks, Label: "查看文档分块", Description: "获取文档完整分块内容"},
 		{Name: ToolQueryKnowledgeGraph, Label: "查询知识图谱", Description: "从知识图谱中查询关系"},
+		{Name: ToolGraphGlobalSearch, Label: "图谱全局检索", Description: "基于图谱社区报告回答整体性、总结性问题"},
 		{Name: ToolGetDocumentInfo, Label: "获取文档信息", Description: "查看文档元数据"},
 		{
 			Name:        ToolSearchConversations,
@@ -75,6 +90,7 @@ func AvailableToolDefinitions() []AvailableTool {
 		{Name: ToolDatabaseQuery, Label: "查询数据库", Description: "查询数据库中的信息"},
 		{Name: ToolDataAnalysis, Label: "数据分析", Description: "理解数据文件并进行数据分析"},
 		{Name: ToolDataSchema, Label: "查看数据元信息", Description: "获取表格文件的元信息"},
+		{Name: ToolDataChart, Label: "数据图表", Description: "根据数据分析结果绘制图表并作为附件输出"},
 		{Name: ToolReadSkill, Label: "读取技能", Description: "按需读取技能内容以学习专业能力"},
 		{Name: ToolExecuteSkillScript, Label: "执行技能脚本", Description: "在沙箱环境中执行技能脚本"},
 		{Name: ToolListSandboxFiles, Label: "列出沙箱文件", Description: "列出当前会话沙箱产出目录下的文件"},
@@ -116,5 +132,6 @@ func DefaultAllowedTools() []string {
 		ToolDatabaseQuery,
 		ToolDataAnalysis,
 		ToolDataSchema,
+		ToolDataChart,
 	}
 }
@@ -0,0 +1,159 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"github.com/Tencent/WeKnora/internal/utils"
+)
+
+var graphGlobalSearchTool = BaseTool{
+	name: ToolGraphGlobalSearch,
+	description: `Answer collection-wide questions from knowledge graph community reports.
+
+## Core Function
+Knowledge bases with graph extraction group their entities into communities and keep a report about each.
+This tool asks every relevant report what it says about the question and merges the answers.
+
+## When to Use
+✅ **Use for**:
+- Questions about a whole collection: "What are the main themes?", "Summarize the key risks across these documents"
+- Overviews where no specific entity or keyword is named
+
+❌ **Don't use for**:
+- Questions about a specific entity or fact → use knowledge_search or query_knowledge_graph
+- Knowledge bases without graph extraction, or searches scoped to some documents or tags
+
+## Parameters
+- **knowledge_base_ids** (required): Array of short bN knowledge base IDs (1-10), each searched as a whole.
+- **query** (required): The question to answer.
+
+## Notes
+- Returns a merged answer plus the rated points it was built from, each naming its community
+- Reports are rebuilt some minutes after ingestion, so very recent documents may not be covered yet`,
+	schema: utils.GenerateSchema[GraphGlobalSearchInput](),
+}
+
+// GraphGlobalSearchInput defines the input parameters for the graph global search tool
+type GraphGlobalSearchInput struct {
+	KnowledgeBaseIDs []string `json:"knowledge_base_ids" jsonschema:"Array of short bN knowledge base IDs to search as a whole"`
+	Query            string   `json:"query" jsonschema:"The collection-wide question to answer"`
+}
+
+// GraphGlobalSearchTool runs GraphRAG global search over community reports
+type GraphGlobalSearchTool struct {
+	BaseTool
+	communityService interfaces.GraphCommunityService
+	searchTargets    types.SearchTargets
+	chatModelID      string
+}
+
+// NewGraphGlobalSearchTool creates a new graph global search tool. Community
+// reports summarize whole knowledge bases, so only knowledge bases the Agent
+// may search in full are accepted.
+func NewGraphGlobalSearchTool(
+	communityService interfaces.GraphCommunityService,
+	searchTargets types.SearchTargets,
+	chatModelID string,
+) *GraphGlobalSearchTool {
+	return &GraphGlobalSearchTool{
+		BaseTool:         graphGlobalSearchTool,
+		communityService: communityService,
+		searchTargets:    searchTargets,
+		chatModelID:      chatModelID,
+	}
+}
+
+// Execute performs the global search
+func (t *GraphGlobalSearchTool) Execute(ctx context.Context, args json.RawMessage) (*types.ToolResult, error) {
+	var input GraphGlobalSearchInput
+	if err := json.Unmarshal(args, &input); err != nil {
+		return &types.ToolResult{
+			Success: false,
+			Error:   fmt.Sprintf("Failed to parse args: %v", err),
+		}, err
+	}
+	if len(input.KnowledgeBaseIDs) == 0 || len(input.KnowledgeBaseIDs) > 10 {
+		return &types.ToolResult{
+			Success: false,
+			Error:   "knowledge_base_ids must contain 1-10 KB IDs",
+		}, fmt.Errorf("invalid knowledge_base_ids")
+	}
+	if strings.TrimSpace(input.Query) == "" {
+		return &types.ToolResult{
+			Success: false,
+			Error:   "query is required",
+		}, fmt.Errorf("invalid query")
+	}
+	if err := validateKnowledgeBaseIDsInSearchTargets(t.searchTargets, input.KnowledgeBaseIDs); err != nil {
+		return &types.ToolResult{Success: false, Error: err.Error()}, err
+	}
+	whole := make(map[string]bool)
+	for _, id := range t.searchTargets.WholeKnowledgeBaseIDs() {
+		whole[id] = true
+	}
+	for _, id := range input.KnowledgeBaseIDs {
+		if !whole[id] {
+			err := fmt.Errorf("knowledge base %s is only partially within the current Agent scope", id)
+			return &types.ToolResult{Success: false, Error: err.Error()}, err
+		}
+	}
+
+	result, err := t.communityService.GlobalSearch(ctx, &types.GraphGlobalSearchRequest{
+		KnowledgeBaseIDs: input.KnowledgeBaseIDs,
+		Query:            input.Query,
+		Level:            -1,
+		ChatModelID:      t.chatModelID,
+		Reduce:           true,
+	})
+	if err != nil {
+		return &types.ToolResult{
+			Success: false,
+			Error:   fmt.Sprintf("Global search failed: %v", err),
+		}, err
+	}
+
+	if len(result.Points) == 0 {
+		return &types.ToolResult{
+			Success: true,
+			Output: "No community report answers this question. The knowledge bases may have no graph " +
+				"communities yet; try knowledge_search instead.",
+			Data: map[string]interface{}{
+				"knowledge_base_ids":     input.KnowledgeBaseIDs,
+				"query":                  input.Query,
+				"communities_considered": result.Communities,
+				"points":                 []interface{}{},
+			},
+		}, nil
+	}
+
+	var output strings.Builder
+	output.WriteString("=== Graph Global Search ===\n\n")
+	fmt.Fprintf(&output, "Query: %s\n", input.Query)
+	fmt.Fprintf(&output, "Communities considered: %d\n\n", result.Communities)
+	if result.Answer != "" {
+		output.WriteString("=== Answer ===\n\n")
+		output.WriteString(result.Answer)
+		output.WriteString("\n\n")
+	}
+	output.WriteString("=== Supporting Points ===\n\n")
+	for i, p := range result.Points {
+		fmt.Fprintf(&output, "%d. [%s, score %d] %s\n", i+1, p.CommunityTitle, p.Score, p.Description)
+	}
+
+	return &types.ToolResult{
+		Success: true,
+		Output:  output.String(),
+		Data: map[string]interface{}{
+			"knowledge_base_ids":     input.KnowledgeBaseIDs,
+			"query":                  input.Query,
+			"answer":                 result.Answer,
+			"communities_considered": result.Communities,
+			"points":                 result.Points,
+		},
+	}, nil
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/Tencent/WeKnora/internal/logger"
 	"github.com/Tencent/WeKnora/internal/searchutil"
 	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/utils"
 	"gorm.io/gorm"
 )
 
@@ -136,7 +137,7 @@ func (t *GrepChunksTool) Execute(ctx context.Context, args json.RawMessage) (*ty
 	logger.Infof(ctx, "[Tool][GrepChunks] Queries: %v, Limit: %d, fullKBs: %d, knowledgeIDs: %d, tagScopes: %d",
 		queries, limit, len(fullKBIDs), len(knowledgeIDs), len(tagTargets))
 
-	results, err := t.searchChunks(ctx, queries, fullKBIDs, knowledgeIDs, tagTargets, kbTenantMap)
+	results, err := t.searchChunks(ctx, queries, compiled, fullKBIDs, knowledgeIDs, tagTargets, kbTenantMap)
 	if err != nil {
 		logger.Errorf(ctx, "[Tool][GrepChunks] Search failed: %v", err)
 		return &types.ToolResult{
@@ -227,6 +228,9 @@ type chunkWithTitle struct {
 	KnowledgeTitle  string  `json:"knowledge_title"   gorm:"column:knowledge_title"`
 	MatchScore      float64 `json:"match_score"       gorm:"column:match_score"`
 	MatchedPatterns int     `json:"matched_patterns"`
+	// SealedContent is true when the stored content was encrypted, so the
+	// SQL regex could not be applied to it.
+	SealedContent bool `json:"-" gorm:"column:sealed_content"`
 	// TitleMatch is true when the query regex matches the owning knowledge's
 	// TITLE (not just chunk body). A doc literally titled "图片素材" is the most
 	// on-topic hit for the query "图片素材", yet its body may mention the term far
@@ -370,6 +374,7 @@ func scopeClause(
 func (t *GrepChunksTool) searchChunks(
 	ctx context.Context,
 	queries []string,
+	compiled []*regexp.Regexp,
 	kbIDs []string,
 	knowledgeIDs []string,
 	tagTargets []*types.SearchTarget,
@@ -383,9 +388,10 @@ func (t *GrepChunksTool) searchChunks(
 	regexOp := t.regexOperatorForDialect()
 
 	query := t.db.WithContext(ctx).Table("chunks").
-		Select("chunks.id, chunks.content, chunks.chunk_index, chunks.knowledge_id, "+
+		Select("chunks.id, chunks.tenant_id, chunks.content, chunks.chunk_index, chunks.knowledge_id, "+
 			"chunks.knowledge_base_id, chunks.chunk_type, chunks.metadata, chunks.created_at, "+
-			"knowledges.title as knowledge_title").
+			"knowledges.title as knowledge_title, chunks.content LIKE ? as sealed_content",
+			utils.EnvelopeContentPrefix+"%").
 		Joins("JOIN knowledges ON chunks.knowledge_id = knowledges.id").
 		Where("chunks.is_enabled = ?", true).
 		Where("chunks.deleted_at IS NULL").
@@ -402,6 +408,10 @@ func (t *GrepChunksTool) searchChunks(
 	logger.Infof(ctx, "[Tool][GrepChunks] Scope: %d knowledge IDs, %d tag scopes, %d KBs",
 		len(knowledgeIDs), len(tagTargets), len(kbIDs))
 	query = query.Where(scopeSQL, scopeArgs...)
+	// Knowledge hidden by document-level ACLs is excluded from every scope.
+	if excluded := t.searchTargets.ExcludedKnowledgeIDs(); len(excluded) > 0 {
+		query = query.Where("chunks.knowledge_id NOT IN ?", excluded)
+	}
 
 	// For MySQL/SQLite REGEXP case-insensitivity we rely on the column's default
 	// collation (utf8mb4_general_ci etc.) OR the driver's REGEXP implementation,
@@ -416,14 +426,44 @@ func (t *GrepChunksTool) searchChunks(
 			fmt.Sprintf("(chunks.content %s ? OR knowledges.title %s ?)", regexOp, regexOp))
 		regexArgs = append(regexArgs, q, q)
 	}
-	query = query.Where("("+strings.Join(regexConditions, " OR ")+")", regexArgs...)
-
-	const maxFetchLimit = 500
+	// Encrypted chunk content cannot be matched in SQL: sealed rows are
+	// fetched as candidates, opened by the Chunk hook and matched in Go below.
+	regexConditions = append(regexConditions, "chunks.content LIKE ?")
+	regexArgs = append(regexArgs, utils.EnvelopeContentPrefix+"%")
+	query = query.Where("("+strings.Join(regexConditions, " OR ")+")", regexArgs...).
+		Order("chunks.created_at DESC")
+
+	const (
+		maxFetchLimit = 500
+		// maxScanRows bounds the candidates read when sealed rows crowd out
+		// matches, so a fully encrypted knowledge base is scanned in pages
+		// instead of all at once.
+		maxScanRows = 20000
+	)
 
 	var results []chunkWithTitle
-	if err := query.Order("chunks.created_at DESC").Limit(maxFetchLimit).Find(&results).Error; err != nil {
-		logger.Errorf(ctx, "[Tool][GrepChunks] Failed to fetch results: %v", err)
-		return nil, err
+	for offset := 0; len(results) < maxFetchLimit && offset < maxScanRows; offset += maxFetchLimit {
+		var page []chunkWithTitle
+		if err := query.Session(&gorm.Session{}).Offset(offset).Limit(maxFetchLimit).
+			Find(&page).Error; err != nil {
+			logger.Errorf(ctx, "[Tool][GrepChunks] Failed to fetch results: %v", err)
+			return nil, err
+		}
+		for _, r := range page {
+			if !r.SealedContent || regexMatchesAny(r.Content, compiled) ||
+				regexMatchesAny(r.KnowledgeTitle, compiled) {
+				results = append(results, r)
+			}
+		}
+		if len(page) < maxFetchLimit {
+			break
+		}
+		if offset+maxFetchLimit >= maxScanRows {
+			logger.Warnf(ctx, "[Tool][GrepChunks] Stopped after scanning %d candidate chunks", maxScanRows)
+		}
+	}
+	if len(results) > maxFetchLimit {
+		results = results[:maxFetchLimit]
 	}
 
 	if len(results) > 0 {
@@ -0,0 +1,79 @@
+package tools
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/utils"
+	sqlite3 "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func init() {
+	// SQLite has no REGEXP function of its own.
+	sql.Register("sqlite3_grep_chunks", &sqlite3.SQLiteDriver{
+		ConnectHook: func(conn *sqlite3.SQLiteConn) error {
+			return conn.RegisterFunc("regexp", func(pattern, text string) (bool, error) {
+				return regexp.MatchString("(?i)"+pattern, text)
+			}, true)
+		},
+	})
+}
+
+// grepMarkerCipher seals by tagging the text with the envelope prefix and the
+// tenant, so a row opened with the wrong tenant stays sealed.
+type grepMarkerCipher struct{}
+
+func (grepMarkerCipher) SealContent(_ context.Context, tenantID uint64, plaintext string) (string, error) {
+	return fmt.Sprintf("%s1:%d:%s", utils.EnvelopeContentPrefix, tenantID, plaintext), nil
+}
+
+func (grepMarkerCipher) OpenContent(_ context.Context, tenantID uint64, sealed string) (string, error) {
+	prefix := fmt.Sprintf("%s1:%d:", utils.EnvelopeContentPrefix, tenantID)
+	if !strings.HasPrefix(sealed, prefix) {
+		return "", fmt.Errorf("wrong tenant")
+	}
+	return strings.TrimPrefix(sealed, prefix), nil
+}
+
+func (grepMarkerCipher) Name() string { return types.ContentCipherPluginName }
+
+func (grepMarkerCipher) Initialize(*gorm.DB) error { return nil }
+
+func TestSearchChunksMatchesSealedContent(t *testing.T) {
+	db, err := gorm.Open(sqlite.New(sqlite.Config{DriverName: "sqlite3_grep_chunks", DSN: ":memory:"}), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.Use(grepMarkerCipher{}))
+	require.NoError(t, db.AutoMigrate(&types.Chunk{}, &types.Knowledge{}))
+	require.NoError(t, db.Create(&types.Knowledge{ID: "doc-1", TenantID: 7, KnowledgeBaseID: "kb-1", Title: "notes"}).Error)
+	for i, content := range []string{"the stardust engine", "unrelated text", "another stardust mention"} {
+		require.NoError(t, db.Create(&types.Chunk{
+			ID: fmt.Sprintf("c%d", i), TenantID: 7, KnowledgeBaseID: "kb-1", KnowledgeID: "doc-1",
+			Content: content, ChunkIndex: i, IsEnabled: true, ChunkType: types.ChunkTypeText,
+		}).Error)
+	}
+	// A row written before encryption was turned on still matches in SQL.
+	require.NoError(t, db.Exec("UPDATE chunks SET content = ? WHERE id = ?", "stardust in plaintext", "c2").Error)
+
+	tool := NewGrepChunksTool(db, types.SearchTargets{{
+		Type: types.SearchTargetTypeKnowledgeBase, KnowledgeBaseID: "kb-1", TenantID: 7,
+	}})
+	re := regexp.MustCompile("(?i)stardust")
+	results, err := tool.searchChunks(context.Background(), []string{"stardust"}, []*regexp.Regexp{re},
+		[]string{"kb-1"}, nil, nil, map[string]uint64{"kb-1": 7})
+	require.NoError(t, err)
+
+	got := map[string]string{}
+	for _, r := range results {
+		got[r.ID] = r.Content
+	}
+	assert.Equal(t, map[string]string{"c0": "the stardust engine", "c2": "stardust in plaintext"}, got)
+}
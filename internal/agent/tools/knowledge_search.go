@@ -1136,6 +1136,23 @@ func writeKnowledgeMetadataHeader(ob *strings.Builder, results []*searchResultWi
 	ob.WriteString("</documents>\n")
 }
 
+// writeFreshnessNote tells the model that a result comes from an expired,
+// not yet valid or soon-to-expire document, so the answer can flag the
+// citation.
+func writeFreshnessNote(ob *strings.Builder, result *types.SearchResult) {
+	if result == nil || result.Freshness == "" {
+		return
+	}
+	validUntil := ""
+	if result.KnowledgeValidUntil != nil {
+		validUntil = fmt.Sprintf(" valid_until=\"%s\"", result.KnowledgeValidUntil.Format("2006-01-02"))
+	}
+	ob.WriteString(fmt.Sprintf(
+		"<freshness state=\"%s\"%s>Mention this when citing the chunk.</freshness>\n",
+		xmlEscape(result.Freshness), validUntil,
+	))
+}
+
 // formatOutput formats the search results for display
 func (t *KnowledgeSearchTool) formatOutput(
 	ctx context.Context,
@@ -1300,6 +1317,7 @@ func (t *KnowledgeSearchTool) formatOutput(
 					xmlEscape(result.SourceQuery),
 				))
 			}
+			writeFreshnessNote(&ob, result.SearchResult)
 			snippet := ""
 			if faqMeta != nil {
 				snippet = faqMatchSnippetFromQueries(faqMeta, queries)
@@ -1343,6 +1361,7 @@ func (t *KnowledgeSearchTool) formatOutput(
 			"source_query":        result.SourceQuery,
 			"query_type":          result.QueryType,
 			"knowledge_base_type": result.KnowledgeBaseType,
+			"freshness":           result.Freshness,
 		})
 
 		last := formattedResults[len(formattedResults)-1]
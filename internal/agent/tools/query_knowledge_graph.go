@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"sort"
+	"strings"
 	"sync"
 
 	"github.com/Tencent/WeKnora/internal/types"
@@ -70,6 +71,7 @@ type QueryKnowledgeGraphTool struct {
 	BaseTool
 	knowledgeService      interfaces.KnowledgeBaseService
 	scopeKnowledgeService interfaces.KnowledgeService
+	entityService         interfaces.GraphEntityService
 	searchTargets         types.SearchTargets
 	scopeEnforced         bool
 }
@@ -84,6 +86,15 @@ func (t *QueryKnowledgeGraphTool) WithKnowledgeScope(
 	return t
 }
 
+// WithGraphEntities adds the entities extracted from the result chunks, under
+// their resolved names, to the visualization data.
+func (t *QueryKnowledgeGraphTool) WithGraphEntities(
+	entityService interfaces.GraphEntityService,
+) *QueryKnowledgeGraphTool {
+	t.entityService = entityService
+	return t
+}
+
 // NewQueryKnowledgeGraphTool creates a new query knowledge graph tool
 func NewQueryKnowledgeGraphTool(
 	knowledgeService interfaces.KnowledgeBaseService,
@@ -214,6 +225,7 @@ func (t *QueryKnowledgeGraphTool) Execute(ctx context.Context, args json.RawMess
 	var errors []string
 	graphConfigs := make(map[string]graphConfigSummary)
 	kbCounts := make(map[string]int)
+	kbChunks := make(map[string][]string)
 
 	for _, kbID := range input.KnowledgeBaseIDs {
 		result := kbResults[kbID]
@@ -228,6 +240,7 @@ func (t *QueryKnowledgeGraphTool) Execute(ctx context.Context, args json.RawMess
 
 		kbCounts[kbID] = len(result.results)
 		for _, r := range result.results {
+			kbChunks[kbID] = append(kbChunks[kbID], r.ID)
 			if _, seen := seenChunks[r.ID]; !seen {
 				seenChunks[r.ID] = r
 			}
@@ -352,6 +365,15 @@ func (t *QueryKnowledgeGraphTool) Execute(ctx context.Context, args json.RawMess
 		})
 	}
 
+	entityGraphs := t.lookupEntities(ctx, graphConfigs, kbChunks)
+	if entityNames := entityDisplayNames(entityGraphs); len(entityNames) > 0 {
+		output += "=== 🧩 Entities in Results ===\n"
+		for _, name := range entityNames {
+			output += fmt.Sprintf("  - %s\n", name)
+		}
+		output += "\n"
+	}
+
 	output += "=== 💡 Tips ===\n"
 	output += "- ✓ Results are deduplicated across knowledge bases and sorted by relevance\n"
 	output += "- ✓ Use get_chunk_detail to get full content\n"
@@ -362,7 +384,7 @@ func (t *QueryKnowledgeGraphTool) Execute(ctx context.Context, args json.RawMess
 	output += "- ⏳ Full graph query language (Cypher) support is under development\n"
 
 	// Build structured graph data for frontend visualization
-	graphData := buildGraphVisualizationData(allResults)
+	graphData := buildGraphVisualizationData(allResults, entityGraphs)
 
 	return &types.ToolResult{
 		Success: true,
@@ -477,8 +499,66 @@ func uniqueStrings(values []string) []string {
 	return result
 }
 
-// buildGraphVisualizationData builds structured data for graph visualization
-func buildGraphVisualizationData(results []*types.SearchResult) map[string]interface{} {
+// maxListedEntities caps the entity names written to the tool output.
+const maxListedEntities = 20
+
+// lookupEntities fetches, per graph-enabled knowledge base, the entities
+// extracted from the result chunks. It is best effort: visualization
+// without entities is still useful.
+func (t *QueryKnowledgeGraphTool) lookupEntities(
+	ctx context.Context,
+	graphConfigs map[string]graphConfigSummary,
+	kbChunks map[string][]string,
+) map[string]*types.GraphData {
+	if t.entityService == nil || len(graphConfigs) == 0 {
+		return nil
+	}
+	graphs := make(map[string]*types.GraphData, len(graphConfigs))
+	for kbID := range graphConfigs {
+		chunkIDs := kbChunks[kbID]
+		if len(chunkIDs) == 0 {
+			continue
+		}
+		graph, err := t.entityService.ChunkEntities(ctx, kbID, chunkIDs)
+		if err != nil || graph == nil {
+			continue
+		}
+		graphs[kbID] = graph
+	}
+	return graphs
+}
+
+// entityDisplayNames lists entity names with their aliases, folded across
+// documents and sorted.
+func entityDisplayNames(graphs map[string]*types.GraphData) []string {
+	aliases := make(map[string][]string)
+	for _, graph := range graphs {
+		for _, node := range graph.Node {
+			aliases[node.Name] = append(aliases[node.Name], node.Aliases...)
+		}
+	}
+	names := make([]string, 0, len(aliases))
+	for name, list := range aliases {
+		if list = uniqueStrings(list); len(list) > 0 {
+			name = fmt.Sprintf("%s (aka %s)", name, strings.Join(list, ", "))
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if len(names) > maxListedEntities {
+		names = append(names[:maxListedEntities], fmt.Sprintf("... and %d more", len(names)-maxListedEntities))
+	}
+	return names
+}
+
+// buildGraphVisualizationData builds structured data for graph visualization.
+// Chunks are always nodes; when entity graphs are given, their entities are
+// added under resolved names, linked to the chunks that mention them and to
+// each other by the extracted relations.
+func buildGraphVisualizationData(
+	results []*types.SearchResult,
+	entityGraphs map[string]*types.GraphData,
+) map[string]interface{} {
 	// Build a simple graph structure for frontend visualization
 	nodes := make([]map[string]interface{}, 0)
 	edges := make([]map[string]interface{}, 0)
@@ -500,6 +580,62 @@ func buildGraphVisualizationData(results []*types.SearchResult) map[string]inter
 		}
 	}
 
+	kbIDs := make([]string, 0, len(entityGraphs))
+	for kbID := range entityGraphs {
+		kbIDs = append(kbIDs, kbID)
+	}
+	sort.Strings(kbIDs)
+	seenEdges := make(map[string]bool)
+	addEdge := func(source, target, kind string) {
+		key := source + "\x1f" + kind + "\x1f" + target
+		if source == target || seenEdges[key] {
+			return
+		}
+		seenEdges[key] = true
+		edges = append(edges, map[string]interface{}{
+			"source": source,
+			"target": target,
+			"type":   kind,
+		})
+	}
+	for _, kbID := range kbIDs {
+		graph := entityGraphs[kbID]
+		entityID := func(name string) string {
+			return "entity:" + kbID + ":" + strings.ToLower(strings.TrimSpace(name))
+		}
+		// The same entity is one node per document in the graph store.
+		entityNodes := make(map[string]map[string]interface{})
+		for _, node := range graph.Node {
+			id := entityID(node.Name)
+			entity, ok := entityNodes[id]
+			if !ok {
+				entity = map[string]interface{}{
+					"id":         id,
+					"label":      node.Name,
+					"aliases":    []string{},
+					"attributes": []string{},
+					"kb_id":      kbID,
+					"type":       "entity",
+				}
+				entityNodes[id] = entity
+				nodes = append(nodes, entity)
+			}
+			entity["aliases"] = uniqueStrings(append(entity["aliases"].([]string), node.Aliases...))
+			entity["attributes"] = uniqueStrings(append(entity["attributes"].([]string), node.Attributes...))
+			for _, chunkID := range node.Chunks {
+				if seenEntities[chunkID] {
+					addEdge(chunkID, id, "mentions")
+				}
+			}
+		}
+		for _, rel := range graph.Relation {
+			source, target := entityID(rel.Node1), entityID(rel.Node2)
+			if entityNodes[source] != nil && entityNodes[target] != nil {
+				addEdge(source, target, rel.Type)
+			}
+		}
+	}
+
 	return map[string]interface{}{
 		"nodes":       nodes,
 		"edges":       edges,
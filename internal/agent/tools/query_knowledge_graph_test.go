@@ -182,3 +182,33 @@ func TestQueryKnowledgeGraph_ReportsConfiguredEntityAndRelationTypes(t *testing.
 	assert.ElementsMatch(t, []string{"合同", "审批流程", "法务部门"}, graphConfig["nodes"])
 	assert.ElementsMatch(t, []string{"属于", "审批", "管理"}, graphConfig["relations"])
 }
+
+func TestBuildGraphVisualizationData_LinksEntitiesToChunks(t *testing.T) {
+	results := []*types.SearchResult{{ID: "chunk-1", KnowledgeID: "k1", Content: "Tencent owns WeChat"}}
+	entityGraphs := map[string]*types.GraphData{
+		"kb-1": {
+			Node: []*types.GraphNode{
+				{Name: "Tencent", Chunks: []string{"chunk-1", "chunk-9"}, Aliases: []string{"腾讯"}},
+				{Name: "Tencent", Chunks: []string{"chunk-1"}},
+				{Name: "WeChat", Chunks: []string{"chunk-1"}},
+			},
+			Relation: []*types.GraphRelation{{Node1: "Tencent", Node2: "WeChat", Type: "owns"}},
+		},
+	}
+
+	data := buildGraphVisualizationData(results, entityGraphs)
+
+	nodes := data["nodes"].([]map[string]interface{})
+	require.Len(t, nodes, 3)
+	assert.Equal(t, "entity:kb-1:tencent", nodes[1]["id"])
+	assert.Equal(t, []string{"腾讯"}, nodes[1]["aliases"])
+
+	edges := data["edges"].([]map[string]interface{})
+	require.Len(t, edges, 3)
+	assert.Equal(t, "mentions", edges[0]["type"])
+	assert.Equal(t, map[string]interface{}{
+		"source": "entity:kb-1:tencent",
+		"target": "entity:kb-1:wechat",
+		"type":   "owns",
+	}, edges[2])
+}
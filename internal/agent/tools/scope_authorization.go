@@ -202,6 +202,10 @@ func searchTargetsAllowKnowledgeID(
 			continue
 		}
 		matchedKB = true
+		// Document-level ACL denials override every scope, including whole-KB.
+		if target.ExcludesKnowledge(knowledgeID) {
+			return false, nil
+		}
 		if searchTargetIsWholeKB(target) {
 			return true, nil
 		}
@@ -238,13 +242,18 @@ func filterSearchResultsInSearchTargets(
 	var explicitIDs []string
 	var tagIDs []string
 	matchedKB := false
+	wholeKB := false
+	excluded := make(map[string]bool)
 	for _, target := range searchTargets {
 		if target == nil || target.KnowledgeBaseID != kbID {
 			continue
 		}
 		matchedKB = true
+		for _, id := range target.ExcludeKnowledgeIDs {
+			excluded[id] = true
+		}
 		if searchTargetIsWholeKB(target) {
-			return results, nil
+			wholeKB = true
 		}
 		targetKnowledgeIDs, targetTagIDs := searchTargetScope(target)
 		explicitIDs = append(explicitIDs, targetKnowledgeIDs...)
@@ -253,6 +262,10 @@ func filterSearchResultsInSearchTargets(
 	if !matchedKB {
 		return nil, fmt.Errorf("knowledge base %s is not within the current Agent scope", kbID)
 	}
+	results = dropACLExcludedResults(results, excluded)
+	if wholeKB {
+		return results, nil
+	}
 
 	explicitSet := make(map[string]struct{}, len(explicitIDs))
 	for _, id := range dedupNonEmptyStrings(explicitIDs) {
@@ -300,6 +313,21 @@ func filterSearchResultsInSearchTargets(
 	return filtered, nil
 }
 
+// dropACLExcludedResults removes results of knowledge hidden by
+// document-level ACLs.
+func dropACLExcludedResults(results []*types.SearchResult, excluded map[string]bool) []*types.SearchResult {
+	if len(excluded) == 0 {
+		return results
+	}
+	filtered := make([]*types.SearchResult, 0, len(results))
+	for _, result := range results {
+		if result != nil && !excluded[result.KnowledgeID] {
+			filtered = append(filtered, result)
+		}
+	}
+	return filtered
+}
+
 func knowledgeIDsMatchingAnyTag(
 	ctx context.Context,
 	knowledgeIDs []string,
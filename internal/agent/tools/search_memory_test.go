@@ -3,6 +3,7 @@ package tools
 import (
 	"context"
 	"encoding/json"
+	"strings"
 	"testing"
 	"time"
 
@@ -60,6 +61,23 @@ func TestSearchMemoryLabelsResultsAsDataNotInstructions(t *testing.T) {
 	require.Contains(t, result.Output, `topic="生产数据库"`)
 }
 
+// A team note read back as if the user had said it would misattribute it, so
+// shared results carry their source and personal ones stay unmarked.
+func TestSearchMemoryMarksSharedResults(t *testing.T) {
+	stub := &stubMemorySearch{result: interfaces.MemorySearchResult{
+		Available: true,
+		Items: []*types.MemoryItem{
+			{Kind: types.MemoryKindPreference, Content: "回答时使用中文", Provenance: types.MemoryProvenancePersonal},
+			{Kind: types.MemoryKindFact, Content: "发布窗口是周四下午", Provenance: types.MemoryProvenanceWorkspace},
+		},
+	}}
+
+	result := runSearchMemory(t, stub, `{"query":"发布"}`)
+
+	require.Equal(t, 1, strings.Count(result.Output, `source="workspace"`))
+	require.NotContains(t, result.Output, `source="personal"`)
+}
+
 // Reporting an empty store to someone who switched memory off would have the
 // agent tell them it remembers nothing about them — wrong, and the opposite of
 // what turning memory off was supposed to do.
@@ -0,0 +1,254 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"github.com/Tencent/WeKnora/internal/utils"
+)
+
+var sqlQueryTool = BaseTool{
+	name: ToolSQLQuery,
+	description: `Run a read-only SQL query against an external database and get the result as a table.
+
+## Rules
+- Call sql_schema first and only use tables and columns it returns
+- Write a single SELECT (or WITH ... SELECT) statement in the connection's dialect
+- Writes, DDL, locking clauses and file / network functions are rejected
+- Aggregate in SQL (GROUP BY, COUNT, SUM) instead of fetching raw rows; results are capped
+- The result includes chart suggestions; mention a fitting chart when it helps the user`,
+	schema: utils.GenerateSchema[SQLQueryInput](),
+}
+
+// SQLQueryInput defines the input parameters for the sql_query tool
+type SQLQueryInput struct {
+	ConnectionID string `json:"connection_id" jsonschema:"ID of the database connection, from the list in the tool description"`
+	SQL          string `json:"sql" jsonschema:"A single read-only SELECT statement in the connection's SQL dialect"`
+	MaxRows      int    `json:"max_rows,omitempty" jsonschema:"Optional row cap for this query. Cannot exceed the connection's limit."`
+}
+
+// SQLQueryTool runs read-only queries on an external SQL connection
+type SQLQueryTool struct {
+	BaseTool
+	service     interfaces.SQLConnectionService
+	connections []*types.SQLConnection
+}
+
+// NewSQLQueryTool creates a sql_query tool limited to the given connections.
+// The connections are resolved under the agent's tenant by the caller.
+func NewSQLQueryTool(service interfaces.SQLConnectionService, connections []*types.SQLConnection) *SQLQueryTool {
+	base := sqlQueryTool
+	base.description += describeSQLConnections(connections)
+	return &SQLQueryTool{BaseTool: base, service: service, connections: connections}
+}
+
+// ChartSuggestion proposes a chart for a tabular result
+type ChartSuggestion struct {
+	Type   string   `json:"type"` // line, bar, pie, scatter
+	X      string   `json:"x"`
+	Y      []string `json:"y"`
+	Reason string   `json:"reason"`
+}
+
+// Execute executes the tool logic
+func (t *SQLQueryTool) Execute(ctx context.Context, args json.RawMessage) (*types.ToolResult, error) {
+	var input SQLQueryInput
+	if err := json.Unmarshal(args, &input); err != nil {
+		return &types.ToolResult{
+			Success: false,
+			Error:   fmt.Sprintf("Failed to parse input args: %v", err),
+		}, err
+	}
+	conn, err := findSQLConnection(t.connections, input.ConnectionID)
+	if err != nil {
+		return &types.ToolResult{Success: false, Error: err.Error()}, err
+	}
+
+	logger.Infof(ctx, "[Tool][SQLQuery] connection=%s sql=%s", conn.ID, input.SQL)
+	result, err := t.service.Query(ctx, conn, input.SQL, input.MaxRows)
+	if err != nil {
+		logger.Warnf(ctx, "[Tool][SQLQuery] query failed: %v", err)
+		return &types.ToolResult{
+			Success: false,
+			Error:   fmt.Sprintf("Query on '%s' failed: %v", conn.Name, err),
+		}, err
+	}
+
+	rows := make([]map[string]interface{}, 0, len(result.Rows))
+	for _, values := range result.Rows {
+		row := make(map[string]interface{}, len(result.Columns))
+		for i, col := range result.Columns {
+			row[col] = values[i]
+		}
+		rows = append(rows, row)
+	}
+	charts := suggestCharts(result)
+
+	logger.Infof(ctx, "[Tool][SQLQuery] returned %d rows in %dms (truncated=%v)",
+		result.RowCount, result.DurationMs, result.Truncated)
+	return &types.ToolResult{
+		Success: true,
+		Output:  formatSQLQueryResult(result, charts),
+		Data: map[string]interface{}{
+			"display_type":      "sql_query",
+			"connection_id":     conn.ID,
+			"connection_name":   conn.Name,
+			"sql":               input.SQL,
+			"columns":           result.Columns,
+			"rows":              rows,
+			"row_count":         result.RowCount,
+			"truncated":         result.Truncated,
+			"duration_ms":       result.DurationMs,
+			"chart_suggestions": charts,
+		},
+	}, nil
+}
+
+// formatSQLQueryResult renders the result as a markdown table for the model.
+func formatSQLQueryResult(result *types.SQLQueryResult, charts []ChartSuggestion) string {
+	var b strings.Builder
+	b.WriteString("=== Query Results ===\n\n")
+	fmt.Fprintf(&b, "Returned %d rows in %dms\n\n", result.RowCount, result.DurationMs)
+	if result.RowCount == 0 {
+		b.WriteString("No matching records found.\n")
+		return b.String()
+	}
+
+	cell := func(v interface{}) string {
+		if v == nil {
+			return "NULL"
+		}
+		s := fmt.Sprint(v)
+		s = strings.ReplaceAll(s, "|", "\\|")
+		return strings.ReplaceAll(s, "\n", " ")
+	}
+	b.WriteString("| " + strings.Join(result.Columns, " | ") + " |\n")
+	b.WriteString("|" + strings.Repeat(" --- |", len(result.Columns)) + "\n")
+	for _, row := range result.Rows {
+		parts := make([]string, len(row))
+		for i, v := range row {
+			parts[i] = cell(v)
+		}
+		b.WriteString("| " + strings.Join(parts, " | ") + " |\n")
+	}
+	if result.Truncated {
+		fmt.Fprintf(&b, "\nNote: only the first %d rows are shown. Aggregate or add a LIMIT to narrow the result.\n", result.RowCount)
+	}
+	if len(charts) > 0 {
+		b.WriteString("\n=== Chart Suggestions ===\n")
+		for _, c := range charts {
+			fmt.Fprintf(&b, "- %s chart: x=%s, y=%s (%s)\n", c.Type, c.X, strings.Join(c.Y, ", "), c.Reason)
+		}
+	}
+	return b.String()
+}
+
+// sqlColumnKind classifies result columns for chart suggestions.
+type sqlColumnKind int
+
+const (
+	sqlColumnOther sqlColumnKind = iota
+	sqlColumnNumeric
+	sqlColumnTemporal
+	sqlColumnCategory
+)
+
+// suggestCharts proposes charts from the shape of the result: a time column
+// with measures suggests a line chart, a category with measures a bar chart
+// (or a pie for a handful of rows), and two measures a scatter plot.
+func suggestCharts(result *types.SQLQueryResult) []ChartSuggestion {
+	if result == nil || result.RowCount < 2 {
+		return nil
+	}
+	var temporal, category, numeric []string
+	for i, col := range result.Columns {
+		colType := ""
+		if i < len(result.ColumnTypes) {
+			colType = result.ColumnTypes[i]
+		}
+		switch classifySQLColumn(result.Rows, i, colType) {
+		case sqlColumnNumeric:
+			numeric = append(numeric, col)
+		case sqlColumnTemporal:
+			temporal = append(temporal, col)
+		case sqlColumnCategory:
+			category = append(category, col)
+		}
+	}
+
+	var charts []ChartSuggestion
+	if len(numeric) == 0 {
+		return nil
+	}
+	if len(temporal) > 0 {
+		charts = append(charts, ChartSuggestion{
+			Type: "line", X: temporal[0], Y: numeric, Reason: "measures over time",
+		})
+	}
+	if len(category) > 0 {
+		charts = append(charts, ChartSuggestion{
+			Type: "bar", X: category[0], Y: numeric, Reason: "measures compared across categories",
+		})
+		if len(numeric) == 1 && result.RowCount <= 8 {
+			charts = append(charts, ChartSuggestion{
+				Type: "pie", X: category[0], Y: numeric, Reason: "share of a total across few categories",
+			})
+		}
+	}
+	if len(temporal) == 0 && len(category) == 0 && len(numeric) >= 2 {
+		charts = append(charts, ChartSuggestion{
+			Type: "scatter", X: numeric[0], Y: numeric[1:2], Reason: "relationship between two measures",
+		})
+	}
+	return charts
+}
+
+func classifySQLColumn(rows [][]interface{}, idx int, colType string) sqlColumnKind {
+	upper := strings.ToUpper(colType)
+	if strings.Contains(upper, "DATE") || strings.Contains(upper, "TIME") {
+		return sqlColumnTemporal
+	}
+	numeric, temporal, text, seen := 0, 0, 0, 0
+	for _, row := range rows {
+		if idx >= len(row) || row[idx] == nil {
+			continue
+		}
+		seen++
+		switch v := row[idx].(type) {
+		case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+			numeric++
+		case string:
+			if looksLikeSQLDate(v) {
+				temporal++
+			} else {
+				text++
+			}
+		}
+	}
+	switch {
+	case seen == 0:
+		return sqlColumnOther
+	case numeric == seen:
+		return sqlColumnNumeric
+	case temporal == seen:
+		return sqlColumnTemporal
+	case text == seen:
+		return sqlColumnCategory
+	}
+	return sqlColumnOther
+}
+
+func looksLikeSQLDate(s string) bool {
+	for _, layout := range []string{"2006-01-02", time.RFC3339, "2006-01-02 15:04:05", "2006-01"} {
+		if _, err := time.Parse(layout, s); err == nil {
+			return true
+		}
+	}
+	return false
+}
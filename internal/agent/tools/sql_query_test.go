@@ -0,0 +1,98 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"github.com/stretchr/testify/require"
+)
+
+// stubSQLConnections returns a fixed result and records the query it saw.
+type stubSQLConnections struct {
+	interfaces.SQLConnectionService
+
+	result  *types.SQLQueryResult
+	gotSQL  string
+	gotConn string
+}
+
+func (s *stubSQLConnections) Query(
+	_ context.Context, conn *types.SQLConnection, query string, _ int,
+) (*types.SQLQueryResult, error) {
+	s.gotSQL, s.gotConn = query, conn.ID
+	return s.result, nil
+}
+
+func TestSuggestCharts(t *testing.T) {
+	byMonth := &types.SQLQueryResult{
+		Columns:     []string{"month", "revenue"},
+		ColumnTypes: []string{"DATE", "NUMERIC"},
+		Rows:        [][]interface{}{{"2026-01-01", 10.5}, {"2026-02-01", 12.0}},
+		RowCount:    2,
+	}
+	charts := suggestCharts(byMonth)
+	require.Len(t, charts, 1)
+	require.Equal(t, "line", charts[0].Type)
+	require.Equal(t, "month", charts[0].X)
+
+	byRegion := &types.SQLQueryResult{
+		Columns:  []string{"region", "orders"},
+		Rows:     [][]interface{}{{"north", int64(3)}, {"south", int64(5)}, {"east", int64(1)}},
+		RowCount: 3,
+	}
+	charts = suggestCharts(byRegion)
+	require.Len(t, charts, 2)
+	require.Equal(t, "bar", charts[0].Type)
+	require.Equal(t, "pie", charts[1].Type)
+	require.Equal(t, []string{"orders"}, charts[1].Y)
+
+	twoMeasures := &types.SQLQueryResult{
+		Columns:  []string{"price", "quantity"},
+		Rows:     [][]interface{}{{1.5, int64(3)}, {2.0, int64(1)}},
+		RowCount: 2,
+	}
+	charts = suggestCharts(twoMeasures)
+	require.Len(t, charts, 1)
+	require.Equal(t, "scatter", charts[0].Type)
+
+	textOnly := &types.SQLQueryResult{
+		Columns:  []string{"name"},
+		Rows:     [][]interface{}{{"a"}, {"b"}},
+		RowCount: 2,
+	}
+	require.Empty(t, suggestCharts(textOnly))
+	require.Empty(t, suggestCharts(&types.SQLQueryResult{Columns: []string{"n"}, Rows: [][]interface{}{{1}}, RowCount: 1}))
+}
+
+func TestSQLQueryToolExecute(t *testing.T) {
+	stub := &stubSQLConnections{result: &types.SQLQueryResult{
+		Columns:   []string{"region", "note"},
+		Rows:      [][]interface{}{{"north", "a|b"}, {"south", nil}},
+		RowCount:  2,
+		Truncated: true,
+	}}
+	conns := []*types.SQLConnection{{ID: "c1", Name: "warehouse", Dialect: types.SQLDialectPostgres}}
+	tool := NewSQLQueryTool(stub, conns)
+	require.Contains(t, tool.Description(), "c1: warehouse (postgres)")
+
+	args, _ := json.Marshal(SQLQueryInput{ConnectionID: "c1", SQL: "SELECT region, note FROM t"})
+	result, err := tool.Execute(context.Background(), args)
+	require.NoError(t, err)
+	require.True(t, result.Success)
+	require.Equal(t, "SELECT region, note FROM t", stub.gotSQL)
+	require.Equal(t, "sql_query", result.Data["display_type"])
+	require.Contains(t, result.Output, "| north | a\\|b |")
+	require.Contains(t, result.Output, "| south | NULL |")
+	require.Contains(t, result.Output, "only the first 2 rows")
+	rows := result.Data["rows"].([]map[string]interface{})
+	require.Equal(t, "north", rows[0]["region"])
+
+	args, _ = json.Marshal(SQLQueryInput{ConnectionID: "other", SQL: "SELECT 1"})
+	result, err = tool.Execute(context.Background(), args)
+	require.Error(t, err)
+	require.False(t, result.Success)
+	require.Contains(t, result.Error, "available: c1")
+}
@@ -0,0 +1,138 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"github.com/Tencent/WeKnora/internal/utils"
+)
+
+var sqlSchemaTool = BaseTool{
+	name: ToolSQLSchema,
+	description: `List the tables and columns of an external database the agent is connected to.
+
+## Usage
+- Call this before writing SQL for sql_query; never guess table or column names
+- Pass "tables" to fetch only the tables you need once you know their names
+- Column descriptions explain business meaning; prefer them over guessing from names`,
+	schema: utils.GenerateSchema[SQLSchemaInput](),
+}
+
+// SQLSchemaInput defines the input parameters for the sql_schema tool
+type SQLSchemaInput struct {
+	ConnectionID string   `json:"connection_id" jsonschema:"ID of the database connection, from the list in the tool description"`
+	Tables       []string `json:"tables,omitempty" jsonschema:"Optional table names to describe. Omit to list every table."`
+}
+
+// SQLSchemaTool introspects an external SQL connection
+type SQLSchemaTool struct {
+	BaseTool
+	service     interfaces.SQLConnectionService
+	connections []*types.SQLConnection
+}
+
+// NewSQLSchemaTool creates a sql_schema tool limited to the given connections.
+// The connections are resolved under the agent's tenant by the caller.
+func NewSQLSchemaTool(service interfaces.SQLConnectionService, connections []*types.SQLConnection) *SQLSchemaTool {
+	base := sqlSchemaTool
+	base.description += describeSQLConnections(connections)
+	return &SQLSchemaTool{BaseTool: base, service: service, connections: connections}
+}
+
+// Execute executes the tool logic
+func (t *SQLSchemaTool) Execute(ctx context.Context, args json.RawMessage) (*types.ToolResult, error) {
+	var input SQLSchemaInput
+	if err := json.Unmarshal(args, &input); err != nil {
+		return &types.ToolResult{
+			Success: false,
+			Error:   fmt.Sprintf("Failed to parse input args: %v", err),
+		}, err
+	}
+	conn, err := findSQLConnection(t.connections, input.ConnectionID)
+	if err != nil {
+		return &types.ToolResult{Success: false, Error: err.Error()}, err
+	}
+
+	logger.Infof(ctx, "[Tool][SQLSchema] connection=%s tables=%v", conn.ID, input.Tables)
+	schema, err := t.service.IntrospectSchema(ctx, conn, input.Tables)
+	if err != nil {
+		return &types.ToolResult{
+			Success: false,
+			Error:   fmt.Sprintf("Failed to read schema of '%s': %v", conn.Name, err),
+		}, err
+	}
+
+	return &types.ToolResult{
+		Success: true,
+		Output:  formatSQLSchema(conn, schema),
+		Data: map[string]interface{}{
+			"connection_id": conn.ID,
+			"dialect":       string(conn.Dialect),
+			"table_count":   len(schema.Tables),
+		},
+	}, nil
+}
+
+// describeSQLConnections renders the connection list appended to tool descriptions.
+func describeSQLConnections(connections []*types.SQLConnection) string {
+	var b strings.Builder
+	b.WriteString("\n\n## Available Connections\n")
+	for _, c := range connections {
+		fmt.Fprintf(&b, "- %s: %s (%s)", c.ID, c.Name, c.Dialect)
+		if c.Description != "" {
+			fmt.Fprintf(&b, " — %s", c.Description)
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// findSQLConnection returns the connection with id, or an error naming the valid ones.
+func findSQLConnection(connections []*types.SQLConnection, id string) (*types.SQLConnection, error) {
+	ids := make([]string, 0, len(connections))
+	for _, c := range connections {
+		if c.ID == id {
+			return c, nil
+		}
+		ids = append(ids, c.ID)
+	}
+	return nil, fmt.Errorf("unknown connection_id '%s'; available: %s", id, strings.Join(ids, ", "))
+}
+
+// formatSQLSchema renders the schema as compact text for the model.
+func formatSQLSchema(conn *types.SQLConnection, schema *types.SQLSchema) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "=== Schema of %s (%s) ===\n\n", conn.Name, conn.Dialect)
+	if len(schema.Tables) == 0 {
+		b.WriteString("No tables found.\n")
+		return b.String()
+	}
+	for _, table := range schema.Tables {
+		fmt.Fprintf(&b, "### %s", table.Name)
+		if table.Description != "" {
+			fmt.Fprintf(&b, " — %s", table.Description)
+		}
+		b.WriteString("\n")
+		for _, col := range table.Columns {
+			fmt.Fprintf(&b, "- %s (%s", col.Name, col.Type)
+			if !col.Nullable {
+				b.WriteString(", not null")
+			}
+			b.WriteString(")")
+			if col.Description != "" {
+				fmt.Fprintf(&b, ": %s", col.Description)
+			}
+			b.WriteString("\n")
+		}
+		b.WriteString("\n")
+	}
+	if schema.Truncated {
+		b.WriteString("Note: the database has more tables than listed; pass \"tables\" to describe specific ones.\n")
+	}
+	return b.String()
+}
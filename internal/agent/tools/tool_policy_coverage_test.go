@@ -20,12 +20,21 @@ func TestEveryBuiltInToolDeclaresAModelHandlePolicy(t *testing.T) {
 		ToolKnowledgeSearch,
 		ToolListKnowledgeChunks,
 		ToolQueryKnowledgeGraph,
+		ToolGraphGlobalSearch,
 		ToolGetDocumentInfo,
 		ToolSearchConversations,
 		ToolSearchMemory,
 		ToolDatabaseQuery,
 		ToolDataAnalysis,
 		ToolDataSchema,
+		ToolDataChart,
+		ToolSQLSchema,
+		ToolSQLQuery,
+		ToolBrowserNavigate,
+		ToolBrowserClick,
+		ToolBrowserFill,
+		ToolBrowserExtract,
+		ToolBrowserScreenshot,
 		ToolWebSearch,
 		ToolWebFetch,
 		ToolExecuteSkillScript,
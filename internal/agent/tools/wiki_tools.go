@@ -104,10 +104,14 @@ func renderIndexOverviewForAgent(resp *types.WikiIndexResponse) string {
 //     search to specific documents inside a KB.
 //   - TagIDs: OPTIONAL whitelist of document tags. When non-empty, a wiki page
 //     is only surfaced if at least one of its SourceRefs belongs to any tag.
+//   - ExcludeKnowledgeIDs: source documents hidden by document-level ACLs. A
+//     page citing any of them is never surfaced, whatever the other filters
+//     say, because its body may quote the hidden document.
 type WikiScope struct {
-	KnowledgeBaseID string
-	KnowledgeIDs    []string
-	TagIDs          []string
+	KnowledgeBaseID     string
+	KnowledgeIDs        []string
+	TagIDs              []string
+	ExcludeKnowledgeIDs []string
 }
 
 // NewWikiScopesFromKBIDs is a convenience constructor for callers that only
@@ -157,6 +161,7 @@ func NewWikiScopesFromSearchTargets(searchTargets types.SearchTargets, wikiKBIDs
 			scope = &accumulatedScope{WikiScope: WikiScope{KnowledgeBaseID: target.KnowledgeBaseID}}
 			byKB[target.KnowledgeBaseID] = scope
 		}
+		scope.ExcludeKnowledgeIDs = append(scope.ExcludeKnowledgeIDs, target.ExcludeKnowledgeIDs...)
 		if wholeKB {
 			scope.unrestricted = true
 			continue
@@ -171,8 +176,9 @@ func NewWikiScopesFromSearchTargets(searchTargets types.SearchTargets, wikiKBIDs
 		if scope == nil {
 			continue
 		}
+		scope.ExcludeKnowledgeIDs = dedupNonEmptyStrings(scope.ExcludeKnowledgeIDs)
 		if scope.unrestricted {
-			scopes = append(scopes, WikiScope{KnowledgeBaseID: kbID})
+			scopes = append(scopes, WikiScope{KnowledgeBaseID: kbID, ExcludeKnowledgeIDs: scope.ExcludeKnowledgeIDs})
 			continue
 		}
 		scope.KnowledgeIDs = dedupNonEmptyStrings(scope.KnowledgeIDs)
@@ -278,12 +284,32 @@ func pageIntersectsKnowledgeIDs(page *types.WikiPage, allowed map[string]bool) b
 	return false
 }
 
+// pageCitesExcludedKnowledge reports whether a content page draws on a
+// document hidden from the caller. The structural index only lists page
+// titles and stays visible.
+func pageCitesExcludedKnowledge(page *types.WikiPage, excluded []string) bool {
+	if len(excluded) == 0 || isStructuralPage(page) {
+		return false
+	}
+	for _, kid := range extractSourceKnowledgeIDs(page) {
+		for _, id := range excluded {
+			if kid == id {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 func pagePassesWikiScope(
 	ctx context.Context,
 	page *types.WikiPage,
 	scope WikiScope,
 	fetchTags knowledgeTagsFetcher,
 ) (bool, error) {
+	if pageCitesExcludedKnowledge(page, scope.ExcludeKnowledgeIDs) {
+		return false, nil
+	}
 	allowed, hasKnowledgeFilter := scopeKnowledgeFilter(scope)
 	tagIDs := dedupNonEmptyStrings(scope.TagIDs)
 	if !hasKnowledgeFilter && len(tagIDs) == 0 {
@@ -2,11 +2,14 @@ package repository
 
 import (
 	"context"
+	"fmt"
+	"sync"
 	"time"
 
 	"github.com/Tencent/WeKnora/internal/types"
 	"github.com/Tencent/WeKnora/internal/types/interfaces"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // auditLogRepository persists audit events to the audit_logs table.
@@ -17,18 +20,63 @@ type auditLogRepository struct {
 	db *gorm.DB
 }
 
+// auditChainMu serialises chain appends on SQLite, which ignores
+// FOR UPDATE; two writers would otherwise read the same head and fork
+// the chain. On Postgres the head row lock does this across replicas.
+var auditChainMu sync.Mutex
+
 // NewAuditLogRepository constructs the production audit log repository
 // backed by the shared GORM connection.
 func NewAuditLogRepository(db *gorm.DB) interfaces.AuditLogRepository {
 	return &auditLogRepository{db: db}
 }
 
-// Create inserts a single audit row. Validation is light — Action is
-// the only required field at the schema level; CreatedAt is filled by
-// the database default if zero. Service-layer Log() fills both before
-// calling here so this is mostly a pass-through.
+// Create appends a single audit row to its tenant's hash chain.
+// Validation is light — Action is the only required field at the schema
+// level. Service-layer Log() fills CreatedAt and Outcome; CreatedAt is
+// truncated to the microsecond here so the hash matches what Postgres
+// stores.
+//
+// The tenant's chain head is locked for the duration of the
+// transaction, which orders concurrent writers of one tenant; writers
+// of different tenants do not contend.
 func (r *auditLogRepository) Create(ctx context.Context, entry *types.AuditLog) error {
-	return r.db.WithContext(ctx).Create(entry).Error
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now()
+	}
+	entry.CreatedAt = entry.CreatedAt.Truncate(time.Microsecond)
+	// Hash what the row will hold, not what the column default fills in.
+	if entry.Outcome == "" {
+		entry.Outcome = types.AuditOutcomeSuccess
+	}
+	if r.db.Dialector.Name() != "postgres" {
+		auditChainMu.Lock()
+		defer auditChainMu.Unlock()
+	}
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&types.AuditLogChainHead{TenantID: entry.TenantID, UpdatedAt: entry.CreatedAt}).Error; err != nil {
+			return err
+		}
+		var head types.AuditLogChainHead
+		if err := tx.Clauses(forUpdateClause()).
+			Where("tenant_id = ?", entry.TenantID).First(&head).Error; err != nil {
+			return err
+		}
+		entry.ChainSeq = head.LastSeq + 1
+		entry.PrevHash = head.LastHash
+		entry.Hash = entry.ComputeHash()
+		if err := tx.Create(entry).Error; err != nil {
+			return err
+		}
+		return tx.Model(&types.AuditLogChainHead{}).
+			Where("tenant_id = ?", entry.TenantID).
+			Updates(map[string]interface{}{
+				"last_seq":   entry.ChainSeq,
+				"last_hash":  entry.Hash,
+				"updated_at": time.Now(),
+			}).Error
+	})
 }
 
 // auditLogListLimitMax is the hard ceiling regardless of caller input.
@@ -114,24 +162,195 @@ func (r *auditLogRepository) CountSinceForDedup(
 	return count, err
 }
 
-// DeleteOlderThan purges rows strictly older than cutoff in a single
-// DELETE. The retention sweep (driven by the audit log service) calls
-// it once a day with cutoff = now - retention_days.
+// DeleteOlderThan purges rows strictly older than cutoff. The retention
+// sweep (driven by the audit log service) calls it once a day with
+// cutoff = now - retention_days and maxID = the slowest export cursor.
 //
 // Tenant scope is intentionally not part of this signature: retention
 // is a global ops policy, not a per-tenant choice. If we ever need
 // per-tenant retention, we'd add a separate DeleteOlderThanForTenant
 // rather than overload this primitive.
 //
+// Chained rows are deleted per tenant as a prefix of the chain (every
+// entry up to the newest one past the horizon) and that entry's hash is
+// recorded as the head's retention checkpoint, so verification of the
+// remaining chain still starts from a known hash. Rows from before the
+// chain existed are deleted by created_at alone.
+//
 // Returns the number of rows affected so the caller can log the sweep
 // outcome at INFO. Errors propagate verbatim — the caller decides
 // whether they're terminal or transient.
-func (r *auditLogRepository) DeleteOlderThan(ctx context.Context, cutoff time.Time) (int64, error) {
-	res := r.db.WithContext(ctx).
-		Where("created_at < ?", cutoff).
-		Delete(&types.AuditLog{})
+func (r *auditLogRepository) DeleteOlderThan(ctx context.Context, cutoff time.Time, maxID uint64) (int64, error) {
+	var deleted int64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		horizon := func(q *gorm.DB) *gorm.DB {
+			q = q.Where("created_at < ?", cutoff)
+			if maxID > 0 {
+				q = q.Where("id <= ?", maxID)
+			}
+			return q
+		}
+		res := horizon(tx.Where("chain_seq = 0")).Delete(&types.AuditLog{})
+		if res.Error != nil {
+			return res.Error
+		}
+		deleted += res.RowsAffected
+
+		var tips []struct {
+			TenantID uint64
+			Seq      uint64
+		}
+		if err := horizon(tx.Model(&types.AuditLog{}).Where("chain_seq > 0")).
+			Select("tenant_id, MAX(chain_seq) AS seq").
+			Group("tenant_id").
+			Scan(&tips).Error; err != nil {
+			return err
+		}
+		for _, tip := range tips {
+			var last types.AuditLog
+			if err := tx.Where("tenant_id = ? AND chain_seq = ?", tip.TenantID, tip.Seq).
+				First(&last).Error; err != nil {
+				return err
+			}
+			if err := tx.Model(&types.AuditLogChainHead{}).
+				Where("tenant_id = ? AND pruned_seq < ?", tip.TenantID, tip.Seq).
+				Updates(map[string]interface{}{
+					"pruned_seq":  tip.Seq,
+					"pruned_hash": last.Hash,
+					"updated_at":  time.Now(),
+				}).Error; err != nil {
+				return err
+			}
+			res := tx.Where("tenant_id = ? AND chain_seq > 0 AND chain_seq <= ?", tip.TenantID, tip.Seq).
+				Delete(&types.AuditLog{})
+			if res.Error != nil {
+				return res.Error
+			}
+			deleted += res.RowsAffected
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return deleted, nil
+}
+
+// GetChainHead returns the tenant's chain head, or nil if it has none.
+func (r *auditLogRepository) GetChainHead(ctx context.Context, tenantID uint64) (*types.AuditLogChainHead, error) {
+	var head types.AuditLogChainHead
+	err := r.db.WithContext(ctx).Where("tenant_id = ?", tenantID).Limit(1).Find(&head).Error
+	if err != nil {
+		return nil, err
+	}
+	if head.TenantID != tenantID || (head.LastSeq == 0 && head.PrunedSeq == 0) {
+		return nil, nil
+	}
+	return &head, nil
+}
+
+// ListChain pages through a tenant's chain in chain_seq order, served by
+// idx_audit_logs_tenant_chain.
+func (r *auditLogRepository) ListChain(
+	ctx context.Context, tenantID uint64, afterSeq uint64, limit int,
+) ([]*types.AuditLog, error) {
+	var entries []*types.AuditLog
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND chain_seq > ?", tenantID, afterSeq).
+		Order("chain_seq ASC").
+		Limit(limit).
+		Find(&entries).Error
+	return entries, err
+}
+
+// CountUnchained counts a tenant's rows written before the chain existed.
+func (r *auditLogRepository) CountUnchained(ctx context.Context, tenantID uint64) (int64, error) {
+	var n int64
+	err := r.db.WithContext(ctx).Model(&types.AuditLog{}).
+		Where("tenant_id = ? AND chain_seq = 0", tenantID).
+		Count(&n).Error
+	return n, err
+}
+
+// ListForExport pages through every tenant's rows in id order for the
+// export sinks. The created_at bound leaves recent rows for the next
+// poll: ids are allocated before commit, so a row with a smaller id can
+// still become visible after a larger one.
+func (r *auditLogRepository) ListForExport(
+	ctx context.Context, afterID uint64, before time.Time, limit int,
+) ([]*types.AuditLog, error) {
+	var entries []*types.AuditLog
+	err := r.db.WithContext(ctx).
+		Where("id > ? AND created_at < ?", afterID, before).
+		Order("id ASC").
+		Limit(limit).
+		Find(&entries).Error
+	return entries, err
+}
+
+// AcquireSinkLease claims or renews the lease on a sink's cursor row.
+// The conditional UPDATE is the arbiter between replicas: it only
+// matches when the lease is free, expired, or already ours.
+func (r *auditLogRepository) AcquireSinkLease(
+	ctx context.Context, sink, owner string, until time.Time,
+) (*types.AuditLogSinkCursor, bool, error) {
+	db := r.db.WithContext(ctx)
+	now := time.Now()
+	if err := db.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&types.AuditLogSinkCursor{Sink: sink, LeaseUntil: now, UpdatedAt: now}).Error; err != nil {
+		return nil, false, err
+	}
+	res := db.Model(&types.AuditLogSinkCursor{}).
+		Where("sink = ? AND (lease_owner = ? OR lease_owner = '' OR lease_until < ?)", sink, owner, now).
+		Updates(map[string]interface{}{
+			"lease_owner": owner,
+			"lease_until": until,
+			"updated_at":  now,
+		})
 	if res.Error != nil {
-		return 0, res.Error
+		return nil, false, res.Error
+	}
+	var cursor types.AuditLogSinkCursor
+	if err := db.Where("sink = ?", sink).First(&cursor).Error; err != nil {
+		return nil, false, err
+	}
+	return &cursor, res.RowsAffected > 0, nil
+}
+
+// auditSinkErrorMaxLen matches the last_error column width.
+const auditSinkErrorMaxLen = 512
+
+// UpdateSinkCursor records export progress. It fails when owner has lost
+// the lease, so a stalled replica cannot move a cursor another replica
+// has taken over.
+func (r *auditLogRepository) UpdateSinkCursor(
+	ctx context.Context, sink, owner string, lastID uint64, lastError string,
+) error {
+	if len(lastError) > auditSinkErrorMaxLen {
+		lastError = lastError[:auditSinkErrorMaxLen]
+	}
+	res := r.db.WithContext(ctx).Model(&types.AuditLogSinkCursor{}).
+		Where("sink = ? AND lease_owner = ?", sink, owner).
+		Updates(map[string]interface{}{
+			"last_id":    lastID,
+			"last_error": lastError,
+			"updated_at": time.Now(),
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return fmt.Errorf("audit sink %s: lease lost", sink)
+	}
+	return nil
+}
+
+// ListSinkCursors returns the cursors of the given sinks.
+func (r *auditLogRepository) ListSinkCursors(ctx context.Context, sinks []string) ([]*types.AuditLogSinkCursor, error) {
+	if len(sinks) == 0 {
+		return nil, nil
 	}
-	return res.RowsAffected, nil
+	var cursors []*types.AuditLogSinkCursor
+	err := r.db.WithContext(ctx).Where("sink IN ?", sinks).Find(&cursors).Error
+	return cursors, err
 }
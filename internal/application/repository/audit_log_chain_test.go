@@ -0,0 +1,161 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func newAuditChainTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(fmt.Sprintf("file:%s?mode=memory&cache=shared", t.Name())), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	if err := db.AutoMigrate(&types.AuditLog{}, &types.AuditLogChainHead{}, &types.AuditLogSinkCursor{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return db
+}
+
+func verifyTenantChain(t *testing.T, repo *auditLogRepository, tenantID uint64) types.AuditChainVerification {
+	t.Helper()
+	ctx := context.Background()
+	head, err := repo.GetChainHead(ctx, tenantID)
+	if err != nil {
+		t.Fatalf("GetChainHead: %v", err)
+	}
+	v := types.NewAuditChainVerifier(tenantID, head)
+	entries, err := repo.ListChain(ctx, tenantID, 0, 1000)
+	if err != nil {
+		t.Fatalf("ListChain: %v", err)
+	}
+	for _, e := range entries {
+		if !v.Add(e) {
+			break
+		}
+	}
+	return v.Finish()
+}
+
+func TestAuditLogRepositoryCreateChainsConcurrentWrites(t *testing.T) {
+	db := newAuditChainTestDB(t)
+	repo := &auditLogRepository{db: db}
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			err := repo.Create(ctx, &types.AuditLog{
+				TenantID:  uint64(7 + i%2),
+				Action:    types.AuditActionKnowledgeDownloaded,
+				Details:   types.JSON(fmt.Sprintf(`{"n":%d}`, i)),
+				CreatedAt: time.Now(),
+			})
+			if err != nil {
+				t.Errorf("Create: %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	for _, tenant := range []uint64{7, 8} {
+		got := verifyTenantChain(t, repo, tenant)
+		if !got.Valid || got.Checked != 10 || got.HeadSeq != 10 {
+			t.Fatalf("tenant %d chain: %+v", tenant, got)
+		}
+	}
+
+	// Editing a stored row behind the application's back breaks the chain.
+	if err := db.Exec("UPDATE audit_logs SET outcome = 'failed' WHERE tenant_id = 7 AND chain_seq = 4").Error; err != nil {
+		t.Fatalf("tamper: %v", err)
+	}
+	if got := verifyTenantChain(t, repo, 7); got.Valid || got.BrokenAtSeq != 4 || got.Reason != types.AuditChainHashMismatch {
+		t.Fatalf("tampered chain must fail at seq 4: %+v", got)
+	}
+}
+
+func TestAuditLogRepositoryDeleteOlderThanKeepsChainVerifiable(t *testing.T) {
+	db := newAuditChainTestDB(t)
+	repo := &auditLogRepository{db: db}
+	ctx := context.Background()
+	old := time.Now().Add(-48 * time.Hour)
+
+	// A row from before the chain existed.
+	if err := db.Create(&types.AuditLog{TenantID: 7, Action: types.AuditActionMemberAdded, CreatedAt: old}).Error; err != nil {
+		t.Fatalf("insert legacy row: %v", err)
+	}
+	var ids []uint64
+	for i := 0; i < 5; i++ {
+		created := old
+		if i >= 3 {
+			created = time.Now()
+		}
+		e := &types.AuditLog{TenantID: 7, Action: types.AuditActionTagCreated, CreatedAt: created}
+		if err := repo.Create(ctx, e); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+		ids = append(ids, e.ID)
+	}
+
+	// An export sink has only shipped up to the second chained row.
+	deleted, err := repo.DeleteOlderThan(ctx, time.Now().Add(-24*time.Hour), ids[1])
+	if err != nil {
+		t.Fatalf("DeleteOlderThan: %v", err)
+	}
+	if deleted != 3 {
+		t.Fatalf("deleted = %d, want the legacy row and chain entries 1-2", deleted)
+	}
+	got := verifyTenantChain(t, repo, 7)
+	if !got.Valid || got.PrunedSeq != 2 || got.FirstSeq != 3 || got.Checked != 3 {
+		t.Fatalf("chain after retention: %+v", got)
+	}
+
+	// Deleting past the checkpoint without going through retention is
+	// reported.
+	if err := db.Exec("DELETE FROM audit_logs WHERE chain_seq = 3").Error; err != nil {
+		t.Fatalf("tamper: %v", err)
+	}
+	if got := verifyTenantChain(t, repo, 7); got.Valid || got.Reason != types.AuditChainMissingEntries {
+		t.Fatalf("deleted entry must be reported: %+v", got)
+	}
+}
+
+func TestAuditLogRepositorySinkLease(t *testing.T) {
+	db := newAuditChainTestDB(t)
+	repo := &auditLogRepository{db: db}
+	ctx := context.Background()
+	until := time.Now().Add(time.Minute)
+
+	cur, ok, err := repo.AcquireSinkLease(ctx, types.AuditSinkWebhook, "replica-a", until)
+	if err != nil || !ok || cur.LastID != 0 {
+		t.Fatalf("first acquire: ok=%v err=%v cursor=%+v", ok, err, cur)
+	}
+	if _, ok, _ := repo.AcquireSinkLease(ctx, types.AuditSinkWebhook, "replica-b", until); ok {
+		t.Fatal("a live lease must not be taken over")
+	}
+	if err := repo.UpdateSinkCursor(ctx, types.AuditSinkWebhook, "replica-b", 9, ""); err == nil {
+		t.Fatal("a non-owner must not move the cursor")
+	}
+	if err := repo.UpdateSinkCursor(ctx, types.AuditSinkWebhook, "replica-a", 9, ""); err != nil {
+		t.Fatalf("UpdateSinkCursor: %v", err)
+	}
+
+	// An expired lease is taken over and the cursor carries on.
+	if err := db.Model(&types.AuditLogSinkCursor{}).Where("sink = ?", types.AuditSinkWebhook).
+		Update("lease_until", time.Now().Add(-time.Second)).Error; err != nil {
+		t.Fatalf("expire lease: %v", err)
+	}
+	cur, ok, err = repo.AcquireSinkLease(ctx, types.AuditSinkWebhook, "replica-b", until)
+	if err != nil || !ok || cur.LastID != 9 || cur.LeaseOwner != "replica-b" {
+		t.Fatalf("takeover: ok=%v err=%v cursor=%+v", ok, err, cur)
+	}
+}
@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"gorm.io/gorm"
+)
+
+// browserConfigRepository implements the BrowserConfigRepository interface
+type browserConfigRepository struct {
+	db *gorm.DB
+}
+
+// NewBrowserConfigRepository creates a new browser config repository
+func NewBrowserConfigRepository(db *gorm.DB) interfaces.BrowserConfigRepository {
+	return &browserConfigRepository{db: db}
+}
+
+// Create creates a new browser config
+func (r *browserConfigRepository) Create(ctx context.Context, cfg *types.BrowserConfig) error {
+	return r.db.WithContext(ctx).Create(cfg).Error
+}
+
+// GetByID retrieves a browser config by ID within a tenant scope
+func (r *browserConfigRepository) GetByID(ctx context.Context, tenantID uint64, id string) (*types.BrowserConfig, error) {
+	var cfg types.BrowserConfig
+	if err := r.db.WithContext(ctx).Where(
+		"id = ? AND tenant_id = ?", id, tenantID,
+	).First(&cfg).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// List lists all browser configs for a tenant
+func (r *browserConfigRepository) List(ctx context.Context, tenantID uint64) ([]*types.BrowserConfig, error) {
+	var cfgs []*types.BrowserConfig
+	if err := r.db.WithContext(ctx).Where(
+		"tenant_id = ?", tenantID,
+	).Order("created_at ASC").Find(&cfgs).Error; err != nil {
+		return nil, err
+	}
+	return cfgs, nil
+}
+
+// Update updates a browser config
+func (r *browserConfigRepository) Update(ctx context.Context, cfg *types.BrowserConfig) error {
+	return r.db.WithContext(ctx).Model(&types.BrowserConfig{}).Where(
+		"id = ? AND tenant_id = ?", cfg.ID, cfg.TenantID,
+	).Select("*").Updates(cfg).Error
+}
+
+// Delete soft-deletes a browser config
+func (r *browserConfigRepository) Delete(ctx context.Context, tenantID uint64, id string) error {
+	return r.db.WithContext(ctx).Where(
+		"id = ? AND tenant_id = ?", id, tenantID,
+	).Delete(&types.BrowserConfig{}).Error
+}
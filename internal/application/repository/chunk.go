@@ -317,12 +317,21 @@ func (r *chunkRepository) CreateChunkRevision(ctx context.Context, revision *typ
 func (r *chunkRepository) SaveChunkRevision(
 	ctx context.Context, chunk *types.Chunk, revision *types.ChunkRevision, expectedRevision int,
 ) error {
+	// A map update bypasses the Chunk hooks, so seal the text here.
+	content, err := types.SealChunkContent(ctx, types.ContentCipherOf(r.db), chunk.TenantID, common.CleanInvalidUTF8(chunk.Content))
+	if err != nil {
+		return err
+	}
+	source, err := types.SealChunkContent(ctx, types.ContentCipherOf(r.db), chunk.TenantID, common.CleanInvalidUTF8(chunk.SourceContent))
+	if err != nil {
+		return err
+	}
 	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		result := tx.Model(&types.Chunk{}).
 			Where("id = ? AND tenant_id = ? AND content_revision = ?", chunk.ID, chunk.TenantID, expectedRevision).
 			Updates(map[string]interface{}{
-				"content":          common.CleanInvalidUTF8(chunk.Content),
-				"source_content":   common.CleanInvalidUTF8(chunk.SourceContent),
+				"content":          content,
+				"source_content":   source,
 				"content_revision": chunk.ContentRevision,
 				"is_enabled":       chunk.IsEnabled,
 				"metadata":         chunk.Metadata,
@@ -414,7 +423,10 @@ func (r *chunkRepository) UpdateChunks(ctx context.Context, chunks []*types.Chun
 
 	for _, chunk := range chunks {
 		ids = append(ids, chunk.ID)
-		content := common.CleanInvalidUTF8(chunk.Content)
+		content, err := types.SealChunkContent(ctx, types.ContentCipherOf(r.db), chunk.TenantID, common.CleanInvalidUTF8(chunk.Content))
+		if err != nil {
+			return err
+		}
 
 		contentCases = append(contentCases, "WHEN id = ? THEN ?")
 		contentArgs = append(contentArgs, chunk.ID, content)
@@ -2,9 +2,12 @@ package repository
 
 import (
 	"context"
+	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/utils"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -331,3 +334,94 @@ func TestListRecentDocumentChunksWithQuestions_UnionsExplicitKBAndKnowledge(t *t
 	require.Len(t, got, 2)
 	assert.ElementsMatch(t, []string{fromExplicitKB.ID, fromExplicitDocument.ID}, []string{got[0].ID, got[1].ID})
 }
+
+// markerCipher seals by tagging the text with the envelope prefix and the
+// tenant, which is enough to see where the chunk hooks apply it.
+type markerCipher struct{}
+
+func (markerCipher) SealContent(_ context.Context, tenantID uint64, plaintext string) (string, error) {
+	return fmt.Sprintf("%s1:%d:%s", utils.EnvelopeContentPrefix, tenantID, plaintext), nil
+}
+
+func (markerCipher) OpenContent(_ context.Context, tenantID uint64, sealed string) (string, error) {
+	return strings.TrimPrefix(sealed, fmt.Sprintf("%s1:%d:", utils.EnvelopeContentPrefix, tenantID)), nil
+}
+
+func (markerCipher) Name() string { return types.ContentCipherPluginName }
+
+func (markerCipher) Initialize(*gorm.DB) error { return nil }
+
+func TestChunkContentEncryption_SQLite(t *testing.T) {
+	db := setupChunkTestDB(t)
+	require.NoError(t, db.Use(markerCipher{}))
+	repo := NewChunkRepository(db)
+	ctx := context.Background()
+
+	chunk := makeChunk(uuid.New().String(), uuid.New().String(), "text")
+	chunk.Content = "secret paragraph"
+	require.NoError(t, repo.CreateChunks(ctx, []*types.Chunk{chunk}))
+	// The caller keeps the plaintext: indexing runs after the save.
+	assert.Equal(t, "secret paragraph", chunk.Content)
+
+	var stored string
+	require.NoError(t, db.Raw("SELECT content FROM chunks WHERE id = ?", chunk.ID).Scan(&stored).Error)
+	assert.Equal(t, utils.EnvelopeContentPrefix+"1:1:secret paragraph", stored)
+
+	loaded, err := repo.GetChunkByID(ctx, 1, chunk.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "secret paragraph", loaded.Content)
+
+	loaded.Content = "edited"
+	require.NoError(t, repo.UpdateChunks(ctx, []*types.Chunk{loaded}))
+	require.NoError(t, db.Raw("SELECT content FROM chunks WHERE id = ?", chunk.ID).Scan(&stored).Error)
+	assert.Equal(t, utils.EnvelopeContentPrefix+"1:1:edited", stored)
+
+	// Rows written before encryption was enabled still load.
+	require.NoError(t, db.Exec("UPDATE chunks SET content = ? WHERE id = ?", "legacy", chunk.ID).Error)
+	loaded, err = repo.GetChunkByID(ctx, 1, chunk.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "legacy", loaded.Content)
+}
+
+func TestKnowledgeVersionContentEncryption_SQLite(t *testing.T) {
+	db := setupChunkTestDB(t)
+	require.NoError(t, db.AutoMigrate(&types.KnowledgeVersion{}))
+	require.NoError(t, db.Use(markerCipher{}))
+	repo := NewKnowledgeVersionRepository(db)
+	ctx := context.Background()
+
+	version := &types.KnowledgeVersion{
+		TenantID: 1, KnowledgeID: "doc-1", Markdown: "# secret",
+		Chunks: types.KnowledgeVersionChunks{{Seq: 0, Content: "secret", ContextHeader: "intro"}},
+	}
+	require.NoError(t, repo.Create(ctx, version))
+	assert.Equal(t, "# secret", version.Markdown)
+	assert.Equal(t, "secret", version.Chunks[0].Content)
+
+	var stored struct{ Markdown, Chunks string }
+	require.NoError(t, db.Raw("SELECT markdown, chunks FROM knowledge_versions WHERE id = ?", version.ID).Scan(&stored).Error)
+	assert.Equal(t, utils.EnvelopeContentPrefix+"1:1:# secret", stored.Markdown)
+	assert.NotContains(t, stored.Chunks, `"secret"`)
+	assert.Contains(t, stored.Chunks, utils.EnvelopeContentPrefix+"1:1:intro")
+
+	loaded, err := repo.Get(ctx, 1, "doc-1", 1)
+	require.NoError(t, err)
+	assert.Equal(t, "# secret", loaded.Markdown)
+	assert.Equal(t, "secret", loaded.Chunks[0].Content)
+	assert.Equal(t, "intro", loaded.Chunks[0].ContextHeader)
+
+	// Versions written before encryption was enabled are sealed in place.
+	require.NoError(t, db.Exec(
+		"UPDATE knowledge_versions SET markdown = ?, chunks = ? WHERE id = ?",
+		"legacy", `[{"seq":0,"content":"legacy chunk","start":0,"end":0}]`, version.ID,
+	).Error)
+	n, err := repo.SealPlaintext(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	require.NoError(t, db.Raw("SELECT markdown, chunks FROM knowledge_versions WHERE id = ?", version.ID).Scan(&stored).Error)
+	assert.Equal(t, utils.EnvelopeContentPrefix+"1:1:legacy", stored.Markdown)
+	assert.Contains(t, stored.Chunks, utils.EnvelopeContentPrefix+"1:1:legacy chunk")
+	n, err = repo.SealPlaintext(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, n)
+}
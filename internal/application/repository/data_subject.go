@@ -0,0 +1,512 @@
+package repository
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"gorm.io/gorm"
+)
+
+// memoryTables hold one subject's memory, keyed by (tenant_id, subject_id).
+var memoryTables = []string{
+	"memory_item_embeddings", "memory_items", "memory_topic_stats",
+	"memory_doc_affinity", "memory_tombstones", "memory_subjects",
+}
+
+type dataSubjectRepository struct {
+	db *gorm.DB
+}
+
+// NewDataSubjectRepository creates the data subject repository.
+func NewDataSubjectRepository(db *gorm.DB) interfaces.DataSubjectRepository {
+	return &dataSubjectRepository{db: db}
+}
+
+// sessionsOf selects the IDs of the user's sessions, soft-deleted included.
+func (r *dataSubjectRepository) sessionsOf(db *gorm.DB, tenantID uint64, userID string) *gorm.DB {
+	return db.Unscoped().Model(&types.Session{}).Select("id").
+		Where("tenant_id = ? AND user_id = ?", tenantID, userID)
+}
+
+// bindingsOf selects the (channel, IM user) pairs bound to the user.
+func (r *dataSubjectRepository) bindingsOf(db *gorm.DB, tenantID uint64, userID string) *gorm.DB {
+	return db.Table("im_user_bindings").Select("im_channel_id, im_user_id").
+		Where("tenant_id = ? AND user_id = ?", tenantID, userID)
+}
+
+func (r *dataSubjectRepository) mcpTokensOf(db *gorm.DB, tenantID uint64, userID string) *gorm.DB {
+	return db.Model(&types.MCPOAuthToken{}).
+		Where("tenant_id = ? AND (user_id = ? OR (principal_type = ? AND principal_id = ?))",
+			tenantID, userID, types.PrincipalWebUser, userID)
+}
+
+// sharedMemoryOf selects the items of other subjects, i.e. shared spaces,
+// the subject proposed or reviewed.
+func (r *dataSubjectRepository) sharedMemoryOf(db *gorm.DB, tenantID uint64, subjectID string) *gorm.DB {
+	return db.Model(&types.MemoryItem{}).
+		Where("tenant_id = ? AND subject_id <> ? AND (promoted_by = ? OR reviewed_by = ?)",
+			tenantID, subjectID, subjectID, subjectID)
+}
+
+// aclEntriesOf selects the knowledge ACL entries naming the user by ID or,
+// when known, by email. Email principals are stored lower-cased.
+func (r *dataSubjectRepository) aclEntriesOf(db *gorm.DB, tenantID uint64, userID, email string) *gorm.DB {
+	q := db.Model(&types.KnowledgeACLEntry{}).Where("tenant_id = ?", tenantID)
+	email = strings.ToLower(strings.TrimSpace(email))
+	if email == "" {
+		return q.Where("principal_type = ? AND principal_id = ?", types.KnowledgeACLPrincipalUser, userID)
+	}
+	return q.Where("((principal_type = ? AND principal_id = ?) OR (principal_type = ? AND principal_id = ?))",
+		types.KnowledgeACLPrincipalUser, userID, types.KnowledgeACLPrincipalEmail, email)
+}
+
+func (r *dataSubjectRepository) Count(
+	ctx context.Context, tenantID uint64, userID, email, memorySubjectID string,
+) (map[types.DataSubjectCategory]int64, error) {
+	db := r.db.WithContext(ctx)
+	queries := map[types.DataSubjectCategory]*gorm.DB{
+		types.DataSubjectSessions: r.sessionsOf(db, tenantID, userID),
+		types.DataSubjectMessages: db.Unscoped().Model(&types.Message{}).
+			Where("session_id IN (?)", r.sessionsOf(db, tenantID, userID)),
+		types.DataSubjectUploadedKnowledge: db.Model(&types.KnowledgeVersion{}).
+			Where("tenant_id = ? AND editor_id = ?", tenantID, userID),
+		types.DataSubjectFeedback: db.Table("im_message_feedback").
+			Where("tenant_id = ? AND (im_channel_id, im_user_id) IN (?)", tenantID, r.bindingsOf(db, tenantID, userID)),
+		types.DataSubjectIMBindings: db.Table("im_user_bindings").
+			Where("tenant_id = ? AND user_id = ?", tenantID, userID),
+		types.DataSubjectAPIKeys: db.Model(&types.TenantAPIKey{}).
+			Where("tenant_id = ? AND created_by = ?", tenantID, userID),
+		types.DataSubjectUsageRecords: db.Model(&types.UsageRecord{}).
+			Where("tenant_id = ? AND user_id = ?", tenantID, userID),
+		types.DataSubjectFavorites: db.Model(&types.UserResourceFavorite{}).
+			Where("tenant_id = ? AND user_id = ?", tenantID, userID),
+		types.DataSubjectMCPTokens: r.mcpTokensOf(db, tenantID, userID),
+		types.DataSubjectAuditLogs: db.Model(&types.AuditLog{}).
+			Where("tenant_id = ? AND (actor_user_id = ? OR target_user_id = ?)", tenantID, userID, userID),
+		types.DataSubjectSharedMemory: r.sharedMemoryOf(db, tenantID, memorySubjectID),
+		types.DataSubjectKnowledgeACL: r.aclEntriesOf(db, tenantID, userID, email),
+		types.DataSubjectUserGroups: db.Model(&types.UserGroupMember{}).
+			Where("tenant_id = ? AND user_id = ?", tenantID, userID),
+		types.DataSubjectSCIM: db.Model(&types.TenantSCIMUser{}).
+			Where("tenant_id = ? AND user_id = ?", tenantID, userID),
+	}
+	counts := make(map[types.DataSubjectCategory]int64, len(queries))
+	for category, q := range queries {
+		var n int64
+		if err := q.Count(&n).Error; err != nil {
+			return nil, err
+		}
+		counts[category] = n
+	}
+	for _, table := range memoryTables {
+		var n int64
+		if err := db.Table(table).Where("tenant_id = ? AND subject_id = ?", tenantID, memorySubjectID).
+			Count(&n).Error; err != nil {
+			return nil, err
+		}
+		counts[types.DataSubjectMemory] += n
+	}
+	return counts, nil
+}
+
+func (r *dataSubjectRepository) ListSessions(ctx context.Context, tenantID uint64, userID string) ([]*types.Session, error) {
+	var out []*types.Session
+	err := r.db.WithContext(ctx).Unscoped().
+		Where("tenant_id = ? AND user_id = ?", tenantID, userID).
+		Order("created_at").Find(&out).Error
+	return out, err
+}
+
+func (r *dataSubjectRepository) ListMessages(ctx context.Context, sessionIDs []string) ([]*types.Message, error) {
+	var out []*types.Message
+	if len(sessionIDs) == 0 {
+		return out, nil
+	}
+	err := r.db.WithContext(ctx).Unscoped().
+		Where("session_id IN ?", sessionIDs).
+		Order("created_at").Find(&out).Error
+	return out, err
+}
+
+func (r *dataSubjectRepository) ListTemporaryDocuments(
+	ctx context.Context, tenantID uint64, sessionIDs []string,
+) ([]*types.TemporaryDocument, error) {
+	var out []*types.TemporaryDocument
+	if len(sessionIDs) == 0 {
+		return out, nil
+	}
+	err := r.db.WithContext(ctx).Unscoped().
+		Where("tenant_id = ? AND session_id IN ?", tenantID, sessionIDs).
+		Find(&out).Error
+	return out, err
+}
+
+func (r *dataSubjectRepository) DeleteSessions(
+	ctx context.Context, tenantID uint64, sessionIDs []string,
+) (sessions, messages int64, err error) {
+	if len(sessionIDs) == 0 {
+		return 0, 0, nil
+	}
+	err = r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Unscoped().Where("session_id IN ?", sessionIDs).Delete(&types.Message{})
+		if res.Error != nil {
+			return res.Error
+		}
+		messages = res.RowsAffected
+		for _, model := range []any{
+			&types.MessageSuggestionEvent{}, &types.MessageSuggestionSet{}, &types.TemporaryDocument{},
+		} {
+			if err := tx.Unscoped().Where("tenant_id = ? AND session_id IN ?", tenantID, sessionIDs).
+				Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Exec("DELETE FROM im_channel_sessions WHERE tenant_id = ? AND session_id IN ?",
+			tenantID, sessionIDs).Error; err != nil {
+			return err
+		}
+		res = tx.Unscoped().Where("tenant_id = ? AND id IN ?", tenantID, sessionIDs).Delete(&types.Session{})
+		sessions = res.RowsAffected
+		return res.Error
+	})
+	return sessions, messages, err
+}
+
+func (r *dataSubjectRepository) GetMemory(
+	ctx context.Context, tenantID uint64, subjectID string,
+) (*types.DataSubjectMemoryData, error) {
+	db := r.db.WithContext(ctx)
+	out := &types.DataSubjectMemoryData{}
+	var subjects []*types.MemorySubject
+	if err := db.Where("tenant_id = ? AND subject_id = ?", tenantID, subjectID).
+		Limit(1).Find(&subjects).Error; err != nil {
+		return nil, err
+	}
+	if len(subjects) > 0 {
+		out.Subject = subjects[0]
+	}
+	for _, dest := range []any{&out.Items, &out.Topics, &out.DocAffinity, &out.Tombstones} {
+		if err := db.Where("tenant_id = ? AND subject_id = ?", tenantID, subjectID).
+			Find(dest).Error; err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+func (r *dataSubjectRepository) DeleteMemory(ctx context.Context, tenantID uint64, subjectID string) (int64, error) {
+	var deleted int64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, table := range memoryTables {
+			res := tx.Exec("DELETE FROM "+table+" WHERE tenant_id = ? AND subject_id = ?", tenantID, subjectID)
+			if res.Error != nil {
+				return res.Error
+			}
+			deleted += res.RowsAffected
+		}
+		return nil
+	})
+	return deleted, err
+}
+
+func (r *dataSubjectRepository) ListSharedMemory(
+	ctx context.Context, tenantID uint64, subjectID string,
+) ([]*types.MemoryItem, error) {
+	var out []*types.MemoryItem
+	err := r.sharedMemoryOf(r.db.WithContext(ctx), tenantID, subjectID).
+		Order("created_at").Find(&out).Error
+	return out, err
+}
+
+// AnonymizeSharedMemory deletes pending proposals, which nobody has accepted
+// into the shared space yet, and keeps approved items as shared knowledge
+// without the link back to the subject. promoted_from points at the
+// subject's personal item and goes with it.
+func (r *dataSubjectRepository) AnonymizeSharedMemory(ctx context.Context, tenantID uint64, subjectID string) (int64, error) {
+	var changed int64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		pending := tx.Model(&types.MemoryItem{}).Select("id").
+			Where("tenant_id = ? AND subject_id <> ? AND promoted_by = ? AND status = ?",
+				tenantID, subjectID, subjectID, types.MemoryStatusPending)
+		if err := tx.Exec("DELETE FROM memory_item_embeddings WHERE tenant_id = ? AND item_id IN (?)",
+			tenantID, pending).Error; err != nil {
+			return err
+		}
+		res := tx.Exec("DELETE FROM memory_items WHERE tenant_id = ? AND subject_id <> ? AND promoted_by = ? AND status = ?",
+			tenantID, subjectID, subjectID, types.MemoryStatusPending)
+		if res.Error != nil {
+			return res.Error
+		}
+		changed = res.RowsAffected
+		// promoted_from is tested before promoted_by is cleared: MySQL
+		// evaluates SET assignments left to right.
+		res = tx.Exec(`UPDATE memory_items SET
+				promoted_from = CASE WHEN promoted_by = ? THEN '' ELSE promoted_from END,
+				promoted_by = CASE WHEN promoted_by = ? THEN '' ELSE promoted_by END,
+				reviewed_by = CASE WHEN reviewed_by = ? THEN '' ELSE reviewed_by END
+			WHERE tenant_id = ? AND subject_id <> ? AND (promoted_by = ? OR reviewed_by = ?)`,
+			subjectID, subjectID, subjectID, tenantID, subjectID, subjectID, subjectID)
+		changed += res.RowsAffected
+		return res.Error
+	})
+	return changed, err
+}
+
+func (r *dataSubjectRepository) ListUploadedKnowledgeIDs(
+	ctx context.Context, tenantID uint64, userID string,
+) ([]string, error) {
+	db := r.db.WithContext(ctx)
+	edited := db.Model(&types.KnowledgeVersion{}).Select("knowledge_id").
+		Where("tenant_id = ? AND editor_id = ?", tenantID, userID)
+	created := db.Model(&types.AuditLog{}).Select("target_id").
+		Where("tenant_id = ? AND action = ? AND target_type = ? AND actor_user_id = ?",
+			tenantID, types.AuditActionKnowledgeCreated, "knowledge", userID)
+	var ids []string
+	err := db.Unscoped().Model(&types.Knowledge{}).
+		Where("tenant_id = ? AND (id IN (?) OR id IN (?))", tenantID, edited, created).
+		Order("created_at").Pluck("id", &ids).Error
+	return ids, err
+}
+
+func (r *dataSubjectRepository) CountKnowledge(ctx context.Context, tenantID uint64, ids []string) (int64, error) {
+	var n int64
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	err := r.db.WithContext(ctx).Unscoped().Model(&types.Knowledge{}).
+		Where("tenant_id = ? AND id IN ?", tenantID, ids).Count(&n).Error
+	return n, err
+}
+
+func (r *dataSubjectRepository) AnonymizeKnowledgeEditor(ctx context.Context, tenantID uint64, userID string) (int64, error) {
+	res := r.db.WithContext(ctx).Model(&types.KnowledgeVersion{}).
+		Where("tenant_id = ? AND editor_id = ?", tenantID, userID).
+		Update("editor_id", "")
+	return res.RowsAffected, res.Error
+}
+
+func (r *dataSubjectRepository) ListFeedback(
+	ctx context.Context, tenantID uint64, userID string,
+) ([]*types.DataSubjectFeedbackEntry, error) {
+	db := r.db.WithContext(ctx)
+	var out []*types.DataSubjectFeedbackEntry
+	err := db.Table("im_message_feedback").
+		Where("tenant_id = ? AND (im_channel_id, im_user_id) IN (?)", tenantID, r.bindingsOf(db, tenantID, userID)).
+		Order("created_at").Find(&out).Error
+	return out, err
+}
+
+func (r *dataSubjectRepository) ListIMBindings(
+	ctx context.Context, tenantID uint64, userID string,
+) ([]*types.DataSubjectIMBinding, error) {
+	var out []*types.DataSubjectIMBinding
+	err := r.db.WithContext(ctx).Table("im_user_bindings").
+		Where("tenant_id = ? AND user_id = ?", tenantID, userID).
+		Order("created_at").Find(&out).Error
+	return out, err
+}
+
+func (r *dataSubjectRepository) DeleteFeedback(
+	ctx context.Context, tenantID uint64, userID string,
+) (feedback, bindings int64, err error) {
+	err = r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Exec("DELETE FROM im_message_feedback WHERE tenant_id = ? AND (im_channel_id, im_user_id) IN (?)",
+			tenantID, r.bindingsOf(tx, tenantID, userID))
+		if res.Error != nil {
+			return res.Error
+		}
+		feedback = res.RowsAffected
+		res = tx.Exec("DELETE FROM im_user_bindings WHERE tenant_id = ? AND user_id = ?", tenantID, userID)
+		bindings = res.RowsAffected
+		return res.Error
+	})
+	return feedback, bindings, err
+}
+
+func (r *dataSubjectRepository) ListAPIKeys(
+	ctx context.Context, tenantID uint64, userID string,
+) ([]*types.TenantAPIKey, error) {
+	var out []*types.TenantAPIKey
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND created_by = ?", tenantID, userID).
+		Order("id").Find(&out).Error
+	return out, err
+}
+
+func (r *dataSubjectRepository) ClearAPIKeyCreator(ctx context.Context, tenantID uint64, userID string) (int64, error) {
+	res := r.db.WithContext(ctx).Model(&types.TenantAPIKey{}).
+		Where("tenant_id = ? AND created_by = ?", tenantID, userID).
+		Update("created_by", "")
+	return res.RowsAffected, res.Error
+}
+
+func (r *dataSubjectRepository) ListUsageRecords(
+	ctx context.Context, tenantID uint64, userID string,
+) ([]*types.UsageRecord, error) {
+	var out []*types.UsageRecord
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND user_id = ?", tenantID, userID).
+		Order("id").Find(&out).Error
+	return out, err
+}
+
+func (r *dataSubjectRepository) AnonymizeUsageRecords(ctx context.Context, tenantID uint64, userID string) (int64, error) {
+	res := r.db.WithContext(ctx).Model(&types.UsageRecord{}).
+		Where("tenant_id = ? AND user_id = ?", tenantID, userID).
+		Update("user_id", "")
+	return res.RowsAffected, res.Error
+}
+
+func (r *dataSubjectRepository) ListFavorites(
+	ctx context.Context, tenantID uint64, userID string,
+) ([]*types.UserResourceFavorite, error) {
+	var out []*types.UserResourceFavorite
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND user_id = ?", tenantID, userID).
+		Order("created_at").Find(&out).Error
+	return out, err
+}
+
+func (r *dataSubjectRepository) DeleteFavorites(ctx context.Context, tenantID uint64, userID string) (int64, error) {
+	res := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND user_id = ?", tenantID, userID).
+		Delete(&types.UserResourceFavorite{})
+	return res.RowsAffected, res.Error
+}
+
+func (r *dataSubjectRepository) ListMCPTokens(
+	ctx context.Context, tenantID uint64, userID string,
+) ([]*types.MCPOAuthToken, error) {
+	var out []*types.MCPOAuthToken
+	err := r.mcpTokensOf(r.db.WithContext(ctx), tenantID, userID).Order("created_at").Find(&out).Error
+	return out, err
+}
+
+func (r *dataSubjectRepository) DeleteMCPTokens(ctx context.Context, tenantID uint64, userID string) (int64, error) {
+	res := r.mcpTokensOf(r.db.WithContext(ctx), tenantID, userID).Delete(&types.MCPOAuthToken{})
+	return res.RowsAffected, res.Error
+}
+
+func (r *dataSubjectRepository) ListAuditLogs(
+	ctx context.Context, tenantID uint64, userID string,
+) ([]*types.AuditLog, error) {
+	var out []*types.AuditLog
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND (actor_user_id = ? OR target_user_id = ?)", tenantID, userID, userID).
+		Order("id").Find(&out).Error
+	return out, err
+}
+
+func (r *dataSubjectRepository) ListKnowledgeACLEntries(
+	ctx context.Context, tenantID uint64, userID, email string,
+) ([]*types.KnowledgeACLEntry, error) {
+	var out []*types.KnowledgeACLEntry
+	err := r.aclEntriesOf(r.db.WithContext(ctx), tenantID, userID, email).
+		Order("created_at").Find(&out).Error
+	return out, err
+}
+
+// DeleteKnowledgeACLEntries removes the user's grants. A knowledge item
+// without entries is open to the whole knowledge base, so an item whose only
+// grantee was the user keeps a KnowledgeACLErasedEmail entry instead.
+func (r *dataSubjectRepository) DeleteKnowledgeACLEntries(
+	ctx context.Context, tenantID uint64, userID, email string,
+) (int64, error) {
+	var deleted int64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var knowledgeIDs []string
+		if err := r.aclEntriesOf(tx, tenantID, userID, email).
+			Distinct("knowledge_id").Pluck("knowledge_id", &knowledgeIDs).Error; err != nil {
+			return err
+		}
+		if len(knowledgeIDs) == 0 {
+			return nil
+		}
+		res := r.aclEntriesOf(tx, tenantID, userID, email).Delete(&types.KnowledgeACLEntry{})
+		if res.Error != nil {
+			return res.Error
+		}
+		deleted = res.RowsAffected
+		var remaining []string
+		if err := tx.Model(&types.KnowledgeACLEntry{}).
+			Where("tenant_id = ? AND knowledge_id IN ?", tenantID, knowledgeIDs).
+			Distinct("knowledge_id").Pluck("knowledge_id", &remaining).Error; err != nil {
+			return err
+		}
+		restricted := make(map[string]bool, len(remaining))
+		for _, id := range remaining {
+			restricted[id] = true
+		}
+		var placeholders []*types.KnowledgeACLEntry
+		for _, id := range knowledgeIDs {
+			if restricted[id] {
+				continue
+			}
+			placeholders = append(placeholders, &types.KnowledgeACLEntry{
+				TenantID:      tenantID,
+				KnowledgeID:   id,
+				PrincipalType: types.KnowledgeACLPrincipalEmail,
+				PrincipalID:   types.KnowledgeACLErasedEmail,
+				Source:        types.KnowledgeACLSourceManual,
+			})
+		}
+		if len(placeholders) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(placeholders, 100).Error
+	})
+	return deleted, err
+}
+
+func (r *dataSubjectRepository) ListUserGroupMemberships(
+	ctx context.Context, tenantID uint64, userID string,
+) ([]*types.DataSubjectGroupMembership, error) {
+	var rows []struct {
+		GroupID    string
+		Name       string
+		ExternalID string
+		CreatedAt  time.Time
+	}
+	err := r.db.WithContext(ctx).Table("user_group_members AS m").
+		Select("m.group_id, g.name, g.external_id, m.created_at").
+		Joins("JOIN user_groups g ON g.id = m.group_id AND g.tenant_id = m.tenant_id").
+		Where("m.tenant_id = ? AND m.user_id = ?", tenantID, userID).
+		Order("m.created_at").Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*types.DataSubjectGroupMembership, 0, len(rows))
+	for _, row := range rows {
+		out = append(out, &types.DataSubjectGroupMembership{
+			GroupID: row.GroupID, Name: row.Name, ExternalID: row.ExternalID, CreatedAt: row.CreatedAt,
+		})
+	}
+	return out, nil
+}
+
+func (r *dataSubjectRepository) DeleteUserGroupMemberships(ctx context.Context, tenantID uint64, userID string) (int64, error) {
+	res := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND user_id = ?", tenantID, userID).
+		Delete(&types.UserGroupMember{})
+	return res.RowsAffected, res.Error
+}
+
+func (r *dataSubjectRepository) ListSCIMLinks(
+	ctx context.Context, tenantID uint64, userID string,
+) ([]*types.TenantSCIMUser, error) {
+	var out []*types.TenantSCIMUser
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND user_id = ?", tenantID, userID).
+		Find(&out).Error
+	return out, err
+}
+
+func (r *dataSubjectRepository) DeleteSCIMLinks(ctx context.Context, tenantID uint64, userID string) (int64, error) {
+	res := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND user_id = ?", tenantID, userID).
+		Delete(&types.TenantSCIMUser{})
+	return res.RowsAffected, res.Error
+}
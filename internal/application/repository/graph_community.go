@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"gorm.io/gorm"
+)
+
+// graphCommunityInsertBatch bounds one INSERT. Reports carry a vector each,
+// so a large hierarchy in one statement can exceed driver parameter limits.
+const graphCommunityInsertBatch = 100
+
+type graphCommunityRepository struct {
+	db *gorm.DB
+}
+
+// NewGraphCommunityRepository creates the graph community repository.
+func NewGraphCommunityRepository(db *gorm.DB) interfaces.GraphCommunityRepository {
+	return &graphCommunityRepository{db: db}
+}
+
+func (r *graphCommunityRepository) scoped(ctx context.Context, tenantID uint64, kbID string) *gorm.DB {
+	return r.db.WithContext(ctx).
+		Where("tenant_id = ? AND knowledge_base_id = ?", tenantID, kbID)
+}
+
+func (r *graphCommunityRepository) ReplaceForKnowledgeBase(
+	ctx context.Context, tenantID uint64, kbID string, communities []*types.GraphCommunity,
+) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("tenant_id = ? AND knowledge_base_id = ?", tenantID, kbID).
+			Delete(&types.GraphCommunity{}).Error; err != nil {
+			return err
+		}
+		if len(communities) == 0 {
+			return nil
+		}
+		for _, community := range communities {
+			community.TenantID = tenantID
+			community.KnowledgeBaseID = kbID
+		}
+		return tx.CreateInBatches(communities, graphCommunityInsertBatch).Error
+	})
+}
+
+func (r *graphCommunityRepository) ListByKnowledgeBase(
+	ctx context.Context, tenantID uint64, kbID string, level int,
+) ([]*types.GraphCommunity, error) {
+	query := r.scoped(ctx, tenantID, kbID)
+	if level >= 0 {
+		query = query.Where("level = ?", level)
+	}
+	var communities []*types.GraphCommunity
+	err := query.Order("level ASC, rating DESC, entity_count DESC, id ASC").Find(&communities).Error
+	return communities, err
+}
+
+func (r *graphCommunityRepository) GetByID(
+	ctx context.Context, tenantID uint64, kbID string, id string,
+) (*types.GraphCommunity, error) {
+	var community types.GraphCommunity
+	err := r.scoped(ctx, tenantID, kbID).Where("id = ?", id).First(&community).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &community, nil
+}
+
+func (r *graphCommunityRepository) DeleteByKnowledgeBase(
+	ctx context.Context, tenantID uint64, kbID string,
+) error {
+	return r.scoped(ctx, tenantID, kbID).Delete(&types.GraphCommunity{}).Error
+}
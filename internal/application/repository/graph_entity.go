@@ -0,0 +1,202 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type graphEntityRepository struct {
+	db *gorm.DB
+}
+
+// NewGraphEntityRepository creates the entity resolution repository.
+func NewGraphEntityRepository(db *gorm.DB) interfaces.GraphEntityRepository {
+	return &graphEntityRepository{db: db}
+}
+
+func (r *graphEntityRepository) scoped(ctx context.Context, tenantID uint64, kbID string) *gorm.DB {
+	return r.db.WithContext(ctx).
+		Where("tenant_id = ? AND knowledge_base_id = ?", tenantID, kbID)
+}
+
+func (r *graphEntityRepository) CreateCandidates(
+	ctx context.Context, candidates []*types.GraphMergeCandidate,
+) error {
+	if len(candidates) == 0 {
+		return nil
+	}
+	for _, c := range candidates {
+		if c.ID == "" {
+			c.ID = uuid.New().String()
+		}
+	}
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "tenant_id"}, {Name: "knowledge_base_id"}, {Name: "pair_key"}},
+			DoNothing: true,
+		}).
+		CreateInBatches(candidates, 100).Error
+}
+
+func (r *graphEntityRepository) ListCandidates(
+	ctx context.Context, tenantID uint64, kbID string, status string,
+) ([]*types.GraphMergeCandidate, error) {
+	query := r.scoped(ctx, tenantID, kbID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	var candidates []*types.GraphMergeCandidate
+	err := query.Order("score DESC, created_at ASC, id ASC").Find(&candidates).Error
+	return candidates, err
+}
+
+func (r *graphEntityRepository) GetCandidate(
+	ctx context.Context, tenantID uint64, kbID string, id string,
+) (*types.GraphMergeCandidate, error) {
+	var candidate types.GraphMergeCandidate
+	err := r.scoped(ctx, tenantID, kbID).Where("id = ?", id).First(&candidate).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &candidate, nil
+}
+
+func (r *graphEntityRepository) ListCandidatePairKeys(
+	ctx context.Context, tenantID uint64, kbID string,
+) ([]string, error) {
+	var keys []string
+	err := r.scoped(ctx, tenantID, kbID).
+		Model(&types.GraphMergeCandidate{}).
+		Pluck("pair_key", &keys).Error
+	return keys, err
+}
+
+func (r *graphEntityRepository) UpdateCandidate(ctx context.Context, candidate *types.GraphMergeCandidate) error {
+	return r.db.WithContext(ctx).Model(candidate).
+		Select("canonical", "alias", "status", "resolved_by", "resolved_at", "updated_at").
+		Updates(candidate).Error
+}
+
+func (r *graphEntityRepository) UpsertCandidateDecision(
+	ctx context.Context, candidate *types.GraphMergeCandidate,
+) error {
+	if candidate.ID == "" {
+		candidate.ID = uuid.New().String()
+	}
+	candidate.UpdatedAt = time.Now()
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "tenant_id"}, {Name: "knowledge_base_id"}, {Name: "pair_key"}},
+			DoUpdates: clause.AssignmentColumns([]string{
+				"canonical", "alias", "status", "resolved_by", "resolved_at", "updated_at",
+			}),
+		}).
+		Create(candidate).Error
+}
+
+func (r *graphEntityRepository) DeletePendingCandidatesFor(
+	ctx context.Context, tenantID uint64, kbID string, names []string,
+) error {
+	if len(names) == 0 {
+		return nil
+	}
+	return r.scoped(ctx, tenantID, kbID).
+		Where("status = ?", types.GraphMergeCandidatePending).
+		Where("canonical IN ? OR alias IN ?", names, names).
+		Delete(&types.GraphMergeCandidate{}).Error
+}
+
+func (r *graphEntityRepository) ListAliases(
+	ctx context.Context, tenantID uint64, kbID string,
+) ([]*types.GraphEntityAlias, error) {
+	var aliases []*types.GraphEntityAlias
+	err := r.scoped(ctx, tenantID, kbID).Order("alias_key ASC").Find(&aliases).Error
+	return aliases, err
+}
+
+func (r *graphEntityRepository) SaveAliases(
+	ctx context.Context, tenantID uint64, kbID string, canonical string, aliases []string,
+) error {
+	canonicalKey := aliasKey(canonical)
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		scoped := func() *gorm.DB {
+			return tx.Where("tenant_id = ? AND knowledge_base_id = ?", tenantID, kbID)
+		}
+		// The canonical name must not itself redirect, or lookups would loop.
+		if err := scoped().Where("alias_key = ?", canonicalKey).
+			Delete(&types.GraphEntityAlias{}).Error; err != nil {
+			return err
+		}
+		// Aliases of the folded names now resolve to the new canonical.
+		if len(aliases) > 0 {
+			if err := scoped().Model(&types.GraphEntityAlias{}).
+				Where("canonical IN ?", aliases).
+				Updates(map[string]interface{}{"canonical": canonical, "updated_at": time.Now()}).Error; err != nil {
+				return err
+			}
+		}
+		now := time.Now()
+		rows := make([]*types.GraphEntityAlias, 0, len(aliases))
+		for _, alias := range aliases {
+			key := aliasKey(alias)
+			if key == "" || key == canonicalKey {
+				continue
+			}
+			rows = append(rows, &types.GraphEntityAlias{
+				ID:              uuid.New().String(),
+				TenantID:        tenantID,
+				KnowledgeBaseID: kbID,
+				AliasKey:        key,
+				Alias:           alias,
+				Canonical:       canonical,
+				CreatedAt:       now,
+				UpdatedAt:       now,
+			})
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+		return tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "tenant_id"}, {Name: "knowledge_base_id"}, {Name: "alias_key"}},
+			DoUpdates: clause.AssignmentColumns([]string{"alias", "canonical", "updated_at"}),
+		}).Create(&rows).Error
+	})
+}
+
+func (r *graphEntityRepository) DeleteAlias(
+	ctx context.Context, tenantID uint64, kbID string, alias string,
+) error {
+	return r.scoped(ctx, tenantID, kbID).
+		Where("alias_key = ?", aliasKey(alias)).
+		Delete(&types.GraphEntityAlias{}).Error
+}
+
+func (r *graphEntityRepository) DeleteByKnowledgeBase(
+	ctx context.Context, tenantID uint64, kbID string,
+) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("tenant_id = ? AND knowledge_base_id = ?", tenantID, kbID).
+			Delete(&types.GraphMergeCandidate{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("tenant_id = ? AND knowledge_base_id = ?", tenantID, kbID).
+			Delete(&types.GraphEntityAlias{}).Error
+	})
+}
+
+// aliasKey is the lookup form of an entity name. It matches the key the
+// graph services fold per-document nodes by.
+func aliasKey(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
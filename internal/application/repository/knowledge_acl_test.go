@@ -0,0 +1,158 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// knowledgeACLTestDDL mirrors migrations/sqlite/000015_knowledge_acl.up.sql.
+const knowledgeACLTestDDL = `
+CREATE TABLE IF NOT EXISTS knowledge_acl_entries (
+    id VARCHAR(36) NOT NULL PRIMARY KEY,
+    tenant_id INTEGER NOT NULL,
+    knowledge_id VARCHAR(36) NOT NULL,
+    principal_type VARCHAR(20) NOT NULL,
+    principal_id VARCHAR(255) NOT NULL,
+    source VARCHAR(16) NOT NULL DEFAULT 'manual',
+    created_by VARCHAR(36) NOT NULL DEFAULT '',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+CREATE TABLE IF NOT EXISTS user_groups (
+    id VARCHAR(36) NOT NULL PRIMARY KEY,
+    tenant_id INTEGER NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    external_id VARCHAR(255) NOT NULL DEFAULT '',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+CREATE TABLE IF NOT EXISTS user_group_members (
+    group_id VARCHAR(36) NOT NULL,
+    user_id VARCHAR(36) NOT NULL,
+    tenant_id INTEGER NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (group_id, user_id)
+);
+`
+
+func setupKnowledgeACLTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db := setupKnowledgeTestDB(t)
+	require.NoError(t, db.Exec(knowledgeACLTestDDL).Error)
+	return db
+}
+
+func insertACLTestKnowledge(t *testing.T, db *gorm.DB, tenantID uint64, kbID string) string {
+	t.Helper()
+	id := uuid.New().String()
+	require.NoError(t, db.Exec(`
+		INSERT INTO knowledges (id, tenant_id, knowledge_base_id, type, title, source, parse_status)
+		VALUES (?, ?, ?, 'document', ?, 'file', 'completed')
+	`, id, tenantID, kbID, id).Error)
+	return id
+}
+
+func deniedIDs(t *testing.T, repo *knowledgeACLRepository, kbID string, subject types.KnowledgeACLSubject) []string {
+	t.Helper()
+	denied, err := repo.ListDenied(context.Background(), []string{kbID}, nil, subject)
+	require.NoError(t, err)
+	ids := make([]string, 0, len(denied))
+	for _, k := range denied {
+		ids = append(ids, k.ID)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func TestKnowledgeACLListDenied(t *testing.T) {
+	db := setupKnowledgeACLTestDB(t)
+	repo := NewKnowledgeACLRepository(db).(*knowledgeACLRepository)
+	ctx := context.Background()
+
+	const tenantID uint64 = 70
+	kbID := uuid.New().String()
+	open := insertACLTestKnowledge(t, db, tenantID, kbID)
+	byUser := insertACLTestKnowledge(t, db, tenantID, kbID)
+	byEmail := insertACLTestKnowledge(t, db, tenantID, kbID)
+	byGroup := insertACLTestKnowledge(t, db, tenantID, kbID)
+	byExternalGroup := insertACLTestKnowledge(t, db, tenantID, kbID)
+	byOrg := insertACLTestKnowledge(t, db, tenantID, kbID)
+
+	grant := func(knowledgeID string, typ types.KnowledgeACLPrincipalType, principalID string) {
+		require.NoError(t, repo.ReplaceEntries(ctx, tenantID, knowledgeID, types.KnowledgeACLSourceManual,
+			[]*types.KnowledgeACLEntry{{PrincipalType: typ, PrincipalID: principalID}}))
+	}
+	grant(byUser, types.KnowledgeACLPrincipalUser, "alice")
+	grant(byEmail, types.KnowledgeACLPrincipalEmail, "alice@example.com")
+
+	group := &types.UserGroup{TenantID: tenantID, Name: "legal", ExternalID: "od-legal"}
+	require.NoError(t, repo.CreateGroup(ctx, group))
+	require.NoError(t, repo.ReplaceGroupMembers(ctx, tenantID, group.ID, []string{"bob"}))
+	grant(byGroup, types.KnowledgeACLPrincipalGroup, group.ID)
+	grant(byExternalGroup, types.KnowledgeACLPrincipalGroup, "od-legal")
+	grant(byOrg, types.KnowledgeACLPrincipalOrganization, "org-1")
+
+	all := []string{byUser, byEmail, byGroup, byExternalGroup, byOrg}
+	sort.Strings(all)
+	without := func(ids ...string) []string {
+		skip := make(map[string]bool, len(ids))
+		for _, id := range ids {
+			skip[id] = true
+		}
+		var out []string
+		for _, id := range all {
+			if !skip[id] {
+				out = append(out, id)
+			}
+		}
+		return out
+	}
+
+	assert.Equal(t, all, deniedIDs(t, repo, kbID, types.KnowledgeACLSubject{}))
+	assert.NotContains(t, deniedIDs(t, repo, kbID, types.KnowledgeACLSubject{}), open)
+	assert.Equal(t, without(byUser, byEmail),
+		deniedIDs(t, repo, kbID, types.KnowledgeACLSubject{UserID: "alice", Email: "Alice@Example.com"}))
+	assert.Equal(t, without(byGroup, byExternalGroup),
+		deniedIDs(t, repo, kbID, types.KnowledgeACLSubject{UserID: "bob"}))
+	assert.Equal(t, without(byOrg),
+		deniedIDs(t, repo, kbID, types.KnowledgeACLSubject{OrganizationIDs: []string{"org-1"}}))
+
+	// Deleting the group keeps its grants, so its documents stay restricted
+	// instead of falling back to knowledge base access.
+	require.NoError(t, repo.DeleteGroup(ctx, tenantID, group.ID))
+	assert.Equal(t, without(byUser, byEmail),
+		deniedIDs(t, repo, kbID, types.KnowledgeACLSubject{UserID: "alice", Email: "alice@example.com"}))
+	assert.Contains(t, deniedIDs(t, repo, kbID, types.KnowledgeACLSubject{UserID: "bob"}), byGroup)
+}
+
+func TestKnowledgeACLReplaceEntriesKeepsOtherSource(t *testing.T) {
+	db := setupKnowledgeACLTestDB(t)
+	repo := NewKnowledgeACLRepository(db).(*knowledgeACLRepository)
+	ctx := context.Background()
+
+	const tenantID uint64 = 71
+	knowledgeID := insertACLTestKnowledge(t, db, tenantID, uuid.New().String())
+	require.NoError(t, repo.ReplaceEntries(ctx, tenantID, knowledgeID, types.KnowledgeACLSourceManual,
+		[]*types.KnowledgeACLEntry{{PrincipalType: types.KnowledgeACLPrincipalUser, PrincipalID: "alice"}}))
+	require.NoError(t, repo.ReplaceEntries(ctx, tenantID, knowledgeID, types.KnowledgeACLSourceConnector,
+		[]*types.KnowledgeACLEntry{{PrincipalType: types.KnowledgeACLPrincipalEmail, PrincipalID: "bob@example.com"}}))
+	require.NoError(t, repo.ReplaceEntries(ctx, tenantID, knowledgeID, types.KnowledgeACLSourceConnector, nil))
+
+	entries, err := repo.ListEntries(ctx, tenantID, []string{knowledgeID})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "alice", entries[0].PrincipalID)
+
+	require.NoError(t, repo.DeleteByKnowledgeIDs(ctx, tenantID, []string{knowledgeID}))
+	entries, err = repo.ListEntries(ctx, tenantID, []string{knowledgeID})
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
@@ -47,6 +47,12 @@ CREATE TABLE IF NOT EXISTS knowledges (
     created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
     updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
     processed_at DATETIME,
+    valid_from DATETIME,
+    valid_until DATETIME,
+    review_by DATETIME,
+    reviewed_at DATETIME,
+    freshness_source VARCHAR(16) NOT NULL DEFAULT '',
+    freshness_notified_at DATETIME,
     error_message TEXT,
     deleted_at DATETIME
 );
@@ -0,0 +1,160 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"gorm.io/gorm"
+)
+
+// fingerprintBatchSize bounds the rows of one bulk insert.
+const fingerprintBatchSize = 500
+
+type knowledgeFingerprintRepository struct {
+	db *gorm.DB
+}
+
+// NewKnowledgeFingerprintRepository creates the knowledge fingerprint repository.
+func NewKnowledgeFingerprintRepository(db *gorm.DB) interfaces.KnowledgeFingerprintRepository {
+	return &knowledgeFingerprintRepository{db: db}
+}
+
+func (r *knowledgeFingerprintRepository) Save(
+	ctx context.Context,
+	fingerprint *types.KnowledgeFingerprint,
+	bandKeys []int64,
+	chunks []*types.ChunkFingerprint,
+) error {
+	now := time.Now()
+	if fingerprint.CreatedAt.IsZero() {
+		fingerprint.CreatedAt = now
+	}
+	fingerprint.UpdatedAt = now
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := deleteFingerprints(tx, fingerprint.TenantID, []string{fingerprint.KnowledgeID}, false); err != nil {
+			return err
+		}
+		if err := tx.Create(fingerprint).Error; err != nil {
+			return err
+		}
+		seen := make(map[int64]bool, len(bandKeys))
+		bands := make([]*types.KnowledgeFingerprintBand, 0, len(bandKeys))
+		for _, key := range bandKeys {
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			bands = append(bands, &types.KnowledgeFingerprintBand{
+				KnowledgeID:     fingerprint.KnowledgeID,
+				BandKey:         key,
+				TenantID:        fingerprint.TenantID,
+				KnowledgeBaseID: fingerprint.KnowledgeBaseID,
+			})
+		}
+		if len(bands) > 0 {
+			if err := tx.CreateInBatches(bands, fingerprintBatchSize).Error; err != nil {
+				return err
+			}
+		}
+		if len(chunks) > 0 {
+			return tx.CreateInBatches(chunks, fingerprintBatchSize).Error
+		}
+		return nil
+	})
+}
+
+func (r *knowledgeFingerprintRepository) FindCandidates(
+	ctx context.Context, tenantID uint64, kbID string, bandKeys []int64, excludeKnowledgeID string,
+) ([]*types.KnowledgeFingerprint, error) {
+	if len(bandKeys) == 0 {
+		return nil, nil
+	}
+	matched := r.db.WithContext(ctx).Model(&types.KnowledgeFingerprintBand{}).
+		Select("DISTINCT knowledge_id").
+		Where("knowledge_base_id = ? AND band_key IN ? AND knowledge_id <> ?", kbID, bandKeys, excludeKnowledgeID)
+	var out []*types.KnowledgeFingerprint
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND knowledge_base_id = ? AND alias_of = '' AND knowledge_id IN (?)",
+			tenantID, kbID, matched).
+		Find(&out).Error
+	return out, err
+}
+
+func (r *knowledgeFingerprintRepository) ListByKnowledgeBase(
+	ctx context.Context, tenantID uint64, kbID string,
+) ([]*types.KnowledgeFingerprint, error) {
+	var out []*types.KnowledgeFingerprint
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND knowledge_base_id = ?", tenantID, kbID).
+		Order("created_at ASC").
+		Find(&out).Error
+	return out, err
+}
+
+func (r *knowledgeFingerprintRepository) ListChunksByKnowledgeBase(
+	ctx context.Context, tenantID uint64, kbID string,
+) ([]*types.ChunkFingerprint, error) {
+	var out []*types.ChunkFingerprint
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND knowledge_base_id = ?", tenantID, kbID).
+		Find(&out).Error
+	return out, err
+}
+
+func (r *knowledgeFingerprintRepository) ListAliases(
+	ctx context.Context, tenantID uint64, knowledgeIDs []string,
+) ([]*types.KnowledgeFingerprint, error) {
+	if len(knowledgeIDs) == 0 {
+		return nil, nil
+	}
+	var out []*types.KnowledgeFingerprint
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND alias_of IN ?", tenantID, knowledgeIDs).
+		Order("created_at ASC").
+		Find(&out).Error
+	return out, err
+}
+
+func (r *knowledgeFingerprintRepository) SetAliasOf(
+	ctx context.Context, tenantID uint64, knowledgeIDs []string, aliasOf string,
+) error {
+	if len(knowledgeIDs) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Model(&types.KnowledgeFingerprint{}).
+		Where("tenant_id = ? AND knowledge_id IN ?", tenantID, knowledgeIDs).
+		Updates(map[string]interface{}{"alias_of": aliasOf, "updated_at": time.Now()}).Error
+}
+
+func (r *knowledgeFingerprintRepository) DeleteByKnowledgeIDs(
+	ctx context.Context, tenantID uint64, knowledgeIDs []string,
+) error {
+	if len(knowledgeIDs) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return deleteFingerprints(tx, tenantID, knowledgeIDs, true)
+	})
+}
+
+// deleteFingerprints removes the document, band and chunk fingerprints of
+// knowledgeIDs. Aliases pointing at them are cleared as well when
+// clearAliases is set, so no fingerprint refers to a missing document.
+func deleteFingerprints(tx *gorm.DB, tenantID uint64, knowledgeIDs []string, clearAliases bool) error {
+	for _, model := range []interface{}{
+		&types.ChunkFingerprint{}, &types.KnowledgeFingerprintBand{}, &types.KnowledgeFingerprint{},
+	} {
+		if err := tx.Where("tenant_id = ? AND knowledge_id IN ?", tenantID, knowledgeIDs).
+			Delete(model).Error; err != nil {
+			return err
+		}
+	}
+	if !clearAliases {
+		return nil
+	}
+	return tx.Model(&types.KnowledgeFingerprint{}).
+		Where("tenant_id = ? AND alias_of IN ?", tenantID, knowledgeIDs).
+		Update("alias_of", "").Error
+}
@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"gorm.io/gorm"
+)
+
+type knowledgeFreshnessRepository struct {
+	db *gorm.DB
+}
+
+// NewKnowledgeFreshnessRepository creates the knowledge freshness repository.
+func NewKnowledgeFreshnessRepository(db *gorm.DB) interfaces.KnowledgeFreshnessRepository {
+	return &knowledgeFreshnessRepository{db: db}
+}
+
+func (r *knowledgeFreshnessRepository) ListOutOfValidity(
+	ctx context.Context, knowledgeIDs []string, now time.Time,
+) ([]string, error) {
+	if len(knowledgeIDs) == 0 {
+		return nil, nil
+	}
+	var ids []string
+	err := r.db.WithContext(ctx).Model(&types.Knowledge{}).
+		Where("id IN ?", knowledgeIDs).
+		Where("(valid_until IS NOT NULL AND valid_until <= ?) OR (valid_from IS NOT NULL AND valid_from > ?)", now, now).
+		Pluck("id", &ids).Error
+	return ids, err
+}
+
+func (r *knowledgeFreshnessRepository) ListStale(
+	ctx context.Context, tenantID uint64, kbID string, now, soonUntil time.Time,
+	notifiedBefore *time.Time, limit int,
+) ([]*types.Knowledge, error) {
+	q := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND knowledge_base_id = ?", tenantID, kbID).
+		Where("(valid_until IS NOT NULL AND valid_until <= ?) OR (valid_from IS NOT NULL AND valid_from > ?) "+
+			"OR (review_by IS NOT NULL AND review_by <= ?)", soonUntil, now, now)
+	if notifiedBefore != nil {
+		q = q.Where("freshness_notified_at IS NULL OR freshness_notified_at < ?", *notifiedBefore)
+	}
+	var out []*types.Knowledge
+	err := q.Order("COALESCE(valid_until, review_by, valid_from) ASC").Order("id ASC").
+		Limit(limit).Find(&out).Error
+	return out, err
+}
+
+func (r *knowledgeFreshnessRepository) UpdateFreshness(ctx context.Context, knowledge *types.Knowledge) error {
+	return r.db.WithContext(ctx).Model(&types.Knowledge{}).
+		Where("tenant_id = ? AND id = ?", knowledge.TenantID, knowledge.ID).
+		Updates(map[string]interface{}{
+			"valid_from":       knowledge.ValidFrom,
+			"valid_until":      knowledge.ValidUntil,
+			"review_by":        knowledge.ReviewBy,
+			"reviewed_at":      knowledge.ReviewedAt,
+			"freshness_source": knowledge.FreshnessSource,
+			// New dates deserve a new notification.
+			"freshness_notified_at": nil,
+		}).Error
+}
+
+func (r *knowledgeFreshnessRepository) ListTagRuleCandidates(
+	ctx context.Context, tenantID uint64, kbID, tagID string, limit int,
+) ([]*types.Knowledge, error) {
+	var out []*types.Knowledge
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND knowledge_base_id = ? AND freshness_source = ''", tenantID, kbID).
+		Where("id IN (SELECT knowledge_id FROM knowledge_tag_relations WHERE tag_id = ?)", tagID).
+		Order("created_at ASC").
+		Limit(limit).
+		Find(&out).Error
+	return out, err
+}
+
+func (r *knowledgeFreshnessRepository) ListUnscheduledReviews(
+	ctx context.Context, tenantID uint64, kbID string, limit int,
+) ([]*types.Knowledge, error) {
+	var out []*types.Knowledge
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND knowledge_base_id = ? AND freshness_source = '' AND review_by IS NULL",
+			tenantID, kbID).
+		Order("created_at ASC").
+		Limit(limit).
+		Find(&out).Error
+	return out, err
+}
+
+func (r *knowledgeFreshnessRepository) MarkNotified(
+	ctx context.Context, tenantID uint64, knowledgeIDs []string, at time.Time,
+) error {
+	if len(knowledgeIDs) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Model(&types.Knowledge{}).
+		Where("tenant_id = ? AND id IN ?", tenantID, knowledgeIDs).
+		UpdateColumn("freshness_notified_at", at).Error
+}
+
+func (r *knowledgeFreshnessRepository) ListConfiguredKnowledgeBases(ctx context.Context) ([]*types.KnowledgeBase, error) {
+	var out []*types.KnowledgeBase
+	err := r.db.WithContext(ctx).
+		Where("freshness_config IS NOT NULL").
+		Find(&out).Error
+	return out, err
+}
@@ -0,0 +1,228 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"github.com/Tencent/WeKnora/internal/utils"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// knowledgeVersionListColumns is the projection for version listings: every
+// column except markdown and chunks.
+const knowledgeVersionListColumns = "id, tenant_id, knowledge_id, version, source, restored_from, title, " +
+	"file_name, file_type, file_size, file_hash, file_path, content_hash, content_length, chunk_count, " +
+	"editor_id, created_at"
+
+// knowledgeVersionSealBatch is how many versions SealPlaintext loads at once.
+const knowledgeVersionSealBatch = 100
+
+type knowledgeVersionRepository struct {
+	db *gorm.DB
+}
+
+// NewKnowledgeVersionRepository creates the knowledge version repository.
+func NewKnowledgeVersionRepository(db *gorm.DB) interfaces.KnowledgeVersionRepository {
+	return &knowledgeVersionRepository{db: db}
+}
+
+func (r *knowledgeVersionRepository) scoped(ctx context.Context, tenantID uint64, knowledgeID string) *gorm.DB {
+	return r.db.WithContext(ctx).Model(&types.KnowledgeVersion{}).
+		Where("tenant_id = ? AND knowledge_id = ?", tenantID, knowledgeID)
+}
+
+func (r *knowledgeVersionRepository) Create(ctx context.Context, version *types.KnowledgeVersion) error {
+	if version.ID == "" {
+		version.ID = uuid.New().String()
+	}
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var latest int
+		if err := tx.Model(&types.KnowledgeVersion{}).
+			Where("tenant_id = ? AND knowledge_id = ?", version.TenantID, version.KnowledgeID).
+			Select("COALESCE(MAX(version), 0)").
+			Scan(&latest).Error; err != nil {
+			return err
+		}
+		version.Version = latest + 1
+		return tx.Create(version).Error
+	})
+}
+
+func (r *knowledgeVersionRepository) Latest(
+	ctx context.Context, tenantID uint64, knowledgeID string,
+) (*types.KnowledgeVersion, error) {
+	var version types.KnowledgeVersion
+	err := r.scoped(ctx, tenantID, knowledgeID).
+		Select(knowledgeVersionListColumns).
+		Order("version DESC").
+		First(&version).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &version, nil
+}
+
+func (r *knowledgeVersionRepository) List(
+	ctx context.Context, tenantID uint64, knowledgeID string,
+) ([]*types.KnowledgeVersion, error) {
+	var versions []*types.KnowledgeVersion
+	err := r.scoped(ctx, tenantID, knowledgeID).
+		Select(knowledgeVersionListColumns).
+		Order("version DESC").
+		Find(&versions).Error
+	return versions, err
+}
+
+func (r *knowledgeVersionRepository) Get(
+	ctx context.Context, tenantID uint64, knowledgeID string, version int,
+) (*types.KnowledgeVersion, error) {
+	var v types.KnowledgeVersion
+	err := r.scoped(ctx, tenantID, knowledgeID).Where("version = ?", version).First(&v).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &v, nil
+}
+
+func (r *knowledgeVersionRepository) Prune(
+	ctx context.Context, tenantID uint64, knowledgeID string, keep int,
+) ([]string, error) {
+	var stale []*types.KnowledgeVersion
+	if err := r.scoped(ctx, tenantID, knowledgeID).
+		Select("id, file_path").
+		Order("version DESC").
+		Offset(keep).
+		Find(&stale).Error; err != nil {
+		return nil, err
+	}
+	if len(stale) == 0 {
+		return nil, nil
+	}
+	ids := make([]string, 0, len(stale))
+	paths := make(map[string]bool)
+	for _, v := range stale {
+		ids = append(ids, v.ID)
+		if v.FilePath != "" {
+			paths[v.FilePath] = true
+		}
+	}
+	if err := r.db.WithContext(ctx).Where("id IN ?", ids).Delete(&types.KnowledgeVersion{}).Error; err != nil {
+		return nil, err
+	}
+	return r.unreferencedPaths(ctx, tenantID, paths)
+}
+
+// unreferencedPaths filters out the paths a remaining version still uses.
+func (r *knowledgeVersionRepository) unreferencedPaths(
+	ctx context.Context, tenantID uint64, paths map[string]bool,
+) ([]string, error) {
+	if len(paths) == 0 {
+		return nil, nil
+	}
+	candidates := make([]string, 0, len(paths))
+	for path := range paths {
+		candidates = append(candidates, path)
+	}
+	var inUse []string
+	if err := r.db.WithContext(ctx).Model(&types.KnowledgeVersion{}).
+		Where("tenant_id = ? AND file_path IN ?", tenantID, candidates).
+		Distinct().
+		Pluck("file_path", &inUse).Error; err != nil {
+		return nil, err
+	}
+	for _, path := range inUse {
+		delete(paths, path)
+	}
+	out := make([]string, 0, len(paths))
+	for path := range paths {
+		out = append(out, path)
+	}
+	return out, nil
+}
+
+func (r *knowledgeVersionRepository) Transfer(
+	ctx context.Context, tenantID uint64, fromKnowledgeID, toKnowledgeID string,
+) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		scoped := func(knowledgeID string) *gorm.DB {
+			return tx.Model(&types.KnowledgeVersion{}).
+				Where("tenant_id = ? AND knowledge_id = ?", tenantID, knowledgeID)
+		}
+		var offset int
+		if err := scoped(fromKnowledgeID).Select("COALESCE(MAX(version), 0)").Scan(&offset).Error; err != nil {
+			return err
+		}
+		if offset == 0 {
+			return nil
+		}
+		// Renumber the target's own versions after the transferred ones. They
+		// pass through negative numbers so no intermediate state collides
+		// with the unique (knowledge_id, version) index.
+		if err := scoped(toKnowledgeID).
+			Update("version", gorm.Expr("-(version + ?)", offset)).Error; err != nil {
+			return err
+		}
+		if err := scoped(fromKnowledgeID).Update("knowledge_id", toKnowledgeID).Error; err != nil {
+			return err
+		}
+		return scoped(toKnowledgeID).Where("version < 0").
+			Update("version", gorm.Expr("-version")).Error
+	})
+}
+
+func (r *knowledgeVersionRepository) DeleteByKnowledgeIDs(
+	ctx context.Context, tenantID uint64, knowledgeIDs []string,
+) ([]string, error) {
+	if len(knowledgeIDs) == 0 {
+		return nil, nil
+	}
+	var paths []string
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		scoped := func() *gorm.DB {
+			return tx.Model(&types.KnowledgeVersion{}).
+				Where("tenant_id = ? AND knowledge_id IN ?", tenantID, knowledgeIDs)
+		}
+		if err := scoped().Where("file_path <> ''").Distinct().Pluck("file_path", &paths).Error; err != nil {
+			return err
+		}
+		return scoped().Delete(&types.KnowledgeVersion{}).Error
+	})
+	return paths, err
+}
+
+func (r *knowledgeVersionRepository) SealPlaintext(ctx context.Context) (int, error) {
+	if types.ContentCipherOf(r.db) == nil {
+		return 0, nil
+	}
+	sealed := 0
+	lastID := ""
+	for {
+		var batch []*types.KnowledgeVersion
+		if err := r.db.WithContext(ctx).
+			Where("id > ? AND markdown <> '' AND markdown NOT LIKE ?", lastID, utils.EnvelopeContentPrefix+"%").
+			Order("id").
+			Limit(knowledgeVersionSealBatch).
+			Find(&batch).Error; err != nil {
+			return sealed, err
+		}
+		for _, v := range batch {
+			if err := r.db.WithContext(ctx).Select("markdown", "chunks").Save(v).Error; err != nil {
+				return sealed, err
+			}
+			sealed++
+			lastID = v.ID
+		}
+		if len(batch) < knowledgeVersionSealBatch {
+			return sealed, nil
+		}
+	}
+}
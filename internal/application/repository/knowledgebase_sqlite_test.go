@@ -33,6 +33,8 @@ CREATE TABLE IF NOT EXISTS knowledge_bases (
     faq_config TEXT,
     question_generation_config TEXT NULL,
     auto_tag_config TEXT NULL,
+    dedup_config TEXT NULL,
+    freshness_config TEXT NULL,
     is_temporary BOOLEAN NOT NULL DEFAULT 0,
     is_pinned INTEGER NOT NULL DEFAULT 0,
     pinned_at DATETIME NULL,
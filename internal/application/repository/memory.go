@@ -1042,3 +1042,73 @@ func (r *memoryRepository) CountActive(
 		Count(&count).Error
 	return count, err
 }
+
+// sharedSpaces starts a query over every shared space of one workspace. The
+// prefix holds no LIKE wildcards, so it matches literally.
+func (r *memoryRepository) sharedSpaces(ctx context.Context, tenantID uint64) *gorm.DB {
+	return r.db.WithContext(ctx).
+		Where("tenant_id = ? AND subject_id LIKE ?", tenantID, types.SharedMemorySubjectPrefix+"%")
+}
+
+func (r *memoryRepository) ListSharedItems(
+	ctx context.Context, tenantID uint64, subjectID, status string, limit, offset int,
+) ([]*types.MemoryItem, int64, error) {
+	query := r.sharedSpaces(ctx, tenantID).Model(&types.MemoryItem{})
+	if subjectID != "" {
+		query = query.Where("subject_id = ?", subjectID)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if limit <= 0 {
+		limit = 50
+	}
+	var items []*types.MemoryItem
+	err := query.Order("valid_from DESC, id DESC").Limit(limit).Offset(offset).Find(&items).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return items, total, nil
+}
+
+func (r *memoryRepository) GetSharedItem(
+	ctx context.Context, tenantID uint64, id string,
+) (*types.MemoryItem, error) {
+	var item types.MemoryItem
+	err := r.sharedSpaces(ctx, tenantID).Where("id = ?", id).First(&item).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &item, nil
+}
+
+func (r *memoryRepository) SetItemExpiry(
+	ctx context.Context, scope interfaces.MemoryScope, id string, expiresAt *time.Time,
+) error {
+	return r.scoped(ctx, scope).
+		Model(&types.MemoryItem{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{"expires_at": expiresAt, "updated_at": time.Now()}).Error
+}
+
+func (r *memoryRepository) MarkItemReviewed(
+	ctx context.Context, scope interfaces.MemoryScope, id, status, reviewer string,
+) error {
+	now := time.Now()
+	return r.scoped(ctx, scope).
+		Model(&types.MemoryItem{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"status":      status,
+			"reviewed_by": reviewer,
+			"reviewed_at": now,
+			"updated_at":  now,
+		}).Error
+}
@@ -34,9 +34,10 @@ func scopeCustomAgentsByModelID(db *gorm.DB, modelID string) *gorm.DB {
 		return db.Where(
 			"config->>'model_id' = ? OR config->>'rerank_model_id' = ? OR "+
 				"config->>'vlm_model_id' = ? OR config->>'asr_model_id' = ? OR "+
+				"config->>'tts_model_id' = ? OR "+
 				"config->>'query_understand_model_id' = ? OR "+
 				"config->'question_suggestions'->'follow_ups'->>'model_id' = ?",
-			modelID, modelID, modelID, modelID, modelID, modelID,
+			modelID, modelID, modelID, modelID, modelID, modelID, modelID,
 		)
 	}
 	return db.Where(
@@ -44,9 +45,10 @@ func scopeCustomAgentsByModelID(db *gorm.DB, modelID string) *gorm.DB {
 			"json_extract(config, '$.rerank_model_id') = ? OR "+
 			"json_extract(config, '$.vlm_model_id') = ? OR "+
 			"json_extract(config, '$.asr_model_id') = ? OR "+
+			"json_extract(config, '$.tts_model_id') = ? OR "+
 			"json_extract(config, '$.query_understand_model_id') = ? OR "+
 			"json_extract(config, '$.question_suggestions.follow_ups.model_id') = ?",
-		modelID, modelID, modelID, modelID, modelID, modelID,
+		modelID, modelID, modelID, modelID, modelID, modelID, modelID,
 	)
 }
 
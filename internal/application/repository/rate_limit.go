@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type rateLimitPolicyRepository struct {
+	db *gorm.DB
+}
+
+// NewRateLimitPolicyRepository creates the rate limit policy repository.
+func NewRateLimitPolicyRepository(db *gorm.DB) interfaces.RateLimitPolicyRepository {
+	return &rateLimitPolicyRepository{db: db}
+}
+
+func (r *rateLimitPolicyRepository) ListPolicies(ctx context.Context, tenantID uint64) ([]*types.RateLimitPolicy, error) {
+	var out []*types.RateLimitPolicy
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ?", tenantID).
+		Order(clause.Expr{SQL: "CASE WHEN scope_type = ? THEN 0 ELSE 1 END, scope_type, scope_id",
+			Vars: []any{types.RateLimitScopeTenant}}).
+		Find(&out).Error
+	return out, err
+}
+
+func (r *rateLimitPolicyRepository) UpsertPolicy(ctx context.Context, policy *types.RateLimitPolicy) error {
+	db := r.db.WithContext(ctx)
+	err := db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "tenant_id"}, {Name: "scope_type"}, {Name: "scope_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"requests_per_minute", "concurrent_streams", "ingest_mb_per_day", "tokens_per_minute", "updated_at",
+		}),
+	}).Create(policy).Error
+	if err != nil {
+		return err
+	}
+	// The conflict path does not reliably return the existing row's ID and
+	// creation time on every dialect, so read the row back.
+	return db.Where("tenant_id = ? AND scope_type = ? AND scope_id = ?",
+		policy.TenantID, policy.ScopeType, policy.ScopeID).First(policy).Error
+}
+
+func (r *rateLimitPolicyRepository) DeletePolicy(ctx context.Context, tenantID, id uint64) (bool, error) {
+	res := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND id = ?", tenantID, id).
+		Delete(&types.RateLimitPolicy{})
+	return res.RowsAffected > 0, res.Error
+}
@@ -177,7 +177,8 @@ func (n *Neo4jRepository) SearchNode(
 		labelExpr := n.Label(namespace)
 		query := `
 			MATCH (n:` + labelExpr + `)-[r]-(m:` + labelExpr + `)
-			WHERE ANY(nodeText IN $nodes WHERE n.name CONTAINS nodeText)
+			WHERE ANY(nodeText IN $nodes WHERE n.name CONTAINS nodeText
+				OR ANY(alias IN coalesce(n.aliases, []) WHERE alias CONTAINS nodeText))
 			RETURN n, r, m
 		`
 		params := map[string]interface{}{"nodes": nodes}
@@ -202,10 +203,12 @@ func (n *Neo4jRepository) SearchNode(
 				nameStr := n.Props["name"].(string)
 				if _, ok := nodeSeen[nameStr]; !ok {
 					nodeSeen[nameStr] = true
+					aliases, _ := n.Props["aliases"].([]interface{})
 					graphData.Node = append(graphData.Node, &types.GraphNode{
 						Name:       nameStr,
 						Chunks:     listI2listS(n.Props["chunks"].([]interface{})),
 						Attributes: listI2listS(n.Props["attributes"].([]interface{})),
+						Aliases:    listI2listS(aliases),
 					})
 				}
 			}
@@ -227,6 +230,305 @@ func (n *Neo4jRepository) SearchNode(
 	return result.(*types.GraphData), nil
 }
 
+// GetGraph returns every node and relation under a namespace
+func (n *Neo4jRepository) GetGraph(ctx context.Context, namespace types.NameSpace) (*types.GraphData, error) {
+	if n.driver == nil {
+		logger.Warnf(ctx, "NOT SUPPORT RETRIEVE GRAPH")
+		return nil, nil
+	}
+	session := n.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close(ctx)
+
+	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		labelExpr := n.Label(namespace)
+		graphData := &types.GraphData{}
+
+		// Nodes are read on their own so isolated entities are not lost:
+		// a relationship-only match would skip every node without edges.
+		nodeResult, err := tx.Run(ctx, `MATCH (n:`+labelExpr+`) RETURN n`, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to run node query: %v", err)
+		}
+		for nodeResult.Next(ctx) {
+			node, _ := nodeResult.Record().Get("n")
+			nodeData, ok := node.(neo4j.Node)
+			if !ok {
+				continue
+			}
+			if graphNode := nodeFromProps(nodeData); graphNode != nil {
+				graphData.Node = append(graphData.Node, graphNode)
+			}
+		}
+		if err := nodeResult.Err(); err != nil {
+			return nil, fmt.Errorf("failed to read nodes: %v", err)
+		}
+
+		relResult, err := tx.Run(ctx, `
+			MATCH (n:`+labelExpr+`)-[r]->(m:`+labelExpr+`)
+			RETURN n.name AS source, type(r) AS type, m.name AS target
+		`, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to run relationship query: %v", err)
+		}
+		for relResult.Next(ctx) {
+			record := relResult.Record()
+			source, _ := record.Get("source")
+			target, _ := record.Get("target")
+			relType, _ := record.Get("type")
+			sourceName, _ := source.(string)
+			targetName, _ := target.(string)
+			if sourceName == "" || targetName == "" {
+				continue
+			}
+			typeName, _ := relType.(string)
+			graphData.Relation = append(graphData.Relation, &types.GraphRelation{
+				Node1: sourceName,
+				Node2: targetName,
+				Type:  typeName,
+			})
+		}
+		if err := relResult.Err(); err != nil {
+			return nil, fmt.Errorf("failed to read relationships: %v", err)
+		}
+		return graphData, nil
+	})
+	if err != nil {
+		logger.Errorf(ctx, "get graph failed: %v", err)
+		return nil, err
+	}
+	return result.(*types.GraphData), nil
+}
+
+// SearchNodeByChunks returns the nodes extracted from any of the given chunks
+func (n *Neo4jRepository) SearchNodeByChunks(
+	ctx context.Context,
+	namespace types.NameSpace,
+	chunkIDs []string,
+) (*types.GraphData, error) {
+	if n.driver == nil {
+		logger.Warnf(ctx, "NOT SUPPORT RETRIEVE GRAPH")
+		return nil, nil
+	}
+	if len(chunkIDs) == 0 {
+		return &types.GraphData{}, nil
+	}
+	session := n.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close(ctx)
+
+	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		labelExpr := n.Label(namespace)
+		query := `
+			MATCH (n:` + labelExpr + `)
+			WHERE ANY(c IN coalesce(n.chunks, []) WHERE c IN $chunks)
+			WITH collect(n) AS nodes
+			UNWIND nodes AS n
+			OPTIONAL MATCH (n)-[r]->(m)
+			WHERE m IN nodes
+			RETURN n, type(r) AS type, m.name AS target
+		`
+		result, err := tx.Run(ctx, query, map[string]interface{}{"chunks": chunkIDs})
+		if err != nil {
+			return nil, fmt.Errorf("failed to run query: %v", err)
+		}
+		graphData := &types.GraphData{}
+		nodeSeen := make(map[string]bool)
+		for result.Next(ctx) {
+			record := result.Record()
+			node, _ := record.Get("n")
+			nodeData, ok := node.(neo4j.Node)
+			if !ok {
+				continue
+			}
+			graphNode := nodeFromProps(nodeData)
+			if graphNode == nil {
+				continue
+			}
+			// The same name appears once per knowledge; keep one node per
+			// element so per-document chunk lists are not lost.
+			if !nodeSeen[nodeData.ElementId] {
+				nodeSeen[nodeData.ElementId] = true
+				graphData.Node = append(graphData.Node, graphNode)
+			}
+			relType, _ := record.Get("type")
+			target, _ := record.Get("target")
+			typeName, _ := relType.(string)
+			targetName, _ := target.(string)
+			if typeName != "" && targetName != "" {
+				graphData.Relation = append(graphData.Relation, &types.GraphRelation{
+					Node1: graphNode.Name,
+					Node2: targetName,
+					Type:  typeName,
+				})
+			}
+		}
+		if err := result.Err(); err != nil {
+			return nil, fmt.Errorf("failed to read nodes: %v", err)
+		}
+		return graphData, nil
+	})
+	if err != nil {
+		logger.Errorf(ctx, "search node by chunks failed: %v", err)
+		return nil, err
+	}
+	return result.(*types.GraphData), nil
+}
+
+// MergeNodes folds the named nodes into one canonical node per knowledge
+func (n *Neo4jRepository) MergeNodes(
+	ctx context.Context,
+	namespace types.NameSpace,
+	canonical string,
+	names []string,
+) error {
+	if n.driver == nil {
+		logger.Warnf(ctx, "NOT SUPPORT RETRIEVE GRAPH")
+		return nil
+	}
+	session := n.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close(ctx)
+
+	_, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		labelExpr := n.Label(namespace)
+		// Nodes are keyed by (name, kg), so merging happens per knowledge.
+		// The canonical node goes first: mergeNodes keeps the first node, and
+		// the unions are computed beforehand because 'discard' drops the
+		// other nodes' properties. mergeRels folds parallel relations, and
+		// relations between two merged names become self-loops, which go.
+		query := `
+			MATCH (n:` + labelExpr + `)
+			WHERE n.name IN $names
+			WITH n.kg AS kg, collect(n) AS nodes
+			WITH kg, [x IN nodes WHERE x.name = $canonical] + [x IN nodes WHERE x.name <> $canonical] AS nodes
+			WITH kg, nodes,
+				apoc.coll.toSet(apoc.coll.flatten([x IN nodes | coalesce(x.chunks, [])])) AS chunks,
+				apoc.coll.toSet(apoc.coll.flatten([x IN nodes | coalesce(x.attributes, [])])) AS attributes,
+				apoc.coll.toSet(apoc.coll.flatten([x IN nodes | [x.name] + coalesce(x.aliases, [])])) AS aliases
+			CALL apoc.refactor.mergeNodes(nodes, {properties: 'discard', mergeRels: true}) YIELD node
+			SET node.name = $canonical,
+				node.chunks = chunks,
+				node.attributes = attributes,
+				node.aliases = [a IN aliases WHERE a <> $canonical]
+			WITH node
+			OPTIONAL MATCH (node)-[loop]->(node)
+			DELETE loop
+			RETURN count(DISTINCT node) AS merged
+		`
+		params := map[string]interface{}{"names": appendIfMissing(names, canonical), "canonical": canonical}
+		if _, err := tx.Run(ctx, query, params); err != nil {
+			return nil, fmt.Errorf("failed to merge nodes: %v", err)
+		}
+		return nil, nil
+	})
+	if err != nil {
+		logger.Errorf(ctx, "merge nodes failed: %v", err)
+		return err
+	}
+	return nil
+}
+
+// SplitNode moves the chunk references of a node onto a new node
+func (n *Neo4jRepository) SplitNode(
+	ctx context.Context,
+	namespace types.NameSpace,
+	name string,
+	newName string,
+	chunkIDs []string,
+) error {
+	if n.driver == nil {
+		logger.Warnf(ctx, "NOT SUPPORT RETRIEVE GRAPH")
+		return nil
+	}
+	session := n.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close(ctx)
+
+	_, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		labelExpr := n.Label(namespace)
+		// Create the split node next to every per-knowledge node that has a
+		// moved chunk, and move the chunks over.
+		splitQuery := `
+			MATCH (n:` + labelExpr + ` {name: $name})
+			WHERE ANY(c IN coalesce(n.chunks, []) WHERE c IN $chunks)
+			WITH n, [c IN n.chunks WHERE c IN $chunks] AS moved
+			CALL apoc.merge.node(labels(n), {name: $new_name, kg: n.kg}, {}, {}) YIELD node AS split
+			SET split.chunks = apoc.coll.union(coalesce(split.chunks, []), moved),
+				split.attributes = apoc.coll.union(coalesce(split.attributes, []), coalesce(n.attributes, [])),
+				n.chunks = [c IN n.chunks WHERE NOT c IN $chunks],
+				n.aliases = [a IN coalesce(n.aliases, []) WHERE a <> $new_name]
+			RETURN count(split) AS splits
+		`
+		params := map[string]interface{}{"name": name, "new_name": newName, "chunks": chunkIDs}
+		if _, err := tx.Run(ctx, splitQuery, params); err != nil {
+			return nil, fmt.Errorf("failed to split node: %v", err)
+		}
+
+		// A relation is extracted from one chunk, and both of its ends are
+		// extracted from that chunk too. So a neighbour that shares a moved
+		// chunk is related to the split node, and one that no longer shares
+		// a kept chunk is not related to the original any more.
+		rewireQuery := `
+			MATCH (n:` + labelExpr + ` {name: $name})-[r]-(m)
+			MATCH (split:` + labelExpr + ` {name: $new_name, kg: n.kg})
+			WHERE m <> split AND ANY(c IN coalesce(m.chunks, []) WHERE c IN $chunks)
+			WITH n, r, m, split, startNode(r) = n AS outgoing, type(r) AS relType,
+				ANY(c IN coalesce(m.chunks, []) WHERE c IN coalesce(n.chunks, [])) AS kept
+			CALL apoc.do.when(outgoing,
+				'CALL apoc.merge.relationship(split, relType, {}, {}, m) YIELD rel RETURN rel',
+				'CALL apoc.merge.relationship(m, relType, {}, {}, split) YIELD rel RETURN rel',
+				{split: split, m: m, relType: relType}) YIELD value
+			WITH r, kept
+			WHERE NOT kept
+			DELETE r
+		`
+		if _, err := tx.Run(ctx, rewireQuery, params); err != nil {
+			return nil, fmt.Errorf("failed to rewire relationships: %v", err)
+		}
+
+		// An original left without chunk references only existed for the
+		// chunks that moved.
+		cleanupQuery := `
+			MATCH (n:` + labelExpr + ` {name: $name})
+			WHERE size(coalesce(n.chunks, [])) = 0
+			DETACH DELETE n
+		`
+		if _, err := tx.Run(ctx, cleanupQuery, params); err != nil {
+			return nil, fmt.Errorf("failed to clean up split node: %v", err)
+		}
+		return nil, nil
+	})
+	if err != nil {
+		logger.Errorf(ctx, "split node failed: %v", err)
+		return err
+	}
+	return nil
+}
+
+// nodeFromProps converts a stored entity node, or returns nil for a node
+// without a name
+func nodeFromProps(node neo4j.Node) *types.GraphNode {
+	name, _ := node.Props["name"].(string)
+	if name == "" {
+		return nil
+	}
+	chunks, _ := node.Props["chunks"].([]interface{})
+	attributes, _ := node.Props["attributes"].([]interface{})
+	aliases, _ := node.Props["aliases"].([]interface{})
+	return &types.GraphNode{
+		Name:       name,
+		Chunks:     listI2listS(chunks),
+		Attributes: listI2listS(attributes),
+		Aliases:    listI2listS(aliases),
+	}
+}
+
+func appendIfMissing(list []string, value string) []string {
+	for _, v := range list {
+		if v == value {
+			return list
+		}
+	}
+	return append(append([]string{}, list...), value)
+}
+
 func listI2listS(list []any) []string {
 	result := make([]string, len(list))
 	for i, v := range list {
@@ -76,6 +76,13 @@ func TestVectorRetrieveFiltersBeforeTopK(t *testing.T) {
 				params.KnowledgeIDs = []string{"knowledge-target"}
 			},
 		},
+		{
+			name:    "excluded knowledge",
+			blocker: sqliteTestIndex("blocker", "kb-target", "knowledge-denied", "tag-target", true),
+			configure: func(params *types.RetrieveParams) {
+				params.ExcludeKnowledgeIDs = []string{"knowledge-denied"}
+			},
+		},
 		{
 			name:    "tag",
 			blocker: sqliteTestIndex("blocker", "kb-target", "knowledge-target", "tag-other", true),
@@ -121,6 +121,20 @@ func (r *tenantAPIKeyRepository) RevokePlatformAPIKey(ctx context.Context, id ui
 	return nil
 }
 
+func (r *tenantAPIKeyRepository) RevokeAPIKeysCreatedBy(
+	ctx context.Context, tenantID uint64, userID string,
+) (int64, error) {
+	if userID == "" {
+		return 0, nil
+	}
+	now := time.Now().UTC()
+	res := r.db.WithContext(ctx).
+		Model(&types.TenantAPIKey{}).
+		Where("tenant_id = ? AND created_by = ? AND revoked_at IS NULL", tenantID, userID).
+		Update("revoked_at", &now)
+	return res.RowsAffected, res.Error
+}
+
 func (r *tenantAPIKeyRepository) UpdateAPIKeyHash(ctx context.Context, id uint64, hash string) error {
 	return r.db.WithContext(ctx).
 		Model(&types.TenantAPIKey{}).
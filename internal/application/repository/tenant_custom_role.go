@@ -0,0 +1,156 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"gorm.io/gorm"
+)
+
+// ErrTenantCustomRoleNotFound is returned when a custom role does not exist
+// in the workspace.
+var ErrTenantCustomRoleNotFound = errors.New("custom role not found")
+
+type tenantCustomRoleRepository struct {
+	db *gorm.DB
+}
+
+// NewTenantCustomRoleRepository creates the tenant custom role repository.
+func NewTenantCustomRoleRepository(db *gorm.DB) interfaces.TenantCustomRoleRepository {
+	return &tenantCustomRoleRepository{db: db}
+}
+
+func (r *tenantCustomRoleRepository) List(ctx context.Context, tenantID uint64) ([]*types.TenantCustomRole, error) {
+	var out []*types.TenantCustomRole
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ?", tenantID).
+		Order("name ASC").
+		Find(&out).Error
+	return out, err
+}
+
+func (r *tenantCustomRoleRepository) Get(ctx context.Context, tenantID, id uint64) (*types.TenantCustomRole, error) {
+	var role types.TenantCustomRole
+	err := r.db.WithContext(ctx).Where("tenant_id = ? AND id = ?", tenantID, id).First(&role).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrTenantCustomRoleNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &role, nil
+}
+
+func (r *tenantCustomRoleRepository) GetByName(
+	ctx context.Context, tenantID uint64, name string,
+) (*types.TenantCustomRole, error) {
+	var role types.TenantCustomRole
+	err := r.db.WithContext(ctx).Where("tenant_id = ? AND name = ?", tenantID, name).First(&role).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &role, nil
+}
+
+func (r *tenantCustomRoleRepository) Create(ctx context.Context, role *types.TenantCustomRole) error {
+	return r.db.WithContext(ctx).Create(role).Error
+}
+
+// Update saves the role and re-syncs tenant_members.role of its holders, so
+// the denormalised base role the auth middleware reads never lags behind.
+func (r *tenantCustomRoleRepository) Update(ctx context.Context, role *types.TenantCustomRole) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		res := tx.Model(&types.TenantCustomRole{}).
+			Where("tenant_id = ? AND id = ?", role.TenantID, role.ID).
+			Updates(map[string]any{
+				"name":        role.Name,
+				"description": role.Description,
+				"base_role":   role.BaseRole,
+				"permissions": role.Permissions,
+				"updated_at":  now,
+			})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrTenantCustomRoleNotFound
+		}
+		role.UpdatedAt = now
+		return tx.Model(&types.TenantMember{}).
+			Where("tenant_id = ? AND custom_role_id = ? AND role <> ?", role.TenantID, role.ID, role.BaseRole).
+			Updates(map[string]any{"role": role.BaseRole, "updated_at": now}).Error
+	})
+}
+
+func (r *tenantCustomRoleRepository) Delete(ctx context.Context, tenantID, id uint64) error {
+	res := r.db.WithContext(ctx).Where("tenant_id = ? AND id = ?", tenantID, id).Delete(&types.TenantCustomRole{})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrTenantCustomRoleNotFound
+	}
+	return nil
+}
+
+type customRoleCount struct {
+	CustomRoleID uint64
+	N            int64
+}
+
+func (r *tenantCustomRoleRepository) CountAssignments(
+	ctx context.Context, tenantID uint64,
+) (map[uint64]int64, map[uint64]int64, error) {
+	var memberRows, keyRows []customRoleCount
+	if err := r.db.WithContext(ctx).Model(&types.TenantMember{}).
+		Select("custom_role_id, COUNT(*) AS n").
+		Where("tenant_id = ? AND custom_role_id IS NOT NULL AND status = ?", tenantID, types.TenantMemberStatusActive).
+		Group("custom_role_id").
+		Scan(&memberRows).Error; err != nil {
+		return nil, nil, err
+	}
+	if err := r.db.WithContext(ctx).Model(&types.TenantAPIKey{}).
+		Select("custom_role_id, COUNT(*) AS n").
+		Where("tenant_id = ? AND custom_role_id IS NOT NULL AND revoked_at IS NULL", tenantID).
+		Group("custom_role_id").
+		Scan(&keyRows).Error; err != nil {
+		return nil, nil, err
+	}
+	members := make(map[uint64]int64, len(memberRows))
+	for _, row := range memberRows {
+		members[row.CustomRoleID] = row.N
+	}
+	keys := make(map[uint64]int64, len(keyRows))
+	for _, row := range keyRows {
+		keys[row.CustomRoleID] = row.N
+	}
+	return members, keys, nil
+}
+
+func (r *tenantCustomRoleRepository) SetAPIKeyRole(
+	ctx context.Context, tenantID, keyID uint64, roleID *uint64,
+) (*types.TenantAPIKey, error) {
+	res := r.db.WithContext(ctx).
+		Model(&types.TenantAPIKey{}).
+		Where("id = ? AND tenant_id = ? AND scope_type = ? AND revoked_at IS NULL",
+			keyID, tenantID, types.APIKeyScopeTenant).
+		Updates(map[string]any{"custom_role_id": roleID, "updated_at": time.Now()})
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return nil, ErrTenantAPIKeyNotFound
+	}
+	var key types.TenantAPIKey
+	if err := r.db.WithContext(ctx).Where("id = ? AND tenant_id = ?", keyID, tenantID).First(&key).Error; err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"gorm.io/gorm"
+)
+
+type tenantDataKeyRepository struct {
+	db *gorm.DB
+}
+
+// NewTenantDataKeyRepository creates the tenant data key repository.
+func NewTenantDataKeyRepository(db *gorm.DB) interfaces.TenantDataKeyRepository {
+	return &tenantDataKeyRepository{db: db}
+}
+
+func (r *tenantDataKeyRepository) ListByTenant(ctx context.Context, tenantID uint64) ([]*types.TenantDataKey, error) {
+	var keys []*types.TenantDataKey
+	err := r.db.WithContext(ctx).Where("tenant_id = ?", tenantID).Order("version ASC").Find(&keys).Error
+	return keys, err
+}
+
+func (r *tenantDataKeyRepository) Get(ctx context.Context, tenantID uint64, version int) (*types.TenantDataKey, error) {
+	return r.first(r.db.WithContext(ctx).Where("tenant_id = ? AND version = ?", tenantID, version))
+}
+
+func (r *tenantDataKeyRepository) GetActive(ctx context.Context, tenantID uint64) (*types.TenantDataKey, error) {
+	return r.first(r.db.WithContext(ctx).Where("tenant_id = ? AND active = ?", tenantID, true).Order("version DESC"))
+}
+
+func (r *tenantDataKeyRepository) first(q *gorm.DB) (*types.TenantDataKey, error) {
+	var key types.TenantDataKey
+	err := q.First(&key).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+func (r *tenantDataKeyRepository) CreateActive(ctx context.Context, key *types.TenantDataKey) error {
+	key.Active = true
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&types.TenantDataKey{}).
+			Where("tenant_id = ? AND active = ?", key.TenantID, true).
+			Updates(map[string]interface{}{"active": false, "updated_at": time.Now()}).Error; err != nil {
+			return err
+		}
+		return tx.Create(key).Error
+	})
+}
+
+func (r *tenantDataKeyRepository) ListNotWrappedBy(
+	ctx context.Context, masterKeyID string, afterID uint64, limit int,
+) ([]*types.TenantDataKey, error) {
+	var keys []*types.TenantDataKey
+	err := r.db.WithContext(ctx).
+		Where("master_key_id <> ? AND id > ?", masterKeyID, afterID).
+		Order("id ASC").Limit(limit).Find(&keys).Error
+	return keys, err
+}
+
+func (r *tenantDataKeyRepository) UpdateWrapping(ctx context.Context, id uint64, masterKeyID, wrappedKey string) error {
+	return r.db.WithContext(ctx).Model(&types.TenantDataKey{}).Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"master_key_id": masterKeyID,
+			"wrapped_key":   wrappedKey,
+			"updated_at":    time.Now(),
+		}).Error
+}
+
+func (r *tenantDataKeyRepository) Count(ctx context.Context) (int64, error) {
+	var n int64
+	err := r.db.WithContext(ctx).Model(&types.TenantDataKey{}).Count(&n).Error
+	return n, err
+}
+
+func (r *tenantDataKeyRepository) CountNotWrappedBy(ctx context.Context, masterKeyID string) (int64, error) {
+	var n int64
+	err := r.db.WithContext(ctx).Model(&types.TenantDataKey{}).Where("master_key_id <> ?", masterKeyID).Count(&n).Error
+	return n, err
+}
@@ -143,14 +143,16 @@ func (r *tenantMemberRepository) ListPagedByTenant(
 	return members, nil
 }
 
-// UpdateRole changes the role of an existing active membership.
+// UpdateRole changes the role of an existing active membership. Assigning
+// a built-in role drops any custom role the member held.
 func (r *tenantMemberRepository) UpdateRole(ctx context.Context, userID string, tenantID uint64, role types.TenantRole) error {
 	res := r.db.WithContext(ctx).
 		Model(&types.TenantMember{}).
 		Where("user_id = ? AND tenant_id = ?", userID, tenantID).
 		Updates(map[string]any{
-			"role":       role,
-			"updated_at": time.Now(),
+			"role":           role,
+			"custom_role_id": nil,
+			"updated_at":     time.Now(),
 		})
 	if res.Error != nil {
 		return res.Error
@@ -222,8 +224,9 @@ func (r *tenantMemberRepository) DemoteOwnerAtomically(
 			Model(&types.TenantMember{}).
 			Where("user_id = ? AND tenant_id = ?", userID, tenantID).
 			Updates(map[string]any{
-				"role":       newRole,
-				"updated_at": time.Now(),
+				"role":           newRole,
+				"custom_role_id": nil,
+				"updated_at":     time.Now(),
 			})
 		if res.Error != nil {
 			return res.Error
@@ -235,6 +238,48 @@ func (r *tenantMemberRepository) DemoteOwnerAtomically(
 	})
 }
 
+// AssignCustomRole gives the member a custom role: role becomes the custom
+// role's base role and custom_role_id points at it. When the member is an
+// Owner the other Owners are locked first, as in DemoteOwnerAtomically, so
+// the assignment cannot leave the tenant ownerless.
+func (r *tenantMemberRepository) AssignCustomRole(
+	ctx context.Context,
+	userID string,
+	tenantID uint64,
+	role *types.TenantCustomRole,
+) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var current types.TenantMember
+		if err := tx.Clauses(forUpdateClause()).
+			Where("user_id = ? AND tenant_id = ?", userID, tenantID).
+			First(&current).Error; err != nil {
+			return err
+		}
+		if current.Role == types.TenantRoleOwner {
+			var locked []types.TenantMember
+			err := tx.
+				Clauses(forUpdateClause()).
+				Where("tenant_id = ? AND user_id <> ? AND role = ? AND status = ?",
+					tenantID, userID, types.TenantRoleOwner, types.TenantMemberStatusActive).
+				Find(&locked).Error
+			if err != nil {
+				return err
+			}
+			if len(locked) == 0 {
+				return ErrLastOwner
+			}
+		}
+		return tx.
+			Model(&types.TenantMember{}).
+			Where("id = ?", current.ID).
+			Updates(map[string]any{
+				"role":           role.BaseRole,
+				"custom_role_id": role.ID,
+				"updated_at":     time.Now(),
+			}).Error
+	})
+}
+
 // RemoveOwnerAtomically soft-deletes an Owner row under the same lock
 // as DemoteOwnerAtomically. Same return semantics.
 func (r *tenantMemberRepository) RemoveOwnerAtomically(
@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type tenantSCIMOrganizationRepository struct {
+	db *gorm.DB
+}
+
+// NewTenantSCIMOrganizationRepository creates the repository of the links
+// between SCIM groups and the organizations provisioned for them.
+func NewTenantSCIMOrganizationRepository(db *gorm.DB) interfaces.TenantSCIMOrganizationRepository {
+	return &tenantSCIMOrganizationRepository{db: db}
+}
+
+func (r *tenantSCIMOrganizationRepository) Get(
+	ctx context.Context, tenantID uint64, groupID string,
+) (*types.TenantSCIMOrganization, error) {
+	var link types.TenantSCIMOrganization
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND group_id = ?", tenantID, groupID).
+		First(&link).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &link, nil
+}
+
+func (r *tenantSCIMOrganizationRepository) Save(ctx context.Context, link *types.TenantSCIMOrganization) error {
+	now := time.Now()
+	if link.CreatedAt.IsZero() {
+		link.CreatedAt = now
+	}
+	link.UpdatedAt = now
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "tenant_id"}, {Name: "group_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"organization_id", "role", "updated_at"}),
+	}).Create(link).Error
+}
+
+func (r *tenantSCIMOrganizationRepository) Delete(ctx context.Context, tenantID uint64, groupID string) error {
+	return r.db.WithContext(ctx).
+		Where("tenant_id = ? AND group_id = ?", tenantID, groupID).
+		Delete(&types.TenantSCIMOrganization{}).Error
+}
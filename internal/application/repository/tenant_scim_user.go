@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type tenantSCIMUserRepository struct {
+	db *gorm.DB
+}
+
+// NewTenantSCIMUserRepository creates the SCIM user link repository.
+func NewTenantSCIMUserRepository(db *gorm.DB) interfaces.TenantSCIMUserRepository {
+	return &tenantSCIMUserRepository{db: db}
+}
+
+func (r *tenantSCIMUserRepository) List(
+	ctx context.Context, tenantID uint64, userName, externalID string, offset, limit int,
+) ([]*types.TenantSCIMUser, int64, error) {
+	q := r.db.WithContext(ctx).Model(&types.TenantSCIMUser{}).Where("tenant_id = ?", tenantID)
+	if userName != "" {
+		q = q.Where("user_name = ?", userName)
+	}
+	if externalID != "" {
+		q = q.Where("external_id = ?", externalID)
+	}
+	var total int64
+	if err := q.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var out []*types.TenantSCIMUser
+	err := q.Order("created_at ASC, user_id ASC").Offset(offset).Limit(limit).Find(&out).Error
+	return out, total, err
+}
+
+func (r *tenantSCIMUserRepository) Get(
+	ctx context.Context, tenantID uint64, userID string,
+) (*types.TenantSCIMUser, error) {
+	return r.first(r.db.WithContext(ctx).Where("tenant_id = ? AND user_id = ?", tenantID, userID))
+}
+
+func (r *tenantSCIMUserRepository) GetByUserName(
+	ctx context.Context, tenantID uint64, userName string,
+) (*types.TenantSCIMUser, error) {
+	return r.first(r.db.WithContext(ctx).Where("tenant_id = ? AND user_name = ?", tenantID, userName))
+}
+
+func (r *tenantSCIMUserRepository) first(q *gorm.DB) (*types.TenantSCIMUser, error) {
+	var link types.TenantSCIMUser
+	err := q.First(&link).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &link, nil
+}
+
+func (r *tenantSCIMUserRepository) Save(ctx context.Context, link *types.TenantSCIMUser) error {
+	now := time.Now()
+	if link.CreatedAt.IsZero() {
+		link.CreatedAt = now
+	}
+	link.UpdatedAt = now
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "tenant_id"}, {Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"user_name", "external_id", "active", "updated_at"}),
+	}).Create(link).Error
+}
+
+func (r *tenantSCIMUserRepository) Delete(ctx context.Context, tenantID uint64, userID string) error {
+	return r.db.WithContext(ctx).
+		Where("tenant_id = ? AND user_id = ?", tenantID, userID).
+		Delete(&types.TenantSCIMUser{}).Error
+}
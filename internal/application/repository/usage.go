@@ -0,0 +1,162 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type usageRepository struct {
+	db *gorm.DB
+}
+
+// NewUsageRepository creates the usage ledger repository.
+func NewUsageRepository(db *gorm.DB) interfaces.UsageRepository {
+	return &usageRepository{db: db}
+}
+
+func (r *usageRepository) CreateRecords(ctx context.Context, records []*types.UsageRecord) error {
+	if len(records) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).CreateInBatches(records, 200).Error
+}
+
+// usageReportSelect sums the metered columns; the billable total mirrors
+// UsageRecord.BillableTokens.
+const usageReportSelect = "COUNT(*) AS records, " +
+	"COALESCE(SUM(calls), 0) AS calls, " +
+	"COALESCE(SUM(prompt_tokens), 0) AS prompt_tokens, " +
+	"COALESCE(SUM(completion_tokens), 0) AS completion_tokens, " +
+	"COALESCE(SUM(cached_tokens), 0) AS cached_tokens, " +
+	"COALESCE(SUM(embedding_tokens), 0) AS embedding_tokens, " +
+	"COALESCE(SUM(prompt_tokens + completion_tokens + embedding_tokens), 0) AS total_tokens"
+
+type usageReportScan struct {
+	GroupKey         string
+	Label            string
+	Records          int64
+	Calls            int64
+	PromptTokens     int64
+	CompletionTokens int64
+	CachedTokens     int64
+	EmbeddingTokens  int64
+	TotalTokens      int64
+}
+
+func (r *usageRepository) Report(ctx context.Context, q *types.UsageReportQuery) ([]types.UsageReportRow, error) {
+	column, ok := types.UsageGroupByColumn(q.GroupBy)
+	if !ok {
+		return nil, fmt.Errorf("unsupported usage group_by %q", q.GroupBy)
+	}
+	// The group key is always read back as text so numeric columns
+	// (api_key_id) and string columns scan into the same field.
+	keyExpr := "CAST(" + column + " AS VARCHAR(64))"
+	if r.db.Dialector.Name() == "sqlite" {
+		keyExpr = "CAST(" + column + " AS TEXT)"
+	}
+	labelExpr := "''"
+	if q.GroupBy == types.UsageGroupByModel {
+		labelExpr = "MAX(model_name)"
+	}
+
+	db := r.db.WithContext(ctx).Model(&types.UsageRecord{}).
+		Select(keyExpr+" AS group_key, "+labelExpr+" AS label, "+usageReportSelect).
+		Where("tenant_id = ? AND day >= ? AND day <= ?", q.TenantID, q.From, q.To)
+	if q.Kind != "" {
+		db = db.Where("kind = ?", q.Kind)
+	}
+	if q.ModelID != "" {
+		db = db.Where("model_id = ?", q.ModelID)
+	}
+	if q.UserID != "" {
+		db = db.Where("user_id = ?", q.UserID)
+	}
+	if q.APIKeyID != 0 {
+		db = db.Where("api_key_id = ?", q.APIKeyID)
+	}
+	if q.AgentID != "" {
+		db = db.Where("agent_id = ?", q.AgentID)
+	}
+	if q.KnowledgeBaseID != "" {
+		db = db.Where("knowledge_base_id = ?", q.KnowledgeBaseID)
+	}
+	if q.SessionID != "" {
+		db = db.Where("session_id = ?", q.SessionID)
+	}
+
+	order := "total_tokens DESC, calls DESC, group_key ASC"
+	if q.GroupBy == types.UsageGroupByDay || q.GroupBy == types.UsageGroupByMonth {
+		order = "group_key ASC"
+	}
+	var scanned []usageReportScan
+	if err := db.Group(column).Order(order).Scan(&scanned).Error; err != nil {
+		return nil, err
+	}
+	rows := make([]types.UsageReportRow, 0, len(scanned))
+	for _, s := range scanned {
+		rows = append(rows, types.UsageReportRow{
+			Key:              s.GroupKey,
+			Label:            s.Label,
+			Records:          s.Records,
+			Calls:            s.Calls,
+			PromptTokens:     s.PromptTokens,
+			CompletionTokens: s.CompletionTokens,
+			CachedTokens:     s.CachedTokens,
+			EmbeddingTokens:  s.EmbeddingTokens,
+			TotalTokens:      s.TotalTokens,
+		})
+	}
+	return rows, nil
+}
+
+func (r *usageRepository) SumBillableTokens(ctx context.Context, tenantID, apiKeyID uint64, period string) (int64, error) {
+	db := r.db.WithContext(ctx).Model(&types.UsageRecord{}).
+		Where("tenant_id = ? AND period = ?", tenantID, period)
+	if apiKeyID != 0 {
+		db = db.Where("api_key_id = ?", apiKeyID)
+	}
+	var total int64
+	err := db.Select("COALESCE(SUM(prompt_tokens + completion_tokens + embedding_tokens), 0)").Scan(&total).Error
+	return total, err
+}
+
+func (r *usageRepository) ListBudgets(ctx context.Context, tenantID uint64) ([]*types.UsageBudget, error) {
+	var out []*types.UsageBudget
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ?", tenantID).
+		Order("api_key_id ASC").
+		Find(&out).Error
+	return out, err
+}
+
+func (r *usageRepository) UpsertBudget(ctx context.Context, budget *types.UsageBudget) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "tenant_id"}, {Name: "api_key_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"monthly_token_limit", "warn_percent", "hard_stop", "updated_at",
+		}),
+	}).Create(budget).Error
+}
+
+func (r *usageRepository) DeleteBudget(ctx context.Context, tenantID, id uint64) (bool, error) {
+	res := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND id = ?", tenantID, id).
+		Delete(&types.UsageBudget{})
+	return res.RowsAffected > 0, res.Error
+}
+
+func (r *usageRepository) MarkBudgetNotified(ctx context.Context, id uint64, exceeded bool, period string) (bool, error) {
+	column := "warned_period"
+	if exceeded {
+		column = "exceeded_period"
+	}
+	res := r.db.WithContext(ctx).Model(&types.UsageBudget{}).
+		Where("id = ? AND "+column+" <> ?", id, period).
+		Update(column, period)
+	return res.RowsAffected > 0, res.Error
+}
@@ -0,0 +1,182 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// usageTestDDL mirrors migrations/sqlite/000016_usage_metering.up.sql.
+const usageTestDDL = `
+CREATE TABLE IF NOT EXISTS usage_records (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    tenant_id INTEGER NOT NULL,
+    user_id VARCHAR(36) NOT NULL DEFAULT '',
+    api_key_id INTEGER NOT NULL DEFAULT 0,
+    agent_id VARCHAR(36) NOT NULL DEFAULT '',
+    knowledge_base_id VARCHAR(36) NOT NULL DEFAULT '',
+    session_id VARCHAR(36) NOT NULL DEFAULT '',
+    kind VARCHAR(16) NOT NULL,
+    model_id VARCHAR(64) NOT NULL DEFAULT '',
+    model_name VARCHAR(255) NOT NULL DEFAULT '',
+    prompt_tokens INTEGER NOT NULL DEFAULT 0,
+    completion_tokens INTEGER NOT NULL DEFAULT 0,
+    cached_tokens INTEGER NOT NULL DEFAULT 0,
+    embedding_tokens INTEGER NOT NULL DEFAULT 0,
+    calls INTEGER NOT NULL DEFAULT 0,
+    period VARCHAR(7) NOT NULL,
+    day VARCHAR(10) NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_usage_records_tenant_day ON usage_records (tenant_id, day);
+CREATE INDEX IF NOT EXISTS idx_usage_records_tenant_period_key ON usage_records (tenant_id, period, api_key_id);
+
+CREATE TABLE IF NOT EXISTS usage_budgets (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    tenant_id INTEGER NOT NULL,
+    api_key_id INTEGER NOT NULL DEFAULT 0,
+    monthly_token_limit INTEGER NOT NULL,
+    warn_percent INTEGER NOT NULL DEFAULT 80,
+    hard_stop BOOLEAN NOT NULL DEFAULT 0,
+    warned_period VARCHAR(7) NOT NULL DEFAULT '',
+    exceeded_period VARCHAR(7) NOT NULL DEFAULT '',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_usage_budgets_scope ON usage_budgets (tenant_id, api_key_id);
+`
+
+func setupUsageTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db := setupKnowledgeTestDB(t)
+	require.NoError(t, db.Exec(usageTestDDL).Error)
+	return db
+}
+
+func usageTestRecord(tenantID, apiKeyID uint64, kind types.UsageKind, model, day string, prompt, completion int64) *types.UsageRecord {
+	return &types.UsageRecord{
+		TenantID:         tenantID,
+		APIKeyID:         apiKeyID,
+		Kind:             kind,
+		ModelID:          model,
+		ModelName:        model + "-name",
+		PromptTokens:     prompt,
+		CompletionTokens: completion,
+		Calls:            1,
+		Period:           day[:7],
+		Day:              day,
+	}
+}
+
+func TestUsageRepositoryReportGroupsAndFilters(t *testing.T) {
+	db := setupUsageTestDB(t)
+	repo := NewUsageRepository(db)
+	ctx := context.Background()
+
+	embed := usageTestRecord(1, 0, types.UsageKindEmbedding, "emb", "2026-03-02", 0, 0)
+	embed.EmbeddingTokens = 50
+	require.NoError(t, repo.CreateRecords(ctx, []*types.UsageRecord{
+		usageTestRecord(1, 7, types.UsageKindChat, "gpt", "2026-03-01", 100, 20),
+		usageTestRecord(1, 0, types.UsageKindChat, "gpt", "2026-03-02", 10, 5),
+		usageTestRecord(1, 0, types.UsageKindChat, "qwen", "2026-04-01", 1000, 0),
+		embed,
+		// Another tenant never leaks into the report.
+		usageTestRecord(2, 0, types.UsageKindChat, "gpt", "2026-03-01", 999, 999),
+	}))
+
+	rows, err := repo.Report(ctx, &types.UsageReportQuery{
+		TenantID: 1, From: "2026-03-01", To: "2026-03-31", GroupBy: types.UsageGroupByModel,
+	})
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+	assert.Equal(t, "gpt", rows[0].Key)
+	assert.Equal(t, "gpt-name", rows[0].Label)
+	assert.Equal(t, int64(2), rows[0].Calls)
+	assert.Equal(t, int64(135), rows[0].TotalTokens)
+	assert.Equal(t, "emb", rows[1].Key)
+	assert.Equal(t, int64(50), rows[1].EmbeddingTokens)
+
+	rows, err = repo.Report(ctx, &types.UsageReportQuery{
+		TenantID: 1, From: "2026-03-01", To: "2026-04-30", GroupBy: types.UsageGroupByDay,
+		Kind: types.UsageKindChat,
+	})
+	require.NoError(t, err)
+	require.Len(t, rows, 3)
+	assert.Equal(t, []string{"2026-03-01", "2026-03-02", "2026-04-01"},
+		[]string{rows[0].Key, rows[1].Key, rows[2].Key})
+
+	rows, err = repo.Report(ctx, &types.UsageReportQuery{
+		TenantID: 1, From: "2026-03-01", To: "2026-03-31", GroupBy: types.UsageGroupByAPIKey, APIKeyID: 7,
+	})
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	assert.Equal(t, "7", rows[0].Key)
+	assert.Equal(t, int64(120), rows[0].TotalTokens)
+}
+
+func TestUsageRepositorySumBillableTokens(t *testing.T) {
+	db := setupUsageTestDB(t)
+	repo := NewUsageRepository(db)
+	ctx := context.Background()
+
+	require.NoError(t, repo.CreateRecords(ctx, []*types.UsageRecord{
+		usageTestRecord(1, 7, types.UsageKindChat, "gpt", "2026-03-01", 100, 20),
+		usageTestRecord(1, 0, types.UsageKindChat, "gpt", "2026-03-02", 10, 5),
+		usageTestRecord(1, 0, types.UsageKindChat, "gpt", "2026-04-01", 1000, 0),
+	}))
+
+	total, err := repo.SumBillableTokens(ctx, 1, 0, "2026-03")
+	require.NoError(t, err)
+	assert.Equal(t, int64(135), total)
+
+	total, err = repo.SumBillableTokens(ctx, 1, 7, "2026-03")
+	require.NoError(t, err)
+	assert.Equal(t, int64(120), total)
+
+	total, err = repo.SumBillableTokens(ctx, 3, 0, "2026-03")
+	require.NoError(t, err)
+	assert.Zero(t, total)
+}
+
+func TestUsageRepositoryBudgets(t *testing.T) {
+	db := setupUsageTestDB(t)
+	repo := NewUsageRepository(db)
+	ctx := context.Background()
+
+	budget := &types.UsageBudget{TenantID: 1, MonthlyTokenLimit: 1000, WarnPercent: 80}
+	require.NoError(t, repo.UpsertBudget(ctx, budget))
+	require.NoError(t, repo.UpsertBudget(ctx, &types.UsageBudget{TenantID: 1, APIKeyID: 7, MonthlyTokenLimit: 10}))
+	// Upserting the same scope replaces the limit instead of adding a row.
+	require.NoError(t, repo.UpsertBudget(ctx, &types.UsageBudget{TenantID: 1, MonthlyTokenLimit: 5000, HardStop: true}))
+
+	budgets, err := repo.ListBudgets(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, budgets, 2)
+	assert.Equal(t, uint64(0), budgets[0].APIKeyID)
+	assert.Equal(t, int64(5000), budgets[0].MonthlyTokenLimit)
+	assert.True(t, budgets[0].HardStop)
+
+	marked, err := repo.MarkBudgetNotified(ctx, budgets[0].ID, false, "2026-03")
+	require.NoError(t, err)
+	assert.True(t, marked)
+	marked, err = repo.MarkBudgetNotified(ctx, budgets[0].ID, false, "2026-03")
+	require.NoError(t, err)
+	assert.False(t, marked, "the same period is only marked once")
+	marked, err = repo.MarkBudgetNotified(ctx, budgets[0].ID, true, "2026-03")
+	require.NoError(t, err)
+	assert.True(t, marked, "warning and exceeded are tracked separately")
+
+	deleted, err := repo.DeleteBudget(ctx, 2, budgets[1].ID)
+	require.NoError(t, err)
+	assert.False(t, deleted, "budgets of other tenants are not deletable")
+	deleted, err = repo.DeleteBudget(ctx, 1, budgets[1].ID)
+	require.NoError(t, err)
+	assert.True(t, deleted)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	filesvc "github.com/Tencent/WeKnora/internal/application/service/file"
 
 	"github.com/Tencent/WeKnora/internal/agent"
 	"github.com/Tencent/WeKnora/internal/agent/approval"
@@ -11,6 +12,7 @@ import (
 	"github.com/Tencent/WeKnora/internal/agent/tools"
 	"github.com/Tencent/WeKnora/internal/config"
 	"github.com/Tencent/WeKnora/internal/event"
+	"github.com/Tencent/WeKnora/internal/infrastructure/browser"
 	"github.com/Tencent/WeKnora/internal/logger"
 	"github.com/Tencent/WeKnora/internal/mcp"
 	"github.com/Tencent/WeKnora/internal/models/chat"
@@ -105,12 +107,30 @@ type agentService struct {
 	tenantService         interfaces.TenantService
 	messageService        interfaces.MessageService
 	memoryService         interfaces.MemoryService
+	graphCommunityService interfaces.GraphCommunityService
+	graphEntityService    interfaces.GraphEntityService
 	storageResolver       interfaces.StorageBackendResolver
 	toolApprovalGate      approval.MCPApproval
 	sandboxMgr            sandbox.Manager
 	sandboxResolver       sandbox.TenantSandboxResolver
 	sandboxPinner         *SessionSandboxPinner
 	sandboxPolicy         WorkspaceSandboxPolicy
+	sqlConnectionService  interfaces.SQLConnectionService
+	// analysisSessions keeps data_analysis tables and views alive across the
+	// turns of a chat session; artifactCollector receives the charts drawn by
+	// data_chart. Either may be nil.
+	analysisSessions  *tools.AnalysisSessions
+	artifactCollector *ArtifactCollector
+	// browserConfigService resolves the agent's browser config;
+	// browserSessions holds the pages the browser_* tools drive, keyed by
+	// config and chat session. Either may be nil.
+	browserConfigService interfaces.BrowserConfigService
+	browserSessions      *browser.Sessions
+	// auditService receives one row per tool execution. May be nil.
+	auditService interfaces.AuditLogService
+	// fileEncryption wraps file services the data_analysis tool builds from
+	// tenant storage config. May be nil.
+	fileEncryption *filesvc.Encryption
 }
 
 // NewAgentService creates a new agent service
@@ -132,12 +152,21 @@ func NewAgentService(
 	tenantService interfaces.TenantService,
 	messageService interfaces.MessageService,
 	memoryService interfaces.MemoryService,
+	graphCommunityService interfaces.GraphCommunityService,
+	graphEntityService interfaces.GraphEntityService,
 	storageResolver interfaces.StorageBackendResolver,
 	toolApprovalGate approval.MCPApproval,
 	sandboxMgr sandbox.Manager,
 	sandboxResolver sandbox.TenantSandboxResolver,
 	sandboxPinner *SessionSandboxPinner,
 	sandboxPolicy WorkspaceSandboxPolicy,
+	sqlConnectionService interfaces.SQLConnectionService,
+	analysisSessions *tools.AnalysisSessions,
+	artifactCollector *ArtifactCollector,
+	browserConfigService interfaces.BrowserConfigService,
+	browserSessions *browser.Sessions,
+	auditService interfaces.AuditLogService,
+	fileEncryption *filesvc.Encryption,
 ) interfaces.AgentService {
 	return &agentService{
 		cfg:                   cfg,
@@ -157,12 +186,21 @@ func NewAgentService(
 		tenantService:         tenantService,
 		messageService:        messageService,
 		memoryService:         memoryService,
+		graphCommunityService: graphCommunityService,
+		graphEntityService:    graphEntityService,
 		storageResolver:       storageResolver,
 		toolApprovalGate:      toolApprovalGate,
 		sandboxMgr:            sandboxMgr,
 		sandboxResolver:       sandboxResolver,
 		sandboxPinner:         sandboxPinner,
 		sandboxPolicy:         sandboxPolicy,
+		sqlConnectionService:  sqlConnectionService,
+		analysisSessions:      analysisSessions,
+		artifactCollector:     artifactCollector,
+		browserConfigService:  browserConfigService,
+		browserSessions:       browserSessions,
+		auditService:          auditService,
+		fileEncryption:        fileEncryption,
 	}
 }
 
@@ -220,6 +258,10 @@ func (s *agentService) CreateAgentEngine(
 		s.resolvePinnedSkillInfos(config),
 	)
 
+	if s.auditService != nil {
+		engine.SetToolAuditor(s.auditToolExecution)
+	}
+
 	// Set VLM image describer for MCP tool result image analysis.
 	// When an MCP tool returns images, the engine uses VLM to generate text descriptions
 	// and appends them to the tool result content (since Chat Completions API does not
@@ -507,10 +549,12 @@ func (s *agentService) registerTools(
 			tools.ToolGrepChunks:          true,
 			tools.ToolListKnowledgeChunks: true,
 			tools.ToolQueryKnowledgeGraph: true,
+			tools.ToolGraphGlobalSearch:   true,
 			tools.ToolGetDocumentInfo:     true,
 			tools.ToolDatabaseQuery:       true,
 			tools.ToolDataAnalysis:        true,
 			tools.ToolDataSchema:          true,
+			tools.ToolDataChart:           true,
 			// Wiki tools also require at least one KB in scope.
 			tools.ToolWikiReadPage:      true,
 			tools.ToolWikiSearch:        true,
@@ -561,6 +605,29 @@ func (s *agentService) registerTools(
 		logger.Infof(ctx, "search_memory not registered: long-term memory is off for this request")
 	}
 
+	// SQL tools follow the agent's connection selection the same way: they
+	// exist exactly when the agent has at least one usable connection.
+	allowedTools = withoutString(allowedTools, tools.ToolSQLSchema)
+	allowedTools = withoutString(allowedTools, tools.ToolSQLQuery)
+	sqlConnections := s.resolveSQLConnections(ctx, config)
+	if len(sqlConnections) > 0 {
+		allowedTools = append(allowedTools, tools.ToolSQLSchema, tools.ToolSQLQuery)
+	}
+
+	// Browser tools likewise exist exactly when the agent's browser config
+	// resolves.
+	browserToolNames := []string{
+		tools.ToolBrowserNavigate, tools.ToolBrowserClick, tools.ToolBrowserFill,
+		tools.ToolBrowserExtract, tools.ToolBrowserScreenshot,
+	}
+	for _, name := range browserToolNames {
+		allowedTools = withoutString(allowedTools, name)
+	}
+	browserConfig := s.resolveBrowserConfig(ctx, config)
+	if browserConfig != nil {
+		allowedTools = append(allowedTools, browserToolNames...)
+	}
+
 	// Tool capability sets — used by the hard safety nets below to drop tools
 	// whose runtime prerequisite (a matching KB surface) is missing.
 	//
@@ -572,6 +639,7 @@ func (s *agentService) registerTools(
 		tools.ToolGrepChunks:          true,
 		tools.ToolListKnowledgeChunks: true,
 		tools.ToolQueryKnowledgeGraph: true,
+		tools.ToolGraphGlobalSearch:   true,
 		tools.ToolGetDocumentInfo:     true,
 		tools.ToolDatabaseQuery:       true,
 	}
@@ -652,7 +720,13 @@ func (s *agentService) registerTools(
 			toolToRegister = tools.NewListKnowledgeChunksTool(s.knowledgeService, s.chunkService, config.SearchTargets)
 		case tools.ToolQueryKnowledgeGraph:
 			toolToRegister = tools.NewQueryKnowledgeGraphTool(s.knowledgeBaseService, config.SearchTargets).
-				WithKnowledgeScope(s.knowledgeService)
+				WithKnowledgeScope(s.knowledgeService).
+				WithGraphEntities(s.graphEntityService)
+		case tools.ToolGraphGlobalSearch:
+			if s.graphCommunityService != nil && chatModel != nil {
+				toolToRegister = tools.NewGraphGlobalSearchTool(
+					s.graphCommunityService, config.SearchTargets, chatModel.GetModelID())
+			}
 		case tools.ToolGetDocumentInfo:
 			toolToRegister = tools.NewGetDocumentInfoTool(s.knowledgeService, s.chunkService, config.SearchTargets)
 		case tools.ToolSearchConversations:
@@ -681,15 +755,49 @@ func (s *agentService) registerTools(
 			)
 			logger.Infof(ctx, "Registered web_search tool for session: %s, maxResults: %d, providerID: %s", sessionID, config.WebSearchMaxResults, config.WebSearchProviderID)
 
+		case tools.ToolSQLSchema:
+			toolToRegister = tools.NewSQLSchemaTool(s.sqlConnectionService, sqlConnections)
+		case tools.ToolSQLQuery:
+			toolToRegister = tools.NewSQLQueryTool(s.sqlConnectionService, sqlConnections)
+			logger.Infof(ctx, "Registered SQL tools with %d connection(s)", len(sqlConnections))
+
+		case tools.ToolBrowserNavigate:
+			toolToRegister = tools.NewBrowserNavigateTool(s.browserSessions, browserConfig, sessionID)
+			logger.Infof(ctx, "Registered browser tools with config %s for session: %s", browserConfig.ID, sessionID)
+		case tools.ToolBrowserClick:
+			toolToRegister = tools.NewBrowserClickTool(s.browserSessions, browserConfig, sessionID)
+		case tools.ToolBrowserFill:
+			toolToRegister = tools.NewBrowserFillTool(s.browserSessions, browserConfig, sessionID)
+		case tools.ToolBrowserExtract:
+			toolToRegister = tools.NewBrowserExtractTool(s.browserSessions, browserConfig, sessionID)
+		case tools.ToolBrowserScreenshot:
+			var stager tools.ArtifactStager
+			if s.artifactCollector != nil {
+				stager = s.artifactCollector
+			}
+			toolToRegister = tools.NewBrowserScreenshotTool(s.browserSessions, browserConfig, sessionID, stager)
+
 		case tools.ToolWebFetch:
 			toolToRegister = tools.NewWebFetchTool(chatModel)
 			logger.Infof(ctx, "Registered web_fetch tool for session: %s", sessionID)
 
 		case tools.ToolDataAnalysis:
-			toolToRegister = tools.NewDataAnalysisTool(s.knowledgeBaseService, s.knowledgeService, s.tenantService, s.fileService, s.duckdb, sessionID, s.storageResolver).
-				WithSearchTargets(config.SearchTargets)
+			toolToRegister = s.newDataAnalysisTool(sessionID, config)
 			logger.Infof(ctx, "Registered data_analysis tool for session: %s", sessionID)
 
+		case tools.ToolDataChart:
+			// Charts read from the analysis session; without one there is
+			// nothing for the tool to plot.
+			if s.analysisSessions == nil {
+				continue
+			}
+			var stager tools.ArtifactStager
+			if s.artifactCollector != nil {
+				stager = s.artifactCollector
+			}
+			toolToRegister = tools.NewDataChartTool(s.newDataAnalysisTool(sessionID, config), stager)
+			logger.Infof(ctx, "Registered data_chart tool for session: %s", sessionID)
+
 		case tools.ToolDataSchema:
 			toolToRegister = tools.NewDataSchemaTool(s.knowledgeService, s.chunkService.GetRepository()).
 				WithSearchTargets(config.SearchTargets)
@@ -736,6 +844,65 @@ func (s *agentService) registerTools(
 	return nil
 }
 
+// newDataAnalysisTool builds the data_analysis tool of one Agent turn, bound
+// to the chat session's analysis session when sessions are available.
+func (s *agentService) newDataAnalysisTool(sessionID string, config *types.AgentConfig) *tools.DataAnalysisTool {
+	return tools.NewDataAnalysisTool(s.knowledgeBaseService, s.knowledgeService, s.tenantService, s.fileService, s.fileEncryption, s.duckdb, sessionID, s.storageResolver).
+		WithSearchTargets(config.SearchTargets).
+		WithAnalysisSessions(s.analysisSessions)
+}
+
+// resolveSQLConnections loads the agent's selected SQL connections. They are
+// looked up under the tenant that owns the agent, so a shared agent keeps
+// using its owner's connections and can never reach the caller's. Unknown or
+// deleted IDs are skipped.
+func (s *agentService) resolveSQLConnections(ctx context.Context, config *types.AgentConfig) []*types.SQLConnection {
+	if s.sqlConnectionService == nil || len(config.SQLConnectionIDs) == 0 {
+		return nil
+	}
+	tenantID := config.AgentTenantID
+	if tenantID == 0 {
+		tenantID, _ = ctx.Value(types.TenantIDContextKey).(uint64)
+	}
+	var conns []*types.SQLConnection
+	for _, id := range dedupStrings(config.SQLConnectionIDs) {
+		conn, err := s.sqlConnectionService.GetConnection(ctx, tenantID, id)
+		if err != nil {
+			logger.Warnf(ctx, "Failed to load SQL connection %s: %v", id, err)
+			continue
+		}
+		if conn == nil {
+			logger.Warnf(ctx, "SQL connection %s not found in tenant %d, skipped", id, tenantID)
+			continue
+		}
+		conns = append(conns, conn)
+	}
+	return conns
+}
+
+// resolveBrowserConfig loads the agent's browser config under the tenant that
+// owns the agent, like resolveSQLConnections. It returns nil when none is
+// selected, the config is gone, or there is no page registry to drive it.
+func (s *agentService) resolveBrowserConfig(ctx context.Context, config *types.AgentConfig) *types.BrowserConfig {
+	if s.browserConfigService == nil || s.browserSessions == nil || config.BrowserConfigID == "" {
+		return nil
+	}
+	tenantID := config.AgentTenantID
+	if tenantID == 0 {
+		tenantID, _ = ctx.Value(types.TenantIDContextKey).(uint64)
+	}
+	cfg, err := s.browserConfigService.GetConfig(ctx, tenantID, config.BrowserConfigID)
+	if err != nil {
+		logger.Warnf(ctx, "Failed to load browser config %s: %v", config.BrowserConfigID, err)
+		return nil
+	}
+	if cfg == nil {
+		logger.Warnf(ctx, "Browser config %s not found in tenant %d, browser tools not registered", config.BrowserConfigID, tenantID)
+		return nil
+	}
+	return cfg
+}
+
 // filterSharedAgentWriteTools enforces the read-only contract of AgentShare.
 // These tools write source-workspace Wiki state and otherwise bypass the HTTP
 // KB permission middleware because they execute inside the agent engine.
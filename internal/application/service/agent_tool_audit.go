@@ -0,0 +1,65 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+const (
+	// auditToolErrorMaxLen caps the tool error copied into the audit row;
+	// the full text stays in the message history.
+	auditToolErrorMaxLen = 512
+	// auditTargetIDMaxLen is the width of audit_logs.target_id. MCP tool
+	// names embed the service name and can run past it.
+	auditTargetIDMaxLen = 64
+)
+
+// auditToolExecution is the engine's ToolAuditFunc. The row names the tool
+// and the session, never the arguments or output: those can hold document
+// text and secrets, and already live in the message history under the
+// session's own access control.
+func (s *agentService) auditToolExecution(ctx context.Context, sessionID string, call *types.ToolCall) {
+	tenantID, _ := types.TenantIDFromContext(ctx)
+	if tenantID == 0 || call == nil {
+		return
+	}
+	outcome := types.AuditOutcomeSuccess
+	details := map[string]any{
+		"session_id":   sessionID,
+		"tool_call_id": call.ID,
+		"duration_ms":  call.Duration,
+	}
+	if call.Result == nil || !call.Result.Success {
+		outcome = types.AuditOutcomeFailed
+		if call.Result != nil && call.Result.Error != "" {
+			msg := call.Result.Error
+			if len(msg) > auditToolErrorMaxLen {
+				msg = msg[:auditToolErrorMaxLen]
+			}
+			details["error"] = msg
+		}
+	}
+	actorID, actorRole := types.AuditActorFromContext(ctx)
+	// IM users and embed visitors are not accounts; keep who they were.
+	if p, ok := types.PrincipalFromContext(ctx); ok && p.Type != types.PrincipalWebUser {
+		details["principal"] = p.StorageID()
+	}
+	target := call.Name
+	if len(target) > auditTargetIDMaxLen {
+		details["tool"] = target
+		target = target[:auditTargetIDMaxLen]
+	}
+	raw, _ := json.Marshal(details)
+	_ = s.auditService.Log(context.WithoutCancel(ctx), &types.AuditLog{
+		TenantID:    tenantID,
+		ActorUserID: actorID,
+		ActorRole:   actorRole,
+		Action:      types.AuditActionAgentToolExecuted,
+		TargetType:  "agent_tool",
+		TargetID:    target,
+		Outcome:     outcome,
+		Details:     types.JSON(raw),
+	})
+}
@@ -0,0 +1,38 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+func TestAgentToolAudit_RecordsOutcomeWithoutArguments(t *testing.T) {
+	audit := &captureKBActivityAudit{}
+	s := &agentService{auditService: audit}
+	ctx := context.WithValue(context.Background(), types.TenantIDContextKey, uint64(7))
+	ctx = types.WithTenantAPIKeyScope(ctx, types.TenantAPIKeyScope{KeyID: 12, FullAccess: true})
+
+	s.auditToolExecution(ctx, "sess-1", &types.ToolCall{
+		ID: "call-1", Name: "knowledge_search", Duration: 40,
+		Args:   map[string]any{"query": "salary bands"},
+		Result: &types.ToolResult{Success: false, Error: "timeout"},
+	})
+
+	e := audit.entry
+	if e == nil || e.TenantID != 7 || e.Action != types.AuditActionAgentToolExecuted ||
+		e.TargetID != "knowledge_search" || e.Outcome != types.AuditOutcomeFailed || e.ActorUserID != "api_key:12" {
+		t.Fatalf("unexpected entry %+v", e)
+	}
+	var details map[string]any
+	if err := json.Unmarshal(e.Details, &details); err != nil {
+		t.Fatalf("details: %v", err)
+	}
+	if details["session_id"] != "sess-1" || details["error"] != "timeout" {
+		t.Fatalf("unexpected details %v", details)
+	}
+	if _, leaked := details["query"]; leaked {
+		t.Fatal("tool arguments must not be copied into the audit log")
+	}
+}
@@ -16,12 +16,18 @@
 //     so a stray unreadable file cannot block the assistant reply.
 //   - De-duplication by (SourcePath, ModTime): if a prior message in the
 //     same session already recorded the same (path, mtime), skip it.
+//
+// Besides the sandbox, in-process tools (the data_chart tool) can stage files
+// during a turn with StageArtifact; Collect attaches them first, whether or
+// not the session has a sandbox.
 package service
 
 import (
 	"context"
+	"fmt"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/Tencent/WeKnora/internal/logger"
@@ -69,6 +75,23 @@ type ArtifactCollectorConfig struct {
 // sandbox client learns to stream to disk.
 const defaultMaxArtifactFileBytes int64 = 50 * 1024 * 1024
 
+// Bounds of the staged-artifact buffer. A turn that never completes (client
+// gone, process restart) leaves its files behind, so staged files expire and
+// each session holds only a handful.
+const (
+	maxStagedArtifactsPerSession = 32
+	stagedArtifactTTL            = 2 * time.Hour
+	stagedArtifactSourcePrefix   = "staged://"
+)
+
+// stagedArtifact is a file produced in-process during a turn, waiting for
+// Collect to persist it.
+type stagedArtifact struct {
+	name     string
+	data     []byte
+	stagedAt time.Time
+}
+
 // Resource binding coordinates for collected artifacts. The owner is the
 // assistant message that produced the file (mirrors how knowledge uploads
 // bind to "knowledge" and chat attachments bind to "temporary_document"),
@@ -99,6 +122,9 @@ type ArtifactCollector struct {
 	// fallbackMgr is the deployment-wide SessionBoundManager. Sentinel pins
 	// ("-") resolve to it rather than a per-config manager.
 	fallbackMgr sandbox.Manager
+
+	stagedMu sync.Mutex
+	staged   map[string][]stagedArtifact
 }
 
 // NewArtifactCollector wires up an ArtifactCollector. Callers keep a single
@@ -124,13 +150,12 @@ func NewArtifactCollector(
 // that promotes a sandbox.Manager to SandboxArtifactSource only when the
 // underlying implementation actually supports per-session file inspection
 // (currently *sandbox.SessionBoundManager). For any other backend the
-// returned *ArtifactCollector is nil, which the AgentStreamHandler treats
-// as "no artifacts to attach" — matching the graceful-degradation contract
-// documented in the design spec.
+// collector has no sandbox source and only attaches staged files.
 // The resolver is consulted per turn so a workspace whose own backend supports
-// artifacts still gets them even when the process-wide default does not. The
-// collector is therefore built whenever either side could supply a source, and
-// Collect degrades to "nothing to attach" when neither does.
+// artifacts still gets them even when the process-wide default does not.
+// The collector is built whenever a file service exists, since in-process
+// tools stage artifacts regardless of the sandbox; Collect degrades to
+// "nothing to attach" when there is neither a staged file nor a source.
 func NewArtifactCollectorFromSandboxManager(
 	sandboxMgr sandbox.Manager,
 	sandboxResolver sandbox.TenantSandboxResolver,
@@ -143,9 +168,6 @@ func NewArtifactCollectorFromSandboxManager(
 		return nil
 	}
 	source, _ := sandboxMgr.(SandboxArtifactSource)
-	if source == nil && sandboxResolver == nil {
-		return nil
-	}
 	collector := NewArtifactCollector(
 		source,
 		fileService,
@@ -233,16 +255,32 @@ func (c *ArtifactCollector) Collect(
 		logger.Infof(ctx, "[ArtifactCollector] skipped: collector or dependencies nil (session=%s)", sessionID)
 		return nil, nil
 	}
+	if sessionID == "" {
+		logger.Infof(ctx, "[ArtifactCollector] skipped: empty sessionID")
+		return nil, nil
+	}
+	staged := c.persistStaged(ctx, sessionID, messageID, tenantID)
+	sandboxArtifacts := c.collectSandbox(ctx, sessionID, messageID, tenantID, outputDir)
+	if len(staged) == 0 {
+		return sandboxArtifacts, nil
+	}
+	return append(staged, sandboxArtifacts...), nil
+}
+
+// collectSandbox is the sandbox half of Collect.
+func (c *ArtifactCollector) collectSandbox(
+	ctx context.Context,
+	sessionID string,
+	messageID string,
+	tenantID uint64,
+	outputDir string,
+) types.MessageArtifacts {
 	source := c.sessionSource(ctx, sessionID)
 	if source == nil {
 		logger.Infof(ctx,
 			"[ArtifactCollector] skipped: sandbox backend has no session filesystem (session=%s)",
 			sessionID)
-		return nil, nil
-	}
-	if sessionID == "" {
-		logger.Infof(ctx, "[ArtifactCollector] skipped: empty sessionID")
-		return nil, nil
+		return nil
 	}
 	if outputDir == "" {
 		outputDir = c.config.OutputDir
@@ -251,7 +289,7 @@ func (c *ArtifactCollector) Collect(
 		// Callers should have resolved this via skills.ArtifactOutputDir
 		// but we guard here anyway to keep Collect self-contained.
 		logger.Infof(ctx, "[ArtifactCollector] skipped: empty outputDir (session=%s)", sessionID)
-		return nil, nil
+		return nil
 	}
 
 	logger.Infof(ctx, "[ArtifactCollector] begin session=%s dir=%s", sessionID, outputDir)
@@ -260,7 +298,7 @@ func (c *ArtifactCollector) Collect(
 	if err != nil {
 		logger.Warnf(ctx, "[ArtifactCollector] list sandbox files failed: session=%s dir=%s err=%v",
 			sessionID, outputDir, err)
-		return nil, nil
+		return nil
 	}
 	if len(entries) == 0 {
 		// The most common cause of "download button never appears" is
@@ -269,7 +307,7 @@ func (c *ArtifactCollector) Collect(
 		// (session, dir) pair makes it a 30-second grep to confirm.
 		logger.Infof(ctx, "[ArtifactCollector] no entries under %s (session=%s) — sandbox reaped or skill wrote elsewhere",
 			outputDir, sessionID)
-		return nil, nil
+		return nil
 	}
 	logger.Infof(ctx, "[ArtifactCollector] listed %d entries under %s (session=%s)", len(entries), outputDir, sessionID)
 
@@ -296,7 +334,7 @@ func (c *ArtifactCollector) Collect(
 	}
 	logger.Infof(ctx, "[ArtifactCollector] done session=%s listed=%d attached=%d",
 		sessionID, len(entries), len(artifacts))
-	return artifacts, nil
+	return artifacts
 }
 
 // loadKnownSet returns the (source_path, mod_time) tuples already recorded
@@ -366,20 +404,13 @@ func (c *ArtifactCollector) maybePersist(
 		return types.MessageArtifact{}, false
 	}
 
-	// Give each blob a UUID-namespaced storage name so concurrent turns
-	// cannot collide, and so the storage key itself is unguessable from
-	// the outside (defence-in-depth on top of the /artifacts/:index
-	// endpoint's ownership check).
-	storageName := "artifact_" + uuid.NewString() + "_" + safeFileName(entry.Name)
-	storagePath, err := c.fileService.SaveBytes(ctx, data, tenantID, storageName, false)
+	storagePath, err := c.saveArtifact(ctx, data, tenantID, messageID, entry.Name)
 	if err != nil {
 		logger.Warnf(ctx, "[ArtifactCollector] upload artifact failed: session=%s path=%s err=%v",
 			sessionID, entry.Path, err)
 		return types.MessageArtifact{}, false
 	}
 
-	c.bindArtifactResource(ctx, storagePath, messageID)
-
 	return types.MessageArtifact{
 		URL:        storagePath,
 		FileName:   entry.Name,
@@ -391,6 +422,91 @@ func (c *ArtifactCollector) maybePersist(
 	}, true
 }
 
+// saveArtifact stores one artifact blob and binds it to its message.
+func (c *ArtifactCollector) saveArtifact(ctx context.Context, data []byte, tenantID uint64, messageID, name string) (string, error) {
+	// Give each blob a UUID-namespaced storage name so concurrent turns
+	// cannot collide, and so the storage key itself is unguessable from
+	// the outside (defence-in-depth on top of the /artifacts/:index
+	// endpoint's ownership check).
+	storageName := "artifact_" + uuid.NewString() + "_" + safeFileName(name)
+	storagePath, err := c.fileService.SaveBytes(ctx, data, tenantID, storageName, false)
+	if err != nil {
+		return "", err
+	}
+	c.bindArtifactResource(ctx, storagePath, messageID)
+	return storagePath, nil
+}
+
+// StageArtifact queues a file produced in-process during the current turn of
+// sessionID. It is persisted and attached to the assistant message by the
+// next Collect of the session.
+func (c *ArtifactCollector) StageArtifact(_ context.Context, sessionID, fileName string, data []byte) error {
+	if c == nil || c.fileService == nil {
+		return fmt.Errorf("artifact storage is not available")
+	}
+	if sessionID == "" || fileName == "" {
+		return fmt.Errorf("staged artifact needs a session and a file name")
+	}
+	if int64(len(data)) > c.config.MaxFileBytes {
+		return fmt.Errorf("artifact %s is %d bytes, over the %d byte limit", fileName, len(data), c.config.MaxFileBytes)
+	}
+
+	c.stagedMu.Lock()
+	defer c.stagedMu.Unlock()
+	if c.staged == nil {
+		c.staged = make(map[string][]stagedArtifact)
+	}
+	now := time.Now()
+	for id, files := range c.staged {
+		if len(files) > 0 && now.Sub(files[len(files)-1].stagedAt) > stagedArtifactTTL {
+			delete(c.staged, id)
+		}
+	}
+	if len(c.staged[sessionID]) >= maxStagedArtifactsPerSession {
+		return fmt.Errorf("too many files staged in this turn (limit %d)", maxStagedArtifactsPerSession)
+	}
+	c.staged[sessionID] = append(c.staged[sessionID], stagedArtifact{
+		name:     fileName,
+		data:     append([]byte(nil), data...),
+		stagedAt: now,
+	})
+	return nil
+}
+
+// persistStaged drains the files staged for sessionID into the file service.
+// Like the sandbox path it is best-effort: a failed upload is logged and the
+// file dropped.
+func (c *ArtifactCollector) persistStaged(ctx context.Context, sessionID, messageID string, tenantID uint64) types.MessageArtifacts {
+	c.stagedMu.Lock()
+	files := c.staged[sessionID]
+	delete(c.staged, sessionID)
+	c.stagedMu.Unlock()
+	if len(files) == 0 {
+		return nil
+	}
+
+	artifacts := make(types.MessageArtifacts, 0, len(files))
+	for _, file := range files {
+		storagePath, err := c.saveArtifact(ctx, file.data, tenantID, messageID, file.name)
+		if err != nil {
+			logger.Warnf(ctx, "[ArtifactCollector] upload staged artifact failed: session=%s name=%s err=%v",
+				sessionID, file.name, err)
+			continue
+		}
+		artifacts = append(artifacts, types.MessageArtifact{
+			URL:        storagePath,
+			FileName:   file.name,
+			FileType:   strings.ToLower(filepath.Ext(file.name)),
+			FileSize:   int64(len(file.data)),
+			SourcePath: stagedArtifactSourcePrefix + file.name,
+			ModTime:    file.stagedAt.UTC(),
+			CreatedAt:  time.Now().UTC(),
+		})
+	}
+	logger.Infof(ctx, "[ArtifactCollector] attached %d staged file(s) session=%s", len(artifacts), sessionID)
+	return artifacts
+}
+
 // bindArtifactResource records that the freshly-persisted artifact resource
 // is owned by its assistant message. Best-effort: a binding failure never
 // discards the artifact, because the file is already stored and remains
@@ -501,6 +501,49 @@ func TestArtifactCollector_BindFailureDoesNotDropArtifact(t *testing.T) {
 	}
 }
 
+func TestArtifactCollector_AttachesStagedFilesWithoutSandbox(t *testing.T) {
+	ctx := context.Background()
+	fs := &fakeFileService{}
+	c := NewArtifactCollector(nil, fs, &fakeStore{}, nil, ArtifactCollectorConfig{MaxFileBytes: 1 << 20})
+
+	if err := c.StageArtifact(ctx, "sess-1", "chart.svg", []byte("<svg/>")); err != nil {
+		t.Fatalf("StageArtifact() error = %v", err)
+	}
+	if err := c.StageArtifact(ctx, "sess-1", "big.svg", bytesN(2<<20)); err == nil {
+		t.Fatal("StageArtifact() must reject files over MaxFileBytes")
+	}
+
+	got, err := c.Collect(ctx, "sess-1", "msg-1", 42, "")
+	if err != nil {
+		t.Fatalf("Collect() error = %v", err)
+	}
+	if len(got) != 1 || got[0].FileName != "chart.svg" || got[0].FileType != ".svg" || got[0].FileSize != 6 {
+		t.Fatalf("Collect() = %+v, want the staged chart.svg", got)
+	}
+	if got[0].SourcePath != stagedArtifactSourcePrefix+"chart.svg" {
+		t.Fatalf("SourcePath = %q", got[0].SourcePath)
+	}
+
+	// Staged files are drained once; the next turn starts empty.
+	again, err := c.Collect(ctx, "sess-1", "msg-2", 42, "")
+	if err != nil || len(again) != 0 {
+		t.Fatalf("second Collect() = %+v, %v; want nothing", again, err)
+	}
+}
+
+func TestArtifactCollector_StagedFilesStayInTheirSession(t *testing.T) {
+	ctx := context.Background()
+	c := NewArtifactCollector(nil, &fakeFileService{}, &fakeStore{}, nil, ArtifactCollectorConfig{})
+
+	if err := c.StageArtifact(ctx, "sess-a", "chart.svg", []byte("<svg/>")); err != nil {
+		t.Fatalf("StageArtifact() error = %v", err)
+	}
+	got, err := c.Collect(ctx, "sess-b", "msg-1", 42, "")
+	if err != nil || len(got) != 0 {
+		t.Fatalf("Collect() for another session = %+v, %v; want nothing", got, err)
+	}
+}
+
 // mustParseTime parses an RFC3339 timestamp for test data. It fails the
 // program on error because test fixtures should never contain malformed
 // timestamps.
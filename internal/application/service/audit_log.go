@@ -4,8 +4,10 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strconv"
 	"time"
 
+	"github.com/Tencent/WeKnora/internal/config"
 	"github.com/Tencent/WeKnora/internal/logger"
 	"github.com/Tencent/WeKnora/internal/types"
 	"github.com/Tencent/WeKnora/internal/types/interfaces"
@@ -27,11 +29,18 @@ import (
 type auditLogService struct {
 	repo interfaces.AuditLogRepository
 	now  func() time.Time
+	// exportSinks are the configured export sinks; Purge keeps rows any
+	// of them has not shipped yet.
+	exportSinks []string
 }
 
 // NewAuditLogService constructs the production service.
-func NewAuditLogService(repo interfaces.AuditLogRepository) interfaces.AuditLogService {
-	return &auditLogService{repo: repo, now: time.Now}
+func NewAuditLogService(repo interfaces.AuditLogRepository, cfg *config.Config) interfaces.AuditLogService {
+	var sinks []string
+	if cfg != nil {
+		sinks = cfg.Audit.ExportSinks()
+	}
+	return &auditLogService{repo: repo, now: time.Now, exportSinks: sinks}
 }
 
 // denyDedupWindow caps how often LogDenied will write a durable row
@@ -155,6 +164,10 @@ func (s *auditLogService) List(
 // The cutoff is computed off the service's clock (s.now) so tests
 // can drive deterministic horizons without touching wall time.
 //
+// With export sinks configured, the slowest sink cursor bounds the
+// purge: an unreachable SIEM holds rows back rather than losing them.
+// A sink that has never shipped anything blocks the purge entirely.
+//
 // We intentionally do NOT batch the DELETE: at the volumes audit_logs
 // realistically reaches in a 24h window, a single DELETE-with-index
 // finishes in well under a second on Postgres. If the table ever
@@ -165,6 +178,116 @@ func (s *auditLogService) Purge(ctx context.Context, retentionDays int) (int64,
 	if retentionDays <= 0 {
 		return 0, nil
 	}
+	maxID, err := s.exportHorizon(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if len(s.exportSinks) > 0 && maxID == 0 {
+		logger.Warnf(ctx, "[audit-retention] skipped: export sinks %v have not shipped any rows yet", s.exportSinks)
+		return 0, nil
+	}
 	cutoff := s.now().Add(-time.Duration(retentionDays) * 24 * time.Hour)
-	return s.repo.DeleteOlderThan(ctx, cutoff)
+	return s.repo.DeleteOlderThan(ctx, cutoff, maxID)
+}
+
+// exportHorizon returns the newest row id every export sink has shipped,
+// 0 when a sink has not shipped anything or when no sink is configured.
+func (s *auditLogService) exportHorizon(ctx context.Context) (uint64, error) {
+	if len(s.exportSinks) == 0 {
+		return 0, nil
+	}
+	cursors, err := s.repo.ListSinkCursors(ctx, s.exportSinks)
+	if err != nil {
+		return 0, err
+	}
+	if len(cursors) < len(s.exportSinks) {
+		return 0, nil
+	}
+	horizon := cursors[0].LastID
+	for _, c := range cursors[1:] {
+		if c.LastID < horizon {
+			horizon = c.LastID
+		}
+	}
+	return horizon, nil
+}
+
+// auditRequestPathMaxLen matches the request_path column width.
+const auditRequestPathMaxLen = 512
+
+// LogAPIKeyRead records a data read by an API key. The actor is the key
+// itself ("api_key:<id>"), not the tenant user the key runs as, so reads
+// by different keys stay distinguishable. Unlike LogDenied the raw path
+// is both the dedup key and the persisted request_path: API keys are
+// authenticated, and which document was read is the point of the row.
+func (s *auditLogService) LogAPIKeyRead(ctx context.Context, c *gin.Context, tenantID, keyID uint64) error {
+	if c == nil || c.Request == nil {
+		return nil
+	}
+	actor := types.AuditActorAPIKey(keyID)
+	rawPath := c.Request.URL.Path
+	if len(rawPath) > auditRequestPathMaxLen {
+		rawPath = rawPath[:auditRequestPathMaxLen]
+	}
+	since := s.now().Add(-denyDedupWindow)
+	if n, err := s.repo.CountSinceForDedup(
+		ctx, tenantID, actor, types.AuditActionDataReadByAPIKey, rawPath, since,
+	); err == nil && n > 0 {
+		return nil
+	}
+	details, _ := json.Marshal(map[string]interface{}{
+		"api_key_id": keyID,
+		"route":      c.FullPath(),
+		"status":     c.Writer.Status(),
+	})
+	return s.Log(ctx, &types.AuditLog{
+		TenantID:      tenantID,
+		ActorUserID:   actor,
+		ActorRole:     "api_key",
+		Action:        types.AuditActionDataReadByAPIKey,
+		TargetType:    "api_key",
+		TargetID:      strconv.FormatUint(keyID, 10),
+		RequestPath:   rawPath,
+		RequestMethod: c.Request.Method,
+		Details:       types.JSON(details),
+	})
+}
+
+// auditVerifyPageSize bounds each chain page VerifyChain loads.
+const auditVerifyPageSize = 500
+
+// VerifyChain re-hashes the tenant's chain page by page. Memory stays
+// flat regardless of chain length; the cost is one indexed range scan.
+func (s *auditLogService) VerifyChain(ctx context.Context, tenantID uint64) (*types.AuditChainVerification, error) {
+	head, err := s.repo.GetChainHead(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	v := types.NewAuditChainVerifier(tenantID, head)
+	afterSeq := uint64(0)
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		page, err := s.repo.ListChain(ctx, tenantID, afterSeq, auditVerifyPageSize)
+		if err != nil {
+			return nil, err
+		}
+		ok := true
+		for _, e := range page {
+			if ok = v.Add(e); !ok {
+				break
+			}
+		}
+		if !ok || len(page) < auditVerifyPageSize {
+			break
+		}
+		afterSeq = page[len(page)-1].ChainSeq
+	}
+	result := v.Finish()
+	if result.Unchained, err = s.repo.CountUnchained(ctx, tenantID); err != nil {
+		return nil, err
+	}
+	result.VerifiedAt = s.now()
+	return &result, nil
 }
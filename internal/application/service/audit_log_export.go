@@ -0,0 +1,180 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/application/service/auditsink"
+	"github.com/Tencent/WeKnora/internal/config"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"github.com/google/uuid"
+)
+
+const (
+	// auditExportPollInterval is how often an idle sink checks for new rows.
+	auditExportPollInterval = 5 * time.Second
+	// auditExportSettleDelay holds back rows younger than this. ids are
+	// allocated before commit, so a concurrent insert can surface below
+	// the cursor after it has moved on; the delay makes that vanishingly
+	// unlikely at the cost of a few seconds of export lag.
+	auditExportSettleDelay = 10 * time.Second
+	// auditExportLeaseTTL is how long a replica owns a sink without
+	// renewing. A crashed replica's sink is picked up after this.
+	auditExportLeaseTTL = time.Minute
+	// auditExportAttemptTimeout bounds one read-send-advance round.
+	auditExportAttemptTimeout = 45 * time.Second
+	// auditExportBackoffMin / Max bound the retry delay of a failing sink.
+	auditExportBackoffMin = 2 * time.Second
+	auditExportBackoffMax = 5 * time.Minute
+)
+
+// AuditLogExportRunner streams audit_logs to the configured sinks. Each
+// sink has its own goroutine and database cursor, so a slow or broken
+// collector never delays the others.
+//
+// Back-pressure is the database itself: a sink only reads the next batch
+// after the previous one was accepted, nothing is buffered in memory, and
+// retention (AuditLogService.Purge) keeps every row a sink has not
+// shipped. A failing batch is retried with exponential backoff, forever.
+//
+// In a multi-replica deployment every replica runs the runner, and the
+// lease on the sink's cursor row lets exactly one of them ship at a time.
+type AuditLogExportRunner struct {
+	repo      interfaces.AuditLogRepository
+	sinks     []auditsink.Sink
+	batchSize int
+	owner     string
+	now       func() time.Time
+
+	startOnce sync.Once
+	stopOnce  sync.Once
+	stopCh    chan struct{}
+	wg        sync.WaitGroup
+	started   atomic.Bool
+}
+
+// NewAuditLogExportRunner builds the sinks enabled in cfg.Audit.Export.
+// Nothing is sent until Start.
+func NewAuditLogExportRunner(
+	cfg *config.Config, repo interfaces.AuditLogRepository, fileService interfaces.FileService,
+) (*AuditLogExportRunner, error) {
+	r := &AuditLogExportRunner{
+		repo:   repo,
+		now:    time.Now,
+		stopCh: make(chan struct{}),
+	}
+	if cfg == nil || cfg.Audit == nil || cfg.Audit.Export == nil {
+		return r, nil
+	}
+	sinks, err := auditsink.FromConfig(cfg.Audit, fileService)
+	if err != nil {
+		return nil, err
+	}
+	hostname, _ := os.Hostname()
+	r.sinks = sinks
+	r.batchSize = cfg.Audit.Export.BatchSize
+	r.owner = fmt.Sprintf("%s/%d/%s", hostname, os.Getpid(), uuid.NewString()[:8])
+	return r, nil
+}
+
+// Start launches one goroutine per sink. Idempotent; a runner without
+// sinks stays dormant.
+func (r *AuditLogExportRunner) Start(ctx context.Context) {
+	if r == nil || len(r.sinks) == 0 {
+		return
+	}
+	r.startOnce.Do(func() {
+		r.started.Store(true)
+		for _, s := range r.sinks {
+			logger.Infof(ctx, "[audit-export] starting sink %s batch_size=%d", s.Name(), r.batchSize)
+			r.wg.Add(1)
+			go r.loop(s)
+		}
+	})
+}
+
+// Stop ends every sink loop, waiting for an in-flight batch, and closes
+// the sinks. Idempotent.
+func (r *AuditLogExportRunner) Stop() {
+	if r == nil || !r.started.Load() {
+		return
+	}
+	r.stopOnce.Do(func() {
+		close(r.stopCh)
+		r.wg.Wait()
+		for _, s := range r.sinks {
+			_ = s.Close()
+		}
+	})
+}
+
+func (r *AuditLogExportRunner) loop(s auditsink.Sink) {
+	defer r.wg.Done()
+	var backoff time.Duration
+	for {
+		wait := auditExportPollInterval
+		shipped, err := r.shipOnce(s)
+		switch {
+		case err != nil:
+			backoff = nextAuditExportBackoff(backoff)
+			wait = backoff
+			logger.Warnf(context.Background(), "[audit-export] sink %s failed, retrying in %s: %v",
+				s.Name(), backoff, err)
+		case shipped:
+			// Drain the backlog without waiting.
+			backoff, wait = 0, 0
+		default:
+			backoff = 0
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-r.stopCh:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// shipOnce sends the next batch to s. It reports whether a batch was
+// delivered; false with a nil error means there was nothing to do or
+// another replica holds the lease.
+func (r *AuditLogExportRunner) shipOnce(s auditsink.Sink) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), auditExportAttemptTimeout)
+	defer cancel()
+
+	cursor, ok, err := r.repo.AcquireSinkLease(ctx, s.Name(), r.owner, r.now().Add(auditExportLeaseTTL))
+	if err != nil || !ok {
+		return false, err
+	}
+	batch, err := r.repo.ListForExport(ctx, cursor.LastID, r.now().Add(-auditExportSettleDelay), r.batchSize)
+	if err != nil || len(batch) == 0 {
+		return false, err
+	}
+	if err := s.Send(ctx, batch); err != nil {
+		// Keep the cursor, record why it is stuck.
+		_ = r.repo.UpdateSinkCursor(ctx, s.Name(), r.owner, cursor.LastID, err.Error())
+		return false, err
+	}
+	if err := r.repo.UpdateSinkCursor(ctx, s.Name(), r.owner, batch[len(batch)-1].ID, ""); err != nil {
+		// The batch went out but the cursor did not move; the next owner
+		// re-sends it, which the at-least-once contract allows.
+		return false, err
+	}
+	return true, nil
+}
+
+func nextAuditExportBackoff(prev time.Duration) time.Duration {
+	if prev < auditExportBackoffMin {
+		return auditExportBackoffMin
+	}
+	if next := prev * 2; next < auditExportBackoffMax {
+		return next
+	}
+	return auditExportBackoffMax
+}
@@ -0,0 +1,207 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+// stubExportRepo serves a fixed list of rows and one sink cursor.
+type stubExportRepo struct {
+	interfaces.AuditLogRepository
+
+	mu       sync.Mutex
+	rows     []*types.AuditLog
+	cursor   types.AuditLogSinkCursor
+	leaseErr error
+	leased   bool
+}
+
+func (s *stubExportRepo) AcquireSinkLease(
+	_ context.Context, sink, owner string, until time.Time,
+) (*types.AuditLogSinkCursor, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.leaseErr != nil {
+		return nil, false, s.leaseErr
+	}
+	if s.cursor.LeaseOwner != "" && s.cursor.LeaseOwner != owner {
+		c := s.cursor
+		return &c, false, nil
+	}
+	s.cursor.Sink, s.cursor.LeaseOwner, s.cursor.LeaseUntil = sink, owner, until
+	s.leased = true
+	c := s.cursor
+	return &c, true, nil
+}
+
+func (s *stubExportRepo) ListForExport(
+	_ context.Context, afterID uint64, before time.Time, limit int,
+) ([]*types.AuditLog, error) {
+	var out []*types.AuditLog
+	for _, r := range s.rows {
+		if r.ID > afterID && r.CreatedAt.Before(before) && len(out) < limit {
+			out = append(out, r)
+		}
+	}
+	return out, nil
+}
+
+func (s *stubExportRepo) UpdateSinkCursor(_ context.Context, _, owner string, lastID uint64, lastError string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cursor.LeaseOwner != owner {
+		return errors.New("lease lost")
+	}
+	s.cursor.LastID, s.cursor.LastError = lastID, lastError
+	return nil
+}
+
+type flakySink struct {
+	failures int
+	sent     [][]uint64
+}
+
+func (f *flakySink) Name() string { return types.AuditSinkWebhook }
+func (f *flakySink) Close() error { return nil }
+func (f *flakySink) Send(_ context.Context, entries []*types.AuditLog) error {
+	if f.failures > 0 {
+		f.failures--
+		return errors.New("collector unavailable")
+	}
+	ids := make([]uint64, 0, len(entries))
+	for _, e := range entries {
+		ids = append(ids, e.ID)
+	}
+	f.sent = append(f.sent, ids)
+	return nil
+}
+
+func newExportRunnerForTest(repo *stubExportRepo, now time.Time) *AuditLogExportRunner {
+	return &AuditLogExportRunner{
+		repo:      repo,
+		batchSize: 2,
+		owner:     "replica-a",
+		now:       func() time.Time { return now },
+		stopCh:    make(chan struct{}),
+	}
+}
+
+func TestAuditLogExport_ShipOnceRetriesFromCursorAfterFailure(t *testing.T) {
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	old := now.Add(-time.Minute)
+	repo := &stubExportRepo{rows: []*types.AuditLog{
+		{ID: 1, CreatedAt: old}, {ID: 2, CreatedAt: old}, {ID: 3, CreatedAt: old},
+		// Too recent: may still have a concurrent insert below it.
+		{ID: 4, CreatedAt: now},
+	}}
+	r := newExportRunnerForTest(repo, now)
+	sink := &flakySink{failures: 1}
+
+	if shipped, err := r.shipOnce(sink); err == nil || shipped {
+		t.Fatal("a failed send must be reported")
+	}
+	if repo.cursor.LastID != 0 || repo.cursor.LastError != "collector unavailable" {
+		t.Fatalf("failed batch must not move the cursor: %+v", repo.cursor)
+	}
+
+	for i := 0; i < 3; i++ {
+		if _, err := r.shipOnce(sink); err != nil {
+			t.Fatalf("shipOnce: %v", err)
+		}
+	}
+	if len(sink.sent) != 2 || sink.sent[0][0] != 1 || sink.sent[1][0] != 3 || len(sink.sent[1]) != 1 {
+		t.Fatalf("unexpected batches %v", sink.sent)
+	}
+	if repo.cursor.LastID != 3 || repo.cursor.LastError != "" {
+		t.Fatalf("cursor = %+v, want last_id 3", repo.cursor)
+	}
+}
+
+func TestAuditLogExport_SkipsSinkLeasedByAnotherReplica(t *testing.T) {
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	repo := &stubExportRepo{
+		rows:   []*types.AuditLog{{ID: 1, CreatedAt: now.Add(-time.Minute)}},
+		cursor: types.AuditLogSinkCursor{LeaseOwner: "replica-b"},
+	}
+	sink := &flakySink{}
+	shipped, err := newExportRunnerForTest(repo, now).shipOnce(sink)
+	if err != nil || shipped || len(sink.sent) != 0 {
+		t.Fatalf("only the lease holder may ship: shipped=%v err=%v sent=%v", shipped, err, sink.sent)
+	}
+}
+
+func TestNextAuditExportBackoff(t *testing.T) {
+	var got []time.Duration
+	var b time.Duration
+	for i := 0; i < 10; i++ {
+		b = nextAuditExportBackoff(b)
+		got = append(got, b)
+	}
+	if got[0] != auditExportBackoffMin || got[1] != 2*auditExportBackoffMin || got[9] != auditExportBackoffMax {
+		t.Fatalf("backoff sequence %v", got)
+	}
+}
+
+// stubChainRepo serves a chain for VerifyChain.
+type stubChainRepo struct {
+	interfaces.AuditLogRepository
+	head      *types.AuditLogChainHead
+	chain     []*types.AuditLog
+	unchained int64
+	pageCalls int
+}
+
+func (s *stubChainRepo) GetChainHead(context.Context, uint64) (*types.AuditLogChainHead, error) {
+	return s.head, nil
+}
+
+func (s *stubChainRepo) ListChain(_ context.Context, _ uint64, afterSeq uint64, limit int) ([]*types.AuditLog, error) {
+	s.pageCalls++
+	var out []*types.AuditLog
+	for _, e := range s.chain {
+		if e.ChainSeq > afterSeq && len(out) < limit {
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
+
+func (s *stubChainRepo) CountUnchained(context.Context, uint64) (int64, error) {
+	return s.unchained, nil
+}
+
+func TestAuditLog_VerifyChain_PagesThroughChain(t *testing.T) {
+	repo := &stubChainRepo{unchained: 4}
+	prev := ""
+	base := time.Date(2026, 10, 1, 8, 0, 0, 0, time.UTC)
+	n := auditVerifyPageSize + 3
+	for i := 1; i <= n; i++ {
+		e := &types.AuditLog{ID: uint64(i), TenantID: 7, Action: types.AuditActionTagCreated,
+			Outcome: types.AuditOutcomeSuccess, CreatedAt: base, ChainSeq: uint64(i), PrevHash: prev}
+		e.Hash = e.ComputeHash()
+		prev = e.Hash
+		repo.chain = append(repo.chain, e)
+	}
+	repo.head = &types.AuditLogChainHead{TenantID: 7, LastSeq: uint64(n), LastHash: prev}
+	svc, _, _ := newSvcForTest()
+	svc.repo = repo
+
+	got, err := svc.VerifyChain(context.Background(), 7)
+	if err != nil {
+		t.Fatalf("VerifyChain: %v", err)
+	}
+	if !got.Valid || got.Checked != uint64(n) || got.Unchained != 4 || repo.pageCalls != 2 || got.VerifiedAt.IsZero() {
+		t.Fatalf("result %+v after %d pages", got, repo.pageCalls)
+	}
+
+	repo.chain[auditVerifyPageSize+1].TargetID = "edited"
+	if got, _ := svc.VerifyChain(context.Background(), 7); got.Valid || got.BrokenAtSeq != uint64(auditVerifyPageSize+2) {
+		t.Fatalf("tampering on the second page must be found: %+v", got)
+	}
+}
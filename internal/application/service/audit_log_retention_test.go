@@ -21,20 +21,35 @@ type stubAuditRepoForRetention struct {
 
 	mu          sync.Mutex
 	calls       []time.Time
+	maxIDs      []uint64
 	deleted     int64
 	deleteError error
+	cursors     []*types.AuditLogSinkCursor
 }
 
-func (s *stubAuditRepoForRetention) DeleteOlderThan(_ context.Context, cutoff time.Time) (int64, error) {
+func (s *stubAuditRepoForRetention) DeleteOlderThan(_ context.Context, cutoff time.Time, maxID uint64) (int64, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	s.calls = append(s.calls, cutoff)
+	s.maxIDs = append(s.maxIDs, maxID)
 	if s.deleteError != nil {
 		return 0, s.deleteError
 	}
 	return s.deleted, nil
 }
 
+func (s *stubAuditRepoForRetention) ListSinkCursors(_ context.Context, sinks []string) ([]*types.AuditLogSinkCursor, error) {
+	var out []*types.AuditLogSinkCursor
+	for _, c := range s.cursors {
+		for _, name := range sinks {
+			if c.Sink == name {
+				out = append(out, c)
+			}
+		}
+	}
+	return out, nil
+}
+
 func TestAuditLog_Purge_NoOpWhenRetentionDisabled(t *testing.T) {
 	// retention_days <= 0 must short-circuit before hitting the repo.
 	// Otherwise an "off" config would still issue a daily DELETE on
@@ -83,6 +98,35 @@ func TestAuditLog_Purge_UsesClockMinusRetention(t *testing.T) {
 	}
 }
 
+func TestAuditLog_Purge_KeepsRowsExportSinksHaveNotShipped(t *testing.T) {
+	// A down SIEM must hold rows back, not lose them: the purge is
+	// bounded by the slowest sink, and skipped while one has shipped
+	// nothing at all.
+	repo := &stubAuditRepoForRetention{cursors: []*types.AuditLogSinkCursor{
+		{Sink: types.AuditSinkWebhook, LastID: 900},
+	}}
+	clock := &fakeClock{t: time.Date(2026, 5, 14, 10, 0, 0, 0, time.UTC)}
+	svc := &auditLogService{
+		repo: repo, now: clock.Now,
+		exportSinks: []string{types.AuditSinkWebhook, types.AuditSinkSyslog},
+	}
+
+	if _, err := svc.Purge(context.Background(), 30); err != nil {
+		t.Fatalf("Purge: %v", err)
+	}
+	if len(repo.calls) != 0 {
+		t.Fatal("a sink without a cursor must block the purge")
+	}
+
+	repo.cursors = append(repo.cursors, &types.AuditLogSinkCursor{Sink: types.AuditSinkSyslog, LastID: 120})
+	if _, err := svc.Purge(context.Background(), 30); err != nil {
+		t.Fatalf("Purge: %v", err)
+	}
+	if len(repo.maxIDs) != 1 || repo.maxIDs[0] != 120 {
+		t.Fatalf("purge must stop at the slowest sink cursor, got %v", repo.maxIDs)
+	}
+}
+
 func TestAuditLog_Purge_PropagatesRepoError(t *testing.T) {
 	// Retention failures must surface to the runner, which logs them
 	// at WARN. Silently swallowing the error would mask a degraded DB
@@ -173,3 +173,29 @@ func TestAuditLog_LogDenied_DegradesGracefullyOnDedupLookupError(t *testing.T) {
 		t.Fatalf("expected fallthrough write on dedup error, got %d entries", len(repo.created))
 	}
 }
+
+func TestAuditLog_LogAPIKeyRead_RecordsKeyAndResourcePerMinute(t *testing.T) {
+	// The key, not the tenant user it runs as, is the actor, and the raw
+	// path is kept so the row names the document that was read. Repeat
+	// reads of one resource within the window collapse; other resources
+	// and other keys still record.
+	svc, repo, _ := newSvcForTest()
+	doc1 := newDeniedCtx(t, "GET", "/api/v1/knowledge/doc-1")
+	doc2 := newDeniedCtx(t, "GET", "/api/v1/knowledge/doc-2")
+
+	_ = svc.LogAPIKeyRead(context.Background(), doc1, 7, 99)
+	_ = svc.LogAPIKeyRead(context.Background(), doc1, 7, 99)
+	_ = svc.LogAPIKeyRead(context.Background(), doc2, 7, 99)
+	_ = svc.LogAPIKeyRead(context.Background(), doc1, 7, 100)
+
+	if len(repo.created) != 3 {
+		t.Fatalf("expected 3 writes, got %d", len(repo.created))
+	}
+	first := repo.created[0]
+	if first.Action != types.AuditActionDataReadByAPIKey ||
+		first.ActorUserID != "api_key:99" ||
+		first.RequestPath != "/api/v1/knowledge/doc-1" ||
+		first.TargetID != "99" {
+		t.Fatalf("unexpected entry: %+v", first)
+	}
+}
@@ -0,0 +1,55 @@
+package auditsink
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+// objectStorageTenantID owns the exported objects. Audit export is
+// platform-wide, so it lives under the system tenant's exports prefix
+// rather than any workspace's.
+const objectStorageTenantID = 0
+
+// objectStorageSink writes each batch as one JSON-lines object through the
+// configured file storage (local, MinIO, COS, S3-compatible, ...).
+type objectStorageSink struct {
+	files interfaces.FileService
+}
+
+// NewObjectStorage returns the object storage sink.
+func NewObjectStorage(files interfaces.FileService) Sink {
+	return &objectStorageSink{files: files}
+}
+
+func (s *objectStorageSink) Name() string { return types.AuditSinkObjectStorage }
+
+// Send names the object after the row id range it holds, zero-padded so
+// objects sort in export order.
+func (s *objectStorageSink) Send(ctx context.Context, entries []*types.AuditLog) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	var buf bytes.Buffer
+	for _, e := range entries {
+		raw, err := encodeEntry(e)
+		if err != nil {
+			return err
+		}
+		buf.Write(raw)
+		buf.WriteByte('\n')
+	}
+	name := fmt.Sprintf("audit-%020d-%020d.jsonl", entries[0].ID, entries[len(entries)-1].ID)
+	path, err := s.files.SaveBytes(ctx, buf.Bytes(), objectStorageTenantID, name, false)
+	if err != nil {
+		return fmt.Errorf("audit object storage: %w", err)
+	}
+	logger.Debugf(ctx, "[audit-export] wrote %d rows to %s", len(entries), path)
+	return nil
+}
+
+func (s *objectStorageSink) Close() error { return nil }
@@ -0,0 +1,52 @@
+// Package auditsink ships audit log rows to external collectors: a syslog
+// server (RFC 5424), an HTTP webhook, and JSON-lines objects in the
+// configured file storage. Sinks are stateless; ordering, retries and the
+// shipped-up-to cursor are owned by the export runner in the service
+// package.
+package auditsink
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/Tencent/WeKnora/internal/config"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+// Sink delivers a batch of audit rows. Send must either deliver the whole
+// batch or return an error; the runner then retries the same batch, so
+// receivers see every row at least once and should de-duplicate on id.
+type Sink interface {
+	// Name identifies the sink and keys its export cursor.
+	Name() string
+	Send(ctx context.Context, entries []*types.AuditLog) error
+	Close() error
+}
+
+// FromConfig builds the sinks enabled in cfg, in config.AuditConfig
+// ExportSinks order. fileService is only needed for the object storage sink.
+func FromConfig(cfg *config.AuditConfig, fileService interfaces.FileService) ([]Sink, error) {
+	var sinks []Sink
+	for _, name := range cfg.ExportSinks() {
+		switch name {
+		case types.AuditSinkSyslog:
+			sinks = append(sinks, NewSyslog(cfg.Export.Syslog))
+		case types.AuditSinkWebhook:
+			sinks = append(sinks, NewWebhook(cfg.Export.Webhook))
+		case types.AuditSinkObjectStorage:
+			if fileService == nil {
+				return nil, fmt.Errorf("auditsink: object storage sink needs a file service")
+			}
+			sinks = append(sinks, NewObjectStorage(fileService))
+		}
+	}
+	return sinks, nil
+}
+
+// encodeEntry is the wire form of one row, shared by every sink so a SIEM
+// sees the same fields whichever transport delivered them.
+func encodeEntry(e *types.AuditLog) ([]byte, error) {
+	return json.Marshal(e)
+}
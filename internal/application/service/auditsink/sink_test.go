@@ -0,0 +1,165 @@
+package auditsink
+
+import (
+	"bufio"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/config"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+func testEntries() []*types.AuditLog {
+	at := time.Date(2026, 10, 1, 8, 0, 0, 123456000, time.UTC)
+	return []*types.AuditLog{
+		{ID: 41, TenantID: 7, ActorUserID: "u1", Action: types.AuditActionKnowledgeDownloaded,
+			Outcome: types.AuditOutcomeSuccess, CreatedAt: at, ChainSeq: 3, Hash: "abc"},
+		{ID: 42, TenantID: 7, ActorUserID: `odd"actor]`, Action: types.AuditActionAccessDenied,
+			Outcome: types.AuditOutcomeDenied, CreatedAt: at, ChainSeq: 4, Hash: "def"},
+	}
+}
+
+func TestSyslogSink_TCPOctetCountedRFC5424(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer ln.Close()
+	received := make(chan []string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		var msgs []string
+		for len(msgs) < 2 {
+			lenField, err := r.ReadString(' ')
+			if err != nil {
+				break
+			}
+			n, _ := strconv.Atoi(strings.TrimSpace(lenField))
+			buf := make([]byte, n)
+			if _, err := io.ReadFull(r, buf); err != nil {
+				break
+			}
+			msgs = append(msgs, string(buf))
+		}
+		received <- msgs
+	}()
+
+	sink := NewSyslog(config.AuditSyslogSinkConfig{Network: "tcp", Address: ln.Addr().String()})
+	defer sink.Close()
+	if err := sink.Send(context.Background(), testEntries()); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	var msgs []string
+	select {
+	case msgs = <-received:
+	case <-time.After(5 * time.Second):
+		t.Fatal("collector received nothing")
+	}
+	if len(msgs) != 2 {
+		t.Fatalf("got %d framed messages, want 2", len(msgs))
+	}
+	// facility 13 (log audit): notice for success, warning for denied.
+	if !strings.HasPrefix(msgs[0], "<109>1 2026-10-01T08:00:00.123456Z ") ||
+		!strings.Contains(msgs[0], " weknora ") ||
+		!strings.Contains(msgs[0], " knowledge.downloaded [weknora@32473 id=\"41\" tenant_id=\"7\" chain_seq=\"3\" hash=\"abc\"") {
+		t.Fatalf("unexpected header: %q", msgs[0])
+	}
+	if !strings.HasPrefix(msgs[1], "<108>1 ") || !strings.Contains(msgs[1], `actor="odd\"actor\]"`) {
+		t.Fatalf("denied row must be a warning with escaped SD params: %q", msgs[1])
+	}
+	body := msgs[0][strings.Index(msgs[0], "\ufeff")+len("\ufeff"):]
+	var decoded types.AuditLog
+	if err := json.Unmarshal([]byte(body), &decoded); err != nil || decoded.ID != 41 || decoded.Hash != "abc" {
+		t.Fatalf("message body must be the row as JSON: %v %+v", err, decoded)
+	}
+}
+
+func TestWebhookSink_SignsBatchAndFailsOnNon2xx(t *testing.T) {
+	const secret = "s3cret"
+	status := http.StatusOK
+	var gotBody []byte
+	var gotSig, gotBatch string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotBody, _ = io.ReadAll(r.Body)
+		gotSig = r.Header.Get("X-WeKnora-Signature")
+		gotBatch = r.Header.Get("X-WeKnora-Batch-ID")
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	sink := NewWebhook(config.AuditWebhookSinkConfig{URL: srv.URL, Secret: secret})
+	if err := sink.Send(context.Background(), testEntries()); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(gotBody)
+	if gotSig != "sha256="+hex.EncodeToString(mac.Sum(nil)) {
+		t.Fatalf("signature %q does not match body", gotSig)
+	}
+	var batch webhookBatch
+	if err := json.Unmarshal(gotBody, &batch); err != nil || len(batch.Events) != 2 || batch.BatchID != "41-42" || gotBatch != "41-42" {
+		t.Fatalf("unexpected batch %+v (header %q): %v", batch, gotBatch, err)
+	}
+
+	status = http.StatusServiceUnavailable
+	if err := sink.Send(context.Background(), testEntries()); err == nil {
+		t.Fatal("a non-2xx response must be reported so the batch is retried")
+	}
+}
+
+type recordingFileService struct {
+	interfaces.FileService
+	tenantID uint64
+	name     string
+	data     []byte
+}
+
+func (f *recordingFileService) SaveBytes(_ context.Context, data []byte, tenantID uint64, name string, _ bool) (string, error) {
+	f.tenantID, f.name, f.data = tenantID, name, data
+	return "local://0/exports/" + name, nil
+}
+
+func TestObjectStorageSink_WritesJSONLines(t *testing.T) {
+	files := &recordingFileService{}
+	if err := NewObjectStorage(files).Send(context.Background(), testEntries()); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if files.tenantID != 0 || files.name != "audit-00000000000000000041-00000000000000000042.jsonl" {
+		t.Fatalf("unexpected object %d/%s", files.tenantID, files.name)
+	}
+	lines := strings.Split(strings.TrimSuffix(string(files.data), "\n"), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[1], `"id":42`) {
+		t.Fatalf("want one JSON row per line, got %q", files.data)
+	}
+}
+
+func TestFromConfig(t *testing.T) {
+	cfg := &config.AuditConfig{Export: &config.AuditExportConfig{
+		Syslog:        config.AuditSyslogSinkConfig{Address: "127.0.0.1:514"},
+		ObjectStorage: config.AuditObjectStorageSinkConfig{Enabled: true},
+	}}
+	if _, err := FromConfig(cfg, nil); err == nil {
+		t.Fatal("object storage without a file service must be rejected")
+	}
+	sinks, err := FromConfig(cfg, &recordingFileService{})
+	if err != nil || len(sinks) != 2 || sinks[0].Name() != types.AuditSinkSyslog || sinks[1].Name() != types.AuditSinkObjectStorage {
+		t.Fatalf("FromConfig = %v, %v", sinks, err)
+	}
+}
@@ -0,0 +1,100 @@
+package auditsink
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/config"
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// webhookTimeout bounds one delivery attempt.
+const webhookTimeout = 30 * time.Second
+
+// webhookSink POSTs each batch as one JSON document. The URL comes from
+// operator configuration, not from tenants, so it may point at a private
+// collector and is not subject to the SSRF guard embed webhooks use.
+type webhookSink struct {
+	url    string
+	secret string
+	client *http.Client
+}
+
+// NewWebhook returns the webhook sink for cfg.
+func NewWebhook(cfg config.AuditWebhookSinkConfig) Sink {
+	return &webhookSink{
+		url:    strings.TrimSpace(cfg.URL),
+		secret: strings.TrimSpace(cfg.Secret),
+		client: &http.Client{Timeout: webhookTimeout},
+	}
+}
+
+func (s *webhookSink) Name() string { return types.AuditSinkWebhook }
+
+// webhookBatch is the request body. BatchID (first-last row id) is stable
+// across retries of the same batch and doubles as an idempotency key.
+type webhookBatch struct {
+	Type    string            `json:"type"`
+	BatchID string            `json:"batch_id"`
+	SentAt  string            `json:"sent_at"`
+	Events  []json.RawMessage `json:"events"`
+}
+
+func (s *webhookSink) Send(ctx context.Context, entries []*types.AuditLog) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	batch := webhookBatch{
+		Type:    "audit_log.batch",
+		BatchID: fmt.Sprintf("%d-%d", entries[0].ID, entries[len(entries)-1].ID),
+		SentAt:  time.Now().UTC().Format(time.RFC3339),
+		Events:  make([]json.RawMessage, 0, len(entries)),
+	}
+	for _, e := range entries {
+		raw, err := encodeEntry(e)
+		if err != nil {
+			return err
+		}
+		batch.Events = append(batch.Events, raw)
+	}
+	body, err := json.Marshal(batch)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "WeKnora-Audit-Export/1.0")
+	req.Header.Set("X-WeKnora-Batch-ID", batch.BatchID)
+	if s.secret != "" {
+		mac := hmac.New(sha256.New, []byte(s.secret))
+		_, _ = mac.Write(body)
+		req.Header.Set("X-WeKnora-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("audit webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("audit webhook: HTTP %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func (s *webhookSink) Close() error {
+	s.client.CloseIdleConnections()
+	return nil
+}
@@ -0,0 +1,167 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Tencent/WeKnora/internal/infrastructure/browser"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+// browserConfigService implements interfaces.BrowserConfigService
+type browserConfigService struct {
+	repo     interfaces.BrowserConfigRepository
+	sessions *browser.Sessions
+}
+
+// NewBrowserConfigService creates a new browser config service. Open agent
+// pages are shared with the browser tools so that deleting a config can
+// close them.
+func NewBrowserConfigService(
+	repo interfaces.BrowserConfigRepository,
+	sessions *browser.Sessions,
+) interfaces.BrowserConfigService {
+	return &browserConfigService{repo: repo, sessions: sessions}
+}
+
+// CreateConfig validates and stores a new config.
+func (s *browserConfigService) CreateConfig(ctx context.Context, cfg *types.BrowserConfig) error {
+	if cfg.TenantID == 0 {
+		return fmt.Errorf("tenant ID is required")
+	}
+	if err := validateBrowserConfig(cfg); err != nil {
+		return err
+	}
+	logger.Infof(ctx, "Creating browser config: tenant=%d, name=%s", cfg.TenantID, cfg.Name)
+	return s.repo.Create(ctx, cfg)
+}
+
+// UpdateConfig validates and updates an existing config. Pages opened with
+// the previous settings are replaced on their next use.
+func (s *browserConfigService) UpdateConfig(ctx context.Context, cfg *types.BrowserConfig) error {
+	if cfg.TenantID == 0 {
+		return fmt.Errorf("tenant ID is required")
+	}
+	if err := validateBrowserConfig(cfg); err != nil {
+		return err
+	}
+	logger.Infof(ctx, "Updating browser config: tenant=%d, id=%s", cfg.TenantID, cfg.ID)
+	return s.repo.Update(ctx, cfg)
+}
+
+// DeleteConfig deletes a config by tenant + id.
+func (s *browserConfigService) DeleteConfig(ctx context.Context, tenantID uint64, id string) error {
+	logger.Infof(ctx, "Deleting browser config: tenant=%d, id=%s", tenantID, id)
+	if err := s.repo.Delete(ctx, tenantID, id); err != nil {
+		return err
+	}
+	s.sessions.CloseConfig(id)
+	return nil
+}
+
+// GetConfig returns a config by tenant + id; nil when absent.
+func (s *browserConfigService) GetConfig(ctx context.Context, tenantID uint64, id string) (*types.BrowserConfig, error) {
+	return s.repo.GetByID(ctx, tenantID, id)
+}
+
+// ListConfigs lists all configs of a tenant.
+func (s *browserConfigService) ListConfigs(ctx context.Context, tenantID uint64) ([]*types.BrowserConfig, error) {
+	return s.repo.List(ctx, tenantID)
+}
+
+// UpdateConfigCredentials writes the token credential.
+func (s *browserConfigService) UpdateConfigCredentials(
+	ctx context.Context, tenantID uint64, id string, token *string,
+) (*types.BrowserConfig, error) {
+	existing, err := s.repo.GetByID(ctx, tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+	if existing == nil {
+		return nil, fmt.Errorf("browser config not found")
+	}
+
+	if token != nil && *token != "" && *token != existing.Parameters.Token {
+		existing.Parameters.Token = *token
+		if err := s.repo.Update(ctx, existing); err != nil {
+			return nil, err
+		}
+		logger.Infof(ctx, "Browser config credentials updated: tenant=%d id=%s", tenantID, id)
+	}
+	return existing, nil
+}
+
+// ClearConfigCredential clears the token credential. Idempotent.
+func (s *browserConfigService) ClearConfigCredential(
+	ctx context.Context, tenantID uint64, id, field string,
+) error {
+	if field != "token" {
+		return fmt.Errorf("unknown credential field: %s", field)
+	}
+	existing, err := s.repo.GetByID(ctx, tenantID, id)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return fmt.Errorf("browser config not found")
+	}
+	if existing.Parameters.Token == "" {
+		return nil
+	}
+	existing.Parameters.Token = ""
+	if err := s.repo.Update(ctx, existing); err != nil {
+		return err
+	}
+	logger.Infof(ctx, "Browser config credential cleared by user: tenant=%d id=%s field=%s", tenantID, id, field)
+	return nil
+}
+
+// TestConfig connects to the browser, opens a blank page and closes it.
+func (s *browserConfigService) TestConfig(ctx context.Context, cfg *types.BrowserConfig) error {
+	if err := validateBrowserConfig(cfg); err != nil {
+		return err
+	}
+	page, err := browser.OpenPage(ctx, browser.OptionsFromConfig(cfg))
+	if err != nil {
+		return err
+	}
+	defer page.Close()
+	_, err = page.State(ctx)
+	return err
+}
+
+// validateBrowserConfig checks the endpoint against the outbound guard and
+// the options against their bounds.
+func validateBrowserConfig(cfg *types.BrowserConfig) error {
+	if cfg == nil {
+		return fmt.Errorf("browser config is required")
+	}
+	if strings.TrimSpace(cfg.Name) == "" {
+		return fmt.Errorf("name is required")
+	}
+	if strings.TrimSpace(cfg.Parameters.EndpointURL) == "" {
+		return fmt.Errorf("endpoint_url is required")
+	}
+	if err := browser.OptionsFromConfig(cfg).Endpoint.Validate(); err != nil {
+		return err
+	}
+	opts := cfg.Options
+	if opts.TimeoutSec < 0 || opts.MaxTextChars < 0 {
+		return fmt.Errorf("timeout_sec and max_text_chars must not be negative")
+	}
+	if opts.ViewportWidth < 0 || opts.ViewportWidth > types.MaxBrowserViewportWidth ||
+		opts.ViewportHeight < 0 || opts.ViewportHeight > types.MaxBrowserViewportHeight {
+		return fmt.Errorf("viewport must be at most %dx%d",
+			types.MaxBrowserViewportWidth, types.MaxBrowserViewportHeight)
+	}
+	for _, domain := range opts.AllowedDomains {
+		domain = strings.TrimSpace(domain)
+		if domain == "" || strings.ContainsAny(domain, "/:?#@ ") {
+			return fmt.Errorf("invalid allowed domain %q: use a host name such as example.com", domain)
+		}
+	}
+	return nil
+}
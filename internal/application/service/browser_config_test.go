@@ -0,0 +1,107 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/agent/tools"
+	"github.com/Tencent/WeKnora/internal/infrastructure/browser"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateBrowserConfig(t *testing.T) {
+	valid := func() *types.BrowserConfig {
+		return &types.BrowserConfig{
+			Name:       "Shared Chromium",
+			Parameters: types.BrowserConfigParameters{EndpointURL: "ws://93.184.216.34:9222/devtools/browser/abc"},
+			Options:    types.BrowserConfigOptions{AllowedDomains: []string{"example.com", "portal.example.org"}},
+		}
+	}
+	require.NoError(t, validateBrowserConfig(valid()))
+
+	private := valid()
+	private.Parameters.EndpointURL = "http://127.0.0.1:9222"
+	require.Error(t, validateBrowserConfig(private))
+	private.Parameters.AllowPrivateEndpoint = true
+	require.NoError(t, validateBrowserConfig(private))
+
+	metadata := valid()
+	metadata.Parameters.EndpointURL = "http://169.254.169.254:9222"
+	metadata.Parameters.AllowPrivateEndpoint = true
+	require.Error(t, validateBrowserConfig(metadata))
+
+	for name, mutate := range map[string]func(*types.BrowserConfig){
+		"no name":        func(c *types.BrowserConfig) { c.Name = " " },
+		"no endpoint":    func(c *types.BrowserConfig) { c.Parameters.EndpointURL = "" },
+		"bad scheme":     func(c *types.BrowserConfig) { c.Parameters.EndpointURL = "ftp://93.184.216.34" },
+		"negative limit": func(c *types.BrowserConfig) { c.Options.TimeoutSec = -1 },
+		"huge viewport":  func(c *types.BrowserConfig) { c.Options.ViewportWidth = types.MaxBrowserViewportWidth + 1 },
+		"url as domain":  func(c *types.BrowserConfig) { c.Options.AllowedDomains = []string{"https://example.com/"} },
+	} {
+		cfg := valid()
+		mutate(cfg)
+		require.Error(t, validateBrowserConfig(cfg), name)
+	}
+}
+
+// recordingBrowserConfigs returns its config only for the expected tenant.
+type recordingBrowserConfigs struct {
+	interfaces.BrowserConfigService
+
+	tenantID uint64
+	cfg      *types.BrowserConfig
+	asked    []uint64
+}
+
+func (r *recordingBrowserConfigs) GetConfig(_ context.Context, tenantID uint64, id string) (*types.BrowserConfig, error) {
+	r.asked = append(r.asked, tenantID)
+	if tenantID == r.tenantID && id == r.cfg.ID {
+		return r.cfg, nil
+	}
+	return nil, nil
+}
+
+func TestResolveBrowserConfig_UsesAgentTenant(t *testing.T) {
+	cfg := &types.BrowserConfig{ID: "cfg-1", TenantID: 7}
+	configs := &recordingBrowserConfigs{tenantID: 7, cfg: cfg}
+	svc := &agentService{browserConfigService: configs, browserSessions: browser.NewSessions()}
+
+	// A shared agent keeps its owner's browser; the caller's tenant is ignored.
+	ctx := context.WithValue(context.Background(), types.TenantIDContextKey, uint64(9))
+	got := svc.resolveBrowserConfig(ctx, &types.AgentConfig{BrowserConfigID: "cfg-1", AgentTenantID: 7})
+	require.Same(t, cfg, got)
+	require.Equal(t, []uint64{7}, configs.asked)
+
+	// Without an agent tenant the caller's is used, which does not own it.
+	require.Nil(t, svc.resolveBrowserConfig(ctx, &types.AgentConfig{BrowserConfigID: "cfg-1"}))
+	require.Nil(t, svc.resolveBrowserConfig(ctx, &types.AgentConfig{AgentTenantID: 7}))
+}
+
+func TestRegisterTools_BrowserToolsFollowConfig(t *testing.T) {
+	cfg := &types.BrowserConfig{ID: "cfg-1", TenantID: 7}
+	svc := &agentService{
+		browserConfigService: &recordingBrowserConfigs{tenantID: 7, cfg: cfg},
+		browserSessions:      browser.NewSessions(),
+	}
+	ctx := context.Background()
+
+	names := func(config *types.AgentConfig) []string {
+		registry := tools.NewToolRegistry()
+		require.NoError(t, svc.registerTools(ctx, registry, config, nil, nil, "session-1"))
+		return registry.ListTools()
+	}
+
+	// A stale allowlist naming browser tools does not register them on its own.
+	stale := names(&types.AgentConfig{AllowedTools: []string{tools.ToolBrowserNavigate}})
+	require.NotContains(t, stale, tools.ToolBrowserNavigate)
+
+	withBrowser := names(&types.AgentConfig{BrowserConfigID: "cfg-1", AgentTenantID: 7})
+	for _, name := range []string{
+		tools.ToolBrowserNavigate, tools.ToolBrowserClick, tools.ToolBrowserFill,
+		tools.ToolBrowserExtract, tools.ToolBrowserScreenshot,
+	} {
+		require.Contains(t, withBrowser, name)
+	}
+}
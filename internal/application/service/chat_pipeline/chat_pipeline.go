@@ -2,8 +2,14 @@ package chatpipeline
 
 import (
 	"context"
+	"time"
 
+	"github.com/Tencent/WeKnora/internal/metrics"
+	"github.com/Tencent/WeKnora/internal/tracing/otlp"
 	"github.com/Tencent/WeKnora/internal/types"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Plugin defines the interface for chat pipeline plugins
@@ -67,12 +73,27 @@ func (e *EventManager) buildHandler(plugins []Plugin) func(
 	return next
 }
 
-// Trigger invokes the handler for the specified event type
+// Trigger invokes the handler for the specified event type and records the
+// stage duration, labelled with the plugin error type on failure.
 func (e *EventManager) Trigger(ctx context.Context,
 	eventType types.EventType, chatManage *types.ChatManage,
 ) *PluginError {
 	if handler, ok := e.handlers[eventType]; ok {
-		return handler(ctx, eventType, chatManage)
+		start := time.Now()
+		ctx, span := otlp.Start(ctx, "chat_pipeline."+string(eventType),
+			trace.WithAttributes(attribute.String("weknora.pipeline.event", string(eventType))))
+		err := handler(ctx, eventType, chatManage)
+		status := metrics.StatusOK
+		if err != nil {
+			status = err.ErrorType
+			span.SetStatus(codes.Error, err.ErrorType)
+			if err.Err != nil {
+				span.RecordError(err.Err)
+			}
+		}
+		span.End()
+		metrics.ObservePipelineStage(string(eventType), status, time.Since(start))
+		return err
 	}
 	return nil
 }
@@ -29,6 +29,24 @@ The retrieved context for this turn contains Markdown images. Images attached to
 - When multiple retrieved images support different sections of a multi-section answer, include them in their corresponding sections instead of stopping after the first image.
 - Before finishing, silently verify that the answer contains a Markdown image whenever this requirement applies.`
 
+const freshnessCitationRequirement = `
+
+## Document Validity
+Some retrieved passages carry a freshness attribute: "expiring_soon" (the source document expires on the date in valid_until), "expired" (it is no longer valid) or "not_yet_valid" (it does not apply yet).
+- When the answer relies on such a passage, say so next to the statement it supports, e.g. "(source expires on 2026-11-01)" or "(source has expired)".
+- Prefer passages without a freshness attribute when they answer the question equally well.`
+
+// freshnessAttrMarker is written into <context> tags by the IntoChatMessage
+// plugin for passages from documents that are not fully current.
+const freshnessAttrMarker = ` freshness="`
+
+func appendFreshnessCitationRequirement(systemPrompt, renderedContexts string) string {
+	if !strings.Contains(renderedContexts, freshnessAttrMarker) {
+		return systemPrompt
+	}
+	return strings.TrimRight(systemPrompt, " \t\r\n") + freshnessCitationRequirement
+}
+
 func appendRetrievedImageOutputRequirement(systemPrompt, renderedContexts string) string {
 	if !searchutil.MarkdownImageRegex.MatchString(renderedContexts) {
 		return systemPrompt
@@ -95,6 +113,7 @@ func prepareMessagesWithHistory(chatManage *types.ChatManage) []chat.Message {
 		"contexts": chatManage.RenderedContexts,
 	})
 	systemPrompt = appendRetrievedImageOutputRequirement(systemPrompt, chatManage.RenderedContexts)
+	systemPrompt = appendFreshnessCitationRequirement(systemPrompt, chatManage.RenderedContexts)
 	// Memory goes at the end of the system prompt, after the retrieved-context
 	// placeholders have been rendered, so a remembered sentence can never be
 	// substituted into prompt structure.
@@ -3,6 +3,9 @@ package chatpipeline
 import (
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/types"
 )
 
 func TestAppendRetrievedImageOutputRequirement(t *testing.T) {
@@ -28,3 +31,19 @@ func TestAppendRetrievedImageOutputRequirement(t *testing.T) {
 		t.Fatalf("text-only context should not change the system prompt: %q", withoutImage)
 	}
 }
+
+func TestAppendFreshnessCitationRequirement(t *testing.T) {
+	base := "Answer from retrieved evidence."
+	until := time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)
+	attrs := freshnessAttrs(&types.SearchResult{Freshness: types.FreshnessExpiringSoon, KnowledgeValidUntil: &until})
+	if attrs != ` freshness="expiring_soon" valid_until="2026-11-01"` {
+		t.Fatalf("freshnessAttrs = %q", attrs)
+	}
+	contexts := "<context id=\"1\"" + attrs + ">policy</context>"
+	if got := appendFreshnessCitationRequirement(base, contexts); !strings.Contains(got, "## Document Validity") {
+		t.Fatalf("expected validity requirement:\n%s", got)
+	}
+	if got := appendFreshnessCitationRequirement(base, "<context id=\"1\">policy</context>"); got != base {
+		t.Fatalf("current passages should not change the system prompt: %q", got)
+	}
+}
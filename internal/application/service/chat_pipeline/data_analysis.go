@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	filesvc "github.com/Tencent/WeKnora/internal/application/service/file"
 	"slices"
 	"strings"
 
@@ -21,6 +22,7 @@ type PluginDataAnalysis struct {
 	knowledgeBaseService interfaces.KnowledgeBaseService
 	knowledgeService     interfaces.KnowledgeService
 	fileService          interfaces.FileService
+	fileEncryption       *filesvc.Encryption
 	chunkRepo            interfaces.ChunkRepository
 	tenantService        interfaces.TenantService
 	db                   *sql.DB
@@ -32,6 +34,7 @@ func NewPluginDataAnalysis(
 	knowledgeBaseService interfaces.KnowledgeBaseService,
 	knowledgeService interfaces.KnowledgeService,
 	fileService interfaces.FileService,
+	fileEncryption *filesvc.Encryption,
 	chunkRepo interfaces.ChunkRepository,
 	tenantService interfaces.TenantService,
 	db *sql.DB,
@@ -41,6 +44,7 @@ func NewPluginDataAnalysis(
 		knowledgeBaseService: knowledgeBaseService,
 		knowledgeService:     knowledgeService,
 		fileService:          fileService,
+		fileEncryption:       fileEncryption,
 		chunkRepo:            chunkRepo,
 		tenantService:        tenantService,
 		db:                   db,
@@ -89,7 +93,7 @@ func (p *PluginDataAnalysis) OnEvent(
 	}
 
 	// Initialize DataAnalysisTool
-	tool := tools.NewDataAnalysisTool(p.knowledgeBaseService, p.knowledgeService, p.tenantService, p.fileService, p.db, chatManage.SessionID)
+	tool := tools.NewDataAnalysisTool(p.knowledgeBaseService, p.knowledgeService, p.tenantService, p.fileService, p.fileEncryption, p.db, chatManage.SessionID)
 	defer tool.Cleanup(ctx)
 
 	// Load data into DuckDB
@@ -137,9 +137,9 @@ func (p *PluginIntoChatMessage) OnEvent(ctx context.Context,
 		for i, result := range faqResults {
 			passage := getEnrichedPassageForChat(ctx, result)
 			if hasHighConfidenceFAQ && i == 0 {
-				contextsBuilder.WriteString(fmt.Sprintf("<context id=\"FAQ-%d\" match=\"exact\">%s</context>\n", i+1, passage))
+				contextsBuilder.WriteString(fmt.Sprintf("<context id=\"FAQ-%d\" match=\"exact\"%s>%s</context>\n", i+1, freshnessAttrs(result), passage))
 			} else {
-				contextsBuilder.WriteString(fmt.Sprintf("<context id=\"FAQ-%d\">%s</context>\n", i+1, passage))
+				contextsBuilder.WriteString(fmt.Sprintf("<context id=\"FAQ-%d\"%s>%s</context>\n", i+1, freshnessAttrs(result), passage))
 			}
 		}
 		contextsBuilder.WriteString("</source>\n")
@@ -148,7 +148,7 @@ func (p *PluginIntoChatMessage) OnEvent(ctx context.Context,
 			contextsBuilder.WriteString("<source type=\"document\" priority=\"supplementary\">\n")
 			for i, result := range docResults {
 				passage := getEnrichedPassageForChat(ctx, result)
-				contextsBuilder.WriteString(fmt.Sprintf("<context id=\"DOC-%d\">%s</context>\n", i+1, passage))
+				contextsBuilder.WriteString(fmt.Sprintf("<context id=\"DOC-%d\"%s>%s</context>\n", i+1, freshnessAttrs(result), passage))
 			}
 			contextsBuilder.WriteString("</source>")
 		}
@@ -158,7 +158,7 @@ func (p *PluginIntoChatMessage) OnEvent(ctx context.Context,
 			if i > 0 {
 				contextsBuilder.WriteString("\n")
 			}
-			contextsBuilder.WriteString(fmt.Sprintf("<context id=\"%d\">%s</context>", i+1, passage))
+			contextsBuilder.WriteString(fmt.Sprintf("<context id=\"%d\"%s>%s</context>", i+1, freshnessAttrs(result), passage))
 		}
 	}
 
@@ -292,6 +292,19 @@ func buildDocumentHeader(results []*types.SearchResult) string {
 	return b.String()
 }
 
+// freshnessAttrs returns the attributes marking a passage from an expired,
+// not yet valid or soon-to-expire document, or "" for current documents.
+func freshnessAttrs(result *types.SearchResult) string {
+	if result == nil || result.Freshness == "" {
+		return ""
+	}
+	attrs := freshnessAttrMarker + html.EscapeString(result.Freshness) + `"`
+	if result.KnowledgeValidUntil != nil {
+		attrs += fmt.Sprintf(` valid_until="%s"`, result.KnowledgeValidUntil.Format("2006-01-02"))
+	}
+	return attrs
+}
+
 // getEnrichedPassageForChat 合并Content和ImageInfo的文本内容，为聊天消息准备
 func getEnrichedPassageForChat(ctx context.Context, result *types.SearchResult) string {
 	// 如果没有图片信息，直接返回内容
@@ -41,6 +41,7 @@ func (p *PluginMerge) ActivationEvents() []types.EventType {
 //  7. Expand short contexts with neighboring chunks
 //     7.5. Re-merge sequential or contained bodies introduced by expansion
 //  8. Final deduplication (ID + signature + partial content overlap)
+//  9. Collapse near-duplicate contents (SimHash), keeping the best-scored copy
 func (p *PluginMerge) OnEvent(ctx context.Context,
 	eventType types.EventType, chatManage *types.ChatManage, next func() *PluginError,
 ) *PluginError {
@@ -92,6 +93,9 @@ func (p *PluginMerge) OnEvent(ctx context.Context,
 	mergedChunks = p.dedup(ctx, "final_dedup", mergedChunks)
 	mergedChunks = removePartialOverlaps(ctx, mergedChunks)
 
+	// Step 9: Collapse near-duplicates — copies of one passage from different documents
+	mergedChunks = collapseNearDuplicates(ctx, mergedChunks)
+
 	chatManage.MergeResult = mergedChunks
 	return next()
 }
@@ -302,6 +302,63 @@ func removePartialOverlaps(ctx context.Context, results []*types.SearchResult) [
 	return out
 }
 
+// minNearDuplicateShingles is the shingle count below which a result is too
+// short for its SimHash to tell near-duplicates from unrelated text.
+const minNearDuplicateShingles = 16
+
+// collapseNearDuplicates keeps one result per group of near-duplicate
+// contents — typically the same passage indexed from several copies of a
+// document — preferring the higher score. Two results are near-duplicates
+// when their SimHashes are within searchutil.SimHashNearDistance. FAQ
+// entries and very short results are left alone.
+func collapseNearDuplicates(ctx context.Context, results []*types.SearchResult) []*types.SearchResult {
+	if len(results) <= 1 {
+		return results
+	}
+	hashes := make([]uint64, len(results))
+	for i, r := range results {
+		if r.ChunkType == string(types.ChunkTypeFAQ) ||
+			len(searchutil.Shingles(r.Content)) < minNearDuplicateShingles {
+			continue
+		}
+		hashes[i] = searchutil.SimHash(r.Content)
+	}
+
+	removed := make(map[int]bool)
+	for i := 0; i < len(results); i++ {
+		if removed[i] || hashes[i] == 0 {
+			continue
+		}
+		for j := i + 1; j < len(results); j++ {
+			if removed[j] || hashes[j] == 0 ||
+				searchutil.SimHashDistance(hashes[i], hashes[j]) > searchutil.SimHashNearDistance {
+				continue
+			}
+			victim, kept := j, i
+			if results[j].Score > results[i].Score {
+				victim, kept = i, j
+			}
+			removed[victim] = true
+			pipelineInfo(ctx, "Merge", "near_duplicate_drop", map[string]interface{}{
+				"kept_id":    results[kept].ID,
+				"dropped_id": results[victim].ID,
+				"distance":   searchutil.SimHashDistance(hashes[i], hashes[j]),
+			})
+			if victim == i {
+				break
+			}
+		}
+	}
+
+	out := make([]*types.SearchResult, 0, len(results)-len(removed))
+	for i, r := range results {
+		if !removed[i] {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
 func logSearchScoreSample(ctx context.Context, action string, results []*types.SearchResult) {
 	const maxLogRows = 8
 	limit := min(maxLogRows, len(results))
@@ -5,6 +5,7 @@ import (
 
 	"github.com/Tencent/WeKnora/internal/config"
 	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/searchutil"
 	"github.com/Tencent/WeKnora/internal/types"
 	"github.com/Tencent/WeKnora/internal/types/interfaces"
 )
@@ -24,6 +25,9 @@ type PluginSearchParallel struct {
 	chunkRepo     interfaces.ChunkRepository
 	knowledgeRepo interfaces.KnowledgeRepository
 
+	// Community search dependencies
+	communityService interfaces.GraphCommunityService
+
 	// Internal plugins
 	searchPlugin       *PluginSearch
 	searchEntityPlugin *PluginSearchEntity
@@ -44,6 +48,7 @@ func NewPluginSearchParallel(
 	graphRepository interfaces.RetrieveGraphRepository,
 	chunkRepository interfaces.ChunkRepository,
 	knowledgeRepository interfaces.KnowledgeRepository,
+	communityService interfaces.GraphCommunityService,
 ) *PluginSearchParallel {
 	// Create internal plugins without registering them
 	searchPlugin := &PluginSearch{
@@ -74,6 +79,7 @@ func NewPluginSearchParallel(
 		graphRepo:            graphRepository,
 		chunkRepo:            chunkRepository,
 		knowledgeRepo:        knowledgeRepository,
+		communityService:     communityService,
 		searchPlugin:         searchPlugin,
 		searchEntityPlugin:   searchEntityPlugin,
 	}
@@ -110,6 +116,7 @@ func (p *PluginSearchParallel) OnEvent(ctx context.Context,
 	chunkCM.SearchResult = nil
 	entityCM := chatManage.Clone()
 	entityCM.SearchResult = nil
+	var communityResults []*types.SearchResult
 
 	noop := func() *PluginError { return nil }
 
@@ -148,12 +155,38 @@ func (p *PluginSearchParallel) OnEvent(ctx context.Context,
 				return err
 			},
 		},
+		{
+			Name: "community_search",
+			Run: func() *PluginError {
+				kbIDs := p.communitySearchKBIDs(chatManage)
+				if len(kbIDs) == 0 {
+					return nil
+				}
+				result, err := p.communityService.GlobalSearch(ctx, &types.GraphGlobalSearchRequest{
+					KnowledgeBaseIDs: kbIDs,
+					Query:            chatManage.RewriteQuery,
+					Level:            -1,
+					ChatModelID:      chatManage.ChatModelID,
+					Language:         chatManage.Language,
+				})
+				if err != nil {
+					return ErrSearch.WithError(err)
+				}
+				communityResults = searchutil.ConvertGraphCommunityPoints(result.Points)
+				pipelineInfo(ctx, "SearchParallel", "community_search_done", map[string]interface{}{
+					"communities":  result.Communities,
+					"result_count": len(communityResults),
+				})
+				return nil
+			},
+		},
 	}
 
 	errs := RunParallel(tasks...)
 
-	// Merge results from both searches
+	// Merge results from all searches
 	chatManage.SearchResult = append(chunkCM.SearchResult, entityCM.SearchResult...)
+	chatManage.SearchResult = append(chatManage.SearchResult, communityResults...)
 	chatManage.SearchResult = removeDuplicateResults(chatManage.SearchResult)
 
 	for name, err := range errs {
@@ -161,11 +194,12 @@ func (p *PluginSearchParallel) OnEvent(ctx context.Context,
 	}
 
 	pipelineInfo(ctx, "SearchParallel", "complete", map[string]interface{}{
-		"session_id":     chatManage.SessionID,
-		"chunk_results":  len(chunkCM.SearchResult),
-		"entity_results": len(entityCM.SearchResult),
-		"total_results":  len(chatManage.SearchResult),
-		"error_count":    len(errs),
+		"session_id":        chatManage.SessionID,
+		"chunk_results":     len(chunkCM.SearchResult),
+		"entity_results":    len(entityCM.SearchResult),
+		"community_results": len(communityResults),
+		"total_results":     len(chatManage.SearchResult),
+		"error_count":       len(errs),
 	})
 
 	if len(chatManage.SearchResult) == 0 {
@@ -177,3 +211,24 @@ func (p *PluginSearchParallel) OnEvent(ctx context.Context,
 
 	return next()
 }
+
+// communitySearchKBIDs returns the knowledge bases global search should run
+// over: graph-enabled ones searched as a whole. Community reports summarize
+// an entire knowledge base, so a search scoped to some documents or tags
+// must not see them.
+func (p *PluginSearchParallel) communitySearchKBIDs(chatManage *types.ChatManage) []string {
+	if p.communityService == nil || !chatManage.WantsGraphGlobalSearch() || len(chatManage.EntityKBIDs) == 0 {
+		return nil
+	}
+	whole := make(map[string]bool)
+	for _, id := range chatManage.SearchTargets.WholeKnowledgeBaseIDs() {
+		whole[id] = true
+	}
+	var out []string
+	for _, id := range chatManage.EntityKBIDs {
+		if whole[id] {
+			out = append(out, id)
+		}
+	}
+	return out
+}
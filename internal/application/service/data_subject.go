@@ -0,0 +1,721 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+const (
+	// dataSubjectAuditScope is the audit scope type of data subject requests.
+	dataSubjectAuditScope = "data_subject"
+	// maxDataSubjectUserID matches the users.id column.
+	maxDataSubjectUserID = 36
+)
+
+type dataSubjectService struct {
+	repo          interfaces.DataSubjectRepository
+	userRepo      interfaces.UserRepository
+	memberRepo    interfaces.TenantMemberRepository
+	knowledge     interfaces.KnowledgeService
+	knowledgeRepo interfaces.KnowledgeRepository
+	apiKeys       interfaces.TenantAPIKeyService
+	webSearch     interfaces.WebSearchStateService
+	streams       interfaces.StreamManager
+	files         interfaces.FileService
+	audit         interfaces.AuditLogService
+	now           func() time.Time
+}
+
+// NewDataSubjectService creates the service answering data subject export
+// and erasure requests.
+func NewDataSubjectService(
+	repo interfaces.DataSubjectRepository,
+	userRepo interfaces.UserRepository,
+	memberRepo interfaces.TenantMemberRepository,
+	knowledge interfaces.KnowledgeService,
+	knowledgeRepo interfaces.KnowledgeRepository,
+	apiKeys interfaces.TenantAPIKeyService,
+	webSearch interfaces.WebSearchStateService,
+	streams interfaces.StreamManager,
+	files interfaces.FileService,
+	audit interfaces.AuditLogService,
+) interfaces.DataSubjectService {
+	return &dataSubjectService{
+		repo:          repo,
+		userRepo:      userRepo,
+		memberRepo:    memberRepo,
+		knowledge:     knowledge,
+		knowledgeRepo: knowledgeRepo,
+		apiKeys:       apiKeys,
+		webSearch:     webSearch,
+		streams:       streams,
+		files:         files,
+		audit:         audit,
+		now:           time.Now,
+	}
+}
+
+// memorySubjectID is the memory subject of a web user.
+func memorySubjectID(userID string) string {
+	return types.Principal{Type: types.PrincipalWebUser, ID: userID}.StorageID()
+}
+
+// dataSubject is the user a request is about, as resolved in the workspace.
+type dataSubject struct {
+	tenantID uint64
+	// user is nil when the account no longer exists.
+	user *types.User
+	// email matches knowledge ACL grants by email; empty without a user.
+	email  string
+	member *types.TenantMember
+	counts map[types.DataSubjectCategory]int64
+}
+
+// resolveSubject checks the request and returns the subject together with
+// the user's current row counts. A user that is neither a member nor has
+// any data in the workspace is reported as not found.
+func (s *dataSubjectService) resolveSubject(ctx context.Context, userID string) (*dataSubject, error) {
+	tenantID, ok := types.TenantIDFromContext(ctx)
+	if !ok {
+		return nil, errors.NewUnauthorizedError("workspace not found in context")
+	}
+	if userID == "" || len(userID) > maxDataSubjectUserID {
+		return nil, errors.NewBadRequestError("invalid user id")
+	}
+	subject := &dataSubject{tenantID: tenantID}
+	if user, err := s.userRepo.GetUserByID(ctx, userID); err == nil && user != nil {
+		subject.user = user
+		subject.email = user.Email
+	}
+	member, err := s.memberRepo.Get(ctx, userID, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	subject.member = member
+	subject.counts, err = s.repo.Count(ctx, tenantID, userID, subject.email, memorySubjectID(userID))
+	if err != nil {
+		return nil, err
+	}
+	if member == nil {
+		var total int64
+		for _, n := range subject.counts {
+			total += n
+		}
+		if total == 0 {
+			return nil, errors.NewNotFoundError("no data found for this user in the workspace")
+		}
+	}
+	return subject, nil
+}
+
+// dataSubjectArchive writes the files of an export archive and records
+// them for the manifest.
+type dataSubjectArchive struct {
+	zw       *zip.Writer
+	manifest *types.DataSubjectExportManifest
+}
+
+func (a *dataSubjectArchive) writeJSON(name string, records int, v any) error {
+	raw, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode %s: %w", name, err)
+	}
+	return a.write(name, records, bytes.NewReader(raw))
+}
+
+func (a *dataSubjectArchive) write(name string, records int, r io.Reader) error {
+	w, err := a.zw.Create(name)
+	if err != nil {
+		return err
+	}
+	h := sha256.New()
+	n, err := io.Copy(io.MultiWriter(w, h), r)
+	if err != nil {
+		return fmt.Errorf("write %s: %w", name, err)
+	}
+	a.manifest.Files = append(a.manifest.Files, types.DataSubjectExportFile{
+		Name: name, Records: records, Size: n, SHA256: hex.EncodeToString(h.Sum(nil)),
+	})
+	return nil
+}
+
+func (s *dataSubjectService) Export(ctx context.Context, userID string, w io.Writer) error {
+	subject, err := s.resolveSubject(ctx, userID)
+	if err != nil {
+		return err
+	}
+	tenantID := subject.tenantID
+
+	// Collect everything from the database before the first byte is
+	// written, so a failed read still yields a proper error response.
+	profile := map[string]any{"user_id": userID, "membership": subject.member}
+	if user := subject.user; user != nil {
+		profile["username"] = user.Username
+		profile["email"] = user.Email
+		profile["avatar"] = user.Avatar
+		profile["created_at"] = user.CreatedAt
+	}
+	sessions, err := s.repo.ListSessions(ctx, tenantID, userID)
+	if err != nil {
+		return err
+	}
+	sessionIDs := make([]string, 0, len(sessions))
+	for _, sess := range sessions {
+		sessionIDs = append(sessionIDs, sess.ID)
+	}
+	messages, err := s.repo.ListMessages(ctx, sessionIDs)
+	if err != nil {
+		return err
+	}
+	bySession := make(map[string][]*types.Message, len(sessions))
+	for _, m := range messages {
+		bySession[m.SessionID] = append(bySession[m.SessionID], m)
+	}
+	exported := make([]types.DataSubjectSession, 0, len(sessions))
+	for _, sess := range sessions {
+		msgs := bySession[sess.ID]
+		if msgs == nil {
+			msgs = []*types.Message{}
+		}
+		exported = append(exported, types.DataSubjectSession{Session: sess, Messages: msgs})
+	}
+	tempDocs, err := s.repo.ListTemporaryDocuments(ctx, tenantID, sessionIDs)
+	if err != nil {
+		return err
+	}
+	memory, err := s.repo.GetMemory(ctx, tenantID, memorySubjectID(userID))
+	if err != nil {
+		return err
+	}
+	sharedMemory, err := s.repo.ListSharedMemory(ctx, tenantID, memorySubjectID(userID))
+	if err != nil {
+		return err
+	}
+	knowledgeIDs, err := s.repo.ListUploadedKnowledgeIDs(ctx, tenantID, userID)
+	if err != nil {
+		return err
+	}
+	knowledgeList := []*types.Knowledge{}
+	if len(knowledgeIDs) > 0 {
+		if knowledgeList, err = s.knowledge.GetKnowledgeBatch(ctx, tenantID, knowledgeIDs); err != nil {
+			return err
+		}
+	}
+	feedback, err := s.repo.ListFeedback(ctx, tenantID, userID)
+	if err != nil {
+		return err
+	}
+	bindings, err := s.repo.ListIMBindings(ctx, tenantID, userID)
+	if err != nil {
+		return err
+	}
+	keys, err := s.repo.ListAPIKeys(ctx, tenantID, userID)
+	if err != nil {
+		return err
+	}
+	apiKeys := make([]types.DataSubjectAPIKey, 0, len(keys))
+	for _, k := range keys {
+		apiKeys = append(apiKeys, types.DataSubjectAPIKey{
+			ID: k.ID, Name: k.Name, FullAccess: k.FullAccess, Capabilities: k.Capabilities,
+			LastUsedAt: k.LastUsedAt, ExpiresAt: k.ExpiresAt, RevokedAt: k.RevokedAt, CreatedAt: k.CreatedAt,
+		})
+	}
+	usage, err := s.repo.ListUsageRecords(ctx, tenantID, userID)
+	if err != nil {
+		return err
+	}
+	favorites, err := s.repo.ListFavorites(ctx, tenantID, userID)
+	if err != nil {
+		return err
+	}
+	mcpTokens, err := s.repo.ListMCPTokens(ctx, tenantID, userID)
+	if err != nil {
+		return err
+	}
+	auditLogs, err := s.repo.ListAuditLogs(ctx, tenantID, userID)
+	if err != nil {
+		return err
+	}
+	aclEntries, err := s.repo.ListKnowledgeACLEntries(ctx, tenantID, userID, subject.email)
+	if err != nil {
+		return err
+	}
+	groups, err := s.repo.ListUserGroupMemberships(ctx, tenantID, userID)
+	if err != nil {
+		return err
+	}
+	scimLinks, err := s.repo.ListSCIMLinks(ctx, tenantID, userID)
+	if err != nil {
+		return err
+	}
+
+	manifest := &types.DataSubjectExportManifest{
+		TenantID:    tenantID,
+		UserID:      userID,
+		GeneratedBy: auditActor(ctx),
+		GeneratedAt: s.now().UTC(),
+	}
+	archive := &dataSubjectArchive{zw: zip.NewWriter(w), manifest: manifest}
+	files := []struct {
+		name    string
+		records int
+		v       any
+	}{
+		{"profile.json", 1, profile},
+		{"sessions.json", len(exported), exported},
+		{"temporary_documents.json", len(tempDocs), tempDocs},
+		{"memory.json", len(memory.Items), memory},
+		{"shared_memory.json", len(sharedMemory), sharedMemory},
+		{"knowledge.json", len(knowledgeList), knowledgeList},
+		{"feedback.json", len(feedback), feedback},
+		{"im_bindings.json", len(bindings), bindings},
+		{"api_keys.json", len(apiKeys), apiKeys},
+		{"usage_records.json", len(usage), usage},
+		{"favorites.json", len(favorites), favorites},
+		{"mcp_oauth_tokens.json", len(mcpTokens), mcpTokens},
+		{"audit_logs.json", len(auditLogs), auditLogs},
+		{"knowledge_acl.json", len(aclEntries), aclEntries},
+		{"user_groups.json", len(groups), groups},
+		{"scim.json", len(scimLinks), scimLinks},
+	}
+	for _, f := range files {
+		if err := archive.writeJSON(f.name, f.records, f.v); err != nil {
+			return err
+		}
+	}
+	for _, k := range knowledgeList {
+		if k.FilePath == "" {
+			continue
+		}
+		if err := s.exportKnowledgeFile(ctx, archive, k); err != nil {
+			logger.Warnf(ctx, "Data subject export: skip file of knowledge %s: %v", k.ID, err)
+			manifest.Missing = append(manifest.Missing, k.ID)
+		}
+	}
+	raw, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	mw, err := archive.zw.Create("manifest.json")
+	if err != nil {
+		return err
+	}
+	if _, err := mw.Write(raw); err != nil {
+		return err
+	}
+	if err := archive.zw.Close(); err != nil {
+		return err
+	}
+
+	records := make(map[string]int, len(manifest.Files))
+	for _, f := range manifest.Files {
+		if f.Records > 0 {
+			records[f.Name] = f.Records
+		}
+	}
+	s.auditRequest(ctx, tenantID, userID, types.AuditActionDataSubjectExported, map[string]any{
+		"records":       records,
+		"files":         len(manifest.Files),
+		"missing_files": len(manifest.Missing),
+	})
+	return nil
+}
+
+// exportKnowledgeFile adds the original file of a knowledge item to the
+// archive as knowledge/<id>/<file name>.
+func (s *dataSubjectService) exportKnowledgeFile(
+	ctx context.Context, archive *dataSubjectArchive, k *types.Knowledge,
+) error {
+	reader, fileName, err := s.knowledge.GetKnowledgeFile(ctx, k.ID)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	name := path.Base(strings.ReplaceAll(fileName, "\\", "/"))
+	if name == "." || name == "/" || name == "" {
+		name = "file"
+	}
+	return archive.write(path.Join("knowledge", k.ID, name), 0, reader)
+}
+
+func (s *dataSubjectService) Erase(
+	ctx context.Context, userID string, req *types.DataSubjectErasureRequest,
+) (*types.DataSubjectErasureReport, error) {
+	subject, err := s.resolveSubject(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	tenantID, before := subject.tenantID, subject.counts
+	if req == nil {
+		req = &types.DataSubjectErasureRequest{}
+	}
+	report := &types.DataSubjectErasureReport{
+		ReportID:        uuid.NewString(),
+		TenantID:        tenantID,
+		UserID:          userID,
+		RequestedBy:     auditActor(ctx),
+		Reason:          strings.TrimSpace(req.Reason),
+		DeleteKnowledge: req.DeleteKnowledge,
+		StartedAt:       s.now().UTC(),
+	}
+	items := make(map[types.DataSubjectCategory]*types.DataSubjectErasureItem)
+	item := func(c types.DataSubjectCategory, action types.DataSubjectErasureAction) *types.DataSubjectErasureItem {
+		it := &types.DataSubjectErasureItem{Category: c, Action: action, Found: before[c]}
+		items[c] = it
+		return it
+	}
+	subjectID := memorySubjectID(userID)
+
+	// Sessions go first: their chat history knowledge, files and streams
+	// can only be found through the session and message rows.
+	chatKnowledgeIDs, err := s.eraseSessions(ctx, tenantID, userID, item)
+	if err != nil {
+		return nil, err
+	}
+
+	n, err := s.repo.DeleteMemory(ctx, tenantID, subjectID)
+	if err != nil {
+		return nil, err
+	}
+	item(types.DataSubjectMemory, types.DataSubjectErasureDeleted).Affected = n
+	if n, err = s.repo.AnonymizeSharedMemory(ctx, tenantID, subjectID); err != nil {
+		return nil, err
+	}
+	item(types.DataSubjectSharedMemory, types.DataSubjectErasureAnonymized).Affected = n
+
+	knowledgeIDs, err := s.repo.ListUploadedKnowledgeIDs(ctx, tenantID, userID)
+	if err != nil {
+		return nil, err
+	}
+	knowledgeIDs = subtractIDs(knowledgeIDs, chatKnowledgeIDs)
+	uploaded := item(types.DataSubjectUploadedKnowledge, types.DataSubjectErasureAnonymized)
+	uploaded.Found = int64(len(knowledgeIDs))
+	if req.DeleteKnowledge {
+		uploaded.Action = types.DataSubjectErasureDeleted
+		if err := s.deleteKnowledge(ctx, tenantID, knowledgeIDs); err != nil {
+			return nil, err
+		}
+		uploaded.Affected = uploaded.Found
+	}
+	if n, err = s.repo.AnonymizeKnowledgeEditor(ctx, tenantID, userID); err != nil {
+		return nil, err
+	}
+	if !req.DeleteKnowledge {
+		uploaded.Affected = n
+	}
+
+	feedback, bindings, err := s.repo.DeleteFeedback(ctx, tenantID, userID)
+	if err != nil {
+		return nil, err
+	}
+	item(types.DataSubjectFeedback, types.DataSubjectErasureDeleted).Affected = feedback
+	item(types.DataSubjectIMBindings, types.DataSubjectErasureDeleted).Affected = bindings
+
+	// Keys are revoked before the creator is cleared; a key outliving its
+	// creator would keep acting with the erased user's grants.
+	if _, err := s.apiKeys.RevokeAPIKeysCreatedBy(ctx, tenantID, userID); err != nil {
+		return nil, err
+	}
+	if n, err = s.repo.ClearAPIKeyCreator(ctx, tenantID, userID); err != nil {
+		return nil, err
+	}
+	item(types.DataSubjectAPIKeys, types.DataSubjectErasureAnonymized).Affected = n
+
+	if n, err = s.repo.AnonymizeUsageRecords(ctx, tenantID, userID); err != nil {
+		return nil, err
+	}
+	item(types.DataSubjectUsageRecords, types.DataSubjectErasureAnonymized).Affected = n
+	if n, err = s.repo.DeleteFavorites(ctx, tenantID, userID); err != nil {
+		return nil, err
+	}
+	item(types.DataSubjectFavorites, types.DataSubjectErasureDeleted).Affected = n
+	if n, err = s.repo.DeleteMCPTokens(ctx, tenantID, userID); err != nil {
+		return nil, err
+	}
+	item(types.DataSubjectMCPTokens, types.DataSubjectErasureDeleted).Affected = n
+	if n, err = s.repo.DeleteKnowledgeACLEntries(ctx, tenantID, userID, subject.email); err != nil {
+		return nil, err
+	}
+	item(types.DataSubjectKnowledgeACL, types.DataSubjectErasureDeleted).Affected = n
+	if n, err = s.repo.DeleteUserGroupMemberships(ctx, tenantID, userID); err != nil {
+		return nil, err
+	}
+	item(types.DataSubjectUserGroups, types.DataSubjectErasureDeleted).Affected = n
+	if n, err = s.repo.DeleteSCIMLinks(ctx, tenantID, userID); err != nil {
+		return nil, err
+	}
+	item(types.DataSubjectSCIM, types.DataSubjectErasureDeleted).Affected = n
+	item(types.DataSubjectAuditLogs, types.DataSubjectErasureRetained)
+
+	// Verify by counting again.
+	after, err := s.repo.Count(ctx, tenantID, userID, subject.email, subjectID)
+	if err != nil {
+		return nil, err
+	}
+	for c, it := range items {
+		switch c {
+		case types.DataSubjectChatHistoryKnowledge:
+			if it.Remaining, err = s.repo.CountKnowledge(ctx, tenantID, chatKnowledgeIDs); err != nil {
+				return nil, err
+			}
+		case types.DataSubjectUploadedKnowledge:
+			it.Remaining = after[c]
+			if req.DeleteKnowledge {
+				n, err := s.repo.CountKnowledge(ctx, tenantID, knowledgeIDs)
+				if err != nil {
+					return nil, err
+				}
+				it.Remaining += n
+			}
+		case types.DataSubjectSessionFiles, types.DataSubjectSessionStreams:
+			it.Remaining = it.Failed
+		default:
+			it.Remaining = after[c]
+		}
+	}
+
+	report.Verified = true
+	for _, c := range dataSubjectCategories {
+		it := items[c]
+		report.Items = append(report.Items, *it)
+		if it.Action != types.DataSubjectErasureRetained && it.Remaining > 0 {
+			report.Verified = false
+		}
+	}
+	report.CompletedAt = s.now().UTC()
+	report.Digest = report.ComputeDigest()
+	s.anchorReport(ctx, report)
+	logger.Infof(ctx, "Erased data of user %s in tenant %d, report %s verified=%v",
+		userID, tenantID, report.ReportID, report.Verified)
+	return report, nil
+}
+
+// dataSubjectCategories is the order of the items in an erasure report.
+var dataSubjectCategories = []types.DataSubjectCategory{
+	types.DataSubjectSessions,
+	types.DataSubjectMessages,
+	types.DataSubjectChatHistoryKnowledge,
+	types.DataSubjectSessionFiles,
+	types.DataSubjectSessionStreams,
+	types.DataSubjectMemory,
+	types.DataSubjectSharedMemory,
+	types.DataSubjectUploadedKnowledge,
+	types.DataSubjectFeedback,
+	types.DataSubjectIMBindings,
+	types.DataSubjectAPIKeys,
+	types.DataSubjectUsageRecords,
+	types.DataSubjectFavorites,
+	types.DataSubjectMCPTokens,
+	types.DataSubjectKnowledgeACL,
+	types.DataSubjectUserGroups,
+	types.DataSubjectSCIM,
+	types.DataSubjectAuditLogs,
+}
+
+// eraseSessions deletes the user's sessions with everything hanging off
+// them and returns the IDs of their chat history knowledge.
+func (s *dataSubjectService) eraseSessions(
+	ctx context.Context, tenantID uint64, userID string,
+	item func(types.DataSubjectCategory, types.DataSubjectErasureAction) *types.DataSubjectErasureItem,
+) ([]string, error) {
+	sessions, err := s.repo.ListSessions(ctx, tenantID, userID)
+	if err != nil {
+		return nil, err
+	}
+	sessionIDs := make([]string, 0, len(sessions))
+	for _, sess := range sessions {
+		sessionIDs = append(sessionIDs, sess.ID)
+	}
+	messages, err := s.repo.ListMessages(ctx, sessionIDs)
+	if err != nil {
+		return nil, err
+	}
+	tempDocs, err := s.repo.ListTemporaryDocuments(ctx, tenantID, sessionIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	var knowledgeIDs, refs []string
+	seen := make(map[string]bool)
+	for _, m := range messages {
+		if m.KnowledgeID != "" && !seen[m.KnowledgeID] {
+			seen[m.KnowledgeID] = true
+			knowledgeIDs = append(knowledgeIDs, m.KnowledgeID)
+		}
+		for _, img := range m.Images {
+			refs = append(refs, img.URL)
+		}
+		for _, a := range m.Artifacts {
+			refs = append(refs, a.URL)
+		}
+	}
+	// Attachment URLs are not persisted; uploads are reachable through
+	// their temporary documents.
+	for _, d := range tempDocs {
+		refs = append(refs, d.ResourceRef)
+		for _, img := range temporaryDocumentImageRefs(d.ImageRefs) {
+			refs = append(refs, img.URL)
+		}
+	}
+
+	chat := item(types.DataSubjectChatHistoryKnowledge, types.DataSubjectErasureDeleted)
+	chat.Found = int64(len(knowledgeIDs))
+	if err := s.deleteKnowledge(ctx, tenantID, knowledgeIDs); err != nil {
+		return nil, err
+	}
+	chat.Affected = chat.Found
+
+	files := item(types.DataSubjectSessionFiles, types.DataSubjectErasureDeleted)
+	for _, ref := range dedupeStoredRefs(refs) {
+		files.Found++
+		if err := s.files.DeleteFile(ctx, ref); err != nil {
+			logger.Warnf(ctx, "Data subject erasure: delete file %s: %v", ref, err)
+			files.Failed++
+			continue
+		}
+		files.Affected++
+	}
+
+	streams := item(types.DataSubjectSessionStreams, types.DataSubjectErasureDeleted)
+	for _, id := range sessionIDs {
+		if err := s.webSearch.DeleteWebSearchTempKBState(ctx, id); err != nil {
+			logger.Warnf(ctx, "Data subject erasure: delete web search state of session %s: %v", id, err)
+			streams.Failed++
+		}
+		n, err := s.streams.DeleteSessionEvents(ctx, id)
+		if err != nil {
+			logger.Warnf(ctx, "Data subject erasure: delete streams of session %s: %v", id, err)
+			streams.Failed++
+			continue
+		}
+		streams.Affected += int64(n)
+	}
+	streams.Found = streams.Affected + streams.Failed
+
+	deletedSessions, deletedMessages, err := s.repo.DeleteSessions(ctx, tenantID, sessionIDs)
+	if err != nil {
+		return nil, err
+	}
+	item(types.DataSubjectSessions, types.DataSubjectErasureDeleted).Affected = deletedSessions
+	item(types.DataSubjectMessages, types.DataSubjectErasureDeleted).Affected = deletedMessages
+	return knowledgeIDs, nil
+}
+
+// deleteKnowledge removes knowledge with its chunks, vectors, graph, files
+// and versions, then drops the rows the regular delete only soft-deletes.
+func (s *dataSubjectService) deleteKnowledge(ctx context.Context, tenantID uint64, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	if err := s.knowledge.DeleteKnowledgeList(ctx, ids); err != nil {
+		return err
+	}
+	return s.knowledgeRepo.HardDeleteKnowledgeList(ctx, tenantID, ids)
+}
+
+// dedupeStoredRefs drops empty and external (http) references; only files
+// in our storage are deleted.
+func dedupeStoredRefs(refs []string) []string {
+	seen := make(map[string]bool, len(refs))
+	out := make([]string, 0, len(refs))
+	for _, ref := range refs {
+		ref = strings.TrimSpace(ref)
+		lower := strings.ToLower(ref)
+		if ref == "" || seen[ref] || strings.HasPrefix(lower, "http://") ||
+			strings.HasPrefix(lower, "https://") || strings.HasPrefix(lower, "data:") {
+			continue
+		}
+		seen[ref] = true
+		out = append(out, ref)
+	}
+	return out
+}
+
+// subtractIDs returns ids without those in drop.
+func subtractIDs(ids, drop []string) []string {
+	if len(drop) == 0 {
+		return ids
+	}
+	skip := make(map[string]bool, len(drop))
+	for _, id := range drop {
+		skip[id] = true
+	}
+	out := ids[:0:0]
+	for _, id := range ids {
+		if !skip[id] {
+			out = append(out, id)
+		}
+	}
+	return out
+}
+
+// anchorReport records the report digest in the audit hash chain and
+// copies the position of the entry into the report.
+func (s *dataSubjectService) anchorReport(ctx context.Context, report *types.DataSubjectErasureReport) {
+	counts := make(map[string]any, len(report.Items))
+	for _, it := range report.Items {
+		counts[string(it.Category)] = map[string]any{
+			"action": it.Action, "found": it.Found, "affected": it.Affected, "remaining": it.Remaining,
+		}
+	}
+	entry := s.auditRequest(ctx, report.TenantID, report.UserID, types.AuditActionDataSubjectErased, map[string]any{
+		"report_id":        report.ReportID,
+		"digest":           report.Digest,
+		"verified":         report.Verified,
+		"reason":           report.Reason,
+		"delete_knowledge": report.DeleteKnowledge,
+		"items":            counts,
+	})
+	if entry != nil {
+		report.AuditLogID = entry.ID
+		report.AuditChainSeq = entry.ChainSeq
+		report.AuditHash = entry.Hash
+	}
+}
+
+// auditRequest writes the audit entry of a data subject request and
+// returns it, or nil when it could not be written.
+func (s *dataSubjectService) auditRequest(
+	ctx context.Context, tenantID uint64, userID string, action types.AuditAction, details map[string]any,
+) *types.AuditLog {
+	if s.audit == nil {
+		return nil
+	}
+	var detailJSON types.JSON
+	if raw, err := json.Marshal(details); err == nil {
+		detailJSON = types.JSON(raw)
+	}
+	entry := &types.AuditLog{
+		TenantID:     tenantID,
+		ActorUserID:  auditActor(ctx),
+		ActorRole:    auditActorRole(ctx),
+		Action:       action,
+		ScopeType:    dataSubjectAuditScope,
+		ScopeID:      userID,
+		TargetType:   "user",
+		TargetID:     userID,
+		TargetUserID: userID,
+		Outcome:      types.AuditOutcomeSuccess,
+		Details:      detailJSON,
+	}
+	if err := s.audit.Log(ctx, entry); err != nil {
+		return nil
+	}
+	return entry
+}
@@ -0,0 +1,469 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"github.com/Tencent/WeKnora/internal/application/repository"
+	"github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/stream"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+// dataSubjectKnowledge serves knowledge rows from the test database and
+// records deletions the way the real service does (soft delete).
+type dataSubjectKnowledge struct {
+	interfaces.KnowledgeService
+	db      *gorm.DB
+	deleted []string
+}
+
+func (k *dataSubjectKnowledge) GetKnowledgeBatch(_ context.Context, tenantID uint64, ids []string) ([]*types.Knowledge, error) {
+	var out []*types.Knowledge
+	err := k.db.Where("tenant_id = ? AND id IN ?", tenantID, ids).Find(&out).Error
+	return out, err
+}
+
+func (k *dataSubjectKnowledge) GetKnowledgeFile(_ context.Context, id string) (io.ReadCloser, string, error) {
+	return io.NopCloser(strings.NewReader("original " + id)), "../report.pdf", nil
+}
+
+func (k *dataSubjectKnowledge) DeleteKnowledgeList(_ context.Context, ids []string) error {
+	k.deleted = append(k.deleted, ids...)
+	return k.db.Where("id IN ?", ids).Delete(&types.Knowledge{}).Error
+}
+
+type dataSubjectAPIKeys struct {
+	interfaces.TenantAPIKeyService
+	db *gorm.DB
+}
+
+func (a *dataSubjectAPIKeys) RevokeAPIKeysCreatedBy(_ context.Context, tenantID uint64, userID string) (int64, error) {
+	res := a.db.Model(&types.TenantAPIKey{}).
+		Where("tenant_id = ? AND created_by = ? AND revoked_at IS NULL", tenantID, userID).
+		Update("revoked_at", time.Now())
+	return res.RowsAffected, res.Error
+}
+
+type dataSubjectWebSearch struct {
+	interfaces.WebSearchStateService
+	sessions []string
+}
+
+func (w *dataSubjectWebSearch) DeleteWebSearchTempKBState(_ context.Context, sessionID string) error {
+	w.sessions = append(w.sessions, sessionID)
+	return nil
+}
+
+type dataSubjectFiles struct {
+	interfaces.FileService
+	deleted []string
+}
+
+func (f *dataSubjectFiles) DeleteFile(_ context.Context, path string) error {
+	f.deleted = append(f.deleted, path)
+	return nil
+}
+
+type dataSubjectUsers struct {
+	interfaces.UserRepository
+}
+
+func (u *dataSubjectUsers) GetUserByID(_ context.Context, id string) (*types.User, error) {
+	return &types.User{ID: id, Username: "alice", Email: id + "@Example.com"}, nil
+}
+
+// chainingAuditLog assigns chain positions the way the audit repository does.
+type chainingAuditLog struct {
+	interfaces.AuditLogService
+	entries []*types.AuditLog
+}
+
+func (a *chainingAuditLog) Log(_ context.Context, entry *types.AuditLog) error {
+	a.entries = append(a.entries, entry)
+	entry.ID = uint64(100 + len(a.entries))
+	entry.ChainSeq = uint64(len(a.entries))
+	entry.Hash = fmt.Sprintf("hash-%d", entry.ChainSeq)
+	return nil
+}
+
+type dataSubjectFixture struct {
+	svc       *dataSubjectService
+	db        *gorm.DB
+	knowledge *dataSubjectKnowledge
+	files     *dataSubjectFiles
+	streams   interfaces.StreamManager
+	audit     *chainingAuditLog
+}
+
+func newDataSubjectFixture(t *testing.T) *dataSubjectFixture {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	require.NoError(t, db.AutoMigrate(
+		&types.Session{}, &types.Message{}, &types.MessageSuggestionSet{}, &types.MessageSuggestionEvent{},
+		&types.TemporaryDocument{}, &types.MemorySubject{}, &types.MemoryItem{}, &types.MemoryTombstone{},
+		&types.MemoryTopicStat{}, &types.MemoryDocAffinity{}, &types.MemoryItemEmbedding{},
+		&types.Knowledge{}, &types.KnowledgeVersion{}, &types.TenantAPIKey{}, &types.UsageRecord{},
+		&types.UserResourceFavorite{}, &types.MCPOAuthToken{}, &types.AuditLog{},
+		&types.KnowledgeACLEntry{}, &types.UserGroup{}, &types.UserGroupMember{}, &types.TenantSCIMUser{},
+	))
+	// The IM tables belong to the im package; only the columns read here.
+	for _, ddl := range []string{
+		`CREATE TABLE im_user_bindings (id TEXT PRIMARY KEY, tenant_id INTEGER, im_channel_id TEXT,
+			im_user_id TEXT, user_id TEXT, created_at DATETIME)`,
+		`CREATE TABLE im_message_feedback (id TEXT PRIMARY KEY, tenant_id INTEGER, im_channel_id TEXT,
+			platform TEXT, im_user_id TEXT, session_id TEXT, message_id TEXT, rating TEXT, comment TEXT,
+			created_at DATETIME, updated_at DATETIME)`,
+		`CREATE TABLE im_channel_sessions (id TEXT PRIMARY KEY, tenant_id INTEGER, session_id TEXT)`,
+	} {
+		require.NoError(t, db.Exec(ddl).Error)
+	}
+
+	f := &dataSubjectFixture{
+		db:        db,
+		knowledge: &dataSubjectKnowledge{db: db},
+		files:     &dataSubjectFiles{},
+		streams:   stream.NewMemoryStreamManager(),
+		audit:     &chainingAuditLog{},
+	}
+	members := newFakeRepo()
+	require.NoError(t, members.Create(context.Background(), &types.TenantMember{UserID: "u1", TenantID: 1}))
+	f.svc = NewDataSubjectService(
+		repository.NewDataSubjectRepository(db), &dataSubjectUsers{}, members, f.knowledge,
+		repository.NewKnowledgeRepository(db), &dataSubjectAPIKeys{db: db}, &dataSubjectWebSearch{},
+		f.streams, f.files, f.audit,
+	).(*dataSubjectService)
+	return f
+}
+
+// seed stores the same data for u1 and u2 in tenant 1, plus data of u1 in
+// tenant 2, so tests can check that nothing but u1 in tenant 1 is touched.
+func (f *dataSubjectFixture) seed(t *testing.T) {
+	t.Helper()
+	ctx := context.Background()
+	for _, owner := range []struct {
+		tenantID uint64
+		userID   string
+	}{{1, "u1"}, {1, "u2"}, {2, "u1"}} {
+		tenantID, uid := owner.tenantID, owner.userID
+		prefix := uid + "-" + string(rune('0'+tenantID))
+		sessions := []*types.Session{
+			{ID: prefix + "-s1", TenantID: tenantID, UserID: uid},
+			{ID: prefix + "-s2", TenantID: tenantID, UserID: uid},
+		}
+		for i, sess := range sessions {
+			want := sess.ID
+			require.NoError(t, f.db.Create(sess).Error)
+			// BeforeCreate assigns a random ID; pin it for the assertions.
+			require.NoError(t, f.db.Model(sess).Update("id", want).Error)
+			sessions[i].ID = want
+		}
+		require.NoError(t, f.db.Delete(sessions[1]).Error) // soft-deleted sessions are erased too
+		require.NoError(t, f.db.Create([]*types.Message{
+			{ID: prefix + "-m1", SessionID: prefix + "-s1", Role: "user", Content: "hello",
+				Attachments: types.MessageAttachments{{ID: prefix + "-t1", FileName: "t.docx"}},
+				Images:      types.MessageImages{{URL: "https://example.com/x.png"}}},
+			{ID: prefix + "-m2", SessionID: prefix + "-s1", Role: "assistant", Content: "hi",
+				KnowledgeID: prefix + "-chat",
+				Artifacts:   types.MessageArtifacts{{URL: "local://" + prefix + "/answer.md", FileName: "answer.md"}}},
+			{ID: prefix + "-m3", SessionID: prefix + "-s2", Role: "user", Content: "bye"},
+		}).Error)
+		require.NoError(t, f.db.Create(&types.MessageSuggestionSet{
+			ID: prefix + "-ss", TenantID: tenantID, SessionID: prefix + "-s1", AssistantMessageID: prefix + "-m2",
+			Status: "ready", Questions: types.SuggestionItems{},
+		}).Error)
+		require.NoError(t, f.db.Create(&types.TemporaryDocument{
+			ID: prefix + "-t1", TenantID: tenantID, SessionID: prefix + "-s1", ResourceRef: "local://" + prefix + "/t.docx",
+			FileName: "t.docx", FileType: ".docx", Status: "ready", ExpiresAt: time.Now().Add(time.Hour),
+		}).Error)
+		require.NoError(t, f.streams.AppendEvent(ctx, prefix+"-s1", prefix+"-m2", interfaces.StreamEvent{ID: "e1"}))
+
+		subject := memorySubjectID(uid)
+		require.NoError(t, f.db.Create(&types.MemorySubject{ID: prefix + "-subj", TenantID: tenantID, SubjectID: subject}).Error)
+		require.NoError(t, f.db.Create(&types.MemoryItem{
+			ID: prefix + "-mem", TenantID: tenantID, SubjectID: subject, Kind: "fact", Content: "likes tea",
+			ValidFrom: time.Now(),
+		}).Error)
+
+		// Shared space items the user proposed or reviewed.
+		shared := "space-" + prefix
+		require.NoError(t, f.db.Create([]*types.MemoryItem{
+			{ID: prefix + "-promoted", TenantID: tenantID, SubjectID: shared, Kind: "fact", Content: "team likes tea",
+				ValidFrom: time.Now(), PromotedBy: subject, PromotedFrom: prefix + "-mem", ReviewedBy: "admin"},
+			{ID: prefix + "-proposal", TenantID: tenantID, SubjectID: shared, Kind: "fact", Content: "team likes coffee",
+				ValidFrom: time.Now(), Status: types.MemoryStatusPending, PromotedBy: subject},
+			{ID: prefix + "-reviewed", TenantID: tenantID, SubjectID: shared, Kind: "fact", Content: "team likes water",
+				ValidFrom: time.Now(), ReviewedBy: subject},
+		}).Error)
+		require.NoError(t, f.db.Create(&types.MemoryItemEmbedding{
+			ItemID: prefix + "-proposal", TenantID: tenantID, SubjectID: shared,
+		}).Error)
+
+		// acl1 names the user next to someone else, acl2 only by email.
+		require.NoError(t, f.db.Create([]*types.KnowledgeACLEntry{
+			{TenantID: tenantID, KnowledgeID: prefix + "-acl1", PrincipalType: types.KnowledgeACLPrincipalUser,
+				PrincipalID: uid, Source: types.KnowledgeACLSourceManual},
+			{TenantID: tenantID, KnowledgeID: prefix + "-acl1", PrincipalType: types.KnowledgeACLPrincipalUser,
+				PrincipalID: "someone", Source: types.KnowledgeACLSourceManual},
+			{TenantID: tenantID, KnowledgeID: prefix + "-acl2", PrincipalType: types.KnowledgeACLPrincipalEmail,
+				PrincipalID: uid + "@example.com", Source: types.KnowledgeACLSourceConnector},
+		}).Error)
+		require.NoError(t, f.db.Create(&types.UserGroup{ID: prefix + "-g", TenantID: tenantID, Name: "team " + prefix}).Error)
+		require.NoError(t, f.db.Create(&types.UserGroupMember{GroupID: prefix + "-g", UserID: uid, TenantID: tenantID}).Error)
+		require.NoError(t, f.db.Create(&types.TenantSCIMUser{
+			TenantID: tenantID, UserID: uid, UserName: uid + "@idp.example.com", ExternalID: "ext-" + prefix,
+		}).Error)
+
+		require.NoError(t, f.db.Create([]*types.Knowledge{
+			{ID: prefix + "-chat", TenantID: tenantID, Title: "chat history"},
+			{ID: prefix + "-doc", TenantID: tenantID, Title: "report", FilePath: "local://" + prefix + "/report.pdf"},
+		}).Error)
+		require.NoError(t, f.db.Create(&types.KnowledgeVersion{
+			ID: prefix + "-v1", TenantID: tenantID, KnowledgeID: prefix + "-doc", Version: 1,
+			Source: types.KnowledgeVersionSourceCreate, EditorID: uid,
+		}).Error)
+
+		require.NoError(t, f.db.Exec(`INSERT INTO im_user_bindings VALUES (?, ?, 'ch1', ?, ?, CURRENT_TIMESTAMP)`,
+			prefix+"-b", tenantID, "im-"+prefix, uid).Error)
+		require.NoError(t, f.db.Exec(`INSERT INTO im_message_feedback VALUES (?, ?, 'ch1', 'slack', ?, 's', 'm', 'good', '',
+			CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)`, prefix+"-f", tenantID, "im-"+prefix).Error)
+		require.NoError(t, f.db.Exec(`INSERT INTO im_channel_sessions VALUES (?, ?, ?)`,
+			prefix+"-cs", tenantID, prefix+"-s1").Error)
+
+		tid := tenantID
+		require.NoError(t, f.db.Create(&types.TenantAPIKey{
+			TenantID: &tid, Name: "key " + prefix, KeyHash: prefix, APIKey: "sk-secret-" + prefix, CreatedBy: uid,
+		}).Error)
+		require.NoError(t, f.db.Create(&types.UsageRecord{
+			TenantID: tenantID, UserID: uid, Kind: "chat", PromptTokens: 10, Period: "2026-10", Day: "2026-10-19",
+		}).Error)
+		require.NoError(t, f.db.Create(&types.UserResourceFavorite{
+			UserID: uid, TenantID: tenantID, ResourceType: types.ResourceTypeKB, ResourceID: "kb1",
+		}).Error)
+		require.NoError(t, f.db.Create(&types.MCPOAuthToken{
+			TenantID: tenantID, UserID: uid, PrincipalType: types.PrincipalWebUser, PrincipalID: uid, ServiceID: "svc",
+		}).Error)
+		require.NoError(t, f.db.Create(&types.AuditLog{
+			TenantID: tenantID, ActorUserID: uid, Action: types.AuditActionKnowledgeCreated,
+			TargetType: "knowledge", TargetID: prefix + "-doc",
+		}).Error)
+	}
+}
+
+func TestDataSubjectEraseRemovesOnlyTheUsersData(t *testing.T) {
+	f := newDataSubjectFixture(t)
+	f.seed(t)
+	repo := repository.NewDataSubjectRepository(f.db)
+	ctx := usageTestContext(1)
+	before2, err := repo.Count(ctx, 1, "u2", "u2@example.com", memorySubjectID("u2"))
+	require.NoError(t, err)
+	beforeOther, err := repo.Count(ctx, 2, "u1", "u1@example.com", memorySubjectID("u1"))
+	require.NoError(t, err)
+
+	report, err := f.svc.Erase(ctx, "u1", &types.DataSubjectErasureRequest{Reason: "ticket 42"})
+	require.NoError(t, err)
+
+	assert.True(t, report.Verified)
+	items := make(map[types.DataSubjectCategory]types.DataSubjectErasureItem)
+	for _, it := range report.Items {
+		items[it.Category] = it
+		if it.Action != types.DataSubjectErasureRetained {
+			assert.Zero(t, it.Remaining, it.Category)
+		}
+	}
+	assert.Len(t, report.Items, len(dataSubjectCategories))
+	assert.Equal(t, types.DataSubjectErasureItem{Category: types.DataSubjectSessions,
+		Action: types.DataSubjectErasureDeleted, Found: 2, Affected: 2}, items[types.DataSubjectSessions])
+	assert.Equal(t, int64(3), items[types.DataSubjectMessages].Affected)
+	assert.Equal(t, int64(1), items[types.DataSubjectChatHistoryKnowledge].Affected)
+	assert.Equal(t, int64(1), items[types.DataSubjectSessionStreams].Affected)
+	assert.Equal(t, int64(2), items[types.DataSubjectMemory].Affected)
+	assert.Equal(t, types.DataSubjectErasureAnonymized, items[types.DataSubjectUploadedKnowledge].Action)
+	assert.Equal(t, int64(1), items[types.DataSubjectFeedback].Affected)
+	assert.Equal(t, int64(1), items[types.DataSubjectAPIKeys].Affected)
+	assert.Equal(t, types.DataSubjectErasureItem{Category: types.DataSubjectAuditLogs,
+		Action: types.DataSubjectErasureRetained, Found: 1, Remaining: 1}, items[types.DataSubjectAuditLogs])
+	assert.Equal(t, types.DataSubjectErasureItem{Category: types.DataSubjectSharedMemory,
+		Action: types.DataSubjectErasureAnonymized, Found: 3, Affected: 3}, items[types.DataSubjectSharedMemory])
+	assert.Equal(t, int64(2), items[types.DataSubjectKnowledgeACL].Affected)
+	assert.Equal(t, int64(1), items[types.DataSubjectUserGroups].Affected)
+	assert.Equal(t, int64(1), items[types.DataSubjectSCIM].Affected)
+
+	// Approved shared items stay without the link to the user; the pending
+	// proposal goes with its embedding.
+	var sharedItems []types.MemoryItem
+	require.NoError(t, f.db.Where("subject_id = ?", "space-u1-1").Order("id").Find(&sharedItems).Error)
+	require.Len(t, sharedItems, 2)
+	assert.Equal(t, "u1-1-promoted", sharedItems[0].ID)
+	assert.Empty(t, sharedItems[0].PromotedBy)
+	assert.Empty(t, sharedItems[0].PromotedFrom)
+	assert.Equal(t, "admin", sharedItems[0].ReviewedBy)
+	assert.Empty(t, sharedItems[1].ReviewedBy)
+	var embeddings int64
+	require.NoError(t, f.db.Model(&types.MemoryItemEmbedding{}).Where("item_id = ?", "u1-1-proposal").
+		Count(&embeddings).Error)
+	assert.Zero(t, embeddings)
+	// An item the user alone was granted stays restricted.
+	var grants []types.KnowledgeACLEntry
+	require.NoError(t, f.db.Where("knowledge_id IN ?", []string{"u1-1-acl1", "u1-1-acl2"}).
+		Order("knowledge_id").Find(&grants).Error)
+	require.Len(t, grants, 2)
+	assert.Equal(t, "someone", grants[0].PrincipalID)
+	assert.Equal(t, types.KnowledgeACLErasedEmail, grants[1].PrincipalID)
+
+	// Only our storage files are deleted; external image URLs are left alone.
+	assert.ElementsMatch(t, []string{"local://u1-1/t.docx", "local://u1-1/answer.md"}, f.files.deleted)
+	assert.Equal(t, []string{"u1-1-chat"}, f.knowledge.deleted)
+	var hardDeleted int64
+	require.NoError(t, f.db.Unscoped().Model(&types.Knowledge{}).Where("id = ?", "u1-1-chat").Count(&hardDeleted).Error)
+	assert.Zero(t, hardDeleted)
+	// Uploaded knowledge stays by default, without the attribution.
+	var doc types.KnowledgeVersion
+	require.NoError(t, f.db.Where("id = ?", "u1-1-v1").First(&doc).Error)
+	assert.Empty(t, doc.EditorID)
+	var key types.TenantAPIKey
+	require.NoError(t, f.db.Where("key_hash = ?", "u1-1").First(&key).Error)
+	assert.NotNil(t, key.RevokedAt)
+	assert.Empty(t, key.CreatedBy)
+	var usage types.UsageRecord
+	require.NoError(t, f.db.Where("tenant_id = ? AND prompt_tokens = 10", 1).Order("id").First(&usage).Error)
+	assert.Empty(t, usage.UserID)
+	var mappings int64
+	require.NoError(t, f.db.Table("im_channel_sessions").Where("session_id = ?", "u1-1-s1").Count(&mappings).Error)
+	assert.Zero(t, mappings)
+
+	// Other users and other workspaces are untouched.
+	after2, err := repo.Count(ctx, 1, "u2", "u2@example.com", memorySubjectID("u2"))
+	require.NoError(t, err)
+	assert.Equal(t, before2, after2)
+	afterOther, err := repo.Count(ctx, 2, "u1", "u1@example.com", memorySubjectID("u1"))
+	require.NoError(t, err)
+	assert.Equal(t, beforeOther, afterOther)
+	events, _, err := f.streams.GetEvents(ctx, "u2-1-s1", "u2-1-m2", 0)
+	require.NoError(t, err)
+	assert.Len(t, events, 1)
+
+	// The digest covers the report and is anchored in the audit chain.
+	assert.Equal(t, report.ComputeDigest(), report.Digest)
+	require.Len(t, f.audit.entries, 1)
+	entry := f.audit.entries[0]
+	assert.Equal(t, types.AuditActionDataSubjectErased, entry.Action)
+	assert.Equal(t, "u1", entry.TargetUserID)
+	assert.Equal(t, entry.ID, report.AuditLogID)
+	assert.Equal(t, entry.Hash, report.AuditHash)
+	var details map[string]any
+	require.NoError(t, json.Unmarshal(entry.Details, &details))
+	assert.Equal(t, report.Digest, details["digest"])
+	assert.Equal(t, "ticket 42", details["reason"])
+
+	// A report read back from JSON verifies against the same digest.
+	raw, err := json.Marshal(report)
+	require.NoError(t, err)
+	var decoded types.DataSubjectErasureReport
+	require.NoError(t, json.Unmarshal(raw, &decoded))
+	assert.Equal(t, report.Digest, decoded.ComputeDigest())
+	decoded.Items[0].Remaining = 1
+	assert.NotEqual(t, report.Digest, decoded.ComputeDigest())
+}
+
+func TestDataSubjectEraseCanDeleteUploadedKnowledge(t *testing.T) {
+	f := newDataSubjectFixture(t)
+	f.seed(t)
+
+	report, err := f.svc.Erase(usageTestContext(1), "u1", &types.DataSubjectErasureRequest{DeleteKnowledge: true})
+	require.NoError(t, err)
+	assert.True(t, report.Verified)
+	assert.ElementsMatch(t, []string{"u1-1-chat", "u1-1-doc"}, f.knowledge.deleted)
+	for _, it := range report.Items {
+		if it.Category == types.DataSubjectUploadedKnowledge {
+			assert.Equal(t, types.DataSubjectErasureItem{Category: it.Category,
+				Action: types.DataSubjectErasureDeleted, Found: 1, Affected: 1}, it)
+		}
+	}
+}
+
+func TestDataSubjectUnknownUser(t *testing.T) {
+	f := newDataSubjectFixture(t)
+	f.seed(t)
+
+	_, err := f.svc.Erase(usageTestContext(1), "nobody", nil)
+	appErr, ok := errors.IsAppError(err)
+	require.True(t, ok, "want an application error, got %v", err)
+	assert.Equal(t, errors.ErrNotFound, appErr.Code)
+
+	_, err = f.svc.Erase(usageTestContext(1), strings.Repeat("x", 40), nil)
+	appErr, ok = errors.IsAppError(err)
+	require.True(t, ok)
+	assert.Equal(t, errors.ErrBadRequest, appErr.Code)
+	assert.Empty(t, f.audit.entries)
+}
+
+func TestDataSubjectExportArchive(t *testing.T) {
+	f := newDataSubjectFixture(t)
+	f.seed(t)
+
+	var buf bytes.Buffer
+	require.NoError(t, f.svc.Export(usageTestContext(1), "u1", &buf))
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	contents := make(map[string][]byte)
+	for _, file := range zr.File {
+		rc, err := file.Open()
+		require.NoError(t, err)
+		data, err := io.ReadAll(rc)
+		require.NoError(t, err)
+		rc.Close()
+		contents[file.Name] = data
+	}
+
+	var manifest types.DataSubjectExportManifest
+	require.NoError(t, json.Unmarshal(contents["manifest.json"], &manifest))
+	assert.Equal(t, "u1", manifest.UserID)
+	assert.Len(t, manifest.Files, len(contents)-1)
+	for _, file := range manifest.Files {
+		sum := sha256.Sum256(contents[file.Name])
+		assert.Equal(t, hex.EncodeToString(sum[:]), file.SHA256, file.Name)
+	}
+
+	var sessions []types.DataSubjectSession
+	require.NoError(t, json.Unmarshal(contents["sessions.json"], &sessions))
+	require.Len(t, sessions, 2)
+	assert.Len(t, sessions[0].Messages, 2)
+	assert.Len(t, sessions[1].Messages, 1)
+	assert.Equal(t, "original u1-1-doc", string(contents["knowledge/u1-1-doc/report.pdf"]))
+	assert.Contains(t, string(contents["profile.json"]), "u1@Example.com")
+	assert.Contains(t, string(contents["shared_memory.json"]), "team likes coffee")
+	assert.Contains(t, string(contents["knowledge_acl.json"]), "u1-1-acl2")
+	assert.Contains(t, string(contents["user_groups.json"]), "team u1-1")
+	assert.Contains(t, string(contents["scim.json"]), "ext-u1-1")
+	assert.Contains(t, string(contents["memory.json"]), "likes tea")
+	assert.Contains(t, string(contents["feedback.json"]), "im-u1-1")
+	assert.Contains(t, string(contents["api_keys.json"]), "key u1-1")
+	for name, data := range contents {
+		assert.NotContains(t, string(data), "sk-secret", name)
+		assert.NotContains(t, string(data), "u2-1", name)
+	}
+
+	require.Len(t, f.audit.entries, 1)
+	assert.Equal(t, types.AuditActionDataSubjectExported, f.audit.entries[0].Action)
+}
@@ -15,6 +15,7 @@ import (
 
 	"github.com/Tencent/WeKnora/internal/datasource"
 	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/metrics"
 	"github.com/Tencent/WeKnora/internal/tracing/langfuse"
 	"github.com/Tencent/WeKnora/internal/types"
 	"github.com/Tencent/WeKnora/internal/types/interfaces"
@@ -34,6 +35,7 @@ type DataSourceService struct {
 	tenantRepo        interfaces.TenantRepository
 	tagService        interfaces.KnowledgeTagService
 	audit             interfaces.AuditLogService
+	aclService        interfaces.KnowledgeACLService
 }
 
 // NewDataSourceService creates a new data source service
@@ -48,6 +50,7 @@ func NewDataSourceService(
 	tenantRepo interfaces.TenantRepository,
 	tagService interfaces.KnowledgeTagService,
 	audit interfaces.AuditLogService,
+	aclService interfaces.KnowledgeACLService,
 ) interfaces.DataSourceService {
 	return &DataSourceService{
 		dsRepo:            dsRepo,
@@ -60,6 +63,7 @@ func NewDataSourceService(
 		tenantRepo:        tenantRepo,
 		tagService:        tagService,
 		audit:             audit,
+		aclService:        aclService,
 	}
 }
 
@@ -616,6 +620,17 @@ func (s *DataSourceService) ProcessSync(ctx context.Context, task *asynq.Task) e
 		return nil
 	}
 
+	// Every exit path below settles syncLog.Status; one still "running" means
+	// the run stopped without recording an outcome (e.g. worker shutdown).
+	syncStarted := time.Now()
+	defer func() {
+		status := syncLog.Status
+		if status == types.SyncLogStatusRunning {
+			status = "interrupted"
+		}
+		metrics.ObserveDatasourceSync(ds.Type, status, time.Since(syncStarted))
+	}()
+
 	kb, kbErr := s.kbService.GetKnowledgeBaseByID(ctx, ds.KnowledgeBaseID)
 	if kbErr != nil {
 		logger.Warnf(ctx, "knowledge base not found (likely deleted), cancelling sync: kb=%s ds=%s err=%v",
@@ -1257,6 +1272,12 @@ func (s *DataSourceService) ingestItem(ctx context.Context, ds *types.DataSource
 
 	// Check if a knowledge item with this external_id already exists → delete it first (update)
 	isUpdate := false
+	// replacedID is the knowledge an update deleted. Its version history is
+	// kept through the delete and handed to whatever replaces it.
+	replacedID := ""
+	// replacedACL holds the entries of the replaced knowledge, read before the
+	// delete purges them, so manual grants survive the re-create.
+	var replacedACL []*types.KnowledgeACLEntry
 	if item.ExternalID != "" {
 		repo := s.knowledgeService.GetRepository()
 		// Scope the lookup to items owned by this data source so identical
@@ -1268,13 +1289,15 @@ func (s *DataSourceService) ingestItem(ctx context.Context, ds *types.DataSource
 			// Non-fatal: proceed with creation (may produce duplicate)
 		} else if existing != nil {
 			logger.Infof(ctx, "found existing knowledge %s for external_id=%s, deleting for update", existing.ID, item.ExternalID)
-			if err := s.knowledgeService.DeleteKnowledge(ctx, existing.ID); err != nil {
+			replacedACL = s.knowledgeACLEntries(ctx, existing.ID)
+			if err := s.knowledgeService.DeleteKnowledge(withKnowledgeVersionsRetained(ctx), existing.ID); err != nil {
 				logger.Warnf(ctx, "failed to delete existing knowledge %s: %v", existing.ID, err)
 			} else {
 				if herr := repo.HardDeleteKnowledge(ctx, ds.TenantID, existing.ID); herr != nil {
 					logger.Warnf(ctx, "failed to hard-delete replaced knowledge %s: %v", existing.ID, herr)
 				}
 				isUpdate = true
+				replacedID = existing.ID
 			}
 		}
 	}
@@ -1285,7 +1308,7 @@ func (s *DataSourceService) ingestItem(ctx context.Context, ds *types.DataSource
 		if err != nil {
 			return isUpdate, fmt.Errorf("build file header: %w", err)
 		}
-		if _, err := s.knowledgeService.CreateKnowledgeFromFile(
+		created, err := s.knowledgeService.CreateKnowledgeFromFile(
 			ctx,
 			ds.KnowledgeBaseID,
 			fh,
@@ -1295,7 +1318,9 @@ func (s *DataSourceService) ingestItem(ctx context.Context, ds *types.DataSource
 			tagIDs,        // auto-tag from data source
 			channel,
 			nil,
-		); err != nil {
+		)
+		if err != nil {
+			s.handOverVersions(ctx, replacedID, nil, err, item)
 			var dupErr *types.DuplicateKnowledgeError
 			if errors.As(err, &dupErr) && dupIsSameNode(dupErr, item) {
 				// Identical content is already present in the KB under THIS node's
@@ -1305,6 +1330,10 @@ func (s *DataSourceService) ingestItem(ctx context.Context, ds *types.DataSource
 			}
 			return isUpdate, err
 		}
+		s.handOverVersions(ctx, replacedID, created, nil, item)
+		if err := s.syncKnowledgeACL(ctx, created, metadata, replacedACL); err != nil {
+			return isUpdate, err
+		}
 		s.sweepStaleSubtree(ctx, ds, item)
 		return isUpdate, nil
 	}
@@ -1324,6 +1353,7 @@ func (s *DataSourceService) ingestItem(ctx context.Context, ds *types.DataSource
 			nil,
 		)
 		if err != nil {
+			s.handOverVersions(ctx, replacedID, nil, err, item)
 			var dupErr *types.DuplicateKnowledgeError
 			if errors.As(err, &dupErr) && dupIsSameNode(dupErr, item) {
 				// Identical content is already present in the KB under THIS node's
@@ -1342,10 +1372,15 @@ func (s *DataSourceService) ingestItem(ctx context.Context, ds *types.DataSource
 				return isUpdate, fmt.Errorf("marshal datasource metadata: %w", mErr)
 			}
 			created.Metadata = types.JSON(metadataBytes)
+			created.ApplyFreshnessMetadata(metadata)
 			if uErr := s.knowledgeService.GetRepository().UpdateKnowledge(ctx, created); uErr != nil {
 				return isUpdate, fmt.Errorf("attach datasource metadata: %w", uErr)
 			}
 		}
+		s.handOverVersions(ctx, replacedID, created, nil, item)
+		if err := s.syncKnowledgeACL(ctx, created, metadata, replacedACL); err != nil {
+			return isUpdate, err
+		}
 		s.sweepStaleSubtree(ctx, ds, item)
 		return isUpdate, nil
 	}
@@ -1353,6 +1388,64 @@ func (s *DataSourceService) ingestItem(ctx context.Context, ds *types.DataSource
 	return isUpdate, fmt.Errorf("item has neither content nor URL")
 }
 
+// knowledgeACLEntries returns the access list of a knowledge item that is
+// about to be replaced. A lookup failure only loses its manual grants; the
+// connector grants come back from the item's metadata.
+func (s *DataSourceService) knowledgeACLEntries(ctx context.Context, knowledgeID string) []*types.KnowledgeACLEntry {
+	if s.aclService == nil {
+		return nil
+	}
+	acl, err := s.aclService.GetKnowledgeACL(ctx, knowledgeID)
+	if err != nil {
+		logger.Warnf(ctx, "failed to read ACL of replaced knowledge %s: %v", knowledgeID, err)
+		return nil
+	}
+	return acl.Entries
+}
+
+// syncKnowledgeACL applies the upstream permissions the connector put in the
+// item metadata (acl_* keys) to the created knowledge. Unlike the other
+// post-create steps a failure fails the item and removes the knowledge again:
+// it would otherwise be readable by the whole knowledge base until the next
+// sync.
+func (s *DataSourceService) syncKnowledgeACL(
+	ctx context.Context, created *types.Knowledge, metadata map[string]string, replaced []*types.KnowledgeACLEntry,
+) error {
+	if s.aclService == nil || created == nil {
+		return nil
+	}
+	if err := s.aclService.SyncConnectorACL(ctx, created, metadata, replaced); err != nil {
+		if derr := s.knowledgeService.DeleteKnowledge(ctx, created.ID); derr != nil {
+			logger.Errorf(ctx, "failed to remove knowledge %s after its ACL sync failed: %v", created.ID, derr)
+		}
+		return fmt.Errorf("sync knowledge ACL: %w", err)
+	}
+	return nil
+}
+
+// handOverVersions moves the version history of a knowledge item an update
+// replaced onto its replacement: the created item, or the existing item of
+// this node that a duplicate-content error points at. When nothing replaced
+// it, the history is dropped with the item.
+func (s *DataSourceService) handOverVersions(
+	ctx context.Context, replacedID string, created *types.Knowledge, createErr error, item *types.FetchedItem,
+) {
+	if replacedID == "" {
+		return
+	}
+	target := ""
+	if created != nil {
+		target = created.ID
+	}
+	var dupErr *types.DuplicateKnowledgeError
+	if errors.As(createErr, &dupErr) && dupIsSameNode(dupErr, item) {
+		target = dupErr.Knowledge.ID
+	}
+	if err := s.knowledgeService.TransferKnowledgeVersions(ctx, replacedID, target); err != nil {
+		logger.Warnf(ctx, "failed to hand over versions of replaced knowledge %s: %v", replacedID, err)
+	}
+}
+
 // dupIsSameNode reports whether a duplicate-content error means the parent still
 // exists in the KB *under this item's own external_id* — i.e. a content-dedup hit
 // against this same node, so reconciling its subtree is safe. File deduplication
@@ -350,3 +350,42 @@ func TestApplyFetchedItem_EmbeddedImageIngestFailureCountsAsSkip(t *testing.T) {
 			resDoc.Failed, resDoc.Skipped)
 	}
 }
+
+type failingACLService struct {
+	interfaces.KnowledgeACLService
+	synced []string
+}
+
+func (a *failingACLService) SyncConnectorACL(
+	_ context.Context, knowledge *types.Knowledge, _ map[string]string, _ []*types.KnowledgeACLEntry,
+) error {
+	a.synced = append(a.synced, knowledge.ID)
+	return errors.New("acl store unavailable")
+}
+
+// TestIngestItem_ACLSyncFailureRemovesKnowledge verifies that a document whose
+// upstream permissions could not be stored is not left behind readable by the
+// whole knowledge base.
+func TestIngestItem_ACLSyncFailureRemovesKnowledge(t *testing.T) {
+	ks := &sweepFakeKS{repo: &sweepFakeRepo{}}
+	acl := &failingACLService{}
+	s := &DataSourceService{knowledgeService: ks, aclService: acl}
+	ds := &types.DataSource{ID: "ds-1", TenantID: 1, KnowledgeBaseID: "kb-1", Type: "feishu"}
+	item := &types.FetchedItem{
+		ExternalID: "doc-1",
+		FileName:   "doc.md",
+		Content:    []byte("# secret"),
+		Metadata:   map[string]string{types.KnowledgeACLMetadataEmails: "alice@example.com"},
+	}
+
+	_, err := s.ingestItem(context.Background(), ds, item, nil)
+	if err == nil {
+		t.Fatal("expected ingestItem to fail when the ACL cannot be stored")
+	}
+	if len(acl.synced) != 1 || acl.synced[0] != "new-knowledge" {
+		t.Fatalf("SyncConnectorACL calls = %v, want [new-knowledge]", acl.synced)
+	}
+	if len(ks.deleted) != 1 || ks.deleted[0] != "new-knowledge" {
+		t.Fatalf("deleted = %v, want [new-knowledge]", ks.deleted)
+	}
+}
@@ -0,0 +1,318 @@
+package file
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/textproto"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	secutils "github.com/Tencent/WeKnora/internal/utils"
+)
+
+// encryptedFormMaxMemory is how much of a re-encoded upload ReadForm keeps in
+// memory before spilling to a temp file, matching gin's default.
+const encryptedFormMaxMemory = 32 << 20
+
+// ErrEncryptedFileNoKeys is returned when an encrypted object is read but no
+// encryption key provider is configured.
+var ErrEncryptedFileNoKeys = errors.New("file: object is encrypted but no encryption key provider is configured")
+
+// Encryption holds the tenant data keys used to encrypt stored objects. The
+// container builds one (see container.newFileEncryption) and it is passed to
+// every file service built through this package. A nil *Encryption, or one
+// without keys, encrypts nothing but still refuses to return encrypted
+// objects as ciphertext.
+type Encryption struct {
+	keys   interfaces.TenantDataKeyProvider
+	writes bool
+}
+
+// NewEncryption creates the file encryption. keys == nil disables it
+// entirely; otherwise existing encrypted objects are readable and new writes
+// are encrypted only when writes is true.
+func NewEncryption(keys interfaces.TenantDataKeyProvider, writes bool) *Encryption {
+	return &Encryption{keys: keys, writes: keys != nil && writes}
+}
+
+func (e *Encryption) current() (interfaces.TenantDataKeyProvider, bool) {
+	if e == nil {
+		return nil, false
+	}
+	return e.keys, e.writes
+}
+
+// encryptedFileService encrypts objects with the owning tenant's data key
+// before they reach the provider driver, and decrypts them on read. Objects
+// written before encryption was turned on carry no envelope header and pass
+// through unchanged, so enabling it needs no migration.
+type encryptedFileService struct {
+	inner       interfaces.FileService
+	enc         *Encryption
+	externalURL string
+}
+
+// Wrap decorates a provider driver with envelope encryption.
+func (e *Encryption) Wrap(inner interfaces.FileService) interfaces.FileService {
+	if inner == nil {
+		return nil
+	}
+	if _, ok := inner.(*encryptedFileService); ok {
+		return inner
+	}
+	return &encryptedFileService{
+		inner:       inner,
+		enc:         e,
+		externalURL: strings.TrimSpace(os.Getenv("APP_EXTERNAL_URL")),
+	}
+}
+
+func (s *encryptedFileService) CheckConnectivity(ctx context.Context) error {
+	return s.inner.CheckConnectivity(ctx)
+}
+
+func (s *encryptedFileService) SaveFile(
+	ctx context.Context, file *multipart.FileHeader, tenantID uint64, knowledgeID string,
+) (string, error) {
+	keys, writes := s.enc.current()
+	if !writes {
+		return s.inner.SaveFile(ctx, file, tenantID, knowledgeID)
+	}
+	version, key, err := keys.ActiveDataKey(ctx, tenantID)
+	if err != nil {
+		return "", fmt.Errorf("encrypt file: %w", err)
+	}
+	src, err := file.Open()
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+	sealed, cleanup, err := rebuildFileHeader(file, func(w io.Writer) error {
+		return secutils.EncryptEnvelopeStream(w, src, key, tenantID, version)
+	})
+	if err != nil {
+		return "", fmt.Errorf("encrypt file: %w", err)
+	}
+	defer cleanup()
+	return s.inner.SaveFile(ctx, sealed, tenantID, knowledgeID)
+}
+
+// rebuildFileHeader builds an upload whose content is produced by write,
+// through a multipart round trip: FileHeader has no public constructor, and
+// the drivers rely on its Size being the stored length, which changes with
+// the envelope overhead.
+func rebuildFileHeader(
+	orig *multipart.FileHeader, write func(io.Writer) error,
+) (*multipart.FileHeader, func(), error) {
+	pr, pw := io.Pipe()
+	mw := multipart.NewWriter(pw)
+	go func() {
+		h := make(textproto.MIMEHeader)
+		h.Set("Content-Disposition", multipart.FileContentDisposition("file", orig.Filename))
+		if ct := orig.Header.Get("Content-Type"); ct != "" {
+			h.Set("Content-Type", ct)
+		}
+		part, err := mw.CreatePart(h)
+		if err == nil {
+			err = write(part)
+		}
+		if err == nil {
+			err = mw.Close()
+		}
+		pw.CloseWithError(err)
+	}()
+	form, err := multipart.NewReader(pr, mw.Boundary()).ReadForm(encryptedFormMaxMemory)
+	// Drain on failure so the writer goroutine never blocks.
+	_, _ = io.Copy(io.Discard, pr)
+	if err != nil {
+		return nil, nil, err
+	}
+	cleanup := func() { _ = form.RemoveAll() }
+	files := form.File["file"]
+	if len(files) != 1 {
+		cleanup()
+		return nil, nil, errors.New("re-encoded upload is missing its file part")
+	}
+	return files[0], cleanup, nil
+}
+
+func (s *encryptedFileService) SaveBytes(
+	ctx context.Context, data []byte, tenantID uint64, fileName string, temp bool,
+) (string, error) {
+	keys, writes := s.enc.current()
+	if !writes {
+		return s.inner.SaveBytes(ctx, data, tenantID, fileName, temp)
+	}
+	version, key, err := keys.ActiveDataKey(ctx, tenantID)
+	if err != nil {
+		return "", fmt.Errorf("encrypt file: %w", err)
+	}
+	sealed, err := secutils.EncryptEnvelopeBytes(data, key, tenantID, version)
+	if err != nil {
+		return "", fmt.Errorf("encrypt file: %w", err)
+	}
+	return s.inner.SaveBytes(ctx, sealed, tenantID, fileName, temp)
+}
+
+func (s *encryptedFileService) GetFile(ctx context.Context, filePath string) (io.ReadCloser, error) {
+	rc, err := s.inner.GetFile(ctx, filePath)
+	if err != nil {
+		return nil, err
+	}
+	plain, err := s.decrypting(ctx, rc)
+	if err != nil {
+		rc.Close()
+		return nil, fmt.Errorf("decrypt %s: %w", filePath, err)
+	}
+	return plain, nil
+}
+
+// decrypting returns rc unchanged for plaintext objects and a decrypting
+// reader for enveloped ones. The header names the tenant and key version,
+// so objects shared or copied across tenants still open with the right key.
+func (s *encryptedFileService) decrypting(ctx context.Context, rc io.ReadCloser) (io.ReadCloser, error) {
+	br := bufio.NewReader(rc)
+	head, _ := br.Peek(secutils.EnvelopeMagicSize)
+	if !secutils.IsEnvelopeStream(head) {
+		return readCloser{Reader: br, Closer: rc}, nil
+	}
+	keys, _ := s.enc.current()
+	if keys == nil {
+		return nil, ErrEncryptedFileNoKeys
+	}
+	h, err := secutils.ReadEnvelopeHeader(br)
+	if err != nil {
+		return nil, err
+	}
+	key, err := keys.DataKey(ctx, h.TenantID, h.KeyVersion)
+	if err != nil {
+		return nil, err
+	}
+	dec, err := secutils.NewEnvelopeDecryptReader(br, h, key)
+	if err != nil {
+		return nil, err
+	}
+	return readCloser{Reader: dec, Closer: rc}, nil
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// GetFileURL keeps clients off direct object store URLs while encryption is
+// configured: those would serve ciphertext. The app's presigned proxy reads
+// through GetFile instead. Drivers that already return a proxy URL (local)
+// or a bare path are passed through.
+func (s *encryptedFileService) GetFileURL(ctx context.Context, filePath string) (string, error) {
+	result, err := s.inner.GetFileURL(ctx, filePath)
+	if err != nil {
+		return "", err
+	}
+	if keys, _ := s.enc.current(); keys == nil || !isDirectObjectURL(result) {
+		return result, nil
+	}
+	if s.externalURL != "" {
+		signed, signErr := secutils.SignFileURL(
+			s.externalURL, filePath, secutils.ParseTenantIDFromStoragePath(filePath), 0)
+		if signErr == nil {
+			return signed, nil
+		}
+		logger.Warnf(ctx, "[crypto] sign proxy URL for %s: %v", filePath, signErr)
+	}
+	// No proxy available: a direct URL is still fine for plaintext objects.
+	encrypted, err := s.isEncrypted(ctx, filePath)
+	if err != nil {
+		return "", err
+	}
+	if encrypted {
+		logger.Warnf(ctx, "[crypto] %s is encrypted and APP_EXTERNAL_URL is unset; no download URL available", filePath)
+		return filePath, nil
+	}
+	return result, nil
+}
+
+func isDirectObjectURL(u string) bool {
+	parsed, err := url.Parse(u)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return false
+	}
+	return !strings.HasSuffix(parsed.Path, "/api/v1/files/presigned")
+}
+
+func (s *encryptedFileService) isEncrypted(ctx context.Context, filePath string) (bool, error) {
+	rc, err := s.inner.GetFile(ctx, filePath)
+	if err != nil {
+		return false, err
+	}
+	defer rc.Close()
+	head := make([]byte, secutils.EnvelopeMagicSize)
+	n, _ := io.ReadFull(rc, head)
+	return secutils.IsEnvelopeStream(head[:n]), nil
+}
+
+func (s *encryptedFileService) DeleteFile(ctx context.Context, filePath string) error {
+	return s.inner.DeleteFile(ctx, filePath)
+}
+
+// CopyFile is a server-side copy within a tenant. A copy into another tenant
+// is re-encrypted with that tenant's key, so it never depends on the source
+// tenant's keys surviving.
+func (s *encryptedFileService) CopyFile(
+	ctx context.Context, srcPath string, tenantID uint64, knowledgeID string,
+) (string, error) {
+	keys, writes := s.enc.current()
+	if keys == nil {
+		return s.inner.CopyFile(ctx, srcPath, tenantID, knowledgeID)
+	}
+	rc, err := s.inner.GetFile(ctx, srcPath)
+	if err != nil {
+		return "", err
+	}
+	defer rc.Close()
+	br := bufio.NewReader(rc)
+	head, _ := br.Peek(secutils.EnvelopeMagicSize)
+	if !secutils.IsEnvelopeStream(head) {
+		return s.inner.CopyFile(ctx, srcPath, tenantID, knowledgeID)
+	}
+	hdr, err := secutils.ReadEnvelopeHeader(br)
+	if err != nil {
+		return "", err
+	}
+	if hdr.TenantID == tenantID {
+		return s.inner.CopyFile(ctx, srcPath, tenantID, knowledgeID)
+	}
+	srcKey, err := keys.DataKey(ctx, hdr.TenantID, hdr.KeyVersion)
+	if err != nil {
+		return "", err
+	}
+	plain, err := secutils.NewEnvelopeDecryptReader(br, hdr, srcKey)
+	if err != nil {
+		return "", err
+	}
+	write := func(w io.Writer) error { _, err := io.Copy(w, plain); return err }
+	if writes {
+		version, key, err := keys.ActiveDataKey(ctx, tenantID)
+		if err != nil {
+			return "", err
+		}
+		write = func(w io.Writer) error {
+			return secutils.EncryptEnvelopeStream(w, plain, key, tenantID, version)
+		}
+	}
+	// With writes off the copy lands in plaintext, like any other new file.
+	rebuilt, cleanup, err := rebuildFileHeader(&multipart.FileHeader{Filename: path.Base(srcPath)}, write)
+	if err != nil {
+		return "", err
+	}
+	defer cleanup()
+	return s.inner.SaveFile(ctx, rebuilt, tenantID, knowledgeID)
+}
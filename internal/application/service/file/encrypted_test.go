@@ -0,0 +1,127 @@
+package file
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	secutils "github.com/Tencent/WeKnora/internal/utils"
+)
+
+// fakeDataKeys hands out one random key per (tenant, version); the active
+// version of every tenant is 1 unless bumped.
+type fakeDataKeys struct {
+	keys   map[string][]byte
+	active map[uint64]int
+}
+
+func newFakeDataKeys() *fakeDataKeys {
+	return &fakeDataKeys{keys: map[string][]byte{}, active: map[uint64]int{}}
+}
+
+func (f *fakeDataKeys) DataKey(_ context.Context, tenantID uint64, version int) ([]byte, error) {
+	ref := fmt.Sprintf("%d/%d", tenantID, version)
+	if key, ok := f.keys[ref]; ok {
+		return key, nil
+	}
+	key := make([]byte, 32)
+	_, _ = rand.Read(key)
+	f.keys[ref] = key
+	return key, nil
+}
+
+func (f *fakeDataKeys) ActiveDataKey(ctx context.Context, tenantID uint64) (int, []byte, error) {
+	version := f.active[tenantID]
+	if version == 0 {
+		version = 1
+	}
+	key, err := f.DataKey(ctx, tenantID, version)
+	return version, key, err
+}
+
+func uploadHeader(t *testing.T, name string, data []byte) *multipart.FileHeader {
+	t.Helper()
+	fh, cleanup, err := rebuildFileHeader(&multipart.FileHeader{Filename: name}, func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	})
+	require.NoError(t, err)
+	t.Cleanup(cleanup)
+	return fh
+}
+
+func TestEncryptedFileService_RoundTrip(t *testing.T) {
+	keys := newFakeDataKeys()
+	raw := NewLocalFileService(t.TempDir(), "")
+	svc := NewEncryption(keys, true).Wrap(raw)
+	ctx := context.Background()
+	content := bytes.Repeat([]byte("confidential "), 20000)
+
+	p, err := svc.SaveFile(ctx, uploadHeader(t, "report.txt", content), 7, "kb-1")
+	require.NoError(t, err)
+	stored := readLocal(t, raw, p)
+	assert.True(t, secutils.IsEnvelopeStream(stored), "object should be stored encrypted")
+	assert.NotContains(t, string(stored), "confidential")
+	assert.Equal(t, content, readLocal(t, svc, p))
+
+	p, err = svc.SaveBytes(ctx, []byte("small"), 7, "note.txt", false)
+	require.NoError(t, err)
+	assert.True(t, secutils.IsEnvelopeStream(readLocal(t, raw, p)))
+	assert.Equal(t, []byte("small"), readLocal(t, svc, p))
+
+	// A rotated tenant still reads objects sealed with version 1.
+	keys.active[7] = 2
+	assert.Equal(t, []byte("small"), readLocal(t, svc, p))
+}
+
+// TestEncryptedFileService_PlaintextPassThrough covers objects stored before
+// encryption was enabled, and writes while only reads are enabled.
+func TestEncryptedFileService_PlaintextPassThrough(t *testing.T) {
+	base := t.TempDir()
+	raw := NewLocalFileService(base, "")
+	svc := NewEncryption(newFakeDataKeys(), false).Wrap(raw)
+
+	legacy := seedLocalObject(t, base, 0, "legacy.txt", []byte("old plaintext"))
+	assert.Equal(t, []byte("old plaintext"), readLocal(t, svc, legacy))
+
+	p, err := svc.SaveBytes(context.Background(), []byte("new plaintext"), 3, "n.txt", false)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("new plaintext"), readLocal(t, raw, p))
+}
+
+func TestEncryptedFileService_NoKeysRejectsEncryptedObject(t *testing.T) {
+	keys := newFakeDataKeys()
+	raw := NewLocalFileService(t.TempDir(), "")
+	svc := NewEncryption(keys, true).Wrap(raw)
+	p, err := svc.SaveBytes(context.Background(), []byte("secret"), 1, "s.txt", false)
+	require.NoError(t, err)
+
+	_, err = NewEncryption(nil, false).Wrap(raw).GetFile(context.Background(), p)
+	assert.ErrorIs(t, err, ErrEncryptedFileNoKeys)
+}
+
+// TestEncryptedFileService_CopyAcrossTenants checks that a cross-tenant copy
+// is re-sealed with the destination tenant's key.
+func TestEncryptedFileService_CopyAcrossTenants(t *testing.T) {
+	keys := newFakeDataKeys()
+	raw := NewLocalFileService(t.TempDir(), "")
+	svc := NewEncryption(keys, true).Wrap(raw)
+	ctx := context.Background()
+
+	src, err := svc.SaveFile(ctx, uploadHeader(t, "doc.txt", []byte("shared doc")), 1, "kb-a")
+	require.NoError(t, err)
+	dst, err := svc.CopyFile(ctx, src, 2, "kb-b")
+	require.NoError(t, err)
+
+	h, err := secutils.ReadEnvelopeHeader(bytes.NewReader(readLocal(t, raw, dst)))
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), h.TenantID)
+	assert.Equal(t, []byte("shared doc"), readLocal(t, svc, dst))
+}
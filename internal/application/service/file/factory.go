@@ -14,10 +14,24 @@ import (
 // NewFileServiceFromStorageConfig builds a provider-specific FileService from tenant storage config.
 // provider can be empty; in that case it falls back to sec.DefaultProvider.
 // Returns the resolved provider name together with the service.
+// The driver is wrapped with envelope encryption (see Encryption).
 func NewFileServiceFromStorageConfig(
 	provider string,
 	sec *types.StorageEngineConfig,
 	localBaseDir string,
+	enc *Encryption,
+) (interfaces.FileService, string, error) {
+	svc, p, err := newProviderFileService(provider, sec, localBaseDir)
+	if err != nil {
+		return svc, p, err
+	}
+	return enc.Wrap(svc), p, nil
+}
+
+func newProviderFileService(
+	provider string,
+	sec *types.StorageEngineConfig,
+	localBaseDir string,
 ) (interfaces.FileService, string, error) {
 	p := strings.ToLower(strings.TrimSpace(provider))
 	if p == "" && sec != nil {
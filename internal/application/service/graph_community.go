@@ -0,0 +1,904 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/application/repository"
+	"github.com/Tencent/WeKnora/internal/common"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/models/chat"
+	"github.com/Tencent/WeKnora/internal/tracing/langfuse"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	// graphCommunityRebuildDelay is the debounce window after the last graph
+	// extraction. Ingesting a document extracts every chunk separately; one
+	// rebuild after the burst is what we want, not one per chunk.
+	graphCommunityRebuildDelay = 2 * time.Minute
+	graphCommunityMaxRetry     = 3
+	// graphCommunityMaxLevels bounds the hierarchy depth. Deeper levels are
+	// rarely distinct enough from their neighbours to be worth a report.
+	graphCommunityMaxLevels = 4
+	// graphCommunityMaxReports bounds model calls per rebuild. When a graph
+	// has more communities than this, the finest levels are dropped first:
+	// coarse reports are what global questions need.
+	graphCommunityMaxReports = 200
+	// graphCommunityReportConcurrency matches the entity extraction fan-out.
+	graphCommunityReportConcurrency = 4
+	// graphCommunityContextRunes is the budget for the entity and relation
+	// listing one report call sees. Past it, child reports stand in for
+	// their members.
+	graphCommunityContextRunes = 12000
+	// graphCommunityMaxStoredEntities caps Entities and ChunkIDs on the stored
+	// row; the counts stay exact.
+	graphCommunityMaxStoredEntities = 50
+	graphCommunityReportTokens      = 1500
+
+	// graphGlobalDefaultCommunities bounds how many reports one global search
+	// maps over when the request does not say.
+	graphGlobalDefaultCommunities = 24
+	// graphGlobalMapBatch is how many reports share one map call.
+	graphGlobalMapBatch       = 4
+	graphGlobalMapConcurrency = 4
+	graphGlobalMapTokens      = 1200
+	// graphGlobalMaxPoints caps the points handed to reduce, best first.
+	graphGlobalMaxPoints    = 30
+	graphGlobalReduceTokens = 2000
+)
+
+// ErrGraphCommunityDisabled is returned when communities are requested for a
+// knowledge base that does not extract a knowledge graph.
+var ErrGraphCommunityDisabled = errors.New("knowledge graph is not enabled for this knowledge base")
+
+type graphCommunityService struct {
+	kbRepo       interfaces.KnowledgeBaseRepository
+	graphEngine  interfaces.RetrieveGraphRepository
+	repo         interfaces.GraphCommunityRepository
+	modelService interfaces.ModelService
+	task         interfaces.TaskEnqueuer
+}
+
+// NewGraphCommunityService creates the graph community service.
+func NewGraphCommunityService(
+	kbRepo interfaces.KnowledgeBaseRepository,
+	graphEngine interfaces.RetrieveGraphRepository,
+	repo interfaces.GraphCommunityRepository,
+	modelService interfaces.ModelService,
+	task interfaces.TaskEnqueuer,
+) interfaces.GraphCommunityService {
+	return &graphCommunityService{
+		kbRepo:       kbRepo,
+		graphEngine:  graphEngine,
+		repo:         repo,
+		modelService: modelService,
+		task:         task,
+	}
+}
+
+func graphCommunityEnabled() bool {
+	return strings.ToLower(os.Getenv("NEO4J_ENABLE")) == "true"
+}
+
+// ScheduleRebuild debounces a rebuild per knowledge base. The fixed task ID
+// coalesces every extraction that lands while one is already waiting.
+func (s *graphCommunityService) ScheduleRebuild(ctx context.Context, tenantID uint64, kbID string) {
+	if !graphCommunityEnabled() || s.task == nil || kbID == "" {
+		return
+	}
+	err := s.enqueue(ctx, tenantID, kbID, "graph-community-"+kbID, graphCommunityRebuildDelay)
+	if err != nil && !errors.Is(err, asynq.ErrTaskIDConflict) && !errors.Is(err, asynq.ErrDuplicateTask) {
+		logger.Warnf(ctx, "graph community: schedule rebuild for %s failed: %v", kbID, err)
+	}
+}
+
+// RequestRebuild queues a rebuild that runs as soon as a worker is free.
+// A click while one is already queued is coalesced rather than reported.
+func (s *graphCommunityService) RequestRebuild(ctx context.Context, kbID string) error {
+	tenantID := types.MustTenantIDFromContext(ctx)
+	kb, err := s.kbRepo.GetKnowledgeBaseByIDAndTenant(ctx, kbID, tenantID)
+	if err != nil {
+		return err
+	}
+	if !graphCommunityEnabled() || !kb.IsGraphEnabled() {
+		return ErrGraphCommunityDisabled
+	}
+	err = s.enqueue(ctx, tenantID, kbID, "graph-community-manual-"+kbID, 0)
+	if errors.Is(err, asynq.ErrTaskIDConflict) || errors.Is(err, asynq.ErrDuplicateTask) {
+		return nil
+	}
+	return err
+}
+
+func (s *graphCommunityService) enqueue(
+	ctx context.Context, tenantID uint64, kbID, taskID string, delay time.Duration,
+) error {
+	language, _ := types.LanguageFromContext(ctx)
+	payload := types.GraphCommunityPayload{
+		TenantID:        tenantID,
+		KnowledgeBaseID: kbID,
+		Language:        language,
+	}
+	langfuse.InjectTracing(ctx, &payload)
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	opts := []asynq.Option{
+		asynq.Queue(types.QueueGraph),
+		asynq.MaxRetry(graphCommunityMaxRetry),
+		asynq.Timeout(60 * time.Minute),
+		asynq.TaskID(taskID),
+	}
+	if delay > 0 {
+		opts = append(opts, asynq.ProcessIn(delay))
+	}
+	_, err = s.task.Enqueue(asynq.NewTask(types.TypeGraphCommunity, b, opts...))
+	return err
+}
+
+func (s *graphCommunityService) ListCommunities(
+	ctx context.Context, kbID string, level int,
+) ([]*types.GraphCommunity, error) {
+	return s.repo.ListByKnowledgeBase(ctx, types.MustTenantIDFromContext(ctx), kbID, level)
+}
+
+func (s *graphCommunityService) GetCommunity(
+	ctx context.Context, kbID string, id string,
+) (*types.GraphCommunity, error) {
+	return s.repo.GetByID(ctx, types.MustTenantIDFromContext(ctx), kbID, id)
+}
+
+// Handle rebuilds the whole community hierarchy of one knowledge base.
+func (s *graphCommunityService) Handle(ctx context.Context, t *asynq.Task) error {
+	var p types.GraphCommunityPayload
+	if err := json.Unmarshal(t.Payload(), &p); err != nil {
+		logger.Errorf(ctx, "graph community: failed to unmarshal task payload: %v", err)
+		return err
+	}
+	ctx = logger.WithRequestID(ctx, uuid.New().String())
+	ctx = logger.WithField(ctx, "graph_community", p.KnowledgeBaseID)
+	ctx = context.WithValue(ctx, types.TenantIDContextKey, p.TenantID)
+	if p.Language != "" {
+		ctx = context.WithValue(ctx, types.LanguageContextKey, p.Language)
+	}
+
+	kb, err := s.kbRepo.GetKnowledgeBaseByIDAndTenant(ctx, p.KnowledgeBaseID, p.TenantID)
+	if err != nil {
+		if errors.Is(err, repository.ErrKnowledgeBaseNotFound) {
+			logger.Infof(ctx, "graph community: knowledge base %s is gone, skipping", p.KnowledgeBaseID)
+			return nil
+		}
+		return err
+	}
+	if !kb.IsGraphEnabled() {
+		return s.repo.DeleteByKnowledgeBase(ctx, p.TenantID, kb.ID)
+	}
+
+	graph, err := s.graphEngine.GetGraph(ctx, types.NameSpace{KnowledgeBase: kb.ID})
+	if err != nil {
+		return fmt.Errorf("read knowledge graph: %w", err)
+	}
+	cg := buildCommunityGraph(graph)
+	levels := detectCommunityLevels(cg.weights, graphCommunityMaxLevels)
+	drafts := cg.draftCommunities(levels)
+	if len(drafts) == 0 {
+		logger.Infof(ctx, "graph community: no communities in %s", kb.ID)
+		return s.repo.DeleteByKnowledgeBase(ctx, p.TenantID, kb.ID)
+	}
+	if kb.SummaryModelID == "" {
+		logger.Warnf(ctx, "graph community: knowledge base %s has no summary model, skipping", kb.ID)
+		return nil
+	}
+	chatModel, err := s.modelService.GetChatModel(ctx, kb.SummaryModelID)
+	if err != nil {
+		return fmt.Errorf("get summary model: %w", err)
+	}
+
+	reports := s.writeReports(ctx, chatModel, cg, drafts, types.ResolveLanguageName(ctx, p.Language))
+	if len(reports) == 0 {
+		return fmt.Errorf("graph community: all %d report calls failed", len(drafts))
+	}
+	s.embedReports(ctx, kb, reports)
+	for _, c := range reports {
+		c.TenantID = p.TenantID
+		c.KnowledgeBaseID = kb.ID
+	}
+	if err := s.repo.ReplaceForKnowledgeBase(ctx, p.TenantID, kb.ID, reports); err != nil {
+		return fmt.Errorf("store communities: %w", err)
+	}
+	logger.Infof(ctx, "graph community: stored %d communities over %d levels for %s",
+		len(reports), len(levels), kb.ID)
+	return nil
+}
+
+// communityEntity is one entity of the merged graph.
+type communityEntity struct {
+	name       string
+	attributes []string
+	chunks     []string
+	aliases    []string
+	degree     int
+}
+
+type communityRelation struct {
+	source, target int
+	kind           string
+}
+
+// communityGraph is the KB graph with per-document duplicates merged: the
+// same entity extracted from two documents is two Neo4j nodes but one
+// entity for the purposes of a report.
+type communityGraph struct {
+	entities  []*communityEntity
+	relations []communityRelation
+	weights   *weightedGraph
+}
+
+func communityEntityKey(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+func buildCommunityGraph(graph *types.GraphData) *communityGraph {
+	cg := &communityGraph{}
+	if graph == nil {
+		cg.weights = newWeightedGraph(0)
+		return cg
+	}
+	byKey := make(map[string]*communityEntity)
+	for _, node := range graph.Node {
+		key := communityEntityKey(node.Name)
+		if key == "" {
+			continue
+		}
+		e, ok := byKey[key]
+		if !ok {
+			e = &communityEntity{name: strings.TrimSpace(node.Name)}
+			byKey[key] = e
+		}
+		e.attributes = appendUniqueStrings(e.attributes, node.Attributes...)
+		e.chunks = appendUniqueStrings(e.chunks, node.Chunks...)
+		e.aliases = appendUniqueStrings(e.aliases, node.Aliases...)
+	}
+	// Sorting gives every entity a stable index, which is what makes the
+	// partition reproducible across rebuilds of an unchanged graph.
+	keys := make([]string, 0, len(byKey))
+	for key := range byKey {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	index := make(map[string]int, len(keys))
+	for i, key := range keys {
+		index[key] = i
+		cg.entities = append(cg.entities, byKey[key])
+	}
+
+	cg.weights = newWeightedGraph(len(cg.entities))
+	for _, rel := range graph.Relation {
+		a, okA := index[communityEntityKey(rel.Node1)]
+		b, okB := index[communityEntityKey(rel.Node2)]
+		if !okA || !okB || a == b {
+			continue
+		}
+		cg.relations = append(cg.relations, communityRelation{source: a, target: b, kind: rel.Type})
+		cg.weights.addEdge(a, b, 1)
+		cg.entities[a].degree++
+		cg.entities[b].degree++
+	}
+	return cg
+}
+
+func appendUniqueStrings(list []string, values ...string) []string {
+	for _, v := range values {
+		if v == "" {
+			continue
+		}
+		found := false
+		for _, existing := range list {
+			if existing == v {
+				found = true
+				break
+			}
+		}
+		if !found {
+			list = append(list, v)
+		}
+	}
+	return list
+}
+
+// communityDraft is a detected community before its report is written.
+type communityDraft struct {
+	id       string
+	level    int // stored level: 0 is the coarsest
+	parent   *communityDraft
+	children []*communityDraft
+	members  []int // entity indexes, most connected first
+	report   *types.GraphCommunity
+}
+
+// draftCommunities turns detected levels (finest first) into drafts with
+// stored levels (coarsest first) and parent links. Single-entity communities
+// are dropped: there is nothing to say about them that the entity does not.
+// When there are more than graphCommunityMaxReports, the finest levels go.
+func (cg *communityGraph) draftCommunities(levels [][]int) []*communityDraft {
+	if len(levels) == 0 {
+		return nil
+	}
+	perLevel := make([]map[int]*communityDraft, len(levels))
+	var drafts []*communityDraft
+	for stored := 0; stored < len(levels); stored++ {
+		membership, _ := splitDisconnected(cg.weights, levels[len(levels)-1-stored])
+		groups := make(map[int][]int)
+		for node, c := range membership {
+			groups[c] = append(groups[c], node)
+		}
+		ids := make([]int, 0, len(groups))
+		for c, members := range groups {
+			if len(members) >= 2 {
+				ids = append(ids, c)
+			}
+		}
+		sort.Ints(ids)
+		if len(drafts)+len(ids) > graphCommunityMaxReports {
+			break
+		}
+		perLevel[stored] = make(map[int]*communityDraft, len(ids))
+		for _, c := range ids {
+			d := &communityDraft{id: uuid.New().String(), level: stored, members: groups[c]}
+			sort.SliceStable(d.members, func(i, j int) bool {
+				return cg.entities[d.members[i]].degree > cg.entities[d.members[j]].degree
+			})
+			if stored > 0 {
+				// Levels nest, so any member finds the parent. A parent
+				// can be missing only when it was a dropped singleton,
+				// which cannot contain a two-member child.
+				for _, parent := range perLevel[stored-1] {
+					if containsInt(parent.members, d.members[0]) {
+						d.parent = parent
+						parent.children = append(parent.children, d)
+						break
+					}
+				}
+			}
+			perLevel[stored][c] = d
+			drafts = append(drafts, d)
+		}
+	}
+	return drafts
+}
+
+func containsInt(list []int, v int) bool {
+	for _, x := range list {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
+
+// writeReports generates reports bottom-up so that a community too large to
+// list can be described through its children's reports. Failed reports are
+// logged and left out; a parent whose child failed falls back to entities.
+func (s *graphCommunityService) writeReports(
+	ctx context.Context, chatModel chat.Chat, cg *communityGraph, drafts []*communityDraft, language string,
+) []*types.GraphCommunity {
+	maxLevel := 0
+	for _, d := range drafts {
+		maxLevel = max(maxLevel, d.level)
+	}
+	ctx = types.WithLLMCallMetadata(ctx, "graph_community_report", "")
+	var mu sync.Mutex
+	for level := maxLevel; level >= 0; level-- {
+		g, gctx := errgroup.WithContext(ctx)
+		g.SetLimit(graphCommunityReportConcurrency)
+		for _, d := range drafts {
+			if d.level != level {
+				continue
+			}
+			g.Go(func() error {
+				report, err := s.writeReport(gctx, chatModel, cg, d, language)
+				if err != nil {
+					logger.Warnf(gctx, "graph community: report for level %d community failed: %v", d.level, err)
+					return nil
+				}
+				mu.Lock()
+				d.report = report
+				mu.Unlock()
+				return nil
+			})
+		}
+		_ = g.Wait()
+	}
+
+	var out []*types.GraphCommunity
+	for _, d := range drafts {
+		if d.report == nil {
+			continue
+		}
+		if d.parent != nil && d.parent.report != nil {
+			d.report.ParentID = d.parent.id
+		}
+		out = append(out, d.report)
+	}
+	return out
+}
+
+var communityReportSchema = json.RawMessage(`{
+  "type": "object",
+  "properties": {
+    "title": {"type": "string"},
+    "summary": {"type": "string"},
+    "rating": {"type": "number"},
+    "findings": {
+      "type": "array",
+      "items": {
+        "type": "object",
+        "properties": {
+          "summary": {"type": "string"},
+          "explanation": {"type": "string"}
+        },
+        "required": ["summary", "explanation"]
+      }
+    }
+  },
+  "required": ["title", "summary", "rating", "findings"]
+}`)
+
+const communityReportSystemPrompt = `You write reports about communities of a knowledge graph extracted from a document collection.
+A community is a group of closely related entities. Given its entities and relationships, write:
+- title: a short name for the community that names its most important entities
+- summary: an executive summary of what the community is about and how its entities relate
+- rating: 0-10, how important this community is for understanding the collection as a whole
+- findings: 3-8 key insights, each with a one-line summary and an explanation grounded in the data
+Only state what the data supports. Respond with a single JSON object.`
+
+func (s *graphCommunityService) writeReport(
+	ctx context.Context, chatModel chat.Chat, cg *communityGraph, d *communityDraft, language string,
+) (*types.GraphCommunity, error) {
+	thinking := false
+	response, err := chatModel.Chat(ctx, []chat.Message{
+		{Role: "system", Content: communityReportSystemPrompt},
+		{Role: "user", Content: cg.reportContext(d) + "\nWrite the report in " + language + "."},
+	}, &chat.ChatOptions{
+		Temperature:         0,
+		MaxCompletionTokens: graphCommunityReportTokens,
+		Thinking:            &thinking,
+		Format:              communityReportSchema,
+	})
+	if err != nil {
+		return nil, err
+	}
+	var parsed struct {
+		Title    string                        `json:"title"`
+		Summary  string                        `json:"summary"`
+		Rating   float64                       `json:"rating"`
+		Findings []types.GraphCommunityFinding `json:"findings"`
+	}
+	if err := common.ParseLLMJsonResponse(response.Content, &parsed); err != nil {
+		return nil, fmt.Errorf("parse report: %w", err)
+	}
+	if strings.TrimSpace(parsed.Summary) == "" {
+		return nil, fmt.Errorf("empty report")
+	}
+	title := strings.TrimSpace(parsed.Title)
+	if title == "" {
+		title = cg.entities[d.members[0]].name
+	}
+	entities, chunkIDs, relations := cg.memberDetails(d.members)
+	return &types.GraphCommunity{
+		ID:            d.id,
+		Level:         d.level,
+		Title:         truncateRunes(title, 500),
+		Summary:       strings.TrimSpace(parsed.Summary),
+		Findings:      parsed.Findings,
+		Rating:        min(max(parsed.Rating, 0), 10),
+		Entities:      entities,
+		EntityCount:   len(d.members),
+		RelationCount: relations,
+		ChunkIDs:      chunkIDs,
+	}, nil
+}
+
+// reportContext lists a community's entities and internal relations, most
+// connected first. When the listing outgrows the budget and the children
+// already have reports, those reports replace the raw listing.
+func (cg *communityGraph) reportContext(d *communityDraft) string {
+	var entities strings.Builder
+	entities.WriteString("Entities:\n")
+	for _, m := range d.members {
+		e := cg.entities[m]
+		fmt.Fprintf(&entities, "- %s (degree %d)", e.name, e.degree)
+		if len(e.attributes) > 0 {
+			fmt.Fprintf(&entities, ": %s", strings.Join(e.attributes, "; "))
+		}
+		entities.WriteString("\n")
+	}
+	var relations strings.Builder
+	relations.WriteString("Relationships:\n")
+	inside := make(map[int]bool, len(d.members))
+	for _, m := range d.members {
+		inside[m] = true
+	}
+	for _, r := range cg.relations {
+		if inside[r.source] && inside[r.target] {
+			fmt.Fprintf(&relations, "- %s -[%s]-> %s\n",
+				cg.entities[r.source].name, r.kind, cg.entities[r.target].name)
+		}
+	}
+	full := entities.String() + "\n" + relations.String()
+	if len([]rune(full)) <= graphCommunityContextRunes {
+		return full
+	}
+
+	var sub strings.Builder
+	for _, child := range d.children {
+		if child.report == nil {
+			continue
+		}
+		fmt.Fprintf(&sub, "## %s\n%s\n\n", child.report.Title, child.report.Summary)
+	}
+	if sub.Len() == 0 {
+		return truncateRunes(full, graphCommunityContextRunes)
+	}
+	out := "Sub-community reports:\n" + sub.String() + entities.String()
+	return truncateRunes(out, graphCommunityContextRunes)
+}
+
+// memberDetails returns the stored entity and chunk lists and the number of
+// relations between members.
+func (cg *communityGraph) memberDetails(members []int) ([]string, []string, int) {
+	var names, chunks []string
+	inside := make(map[int]bool, len(members))
+	for _, m := range members {
+		inside[m] = true
+		if len(names) < graphCommunityMaxStoredEntities {
+			names = append(names, cg.entities[m].name)
+		}
+		for _, c := range cg.entities[m].chunks {
+			if len(chunks) >= graphCommunityMaxStoredEntities {
+				break
+			}
+			chunks = appendUniqueStrings(chunks, c)
+		}
+	}
+	relations := 0
+	for _, r := range cg.relations {
+		if inside[r.source] && inside[r.target] {
+			relations++
+		}
+	}
+	return names, chunks, relations
+}
+
+// embedReports attaches vectors for query ranking. Failure only costs
+// ranking quality, so it is logged and the reports are stored without.
+func (s *graphCommunityService) embedReports(ctx context.Context, kb *types.KnowledgeBase, reports []*types.GraphCommunity) {
+	if kb.EmbeddingModelID == "" {
+		return
+	}
+	embedder, err := s.modelService.GetEmbeddingModel(ctx, kb.EmbeddingModelID)
+	if err != nil {
+		logger.Warnf(ctx, "graph community: get embedding model: %v", err)
+		return
+	}
+	texts := make([]string, len(reports))
+	for i, c := range reports {
+		texts[i] = c.Title + "\n" + c.Summary
+	}
+	vectors, err := embedder.BatchEmbed(ctx, texts)
+	if err != nil || len(vectors) != len(reports) {
+		logger.Warnf(ctx, "graph community: embed reports: %v", err)
+		return
+	}
+	for i, c := range reports {
+		c.Vector = types.EncodeEmbedding(vectors[i])
+		c.EmbeddingModelID = kb.EmbeddingModelID
+	}
+}
+
+// GlobalSearch is the map-reduce over community reports described in the
+// GraphRAG paper: rank reports against the question, ask the model for rated
+// points from each batch, keep the best points and optionally reduce them.
+func (s *graphCommunityService) GlobalSearch(
+	ctx context.Context, req *types.GraphGlobalSearchRequest,
+) (*types.GraphGlobalSearchResult, error) {
+	if req == nil || strings.TrimSpace(req.Query) == "" || len(req.KnowledgeBaseIDs) == 0 {
+		return &types.GraphGlobalSearchResult{}, nil
+	}
+	kbs, err := s.kbRepo.GetKnowledgeBaseByIDs(ctx, req.KnowledgeBaseIDs)
+	if err != nil {
+		return nil, err
+	}
+	limit := req.MaxCommunities
+	if limit <= 0 {
+		limit = graphGlobalDefaultCommunities
+	}
+
+	candidates, modelID := s.rankCommunities(ctx, kbs, req, limit)
+	result := &types.GraphGlobalSearchResult{Communities: len(candidates)}
+	if len(candidates) == 0 {
+		return result, nil
+	}
+	if req.ChatModelID != "" {
+		modelID = req.ChatModelID
+	}
+	if modelID == "" {
+		return nil, fmt.Errorf("no chat model for global search")
+	}
+	chatModel, err := s.modelService.GetChatModel(ctx, modelID)
+	if err != nil {
+		return nil, fmt.Errorf("get chat model: %w", err)
+	}
+	language := types.ResolveLanguageName(ctx, req.Language)
+
+	points, err := s.mapCommunities(ctx, chatModel, req.Query, candidates, language)
+	if err != nil {
+		return nil, err
+	}
+	result.Points = points
+	if req.Reduce && len(points) > 0 {
+		answer, err := s.reducePoints(ctx, chatModel, req.Query, points, language)
+		if err != nil {
+			return nil, err
+		}
+		result.Answer = answer
+	}
+	return result, nil
+}
+
+// rankCommunities picks, per knowledge base, the level to search and then
+// the best communities overall. It also returns the first summary model
+// seen, the fallback for the map and reduce calls.
+func (s *graphCommunityService) rankCommunities(
+	ctx context.Context, kbs []*types.KnowledgeBase, req *types.GraphGlobalSearchRequest, limit int,
+) ([]*types.GraphCommunity, string) {
+	type ranked struct {
+		community *types.GraphCommunity
+		score     float64
+	}
+	var (
+		pool     []ranked
+		modelID  string
+		queryVec = make(map[string][]float32)
+	)
+	for _, kb := range kbs {
+		if !kb.IsGraphEnabled() {
+			continue
+		}
+		if modelID == "" {
+			modelID = kb.SummaryModelID
+		}
+		communities, err := s.repo.ListByKnowledgeBase(ctx, kb.TenantID, kb.ID, -1)
+		if err != nil {
+			logger.Warnf(ctx, "graph global search: list communities of %s: %v", kb.ID, err)
+			continue
+		}
+		communities = selectCommunityLevel(communities, req.Level, limit)
+		for _, c := range communities {
+			score := c.Rating / 10 * 0.2
+			if len(c.Vector) > 0 && c.EmbeddingModelID != "" {
+				key := fmt.Sprintf("%d/%s", kb.TenantID, c.EmbeddingModelID)
+				vec, ok := queryVec[key]
+				if !ok {
+					vec = s.embedQuery(ctx, kb.TenantID, c.EmbeddingModelID, req.Query)
+					queryVec[key] = vec
+				}
+				if vec != nil {
+					score += types.CosineSimilarity(vec, types.DecodeEmbedding(c.Vector)) * 0.8
+				}
+			}
+			pool = append(pool, ranked{community: c, score: score})
+		}
+	}
+	sort.SliceStable(pool, func(i, j int) bool { return pool[i].score > pool[j].score })
+	if len(pool) > limit {
+		pool = pool[:limit]
+	}
+	out := make([]*types.GraphCommunity, len(pool))
+	for i, r := range pool {
+		out[i] = r.community
+	}
+	return out, modelID
+}
+
+// selectCommunityLevel keeps one level. A pinned level is used as is;
+// otherwise the finest level that fits the limit is the best trade between
+// detail and coverage, falling back to the coarsest.
+func selectCommunityLevel(communities []*types.GraphCommunity, level, limit int) []*types.GraphCommunity {
+	counts := make(map[int]int)
+	maxLevel := 0
+	for _, c := range communities {
+		counts[c.Level]++
+		maxLevel = max(maxLevel, c.Level)
+	}
+	if level < 0 {
+		level = 0
+		for l := maxLevel; l >= 0; l-- {
+			if counts[l] > 0 && counts[l] <= limit {
+				level = l
+				break
+			}
+		}
+	}
+	var out []*types.GraphCommunity
+	for _, c := range communities {
+		if c.Level == level {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+func (s *graphCommunityService) embedQuery(ctx context.Context, tenantID uint64, modelID, query string) []float32 {
+	embedder, err := s.modelService.GetEmbeddingModelForTenant(ctx, modelID, tenantID)
+	if err != nil {
+		logger.Warnf(ctx, "graph global search: get embedding model: %v", err)
+		return nil
+	}
+	vec, err := embedder.Embed(ctx, query)
+	if err != nil {
+		logger.Warnf(ctx, "graph global search: embed query: %v", err)
+		return nil
+	}
+	return vec
+}
+
+var globalMapSchema = json.RawMessage(`{
+  "type": "object",
+  "properties": {
+    "points": {
+      "type": "array",
+      "items": {
+        "type": "object",
+        "properties": {
+          "report": {"type": "integer"},
+          "description": {"type": "string"},
+          "score": {"type": "integer"}
+        },
+        "required": ["report", "description", "score"]
+      }
+    }
+  },
+  "required": ["points"]
+}`)
+
+const globalMapSystemPrompt = `You help answer a question about a whole document collection from reports about communities of its knowledge graph.
+From the numbered reports, extract the key points that help answer the question. For each point give:
+- report: the number of the report it comes from
+- description: the point, self-contained and specific
+- score: 0-100, how much the point helps answer the question
+Return an empty list when the reports are irrelevant. Respond with a single JSON object.`
+
+func (s *graphCommunityService) mapCommunities(
+	ctx context.Context, chatModel chat.Chat, query string, communities []*types.GraphCommunity, language string,
+) ([]*types.GraphGlobalSearchPoint, error) {
+	ctx = types.WithLLMCallMetadata(ctx, "graph_global_map", "")
+	var (
+		mu     sync.Mutex
+		points []*types.GraphGlobalSearchPoint
+		failed int
+	)
+	batches := 0
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(graphGlobalMapConcurrency)
+	for start := 0; start < len(communities); start += graphGlobalMapBatch {
+		batch := communities[start:min(start+graphGlobalMapBatch, len(communities))]
+		batches++
+		g.Go(func() error {
+			got, err := s.mapBatch(gctx, chatModel, query, batch, language)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				logger.Warnf(gctx, "graph global search: map batch failed: %v", err)
+				failed++
+				return nil
+			}
+			points = append(points, got...)
+			return nil
+		})
+	}
+	_ = g.Wait()
+	if failed == batches {
+		return nil, fmt.Errorf("graph global search: all %d map calls failed", batches)
+	}
+	sort.SliceStable(points, func(i, j int) bool { return points[i].Score > points[j].Score })
+	if len(points) > graphGlobalMaxPoints {
+		points = points[:graphGlobalMaxPoints]
+	}
+	return points, nil
+}
+
+func (s *graphCommunityService) mapBatch(
+	ctx context.Context, chatModel chat.Chat, query string, batch []*types.GraphCommunity, language string,
+) ([]*types.GraphGlobalSearchPoint, error) {
+	var prompt strings.Builder
+	for i, c := range batch {
+		fmt.Fprintf(&prompt, "Report %d: %s\n%s\n", i+1, c.Title, c.Summary)
+		for _, f := range c.Findings {
+			fmt.Fprintf(&prompt, "- %s: %s\n", f.Summary, f.Explanation)
+		}
+		prompt.WriteString("\n")
+	}
+	fmt.Fprintf(&prompt, "Question: %s\nWrite the points in %s.", query, language)
+
+	thinking := false
+	response, err := chatModel.Chat(ctx, []chat.Message{
+		{Role: "system", Content: globalMapSystemPrompt},
+		{Role: "user", Content: prompt.String()},
+	}, &chat.ChatOptions{
+		Temperature:         0,
+		MaxCompletionTokens: graphGlobalMapTokens,
+		Thinking:            &thinking,
+		Format:              globalMapSchema,
+	})
+	if err != nil {
+		return nil, err
+	}
+	var parsed struct {
+		Points []struct {
+			Report      int    `json:"report"`
+			Description string `json:"description"`
+			Score       int    `json:"score"`
+		} `json:"points"`
+	}
+	if err := common.ParseLLMJsonResponse(response.Content, &parsed); err != nil {
+		return nil, fmt.Errorf("parse map response: %w", err)
+	}
+	var out []*types.GraphGlobalSearchPoint
+	for _, p := range parsed.Points {
+		if p.Score <= 0 || p.Report < 1 || p.Report > len(batch) || strings.TrimSpace(p.Description) == "" {
+			continue
+		}
+		c := batch[p.Report-1]
+		out = append(out, &types.GraphGlobalSearchPoint{
+			CommunityID:     c.ID,
+			KnowledgeBaseID: c.KnowledgeBaseID,
+			CommunityTitle:  c.Title,
+			Level:           c.Level,
+			Description:     strings.TrimSpace(p.Description),
+			Score:           min(p.Score, 100),
+		})
+	}
+	return out, nil
+}
+
+const globalReduceSystemPrompt = `You answer a question about a whole document collection from rated key points that analysts extracted from reports about it.
+Points with higher scores are more relevant. Merge what the points say into a well-structured answer, drop what is irrelevant,
+and say so plainly when the points do not answer the question. Do not invent facts beyond the points.`
+
+func (s *graphCommunityService) reducePoints(
+	ctx context.Context, chatModel chat.Chat, query string, points []*types.GraphGlobalSearchPoint, language string,
+) (string, error) {
+	var prompt strings.Builder
+	for _, p := range points {
+		fmt.Fprintf(&prompt, "- [score %d, %s] %s\n", p.Score, p.CommunityTitle, p.Description)
+	}
+	fmt.Fprintf(&prompt, "\nQuestion: %s\nAnswer in %s.", query, language)
+
+	thinking := false
+	response, err := chatModel.Chat(types.WithLLMCallMetadata(ctx, "graph_global_reduce", ""), []chat.Message{
+		{Role: "system", Content: globalReduceSystemPrompt},
+		{Role: "user", Content: prompt.String()},
+	}, &chat.ChatOptions{
+		Temperature:         DefaultLLMTemperature,
+		MaxCompletionTokens: graphGlobalReduceTokens,
+		Thinking:            &thinking,
+	})
+	if err != nil {
+		return "", fmt.Errorf("reduce call: %w", err)
+	}
+	return strings.TrimSpace(response.Content), nil
+}
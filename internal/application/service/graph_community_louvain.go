@@ -0,0 +1,263 @@
+package service
+
+import "sort"
+
+// Community detection for GraphRAG global search.
+//
+// This is the Louvain method: repeatedly move single nodes to the neighbouring
+// community with the best modularity gain, then collapse each community into
+// one node and repeat on the smaller graph. Every collapse is one level of the
+// hierarchy, and because a collapsed node is never split again the levels nest
+// by construction, which is what the report hierarchy relies on.
+//
+// Leiden refines each community before collapsing so that none comes out
+// internally disconnected. Extracted entity graphs are small and sparse
+// enough that the difference rarely shows, and the refinement step would more
+// than double this file; disconnected communities are split afterwards
+// instead (see splitDisconnected), which is the property reports care about.
+
+const (
+	// louvainMaxPasses bounds local-move sweeps per level. Louvain converges in
+	// a handful of sweeps; the bound only guards against oscillation.
+	louvainMaxPasses = 32
+	// louvainMinGain ignores moves whose gain is floating-point noise, which
+	// would otherwise let two equivalent communities trade a node forever.
+	louvainMinGain = 1e-12
+)
+
+// weightedGraph is an undirected weighted graph over dense node indexes.
+// adj[i][i] holds a self-loop, which only appears in collapsed graphs and
+// stands for the edges inside the community the node replaced.
+type weightedGraph struct {
+	adj []map[int]float64
+}
+
+func newWeightedGraph(n int) *weightedGraph {
+	adj := make([]map[int]float64, n)
+	for i := range adj {
+		adj[i] = make(map[int]float64)
+	}
+	return &weightedGraph{adj: adj}
+}
+
+// addEdge adds weight to the undirected edge (a, b). Self-loops are ignored
+// here; only collapse creates them.
+func (g *weightedGraph) addEdge(a, b int, weight float64) {
+	if a == b || weight <= 0 {
+		return
+	}
+	g.adj[a][b] += weight
+	g.adj[b][a] += weight
+}
+
+func (g *weightedGraph) size() int { return len(g.adj) }
+
+// degree counts a self-loop twice, as both of its ends touch the node.
+func (g *weightedGraph) degree(i int) float64 {
+	var k float64
+	for j, w := range g.adj[i] {
+		if j == i {
+			k += 2 * w
+		} else {
+			k += w
+		}
+	}
+	return k
+}
+
+// sortedNeighbors returns neighbour indexes in ascending order so that a
+// given graph always produces the same partition.
+func (g *weightedGraph) sortedNeighbors(i int) []int {
+	out := make([]int, 0, len(g.adj[i]))
+	for j := range g.adj[i] {
+		out = append(out, j)
+	}
+	sort.Ints(out)
+	return out
+}
+
+// detectCommunityLevels runs hierarchical Louvain and returns one membership
+// slice per level, finest first. levels[l][node] is the community of an
+// original node at level l; community ids are dense from 0 within a level.
+// A graph with no edges has no structure to report and yields no levels.
+func detectCommunityLevels(g *weightedGraph, maxLevels int) [][]int {
+	n := g.size()
+	if n == 0 || maxLevels <= 0 {
+		return nil
+	}
+	hasEdge := false
+	for i := 0; i < n && !hasEdge; i++ {
+		for j := range g.adj[i] {
+			if j != i {
+				hasEdge = true
+				break
+			}
+		}
+	}
+	if !hasEdge {
+		return nil
+	}
+
+	// membership maps every original node to its node in the current graph.
+	membership := make([]int, n)
+	for i := range membership {
+		membership[i] = i
+	}
+	var levels [][]int
+	current := g
+	for len(levels) < maxLevels {
+		partition, moved := louvainLocalMoves(current)
+		if !moved {
+			break
+		}
+		partition, count := renumberPartition(partition)
+		if count == current.size() {
+			break
+		}
+		level := make([]int, n)
+		for node, at := range membership {
+			level[node] = partition[at]
+		}
+		levels = append(levels, level)
+		membership = level
+		if count == 1 {
+			break
+		}
+		current = collapseGraph(current, partition, count)
+	}
+	return levels
+}
+
+// louvainLocalMoves is phase one of Louvain: starting from singletons, move
+// each node to the neighbouring community with the best modularity gain
+// until a full sweep moves nothing.
+func louvainLocalMoves(g *weightedGraph) ([]int, bool) {
+	n := g.size()
+	community := make([]int, n)
+	degree := make([]float64, n)
+	total := make([]float64, n) // sum of degrees per community
+	var m2 float64
+	for i := 0; i < n; i++ {
+		community[i] = i
+		degree[i] = g.degree(i)
+		total[i] = degree[i]
+		m2 += degree[i]
+	}
+	if m2 == 0 {
+		return community, false
+	}
+
+	movedAny := false
+	for pass := 0; pass < louvainMaxPasses; pass++ {
+		moved := false
+		for i := 0; i < n; i++ {
+			// Weight from i into each neighbouring community, self-loop excluded.
+			links := make(map[int]float64)
+			var candidates []int
+			for _, j := range g.sortedNeighbors(i) {
+				if j == i {
+					continue
+				}
+				c := community[j]
+				if _, seen := links[c]; !seen {
+					candidates = append(candidates, c)
+				}
+				links[c] += g.adj[i][j]
+			}
+
+			old := community[i]
+			total[old] -= degree[i]
+			best := old
+			bestGain := links[old] - total[old]*degree[i]/m2
+			for _, c := range candidates {
+				gain := links[c] - total[c]*degree[i]/m2
+				if gain > bestGain+louvainMinGain {
+					best, bestGain = c, gain
+				}
+			}
+			total[best] += degree[i]
+			if best != old {
+				community[i] = best
+				moved = true
+				movedAny = true
+			}
+		}
+		if !moved {
+			break
+		}
+	}
+	return community, movedAny
+}
+
+// renumberPartition maps community labels to dense ids in order of first
+// appearance and returns the community count.
+func renumberPartition(partition []int) ([]int, int) {
+	ids := make(map[int]int)
+	out := make([]int, len(partition))
+	for i, c := range partition {
+		id, ok := ids[c]
+		if !ok {
+			id = len(ids)
+			ids[c] = id
+		}
+		out[i] = id
+	}
+	return out, len(ids)
+}
+
+// collapseGraph is phase two of Louvain: one node per community, edges summed,
+// and the weight inside each community kept as a self-loop so degrees and
+// therefore modularity are preserved across levels.
+func collapseGraph(g *weightedGraph, partition []int, count int) *weightedGraph {
+	out := newWeightedGraph(count)
+	for i := 0; i < g.size(); i++ {
+		ci := partition[i]
+		for j, w := range g.adj[i] {
+			cj := partition[j]
+			switch {
+			case j == i:
+				out.adj[ci][ci] += w
+			case ci == cj:
+				// Each internal edge is visited from both ends.
+				if i < j {
+					out.adj[ci][ci] += w
+				}
+			default:
+				out.adj[ci][cj] += w
+			}
+		}
+	}
+	return out
+}
+
+// splitDisconnected splits every community of a level into its connected
+// components within g. Louvain can leave a community held together only
+// through nodes that later moved out; a report about two unrelated clusters
+// is worse than two reports. Returns the new membership and community count.
+func splitDisconnected(g *weightedGraph, level []int) ([]int, int) {
+	n := len(level)
+	out := make([]int, n)
+	for i := range out {
+		out[i] = -1
+	}
+	next := 0
+	for start := 0; start < n; start++ {
+		if out[start] >= 0 {
+			continue
+		}
+		out[start] = next
+		stack := []int{start}
+		for len(stack) > 0 {
+			i := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			for _, j := range g.sortedNeighbors(i) {
+				if out[j] < 0 && level[j] == level[i] {
+					out[j] = next
+					stack = append(stack, j)
+				}
+			}
+		}
+		next++
+	}
+	return out, next
+}
@@ -0,0 +1,96 @@
+package service
+
+import "testing"
+
+// twoCliquesGraph builds two 4-cliques joined by a single bridge edge 3-4.
+func twoCliquesGraph() *weightedGraph {
+	g := newWeightedGraph(8)
+	for _, clique := range [][]int{{0, 1, 2, 3}, {4, 5, 6, 7}} {
+		for i := range clique {
+			for j := i + 1; j < len(clique); j++ {
+				g.addEdge(clique[i], clique[j], 1)
+			}
+		}
+	}
+	g.addEdge(3, 4, 1)
+	return g
+}
+
+func TestDetectCommunityLevelsSeparatesCliques(t *testing.T) {
+	levels := detectCommunityLevels(twoCliquesGraph(), 4)
+	if len(levels) == 0 {
+		t.Fatal("expected at least one level")
+	}
+	finest := levels[0]
+	for _, i := range []int{1, 2, 3} {
+		if finest[i] != finest[0] {
+			t.Fatalf("node %d not grouped with node 0: %v", i, finest)
+		}
+	}
+	for _, i := range []int{5, 6, 7} {
+		if finest[i] != finest[4] {
+			t.Fatalf("node %d not grouped with node 4: %v", i, finest)
+		}
+	}
+	if finest[0] == finest[4] {
+		t.Fatalf("cliques merged at the finest level: %v", finest)
+	}
+}
+
+func TestDetectCommunityLevelsIsDeterministic(t *testing.T) {
+	first := detectCommunityLevels(twoCliquesGraph(), 4)
+	for run := 0; run < 10; run++ {
+		again := detectCommunityLevels(twoCliquesGraph(), 4)
+		if len(again) != len(first) {
+			t.Fatalf("run %d: %d levels, want %d", run, len(again), len(first))
+		}
+		for l := range first {
+			for i := range first[l] {
+				if again[l][i] != first[l][i] {
+					t.Fatalf("run %d: level %d differs: %v vs %v", run, l, again[l], first[l])
+				}
+			}
+		}
+	}
+}
+
+func TestDetectCommunityLevelsNests(t *testing.T) {
+	g := newWeightedGraph(12)
+	// Three triangles in a ring, plus a pendant triangle hanging off the ring.
+	for base := 0; base < 12; base += 3 {
+		g.addEdge(base, base+1, 1)
+		g.addEdge(base+1, base+2, 1)
+		g.addEdge(base, base+2, 1)
+	}
+	g.addEdge(2, 3, 1)
+	g.addEdge(5, 6, 1)
+	g.addEdge(8, 0, 1)
+	g.addEdge(9, 0, 1)
+
+	levels := detectCommunityLevels(g, 4)
+	for l := 1; l < len(levels); l++ {
+		parentOf := map[int]int{}
+		for node, c := range levels[l-1] {
+			if p, ok := parentOf[c]; ok && p != levels[l][node] {
+				t.Fatalf("level %d community %d splits across level %d", l-1, c, l)
+			}
+			parentOf[c] = levels[l][node]
+		}
+	}
+}
+
+func TestDetectCommunityLevelsWithoutEdges(t *testing.T) {
+	if levels := detectCommunityLevels(newWeightedGraph(3), 4); levels != nil {
+		t.Fatalf("expected no levels, got %v", levels)
+	}
+}
+
+func TestSplitDisconnected(t *testing.T) {
+	g := newWeightedGraph(4)
+	g.addEdge(0, 1, 1)
+	g.addEdge(2, 3, 1)
+	out, count := splitDisconnected(g, []int{0, 0, 0, 0})
+	if count != 2 || out[0] != out[1] || out[2] != out[3] || out[0] == out[2] {
+		t.Fatalf("unexpected split: %v (%d)", out, count)
+	}
+}
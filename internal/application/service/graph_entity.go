@@ -0,0 +1,500 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/application/repository"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/tracing/langfuse"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+)
+
+const (
+	// graphEntityMaxEmbedded bounds the entities compared by embedding. The
+	// comparison is all-pairs; past this, only the rules run.
+	graphEntityMaxEmbedded = 2000
+	graphEntityDefaultList = 100
+	graphEntityMaxList     = 1000
+)
+
+var (
+	// ErrGraphMergeCandidateNotFound is returned for an unknown candidate.
+	ErrGraphMergeCandidateNotFound = errors.New("merge candidate not found")
+	// ErrGraphMergeCandidateResolved is returned when reviewing a candidate
+	// that was already accepted or rejected.
+	ErrGraphMergeCandidateResolved = errors.New("merge candidate has already been reviewed")
+	// ErrGraphEntityInvalidRequest is returned for merge and split requests
+	// that name no entity to change.
+	ErrGraphEntityInvalidRequest = errors.New("invalid entity merge or split request")
+)
+
+type graphEntityService struct {
+	kbRepo      interfaces.KnowledgeBaseRepository
+	graphEngine interfaces.RetrieveGraphRepository
+	repo        interfaces.GraphEntityRepository
+	models      interfaces.ModelService
+	communities interfaces.GraphCommunityService
+	task        interfaces.TaskEnqueuer
+}
+
+// NewGraphEntityService creates the entity resolution service.
+func NewGraphEntityService(
+	kbRepo interfaces.KnowledgeBaseRepository,
+	graphEngine interfaces.RetrieveGraphRepository,
+	repo interfaces.GraphEntityRepository,
+	models interfaces.ModelService,
+	communities interfaces.GraphCommunityService,
+	task interfaces.TaskEnqueuer,
+) interfaces.GraphEntityService {
+	return &graphEntityService{
+		kbRepo:      kbRepo,
+		graphEngine: graphEngine,
+		repo:        repo,
+		models:      models,
+		communities: communities,
+		task:        task,
+	}
+}
+
+// graphKnowledgeBase loads a knowledge base of the request's tenant and
+// checks that it extracts a graph.
+func (s *graphEntityService) graphKnowledgeBase(ctx context.Context, kbID string) (*types.KnowledgeBase, error) {
+	kb, err := s.kbRepo.GetKnowledgeBaseByIDAndTenant(ctx, kbID, types.MustTenantIDFromContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	if !graphCommunityEnabled() || !kb.IsGraphEnabled() {
+		return nil, ErrGraphCommunityDisabled
+	}
+	return kb, nil
+}
+
+func (s *graphEntityService) ListEntities(
+	ctx context.Context, kbID string, keyword string, limit int,
+) ([]*types.GraphEntitySummary, error) {
+	kb, err := s.graphKnowledgeBase(ctx, kbID)
+	if err != nil {
+		return nil, err
+	}
+	graph, err := s.graphEngine.GetGraph(ctx, types.NameSpace{KnowledgeBase: kb.ID})
+	if err != nil {
+		return nil, fmt.Errorf("read knowledge graph: %w", err)
+	}
+	if limit <= 0 {
+		limit = graphEntityDefaultList
+	}
+	limit = min(limit, graphEntityMaxList)
+	keyword = strings.ToLower(strings.TrimSpace(keyword))
+
+	cg := buildCommunityGraph(graph)
+	out := make([]*types.GraphEntitySummary, 0, len(cg.entities))
+	for _, e := range cg.entities {
+		if keyword != "" && !entityMatchesKeyword(e, keyword) {
+			continue
+		}
+		out = append(out, &types.GraphEntitySummary{
+			Name:       e.name,
+			Aliases:    e.aliases,
+			Attributes: e.attributes,
+			ChunkIDs:   e.chunks,
+			Degree:     e.degree,
+		})
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Degree != out[j].Degree {
+			return out[i].Degree > out[j].Degree
+		}
+		return len(out[i].ChunkIDs) > len(out[j].ChunkIDs)
+	})
+	if len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
+
+func entityMatchesKeyword(e *communityEntity, keyword string) bool {
+	if strings.Contains(strings.ToLower(e.name), keyword) {
+		return true
+	}
+	for _, alias := range e.aliases {
+		if strings.Contains(strings.ToLower(alias), keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+// RequestResolution queues a resolution pass. A pass that is already queued
+// covers this request too.
+func (s *graphEntityService) RequestResolution(ctx context.Context, kbID string) error {
+	kb, err := s.graphKnowledgeBase(ctx, kbID)
+	if err != nil {
+		return err
+	}
+	payload := types.GraphEntityResolutionPayload{
+		TenantID:        kb.TenantID,
+		KnowledgeBaseID: kb.ID,
+	}
+	langfuse.InjectTracing(ctx, &payload)
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	_, err = s.task.Enqueue(asynq.NewTask(types.TypeGraphEntityResolution, b,
+		asynq.Queue(types.QueueGraph),
+		asynq.MaxRetry(1),
+		asynq.Timeout(30*time.Minute),
+		asynq.TaskID("graph-entity-resolution-"+kb.ID),
+	))
+	if errors.Is(err, asynq.ErrTaskIDConflict) || errors.Is(err, asynq.ErrDuplicateTask) {
+		return nil
+	}
+	return err
+}
+
+func (s *graphEntityService) ListCandidates(
+	ctx context.Context, kbID string, status string,
+) ([]*types.GraphMergeCandidate, error) {
+	return s.repo.ListCandidates(ctx, types.MustTenantIDFromContext(ctx), kbID, status)
+}
+
+func (s *graphEntityService) pendingCandidate(
+	ctx context.Context, kbID string, id string,
+) (*types.GraphMergeCandidate, error) {
+	candidate, err := s.repo.GetCandidate(ctx, types.MustTenantIDFromContext(ctx), kbID, id)
+	if err != nil {
+		return nil, err
+	}
+	if candidate == nil {
+		return nil, ErrGraphMergeCandidateNotFound
+	}
+	if candidate.Status != types.GraphMergeCandidatePending {
+		return nil, ErrGraphMergeCandidateResolved
+	}
+	return candidate, nil
+}
+
+func (s *graphEntityService) AcceptCandidate(
+	ctx context.Context, kbID string, id string, canonical string,
+) (*types.GraphMergeCandidate, error) {
+	kb, err := s.graphKnowledgeBase(ctx, kbID)
+	if err != nil {
+		return nil, err
+	}
+	candidate, err := s.pendingCandidate(ctx, kb.ID, id)
+	if err != nil {
+		return nil, err
+	}
+	switch canonical {
+	case "", candidate.Canonical:
+	case candidate.Alias:
+		candidate.Canonical, candidate.Alias = candidate.Alias, candidate.Canonical
+	default:
+		return nil, fmt.Errorf("%w: canonical must be %q or %q",
+			ErrGraphEntityInvalidRequest, candidate.Canonical, candidate.Alias)
+	}
+	if err := s.merge(ctx, kb, candidate.Canonical, []string{candidate.Alias}); err != nil {
+		return nil, err
+	}
+	s.resolve(ctx, candidate, types.GraphMergeCandidateAccepted)
+	if err := s.repo.UpdateCandidate(ctx, candidate); err != nil {
+		return nil, err
+	}
+	s.dropStaleCandidates(ctx, kb, candidate.Canonical, candidate.Alias)
+	return candidate, nil
+}
+
+func (s *graphEntityService) RejectCandidate(
+	ctx context.Context, kbID string, id string,
+) (*types.GraphMergeCandidate, error) {
+	candidate, err := s.pendingCandidate(ctx, kbID, id)
+	if err != nil {
+		return nil, err
+	}
+	s.resolve(ctx, candidate, types.GraphMergeCandidateRejected)
+	if err := s.repo.UpdateCandidate(ctx, candidate); err != nil {
+		return nil, err
+	}
+	return candidate, nil
+}
+
+func (s *graphEntityService) resolve(ctx context.Context, candidate *types.GraphMergeCandidate, status string) {
+	now := time.Now()
+	userID, _ := types.UserIDFromContext(ctx)
+	candidate.Status = status
+	candidate.ResolvedBy = userID
+	candidate.ResolvedAt = &now
+	candidate.UpdatedAt = now
+}
+
+func (s *graphEntityService) MergeEntities(
+	ctx context.Context, kbID string, req *types.GraphEntityMergeRequest,
+) error {
+	canonical := strings.TrimSpace(req.Canonical)
+	var names []string
+	for _, name := range req.Names {
+		if name = strings.TrimSpace(name); name != "" && name != canonical {
+			names = appendUniqueStrings(names, name)
+		}
+	}
+	if canonical == "" || len(names) == 0 {
+		return ErrGraphEntityInvalidRequest
+	}
+	kb, err := s.graphKnowledgeBase(ctx, kbID)
+	if err != nil {
+		return err
+	}
+	if err := s.merge(ctx, kb, canonical, names); err != nil {
+		return err
+	}
+	s.dropStaleCandidates(ctx, kb, append([]string{canonical}, names...)...)
+	return nil
+}
+
+// merge rewires the graph and records the aliases so later extractions of
+// the folded names land on the canonical node.
+func (s *graphEntityService) merge(ctx context.Context, kb *types.KnowledgeBase, canonical string, names []string) error {
+	if err := s.graphEngine.MergeNodes(ctx, types.NameSpace{KnowledgeBase: kb.ID}, canonical, names); err != nil {
+		return fmt.Errorf("merge graph nodes: %w", err)
+	}
+	if err := s.repo.SaveAliases(ctx, kb.TenantID, kb.ID, canonical, names); err != nil {
+		return fmt.Errorf("save entity aliases: %w", err)
+	}
+	logger.Infof(ctx, "graph entity: merged %v into %q in %s", names, canonical, kb.ID)
+	if s.communities != nil {
+		s.communities.ScheduleRebuild(ctx, kb.TenantID, kb.ID)
+	}
+	return nil
+}
+
+// dropStaleCandidates removes pending candidates that name merged entities;
+// the next resolution pass proposes them again under the surviving name.
+func (s *graphEntityService) dropStaleCandidates(ctx context.Context, kb *types.KnowledgeBase, names ...string) {
+	if err := s.repo.DeletePendingCandidatesFor(ctx, kb.TenantID, kb.ID, names); err != nil {
+		logger.Warnf(ctx, "graph entity: drop stale candidates: %v", err)
+	}
+}
+
+func (s *graphEntityService) SplitEntity(
+	ctx context.Context, kbID string, req *types.GraphEntitySplitRequest,
+) error {
+	name, newName := strings.TrimSpace(req.Name), strings.TrimSpace(req.NewName)
+	var chunkIDs []string
+	for _, id := range req.ChunkIDs {
+		if id = strings.TrimSpace(id); id != "" {
+			chunkIDs = appendUniqueStrings(chunkIDs, id)
+		}
+	}
+	if name == "" || newName == "" || communityEntityKey(name) == communityEntityKey(newName) || len(chunkIDs) == 0 {
+		return ErrGraphEntityInvalidRequest
+	}
+	kb, err := s.graphKnowledgeBase(ctx, kbID)
+	if err != nil {
+		return err
+	}
+	if err := s.graphEngine.SplitNode(ctx, types.NameSpace{KnowledgeBase: kb.ID}, name, newName, chunkIDs); err != nil {
+		return fmt.Errorf("split graph node: %w", err)
+	}
+	// Without this, the next extraction of newName would fold it back.
+	if err := s.repo.DeleteAlias(ctx, kb.TenantID, kb.ID, newName); err != nil {
+		return fmt.Errorf("delete entity alias: %w", err)
+	}
+	// A split is a decision that the two are different entities.
+	decision := &types.GraphMergeCandidate{
+		TenantID:        kb.TenantID,
+		KnowledgeBaseID: kb.ID,
+		PairKey:         types.GraphMergePairKey(communityEntityKey(name), communityEntityKey(newName)),
+		Canonical:       name,
+		Alias:           newName,
+		Score:           0,
+		Reason:          types.GraphMergeReasonManual,
+		CreatedAt:       time.Now(),
+	}
+	s.resolve(ctx, decision, types.GraphMergeCandidateRejected)
+	if err := s.repo.UpsertCandidateDecision(ctx, decision); err != nil {
+		logger.Warnf(ctx, "graph entity: record split decision: %v", err)
+	}
+	logger.Infof(ctx, "graph entity: split %d chunk(s) of %q into %q in %s", len(chunkIDs), name, newName, kb.ID)
+	if s.communities != nil {
+		s.communities.ScheduleRebuild(ctx, kb.TenantID, kb.ID)
+	}
+	return nil
+}
+
+func (s *graphEntityService) ChunkEntities(
+	ctx context.Context, kbID string, chunkIDs []string,
+) (*types.GraphData, error) {
+	if len(chunkIDs) == 0 {
+		return &types.GraphData{}, nil
+	}
+	return s.graphEngine.SearchNodeByChunks(ctx, types.NameSpace{KnowledgeBase: kbID}, chunkIDs)
+}
+
+// CanonicalizeGraph renames aliases to their canonical names and folds the
+// nodes and relations that become duplicates. A failed alias lookup leaves
+// the graph as extracted: a later merge can still fold it.
+func (s *graphEntityService) CanonicalizeGraph(
+	ctx context.Context, tenantID uint64, kbID string, graph *types.GraphData,
+) {
+	if graph == nil || (len(graph.Node) == 0 && len(graph.Relation) == 0) {
+		return
+	}
+	aliases, err := s.repo.ListAliases(ctx, tenantID, kbID)
+	if err != nil {
+		logger.Warnf(ctx, "graph entity: load aliases of %s: %v", kbID, err)
+		return
+	}
+	if len(aliases) == 0 {
+		return
+	}
+	canonical := make(map[string]string, len(aliases))
+	for _, a := range aliases {
+		canonical[a.AliasKey] = a.Canonical
+	}
+	canonicalizeGraphData(graph, canonical)
+}
+
+// canonicalizeGraphData applies an alias map (lower-cased alias to
+// canonical name) to extracted graph data in place.
+func canonicalizeGraphData(graph *types.GraphData, canonical map[string]string) {
+	rename := func(name string) string {
+		if to, ok := canonical[communityEntityKey(name)]; ok {
+			return to
+		}
+		return name
+	}
+
+	byName := make(map[string]*types.GraphNode, len(graph.Node))
+	nodes := graph.Node[:0]
+	for _, node := range graph.Node {
+		node.Name = rename(node.Name)
+		if existing, ok := byName[node.Name]; ok {
+			existing.Chunks = appendUniqueStrings(existing.Chunks, node.Chunks...)
+			existing.Attributes = appendUniqueStrings(existing.Attributes, node.Attributes...)
+			continue
+		}
+		byName[node.Name] = node
+		nodes = append(nodes, node)
+	}
+	graph.Node = nodes
+
+	seen := make(map[string]bool, len(graph.Relation))
+	relations := graph.Relation[:0]
+	for _, rel := range graph.Relation {
+		rel.Node1, rel.Node2 = rename(rel.Node1), rename(rel.Node2)
+		key := rel.Node1 + "\x1f" + rel.Type + "\x1f" + rel.Node2
+		if rel.Node1 == rel.Node2 || seen[key] {
+			continue
+		}
+		seen[key] = true
+		relations = append(relations, rel)
+	}
+	graph.Relation = relations
+}
+
+// Handle runs one resolution pass over a knowledge base and stores new
+// proposals for review.
+func (s *graphEntityService) Handle(ctx context.Context, t *asynq.Task) error {
+	var p types.GraphEntityResolutionPayload
+	if err := json.Unmarshal(t.Payload(), &p); err != nil {
+		logger.Errorf(ctx, "graph entity: failed to unmarshal task payload: %v", err)
+		return err
+	}
+	ctx = logger.WithRequestID(ctx, uuid.New().String())
+	ctx = logger.WithField(ctx, "graph_entity_resolution", p.KnowledgeBaseID)
+	ctx = context.WithValue(ctx, types.TenantIDContextKey, p.TenantID)
+
+	kb, err := s.kbRepo.GetKnowledgeBaseByIDAndTenant(ctx, p.KnowledgeBaseID, p.TenantID)
+	if err != nil {
+		if errors.Is(err, repository.ErrKnowledgeBaseNotFound) {
+			logger.Infof(ctx, "graph entity: knowledge base %s is gone, skipping", p.KnowledgeBaseID)
+			return nil
+		}
+		return err
+	}
+	if !kb.IsGraphEnabled() {
+		return nil
+	}
+	graph, err := s.graphEngine.GetGraph(ctx, types.NameSpace{KnowledgeBase: kb.ID})
+	if err != nil {
+		return fmt.Errorf("read knowledge graph: %w", err)
+	}
+	cg := buildCommunityGraph(graph)
+	if len(cg.entities) < 2 {
+		return nil
+	}
+	entities := make([]resolutionEntity, len(cg.entities))
+	for i, e := range cg.entities {
+		entities[i] = resolutionEntity{name: e.name, chunks: len(e.chunks), degree: e.degree}
+	}
+
+	existing, err := s.repo.ListCandidatePairKeys(ctx, p.TenantID, kb.ID)
+	if err != nil {
+		return fmt.Errorf("list candidates: %w", err)
+	}
+	set := newProposalSet(existing)
+	proposeRuleMerges(entities, set)
+	if vectors := s.embedEntityNames(ctx, kb, entities); vectors != nil {
+		proposeEmbeddingMerges(entities, vectors, set)
+	}
+
+	proposals := set.topProposals(graphEntityMaxCandidates)
+	candidates := make([]*types.GraphMergeCandidate, 0, len(proposals))
+	for _, proposal := range proposals {
+		candidates = append(candidates, &types.GraphMergeCandidate{
+			ID:              uuid.New().String(),
+			TenantID:        p.TenantID,
+			KnowledgeBaseID: kb.ID,
+			PairKey: types.GraphMergePairKey(
+				communityEntityKey(proposal.canonical), communityEntityKey(proposal.alias)),
+			Canonical: proposal.canonical,
+			Alias:     proposal.alias,
+			Score:     proposal.score,
+			Reason:    proposal.reason,
+			Status:    types.GraphMergeCandidatePending,
+		})
+	}
+	if err := s.repo.CreateCandidates(ctx, candidates); err != nil {
+		return fmt.Errorf("store candidates: %w", err)
+	}
+	logger.Infof(ctx, "graph entity: proposed %d merge(s) over %d entities in %s",
+		len(candidates), len(entities), kb.ID)
+	return nil
+}
+
+// embedEntityNames embeds every entity name with the KB's embedding model.
+// Resolution still runs on the rules alone when it cannot.
+func (s *graphEntityService) embedEntityNames(
+	ctx context.Context, kb *types.KnowledgeBase, entities []resolutionEntity,
+) [][]float32 {
+	if kb.EmbeddingModelID == "" || len(entities) > graphEntityMaxEmbedded {
+		if len(entities) > graphEntityMaxEmbedded {
+			logger.Infof(ctx, "graph entity: %d entities exceed the embedding limit, rules only", len(entities))
+		}
+		return nil
+	}
+	embedder, err := s.models.GetEmbeddingModel(ctx, kb.EmbeddingModelID)
+	if err != nil {
+		logger.Warnf(ctx, "graph entity: get embedding model: %v", err)
+		return nil
+	}
+	names := make([]string, len(entities))
+	for i, e := range entities {
+		names[i] = e.name
+	}
+	vectors, err := embedder.BatchEmbedWithPool(ctx, embedder, names)
+	if err != nil || len(vectors) != len(entities) {
+		logger.Warnf(ctx, "graph entity: embed entity names: %v", err)
+		return nil
+	}
+	return vectors
+}
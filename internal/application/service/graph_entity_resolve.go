@@ -0,0 +1,276 @@
+package service
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// Merge proposal rules for entity resolution. They only propose: every
+// candidate goes to review, so the rules lean towards recall and the
+// embedding threshold is set where unrelated names rarely land.
+
+const (
+	// graphEntityEmbeddingThreshold is the cosine similarity of two name
+	// embeddings above which they are proposed as the same entity.
+	graphEntityEmbeddingThreshold = 0.9
+	// graphEntityMaxCandidates caps the proposals of one pass, best first,
+	// so a noisy graph does not bury the review queue.
+	graphEntityMaxCandidates = 500
+)
+
+// entitySuffixTokens are legal-form and grouping words that do not change
+// which organisation a name refers to.
+var entitySuffixTokens = map[string]bool{
+	"inc": true, "incorporated": true, "corp": true, "corporation": true,
+	"co": true, "company": true, "ltd": true, "limited": true, "llc": true,
+	"plc": true, "gmbh": true, "ag": true, "sa": true, "holdings": true,
+	"holding": true, "group": true,
+}
+
+// entitySuffixesCJK are checked longest first.
+var entitySuffixesCJK = []string{
+	"股份有限公司", "有限责任公司", "控股有限公司", "集团有限公司",
+	"有限公司", "控股", "集团", "公司",
+}
+
+// entityAcronymStopwords are skipped when forming initials.
+var entityAcronymStopwords = map[string]bool{
+	"of": true, "and": true, "the": true, "for": true, "&": true,
+}
+
+// resolutionEntity is one entity as the proposal rules see it.
+type resolutionEntity struct {
+	name   string
+	chunks int
+	degree int
+}
+
+// mergeProposal is a candidate before it is stored.
+type mergeProposal struct {
+	canonical string
+	alias     string
+	score     float64
+	reason    string
+}
+
+// entityNameTokens maps full-width forms to ASCII and lower-cases, then
+// splits into tokens on anything that is not a letter or digit.
+func entityNameTokens(name string) []string {
+	var b strings.Builder
+	for _, r := range name {
+		switch {
+		case r == '　':
+			r = ' '
+		case r >= '！' && r <= '～':
+			r -= 0xFEE0
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return strings.FieldsFunc(b.String(), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// normalizedEntityName is the name with case, width, spacing and
+// punctuation folded away.
+func normalizedEntityName(name string) string {
+	return strings.Join(entityNameTokens(name), "")
+}
+
+// baseEntityName additionally drops legal-form suffixes. It returns "" when
+// nothing distinctive would be left.
+func baseEntityName(name string) string {
+	tokens := entityNameTokens(name)
+	for len(tokens) > 1 && entitySuffixTokens[tokens[len(tokens)-1]] {
+		tokens = tokens[:len(tokens)-1]
+	}
+	base := strings.Join(tokens, "")
+	for trimmed := true; trimmed; {
+		trimmed = false
+		for _, suffix := range entitySuffixesCJK {
+			if strings.HasSuffix(base, suffix) && base != suffix {
+				base = strings.TrimSuffix(base, suffix)
+				trimmed = true
+				break
+			}
+		}
+	}
+	if utf8.RuneCountInString(base) < 2 {
+		return ""
+	}
+	return base
+}
+
+// entityInitials returns the initials of a multi-word Latin name, or "".
+func entityInitials(name string) string {
+	var initials []rune
+	for _, token := range entityNameTokens(name) {
+		if entityAcronymStopwords[token] {
+			continue
+		}
+		r, _ := utf8.DecodeRuneInString(token)
+		if r > unicode.MaxASCII || !unicode.IsLetter(r) {
+			return ""
+		}
+		initials = append(initials, r)
+	}
+	if len(initials) < 2 {
+		return ""
+	}
+	return string(initials)
+}
+
+// isEntityAcronym reports whether name looks like an acronym: one Latin
+// token, letters only.
+func isEntityAcronym(name string) bool {
+	tokens := entityNameTokens(name)
+	if len(tokens) != 1 || len(tokens[0]) < 2 {
+		return false
+	}
+	for _, r := range tokens[0] {
+		if r > unicode.MaxASCII || !unicode.IsLetter(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// preferCanonical orders two entities by which should survive a merge: the
+// one cited by more chunks, then the better connected, then the shorter name.
+func preferCanonical(a, b resolutionEntity) bool {
+	if a.chunks != b.chunks {
+		return a.chunks > b.chunks
+	}
+	if a.degree != b.degree {
+		return a.degree > b.degree
+	}
+	la, lb := utf8.RuneCountInString(a.name), utf8.RuneCountInString(b.name)
+	if la != lb {
+		return la < lb
+	}
+	return a.name < b.name
+}
+
+// proposalSet collects proposals, one per pair, skipping pairs that
+// already have a stored candidate.
+type proposalSet struct {
+	seen      map[string]bool
+	proposals []mergeProposal
+}
+
+func newProposalSet(existing []string) *proposalSet {
+	seen := make(map[string]bool, len(existing))
+	for _, key := range existing {
+		seen[key] = true
+	}
+	return &proposalSet{seen: seen}
+}
+
+func (p *proposalSet) add(a, b resolutionEntity, score float64, reason string) {
+	keyA, keyB := communityEntityKey(a.name), communityEntityKey(b.name)
+	if keyA == keyB {
+		return
+	}
+	key := types.GraphMergePairKey(keyA, keyB)
+	if p.seen[key] {
+		return
+	}
+	p.seen[key] = true
+	if !preferCanonical(a, b) {
+		a, b = b, a
+	}
+	p.proposals = append(p.proposals, mergeProposal{
+		canonical: a.name, alias: b.name, score: score, reason: reason,
+	})
+}
+
+// proposeRuleMerges applies the name rules. Within a group of equivalent
+// names every member is paired with the preferred one, not with each other:
+// accepting the star merges the whole group.
+func proposeRuleMerges(entities []resolutionEntity, set *proposalSet) {
+	star := func(groups map[string][]resolutionEntity, score float64, reason string) {
+		keys := make([]string, 0, len(groups))
+		for key, group := range groups {
+			if len(group) > 1 {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			group := groups[key]
+			sort.SliceStable(group, func(i, j int) bool { return preferCanonical(group[i], group[j]) })
+			for _, member := range group[1:] {
+				set.add(group[0], member, score, reason)
+			}
+		}
+	}
+
+	normalized := make(map[string][]resolutionEntity)
+	base := make(map[string][]resolutionEntity)
+	initials := make(map[string][]resolutionEntity)
+	for _, e := range entities {
+		if key := normalizedEntityName(e.name); key != "" {
+			normalized[key] = append(normalized[key], e)
+		}
+		if key := baseEntityName(e.name); key != "" {
+			base[key] = append(base[key], e)
+		}
+		if key := entityInitials(e.name); key != "" {
+			initials[key] = append(initials[key], e)
+		}
+	}
+	star(normalized, 1, types.GraphMergeReasonNormalized)
+	star(base, 0.95, types.GraphMergeReasonSuffix)
+
+	for _, e := range entities {
+		if !isEntityAcronym(e.name) {
+			continue
+		}
+		expansions := initials[normalizedEntityName(e.name)]
+		// An acronym with several expansions is ambiguous; leave it alone.
+		if len(expansions) == 1 {
+			set.add(expansions[0], e, 0.9, types.GraphMergeReasonAcronym)
+		}
+	}
+}
+
+// proposeEmbeddingMerges pairs entities whose name embeddings are close.
+// vectors[i] belongs to entities[i]; a nil vector is skipped.
+func proposeEmbeddingMerges(entities []resolutionEntity, vectors [][]float32, set *proposalSet) {
+	type scored struct {
+		i, j  int
+		score float64
+	}
+	var pairs []scored
+	for i := range entities {
+		if vectors[i] == nil {
+			continue
+		}
+		for j := i + 1; j < len(entities); j++ {
+			if vectors[j] == nil {
+				continue
+			}
+			if score := types.CosineSimilarity(vectors[i], vectors[j]); score >= graphEntityEmbeddingThreshold {
+				pairs = append(pairs, scored{i: i, j: j, score: score})
+			}
+		}
+	}
+	sort.SliceStable(pairs, func(a, b int) bool { return pairs[a].score > pairs[b].score })
+	for _, p := range pairs {
+		set.add(entities[p.i], entities[p.j], p.score, types.GraphMergeReasonEmbedding)
+	}
+}
+
+// topProposals returns at most limit proposals, best score first.
+func (p *proposalSet) topProposals(limit int) []mergeProposal {
+	out := append([]mergeProposal(nil), p.proposals...)
+	sort.SliceStable(out, func(i, j int) bool { return out[i].score > out[j].score })
+	if len(out) > limit {
+		out = out[:limit]
+	}
+	return out
+}
@@ -0,0 +1,111 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+func TestBaseEntityNameDropsLegalForms(t *testing.T) {
+	cases := map[string]string{
+		"Tencent Holdings Ltd.": "tencent",
+		"Tencent":               "tencent",
+		"ＴＥＮＣＥＮＴ":               "tencent",
+		"腾讯控股有限公司":              "腾讯",
+		"腾讯":                    "腾讯",
+		"Group":                 "group",
+		"A公司":                   "",
+	}
+	for name, want := range cases {
+		if got := baseEntityName(name); got != want {
+			t.Errorf("baseEntityName(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestProposeRuleMerges(t *testing.T) {
+	entities := []resolutionEntity{
+		{name: "Tencent", chunks: 5},
+		{name: "Tencent Holdings", chunks: 2},
+		{name: "腾讯", chunks: 3},
+		{name: "Open AI", chunks: 1},
+		{name: "OpenAI", chunks: 4},
+		{name: "World Health Organization", chunks: 2},
+		{name: "WHO", chunks: 1},
+		{name: "Kubernetes", chunks: 1},
+	}
+	set := newProposalSet(nil)
+	proposeRuleMerges(entities, set)
+
+	got := make(map[string]mergeProposal)
+	for _, p := range set.topProposals(graphEntityMaxCandidates) {
+		got[p.alias] = p
+	}
+	want := map[string]struct{ canonical, reason string }{
+		"Tencent Holdings": {"Tencent", types.GraphMergeReasonSuffix},
+		"Open AI":          {"OpenAI", types.GraphMergeReasonNormalized},
+		"WHO":              {"World Health Organization", types.GraphMergeReasonAcronym},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d proposals, want %d: %+v", len(got), len(want), got)
+	}
+	for alias, w := range want {
+		p, ok := got[alias]
+		if !ok || p.canonical != w.canonical || p.reason != w.reason {
+			t.Errorf("proposal for %q = %+v, want canonical %q reason %q", alias, p, w.canonical, w.reason)
+		}
+	}
+}
+
+func TestProposalSetSkipsDecidedPairs(t *testing.T) {
+	decided := types.GraphMergePairKey("open ai", "openai")
+	set := newProposalSet([]string{decided})
+	proposeRuleMerges([]resolutionEntity{{name: "Open AI"}, {name: "OpenAI"}}, set)
+	if n := len(set.topProposals(10)); n != 0 {
+		t.Fatalf("expected decided pair to be skipped, got %d proposals", n)
+	}
+}
+
+func TestProposeEmbeddingMerges(t *testing.T) {
+	entities := []resolutionEntity{
+		{name: "Tencent", chunks: 5},
+		{name: "腾讯", chunks: 3},
+		{name: "Alibaba", chunks: 4},
+	}
+	vectors := [][]float32{{1, 0.05, 0}, {0.98, 0.1, 0}, {0, 0, 1}}
+	set := newProposalSet(nil)
+	proposeEmbeddingMerges(entities, vectors, set)
+	proposals := set.topProposals(10)
+	if len(proposals) != 1 {
+		t.Fatalf("expected one proposal, got %+v", proposals)
+	}
+	if p := proposals[0]; p.canonical != "Tencent" || p.alias != "腾讯" || p.reason != types.GraphMergeReasonEmbedding {
+		t.Fatalf("unexpected proposal %+v", p)
+	}
+}
+
+func TestCanonicalizeGraphData(t *testing.T) {
+	graph := &types.GraphData{
+		Node: []*types.GraphNode{
+			{Name: "腾讯", Chunks: []string{"c1"}},
+			{Name: "Tencent", Chunks: []string{"c1"}, Attributes: []string{"company"}},
+			{Name: "WeChat", Chunks: []string{"c1"}},
+		},
+		Relation: []*types.GraphRelation{
+			{Node1: "腾讯", Node2: "WeChat", Type: "owns"},
+			{Node1: "Tencent", Node2: "WeChat", Type: "owns"},
+			{Node1: "腾讯", Node2: "Tencent", Type: "same_as"},
+		},
+	}
+	canonicalizeGraphData(graph, map[string]string{"腾讯": "Tencent"})
+
+	if len(graph.Node) != 2 || graph.Node[0].Name != "Tencent" || graph.Node[1].Name != "WeChat" {
+		t.Fatalf("unexpected nodes: %+v", graph.Node)
+	}
+	if len(graph.Node[0].Attributes) != 1 {
+		t.Fatalf("attributes not folded: %+v", graph.Node[0])
+	}
+	if len(graph.Relation) != 1 || graph.Relation[0].Node1 != "Tencent" {
+		t.Fatalf("unexpected relations: %+v", graph.Relation)
+	}
+}
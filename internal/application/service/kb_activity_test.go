@@ -29,6 +29,14 @@ func (*captureKBActivityAudit) List(context.Context, uint64, *interfaces.AuditLo
 
 func (*captureKBActivityAudit) Purge(context.Context, int) (int64, error) { return 0, nil }
 
+func (*captureKBActivityAudit) LogAPIKeyRead(context.Context, *gin.Context, uint64, uint64) error {
+	return nil
+}
+
+func (*captureKBActivityAudit) VerifyChain(context.Context, uint64) (*types.AuditChainVerification, error) {
+	return nil, nil
+}
+
 func TestRecordKBActivityCarriesInitiatorAndTaskMetadata(t *testing.T) {
 	ctx := types.TaskInitiator{UserID: "user-1", Role: types.TenantRoleAdmin}.Apply(context.Background())
 	ctx = withKBActivityTask(ctx, "task-1", "user")
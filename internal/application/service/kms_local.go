@@ -0,0 +1,124 @@
+package service
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+// localKeyFile is the master key file of the local key provider:
+//
+//	{"current": "2026-10", "keys": {"2026-10": "<base64 32 bytes>", "2025-04": "..."}}
+//
+// Rotating the master key means adding a new entry, pointing current at it
+// and calling the re-wrap endpoint; the old entry can be removed once the
+// re-wrap reports every data key moved.
+type localKeyFile struct {
+	Current string            `json:"current"`
+	Keys    map[string]string `json:"keys"`
+}
+
+// localKMS is the key file KeyManagementService, and the stand-in for an
+// external KMS in development. The file is re-read on every call so a
+// rotation needs no restart; data keys are cached by the caller, so calls
+// are rare.
+type localKMS struct {
+	path string
+}
+
+// NewLocalKMS creates the key file provider and checks that the file is
+// usable.
+func NewLocalKMS(path string) (interfaces.KeyManagementService, error) {
+	k := &localKMS{path: path}
+	if _, _, err := k.load(); err != nil {
+		return nil, err
+	}
+	return k, nil
+}
+
+func (k *localKMS) load() (string, map[string][]byte, error) {
+	raw, err := os.ReadFile(k.path)
+	if err != nil {
+		return "", nil, fmt.Errorf("read master key file: %w", err)
+	}
+	var file localKeyFile
+	if err := json.Unmarshal(raw, &file); err != nil {
+		return "", nil, fmt.Errorf("parse master key file: %w", err)
+	}
+	keys := make(map[string][]byte, len(file.Keys))
+	for id, encoded := range file.Keys {
+		key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+		if err != nil || len(key) != 32 {
+			return "", nil, fmt.Errorf("master key %q must be 32 bytes of base64", id)
+		}
+		keys[id] = key
+	}
+	current := strings.TrimSpace(file.Current)
+	if _, ok := keys[current]; !ok {
+		return "", nil, fmt.Errorf("master key file: current key %q is not in keys", current)
+	}
+	return current, keys, nil
+}
+
+func (k *localKMS) CurrentKeyID(context.Context) (string, error) {
+	current, _, err := k.load()
+	return current, err
+}
+
+func (k *localKMS) Wrap(_ context.Context, plaintext []byte) (string, []byte, error) {
+	current, keys, err := k.load()
+	if err != nil {
+		return "", nil, err
+	}
+	aead, err := newMasterAEAD(keys[current])
+	if err != nil {
+		return "", nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", nil, err
+	}
+	// The key ID is authenticated so a blob cannot be relabelled.
+	return current, aead.Seal(nonce, nonce, plaintext, []byte(current)), nil
+}
+
+func (k *localKMS) Unwrap(_ context.Context, keyID string, wrapped []byte) ([]byte, error) {
+	_, keys, err := k.load()
+	if err != nil {
+		return nil, err
+	}
+	master, ok := keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("master key %q is not in the key file", keyID)
+	}
+	aead, err := newMasterAEAD(master)
+	if err != nil {
+		return nil, err
+	}
+	if len(wrapped) < aead.NonceSize() {
+		return nil, errors.New("wrapped data key is too short")
+	}
+	plain, err := aead.Open(nil, wrapped[:aead.NonceSize()], wrapped[aead.NonceSize():], []byte(keyID))
+	if err != nil {
+		return nil, fmt.Errorf("unwrap data key with master key %q: %w", keyID, err)
+	}
+	return plain, nil
+}
+
+func newMasterAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	filesvc "github.com/Tencent/WeKnora/internal/application/service/file"
 	"io"
 	"reflect"
 	"strings"
@@ -58,16 +59,25 @@ type knowledgeService struct {
 	tagRepo         interfaces.KnowledgeTagRepository
 	tagService      interfaces.KnowledgeTagService
 	fileSvc         interfaces.FileService
+	fileEncryption  *filesvc.Encryption
 	storageResolver interfaces.StorageBackendResolver
 	resourceCatalog interfaces.ResourceCatalog
 	modelService    interfaces.ModelService
 	task            interfaces.TaskEnqueuer
 	taskInspector   interfaces.TaskInspector
 	graphEngine     interfaces.RetrieveGraphRepository
+	communities     interfaces.GraphCommunityService
 	redisClient     *redis.Client
 	kbShareService  interfaces.KBShareService
 	imageResolver   *docparser.ImageResolver
 	taskPendingRepo interfaces.TaskPendingOpsRepository
+	versionRepo     interfaces.KnowledgeVersionRepository
+	fingerprintRepo interfaces.KnowledgeFingerprintRepository
+	freshnessRepo   interfaces.KnowledgeFreshnessRepository
+	aclService      interfaces.KnowledgeACLService
+	// rateLimits enforces the daily upload volume on every ingestion
+	// path; nil disables it.
+	rateLimits interfaces.RateLimitService
 
 	// In-memory fallbacks for Lite mode (no Redis)
 	memFAQProgress      sync.Map // taskID -> *types.FAQImportProgress
@@ -108,6 +118,7 @@ func NewKnowledgeService(
 	task interfaces.TaskEnqueuer,
 	taskInspector interfaces.TaskInspector,
 	graphEngine interfaces.RetrieveGraphRepository,
+	communities interfaces.GraphCommunityService,
 	retrieveEngine interfaces.RetrieveEngineRegistry,
 	ownership retriever.TenantStoreOwnership,
 	redisClient *redis.Client,
@@ -118,6 +129,12 @@ func NewKnowledgeService(
 	taskPendingRepo interfaces.TaskPendingOpsRepository,
 	spanTracker SpanTracker,
 	audit interfaces.AuditLogService,
+	versionRepo interfaces.KnowledgeVersionRepository,
+	fingerprintRepo interfaces.KnowledgeFingerprintRepository,
+	freshnessRepo interfaces.KnowledgeFreshnessRepository,
+	aclService interfaces.KnowledgeACLService,
+	fileEncryption *filesvc.Encryption,
+	rateLimits interfaces.RateLimitService,
 ) (interfaces.KnowledgeService, error) {
 	return &knowledgeService{
 		config:          config,
@@ -137,6 +154,7 @@ func NewKnowledgeService(
 		task:            task,
 		taskInspector:   taskInspector,
 		graphEngine:     graphEngine,
+		communities:     communities,
 		retrieveEngine:  retrieveEngine,
 		ownership:       ownership,
 		redisClient:     redisClient,
@@ -147,6 +165,12 @@ func NewKnowledgeService(
 		taskPendingRepo: taskPendingRepo,
 		spanTracker:     spanTracker,
 		audit:           audit,
+		versionRepo:     versionRepo,
+		fingerprintRepo: fingerprintRepo,
+		freshnessRepo:   freshnessRepo,
+		aclService:      aclService,
+		fileEncryption:  fileEncryption,
+		rateLimits:      rateLimits,
 	}, nil
 }
 
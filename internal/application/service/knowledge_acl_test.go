@@ -0,0 +1,154 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+// aclDenyRepo denies a fixed set of knowledge and records the subject it was
+// asked about.
+type aclDenyRepo struct {
+	interfaces.KnowledgeACLRepository
+	denied   []*types.Knowledge
+	subjects []types.KnowledgeACLSubject
+}
+
+func (r *aclDenyRepo) ListDenied(
+	_ context.Context, kbIDs, _ []string, subject types.KnowledgeACLSubject,
+) ([]*types.Knowledge, error) {
+	r.subjects = append(r.subjects, subject)
+	want := make(map[string]bool, len(kbIDs))
+	for _, id := range kbIDs {
+		want[id] = true
+	}
+	var out []*types.Knowledge
+	for _, k := range r.denied {
+		if want[k.KnowledgeBaseID] {
+			out = append(out, k)
+		}
+	}
+	return out, nil
+}
+
+type aclKBRepo struct {
+	interfaces.KnowledgeBaseRepository
+	kbs map[string]*types.KnowledgeBase
+}
+
+func (r *aclKBRepo) GetKnowledgeBaseByIDs(_ context.Context, ids []string) ([]*types.KnowledgeBase, error) {
+	var out []*types.KnowledgeBase
+	for _, id := range ids {
+		if kb, ok := r.kbs[id]; ok {
+			out = append(out, kb)
+		}
+	}
+	return out, nil
+}
+
+func (r *aclKBRepo) GetKnowledgeBaseByID(_ context.Context, id string) (*types.KnowledgeBase, error) {
+	return r.kbs[id], nil
+}
+
+func newACLTestService() (*knowledgeACLService, *aclDenyRepo) {
+	repo := &aclDenyRepo{denied: []*types.Knowledge{
+		{ID: "k-secret", KnowledgeBaseID: "kb-1"},
+		{ID: "k-hr", KnowledgeBaseID: "kb-2"},
+	}}
+	kbRepo := &aclKBRepo{kbs: map[string]*types.KnowledgeBase{
+		"kb-1": {ID: "kb-1", TenantID: 1, CreatorID: "owner"},
+		"kb-2": {ID: "kb-2", TenantID: 2},
+	}}
+	return &knowledgeACLService{aclRepo: repo, kbRepo: kbRepo}, repo
+}
+
+func aclTestContext(userID string, role types.TenantRole, roleTenantID uint64) context.Context {
+	ctx := types.WithPrincipal(context.Background(), types.Principal{Type: types.PrincipalWebUser, ID: userID})
+	ctx = context.WithValue(ctx, types.UserContextKey, &types.User{ID: userID, Email: " Member@Example.com "})
+	ctx = context.WithValue(ctx, types.TenantRoleContextKey, role)
+	return context.WithValue(ctx, types.TenantRoleTenantIDContextKey, roleTenantID)
+}
+
+func TestKnowledgeACLRestrictSearchTargets(t *testing.T) {
+	svc, repo := newACLTestService()
+	ctx := aclTestContext("member", types.TenantRoleViewer, 1)
+
+	targets := types.SearchTargets{
+		{Type: types.SearchTargetTypeKnowledgeBase, KnowledgeBaseID: "kb-1", TenantID: 1},
+		{Type: types.SearchTargetTypeKnowledge, KnowledgeBaseID: "kb-2", TenantID: 2,
+			KnowledgeIDs: []string{"k-hr"}},
+	}
+	got, err := svc.RestrictSearchTargets(ctx, targets)
+	require.NoError(t, err)
+
+	// The whole-KB target keeps searching with the denied document excluded;
+	// the document target is dropped because nothing readable is left in it.
+	require.Len(t, got, 1)
+	assert.Equal(t, "kb-1", got[0].KnowledgeBaseID)
+	assert.Equal(t, []string{"k-secret"}, got[0].ExcludeKnowledgeIDs)
+	assert.Empty(t, targets[0].ExcludeKnowledgeIDs, "input targets must not be modified")
+
+	require.Len(t, repo.subjects, 1)
+	assert.Equal(t, "member", repo.subjects[0].UserID)
+	assert.Equal(t, "member@example.com", repo.subjects[0].Email)
+}
+
+func TestKnowledgeACLBypass(t *testing.T) {
+	kb1 := []*types.KnowledgeBase{{ID: "kb-1", TenantID: 1, CreatorID: "owner"}}
+
+	tests := []struct {
+		name   string
+		ctx    context.Context
+		denied bool
+	}{
+		{"admin of owning workspace", aclTestContext("admin", types.TenantRoleAdmin, 1), false},
+		{"knowledge base creator", aclTestContext("owner", types.TenantRoleContributor, 1), false},
+		{"member of owning workspace", aclTestContext("member", types.TenantRoleContributor, 1), true},
+		// An admin of another workspace borrowing a shared agent runs with the
+		// owner's tenant ID but holds no role in it.
+		{"admin of another workspace", aclTestContext("admin", types.TenantRoleAdmin, 9), true},
+		{"no role tenant", types.WithPrincipal(context.Background(),
+			types.Principal{Type: types.PrincipalWebUser, ID: "owner"}), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, _ := newACLTestService()
+			denied, err := svc.DeniedKnowledgeIDs(tt.ctx, kb1)
+			require.NoError(t, err)
+			if tt.denied {
+				assert.Equal(t, []string{"k-secret"}, denied)
+			} else {
+				assert.Empty(t, denied)
+			}
+		})
+	}
+}
+
+func TestKnowledgeACLSubjectIgnoresSyntheticUsers(t *testing.T) {
+	svc, repo := newACLTestService()
+	ctx := types.WithPrincipal(context.Background(), types.Principal{Type: types.PrincipalAPITenant, ID: "api_tenant_key:1:k"})
+
+	_, err := svc.DeniedKnowledgeIDs(ctx, []*types.KnowledgeBase{{ID: "kb-1", TenantID: 1}})
+	require.NoError(t, err)
+	require.Len(t, repo.subjects, 1)
+	assert.Empty(t, repo.subjects[0].UserID)
+	assert.Empty(t, repo.subjects[0].Email)
+}
+
+func TestKnowledgeACLCanReadKnowledge(t *testing.T) {
+	svc, _ := newACLTestService()
+	ctx := aclTestContext("member", types.TenantRoleViewer, 1)
+
+	ok, err := svc.CanReadKnowledge(ctx, &types.Knowledge{ID: "k-secret", KnowledgeBaseID: "kb-1"})
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	ok, err = svc.CanReadKnowledge(ctx, &types.Knowledge{ID: "k-secret", KnowledgeBaseID: "kb-missing"})
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
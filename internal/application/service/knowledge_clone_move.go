@@ -1209,6 +1209,14 @@ func (s *knowledgeService) moveOneKnowledge(
 		s.cleanupWikiOnKnowledgeDelete(ctx, knowledge)
 	}
 
+	// Fingerprints are scoped to a knowledge base. An alias owns no chunks to
+	// carry over, so it is re-parsed in the target, where it is checked for
+	// duplicates against that knowledge base instead.
+	s.purgeKnowledgeFingerprints(ctx, tenantID, []string{knowledge.ID})
+	if mode == "reuse_vectors" && isKnowledgeAlias(knowledge) {
+		mode = "reparse"
+	}
+
 	switch mode {
 	case "reuse_vectors":
 		if err := s.moveKnowledgeReuseVectors(ctx, knowledge, sourceKB, targetKB); err != nil {
@@ -21,10 +21,38 @@ import (
 	"github.com/hibiken/asynq"
 )
 
+// reserveIngest counts size bytes against the daily upload volume of the
+// workspace and the caller. release gives them back when the document is
+// then rejected, so only accepted documents use up the quota.
+func (s *knowledgeService) reserveIngest(ctx context.Context, size int64) (release func(), err error) {
+	if s.rateLimits == nil || size <= 0 {
+		return func() {}, nil
+	}
+	release, _, err = s.rateLimits.ReserveIngest(ctx, size)
+	return release, err
+}
+
 // CreateKnowledgeFromFile creates a knowledge entry from an uploaded file
 func (s *knowledgeService) CreateKnowledgeFromFile(ctx context.Context,
 	kbID string, file *multipart.FileHeader, metadata map[string]string, enableMultimodel *bool, customFileName string, tagIDs []string, channel string,
 	processOverrides *types.KnowledgeProcessOverrides,
+) (*types.Knowledge, error) {
+	release, err := s.reserveIngest(ctx, file.Size)
+	if err != nil {
+		return nil, err
+	}
+	knowledge, err := s.createKnowledgeFromFile(
+		ctx, kbID, file, metadata, enableMultimodel, customFileName, tagIDs, channel, processOverrides,
+	)
+	if err != nil {
+		release()
+	}
+	return knowledge, err
+}
+
+func (s *knowledgeService) createKnowledgeFromFile(ctx context.Context,
+	kbID string, file *multipart.FileHeader, metadata map[string]string, enableMultimodel *bool, customFileName string, tagIDs []string, channel string,
+	processOverrides *types.KnowledgeProcessOverrides,
 ) (*types.Knowledge, error) {
 	logger.Info(ctx, "Start creating knowledge from file")
 
@@ -170,6 +198,8 @@ func (s *knowledgeService) CreateKnowledgeFromFile(ctx context.Context,
 		EmbeddingModelID: kb.EmbeddingModelID,
 		Metadata:         metadataJSON,
 	}
+	// Connectors and API callers may pass validity dates as metadata.
+	knowledge.ApplyFreshnessMetadata(metadata)
 
 	if processOverrides != nil {
 		if err := knowledge.SetProcessOverrides(processOverrides); err != nil {
@@ -225,6 +255,7 @@ func (s *knowledgeService) CreateKnowledgeFromFile(ctx context.Context,
 		EnableQuestionGeneration: enableQuestionGeneration,
 		QuestionCount:            questionCount,
 		Language:                 lang,
+		VersionEditorID:          knowledgeVersionOriginFrom(ctx).editorID,
 	}
 
 	langfuse.InjectTracing(ctx, &taskPayload)
@@ -300,6 +331,14 @@ func (s *knowledgeService) CreateKnowledgeFromURL(ctx context.Context,
 	logger.Info(ctx, "Start creating knowledge from URL")
 	logger.Infof(ctx, "Knowledge base ID: %s, URL: %s", kbID, rawURL)
 
+	// The content is fetched by the processing task, so its size is counted
+	// there; here the import is only turned away once the quota is used up.
+	if s.rateLimits != nil {
+		if _, err := s.rateLimits.CheckIngest(ctx); err != nil {
+			return nil, err
+		}
+	}
+
 	// Route to file_url logic when the URL points to a downloadable file
 	if isFileURL(rawURL, fileName, fileType) {
 		return s.createKnowledgeFromFileURL(
@@ -421,6 +460,7 @@ func (s *knowledgeService) CreateKnowledgeFromURL(ctx context.Context,
 		EnableQuestionGeneration: enableQuestionGeneration,
 		QuestionCount:            questionCount,
 		Language:                 lang,
+		VersionEditorID:          knowledgeVersionOriginFrom(ctx).editorID,
 	}
 
 	langfuse.InjectTracing(ctx, &taskPayload)
@@ -665,6 +705,7 @@ func (s *knowledgeService) createKnowledgeFromFileURL(
 		EnableQuestionGeneration: enableQuestionGeneration,
 		QuestionCount:            questionCount,
 		Language:                 lang,
+		VersionEditorID:          knowledgeVersionOriginFrom(ctx).editorID,
 	}
 
 	langfuse.InjectTracing(ctx, &taskPayload)
@@ -727,11 +768,24 @@ func (s *knowledgeService) CreateKnowledgeFromPassageSync(ctx context.Context,
 func (s *knowledgeService) CreateKnowledgeFromManual(ctx context.Context,
 	kbID string, payload *types.ManualKnowledgePayload, channel string,
 ) (*types.Knowledge, error) {
-	logger.Info(ctx, "Start creating manual knowledge entry")
-
 	if payload == nil {
 		return nil, werrors.NewBadRequestError("请求内容不能为空")
 	}
+	release, err := s.reserveIngest(ctx, int64(len(payload.Content)))
+	if err != nil {
+		return nil, err
+	}
+	knowledge, err := s.createKnowledgeFromManual(ctx, kbID, payload, channel)
+	if err != nil {
+		release()
+	}
+	return knowledge, err
+}
+
+func (s *knowledgeService) createKnowledgeFromManual(ctx context.Context,
+	kbID string, payload *types.ManualKnowledgePayload, channel string,
+) (*types.Knowledge, error) {
+	logger.Info(ctx, "Start creating manual knowledge entry")
 
 	cleanContent := secutils.CleanMarkdown(payload.Content)
 	if strings.TrimSpace(cleanContent) == "" {
@@ -937,6 +991,7 @@ func (s *knowledgeService) createKnowledgeFromPassageInternal(ctx context.Contex
 			EnableQuestionGeneration: enableQuestionGeneration,
 			QuestionCount:            questionCount,
 			Language:                 lang,
+			VersionEditorID:          knowledgeVersionOriginFrom(ctx).editorID,
 		}
 
 		langfuse.InjectTracing(ctx, &taskPayload)
@@ -1112,6 +1167,7 @@ func (s *knowledgeService) enqueueManualProcessing(ctx context.Context,
 	knowledge *types.Knowledge, content string, needCleanup bool,
 ) (string, error) {
 	requestID, _ := types.RequestIDFromContext(ctx)
+	origin := knowledgeVersionOriginFrom(ctx)
 	payload := types.ManualProcessPayload{
 		RequestId:       requestID,
 		TenantID:        knowledge.TenantID,
@@ -1119,6 +1175,8 @@ func (s *knowledgeService) enqueueManualProcessing(ctx context.Context,
 		KnowledgeBaseID: knowledge.KnowledgeBaseID,
 		Content:         content,
 		NeedCleanup:     needCleanup,
+		VersionEditorID: origin.editorID,
+		RestoreVersion:  origin.restoreVersion,
 	}
 	langfuse.InjectTracing(ctx, &payload)
 	payloadBytes, err := json.Marshal(payload)
@@ -1264,6 +1322,7 @@ func (s *knowledgeService) triggerManualProcessing(ctx context.Context,
 		}
 	}
 
+	s.recordKnowledgeVersion(ctx, kb, knowledge, clean, parsed)
 	if doSync {
 		s.processChunks(ctx, kb, knowledge, parsed, opts)
 		return
@@ -192,11 +192,15 @@ func (s *knowledgeService) DeleteKnowledge(ctx context.Context, id string) error
 		}
 	}
 	deleteExtractedImages(ctx, kbFileSvc, imageURLs)
+	s.purgeKnowledgeVersions(ctx, tenantID, kb, []string{id})
+	s.purgeKnowledgeFingerprints(ctx, tenantID, []string{id})
+	s.purgeKnowledgeACL(ctx, tenantID, []string{id})
 	tenantInfo := ctx.Value(types.TenantInfoContextKey).(*types.Tenant)
 	tenantInfo.StorageUsed -= knowledge.StorageSize
 	if err := s.tenantRepo.AdjustStorageUsed(ctx, tenantInfo.ID, -knowledge.StorageSize); err != nil {
 		logger.GetLogger(ctx).WithField("error", err).Errorf("DeleteKnowledge update tenant storage used failed")
 	}
+	s.scheduleCommunityRebuild(ctx, tenantID, kb)
 	recordKBActivity(ctx, s.audit, tenantID, knowledge.KnowledgeBaseID, types.AuditActionKnowledgeDeleted,
 		"knowledge", knowledge.ID, types.AuditOutcomeSuccess,
 		map[string]any{"title": knowledge.Title, "type": knowledge.Type})
@@ -664,10 +668,22 @@ func (s *knowledgeService) DeleteKnowledgeList(ctx context.Context, ids []string
 		}
 		deleteExtractedImages(ctx, fSvc, urls)
 	}
+	versionedIDs := make(map[string][]string)
+	for _, knowledge := range knowledgeList {
+		versionedIDs[knowledge.KnowledgeBaseID] = append(versionedIDs[knowledge.KnowledgeBaseID], knowledge.ID)
+	}
+	for kbID, knowledgeIDs := range versionedIDs {
+		s.purgeKnowledgeVersions(ctx, tenantInfo.ID, knowledgeBases[kbID], knowledgeIDs)
+		s.purgeKnowledgeFingerprints(ctx, tenantInfo.ID, knowledgeIDs)
+		s.purgeKnowledgeACL(ctx, tenantInfo.ID, knowledgeIDs)
+	}
 	tenantInfo.StorageUsed += storageAdjust
 	if err := s.tenantRepo.AdjustStorageUsed(ctx, tenantInfo.ID, storageAdjust); err != nil {
 		logger.GetLogger(ctx).WithField("error", err).Errorf("DeleteKnowledge update tenant storage used failed")
 	}
+	for _, kb := range knowledgeBases {
+		s.scheduleCommunityRebuild(ctx, tenantInfo.ID, kb)
+	}
 	byKB := make(map[string][]*types.Knowledge)
 	for i := range knowledgeList {
 		knowledge := knowledgeList[i]
@@ -811,3 +827,23 @@ func (s *knowledgeService) ProcessKnowledgeListDelete(ctx context.Context, t *as
 	logger.Infof(ctx, "Successfully deleted %d knowledge items", len(payload.KnowledgeIDs))
 	return nil
 }
+
+// scheduleCommunityRebuild refreshes graph community reports after documents
+// leave a graph-enabled knowledge base, so summaries stop citing them.
+func (s *knowledgeService) scheduleCommunityRebuild(ctx context.Context, tenantID uint64, kb *types.KnowledgeBase) {
+	if s.communities == nil || kb == nil || !kb.IsGraphEnabled() {
+		return
+	}
+	s.communities.ScheduleRebuild(ctx, tenantID, kb.ID)
+}
+
+// purgeKnowledgeACL drops the access lists of deleted knowledge. Best-effort:
+// entries of a deleted item match nothing retrieval can return.
+func (s *knowledgeService) purgeKnowledgeACL(ctx context.Context, tenantID uint64, knowledgeIDs []string) {
+	if s.aclService == nil {
+		return
+	}
+	if err := s.aclService.DeleteKnowledgeACL(ctx, tenantID, knowledgeIDs); err != nil {
+		logger.Warnf(ctx, "Failed to delete knowledge ACL entries: %v", err)
+	}
+}
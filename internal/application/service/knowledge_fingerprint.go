@@ -0,0 +1,666 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	werrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/searchutil"
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// Near-duplicate detection. processChunks fingerprints every document it
+// indexes: a MinHash signature of the whole content, whose LSH bands find
+// candidate duplicates inside the knowledge base, and a SimHash per text
+// chunk for the duplicate report. The knowledge base's DedupConfig decides
+// what a match does to the incoming document. Fingerprinting is best
+// effort: a failure is logged and the document is indexed as usual.
+
+const (
+	// duplicateBackfillLimit bounds how many documents indexed before
+	// fingerprinting existed one duplicate report fingerprints.
+	duplicateBackfillLimit = 100
+	// maxChunkDuplicateSets bounds the chunk clusters of a report.
+	maxChunkDuplicateSets = 100
+	// duplicatePreviewRunes is the preview length of a chunk cluster.
+	duplicatePreviewRunes = 200
+)
+
+// knowledgeFingerprintDraft is a document fingerprint computed from its
+// content before it is stored.
+type knowledgeFingerprintDraft struct {
+	signature   []uint32
+	contentHash string
+	bandKeys    []int64
+}
+
+// duplicateMatch is the closest indexed document an incoming one duplicates.
+type duplicateMatch struct {
+	knowledgeID string
+	similarity  float64
+}
+
+// draftKnowledgeFingerprint fingerprints the given chunk contents, or returns
+// nil when they carry no text.
+func draftKnowledgeFingerprint(contents []string) *knowledgeFingerprintDraft {
+	joined := strings.Join(contents, "\n")
+	signature := searchutil.MinHash(joined)
+	if signature == nil {
+		return nil
+	}
+	return &knowledgeFingerprintDraft{
+		signature:   signature,
+		contentHash: knowledgeContentHash(searchutil.FingerprintText(joined)),
+		bandKeys:    searchutil.MinHashBandKeys(signature),
+	}
+}
+
+func parsedChunkContents(chunks []types.ParsedChunk) []string {
+	contents := make([]string, 0, len(chunks))
+	for _, c := range chunks {
+		if strings.TrimSpace(c.Content) != "" {
+			contents = append(contents, c.Content)
+		}
+	}
+	return contents
+}
+
+// findDuplicateKnowledge returns the indexed document of the knowledge base
+// most similar to draft, when it reaches the threshold.
+func (s *knowledgeService) findDuplicateKnowledge(
+	ctx context.Context, kb *types.KnowledgeBase, knowledge *types.Knowledge, draft *knowledgeFingerprintDraft,
+) (*duplicateMatch, error) {
+	candidates, err := s.fingerprintRepo.FindCandidates(
+		ctx, knowledge.TenantID, kb.ID, draft.bandKeys, knowledge.ID)
+	if err != nil {
+		return nil, err
+	}
+	threshold := kb.DedupConfig.EffectiveThreshold()
+	var best *duplicateMatch
+	for _, c := range candidates {
+		similarity := searchutil.MinHashSimilarity(draft.signature, c.MinHash)
+		if c.ContentHash == draft.contentHash {
+			similarity = 1
+		}
+		if similarity < threshold {
+			continue
+		}
+		if best == nil || similarity > best.similarity {
+			best = &duplicateMatch{knowledgeID: c.KnowledgeID, similarity: similarity}
+		}
+	}
+	return best, nil
+}
+
+// applyDuplicatePolicy runs duplicate detection for a document about to be
+// indexed. It returns the document's fingerprint, to be stored once its
+// chunks exist, and whether the policy already finished the parse (skip or
+// alias), in which case nothing must be indexed.
+func (s *knowledgeService) applyDuplicatePolicy(
+	ctx context.Context, kb *types.KnowledgeBase, knowledge *types.Knowledge, chunks []types.ParsedChunk,
+) (*knowledgeFingerprintDraft, bool) {
+	if s.fingerprintRepo == nil {
+		return nil, false
+	}
+	setKnowledgeDuplicateMetadata(knowledge, nil, false)
+	draft := draftKnowledgeFingerprint(parsedChunkContents(chunks))
+	if draft == nil {
+		return nil, false
+	}
+	policy := kb.DedupConfig.EffectivePolicy()
+	match, err := s.findDuplicateKnowledge(ctx, kb, knowledge, draft)
+	if err != nil {
+		logger.Warnf(ctx, "Duplicate lookup failed for knowledge %s: %v", knowledge.ID, err)
+		return draft, false
+	}
+	if match == nil || policy == types.DuplicatePolicyAllow {
+		return draft, false
+	}
+	logger.Infof(ctx, "Knowledge %s is a near-duplicate of %s (similarity %.2f), policy %s",
+		knowledge.ID, match.knowledgeID, match.similarity, policy)
+
+	// The document no longer stands for its previous content, so whatever
+	// aliased it has to be re-homed.
+	s.purgeKnowledgeFingerprints(ctx, knowledge.TenantID, []string{knowledge.ID})
+	switch policy {
+	case types.DuplicatePolicySkip:
+		setKnowledgeDuplicateMetadata(knowledge, match, false)
+		err := fmt.Errorf("near-duplicate of %s (similarity %.2f)",
+			s.duplicateTitle(ctx, knowledge.TenantID, match.knowledgeID), match.similarity)
+		knowledge.ParseStatus = types.ParseStatusFailed
+		knowledge.ErrorMessage = "Skipped as " + err.Error()
+		knowledge.UpdatedAt = time.Now()
+		if uErr := s.repo.UpdateKnowledge(ctx, knowledge); uErr != nil {
+			logger.Errorf(ctx, "Failed to mark duplicate knowledge %s as skipped: %v", knowledge.ID, uErr)
+		}
+		s.failStage(ctx, knowledge.ID, types.StageChunking,
+			werrors.ErrCodeDuplicateContent, "skipped duplicate content", err)
+		return draft, true
+
+	case types.DuplicatePolicyAlias:
+		setKnowledgeDuplicateMetadata(knowledge, match, true)
+		s.saveKnowledgeFingerprint(ctx, knowledge, draft, match.knowledgeID, nil)
+		finalizeIndexedKnowledgeState(knowledge, 0, 0, false, time.Now())
+		if err := s.repo.UpdateKnowledge(ctx, knowledge); err != nil {
+			logger.Errorf(ctx, "Failed to mark knowledge %s as alias: %v", knowledge.ID, err)
+		}
+		for _, stage := range []string{types.StageChunking, types.StageEmbedding, types.StageMultimodal} {
+			s.skipStage(ctx, knowledge.ID, stage, "duplicate_alias")
+		}
+		return draft, true
+
+	default: // warn
+		setKnowledgeDuplicateMetadata(knowledge, match, false)
+		return draft, false
+	}
+}
+
+// duplicateTitle names a matched document for messages, falling back to its ID.
+func (s *knowledgeService) duplicateTitle(ctx context.Context, tenantID uint64, knowledgeID string) string {
+	k, err := s.repo.GetKnowledgeByID(ctx, tenantID, knowledgeID)
+	if err != nil || k == nil {
+		return knowledgeID
+	}
+	if k.Title != "" {
+		return fmt.Sprintf("%q", k.Title)
+	}
+	if k.FileName != "" {
+		return fmt.Sprintf("%q", k.FileName)
+	}
+	return knowledgeID
+}
+
+// setKnowledgeDuplicateMetadata records match in the knowledge metadata, or
+// clears an earlier record when match is nil.
+func setKnowledgeDuplicateMetadata(knowledge *types.Knowledge, match *duplicateMatch, alias bool) {
+	metadata, err := knowledge.Metadata.Map()
+	if err != nil || metadata == nil {
+		if match == nil {
+			return
+		}
+		metadata = make(map[string]interface{})
+	}
+	_, hadDuplicate := metadata[types.KnowledgeMetaDuplicateOf]
+	_, hadAlias := metadata[types.KnowledgeMetaAliasOf]
+	if match == nil && !hadDuplicate && !hadAlias {
+		return
+	}
+	delete(metadata, types.KnowledgeMetaDuplicateOf)
+	delete(metadata, types.KnowledgeMetaDuplicateSimilarity)
+	delete(metadata, types.KnowledgeMetaAliasOf)
+	if match != nil {
+		metadata[types.KnowledgeMetaDuplicateOf] = match.knowledgeID
+		metadata[types.KnowledgeMetaDuplicateSimilarity] = strconv.FormatFloat(match.similarity, 'f', 4, 64)
+		if alias {
+			metadata[types.KnowledgeMetaAliasOf] = match.knowledgeID
+		}
+	}
+	b, err := json.Marshal(metadata)
+	if err != nil {
+		return
+	}
+	knowledge.Metadata = types.JSON(b)
+}
+
+// saveKnowledgeFingerprint stores the fingerprint of an indexed document
+// with the SimHash of each of its text chunks.
+func (s *knowledgeService) saveKnowledgeFingerprint(
+	ctx context.Context,
+	knowledge *types.Knowledge,
+	draft *knowledgeFingerprintDraft,
+	aliasOf string,
+	chunks []*types.Chunk,
+) {
+	if s.fingerprintRepo == nil || draft == nil {
+		return
+	}
+	chunkFingerprints := make([]*types.ChunkFingerprint, 0, len(chunks))
+	for _, c := range chunks {
+		if c.ChunkType != types.ChunkTypeText {
+			continue
+		}
+		simHash := searchutil.SimHash(c.Content)
+		if simHash == 0 {
+			continue
+		}
+		chunkFingerprints = append(chunkFingerprints, &types.ChunkFingerprint{
+			ChunkID:         c.ID,
+			TenantID:        knowledge.TenantID,
+			KnowledgeBaseID: knowledge.KnowledgeBaseID,
+			KnowledgeID:     knowledge.ID,
+			ChunkIndex:      c.ChunkIndex,
+			SimHash:         int64(simHash),
+		})
+	}
+	fingerprint := &types.KnowledgeFingerprint{
+		KnowledgeID:     knowledge.ID,
+		TenantID:        knowledge.TenantID,
+		KnowledgeBaseID: knowledge.KnowledgeBaseID,
+		AliasOf:         aliasOf,
+		ContentHash:     draft.contentHash,
+		MinHash:         draft.signature,
+	}
+	if err := s.fingerprintRepo.Save(ctx, fingerprint, draft.bandKeys, chunkFingerprints); err != nil {
+		logger.Warnf(ctx, "Failed to save fingerprint of knowledge %s: %v", knowledge.ID, err)
+	}
+}
+
+// purgeKnowledgeFingerprints drops the fingerprints of documents leaving a
+// knowledge base. Aliases of a leaving document lose the chunks that stood
+// in for them, so the oldest alias is re-parsed to become the indexed copy
+// and the others are pointed at it.
+func (s *knowledgeService) purgeKnowledgeFingerprints(ctx context.Context, tenantID uint64, knowledgeIDs []string) {
+	if s.fingerprintRepo == nil || len(knowledgeIDs) == 0 {
+		return
+	}
+	aliases, err := s.fingerprintRepo.ListAliases(ctx, tenantID, knowledgeIDs)
+	if err != nil {
+		logger.Warnf(ctx, "Failed to list aliases of deleted knowledge: %v", err)
+	}
+	if err := s.fingerprintRepo.DeleteByKnowledgeIDs(ctx, tenantID, knowledgeIDs); err != nil {
+		logger.Warnf(ctx, "Failed to delete knowledge fingerprints: %v", err)
+		return
+	}
+
+	leaving := make(map[string]bool, len(knowledgeIDs))
+	for _, id := range knowledgeIDs {
+		leaving[id] = true
+	}
+	byOriginal := make(map[string][]string)
+	for _, alias := range aliases {
+		if !leaving[alias.KnowledgeID] {
+			byOriginal[alias.AliasOf] = append(byOriginal[alias.AliasOf], alias.KnowledgeID)
+		}
+	}
+	for original, ids := range byOriginal {
+		promoted, rest := ids[0], ids[1:]
+		if err := s.fingerprintRepo.SetAliasOf(ctx, tenantID, rest, promoted); err != nil {
+			logger.Warnf(ctx, "Failed to re-point aliases of knowledge %s: %v", original, err)
+		}
+		s.repointKnowledgeAliases(ctx, tenantID, rest, promoted)
+		if _, err := s.ReparseKnowledge(ctx, promoted, nil); err != nil {
+			logger.Warnf(ctx, "Failed to re-parse alias %s of deleted knowledge %s: %v", promoted, original, err)
+			continue
+		}
+		logger.Infof(ctx, "Promoted alias %s of deleted knowledge %s", promoted, original)
+	}
+}
+
+// repointKnowledgeAliases updates the alias metadata of knowledgeIDs.
+func (s *knowledgeService) repointKnowledgeAliases(
+	ctx context.Context, tenantID uint64, knowledgeIDs []string, aliasOf string,
+) {
+	if len(knowledgeIDs) == 0 {
+		return
+	}
+	knowledgeList, err := s.repo.GetKnowledgeBatch(ctx, tenantID, knowledgeIDs)
+	if err != nil {
+		logger.Warnf(ctx, "Failed to load aliases to re-point: %v", err)
+		return
+	}
+	for _, k := range knowledgeList {
+		setKnowledgeDuplicateMetadata(k, &duplicateMatch{knowledgeID: aliasOf, similarity: 1}, true)
+	}
+	if err := s.repo.UpdateKnowledgeBatch(ctx, knowledgeList); err != nil {
+		logger.Warnf(ctx, "Failed to re-point aliases: %v", err)
+	}
+}
+
+// isKnowledgeAlias reports whether a document was kept as an alias.
+func isKnowledgeAlias(knowledge *types.Knowledge) bool {
+	return knowledge.GetMetadata()[types.KnowledgeMetaAliasOf] != ""
+}
+
+// GetKnowledgeDuplicateReport clusters the near-duplicate documents and
+// chunks of a knowledge base. Documents indexed before fingerprinting
+// existed are fingerprinted from their stored chunks, a bounded number per
+// report.
+func (s *knowledgeService) GetKnowledgeDuplicateReport(
+	ctx context.Context, kb *types.KnowledgeBase, threshold float64,
+) (*types.DuplicateReport, error) {
+	if s.fingerprintRepo == nil {
+		return nil, werrors.NewBadRequestError("duplicate detection is not available")
+	}
+	if threshold <= 0 {
+		threshold = kb.DedupConfig.EffectiveThreshold()
+	}
+	threshold = min(max(threshold, types.MinimumDuplicateThreshold), 1)
+
+	knowledgeList, err := s.repo.ListKnowledgeByKnowledgeBaseID(ctx, kb.TenantID, kb.ID)
+	if err != nil {
+		return nil, err
+	}
+	knowledgeByID := make(map[string]*types.Knowledge, len(knowledgeList))
+	for _, k := range knowledgeList {
+		knowledgeByID[k.ID] = k
+	}
+	fingerprints, err := s.fingerprintRepo.ListByKnowledgeBase(ctx, kb.TenantID, kb.ID)
+	if err != nil {
+		return nil, err
+	}
+	unfingerprinted := s.backfillKnowledgeFingerprints(ctx, kb, knowledgeList, fingerprints)
+	if unfingerprinted > 0 {
+		// Pick up what the backfill just stored.
+		if fingerprints, err = s.fingerprintRepo.ListByKnowledgeBase(ctx, kb.TenantID, kb.ID); err != nil {
+			return nil, err
+		}
+		unfingerprinted = 0
+		covered := make(map[string]bool, len(fingerprints))
+		for _, fp := range fingerprints {
+			covered[fp.KnowledgeID] = true
+		}
+		for _, k := range knowledgeList {
+			if !covered[k.ID] && needsKnowledgeFingerprint(k) {
+				unfingerprinted++
+			}
+		}
+	}
+
+	report := &types.DuplicateReport{
+		KnowledgeBaseID: kb.ID,
+		Threshold:       threshold,
+		Fingerprinted:   len(fingerprints),
+		Unfingerprinted: unfingerprinted,
+		Documents:       clusterDuplicateDocuments(fingerprints, knowledgeByID, threshold),
+		Chunks:          []*types.ChunkDuplicateSet{},
+	}
+
+	chunkFingerprints, err := s.fingerprintRepo.ListChunksByKnowledgeBase(ctx, kb.TenantID, kb.ID)
+	if err != nil {
+		return nil, err
+	}
+	report.Chunks, report.ChunksTruncated = clusterDuplicateChunks(chunkFingerprints, knowledgeByID)
+	s.fillChunkDuplicatePreviews(ctx, kb.TenantID, report.Chunks)
+	return report, nil
+}
+
+// needsKnowledgeFingerprint reports whether a document should have a
+// fingerprint: it finished parsing and was not skipped.
+func needsKnowledgeFingerprint(k *types.Knowledge) bool {
+	return k.ParseStatus == types.ParseStatusCompleted || k.ParseStatus == types.ParseStatusFinalizing
+}
+
+// backfillKnowledgeFingerprints fingerprints parsed documents that have no
+// fingerprint yet from their stored chunks. It returns how many were
+// missing before the backfill.
+func (s *knowledgeService) backfillKnowledgeFingerprints(
+	ctx context.Context,
+	kb *types.KnowledgeBase,
+	knowledgeList []*types.Knowledge,
+	fingerprints []*types.KnowledgeFingerprint,
+) int {
+	covered := make(map[string]bool, len(fingerprints))
+	for _, fp := range fingerprints {
+		covered[fp.KnowledgeID] = true
+	}
+	missing, filled := 0, 0
+	for _, k := range knowledgeList {
+		if covered[k.ID] || !needsKnowledgeFingerprint(k) {
+			continue
+		}
+		missing++
+		if filled >= duplicateBackfillLimit {
+			continue
+		}
+		chunks, err := s.chunkRepo.ListChunksByKnowledgeID(ctx, kb.TenantID, k.ID)
+		if err != nil {
+			logger.Warnf(ctx, "Failed to load chunks to fingerprint knowledge %s: %v", k.ID, err)
+			continue
+		}
+		sort.Slice(chunks, func(i, j int) bool { return chunks[i].ChunkIndex < chunks[j].ChunkIndex })
+		contents := make([]string, 0, len(chunks))
+		for _, c := range chunks {
+			if c.ChunkType == types.ChunkTypeText {
+				contents = append(contents, c.Content)
+			}
+		}
+		draft := draftKnowledgeFingerprint(contents)
+		if draft == nil {
+			continue
+		}
+		s.saveKnowledgeFingerprint(ctx, k, draft, k.GetMetadata()[types.KnowledgeMetaAliasOf], chunks)
+		filled++
+	}
+	return missing
+}
+
+// duplicateUnion is a union-find over indexes.
+type duplicateUnion []int
+
+func newDuplicateUnion(n int) duplicateUnion {
+	u := make(duplicateUnion, n)
+	for i := range u {
+		u[i] = i
+	}
+	return u
+}
+
+func (u duplicateUnion) find(i int) int {
+	for u[i] != i {
+		u[i] = u[u[i]]
+		i = u[i]
+	}
+	return i
+}
+
+func (u duplicateUnion) union(a, b int) {
+	ra, rb := u.find(a), u.find(b)
+	if ra == rb {
+		return
+	}
+	// The lower index stays the root so clusters keep their oldest member first.
+	if rb < ra {
+		ra, rb = rb, ra
+	}
+	u[rb] = ra
+}
+
+// groups returns the members of every cluster with at least two members,
+// in index order.
+func (u duplicateUnion) groups() [][]int {
+	byRoot := make(map[int][]int)
+	for i := range u {
+		root := u.find(i)
+		byRoot[root] = append(byRoot[root], i)
+	}
+	out := make([][]int, 0)
+	for _, members := range byRoot {
+		if len(members) > 1 {
+			out = append(out, members)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if len(out[i]) != len(out[j]) {
+			return len(out[i]) > len(out[j])
+		}
+		return out[i][0] < out[j][0]
+	})
+	return out
+}
+
+// clusterDuplicateDocuments links documents whose signatures share an LSH
+// band and reach the threshold. fingerprints are ordered oldest first.
+func clusterDuplicateDocuments(
+	fingerprints []*types.KnowledgeFingerprint,
+	knowledgeByID map[string]*types.Knowledge,
+	threshold float64,
+) []*types.DocumentDuplicateSet {
+	similarity := func(a, b *types.KnowledgeFingerprint) float64 {
+		if a.ContentHash != "" && a.ContentHash == b.ContentHash {
+			return 1
+		}
+		return searchutil.MinHashSimilarity(a.MinHash, b.MinHash)
+	}
+	buckets := make(map[int64][]int)
+	for i, fp := range fingerprints {
+		for _, key := range searchutil.MinHashBandKeys(fp.MinHash) {
+			buckets[key] = append(buckets[key], i)
+		}
+	}
+	union := newDuplicateUnion(len(fingerprints))
+	compared := make(map[[2]int]bool)
+	for _, members := range buckets {
+		for x := 0; x < len(members); x++ {
+			for y := x + 1; y < len(members); y++ {
+				pair := [2]int{members[x], members[y]}
+				if compared[pair] {
+					continue
+				}
+				compared[pair] = true
+				if similarity(fingerprints[pair[0]], fingerprints[pair[1]]) >= threshold {
+					union.union(pair[0], pair[1])
+				}
+			}
+		}
+	}
+
+	sets := make([]*types.DocumentDuplicateSet, 0)
+	for _, group := range union.groups() {
+		first := fingerprints[group[0]]
+		set := &types.DocumentDuplicateSet{}
+		for _, i := range group {
+			fp := fingerprints[i]
+			member := &types.DocumentDuplicateMember{
+				KnowledgeID: fp.KnowledgeID,
+				AliasOf:     fp.AliasOf,
+				Similarity:  similarity(first, fp),
+				Exact:       fp.ContentHash == first.ContentHash,
+			}
+			if k := knowledgeByID[fp.KnowledgeID]; k != nil {
+				member.Title = k.Title
+				member.FileName = k.FileName
+			}
+			set.Members = append(set.Members, member)
+		}
+		sets = append(sets, set)
+	}
+	return sets
+}
+
+// clusterDuplicateChunks links chunks whose SimHashes are within
+// searchutil.SimHashNearDistance. Chunks with equal SimHashes are grouped
+// first, so repeated boilerplate does not turn the block buckets quadratic.
+func clusterDuplicateChunks(
+	chunks []*types.ChunkFingerprint,
+	knowledgeByID map[string]*types.Knowledge,
+) ([]*types.ChunkDuplicateSet, bool) {
+	sort.Slice(chunks, func(i, j int) bool {
+		if chunks[i].KnowledgeID != chunks[j].KnowledgeID {
+			return chunks[i].KnowledgeID < chunks[j].KnowledgeID
+		}
+		return chunks[i].ChunkIndex < chunks[j].ChunkIndex
+	})
+	hashIndex := make(map[uint64]int)
+	hashes := make([]uint64, 0)
+	members := make([][]int, 0)
+	for i, c := range chunks {
+		h := uint64(c.SimHash)
+		idx, ok := hashIndex[h]
+		if !ok {
+			idx = len(hashes)
+			hashIndex[h] = idx
+			hashes = append(hashes, h)
+			members = append(members, nil)
+		}
+		members[idx] = append(members[idx], i)
+	}
+
+	union := newDuplicateUnion(len(hashes))
+	buckets := make(map[uint32][]int)
+	for i, h := range hashes {
+		for _, block := range searchutil.SimHashBlocks(h) {
+			buckets[block] = append(buckets[block], i)
+		}
+	}
+	for _, bucket := range buckets {
+		for x := 0; x < len(bucket); x++ {
+			for y := x + 1; y < len(bucket); y++ {
+				if searchutil.SimHashDistance(hashes[bucket[x]], hashes[bucket[y]]) <= searchutil.SimHashNearDistance {
+					union.union(bucket[x], bucket[y])
+				}
+			}
+		}
+	}
+
+	// Expand hash clusters into chunk clusters; a single hash shared by
+	// several chunks is a cluster of its own.
+	clusters := make(map[int][]int)
+	for i := range hashes {
+		root := union.find(i)
+		clusters[root] = append(clusters[root], members[i]...)
+	}
+	roots := make([]int, 0, len(clusters))
+	for root, chunkIdx := range clusters {
+		if len(chunkIdx) > 1 {
+			roots = append(roots, root)
+		}
+	}
+	sort.Slice(roots, func(i, j int) bool {
+		if len(clusters[roots[i]]) != len(clusters[roots[j]]) {
+			return len(clusters[roots[i]]) > len(clusters[roots[j]])
+		}
+		return roots[i] < roots[j]
+	})
+	truncated := len(roots) > maxChunkDuplicateSets
+	if truncated {
+		roots = roots[:maxChunkDuplicateSets]
+	}
+
+	sets := make([]*types.ChunkDuplicateSet, 0, len(roots))
+	for _, root := range roots {
+		chunkIdx := clusters[root]
+		sort.Ints(chunkIdx)
+		first := uint64(chunks[chunkIdx[0]].SimHash)
+		set := &types.ChunkDuplicateSet{}
+		for _, i := range chunkIdx {
+			c := chunks[i]
+			member := &types.ChunkDuplicateMember{
+				ChunkID:     c.ChunkID,
+				KnowledgeID: c.KnowledgeID,
+				ChunkIndex:  c.ChunkIndex,
+				Similarity:  searchutil.SimHashSimilarity(first, uint64(c.SimHash)),
+			}
+			if k := knowledgeByID[c.KnowledgeID]; k != nil {
+				member.Title = k.Title
+			}
+			set.Members = append(set.Members, member)
+		}
+		sets = append(sets, set)
+	}
+	return sets, truncated
+}
+
+// fillChunkDuplicatePreviews sets each cluster's preview from its first chunk.
+func (s *knowledgeService) fillChunkDuplicatePreviews(
+	ctx context.Context, tenantID uint64, sets []*types.ChunkDuplicateSet,
+) {
+	if len(sets) == 0 {
+		return
+	}
+	ids := make([]string, 0, len(sets))
+	for _, set := range sets {
+		ids = append(ids, set.Members[0].ChunkID)
+	}
+	chunks, err := s.chunkRepo.ListChunksByID(ctx, tenantID, ids)
+	if err != nil {
+		logger.Warnf(ctx, "Failed to load duplicate chunk previews: %v", err)
+		return
+	}
+	content := make(map[string]string, len(chunks))
+	for _, c := range chunks {
+		content[c.ID] = c.Content
+	}
+	for _, set := range sets {
+		preview := []rune(strings.TrimSpace(content[set.Members[0].ChunkID]))
+		if len(preview) > duplicatePreviewRunes {
+			preview = append(preview[:duplicatePreviewRunes], '…')
+		}
+		set.Preview = string(preview)
+	}
+}
@@ -0,0 +1,82 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/searchutil"
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+const duplicateSample = "The onboarding guide explains how new employees request laptop access, " +
+	"enroll in the benefits plan, book the security training and find the team wiki. " +
+	"Managers approve each request within two working days."
+
+func fingerprintFor(id, content string) *types.KnowledgeFingerprint {
+	draft := draftKnowledgeFingerprint([]string{content})
+	return &types.KnowledgeFingerprint{KnowledgeID: id, ContentHash: draft.contentHash, MinHash: draft.signature}
+}
+
+func TestClusterDuplicateDocuments(t *testing.T) {
+	fingerprints := []*types.KnowledgeFingerprint{
+		fingerprintFor("k1", duplicateSample),
+		fingerprintFor("k2", "Unrelated release notes: the exporter now streams rows and the CLI gained a --dry-run flag."),
+		fingerprintFor("k3", strings.ToUpper(duplicateSample)),
+		fingerprintFor("k4", strings.Replace(duplicateSample, "two working days", "two business days", 1)),
+	}
+	knowledgeByID := map[string]*types.Knowledge{"k1": {ID: "k1", Title: "Onboarding"}}
+
+	sets := clusterDuplicateDocuments(fingerprints, knowledgeByID, 0.8)
+	if len(sets) != 1 {
+		t.Fatalf("clusters = %d, want 1", len(sets))
+	}
+	members := sets[0].Members
+	if len(members) != 3 || members[0].KnowledgeID != "k1" || members[0].Title != "Onboarding" {
+		t.Fatalf("unexpected cluster: %+v", members)
+	}
+	if !members[1].Exact || members[1].Similarity != 1 {
+		t.Fatalf("case-only copy should be exact: %+v", members[1])
+	}
+	if members[2].Exact || members[2].Similarity < 0.8 {
+		t.Fatalf("edited copy should be a near-duplicate: %+v", members[2])
+	}
+}
+
+func TestClusterDuplicateChunks(t *testing.T) {
+	h := searchutil.SimHash(duplicateSample)
+	chunks := []*types.ChunkFingerprint{
+		{ChunkID: "c1", KnowledgeID: "k1", ChunkIndex: 0, SimHash: int64(h)},
+		{ChunkID: "c2", KnowledgeID: "k2", ChunkIndex: 3, SimHash: int64(h ^ 1<<5)},
+		{ChunkID: "c3", KnowledgeID: "k2", ChunkIndex: 4, SimHash: int64(^h)},
+		{ChunkID: "c4", KnowledgeID: "k3", ChunkIndex: 1, SimHash: int64(h)},
+	}
+	sets, truncated := clusterDuplicateChunks(chunks, map[string]*types.Knowledge{})
+	if truncated || len(sets) != 1 {
+		t.Fatalf("clusters = %d (truncated %v), want 1", len(sets), truncated)
+	}
+	var ids []string
+	for _, m := range sets[0].Members {
+		ids = append(ids, m.ChunkID)
+	}
+	if strings.Join(ids, ",") != "c1,c2,c4" {
+		t.Fatalf("members = %v, want c1,c2,c4", ids)
+	}
+}
+
+func TestSetKnowledgeDuplicateMetadata(t *testing.T) {
+	k := &types.Knowledge{Metadata: types.JSON(`{"source":"upload"}`)}
+	setKnowledgeDuplicateMetadata(k, &duplicateMatch{knowledgeID: "k1", similarity: 0.95}, true)
+	meta := k.GetMetadata()
+	if meta[types.KnowledgeMetaAliasOf] != "k1" || meta[types.KnowledgeMetaDuplicateOf] != "k1" || meta["source"] != "upload" {
+		t.Fatalf("unexpected metadata after alias: %v", meta)
+	}
+	if !isKnowledgeAlias(k) {
+		t.Fatal("knowledge should be an alias")
+	}
+
+	setKnowledgeDuplicateMetadata(k, nil, false)
+	meta = k.GetMetadata()
+	if _, ok := meta[types.KnowledgeMetaDuplicateOf]; ok || isKnowledgeAlias(k) || meta["source"] != "upload" {
+		t.Fatalf("duplicate metadata not cleared: %v", meta)
+	}
+}
@@ -0,0 +1,184 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	werrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/types"
+	secutils "github.com/Tencent/WeKnora/internal/utils"
+)
+
+// staleReportLimit bounds the items of one stale report.
+const staleReportLimit = 500
+
+// validateFreshnessConfig rejects notification webhooks that are not
+// reachable http(s) URLs or that point at internal addresses.
+func validateFreshnessConfig(cfg *types.FreshnessConfig) error {
+	if cfg == nil || cfg.Notify == nil || cfg.Notify.WebhookURL == "" {
+		return nil
+	}
+	if err := secutils.ValidateURLForSSRF(cfg.Notify.WebhookURL); err != nil {
+		if hint := secutils.FormatSSRFError("Webhook URL", cfg.Notify.WebhookURL, err); hint != "" {
+			return werrors.NewBadRequestError(hint)
+		}
+		return werrors.NewBadRequestError(fmt.Sprintf("invalid freshness webhook URL: %v", err))
+	}
+	if cfg.Notify.IMChannelID != "" && cfg.Notify.IMChatID == "" {
+		return werrors.NewBadRequestError("freshness notify im_chat_id is required with im_channel_id")
+	}
+	return nil
+}
+
+// UpdateKnowledgeFreshness sets the validity and review dates of a knowledge
+// item by hand. Manual dates win over connector metadata and tag rules.
+func (s *knowledgeService) UpdateKnowledgeFreshness(
+	ctx context.Context, knowledgeID string, update *types.KnowledgeFreshnessUpdate,
+) (*types.Knowledge, error) {
+	knowledge, err := s.loadFreshnessKnowledge(ctx, knowledgeID)
+	if err != nil {
+		return nil, err
+	}
+	if update.Clear {
+		knowledge.ValidFrom, knowledge.ValidUntil, knowledge.ReviewBy = nil, nil, nil
+		knowledge.FreshnessSource = ""
+	} else {
+		if update.ValidFrom != nil {
+			knowledge.ValidFrom = update.ValidFrom
+		}
+		if update.ValidUntil != nil {
+			knowledge.ValidUntil = update.ValidUntil
+		}
+		if update.ReviewBy != nil {
+			knowledge.ReviewBy = update.ReviewBy
+		}
+		if knowledge.ValidFrom != nil && knowledge.ValidUntil != nil && !knowledge.ValidUntil.After(*knowledge.ValidFrom) {
+			return nil, werrors.NewBadRequestError("valid_until must be after valid_from")
+		}
+		knowledge.FreshnessSource = types.FreshnessSourceManual
+	}
+	if err := s.freshnessRepo.UpdateFreshness(ctx, knowledge); err != nil {
+		return nil, err
+	}
+	knowledge.FreshnessNotifiedAt = nil
+	return knowledge, nil
+}
+
+// ReviewKnowledge records that the content of a knowledge item was confirmed
+// and schedules the next review: one review cadence of the item later, or
+// the knowledge base's review interval when the item has none.
+func (s *knowledgeService) ReviewKnowledge(ctx context.Context, knowledgeID string) (*types.Knowledge, error) {
+	knowledge, err := s.loadFreshnessKnowledge(ctx, knowledgeID)
+	if err != nil {
+		return nil, err
+	}
+	kb, err := s.kbService.GetKnowledgeBaseByID(ctx, knowledge.KnowledgeBaseID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	knowledge.ReviewBy = nextReviewDate(knowledge, kb.FreshnessConfig, now)
+	knowledge.ReviewedAt = &now
+	if err := s.freshnessRepo.UpdateFreshness(ctx, knowledge); err != nil {
+		return nil, err
+	}
+	knowledge.FreshnessNotifiedAt = nil
+	return knowledge, nil
+}
+
+// GetStaleKnowledgeReport lists the items of a knowledge base that are
+// expired, not yet valid, expiring soon or overdue for review.
+func (s *knowledgeService) GetStaleKnowledgeReport(
+	ctx context.Context, kb *types.KnowledgeBase,
+) (*types.StaleKnowledgeReport, error) {
+	if s.freshnessRepo == nil {
+		return nil, werrors.NewBadRequestError("knowledge freshness is not available")
+	}
+	now := time.Now()
+	soon := kb.FreshnessConfig.ExpiringSoonWindow()
+	list, err := s.freshnessRepo.ListStale(ctx, kb.TenantID, kb.ID, now, now.Add(soon), nil, staleReportLimit+1)
+	if err != nil {
+		return nil, err
+	}
+	report := &types.StaleKnowledgeReport{KnowledgeBaseID: kb.ID, GeneratedAt: now}
+	if len(list) > staleReportLimit {
+		list, report.Truncated = list[:staleReportLimit], true
+	}
+	report.Items = staleKnowledgeItems(list, now, soon)
+	return report, nil
+}
+
+func (s *knowledgeService) loadFreshnessKnowledge(ctx context.Context, knowledgeID string) (*types.Knowledge, error) {
+	if s.freshnessRepo == nil {
+		return nil, werrors.NewBadRequestError("knowledge freshness is not available")
+	}
+	knowledge, err := s.repo.GetKnowledgeByID(ctx, types.MustTenantIDFromContext(ctx), knowledgeID)
+	if err != nil {
+		return nil, err
+	}
+	if knowledge == nil {
+		return nil, werrors.NewNotFoundError("knowledge not found")
+	}
+	return knowledge, nil
+}
+
+// nextReviewDate keeps the review cadence the item already has (the gap
+// between its last review, or creation, and its review date) and falls back
+// to the knowledge base's review interval. Nil means no further review.
+func nextReviewDate(k *types.Knowledge, cfg *types.FreshnessConfig, now time.Time) *time.Time {
+	var interval time.Duration
+	if k.ReviewBy != nil {
+		start := k.CreatedAt
+		if k.ReviewedAt != nil {
+			start = *k.ReviewedAt
+		}
+		interval = k.ReviewBy.Sub(start)
+	}
+	if interval <= 0 && cfg != nil && cfg.ReviewIntervalDays > 0 {
+		interval = time.Duration(cfg.ReviewIntervalDays) * 24 * time.Hour
+	}
+	if interval <= 0 {
+		return nil
+	}
+	next := now.Add(interval)
+	return &next
+}
+
+// staleState is the most pressing freshness problem of an item: being out
+// of validity beats an overdue review, which beats expiring soon.
+func staleState(k *types.Knowledge, now time.Time, soon time.Duration) string {
+	state := k.FreshnessAt(now, soon)
+	switch {
+	case state == types.FreshnessExpired || state == types.FreshnessNotYetValid:
+		return state
+	case k.ReviewOverdue(now):
+		return types.FreshnessReviewOverdue
+	}
+	return state
+}
+
+func staleKnowledgeItems(list []*types.Knowledge, now time.Time, soon time.Duration) []*types.StaleKnowledgeItem {
+	items := make([]*types.StaleKnowledgeItem, 0, len(list))
+	for _, k := range list {
+		state := staleState(k, now, soon)
+		if state == "" {
+			continue
+		}
+		title := k.Title
+		if title == "" {
+			title = k.FileName
+		}
+		items = append(items, &types.StaleKnowledgeItem{
+			KnowledgeID: k.ID,
+			Title:       title,
+			State:       state,
+			ValidFrom:   k.ValidFrom,
+			ValidUntil:  k.ValidUntil,
+			ReviewBy:    k.ReviewBy,
+			ReviewedAt:  k.ReviewedAt,
+			Source:      k.FreshnessSource,
+		})
+	}
+	return items
+}
@@ -0,0 +1,313 @@
+// Package service: knowledge freshness sweep.
+//
+// KnowledgeFreshnessService runs an hourly job over every knowledge base
+// that has a freshness_config. For each one it
+//
+//   - applies the tag rules, giving tagged items without dates of their own
+//     a valid_until and review_by counted from their creation time;
+//   - schedules a first review for the remaining undated items when the
+//     knowledge base has a review interval;
+//   - reports stale items (expired, not yet valid, expiring soon, overdue
+//     for review) to the configured IM chat and/or webhook.
+//
+// Each item is reported at most once per freshnessRenotifyInterval; setting
+// new dates or recording a review makes it eligible again at once. Search
+// does not depend on this job: it checks validity on every query.
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	secutils "github.com/Tencent/WeKnora/internal/utils"
+	"github.com/robfig/cron/v3"
+)
+
+const (
+	// freshnessSweepBatch bounds the items one sweep updates per rule and
+	// per knowledge base; the rest are picked up by the next sweep.
+	freshnessSweepBatch = 500
+	// freshnessNotifyLimit bounds the items listed in one notification.
+	freshnessNotifyLimit = 50
+	// freshnessRenotifyInterval is how long an item stays quiet after it
+	// was reported.
+	freshnessRenotifyInterval = 7 * 24 * time.Hour
+	// freshnessWebhookTimeout bounds one webhook delivery.
+	freshnessWebhookTimeout = 10 * time.Second
+	// freshnessWebhookEvent is the type field of webhook payloads.
+	freshnessWebhookEvent = "knowledge.stale"
+)
+
+// KnowledgeFreshnessService runs the freshness sweep.
+type KnowledgeFreshnessService struct {
+	repo     interfaces.KnowledgeFreshnessRepository
+	notifier interfaces.IMNotifier
+	client   *http.Client
+	cron     *cron.Cron
+
+	mu      sync.Mutex
+	started bool
+}
+
+// NewKnowledgeFreshnessService constructs a KnowledgeFreshnessService. Like
+// HousekeepingService it does NOT start the cron; call Start from the
+// application bootstrap.
+func NewKnowledgeFreshnessService(
+	repo interfaces.KnowledgeFreshnessRepository, notifier interfaces.IMNotifier,
+) *KnowledgeFreshnessService {
+	return &KnowledgeFreshnessService{
+		repo:     repo,
+		notifier: notifier,
+		client: secutils.NewSSRFSafeHTTPClient(secutils.SSRFSafeHTTPClientConfig{
+			Timeout:      freshnessWebhookTimeout,
+			MaxRedirects: 5,
+		}),
+		cron: cron.New(cron.WithSeconds(), cron.WithChain(
+			cron.Recover(cron.DefaultLogger),
+		)),
+	}
+}
+
+// Start registers the hourly sweep. Idempotent.
+func (f *KnowledgeFreshnessService) Start(ctx context.Context) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.started {
+		return nil
+	}
+	if !freshnessSweepEnabled() {
+		logger.Infof(ctx, "[Freshness] disabled via WEKNORA_FRESHNESS_SWEEP_ENABLED=false")
+		return nil
+	}
+	// Validity dates have day granularity in practice; hourly keeps
+	// notifications timely without repeated scans of large tables.
+	if _, err := f.cron.AddFunc("0 7 * * * *", func() {
+		f.runSweep(context.Background())
+	}); err != nil {
+		return err
+	}
+	f.cron.Start()
+	f.started = true
+	logger.Infof(ctx, "[Freshness] started with hourly sweep")
+	return nil
+}
+
+// Stop halts the cron and waits for an in-flight sweep to finish.
+func (f *KnowledgeFreshnessService) Stop() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !f.started {
+		return
+	}
+	c := f.cron.Stop()
+	<-c.Done()
+	f.started = false
+}
+
+func (f *KnowledgeFreshnessService) runSweep(ctx context.Context) {
+	kbs, err := f.repo.ListConfiguredKnowledgeBases(ctx)
+	if err != nil {
+		logger.Warnf(ctx, "[Freshness] knowledge base query failed: %v", err)
+		return
+	}
+	now := time.Now()
+	for _, kb := range kbs {
+		kb.FreshnessConfig.Normalize()
+		f.applyTagRules(ctx, kb)
+		f.scheduleReviews(ctx, kb)
+		f.notifyStale(ctx, kb, now)
+	}
+}
+
+// applyTagRules dates the undated items carrying a rule's tag. Rules apply
+// in order, so the first matching rule of an item wins.
+func (f *KnowledgeFreshnessService) applyTagRules(ctx context.Context, kb *types.KnowledgeBase) {
+	for _, rule := range kb.FreshnessConfig.TagRules {
+		list, err := f.repo.ListTagRuleCandidates(ctx, kb.TenantID, kb.ID, rule.TagID, freshnessSweepBatch)
+		if err != nil {
+			logger.Warnf(ctx, "[Freshness] tag rule query failed for kb %s tag %s: %v", kb.ID, rule.TagID, err)
+			continue
+		}
+		for _, k := range list {
+			applyFreshnessTagRule(k, rule)
+			if err := f.repo.UpdateFreshness(ctx, k); err != nil {
+				logger.Warnf(ctx, "[Freshness] tag rule update failed for knowledge %s: %v", k.ID, err)
+			}
+		}
+		if len(list) > 0 {
+			logger.Infof(ctx, "[Freshness] tag rule %s dated %d item(s) in kb %s", rule.TagID, len(list), kb.ID)
+		}
+	}
+}
+
+// applyFreshnessTagRule sets the dates a tag rule gives an item, counted
+// from its creation time.
+func applyFreshnessTagRule(k *types.Knowledge, rule types.FreshnessTagRule) {
+	if rule.ValidForDays > 0 {
+		until := k.CreatedAt.AddDate(0, 0, rule.ValidForDays)
+		k.ValidUntil = &until
+	}
+	if rule.ReviewEveryDays > 0 {
+		review := k.CreatedAt.AddDate(0, 0, rule.ReviewEveryDays)
+		k.ReviewBy = &review
+	}
+	k.FreshnessSource = types.FreshnessSourceTagRule
+}
+
+// scheduleReviews gives undated items a first review date one review
+// interval after their creation.
+func (f *KnowledgeFreshnessService) scheduleReviews(ctx context.Context, kb *types.KnowledgeBase) {
+	days := kb.FreshnessConfig.ReviewIntervalDays
+	if days <= 0 {
+		return
+	}
+	list, err := f.repo.ListUnscheduledReviews(ctx, kb.TenantID, kb.ID, freshnessSweepBatch)
+	if err != nil {
+		logger.Warnf(ctx, "[Freshness] review schedule query failed for kb %s: %v", kb.ID, err)
+		return
+	}
+	for _, k := range list {
+		review := k.CreatedAt.AddDate(0, 0, days)
+		k.ReviewBy = &review
+		if err := f.repo.UpdateFreshness(ctx, k); err != nil {
+			logger.Warnf(ctx, "[Freshness] review schedule update failed for knowledge %s: %v", k.ID, err)
+		}
+	}
+}
+
+// notifyStale reports the stale items not reported recently. Items are
+// marked as reported when at least one target accepted the report.
+func (f *KnowledgeFreshnessService) notifyStale(ctx context.Context, kb *types.KnowledgeBase, now time.Time) {
+	notify := kb.FreshnessConfig.Notify
+	if notify == nil {
+		return
+	}
+	soon := kb.FreshnessConfig.ExpiringSoonWindow()
+	notifiedBefore := now.Add(-freshnessRenotifyInterval)
+	list, err := f.repo.ListStale(ctx, kb.TenantID, kb.ID, now, now.Add(soon), &notifiedBefore, freshnessNotifyLimit)
+	if err != nil {
+		logger.Warnf(ctx, "[Freshness] stale query failed for kb %s: %v", kb.ID, err)
+		return
+	}
+	items := staleKnowledgeItems(list, now, soon)
+	if len(items) == 0 {
+		return
+	}
+
+	delivered := false
+	if notify.IMChannelID != "" && f.notifier != nil {
+		content := formatStaleNotification(kb, items)
+		if err := f.notifier.NotifyChat(ctx, kb.TenantID, notify.IMChannelID, notify.IMChatID, content); err != nil {
+			logger.Warnf(ctx, "[Freshness] IM notification failed for kb %s: %v", kb.ID, err)
+		} else {
+			delivered = true
+		}
+	}
+	if notify.WebhookURL != "" {
+		if err := f.postStaleWebhook(ctx, notify.WebhookURL, kb, items, now); err != nil {
+			logger.Warnf(ctx, "[Freshness] webhook notification failed for kb %s: %v", kb.ID, err)
+		} else {
+			delivered = true
+		}
+	}
+	if !delivered {
+		return
+	}
+	ids := make([]string, 0, len(items))
+	for _, item := range items {
+		ids = append(ids, item.KnowledgeID)
+	}
+	if err := f.repo.MarkNotified(ctx, kb.TenantID, ids, now); err != nil {
+		logger.Warnf(ctx, "[Freshness] mark notified failed for kb %s: %v", kb.ID, err)
+		return
+	}
+	logger.Infof(ctx, "[Freshness] reported %d stale item(s) in kb %s", len(items), kb.ID)
+}
+
+// staleStateLabels are the IM wording of the stale states.
+var staleStateLabels = map[string]string{
+	types.FreshnessExpired:       "已过期",
+	types.FreshnessNotYetValid:   "尚未生效",
+	types.FreshnessExpiringSoon:  "即将过期",
+	types.FreshnessReviewOverdue: "待复核",
+}
+
+// formatStaleNotification renders the IM report of a knowledge base.
+func formatStaleNotification(kb *types.KnowledgeBase, items []*types.StaleKnowledgeItem) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("📅 **知识库「%s」有 %d 条内容需要处理**\n", kb.Name, len(items)))
+	for _, item := range items {
+		sb.WriteString(fmt.Sprintf("- %s：%s", item.Title, staleStateLabels[item.State]))
+		switch {
+		case item.State == types.FreshnessReviewOverdue && item.ReviewBy != nil:
+			sb.WriteString(fmt.Sprintf("（复核期限 %s）", item.ReviewBy.Format("2006-01-02")))
+		case item.State == types.FreshnessNotYetValid && item.ValidFrom != nil:
+			sb.WriteString(fmt.Sprintf("（生效日期 %s）", item.ValidFrom.Format("2006-01-02")))
+		case item.ValidUntil != nil:
+			sb.WriteString(fmt.Sprintf("（有效期至 %s）", item.ValidUntil.Format("2006-01-02")))
+		}
+		sb.WriteString("\n")
+	}
+	sb.WriteString("\n请更新内容，或在知识详情中确认复核。")
+	return sb.String()
+}
+
+// postStaleWebhook delivers the stale report of a knowledge base as JSON.
+func (f *KnowledgeFreshnessService) postStaleWebhook(
+	ctx context.Context, webhookURL string, kb *types.KnowledgeBase, items []*types.StaleKnowledgeItem, now time.Time,
+) error {
+	if err := secutils.ValidateURLForSSRF(webhookURL); err != nil {
+		return err
+	}
+	raw, err := json.Marshal(map[string]any{
+		"type":                freshnessWebhookEvent,
+		"tenant_id":           kb.TenantID,
+		"knowledge_base_id":   kb.ID,
+		"knowledge_base_name": kb.Name,
+		"owner_id":            kb.CreatorID,
+		"items":               items,
+		"timestamp":           now.UTC().Format(time.RFC3339),
+	})
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, freshnessWebhookTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhookURL, bytes.NewReader(raw))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "WeKnora-Freshness-Webhook/1.0")
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned HTTP %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func freshnessSweepEnabled() bool {
+	// Default-on like the housekeeping sweep; it only touches knowledge
+	// bases that opted in with a freshness_config.
+	switch strings.ToLower(strings.TrimSpace(os.Getenv("WEKNORA_FRESHNESS_SWEEP_ENABLED"))) {
+	case "0", "false", "off", "no":
+		return false
+	}
+	return true
+}
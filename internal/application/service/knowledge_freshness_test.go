@@ -0,0 +1,105 @@
+package service
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+func TestApplyExpiredPolicy(t *testing.T) {
+	results := []*types.IndexWithScore{
+		{ChunkID: "c1", KnowledgeID: "old-a", KnowledgeBaseID: "kb-exclude", Score: 0.9},
+		{ChunkID: "c2", KnowledgeID: "old-b", KnowledgeBaseID: "kb-down", Score: 0.8},
+		{ChunkID: "c3", KnowledgeID: "current", KnowledgeBaseID: "kb-down", Score: 0.5},
+	}
+	stale := map[string]bool{"old-a": true, "old-b": true}
+	configs := map[string]*types.FreshnessConfig{
+		"kb-down": {ExpiredPolicy: types.ExpiredPolicyDownweight, DownweightFactor: 0.5},
+	}
+	out := applyExpiredPolicy(results, stale, configs)
+	if len(out) != 2 || out[0].ChunkID != "c3" || out[1].ChunkID != "c2" {
+		t.Fatalf("unexpected results: %+v", out)
+	}
+	if out[1].Score != 0.4 {
+		t.Fatalf("down-weighted score = %v, want 0.4", out[1].Score)
+	}
+}
+
+func TestMarkSearchResultFreshness(t *testing.T) {
+	now := time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)
+	in := func(days int) *time.Time { v := now.AddDate(0, 0, days); return &v }
+	results := []*types.SearchResult{
+		{KnowledgeBaseID: "kb", KnowledgeValidUntil: in(20)},
+		{KnowledgeBaseID: "kb-short", KnowledgeValidUntil: in(20)},
+		{KnowledgeBaseID: "kb"},
+	}
+	configs := map[string]*types.FreshnessConfig{"kb-short": {ExpiringSoonDays: 7}}
+	markSearchResultFreshness(results, configs, now)
+	if results[0].Freshness != types.FreshnessExpiringSoon || results[1].Freshness != "" || results[2].Freshness != "" {
+		t.Fatalf("freshness = %q, %q, %q", results[0].Freshness, results[1].Freshness, results[2].Freshness)
+	}
+}
+
+func TestNextReviewDate(t *testing.T) {
+	created := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	now := time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)
+	reviewBy := created.AddDate(0, 0, 90)
+	k := &types.Knowledge{CreatedAt: created, ReviewBy: &reviewBy}
+
+	next := nextReviewDate(k, &types.FreshnessConfig{ReviewIntervalDays: 30}, now)
+	if next == nil || !next.Equal(now.AddDate(0, 0, 90)) {
+		t.Fatalf("item cadence should win: %v", next)
+	}
+	next = nextReviewDate(&types.Knowledge{CreatedAt: created}, &types.FreshnessConfig{ReviewIntervalDays: 30}, now)
+	if next == nil || !next.Equal(now.AddDate(0, 0, 30)) {
+		t.Fatalf("knowledge base interval expected: %v", next)
+	}
+	if next = nextReviewDate(&types.Knowledge{CreatedAt: created}, nil, now); next != nil {
+		t.Fatalf("no cadence means no next review: %v", next)
+	}
+}
+
+func TestStaleKnowledgeItemsAndNotification(t *testing.T) {
+	now := time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)
+	in := func(days int) *time.Time { v := now.AddDate(0, 0, days); return &v }
+	list := []*types.Knowledge{
+		{ID: "k1", Title: "Travel policy", ValidUntil: in(-3), ReviewBy: in(-10)},
+		{ID: "k2", FileName: "pricing.pdf", ReviewBy: in(-1)},
+		{ID: "k3", Title: "Holidays", ValidUntil: in(5)},
+		{ID: "k4", Title: "Current", ValidUntil: in(200)},
+	}
+	items := staleKnowledgeItems(list, now, 30*24*time.Hour)
+	if len(items) != 3 {
+		t.Fatalf("items = %d, want 3", len(items))
+	}
+	want := []string{types.FreshnessExpired, types.FreshnessReviewOverdue, types.FreshnessExpiringSoon}
+	for i, item := range items {
+		if item.State != want[i] {
+			t.Errorf("item %s state = %s, want %s", item.KnowledgeID, item.State, want[i])
+		}
+	}
+	if items[1].Title != "pricing.pdf" {
+		t.Errorf("title should fall back to the file name: %q", items[1].Title)
+	}
+
+	msg := formatStaleNotification(&types.KnowledgeBase{Name: "HR"}, items)
+	for _, part := range []string{"知识库「HR」有 3 条", "Travel policy：已过期（有效期至 2026-10-15）", "pricing.pdf：待复核（复核期限 2026-10-17）"} {
+		if !strings.Contains(msg, part) {
+			t.Errorf("notification misses %q:\n%s", part, msg)
+		}
+	}
+}
+
+func TestApplyFreshnessTagRule(t *testing.T) {
+	created := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	k := &types.Knowledge{CreatedAt: created}
+	applyFreshnessTagRule(k, types.FreshnessTagRule{TagID: "t", ValidForDays: 365})
+	if k.ValidUntil == nil || !k.ValidUntil.Equal(created.AddDate(1, 0, 0)) || k.ReviewBy != nil {
+		t.Fatalf("unexpected dates: until=%v review=%v", k.ValidUntil, k.ReviewBy)
+	}
+	if k.FreshnessSource != types.FreshnessSourceTagRule {
+		t.Fatalf("source = %q", k.FreshnessSource)
+	}
+}
@@ -19,11 +19,14 @@ import (
 	"github.com/Tencent/WeKnora/internal/models/embedding"
 	"github.com/Tencent/WeKnora/internal/searchutil"
 	"github.com/Tencent/WeKnora/internal/tracing/langfuse"
+	"github.com/Tencent/WeKnora/internal/tracing/otlp"
 	"github.com/Tencent/WeKnora/internal/types"
 	"github.com/Tencent/WeKnora/internal/types/interfaces"
 	secutils "github.com/Tencent/WeKnora/internal/utils"
 	"github.com/google/uuid"
 	"github.com/hibiken/asynq"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 func (s *knowledgeService) cloneKnowledge(
@@ -148,6 +151,7 @@ func (s *knowledgeService) processDocumentFromPassage(ctx context.Context,
 			opts.QuestionCount = 3
 		}
 	}
+	s.recordKnowledgeVersion(ctx, kb, knowledge, strings.Join(passage, "\n\n"), chunks)
 	s.processChunks(ctx, kb, knowledge, chunks, opts)
 }
 
@@ -250,6 +254,13 @@ func (s *knowledgeService) processChunks(ctx context.Context,
 	if len(opts) > 0 {
 		options = opts[0]
 	}
+	// Attribute the embedding tokens of this ingestion to the knowledge base.
+	ctx = types.WithUsageKnowledgeBase(ctx, kb.ID)
+	ctx, span := otlp.Start(ctx, "knowledge.process_chunks", trace.WithAttributes(
+		attribute.String("weknora.knowledge_id", knowledge.ID),
+		attribute.Int("weknora.chunk.count", len(chunks)),
+	))
+	defer span.End()
 
 	// Check if knowledge is being deleted/cancelled before processing.
 	// Both statuses short-circuit identically here — there's nothing to clean
@@ -303,6 +314,11 @@ func (s *knowledgeService) processChunks(ctx context.Context,
 
 	logger.Infof(ctx, "Cleanup completed, starting to process new chunks")
 
+	fingerprint, handled := s.applyDuplicatePolicy(ctx, kb, knowledge, chunks)
+	if handled {
+		return
+	}
+
 	// ========== DocReader 解析结果日志 ==========
 	logger.Infof(ctx, "[DocReader] ========== 解析结果概览 ==========")
 	logger.Infof(ctx, "[DocReader] 知识ID: %s, 知识库ID: %s", knowledge.ID, knowledge.KnowledgeBaseID)
@@ -493,6 +509,7 @@ func (s *knowledgeService) processChunks(ctx context.Context,
 		"chunks_written":   len(insertChunks),
 		"total_text_chars": totalChunkChars,
 	})
+	s.saveKnowledgeFingerprint(ctx, knowledge, fingerprint, "", textChunks)
 
 	// Create index information and perform vector indexing — only when vector/keyword is enabled.
 	// Chunks are ALWAYS saved to DB (above) because wiki and graph need them even without vector indexing.
@@ -2434,6 +2451,7 @@ func (s *knowledgeService) ReparseKnowledge(
 
 	processOverrides, _ = existing.ProcessOverrides()
 	reparseEff := ResolveProcessConfig(kb, processOverrides)
+	versionOrigin := knowledgeVersionOriginFrom(ctx)
 
 	// Keep wiki's pending queue consistent across both manual and non-manual
 	// paths. The destructive work (swapping old wiki contributions for new)
@@ -2527,6 +2545,8 @@ func (s *knowledgeService) ReparseKnowledge(
 			QuestionCount:            questionCount,
 			Language:                 lang,
 			Attempt:                  reparseAttempt,
+			VersionEditorID:          versionOrigin.editorID,
+			RestoreVersion:           versionOrigin.restoreVersion,
 		}
 
 		langfuse.InjectTracing(ctx, &taskPayload)
@@ -2581,6 +2601,8 @@ func (s *knowledgeService) ReparseKnowledge(
 			QuestionCount:            questionCount,
 			Language:                 lang,
 			Attempt:                  reparseAttempt,
+			VersionEditorID:          versionOrigin.editorID,
+			RestoreVersion:           versionOrigin.restoreVersion,
 		}
 
 		langfuse.InjectTracing(ctx, &taskPayload)
@@ -2632,6 +2654,8 @@ func (s *knowledgeService) ReparseKnowledge(
 			QuestionCount:            questionCount,
 			Language:                 lang,
 			Attempt:                  reparseAttempt,
+			VersionEditorID:          versionOrigin.editorID,
+			RestoreVersion:           versionOrigin.restoreVersion,
 		}
 
 		langfuse.InjectTracing(ctx, &taskPayload)
@@ -3056,6 +3080,9 @@ func (s *knowledgeService) ProcessManualUpdate(ctx context.Context, t *asynq.Tas
 	ctx = logger.WithRequestID(ctx, payload.RequestId)
 	ctx = logger.WithField(ctx, "manual_process", payload.KnowledgeID)
 	ctx = context.WithValue(ctx, types.TenantIDContextKey, payload.TenantID)
+	ctx = withKnowledgeVersionOrigin(ctx, knowledgeVersionOrigin{
+		editorID: payload.VersionEditorID, restoreVersion: payload.RestoreVersion,
+	})
 
 	tenantInfo, err := s.tenantRepo.GetTenantByID(ctx, payload.TenantID)
 	if err != nil {
@@ -3142,6 +3169,15 @@ func (s *knowledgeService) ProcessManualUpdate(ctx context.Context, t *asynq.Tas
 	return nil
 }
 
+// recordURLIngest counts the fetched content of a URL import against the
+// daily upload volume. The task runs without the request's caller, so only
+// the workspace is charged; CreateKnowledgeFromURL checked the caller.
+func (s *knowledgeService) recordURLIngest(ctx context.Context, size int64) {
+	if s.rateLimits != nil {
+		s.rateLimits.RecordIngest(ctx, size)
+	}
+}
+
 // ProcessDocument handles Asynq document processing tasks
 func (s *knowledgeService) ProcessDocument(ctx context.Context, t *asynq.Task) error {
 	var payload types.DocumentProcessPayload
@@ -3153,6 +3189,9 @@ func (s *knowledgeService) ProcessDocument(ctx context.Context, t *asynq.Task) e
 	ctx = logger.WithRequestID(ctx, payload.RequestId)
 	ctx = logger.WithField(ctx, "document_process", payload.KnowledgeID)
 	ctx = context.WithValue(ctx, types.TenantIDContextKey, payload.TenantID)
+	ctx = withKnowledgeVersionOrigin(ctx, knowledgeVersionOrigin{
+		editorID: payload.VersionEditorID, restoreVersion: payload.RestoreVersion,
+	})
 	if payload.Language != "" {
 		ctx = context.WithValue(ctx, types.LanguageContextKey, payload.Language)
 	}
@@ -3297,7 +3336,18 @@ func (s *knowledgeService) ProcessDocument(ctx context.Context, t *asynq.Task) e
 	var convertResult *types.ReadResult
 	var chunks []types.ParsedChunk
 
-	if payload.FileURL != "" {
+	if payload.RestoreVersion > 0 && payload.FilePath == "" {
+		// Restore of content without a stored file: index the version's
+		// markdown rather than fetching the source again.
+		convertResult, err = s.restoredVersionContent(ctx, knowledge, payload.RestoreVersion)
+		if err != nil {
+			knowledge.ParseStatus = "failed"
+			knowledge.ErrorMessage = err.Error()
+			knowledge.UpdatedAt = time.Now()
+			s.repo.UpdateKnowledge(ctx, knowledge)
+			return nil
+		}
+	} else if payload.FileURL != "" {
 		// file_url import: SSRF re-check (防 DNS 重绑定), download, persist, then delegate to convert()
 		if err := secutils.ValidateURLForSSRF(payload.FileURL); err != nil {
 			logger.Errorf(ctx, "File URL rejected for SSRF protection in ProcessDocument: %s, err: %v", payload.FileURL, err)
@@ -3361,6 +3411,7 @@ func (s *knowledgeService) ProcessDocument(ctx context.Context, t *asynq.Task) e
 		if convertResult == nil {
 			return nil
 		}
+		s.recordURLIngest(ctx, int64(len(contentBytes)))
 	} else if payload.URL != "" {
 		// URL import
 		convertResult, err = s.convert(ctx, payload, kb, knowledge, eff, isLastRetry)
@@ -3370,6 +3421,9 @@ func (s *knowledgeService) ProcessDocument(ctx context.Context, t *asynq.Task) e
 		if convertResult == nil {
 			return nil
 		}
+		// The page is fetched by the document reader; its extracted text is
+		// what reaches the knowledge base.
+		s.recordURLIngest(ctx, int64(len(convertResult.MarkdownContent)))
 		// Update knowledge title from extracted page title if not already set
 		if knowledge.Title == "" || knowledge.Title == payload.URL {
 			if extractedTitle := convertResult.Metadata["title"]; extractedTitle != "" {
@@ -3403,6 +3457,7 @@ func (s *knowledgeService) ProcessDocument(ctx context.Context, t *asynq.Task) e
 			EnableQuestionGeneration: payload.EnableQuestionGeneration,
 			QuestionCount:            payload.QuestionCount,
 		}
+		s.recordKnowledgeVersion(ctx, kb, knowledge, strings.Join(payload.Passages, "\n\n"), passageChunks)
 		s.processChunks(ctx, kb, knowledge, passageChunks, passageOpts)
 		return nil
 	} else {
@@ -3502,6 +3557,10 @@ func (s *knowledgeService) ProcessDocument(ctx context.Context, t *asynq.Task) e
 	// Step 3: Split into chunks using Go chunker. Browser textareas normalize
 	// pasted content to LF, so normalize uploaded source text before calculating
 	// chunk boundaries as well.
+	_, chunkSpan := otlp.Start(ctx, "knowledge.chunk", trace.WithAttributes(
+		attribute.String("weknora.knowledge_id", knowledge.ID),
+		attribute.Bool("weknora.chunk.parent_child", eff.ChunkingConfig.EnableParentChild),
+	))
 	convertResult.MarkdownContent = chunker.NormalizeLineEndings(convertResult.MarkdownContent)
 	chunkCfg := buildSplitterConfigFromChunking(eff.ChunkingConfig)
 
@@ -3552,7 +3611,11 @@ func (s *knowledgeService) ProcessDocument(ctx context.Context, t *asynq.Task) e
 		logger.Infof(ctx, "Split document into %d chunks for knowledge %s", len(chunks), knowledge.ID)
 	}
 
+	chunkSpan.SetAttributes(attribute.Int("weknora.chunk.count", len(chunks)))
+	chunkSpan.End()
+
 	// Step 4: Process chunks (vectorize + index + enqueue async tasks)
+	s.recordKnowledgeVersion(ctx, kb, knowledge, convertResult.MarkdownContent, chunks)
 	s.processChunks(ctx, kb, knowledge, chunks, processOpts)
 
 	return nil
@@ -3566,7 +3629,13 @@ func (s *knowledgeService) convert(
 	knowledge *types.Knowledge,
 	eff types.EffectiveProcessConfig,
 	isLastRetry bool,
-) (*types.ReadResult, error) {
+) (_ *types.ReadResult, retErr error) {
+	ctx, span := otlp.Start(ctx, "knowledge.parse", trace.WithAttributes(
+		attribute.String("weknora.knowledge_id", knowledge.ID),
+		attribute.String("weknora.file_type", payload.FileType),
+	))
+	defer func() { otlp.End(span, retErr) }()
+
 	// Stage tracking: docreader. Mark the stage as running here so the
 	// timeline reflects "DocReader" the moment a worker picks the task
 	// up — before that, the stage stays "pending" from the initial
@@ -0,0 +1,439 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	werrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// Knowledge content versions. A version is recorded where a parse hands its
+// chunks to processChunks, so every path that changes indexed content (first
+// parse, reparse, manual edit, data source sync, restore) produces one.
+// Recording is best effort: a failure is logged and never fails the parse.
+
+// knowledgeVersionOriginKey carries a knowledgeVersionOrigin in a context.
+type knowledgeVersionOriginKey struct{}
+
+// knowledgeVersionsRetainedKey marks a deletion that keeps version history.
+type knowledgeVersionsRetainedKey struct{}
+
+// knowledgeVersionOrigin describes who triggered a parse and, for a restore,
+// which version it brings back. It travels from the request to the worker in
+// the task payload.
+type knowledgeVersionOrigin struct {
+	editorID       string
+	restoreVersion int
+}
+
+func withKnowledgeVersionOrigin(ctx context.Context, origin knowledgeVersionOrigin) context.Context {
+	return context.WithValue(ctx, knowledgeVersionOriginKey{}, origin)
+}
+
+// knowledgeVersionOriginFrom returns the origin in ctx. On the request side,
+// where none was set, the editor is the calling user.
+func knowledgeVersionOriginFrom(ctx context.Context) knowledgeVersionOrigin {
+	origin, _ := ctx.Value(knowledgeVersionOriginKey{}).(knowledgeVersionOrigin)
+	if origin.editorID == "" {
+		origin.editorID, _ = types.UserIDFromContext(ctx)
+	}
+	return origin
+}
+
+// withKnowledgeVersionsRetained makes DeleteKnowledge keep the version
+// history, for a caller that hands it to a replacement afterwards.
+func withKnowledgeVersionsRetained(ctx context.Context) context.Context {
+	return context.WithValue(ctx, knowledgeVersionsRetainedKey{}, true)
+}
+
+func knowledgeVersionsRetained(ctx context.Context) bool {
+	retained, _ := ctx.Value(knowledgeVersionsRetainedKey{}).(bool)
+	return retained
+}
+
+func knowledgeContentHash(markdown string) string {
+	sum := sha256.Sum256([]byte(markdown))
+	return hex.EncodeToString(sum[:])
+}
+
+// knowledgeVersionSource names what produced a new version.
+func knowledgeVersionSource(
+	knowledge *types.Knowledge, latest *types.KnowledgeVersion, origin knowledgeVersionOrigin,
+) string {
+	switch {
+	case origin.restoreVersion > 0:
+		return types.KnowledgeVersionSourceRestore
+	case knowledge.GetMetadata()["datasource_id"] != "":
+		return types.KnowledgeVersionSourceSync
+	case latest == nil:
+		return types.KnowledgeVersionSourceCreate
+	case knowledge.IsManual():
+		return types.KnowledgeVersionSourceEdit
+	default:
+		return types.KnowledgeVersionSourceReparse
+	}
+}
+
+// recordKnowledgeVersion snapshots the content a parse is about to index,
+// unless it matches the latest version.
+func (s *knowledgeService) recordKnowledgeVersion(
+	ctx context.Context,
+	kb *types.KnowledgeBase,
+	knowledge *types.Knowledge,
+	markdown string,
+	chunks []types.ParsedChunk,
+) {
+	if s.versionRepo == nil || knowledge == nil {
+		return
+	}
+	latest, err := s.versionRepo.Latest(ctx, knowledge.TenantID, knowledge.ID)
+	if err != nil {
+		logger.Warnf(ctx, "Failed to load latest version of knowledge %s: %v", knowledge.ID, err)
+		return
+	}
+	hash := knowledgeContentHash(markdown)
+	if latest != nil && latest.ContentHash == hash {
+		return
+	}
+
+	origin := knowledgeVersionOriginFrom(ctx)
+	versionChunks := make(types.KnowledgeVersionChunks, 0, len(chunks))
+	for _, c := range chunks {
+		versionChunks = append(versionChunks, types.KnowledgeVersionChunk{
+			Seq:           c.Seq,
+			Content:       c.Content,
+			ContextHeader: c.ContextHeader,
+			Start:         c.Start,
+			End:           c.End,
+		})
+	}
+	version := &types.KnowledgeVersion{
+		TenantID:      knowledge.TenantID,
+		KnowledgeID:   knowledge.ID,
+		Source:        knowledgeVersionSource(knowledge, latest, origin),
+		RestoredFrom:  origin.restoreVersion,
+		Title:         knowledge.Title,
+		FileName:      knowledge.FileName,
+		FileType:      knowledge.FileType,
+		FileSize:      knowledge.FileSize,
+		FileHash:      knowledge.FileHash,
+		ContentHash:   hash,
+		ContentLength: utf8.RuneCountInString(markdown),
+		ChunkCount:    len(chunks),
+		Markdown:      markdown,
+		Chunks:        versionChunks,
+		EditorID:      origin.editorID,
+		CreatedAt:     time.Now(),
+	}
+	if knowledge.FilePath != "" {
+		if latest.HasFile() && knowledge.FileHash != "" && latest.FileHash == knowledge.FileHash {
+			version.FilePath = latest.FilePath
+		} else {
+			fileSvc := s.resolveFileServiceForPath(ctx, kb, knowledge.FilePath)
+			copied, err := fileSvc.CopyFile(ctx, knowledge.FilePath, knowledge.TenantID, knowledge.ID)
+			if err != nil {
+				logger.Warnf(ctx, "Failed to copy file of knowledge %s for its version: %v", knowledge.ID, err)
+			} else {
+				version.FilePath = copied
+			}
+		}
+	}
+	if err := s.versionRepo.Create(ctx, version); err != nil {
+		logger.Warnf(ctx, "Failed to record version of knowledge %s: %v", knowledge.ID, err)
+		return
+	}
+	logger.Infof(ctx, "Recorded version %d (%s) of knowledge %s", version.Version, version.Source, knowledge.ID)
+
+	stale, err := s.versionRepo.Prune(ctx, knowledge.TenantID, knowledge.ID, types.KnowledgeMaxVersions)
+	if err != nil {
+		logger.Warnf(ctx, "Failed to prune versions of knowledge %s: %v", knowledge.ID, err)
+		return
+	}
+	s.deleteKnowledgeVersionFiles(ctx, kb, stale)
+}
+
+// deleteKnowledgeVersionFiles removes version file copies. Errors only leak
+// storage and are logged.
+func (s *knowledgeService) deleteKnowledgeVersionFiles(ctx context.Context, kb *types.KnowledgeBase, paths []string) {
+	for _, path := range paths {
+		if err := s.resolveFileServiceForPath(ctx, kb, path).DeleteFile(ctx, path); err != nil {
+			logger.Warnf(ctx, "Failed to delete knowledge version file %s: %v", path, err)
+		}
+	}
+}
+
+// purgeKnowledgeVersions drops the versions of deleted knowledge, unless the
+// caller retains them for a replacement.
+func (s *knowledgeService) purgeKnowledgeVersions(
+	ctx context.Context, tenantID uint64, kb *types.KnowledgeBase, knowledgeIDs []string,
+) {
+	if s.versionRepo == nil || len(knowledgeIDs) == 0 || knowledgeVersionsRetained(ctx) {
+		return
+	}
+	paths, err := s.versionRepo.DeleteByKnowledgeIDs(ctx, tenantID, knowledgeIDs)
+	if err != nil {
+		logger.Warnf(ctx, "Failed to delete knowledge versions: %v", err)
+		return
+	}
+	s.deleteKnowledgeVersionFiles(ctx, kb, paths)
+}
+
+// loadVersionedKnowledge resolves a knowledge item of the request's tenant.
+func (s *knowledgeService) loadVersionedKnowledge(ctx context.Context, knowledgeID string) (*types.Knowledge, error) {
+	if s.versionRepo == nil {
+		return nil, werrors.NewBadRequestError("knowledge versions are not available")
+	}
+	knowledge, err := s.repo.GetKnowledgeByID(ctx, types.MustTenantIDFromContext(ctx), knowledgeID)
+	if err != nil {
+		return nil, err
+	}
+	if knowledge == nil {
+		return nil, werrors.NewNotFoundError("knowledge not found")
+	}
+	return knowledge, nil
+}
+
+func (s *knowledgeService) getKnowledgeVersion(
+	ctx context.Context, knowledge *types.Knowledge, version int,
+) (*types.KnowledgeVersion, error) {
+	v, err := s.versionRepo.Get(ctx, knowledge.TenantID, knowledge.ID, version)
+	if err != nil {
+		return nil, err
+	}
+	if v == nil {
+		return nil, werrors.NewNotFoundError(fmt.Sprintf("version %d not found", version))
+	}
+	return v, nil
+}
+
+// ListKnowledgeVersions returns the content versions of a knowledge item.
+func (s *knowledgeService) ListKnowledgeVersions(
+	ctx context.Context, knowledgeID string,
+) (*types.KnowledgeVersionListResponse, error) {
+	knowledge, err := s.loadVersionedKnowledge(ctx, knowledgeID)
+	if err != nil {
+		return nil, err
+	}
+	versions, err := s.versionRepo.List(ctx, knowledge.TenantID, knowledge.ID)
+	if err != nil {
+		return nil, err
+	}
+	resp := &types.KnowledgeVersionListResponse{Versions: versions}
+	if len(versions) > 0 {
+		resp.CurrentVersion = versions[0].Version
+	}
+	return resp, nil
+}
+
+// GetKnowledgeVersion returns one version with its markdown and chunk set.
+func (s *knowledgeService) GetKnowledgeVersion(
+	ctx context.Context, knowledgeID string, version int,
+) (*types.KnowledgeVersion, error) {
+	knowledge, err := s.loadVersionedKnowledge(ctx, knowledgeID)
+	if err != nil {
+		return nil, err
+	}
+	return s.getKnowledgeVersion(ctx, knowledge, version)
+}
+
+// DiffKnowledgeVersions returns a unified diff of two versions' markdown.
+func (s *knowledgeService) DiffKnowledgeVersions(
+	ctx context.Context, knowledgeID string, from, to int,
+) (*types.KnowledgeVersionDiff, error) {
+	knowledge, err := s.loadVersionedKnowledge(ctx, knowledgeID)
+	if err != nil {
+		return nil, err
+	}
+	if to <= 0 {
+		latest, err := s.versionRepo.Latest(ctx, knowledge.TenantID, knowledge.ID)
+		if err != nil {
+			return nil, err
+		}
+		if latest == nil {
+			return nil, werrors.NewNotFoundError("knowledge has no versions")
+		}
+		to = latest.Version
+	}
+	fromVersion, err := s.getKnowledgeVersion(ctx, knowledge, from)
+	if err != nil {
+		return nil, err
+	}
+	toVersion, err := s.getKnowledgeVersion(ctx, knowledge, to)
+	if err != nil {
+		return nil, err
+	}
+	diff, added, removed, err := diffMarkdown(fromVersion, toVersion)
+	if err != nil {
+		return nil, err
+	}
+	return &types.KnowledgeVersionDiff{
+		KnowledgeID:    knowledge.ID,
+		FromVersion:    from,
+		ToVersion:      to,
+		UnifiedDiff:    diff,
+		AddedLines:     added,
+		RemovedLines:   removed,
+		ChunkCountFrom: fromVersion.ChunkCount,
+		ChunkCountTo:   toVersion.ChunkCount,
+	}, nil
+}
+
+// diffMarkdown renders a unified diff and counts changed lines.
+func diffMarkdown(from, to *types.KnowledgeVersion) (string, int, int, error) {
+	diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(from.Markdown),
+		B:        difflib.SplitLines(to.Markdown),
+		FromFile: fmt.Sprintf("v%d", from.Version),
+		ToFile:   fmt.Sprintf("v%d", to.Version),
+		Context:  3,
+	})
+	if err != nil {
+		return "", 0, 0, err
+	}
+	added, removed := 0, 0
+	for _, line := range strings.Split(diff, "\n") {
+		switch {
+		case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"):
+		case strings.HasPrefix(line, "+"):
+			added++
+		case strings.HasPrefix(line, "-"):
+			removed++
+		}
+	}
+	return diff, added, removed, nil
+}
+
+// RestoreKnowledgeVersion re-indexes the knowledge from an earlier version.
+// A version with a file copy is restored by reparsing that file, so images
+// are extracted again; other versions are re-indexed from their markdown.
+func (s *knowledgeService) RestoreKnowledgeVersion(
+	ctx context.Context, knowledgeID string, version int,
+) (*types.Knowledge, error) {
+	knowledge, err := s.loadVersionedKnowledge(ctx, knowledgeID)
+	if err != nil {
+		return nil, err
+	}
+	switch knowledge.ParseStatus {
+	case types.ParseStatusPending, types.ParseStatusProcessing, types.ParseStatusDeleting:
+		return nil, werrors.NewConflictError("knowledge is being processed, retry when it finishes")
+	}
+	v, err := s.getKnowledgeVersion(ctx, knowledge, version)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case knowledge.IsManual():
+		meta, err := knowledge.ManualMetadata()
+		if err != nil || meta == nil {
+			return nil, werrors.NewBadRequestError("无法获取手工知识内容")
+		}
+		meta.Content = v.Markdown
+		meta.Version++
+		meta.UpdatedAt = time.Now().UTC().Format(time.RFC3339)
+		metaJSON, err := meta.ToJSON()
+		if err != nil {
+			return nil, err
+		}
+		if err := s.repo.UpdateKnowledgeColumn(ctx, knowledge.ID, "metadata", metaJSON); err != nil {
+			return nil, err
+		}
+	case knowledge.FilePath != "" && v.HasFile():
+		if err := s.restoreKnowledgeFile(ctx, knowledge, v); err != nil {
+			return nil, err
+		}
+	case knowledge.FilePath != "":
+		return nil, werrors.NewBadRequestError(
+			fmt.Sprintf("version %d has no stored file to restore", version))
+	case knowledge.Source == "" || (knowledge.Type != "url" && knowledge.Type != "file_url"):
+		// Passage knowledge cannot be reparsed, so there is no path to re-index it.
+		return nil, werrors.NewBadRequestError("this knowledge type cannot be restored")
+	}
+
+	origin := knowledgeVersionOriginFrom(ctx)
+	origin.restoreVersion = v.Version
+	restored, err := s.ReparseKnowledge(withKnowledgeVersionOrigin(ctx, origin), knowledge.ID, nil)
+	if err != nil {
+		return restored, err
+	}
+	logger.Infof(ctx, "Restoring knowledge %s to version %d", knowledge.ID, v.Version)
+	return restored, nil
+}
+
+// restoredVersionContent returns a version's markdown as the parse input of
+// a restore task.
+func (s *knowledgeService) restoredVersionContent(
+	ctx context.Context, knowledge *types.Knowledge, version int,
+) (*types.ReadResult, error) {
+	if s.versionRepo == nil {
+		return nil, fmt.Errorf("knowledge versions are not available")
+	}
+	v, err := s.versionRepo.Get(ctx, knowledge.TenantID, knowledge.ID, version)
+	if err != nil {
+		return nil, fmt.Errorf("load version %d: %w", version, err)
+	}
+	if v == nil {
+		return nil, fmt.Errorf("version %d no longer exists", version)
+	}
+	return &types.ReadResult{MarkdownContent: v.Markdown}, nil
+}
+
+// restoreKnowledgeFile points the knowledge at a fresh copy of the version's
+// file and drops the current one.
+func (s *knowledgeService) restoreKnowledgeFile(
+	ctx context.Context, knowledge *types.Knowledge, v *types.KnowledgeVersion,
+) error {
+	kb, err := s.kbService.GetKnowledgeBaseByID(ctx, knowledge.KnowledgeBaseID)
+	if err != nil {
+		return err
+	}
+	copied, err := s.resolveFileServiceForPath(ctx, kb, v.FilePath).
+		CopyFile(ctx, v.FilePath, knowledge.TenantID, knowledge.ID)
+	if err != nil {
+		return fmt.Errorf("copy version file: %w", err)
+	}
+	previous := knowledge.FilePath
+	knowledge.FilePath = copied
+	knowledge.FileName = v.FileName
+	knowledge.FileType = v.FileType
+	knowledge.FileSize = v.FileSize
+	knowledge.FileHash = v.FileHash
+	knowledge.UpdatedAt = time.Now()
+	if err := s.repo.UpdateKnowledge(ctx, knowledge); err != nil {
+		return err
+	}
+	if previous != copied {
+		if err := s.resolveFileServiceForPath(ctx, kb, previous).DeleteFile(ctx, previous); err != nil {
+			logger.Warnf(ctx, "Failed to delete replaced file of knowledge %s: %v", knowledge.ID, err)
+		}
+	}
+	return nil
+}
+
+// TransferKnowledgeVersions hands the version history of a replaced
+// knowledge item to its replacement, or drops it when there is none.
+func (s *knowledgeService) TransferKnowledgeVersions(
+	ctx context.Context, fromKnowledgeID, toKnowledgeID string,
+) error {
+	if s.versionRepo == nil || fromKnowledgeID == "" || fromKnowledgeID == toKnowledgeID {
+		return nil
+	}
+	tenantID := types.MustTenantIDFromContext(ctx)
+	if toKnowledgeID == "" {
+		paths, err := s.versionRepo.DeleteByKnowledgeIDs(ctx, tenantID, []string{fromKnowledgeID})
+		if err != nil {
+			return err
+		}
+		s.deleteKnowledgeVersionFiles(ctx, nil, paths)
+		return nil
+	}
+	return s.versionRepo.Transfer(ctx, tenantID, fromKnowledgeID, toKnowledgeID)
+}
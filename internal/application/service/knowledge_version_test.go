@@ -0,0 +1,347 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/application/repository"
+	werrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"github.com/hibiken/asynq"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestDiffMarkdownCountsChangedLines(t *testing.T) {
+	from := &types.KnowledgeVersion{Version: 1, Markdown: "# Title\n\nkeep\nold line\n"}
+	to := &types.KnowledgeVersion{Version: 3, Markdown: "# Title\n\nkeep\nnew line\nadded\n"}
+
+	diff, added, removed, err := diffMarkdown(from, to)
+	if err != nil {
+		t.Fatalf("diffMarkdown: %v", err)
+	}
+	if added != 2 || removed != 1 {
+		t.Fatalf("added=%d removed=%d, want 2 and 1\n%s", added, removed, diff)
+	}
+	if !strings.Contains(diff, "--- v1") || !strings.Contains(diff, "+++ v3") {
+		t.Fatalf("diff is missing version headers:\n%s", diff)
+	}
+}
+
+func TestKnowledgeVersionSource(t *testing.T) {
+	file := &types.Knowledge{Type: "file"}
+	manual := &types.Knowledge{Type: types.KnowledgeTypeManual}
+	synced := &types.Knowledge{Type: "file", Metadata: types.JSON(`{"datasource_id":"ds-1"}`)}
+	latest := &types.KnowledgeVersion{Version: 1}
+
+	cases := []struct {
+		name      string
+		knowledge *types.Knowledge
+		latest    *types.KnowledgeVersion
+		origin    knowledgeVersionOrigin
+		want      string
+	}{
+		{"first parse", file, nil, knowledgeVersionOrigin{}, types.KnowledgeVersionSourceCreate},
+		{"reparse", file, latest, knowledgeVersionOrigin{}, types.KnowledgeVersionSourceReparse},
+		{"manual edit", manual, latest, knowledgeVersionOrigin{}, types.KnowledgeVersionSourceEdit},
+		{"sync", synced, latest, knowledgeVersionOrigin{}, types.KnowledgeVersionSourceSync},
+		{"restore", synced, latest, knowledgeVersionOrigin{restoreVersion: 1}, types.KnowledgeVersionSourceRestore},
+	}
+	for _, tc := range cases {
+		if got := knowledgeVersionSource(tc.knowledge, tc.latest, tc.origin); got != tc.want {
+			t.Errorf("%s: got %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
+
+type versionTestFiles struct {
+	interfaces.FileService
+	copiedFrom []string
+	deleted    []string
+}
+
+func (f *versionTestFiles) CopyFile(_ context.Context, src string, _ uint64, knowledgeID string) (string, error) {
+	f.copiedFrom = append(f.copiedFrom, src)
+	return fmt.Sprintf("versions/%s/copy-%d", knowledgeID, len(f.copiedFrom)), nil
+}
+
+func (f *versionTestFiles) DeleteFile(_ context.Context, path string) error {
+	f.deleted = append(f.deleted, path)
+	return nil
+}
+
+type versionTestTasks struct {
+	tasks []*asynq.Task
+}
+
+func (q *versionTestTasks) Enqueue(task *asynq.Task, _ ...asynq.Option) (*asynq.TaskInfo, error) {
+	q.tasks = append(q.tasks, task)
+	return &asynq.TaskInfo{ID: fmt.Sprintf("task-%d", len(q.tasks))}, nil
+}
+
+type versionTestChunkRepo struct {
+	interfaces.ChunkRepository
+}
+
+func (versionTestChunkRepo) ListImageInfoByKnowledgeIDs(
+	context.Context, uint64, []string,
+) ([]interfaces.ChunkImageInfo, error) {
+	return nil, nil
+}
+
+type versionTestChunks struct {
+	interfaces.ChunkService
+}
+
+func (versionTestChunks) GetRepository() interfaces.ChunkRepository {
+	return versionTestChunkRepo{}
+}
+
+func (versionTestChunks) DeleteChunksByKnowledgeID(context.Context, string) error {
+	return nil
+}
+
+type versionTestFixture struct {
+	ctx   context.Context
+	svc   *knowledgeService
+	kb    *types.KnowledgeBase
+	files *versionTestFiles
+	tasks *versionTestTasks
+}
+
+func newVersionTestFixture(t *testing.T) *versionTestFixture {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	require.NoError(t, db.AutoMigrate(&types.Knowledge{}, &types.KnowledgeVersion{}))
+
+	kb := &types.KnowledgeBase{ID: "kb-1", TenantID: 7}
+	f := &versionTestFixture{kb: kb, files: &versionTestFiles{}, tasks: &versionTestTasks{}}
+	f.svc = &knowledgeService{
+		repo:         repository.NewKnowledgeRepository(db),
+		versionRepo:  repository.NewKnowledgeVersionRepository(db),
+		kbService:    &reparseFailureKBService{kb: kb},
+		fileSvc:      f.files,
+		task:         f.tasks,
+		chunkService: versionTestChunks{},
+		graphEngine:  parentChildGraphRepo{},
+	}
+	ctx := context.WithValue(context.Background(), types.TenantIDContextKey, uint64(7))
+	ctx = context.WithValue(ctx, types.TenantInfoContextKey, &types.Tenant{ID: 7})
+	f.ctx = context.WithValue(ctx, types.UserIDContextKey, "editor-1")
+	return f
+}
+
+func (f *versionTestFixture) createKnowledge(t *testing.T, knowledge *types.Knowledge) *types.Knowledge {
+	t.Helper()
+	knowledge.TenantID = 7
+	knowledge.KnowledgeBaseID = f.kb.ID
+	knowledge.ParseStatus = types.ParseStatusCompleted
+	require.NoError(t, f.svc.repo.CreateKnowledge(f.ctx, knowledge))
+	return knowledge
+}
+
+func (f *versionTestFixture) versions(t *testing.T, knowledgeID string) []*types.KnowledgeVersion {
+	t.Helper()
+	versions, err := f.svc.versionRepo.List(f.ctx, 7, knowledgeID)
+	require.NoError(t, err)
+	return versions
+}
+
+// documentPayload decodes the only enqueued task as a document process task.
+func (f *versionTestFixture) documentPayload(t *testing.T) types.DocumentProcessPayload {
+	t.Helper()
+	require.Len(t, f.tasks.tasks, 1)
+	require.Equal(t, types.TypeDocumentProcess, f.tasks.tasks[0].Type())
+	var payload types.DocumentProcessPayload
+	require.NoError(t, json.Unmarshal(f.tasks.tasks[0].Payload(), &payload))
+	return payload
+}
+
+func TestRestoreKnowledgeVersionRewritesManualContent(t *testing.T) {
+	f := newVersionTestFixture(t)
+	knowledge := &types.Knowledge{ID: "manual-1", Type: types.KnowledgeTypeManual, Title: "Notes"}
+	require.NoError(t, knowledge.SetManualMetadata(types.NewManualKnowledgeMetadata("second draft", "publish", 2)))
+	f.createKnowledge(t, knowledge)
+	f.svc.recordKnowledgeVersion(f.ctx, f.kb, knowledge, "first draft", nil)
+	f.svc.recordKnowledgeVersion(f.ctx, f.kb, knowledge, "second draft", nil)
+
+	_, err := f.svc.RestoreKnowledgeVersion(f.ctx, knowledge.ID, 1)
+	require.NoError(t, err)
+
+	stored, err := f.svc.repo.GetKnowledgeByID(f.ctx, 7, knowledge.ID)
+	require.NoError(t, err)
+	meta, err := stored.ManualMetadata()
+	require.NoError(t, err)
+	require.Equal(t, "first draft", meta.Content)
+	require.Equal(t, 3, meta.Version)
+	require.Equal(t, types.ParseStatusPending, stored.ParseStatus)
+
+	require.Len(t, f.tasks.tasks, 1)
+	require.Equal(t, types.TypeManualProcess, f.tasks.tasks[0].Type())
+	var payload types.ManualProcessPayload
+	require.NoError(t, json.Unmarshal(f.tasks.tasks[0].Payload(), &payload))
+	require.Equal(t, "first draft", payload.Content)
+	require.Equal(t, 1, payload.RestoreVersion)
+	require.Equal(t, "editor-1", payload.VersionEditorID)
+}
+
+func TestRestoreKnowledgeVersionRejectsKnowledgeBeingProcessed(t *testing.T) {
+	f := newVersionTestFixture(t)
+	knowledge := &types.Knowledge{ID: "manual-1", Type: types.KnowledgeTypeManual}
+	require.NoError(t, knowledge.SetManualMetadata(types.NewManualKnowledgeMetadata("draft", "publish", 1)))
+	f.createKnowledge(t, knowledge)
+	f.svc.recordKnowledgeVersion(f.ctx, f.kb, knowledge, "draft", nil)
+	require.NoError(t, f.svc.repo.UpdateKnowledgeColumn(f.ctx, knowledge.ID, "parse_status", types.ParseStatusProcessing))
+
+	_, err := f.svc.RestoreKnowledgeVersion(f.ctx, knowledge.ID, 1)
+
+	var appErr *werrors.AppError
+	require.ErrorAs(t, err, &appErr)
+	require.Equal(t, werrors.ErrConflict, appErr.Code)
+	require.Empty(t, f.tasks.tasks)
+}
+
+func TestRestoreKnowledgeVersionReparsesVersionFile(t *testing.T) {
+	f := newVersionTestFixture(t)
+	knowledge := f.createKnowledge(t, &types.Knowledge{
+		ID: "file-1", Type: "file", FileName: "report-v1.pdf", FileType: "pdf",
+		FilePath: "uploads/file-1/report-v1.pdf", FileHash: "hash-1", FileSize: 10,
+	})
+	f.svc.recordKnowledgeVersion(f.ctx, f.kb, knowledge, "v1 text", nil)
+	knowledge.FileName, knowledge.FilePath, knowledge.FileHash, knowledge.FileSize =
+		"report-v2.pdf", "uploads/file-1/report-v2.pdf", "hash-2", 20
+	require.NoError(t, f.svc.repo.UpdateKnowledge(f.ctx, knowledge))
+	f.svc.recordKnowledgeVersion(f.ctx, f.kb, knowledge, "v2 text", nil)
+
+	_, err := f.svc.RestoreKnowledgeVersion(f.ctx, knowledge.ID, 1)
+	require.NoError(t, err)
+
+	// The knowledge gets its own copy of the version file, so pruning the
+	// version later cannot pull the file out from under it.
+	require.Equal(t, []string{
+		"uploads/file-1/report-v1.pdf", "uploads/file-1/report-v2.pdf", "versions/file-1/copy-1",
+	}, f.files.copiedFrom)
+	require.Equal(t, []string{"uploads/file-1/report-v2.pdf"}, f.files.deleted)
+	stored, err := f.svc.repo.GetKnowledgeByID(f.ctx, 7, knowledge.ID)
+	require.NoError(t, err)
+	require.Equal(t, "versions/file-1/copy-3", stored.FilePath)
+	require.Equal(t, "report-v1.pdf", stored.FileName)
+	require.Equal(t, "hash-1", stored.FileHash)
+	require.Equal(t, int64(10), stored.FileSize)
+
+	payload := f.documentPayload(t)
+	require.Equal(t, "versions/file-1/copy-3", payload.FilePath)
+	require.Equal(t, 1, payload.RestoreVersion)
+}
+
+func TestRestoreKnowledgeVersionWithoutFileIsRejected(t *testing.T) {
+	f := newVersionTestFixture(t)
+	knowledge := f.createKnowledge(t, &types.Knowledge{
+		ID: "file-1", Type: "file", FileName: "report.pdf", FilePath: "uploads/file-1/report.pdf",
+	})
+	f.svc.recordKnowledgeVersion(f.ctx, f.kb, knowledge, "v1 text", nil)
+	// A version whose copy failed has no file of its own.
+	require.NoError(t, f.svc.versionRepo.Create(f.ctx, &types.KnowledgeVersion{
+		TenantID: 7, KnowledgeID: knowledge.ID, Markdown: "v2 text", CreatedAt: time.Now(),
+	}))
+
+	_, err := f.svc.RestoreKnowledgeVersion(f.ctx, knowledge.ID, 2)
+
+	var appErr *werrors.AppError
+	require.ErrorAs(t, err, &appErr)
+	require.Equal(t, werrors.ErrBadRequest, appErr.Code)
+	require.Empty(t, f.tasks.tasks)
+	require.Empty(t, f.files.deleted)
+}
+
+func TestRestoreKnowledgeVersionReindexesURLFromMarkdown(t *testing.T) {
+	f := newVersionTestFixture(t)
+	knowledge := f.createKnowledge(t, &types.Knowledge{
+		ID: "url-1", Type: "url", Source: "https://example.com/page",
+	})
+	f.svc.recordKnowledgeVersion(f.ctx, f.kb, knowledge, "old page", nil)
+	f.svc.recordKnowledgeVersion(f.ctx, f.kb, knowledge, "new page", nil)
+
+	_, err := f.svc.RestoreKnowledgeVersion(f.ctx, knowledge.ID, 1)
+	require.NoError(t, err)
+
+	payload := f.documentPayload(t)
+	require.Equal(t, "https://example.com/page", payload.URL)
+	require.Equal(t, 1, payload.RestoreVersion)
+
+	// The worker parses the version's markdown instead of fetching the page.
+	content, err := f.svc.restoredVersionContent(f.ctx, knowledge, payload.RestoreVersion)
+	require.NoError(t, err)
+	require.Equal(t, "old page", content.MarkdownContent)
+	_, err = f.svc.restoredVersionContent(f.ctx, knowledge, 9)
+	require.Error(t, err)
+}
+
+func TestRecordKnowledgeVersionPrunesOnlyUnreferencedFiles(t *testing.T) {
+	f := newVersionTestFixture(t)
+	knowledge := f.createKnowledge(t, &types.Knowledge{
+		ID: "file-1", Type: "file", FilePath: "uploads/file-1/a.pdf", FileHash: "hash-a",
+	})
+	// Versions 1 and 2 share the copy of file a; the rest share file b's.
+	f.svc.recordKnowledgeVersion(f.ctx, f.kb, knowledge, "a, parse 1", nil)
+	f.svc.recordKnowledgeVersion(f.ctx, f.kb, knowledge, "a, parse 2", nil)
+	knowledge.FilePath, knowledge.FileHash = "uploads/file-1/b.pdf", "hash-b"
+	for i := 0; i < types.KnowledgeMaxVersions-2; i++ {
+		f.svc.recordKnowledgeVersion(f.ctx, f.kb, knowledge, fmt.Sprintf("b, parse %d", i), nil)
+	}
+	require.Equal(t, []string{"uploads/file-1/a.pdf", "uploads/file-1/b.pdf"}, f.files.copiedFrom)
+	require.Empty(t, f.files.deleted)
+
+	// Pruning version 1 must keep the copy version 2 still references.
+	f.svc.recordKnowledgeVersion(f.ctx, f.kb, knowledge, "b, one more", nil)
+	versions := f.versions(t, knowledge.ID)
+	require.Len(t, versions, types.KnowledgeMaxVersions)
+	require.Equal(t, 2, versions[len(versions)-1].Version)
+	require.Empty(t, f.files.deleted)
+
+	f.svc.recordKnowledgeVersion(f.ctx, f.kb, knowledge, "b, last", nil)
+	require.Len(t, f.versions(t, knowledge.ID), types.KnowledgeMaxVersions)
+	require.Equal(t, []string{"versions/file-1/copy-1"}, f.files.deleted)
+}
+
+func TestRecordKnowledgeVersionOnDataSourceResync(t *testing.T) {
+	f := newVersionTestFixture(t)
+	ctx := context.WithValue(f.ctx, types.UserIDContextKey, "")
+	synced := types.JSON(`{"datasource_id":"ds-1"}`)
+	replaced := f.createKnowledge(t, &types.Knowledge{
+		ID: "synced-1", Type: "file", FilePath: "uploads/synced-1/doc.md", FileHash: "hash-1", Metadata: synced,
+	})
+	f.svc.recordKnowledgeVersion(ctx, f.kb, replaced, "first sync", nil)
+	f.svc.recordKnowledgeVersion(ctx, f.kb, replaced, "first sync", nil)
+	require.Len(t, f.versions(t, replaced.ID), 1, "unchanged content must not record a version")
+
+	// A re-sync deletes the item, keeping its history, and re-creates it.
+	f.svc.purgeKnowledgeVersions(withKnowledgeVersionsRetained(ctx), 7, f.kb, []string{replaced.ID})
+	replacement := f.createKnowledge(t, &types.Knowledge{
+		ID: "synced-2", Type: "file", FilePath: "uploads/synced-2/doc.md", FileHash: "hash-1", Metadata: synced,
+	})
+	require.NoError(t, f.svc.TransferKnowledgeVersions(ctx, replaced.ID, replacement.ID))
+	f.svc.recordKnowledgeVersion(ctx, f.kb, replacement, "second sync", nil)
+
+	versions := f.versions(t, replacement.ID)
+	require.Len(t, versions, 2)
+	require.Equal(t, 2, versions[0].Version)
+	require.Equal(t, types.KnowledgeVersionSourceSync, versions[0].Source)
+	require.Empty(t, versions[0].EditorID)
+	// The file is unchanged, so the new version shares the first one's copy.
+	require.Equal(t, []string{"uploads/synced-1/doc.md"}, f.files.copiedFrom)
+	latest, err := f.svc.versionRepo.Get(ctx, 7, replacement.ID, 2)
+	require.NoError(t, err)
+	require.Equal(t, "versions/synced-1/copy-1", latest.FilePath)
+	require.Empty(t, f.files.deleted)
+}
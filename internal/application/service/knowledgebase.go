@@ -41,6 +41,12 @@ type knowledgeBaseService struct {
 	fileSvc         interfaces.FileService
 	storageResolver interfaces.StorageBackendResolver
 	graphEngine     interfaces.RetrieveGraphRepository
+	communityRepo   interfaces.GraphCommunityRepository
+	entityRepo      interfaces.GraphEntityRepository
+	versionRepo     interfaces.KnowledgeVersionRepository
+	fingerprintRepo interfaces.KnowledgeFingerprintRepository
+	freshnessRepo   interfaces.KnowledgeFreshnessRepository
+	aclService      interfaces.KnowledgeACLService
 	asynqClient     interfaces.TaskEnqueuer
 	taskInspector   interfaces.TaskInspector
 	taskPendingRepo interfaces.TaskPendingOpsRepository
@@ -63,6 +69,12 @@ func NewKnowledgeBaseService(repo interfaces.KnowledgeBaseRepository,
 	fileSvc interfaces.FileService,
 	storageResolver interfaces.StorageBackendResolver,
 	graphEngine interfaces.RetrieveGraphRepository,
+	communityRepo interfaces.GraphCommunityRepository,
+	entityRepo interfaces.GraphEntityRepository,
+	versionRepo interfaces.KnowledgeVersionRepository,
+	fingerprintRepo interfaces.KnowledgeFingerprintRepository,
+	freshnessRepo interfaces.KnowledgeFreshnessRepository,
+	aclService interfaces.KnowledgeACLService,
 	asynqClient interfaces.TaskEnqueuer,
 	taskInspector interfaces.TaskInspector,
 	taskPendingRepo interfaces.TaskPendingOpsRepository,
@@ -84,6 +96,12 @@ func NewKnowledgeBaseService(repo interfaces.KnowledgeBaseRepository,
 		fileSvc:         fileSvc,
 		storageResolver: storageResolver,
 		graphEngine:     graphEngine,
+		communityRepo:   communityRepo,
+		entityRepo:      entityRepo,
+		versionRepo:     versionRepo,
+		fingerprintRepo: fingerprintRepo,
+		freshnessRepo:   freshnessRepo,
+		aclService:      aclService,
 		asynqClient:     asynqClient,
 		taskInspector:   taskInspector,
 		taskPendingRepo: taskPendingRepo,
@@ -132,6 +150,9 @@ func (s *knowledgeBaseService) CreateKnowledgeBase(ctx context.Context,
 		kb.CreatorID = uid
 	}
 	kb.EnsureDefaults()
+	if err := validateFreshnessConfig(kb.FreshnessConfig); err != nil {
+		return nil, err
+	}
 	applyTenantDefaultStorageProvider(ctx, kb)
 	if err := s.applyAndValidateStorageBackend(ctx, kb); err != nil {
 		return nil, err
@@ -533,6 +554,17 @@ func (s *knowledgeBaseService) UpdateKnowledgeBase(ctx context.Context,
 			config.AutoTagConfig.Normalize()
 			kb.AutoTagConfig = config.AutoTagConfig
 		}
+		if config.DedupConfig != nil {
+			config.DedupConfig.Normalize()
+			kb.DedupConfig = config.DedupConfig
+		}
+		if config.FreshnessConfig != nil {
+			config.FreshnessConfig.Normalize()
+			if err := validateFreshnessConfig(config.FreshnessConfig); err != nil {
+				return nil, err
+			}
+			kb.FreshnessConfig = config.FreshnessConfig
+		}
 		// Update indexing strategy — syncs to ExtractConfig for backward compat
 		if config.IndexingStrategy != nil {
 			if !config.IndexingStrategy.HasAnyIndexing() {
@@ -933,6 +965,22 @@ func (s *knowledgeBaseService) ProcessKBDelete(ctx context.Context, t *asynq.Tas
 			storageAdjust -= knowledge.StorageSize
 		}
 		deleteExtractedImages(ctx, s.fileSvc, imageURLs)
+		if s.versionRepo != nil {
+			versionPaths, err := s.versionRepo.DeleteByKnowledgeIDs(ctx, tenantID, knowledgeIDs)
+			if err != nil {
+				logger.Warnf(ctx, "Failed to delete knowledge versions: %v", err)
+			}
+			for _, path := range versionPaths {
+				if err := s.fileSvc.DeleteFile(ctx, path); err != nil {
+					logger.Warnf(ctx, "Failed to delete version file %s: %v", path, err)
+				}
+			}
+		}
+		if s.fingerprintRepo != nil {
+			if err := s.fingerprintRepo.DeleteByKnowledgeIDs(ctx, tenantID, knowledgeIDs); err != nil {
+				logger.Warnf(ctx, "Failed to delete knowledge fingerprints: %v", err)
+			}
+		}
 		if storageAdjust != 0 {
 			if err := s.tenantRepo.AdjustStorageUsed(ctx, tenantID, storageAdjust); err != nil {
 				logger.Warnf(ctx, "Failed to adjust tenant storage: %v", err)
@@ -962,6 +1010,23 @@ func (s *knowledgeBaseService) ProcessKBDelete(ctx context.Context, t *asynq.Tas
 			})
 			return err
 		}
+		if s.aclService != nil {
+			if err := s.aclService.DeleteKnowledgeACL(ctx, tenantID, knowledgeIDs); err != nil {
+				logger.Warnf(ctx, "Failed to delete knowledge ACL entries: %v", err)
+			}
+		}
+	}
+
+	// Community reports and entity resolution state describe the graph just deleted
+	if s.communityRepo != nil {
+		if err := s.communityRepo.DeleteByKnowledgeBase(ctx, tenantID, kbID); err != nil {
+			logger.Warnf(ctx, "Failed to delete graph communities: %v", err)
+		}
+	}
+	if s.entityRepo != nil {
+		if err := s.entityRepo.DeleteByKnowledgeBase(ctx, tenantID, kbID); err != nil {
+			logger.Warnf(ctx, "Failed to delete graph entity resolution data: %v", err)
+		}
 	}
 
 	logger.Infof(ctx, "KB delete task completed successfully, knowledge base ID: %s", kbID)
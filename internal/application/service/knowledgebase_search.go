@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"time"
 
 	"github.com/Tencent/WeKnora/internal/application/service/retriever"
 	apperrors "github.com/Tencent/WeKnora/internal/errors"
@@ -93,6 +94,10 @@ func (s *knowledgeBaseService) HybridSearch(ctx context.Context,
 	if len(searchKBIDs) == 0 {
 		searchKBIDs = []string{id}
 	}
+	if len(searchKBIDs) == 1 {
+		// Query embeddings of a single-KB search are billed to that KB.
+		ctx = types.WithUsageKnowledgeBase(ctx, searchKBIDs[0])
+	}
 
 	// QueryText is user-controlled; sanitize before logging to prevent
 	// CR/LF/tab log injection. Matches the handler-layer sanitization at
@@ -130,6 +135,20 @@ func (s *knowledgeBaseService) HybridSearch(ctx context.Context,
 		return nil, err
 	}
 
+	// Document-level ACLs: restricted knowledge the caller may not read is
+	// excluded inside every engine, so it never takes a TopK slot.
+	if s.aclService != nil {
+		denied, aclErr := s.aclService.DeniedKnowledgeIDs(ctx, kbs)
+		if aclErr != nil {
+			logger.ErrorWithFields(ctx, aclErr, map[string]interface{}{
+				"knowledge_base_ids": searchKBIDs,
+				"reason":             "knowledge ACL lookup failed",
+			})
+			return nil, apperrors.NewInternalServerError("failed to verify knowledge access")
+		}
+		params.ExcludeKnowledgeIDs = mergeUniqueStrings(params.ExcludeKnowledgeIDs, denied)
+	}
+
 	// Explicit embedding-model consistency check. Multi-KB searches that
 	// span different embedding spaces would otherwise silently produce
 	// meaningless cross-model scores. Same-model wiki/graph KBs are
@@ -248,11 +267,24 @@ func (s *knowledgeBaseService) HybridSearch(ctx context.Context,
 		return nil, err
 	}
 
+	// Engines already filter excluded knowledge; this guards engines or FAQ
+	// expansions that bypass the filter.
+	deduplicatedChunks = dropExcludedKnowledge(deduplicatedChunks, params.ExcludeKnowledgeIDs)
+
+	// Expired knowledge is dropped or down-weighted before truncation so that
+	// current documents fill the freed slots.
+	deduplicatedChunks = s.applyFreshnessPolicy(ctx, kbs, deduplicatedChunks)
+
 	if len(deduplicatedChunks) > params.MatchCount {
 		deduplicatedChunks = deduplicatedChunks[:params.MatchCount]
 	}
 
-	return s.processSearchResults(ctx, deduplicatedChunks, params.SkipContextEnrichment)
+	results, err := s.processSearchResults(ctx, deduplicatedChunks, params.SkipContextEnrichment)
+	if err != nil {
+		return nil, err
+	}
+	markSearchResultFreshness(results, freshnessConfigs(kbs), time.Now())
+	return results, nil
 }
 
 // pickPrimary returns the KB whose ID matches id, or nil if id is not in
@@ -361,15 +393,16 @@ func (s *knowledgeBaseService) buildRetrievalParams(
 
 		appendVectorParams := func(kbIDs []string, knowledgeType string) {
 			retrieveParams = append(retrieveParams, types.RetrieveParams{
-				Query:            params.QueryText,
-				Embedding:        queryEmbedding,
-				KnowledgeBaseIDs: kbIDs,
-				TopK:             matchCount,
-				Threshold:        params.VectorThreshold,
-				RetrieverType:    types.VectorRetrieverType,
-				KnowledgeIDs:     params.KnowledgeIDs,
-				TagIDs:           params.TagIDs,
-				KnowledgeType:    knowledgeType,
+				Query:               params.QueryText,
+				Embedding:           queryEmbedding,
+				KnowledgeBaseIDs:    kbIDs,
+				TopK:                matchCount,
+				Threshold:           params.VectorThreshold,
+				RetrieverType:       types.VectorRetrieverType,
+				KnowledgeIDs:        params.KnowledgeIDs,
+				TagIDs:              params.TagIDs,
+				KnowledgeType:       knowledgeType,
+				ExcludeKnowledgeIDs: params.ExcludeKnowledgeIDs,
 			})
 		}
 
@@ -391,13 +424,14 @@ func (s *knowledgeBaseService) buildRetrievalParams(
 		len(docKeywordKBIDs) > 0 {
 		logger.Info(ctx, "Keyword retrieval supported, preparing keyword retrieval parameters")
 		retrieveParams = append(retrieveParams, types.RetrieveParams{
-			Query:            params.QueryText,
-			KnowledgeBaseIDs: docKeywordKBIDs,
-			TopK:             matchCount,
-			Threshold:        params.KeywordThreshold,
-			RetrieverType:    types.KeywordsRetrieverType,
-			KnowledgeIDs:     params.KnowledgeIDs,
-			TagIDs:           params.TagIDs,
+			Query:               params.QueryText,
+			KnowledgeBaseIDs:    docKeywordKBIDs,
+			TopK:                matchCount,
+			Threshold:           params.KeywordThreshold,
+			RetrieverType:       types.KeywordsRetrieverType,
+			KnowledgeIDs:        params.KnowledgeIDs,
+			TagIDs:              params.TagIDs,
+			ExcludeKnowledgeIDs: params.ExcludeKnowledgeIDs,
 		})
 		logger.Info(ctx, "Keyword retrieval parameters setup completed")
 	}
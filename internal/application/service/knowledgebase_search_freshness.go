@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// applyFreshnessPolicy drops or down-weights results whose knowledge is
+// outside its validity window, following the FreshnessConfig of the
+// knowledge base each result comes from. Knowledge without dates is never
+// affected. A failed lookup leaves the results unchanged: stale content is
+// better than no answer.
+func (s *knowledgeBaseService) applyFreshnessPolicy(
+	ctx context.Context, kbs []*types.KnowledgeBase, results []*types.IndexWithScore,
+) []*types.IndexWithScore {
+	if s.freshnessRepo == nil || len(results) == 0 {
+		return results
+	}
+	seen := make(map[string]bool, len(results))
+	ids := make([]string, 0, len(results))
+	for _, r := range results {
+		if r.KnowledgeID != "" && !seen[r.KnowledgeID] {
+			seen[r.KnowledgeID] = true
+			ids = append(ids, r.KnowledgeID)
+		}
+	}
+	outOfValidity, err := s.freshnessRepo.ListOutOfValidity(ctx, ids, time.Now())
+	if err != nil {
+		logger.Warnf(ctx, "freshness lookup failed, keeping all results: %v", err)
+		return results
+	}
+	if len(outOfValidity) == 0 {
+		return results
+	}
+	stale := make(map[string]bool, len(outOfValidity))
+	for _, id := range outOfValidity {
+		stale[id] = true
+	}
+	return applyExpiredPolicy(results, stale, freshnessConfigs(kbs))
+}
+
+// applyExpiredPolicy excludes or down-weights the results whose knowledge is
+// in stale and re-sorts by score when any score changed.
+func applyExpiredPolicy(
+	results []*types.IndexWithScore, stale map[string]bool, configs map[string]*types.FreshnessConfig,
+) []*types.IndexWithScore {
+	out := make([]*types.IndexWithScore, 0, len(results))
+	reweighted := false
+	for _, r := range results {
+		if !stale[r.KnowledgeID] {
+			out = append(out, r)
+			continue
+		}
+		cfg := configs[r.KnowledgeBaseID]
+		if cfg.EffectivePolicy() == types.ExpiredPolicyExclude {
+			continue
+		}
+		r.Score *= cfg.EffectiveDownweightFactor()
+		reweighted = true
+		out = append(out, r)
+	}
+	if reweighted {
+		sort.SliceStable(out, func(i, j int) bool { return out[i].Score > out[j].Score })
+	}
+	return out
+}
+
+// markSearchResultFreshness sets Freshness on results from expired, not yet
+// valid or soon-to-expire knowledge, using the expiring-soon window of the
+// result's knowledge base.
+func markSearchResultFreshness(
+	results []*types.SearchResult, configs map[string]*types.FreshnessConfig, now time.Time,
+) {
+	for _, r := range results {
+		if r == nil || (r.KnowledgeValidFrom == nil && r.KnowledgeValidUntil == nil) {
+			continue
+		}
+		window := configs[r.KnowledgeBaseID].ExpiringSoonWindow()
+		r.Freshness = types.FreshnessState(r.KnowledgeValidFrom, r.KnowledgeValidUntil, now, window)
+	}
+}
+
+// freshnessConfigs indexes the freshness configuration of each knowledge
+// base by ID; knowledge bases without one map to nil, which means defaults.
+func freshnessConfigs(kbs []*types.KnowledgeBase) map[string]*types.FreshnessConfig {
+	configs := make(map[string]*types.FreshnessConfig, len(kbs))
+	for _, kb := range kbs {
+		if kb != nil {
+			configs[kb.ID] = kb.FreshnessConfig
+		}
+	}
+	return configs
+}
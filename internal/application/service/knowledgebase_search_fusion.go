@@ -140,3 +140,21 @@ func fuseWithRRF(ctx context.Context, vectorResults, keywordResults []*types.Ind
 
 	return result
 }
+
+// dropExcludedKnowledge removes chunks of excluded knowledge items.
+func dropExcludedKnowledge(chunks []*types.IndexWithScore, excluded []string) []*types.IndexWithScore {
+	if len(excluded) == 0 || len(chunks) == 0 {
+		return chunks
+	}
+	skip := make(map[string]bool, len(excluded))
+	for _, id := range excluded {
+		skip[id] = true
+	}
+	out := chunks[:0]
+	for _, chunk := range chunks {
+		if !skip[chunk.KnowledgeID] {
+			out = append(out, chunk)
+		}
+	}
+	return out
+}
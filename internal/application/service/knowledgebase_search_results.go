@@ -330,6 +330,8 @@ func (s *knowledgeBaseService) buildSearchResult(chunk *types.Chunk,
 		ChunkMetadata:           chunk.Metadata,
 		MatchedContent:          matchedContent,
 		KnowledgeBaseID:         knowledge.KnowledgeBaseID,
+		KnowledgeValidFrom:      knowledge.ValidFrom,
+		KnowledgeValidUntil:     knowledge.ValidUntil,
 	}
 }
 
@@ -35,7 +35,10 @@ func ResolveScope(ctx context.Context) (interfaces.MemoryScope, error) {
 		return interfaces.MemoryScope{}, ErrNoMemoryScope
 	}
 	subjectID := principal.StorageID()
-	if subjectID == "" {
+	// Shared spaces are addressed only through the admin curation API. No
+	// principal type produces this prefix today; refusing it here keeps a
+	// future one from landing a person inside the team's space.
+	if subjectID == "" || types.IsSharedMemorySubject(subjectID) {
 		return interfaces.MemoryScope{}, ErrNoMemoryScope
 	}
 	return interfaces.MemoryScope{TenantID: tenantID, SubjectID: subjectID}, nil
@@ -106,10 +106,18 @@ func (s *Service) SearchMemory(
 	// search would look permanently unused and rank lowest when the capacity
 	// cap next decides what to archive.
 	s.touchAsync(searchCtx, scope, matched)
+	types.MarkMemoryProvenance(matched)
+
+	// The team's notes fill whatever the person's own matches left of the
+	// limit. Personal memory goes first because it is what the tool is for;
+	// shared notes are reachable here mainly so an agent can look past the
+	// three that recall admits per turn.
+	shared := s.searchShared(searchCtx, scope.TenantID, query, limit-len(matched))
+	matched = append(matched, shared...)
 
 	logger.Infof(searchCtx,
-		"memory: search done subject=%s candidates=%d matched=%d mode=%s",
-		scope.SubjectID, len(candidates), len(matched), rankTrace.Mode)
+		"memory: search done subject=%s candidates=%d matched=%d shared=%d mode=%s",
+		scope.SubjectID, len(candidates), len(matched), len(shared), rankTrace.Mode)
 	searchSpan.Finish(langfuse.SummarizeMemoryRecallOutput(map[string]interface{}{
 		"outcome":         "ok",
 		"subject_id":      scope.SubjectID,
@@ -119,6 +127,7 @@ func (s *Service) SearchMemory(
 		"vector_skip":     rankTrace.VectorSkipReason,
 		"ranking_mode":    rankTrace.Mode,
 		"matched_count":   len(matched),
+		"shared_count":    len(shared),
 	}, matched), map[string]interface{}{
 		"tenant_id": scope.TenantID,
 	}, nil)
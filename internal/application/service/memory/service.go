@@ -130,22 +130,23 @@ func (s *Service) Recall(ctx context.Context, query string) interfaces.MemoryRec
 	}
 
 	subject, err := s.repo.GetSubject(recallCtx, scope)
-	if err != nil || subject == nil {
-		reason := "no_subject"
-		if err != nil {
-			reason = "subject_load_failed"
-			logger.Warnf(recallCtx, "memory: load subject for recall failed: %v", err)
-		}
-		logger.Infof(recallCtx, "memory: recall skipped (%s)", reason)
+	if err != nil {
+		logger.Warnf(recallCtx, "memory: load subject for recall failed: %v", err)
+		logger.Infof(recallCtx, "memory: recall skipped (subject_load_failed)")
 		recallSpan.Finish(langfuse.SummarizeMemoryRecallOutput(map[string]interface{}{
 			"outcome":    "empty",
-			"reason":     reason,
+			"reason":     "subject_load_failed",
 			"subject_id": scope.SubjectID,
 		}, nil), map[string]interface{}{
 			"tenant_id": scope.TenantID,
 		}, nil)
 		return interfaces.MemoryRecall{}
 	}
+	if subject == nil {
+		// Someone with nothing stored yet still reads the team's notes, so
+		// the personal half simply comes out empty below.
+		subject = &types.MemorySubject{}
+	}
 
 	residentItems, err := s.repo.ListActiveResident(recallCtx, scope, 60)
 	if err != nil {
@@ -193,7 +194,24 @@ func (s *Service) Recall(ctx context.Context, query string) interfaces.MemoryRec
 	matched, rankTrace := s.selectRecallWithTrace(recallCtx, scope, cfg, query, candidates,
 		types.MemoryRecallMaxItems, types.MemoryRecallRuneBudget)
 
-	prompt := types.WrapMemoryForPrompt(block, types.RenderMemoryRecall(matched))
+	// What was injected and what is reported are deliberately not the same set.
+	// An interest that rode along because the cap left room is standing
+	// background, not something this question pulled in, and reporting it would
+	// put a memory unrelated to the answer on the chat timeline every turn.
+	//
+	// The block is also rendered from a truncated list, so report the items
+	// that actually fit rather than everything that was loaded.
+	used := residentItemsWithinBlock(standing, block)
+	used = append(used, residentItemsWithinBlock(relevantInterests, block)...)
+	used = append(used, matched...)
+	types.MarkMemoryProvenance(used)
+
+	// The team's notes ride in their own envelope after the person's own, and
+	// are deduplicated against everything the personal half loaded.
+	sharedPrompt, sharedUsed := s.recallShared(recallCtx, scope.TenantID, query,
+		append(append([]*types.MemoryItem(nil), residentItems...), matched...))
+
+	prompt := types.WrapMemoryForPrompt(block, types.RenderMemoryRecall(matched)) + sharedPrompt
 	if prompt == "" {
 		emptyMeta := s.recallEmptyMeta(scope, len(residentItems), len(candidates), rankTrace)
 		emptyMeta["block_runes"] = len([]rune(block))
@@ -206,17 +224,8 @@ func (s *Service) Recall(ctx context.Context, query string) interfaces.MemoryRec
 		return interfaces.MemoryRecall{}
 	}
 
-	// What was injected and what is reported are deliberately not the same set.
-	// An interest that rode along because the cap left room is standing
-	// background, not something this question pulled in, and reporting it would
-	// put a memory unrelated to the answer on the chat timeline every turn.
-	//
-	// The block is also rendered from a truncated list, so report the items
-	// that actually fit rather than everything that was loaded.
-	used := residentItemsWithinBlock(standing, block)
-	used = append(used, residentItemsWithinBlock(relevantInterests, block)...)
-	used = append(used, matched...)
 	s.touchAsync(recallCtx, scope, used)
+	used = append(used, sharedUsed...)
 
 	logger.Infof(recallCtx,
 		"memory: recall done subject=%s used=%d matched=%d interest_injected=%d interest_relevant=%d mode=%s prompt_runes=%d",
@@ -237,6 +246,7 @@ func (s *Service) Recall(ctx context.Context, query string) interfaces.MemoryRec
 		"interest_total":    len(interests),
 		"interest_injected": len(selectedInterests),
 		"interest_relevant": len(relevantInterests),
+		"shared_count":      len(sharedUsed),
 		"used_count":        len(used),
 		"prompt_runes":      len([]rune(prompt)),
 	}, used), map[string]interface{}{
@@ -466,7 +476,14 @@ func statusForWrite(item types.MemoryItem) string {
 
 // enforceCapacity archives the lowest ranked items once the subject exceeds
 // its cap. This is the only automatic forgetting in the system.
+//
+// Shared spaces are exempt. Every note in them was written or approved by an
+// admin who can see the whole list and expire items explicitly, and quietly
+// archiving one of those decisions would be a surprise nobody asked for.
 func (s *Service) enforceCapacity(ctx context.Context, scope interfaces.MemoryScope, cfg *types.MemoryConfig) {
+	if types.IsSharedMemorySubject(scope.SubjectID) {
+		return
+	}
 	maxItems := cfg.EffectiveMaxItems()
 	count, err := s.repo.CountActive(ctx, scope)
 	if err != nil {
@@ -0,0 +1,406 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"github.com/google/uuid"
+)
+
+// ErrInvalidSharedSpace means the request named neither the workspace space
+// nor an agent space with a usable agent id.
+var ErrInvalidSharedSpace = errors.New("memory: invalid shared memory space")
+
+// ErrNotShareable means the memory cannot be proposed for a shared space:
+// it is not in use, or it is an interest, which only means something for the
+// one person whose questions produced it.
+var ErrNotShareable = errors.New("memory: only active memories can be shared")
+
+// ErrNotPendingReview means an approve or reject targeted a shared item that
+// is not waiting for a decision.
+var ErrNotPendingReview = errors.New("memory: shared item is not awaiting review")
+
+// sharedScope resolves a shared space inside the caller's workspace. Like
+// ResolveScope the workspace always comes from the context; the request only
+// picks which of the workspace's shared spaces it means.
+func sharedScope(ctx context.Context, provenance, agentID string) (interfaces.MemoryScope, error) {
+	tenantID, ok := types.TenantIDFromContext(ctx)
+	if !ok || tenantID == 0 {
+		return interfaces.MemoryScope{}, ErrNoMemoryScope
+	}
+	subjectID := types.SharedMemorySubjectID(provenance, agentID)
+	if subjectID == "" {
+		return interfaces.MemoryScope{}, ErrInvalidSharedSpace
+	}
+	return interfaces.MemoryScope{TenantID: tenantID, SubjectID: subjectID}, nil
+}
+
+// sharedScopesFor returns the shared spaces one request reads: the answering
+// agent's own space first, because it is the more specific of the two, then
+// the workspace space.
+func sharedScopesFor(ctx context.Context, tenantID uint64) []interfaces.MemoryScope {
+	scopes := make([]interfaces.MemoryScope, 0, 2)
+	if agentID := types.MemoryAgentFromContext(ctx); agentID != "" {
+		if subjectID := types.SharedMemorySubjectID(types.MemoryProvenanceAgent, agentID); subjectID != "" {
+			scopes = append(scopes, interfaces.MemoryScope{TenantID: tenantID, SubjectID: subjectID})
+		}
+	}
+	return append(scopes, interfaces.MemoryScope{
+		TenantID:  tenantID,
+		SubjectID: types.SharedMemorySubjectID(types.MemoryProvenanceWorkspace, ""),
+	})
+}
+
+// recallShared selects the team's notes for one turn and returns them wrapped
+// in their own envelope, plus the items that made it in.
+//
+// Shared notes are ranked lexically only. Semantic ranking would cost a second
+// query embedding on every turn, and these notes are written by an admin on
+// purpose rather than distilled from chat, so their wording is deliberate
+// enough for token matching to find them.
+//
+// A note the person already holds in their own memory — usually the one they
+// promoted — is left out so the prompt does not carry it twice.
+func (s *Service) recallShared(
+	ctx context.Context, tenantID uint64, query string, personal []*types.MemoryItem,
+) (string, []*types.MemoryItem) {
+	known := make(map[string]struct{}, len(personal))
+	for _, item := range personal {
+		if item != nil {
+			known[types.MemoryFingerprint(item.Content)] = struct{}{}
+		}
+	}
+	fresh := func(items []*types.MemoryItem) []*types.MemoryItem {
+		out := items[:0:0]
+		for _, item := range items {
+			if item == nil {
+				continue
+			}
+			fingerprint := types.MemoryFingerprint(item.Content)
+			if _, dup := known[fingerprint]; dup {
+				continue
+			}
+			known[fingerprint] = struct{}{}
+			out = append(out, item)
+		}
+		return out
+	}
+
+	var resident, candidates []*types.MemoryItem
+	for _, scope := range sharedScopesFor(ctx, tenantID) {
+		items, err := s.repo.ListActiveResident(ctx, scope, 30)
+		if err != nil {
+			logger.Warnf(ctx, "memory: load shared resident items failed: %v", err)
+			continue
+		}
+		resident = append(resident, fresh(items)...)
+		situational, err := s.repo.ListActiveByKinds(ctx, scope,
+			[]string{types.MemoryKindFact, types.MemoryKindTask}, searchCandidatePool)
+		if err != nil {
+			logger.Warnf(ctx, "memory: load shared situational items failed: %v", err)
+			continue
+		}
+		candidates = append(candidates, fresh(situational)...)
+	}
+	if len(resident) == 0 && len(candidates) == 0 {
+		return "", nil
+	}
+
+	block := types.RenderSharedMemoryBlock(resident, types.MemorySharedBlockRuneBudget)
+	used := residentItemsWithinBlock(resident, block)
+	matched := selectRecallItems(query, candidates,
+		types.MemorySharedRecallMaxItems, types.MemorySharedRecallRuneBudget)
+	used = append(used, matched...)
+
+	body := block
+	if recall := types.RenderSharedMemoryBlock(matched, types.MemorySharedRecallRuneBudget); recall != "" {
+		if body != "" {
+			body += "\n"
+		}
+		body += recall
+	}
+	types.MarkMemoryProvenance(used)
+	s.touchShared(ctx, tenantID, used)
+	return types.WrapSharedMemoryForPrompt(body), used
+}
+
+// searchShared ranks the team's notes for the search_memory tool.
+func (s *Service) searchShared(
+	ctx context.Context, tenantID uint64, query string, limit int,
+) []*types.MemoryItem {
+	if limit <= 0 {
+		return nil
+	}
+	var candidates []*types.MemoryItem
+	for _, scope := range sharedScopesFor(ctx, tenantID) {
+		items, err := s.repo.ListActiveByKinds(ctx, scope, types.SharedMemoryKinds, searchCandidatePool)
+		if err != nil {
+			logger.Warnf(ctx, "memory: load shared search candidates failed: %v", err)
+			continue
+		}
+		candidates = append(candidates, items...)
+	}
+	matched := selectRecallItems(query, candidates, limit, types.MemorySearchRuneBudget)
+	types.MarkMemoryProvenance(matched)
+	s.touchShared(ctx, tenantID, matched)
+	return matched
+}
+
+// touchShared records usage per shared space, since TouchUsed is scoped.
+func (s *Service) touchShared(ctx context.Context, tenantID uint64, items []*types.MemoryItem) {
+	bySubject := make(map[string][]*types.MemoryItem)
+	for _, item := range items {
+		bySubject[item.SubjectID] = append(bySubject[item.SubjectID], item)
+	}
+	for subjectID, group := range bySubject {
+		s.touchAsync(ctx, interfaces.MemoryScope{TenantID: tenantID, SubjectID: subjectID}, group)
+	}
+}
+
+// ShareItem proposes one of the caller's memories for a shared space.
+//
+// The proposal is a copy, not a move: the person keeps their own memory, and
+// the team's copy waits as pending until an admin approves it. Nothing a
+// member writes reaches other people's prompts without that decision. The
+// source conversation is not carried over, since it belongs to the person
+// and not to the team.
+func (s *Service) ShareItem(
+	ctx context.Context, id, provenance, agentID string,
+) (*types.MemoryItem, error) {
+	scope, _, ok := s.enabledScope(ctx)
+	if !ok {
+		return nil, ErrMemoryDisabled
+	}
+	target, err := sharedScope(ctx, provenance, agentID)
+	if err != nil {
+		return nil, err
+	}
+	source, err := s.repo.GetItem(ctx, scope, id)
+	if err != nil {
+		return nil, err
+	}
+	if source == nil {
+		return nil, ErrItemNotFound
+	}
+	if source.Status != types.MemoryStatusActive || !types.IsValidSharedMemoryKind(source.Kind) {
+		return nil, ErrNotShareable
+	}
+	if _, err := s.repo.EnsureSubject(ctx, target); err != nil {
+		return nil, err
+	}
+	// Already there, in use or waiting: a second copy would only give the
+	// admin the same decision twice.
+	duplicate, _, err := s.findContainedDuplicate(ctx, target, source.Kind, source.Content)
+	if err != nil {
+		return nil, err
+	}
+	if duplicate != nil {
+		return types.MarkMemoryProvenance([]*types.MemoryItem{duplicate})[0], nil
+	}
+
+	proposal := &types.MemoryItem{
+		ID:            uuid.New().String(),
+		TenantID:      target.TenantID,
+		SubjectID:     target.SubjectID,
+		Kind:          source.Kind,
+		Content:       source.Content,
+		Topic:         source.Topic,
+		NormalizedKey: source.NormalizedKey,
+		Importance:    source.Importance,
+		Origin:        types.MemoryOriginPromoted,
+		Status:        types.MemoryStatusPending,
+		ValidFrom:     time.Now(),
+		ExpiresAt:     source.ExpiresAt,
+		PromotedBy:    scope.SubjectID,
+		PromotedFrom:  source.ID,
+	}
+	if err := s.repo.CreateItem(ctx, proposal); err != nil {
+		return nil, err
+	}
+	logger.Infof(ctx, "memory: %s proposed item %s for %s", scope.SubjectID, source.ID, target.SubjectID)
+	return types.MarkMemoryProvenance([]*types.MemoryItem{proposal})[0], nil
+}
+
+// ListSharedItems backs the admin curation list. A blank provenance lists
+// every shared space of the workspace.
+func (s *Service) ListSharedItems(
+	ctx context.Context, provenance, agentID, status string, limit, offset int,
+) ([]*types.MemoryItem, int64, error) {
+	tenantID, ok := types.TenantIDFromContext(ctx)
+	if !ok || tenantID == 0 {
+		return nil, 0, ErrNoMemoryScope
+	}
+	subjectID := ""
+	if provenance != "" {
+		scope, err := sharedScope(ctx, provenance, agentID)
+		if err != nil {
+			return nil, 0, err
+		}
+		subjectID = scope.SubjectID
+	}
+	items, total, err := s.repo.ListSharedItems(ctx, tenantID, subjectID, status, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	return types.MarkMemoryProvenance(items), total, nil
+}
+
+// CreateSharedItem adds a note an admin wrote directly. It goes through the
+// same write path as personal memory, so it supersedes an older note on the
+// same topic instead of contradicting it, and it takes effect at once: the
+// admin writing it is the review.
+func (s *Service) CreateSharedItem(
+	ctx context.Context, provenance, agentID, kind, content string, importance int, expiresAt *time.Time,
+) (*types.MemoryItem, error) {
+	target, err := sharedScope(ctx, provenance, agentID)
+	if err != nil {
+		return nil, err
+	}
+	cfg := s.workspaceConfig(ctx, target.TenantID)
+	if !cfg.MemoryEnabled() {
+		return nil, ErrMemoryDisabled
+	}
+	if !types.IsValidSharedMemoryKind(kind) {
+		kind = types.MemoryKindFact
+	}
+	if importance <= 0 {
+		importance = 3
+	}
+	stored, err := s.write(ctx, target, cfg, types.MemoryItem{
+		Kind:       kind,
+		Content:    content,
+		Importance: importance,
+		Origin:     types.MemoryOriginManual,
+		ExpiresAt:  expiresAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+	// write returns an existing item when the note is already there. If that
+	// was a member's pending proposal, the admin writing the same thing is as
+	// good as approving it.
+	if err := s.repo.MarkItemReviewed(
+		ctx, target, stored.ID, types.MemoryStatusActive, reviewerID(ctx),
+	); err != nil {
+		return nil, err
+	}
+	s.rebuildBlock(ctx, target)
+	return s.reloadShared(ctx, target, stored.ID)
+}
+
+// UpdateSharedItem edits a shared note's content, importance and expiry. A
+// nil expiresAt clears the expiry; a time in the past expires the note now.
+func (s *Service) UpdateSharedItem(
+	ctx context.Context, id, content string, importance int, expiresAt *time.Time,
+) (*types.MemoryItem, error) {
+	existing, scope, err := s.sharedItem(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	sanitized := types.SanitizeMemoryContent(content)
+	if sanitized == "" {
+		return nil, errors.New("memory: empty content")
+	}
+	normalizedKey := types.MemoryItemKey(existing.Topic, sanitized)
+	importance = types.ClampMemoryImportance(importance)
+	if err := s.repo.UpdateItemContent(ctx, scope, id, sanitized, normalizedKey, importance); err != nil {
+		return nil, err
+	}
+	if err := s.repo.SetItemExpiry(ctx, scope, id, expiresAt); err != nil {
+		return nil, err
+	}
+	s.rebuildBlock(ctx, scope)
+	return s.reloadShared(ctx, scope, id)
+}
+
+// ApproveSharedItem puts a member's proposal into use for everyone reading
+// that space.
+func (s *Service) ApproveSharedItem(ctx context.Context, id string) (*types.MemoryItem, error) {
+	existing, scope, err := s.sharedItem(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if existing.Status != types.MemoryStatusPending {
+		return nil, ErrNotPendingReview
+	}
+	if err := s.repo.MarkItemReviewed(ctx, scope, id, types.MemoryStatusActive, reviewerID(ctx)); err != nil {
+		return nil, err
+	}
+	s.rebuildBlock(ctx, scope)
+	return s.reloadShared(ctx, scope, id)
+}
+
+// RejectSharedItem declines a proposal. Unlike a personal rejection it leaves
+// no tombstone: the member's own memory is untouched, and an admin should be
+// able to write the same note later without the store refusing it.
+func (s *Service) RejectSharedItem(ctx context.Context, id string) error {
+	existing, scope, err := s.sharedItem(ctx, id)
+	if err != nil {
+		return err
+	}
+	if existing.Status != types.MemoryStatusPending {
+		return ErrNotPendingReview
+	}
+	return s.repo.DeleteItem(ctx, scope, id)
+}
+
+// DeleteSharedItem removes a shared note for everyone.
+func (s *Service) DeleteSharedItem(ctx context.Context, id string) error {
+	_, scope, err := s.sharedItem(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.DeleteItem(ctx, scope, id); err != nil {
+		return err
+	}
+	s.rebuildBlock(ctx, scope)
+	return nil
+}
+
+// sharedItem loads one item from any shared space of the caller's workspace.
+// A personal item's id produces the same not-found as a missing one, so the
+// curation API cannot be used to reach into a person's memory.
+func (s *Service) sharedItem(
+	ctx context.Context, id string,
+) (*types.MemoryItem, interfaces.MemoryScope, error) {
+	tenantID, ok := types.TenantIDFromContext(ctx)
+	if !ok || tenantID == 0 {
+		return nil, interfaces.MemoryScope{}, ErrNoMemoryScope
+	}
+	item, err := s.repo.GetSharedItem(ctx, tenantID, id)
+	if err != nil {
+		return nil, interfaces.MemoryScope{}, err
+	}
+	if item == nil {
+		return nil, interfaces.MemoryScope{}, ErrItemNotFound
+	}
+	return item, interfaces.MemoryScope{TenantID: tenantID, SubjectID: item.SubjectID}, nil
+}
+
+func (s *Service) reloadShared(
+	ctx context.Context, scope interfaces.MemoryScope, id string,
+) (*types.MemoryItem, error) {
+	item, err := s.repo.GetItem(ctx, scope, id)
+	if err != nil {
+		return nil, err
+	}
+	if item == nil {
+		return nil, ErrItemNotFound
+	}
+	return types.MarkMemoryProvenance([]*types.MemoryItem{item})[0], nil
+}
+
+// reviewerID is who an approval is attributed to. It is informational only —
+// the route is already admin-gated — so a context without a principal simply
+// records nobody.
+func reviewerID(ctx context.Context) string {
+	scope, err := ResolveScope(ctx)
+	if err != nil {
+		return ""
+	}
+	return scope.SubjectID
+}
@@ -0,0 +1,180 @@
+package memory
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/stretchr/testify/require"
+)
+
+// A member's proposal must not reach anyone's prompt until an admin approves
+// it; after approval every member of the workspace reads it, labelled as team
+// knowledge rather than as something about them.
+func TestSharedMemoryNeedsApprovalBeforeRecall(t *testing.T) {
+	svc, _, tenantRepo := newMemoryHarness(t)
+	alice := enabledCtx(t, tenantRepo, 1, "alice")
+	bob := enabledCtx(t, tenantRepo, 1, "bob")
+
+	personal, err := svc.CreateItem(alice, types.MemoryKindPreference, "代码评审至少需要两人通过", 4)
+	require.NoError(t, err)
+
+	proposal, err := svc.ShareItem(alice, personal.ID, types.MemoryProvenanceWorkspace, "")
+	require.NoError(t, err)
+	require.Equal(t, types.MemoryStatusPending, proposal.Status)
+	require.Equal(t, types.MemoryOriginPromoted, proposal.Origin)
+	require.Equal(t, "web_user:alice", proposal.PromotedBy)
+	require.Equal(t, personal.ID, proposal.PromotedFrom)
+
+	require.Empty(t, svc.Recall(bob, "怎么提交代码").Prompt)
+
+	approved, err := svc.ApproveSharedItem(bob, proposal.ID)
+	require.NoError(t, err)
+	require.Equal(t, types.MemoryStatusActive, approved.Status)
+	require.Equal(t, "web_user:bob", approved.ReviewedBy)
+
+	recall := svc.Recall(bob, "怎么提交代码")
+	require.Contains(t, recall.Prompt, "<team_memory>")
+	require.Contains(t, recall.Prompt, "代码评审至少需要两人通过")
+	require.NotContains(t, recall.Prompt, "<user_memory>")
+	require.Len(t, recall.Items, 1)
+	require.Equal(t, types.MemoryProvenanceWorkspace, recall.Items[0].Provenance)
+
+	// Alice already holds the statement herself, so it is injected once.
+	recall = svc.Recall(alice, "怎么提交代码")
+	require.Contains(t, recall.Prompt, "<user_memory>")
+	require.NotContains(t, recall.Prompt, "<team_memory>")
+
+	_, err = svc.ApproveSharedItem(bob, proposal.ID)
+	require.ErrorIs(t, err, ErrNotPendingReview)
+}
+
+func TestSharedMemoryRejectKeepsThePersonalCopy(t *testing.T) {
+	svc, _, tenantRepo := newMemoryHarness(t)
+	alice := enabledCtx(t, tenantRepo, 1, "alice")
+
+	personal, err := svc.CreateItem(alice, types.MemoryKindFact, "测试环境数据库每晚重置", 3)
+	require.NoError(t, err)
+	proposal, err := svc.ShareItem(alice, personal.ID, types.MemoryProvenanceWorkspace, "")
+	require.NoError(t, err)
+
+	// Proposing the same thing twice yields the existing proposal.
+	again, err := svc.ShareItem(alice, personal.ID, types.MemoryProvenanceWorkspace, "")
+	require.NoError(t, err)
+	require.Equal(t, proposal.ID, again.ID)
+
+	require.NoError(t, svc.RejectSharedItem(alice, proposal.ID))
+	items, total, err := svc.ListSharedItems(alice, "", "", "", 50, 0)
+	require.NoError(t, err)
+	require.Zero(t, total)
+	require.Empty(t, items)
+
+	mine, _, err := svc.ListItems(alice, types.MemoryStatusActive, 50, 0)
+	require.NoError(t, err)
+	require.Len(t, mine, 1)
+}
+
+func TestShareItemRefusesWhatCannotBeShared(t *testing.T) {
+	svc, _, tenantRepo := newMemoryHarness(t)
+	alice := enabledCtx(t, tenantRepo, 1, "alice")
+	bob := enabledCtx(t, tenantRepo, 1, "bob")
+
+	interest, err := svc.CreateItem(alice, types.MemoryKindInterest, "医学影像分割", 3)
+	require.NoError(t, err)
+	_, err = svc.ShareItem(alice, interest.ID, types.MemoryProvenanceWorkspace, "")
+	require.ErrorIs(t, err, ErrNotShareable)
+
+	fact, err := svc.CreateItem(alice, types.MemoryKindFact, "发布窗口是周四下午", 3)
+	require.NoError(t, err)
+	_, err = svc.ShareItem(alice, fact.ID, types.MemoryProvenanceAgent, "")
+	require.ErrorIs(t, err, ErrInvalidSharedSpace)
+	_, err = svc.ShareItem(alice, fact.ID, "team", "")
+	require.ErrorIs(t, err, ErrInvalidSharedSpace)
+
+	// Someone else's memory is not theirs to share.
+	_, err = svc.ShareItem(bob, fact.ID, types.MemoryProvenanceWorkspace, "")
+	require.ErrorIs(t, err, ErrItemNotFound)
+
+	// The curation API reaches shared spaces only, never a person's memory.
+	_, err = svc.UpdateSharedItem(bob, fact.ID, "改掉别人的记忆", 3, nil)
+	require.ErrorIs(t, err, ErrItemNotFound)
+	require.ErrorIs(t, svc.DeleteSharedItem(bob, fact.ID), ErrItemNotFound)
+}
+
+// An agent's shared notes belong to conversations with that agent only.
+func TestAgentSharedMemoryFollowsTheAnsweringAgent(t *testing.T) {
+	svc, _, tenantRepo := newMemoryHarness(t)
+	admin := enabledCtx(t, tenantRepo, 1, "admin")
+
+	_, err := svc.CreateSharedItem(admin, types.MemoryProvenanceAgent, "agent-ops",
+		types.MemoryKindPreference, "回答运维问题时先给出回滚步骤", 4, nil)
+	require.NoError(t, err)
+	_, err = svc.CreateSharedItem(admin, types.MemoryProvenanceWorkspace, "",
+		types.MemoryKindProfile, "团队负责公司内部的数据平台", 3, nil)
+	require.NoError(t, err)
+
+	plain := svc.Recall(admin, "服务挂了怎么办")
+	require.Contains(t, plain.Prompt, "数据平台")
+	require.NotContains(t, plain.Prompt, "回滚步骤")
+
+	ops := svc.Recall(types.WithMemoryAgent(admin, "agent-ops"), "服务挂了怎么办")
+	require.Contains(t, ops.Prompt, "数据平台")
+	require.Contains(t, ops.Prompt, "回滚步骤")
+	require.Contains(t, ops.Prompt, "Team conventions")
+	require.Len(t, ops.Items, 2)
+	require.Equal(t, types.MemoryProvenanceAgent, ops.Items[0].Provenance)
+	require.Equal(t, "agent-ops", ops.Items[0].AgentID)
+
+	listed, total, err := svc.ListSharedItems(admin, types.MemoryProvenanceAgent, "agent-ops", "", 50, 0)
+	require.NoError(t, err)
+	require.EqualValues(t, 1, total)
+	require.Equal(t, "web_user:admin", listed[0].ReviewedBy)
+
+	found := svc.SearchMemory(types.WithMemoryAgent(admin, "agent-ops"), "回滚步骤", 5)
+	require.Len(t, found.Items, 1)
+	require.Equal(t, types.MemoryProvenanceAgent, found.Items[0].Provenance)
+}
+
+func TestSharedMemoryExpiry(t *testing.T) {
+	svc, _, tenantRepo := newMemoryHarness(t)
+	admin := enabledCtx(t, tenantRepo, 1, "admin")
+
+	later := time.Now().Add(24 * time.Hour)
+	item, err := svc.CreateSharedItem(admin, types.MemoryProvenanceWorkspace, "",
+		types.MemoryKindPreference, "本周冻结生产发布", 4, &later)
+	require.NoError(t, err)
+	require.NotNil(t, item.ExpiresAt)
+	require.Contains(t, svc.Recall(admin, "可以发布吗").Prompt, "冻结生产发布")
+
+	past := time.Now().Add(-time.Minute)
+	updated, err := svc.UpdateSharedItem(admin, item.ID, "本周冻结生产发布", 4, &past)
+	require.NoError(t, err)
+	require.True(t, updated.ExpiresAt.Before(time.Now()))
+	require.Empty(t, svc.Recall(admin, "可以发布吗").Prompt)
+
+	updated, err = svc.UpdateSharedItem(admin, item.ID, "本周冻结生产发布，紧急修复除外", 4, nil)
+	require.NoError(t, err)
+	require.Nil(t, updated.ExpiresAt)
+	require.Contains(t, svc.Recall(admin, "可以发布吗").Prompt, "紧急修复除外")
+}
+
+// Curated notes are exempt from the personal capacity cap: archiving an
+// admin's decision behind their back would be a surprise.
+func TestSharedMemoryIgnoresCapacityCap(t *testing.T) {
+	svc, _, tenantRepo := newMemoryHarness(t)
+	admin := enabledCtx(t, tenantRepo, 1, "admin")
+	tenantRepo.set(1, &types.MemoryConfig{Enabled: true, WriteMode: types.MemoryWriteAuto, MaxItems: 10})
+
+	for _, content := range []string{
+		"周一例会十点开始", "代码仓库在内网 GitLab", "值班表每月更新", "报销走 OA 系统",
+		"设计稿放在共享盘", "新人第一周先读手册", "线上变更需要工单", "周五不做大版本发布",
+		"测试覆盖率要求八成", "日志保留三十天", "告警先看值班群", "数据库备份每天凌晨",
+	} {
+		_, err := svc.CreateSharedItem(admin, types.MemoryProvenanceWorkspace, "",
+			types.MemoryKindFact, content, 3, nil)
+		require.NoError(t, err)
+	}
+	_, total, err := svc.ListSharedItems(admin, types.MemoryProvenanceWorkspace, "", types.MemoryStatusActive, 50, 0)
+	require.NoError(t, err)
+	require.EqualValues(t, 12, total)
+}
@@ -0,0 +1,468 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/ratelimit"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+const (
+	// rateLimitPolicyCacheTTL is how long a workspace's policies are reused
+	// before they are read again. Changes made through this replica apply
+	// immediately; other replicas pick them up after the next reload.
+	rateLimitPolicyCacheTTL = 30 * time.Second
+	// rateLimitAuditScope is the audit scope type of policy events.
+	rateLimitAuditScope = "rate_limit_policy"
+	// rateLimitKeyPrefix namespaces the counters in Redis.
+	rateLimitKeyPrefix = "weknora:ratelimit:"
+	// maxRateLimitScopeID matches the scope_id column.
+	maxRateLimitScopeID = 64
+)
+
+type cachedRateLimitPolicies struct {
+	policies []*types.RateLimitPolicy
+	loadedAt time.Time
+}
+
+type rateLimitService struct {
+	repo        interfaces.RateLimitPolicyRepository
+	apiKeyRepo  interfaces.TenantAPIKeyRepository
+	memberRepo  interfaces.TenantMemberRepository
+	channelRepo interfaces.EmbedChannelRepository
+	audit       interfaces.AuditLogService
+	now         func() time.Time
+
+	requests *ratelimit.Limiter
+	streams  *ratelimit.Semaphore
+	ingest   *ratelimit.Counter
+	tokens   *ratelimit.Counter
+
+	cacheMu  sync.Mutex
+	policies map[uint64]*cachedRateLimitPolicies
+}
+
+// NewRateLimitService creates the rate limit service. Counters live in
+// Redis so every replica enforces the same budget; without Redis (Lite)
+// they are kept in process.
+func NewRateLimitService(
+	repo interfaces.RateLimitPolicyRepository,
+	apiKeyRepo interfaces.TenantAPIKeyRepository,
+	memberRepo interfaces.TenantMemberRepository,
+	channelRepo interfaces.EmbedChannelRepository,
+	audit interfaces.AuditLogService,
+	rdb *redis.Client,
+) interfaces.RateLimitService {
+	s := &rateLimitService{
+		repo:        repo,
+		apiKeyRepo:  apiKeyRepo,
+		memberRepo:  memberRepo,
+		channelRepo: channelRepo,
+		audit:       audit,
+		now:         time.Now,
+		requests:    ratelimit.New(rdb, rateLimitKeyPrefix+"rpm:", time.Minute, ""),
+		streams:     ratelimit.NewSemaphore(rdb, rateLimitKeyPrefix+"streams:"),
+		ingest:      ratelimit.NewCounter(rdb, rateLimitKeyPrefix+"ingest:", 24*time.Hour),
+		tokens:      ratelimit.NewCounter(rdb, rateLimitKeyPrefix+"tpm:", time.Minute),
+		policies:    make(map[uint64]*cachedRateLimitPolicies),
+	}
+	// Local-fallback eviction; Redis keys expire on their own.
+	go s.requests.StartCleanup(make(chan struct{}))
+	return s
+}
+
+// rateLimitCheck is one policy limit applied to one subject.
+type rateLimitCheck struct {
+	tenantID uint64
+	subject  types.RateLimitSubject
+	limit    int64
+}
+
+// counterKey identifies the subject's counters; a default policy still
+// counts each caller separately.
+func (c rateLimitCheck) counterKey() string {
+	return fmt.Sprintf("%d:%s:%s", c.tenantID, c.subject.ScopeType, c.subject.ScopeID)
+}
+
+func (c rateLimitCheck) label() string {
+	switch c.subject.ScopeType {
+	case types.RateLimitScopeTenant:
+		return "the workspace"
+	case types.RateLimitScopeAPIKey:
+		return "API key " + c.subject.ScopeID
+	case types.RateLimitScopeEmbedChannel:
+		return "embed channel " + c.subject.ScopeID
+	default:
+		return "user " + c.subject.ScopeID
+	}
+}
+
+// checks resolves the limits that apply to the request in ctx, caller
+// first and workspace last, so a caller over its own budget does not eat
+// into the shared one. pick selects the limit in question from a policy.
+func (s *rateLimitService) checks(ctx context.Context, pick func(*types.RateLimitPolicy) int64) []rateLimitCheck {
+	tenantID, ok := types.TenantIDFromContext(ctx)
+	if !ok || tenantID == 0 {
+		return nil
+	}
+	policies, err := s.cachedPolicies(ctx, tenantID)
+	if err != nil {
+		// Like budgets, rate limits protect capacity rather than data: a
+		// database hiccup must not reject every request.
+		logger.Warnf(ctx, "[RateLimit] failed to load policies for tenant %d: %v", tenantID, err)
+		return nil
+	}
+	if len(policies) == 0 {
+		return nil
+	}
+	subjects := types.RateLimitSubjectsFromContext(ctx)
+	out := make([]rateLimitCheck, 0, len(subjects))
+	for i := len(subjects) - 1; i >= 0; i-- {
+		policy := resolveRateLimitPolicy(policies, subjects[i])
+		if policy == nil {
+			continue
+		}
+		if limit := pick(policy); limit > 0 {
+			out = append(out, rateLimitCheck{tenantID: tenantID, subject: subjects[i], limit: limit})
+		}
+	}
+	return out
+}
+
+// resolveRateLimitPolicy returns the policy of subject, falling back to the
+// default policy of its type.
+func resolveRateLimitPolicy(policies []*types.RateLimitPolicy, subject types.RateLimitSubject) *types.RateLimitPolicy {
+	var fallback *types.RateLimitPolicy
+	for _, p := range policies {
+		if p.ScopeType != subject.ScopeType {
+			continue
+		}
+		if p.ScopeID == subject.ScopeID {
+			return p
+		}
+		if p.ScopeID == "" {
+			fallback = p
+		}
+	}
+	return fallback
+}
+
+// tighter reports whether a leaves less headroom than b.
+func tighter(a, b types.RateLimitDecision) bool {
+	if b.Limit == 0 {
+		return true
+	}
+	return a.Remaining < b.Remaining
+}
+
+func toRateLimitDecision(d ratelimit.Decision) types.RateLimitDecision {
+	return types.RateLimitDecision{
+		Allowed: d.Allowed, Limit: d.Limit, Remaining: d.Remaining, Reset: d.Reset, RetryAfter: d.RetryAfter,
+	}
+}
+
+// rateLimitExceeded builds the 429 returned for a denied check.
+func rateLimitExceeded(c rateLimitCheck, what string, d types.RateLimitDecision) error {
+	retryAfter := int64(math.Ceil(d.RetryAfter.Seconds()))
+	return errors.NewTooManyRequestsError(
+		fmt.Sprintf("rate limit exceeded: %s of %s is limited to %d", what, c.label(), c.limit),
+	).WithDetails(&types.RateLimitExceededDetails{
+		ScopeType:         c.subject.ScopeType,
+		ScopeID:           c.subject.ScopeID,
+		Limit:             c.limit,
+		RetryAfterSeconds: retryAfter,
+		Decision:          d,
+	})
+}
+
+func (s *rateLimitService) CheckRequest(ctx context.Context) (types.RateLimitDecision, error) {
+	var result types.RateLimitDecision
+	for _, c := range s.checks(ctx, func(p *types.RateLimitPolicy) int64 { return int64(p.RequestsPerMinute) }) {
+		d := toRateLimitDecision(s.requests.Take(ctx, c.counterKey(), int(c.limit)))
+		if !d.Allowed {
+			return d, rateLimitExceeded(c, "requests per minute", d)
+		}
+		if tighter(d, result) {
+			result = d
+		}
+	}
+	result.Allowed = true
+	return result, nil
+}
+
+func (s *rateLimitService) AcquireStream(ctx context.Context) (func(), types.RateLimitDecision, error) {
+	var (
+		result   types.RateLimitDecision
+		releases []func()
+	)
+	release := func() {
+		for _, r := range releases {
+			r()
+		}
+	}
+	for _, c := range s.checks(ctx, func(p *types.RateLimitPolicy) int64 { return int64(p.ConcurrentStreams) }) {
+		r, rd := s.streams.TryAcquire(ctx, c.counterKey(), int(c.limit))
+		d := toRateLimitDecision(rd)
+		if !d.Allowed {
+			release()
+			return func() {}, d, rateLimitExceeded(c, "concurrent chat streams", d)
+		}
+		releases = append(releases, r)
+		if tighter(d, result) {
+			result = d
+		}
+	}
+	result.Allowed = true
+	return release, result, nil
+}
+
+func (s *rateLimitService) ReserveIngest(ctx context.Context, size int64) (func(), types.RateLimitDecision, error) {
+	var (
+		result   types.RateLimitDecision
+		releases []func()
+	)
+	release := func() {
+		for _, r := range releases {
+			r()
+		}
+	}
+	for _, c := range s.checks(ctx, func(p *types.RateLimitPolicy) int64 { return int64(p.IngestMBPerDay) << 20 }) {
+		r, rd := s.ingest.Reserve(ctx, c.counterKey(), size, c.limit)
+		d := toRateLimitDecision(rd)
+		if !d.Allowed {
+			release()
+			return func() {}, d, rateLimitExceeded(c, "daily upload volume (bytes)", d)
+		}
+		releases = append(releases, r)
+		if tighter(d, result) {
+			result = d
+		}
+	}
+	result.Allowed = true
+	return release, result, nil
+}
+
+func (s *rateLimitService) CheckIngest(ctx context.Context) (types.RateLimitDecision, error) {
+	var result types.RateLimitDecision
+	for _, c := range s.checks(ctx, func(p *types.RateLimitPolicy) int64 { return int64(p.IngestMBPerDay) << 20 }) {
+		d := toRateLimitDecision(s.ingest.Check(ctx, c.counterKey(), c.limit))
+		if !d.Allowed {
+			return d, rateLimitExceeded(c, "daily upload volume (bytes)", d)
+		}
+		if tighter(d, result) {
+			result = d
+		}
+	}
+	result.Allowed = true
+	return result, nil
+}
+
+func (s *rateLimitService) RecordIngest(ctx context.Context, size int64) {
+	if size <= 0 {
+		return
+	}
+	for _, c := range s.checks(ctx, func(p *types.RateLimitPolicy) int64 { return int64(p.IngestMBPerDay) << 20 }) {
+		s.ingest.Add(ctx, c.counterKey(), size)
+	}
+}
+
+func (s *rateLimitService) CheckTokens(ctx context.Context) (types.RateLimitDecision, error) {
+	var result types.RateLimitDecision
+	for _, c := range s.checks(ctx, func(p *types.RateLimitPolicy) int64 { return p.TokensPerMinute }) {
+		d := toRateLimitDecision(s.tokens.Check(ctx, c.counterKey(), c.limit))
+		if !d.Allowed {
+			return d, rateLimitExceeded(c, "chat tokens per minute", d)
+		}
+		if tighter(d, result) {
+			result = d
+		}
+	}
+	result.Allowed = true
+	return result, nil
+}
+
+// Record counts chat tokens only: embedding runs in background ingestion,
+// which has no caller to throttle.
+func (s *rateLimitService) Record(ctx context.Context, ev types.UsageEvent) {
+	if ev.Kind != types.UsageKindChat {
+		return
+	}
+	tokens := ev.PromptTokens + ev.CompletionTokens
+	if tokens <= 0 {
+		return
+	}
+	for _, c := range s.checks(ctx, func(p *types.RateLimitPolicy) int64 { return p.TokensPerMinute }) {
+		s.tokens.Add(ctx, c.counterKey(), tokens)
+	}
+}
+
+func (s *rateLimitService) cachedPolicies(ctx context.Context, tenantID uint64) ([]*types.RateLimitPolicy, error) {
+	now := s.now()
+	s.cacheMu.Lock()
+	if c, ok := s.policies[tenantID]; ok && now.Sub(c.loadedAt) < rateLimitPolicyCacheTTL {
+		s.cacheMu.Unlock()
+		return c.policies, nil
+	}
+	s.cacheMu.Unlock()
+
+	policies, err := s.repo.ListPolicies(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	s.cacheMu.Lock()
+	s.policies[tenantID] = &cachedRateLimitPolicies{policies: policies, loadedAt: now}
+	s.cacheMu.Unlock()
+	return policies, nil
+}
+
+func (s *rateLimitService) invalidatePolicies(tenantID uint64) {
+	s.cacheMu.Lock()
+	delete(s.policies, tenantID)
+	s.cacheMu.Unlock()
+}
+
+func (s *rateLimitService) ListPolicies(ctx context.Context) ([]*types.RateLimitPolicy, error) {
+	tenantID, ok := types.TenantIDFromContext(ctx)
+	if !ok {
+		return nil, errors.NewUnauthorizedError("workspace not found in context")
+	}
+	return s.repo.ListPolicies(ctx, tenantID)
+}
+
+func (s *rateLimitService) SetPolicy(ctx context.Context, req *types.RateLimitPolicyRequest) (*types.RateLimitPolicy, error) {
+	tenantID, ok := types.TenantIDFromContext(ctx)
+	if !ok {
+		return nil, errors.NewUnauthorizedError("workspace not found in context")
+	}
+	req.ScopeID = strings.TrimSpace(req.ScopeID)
+	if err := s.validatePolicyRequest(ctx, tenantID, req); err != nil {
+		return nil, err
+	}
+	policy := &types.RateLimitPolicy{
+		TenantID:          tenantID,
+		ScopeType:         req.ScopeType,
+		ScopeID:           req.ScopeID,
+		RequestsPerMinute: req.RequestsPerMinute,
+		ConcurrentStreams: req.ConcurrentStreams,
+		IngestMBPerDay:    req.IngestMBPerDay,
+		TokensPerMinute:   req.TokensPerMinute,
+	}
+	if err := s.repo.UpsertPolicy(ctx, policy); err != nil {
+		return nil, err
+	}
+	s.invalidatePolicies(tenantID)
+	s.auditPolicy(ctx, policy, types.AuditActionRateLimitPolicyUpdated, map[string]any{
+		"scope_type":          policy.ScopeType,
+		"scope_id":            policy.ScopeID,
+		"requests_per_minute": policy.RequestsPerMinute,
+		"concurrent_streams":  policy.ConcurrentStreams,
+		"ingest_mb_per_day":   policy.IngestMBPerDay,
+		"tokens_per_minute":   policy.TokensPerMinute,
+	})
+	return policy, nil
+}
+
+// validatePolicyRequest rejects unknown scopes, negative limits and scope
+// IDs that do not name an API key, member or embed channel of the
+// workspace.
+func (s *rateLimitService) validatePolicyRequest(
+	ctx context.Context, tenantID uint64, req *types.RateLimitPolicyRequest,
+) error {
+	if !req.ScopeType.IsValid() {
+		return errors.NewBadRequestError(fmt.Sprintf("unsupported scope_type %q", req.ScopeType))
+	}
+	if req.RequestsPerMinute < 0 || req.ConcurrentStreams < 0 || req.IngestMBPerDay < 0 || req.TokensPerMinute < 0 {
+		return errors.NewBadRequestError("limits must not be negative")
+	}
+	if len(req.ScopeID) > maxRateLimitScopeID {
+		return errors.NewBadRequestError(fmt.Sprintf("scope_id must not exceed %d characters", maxRateLimitScopeID))
+	}
+	if req.ScopeID == "" {
+		return nil
+	}
+	switch req.ScopeType {
+	case types.RateLimitScopeTenant:
+		return errors.NewBadRequestError("scope_id must be empty for the tenant scope")
+	case types.RateLimitScopeAPIKey:
+		keyID, err := strconv.ParseUint(req.ScopeID, 10, 64)
+		if err != nil || keyID == 0 {
+			return errors.NewBadRequestError("scope_id of an api_key policy must be the API key ID")
+		}
+		keys, err := s.apiKeyRepo.ListAPIKeys(ctx, tenantID)
+		if err != nil {
+			return err
+		}
+		for _, k := range keys {
+			if k.ID == keyID {
+				return nil
+			}
+		}
+		return errors.NewBadRequestError(fmt.Sprintf("API key %d not found in this workspace", keyID))
+	case types.RateLimitScopeUser:
+		member, err := s.memberRepo.Get(ctx, req.ScopeID, tenantID)
+		if err != nil {
+			return err
+		}
+		if member == nil {
+			return errors.NewBadRequestError(fmt.Sprintf("user %s is not a member of this workspace", req.ScopeID))
+		}
+	case types.RateLimitScopeEmbedChannel:
+		ch, err := s.channelRepo.GetByID(ctx, req.ScopeID)
+		if err != nil || ch == nil || ch.TenantID != tenantID {
+			return errors.NewBadRequestError(fmt.Sprintf("embed channel %s not found in this workspace", req.ScopeID))
+		}
+	}
+	return nil
+}
+
+func (s *rateLimitService) DeletePolicy(ctx context.Context, id uint64) error {
+	tenantID, ok := types.TenantIDFromContext(ctx)
+	if !ok {
+		return errors.NewUnauthorizedError("workspace not found in context")
+	}
+	deleted, err := s.repo.DeletePolicy(ctx, tenantID, id)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return errors.NewNotFoundError("rate limit policy not found")
+	}
+	s.invalidatePolicies(tenantID)
+	s.auditPolicy(ctx, &types.RateLimitPolicy{ID: id, TenantID: tenantID},
+		types.AuditActionRateLimitPolicyDeleted, map[string]any{})
+	return nil
+}
+
+func (s *rateLimitService) auditPolicy(
+	ctx context.Context, p *types.RateLimitPolicy, action types.AuditAction, details map[string]any,
+) {
+	if s.audit == nil {
+		return
+	}
+	var detailJSON types.JSON
+	if raw, err := json.Marshal(details); err == nil {
+		detailJSON = types.JSON(raw)
+	}
+	_ = s.audit.Log(ctx, &types.AuditLog{
+		TenantID:    p.TenantID,
+		ActorUserID: auditActor(ctx),
+		ActorRole:   auditActorRole(ctx),
+		Action:      action,
+		ScopeType:   rateLimitAuditScope,
+		ScopeID:     strconv.FormatUint(p.ID, 10),
+		Outcome:     types.AuditOutcomeSuccess,
+		Details:     detailJSON,
+	})
+}
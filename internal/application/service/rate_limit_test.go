@@ -0,0 +1,216 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"github.com/Tencent/WeKnora/internal/application/repository"
+	"github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+func newTestRateLimitService(t *testing.T, audit interfaces.AuditLogService) (*rateLimitService, *fakeTenantAPIKeyRepo) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&types.RateLimitPolicy{}))
+	keys := newFakeTenantAPIKeyRepo()
+	svc := NewRateLimitService(repository.NewRateLimitPolicyRepository(db), keys, nil, nil, audit, nil)
+	return svc.(*rateLimitService), keys
+}
+
+func apiKeyContext(tenantID, keyID uint64) context.Context {
+	return types.WithTenantAPIKeyScope(usageTestContext(tenantID), types.TenantAPIKeyScope{KeyID: keyID, FullAccess: true})
+}
+
+func assertTooManyRequests(t *testing.T, err error) {
+	t.Helper()
+	appErr, ok := errors.IsAppError(err)
+	require.True(t, ok, "want an application error, got %v", err)
+	assert.Equal(t, errors.ErrTooManyRequests, appErr.Code)
+}
+
+func TestRateLimitServiceRequestsPerMinute(t *testing.T) {
+	svc, _ := newTestRateLimitService(t, nil)
+	admin := usageTestContext(1)
+	_, err := svc.SetPolicy(admin, &types.RateLimitPolicyRequest{ScopeType: types.RateLimitScopeAPIKey, RequestsPerMinute: 2})
+	require.NoError(t, err)
+	_, err = svc.SetPolicy(admin, &types.RateLimitPolicyRequest{ScopeType: types.RateLimitScopeTenant, RequestsPerMinute: 3})
+	require.NoError(t, err)
+
+	// The per-key default gives each key its own two requests.
+	ctx := apiKeyContext(1, 9)
+	d, err := svc.CheckRequest(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, types.RateLimitDecision{Allowed: true, Limit: 2, Remaining: 1, Reset: d.Reset}, d)
+	_, err = svc.CheckRequest(ctx)
+	require.NoError(t, err)
+	d, err = svc.CheckRequest(ctx)
+	assertTooManyRequests(t, err)
+	assert.False(t, d.Allowed)
+	assert.Positive(t, d.RetryAfter)
+
+	// Another key still has its own budget, until the workspace runs out.
+	_, err = svc.CheckRequest(apiKeyContext(1, 10))
+	require.NoError(t, err)
+	_, err = svc.CheckRequest(apiKeyContext(1, 10))
+	assertTooManyRequests(t, err)
+
+	// Other workspaces and callers without policies are not limited.
+	for i := 0; i < 5; i++ {
+		d, err = svc.CheckRequest(apiKeyContext(2, 9))
+		require.NoError(t, err)
+		assert.Zero(t, d.Limit)
+	}
+}
+
+func TestRateLimitServiceSpecificPolicyOverridesDefault(t *testing.T) {
+	svc, keys := newTestRateLimitService(t, nil)
+	admin := usageTestContext(1)
+	tenantID := uint64(1)
+	require.NoError(t, keys.CreateAPIKey(admin, &types.TenantAPIKey{TenantID: &tenantID, KeyHash: "h1"}))
+	_, err := svc.SetPolicy(admin, &types.RateLimitPolicyRequest{ScopeType: types.RateLimitScopeAPIKey, RequestsPerMinute: 1})
+	require.NoError(t, err)
+	_, err = svc.SetPolicy(admin, &types.RateLimitPolicyRequest{
+		ScopeType: types.RateLimitScopeAPIKey, ScopeID: "1", RequestsPerMinute: 5,
+	})
+	require.NoError(t, err)
+
+	for i := 0; i < 5; i++ {
+		_, err = svc.CheckRequest(apiKeyContext(1, 1))
+		require.NoError(t, err)
+	}
+	_, err = svc.CheckRequest(apiKeyContext(1, 1))
+	assertTooManyRequests(t, err)
+}
+
+func TestRateLimitServiceConcurrentStreams(t *testing.T) {
+	svc, _ := newTestRateLimitService(t, nil)
+	_, err := svc.SetPolicy(usageTestContext(1), &types.RateLimitPolicyRequest{
+		ScopeType: types.RateLimitScopeUser, ConcurrentStreams: 1,
+	})
+	require.NoError(t, err)
+	ctx := types.WithPrincipal(usageTestContext(1), types.Principal{Type: types.PrincipalWebUser, ID: "u1"})
+
+	release, _, err := svc.AcquireStream(ctx)
+	require.NoError(t, err)
+	_, d, err := svc.AcquireStream(ctx)
+	assertTooManyRequests(t, err)
+	assert.Positive(t, d.RetryAfter)
+	release()
+	release2, _, err := svc.AcquireStream(ctx)
+	require.NoError(t, err)
+	release2()
+}
+
+func TestRateLimitServiceIngestPerDay(t *testing.T) {
+	svc, _ := newTestRateLimitService(t, nil)
+	ctx := usageTestContext(1)
+	_, err := svc.SetPolicy(ctx, &types.RateLimitPolicyRequest{ScopeType: types.RateLimitScopeTenant, IngestMBPerDay: 1})
+	require.NoError(t, err)
+
+	release, d, err := svc.ReserveIngest(ctx, 600<<10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1<<20), d.Limit)
+	_, _, err = svc.ReserveIngest(ctx, 600<<10)
+	assertTooManyRequests(t, err)
+	// A rejected upload gives its bytes back.
+	release()
+	_, _, err = svc.ReserveIngest(ctx, 600<<10)
+	require.NoError(t, err)
+}
+
+func TestKnowledgeServiceEnforcesIngestQuota(t *testing.T) {
+	limits, _ := newTestRateLimitService(t, nil)
+	ctx := usageTestContext(1)
+	_, err := limits.SetPolicy(ctx, &types.RateLimitPolicyRequest{ScopeType: types.RateLimitScopeTenant, IngestMBPerDay: 1})
+	require.NoError(t, err)
+	ks := &knowledgeService{rateLimits: limits}
+
+	// Rejected content gives its reservation back.
+	blank := &types.ManualKnowledgePayload{Content: strings.Repeat(" ", 600<<10)}
+	for i := 0; i < 2; i++ {
+		_, err = ks.CreateKnowledgeFromManual(ctx, "kb1", blank, "")
+		appErr, ok := errors.IsAppError(err)
+		require.True(t, ok, "want an application error, got %v", err)
+		assert.Equal(t, errors.ErrValidation, appErr.Code)
+	}
+
+	// Fetched URL content is counted afterwards; once the day is used up,
+	// URL imports and manual content are turned away up front.
+	limits.RecordIngest(ctx, 1<<20)
+	_, err = ks.CreateKnowledgeFromURL(ctx, "kb1", "https://example.com/a", "", "", nil, "", nil, "", nil)
+	assertTooManyRequests(t, err)
+	_, err = ks.CreateKnowledgeFromManual(ctx, "kb1", &types.ManualKnowledgePayload{Content: "hello"}, "")
+	assertTooManyRequests(t, err)
+	appErr, _ := errors.IsAppError(err)
+	details, ok := appErr.Details.(*types.RateLimitExceededDetails)
+	require.True(t, ok, "details = %T", appErr.Details)
+	assert.Equal(t, types.RateLimitScopeTenant, details.ScopeType)
+	assert.Positive(t, details.Decision.RetryAfter)
+}
+
+func TestRateLimitServiceTokensPerMinute(t *testing.T) {
+	svc, _ := newTestRateLimitService(t, nil)
+	ctx := usageTestContext(1)
+	_, err := svc.SetPolicy(ctx, &types.RateLimitPolicyRequest{ScopeType: types.RateLimitScopeTenant, TokensPerMinute: 100})
+	require.NoError(t, err)
+
+	// Embedding tokens come from background ingestion and do not count.
+	svc.Record(ctx, types.UsageEvent{Kind: types.UsageKindEmbedding, EmbeddingTokens: 500})
+	_, err = svc.CheckTokens(ctx)
+	require.NoError(t, err)
+
+	svc.Record(ctx, types.UsageEvent{Kind: types.UsageKindChat, PromptTokens: 80, CompletionTokens: 40})
+	d, err := svc.CheckTokens(ctx)
+	assertTooManyRequests(t, err)
+	assert.Zero(t, d.Remaining)
+}
+
+func TestRateLimitServicePolicyValidationAndAudit(t *testing.T) {
+	audit := &recordingAuditLog{}
+	svc, _ := newTestRateLimitService(t, audit)
+	ctx := usageTestContext(1)
+
+	for _, req := range []*types.RateLimitPolicyRequest{
+		{ScopeType: "team"},
+		{ScopeType: types.RateLimitScopeTenant, ScopeID: "x"},
+		{ScopeType: types.RateLimitScopeTenant, RequestsPerMinute: -1},
+		{ScopeType: types.RateLimitScopeAPIKey, ScopeID: "abc"},
+		{ScopeType: types.RateLimitScopeAPIKey, ScopeID: "42"},
+	} {
+		_, err := svc.SetPolicy(ctx, req)
+		appErr, ok := errors.IsAppError(err)
+		require.True(t, ok, "request %+v: %v", req, err)
+		assert.Equal(t, errors.ErrBadRequest, appErr.Code)
+	}
+
+	first, err := svc.SetPolicy(ctx, &types.RateLimitPolicyRequest{ScopeType: types.RateLimitScopeTenant, RequestsPerMinute: 10})
+	require.NoError(t, err)
+	second, err := svc.SetPolicy(ctx, &types.RateLimitPolicyRequest{ScopeType: types.RateLimitScopeTenant, RequestsPerMinute: 20})
+	require.NoError(t, err)
+	assert.Equal(t, first.ID, second.ID, "setting a scope again replaces its policy")
+	assert.Equal(t, 20, second.RequestsPerMinute)
+
+	policies, err := svc.ListPolicies(ctx)
+	require.NoError(t, err)
+	require.Len(t, policies, 1)
+
+	require.NoError(t, svc.DeletePolicy(ctx, second.ID))
+	appErr, ok := errors.IsAppError(svc.DeletePolicy(ctx, second.ID))
+	require.True(t, ok)
+	assert.Equal(t, errors.ErrNotFound, appErr.Code)
+
+	assert.Equal(t, []types.AuditAction{
+		types.AuditActionRateLimitPolicyUpdated,
+		types.AuditActionRateLimitPolicyUpdated,
+		types.AuditActionRateLimitPolicyDeleted,
+	}, audit.actions)
+}
@@ -7,12 +7,17 @@ import (
 	"slices"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/Tencent/WeKnora/internal/common"
 	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/metrics"
 	"github.com/Tencent/WeKnora/internal/models/embedding"
+	"github.com/Tencent/WeKnora/internal/tracing/otlp"
 	"github.com/Tencent/WeKnora/internal/types"
 	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // engineInfo holds information about a retrieve engine and its supported retriever types
@@ -40,7 +45,15 @@ func (c *CompositeRetrieveEngine) Retrieve(ctx context.Context,
 					continue
 				}
 				if slices.Contains(engineInfo.retrieverType, param.RetrieverType) {
-					result, err := engineInfo.retrieveEngine.Retrieve(ctx, param)
+					start := time.Now()
+					spanCtx, span := otlp.Start(ctx, "retriever.retrieve", engineSpanAttrs(engineInfo,
+						attribute.String("weknora.retriever.type", string(param.RetrieverType)),
+						attribute.Int("weknora.retriever.top_k", param.TopK),
+					))
+					result, err := engineInfo.retrieveEngine.Retrieve(spanCtx, param)
+					otlp.End(span, err)
+					metrics.ObserveRetrieve(string(engineInfo.retrieveEngine.EngineType()),
+						string(param.RetrieverType), time.Since(start), err)
 					if err != nil {
 						return err
 					}
@@ -59,6 +72,12 @@ func (c *CompositeRetrieveEngine) Retrieve(ctx context.Context,
 	)
 }
 
+// engineSpanAttrs labels an OTLP span with the engine it covers.
+func engineSpanAttrs(info *engineInfo, extra ...attribute.KeyValue) trace.SpanStartOption {
+	return trace.WithAttributes(append(extra,
+		attribute.String("db.system.name", string(info.retrieveEngine.EngineType())))...)
+}
+
 // NewCompositeRetrieveEngine creates a new composite retrieve engine with the given parameters
 func NewCompositeRetrieveEngine(
 	registry interfaces.RetrieveEngineRegistry,
@@ -214,7 +233,10 @@ func (c *CompositeRetrieveEngine) BatchIndex(ctx context.Context,
 ) error {
 	// Deduplicate sourceIDs
 	indexInfoList = common.Deduplicate(func(info *types.IndexInfo) string { return info.SourceID }, indexInfoList...)
-	err := c.concurrentExecWithError(ctx, func(ctx context.Context, engineInfo *engineInfo) error {
+	err := c.concurrentExecWithError(ctx, func(ctx context.Context, engineInfo *engineInfo) (err error) {
+		ctx, span := otlp.Start(ctx, "retriever.batch_index", engineSpanAttrs(engineInfo,
+			attribute.Int("weknora.retriever.items", len(indexInfoList))))
+		defer func() { otlp.End(span, err) }()
 		if err := engineInfo.retrieveEngine.BatchIndex(
 			ctx,
 			embedder,
@@ -0,0 +1,1203 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/application/repository"
+	werrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+// scimBasePath prefixes the meta.location and $ref URLs of SCIM resources.
+const scimBasePath = "/api/v1/scim/v2"
+
+// scimDefaultPageSize is the page size of a list request without count.
+const scimDefaultPageSize = 100
+
+// scimService maps SCIM Users onto workspace members and SCIM Groups onto
+// workspace user groups. A group carrying the organization extension is also
+// provisioned as a collaboration organization owned by the workspace, whose
+// member workspaces follow the group's members (see syncOrganization).
+//
+// Deprovisioning is scoped to the workspace that issued it: the membership
+// is removed (which also revokes the user's sessions) and the workspace API
+// keys the user created are revoked. The account itself stays enabled, as
+// it may belong to other workspaces; a workspace API key must not be able to
+// lock a user out of the whole platform.
+type scimService struct {
+	linkRepo      interfaces.TenantSCIMUserRepository
+	userService   interfaces.UserService
+	memberService interfaces.TenantMemberService
+	apiKeyService interfaces.TenantAPIKeyService
+	aclService    interfaces.KnowledgeACLService
+	orgService    interfaces.OrganizationService
+	orgLinkRepo   interfaces.TenantSCIMOrganizationRepository
+	audit         interfaces.AuditLogService
+}
+
+// NewSCIMService creates the SCIM 2.0 provisioning service.
+func NewSCIMService(
+	linkRepo interfaces.TenantSCIMUserRepository,
+	userService interfaces.UserService,
+	memberService interfaces.TenantMemberService,
+	apiKeyService interfaces.TenantAPIKeyService,
+	aclService interfaces.KnowledgeACLService,
+	orgService interfaces.OrganizationService,
+	orgLinkRepo interfaces.TenantSCIMOrganizationRepository,
+	audit interfaces.AuditLogService,
+) interfaces.SCIMService {
+	return &scimService{
+		linkRepo:      linkRepo,
+		userService:   userService,
+		memberService: memberService,
+		apiKeyService: apiKeyService,
+		aclService:    aclService,
+		orgService:    orgService,
+		orgLinkRepo:   orgLinkRepo,
+		audit:         audit,
+	}
+}
+
+// scimUserState is the part of a SCIM user this service stores. Role is nil
+// when the request did not carry roles, which keeps the current role.
+type scimUserState struct {
+	UserName   string
+	ExternalID string
+	Active     bool
+	Role       *types.TenantRole
+}
+
+func (s *scimService) ListUsers(
+	ctx context.Context, tenantID uint64, query *types.SCIMListQuery,
+) (*types.SCIMListResponse, error) {
+	var userName, externalID string
+	switch strings.ToLower(query.FilterAttribute) {
+	case "":
+	case "username":
+		userName = query.FilterValue
+	case "externalid":
+		externalID = query.FilterValue
+	default:
+		return nil, werrors.NewBadRequestError("unsupported filter attribute: " + query.FilterAttribute)
+	}
+	startIndex, count := scimPage(query)
+	links, total, err := s.linkRepo.List(ctx, tenantID, userName, externalID, startIndex-1, count)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(links))
+	for _, l := range links {
+		ids = append(ids, l.UserID)
+	}
+	users := map[string]*types.User{}
+	if len(ids) > 0 {
+		if users, err = s.userService.GetUsersByIDs(ctx, ids); err != nil {
+			return nil, err
+		}
+	}
+	members, err := s.memberService.ListByTenant(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	byUser := make(map[string]*types.TenantMember, len(members))
+	for _, m := range members {
+		byUser[m.UserID] = m
+	}
+
+	resources := make([]any, 0, len(links))
+	for _, l := range links {
+		resources = append(resources, scimUserResource(l, users[l.UserID], byUser[l.UserID]))
+	}
+	return scimListResponse(resources, int(total), startIndex), nil
+}
+
+func (s *scimService) GetUser(ctx context.Context, tenantID uint64, id string) (*types.SCIMUser, error) {
+	link, err := s.getLink(ctx, tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.userResource(ctx, tenantID, link)
+}
+
+func (s *scimService) CreateUser(
+	ctx context.Context, tenantID uint64, in *types.SCIMUser,
+) (*types.SCIMUser, error) {
+	state, err := scimUserStateFrom(in)
+	if err != nil {
+		return nil, err
+	}
+	email := in.PrimaryEmail()
+	if email == "" {
+		return nil, werrors.NewBadRequestError("an e-mail address is required")
+	}
+	if err := s.ensureUserNameFree(ctx, tenantID, state.UserName, ""); err != nil {
+		return nil, err
+	}
+
+	user, err := s.userService.GetUserByEmail(ctx, email)
+	if err != nil && !isUserLookupNotFound(err) {
+		return nil, err
+	}
+	if user == nil {
+		if user, err = s.createAccount(ctx, in, email); err != nil {
+			return nil, err
+		}
+	} else if link, err := s.linkRepo.Get(ctx, tenantID, user.ID); err != nil {
+		return nil, err
+	} else if link != nil {
+		return nil, werrors.NewConflictError("user is already provisioned as " + link.UserName)
+	}
+
+	link := &types.TenantSCIMUser{
+		TenantID:   tenantID,
+		UserID:     user.ID,
+		UserName:   state.UserName,
+		ExternalID: state.ExternalID,
+	}
+	if state.Active {
+		if err := s.ensureMembership(ctx, tenantID, user.ID, state.Role); err != nil {
+			return nil, err
+		}
+		link.Active = true
+	}
+	if err := s.linkRepo.Save(ctx, link); err != nil {
+		return nil, err
+	}
+	if link.Active {
+		s.emitAudit(ctx, tenantID, types.AuditActionSCIMUserProvisioned, user.ID, map[string]any{
+			"user_name": link.UserName,
+		})
+	}
+	return s.userResource(ctx, tenantID, link)
+}
+
+func (s *scimService) ReplaceUser(
+	ctx context.Context, tenantID uint64, id string, in *types.SCIMUser,
+) (*types.SCIMUser, error) {
+	link, err := s.getLink(ctx, tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+	state, err := scimUserStateFrom(in)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.applyUserState(ctx, link, state); err != nil {
+		return nil, err
+	}
+	return s.userResource(ctx, tenantID, link)
+}
+
+func (s *scimService) PatchUser(
+	ctx context.Context, tenantID uint64, id string, patch *types.SCIMPatchRequest,
+) (*types.SCIMUser, error) {
+	link, err := s.getLink(ctx, tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+	state := scimUserState{UserName: link.UserName, ExternalID: link.ExternalID, Active: link.Active}
+	for _, op := range patch.Operations {
+		if err := patchUserState(&state, op); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.applyUserState(ctx, link, state); err != nil {
+		return nil, err
+	}
+	return s.userResource(ctx, tenantID, link)
+}
+
+func (s *scimService) DeleteUser(ctx context.Context, tenantID uint64, id string) error {
+	link, err := s.getLink(ctx, tenantID, id)
+	if err != nil {
+		return err
+	}
+	if link.Active {
+		if err := s.deprovision(ctx, link); err != nil {
+			return err
+		}
+	}
+	return s.linkRepo.Delete(ctx, tenantID, link.UserID)
+}
+
+// applyUserState moves link to state: it renames, reactivates,
+// deprovisions or changes the role as needed, then saves the link.
+func (s *scimService) applyUserState(ctx context.Context, link *types.TenantSCIMUser, state scimUserState) error {
+	if state.UserName == "" {
+		return werrors.NewBadRequestError("userName is required")
+	}
+	if state.UserName != link.UserName {
+		if err := s.ensureUserNameFree(ctx, link.TenantID, state.UserName, link.UserID); err != nil {
+			return err
+		}
+	}
+	link.UserName, link.ExternalID = state.UserName, state.ExternalID
+
+	switch {
+	case state.Active:
+		if err := s.ensureMembership(ctx, link.TenantID, link.UserID, state.Role); err != nil {
+			return err
+		}
+		if !link.Active {
+			link.Active = true
+			s.emitAudit(ctx, link.TenantID, types.AuditActionSCIMUserProvisioned, link.UserID, map[string]any{
+				"user_name":   link.UserName,
+				"reactivated": true,
+			})
+		}
+	case link.Active:
+		if err := s.deprovision(ctx, link); err != nil {
+			return err
+		}
+	}
+	return s.linkRepo.Save(ctx, link)
+}
+
+// deprovision removes the membership and revokes the API keys the user
+// created in the workspace. The last Owner cannot be deprovisioned.
+func (s *scimService) deprovision(ctx context.Context, link *types.TenantSCIMUser) error {
+	err := s.memberService.RemoveMember(ctx, link.UserID, link.TenantID)
+	switch {
+	case errors.Is(err, ErrLastOwner):
+		return werrors.NewConflictError("cannot deprovision the last owner of the workspace")
+	case err != nil && !errors.Is(err, ErrMembershipNotFound):
+		return err
+	}
+	revoked, err := s.apiKeyService.RevokeAPIKeysCreatedBy(ctx, link.TenantID, link.UserID)
+	if err != nil {
+		return fmt.Errorf("revoke API keys of %s: %w", link.UserID, err)
+	}
+	link.Active = false
+	s.emitAudit(ctx, link.TenantID, types.AuditActionSCIMUserDeprovisioned, link.UserID, map[string]any{
+		"user_name":        link.UserName,
+		"revoked_api_keys": revoked,
+	})
+	logger.Infof(ctx, "SCIM deprovisioned user %s in tenant %d, revoked %d API keys",
+		link.UserID, link.TenantID, revoked)
+	return nil
+}
+
+// ensureMembership makes the user an active member. An existing member is
+// moved to role when one was requested; Owners are never changed, and a
+// member whose custom role already has the requested base role keeps it.
+func (s *scimService) ensureMembership(
+	ctx context.Context, tenantID uint64, userID string, role *types.TenantRole,
+) error {
+	member, err := s.memberService.GetMembership(ctx, userID, tenantID)
+	if err != nil {
+		return err
+	}
+	if member == nil {
+		initial := types.TenantRoleViewer
+		if role != nil {
+			initial = *role
+		}
+		_, err := s.memberService.AddMember(ctx, userID, tenantID, initial, nil)
+		if errors.Is(err, ErrMembershipAlreadyExists) {
+			return nil
+		}
+		return err
+	}
+	if role == nil || member.Role == types.TenantRoleOwner || member.Role == *role {
+		return nil
+	}
+	return s.memberService.UpdateRole(ctx, userID, tenantID, *role)
+}
+
+// createAccount creates the login-less account of a new SCIM user. It has
+// no workspace of its own and a random password; the user signs in through
+// OIDC or LDAP.
+func (s *scimService) createAccount(ctx context.Context, in *types.SCIMUser, email string) (*types.User, error) {
+	password, err := generateRandomString(32)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate password for SCIM user: %w", err)
+	}
+	user, err := s.userService.Register(ctx, &types.RegisterRequest{
+		Username:           s.freeUsername(ctx, in, email),
+		Email:              email,
+		Password:           password,
+		TenantProvisioning: types.TenantProvisioningTenantless,
+	})
+	if err != nil {
+		return nil, werrors.NewBadRequestError("failed to create user: " + err.Error())
+	}
+	oidcOnly := true
+	user.Preferences.OidcOnlyLogin = &oidcOnly
+	user.UpdatedAt = time.Now()
+	if err := s.userService.UpdateUser(ctx, user); err != nil {
+		return nil, fmt.Errorf("failed to mark SCIM user login-less: %w", err)
+	}
+	return user, nil
+}
+
+// freeUsername derives an unused local username from the SCIM userName,
+// falling back to the e-mail's local part.
+func (s *scimService) freeUsername(ctx context.Context, in *types.SCIMUser, email string) string {
+	base := sanitizeUsernameCandidate(strings.Split(in.UserName, "@")[0])
+	if base == "" {
+		base = sanitizeUsernameCandidate(strings.Split(email, "@")[0])
+	}
+	if base == "" {
+		base = "scim-user"
+	}
+	candidate := base
+	for i := 0; i < 20; i++ {
+		if existing, err := s.userService.GetUserByUsername(ctx, candidate); existing == nil || isUserLookupNotFound(err) {
+			return candidate
+		}
+		candidate = fmt.Sprintf("%s-%d", base, i+1)
+	}
+	return fmt.Sprintf("%s-%d", base, time.Now().Unix())
+}
+
+func (s *scimService) ensureUserNameFree(ctx context.Context, tenantID uint64, userName, selfID string) error {
+	existing, err := s.linkRepo.GetByUserName(ctx, tenantID, userName)
+	if err != nil {
+		return err
+	}
+	if existing != nil && existing.UserID != selfID {
+		return werrors.NewConflictError("userName already exists: " + userName)
+	}
+	return nil
+}
+
+func (s *scimService) getLink(ctx context.Context, tenantID uint64, id string) (*types.TenantSCIMUser, error) {
+	link, err := s.linkRepo.Get(ctx, tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+	if link == nil {
+		return nil, werrors.NewNotFoundError("user not found: " + id)
+	}
+	return link, nil
+}
+
+func (s *scimService) userResource(
+	ctx context.Context, tenantID uint64, link *types.TenantSCIMUser,
+) (*types.SCIMUser, error) {
+	user, err := s.userService.GetUserByID(ctx, link.UserID)
+	if err != nil && !isUserLookupNotFound(err) {
+		return nil, err
+	}
+	member, err := s.memberService.GetMembership(ctx, link.UserID, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	return scimUserResource(link, user, member), nil
+}
+
+func (s *scimService) emitAudit(
+	ctx context.Context, tenantID uint64, action types.AuditAction, userID string, details map[string]any,
+) {
+	if s.audit == nil {
+		return
+	}
+	raw, _ := json.Marshal(details)
+	actorID, actorRole := types.AuditActorFromContext(ctx)
+	_ = s.audit.Log(ctx, &types.AuditLog{
+		TenantID:     tenantID,
+		ActorUserID:  actorID,
+		ActorRole:    actorRole,
+		Action:       action,
+		TargetType:   "user",
+		TargetUserID: userID,
+		Outcome:      types.AuditOutcomeSuccess,
+		Details:      types.JSON(raw),
+	})
+}
+
+func (s *scimService) ListGroups(
+	ctx context.Context, tenantID uint64, query *types.SCIMListQuery,
+) (*types.SCIMListResponse, error) {
+	ctx = scimTenantContext(ctx, tenantID)
+	var match func(*types.UserGroup) bool
+	switch strings.ToLower(query.FilterAttribute) {
+	case "":
+	case "displayname":
+		match = func(g *types.UserGroup) bool { return g.Name == query.FilterValue }
+	case "externalid":
+		match = func(g *types.UserGroup) bool { return g.ExternalID == query.FilterValue }
+	default:
+		return nil, werrors.NewBadRequestError("unsupported filter attribute: " + query.FilterAttribute)
+	}
+	groups, err := s.aclService.ListUserGroups(ctx)
+	if err != nil {
+		return nil, err
+	}
+	matched := make([]*types.UserGroup, 0, len(groups))
+	for _, g := range groups {
+		if match == nil || match(g) {
+			matched = append(matched, g)
+		}
+	}
+	sort.Slice(matched, func(i, j int) bool { return matched[i].CreatedAt.Before(matched[j].CreatedAt) })
+
+	startIndex, count := scimPage(query)
+	resources := []any{}
+	for i := startIndex - 1; i < len(matched) && len(resources) < count; i++ {
+		detail, err := s.aclService.GetUserGroup(ctx, matched[i].ID)
+		if err != nil {
+			return nil, err
+		}
+		group, err := s.groupResource(ctx, tenantID, detail)
+		if err != nil {
+			return nil, err
+		}
+		resources = append(resources, group)
+	}
+	return scimListResponse(resources, len(matched), startIndex), nil
+}
+
+func (s *scimService) GetGroup(ctx context.Context, tenantID uint64, id string) (*types.SCIMGroup, error) {
+	detail, err := s.aclService.GetUserGroup(scimTenantContext(ctx, tenantID), id)
+	if err != nil {
+		return nil, err
+	}
+	return s.groupResource(ctx, tenantID, detail)
+}
+
+// CreateGroup provisions a SCIM group as a user group of the workspace and,
+// with the organization extension, as an organization.
+func (s *scimService) CreateGroup(
+	ctx context.Context, tenantID uint64, in *types.SCIMGroup,
+) (*types.SCIMGroup, error) {
+	ctx = scimTenantContext(ctx, tenantID)
+	org, err := scimOrgStateFrom(in.Organization)
+	if err != nil {
+		return nil, err
+	}
+	members, err := s.groupMemberIDs(ctx, tenantID, in.Members)
+	if err != nil {
+		return nil, err
+	}
+	detail, err := s.aclService.CreateUserGroup(ctx, &types.UserGroupRequest{
+		Name:          in.DisplayName,
+		ExternalID:    in.ExternalID,
+		MemberUserIDs: members,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := s.syncOrganization(ctx, tenantID, detail, org); err != nil {
+		// Undo the group so the provider's retry of the create succeeds.
+		if cleanupErr := s.deleteGroup(ctx, tenantID, detail.ID); cleanupErr != nil {
+			logger.Warnf(ctx, "[SCIM] Failed to remove group %s after a failed create: %v", detail.ID, cleanupErr)
+		}
+		return nil, err
+	}
+	return s.groupResource(ctx, tenantID, detail)
+}
+
+func (s *scimService) ReplaceGroup(
+	ctx context.Context, tenantID uint64, id string, in *types.SCIMGroup,
+) (*types.SCIMGroup, error) {
+	ctx = scimTenantContext(ctx, tenantID)
+	org, err := scimOrgStateFrom(in.Organization)
+	if err != nil {
+		return nil, err
+	}
+	current, err := s.aclService.GetUserGroup(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	members, err := s.groupMemberIDs(ctx, tenantID, in.Members)
+	if err != nil {
+		return nil, err
+	}
+	detail, err := s.aclService.UpdateUserGroup(ctx, id, &types.UserGroupRequest{
+		Name:          in.DisplayName,
+		Description:   current.Description,
+		ExternalID:    in.ExternalID,
+		MemberUserIDs: members,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := s.syncOrganization(ctx, tenantID, detail, org); err != nil {
+		return nil, err
+	}
+	return s.groupResource(ctx, tenantID, detail)
+}
+
+func (s *scimService) PatchGroup(
+	ctx context.Context, tenantID uint64, id string, patch *types.SCIMPatchRequest,
+) (*types.SCIMGroup, error) {
+	ctx = scimTenantContext(ctx, tenantID)
+	current, err := s.aclService.GetUserGroup(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	req := &types.UserGroupRequest{
+		Name:          current.Name,
+		Description:   current.Description,
+		ExternalID:    current.ExternalID,
+		MemberUserIDs: make([]string, 0, len(current.Members)),
+	}
+	for _, m := range current.Members {
+		req.MemberUserIDs = append(req.MemberUserIDs, m.UserID)
+	}
+	org, err := s.orgState(ctx, tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+	added := map[string]bool{}
+	for _, op := range patch.Operations {
+		if err := patchGroupRequest(req, &org, op, added); err != nil {
+			return nil, err
+		}
+	}
+	if len(added) > 0 {
+		ids := make([]string, 0, len(added))
+		for id := range added {
+			ids = append(ids, id)
+		}
+		if err := s.ensureLinked(ctx, tenantID, ids); err != nil {
+			return nil, err
+		}
+	}
+	detail, err := s.aclService.UpdateUserGroup(ctx, id, req)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.syncOrganization(ctx, tenantID, detail, org); err != nil {
+		return nil, err
+	}
+	return s.groupResource(ctx, tenantID, detail)
+}
+
+func (s *scimService) DeleteGroup(ctx context.Context, tenantID uint64, id string) error {
+	return s.deleteGroup(scimTenantContext(ctx, tenantID), tenantID, id)
+}
+
+// deleteGroup deletes a group together with its organization.
+func (s *scimService) deleteGroup(ctx context.Context, tenantID uint64, id string) error {
+	link, err := s.orgLinkRepo.Get(ctx, tenantID, id)
+	if err != nil {
+		return err
+	}
+	if link != nil {
+		if err := s.removeOrganization(ctx, tenantID, link); err != nil {
+			return err
+		}
+	}
+	return s.aclService.DeleteUserGroup(ctx, id)
+}
+
+// scimOrgState is the organization extension of a group as this service
+// stores it.
+type scimOrgState struct {
+	Enabled bool
+	Role    types.OrgMemberRole
+}
+
+// orgState returns the stored organization state of a group.
+func (s *scimService) orgState(ctx context.Context, tenantID uint64, groupID string) (scimOrgState, error) {
+	link, err := s.orgLinkRepo.Get(ctx, tenantID, groupID)
+	if err != nil || link == nil {
+		return scimOrgState{}, err
+	}
+	return scimOrgState{Enabled: true, Role: link.Role}, nil
+}
+
+// syncOrganization provisions, renames or removes the organization of a
+// group so that it matches state.
+//
+// The organization is owned by the workspace, like one created in the
+// product by its members. Organization members are workspaces rather than
+// users, so a group member joins through the workspace their account
+// belongs to, with the member as its representative, as inviting a user to
+// an organization does. Members without a workspace of their own (accounts
+// the SCIM endpoint created) and members whose workspace is this one reach
+// the organization through the owning workspace. The identity provider owns
+// the member list: workspaces that no member leads to are removed, and all
+// others get the group's role.
+func (s *scimService) syncOrganization(
+	ctx context.Context, tenantID uint64, detail *types.UserGroupDetail, state scimOrgState,
+) error {
+	link, err := s.orgLinkRepo.Get(ctx, tenantID, detail.ID)
+	if err != nil {
+		return err
+	}
+	if !state.Enabled {
+		if link == nil {
+			return nil
+		}
+		return s.removeOrganization(ctx, tenantID, link)
+	}
+
+	var org *types.Organization
+	if link != nil {
+		org, err = s.orgService.GetOrganization(ctx, link.OrganizationID)
+		if err != nil && !errors.Is(err, ErrOrgNotFound) {
+			return err
+		}
+	}
+	if org == nil {
+		// First provisioning, or the organization was deleted in the product
+		// while the provider still manages it.
+		owner, err := s.workspaceOwner(ctx, tenantID)
+		if err != nil {
+			return err
+		}
+		unlimited := 0
+		org, err = s.orgService.CreateOrganization(ctx, owner, tenantID, &types.CreateOrganizationRequest{
+			Name:        detail.Name,
+			MemberLimit: &unlimited,
+		})
+		if err != nil {
+			return err
+		}
+	} else if org.Name != detail.Name {
+		name := detail.Name
+		if _, err := s.orgService.UpdateOrganization(ctx, org.ID, "", tenantID, &types.UpdateOrganizationRequest{
+			Name: &name,
+		}); err != nil {
+			return err
+		}
+	}
+	if link == nil {
+		link = &types.TenantSCIMOrganization{TenantID: tenantID, GroupID: detail.ID}
+	}
+	link.OrganizationID, link.Role = org.ID, state.Role
+	if err := s.orgLinkRepo.Save(ctx, link); err != nil {
+		return err
+	}
+	return s.syncOrganizationMembers(ctx, tenantID, org.ID, detail.Members, state.Role)
+}
+
+// syncOrganizationMembers makes the organization's member workspaces, other
+// than the owning one, those the group's members lead to.
+func (s *scimService) syncOrganizationMembers(
+	ctx context.Context, tenantID uint64, orgID string, members []*types.UserGroupMemberInfo, role types.OrgMemberRole,
+) error {
+	want := map[uint64]string{}
+	for _, m := range members {
+		user, err := s.userService.GetUserByID(ctx, m.UserID)
+		if err != nil {
+			return err
+		}
+		if user == nil || user.TenantID == 0 || user.TenantID == tenantID {
+			continue
+		}
+		if _, ok := want[user.TenantID]; !ok {
+			want[user.TenantID] = user.ID
+		}
+	}
+
+	current, err := s.orgService.ListTenantMembers(ctx, orgID)
+	if err != nil {
+		return err
+	}
+	for _, m := range current {
+		if m.TenantID == tenantID {
+			continue
+		}
+		if _, ok := want[m.TenantID]; !ok {
+			if err := s.orgService.RemoveTenantMember(ctx, orgID, m.TenantID, "", tenantID); err != nil {
+				return err
+			}
+			continue
+		}
+		delete(want, m.TenantID)
+		if m.Role != role {
+			if err := s.orgService.UpdateTenantMemberRole(ctx, orgID, m.TenantID, role, "", tenantID); err != nil {
+				return err
+			}
+		}
+	}
+
+	joining := make([]uint64, 0, len(want))
+	for id := range want {
+		joining = append(joining, id)
+	}
+	sort.Slice(joining, func(i, j int) bool { return joining[i] < joining[j] })
+	for _, memberTenantID := range joining {
+		err := s.orgService.AddTenantMember(ctx, orgID, memberTenantID, want[memberTenantID], role)
+		if errors.Is(err, ErrOrgMemberLimitReached) {
+			return werrors.NewConflictError("organization member limit reached")
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// removeOrganization deletes the organization of a group, with the content
+// shared into it, and forgets the link.
+func (s *scimService) removeOrganization(
+	ctx context.Context, tenantID uint64, link *types.TenantSCIMOrganization,
+) error {
+	err := s.orgService.DeleteOrganization(ctx, link.OrganizationID, "", tenantID)
+	if err != nil && !errors.Is(err, ErrOrgNotFound) && !errors.Is(err, repository.ErrOrganizationNotFound) {
+		return err
+	}
+	return s.orgLinkRepo.Delete(ctx, tenantID, link.GroupID)
+}
+
+// workspaceOwner returns the earliest owner of the workspace, recorded as
+// the owner of the organizations its SCIM endpoint creates.
+func (s *scimService) workspaceOwner(ctx context.Context, tenantID uint64) (string, error) {
+	members, err := s.memberService.ListByTenant(ctx, tenantID)
+	if err != nil {
+		return "", err
+	}
+	for _, m := range members {
+		if m.Role == types.TenantRoleOwner {
+			return m.UserID, nil
+		}
+	}
+	return "", werrors.NewConflictError("the workspace has no owner to own the organization")
+}
+
+// groupResource renders a group with its organization extension.
+func (s *scimService) groupResource(
+	ctx context.Context, tenantID uint64, detail *types.UserGroupDetail,
+) (*types.SCIMGroup, error) {
+	out := scimGroupResource(detail)
+	link, err := s.orgLinkRepo.Get(ctx, tenantID, detail.ID)
+	if err != nil {
+		return nil, err
+	}
+	if link != nil {
+		out.Schemas = append(out.Schemas, types.SCIMSchemaOrganizationGroup)
+		out.Organization = &types.SCIMOrganizationExtension{
+			Organization:   true,
+			Role:           string(link.Role),
+			OrganizationID: link.OrganizationID,
+		}
+	}
+	return out, nil
+}
+
+// groupMemberIDs returns the user IDs of members, which must all be SCIM
+// users of the workspace.
+func (s *scimService) groupMemberIDs(
+	ctx context.Context, tenantID uint64, members []types.SCIMMultiValue,
+) ([]string, error) {
+	ids := make([]string, 0, len(members))
+	for _, m := range members {
+		if v := strings.TrimSpace(m.Value); v != "" {
+			ids = append(ids, v)
+		}
+	}
+	if err := s.ensureLinked(ctx, tenantID, ids); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// ensureLinked rejects user IDs the workspace's SCIM endpoint did not
+// provision, so a group can only reference users the provider manages.
+func (s *scimService) ensureLinked(ctx context.Context, tenantID uint64, ids []string) error {
+	for _, id := range ids {
+		link, err := s.linkRepo.Get(ctx, tenantID, id)
+		if err != nil {
+			return err
+		}
+		if link == nil {
+			return werrors.NewBadRequestError("unknown group member: " + id)
+		}
+	}
+	return nil
+}
+
+// scimTenantContext scopes ctx to tenantID for the tenant-from-context user
+// group service.
+func scimTenantContext(ctx context.Context, tenantID uint64) context.Context {
+	return context.WithValue(ctx, types.TenantIDContextKey, tenantID)
+}
+
+// scimPage returns the 1-based start index and the page size of query.
+func scimPage(query *types.SCIMListQuery) (int, int) {
+	startIndex, count := query.StartIndex, query.Count
+	if startIndex < 1 {
+		startIndex = 1
+	}
+	if count <= 0 {
+		count = scimDefaultPageSize
+	}
+	if count > types.SCIMMaxPageSize {
+		count = types.SCIMMaxPageSize
+	}
+	return startIndex, count
+}
+
+func scimListResponse(resources []any, total, startIndex int) *types.SCIMListResponse {
+	return &types.SCIMListResponse{
+		Schemas:      []string{types.SCIMSchemaListResponse},
+		TotalResults: total,
+		StartIndex:   startIndex,
+		ItemsPerPage: len(resources),
+		Resources:    resources,
+	}
+}
+
+func scimUserResource(link *types.TenantSCIMUser, user *types.User, member *types.TenantMember) *types.SCIMUser {
+	active := link.Active
+	created, modified := link.CreatedAt, link.UpdatedAt
+	out := &types.SCIMUser{
+		Schemas:    []string{types.SCIMSchemaUser},
+		ID:         link.UserID,
+		ExternalID: link.ExternalID,
+		UserName:   link.UserName,
+		Active:     &active,
+		Meta: &types.SCIMMeta{
+			ResourceType: "User",
+			Created:      &created,
+			LastModified: &modified,
+			Location:     scimBasePath + "/Users/" + link.UserID,
+		},
+	}
+	if user != nil {
+		out.DisplayName = user.Username
+		out.Emails = []types.SCIMMultiValue{{Value: user.Email, Type: "work", Primary: true}}
+	}
+	if member != nil {
+		out.Roles = []types.SCIMMultiValue{{Value: string(member.Role), Primary: true}}
+	}
+	return out
+}
+
+func scimGroupResource(detail *types.UserGroupDetail) *types.SCIMGroup {
+	created, modified := detail.CreatedAt, detail.UpdatedAt
+	out := &types.SCIMGroup{
+		Schemas:     []string{types.SCIMSchemaGroup},
+		ID:          detail.ID,
+		ExternalID:  detail.ExternalID,
+		DisplayName: detail.Name,
+		Members:     make([]types.SCIMMultiValue, 0, len(detail.Members)),
+		Meta: &types.SCIMMeta{
+			ResourceType: "Group",
+			Created:      &created,
+			LastModified: &modified,
+			Location:     scimBasePath + "/Groups/" + detail.ID,
+		},
+	}
+	for _, m := range detail.Members {
+		out.Members = append(out.Members, types.SCIMMultiValue{
+			Value:   m.UserID,
+			Display: m.Username,
+			Ref:     scimBasePath + "/Users/" + m.UserID,
+		})
+	}
+	return out
+}
+
+func scimUserStateFrom(in *types.SCIMUser) (scimUserState, error) {
+	state := scimUserState{
+		UserName:   strings.TrimSpace(in.UserName),
+		ExternalID: strings.TrimSpace(in.ExternalID),
+		Active:     in.IsActive(),
+	}
+	if state.UserName == "" {
+		return state, werrors.NewBadRequestError("userName is required")
+	}
+	role, err := scimRole(in.Roles)
+	if err != nil {
+		return state, err
+	}
+	state.Role = role
+	return state, nil
+}
+
+// scimRole returns the workspace role named by roles (the primary entry, or
+// the first), or nil when roles is empty. Owner cannot be granted through
+// SCIM, matching the API key rule for member management.
+func scimRole(roles []types.SCIMMultiValue) (*types.TenantRole, error) {
+	if len(roles) == 0 {
+		return nil, nil
+	}
+	pick := roles[0]
+	for _, r := range roles {
+		if r.Primary {
+			pick = r
+			break
+		}
+	}
+	role := types.TenantRole(strings.ToLower(strings.TrimSpace(pick.Value)))
+	if !role.IsValid() || role == types.TenantRoleOwner {
+		return nil, werrors.NewBadRequestError(
+			"role must be one of admin, contributor or viewer, got " + pick.Value)
+	}
+	return &role, nil
+}
+
+// patchUserState applies one PATCH operation to state. Attributes this
+// service does not store (name, emails, displayName, ...) are ignored, as
+// identity providers routinely send them along.
+func patchUserState(state *scimUserState, op types.SCIMPatchOperation) error {
+	opName := strings.ToLower(op.Op)
+	if opName != "add" && opName != "replace" && opName != "remove" {
+		return werrors.NewBadRequestError("unsupported patch op: " + op.Op)
+	}
+	if op.Path == "" {
+		if opName == "remove" {
+			return werrors.NewBadRequestError("remove requires a path")
+		}
+		var attrs map[string]json.RawMessage
+		if err := json.Unmarshal(op.Value, &attrs); err != nil {
+			return werrors.NewBadRequestError("patch value must be an object when path is omitted")
+		}
+		for name, value := range attrs {
+			if err := patchUserAttribute(state, opName, name, value); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return patchUserAttribute(state, opName, op.Path, op.Value)
+}
+
+func patchUserAttribute(state *scimUserState, op, attribute string, value json.RawMessage) error {
+	switch strings.ToLower(attribute) {
+	case "active":
+		if op == "remove" {
+			return werrors.NewBadRequestError("active cannot be removed")
+		}
+		active, err := scimBool(value)
+		if err != nil {
+			return err
+		}
+		state.Active = active
+	case "username":
+		var v string
+		if op == "remove" || json.Unmarshal(value, &v) != nil || strings.TrimSpace(v) == "" {
+			return werrors.NewBadRequestError("userName must be a non-empty string")
+		}
+		state.UserName = strings.TrimSpace(v)
+	case "externalid":
+		var v string
+		if op != "remove" {
+			if err := json.Unmarshal(value, &v); err != nil {
+				return werrors.NewBadRequestError("externalId must be a string")
+			}
+		}
+		state.ExternalID = strings.TrimSpace(v)
+	case "roles":
+		if op == "remove" {
+			return nil
+		}
+		var roles []types.SCIMMultiValue
+		if err := json.Unmarshal(value, &roles); err != nil {
+			return werrors.NewBadRequestError("roles must be an array")
+		}
+		role, err := scimRole(roles)
+		if err != nil {
+			return err
+		}
+		state.Role = role
+	}
+	return nil
+}
+
+// scimBool accepts a JSON boolean or the strings "true" / "false", which
+// some identity providers send for active.
+func scimBool(value json.RawMessage) (bool, error) {
+	var b bool
+	if err := json.Unmarshal(value, &b); err == nil {
+		return b, nil
+	}
+	var str string
+	if err := json.Unmarshal(value, &str); err == nil {
+		switch strings.ToLower(strings.TrimSpace(str)) {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		}
+	}
+	return false, werrors.NewBadRequestError("active must be a boolean")
+}
+
+// scimOrgStateFrom reads the organization extension of a POST or PUT; a
+// missing extension turns the organization off.
+func scimOrgStateFrom(ext *types.SCIMOrganizationExtension) (scimOrgState, error) {
+	if ext == nil || !ext.Organization {
+		return scimOrgState{}, nil
+	}
+	role, err := scimOrgRole(ext.Role)
+	if err != nil {
+		return scimOrgState{}, err
+	}
+	return scimOrgState{Enabled: true, Role: role}, nil
+}
+
+// scimOrgRole parses the organization role of a group; empty means viewer.
+func scimOrgRole(value string) (types.OrgMemberRole, error) {
+	role := types.OrgMemberRole(strings.ToLower(strings.TrimSpace(value)))
+	if role == "" {
+		return types.OrgRoleViewer, nil
+	}
+	if !role.IsValid() {
+		return "", werrors.NewBadRequestError("organization role must be viewer, editor or admin")
+	}
+	return role, nil
+}
+
+// patchOrgState applies one PATCH operation on the organization extension.
+// attribute is the lower-cased part of the path after the schema URN.
+func patchOrgState(state *scimOrgState, opName, attribute string, value json.RawMessage) error {
+	switch attribute {
+	case "":
+		if opName == "remove" {
+			*state = scimOrgState{}
+			return nil
+		}
+		var attrs map[string]json.RawMessage
+		if err := json.Unmarshal(value, &attrs); err != nil {
+			return werrors.NewBadRequestError("the organization extension must be an object")
+		}
+		for name, v := range attrs {
+			if err := patchOrgState(state, opName, strings.ToLower(name), v); err != nil {
+				return err
+			}
+		}
+	case "organization":
+		enabled := false
+		if opName != "remove" {
+			b, err := scimBool(value)
+			if err != nil {
+				return werrors.NewBadRequestError("organization must be a boolean")
+			}
+			enabled = b
+		}
+		state.Enabled = enabled
+		if state.Role == "" {
+			state.Role = types.OrgRoleViewer
+		}
+	case "role":
+		var v string
+		if opName != "remove" {
+			if err := json.Unmarshal(value, &v); err != nil {
+				return werrors.NewBadRequestError("organization role must be a string")
+			}
+		}
+		role, err := scimOrgRole(v)
+		if err != nil {
+			return err
+		}
+		state.Role = role
+	case "organizationid":
+		// Read-only; providers echo it back.
+	default:
+		return werrors.NewBadRequestError("unsupported organization attribute: " + attribute)
+	}
+	return nil
+}
+
+// patchGroupRequest applies one PATCH operation to req and org. added
+// collects the user IDs the operation adds so the caller can check them.
+func patchGroupRequest(
+	req *types.UserGroupRequest, org *scimOrgState, op types.SCIMPatchOperation, added map[string]bool,
+) error {
+	opName := strings.ToLower(op.Op)
+	path := strings.TrimSpace(op.Path)
+	lowerPath := strings.ToLower(path)
+
+	switch {
+	case path == "":
+		if opName == "remove" {
+			return werrors.NewBadRequestError("remove requires a path")
+		}
+		var attrs map[string]json.RawMessage
+		if err := json.Unmarshal(op.Value, &attrs); err != nil {
+			return werrors.NewBadRequestError("patch value must be an object when path is omitted")
+		}
+		for name, value := range attrs {
+			if err := patchGroupRequest(req, org, types.SCIMPatchOperation{Op: op.Op, Path: name, Value: value}, added); err != nil {
+				return err
+			}
+		}
+		return nil
+	case lowerPath == scimOrgSchema || strings.HasPrefix(lowerPath, scimOrgSchema+":"):
+		attribute := strings.TrimPrefix(strings.TrimPrefix(lowerPath, scimOrgSchema), ":")
+		return patchOrgState(org, opName, attribute, op.Value)
+	case lowerPath == "displayname":
+		var v string
+		if opName == "remove" || json.Unmarshal(op.Value, &v) != nil || strings.TrimSpace(v) == "" {
+			return werrors.NewBadRequestError("displayName must be a non-empty string")
+		}
+		req.Name = strings.TrimSpace(v)
+	case lowerPath == "externalid":
+		var v string
+		if opName != "remove" {
+			if err := json.Unmarshal(op.Value, &v); err != nil {
+				return werrors.NewBadRequestError("externalId must be a string")
+			}
+		}
+		req.ExternalID = strings.TrimSpace(v)
+	case lowerPath == "members":
+		var members []types.SCIMMultiValue
+		if len(op.Value) > 0 {
+			if err := json.Unmarshal(op.Value, &members); err != nil {
+				return werrors.NewBadRequestError("members must be an array")
+			}
+		}
+		ids := make([]string, 0, len(members))
+		for _, m := range members {
+			if v := strings.TrimSpace(m.Value); v != "" {
+				ids = append(ids, v)
+			}
+		}
+		switch opName {
+		case "add":
+			for _, id := range ids {
+				added[id] = true
+			}
+			req.MemberUserIDs = mergeUniqueStrings(req.MemberUserIDs, ids)
+		case "replace":
+			for _, id := range ids {
+				added[id] = true
+			}
+			req.MemberUserIDs = mergeUniqueStrings(nil, ids)
+		case "remove":
+			if len(op.Value) == 0 {
+				req.MemberUserIDs = []string{}
+			} else {
+				req.MemberUserIDs = removeStrings(req.MemberUserIDs, ids)
+			}
+		default:
+			return werrors.NewBadRequestError("unsupported patch op: " + op.Op)
+		}
+	case strings.HasPrefix(lowerPath, "members["):
+		// members[value eq "<id>"], the form providers use to remove one member.
+		if opName != "remove" {
+			return werrors.NewBadRequestError("unsupported patch path: " + path)
+		}
+		inner := strings.TrimSuffix(path[len("members["):], "]")
+		attr, value, ok := types.ParseSCIMFilter(inner)
+		if !ok || !strings.EqualFold(attr, "value") {
+			return werrors.NewBadRequestError("unsupported patch path: " + path)
+		}
+		req.MemberUserIDs = removeStrings(req.MemberUserIDs, []string{value})
+	}
+	return nil
+}
+
+// scimOrgSchema is the lower-cased organization extension URN, the prefix
+// of its PATCH paths.
+var scimOrgSchema = strings.ToLower(types.SCIMSchemaOrganizationGroup)
+
+func removeStrings(list, remove []string) []string {
+	drop := make(map[string]bool, len(remove))
+	for _, v := range remove {
+		drop[v] = true
+	}
+	out := make([]string, 0, len(list))
+	for _, v := range list {
+		if !drop[v] {
+			out = append(out, v)
+		}
+	}
+	return out
+}
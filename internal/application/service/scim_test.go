@@ -0,0 +1,427 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/application/repository"
+	werrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+type fakeSCIMLinkRepo struct {
+	links map[string]*types.TenantSCIMUser
+}
+
+func (r *fakeSCIMLinkRepo) List(
+	context.Context, uint64, string, string, int, int,
+) ([]*types.TenantSCIMUser, int64, error) {
+	return nil, 0, nil
+}
+
+func (r *fakeSCIMLinkRepo) Get(_ context.Context, tenantID uint64, userID string) (*types.TenantSCIMUser, error) {
+	if link, ok := r.links[userID]; ok && link.TenantID == tenantID {
+		cp := *link
+		return &cp, nil
+	}
+	return nil, nil
+}
+
+func (r *fakeSCIMLinkRepo) GetByUserName(_ context.Context, tenantID uint64, userName string) (*types.TenantSCIMUser, error) {
+	for _, link := range r.links {
+		if link.TenantID == tenantID && link.UserName == userName {
+			cp := *link
+			return &cp, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *fakeSCIMLinkRepo) Save(_ context.Context, link *types.TenantSCIMUser) error {
+	cp := *link
+	r.links[link.UserID] = &cp
+	return nil
+}
+
+func (r *fakeSCIMLinkRepo) Delete(_ context.Context, _ uint64, userID string) error {
+	delete(r.links, userID)
+	return nil
+}
+
+var _ interfaces.TenantSCIMUserRepository = (*fakeSCIMLinkRepo)(nil)
+
+// scimTestUserService serves the account lookups the SCIM resources need.
+type scimTestUserService struct {
+	interfaces.UserService
+}
+
+func (scimTestUserService) GetUserByID(_ context.Context, id string) (*types.User, error) {
+	return &types.User{ID: id, Username: id, Email: id + "@example.com", IsActive: true}, nil
+}
+
+func newTestSCIMService(t *testing.T) (*scimService, interfaces.TenantMemberService, *fakeTenantAPIKeyRepo, *fakeSCIMLinkRepo) {
+	t.Helper()
+	members, _ := newServiceWithRepo()
+	keys := newFakeTenantAPIKeyRepo()
+	links := &fakeSCIMLinkRepo{links: map[string]*types.TenantSCIMUser{}}
+	svc := NewSCIMService(links, scimTestUserService{}, members, NewTenantAPIKeyService(keys), nil, nil, nil, nil)
+	return svc.(*scimService), members, keys, links
+}
+
+func seedSCIMKey(t *testing.T, repo *fakeTenantAPIKeyRepo, tenantID uint64, hash, createdBy string) {
+	t.Helper()
+	if err := repo.CreateAPIKey(context.Background(), &types.TenantAPIKey{
+		TenantID: &tenantID, Name: hash, KeyHash: hash, CreatedBy: createdBy,
+	}); err != nil {
+		t.Fatalf("seed key: %v", err)
+	}
+}
+
+func TestSCIMPatchActiveFalseDeprovisionsUser(t *testing.T) {
+	ctx := context.Background()
+	svc, members, keys, links := newTestSCIMService(t)
+	if _, err := members.AddMember(ctx, "owner", 7, types.TenantRoleOwner, nil); err != nil {
+		t.Fatalf("seed owner: %v", err)
+	}
+	if _, err := members.AddMember(ctx, "alice", 7, types.TenantRoleAdmin, nil); err != nil {
+		t.Fatalf("seed member: %v", err)
+	}
+	links.links["alice"] = &types.TenantSCIMUser{TenantID: 7, UserID: "alice", UserName: "alice", Active: true}
+	seedSCIMKey(t, keys, 7, "alice-key", "alice")
+	seedSCIMKey(t, keys, 8, "alice-other-tenant", "alice")
+	seedSCIMKey(t, keys, 7, "owner-key", "owner")
+
+	// Azure AD sends the boolean as a capitalised string.
+	out, err := svc.PatchUser(ctx, 7, "alice", &types.SCIMPatchRequest{Operations: []types.SCIMPatchOperation{
+		{Op: "Replace", Path: "active", Value: json.RawMessage(`"False"`)},
+	}})
+	if err != nil {
+		t.Fatalf("PatchUser: %v", err)
+	}
+	if out.Active == nil || *out.Active {
+		t.Fatal("patched user is still active")
+	}
+	if m, _ := members.GetMembership(ctx, "alice", 7); m != nil {
+		t.Fatalf("membership still present: %+v", m)
+	}
+	if links.links["alice"].Active {
+		t.Fatal("SCIM link still active")
+	}
+	for hash, revoked := range map[string]bool{"alice-key": true, "alice-other-tenant": false, "owner-key": false} {
+		if got := keys.byHash[hash].RevokedAt != nil; got != revoked {
+			t.Fatalf("key %s revoked = %v, want %v", hash, got, revoked)
+		}
+	}
+
+	// Re-activating restores the membership with the default role.
+	out, err = svc.PatchUser(ctx, 7, "alice", &types.SCIMPatchRequest{Operations: []types.SCIMPatchOperation{
+		{Op: "replace", Value: json.RawMessage(`{"active":true}`)},
+	}})
+	if err != nil {
+		t.Fatalf("reactivate: %v", err)
+	}
+	m, _ := members.GetMembership(ctx, "alice", 7)
+	if out.Active == nil || !*out.Active || m == nil || m.Role != types.TenantRoleViewer {
+		t.Fatalf("reactivated user = %+v, membership = %+v", out, m)
+	}
+}
+
+func TestSCIMDeleteUserRefusesLastOwner(t *testing.T) {
+	ctx := context.Background()
+	svc, members, _, links := newTestSCIMService(t)
+	if _, err := members.AddMember(ctx, "owner", 7, types.TenantRoleOwner, nil); err != nil {
+		t.Fatalf("seed owner: %v", err)
+	}
+	links.links["owner"] = &types.TenantSCIMUser{TenantID: 7, UserID: "owner", UserName: "owner", Active: true}
+
+	err := svc.DeleteUser(ctx, 7, "owner")
+	var appErr *werrors.AppError
+	if !errors.As(err, &appErr) || appErr.HTTPCode != http.StatusConflict {
+		t.Fatalf("DeleteUser(last owner) error = %v, want 409", err)
+	}
+	if _, ok := links.links["owner"]; !ok {
+		t.Fatal("link removed although deprovisioning failed")
+	}
+}
+
+func TestSCIMGetUserOfOtherTenantIsNotFound(t *testing.T) {
+	svc, _, _, links := newTestSCIMService(t)
+	links.links["alice"] = &types.TenantSCIMUser{TenantID: 7, UserID: "alice", UserName: "alice", Active: true}
+
+	_, err := svc.GetUser(context.Background(), 8, "alice")
+	var appErr *werrors.AppError
+	if !errors.As(err, &appErr) || appErr.HTTPCode != http.StatusNotFound {
+		t.Fatalf("GetUser(other tenant) error = %v, want 404", err)
+	}
+}
+
+func TestPatchGroupRequestMembers(t *testing.T) {
+	req := &types.UserGroupRequest{Name: "eng", MemberUserIDs: []string{"a", "b"}}
+	added := map[string]bool{}
+	ops := []types.SCIMPatchOperation{
+		{Op: "add", Path: "members", Value: json.RawMessage(`[{"value":"c"},{"value":"a"}]`)},
+		{Op: "remove", Path: `members[value eq "b"]`},
+		{Op: "replace", Value: json.RawMessage(`{"displayName":"Engineering"}`)},
+	}
+	for _, op := range ops {
+		if err := patchGroupRequest(req, &scimOrgState{}, op, added); err != nil {
+			t.Fatalf("patchGroupRequest(%+v): %v", op, err)
+		}
+	}
+	if req.Name != "Engineering" {
+		t.Fatalf("name = %q", req.Name)
+	}
+	if !reflect.DeepEqual(req.MemberUserIDs, []string{"a", "c"}) {
+		t.Fatalf("members = %v, want [a c]", req.MemberUserIDs)
+	}
+	if !added["c"] || !added["a"] || added["b"] {
+		t.Fatalf("added = %v", added)
+	}
+
+	if err := patchGroupRequest(req, &scimOrgState{}, types.SCIMPatchOperation{Op: "add", Path: `members[value eq "d"]`}, added); err == nil {
+		t.Fatal("add on a filtered members path succeeded")
+	}
+}
+
+// scimTestHomeUserService resolves accounts with the workspace each belongs
+// to; users it does not know have no workspace of their own.
+type scimTestHomeUserService struct {
+	interfaces.UserService
+	homes map[string]uint64
+}
+
+func (u scimTestHomeUserService) GetUserByID(_ context.Context, id string) (*types.User, error) {
+	return &types.User{ID: id, Username: id, Email: id + "@example.com", TenantID: u.homes[id], IsActive: true}, nil
+}
+
+// scimTestACLService keeps user groups in memory.
+type scimTestACLService struct {
+	interfaces.KnowledgeACLService
+	groups map[string]*types.UserGroupDetail
+	nextID int
+}
+
+func (a *scimTestACLService) detail(id string, req *types.UserGroupRequest) *types.UserGroupDetail {
+	d := &types.UserGroupDetail{UserGroup: &types.UserGroup{ID: id, Name: req.Name, ExternalID: req.ExternalID}}
+	for _, uid := range req.MemberUserIDs {
+		d.Members = append(d.Members, &types.UserGroupMemberInfo{UserID: uid, Username: uid})
+	}
+	a.groups[id] = d
+	return d
+}
+
+func (a *scimTestACLService) CreateUserGroup(_ context.Context, req *types.UserGroupRequest) (*types.UserGroupDetail, error) {
+	a.nextID++
+	return a.detail(fmt.Sprintf("group-%d", a.nextID), req), nil
+}
+
+func (a *scimTestACLService) GetUserGroup(_ context.Context, id string) (*types.UserGroupDetail, error) {
+	if d, ok := a.groups[id]; ok {
+		return d, nil
+	}
+	return nil, werrors.NewNotFoundError("user group not found")
+}
+
+func (a *scimTestACLService) UpdateUserGroup(_ context.Context, id string, req *types.UserGroupRequest) (*types.UserGroupDetail, error) {
+	if _, ok := a.groups[id]; !ok {
+		return nil, werrors.NewNotFoundError("user group not found")
+	}
+	return a.detail(id, req), nil
+}
+
+func (a *scimTestACLService) DeleteUserGroup(_ context.Context, id string) error {
+	delete(a.groups, id)
+	return nil
+}
+
+// scimOrganizationsTestDDL mirrors the organizations table of
+// migrations/sqlite/000000_init.up.sql; the model's timestamp column types
+// do not scan back on SQLite.
+const scimOrganizationsTestDDL = `CREATE TABLE organizations (
+    id VARCHAR(36) PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    owner_id VARCHAR(36) NOT NULL,
+    owner_tenant_id INTEGER NOT NULL DEFAULT 0,
+    invite_code VARCHAR(32),
+    require_approval BOOLEAN DEFAULT 0,
+    invite_code_expires_at DATETIME,
+    invite_code_validity_days SMALLINT NOT NULL DEFAULT 7,
+    avatar VARCHAR(512) DEFAULT '',
+    searchable BOOLEAN NOT NULL DEFAULT 0,
+    member_limit INTEGER NOT NULL DEFAULT 50,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    deleted_at DATETIME
+)`
+
+// newTestSCIMOrgService returns a SCIM service whose organizations are kept
+// by the real organization service on SQLite. Workspace 7 is the SCIM
+// workspace, owned by "owner".
+func newTestSCIMOrgService(t *testing.T, homes map[string]uint64) (*scimService, interfaces.OrganizationService) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{IgnoreRelationshipsWhenMigrating: true})
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	linkDDL, err := os.ReadFile("../../../migrations/sqlite/000022_scim_organizations.up.sql")
+	if err != nil {
+		t.Fatalf("read migration: %v", err)
+	}
+	for _, ddl := range []string{scimOrganizationsTestDDL, string(linkDDL)} {
+		if err := db.Exec(ddl).Error; err != nil {
+			t.Fatalf("create tables: %v", err)
+		}
+	}
+	if err := db.AutoMigrate(&types.User{}, &types.OrganizationTenantMember{}, &types.KnowledgeBaseShare{}, &types.AgentShare{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	orgs := NewOrganizationService(repository.NewOrganizationRepository(db), nil,
+		repository.NewKBShareRepository(db), repository.NewAgentShareRepository(db))
+
+	members, _ := newServiceWithRepo()
+	if _, err := members.AddMember(context.Background(), "owner", 7, types.TenantRoleOwner, nil); err != nil {
+		t.Fatalf("seed owner: %v", err)
+	}
+	links := &fakeSCIMLinkRepo{links: map[string]*types.TenantSCIMUser{}}
+	for id := range homes {
+		links.links[id] = &types.TenantSCIMUser{TenantID: 7, UserID: id, UserName: id, Active: true}
+	}
+	svc := NewSCIMService(links, scimTestHomeUserService{homes: homes}, members, nil,
+		&scimTestACLService{groups: map[string]*types.UserGroupDetail{}}, orgs,
+		repository.NewTenantSCIMOrganizationRepository(db), nil)
+	return svc.(*scimService), orgs
+}
+
+// orgMemberRoles returns the role of every member workspace.
+func orgMemberRoles(t *testing.T, orgs interfaces.OrganizationService, orgID string) map[uint64]types.OrgMemberRole {
+	t.Helper()
+	list, err := orgs.ListTenantMembers(context.Background(), orgID)
+	if err != nil {
+		t.Fatalf("ListTenantMembers: %v", err)
+	}
+	out := map[uint64]types.OrgMemberRole{}
+	for _, m := range list {
+		out[m.TenantID] = m.Role
+	}
+	return out
+}
+
+func TestSCIMGroupProvisionsOrganization(t *testing.T) {
+	ctx := context.Background()
+	// alice and bob belong to workspace 20, carol to 30; dave was created by
+	// SCIM and has no workspace; erin's workspace is the SCIM workspace.
+	svc, orgs := newTestSCIMOrgService(t, map[string]uint64{
+		"alice": 20, "bob": 20, "carol": 30, "dave": 0, "erin": 7,
+	})
+
+	group, err := svc.CreateGroup(ctx, 7, &types.SCIMGroup{
+		DisplayName: "Partners",
+		Members: []types.SCIMMultiValue{
+			{Value: "alice"}, {Value: "bob"}, {Value: "carol"}, {Value: "dave"}, {Value: "erin"},
+		},
+		Organization: &types.SCIMOrganizationExtension{Organization: true, Role: "Editor"},
+	})
+	if err != nil {
+		t.Fatalf("CreateGroup: %v", err)
+	}
+	if group.Organization == nil || group.Organization.OrganizationID == "" || group.Organization.Role != "editor" {
+		t.Fatalf("organization extension = %+v", group.Organization)
+	}
+	orgID := group.Organization.OrganizationID
+	org, err := orgs.GetOrganization(ctx, orgID)
+	if err != nil {
+		t.Fatalf("GetOrganization: %v", err)
+	}
+	if org.Name != "Partners" || org.OwnerTenantID != 7 || org.OwnerID != "owner" {
+		t.Fatalf("organization = %+v", org)
+	}
+	want := map[uint64]types.OrgMemberRole{7: types.OrgRoleAdmin, 20: types.OrgRoleEditor, 30: types.OrgRoleEditor}
+	if got := orgMemberRoles(t, orgs, orgID); !reflect.DeepEqual(got, want) {
+		t.Fatalf("members = %v, want %v", got, want)
+	}
+	rep, err := orgs.GetTenantMember(ctx, orgID, 20)
+	if err != nil || rep.RepresentativeUserID != "alice" {
+		t.Fatalf("workspace 20 member = %+v, %v", rep, err)
+	}
+
+	// Renaming, dropping carol and lowering the role follow through.
+	group, err = svc.PatchGroup(ctx, 7, group.ID, &types.SCIMPatchRequest{Operations: []types.SCIMPatchOperation{
+		{Op: "replace", Path: "displayName", Value: json.RawMessage(`"Partners EU"`)},
+		{Op: "remove", Path: `members[value eq "carol"]`},
+		{Op: "replace", Path: types.SCIMSchemaOrganizationGroup + ":role", Value: json.RawMessage(`"viewer"`)},
+	}})
+	if err != nil {
+		t.Fatalf("PatchGroup: %v", err)
+	}
+	if org, _ := orgs.GetOrganization(ctx, orgID); org == nil || org.Name != "Partners EU" {
+		t.Fatalf("organization after rename = %+v", org)
+	}
+	want = map[uint64]types.OrgMemberRole{7: types.OrgRoleAdmin, 20: types.OrgRoleViewer}
+	if got := orgMemberRoles(t, orgs, orgID); !reflect.DeepEqual(got, want) {
+		t.Fatalf("members after patch = %v, want %v", got, want)
+	}
+
+	// Turning the extension off deprovisions the organization but keeps the
+	// user group.
+	group, err = svc.PatchGroup(ctx, 7, group.ID, &types.SCIMPatchRequest{Operations: []types.SCIMPatchOperation{
+		{Op: "replace", Value: json.RawMessage(`{"` + types.SCIMSchemaOrganizationGroup + `":{"organization":"False"}}`)},
+	}})
+	if err != nil {
+		t.Fatalf("PatchGroup(organization off): %v", err)
+	}
+	if group.Organization != nil {
+		t.Fatalf("organization extension still present: %+v", group.Organization)
+	}
+	if _, err := orgs.GetOrganization(ctx, orgID); !errors.Is(err, ErrOrgNotFound) {
+		t.Fatalf("GetOrganization after deprovisioning error = %v, want not found", err)
+	}
+	if _, err := svc.GetGroup(ctx, 7, group.ID); err != nil {
+		t.Fatalf("GetGroup: %v", err)
+	}
+}
+
+func TestSCIMDeleteGroupDeletesOrganization(t *testing.T) {
+	ctx := context.Background()
+	svc, orgs := newTestSCIMOrgService(t, map[string]uint64{"alice": 20})
+
+	group, err := svc.CreateGroup(ctx, 7, &types.SCIMGroup{
+		DisplayName:  "Partners",
+		Members:      []types.SCIMMultiValue{{Value: "alice"}},
+		Organization: &types.SCIMOrganizationExtension{Organization: true},
+	})
+	if err != nil {
+		t.Fatalf("CreateGroup: %v", err)
+	}
+	if group.Organization == nil || group.Organization.Role != "viewer" {
+		t.Fatalf("organization extension = %+v", group.Organization)
+	}
+	if err := svc.DeleteGroup(ctx, 7, group.ID); err != nil {
+		t.Fatalf("DeleteGroup: %v", err)
+	}
+	if _, err := orgs.GetOrganization(ctx, group.Organization.OrganizationID); !errors.Is(err, ErrOrgNotFound) {
+		t.Fatalf("GetOrganization after DeleteGroup error = %v, want not found", err)
+	}
+
+	// An invalid role is rejected before anything is created.
+	_, err = svc.CreateGroup(ctx, 7, &types.SCIMGroup{
+		DisplayName:  "Bad",
+		Organization: &types.SCIMOrganizationExtension{Organization: true, Role: "owner"},
+	})
+	var appErr *werrors.AppError
+	if !errors.As(err, &appErr) || appErr.HTTPCode != http.StatusBadRequest {
+		t.Fatalf("CreateGroup(role owner) error = %v, want 400", err)
+	}
+	if groups := svc.aclService.(*scimTestACLService).groups; len(groups) != 0 {
+		t.Fatalf("groups left behind: %v", groups)
+	}
+}
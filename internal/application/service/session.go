@@ -128,7 +128,8 @@ type sessionService struct {
 	sandboxResolver       sandbox.TenantSandboxResolver
 	sandboxPinner         *SessionSandboxPinner
 	sandboxPolicy         WorkspaceSandboxPolicy
-	memoryService         interfaces.MemoryService // Service for cross-session long-term memory
+	memoryService         interfaces.MemoryService       // Service for cross-session long-term memory
+	aclService            interfaces.KnowledgeACLService // Document-level ACLs applied to search targets
 }
 
 // NewSessionService creates a new session service instance with all required dependencies
@@ -151,6 +152,7 @@ func NewSessionService(cfg *config.Config,
 	sandboxPinner *SessionSandboxPinner,
 	sandboxPolicy WorkspaceSandboxPolicy,
 	memoryService interfaces.MemoryService,
+	aclService interfaces.KnowledgeACLService,
 ) interfaces.SessionService {
 	return &sessionService{
 		cfg:                   cfg,
@@ -172,6 +174,7 @@ func NewSessionService(cfg *config.Config,
 		sandboxPinner:         sandboxPinner,
 		sandboxPolicy:         sandboxPolicy,
 		memoryService:         memoryService,
+		aclService:            aclService,
 	}
 }
 
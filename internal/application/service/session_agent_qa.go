@@ -188,7 +188,8 @@ func (s *sessionService) AgentQA(
 
 	// Recall long-term memory for this turn. Like the RAG path this is a
 	// no-model read, and an agent may opt out of it entirely.
-	memoryCtx := types.ApplyAgentMemoryPreference(ctx, agentConfig.MemoryEnabled)
+	memoryCtx := types.WithMemoryAgent(
+		types.ApplyAgentMemoryPreference(ctx, agentConfig.MemoryEnabled), req.CustomAgent.ID)
 	if s.memoryService != nil {
 		recall := s.memoryService.Recall(memoryCtx, req.Query)
 		if recall.Prompt != "" {
@@ -276,6 +277,9 @@ func (s *sessionService) buildAgentConfig(
 		WebSearchEnabled:            customAgent.Config.WebSearchEnabled && req.WebSearchEnabled,
 		WebSearchMaxResults:         customAgent.Config.WebSearchMaxResults,
 		WebSearchProviderID:         customAgent.Config.WebSearchProviderID,
+		SQLConnectionIDs:            customAgent.Config.SQLConnectionIDs,
+		BrowserConfigID:             customAgent.Config.BrowserConfigID,
+		AgentTenantID:               agentTenantID,
 		MultiTurnEnabled:            customAgent.Config.MultiTurnEnabled,
 		HistoryTurns:                customAgent.Config.HistoryTurns,
 		MemoryEnabled:               customAgent.Config.MemoryEnabled,
@@ -363,6 +367,9 @@ func (s *sessionService) buildAgentConfig(
 		return nil, fmt.Errorf("build search targets: %w", err)
 	}
 	agentConfig.SearchTargets = searchTargets
+	// Pinned documents hidden by document-level ACLs must not be named to the
+	// model either.
+	agentConfig.KnowledgeIDs = withoutExcludedKnowledge(agentConfig.KnowledgeIDs, searchTargets)
 	// Document tags are stored in knowledge_tag_relations, so document-KB tag
 	// scopes are resolved to concrete knowledge IDs before retrieval. Preserve
 	// those resolved IDs as this turn's pinned documents as well: otherwise the
@@ -384,6 +391,26 @@ func (s *sessionService) buildAgentConfig(
 	return agentConfig, nil
 }
 
+// withoutExcludedKnowledge drops knowledge IDs that any search target
+// excludes.
+func withoutExcludedKnowledge(ids []string, targets types.SearchTargets) []string {
+	excluded := targets.ExcludedKnowledgeIDs()
+	if len(ids) == 0 || len(excluded) == 0 {
+		return ids
+	}
+	skip := make(map[string]bool, len(excluded))
+	for _, id := range excluded {
+		skip[id] = true
+	}
+	out := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if !skip[id] {
+			out = append(out, id)
+		}
+	}
+	return out
+}
+
 func mergeResolvedTagKnowledgeIDs(
 	existing []string,
 	searchTargets types.SearchTargets,
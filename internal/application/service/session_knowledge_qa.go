@@ -153,11 +153,12 @@ func (s *sessionService) KnowledgeQA(
 	// rewrite, fallback, FAQ strategy, history turns)
 	s.applyAgentOverridesToChatManage(ctx, req.CustomAgent, chatManage)
 
-	// An agent may opt out of long-term memory. The preference is per-request
-	// rather than per-user, so it travels in the context that the recall
-	// plugin reads.
+	// An agent may opt out of long-term memory, and may have shared notes of
+	// its own. Both are per-request rather than per-user, so they travel in the
+	// context that the recall plugin reads.
 	if req.CustomAgent != nil {
 		ctx = types.ApplyAgentMemoryPreference(ctx, req.CustomAgent.Config.MemoryEnabled)
+		ctx = types.WithMemoryAgent(ctx, req.CustomAgent.ID)
 	}
 
 	// Determine pipeline based on the effective knowledge retrieval scope and
@@ -519,7 +520,7 @@ func (s *sessionService) buildSearchTargets(
 		knowledgeList, err := s.knowledgeService.GetKnowledgeBatchWithSharedAccess(ctx, tenantID, knowledgeIDs)
 		if err != nil {
 			logger.Warnf(ctx, "Failed to get knowledge batch for search targets: %v", err)
-			return targets, nil // Return what we have, don't fail
+			return s.restrictSearchTargets(ctx, targets) // Return what we have, don't fail
 		}
 
 		// Group knowledge IDs by their KB, excluding those already covered by full KB search
@@ -612,7 +613,24 @@ func (s *sessionService) buildSearchTargets(
 	logger.Infof(ctx, "Built %d search targets: %d full KB, %d partial/tag KB, kbTenantMap=%v",
 		len(targets), len(knowledgeBaseIDs), len(targets)-len(knowledgeBaseIDs), kbTenantMap)
 
-	return targets, nil
+	return s.restrictSearchTargets(ctx, targets)
+}
+
+// restrictSearchTargets applies document-level ACLs to the targets so every
+// downstream consumer (retrieval, agent tools, wiki scopes) sees only the
+// knowledge the caller may read. ACL lookup failures fail the request.
+func (s *sessionService) restrictSearchTargets(
+	ctx context.Context, targets types.SearchTargets,
+) (types.SearchTargets, error) {
+	if s.aclService == nil || len(targets) == 0 {
+		return targets, nil
+	}
+	restricted, err := s.aclService.RestrictSearchTargets(ctx, targets)
+	if err != nil {
+		logger.Errorf(ctx, "Failed to apply knowledge ACLs to search targets: %v", err)
+		return nil, err
+	}
+	return restricted, nil
 }
 
 func mergeTagScopesByKB(scopes []types.TagScope) map[string][]string {
@@ -9,6 +9,7 @@ import (
 	"github.com/Tencent/WeKnora/internal/models/chat"
 	"github.com/Tencent/WeKnora/internal/models/embedding"
 	"github.com/Tencent/WeKnora/internal/models/rerank"
+	"github.com/Tencent/WeKnora/internal/models/tts"
 	"github.com/Tencent/WeKnora/internal/models/vlm"
 	"github.com/Tencent/WeKnora/internal/types"
 	"github.com/stretchr/testify/assert"
@@ -134,6 +135,10 @@ func (s *stubModelService) GetASRModel(context.Context, string) (asr.ASR, error)
 	return nil, nil
 }
 
+func (s *stubModelService) GetTTSModel(context.Context, string) (tts.TTS, error) {
+	return nil, nil
+}
+
 func TestHandleModelFallback_IncludesHistoryMessages(t *testing.T) {
 	chatModel := &captureChatModel{}
 	svc := &sessionService{
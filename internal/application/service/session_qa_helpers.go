@@ -264,6 +264,8 @@ func (s *sessionService) applyAgentOverridesToChatManage(
 			cm.FAQDirectAnswerThreshold, cm.FAQScoreBoost)
 	}
 
+	cm.GraphSearchMode = customAgent.Config.GraphSearchMode
+
 	// Data-analysis pipeline stage (opt-in, default off).
 	cm.DataAnalysisEnabled = customAgent.Config.DataAnalysisEnabled
 	if cm.DataAnalysisEnabled {
@@ -173,7 +173,10 @@ func resolveSandboxForExecution(
 	if err != nil || mgr == nil {
 		return mgr, configID, err
 	}
-	if mgr.GetType() != sandbox.SandboxTypeCube && mgr.GetType() != sandbox.SandboxTypeE2B {
+	switch mgr.GetType() {
+	case sandbox.SandboxTypeCube, sandbox.SandboxTypeE2B, sandbox.SandboxTypeNamespace:
+		// Session-persistent: pin below.
+	default:
 		return mgr, configID, nil
 	}
 	if pinner == nil || strings.TrimSpace(sessionID) == "" {
@@ -0,0 +1,211 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+// sqlConnectionService implements interfaces.SQLConnectionService
+type sqlConnectionService struct {
+	repo interfaces.SQLConnectionRepository
+}
+
+// NewSQLConnectionService creates a new SQL connection service
+func NewSQLConnectionService(repo interfaces.SQLConnectionRepository) interfaces.SQLConnectionService {
+	return &sqlConnectionService{repo: repo}
+}
+
+// CreateConnection validates and stores a new connection.
+func (s *sqlConnectionService) CreateConnection(ctx context.Context, conn *types.SQLConnection) error {
+	if conn.TenantID == 0 {
+		return fmt.Errorf("tenant ID is required")
+	}
+	if err := validateSQLConnection(conn); err != nil {
+		return err
+	}
+	logger.Infof(ctx, "Creating SQL connection: tenant=%d, name=%s, dialect=%s", conn.TenantID, conn.Name, conn.Dialect)
+	return s.repo.Create(ctx, conn)
+}
+
+// UpdateConnection validates and updates an existing connection.
+func (s *sqlConnectionService) UpdateConnection(ctx context.Context, conn *types.SQLConnection) error {
+	if conn.TenantID == 0 {
+		return fmt.Errorf("tenant ID is required")
+	}
+	if err := validateSQLConnection(conn); err != nil {
+		return err
+	}
+	logger.Infof(ctx, "Updating SQL connection: tenant=%d, id=%s", conn.TenantID, conn.ID)
+	return s.repo.Update(ctx, conn)
+}
+
+// DeleteConnection deletes a connection by tenant + id.
+func (s *sqlConnectionService) DeleteConnection(ctx context.Context, tenantID uint64, id string) error {
+	logger.Infof(ctx, "Deleting SQL connection: tenant=%d, id=%s", tenantID, id)
+	return s.repo.Delete(ctx, tenantID, id)
+}
+
+// GetConnection returns a connection by tenant + id; nil when absent.
+func (s *sqlConnectionService) GetConnection(ctx context.Context, tenantID uint64, id string) (*types.SQLConnection, error) {
+	return s.repo.GetByID(ctx, tenantID, id)
+}
+
+// ListConnections lists all connections of a tenant.
+func (s *sqlConnectionService) ListConnections(ctx context.Context, tenantID uint64) ([]*types.SQLConnection, error) {
+	return s.repo.List(ctx, tenantID)
+}
+
+// UpdateConnectionCredentials writes the password credential. Connections are
+// opened per query, so no pool needs to be invalidated.
+func (s *sqlConnectionService) UpdateConnectionCredentials(
+	ctx context.Context, tenantID uint64, id string, password *string,
+) (*types.SQLConnection, error) {
+	existing, err := s.repo.GetByID(ctx, tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+	if existing == nil {
+		return nil, fmt.Errorf("sql connection not found")
+	}
+
+	if password != nil && *password != "" && *password != existing.Parameters.Password {
+		existing.Parameters.Password = *password
+		if err := s.repo.Update(ctx, existing); err != nil {
+			return nil, err
+		}
+		logger.Infof(ctx, "SQL connection credentials updated: tenant=%d id=%s", tenantID, id)
+	}
+	return existing, nil
+}
+
+// ClearConnectionCredential clears the password credential. Idempotent.
+func (s *sqlConnectionService) ClearConnectionCredential(
+	ctx context.Context, tenantID uint64, id, field string,
+) error {
+	if field != "password" {
+		return fmt.Errorf("unknown credential field: %s", field)
+	}
+	existing, err := s.repo.GetByID(ctx, tenantID, id)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return fmt.Errorf("sql connection not found")
+	}
+	if existing.Parameters.Password == "" {
+		return nil
+	}
+	existing.Parameters.Password = ""
+	if err := s.repo.Update(ctx, existing); err != nil {
+		return err
+	}
+	logger.Infof(ctx, "SQL connection credential cleared by user: tenant=%d id=%s field=%s", tenantID, id, field)
+	return nil
+}
+
+// TestConnection opens the database and runs a trivial read-only query.
+func (s *sqlConnectionService) TestConnection(ctx context.Context, conn *types.SQLConnection) error {
+	if err := validateSQLConnection(conn); err != nil {
+		return err
+	}
+	_, err := s.Query(ctx, conn, "SELECT 1", 1)
+	return err
+}
+
+// IntrospectSchema lists tables and columns, merged with the configured descriptions.
+func (s *sqlConnectionService) IntrospectSchema(
+	ctx context.Context, conn *types.SQLConnection, tables []string,
+) (*types.SQLSchema, error) {
+	if err := validateSQLConnection(conn); err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, conn.Options.EffectiveTimeout())
+	defer cancel()
+
+	db, err := openSQLConnection(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	schema, err := introspectSQLSchema(ctx, db, conn.Dialect)
+	if err != nil {
+		logger.Warnf(ctx, "SQL connection introspection failed: id=%s err=%v", conn.ID, err)
+		return nil, fmt.Errorf("failed to read schema: %w", err)
+	}
+	filterSQLSchema(schema, conn.Options.IncludeTables, tables)
+	annotateSQLSchema(schema, conn.Options)
+	return schema, nil
+}
+
+// Query runs a single read-only SELECT with the connection's row limit and timeout.
+func (s *sqlConnectionService) Query(
+	ctx context.Context, conn *types.SQLConnection, query string, maxRows int,
+) (*types.SQLQueryResult, error) {
+	if err := validateSQLConnection(conn); err != nil {
+		return nil, err
+	}
+	if err := validateReadOnlySQL(conn.Dialect, query); err != nil {
+		return nil, err
+	}
+	limit := conn.Options.EffectiveMaxRows()
+	if maxRows > 0 && maxRows < limit {
+		limit = maxRows
+	}
+	timeout := conn.Options.EffectiveTimeout()
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	db, err := openSQLConnection(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	result, err := runReadOnlyQuery(ctx, db, conn.Dialect, strings.TrimSpace(query), limit, timeout)
+	if err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return nil, fmt.Errorf("query exceeded the %s timeout", timeout)
+		}
+		return nil, err
+	}
+	return result, nil
+}
+
+// validateSQLConnection checks the fields every dialect needs.
+func validateSQLConnection(conn *types.SQLConnection) error {
+	if conn == nil {
+		return fmt.Errorf("sql connection is required")
+	}
+	if strings.TrimSpace(conn.Name) == "" {
+		return fmt.Errorf("name is required")
+	}
+	if !conn.Dialect.IsValid() {
+		return fmt.Errorf("invalid dialect: %s", conn.Dialect)
+	}
+	if conn.Dialect.IsFileBased() {
+		_, err := resolveSQLFilePath(conn.Parameters.FilePath)
+		return err
+	}
+	if strings.TrimSpace(conn.Parameters.Host) == "" {
+		return fmt.Errorf("host is required for %s", conn.Dialect)
+	}
+	if strings.ContainsAny(conn.Parameters.Host, "/?#@ ") {
+		return fmt.Errorf("invalid host: %s", conn.Parameters.Host)
+	}
+	if conn.Parameters.Port < 0 || conn.Parameters.Port > 65535 {
+		return fmt.Errorf("invalid port: %d", conn.Parameters.Port)
+	}
+	if strings.TrimSpace(conn.Parameters.Database) == "" {
+		return fmt.Errorf("database is required for %s", conn.Dialect)
+	}
+	if conn.Parameters.Username == "" {
+		return fmt.Errorf("username is required for %s", conn.Dialect)
+	}
+	return nil
+}
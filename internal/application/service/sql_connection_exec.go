@@ -0,0 +1,514 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	_ "github.com/duckdb/duckdb-go/v2" // duckdb driver for database/sql
+	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+	_ "github.com/mattn/go-sqlite3" // sqlite3 driver for database/sql
+
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	secutils "github.com/Tencent/WeKnora/internal/utils"
+)
+
+// sqlFileDirsEnv lists the directories (separated by the OS path list
+// separator or commas) that SQLite / DuckDB connections may read from.
+// File connections are disabled while it is unset, so a workspace admin
+// cannot point a connection at arbitrary files on the server.
+const sqlFileDirsEnv = "WEKNORA_SQL_FILE_DIRS"
+
+const (
+	// sqlSchemaMaxTables caps how many tables introspection returns.
+	sqlSchemaMaxTables = 200
+	// sqlCellMaxRunes truncates long text cells so one wide column cannot
+	// blow up the tool output.
+	sqlCellMaxRunes = 1000
+	// sqlConnectTimeout bounds the TCP / handshake phase of server dialects.
+	sqlConnectTimeout = 10 * time.Second
+)
+
+var pgSSLModes = map[string]bool{
+	"disable": true, "allow": true, "prefer": true,
+	"require": true, "verify-ca": true, "verify-full": true,
+}
+
+// sqlFileDirs returns the configured file connection roots.
+func sqlFileDirs() []string {
+	raw := os.Getenv(sqlFileDirsEnv)
+	var dirs []string
+	for _, part := range strings.FieldsFunc(raw, func(r rune) bool {
+		return r == ',' || r == filepath.ListSeparator
+	}) {
+		if part = strings.TrimSpace(part); part != "" {
+			dirs = append(dirs, part)
+		}
+	}
+	return dirs
+}
+
+// resolveSQLFilePath resolves symlinks and checks the file lives under one
+// of WEKNORA_SQL_FILE_DIRS.
+func resolveSQLFilePath(path string) (string, error) {
+	path = strings.TrimSpace(path)
+	if path == "" {
+		return "", fmt.Errorf("file_path is required")
+	}
+	roots := sqlFileDirs()
+	if len(roots) == 0 {
+		return "", fmt.Errorf("file connections are disabled; set %s on the server", sqlFileDirsEnv)
+	}
+	if !filepath.IsAbs(path) {
+		return "", fmt.Errorf("file_path must be absolute")
+	}
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return "", fmt.Errorf("file_path is not accessible")
+	}
+	if info, err := os.Stat(resolved); err != nil || info.IsDir() {
+		return "", fmt.Errorf("file_path must be a regular file")
+	}
+	for _, root := range roots {
+		realRoot, err := filepath.EvalSymlinks(root)
+		if err != nil {
+			continue
+		}
+		rel, err := filepath.Rel(realRoot, resolved)
+		if err == nil && rel != "." && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return resolved, nil
+		}
+	}
+	return "", fmt.Errorf("file_path must be under one of %s", sqlFileDirsEnv)
+}
+
+// openSQLConnection opens a single-connection pool for conn. Every dialect is
+// opened read-only at the database level; validateReadOnlySQL is only a
+// first line of defence.
+func openSQLConnection(ctx context.Context, conn *types.SQLConnection) (*sql.DB, error) {
+	var (
+		db  *sql.DB
+		err error
+	)
+	switch conn.Dialect {
+	case types.SQLDialectPostgres:
+		db, err = openPostgresConnection(conn.Parameters)
+	case types.SQLDialectMySQL:
+		db, err = openMySQLConnection(conn.Parameters)
+	case types.SQLDialectSQLite:
+		db, err = openSQLiteConnection(conn.Parameters)
+	case types.SQLDialectDuckDB:
+		db, err = openDuckDBConnection(ctx, conn.Parameters)
+	default:
+		return nil, fmt.Errorf("invalid dialect: %s", conn.Dialect)
+	}
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(1)
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		logger.Warnf(ctx, "SQL connection ping failed: id=%s dialect=%s err=%v", conn.ID, conn.Dialect, err)
+		return nil, fmt.Errorf("failed to connect to %s: connection refused or authentication failed", conn.Dialect)
+	}
+	return db, nil
+}
+
+func openPostgresConnection(p types.SQLConnectionParameters) (*sql.DB, error) {
+	port := p.Port
+	if port == 0 {
+		port = 5432
+	}
+	sslMode := p.SSLMode
+	if sslMode == "" {
+		sslMode = "prefer"
+	}
+	if !pgSSLModes[sslMode] {
+		return nil, fmt.Errorf("invalid ssl_mode: %s", sslMode)
+	}
+	u := &url.URL{
+		Scheme: "postgres",
+		User:   url.UserPassword(p.Username, p.Password),
+		Host:   net.JoinHostPort(p.Host, strconv.Itoa(port)),
+		Path:   "/" + p.Database,
+	}
+	q := url.Values{}
+	q.Set("sslmode", sslMode)
+	q.Set("application_name", "weknora")
+	u.RawQuery = q.Encode()
+	cfg, err := pgx.ParseConfig(u.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create postgres connection: invalid configuration")
+	}
+	// The whole session is read-only, including the introspection queries.
+	cfg.RuntimeParams["default_transaction_read_only"] = "on"
+	cfg.ConnectTimeout = sqlConnectTimeout
+	cfg.DialFunc = secutils.SSRFSafeDialContext
+	return stdlib.OpenDB(*cfg), nil
+}
+
+func openMySQLConnection(p types.SQLConnectionParameters) (*sql.DB, error) {
+	port := p.Port
+	if port == 0 {
+		port = 3306
+	}
+	cfg := mysql.NewConfig()
+	cfg.User = p.Username
+	cfg.Passwd = p.Password
+	secutils.RegisterMySQLSSRFDialer()
+	cfg.Net = secutils.MySQLSSRFNetwork
+	cfg.Addr = net.JoinHostPort(p.Host, strconv.Itoa(port))
+	cfg.DBName = p.Database
+	cfg.Timeout = sqlConnectTimeout
+	cfg.ParseTime = true
+	cfg.AllowAllFiles = false
+	cfg.MultiStatements = false
+	db, err := sql.Open("mysql", cfg.FormatDSN())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create mysql connection: invalid configuration")
+	}
+	return db, nil
+}
+
+func openSQLiteConnection(p types.SQLConnectionParameters) (*sql.DB, error) {
+	path, err := resolveSQLFilePath(p.FilePath)
+	if err != nil {
+		return nil, err
+	}
+	// mode=ro opens the file read-only; _query_only additionally rejects any
+	// write to temp tables or attached databases.
+	dsn := (&url.URL{Scheme: "file", Path: path, RawQuery: "mode=ro&_query_only=true"}).String()
+	db, err := sql.Open("sqlite3", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open sqlite file: invalid configuration")
+	}
+	return db, nil
+}
+
+func openDuckDBConnection(ctx context.Context, p types.SQLConnectionParameters) (*sql.DB, error) {
+	path, err := resolveSQLFilePath(p.FilePath)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		db    *sql.DB
+		setup []string
+	)
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".csv", ".tsv", ".parquet":
+		db, err = sql.Open("duckdb", "")
+		reader := "read_csv_auto"
+		if ext == ".parquet" {
+			reader = "read_parquet"
+		}
+		view := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		setup = append(setup,
+			fmt.Sprintf("CREATE VIEW %s AS SELECT * FROM %s(%s)", quoteSQLIdent(view), reader, quoteSQLString(path)),
+			fmt.Sprintf("SET allowed_paths = [%s]", quoteSQLString(path)),
+		)
+	default:
+		db, err = sql.Open("duckdb", path+"?access_mode=READ_ONLY")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open duckdb file: invalid configuration")
+	}
+	// Only the configured file stays reachable, and the agent cannot turn
+	// external access back on.
+	setup = append(setup, "SET enable_external_access = false", "SET lock_configuration = true")
+	for _, stmt := range setup {
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			db.Close()
+			logger.Warnf(ctx, "DuckDB connection setup failed: stmt=%q err=%v", stmt, err)
+			return nil, fmt.Errorf("failed to open duckdb file: %v", err)
+		}
+	}
+	return db, nil
+}
+
+func quoteSQLIdent(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+func quoteSQLString(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// sqlQueryer is satisfied by *sql.Conn and *sql.Tx.
+type sqlQueryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// runReadOnlyQuery executes query on a dedicated connection, inside a
+// read-only transaction for server dialects, and reads at most limit rows.
+func runReadOnlyQuery(
+	ctx context.Context, db *sql.DB, dialect types.SQLDialect, query string, limit int, timeout time.Duration,
+) (*types.SQLQueryResult, error) {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	var q sqlQueryer = conn
+	if dialect == types.SQLDialectPostgres || dialect == types.SQLDialectMySQL {
+		if dialect == types.SQLDialectMySQL {
+			// MySQL 5.7+ only; MariaDB ignores the setting and relies on ctx.
+			if _, err := conn.ExecContext(ctx, fmt.Sprintf("SET SESSION max_execution_time = %d", timeout.Milliseconds())); err != nil {
+				logger.Debugf(ctx, "SQL connection: max_execution_time not supported: %v", err)
+			}
+		}
+		tx, err := conn.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+		if err != nil {
+			return nil, err
+		}
+		defer tx.Rollback()
+		if dialect == types.SQLDialectPostgres {
+			if _, err := tx.ExecContext(ctx, fmt.Sprintf("SET LOCAL statement_timeout = %d", timeout.Milliseconds())); err != nil {
+				return nil, err
+			}
+		}
+		q = tx
+	}
+
+	// Cancelling the query context once the limit is reached stops drivers
+	// from draining the rest of a large result set on Close.
+	qctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	start := time.Now()
+	rows, err := q.QueryContext(qctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	columnTypes := make([]string, len(columns))
+	if cts, err := rows.ColumnTypes(); err == nil {
+		for i, ct := range cts {
+			columnTypes[i] = strings.ToUpper(ct.DatabaseTypeName())
+		}
+	}
+
+	result := &types.SQLQueryResult{Columns: columns, ColumnTypes: columnTypes, Rows: [][]interface{}{}}
+	for rows.Next() {
+		if len(result.Rows) >= limit {
+			result.Truncated = true
+			cancel()
+			break
+		}
+		values := make([]interface{}, len(columns))
+		ptrs := make([]interface{}, len(columns))
+		for i := range values {
+			ptrs[i] = &values[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return nil, err
+		}
+		for i, v := range values {
+			values[i] = normalizeSQLValue(v, columnTypes[i])
+		}
+		result.Rows = append(result.Rows, values)
+	}
+	if err := rows.Err(); err != nil && !result.Truncated {
+		return nil, err
+	}
+	result.RowCount = len(result.Rows)
+	result.DurationMs = time.Since(start).Milliseconds()
+	return result, nil
+}
+
+// normalizeSQLValue turns driver values into JSON-friendly scalars.
+func normalizeSQLValue(v interface{}, dbType string) interface{} {
+	switch x := v.(type) {
+	case nil, bool, int64, int32, int16, int8, int, uint64, uint32, uint16, uint8, float64, float32:
+		return x
+	case []byte:
+		if !utf8.Valid(x) {
+			return fmt.Sprintf("<binary %d bytes>", len(x))
+		}
+		return coerceSQLText(string(x), dbType)
+	case string:
+		return coerceSQLText(x, dbType)
+	case time.Time:
+		if x.Hour() == 0 && x.Minute() == 0 && x.Second() == 0 && x.Nanosecond() == 0 {
+			return x.Format("2006-01-02")
+		}
+		return x.Format(time.RFC3339)
+	case interface{ Float64() float64 }:
+		return x.Float64()
+	default:
+		return truncateSQLText(fmt.Sprint(x))
+	}
+}
+
+// coerceSQLText parses numeric columns that drivers return as text (MySQL
+// text protocol, Postgres NUMERIC) so results can be charted.
+func coerceSQLText(s, dbType string) interface{} {
+	if isNumericSQLType(dbType) {
+		if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return n
+		}
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			return f
+		}
+	}
+	return truncateSQLText(s)
+}
+
+func truncateSQLText(s string) string {
+	if utf8.RuneCountInString(s) <= sqlCellMaxRunes {
+		return s
+	}
+	return string([]rune(s)[:sqlCellMaxRunes]) + "…"
+}
+
+func isNumericSQLType(dbType string) bool {
+	for _, marker := range []string{"INT", "DEC", "NUMERIC", "FLOAT", "DOUBLE", "REAL"} {
+		if strings.Contains(dbType, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// sqlIntrospectionQueries return (schema, table, column, type, nullable,
+// column comment, table comment), ordered by table and column position.
+var sqlIntrospectionQueries = map[types.SQLDialect]string{
+	types.SQLDialectPostgres: `
+SELECT c.table_schema, c.table_name, c.column_name, c.data_type, c.is_nullable = 'YES',
+       COALESCE(pg_catalog.col_description(cls.oid, a.attnum), ''),
+       COALESCE(pg_catalog.obj_description(cls.oid, 'pg_class'), '')
+FROM information_schema.columns c
+JOIN pg_catalog.pg_namespace ns ON ns.nspname = c.table_schema
+JOIN pg_catalog.pg_class cls ON cls.relnamespace = ns.oid AND cls.relname = c.table_name
+LEFT JOIN pg_catalog.pg_attribute a ON a.attrelid = cls.oid AND a.attname = c.column_name
+WHERE c.table_schema NOT IN ('pg_catalog', 'information_schema')
+  AND c.table_schema NOT LIKE 'pg_toast%'
+ORDER BY c.table_schema, c.table_name, c.ordinal_position`,
+	types.SQLDialectMySQL: `
+SELECT '', c.TABLE_NAME, c.COLUMN_NAME, c.COLUMN_TYPE, c.IS_NULLABLE = 'YES',
+       c.COLUMN_COMMENT, t.TABLE_COMMENT
+FROM information_schema.COLUMNS c
+JOIN information_schema.TABLES t ON t.TABLE_SCHEMA = c.TABLE_SCHEMA AND t.TABLE_NAME = c.TABLE_NAME
+WHERE c.TABLE_SCHEMA = DATABASE()
+ORDER BY c.TABLE_NAME, c.ORDINAL_POSITION`,
+	types.SQLDialectSQLite: `
+SELECT '', m.name, p.name, p.type, p."notnull" = 0, '', ''
+FROM sqlite_master m
+JOIN pragma_table_info(m.name) p
+WHERE m.type IN ('table', 'view') AND m.name NOT LIKE 'sqlite_%'
+ORDER BY m.name, p.cid`,
+	types.SQLDialectDuckDB: `
+SELECT schema_name, table_name, column_name, data_type, is_nullable,
+       COALESCE(comment, ''), ''
+FROM duckdb_columns()
+WHERE NOT internal AND database_name = current_database()
+ORDER BY schema_name, table_name, column_index`,
+}
+
+// introspectSQLSchema reads tables and columns using the dialect's catalog.
+func introspectSQLSchema(ctx context.Context, db *sql.DB, dialect types.SQLDialect) (*types.SQLSchema, error) {
+	query, ok := sqlIntrospectionQueries[dialect]
+	if !ok {
+		return nil, fmt.Errorf("invalid dialect: %s", dialect)
+	}
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	schema := &types.SQLSchema{Dialect: dialect, Tables: []types.SQLTable{}}
+	for rows.Next() {
+		var (
+			schemaName, table, column, colType, colComment, tableComment string
+			nullable                                                     bool
+		)
+		if err := rows.Scan(&schemaName, &table, &column, &colType, &nullable, &colComment, &tableComment); err != nil {
+			return nil, err
+		}
+		if schemaName != "" && schemaName != "public" && schemaName != "main" {
+			table = schemaName + "." + table
+		}
+		n := len(schema.Tables)
+		if n == 0 || schema.Tables[n-1].Name != table {
+			if n >= sqlSchemaMaxTables {
+				schema.Truncated = true
+				break
+			}
+			schema.Tables = append(schema.Tables, types.SQLTable{Name: table, Description: tableComment})
+			n++
+		}
+		schema.Tables[n-1].Columns = append(schema.Tables[n-1].Columns, types.SQLColumn{
+			Name:        column,
+			Type:        colType,
+			Nullable:    nullable,
+			Description: colComment,
+		})
+	}
+	return schema, rows.Err()
+}
+
+// filterSQLSchema keeps the tables named in include (the connection's scope)
+// and, when given, the tables the caller asked for. Matching is case-insensitive.
+func filterSQLSchema(schema *types.SQLSchema, include, requested []string) {
+	keep := func(names []string) func(string) bool {
+		if len(names) == 0 {
+			return func(string) bool { return true }
+		}
+		set := make(map[string]bool, len(names))
+		for _, n := range names {
+			set[strings.ToLower(strings.TrimSpace(n))] = true
+		}
+		return func(name string) bool { return set[strings.ToLower(name)] }
+	}
+	inScope, asked := keep(include), keep(requested)
+	filtered := schema.Tables[:0]
+	for _, t := range schema.Tables {
+		if inScope(t.Name) && asked(t.Name) {
+			filtered = append(filtered, t)
+		}
+	}
+	schema.Tables = filtered
+}
+
+// annotateSQLSchema overlays the configured business descriptions on top of
+// the database comments.
+func annotateSQLSchema(schema *types.SQLSchema, opts types.SQLConnectionOptions) {
+	lookup := func(m map[string]string, key string) string {
+		if v, ok := m[key]; ok {
+			return v
+		}
+		for k, v := range m {
+			if strings.EqualFold(k, key) {
+				return v
+			}
+		}
+		return ""
+	}
+	for i := range schema.Tables {
+		t := &schema.Tables[i]
+		if desc := lookup(opts.TableDescriptions, t.Name); desc != "" {
+			t.Description = desc
+		}
+		for j := range t.Columns {
+			if desc := lookup(opts.ColumnDescriptions, t.Name+"."+t.Columns[j].Name); desc != "" {
+				t.Columns[j].Description = desc
+			}
+		}
+	}
+}
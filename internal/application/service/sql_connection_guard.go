@@ -0,0 +1,233 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// validateReadOnlySQL is a dialect-aware lexical check run before a query
+// reaches an external database. It is deliberately conservative: anything it
+// cannot classify as a single plain SELECT is rejected. The database-level
+// read-only session (see openSQLConnection) remains the real guarantee; this
+// check only gives the agent a clear error early and blocks side-effecting
+// functions that a read-only transaction would still allow.
+func validateReadOnlySQL(dialect types.SQLDialect, query string) error {
+	// MySQL honours backslash escapes unless NO_BACKSLASH_ESCAPES is set, and
+	// the session mode is not ours to know, so a MySQL query has to pass
+	// under both readings.
+	readings := []bool{false}
+	if dialect == types.SQLDialectMySQL {
+		readings = []bool{true, false}
+	}
+	for _, backslashEscapes := range readings {
+		tokens, err := tokenizeSQL(dialect, query, backslashEscapes)
+		if err != nil {
+			return err
+		}
+		if err := checkReadOnlyTokens(tokens); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkReadOnlyTokens enforces a single SELECT / WITH statement without
+// writes, locks or side-effecting functions.
+func checkReadOnlyTokens(tokens []sqlToken) error {
+	// Trailing semicolons are harmless; anything after one is a second statement.
+	for len(tokens) > 0 && tokens[len(tokens)-1].text == ";" {
+		tokens = tokens[:len(tokens)-1]
+	}
+	if len(tokens) == 0 {
+		return fmt.Errorf("sql is empty")
+	}
+	first := ""
+	for i, tok := range tokens {
+		if tok.text == ";" {
+			return fmt.Errorf("only a single statement is allowed")
+		}
+		if first == "" && tok.word && !tok.quoted {
+			first = strings.ToUpper(tok.text)
+		}
+		if !tok.word {
+			continue
+		}
+		upper := strings.ToUpper(tok.text)
+		if !tok.quoted && sqlForbiddenKeywords[upper] {
+			return fmt.Errorf("%s is not allowed in read-only queries", upper)
+		}
+		if tok.call && sqlForbiddenFunctions[strings.ToLower(tok.text)] {
+			return fmt.Errorf("function %s is not allowed", strings.ToLower(tok.text))
+		}
+		// FOR UPDATE is caught above; FOR SHARE / FOR KEY SHARE take row locks too.
+		if upper == "FOR" && !tok.quoted && i+1 < len(tokens) {
+			next := strings.ToUpper(tokens[i+1].text)
+			if next == "SHARE" || next == "KEY" || next == "NO" {
+				return fmt.Errorf("locking clauses are not allowed")
+			}
+		}
+	}
+	if first != "SELECT" && first != "WITH" {
+		return fmt.Errorf("only SELECT queries are allowed")
+	}
+	return nil
+}
+
+// sqlForbiddenKeywords can only appear in a statement that writes, locks or
+// reaches outside the database. Statement-leading commands (CALL, SET,
+// VACUUM, ...) are already rejected by the SELECT/WITH rule.
+var sqlForbiddenKeywords = map[string]bool{
+	"INSERT": true, "UPDATE": true, "DELETE": true, "MERGE": true, "UPSERT": true,
+	"CREATE": true, "DROP": true, "ALTER": true, "TRUNCATE": true,
+	"GRANT": true, "REVOKE": true, "ATTACH": true, "DETACH": true,
+	"PRAGMA": true, "COPY": true, "INTO": true, "OUTFILE": true, "DUMPFILE": true,
+	"LOCK": true, "INSTALL": true,
+}
+
+// sqlForbiddenFunctions read server files, sleep, change settings or
+// sequences, or open connections elsewhere.
+var sqlForbiddenFunctions = map[string]bool{
+	// Postgres
+	"pg_read_file": true, "pg_read_binary_file": true, "pg_ls_dir": true, "pg_stat_file": true,
+	"pg_sleep": true, "pg_sleep_for": true, "pg_sleep_until": true,
+	"lo_import": true, "lo_export": true, "lo_get": true,
+	"dblink": true, "dblink_exec": true, "dblink_connect": true,
+	"set_config": true, "current_setting": true, "nextval": true, "setval": true,
+	"pg_terminate_backend": true, "pg_cancel_backend": true, "pg_reload_conf": true,
+	// MySQL
+	"load_file": true, "sleep": true, "benchmark": true, "get_lock": true,
+	// SQLite
+	"load_extension": true, "readfile": true, "writefile": true, "fts3_tokenizer": true,
+	// DuckDB
+	"read_csv": true, "read_csv_auto": true, "read_parquet": true, "parquet_scan": true,
+	"read_json": true, "read_json_auto": true, "read_ndjson": true, "read_text": true,
+	"read_blob": true, "glob": true, "sniff_csv": true, "query": true, "query_table": true,
+	"getenv": true, "duckdb_secrets": true, "duckdb_settings": true,
+}
+
+// sqlToken is a word, a quoted identifier, or a single punctuation rune.
+// String literals and comments are dropped.
+type sqlToken struct {
+	text   string
+	word   bool
+	quoted bool
+	// call is set when the word is directly followed by "(".
+	call bool
+}
+
+// tokenizeSQL splits query into tokens, honouring the quoting rules of the
+// dialect. Where dialects disagree the stricter reading is used, so text one
+// engine would execute is never mistaken for a literal or comment.
+// backslashEscapes selects MySQL's default string escaping; Postgres and
+// DuckDB only honour backslashes in E'...' literals.
+func tokenizeSQL(dialect types.SQLDialect, query string, backslashEscapes bool) ([]sqlToken, error) {
+	src := []rune(query)
+	var tokens []sqlToken
+	escapeStrings := dialect == types.SQLDialectPostgres || dialect == types.SQLDialectDuckDB
+	dollarQuotes := dialect == types.SQLDialectPostgres || dialect == types.SQLDialectDuckDB
+
+	markCall := func() {
+		if n := len(tokens); n > 0 && tokens[n-1].word {
+			tokens[n-1].call = true
+		}
+	}
+
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '-' && i+1 < len(src) && src[i+1] == '-':
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+		case c == '/' && i+1 < len(src) && src[i+1] == '*':
+			if i+2 < len(src) && src[i+2] == '!' {
+				// MySQL executes the body of /*! ... */ comments.
+				return nil, fmt.Errorf("executable comments are not allowed")
+			}
+			end := strings.Index(string(src[i+2:]), "*/")
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated comment")
+			}
+			i += 2 + len([]rune(string(src[i+2:])[:end])) + 2
+		case c == '\'':
+			escapes := backslashEscapes
+			if n := len(tokens); escapeStrings && n > 0 && i > 0 && (src[i-1] == 'E' || src[i-1] == 'e') &&
+				tokens[n-1].word && !tokens[n-1].quoted && strings.EqualFold(tokens[n-1].text, "E") {
+				escapes = true
+				tokens = tokens[:n-1]
+			}
+			j, err := skipSQLQuoted(src, i, '\'', escapes)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, sqlToken{text: "''"})
+			i = j
+		case c == '"' || c == '`':
+			// Without ANSI_QUOTES MySQL reads "..." as a string, escapes included.
+			j, err := skipSQLQuoted(src, i, c, c == '"' && backslashEscapes)
+			if err != nil {
+				return nil, err
+			}
+			inner := strings.ReplaceAll(string(src[i+1:j-1]), string([]rune{c, c}), string(c))
+			tokens = append(tokens, sqlToken{text: inner, word: true, quoted: true})
+			i = j
+		case c == '$' && dollarQuotes:
+			j := i + 1
+			for j < len(src) && (src[j] == '_' || unicode.IsLetter(src[j]) || unicode.IsDigit(src[j])) {
+				j++
+			}
+			if j < len(src) && src[j] == '$' && (j == i+1 || !unicode.IsDigit(src[i+1])) {
+				tag := string(src[i : j+1])
+				end := strings.Index(string(src[j+1:]), tag)
+				if end < 0 {
+					return nil, fmt.Errorf("unterminated dollar-quoted string")
+				}
+				tokens = append(tokens, sqlToken{text: "''"})
+				i = j + 1 + len([]rune(string(src[j+1:])[:end])) + len([]rune(tag))
+				continue
+			}
+			// Positional parameter such as $1.
+			tokens = append(tokens, sqlToken{text: string(src[i:j])})
+			i = j
+		case c == '_' || unicode.IsLetter(c):
+			j := i + 1
+			for j < len(src) && (src[j] == '_' || src[j] == '$' || unicode.IsLetter(src[j]) || unicode.IsDigit(src[j])) {
+				j++
+			}
+			tokens = append(tokens, sqlToken{text: string(src[i:j]), word: true})
+			i = j
+		case c == '(':
+			markCall()
+			tokens = append(tokens, sqlToken{text: "("})
+			i++
+		default:
+			tokens = append(tokens, sqlToken{text: string(c)})
+			i++
+		}
+	}
+	return tokens, nil
+}
+
+// skipSQLQuoted returns the index just past the literal opened at src[start].
+// A doubled quote is an escaped quote; a backslash escapes the next rune when
+// escapes is set.
+func skipSQLQuoted(src []rune, start int, quote rune, escapes bool) (int, error) {
+	for i := start + 1; i < len(src); i++ {
+		switch {
+		case escapes && src[i] == '\\':
+			i++
+		case src[i] == quote:
+			if i+1 < len(src) && src[i+1] == quote {
+				i++
+				continue
+			}
+			return i + 1, nil
+		}
+	}
+	return 0, fmt.Errorf("unterminated quoted string")
+}
@@ -0,0 +1,245 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateReadOnlySQL(t *testing.T) {
+	allowed := []struct {
+		dialect types.SQLDialect
+		query   string
+	}{
+		{types.SQLDialectPostgres, "SELECT id, name FROM orders WHERE status = 'paid'"},
+		{types.SQLDialectPostgres, "select 1;"},
+		{types.SQLDialectPostgres, "WITH t AS (SELECT * FROM orders) SELECT count(*) FROM t"},
+		{types.SQLDialectPostgres, "SELECT 'insert into x' AS note -- delete me\nFROM orders"},
+		{types.SQLDialectPostgres, `SELECT "update" FROM "orders"`},
+		{types.SQLDialectPostgres, "SELECT $$drop table x$$"},
+		{types.SQLDialectPostgres, "SELECT E'\\' ; DROP TABLE x; --'"},
+		{types.SQLDialectMySQL, "SELECT `delete` FROM t /* update */ UNION SELECT 2"},
+		{types.SQLDialectSQLite, "SELECT replace(name, 'a', 'b') FROM t"},
+		{types.SQLDialectDuckDB, "SELECT * FROM sales ORDER BY amount DESC LIMIT 10"},
+	}
+	for _, tc := range allowed {
+		require.NoError(t, validateReadOnlySQL(tc.dialect, tc.query), tc.query)
+	}
+
+	rejected := []struct {
+		dialect types.SQLDialect
+		query   string
+	}{
+		{types.SQLDialectPostgres, ""},
+		{types.SQLDialectPostgres, "DELETE FROM orders"},
+		{types.SQLDialectPostgres, "SELECT 1; DROP TABLE orders"},
+		{types.SQLDialectPostgres, "WITH d AS (DELETE FROM orders RETURNING *) SELECT * FROM d"},
+		{types.SQLDialectPostgres, "SELECT * INTO backup FROM orders"},
+		{types.SQLDialectPostgres, "SELECT * FROM orders FOR UPDATE"},
+		{types.SQLDialectPostgres, "SELECT * FROM orders FOR SHARE"},
+		{types.SQLDialectPostgres, "SELECT pg_read_file('/etc/passwd')"},
+		{types.SQLDialectPostgres, `SELECT "pg_sleep"(10)`},
+		{types.SQLDialectPostgres, "SELECT '\\' , pg_sleep(10), '' FROM t"},
+		{types.SQLDialectPostgres, "EXPLAIN ANALYZE SELECT 1"},
+		{types.SQLDialectPostgres, "SET statement_timeout = 0"},
+		{types.SQLDialectMySQL, "SELECT * FROM t INTO OUTFILE '/tmp/x'"},
+		{types.SQLDialectMySQL, "SELECT 'a\\' , load_file('/etc/passwd'), '' FROM t"},
+		{types.SQLDialectMySQL, "SELECT /*! sleep(10) */ 1"},
+		{types.SQLDialectMySQL, "SELECT * FROM t LOCK IN SHARE MODE"},
+		{types.SQLDialectSQLite, "ATTACH DATABASE '/tmp/x.db' AS x"},
+		{types.SQLDialectSQLite, "PRAGMA table_info(t)"},
+		{types.SQLDialectDuckDB, "SELECT * FROM read_csv_auto('/etc/passwd')"},
+		{types.SQLDialectDuckDB, "COPY t TO '/tmp/out.csv'"},
+		{types.SQLDialectPostgres, "SELECT 'unterminated"},
+	}
+	for _, tc := range rejected {
+		require.Error(t, validateReadOnlySQL(tc.dialect, tc.query), tc.query)
+	}
+}
+
+// newSQLiteFixture creates a SQLite database under a WEKNORA_SQL_FILE_DIRS
+// root and returns its path.
+func newSQLiteFixture(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	t.Setenv(sqlFileDirsEnv, root)
+	path := filepath.Join(root, "shop.db")
+	db, err := sql.Open("sqlite3", path)
+	require.NoError(t, err)
+	defer db.Close()
+	_, err = db.Exec(`CREATE TABLE orders (id INTEGER PRIMARY KEY, region TEXT NOT NULL, amount REAL, created_at TEXT)`)
+	require.NoError(t, err)
+	for i := 0; i < 30; i++ {
+		_, err = db.Exec(`INSERT INTO orders (region, amount, created_at) VALUES (?, ?, ?)`,
+			[]string{"north", "south", "east"}[i%3], float64(i)*1.5, "2026-01-0"+string(rune('1'+i%9)))
+		require.NoError(t, err)
+	}
+	return path
+}
+
+func TestSQLConnectionQuerySQLite(t *testing.T) {
+	path := newSQLiteFixture(t)
+	svc := NewSQLConnectionService(nil)
+	conn := &types.SQLConnection{
+		Name:       "shop",
+		Dialect:    types.SQLDialectSQLite,
+		Parameters: types.SQLConnectionParameters{FilePath: path},
+		Options:    types.SQLConnectionOptions{MaxRows: 5},
+	}
+	ctx := context.Background()
+
+	require.NoError(t, svc.TestConnection(ctx, conn))
+
+	result, err := svc.Query(ctx, conn, "SELECT region, SUM(amount) AS total FROM orders GROUP BY region ORDER BY region", 0)
+	require.NoError(t, err)
+	require.Equal(t, []string{"region", "total"}, result.Columns)
+	require.Equal(t, 3, result.RowCount)
+	require.False(t, result.Truncated)
+	require.Equal(t, "east", result.Rows[0][0])
+
+	result, err = svc.Query(ctx, conn, "SELECT * FROM orders", 0)
+	require.NoError(t, err)
+	require.Equal(t, 5, result.RowCount, "capped by the connection limit")
+	require.True(t, result.Truncated)
+
+	result, err = svc.Query(ctx, conn, "SELECT * FROM orders", 2)
+	require.NoError(t, err)
+	require.Equal(t, 2, result.RowCount)
+
+	_, err = svc.Query(ctx, conn, "DELETE FROM orders", 0)
+	require.Error(t, err)
+
+	// Even past the lexical guard, the database itself is read-only.
+	db, err := openSQLConnection(ctx, conn)
+	require.NoError(t, err)
+	defer db.Close()
+	_, err = db.ExecContext(ctx, "DELETE FROM orders")
+	require.Error(t, err)
+}
+
+func TestSQLConnectionQueryTimeout(t *testing.T) {
+	path := newSQLiteFixture(t)
+	svc := NewSQLConnectionService(nil)
+	conn := &types.SQLConnection{
+		Name:       "shop",
+		Dialect:    types.SQLDialectSQLite,
+		Parameters: types.SQLConnectionParameters{FilePath: path},
+		Options:    types.SQLConnectionOptions{TimeoutSec: 1},
+	}
+
+	start := time.Now()
+	_, err := svc.Query(context.Background(), conn,
+		"WITH RECURSIVE c(x) AS (SELECT 1 UNION ALL SELECT x + 1 FROM c) SELECT count(*) FROM c", 0)
+	require.Error(t, err)
+	require.Less(t, time.Since(start), 10*time.Second)
+}
+
+func TestSQLConnectionIntrospectSQLite(t *testing.T) {
+	path := newSQLiteFixture(t)
+	svc := NewSQLConnectionService(nil)
+	conn := &types.SQLConnection{
+		Name:       "shop",
+		Dialect:    types.SQLDialectSQLite,
+		Parameters: types.SQLConnectionParameters{FilePath: path},
+		Options: types.SQLConnectionOptions{
+			TableDescriptions:  map[string]string{"orders": "One row per order"},
+			ColumnDescriptions: map[string]string{"orders.amount": "Order value in CNY"},
+		},
+	}
+
+	schema, err := svc.IntrospectSchema(context.Background(), conn, nil)
+	require.NoError(t, err)
+	require.Len(t, schema.Tables, 1)
+	table := schema.Tables[0]
+	require.Equal(t, "orders", table.Name)
+	require.Equal(t, "One row per order", table.Description)
+	require.Len(t, table.Columns, 4)
+	require.Equal(t, "region", table.Columns[1].Name)
+	require.False(t, table.Columns[1].Nullable)
+	require.Equal(t, "Order value in CNY", table.Columns[2].Description)
+
+	schema, err = svc.IntrospectSchema(context.Background(), conn, []string{"missing"})
+	require.NoError(t, err)
+	require.Empty(t, schema.Tables)
+}
+
+func TestResolveSQLFilePath(t *testing.T) {
+	root := t.TempDir()
+	outside := t.TempDir()
+	inside := filepath.Join(root, "data.db")
+	require.NoError(t, os.WriteFile(inside, []byte{}, 0o644))
+	secret := filepath.Join(outside, "secret.db")
+	require.NoError(t, os.WriteFile(secret, []byte{}, 0o644))
+	link := filepath.Join(root, "link.db")
+	require.NoError(t, os.Symlink(secret, link))
+
+	t.Setenv(sqlFileDirsEnv, "")
+	_, err := resolveSQLFilePath(inside)
+	require.Error(t, err, "file connections are off until the operator opts in")
+
+	t.Setenv(sqlFileDirsEnv, root)
+	got, err := resolveSQLFilePath(inside)
+	require.NoError(t, err)
+	require.Equal(t, filepath.Base(inside), filepath.Base(got))
+
+	for _, bad := range []string{secret, link, filepath.Join(root, "..", filepath.Base(outside), "secret.db"), "data.db", root} {
+		_, err := resolveSQLFilePath(bad)
+		require.Error(t, err, bad)
+	}
+}
+
+func TestValidateSQLConnection(t *testing.T) {
+	valid := &types.SQLConnection{
+		Name:    "warehouse",
+		Dialect: types.SQLDialectPostgres,
+		Parameters: types.SQLConnectionParameters{
+			Host: "db.internal", Database: "dw", Username: "reader",
+		},
+	}
+	require.NoError(t, validateSQLConnection(valid))
+
+	bad := *valid
+	bad.Dialect = "oracle"
+	require.Error(t, validateSQLConnection(&bad))
+
+	bad = *valid
+	bad.Parameters.Host = "db.internal/other?x"
+	require.Error(t, validateSQLConnection(&bad))
+
+	bad = *valid
+	bad.Parameters.Username = ""
+	require.Error(t, validateSQLConnection(&bad))
+}
+
+func TestSQLConnectionQueryDuckDBCSV(t *testing.T) {
+	root := t.TempDir()
+	t.Setenv(sqlFileDirsEnv, root)
+	path := filepath.Join(root, "sales.csv")
+	require.NoError(t, os.WriteFile(path, []byte("region,amount\nnorth,10\nsouth,20\nnorth,5\n"), 0o644))
+
+	svc := NewSQLConnectionService(nil)
+	conn := &types.SQLConnection{
+		Name:       "sales",
+		Dialect:    types.SQLDialectDuckDB,
+		Parameters: types.SQLConnectionParameters{FilePath: path},
+	}
+	ctx := context.Background()
+
+	result, err := svc.Query(ctx, conn, "SELECT region, SUM(amount) AS total FROM sales GROUP BY region ORDER BY region", 0)
+	require.NoError(t, err)
+	require.Equal(t, 2, result.RowCount)
+	require.Equal(t, "north", result.Rows[0][0])
+
+	// The configuration is locked, so the session cannot re-enable file access.
+	db, err := openSQLConnection(ctx, conn)
+	require.NoError(t, err)
+	defer db.Close()
+	_, err = db.ExecContext(ctx, "SET enable_external_access=true")
+	require.Error(t, err)
+}
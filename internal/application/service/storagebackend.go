@@ -22,6 +22,7 @@ type StorageBackendService struct {
 	repo            interfaces.StorageBackendRepository
 	db              *gorm.DB
 	resourceCatalog interfaces.ResourceCatalog
+	encryption      *filesvc.Encryption
 }
 
 // NewStorageBackendService creates a storage backend service. The optional
@@ -46,8 +47,11 @@ func NewStorageBackendServiceWithResources(
 	repo interfaces.StorageBackendRepository,
 	db *gorm.DB,
 	catalog interfaces.ResourceCatalog,
+	encryption *filesvc.Encryption,
 ) *StorageBackendService {
-	return NewStorageBackendService(repo, db, catalog)
+	service := NewStorageBackendService(repo, db, catalog)
+	service.encryption = encryption
+	return service
 }
 
 func (s *StorageBackendService) Create(ctx context.Context, backend *types.StorageBackend) error {
@@ -218,7 +222,7 @@ func (s *StorageBackendService) Test(ctx context.Context, backend *types.Storage
 	c := backend.Config
 	switch backend.Provider {
 	case "local":
-		fileService, _, err := filesvc.NewFileServiceFromStorageConfig("local", backend.ToStorageEngineConfig(), "")
+		fileService, _, err := filesvc.NewFileServiceFromStorageConfig("local", backend.ToStorageEngineConfig(), "", nil)
 		if err != nil {
 			return err
 		}
@@ -287,7 +291,7 @@ func (s *StorageBackendService) ResolveFileService(ctx context.Context, tenant *
 		return nil, "", err
 	}
 	if backend != nil {
-		inner, provider, err := filesvc.NewFileServiceFromStorageConfig(backend.Provider, backend.ToStorageEngineConfig(), localBaseDir)
+		inner, provider, err := filesvc.NewFileServiceFromStorageConfig(backend.Provider, backend.ToStorageEngineConfig(), localBaseDir, s.encryption)
 		if err != nil {
 			return nil, provider, err
 		}
@@ -301,6 +305,7 @@ func (s *StorageBackendService) ResolveFileService(ctx context.Context, tenant *
 		provider,
 		tenant.StorageEngineConfig,
 		localBaseDir,
+		s.encryption,
 	)
 	if err != nil {
 		return nil, resolvedProvider, err
@@ -72,6 +72,7 @@ func (s *tenantAPIKeyService) CreateAPIKey(
 		KnowledgeBaseIDs: normalizeAPIKeyIDs(req.KnowledgeBaseIDs),
 		Capabilities:     capabilities,
 		ExpiresAt:        expiresAt,
+		CreatedBy:        apiKeyCreator(ctx),
 	}
 	if key.FullAccess {
 		key.KnowledgeBaseIDs = nil
@@ -83,6 +84,16 @@ func (s *tenantAPIKeyService) CreateAPIKey(
 	return &interfaces.TenantAPIKeyCreateResult{APIKey: key, Token: token}, nil
 }
 
+// apiKeyCreator returns the user creating a key. A key created by another API
+// key records no creator: that caller's context carries the workspace owner's
+// user, who must not have the key revoked when they are deprovisioned.
+func apiKeyCreator(ctx context.Context) string {
+	if _, isKey := types.TenantAPIKeyScopeFromContext(ctx); isKey {
+		return ""
+	}
+	return auditActor(ctx)
+}
+
 func (s *tenantAPIKeyService) AuthenticateAPIKey(ctx context.Context, token string) (*types.TenantAPIKey, error) {
 	token = strings.TrimSpace(token)
 	if token == "" {
@@ -176,6 +187,10 @@ func (s *tenantAPIKeyService) RevokePlatformAPIKey(ctx context.Context, id uint6
 	return s.repo.RevokePlatformAPIKey(ctx, id)
 }
 
+func (s *tenantAPIKeyService) RevokeAPIKeysCreatedBy(ctx context.Context, tenantID uint64, userID string) (int64, error) {
+	return s.repo.RevokeAPIKeysCreatedBy(ctx, tenantID, userID)
+}
+
 func (s *tenantAPIKeyService) BackfillMissingKeyHashes(ctx context.Context) (int, error) {
 	has, err := s.repo.HasKeysWithPlaceholderHash(ctx)
 	if err != nil {
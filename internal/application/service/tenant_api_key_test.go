@@ -96,6 +96,18 @@ func (r *fakeTenantAPIKeyRepo) RevokeAPIKey(_ context.Context, tenantID uint64,
 	return apprepo.ErrTenantAPIKeyNotFound
 }
 
+func (r *fakeTenantAPIKeyRepo) RevokeAPIKeysCreatedBy(_ context.Context, tenantID uint64, userID string) (int64, error) {
+	now := time.Now()
+	var n int64
+	for _, key := range r.byHash {
+		if key.TenantIDValue() == tenantID && key.CreatedBy == userID && key.RevokedAt == nil {
+			key.RevokedAt = &now
+			n++
+		}
+	}
+	return n, nil
+}
+
 // UpdateAPIKey 模拟仓储的租户边界并覆盖 API Key 的可配置属性。
 // 传入租户 ID、Key ID 和新配置，返回更新后的 Key；跨租户或已撤销目标返回未找到。
 func (r *fakeTenantAPIKeyRepo) UpdateAPIKey(
@@ -0,0 +1,400 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/config"
+	werrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"github.com/Tencent/WeKnora/internal/utils"
+	"gorm.io/gorm"
+)
+
+const (
+	// activeDataKeyTTL bounds how long another instance keeps sealing with a
+	// version that has since been rotated away. Older versions stay valid,
+	// so the lag only affects which version new data gets.
+	activeDataKeyTTL = time.Minute
+	rewrapBatchSize  = 100
+)
+
+// ErrEncryptionNotConfigured is returned by key operations when the
+// deployment has no master key provider.
+var ErrEncryptionNotConfigured = errors.New("encryption at rest is not configured")
+
+type dataKeyRef struct {
+	tenantID uint64
+	version  int
+}
+
+type activeDataKey struct {
+	version int
+	key     []byte
+	expires time.Time
+}
+
+type tenantDataKeyService struct {
+	cfg   *config.EncryptionConfig // nil ⇒ not configured
+	kms   interfaces.KeyManagementService
+	repo  interfaces.TenantDataKeyRepository
+	audit interfaces.AuditLogService // optional; nil ⇒ no audit
+
+	mu     sync.Mutex
+	keys   map[dataKeyRef][]byte
+	active map[uint64]activeDataKey
+}
+
+// NewTenantDataKeyService creates the tenant data key service. Without an
+// encryption key file the service is inert: Configured reports false and key
+// operations return ErrEncryptionNotConfigured. A key file that is set but
+// unusable fails startup, rather than silently storing plaintext.
+func NewTenantDataKeyService(
+	cfg *config.Config,
+	repo interfaces.TenantDataKeyRepository,
+	audit interfaces.AuditLogService,
+) (interfaces.TenantDataKeyService, error) {
+	s := &tenantDataKeyService{
+		repo:   repo,
+		audit:  audit,
+		keys:   make(map[dataKeyRef][]byte),
+		active: make(map[uint64]activeDataKey),
+	}
+	if cfg == nil || !cfg.Encryption.Configured() {
+		return s, nil
+	}
+	kms, err := NewLocalKMS(cfg.Encryption.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("encryption: %w", err)
+	}
+	s.cfg = cfg.Encryption
+	s.kms = kms
+	return s, nil
+}
+
+func (s *tenantDataKeyService) Configured() bool {
+	return s.kms != nil
+}
+
+func (s *tenantDataKeyService) ActiveDataKey(ctx context.Context, tenantID uint64) (int, []byte, error) {
+	if s.kms == nil {
+		return 0, nil, ErrEncryptionNotConfigured
+	}
+	s.mu.Lock()
+	cached, ok := s.active[tenantID]
+	s.mu.Unlock()
+	if ok && time.Now().Before(cached.expires) {
+		return cached.version, cached.key, nil
+	}
+
+	row, err := s.repo.GetActive(ctx, tenantID)
+	if err != nil {
+		return 0, nil, err
+	}
+	if row == nil {
+		row, err = s.createDataKey(ctx, tenantID, 1)
+		if err != nil {
+			// Another request may have created version 1 first.
+			if row, _ = s.repo.GetActive(ctx, tenantID); row == nil {
+				return 0, nil, err
+			}
+		}
+	}
+	key, err := s.unwrapRow(ctx, row)
+	if err != nil {
+		return 0, nil, err
+	}
+	s.mu.Lock()
+	s.active[tenantID] = activeDataKey{version: row.Version, key: key, expires: time.Now().Add(activeDataKeyTTL)}
+	s.mu.Unlock()
+	return row.Version, key, nil
+}
+
+func (s *tenantDataKeyService) DataKey(ctx context.Context, tenantID uint64, version int) ([]byte, error) {
+	if s.kms == nil {
+		return nil, ErrEncryptionNotConfigured
+	}
+	ref := dataKeyRef{tenantID: tenantID, version: version}
+	s.mu.Lock()
+	key, ok := s.keys[ref]
+	s.mu.Unlock()
+	if ok {
+		return key, nil
+	}
+	row, err := s.repo.Get(ctx, tenantID, version)
+	if err != nil {
+		return nil, err
+	}
+	if row == nil {
+		return nil, fmt.Errorf("data key version %d of tenant %d not found", version, tenantID)
+	}
+	return s.unwrapRow(ctx, row)
+}
+
+// unwrapRow opens a stored data key and caches it. The plaintext key of a
+// version never changes (a re-wrap only changes how it is stored), so
+// cached entries need no invalidation.
+func (s *tenantDataKeyService) unwrapRow(ctx context.Context, row *types.TenantDataKey) ([]byte, error) {
+	ref := dataKeyRef{tenantID: row.TenantID, version: row.Version}
+	s.mu.Lock()
+	key, ok := s.keys[ref]
+	s.mu.Unlock()
+	if ok {
+		return key, nil
+	}
+	wrapped, err := base64.StdEncoding.DecodeString(row.WrappedKey)
+	if err != nil {
+		return nil, fmt.Errorf("data key %d: %w", row.ID, err)
+	}
+	key, err = s.kms.Unwrap(ctx, row.MasterKeyID, wrapped)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	s.keys[ref] = key
+	s.mu.Unlock()
+	return key, nil
+}
+
+func (s *tenantDataKeyService) createDataKey(ctx context.Context, tenantID uint64, version int) (*types.TenantDataKey, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	keyID, wrapped, err := s.kms.Wrap(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	row := &types.TenantDataKey{
+		TenantID:    tenantID,
+		Version:     version,
+		MasterKeyID: keyID,
+		WrappedKey:  base64.StdEncoding.EncodeToString(wrapped),
+	}
+	if err := s.repo.CreateActive(ctx, row); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	s.keys[dataKeyRef{tenantID: tenantID, version: version}] = key
+	delete(s.active, tenantID)
+	s.mu.Unlock()
+	return row, nil
+}
+
+func (s *tenantDataKeyService) Status(ctx context.Context, tenantID uint64) (*types.TenantEncryptionStatus, error) {
+	status := &types.TenantEncryptionStatus{Keys: []*types.TenantDataKey{}}
+	if s.kms == nil {
+		return status, nil
+	}
+	status.Configured = true
+	status.FilesEncrypted = s.cfg.Files
+	status.ChunkContentEncrypted = s.cfg.ChunkContent
+	currentID, err := s.kms.CurrentKeyID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	status.CurrentMasterKeyID = currentID
+	keys, err := s.repo.ListByTenant(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	status.Keys = keys
+	for _, k := range keys {
+		if k.Active {
+			status.ActiveKeyVersion = k.Version
+		}
+	}
+	return status, nil
+}
+
+func (s *tenantDataKeyService) SystemStatus(ctx context.Context) (*types.EncryptionSystemStatus, error) {
+	status := &types.EncryptionSystemStatus{}
+	if s.kms == nil {
+		return status, nil
+	}
+	status.Configured = true
+	status.FilesEncrypted = s.cfg.Files
+	status.ChunkContentEncrypted = s.cfg.ChunkContent
+	currentID, err := s.kms.CurrentKeyID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	status.CurrentMasterKeyID = currentID
+	if status.DataKeys, err = s.repo.Count(ctx); err != nil {
+		return nil, err
+	}
+	if status.PendingRewrap, err = s.repo.CountNotWrappedBy(ctx, currentID); err != nil {
+		return nil, err
+	}
+	return status, nil
+}
+
+func (s *tenantDataKeyService) RotateTenantKey(ctx context.Context, tenantID uint64) (*types.TenantDataKey, error) {
+	if s.kms == nil {
+		return nil, werrors.NewBadRequestError(ErrEncryptionNotConfigured.Error())
+	}
+	keys, err := s.repo.ListByTenant(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	next := 1
+	for _, k := range keys {
+		if k.Version >= next {
+			next = k.Version + 1
+		}
+	}
+	row, err := s.createDataKey(ctx, tenantID, next)
+	if err != nil {
+		if existing, _ := s.repo.Get(ctx, tenantID, next); existing != nil {
+			return nil, werrors.NewConflictError("a concurrent key rotation is in progress")
+		}
+		return nil, err
+	}
+	details, _ := json.Marshal(map[string]any{"version": row.Version, "master_key_id": row.MasterKeyID})
+	s.emitAudit(ctx, &types.AuditLog{
+		TenantID:    tenantID,
+		ActorUserID: auditActor(ctx),
+		ActorRole:   auditActorRole(ctx),
+		Action:      types.AuditActionEncryptionKeyRotated,
+		TargetType:  "data_key",
+		TargetID:    strconv.Itoa(row.Version),
+		Outcome:     types.AuditOutcomeSuccess,
+		Details:     types.JSON(details),
+	})
+	logger.Infof(ctx, "[crypto] tenant %d data key rotated to version %d", tenantID, row.Version)
+	return row, nil
+}
+
+func (s *tenantDataKeyService) RewrapDataKeys(ctx context.Context) (*types.RewrapResult, error) {
+	if s.kms == nil {
+		return nil, werrors.NewBadRequestError(ErrEncryptionNotConfigured.Error())
+	}
+	currentID, err := s.kms.CurrentKeyID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result := &types.RewrapResult{MasterKeyID: currentID}
+	var afterID uint64
+	for {
+		batch, err := s.repo.ListNotWrappedBy(ctx, currentID, afterID, rewrapBatchSize)
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range batch {
+			afterID = row.ID
+			key, err := s.unwrapRow(ctx, row)
+			if err != nil {
+				return nil, fmt.Errorf("re-wrap data key %d: %w", row.ID, err)
+			}
+			keyID, wrapped, err := s.kms.Wrap(ctx, key)
+			if err != nil {
+				return nil, err
+			}
+			if err := s.repo.UpdateWrapping(ctx, row.ID, keyID, base64.StdEncoding.EncodeToString(wrapped)); err != nil {
+				return nil, err
+			}
+			result.Rewrapped++
+		}
+		if len(batch) < rewrapBatchSize {
+			break
+		}
+	}
+	total, err := s.repo.Count(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result.Total = int(total)
+
+	details, _ := json.Marshal(result)
+	s.emitAudit(ctx, &types.AuditLog{
+		ActorUserID: auditActor(ctx),
+		Action:      types.AuditActionEncryptionKeysRewrapped,
+		TargetType:  "master_key",
+		TargetID:    currentID,
+		Outcome:     types.AuditOutcomeSuccess,
+		Details:     types.JSON(details),
+	})
+	logger.Infof(ctx, "[crypto] re-wrapped %d of %d data keys with master key %s", result.Rewrapped, result.Total, currentID)
+	return result, nil
+}
+
+// chunkContentAAD binds sealed chunk text to its tenant, so a value copied
+// into another tenant's row fails to open.
+func chunkContentAAD(tenantID uint64) string {
+	return "chunk:" + strconv.FormatUint(tenantID, 10)
+}
+
+func (s *tenantDataKeyService) SealContent(ctx context.Context, tenantID uint64, plaintext string) (string, error) {
+	if s.kms == nil || !s.cfg.ChunkContent {
+		return plaintext, nil
+	}
+	version, key, err := s.ActiveDataKey(ctx, tenantID)
+	if err != nil {
+		return "", err
+	}
+	return utils.SealEnvelopeString(plaintext, key, version, chunkContentAAD(tenantID))
+}
+
+func (s *tenantDataKeyService) OpenContent(ctx context.Context, tenantID uint64, sealed string) (string, error) {
+	version, ok, err := utils.EnvelopeStringVersion(sealed)
+	if err != nil || !ok {
+		return sealed, err
+	}
+	key, err := s.DataKey(ctx, tenantID, version)
+	if err != nil {
+		return "", err
+	}
+	return utils.OpenEnvelopeString(sealed, key, chunkContentAAD(tenantID))
+}
+
+// contentCipherPlugin registers the tenant data keys on the database as the
+// cipher the chunk model hooks seal and open content with.
+type contentCipherPlugin struct {
+	keys interfaces.TenantDataKeyService
+}
+
+// NewContentCipherPlugin wraps keys for registration with db.Use. Without a
+// configured key provider content is written in plaintext, and sealed values
+// read back are left sealed.
+func NewContentCipherPlugin(keys interfaces.TenantDataKeyService) types.ContentCipherPlugin {
+	return &contentCipherPlugin{keys: keys}
+}
+
+func (p *contentCipherPlugin) Name() string {
+	return types.ContentCipherPluginName
+}
+
+func (p *contentCipherPlugin) Initialize(*gorm.DB) error {
+	return nil
+}
+
+func (p *contentCipherPlugin) SealContent(ctx context.Context, tenantID uint64, plaintext string) (string, error) {
+	return p.keys.SealContent(ctx, tenantID, plaintext)
+}
+
+func (p *contentCipherPlugin) OpenContent(ctx context.Context, tenantID uint64, sealed string) (string, error) {
+	plain, err := p.keys.OpenContent(ctx, tenantID, sealed)
+	if err != nil {
+		if !p.keys.Configured() {
+			err = types.ErrContentCipherMissing
+		}
+		logger.Warnf(ctx, "[crypto] content of tenant %d cannot be decrypted, left sealed: %v", tenantID, err)
+	}
+	return plain, err
+}
+
+func (s *tenantDataKeyService) emitAudit(ctx context.Context, entry *types.AuditLog) {
+	if s.audit == nil {
+		return
+	}
+	_ = s.audit.Log(ctx, entry)
+}
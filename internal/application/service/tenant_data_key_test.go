@@ -0,0 +1,213 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"github.com/Tencent/WeKnora/internal/application/repository"
+	"github.com/Tencent/WeKnora/internal/config"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"github.com/Tencent/WeKnora/internal/utils"
+)
+
+func writeMasterKeyFile(t *testing.T, path, current string, ids ...string) {
+	t.Helper()
+	keys := map[string]string{}
+	for _, id := range ids {
+		key := make([]byte, 32)
+		_, _ = rand.Read(key)
+		keys[id] = base64.StdEncoding.EncodeToString(key)
+	}
+	if raw, err := os.ReadFile(path); err == nil {
+		// Keep existing entries so previously wrapped keys still open.
+		var prev localKeyFile
+		require.NoError(t, json.Unmarshal(raw, &prev))
+		for id, k := range prev.Keys {
+			keys[id] = k
+		}
+	}
+	raw, err := json.Marshal(localKeyFile{Current: current, Keys: keys})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, raw, 0o600))
+}
+
+func newTestDataKeyService(
+	t *testing.T, keyFile string, chunkContent bool,
+) (interfaces.TenantDataKeyService, interfaces.TenantDataKeyRepository) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&types.TenantDataKey{}))
+	repo := repository.NewTenantDataKeyRepository(db)
+	cfg := &config.Config{Encryption: &config.EncryptionConfig{ChunkContent: chunkContent, KeyFile: keyFile}}
+	svc, err := NewTenantDataKeyService(cfg, repo, nil)
+	require.NoError(t, err)
+	return svc, repo
+}
+
+func TestTenantDataKeyService_NotConfigured(t *testing.T) {
+	svc, err := NewTenantDataKeyService(&config.Config{}, nil, nil)
+	require.NoError(t, err)
+	assert.False(t, svc.Configured())
+	_, _, err = svc.ActiveDataKey(context.Background(), 1)
+	assert.ErrorIs(t, err, ErrEncryptionNotConfigured)
+	sealed, err := svc.SealContent(context.Background(), 1, "text")
+	require.NoError(t, err)
+	assert.Equal(t, "text", sealed)
+}
+
+func TestContentCipherPlugin_RegisteredOnDB(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.json")
+	writeMasterKeyFile(t, path, "m1", "m1")
+	svc, _ := newTestDataKeyService(t, path, true)
+	sealed, err := svc.SealContent(context.Background(), 7, "text")
+	require.NoError(t, err)
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.Use(NewContentCipherPlugin(svc)))
+	plain, err := types.OpenChunkContent(context.Background(), types.ContentCipherOf(db.WithContext(context.Background())), 7, sealed)
+	require.NoError(t, err)
+	assert.Equal(t, "text", plain)
+
+	// Without a key provider sealed values are reported, not opened.
+	inert, err := NewTenantDataKeyService(&config.Config{}, nil, nil)
+	require.NoError(t, err)
+	_, err = NewContentCipherPlugin(inert).OpenContent(context.Background(), 7, sealed)
+	assert.ErrorIs(t, err, types.ErrContentCipherMissing)
+}
+
+// TestContentCipherPlugin_KnowledgeVersionsSurviveRotation seals a version,
+// rotates the tenant key and seals another: both keep their key version and
+// still open.
+func TestContentCipherPlugin_KnowledgeVersionsSurviveRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.json")
+	writeMasterKeyFile(t, path, "m1", "m1")
+	svc, _ := newTestDataKeyService(t, path, true)
+	ctx := context.Background()
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&types.KnowledgeVersion{}))
+	require.NoError(t, db.Use(NewContentCipherPlugin(svc)))
+	repo := repository.NewKnowledgeVersionRepository(db)
+
+	require.NoError(t, repo.Create(ctx, &types.KnowledgeVersion{TenantID: 7, KnowledgeID: "doc", Markdown: "v1"}))
+	_, err = svc.RotateTenantKey(ctx, 7)
+	require.NoError(t, err)
+	require.NoError(t, repo.Create(ctx, &types.KnowledgeVersion{TenantID: 7, KnowledgeID: "doc", Markdown: "v2"}))
+
+	for n, want := range map[int]string{1: "v1", 2: "v2"} {
+		var stored string
+		require.NoError(t, db.Raw("SELECT markdown FROM knowledge_versions WHERE version = ?", n).Scan(&stored).Error)
+		keyVersion, _, _ := utils.EnvelopeStringVersion(stored)
+		assert.Equal(t, n, keyVersion)
+		v, err := repo.Get(ctx, 7, "doc", n)
+		require.NoError(t, err)
+		assert.Equal(t, want, v.Markdown)
+	}
+}
+
+func TestTenantDataKeyService_BadKeyFileFailsStartup(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"current":"a","keys":{"a":"c2hvcnQ="}}`), 0o600))
+	_, err := NewTenantDataKeyService(&config.Config{Encryption: &config.EncryptionConfig{KeyFile: path}}, nil, nil)
+	assert.Error(t, err)
+}
+
+func TestTenantDataKeyService_SealRotateAndOpen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.json")
+	writeMasterKeyFile(t, path, "m1", "m1")
+	svc, _ := newTestDataKeyService(t, path, true)
+	ctx := context.Background()
+
+	v1, err := svc.SealContent(ctx, 7, "机密段落")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(v1, utils.EnvelopeContentPrefix))
+
+	rotated, err := svc.RotateTenantKey(ctx, 7)
+	require.NoError(t, err)
+	assert.Equal(t, 2, rotated.Version)
+	v2, err := svc.SealContent(ctx, 7, "新段落")
+	require.NoError(t, err)
+	version, _, _ := utils.EnvelopeStringVersion(v2)
+	assert.Equal(t, 2, version)
+
+	// Both versions open; another tenant's rows cannot be swapped in.
+	plain, err := svc.OpenContent(ctx, 7, v1)
+	require.NoError(t, err)
+	assert.Equal(t, "机密段落", plain)
+	plain, err = svc.OpenContent(ctx, 7, v2)
+	require.NoError(t, err)
+	assert.Equal(t, "新段落", plain)
+	_, err = svc.OpenContent(ctx, 8, v1)
+	assert.Error(t, err)
+
+	status, err := svc.Status(ctx, 7)
+	require.NoError(t, err)
+	assert.Equal(t, 2, status.ActiveKeyVersion)
+	assert.Len(t, status.Keys, 2)
+}
+
+func TestTenantDataKeyService_SealOffLeavesPlaintext(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.json")
+	writeMasterKeyFile(t, path, "m1", "m1")
+	svc, _ := newTestDataKeyService(t, path, false)
+	sealed, err := svc.SealContent(context.Background(), 7, "plain")
+	require.NoError(t, err)
+	assert.Equal(t, "plain", sealed)
+}
+
+// TestTenantDataKeyService_RewrapAfterMasterRotation rotates the master key,
+// re-wraps, then drops the old master key: data sealed before must still
+// open through a fresh service (no warm cache).
+func TestTenantDataKeyService_RewrapAfterMasterRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.json")
+	writeMasterKeyFile(t, path, "m1", "m1")
+	svc, repo := newTestDataKeyService(t, path, true)
+	ctx := context.Background()
+
+	sealedA, err := svc.SealContent(ctx, 1, "tenant one")
+	require.NoError(t, err)
+	sealedB, err := svc.SealContent(ctx, 2, "tenant two")
+	require.NoError(t, err)
+
+	writeMasterKeyFile(t, path, "m2", "m2")
+	status, err := svc.SystemStatus(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "m2", status.CurrentMasterKeyID)
+	assert.Equal(t, int64(2), status.PendingRewrap)
+
+	result, err := svc.RewrapDataKeys(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, &types.RewrapResult{MasterKeyID: "m2", Rewrapped: 2, Total: 2}, result)
+
+	var file localKeyFile
+	raw, _ := os.ReadFile(path)
+	require.NoError(t, json.Unmarshal(raw, &file))
+	delete(file.Keys, "m1")
+	raw, _ = json.Marshal(file)
+	require.NoError(t, os.WriteFile(path, raw, 0o600))
+
+	fresh, err := NewTenantDataKeyService(
+		&config.Config{Encryption: &config.EncryptionConfig{ChunkContent: true, KeyFile: path}}, repo, nil)
+	require.NoError(t, err)
+	plain, err := fresh.OpenContent(ctx, 1, sealedA)
+	require.NoError(t, err)
+	assert.Equal(t, "tenant one", plain)
+	plain, err = fresh.OpenContent(ctx, 2, sealedB)
+	require.NoError(t, err)
+	assert.Equal(t, "tenant two", plain)
+}
@@ -325,7 +325,9 @@ func (s *tenantMemberService) UpdateRole(
 	if current == nil {
 		return ErrMembershipNotFound
 	}
-	if current.Role == newRole {
+	// Same built-in role is a no-op, unless it is the base of a custom role
+	// the caller is replacing with the plain built-in one.
+	if current.Role == newRole && current.CustomRoleID == nil {
 		return nil
 	}
 	oldRole := current.Role
@@ -353,6 +355,55 @@ func (s *tenantMemberService) UpdateRole(
 	return nil
 }
 
+// AssignCustomRole gives the member a custom role. The repository keeps
+// the last-Owner invariant, since a custom role is never Owner-based.
+func (s *tenantMemberService) AssignCustomRole(
+	ctx context.Context,
+	userID string,
+	tenantID uint64,
+	role *types.TenantCustomRole,
+) error {
+	if role == nil || !types.IsValidCustomRoleBase(role.BaseRole) {
+		return ErrInvalidTenantRole
+	}
+	current, err := s.repo.Get(ctx, userID, tenantID)
+	if err != nil {
+		return err
+	}
+	if current == nil {
+		return ErrMembershipNotFound
+	}
+	if current.CustomRoleID != nil && *current.CustomRoleID == role.ID {
+		return nil
+	}
+	err = s.repo.AssignCustomRole(ctx, userID, tenantID, role)
+	switch {
+	case errors.Is(err, apprepo.ErrLastOwner):
+		return ErrLastOwner
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return ErrMembershipNotFound
+	case err != nil:
+		return err
+	}
+	details, _ := json.Marshal(map[string]any{
+		"old_role":         string(current.Role),
+		"new_role":         string(role.BaseRole),
+		"custom_role_id":   role.ID,
+		"custom_role_name": role.Name,
+	})
+	s.emitAudit(ctx, &types.AuditLog{
+		TenantID:     tenantID,
+		ActorUserID:  auditActor(ctx),
+		ActorRole:    auditActorRole(ctx),
+		Action:       types.AuditActionMemberRoleChanged,
+		TargetType:   "tenant_member",
+		TargetUserID: userID,
+		Outcome:      types.AuditOutcomeSuccess,
+		Details:      types.JSON(details),
+	})
+	return nil
+}
+
 // emitRoleChangeAudit packs the old/new role into Details so the
 // audit-log UI can render "promoted Alice from contributor to admin"
 // without a separate column per role transition.
@@ -143,6 +143,7 @@ func (r *fakeTenantMemberRepo) UpdateRole(ctx context.Context, userID string, te
 	for _, e := range r.rows {
 		if e.UserID == userID && e.TenantID == tenantID && !e.DeletedAt.Valid {
 			e.Role = role
+			e.CustomRoleID = nil
 			return nil
 		}
 	}
@@ -217,10 +218,39 @@ func (r *fakeTenantMemberRepo) DemoteOwnerAtomically(
 		return gormErrRecordNotFound
 	}
 	target.Role = newRole
+	target.CustomRoleID = nil
 	target.UpdatedAt = time.Now()
 	return nil
 }
 
+func (r *fakeTenantMemberRepo) AssignCustomRole(
+	ctx context.Context, userID string, tenantID uint64, role *types.TenantCustomRole,
+) error {
+	others := int64(0)
+	var target *types.TenantMember
+	for _, e := range r.rows {
+		if e.TenantID != tenantID || e.DeletedAt.Valid || e.Status != types.TenantMemberStatusActive {
+			continue
+		}
+		if e.Role == types.TenantRoleOwner && e.UserID != userID {
+			others++
+		}
+		if e.UserID == userID {
+			target = e
+		}
+	}
+	if target == nil {
+		return gormErrRecordNotFound
+	}
+	if target.Role == types.TenantRoleOwner && others == 0 {
+		return apprepo.ErrLastOwner
+	}
+	id := role.ID
+	target.Role = role.BaseRole
+	target.CustomRoleID = &id
+	return nil
+}
+
 func (r *fakeTenantMemberRepo) RemoveOwnerAtomically(
 	ctx context.Context, userID string, tenantID uint64,
 ) error {
@@ -547,6 +577,44 @@ func TestTenantMemberService_UpdateRole_ReturnsNotFound(t *testing.T) {
 	}
 }
 
+func TestTenantMemberService_AssignCustomRole_BlocksDemotingLastOwner(t *testing.T) {
+	svc, repo := newServiceWithRepo()
+	ctx := context.Background()
+	if _, err := svc.EnsureOwner(ctx, "owner", 1); err != nil {
+		t.Fatalf("seed: %v", err)
+	}
+	role := &types.TenantCustomRole{ID: 9, TenantID: 1, Name: "analyst", BaseRole: types.TenantRoleAdmin}
+	if err := svc.AssignCustomRole(ctx, "owner", 1, role); !errors.Is(err, ErrLastOwner) {
+		t.Fatalf("want ErrLastOwner, got %v", err)
+	}
+	if repo.rows[0].Role != types.TenantRoleOwner || repo.rows[0].CustomRoleID != nil {
+		t.Fatalf("rejected assignment must leave the row unchanged: %+v", repo.rows[0])
+	}
+}
+
+func TestTenantMemberService_UpdateRole_BuiltInRoleClearsCustomRole(t *testing.T) {
+	svc, repo := newServiceWithRepo()
+	ctx := context.Background()
+	if _, err := svc.AddMember(ctx, "u1", 1, types.TenantRoleViewer, nil); err != nil {
+		t.Fatalf("seed: %v", err)
+	}
+	role := &types.TenantCustomRole{ID: 9, TenantID: 1, Name: "chat-only", BaseRole: types.TenantRoleViewer}
+	if err := svc.AssignCustomRole(ctx, "u1", 1, role); err != nil {
+		t.Fatalf("AssignCustomRole: %v", err)
+	}
+	if repo.rows[0].CustomRoleID == nil || *repo.rows[0].CustomRoleID != 9 {
+		t.Fatalf("custom role not recorded: %+v", repo.rows[0])
+	}
+	// Same built-in role as the custom role's base: not a no-op, it drops
+	// the narrowing.
+	if err := svc.UpdateRole(ctx, "u1", 1, types.TenantRoleViewer); err != nil {
+		t.Fatalf("UpdateRole: %v", err)
+	}
+	if repo.rows[0].CustomRoleID != nil {
+		t.Fatalf("built-in role must clear the custom role: %+v", repo.rows[0])
+	}
+}
+
 func TestTenantMemberService_RemoveMember_BlocksLastOwner(t *testing.T) {
 	svc, _ := newServiceWithRepo()
 	ctx := context.Background()
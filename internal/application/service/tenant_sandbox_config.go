@@ -105,7 +105,7 @@ var ErrSandboxConfigNameRequired = stderrors.New("sandbox config name is require
 // ErrNamedSandboxBackendUnsupported marks a sandbox type that cannot be stored
 // as a user-facing named backend config.
 var ErrNamedSandboxBackendUnsupported = stderrors.New(
-	"named sandbox configs only support cube, e2b, docker and local backends",
+	"named sandbox configs only support cube, e2b, namespace, wasm, docker and local backends",
 )
 
 // SandboxInventory describes what a config holds and who a change disturbs.
@@ -707,7 +707,7 @@ func (s *TenantSandboxConfigService) clientFor(
 		return nil, err
 	}
 	switch effective.Type {
-	case sandbox.SandboxTypeCube, sandbox.SandboxTypeE2B:
+	case sandbox.SandboxTypeCube, sandbox.SandboxTypeE2B, sandbox.SandboxTypeNamespace:
 		return s.newClient(effective)
 	default:
 		return nil, nil
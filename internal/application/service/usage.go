@@ -0,0 +1,577 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+const (
+	// usageFlushInterval and usageFlushBatch bound how long and how many
+	// records wait in memory before they are written.
+	usageFlushInterval = 2 * time.Second
+	usageFlushBatch    = 200
+	// usageMaxPending caps the buffer when the database is unavailable;
+	// records beyond it are dropped with a warning rather than growing
+	// without bound.
+	usageMaxPending = 20000
+	// usageBudgetCacheTTL is how long budgets and month-to-date sums are
+	// reused before they are read again. Calls recorded by this replica are
+	// added to the cached sums immediately; other replicas' calls show up
+	// after the next reload.
+	usageBudgetCacheTTL = 30 * time.Second
+	// usageAuditScope is the audit scope type of budget events.
+	usageAuditScope = "usage_budget"
+)
+
+type usageScopeKey struct {
+	tenantID uint64
+	apiKeyID uint64
+	period   string
+}
+
+type cachedUsageBudgets struct {
+	budgets  []*types.UsageBudget
+	loadedAt time.Time
+}
+
+type cachedUsageSum struct {
+	tokens   int64
+	loadedAt time.Time
+}
+
+type usageService struct {
+	repo       interfaces.UsageRepository
+	apiKeyRepo interfaces.TenantAPIKeyRepository
+	audit      interfaces.AuditLogService
+	now        func() time.Time
+
+	pendingMu sync.Mutex
+	pending   []*types.UsageRecord
+	flushCh   chan struct{}
+	stopCh    chan struct{}
+	doneCh    chan struct{}
+	closeOnce sync.Once
+
+	cacheMu sync.Mutex
+	budgets map[uint64]*cachedUsageBudgets
+	sums    map[usageScopeKey]*cachedUsageSum
+}
+
+// NewUsageService creates the usage service and starts its background writer.
+func NewUsageService(
+	repo interfaces.UsageRepository,
+	apiKeyRepo interfaces.TenantAPIKeyRepository,
+	audit interfaces.AuditLogService,
+) interfaces.UsageService {
+	s := &usageService{
+		repo:       repo,
+		apiKeyRepo: apiKeyRepo,
+		audit:      audit,
+		now:        time.Now,
+		flushCh:    make(chan struct{}, 1),
+		stopCh:     make(chan struct{}),
+		doneCh:     make(chan struct{}),
+		budgets:    make(map[uint64]*cachedUsageBudgets),
+		sums:       make(map[usageScopeKey]*cachedUsageSum),
+	}
+	go s.writeLoop()
+	return s
+}
+
+// Record implements metering.Meter.
+func (s *usageService) Record(ctx context.Context, ev types.UsageEvent) {
+	tenantID, ok := types.TenantIDFromContext(ctx)
+	if !ok || tenantID == 0 {
+		return
+	}
+	if ev.PromptTokens == 0 && ev.CompletionTokens == 0 && ev.EmbeddingTokens == 0 && ev.Calls == 0 {
+		return
+	}
+	now := s.now().UTC()
+	rec := &types.UsageRecord{
+		TenantID:         tenantID,
+		AgentID:          types.MemoryAgentFromContext(ctx),
+		KnowledgeBaseID:  types.UsageKnowledgeBaseFromContext(ctx),
+		Kind:             ev.Kind,
+		ModelID:          ev.ModelID,
+		ModelName:        ev.ModelName,
+		PromptTokens:     ev.PromptTokens,
+		CompletionTokens: ev.CompletionTokens,
+		CachedTokens:     ev.CachedTokens,
+		EmbeddingTokens:  ev.EmbeddingTokens,
+		Calls:            ev.Calls,
+		Period:           now.Format(types.UsagePeriodFormat),
+		Day:              now.Format(types.UsageDayFormat),
+		CreatedAt:        now,
+	}
+	if p, ok := types.PrincipalFromContext(ctx); ok && p.Type == types.PrincipalWebUser &&
+		!types.IsSyntheticUserID(p.ID) {
+		rec.UserID = p.ID
+	}
+	if scope, ok := types.TenantAPIKeyScopeFromContext(ctx); ok {
+		rec.APIKeyID = scope.KeyID
+	}
+	if sessionID, ok := types.SessionIDFromContext(ctx); ok {
+		rec.SessionID = sessionID
+	}
+
+	s.addToCachedSums(rec)
+
+	s.pendingMu.Lock()
+	if len(s.pending) >= usageMaxPending {
+		s.pendingMu.Unlock()
+		logger.Warnf(ctx, "[Usage] ledger buffer full, dropping %s record for tenant %d", rec.Kind, tenantID)
+		return
+	}
+	s.pending = append(s.pending, rec)
+	full := len(s.pending) >= usageFlushBatch
+	s.pendingMu.Unlock()
+	if full {
+		select {
+		case s.flushCh <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// addToCachedSums counts a record against the cached month-to-date sums it
+// belongs to, so budgets react before the record is written and reloaded.
+func (s *usageService) addToCachedSums(rec *types.UsageRecord) {
+	tokens := rec.BillableTokens()
+	if tokens == 0 {
+		return
+	}
+	s.cacheMu.Lock()
+	defer s.cacheMu.Unlock()
+	if sum, ok := s.sums[usageScopeKey{tenantID: rec.TenantID, period: rec.Period}]; ok {
+		sum.tokens += tokens
+	}
+	if rec.APIKeyID != 0 {
+		if sum, ok := s.sums[usageScopeKey{tenantID: rec.TenantID, apiKeyID: rec.APIKeyID, period: rec.Period}]; ok {
+			sum.tokens += tokens
+		}
+	}
+}
+
+func (s *usageService) writeLoop() {
+	defer close(s.doneCh)
+	ticker := time.NewTicker(usageFlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.flush()
+		case <-s.flushCh:
+			s.flush()
+		case <-s.stopCh:
+			s.flush()
+			return
+		}
+	}
+}
+
+func (s *usageService) flush() {
+	s.pendingMu.Lock()
+	batch := s.pending
+	s.pending = nil
+	s.pendingMu.Unlock()
+	if len(batch) == 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := s.repo.CreateRecords(ctx, batch); err != nil {
+		logger.Warnf(ctx, "[Usage] failed to write %d ledger records: %v", len(batch), err)
+	}
+}
+
+// Close stops the writer after writing buffered records.
+func (s *usageService) Close() error {
+	s.closeOnce.Do(func() {
+		close(s.stopCh)
+		<-s.doneCh
+	})
+	return nil
+}
+
+// CheckBudget implements metering.Meter.
+func (s *usageService) CheckBudget(ctx context.Context) error {
+	_, err := s.EvaluateBudgets(ctx)
+	return err
+}
+
+func (s *usageService) EvaluateBudgets(ctx context.Context) (string, error) {
+	tenantID, ok := types.TenantIDFromContext(ctx)
+	if !ok || tenantID == 0 {
+		return "", nil
+	}
+	budgets, err := s.cachedBudgets(ctx, tenantID)
+	if err != nil {
+		// Budgets are a cost control, not an access control: a database
+		// hiccup must not take chat down with it.
+		logger.Warnf(ctx, "[Usage] failed to load budgets for tenant %d: %v", tenantID, err)
+		return "", nil
+	}
+	if len(budgets) == 0 {
+		return "", nil
+	}
+	var apiKeyID uint64
+	if scope, ok := types.TenantAPIKeyScopeFromContext(ctx); ok {
+		apiKeyID = scope.KeyID
+	}
+	period := types.UsagePeriod(s.now())
+
+	var warning string
+	for _, b := range budgets {
+		if b.APIKeyID != 0 && b.APIKeyID != apiKeyID {
+			continue
+		}
+		used, err := s.cachedSum(ctx, tenantID, b.APIKeyID, period)
+		if err != nil {
+			logger.Warnf(ctx, "[Usage] failed to sum usage for tenant %d: %v", tenantID, err)
+			continue
+		}
+		switch b.StateFor(used) {
+		case types.UsageBudgetStateExceeded:
+			s.notify(ctx, b, true, period, used)
+			if b.HardStop {
+				return "", errors.NewUsageBudgetExceededError(
+					fmt.Sprintf("monthly token budget of %s exceeded (%d/%d tokens in %s)",
+						usageBudgetScopeLabel(b), used, b.MonthlyTokenLimit, period),
+				).WithDetails(map[string]any{
+					"api_key_id":          b.APIKeyID,
+					"period":              period,
+					"monthly_token_limit": b.MonthlyTokenLimit,
+					"used_tokens":         used,
+				})
+			}
+			warning = usageBudgetWarning(b, used, period)
+		case types.UsageBudgetStateWarning:
+			s.notify(ctx, b, false, period, used)
+			if warning == "" {
+				warning = usageBudgetWarning(b, used, period)
+			}
+		}
+	}
+	return warning, nil
+}
+
+func usageBudgetScopeLabel(b *types.UsageBudget) string {
+	if b.APIKeyID != 0 {
+		return fmt.Sprintf("API key %d", b.APIKeyID)
+	}
+	return "the workspace"
+}
+
+// usageBudgetWarning renders the X-WeKnora-Usage-Warning header value, so it
+// stays plain ASCII.
+func usageBudgetWarning(b *types.UsageBudget, used int64, period string) string {
+	scope := "workspace"
+	if b.APIKeyID != 0 {
+		scope = fmt.Sprintf("api_key=%d", b.APIKeyID)
+	}
+	return fmt.Sprintf("%s budget %d%% used (%d/%d tokens, %s)",
+		scope, used*100/b.MonthlyTokenLimit, used, b.MonthlyTokenLimit, period)
+}
+
+// notify logs and audits a budget crossing its warning threshold or limit,
+// once per budget and period across replicas.
+func (s *usageService) notify(ctx context.Context, b *types.UsageBudget, exceeded bool, period string, used int64) {
+	s.cacheMu.Lock()
+	already := b.WarnedPeriod == period
+	if exceeded {
+		already = b.ExceededPeriod == period
+	}
+	s.cacheMu.Unlock()
+	if already {
+		return
+	}
+	marked, err := s.repo.MarkBudgetNotified(ctx, b.ID, exceeded, period)
+	if err != nil {
+		logger.Warnf(ctx, "[Usage] failed to mark budget %d notified: %v", b.ID, err)
+		return
+	}
+	s.cacheMu.Lock()
+	if exceeded {
+		b.ExceededPeriod = period
+	} else {
+		b.WarnedPeriod = period
+	}
+	s.cacheMu.Unlock()
+	if !marked {
+		return
+	}
+
+	action := types.AuditActionUsageBudgetWarning
+	if exceeded {
+		action = types.AuditActionUsageBudgetExceeded
+	}
+	logger.Warnf(ctx, "[Usage] tenant %d: %s", b.TenantID, usageBudgetWarning(b, used, period))
+	s.auditBudget(ctx, b, action, map[string]any{
+		"api_key_id":          b.APIKeyID,
+		"period":              period,
+		"monthly_token_limit": b.MonthlyTokenLimit,
+		"used_tokens":         used,
+		"hard_stop":           b.HardStop,
+	}, "")
+}
+
+func (s *usageService) auditBudget(
+	ctx context.Context, b *types.UsageBudget, action types.AuditAction, details map[string]any, actorID string,
+) {
+	if s.audit == nil {
+		return
+	}
+	var detailJSON types.JSON
+	if raw, err := json.Marshal(details); err == nil {
+		detailJSON = types.JSON(raw)
+	}
+	actorRole := ""
+	if actorID != "" {
+		actorRole = auditActorRole(ctx)
+	}
+	_ = s.audit.Log(ctx, &types.AuditLog{
+		TenantID:    b.TenantID,
+		ActorUserID: actorID,
+		ActorRole:   actorRole,
+		Action:      action,
+		ScopeType:   usageAuditScope,
+		ScopeID:     fmt.Sprintf("%d", b.ID),
+		Outcome:     types.AuditOutcomeSuccess,
+		Details:     detailJSON,
+	})
+}
+
+func (s *usageService) cachedBudgets(ctx context.Context, tenantID uint64) ([]*types.UsageBudget, error) {
+	now := s.now()
+	s.cacheMu.Lock()
+	if c, ok := s.budgets[tenantID]; ok && now.Sub(c.loadedAt) < usageBudgetCacheTTL {
+		s.cacheMu.Unlock()
+		return c.budgets, nil
+	}
+	s.cacheMu.Unlock()
+
+	budgets, err := s.repo.ListBudgets(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	s.cacheMu.Lock()
+	s.budgets[tenantID] = &cachedUsageBudgets{budgets: budgets, loadedAt: now}
+	s.cacheMu.Unlock()
+	return budgets, nil
+}
+
+func (s *usageService) cachedSum(ctx context.Context, tenantID, apiKeyID uint64, period string) (int64, error) {
+	key := usageScopeKey{tenantID: tenantID, apiKeyID: apiKeyID, period: period}
+	now := s.now()
+	s.cacheMu.Lock()
+	if c, ok := s.sums[key]; ok && now.Sub(c.loadedAt) < usageBudgetCacheTTL {
+		tokens := c.tokens
+		s.cacheMu.Unlock()
+		return tokens, nil
+	}
+	s.cacheMu.Unlock()
+
+	tokens, err := s.sumBillableTokens(ctx, tenantID, apiKeyID, period)
+	if err != nil {
+		return 0, err
+	}
+	s.cacheMu.Lock()
+	s.sums[key] = &cachedUsageSum{tokens: tokens, loadedAt: now}
+	s.cacheMu.Unlock()
+	return tokens, nil
+}
+
+// sumBillableTokens is the stored month-to-date sum plus the records still
+// waiting in the write buffer.
+func (s *usageService) sumBillableTokens(ctx context.Context, tenantID, apiKeyID uint64, period string) (int64, error) {
+	tokens, err := s.repo.SumBillableTokens(ctx, tenantID, apiKeyID, period)
+	if err != nil {
+		return 0, err
+	}
+	s.pendingMu.Lock()
+	for _, rec := range s.pending {
+		if rec.TenantID == tenantID && rec.Period == period && (apiKeyID == 0 || rec.APIKeyID == apiKeyID) {
+			tokens += rec.BillableTokens()
+		}
+	}
+	s.pendingMu.Unlock()
+	return tokens, nil
+}
+
+func (s *usageService) invalidateBudgets(tenantID uint64) {
+	s.cacheMu.Lock()
+	delete(s.budgets, tenantID)
+	s.cacheMu.Unlock()
+}
+
+func (s *usageService) Report(ctx context.Context, q *types.UsageReportQuery) (*types.UsageReport, error) {
+	tenantID, ok := types.TenantIDFromContext(ctx)
+	if !ok {
+		return nil, errors.NewUnauthorizedError("workspace not found in context")
+	}
+	query := *q
+	query.TenantID = tenantID
+	if err := s.normalizeReportQuery(&query); err != nil {
+		return nil, err
+	}
+	rows, err := s.repo.Report(ctx, &query)
+	if err != nil {
+		return nil, err
+	}
+	report := &types.UsageReport{Query: query, Rows: rows}
+	for _, row := range rows {
+		report.Total.Add(row)
+	}
+	return report, nil
+}
+
+// normalizeReportQuery defaults the range to the current month so far and
+// the grouping to days, and rejects ranges the ledger indexes cannot serve
+// cheaply.
+func (s *usageService) normalizeReportQuery(q *types.UsageReportQuery) error {
+	now := s.now().UTC()
+	if q.GroupBy == "" {
+		q.GroupBy = types.UsageGroupByDay
+	}
+	if _, ok := types.UsageGroupByColumn(q.GroupBy); !ok {
+		return errors.NewBadRequestError(fmt.Sprintf("unsupported group_by %q", q.GroupBy))
+	}
+	if q.Kind != "" && !q.Kind.IsValid() {
+		return errors.NewBadRequestError(fmt.Sprintf("unsupported kind %q", q.Kind))
+	}
+	if q.To == "" {
+		q.To = now.Format(types.UsageDayFormat)
+	}
+	to, err := time.Parse(types.UsageDayFormat, q.To)
+	if err != nil {
+		return errors.NewBadRequestError("to must be a date in YYYY-MM-DD format")
+	}
+	if q.From == "" {
+		q.From = time.Date(to.Year(), to.Month(), 1, 0, 0, 0, 0, time.UTC).Format(types.UsageDayFormat)
+	}
+	from, err := time.Parse(types.UsageDayFormat, q.From)
+	if err != nil {
+		return errors.NewBadRequestError("from must be a date in YYYY-MM-DD format")
+	}
+	if from.After(to) {
+		return errors.NewBadRequestError("from must not be after to")
+	}
+	if to.Sub(from) >= types.MaxUsageReportDays*24*time.Hour {
+		return errors.NewBadRequestError(fmt.Sprintf("report range must not exceed %d days", types.MaxUsageReportDays))
+	}
+	return nil
+}
+
+func (s *usageService) ListBudgets(ctx context.Context) ([]*types.UsageBudgetStatus, error) {
+	tenantID, ok := types.TenantIDFromContext(ctx)
+	if !ok {
+		return nil, errors.NewUnauthorizedError("workspace not found in context")
+	}
+	budgets, err := s.repo.ListBudgets(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	period := types.UsagePeriod(s.now())
+	out := make([]*types.UsageBudgetStatus, 0, len(budgets))
+	for _, b := range budgets {
+		used, err := s.sumBillableTokens(ctx, tenantID, b.APIKeyID, period)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, &types.UsageBudgetStatus{
+			UsageBudget: b, Period: period, UsedTokens: used, State: b.StateFor(used),
+		})
+	}
+	return out, nil
+}
+
+func (s *usageService) SetBudget(ctx context.Context, req *types.UsageBudgetRequest) (*types.UsageBudgetStatus, error) {
+	tenantID, ok := types.TenantIDFromContext(ctx)
+	if !ok {
+		return nil, errors.NewUnauthorizedError("workspace not found in context")
+	}
+	if req.MonthlyTokenLimit <= 0 {
+		return nil, errors.NewBadRequestError("monthly_token_limit must be positive")
+	}
+	if req.WarnPercent < 0 || req.WarnPercent > 100 {
+		return nil, errors.NewBadRequestError("warn_percent must be between 1 and 100")
+	}
+	if req.APIKeyID != 0 {
+		if err := s.ensureAPIKey(ctx, tenantID, req.APIKeyID); err != nil {
+			return nil, err
+		}
+	}
+	warn := req.WarnPercent
+	if warn == 0 {
+		warn = types.DefaultUsageWarnPercent
+	}
+	budget := &types.UsageBudget{
+		TenantID:          tenantID,
+		APIKeyID:          req.APIKeyID,
+		MonthlyTokenLimit: req.MonthlyTokenLimit,
+		WarnPercent:       warn,
+		HardStop:          req.HardStop,
+	}
+	if err := s.repo.UpsertBudget(ctx, budget); err != nil {
+		return nil, err
+	}
+	s.invalidateBudgets(tenantID)
+
+	statuses, err := s.ListBudgets(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, st := range statuses {
+		if st.APIKeyID == req.APIKeyID {
+			s.auditBudget(ctx, st.UsageBudget, types.AuditActionUsageBudgetUpdated, map[string]any{
+				"api_key_id":          st.APIKeyID,
+				"monthly_token_limit": st.MonthlyTokenLimit,
+				"warn_percent":        st.WarnPercent,
+				"hard_stop":           st.HardStop,
+			}, auditActor(ctx))
+			return st, nil
+		}
+	}
+	return nil, errors.NewInternalServerError("budget not found after update")
+}
+
+// ensureAPIKey rejects budgets for keys that do not belong to the workspace.
+func (s *usageService) ensureAPIKey(ctx context.Context, tenantID, keyID uint64) error {
+	keys, err := s.apiKeyRepo.ListAPIKeys(ctx, tenantID)
+	if err != nil {
+		return err
+	}
+	for _, k := range keys {
+		if k.ID == keyID {
+			return nil
+		}
+	}
+	return errors.NewBadRequestError(fmt.Sprintf("API key %d not found in this workspace", keyID))
+}
+
+func (s *usageService) DeleteBudget(ctx context.Context, id uint64) error {
+	tenantID, ok := types.TenantIDFromContext(ctx)
+	if !ok {
+		return errors.NewUnauthorizedError("workspace not found in context")
+	}
+	deleted, err := s.repo.DeleteBudget(ctx, tenantID, id)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return errors.NewNotFoundError("budget not found")
+	}
+	s.invalidateBudgets(tenantID)
+	s.auditBudget(ctx, &types.UsageBudget{ID: id, TenantID: tenantID},
+		types.AuditActionUsageBudgetDeleted, map[string]any{}, auditActor(ctx))
+	return nil
+}
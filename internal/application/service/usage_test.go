@@ -0,0 +1,246 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+// fakeUsageRepo keeps the ledger in memory; only the methods the service
+// relies on for budgets and attribution do real work.
+type fakeUsageRepo struct {
+	mu      sync.Mutex
+	records []*types.UsageRecord
+	budgets []*types.UsageBudget
+	marks   map[string]bool
+	lastQ   *types.UsageReportQuery
+}
+
+func newFakeUsageRepo() *fakeUsageRepo {
+	return &fakeUsageRepo{marks: map[string]bool{}}
+}
+
+func (r *fakeUsageRepo) CreateRecords(_ context.Context, records []*types.UsageRecord) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.records = append(r.records, records...)
+	return nil
+}
+
+func (r *fakeUsageRepo) Report(_ context.Context, q *types.UsageReportQuery) ([]types.UsageReportRow, error) {
+	r.lastQ = q
+	return []types.UsageReportRow{{Key: "a", TotalTokens: 3}, {Key: "b", TotalTokens: 4}}, nil
+}
+
+func (r *fakeUsageRepo) SumBillableTokens(_ context.Context, tenantID, apiKeyID uint64, period string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var total int64
+	for _, rec := range r.records {
+		if rec.TenantID == tenantID && rec.Period == period && (apiKeyID == 0 || rec.APIKeyID == apiKeyID) {
+			total += rec.BillableTokens()
+		}
+	}
+	return total, nil
+}
+
+func (r *fakeUsageRepo) ListBudgets(_ context.Context, tenantID uint64) ([]*types.UsageBudget, error) {
+	var out []*types.UsageBudget
+	for _, b := range r.budgets {
+		if b.TenantID == tenantID {
+			out = append(out, b)
+		}
+	}
+	return out, nil
+}
+
+func (r *fakeUsageRepo) UpsertBudget(_ context.Context, budget *types.UsageBudget) error {
+	for _, b := range r.budgets {
+		if b.TenantID == budget.TenantID && b.APIKeyID == budget.APIKeyID {
+			b.MonthlyTokenLimit, b.WarnPercent, b.HardStop = budget.MonthlyTokenLimit, budget.WarnPercent, budget.HardStop
+			return nil
+		}
+	}
+	budget.ID = uint64(len(r.budgets) + 1)
+	r.budgets = append(r.budgets, budget)
+	return nil
+}
+
+func (r *fakeUsageRepo) DeleteBudget(_ context.Context, tenantID, id uint64) (bool, error) {
+	for i, b := range r.budgets {
+		if b.TenantID == tenantID && b.ID == id {
+			r.budgets = append(r.budgets[:i], r.budgets[i+1:]...)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *fakeUsageRepo) MarkBudgetNotified(_ context.Context, id uint64, exceeded bool, period string) (bool, error) {
+	key := fmt.Sprintf("%d/%s/%t", id, period, exceeded)
+	if r.marks[key] {
+		return false, nil
+	}
+	r.marks[key] = true
+	return true, nil
+}
+
+type recordingAuditLog struct {
+	interfaces.AuditLogService
+	mu      sync.Mutex
+	actions []types.AuditAction
+}
+
+func (a *recordingAuditLog) Log(_ context.Context, entry *types.AuditLog) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.actions = append(a.actions, entry.Action)
+	return nil
+}
+
+func newTestUsageService(repo interfaces.UsageRepository, audit interfaces.AuditLogService) *usageService {
+	svc := NewUsageService(repo, nil, audit).(*usageService)
+	svc.now = func() time.Time { return time.Date(2026, 3, 15, 12, 0, 0, 0, time.UTC) }
+	return svc
+}
+
+func usageTestContext(tenantID uint64) context.Context {
+	return context.WithValue(context.Background(), types.TenantIDContextKey, tenantID)
+}
+
+func TestUsageServiceRecordAttribution(t *testing.T) {
+	repo := newFakeUsageRepo()
+	svc := newTestUsageService(repo, nil)
+
+	ctx := usageTestContext(4)
+	ctx = types.WithPrincipal(ctx, types.Principal{Type: types.PrincipalWebUser, ID: "user-1"})
+	ctx = types.WithTenantAPIKeyScope(ctx, types.TenantAPIKeyScope{KeyID: 9, FullAccess: true})
+	ctx = types.WithMemoryAgent(ctx, "agent-1")
+	ctx = types.WithUsageKnowledgeBase(ctx, "kb-1")
+	ctx = types.WithSessionID(ctx, "sess-1")
+
+	svc.Record(ctx, types.UsageEvent{Kind: types.UsageKindChat, ModelID: "m1", PromptTokens: 10, CompletionTokens: 2, Calls: 1})
+	// Calls without a workspace and empty events are not recorded.
+	svc.Record(context.Background(), types.UsageEvent{Kind: types.UsageKindChat, PromptTokens: 10, Calls: 1})
+	svc.Record(ctx, types.UsageEvent{Kind: types.UsageKindChat})
+	require.NoError(t, svc.Close())
+
+	require.Len(t, repo.records, 1)
+	rec := repo.records[0]
+	assert.Equal(t, uint64(4), rec.TenantID)
+	assert.Equal(t, "user-1", rec.UserID)
+	assert.Equal(t, uint64(9), rec.APIKeyID)
+	assert.Equal(t, "agent-1", rec.AgentID)
+	assert.Equal(t, "kb-1", rec.KnowledgeBaseID)
+	assert.Equal(t, "sess-1", rec.SessionID)
+	assert.Equal(t, "2026-03", rec.Period)
+	assert.Equal(t, "2026-03-15", rec.Day)
+}
+
+func TestUsageServiceBudgetWarningAndHardStop(t *testing.T) {
+	repo := newFakeUsageRepo()
+	audit := &recordingAuditLog{}
+	svc := newTestUsageService(repo, audit)
+	defer svc.Close()
+
+	repo.budgets = []*types.UsageBudget{{ID: 1, TenantID: 1, MonthlyTokenLimit: 100, WarnPercent: 80, HardStop: true}}
+	ctx := usageTestContext(1)
+
+	warning, err := svc.EvaluateBudgets(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, warning)
+
+	// Recorded usage counts against the cached sum before it is written.
+	svc.Record(ctx, types.UsageEvent{Kind: types.UsageKindChat, PromptTokens: 85, Calls: 1})
+	warning, err = svc.EvaluateBudgets(ctx)
+	require.NoError(t, err)
+	assert.Contains(t, warning, "workspace budget 85% used")
+
+	svc.Record(ctx, types.UsageEvent{Kind: types.UsageKindEmbedding, EmbeddingTokens: 20, Calls: 1})
+	err = svc.CheckBudget(ctx)
+	require.Error(t, err)
+	appErr, ok := errors.IsAppError(err)
+	require.True(t, ok)
+	assert.Equal(t, errors.ErrUsageBudgetExceeded, appErr.Code)
+	assert.Equal(t, 429, appErr.HTTPCode)
+
+	// Each notice is audited once per period.
+	require.Error(t, svc.CheckBudget(ctx))
+	assert.Equal(t, []types.AuditAction{
+		types.AuditActionUsageBudgetWarning, types.AuditActionUsageBudgetExceeded,
+	}, audit.actions)
+
+	// Other workspaces are unaffected.
+	require.NoError(t, svc.CheckBudget(usageTestContext(2)))
+}
+
+func TestUsageServiceSoftBudgetOnlyWarns(t *testing.T) {
+	repo := newFakeUsageRepo()
+	svc := newTestUsageService(repo, nil)
+	defer svc.Close()
+
+	repo.budgets = []*types.UsageBudget{{ID: 1, TenantID: 1, MonthlyTokenLimit: 10, WarnPercent: 50}}
+	ctx := usageTestContext(1)
+	svc.Record(ctx, types.UsageEvent{Kind: types.UsageKindChat, PromptTokens: 50, Calls: 1})
+
+	warning, err := svc.EvaluateBudgets(ctx)
+	require.NoError(t, err)
+	assert.Contains(t, warning, "500% used")
+}
+
+func TestUsageServiceAPIKeyBudgetScope(t *testing.T) {
+	repo := newFakeUsageRepo()
+	svc := newTestUsageService(repo, nil)
+	defer svc.Close()
+
+	repo.budgets = []*types.UsageBudget{{ID: 1, TenantID: 1, APIKeyID: 7, MonthlyTokenLimit: 10, HardStop: true}}
+	keyCtx := types.WithTenantAPIKeyScope(usageTestContext(1), types.TenantAPIKeyScope{KeyID: 7, FullAccess: true})
+	otherCtx := types.WithTenantAPIKeyScope(usageTestContext(1), types.TenantAPIKeyScope{KeyID: 8, FullAccess: true})
+
+	require.NoError(t, svc.CheckBudget(keyCtx))
+	svc.Record(otherCtx, types.UsageEvent{Kind: types.UsageKindChat, PromptTokens: 50, Calls: 1})
+	require.NoError(t, svc.CheckBudget(keyCtx), "another key's usage does not count")
+
+	svc.Record(keyCtx, types.UsageEvent{Kind: types.UsageKindChat, PromptTokens: 10, Calls: 1})
+	require.Error(t, svc.CheckBudget(keyCtx))
+	require.NoError(t, svc.CheckBudget(otherCtx), "the budget only stops its own key")
+	require.NoError(t, svc.CheckBudget(usageTestContext(1)))
+}
+
+func TestUsageServiceReportNormalization(t *testing.T) {
+	repo := newFakeUsageRepo()
+	svc := newTestUsageService(repo, nil)
+	defer svc.Close()
+	ctx := usageTestContext(3)
+
+	report, err := svc.Report(ctx, &types.UsageReportQuery{TenantID: 99})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(3), repo.lastQ.TenantID, "the tenant always comes from the context")
+	assert.Equal(t, "2026-03-01", report.Query.From)
+	assert.Equal(t, "2026-03-15", report.Query.To)
+	assert.Equal(t, types.UsageGroupByDay, report.Query.GroupBy)
+	assert.Equal(t, int64(7), report.Total.TotalTokens)
+
+	for _, q := range []*types.UsageReportQuery{
+		{GroupBy: "tenant"},
+		{Kind: "image"},
+		{From: "2026-13-01"},
+		{From: "2026-03-10", To: "2026-03-01"},
+		{From: "2025-01-01", To: "2026-03-01"},
+	} {
+		_, err := svc.Report(ctx, q)
+		require.Error(t, err, "%+v", q)
+		appErr, ok := errors.IsAppError(err)
+		require.True(t, ok)
+		assert.Equal(t, errors.ErrBadRequest, appErr.Code)
+	}
+}
@@ -105,6 +105,9 @@ type userService struct {
 	tenantService interfaces.TenantService
 	memberService interfaces.TenantMemberService
 	config        *config.Config
+	// ldap is the directory used by LoginWithLDAP; nil means the live
+	// server described by config.LDAPAuth.
+	ldap ldapDirectory
 }
 
 // NewUserService creates a new user service instance
@@ -0,0 +1,310 @@
+package service
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+
+	"github.com/Tencent/WeKnora/internal/config"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// errLDAPInvalidCredentials is returned by a directory when the user entry
+// is missing, ambiguous or rejects the password. The three cases are
+// reported identically so the login endpoint cannot be used to enumerate
+// directory accounts.
+var errLDAPInvalidCredentials = errors.New("invalid LDAP credentials")
+
+// ldapDirectory authenticates a user against the configured directory and
+// returns the resolved entry. It is the seam tests replace.
+type ldapDirectory interface {
+	Authenticate(ctx context.Context, cfg *config.LDAPAuthConfig, username, password string) (*types.LDAPUserInfo, error)
+}
+
+// LoginWithLDAP binds to the directory as username, provisions the local
+// account on first login, applies the configured group mappings and returns
+// the same response shape as the password login. provisioning is the default
+// tenant mode for a newly created user, as for LoginWithOIDC.
+func (s *userService) LoginWithLDAP(
+	ctx context.Context,
+	username, password string,
+	provisioning types.TenantProvisioningMode,
+) (*types.LoginResponse, error) {
+	if s.config == nil || s.config.LDAPAuth == nil || !s.config.LDAPAuth.Enable {
+		return nil, errors.New("LDAP login is disabled")
+	}
+	cfg := s.config.LDAPAuth
+	username = strings.TrimSpace(username)
+	// An empty password turns the user bind into an unauthenticated bind,
+	// which most directories accept for any DN.
+	if username == "" || password == "" {
+		return &types.LoginResponse{Success: false, Message: "Invalid username or password"}, nil
+	}
+
+	info, err := s.directory().Authenticate(ctx, cfg, username, password)
+	if errors.Is(err, errLDAPInvalidCredentials) {
+		logger.Warnf(ctx, "LDAP login rejected for %q", username)
+		return &types.LoginResponse{Success: false, Message: "Invalid username or password"}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(info.Email) == "" {
+		return nil, fmt.Errorf("LDAP entry %s has no %s attribute", info.DN, cfg.EmailAttribute)
+	}
+
+	user, err := s.userRepo.GetUserByEmail(ctx, info.Email)
+	if err != nil && !isUserLookupNotFound(err) {
+		return nil, fmt.Errorf("failed to query user by email: %w", err)
+	}
+	if isUserLookupNotFound(err) || user == nil {
+		user, err = s.provisionOIDCUser(ctx, &types.OIDCUserInfo{
+			Subject:  info.DN,
+			Username: info.Username,
+			Email:    info.Email,
+		}, provisioning)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if !user.IsActive {
+		return &types.LoginResponse{Success: false, Message: "Account is disabled"}, nil
+	}
+
+	s.applyLDAPGroupMappings(ctx, cfg, user, info.Groups)
+
+	resolvedTenantID := s.resolveLoginTenantID(ctx, user)
+	accessToken, refreshToken, err := s.generateTokensForTenant(ctx, user, resolvedTenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate local tokens: %w", err)
+	}
+	var tenant *types.Tenant
+	if resolvedTenantID > 0 {
+		if t, terr := s.tenantService.GetTenantByID(ctx, resolvedTenantID); terr == nil {
+			tenant = t
+		} else {
+			logger.Warnf(ctx, "LDAP login: failed to load tenant %d for user %s: %v",
+				resolvedTenantID, user.ID, terr)
+		}
+	}
+	return &types.LoginResponse{
+		Success:      true,
+		Message:      "Login successful",
+		User:         user,
+		ActiveTenant: tenant,
+		Memberships:  s.buildMembershipsForUser(ctx, user, tenant),
+		Token:        accessToken,
+		RefreshToken: refreshToken,
+	}, nil
+}
+
+func (s *userService) directory() ldapDirectory {
+	if s.ldap != nil {
+		return s.ldap
+	}
+	return goLDAPDirectory{}
+}
+
+// applyLDAPGroupMappings brings the user's memberships in the mapped
+// workspaces in line with their directory groups. When several groups map
+// to one workspace the highest role wins. The directory only grants: a user
+// who left every mapped group keeps their memberships (removal is what SCIM
+// deprovisioning is for), Owners are never changed, and a member holding a
+// custom role on the mapped base role keeps it. Failures are logged so a
+// broken mapping never blocks a login.
+func (s *userService) applyLDAPGroupMappings(
+	ctx context.Context,
+	cfg *config.LDAPAuthConfig,
+	user *types.User,
+	groups []string,
+) {
+	desired := ldapMappedRoles(cfg.GroupMappings, groups)
+	if len(desired) == 0 || s.memberService == nil {
+		return
+	}
+	tenantIDs := make([]uint64, 0, len(desired))
+	for tenantID := range desired {
+		tenantIDs = append(tenantIDs, tenantID)
+	}
+	sort.Slice(tenantIDs, func(i, j int) bool { return tenantIDs[i] < tenantIDs[j] })
+
+	for _, tenantID := range tenantIDs {
+		role := desired[tenantID]
+		member, err := s.memberService.GetMembership(ctx, user.ID, tenantID)
+		if err != nil {
+			logger.Warnf(ctx, "LDAP login: failed to load membership of %s in tenant %d: %v", user.ID, tenantID, err)
+			continue
+		}
+		switch {
+		case member == nil:
+			if _, err := s.memberService.AddMember(ctx, user.ID, tenantID, role, nil); err != nil {
+				logger.Warnf(ctx, "LDAP login: failed to add %s to tenant %d as %s: %v", user.ID, tenantID, role, err)
+			}
+		case member.Role == types.TenantRoleOwner, member.Role == role:
+		default:
+			if err := s.memberService.UpdateRole(ctx, user.ID, tenantID, role); err != nil {
+				logger.Warnf(ctx, "LDAP login: failed to set role of %s in tenant %d to %s: %v", user.ID, tenantID, role, err)
+			}
+		}
+	}
+}
+
+// ldapMappedRoles returns the highest role each workspace grants the given
+// groups.
+func ldapMappedRoles(mappings []config.LDAPGroupMapping, groups []string) map[uint64]types.TenantRole {
+	desired := map[uint64]types.TenantRole{}
+	for _, m := range mappings {
+		role := types.TenantRole(strings.ToLower(strings.TrimSpace(m.Role)))
+		if m.TenantID == 0 || !role.IsValid() || role == types.TenantRoleOwner {
+			continue
+		}
+		if !ldapGroupMatches(m.Group, groups) {
+			continue
+		}
+		if current, ok := desired[m.TenantID]; !ok || role.Level() > current.Level() {
+			desired[m.TenantID] = role
+		}
+	}
+	return desired
+}
+
+// ldapGroupMatches reports whether group, a DN or a bare common name, names
+// one of the user's group DNs. DNs compare case-insensitively after
+// normalisation; a common name matches the first RDN of a group DN.
+func ldapGroupMatches(group string, userGroups []string) bool {
+	group = strings.TrimSpace(group)
+	if group == "" {
+		return false
+	}
+	wanted, wantedErr := ldap.ParseDN(group)
+	isDN := wantedErr == nil && len(wanted.RDNs) > 1
+	for _, candidate := range userGroups {
+		dn, err := ldap.ParseDN(candidate)
+		if err != nil || len(dn.RDNs) == 0 {
+			if strings.EqualFold(strings.TrimSpace(candidate), group) {
+				return true
+			}
+			continue
+		}
+		if isDN {
+			if dn.EqualFold(wanted) {
+				return true
+			}
+			continue
+		}
+		for _, attr := range dn.RDNs[0].Attributes {
+			if strings.EqualFold(attr.Type, "cn") && strings.EqualFold(attr.Value, group) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// goLDAPDirectory is the ldapDirectory backed by a live server.
+type goLDAPDirectory struct{}
+
+func (goLDAPDirectory) Authenticate(
+	ctx context.Context,
+	cfg *config.LDAPAuthConfig,
+	username, password string,
+) (*types.LDAPUserInfo, error) {
+	timeout := time.Duration(cfg.TimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	tlsConfig := &tls.Config{InsecureSkipVerify: cfg.InsecureSkipVerify} //nolint:gosec // operator opt-in for self-signed directories
+	if host, _, err := net.SplitHostPort(strings.TrimPrefix(strings.TrimPrefix(cfg.URL, "ldaps://"), "ldap://")); err == nil {
+		tlsConfig.ServerName = host
+	}
+	conn, err := ldap.DialURL(cfg.URL,
+		ldap.DialWithDialer(&net.Dialer{Timeout: timeout}),
+		ldap.DialWithTLSConfig(tlsConfig))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to LDAP server: %w", err)
+	}
+	defer conn.Close()
+	conn.SetTimeout(timeout)
+	if cfg.StartTLS && !strings.HasPrefix(cfg.URL, "ldaps://") {
+		if err := conn.StartTLS(tlsConfig); err != nil {
+			return nil, fmt.Errorf("LDAP StartTLS failed: %w", err)
+		}
+	}
+	if cfg.BindDN != "" {
+		if err := conn.Bind(cfg.BindDN, cfg.BindPassword); err != nil {
+			return nil, fmt.Errorf("LDAP service bind failed: %w", err)
+		}
+	}
+
+	attrs := []string{"dn", cfg.UsernameAttribute, cfg.EmailAttribute}
+	if cfg.GroupAttribute != "" {
+		attrs = append(attrs, cfg.GroupAttribute)
+	}
+	filter := strings.ReplaceAll(cfg.UserFilter, "{username}", ldap.EscapeFilter(username))
+	result, err := conn.Search(ldap.NewSearchRequest(
+		cfg.BaseDN, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases,
+		2, int(timeout/time.Second), false, filter, attrs, nil))
+	if err != nil && !ldap.IsErrorWithCode(err, ldap.LDAPResultSizeLimitExceeded) {
+		return nil, fmt.Errorf("LDAP user search failed: %w", err)
+	}
+	if result == nil || len(result.Entries) != 1 {
+		return nil, errLDAPInvalidCredentials
+	}
+	entry := result.Entries[0]
+
+	if err := conn.Bind(entry.DN, password); err != nil {
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials) {
+			return nil, errLDAPInvalidCredentials
+		}
+		return nil, fmt.Errorf("LDAP user bind failed: %w", err)
+	}
+
+	info := &types.LDAPUserInfo{
+		DN:       entry.DN,
+		Username: entry.GetAttributeValue(cfg.UsernameAttribute),
+		Email:    strings.TrimSpace(entry.GetAttributeValue(cfg.EmailAttribute)),
+	}
+	if info.Username == "" {
+		info.Username = username
+	}
+	if cfg.GroupAttribute != "" {
+		info.Groups = append(info.Groups, entry.GetAttributeValues(cfg.GroupAttribute)...)
+	}
+	if cfg.GroupFilter != "" {
+		// Re-bind as the service account: the user may not be allowed to
+		// search the group tree.
+		if cfg.BindDN != "" {
+			if err := conn.Bind(cfg.BindDN, cfg.BindPassword); err != nil {
+				return nil, fmt.Errorf("LDAP service bind failed: %w", err)
+			}
+		}
+		groupBase := cfg.GroupBaseDN
+		if groupBase == "" {
+			groupBase = cfg.BaseDN
+		}
+		groupFilter := strings.NewReplacer(
+			"{dn}", ldap.EscapeFilter(entry.DN),
+			"{username}", ldap.EscapeFilter(username),
+		).Replace(cfg.GroupFilter)
+		groups, err := conn.Search(ldap.NewSearchRequest(
+			groupBase, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases,
+			0, int(timeout/time.Second), false, groupFilter, []string{"dn"}, nil))
+		if err != nil {
+			logger.Warnf(ctx, "LDAP group search failed for %s: %v", entry.DN, err)
+		} else {
+			for _, g := range groups.Entries {
+				info.Groups = append(info.Groups, g.DN)
+			}
+		}
+	}
+	return info, nil
+}
@@ -0,0 +1,125 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/config"
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+func TestLDAPGroupMatches(t *testing.T) {
+	groups := []string{
+		"CN=KB Editors,OU=Groups,DC=corp,DC=example",
+		"cn=viewers,ou=groups,dc=corp,dc=example",
+	}
+	cases := []struct {
+		group string
+		want  bool
+	}{
+		{"cn=kb editors,ou=groups,dc=corp,dc=example", true},
+		{"CN=Viewers, OU=Groups, DC=corp, DC=example", true},
+		{"KB Editors", true},
+		{"viewers", true},
+		{"cn=viewers,ou=other,dc=corp,dc=example", false},
+		{"Groups", false},
+		{"", false},
+	}
+	for _, tc := range cases {
+		if got := ldapGroupMatches(tc.group, groups); got != tc.want {
+			t.Errorf("ldapGroupMatches(%q) = %v, want %v", tc.group, got, tc.want)
+		}
+	}
+}
+
+func TestLDAPMappedRolesPicksHighestRolePerTenant(t *testing.T) {
+	mappings := []config.LDAPGroupMapping{
+		{Group: "viewers", TenantID: 1, Role: "viewer"},
+		{Group: "editors", TenantID: 1, Role: "Contributor"},
+		{Group: "viewers", TenantID: 2, Role: "viewer"},
+		{Group: "admins", TenantID: 3, Role: "owner"},
+		{Group: "viewers", TenantID: 0, Role: "admin"},
+		{Group: "viewers", TenantID: 4, Role: "superuser"},
+		{Group: "nobody", TenantID: 5, Role: "admin"},
+	}
+	got := ldapMappedRoles(mappings, []string{
+		"cn=viewers,dc=example", "cn=editors,dc=example", "cn=admins,dc=example",
+	})
+	want := map[uint64]types.TenantRole{1: types.TenantRoleContributor, 2: types.TenantRoleViewer}
+	if len(got) != len(want) {
+		t.Fatalf("mapped roles = %v, want %v", got, want)
+	}
+	for tenantID, role := range want {
+		if got[tenantID] != role {
+			t.Fatalf("tenant %d role = %q, want %q", tenantID, got[tenantID], role)
+		}
+	}
+}
+
+func TestApplyLDAPGroupMappingsGrantsButNeverDemotesOwners(t *testing.T) {
+	ctx := context.Background()
+	members, repo := newServiceWithRepo()
+	if _, err := members.AddMember(ctx, "u1", 2, types.TenantRoleOwner, nil); err != nil {
+		t.Fatalf("seed owner: %v", err)
+	}
+	if _, err := members.AddMember(ctx, "u1", 3, types.TenantRoleViewer, nil); err != nil {
+		t.Fatalf("seed viewer: %v", err)
+	}
+	svc := &userService{memberService: members}
+	cfg := &config.LDAPAuthConfig{GroupMappings: []config.LDAPGroupMapping{
+		{Group: "editors", TenantID: 1, Role: "contributor"},
+		{Group: "editors", TenantID: 2, Role: "contributor"},
+		{Group: "editors", TenantID: 3, Role: "contributor"},
+	}}
+
+	svc.applyLDAPGroupMappings(ctx, cfg, &types.User{ID: "u1"}, []string{"cn=editors,dc=example"})
+
+	want := map[uint64]types.TenantRole{
+		1: types.TenantRoleContributor,
+		2: types.TenantRoleOwner,
+		3: types.TenantRoleContributor,
+	}
+	for tenantID, role := range want {
+		m, err := repo.Get(ctx, "u1", tenantID)
+		if err != nil || m == nil {
+			t.Fatalf("membership in tenant %d: %v, %v", tenantID, m, err)
+		}
+		if m.Role != role {
+			t.Fatalf("tenant %d role = %q, want %q", tenantID, m.Role, role)
+		}
+	}
+}
+
+type fakeLDAPDirectory struct {
+	info *types.LDAPUserInfo
+	err  error
+}
+
+func (d fakeLDAPDirectory) Authenticate(
+	context.Context, *config.LDAPAuthConfig, string, string,
+) (*types.LDAPUserInfo, error) {
+	return d.info, d.err
+}
+
+func TestLoginWithLDAPRejectsInvalidCredentials(t *testing.T) {
+	svc := &userService{
+		config: &config.Config{LDAPAuth: &config.LDAPAuthConfig{Enable: true}},
+		ldap:   fakeLDAPDirectory{err: errLDAPInvalidCredentials},
+	}
+	for _, password := range []string{"", "wrong"} {
+		resp, err := svc.LoginWithLDAP(context.Background(), "alice", password, "")
+		if err != nil {
+			t.Fatalf("LoginWithLDAP(%q) error: %v", password, err)
+		}
+		if resp.Success || resp.Message != "Invalid username or password" {
+			t.Fatalf("LoginWithLDAP(%q) = %+v, want invalid credentials", password, resp)
+		}
+	}
+}
+
+func TestLoginWithLDAPDisabled(t *testing.T) {
+	svc := &userService{config: &config.Config{}}
+	if _, err := svc.LoginWithLDAP(context.Background(), "alice", "secret", ""); err == nil {
+		t.Fatal("LoginWithLDAP succeeded with LDAP disabled")
+	}
+}
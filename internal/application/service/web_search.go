@@ -10,6 +10,7 @@ import (
 	"github.com/Tencent/WeKnora/internal/config"
 	infra_web_search "github.com/Tencent/WeKnora/internal/infrastructure/web_search"
 	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/models/metering"
 	"github.com/Tencent/WeKnora/internal/searchutil"
 	"github.com/Tencent/WeKnora/internal/types"
 	"github.com/Tencent/WeKnora/internal/types/interfaces"
@@ -75,6 +76,12 @@ func (s *WebSearchService) Search(
 	if err != nil {
 		return nil, fmt.Errorf("web search failed: %w", err)
 	}
+	metering.Record(ctx, types.UsageEvent{
+		Kind:      types.UsageKindWebSearch,
+		ModelID:   providerID,
+		ModelName: searchProvider.Name(),
+		Calls:     1,
+	})
 
 	// Apply blacklist filtering
 	results = s.filterBlacklist(results, config.Blacklist)
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -24,6 +25,8 @@ type Config struct {
 	Auth            *AuthConfig            `yaml:"auth"             json:"auth"`
 	Audit           *AuditConfig           `yaml:"audit"            json:"audit"`
 	OIDCAuth        *OIDCAuthConfig        `yaml:"oidc_auth"        json:"oidc_auth"`
+	LDAPAuth        *LDAPAuthConfig        `yaml:"ldap_auth"        json:"ldap_auth"`
+	Encryption      *EncryptionConfig      `yaml:"encryption"       json:"encryption"`
 	Models          []ModelConfig          `yaml:"models"           json:"models"`
 	VectorDatabase  *VectorDatabaseConfig  `yaml:"vector_database"  json:"vector_database"`
 	DocReader       *DocReaderConfig       `yaml:"docreader"        json:"docreader"`
@@ -262,7 +265,70 @@ type AuditConfig struct {
 	//   = 0 — disable purge entirely (the pre-rollout default).
 	//   < 0 — invalid; ValidateConfig rejects it.
 	// Default: 90 (set by applyAuditDefaults when the section is omitted).
+	//
+	// When export sinks are configured, rows a sink has not shipped yet are
+	// kept past the horizon until it catches up.
 	RetentionDays int `yaml:"retention_days" json:"retention_days"`
+	// Export ships every audit row to external collectors (SIEM). Nil or
+	// all sinks empty disables export.
+	Export *AuditExportConfig `yaml:"export" json:"export"`
+}
+
+// AuditExportConfig configures the audit export sinks. Each configured
+// sink receives every row exactly in id order, at least once; a failing
+// sink is retried with backoff and holds back retention, it never drops
+// rows.
+type AuditExportConfig struct {
+	// BatchSize is the number of rows shipped per request / object.
+	// Default 200.
+	BatchSize     int                          `yaml:"batch_size"     json:"batch_size"`
+	Syslog        AuditSyslogSinkConfig        `yaml:"syslog"         json:"syslog"`
+	Webhook       AuditWebhookSinkConfig       `yaml:"webhook"        json:"webhook"`
+	ObjectStorage AuditObjectStorageSinkConfig `yaml:"object_storage" json:"object_storage"`
+}
+
+// AuditSyslogSinkConfig sends RFC 5424 messages to a syslog collector.
+// Empty Address disables the sink.
+type AuditSyslogSinkConfig struct {
+	// Network is "udp", "tcp" (default) or "tls". TCP and TLS use
+	// octet-counting framing (RFC 6587).
+	Network string `yaml:"network"  json:"network"`
+	Address string `yaml:"address"  json:"address"`
+	// AppName is the APP-NAME header field. Default "weknora".
+	AppName string `yaml:"app_name" json:"app_name"`
+}
+
+// AuditWebhookSinkConfig POSTs batches as JSON. When Secret is set the body
+// is signed like embed webhooks (X-WeKnora-Signature: sha256=<hex hmac>).
+// Empty URL disables the sink.
+type AuditWebhookSinkConfig struct {
+	URL    string `yaml:"url"    json:"url"`
+	Secret string `yaml:"secret" json:"-"`
+}
+
+// AuditObjectStorageSinkConfig writes each batch as a JSON-lines object
+// through the configured file storage (STORAGE_TYPE), under tenant 0's
+// exports prefix.
+type AuditObjectStorageSinkConfig struct {
+	Enabled bool `yaml:"enabled" json:"enabled"`
+}
+
+// ExportSinks returns the names of the configured export sinks.
+func (c *AuditConfig) ExportSinks() []string {
+	if c == nil || c.Export == nil {
+		return nil
+	}
+	var sinks []string
+	if strings.TrimSpace(c.Export.Syslog.Address) != "" {
+		sinks = append(sinks, types.AuditSinkSyslog)
+	}
+	if strings.TrimSpace(c.Export.Webhook.URL) != "" {
+		sinks = append(sinks, types.AuditSinkWebhook)
+	}
+	if c.Export.ObjectStorage.Enabled {
+		sinks = append(sinks, types.AuditSinkObjectStorage)
+	}
+	return sinks
 }
 
 // AuthConfig governs the user authentication entry points.
@@ -320,6 +386,70 @@ type OIDCAuthConfig struct {
 	UserInfoMapping       *OIDCUserInfoMapping `yaml:"user_info_mapping"      json:"user_info_mapping"`
 }
 
+// LDAPGroupMapping grants a role in a workspace to the members of a directory
+// group. Group is the group's distinguished name (compared case-insensitively)
+// or its common name.
+type LDAPGroupMapping struct {
+	Group    string `yaml:"group"     json:"group"`
+	TenantID uint64 `yaml:"tenant_id" json:"tenant_id"`
+	Role     string `yaml:"role"      json:"role"`
+}
+
+// LDAPAuthConfig configures bind authentication against an LDAP directory or
+// Active Directory. A service account (BindDN) searches for the user entry,
+// then the user's own DN is bound with the submitted password.
+type LDAPAuthConfig struct {
+	Enable              bool   `yaml:"enable"                json:"enable"`
+	ProviderDisplayName string `yaml:"provider_display_name" json:"provider_display_name"`
+	// URL is ldap://host:389 or ldaps://host:636.
+	URL                string `yaml:"url"                  json:"url"`
+	StartTLS           bool   `yaml:"start_tls"            json:"start_tls"`
+	InsecureSkipVerify bool   `yaml:"insecure_skip_verify" json:"insecure_skip_verify"`
+	BindDN             string `yaml:"bind_dn"              json:"bind_dn"`
+	BindPassword       string `yaml:"bind_password"        json:"-"`
+	BaseDN             string `yaml:"base_dn"              json:"base_dn"`
+	// UserFilter locates the entry; {username} is replaced by the escaped
+	// login name.
+	UserFilter        string `yaml:"user_filter"        json:"user_filter"`
+	UsernameAttribute string `yaml:"username_attribute" json:"username_attribute"`
+	EmailAttribute    string `yaml:"email_attribute"    json:"email_attribute"`
+	// GroupAttribute lists the groups on the user entry (memberOf on AD and
+	// on OpenLDAP with the memberof overlay).
+	GroupAttribute string `yaml:"group_attribute" json:"group_attribute"`
+	// GroupFilter, when set, additionally searches GroupBaseDN (default
+	// BaseDN) for groups listing the user; {dn} and {username} are replaced.
+	GroupBaseDN    string             `yaml:"group_base_dn"  json:"group_base_dn"`
+	GroupFilter    string             `yaml:"group_filter"   json:"group_filter"`
+	GroupMappings  []LDAPGroupMapping `yaml:"group_mappings" json:"group_mappings"`
+	TimeoutSeconds int                `yaml:"timeout_seconds" json:"timeout_seconds"`
+}
+
+// EncryptionConfig configures envelope encryption at rest. Every tenant gets
+// its own data keys, which are stored wrapped by a master key held by the
+// key provider; rotating the master key only re-wraps the data keys.
+//
+// Data encrypted once stays readable as long as the key provider is
+// configured, so turning Files or ChunkContent off only stops encrypting new
+// writes.
+type EncryptionConfig struct {
+	// Files encrypts every object written through the file service.
+	Files bool `yaml:"files" json:"files"`
+	// ChunkContent encrypts the chunk text columns in the database. The
+	// retrieval stores keep their own plaintext copy for search.
+	ChunkContent bool `yaml:"chunk_content" json:"chunk_content"`
+	// KeyProvider selects the master key source. Only "local" (a key file)
+	// is built in; it doubles as the stand-in for an external KMS.
+	KeyProvider string `yaml:"key_provider" json:"key_provider"`
+	// KeyFile is the local master key file, see docs/静态加密.md.
+	KeyFile string `yaml:"key_file" json:"key_file"`
+}
+
+// Configured reports whether a key provider is set up, i.e. whether
+// encrypted data can be read.
+func (c *EncryptionConfig) Configured() bool {
+	return c != nil && strings.TrimSpace(c.KeyFile) != ""
+}
+
 // PromptTemplateI18n holds localized name and description for a prompt template.
 type PromptTemplateI18n struct {
 	Name        string `yaml:"name"        json:"name"`
@@ -578,6 +708,8 @@ func LoadConfig() (*Config, error) {
 
 	// Validate configuration values
 	applyOIDCEnvOverrides(&cfg)
+	applyLDAPEnvOverrides(&cfg)
+	applyEncryptionEnvOverrides(&cfg)
 	applyAgentEnvOverrides(&cfg)
 	applyKnowledgeBaseEnvOverrides(&cfg)
 	applyAuthAndTenantDefaults(&cfg)
@@ -626,6 +758,34 @@ func ValidateConfig(cfg *Config) error {
 		}
 	}
 
+	if cfg.LDAPAuth != nil && cfg.LDAPAuth.Enable {
+		if !strings.HasPrefix(cfg.LDAPAuth.URL, "ldap://") && !strings.HasPrefix(cfg.LDAPAuth.URL, "ldaps://") {
+			errs = append(errs, "ldap_auth.url must start with ldap:// or ldaps:// when LDAP is enabled")
+		}
+		if strings.TrimSpace(cfg.LDAPAuth.BaseDN) == "" {
+			errs = append(errs, "ldap_auth.base_dn is required when LDAP is enabled")
+		}
+		if !strings.Contains(cfg.LDAPAuth.UserFilter, "{username}") {
+			errs = append(errs, "ldap_auth.user_filter must contain {username}")
+		}
+		for i, m := range cfg.LDAPAuth.GroupMappings {
+			role := types.TenantRole(strings.ToLower(strings.TrimSpace(m.Role)))
+			if strings.TrimSpace(m.Group) == "" || m.TenantID == 0 || !role.IsValid() || role == types.TenantRoleOwner {
+				errs = append(errs, fmt.Sprintf(
+					"ldap_auth.group_mappings[%d] needs group, tenant_id and a role of admin, contributor or viewer", i))
+			}
+		}
+	}
+
+	if cfg.Encryption != nil {
+		if (cfg.Encryption.Files || cfg.Encryption.ChunkContent) && !cfg.Encryption.Configured() {
+			errs = append(errs, "encryption.key_file is required when file or chunk content encryption is enabled")
+		}
+		if p := strings.TrimSpace(cfg.Encryption.KeyProvider); p != "" && p != "local" {
+			errs = append(errs, fmt.Sprintf("encryption.key_provider %q is not supported (only \"local\")", p))
+		}
+	}
+
 	if cfg.Auth != nil {
 		mode := strings.TrimSpace(cfg.Auth.RegistrationMode)
 		if mode != "" && mode != AuthRegistrationModeSelfServe && mode != AuthRegistrationModeInviteOnly {
@@ -643,6 +803,22 @@ func ValidateConfig(cfg *Config) error {
 		errs = append(errs, fmt.Sprintf("audit.retention_days must be >= 0 (got %d); use 0 to disable purge",
 			cfg.Audit.RetentionDays))
 	}
+	if cfg.Audit != nil && cfg.Audit.Export != nil {
+		exp := cfg.Audit.Export
+		if exp.BatchSize < 0 {
+			errs = append(errs, "audit.export.batch_size must be >= 0")
+		}
+		switch exp.Syslog.Network {
+		case "", "udp", "tcp", "tls":
+		default:
+			errs = append(errs, fmt.Sprintf("audit.export.syslog.network must be udp, tcp or tls, got %q",
+				exp.Syslog.Network))
+		}
+		if u := strings.TrimSpace(exp.Webhook.URL); u != "" &&
+			!strings.HasPrefix(u, "http://") && !strings.HasPrefix(u, "https://") {
+			errs = append(errs, "audit.export.webhook.url must be an http(s) URL")
+		}
+	}
 
 	if cfg.Conversation != nil {
 		if cfg.Conversation.EmbeddingTopK < 0 {
@@ -745,6 +921,90 @@ func applyOIDCEnvOverrides(cfg *Config) {
 	}
 }
 
+func applyLDAPEnvOverrides(cfg *Config) {
+	if cfg.LDAPAuth == nil {
+		cfg.LDAPAuth = &LDAPAuthConfig{}
+	}
+	l := cfg.LDAPAuth
+	if value := strings.TrimSpace(os.Getenv("LDAP_AUTH_ENABLE")); value != "" {
+		l.Enable = strings.EqualFold(value, "true")
+	}
+	for env, target := range map[string]*string{
+		"LDAP_AUTH_PROVIDER_DISPLAY_NAME": &l.ProviderDisplayName,
+		"LDAP_AUTH_URL":                   &l.URL,
+		"LDAP_AUTH_BIND_DN":               &l.BindDN,
+		"LDAP_AUTH_BIND_PASSWORD":         &l.BindPassword,
+		"LDAP_AUTH_BASE_DN":               &l.BaseDN,
+		"LDAP_AUTH_USER_FILTER":           &l.UserFilter,
+		"LDAP_AUTH_USERNAME_ATTRIBUTE":    &l.UsernameAttribute,
+		"LDAP_AUTH_EMAIL_ATTRIBUTE":       &l.EmailAttribute,
+		"LDAP_AUTH_GROUP_ATTRIBUTE":       &l.GroupAttribute,
+		"LDAP_AUTH_GROUP_BASE_DN":         &l.GroupBaseDN,
+		"LDAP_AUTH_GROUP_FILTER":          &l.GroupFilter,
+	} {
+		if value := strings.TrimSpace(os.Getenv(env)); value != "" {
+			*target = value
+		}
+	}
+	if value := strings.TrimSpace(os.Getenv("LDAP_AUTH_START_TLS")); value != "" {
+		l.StartTLS = strings.EqualFold(value, "true")
+	}
+	if value := strings.TrimSpace(os.Getenv("LDAP_AUTH_INSECURE_SKIP_VERIFY")); value != "" {
+		l.InsecureSkipVerify = strings.EqualFold(value, "true")
+	}
+	// LDAP_AUTH_GROUP_MAPPINGS is a JSON array of {group, tenant_id, role};
+	// DNs contain commas and equals signs, so a delimited list would not do.
+	if value := strings.TrimSpace(os.Getenv("LDAP_AUTH_GROUP_MAPPINGS")); value != "" {
+		var mappings []LDAPGroupMapping
+		if err := json.Unmarshal([]byte(value), &mappings); err != nil {
+			fmt.Printf("[config] ignoring invalid LDAP_AUTH_GROUP_MAPPINGS: %v\n", err)
+		} else {
+			l.GroupMappings = mappings
+		}
+	}
+
+	if l.ProviderDisplayName == "" {
+		l.ProviderDisplayName = "LDAP"
+	}
+	if l.UserFilter == "" {
+		l.UserFilter = "(&(objectClass=person)(|(uid={username})(sAMAccountName={username})))"
+	}
+	if l.UsernameAttribute == "" {
+		l.UsernameAttribute = "uid"
+	}
+	if l.EmailAttribute == "" {
+		l.EmailAttribute = "mail"
+	}
+	if l.GroupAttribute == "" {
+		l.GroupAttribute = "memberOf"
+	}
+	if l.TimeoutSeconds <= 0 {
+		l.TimeoutSeconds = 10
+	}
+}
+
+func applyEncryptionEnvOverrides(cfg *Config) {
+	if cfg.Encryption == nil {
+		cfg.Encryption = &EncryptionConfig{}
+	}
+	e := cfg.Encryption
+	if value := strings.TrimSpace(os.Getenv("WEKNORA_ENCRYPTION_FILES")); value != "" {
+		e.Files = strings.EqualFold(value, "true")
+	}
+	if value := strings.TrimSpace(os.Getenv("WEKNORA_ENCRYPTION_CHUNK_CONTENT")); value != "" {
+		e.ChunkContent = strings.EqualFold(value, "true")
+	}
+	if value := strings.TrimSpace(os.Getenv("WEKNORA_ENCRYPTION_KEY_PROVIDER")); value != "" {
+		e.KeyProvider = value
+	}
+	if value := strings.TrimSpace(os.Getenv("WEKNORA_ENCRYPTION_KEY_FILE")); value != "" {
+		e.KeyFile = value
+	}
+	if e.KeyProvider == "" {
+		e.KeyProvider = "local"
+	}
+}
+
 func applyKnowledgeBaseEnvOverrides(cfg *Config) {
 	if cfg.KnowledgeBase == nil {
 		cfg.KnowledgeBase = &KnowledgeBaseConfig{}
@@ -914,6 +1174,10 @@ func applyAuthAndTenantDefaults(cfg *Config) {
 //
 // Env overrides (when set and parseable; out-of-range is ignored):
 //   - WEKNORA_AUDIT_RETENTION_DAYS (non-negative integer)
+//   - WEKNORA_AUDIT_EXPORT_BATCH_SIZE (positive integer)
+//   - WEKNORA_AUDIT_SYSLOG_ADDR / WEKNORA_AUDIT_SYSLOG_NETWORK
+//   - WEKNORA_AUDIT_WEBHOOK_URL / WEKNORA_AUDIT_WEBHOOK_SECRET
+//   - WEKNORA_AUDIT_OBJECT_STORAGE (bool)
 func applyAuditDefaults(cfg *Config) {
 	// Section omitted entirely -> apply the default and no env wiring
 	// is needed for the most common path.
@@ -929,6 +1193,42 @@ func applyAuditDefaults(cfg *Config) {
 			cfg.Audit.RetentionDays = n
 		}
 	}
+
+	if cfg.Audit.Export == nil {
+		cfg.Audit.Export = &AuditExportConfig{}
+	}
+	exp := cfg.Audit.Export
+	if value := strings.TrimSpace(os.Getenv("WEKNORA_AUDIT_EXPORT_BATCH_SIZE")); value != "" {
+		if n, err := strconv.Atoi(value); err == nil && n > 0 {
+			exp.BatchSize = n
+		}
+	}
+	if value := strings.TrimSpace(os.Getenv("WEKNORA_AUDIT_SYSLOG_ADDR")); value != "" {
+		exp.Syslog.Address = value
+	}
+	if value := strings.TrimSpace(os.Getenv("WEKNORA_AUDIT_SYSLOG_NETWORK")); value != "" {
+		exp.Syslog.Network = strings.ToLower(value)
+	}
+	if value := strings.TrimSpace(os.Getenv("WEKNORA_AUDIT_WEBHOOK_URL")); value != "" {
+		exp.Webhook.URL = value
+	}
+	if value := strings.TrimSpace(os.Getenv("WEKNORA_AUDIT_WEBHOOK_SECRET")); value != "" {
+		exp.Webhook.Secret = value
+	}
+	if value := strings.TrimSpace(os.Getenv("WEKNORA_AUDIT_OBJECT_STORAGE")); value != "" {
+		if b, err := strconv.ParseBool(value); err == nil {
+			exp.ObjectStorage.Enabled = b
+		}
+	}
+	if exp.BatchSize == 0 {
+		exp.BatchSize = 200
+	}
+	if exp.Syslog.Network == "" {
+		exp.Syslog.Network = "tcp"
+	}
+	if exp.Syslog.AppName == "" {
+		exp.Syslog.AppName = "weknora"
+	}
 }
 
 // into actual prompt text content. Only xxx_id fields are used;
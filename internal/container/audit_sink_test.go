@@ -27,6 +27,12 @@ func (f *fakeAuditSvc) List(context.Context, uint64, *interfaces.AuditLogQuery)
 	return nil, nil
 }
 func (f *fakeAuditSvc) Purge(context.Context, int) (int64, error) { return 0, nil }
+func (f *fakeAuditSvc) LogAPIKeyRead(context.Context, *gin.Context, uint64, uint64) error {
+	return nil
+}
+func (f *fakeAuditSvc) VerifyChain(context.Context, uint64) (*types.AuditChainVerification, error) {
+	return nil, nil
+}
 
 func ctxWithTenant(id uint64) context.Context {
 	return context.WithValue(context.Background(), types.TenantIDContextKey, id)
@@ -22,6 +22,7 @@ import (
 	esv7 "github.com/elastic/go-elasticsearch/v7"
 	"github.com/elastic/go-elasticsearch/v8"
 	_ "github.com/go-sql-driver/mysql" // 给 Doris (database/sql) 注册 MySQL 协议驱动
+	"github.com/hibiken/asynq"
 	"github.com/milvus-io/milvus/client/v2/milvusclient"
 	"github.com/neo4j/neo4j-go-driver/v6/neo4j"
 	"github.com/panjf2000/ants/v2"
@@ -34,6 +35,7 @@ import (
 	"gorm.io/gorm"
 
 	"github.com/Tencent/WeKnora/internal/agent/approval"
+	agenttools "github.com/Tencent/WeKnora/internal/agent/tools"
 	"github.com/Tencent/WeKnora/internal/application/repository"
 	dorisRepo "github.com/Tencent/WeKnora/internal/application/repository/retriever/doris"
 	elasticsearchRepoV7 "github.com/Tencent/WeKnora/internal/application/repository/retriever/elasticsearch/v7"
@@ -68,26 +70,33 @@ import (
 	"github.com/Tencent/WeKnora/internal/handler/session"
 	imPkg "github.com/Tencent/WeKnora/internal/im"
 	"github.com/Tencent/WeKnora/internal/im/dingtalk"
+	"github.com/Tencent/WeKnora/internal/im/discord"
 	"github.com/Tencent/WeKnora/internal/im/feishu"
+	"github.com/Tencent/WeKnora/internal/im/matrix"
 	"github.com/Tencent/WeKnora/internal/im/mattermost"
 	"github.com/Tencent/WeKnora/internal/im/qqbot"
 	"github.com/Tencent/WeKnora/internal/im/slack"
+	"github.com/Tencent/WeKnora/internal/im/teams"
 	"github.com/Tencent/WeKnora/internal/im/telegram"
 	"github.com/Tencent/WeKnora/internal/im/wechat"
 	"github.com/Tencent/WeKnora/internal/im/wecom"
 	"github.com/Tencent/WeKnora/internal/im/yunzhijia"
+	"github.com/Tencent/WeKnora/internal/infrastructure/browser"
 	"github.com/Tencent/WeKnora/internal/infrastructure/docparser"
 	infra_web_search "github.com/Tencent/WeKnora/internal/infrastructure/web_search"
 	"github.com/Tencent/WeKnora/internal/logger"
 	"github.com/Tencent/WeKnora/internal/mcp"
+	"github.com/Tencent/WeKnora/internal/metrics"
 	"github.com/Tencent/WeKnora/internal/models/chat"
 	"github.com/Tencent/WeKnora/internal/models/embedding"
 	"github.com/Tencent/WeKnora/internal/models/limiter"
+	"github.com/Tencent/WeKnora/internal/models/metering"
 	"github.com/Tencent/WeKnora/internal/models/utils/ollama"
 	"github.com/Tencent/WeKnora/internal/router"
 	"github.com/Tencent/WeKnora/internal/storageallowlist"
 	"github.com/Tencent/WeKnora/internal/stream"
 	"github.com/Tencent/WeKnora/internal/tracing/langfuse"
+	"github.com/Tencent/WeKnora/internal/tracing/otlp"
 	"github.com/Tencent/WeKnora/internal/types"
 	"github.com/Tencent/WeKnora/internal/types/interfaces"
 	secutils "github.com/Tencent/WeKnora/internal/utils"
@@ -116,12 +125,14 @@ func BuildContainer(container *dig.Container) *dig.Container {
 	logger.Debugf(ctx, "[Container] Registering core infrastructure...")
 	must(container.Provide(config.LoadConfig))
 	must(container.Provide(initLangfuse))
+	must(container.Provide(initOTLP))
 	must(container.Provide(initDatabase))
 	must(container.Provide(initFileService))
 	must(container.Provide(initRedisClient))
 	must(container.Provide(initAntsPool))
 
 	must(container.Invoke(registerLangfuseCleanup))
+	must(container.Invoke(registerOTLPCleanup))
 
 	// Register goroutine pool cleanup handler
 	must(container.Invoke(registerPoolCleanup))
@@ -139,17 +150,30 @@ func BuildContainer(container *dig.Container) *dig.Container {
 	must(container.Provide(stream.NewStreamManager))
 	logger.Debugf(ctx, "[Container] Initializing DuckDB...")
 	must(container.Provide(NewDuckDB))
+	// Analysis sessions keep data_analysis tables and views per chat session
+	// on top of the shared DuckDB instance.
+	must(container.Provide(agenttools.NewAnalysisSessions))
 	logger.Debugf(ctx, "[Container] DuckDB registered")
+	// Browser sessions keep the pages of the browser_* tools open across
+	// turns; the browser config service closes them when a config goes away.
+	must(container.Provide(browser.NewSessions))
 
 	// Data repositories layer
 	logger.Debugf(ctx, "[Container] Registering repositories...")
 	must(container.Provide(repository.NewTenantRepository))
 	must(container.Provide(repository.NewTenantAPIKeyRepository))
 	must(container.Provide(repository.NewTenantMemberRepository))
+	must(container.Provide(repository.NewTenantCustomRoleRepository))
+	must(container.Provide(repository.NewTenantSCIMUserRepository))
+	must(container.Provide(repository.NewTenantSCIMOrganizationRepository))
+	must(container.Provide(repository.NewTenantDataKeyRepository))
 	must(container.Provide(repository.NewTenantInvitationRepository))
 	must(container.Provide(repository.NewAuditLogRepository))
 	must(container.Provide(repository.NewKnowledgeBaseRepository))
 	must(container.Provide(repository.NewKnowledgeRepository))
+	must(container.Provide(repository.NewKnowledgeVersionRepository))
+	must(container.Provide(repository.NewKnowledgeFingerprintRepository))
+	must(container.Provide(repository.NewKnowledgeFreshnessRepository))
 	must(container.Provide(repository.NewKnowledgeSpanRepository))
 	must(container.Provide(repository.NewChunkRepository))
 	must(container.Provide(repository.NewKnowledgeTagRepository))
@@ -161,12 +185,18 @@ func BuildContainer(container *dig.Container) *dig.Container {
 	must(container.Provide(repository.NewAuthTokenRepository))
 	must(container.Provide(repository.NewSystemSettingRepository))
 	must(container.Provide(neo4jRepo.NewNeo4jRepository))
+	must(container.Provide(repository.NewGraphCommunityRepository))
+	must(container.Provide(repository.NewGraphEntityRepository))
 	must(container.Provide(repository.NewMCPServiceRepository))
 	must(container.Provide(repository.NewMCPToolApprovalRepository))
 	must(container.Provide(repository.NewMCPOAuthRepository))
 	must(container.Provide(repository.NewTenantSandboxConfigRepository))
 	must(container.Provide(repository.NewCustomAgentRepository))
 	must(container.Provide(repository.NewOrganizationRepository))
+	must(container.Provide(repository.NewKnowledgeACLRepository))
+	must(container.Provide(repository.NewUsageRepository))
+	must(container.Provide(repository.NewRateLimitPolicyRepository))
+	must(container.Provide(repository.NewDataSubjectRepository))
 	must(container.Provide(repository.NewKBShareRepository))
 	must(container.Provide(repository.NewAgentShareRepository))
 	must(container.Provide(repository.NewEmbedChannelRepository))
@@ -200,9 +230,20 @@ func BuildContainer(container *dig.Container) *dig.Container {
 	must(container.Provide(service.NewTenantService))
 	must(container.Provide(service.NewTenantAPIKeyService))
 	must(container.Provide(service.NewTenantMemberService))
+	must(container.Provide(service.NewTenantCustomRoleService))
+	must(container.Provide(service.NewSCIMService))
 	must(container.Provide(service.NewTenantInvitationService))
 	must(container.Provide(service.NewAuditLogService))
 	must(container.Provide(service.NewAuditLogRetentionRunner))
+	must(container.Provide(newAuditLogExportRunner))
+	must(container.Provide(service.NewUsageService))
+	must(container.Provide(service.NewRateLimitService))
+	must(container.Provide(service.NewDataSubjectService))
+	must(container.Invoke(registerUsageMeter))
+	must(container.Provide(service.NewTenantDataKeyService))
+	must(container.Provide(newFileEncryption))
+	must(container.Invoke(registerEncryption))
+	must(container.Provide(service.NewKnowledgeACLService)) // KnowledgeACLService must be registered before KnowledgeBaseService and SessionService
 	must(container.Provide(service.NewKnowledgeBaseService))
 	must(container.Provide(service.NewOrganizationService))
 	must(container.Provide(service.NewKBShareService)) // KBShareService must be registered before KnowledgeService and KnowledgeTagService
@@ -228,6 +269,9 @@ func BuildContainer(container *dig.Container) *dig.Container {
 	}))
 	must(container.Provide(service.NewWeKnoraCloudService))
 
+	must(container.Provide(service.NewGraphCommunityService))
+	must(container.Provide(service.NewGraphEntityService))
+
 	// Extract services - register individual extracters with names
 	must(container.Provide(service.NewChunkExtractService, dig.Name("chunkExtractor")))
 	must(container.Provide(service.NewDataTableSummaryService, dig.Name("dataTableSummary")))
@@ -251,6 +295,8 @@ func BuildContainer(container *dig.Container) *dig.Container {
 	must(container.Provide(infra_web_search.NewRegistry))
 	must(container.Invoke(registerWebSearchProviders))
 	must(container.Provide(repository.NewWebSearchProviderRepository))
+	must(container.Provide(repository.NewSQLConnectionRepository))
+	must(container.Provide(repository.NewBrowserConfigRepository))
 	must(container.Provide(repository.NewVectorStoreRepository))
 	must(container.Provide(repository.NewStorageBackendRepository))
 	must(container.Provide(repository.NewResourceRepository))
@@ -261,6 +307,8 @@ func BuildContainer(container *dig.Container) *dig.Container {
 	must(container.Provide(retriever.NewVectorStoreRepoOwnership))
 	must(container.Provide(service.NewWebSearchService))
 	must(container.Provide(service.NewWebSearchProviderService))
+	must(container.Provide(service.NewSQLConnectionService))
+	must(container.Provide(service.NewBrowserConfigService))
 	must(container.Provide(NewEngineFactory))
 	// StoreRegistry: same instance as RetrieveEngineRegistry, exposed as StoreRegistry interface.
 	// NewRetrieveEngineRegistry always returns *retriever.RetrieveEngineRegistry which implements both.
@@ -299,8 +347,8 @@ func BuildContainer(container *dig.Container) *dig.Container {
 	// ArtifactCollector drains skill-generated files from the sandbox on
 	// each agent turn (see spec at
 	// docs/superpowers/specs/2026-07-10-skill-artifact-download-design.md).
-	// The factory returns nil when the sandbox backend does not support
-	// per-session file inspection; downstream code guards on nil.
+	// It also attaches files staged in-process by the data_chart tool, so it
+	// exists whenever a file service does; downstream code guards on nil.
 	must(container.Provide(service.NewArtifactCollectorFromSandboxManager))
 
 	logger.Debugf(ctx, "[Container] Registering task enqueuer...")
@@ -320,6 +368,8 @@ func BuildContainer(container *dig.Container) *dig.Container {
 		// dequeue of pending/scheduled/retry tasks + active-task cancel).
 		must(container.Provide(router.NewAsynqInspector))
 		must(container.Provide(router.NewAsynqTaskInspector))
+		// Queue depth gauges for /metrics, read from Redis at scrape time.
+		must(container.Invoke(registerQueueMetrics))
 		// Install the distributed per-model chat concurrency governor. Only
 		// available with Redis (the shared semaphore backend); Lite mode is
 		// single-process and low-volume, so it runs ungated.
@@ -351,6 +401,8 @@ func BuildContainer(container *dig.Container) *dig.Container {
 	logger.Debugf(ctx, "[Container] Data source sync framework registered")
 	must(container.Invoke(startAuditLogRetention))
 	logger.Debugf(ctx, "[Container] Audit log retention runner registered")
+	must(container.Invoke(startAuditLogExport))
+	logger.Debugf(ctx, "[Container] Audit log export runner registered")
 	must(container.Provide(service.NewHousekeepingService))
 	must(container.Invoke(startHousekeepingService))
 	logger.Debugf(ctx, "[Container] Knowledge housekeeping runner registered")
@@ -378,9 +430,14 @@ func BuildContainer(container *dig.Container) *dig.Container {
 	logger.Debugf(ctx, "[Container] Registering HTTP handlers...")
 	must(container.Provide(handler.NewTenantHandler))
 	must(container.Provide(handler.NewTenantMemberHandler))
+	must(container.Provide(handler.NewTenantCustomRoleHandler))
+	must(container.Provide(handler.NewSCIMHandler))
+	must(container.Provide(handler.NewEncryptionHandler))
 	must(container.Provide(handler.NewTenantInvitationHandler))
 	must(container.Provide(handler.NewAuditLogHandler))
 	must(container.Provide(handler.NewKnowledgeBaseHandler))
+	must(container.Provide(handler.NewGraphCommunityHandler))
+	must(container.Provide(handler.NewGraphEntityHandler))
 	must(container.Provide(handler.NewKnowledgeHandler))
 	must(container.Provide(handler.NewChunkHandler))
 	must(container.Provide(handler.NewFAQHandler))
@@ -402,6 +459,10 @@ func BuildContainer(container *dig.Container) *dig.Container {
 	must(container.Provide(handler.NewDataSourceCredentialsHandler))
 	must(container.Provide(handler.NewWebSearchHandler))
 	must(container.Provide(handler.NewWebSearchProviderHandler))
+	must(container.Provide(handler.NewSQLConnectionHandler))
+	must(container.Provide(handler.NewSQLConnectionCredentialsHandler))
+	must(container.Provide(handler.NewBrowserConfigHandler))
+	must(container.Provide(handler.NewBrowserConfigCredentialsHandler))
 	must(container.Provide(handler.NewVectorStoreHandler))
 	must(container.Provide(handler.NewStorageBackendHandler))
 	must(container.Provide(handler.NewCustomAgentHandler))
@@ -410,6 +471,10 @@ func BuildContainer(container *dig.Container) *dig.Container {
 	must(container.Provide(handler.NewSkillHandler))
 	must(container.Provide(handler.NewOrganizationHandler))
 	must(container.Provide(handler.NewMemoryHandler))
+	must(container.Provide(handler.NewKnowledgeACLHandler))
+	must(container.Provide(handler.NewUsageHandler))
+	must(container.Provide(handler.NewRateLimitHandler))
+	must(container.Provide(handler.NewDataSubjectHandler))
 
 	// Data source handler
 	must(container.Provide(handler.NewDataSourceHandler))
@@ -419,6 +484,9 @@ func BuildContainer(container *dig.Container) *dig.Container {
 	logger.Debugf(ctx, "[Container] Registering IM integration...")
 	must(container.Provide(imPkg.NewService))
 	must(container.Invoke(registerIMService))
+	must(container.Provide(func(s *imPkg.Service) interfaces.IMNotifier { return s }))
+	must(container.Provide(service.NewKnowledgeFreshnessService))
+	must(container.Invoke(startKnowledgeFreshnessService))
 	must(container.Provide(handler.NewIMHandler))
 	must(container.Provide(handler.NewEmbedChannelHandler))
 	must(container.Provide(handler.NewWeKnoraCloudHandler))
@@ -935,16 +1003,20 @@ func syncSequences(db *gorm.DB) {
 // Supports multiple storage backends (MinIO, COS, local filesystem)
 // Parameters:
 //   - cfg: Application configuration
+//   - catalog: Resource catalog that maps logical paths to stored objects
+//   - enc: Encryption applied to stored files
 //
 // Returns:
 //   - Configured file service implementation
 //   - Error if initialization fails
-func initFileService(cfg *config.Config, catalog interfaces.ResourceCatalog) (interfaces.FileService, error) {
+func initFileService(
+	cfg *config.Config, catalog interfaces.ResourceCatalog, enc *file.Encryption,
+) (interfaces.FileService, error) {
 	inner, err := initRawFileService(cfg)
 	if err != nil {
 		return nil, err
 	}
-	return file.NewResourceCatalogFileService(inner, catalog), nil
+	return file.NewResourceCatalogFileService(enc.Wrap(inner), catalog), nil
 }
 
 func initRawFileService(_ *config.Config) (interfaces.FileService, error) {
@@ -1481,6 +1553,26 @@ func registerLangfuseCleanup(mgr *langfuse.Manager, cleaner interfaces.ResourceC
 	})
 }
 
+// initOTLP initializes OpenTelemetry trace export. It is enabled by the
+// standard OTEL_EXPORTER_OTLP_ENDPOINT variable (see docs/链路追踪.md) and
+// returns a nil provider otherwise.
+func initOTLP() (*otlp.Provider, error) {
+	return otlp.Init(otlp.LoadConfigFromEnv())
+}
+
+// registerOTLPCleanup flushes buffered spans on shutdown, with the same
+// timeout as the Langfuse cleanup.
+func registerOTLPCleanup(p *otlp.Provider, cleaner interfaces.ResourceCleaner) {
+	if p == nil {
+		return
+	}
+	cleaner.RegisterWithName("OTLP", func() error {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		return p.Shutdown(ctx)
+	})
+}
+
 // initDocReaderClient initializes the DocumentReader client (lightweight API).
 func initDocReaderClient(cfg *config.Config) (interfaces.DocumentReader, error) {
 	addr := strings.TrimSpace(os.Getenv("DOCREADER_ADDR"))
@@ -1622,6 +1714,9 @@ func registerIMService(imService *imPkg.Service, cleaner interfaces.ResourceClea
 	imService.RegisterAdapterFactory("wechat", wechat.NewFactory())
 	imService.RegisterAdapterFactory("qqbot", qqbot.NewFactory())
 	imService.RegisterAdapterFactory("yunzhijia", yunzhijia.NewFactory())
+	imService.RegisterAdapterFactory("discord", discord.NewFactory())
+	imService.RegisterAdapterFactory("teams", teams.NewFactory())
+	imService.RegisterAdapterFactory("matrix", matrix.NewFactory())
 
 	// Load and start all enabled channels from database
 	if err := imService.LoadAndStartChannels(); err != nil {
@@ -1713,6 +1808,22 @@ func startHousekeepingService(svc *service.HousekeepingService, cleaner interfac
 	})
 }
 
+// startKnowledgeFreshnessService starts the hourly freshness sweep (tag
+// rules, review scheduling, stale notifications). Like housekeeping, a start
+// error is logged and does not abort the container.
+func startKnowledgeFreshnessService(svc *service.KnowledgeFreshnessService, cleaner interfaces.ResourceCleaner) {
+	if svc == nil {
+		return
+	}
+	if err := svc.Start(context.Background()); err != nil {
+		logger.Warnf(context.Background(), "[Container] knowledge freshness start failed: %v", err)
+	}
+	cleaner.RegisterWithName("KnowledgeFreshness", func() error {
+		svc.Stop()
+		return nil
+	})
+}
+
 // startTemporaryDocumentCleanup removes expired session attachments and their
 // extracted images. The durable expiry timestamp is the source of truth; the
 // ticker only controls how quickly storage is reclaimed.
@@ -1756,3 +1867,73 @@ func startAuditLogRetention(
 		return nil
 	})
 }
+
+// newAuditLogExportRunner builds the SIEM export runner. The object
+// storage sink writes through the raw storage backend: audit batches are
+// platform files, not tenant resources, and must not be registered in the
+// resource catalog.
+func newAuditLogExportRunner(
+	cfg *config.Config, repo interfaces.AuditLogRepository,
+) (*service.AuditLogExportRunner, error) {
+	var files interfaces.FileService
+	if cfg.Audit != nil && cfg.Audit.Export != nil && cfg.Audit.Export.ObjectStorage.Enabled {
+		raw, err := initRawFileService(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("audit object storage sink: %w", err)
+		}
+		files = raw
+	}
+	return service.NewAuditLogExportRunner(cfg, repo, files)
+}
+
+// startAuditLogExport starts one shipping loop per configured sink and
+// stops them on shutdown. Without sinks the runner stays dormant.
+func startAuditLogExport(
+	runner *service.AuditLogExportRunner, cleaner interfaces.ResourceCleaner,
+) {
+	runner.Start(context.Background())
+	cleaner.RegisterWithName("AuditLogExportRunner", func() error {
+		runner.Stop()
+		return nil
+	})
+}
+
+// registerQueueMetrics exposes the depth of every declared asynq queue.
+func registerQueueMetrics(inspector *asynq.Inspector) error {
+	queues := make([]string, 0, len(types.QueueDefinitions()))
+	for _, q := range types.QueueDefinitions() {
+		queues = append(queues, q.Name)
+	}
+	return metrics.RegisterQueueCollector(inspector, queues)
+}
+
+// registerUsageMeter installs the usage service as the meter every model
+// decorator reports to, with the rate limits counting chat tokens next to
+// it, and flushes its buffered ledger rows on shutdown.
+func registerUsageMeter(
+	usage interfaces.UsageService, limits interfaces.RateLimitService, cleaner interfaces.ResourceCleaner,
+) {
+	metering.SetMeter(metering.WithRecorders(usage, limits))
+	cleaner.RegisterWithName("UsageMeter", func() error {
+		metering.SetMeter(nil)
+		return usage.Close()
+	})
+}
+
+// registerEncryption registers the tenant data keys on the database as the
+// chunk content cipher. The plugin is always installed so that sealed rows
+// read back after the key file was removed are reported instead of passed on
+// silently.
+func registerEncryption(db *gorm.DB, keys interfaces.TenantDataKeyService) error {
+	return db.Use(service.NewContentCipherPlugin(keys))
+}
+
+// newFileEncryption builds the wrapper that seals stored files with the
+// tenant data keys. Without a configured key provider files are neither
+// sealed nor opened.
+func newFileEncryption(keys interfaces.TenantDataKeyService, cfg *config.Config) *file.Encryption {
+	if !keys.Configured() {
+		return file.NewEncryption(nil, false)
+	}
+	return file.NewEncryption(keys, cfg.Encryption.Files)
+}
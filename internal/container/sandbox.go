@@ -99,12 +99,29 @@ func (c *repositorySessionExistenceChecker) SessionExists(
 // buildGlobalSandboxConfig returns the process-wide *sandbox.Config that
 // per-tenant overrides are merged onto.
 func buildGlobalSandboxConfig() *sandbox.Config {
+	sandbox.SetNamespaceHostConfig(namespaceSandboxHostConfig())
+	sandbox.SetWasmHostConfig(sandbox.WasmHostConfig{
+		RuntimeDir: strings.TrimSpace(os.Getenv("WEKNORA_WASM_SANDBOX_DIR")),
+	})
 	cfg := sandbox.DefaultConfig()
 	cfg.Type = sandbox.SandboxTypeDisabled
 	cfg.FallbackEnabled = false
 	return cfg
 }
 
+// namespaceSandboxHostConfig reads where the namespace backend keeps its
+// state on this host. Unlike provider endpoints these are not workspace
+// settings: they name local directories and a cgroup that only the operator
+// can vouch for.
+func namespaceSandboxHostConfig() sandbox.NamespaceHostConfig {
+	return sandbox.NamespaceHostConfig{
+		StateDir:   strings.TrimSpace(os.Getenv("WEKNORA_NS_SANDBOX_STATE_DIR")),
+		Rootfs:     strings.TrimSpace(os.Getenv("WEKNORA_NS_SANDBOX_ROOTFS")),
+		CgroupRoot: strings.TrimSpace(os.Getenv("WEKNORA_NS_SANDBOX_CGROUP_ROOT")),
+		InitPath:   strings.TrimSpace(os.Getenv("WEKNORA_NS_SANDBOX_INIT")),
+	}
+}
+
 // newTenantSandboxResolver wires the workspace-config resolver. The
 // process-wide manager is disabled; agents without a selected config stay
 // disabled as well.
@@ -44,6 +44,12 @@ const (
 	ErrVectorStoreBindingInvalid ErrorCode = 2200
 	ErrVectorStoreUnavailable    ErrorCode = 2201
 
+	// Usage metering related error codes (2300-2399).
+	// ErrUsageBudgetExceeded is returned with HTTP 429 once a monthly token
+	// budget with hard stop enabled is used up, so clients can tell it apart
+	// from ordinary rate limiting (1006).
+	ErrUsageBudgetExceeded ErrorCode = 2300
+
 	// Add more error codes here
 )
 
@@ -259,6 +265,20 @@ func NewVectorStoreUnavailableError(message string) *AppError {
 	}
 }
 
+// NewUsageBudgetExceededError signals that the tenant or API key making the
+// call has used up a monthly token budget that has hard stop enabled. Details
+// carries the budget scope, period, limit and usage.
+func NewUsageBudgetExceededError(message string) *AppError {
+	if message == "" {
+		message = "monthly token budget exceeded"
+	}
+	return &AppError{
+		Code:     ErrUsageBudgetExceeded,
+		Message:  message,
+		HTTPCode: http.StatusTooManyRequests,
+	}
+}
+
 // IsAppError checks if the error is an AppError type
 func IsAppError(err error) (*AppError, bool) {
 	appErr, ok := err.(*AppError)
@@ -56,6 +56,11 @@ const (
 	// timeout (whole task), not the docreader-call-level timeout.
 	ErrCodeTaskTimeout = "TASK_TIMEOUT"
 
+	// ErrCodeDuplicateContent — the document is a near-duplicate of one
+	// already indexed and the knowledge base's dedup policy is "skip".
+	// The matched document is named in the error detail.
+	ErrCodeDuplicateContent = "DUPLICATE_CONTENT"
+
 	// ErrCodeUnknown — fallback when a wrapped error doesn't classify.
 	// The full message is still recorded in error_detail so operators
 	// can debug; the UI shows a generic "see admin" hint.
@@ -1,6 +1,8 @@
 package handler
 
 import (
+	"context"
+	"encoding/json"
 	"net/http"
 	"strconv"
 
@@ -106,6 +108,32 @@ func (h *AuditLogHandler) ListTenantAuditLog(c *gin.Context) {
 	})
 }
 
+// VerifyTenantAuditLogChain godoc
+// @Summary      校验空间审计日志哈希链
+// @Description  按 chain_seq 顺序重算该空间每条审计记录的哈希并与链头比对，任何记录被修改、删除或重排都会使校验失败并返回首个断点。保留期清理删除的前缀以检查点衔接，不视为断链；启用哈希链之前写入的历史记录计入 unchained。
+// @Tags         审计日志
+// @Produce      json
+// @Param        id   path  string  true  "空间ID"
+// @Success      200  {object}  map[string]interface{}  "data 为 AuditChainVerification"
+// @Failure      400  {object}  errors.AppError
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /tenants/{id}/audit-log/verify [get]
+func (h *AuditLogHandler) VerifyTenantAuditLogChain(c *gin.Context) {
+	ctx := c.Request.Context()
+	tenantID, ok := parseTenantIDFromPath(c)
+	if !ok {
+		return
+	}
+	result, err := h.auditService.VerifyChain(ctx, tenantID)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{"tenant_id": tenantID})
+		c.Error(errors.NewInternalServerError(err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": result})
+}
+
 // ListKnowledgeBaseActivity returns the durable activity projection for one
 // knowledge base. The route has already resolved KB access; this handler adds
 // an owner-tenant check so organization-shared consumers cannot inspect source
@@ -255,3 +283,45 @@ func (h *AuditLogHandler) ListSystemAuditLog(c *gin.Context) {
 		NextCursor: nextCursor,
 	})
 }
+
+// recordDataExport audits content leaving the system through a download or
+// export route. The row lands in ownerTenantID, the workspace that owns the
+// data, so a shared knowledge base's owner sees who took copies; a caller
+// from another workspace is named in details. Best effort: a missing audit
+// service or a failed write never blocks the download.
+func recordDataExport(
+	c *gin.Context,
+	ownerTenantID uint64,
+	kbID string,
+	action types.AuditAction,
+	targetType, targetID string,
+	details map[string]any,
+) {
+	svc := middleware.AuditServiceFromContext(c)
+	if svc == nil || ownerTenantID == 0 {
+		return
+	}
+	if details == nil {
+		details = map[string]any{}
+	}
+	if callerTenantID := c.GetUint64(types.TenantIDContextKey.String()); callerTenantID != 0 &&
+		callerTenantID != ownerTenantID {
+		details["caller_tenant_id"] = callerTenantID
+	}
+	raw, _ := json.Marshal(details)
+	ctx := c.Request.Context()
+	actorID, actorRole := types.AuditActorFromContext(ctx)
+	_ = svc.Log(context.WithoutCancel(ctx), &types.AuditLog{
+		TenantID:      ownerTenantID,
+		ActorUserID:   actorID,
+		ActorRole:     actorRole,
+		Action:        action,
+		ScopeType:     "knowledge_base",
+		ScopeID:       kbID,
+		TargetType:    targetType,
+		TargetID:      targetID,
+		RequestPath:   c.Request.URL.Path,
+		RequestMethod: c.Request.Method,
+		Details:       types.JSON(raw),
+	})
+}
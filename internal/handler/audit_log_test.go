@@ -20,7 +20,18 @@ import (
 // surfaces a contract drift loudly instead of silently working.
 type stubAuditService struct {
 	interfaces.AuditLogService
-	list func(ctx context.Context, tenantID uint64, q *interfaces.AuditLogQuery) ([]*types.AuditLog, error)
+	list   func(ctx context.Context, tenantID uint64, q *interfaces.AuditLogQuery) ([]*types.AuditLog, error)
+	verify func(ctx context.Context, tenantID uint64) (*types.AuditChainVerification, error)
+	logged []*types.AuditLog
+}
+
+func (s *stubAuditService) Log(_ context.Context, entry *types.AuditLog) error {
+	s.logged = append(s.logged, entry)
+	return nil
+}
+
+func (s *stubAuditService) VerifyChain(ctx context.Context, tenantID uint64) (*types.AuditChainVerification, error) {
+	return s.verify(ctx, tenantID)
 }
 
 func (s *stubAuditService) List(
@@ -40,6 +51,7 @@ func newAuditHandlerTestRouter(svc interfaces.AuditLogService) *gin.Engine {
 	r.Use(middleware.ErrorHandler())
 	h := NewAuditLogHandler(svc)
 	r.GET("/tenants/:id/audit-log", h.ListTenantAuditLog)
+	r.GET("/tenants/:id/audit-log/verify", h.VerifyTenantAuditLogChain)
 	return r
 }
 
@@ -157,6 +169,37 @@ func TestAuditLogHandler_InvalidTenantIDReturns400(t *testing.T) {
 	}
 }
 
+func TestAuditLogHandler_VerifyReturnsFirstBreak(t *testing.T) {
+	// A broken chain is still a 200: the verification ran, and the body
+	// says where it failed so an operator can inspect that row.
+	svc := &stubAuditService{
+		verify: func(_ context.Context, tenantID uint64) (*types.AuditChainVerification, error) {
+			if tenantID != 7 {
+				t.Fatalf("expected tenant 7, got %d", tenantID)
+			}
+			return &types.AuditChainVerification{
+				TenantID: 7, Checked: 12, BrokenAtSeq: 5, BrokenAtID: 88, Reason: types.AuditChainHashMismatch,
+			}, nil
+		},
+	}
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/tenants/7/audit-log/verify", nil)
+	newAuditHandlerTestRouter(svc).ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d body=%s", w.Code, w.Body.String())
+	}
+	var got struct {
+		Success bool                         `json:"success"`
+		Data    types.AuditChainVerification `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if !got.Success || got.Data.Valid || got.Data.BrokenAtSeq != 5 || got.Data.Reason != types.AuditChainHashMismatch {
+		t.Fatalf("unexpected body %s", w.Body.String())
+	}
+}
+
 func newKBActivityHandlerTestRouter(
 	t *testing.T,
 	svc interfaces.AuditLogService,
@@ -408,3 +451,37 @@ func TestSystemAuditLogHandler_ServiceErrorReturns500(t *testing.T) {
 		t.Fatalf("expected non-empty error body for drawer alert")
 	}
 }
+
+func TestRecordDataExport_LandsInOwnerWorkspace(t *testing.T) {
+	// A download from an organization-shared KB is recorded against the
+	// workspace that owns the document, naming the caller's workspace, so
+	// the owner's audit feed shows every copy that left.
+	svc := &stubAuditService{}
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(middleware.AuditServiceProvider(svc))
+	r.Use(func(c *gin.Context) {
+		c.Set(types.TenantIDContextKey.String(), uint64(9))
+		c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), types.UserIDContextKey, "u-reader"))
+		c.Next()
+	})
+	r.GET("/knowledge/:id/download", func(c *gin.Context) {
+		recordDataExport(c, 7, "kb-1", types.AuditActionKnowledgeDownloaded, "knowledge", c.Param("id"),
+			map[string]any{"file_name": "plan.pdf"})
+		c.Status(http.StatusOK)
+	})
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/knowledge/doc-1/download", nil))
+
+	if len(svc.logged) != 1 {
+		t.Fatalf("expected 1 audit row, got %d", len(svc.logged))
+	}
+	e := svc.logged[0]
+	if e.TenantID != 7 || e.ScopeID != "kb-1" || e.TargetID != "doc-1" || e.ActorUserID != "u-reader" {
+		t.Fatalf("unexpected entry %+v", e)
+	}
+	var details map[string]any
+	_ = json.Unmarshal(e.Details, &details)
+	if details["caller_tenant_id"] != float64(9) || details["file_name"] != "plan.pdf" {
+		t.Fatalf("unexpected details %v", details)
+	}
+}
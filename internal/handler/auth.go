@@ -360,6 +360,75 @@ func (h *AuthHandler) GetOIDCConfig(c *gin.Context) {
 	})
 }
 
+// GetLDAPConfig godoc
+// @Summary      获取LDAP登录配置
+// @Description  返回LDAP登录是否启用以及展示名称，供前端决定是否展示目录账号登录入口
+// @Tags         认证
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}  types.LDAPConfigResponse
+// @Router       /auth/ldap/config [get]
+func (h *AuthHandler) GetLDAPConfig(c *gin.Context) {
+	providerDisplayName := ""
+	enabled := false
+
+	if h.configInfo != nil && h.configInfo.LDAPAuth != nil {
+		enabled = h.configInfo.LDAPAuth.Enable
+		providerDisplayName = strings.TrimSpace(h.configInfo.LDAPAuth.ProviderDisplayName)
+	}
+
+	c.JSON(http.StatusOK, &types.LDAPConfigResponse{
+		Success:             true,
+		Enabled:             enabled,
+		ProviderDisplayName: providerDisplayName,
+	})
+}
+
+// LDAPLogin godoc
+// @Summary      LDAP登录
+// @Description  使用LDAP / Active Directory账号登录。首次登录自动创建本地账号，并按目录组映射加入空间
+// @Tags         认证
+// @Accept       json
+// @Produce      json
+// @Param        request  body      types.LDAPLoginRequest  true  "目录账号与密码"
+// @Success      200      {object}  types.LoginResponse
+// @Failure      401      {object}  errors.AppError  "认证失败"
+// @Failure      403      {object}  errors.AppError  "LDAP未启用"
+// @Router       /auth/ldap/login [post]
+func (h *AuthHandler) LDAPLogin(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	if h.configInfo == nil || h.configInfo.LDAPAuth == nil || !h.configInfo.LDAPAuth.Enable {
+		c.Error(errors.NewForbiddenError("LDAP login is disabled"))
+		return
+	}
+
+	var req types.LDAPLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error(ctx, "Failed to parse LDAP login request parameters", err)
+		appErr := errors.NewValidationError("Invalid login parameters").WithDetails(err.Error())
+		c.Error(appErr)
+		return
+	}
+	username := secutils.SanitizeForLog(req.Username)
+
+	response, err := h.userService.LoginWithLDAP(ctx, req.Username, req.Password, h.resolveDefaultTenantMode(ctx))
+	if err != nil {
+		logger.Errorf(ctx, "Failed to complete LDAP login for %s: %v", username, err)
+		appErr := errors.NewUnauthorizedError("Login failed").WithDetails(err.Error())
+		c.Error(appErr)
+		return
+	}
+	if !response.Success {
+		logger.Warnf(ctx, "LDAP login failed for %s: %s", username, response.Message)
+		c.JSON(http.StatusUnauthorized, dto.NewAuthLoginResponse(response))
+		return
+	}
+
+	logger.Infof(ctx, "User logged in via LDAP: %s", username)
+	c.JSON(http.StatusOK, dto.NewAuthLoginResponse(response))
+}
+
 // OIDCRedirectCallback godoc
 // @Summary      OIDC登录重定向回调
 // @Description  接收OIDC provider回调并由后端完成code交换，随后重定向回前端登录页
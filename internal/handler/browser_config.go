@@ -0,0 +1,378 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/handler/dto"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	secutils "github.com/Tencent/WeKnora/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// BrowserConfigHandler handles HTTP requests for headless browser config CRUD
+type BrowserConfigHandler struct {
+	service interfaces.BrowserConfigService
+}
+
+// NewBrowserConfigHandler creates a new handler
+func NewBrowserConfigHandler(service interfaces.BrowserConfigService) *BrowserConfigHandler {
+	return &BrowserConfigHandler{service: service}
+}
+
+// --- request DTOs ---
+
+// CreateBrowserConfigRequest defines the request body for creating a config
+type CreateBrowserConfigRequest struct {
+	Name        string                        `json:"name" binding:"required"`
+	Description string                        `json:"description"`
+	Parameters  types.BrowserConfigParameters `json:"parameters"`
+	Options     types.BrowserConfigOptions    `json:"options"`
+}
+
+// UpdateBrowserConfigRequest defines the request body for updating a config.
+// The token is managed through /credentials.
+type UpdateBrowserConfigRequest struct {
+	Name        string                        `json:"name"`
+	Description string                        `json:"description"`
+	Parameters  types.BrowserConfigParameters `json:"parameters"`
+	Options     types.BrowserConfigOptions    `json:"options"`
+}
+
+// TestBrowserConfigRequest defines the body for testing unsaved config settings
+type TestBrowserConfigRequest struct {
+	Parameters types.BrowserConfigParameters `json:"parameters"`
+	Options    types.BrowserConfigOptions    `json:"options"`
+}
+
+// --- helpers ---
+
+// getTenantID extracts tenant ID from gin context (set by auth middleware).
+func (h *BrowserConfigHandler) getTenantID(c *gin.Context) uint64 {
+	return c.GetUint64(types.TenantIDContextKey.String())
+}
+
+// getOwnedConfig loads a config and verifies it belongs to the given tenant.
+// Returns (nil, status, msg) on failure so callers can respond immediately.
+func (h *BrowserConfigHandler) getOwnedConfig(
+	ctx context.Context, tenantID uint64, id string,
+) (*types.BrowserConfig, int, string) {
+	cfg, err := h.service.GetConfig(ctx, tenantID, id)
+	if err != nil {
+		return nil, http.StatusInternalServerError, "failed to query browser config"
+	}
+	if cfg == nil {
+		return nil, http.StatusNotFound, "browser config not found"
+	}
+	return cfg, http.StatusOK, ""
+}
+
+// --- endpoints ---
+
+// CreateConfig creates a new browser config.
+//
+// CreateConfig godoc
+// @Summary      创建浏览器服务配置
+// @Description  登记一个无头浏览器服务（CDP 端点），供智能体的浏览器自动化工具使用
+// @Tags         浏览器服务
+// @Accept       json
+// @Produce      json
+// @Param        request  body      handler.CreateBrowserConfigRequest  true  "浏览器服务配置"
+// @Success      201      {object}  dto.BrowserConfigResponse           "创建的配置"
+// @Failure      400      {object}  map[string]interface{}              "请求参数错误"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /browser-configs [post]
+func (h *BrowserConfigHandler) CreateConfig(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	tenantID := h.getTenantID(c)
+	if tenantID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "error": "unauthorized: workspace context missing"})
+		return
+	}
+
+	var req CreateBrowserConfigRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Warnf(ctx, "Invalid create browser config request: %v", err)
+		c.Error(errors.NewBadRequestError(err.Error()))
+		return
+	}
+
+	cfg := &types.BrowserConfig{
+		TenantID:    tenantID,
+		Name:        secutils.SanitizeForLog(req.Name),
+		Description: secutils.SanitizeForLog(req.Description),
+		Parameters:  req.Parameters,
+		Options:     req.Options,
+	}
+
+	if err := h.service.CreateConfig(ctx, cfg); err != nil {
+		logger.Warnf(ctx, "Failed to create browser config: %v", err)
+		c.Error(errors.NewBadRequestError(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    dto.NewBrowserConfigResponse(cfg),
+	})
+}
+
+// ListConfigs lists all browser configs for the current tenant.
+//
+// ListConfigs godoc
+// @Summary      获取浏览器服务配置列表
+// @Description  列出当前空间登记的浏览器服务（不含访问令牌）
+// @Tags         浏览器服务
+// @Produce      json
+// @Success      200  {object}  map[string]interface{}  "配置列表"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /browser-configs [get]
+func (h *BrowserConfigHandler) ListConfigs(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	tenantID := h.getTenantID(c)
+	if tenantID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "error": "unauthorized: workspace context missing"})
+		return
+	}
+
+	cfgs, err := h.service.ListConfigs(ctx, tenantID)
+	if err != nil {
+		logger.Warnf(ctx, "Failed to list browser configs: %v", err)
+		c.Error(errors.NewInternalServerError(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    dto.NewBrowserConfigResponses(cfgs),
+	})
+}
+
+// GetConfig retrieves a single browser config by ID.
+//
+// GetConfig godoc
+// @Summary      获取浏览器服务配置详情
+// @Description  根据 ID 获取配置（不含访问令牌）
+// @Tags         浏览器服务
+// @Produce      json
+// @Param        id   path      string                     true  "配置 ID"
+// @Success      200  {object}  dto.BrowserConfigResponse  "配置详情"
+// @Failure      404  {object}  map[string]interface{}     "配置不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /browser-configs/{id} [get]
+func (h *BrowserConfigHandler) GetConfig(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	tenantID := h.getTenantID(c)
+	if tenantID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "error": "unauthorized: workspace context missing"})
+		return
+	}
+
+	cfg, status, msg := h.getOwnedConfig(ctx, tenantID, c.Param("id"))
+	if status != http.StatusOK {
+		c.JSON(status, gin.H{"success": false, "error": msg})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    dto.NewBrowserConfigResponse(cfg),
+	})
+}
+
+// UpdateConfig updates a browser config.
+//
+// UpdateConfig godoc
+// @Summary      更新浏览器服务配置
+// @Description  更新名称、描述、端点与页面限制；访问令牌请通过 /credentials 设置。已打开的页面在下次使用时按新配置重建
+// @Tags         浏览器服务
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                              true  "配置 ID"
+// @Param        request  body      handler.UpdateBrowserConfigRequest  true  "更新字段"
+// @Success      200      {object}  dto.BrowserConfigResponse           "更新后的配置"
+// @Failure      400      {object}  map[string]interface{}              "请求参数错误"
+// @Failure      404      {object}  map[string]interface{}              "配置不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /browser-configs/{id} [put]
+func (h *BrowserConfigHandler) UpdateConfig(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	tenantID := h.getTenantID(c)
+	if tenantID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "error": "unauthorized: workspace context missing"})
+		return
+	}
+
+	id := c.Param("id")
+	existing, status, msg := h.getOwnedConfig(ctx, tenantID, id)
+	if status != http.StatusOK {
+		c.JSON(status, gin.H{"success": false, "error": msg})
+		return
+	}
+
+	var req UpdateBrowserConfigRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(errors.NewBadRequestError(err.Error()))
+		return
+	}
+
+	// The token NEVER flows through this endpoint — it lives behind the
+	// /credentials subresource.
+	if req.Parameters.Token != "" && req.Parameters.Token != existing.Parameters.Token {
+		logger.Warnf(ctx,
+			"token in PUT /browser-configs/%s body is ignored; use PUT /credentials instead",
+			secutils.SanitizeForLog(id))
+	}
+	params := req.Parameters
+	params.Token = existing.Parameters.Token
+
+	name := req.Name
+	if name == "" {
+		name = existing.Name
+	}
+	description := req.Description
+	if description == "" {
+		description = existing.Description
+	}
+
+	cfg := &types.BrowserConfig{
+		ID:          id,
+		TenantID:    tenantID,
+		Name:        secutils.SanitizeForLog(name),
+		Description: secutils.SanitizeForLog(description),
+		Parameters:  params,
+		Options:     req.Options,
+		CreatedAt:   existing.CreatedAt,
+	}
+
+	if err := h.service.UpdateConfig(ctx, cfg); err != nil {
+		logger.Warnf(ctx, "Failed to update browser config %s: %v", secutils.SanitizeForLog(id), err)
+		c.Error(errors.NewBadRequestError(err.Error()))
+		return
+	}
+
+	updated, _ := h.service.GetConfig(ctx, tenantID, id)
+	if updated != nil {
+		c.JSON(http.StatusOK, gin.H{"success": true, "data": dto.NewBrowserConfigResponse(updated)})
+	} else {
+		c.JSON(http.StatusOK, gin.H{"success": true})
+	}
+}
+
+// DeleteConfig deletes a browser config.
+//
+// DeleteConfig godoc
+// @Summary      删除浏览器服务配置
+// @Description  删除指定配置并关闭通过它打开的页面；引用它的智能体将不再注册浏览器工具
+// @Tags         浏览器服务
+// @Produce      json
+// @Param        id   path      string                  true  "配置 ID"
+// @Success      200  {object}  map[string]interface{}  "success: true"
+// @Failure      404  {object}  map[string]interface{}  "配置不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /browser-configs/{id} [delete]
+func (h *BrowserConfigHandler) DeleteConfig(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	tenantID := h.getTenantID(c)
+	if tenantID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "error": "unauthorized: workspace context missing"})
+		return
+	}
+
+	id := c.Param("id")
+	if _, status, msg := h.getOwnedConfig(ctx, tenantID, id); status != http.StatusOK {
+		c.JSON(status, gin.H{"success": false, "error": msg})
+		return
+	}
+
+	if err := h.service.DeleteConfig(ctx, tenantID, id); err != nil {
+		logger.Warnf(ctx, "Failed to delete browser config %s: %v", secutils.SanitizeForLog(id), err)
+		c.Error(errors.NewInternalServerError(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// TestConfigByID tests a saved config with its stored token.
+//
+// TestConfigByID godoc
+// @Summary      测试已保存的浏览器服务配置
+// @Description  使用已保存的令牌连接浏览器服务，打开并关闭一个空白页面
+// @Tags         浏览器服务
+// @Produce      json
+// @Param        id   path      string                  true  "配置 ID"
+// @Success      200  {object}  map[string]interface{}  "测试结果"
+// @Failure      404  {object}  map[string]interface{}  "配置不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /browser-configs/{id}/test [post]
+func (h *BrowserConfigHandler) TestConfigByID(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	tenantID := h.getTenantID(c)
+	if tenantID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "error": "unauthorized: workspace context missing"})
+		return
+	}
+
+	cfg, status, msg := h.getOwnedConfig(ctx, tenantID, c.Param("id"))
+	if status != http.StatusOK {
+		c.JSON(status, gin.H{"success": false, "error": msg})
+		return
+	}
+
+	if err := h.service.TestConfig(ctx, cfg); err != nil {
+		logger.Warnf(ctx, "Browser config test failed: %v", err)
+		c.JSON(http.StatusOK, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// TestConfigRaw tests unsaved config settings (no persistence).
+//
+// TestConfigRaw godoc
+// @Summary      使用原始配置测试浏览器服务（不落库）
+// @Description  使用前端表单中尚未保存的配置测试连通性，用于"测试连接"按钮
+// @Tags         浏览器服务
+// @Accept       json
+// @Produce      json
+// @Param        request  body      handler.TestBrowserConfigRequest  true  "{parameters, options}"
+// @Success      200      {object}  map[string]interface{}            "测试结果"
+// @Failure      400      {object}  map[string]interface{}            "请求参数错误"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /browser-configs/test [post]
+func (h *BrowserConfigHandler) TestConfigRaw(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req TestBrowserConfigRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(errors.NewBadRequestError(err.Error()))
+		return
+	}
+
+	cfg := &types.BrowserConfig{Name: "test", Parameters: req.Parameters, Options: req.Options}
+	if err := h.service.TestConfig(ctx, cfg); err != nil {
+		logger.Warnf(ctx, "Browser config test failed: %v", err)
+		c.JSON(http.StatusOK, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
@@ -0,0 +1,98 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/handler/dto"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	secutils "github.com/Tencent/WeKnora/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// BrowserConfigCredentialsHandler handles the endpoint token of browser
+// configs via the dedicated /credentials subresource. The only recognized
+// field is "token"; endpoints without authentication need none.
+type BrowserConfigCredentialsHandler struct {
+	svc interfaces.BrowserConfigService
+}
+
+func NewBrowserConfigCredentialsHandler(svc interfaces.BrowserConfigService) *BrowserConfigCredentialsHandler {
+	return &BrowserConfigCredentialsHandler{svc: svc}
+}
+
+func (h *BrowserConfigCredentialsHandler) tenantID(c *gin.Context) uint64 {
+	return c.GetUint64(types.TenantIDContextKey.String())
+}
+
+type browserConfigCredentialsPutRequest struct {
+	Token *string `json:"token,omitempty"`
+}
+
+func (h *BrowserConfigCredentialsHandler) Put(c *gin.Context) {
+	ctx := c.Request.Context()
+	tenantID := h.tenantID(c)
+	if tenantID == 0 {
+		c.Error(errors.NewBadRequestError("Workspace ID cannot be empty"))
+		return
+	}
+	id := c.Param("id")
+	var req browserConfigCredentialsPutRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(errors.NewBadRequestError(err.Error()))
+		return
+	}
+	if req.Token == nil {
+		cfg, err := h.svc.GetConfig(ctx, tenantID, id)
+		if err != nil || cfg == nil {
+			c.Error(errors.NewNotFoundError("browser config not found"))
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"success": true, "data": dto.CredentialsResponse{
+			Fields: map[string]dto.CredentialFieldMetadata{
+				"token": {Configured: cfg.Parameters.Token != ""},
+			},
+		}})
+		return
+	}
+	updated, err := h.svc.UpdateConfigCredentials(ctx, tenantID, id, req.Token)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{
+			"browser_config_id": secutils.SanitizeForLog(id),
+		})
+		c.Error(errors.NewInternalServerError("failed to update credentials: " + err.Error()))
+		return
+	}
+	resp := dto.CredentialsResponse{
+		Fields: map[string]dto.CredentialFieldMetadata{
+			"token": {Configured: updated.Parameters.Token != ""},
+		},
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": resp})
+}
+
+func (h *BrowserConfigCredentialsHandler) DeleteField(c *gin.Context) {
+	ctx := c.Request.Context()
+	tenantID := h.tenantID(c)
+	if tenantID == 0 {
+		c.Error(errors.NewBadRequestError("Workspace ID cannot be empty"))
+		return
+	}
+	id := c.Param("id")
+	field := c.Param("field")
+	if field != "token" {
+		c.Error(errors.NewBadRequestError("unknown credential field: " + secutils.SanitizeForLog(field)))
+		return
+	}
+	if err := h.svc.ClearConfigCredential(ctx, tenantID, id, field); err != nil {
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{
+			"browser_config_id": secutils.SanitizeForLog(id),
+			"field":             field,
+		})
+		c.Error(errors.NewInternalServerError("failed to clear credential: " + err.Error()))
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	secutils "github.com/Tencent/WeKnora/internal/utils"
+)
+
+// DataSubjectHandler answers data subject export and erasure requests.
+type DataSubjectHandler struct {
+	service interfaces.DataSubjectService
+}
+
+// NewDataSubjectHandler creates a new handler
+func NewDataSubjectHandler(service interfaces.DataSubjectService) *DataSubjectHandler {
+	return &DataSubjectHandler{service: service}
+}
+
+// fail reports a service error, passing application errors through as-is.
+func (h *DataSubjectHandler) fail(c *gin.Context, err error, message string) {
+	if appErr, ok := errors.IsAppError(err); ok {
+		c.Error(appErr)
+		return
+	}
+	logger.ErrorWithFields(c.Request.Context(), err, nil)
+	c.Error(errors.NewInternalServerError(message).WithDetails(err.Error()))
+}
+
+// attachmentWriter sends the download headers with the first byte, so an
+// export that fails before writing anything can still answer with an error.
+type attachmentWriter struct {
+	c        *gin.Context
+	filename string
+	started  bool
+}
+
+func (w *attachmentWriter) Write(p []byte) (int, error) {
+	if !w.started {
+		w.started = true
+		w.c.Header("Content-Type", "application/zip")
+		w.c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", w.filename))
+		w.c.Status(http.StatusOK)
+	}
+	return w.c.Writer.Write(p)
+}
+
+// Export godoc
+// @Summary      导出用户数据
+// @Description  将空间中与该用户相关的全部数据打包为 zip 下载：个人资料与成员信息、会话与消息（含已删除的会话）、临时文档、记忆、上传的文档及原文件、IM 反馈与绑定、API Key（不含密钥）、用量记录、收藏、MCP 授权和审计日志。manifest.json 列出每个文件的记录数和 SHA-256
+// @Tags         数据主体请求
+// @Produce      application/zip
+// @Param        user_id  path      string           true  "用户ID"
+// @Success      200      {file}    file             "zip 归档"
+// @Failure      404      {object}  errors.AppError  "用户在本空间没有数据"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /data-subjects/{user_id}/export [get]
+func (h *DataSubjectHandler) Export(c *gin.Context) {
+	ctx := c.Request.Context()
+	userID := c.Param("user_id")
+	w := &attachmentWriter{c: c, filename: "weknora-user-" + userID + ".zip"}
+	if err := h.service.Export(ctx, userID, w); err != nil {
+		if w.started {
+			// The archive is already on the wire; a truncated zip is the
+			// only signal left for the client.
+			logger.Errorf(ctx, "Data subject export of user %s failed midway: %v",
+				secutils.SanitizeForLog(userID), err)
+			c.Abort()
+			return
+		}
+		h.fail(c, err, "Failed to export user data")
+	}
+}
+
+// Erase godoc
+// @Summary      删除用户数据
+// @Description  彻底删除或匿名化空间中与该用户相关的数据：会话、消息及其对话历史索引、附件文件、流式缓存、记忆、IM 反馈与绑定、收藏和 MCP 授权被硬删除；API Key 被吊销并清除创建者；用量记录和文档版本中的用户被匿名化；delete_knowledge 为 true 时同时删除用户上传的文档。审计日志保留。返回的报告包含每类数据删除后的复核计数和摘要，摘要写入 privacy.subject_erased 审计日志的哈希链
+// @Tags         数据主体请求
+// @Accept       json
+// @Produce      json
+// @Param        user_id  path      string                           true   "用户ID"
+// @Param        request  body      types.DataSubjectErasureRequest  false  "删除选项"
+// @Success      200      {object}  types.DataSubjectErasureReport   "删除报告"
+// @Failure      404      {object}  errors.AppError                  "用户在本空间没有数据"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /data-subjects/{user_id}/erase [post]
+func (h *DataSubjectHandler) Erase(c *gin.Context) {
+	ctx := c.Request.Context()
+	var req types.DataSubjectErasureRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			logger.Warnf(ctx, "Invalid data subject erasure request: %v", err)
+			c.Error(errors.NewBadRequestError(err.Error()))
+			return
+		}
+	}
+	report, err := h.service.Erase(ctx, c.Param("user_id"), &req)
+	if err != nil {
+		h.fail(c, err, "Failed to erase user data")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": report})
+}
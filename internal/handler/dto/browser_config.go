@@ -0,0 +1,61 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// BrowserConfigResponse mirrors types.BrowserConfig for response bodies, with
+// the Token field removed by construction. Credential presence is exposed
+// via the /credentials subresource.
+type BrowserConfigResponse struct {
+	ID          string                     `json:"id"`
+	TenantID    uint64                     `json:"tenant_id"`
+	Name        string                     `json:"name"`
+	Description string                     `json:"description"`
+	Parameters  BrowserConfigParametersDTO `json:"parameters"`
+	Options     types.BrowserConfigOptions `json:"options"`
+	CreatedAt   time.Time                  `json:"created_at"`
+	UpdatedAt   time.Time                  `json:"updated_at"`
+	// Per-field "configured?" map. See MCPServiceResponse.Credentials.
+	Credentials map[string]CredentialFieldMetadata `json:"credentials,omitempty"`
+}
+
+// BrowserConfigParametersDTO holds every parameter field except Token.
+type BrowserConfigParametersDTO struct {
+	EndpointURL          string `json:"endpoint_url"`
+	AllowPrivateEndpoint bool   `json:"allow_private_endpoint"`
+}
+
+// NewBrowserConfigResponse converts a stored entity into its response shape.
+func NewBrowserConfigResponse(e *types.BrowserConfig) *BrowserConfigResponse {
+	if e == nil {
+		return nil
+	}
+	return &BrowserConfigResponse{
+		ID:          e.ID,
+		TenantID:    e.TenantID,
+		Name:        e.Name,
+		Description: e.Description,
+		Parameters: BrowserConfigParametersDTO{
+			EndpointURL:          e.Parameters.EndpointURL,
+			AllowPrivateEndpoint: e.Parameters.AllowPrivateEndpoint,
+		},
+		Options:   e.Options,
+		CreatedAt: e.CreatedAt,
+		UpdatedAt: e.UpdatedAt,
+		Credentials: map[string]CredentialFieldMetadata{
+			"token": {Configured: e.Parameters.Token != ""},
+		},
+	}
+}
+
+// NewBrowserConfigResponses converts a list of stored entities.
+func NewBrowserConfigResponses(es []*types.BrowserConfig) []*BrowserConfigResponse {
+	out := make([]*BrowserConfigResponse, 0, len(es))
+	for _, e := range es {
+		out = append(out, NewBrowserConfigResponse(e))
+	}
+	return out
+}
@@ -0,0 +1,71 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// SQLConnectionResponse mirrors types.SQLConnection for response bodies, with
+// the Password field removed by construction. Credential presence is exposed
+// via the /credentials subresource.
+type SQLConnectionResponse struct {
+	ID          string                     `json:"id"`
+	TenantID    uint64                     `json:"tenant_id"`
+	Name        string                     `json:"name"`
+	Description string                     `json:"description"`
+	Dialect     types.SQLDialect           `json:"dialect"`
+	Parameters  SQLConnectionParametersDTO `json:"parameters"`
+	Options     types.SQLConnectionOptions `json:"options"`
+	CreatedAt   time.Time                  `json:"created_at"`
+	UpdatedAt   time.Time                  `json:"updated_at"`
+	// Per-field "configured?" map. See MCPServiceResponse.Credentials.
+	Credentials map[string]CredentialFieldMetadata `json:"credentials,omitempty"`
+}
+
+// SQLConnectionParametersDTO holds every parameter field except Password.
+type SQLConnectionParametersDTO struct {
+	Host     string `json:"host,omitempty"`
+	Port     int    `json:"port,omitempty"`
+	Database string `json:"database,omitempty"`
+	Username string `json:"username,omitempty"`
+	SSLMode  string `json:"ssl_mode,omitempty"`
+	FilePath string `json:"file_path,omitempty"`
+}
+
+// NewSQLConnectionResponse converts a stored entity into its response shape.
+func NewSQLConnectionResponse(e *types.SQLConnection) *SQLConnectionResponse {
+	if e == nil {
+		return nil
+	}
+	return &SQLConnectionResponse{
+		ID:          e.ID,
+		TenantID:    e.TenantID,
+		Name:        e.Name,
+		Description: e.Description,
+		Dialect:     e.Dialect,
+		Parameters: SQLConnectionParametersDTO{
+			Host:     e.Parameters.Host,
+			Port:     e.Parameters.Port,
+			Database: e.Parameters.Database,
+			Username: e.Parameters.Username,
+			SSLMode:  e.Parameters.SSLMode,
+			FilePath: e.Parameters.FilePath,
+		},
+		Options:   e.Options,
+		CreatedAt: e.CreatedAt,
+		UpdatedAt: e.UpdatedAt,
+		Credentials: map[string]CredentialFieldMetadata{
+			"password": {Configured: e.Parameters.Password != ""},
+		},
+	}
+}
+
+// NewSQLConnectionResponses converts a list of stored entities.
+func NewSQLConnectionResponses(es []*types.SQLConnection) []*SQLConnectionResponse {
+	out := make([]*SQLConnectionResponse, 0, len(es))
+	for _, e := range es {
+		out = append(out, NewSQLConnectionResponse(e))
+	}
+	return out
+}
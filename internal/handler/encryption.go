@@ -0,0 +1,112 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+// EncryptionHandler exposes encryption at rest status and key rotation.
+// Tenant routes rely on the route layer for the role gate and the :id /
+// active-tenant cross-check; system routes sit behind SystemAdmin.
+type EncryptionHandler struct {
+	service interfaces.TenantDataKeyService
+}
+
+// NewEncryptionHandler creates a new encryption handler
+func NewEncryptionHandler(service interfaces.TenantDataKeyService) *EncryptionHandler {
+	return &EncryptionHandler{service: service}
+}
+
+func (h *EncryptionHandler) fail(c *gin.Context, err error, message string) {
+	if appErr, ok := errors.IsAppError(err); ok {
+		c.Error(appErr)
+		return
+	}
+	logger.ErrorWithFields(c.Request.Context(), err, nil)
+	c.Error(errors.NewInternalServerError(message).WithDetails(err.Error()))
+}
+
+// GetTenantEncryption godoc
+// @Summary      查询空间加密状态
+// @Description  返回部署是否启用静态加密、当前主密钥 ID，以及空间数据密钥的各个版本（不含密钥内容）
+// @Tags         静态加密
+// @Produce      json
+// @Param        id   path      string  true  "空间 ID"
+// @Success      200  {object}  types.TenantEncryptionStatus
+// @Security     Bearer
+// @Router       /tenants/{id}/encryption [get]
+func (h *EncryptionHandler) GetTenantEncryption(c *gin.Context) {
+	tenantID, ok := parseTenantIDFromPath(c)
+	if !ok {
+		return
+	}
+	status, err := h.service.Status(c.Request.Context(), tenantID)
+	if err != nil {
+		h.fail(c, err, "Failed to get encryption status")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": status})
+}
+
+// RotateTenantKey godoc
+// @Summary      轮换空间数据密钥
+// @Description  生成新的数据密钥版本并设为活动版本，之后写入的数据使用新版本加密；已有文件与分块保留原版本，不会重新上传或重新加密
+// @Tags         静态加密
+// @Produce      json
+// @Param        id   path      string  true  "空间 ID"
+// @Success      200  {object}  types.TenantDataKey
+// @Failure      400  {object}  errors.AppError  "未配置静态加密"
+// @Security     Bearer
+// @Router       /tenants/{id}/encryption/rotate [post]
+func (h *EncryptionHandler) RotateTenantKey(c *gin.Context) {
+	tenantID, ok := parseTenantIDFromPath(c)
+	if !ok {
+		return
+	}
+	key, err := h.service.RotateTenantKey(c.Request.Context(), tenantID)
+	if err != nil {
+		h.fail(c, err, "Failed to rotate data key")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": key})
+}
+
+// GetSystemEncryption godoc
+// @Summary      查询全局加密状态
+// @Description  返回当前主密钥 ID、数据密钥总数，以及仍由旧主密钥包裹、等待重新包裹的数据密钥数
+// @Tags         静态加密
+// @Produce      json
+// @Success      200  {object}  types.EncryptionSystemStatus
+// @Security     Bearer
+// @Router       /system/admin/encryption/status [get]
+func (h *EncryptionHandler) GetSystemEncryption(c *gin.Context) {
+	status, err := h.service.SystemStatus(c.Request.Context())
+	if err != nil {
+		h.fail(c, err, "Failed to get encryption status")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": status})
+}
+
+// RewrapDataKeys godoc
+// @Summary      用当前主密钥重新包裹数据密钥
+// @Description  主密钥轮换后调用：逐个解包仍由旧主密钥包裹的数据密钥，再用当前主密钥包裹。只改写密钥表，文件与分块内容不变
+// @Tags         静态加密
+// @Produce      json
+// @Success      200  {object}  types.RewrapResult
+// @Failure      400  {object}  errors.AppError  "未配置静态加密"
+// @Security     Bearer
+// @Router       /system/admin/encryption/rewrap [post]
+func (h *EncryptionHandler) RewrapDataKeys(c *gin.Context) {
+	result, err := h.service.RewrapDataKeys(c.Request.Context())
+	if err != nil {
+		h.fail(c, err, "Failed to re-wrap data keys")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": result})
+}
@@ -9,6 +9,7 @@ import (
 
 	"github.com/Tencent/WeKnora/internal/errors"
 	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/middleware"
 	"github.com/Tencent/WeKnora/internal/types"
 	"github.com/Tencent/WeKnora/internal/types/interfaces"
 	secutils "github.com/Tencent/WeKnora/internal/utils"
@@ -408,6 +409,7 @@ func (h *FAQHandler) ExportEntries(c *gin.Context) {
 			c.Error(err)
 			return
 		}
+		h.recordExport(c, kbID, format)
 		c.Header("Content-Type", "application/json; charset=utf-8")
 		c.Header("Content-Disposition", "attachment; filename=faq_export.json")
 		c.Data(http.StatusOK, "application/json; charset=utf-8", jsonData)
@@ -421,6 +423,8 @@ func (h *FAQHandler) ExportEntries(c *gin.Context) {
 		return
 	}
 
+	h.recordExport(c, kbID, "csv")
+
 	// Set response headers for CSV download
 	c.Header("Content-Type", "text/csv; charset=utf-8")
 	c.Header("Content-Disposition", "attachment; filename=faq_export.csv")
@@ -429,6 +433,17 @@ func (h *FAQHandler) ExportEntries(c *gin.Context) {
 	c.Data(http.StatusOK, "text/csv; charset=utf-8", append(bom, csvData...))
 }
 
+// recordExport audits an FAQ export against the knowledge base's owner
+// workspace, resolved by the KBAccessRead guard.
+func (h *FAQHandler) recordExport(c *gin.Context, kbID, format string) {
+	ownerTenantID, _ := types.TenantIDFromContext(c.Request.Context())
+	if access, ok := middleware.KBAccessFromContext(c); ok && access.KnowledgeBase != nil {
+		ownerTenantID = access.KnowledgeBase.TenantID
+	}
+	recordDataExport(c, ownerTenantID, kbID, types.AuditActionFAQExported,
+		"knowledge_base", kbID, map[string]any{"format": format})
+}
+
 // GetEntry godoc
 // @Summary      获取FAQ条目详情
 // @Description  根据ID获取单个FAQ条目的详情
@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Tencent/WeKnora/internal/application/repository"
+	"github.com/Tencent/WeKnora/internal/application/service"
+	apperrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+// GraphCommunityHandler exposes the community hierarchy of a knowledge
+// base's graph. KB access is resolved by the route guard, which also points
+// the request context at the owning tenant for shared knowledge bases.
+type GraphCommunityHandler struct {
+	communityService interfaces.GraphCommunityService
+}
+
+func NewGraphCommunityHandler(communityService interfaces.GraphCommunityService) *GraphCommunityHandler {
+	return &GraphCommunityHandler{communityService: communityService}
+}
+
+// ListCommunities godoc
+// @Summary      获取知识图谱社区列表
+// @Description  返回知识库图谱的社区层级及社区报告；level 为空时返回所有层级（0 为最粗粒度）
+// @Tags         知识图谱
+// @Produce      json
+// @Param        id     path      string  true   "知识库ID"
+// @Param        level  query     int     false  "社区层级"
+// @Success      200    {object}  map[string]interface{}  "社区列表"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge-bases/{id}/graph/communities [get]
+func (h *GraphCommunityHandler) ListCommunities(c *gin.Context) {
+	level := -1
+	if raw := c.Query("level"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 0 {
+			c.Error(apperrors.NewBadRequestError("level must be a non-negative integer"))
+			return
+		}
+		level = parsed
+	}
+	communities, err := h.communityService.ListCommunities(c.Request.Context(), c.Param("id"), level)
+	if err != nil {
+		h.fail(c, err, "Failed to list graph communities")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": communities})
+}
+
+// GetCommunity godoc
+// @Summary      获取知识图谱社区详情
+// @Description  返回单个社区的报告、成员实体与来源分块
+// @Tags         知识图谱
+// @Produce      json
+// @Param        id            path      string  true  "知识库ID"
+// @Param        community_id  path      string  true  "社区ID"
+// @Success      200           {object}  map[string]interface{}  "社区详情"
+// @Failure      404           {object}  errors.AppError         "社区不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge-bases/{id}/graph/communities/{community_id} [get]
+func (h *GraphCommunityHandler) GetCommunity(c *gin.Context) {
+	community, err := h.communityService.GetCommunity(c.Request.Context(), c.Param("id"), c.Param("community_id"))
+	if err != nil {
+		h.fail(c, err, "Failed to get graph community")
+		return
+	}
+	if community == nil {
+		c.Error(apperrors.NewNotFoundError("graph community not found"))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": community})
+}
+
+// RebuildCommunities godoc
+// @Summary      重建知识图谱社区
+// @Description  异步重新检测社区并生成社区报告；图谱写入后系统也会自动防抖重建
+// @Tags         知识图谱
+// @Produce      json
+// @Param        id  path      string  true  "知识库ID"
+// @Success      202 {object}  map[string]interface{}  "已加入队列"
+// @Failure      400 {object}  errors.AppError         "知识库未开启知识图谱"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge-bases/{id}/graph/communities/rebuild [post]
+func (h *GraphCommunityHandler) RebuildCommunities(c *gin.Context) {
+	if err := h.communityService.RequestRebuild(c.Request.Context(), c.Param("id")); err != nil {
+		h.fail(c, err, "Failed to queue graph community rebuild")
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"success": true})
+}
+
+func (h *GraphCommunityHandler) fail(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, service.ErrGraphCommunityDisabled):
+		c.Error(apperrors.NewBadRequestError(err.Error()))
+	case errors.Is(err, repository.ErrKnowledgeBaseNotFound):
+		c.Error(apperrors.NewNotFoundError("knowledge base not found"))
+	default:
+		logger.ErrorWithFields(c.Request.Context(), err, nil)
+		c.Error(apperrors.NewInternalServerError(message).WithDetails(err.Error()))
+	}
+}
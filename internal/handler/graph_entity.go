@@ -0,0 +1,223 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Tencent/WeKnora/internal/application/repository"
+	"github.com/Tencent/WeKnora/internal/application/service"
+	apperrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+// GraphEntityHandler exposes entity resolution for a knowledge base's graph:
+// listing entities, reviewing proposed merges, and merging or splitting by
+// hand. KB access is resolved by the route guard.
+type GraphEntityHandler struct {
+	entityService interfaces.GraphEntityService
+}
+
+func NewGraphEntityHandler(entityService interfaces.GraphEntityService) *GraphEntityHandler {
+	return &GraphEntityHandler{entityService: entityService}
+}
+
+// ListEntities godoc
+// @Summary      获取知识图谱实体列表
+// @Description  返回知识库图谱中的实体（跨文档按名称合并），按连接数排序；keyword 同时匹配名称与别名
+// @Tags         知识图谱
+// @Produce      json
+// @Param        id       path      string  true   "知识库ID"
+// @Param        keyword  query     string  false  "名称关键词"
+// @Param        limit    query     int     false  "返回数量上限，默认 100，最大 1000"
+// @Success      200      {object}  map[string]interface{}  "实体列表"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge-bases/{id}/graph/entities [get]
+func (h *GraphEntityHandler) ListEntities(c *gin.Context) {
+	limit := 0
+	if raw := c.Query("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 0 {
+			c.Error(apperrors.NewBadRequestError("limit must be a non-negative integer"))
+			return
+		}
+		limit = parsed
+	}
+	entities, err := h.entityService.ListEntities(c.Request.Context(), c.Param("id"), c.Query("keyword"), limit)
+	if err != nil {
+		h.fail(c, err, "Failed to list graph entities")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": entities})
+}
+
+// ResolveEntities godoc
+// @Summary      运行实体消歧
+// @Description  异步比对实体名称（别名规则 + 向量相似度），生成待审核的合并建议
+// @Tags         知识图谱
+// @Produce      json
+// @Param        id  path      string  true  "知识库ID"
+// @Success      202 {object}  map[string]interface{}  "已加入队列"
+// @Failure      400 {object}  errors.AppError         "知识库未开启知识图谱"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge-bases/{id}/graph/entities/resolve [post]
+func (h *GraphEntityHandler) ResolveEntities(c *gin.Context) {
+	if err := h.entityService.RequestResolution(c.Request.Context(), c.Param("id")); err != nil {
+		h.fail(c, err, "Failed to queue entity resolution")
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"success": true})
+}
+
+// ListMergeCandidates godoc
+// @Summary      获取实体合并建议
+// @Description  返回实体消歧生成的合并建议，按得分排序；status 可选 pending/accepted/rejected
+// @Tags         知识图谱
+// @Produce      json
+// @Param        id      path      string  true   "知识库ID"
+// @Param        status  query     string  false  "状态"
+// @Success      200     {object}  map[string]interface{}  "合并建议列表"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge-bases/{id}/graph/entities/merge-candidates [get]
+func (h *GraphEntityHandler) ListMergeCandidates(c *gin.Context) {
+	status := c.Query("status")
+	switch status {
+	case "", types.GraphMergeCandidatePending, types.GraphMergeCandidateAccepted, types.GraphMergeCandidateRejected:
+	default:
+		c.Error(apperrors.NewBadRequestError("status must be pending, accepted or rejected"))
+		return
+	}
+	candidates, err := h.entityService.ListCandidates(c.Request.Context(), c.Param("id"), status)
+	if err != nil {
+		h.fail(c, err, "Failed to list merge candidates")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": candidates})
+}
+
+// AcceptMergeCandidate godoc
+// @Summary      接受实体合并建议
+// @Description  合并两个实体：关系与来源分块迁移到保留的实体上，被合并的名称记为别名；可通过 canonical 指定保留哪一个
+// @Tags         知识图谱
+// @Accept       json
+// @Produce      json
+// @Param        id            path      string                                  true   "知识库ID"
+// @Param        candidate_id  path      string                                  true   "合并建议ID"
+// @Param        request       body      types.GraphMergeCandidateAcceptRequest  false  "保留的实体名称"
+// @Success      200           {object}  map[string]interface{}                  "已合并"
+// @Failure      404           {object}  errors.AppError                         "合并建议不存在"
+// @Failure      409           {object}  errors.AppError                         "合并建议已审核"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge-bases/{id}/graph/entities/merge-candidates/{candidate_id}/accept [post]
+func (h *GraphEntityHandler) AcceptMergeCandidate(c *gin.Context) {
+	var req types.GraphMergeCandidateAcceptRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.Error(apperrors.NewValidationError("Invalid request data").WithDetails(err.Error()))
+			return
+		}
+	}
+	candidate, err := h.entityService.AcceptCandidate(
+		c.Request.Context(), c.Param("id"), c.Param("candidate_id"), req.Canonical)
+	if err != nil {
+		h.fail(c, err, "Failed to accept merge candidate")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": candidate})
+}
+
+// RejectMergeCandidate godoc
+// @Summary      拒绝实体合并建议
+// @Description  拒绝后该实体对不会再被推荐合并
+// @Tags         知识图谱
+// @Produce      json
+// @Param        id            path      string  true  "知识库ID"
+// @Param        candidate_id  path      string  true  "合并建议ID"
+// @Success      200           {object}  map[string]interface{}  "已拒绝"
+// @Failure      404           {object}  errors.AppError         "合并建议不存在"
+// @Failure      409           {object}  errors.AppError         "合并建议已审核"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge-bases/{id}/graph/entities/merge-candidates/{candidate_id}/reject [post]
+func (h *GraphEntityHandler) RejectMergeCandidate(c *gin.Context) {
+	candidate, err := h.entityService.RejectCandidate(c.Request.Context(), c.Param("id"), c.Param("candidate_id"))
+	if err != nil {
+		h.fail(c, err, "Failed to reject merge candidate")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": candidate})
+}
+
+// MergeEntities godoc
+// @Summary      手动合并实体
+// @Description  将 names 中的实体合并为 canonical；关系与来源分块随之迁移，后续抽取到的别名也会归入 canonical
+// @Tags         知识图谱
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                         true  "知识库ID"
+// @Param        request  body      types.GraphEntityMergeRequest  true  "合并请求"
+// @Success      200      {object}  map[string]interface{}         "已合并"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge-bases/{id}/graph/entities/merge [post]
+func (h *GraphEntityHandler) MergeEntities(c *gin.Context) {
+	var req types.GraphEntityMergeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperrors.NewValidationError("Invalid request data").WithDetails(err.Error()))
+		return
+	}
+	if err := h.entityService.MergeEntities(c.Request.Context(), c.Param("id"), &req); err != nil {
+		h.fail(c, err, "Failed to merge graph entities")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// SplitEntity godoc
+// @Summary      手动拆分实体
+// @Description  将实体在指定分块中的引用拆分为新实体；与这些分块相关的关系随之迁移
+// @Tags         知识图谱
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                         true  "知识库ID"
+// @Param        request  body      types.GraphEntitySplitRequest  true  "拆分请求"
+// @Success      200      {object}  map[string]interface{}         "已拆分"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge-bases/{id}/graph/entities/split [post]
+func (h *GraphEntityHandler) SplitEntity(c *gin.Context) {
+	var req types.GraphEntitySplitRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperrors.NewValidationError("Invalid request data").WithDetails(err.Error()))
+		return
+	}
+	if err := h.entityService.SplitEntity(c.Request.Context(), c.Param("id"), &req); err != nil {
+		h.fail(c, err, "Failed to split graph entity")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+func (h *GraphEntityHandler) fail(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, service.ErrGraphCommunityDisabled), errors.Is(err, service.ErrGraphEntityInvalidRequest):
+		c.Error(apperrors.NewBadRequestError(err.Error()))
+	case errors.Is(err, service.ErrGraphMergeCandidateNotFound):
+		c.Error(apperrors.NewNotFoundError(err.Error()))
+	case errors.Is(err, service.ErrGraphMergeCandidateResolved):
+		c.Error(apperrors.NewConflictError(err.Error()))
+	case errors.Is(err, repository.ErrKnowledgeBaseNotFound):
+		c.Error(apperrors.NewNotFoundError("knowledge base not found"))
+	default:
+		logger.ErrorWithFields(c.Request.Context(), err, nil)
+		c.Error(apperrors.NewInternalServerError(message).WithDetails(err.Error()))
+	}
+}
@@ -18,6 +18,7 @@ import (
 var validIMPlatforms = map[string]bool{
 	"wecom": true, "feishu": true, "lark": true, "slack": true, "telegram": true, "dingtalk": true,
 	"mattermost": true, "wechat": true, "qqbot": true, "yunzhijia": true,
+	"discord": true, "teams": true, "matrix": true,
 }
 
 // invalidIMPlatformError is the 400 message listing the accepted platforms. It
@@ -61,14 +62,15 @@ func (h *IMHandler) CreateIMChannel(c *gin.Context) {
 	}
 
 	var req struct {
-		Platform        string     `json:"platform" binding:"required"`
-		Name            string     `json:"name"`
-		Mode            string     `json:"mode"`
-		OutputMode      string     `json:"output_mode"`
-		SessionMode     string     `json:"session_mode"`
-		KnowledgeBaseID string     `json:"knowledge_base_id"`
-		Credentials     types.JSON `json:"credentials"`
-		Enabled         *bool      `json:"enabled"`
+		Platform        string                 `json:"platform" binding:"required"`
+		Name            string                 `json:"name"`
+		Mode            string                 `json:"mode"`
+		OutputMode      string                 `json:"output_mode"`
+		SessionMode     string                 `json:"session_mode"`
+		KnowledgeBaseID string                 `json:"knowledge_base_id"`
+		Credentials     types.JSON             `json:"credentials"`
+		Enabled         *bool                  `json:"enabled"`
+		ThreadArchive   im.ThreadArchiveConfig `json:"thread_archive"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -79,6 +81,10 @@ func (h *IMHandler) CreateIMChannel(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": invalidIMPlatformError})
 		return
 	}
+	if err := h.imService.ValidateThreadArchiveConfig(c.Request.Context(), tenantID, &req.ThreadArchive); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
 	channel := &im.IMChannel{
 		TenantID:        tenantID,
@@ -90,6 +96,7 @@ func (h *IMHandler) CreateIMChannel(c *gin.Context) {
 		SessionMode:     req.SessionMode,
 		KnowledgeBaseID: req.KnowledgeBaseID,
 		Credentials:     req.Credentials,
+		ThreadArchive:   req.ThreadArchive,
 		Enabled:         true,
 	}
 	if req.Enabled != nil {
@@ -101,7 +108,7 @@ func (h *IMHandler) CreateIMChannel(c *gin.Context) {
 		channel.OutputMode = "full"
 	} else {
 		if channel.Mode == "" {
-			if channel.Platform == "mattermost" || channel.Platform == "yunzhijia" {
+			if channel.Platform == "mattermost" || channel.Platform == "yunzhijia" || channel.Platform == "teams" {
 				channel.Mode = "webhook"
 			} else {
 				channel.Mode = "websocket"
@@ -179,7 +186,7 @@ func (h *IMHandler) ListAllIMChannels(c *gin.Context) {
 // @Accept       json
 // @Produce      json
 // @Param        id       path      string                  true  "渠道 ID"
-// @Param        request  body      map[string]interface{}  true  "更新字段（name/mode/output_mode/knowledge_base_id/credentials/enabled）"
+// @Param        request  body      map[string]interface{}  true  "更新字段（name/mode/output_mode/knowledge_base_id/credentials/enabled/thread_archive）"
 // @Success      200      {object}  map[string]interface{}  "更新后的渠道"
 // @Failure      400      {object}  map[string]interface{}  "请求参数错误"
 // @Failure      404      {object}  map[string]interface{}  "渠道不存在"
@@ -206,14 +213,15 @@ func (h *IMHandler) UpdateIMChannel(c *gin.Context) {
 	}
 
 	var req struct {
-		Name            *string    `json:"name"`
-		Mode            *string    `json:"mode"`
-		OutputMode      *string    `json:"output_mode"`
-		SessionMode     *string    `json:"session_mode"`
-		KnowledgeBaseID *string    `json:"knowledge_base_id"`
-		Credentials     types.JSON `json:"credentials"`
-		Enabled         *bool      `json:"enabled"`
-		AgentID         *string    `json:"agent_id"`
+		Name            *string                 `json:"name"`
+		Mode            *string                 `json:"mode"`
+		OutputMode      *string                 `json:"output_mode"`
+		SessionMode     *string                 `json:"session_mode"`
+		KnowledgeBaseID *string                 `json:"knowledge_base_id"`
+		Credentials     types.JSON              `json:"credentials"`
+		Enabled         *bool                   `json:"enabled"`
+		AgentID         *string                 `json:"agent_id"`
+		ThreadArchive   *im.ThreadArchiveConfig `json:"thread_archive"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -241,6 +249,13 @@ func (h *IMHandler) UpdateIMChannel(c *gin.Context) {
 	if req.Enabled != nil {
 		channel.Enabled = *req.Enabled
 	}
+	if req.ThreadArchive != nil {
+		if err := h.imService.ValidateThreadArchiveConfig(c.Request.Context(), tenantID, req.ThreadArchive); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		channel.ThreadArchive = *req.ThreadArchive
+	}
 	if req.AgentID != nil {
 		newAgentID := strings.TrimSpace(*req.AgentID)
 		if newAgentID != "" && newAgentID != channel.AgentID {
@@ -348,6 +363,13 @@ func writeIMCallbackACK(c *gin.Context, platform string) {
 		return
 	}
 
+	if platform == "discord" {
+		// Deferred channel message: Discord shows "thinking..." until the
+		// answer edits the original interaction response.
+		c.JSON(http.StatusOK, gin.H{"type": 5})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{"success": true})
 }
 
@@ -421,6 +443,12 @@ func (h *IMHandler) IMCallback(c *gin.Context) {
 		} else {
 			logger.Infof(ctx, "[IM] Callback parsed no message to process platform=%s path_channel_id=%s", channel.Platform, channelID)
 		}
+		if channel.Platform == "discord" {
+			// Every interaction needs a response; a deferred one would never
+			// be filled, so answer with an ephemeral hint instead.
+			c.JSON(http.StatusOK, gin.H{"type": 4, "data": gin.H{"content": "请在命令中输入问题。", "flags": 64}})
+			return
+		}
 		writeIMCallbackACK(c, channel.Platform)
 		return
 	}
@@ -0,0 +1,167 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/Tencent/WeKnora/internal/im"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/gin-gonic/gin"
+)
+
+// loadIMChannelForRequest resolves the :id channel of the caller's tenant,
+// writing the error response itself when it cannot.
+func (h *IMHandler) loadIMChannelForRequest(c *gin.Context) (*im.IMChannel, bool) {
+	channelID := c.Param("id")
+	if channelID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "channel id is required"})
+		return nil, false
+	}
+	tenantID, ok := c.Request.Context().Value(types.TenantIDContextKey).(uint64)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return nil, false
+	}
+	channel, err := h.imService.GetChannelByIDAndTenant(channelID, tenantID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "channel not found"})
+		return nil, false
+	}
+	return channel, true
+}
+
+// ListIMUserBindings lists the IM user bindings of a channel.
+//
+// ListIMUserBindings godoc
+// @Summary      获取 IM 用户绑定
+// @Description  列出渠道中 IM 用户与租户成员的绑定关系，用于 IM 指令的权限判断
+// @Tags         IM 渠道
+// @Produce      json
+// @Param        id   path      string                  true  "渠道 ID"
+// @Success      200  {object}  map[string]interface{}  "绑定列表"
+// @Failure      404  {object}  map[string]interface{}  "渠道不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /im-channels/{id}/user-bindings [get]
+func (h *IMHandler) ListIMUserBindings(c *gin.Context) {
+	channel, ok := h.loadIMChannelForRequest(c)
+	if !ok {
+		return
+	}
+	bindings, err := h.imService.ListUserBindings(c.Request.Context(), channel)
+	if err != nil {
+		logger.Errorf(c.Request.Context(), "[IM] list user bindings: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list user bindings"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": bindings})
+}
+
+// CreateIMUserBinding binds an IM user of a channel to a tenant member.
+//
+// CreateIMUserBinding godoc
+// @Summary      绑定 IM 用户
+// @Description  将渠道中的 IM 用户绑定到租户成员，IM 指令按该成员的角色判断权限；重复绑定同一 IM 用户会替换原成员
+// @Tags         IM 渠道
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                  true  "渠道 ID"
+// @Param        request  body      object                  true  "im_user_id 与 user_id"
+// @Success      200      {object}  map[string]interface{}  "绑定信息"
+// @Failure      400      {object}  map[string]interface{}  "请求参数错误或用户不是租户成员"
+// @Failure      404      {object}  map[string]interface{}  "渠道不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /im-channels/{id}/user-bindings [post]
+func (h *IMHandler) CreateIMUserBinding(c *gin.Context) {
+	channel, ok := h.loadIMChannelForRequest(c)
+	if !ok {
+		return
+	}
+	var req struct {
+		IMUserID string `json:"im_user_id" binding:"required"`
+		UserID   string `json:"user_id"    binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	createdBy, _ := c.Request.Context().Value(types.UserIDContextKey).(string)
+
+	binding, err := h.imService.BindUser(c.Request.Context(), channel, req.IMUserID, req.UserID, createdBy)
+	if err != nil {
+		if errors.Is(err, im.ErrBindingNotMember) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		logger.Errorf(c.Request.Context(), "[IM] bind user: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to bind user"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": binding})
+}
+
+// DeleteIMUserBinding removes an IM user binding.
+//
+// DeleteIMUserBinding godoc
+// @Summary      解除 IM 用户绑定
+// @Description  删除渠道中的一条 IM 用户绑定，该 IM 用户此后按访客权限执行指令
+// @Tags         IM 渠道
+// @Produce      json
+// @Param        id          path      string                  true  "渠道 ID"
+// @Param        binding_id  path      string                  true  "绑定 ID"
+// @Success      200         {object}  map[string]interface{}  "success: true"
+// @Failure      404         {object}  map[string]interface{}  "渠道或绑定不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /im-channels/{id}/user-bindings/{binding_id} [delete]
+func (h *IMHandler) DeleteIMUserBinding(c *gin.Context) {
+	channel, ok := h.loadIMChannelForRequest(c)
+	if !ok {
+		return
+	}
+	if err := h.imService.DeleteUserBinding(c.Request.Context(), channel, c.Param("binding_id")); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "binding not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// ListIMFeedback lists the /good and /bad ratings given in a channel.
+//
+// ListIMFeedback godoc
+// @Summary      获取 IM 回答反馈
+// @Description  列出渠道中用户通过 /good、/bad 指令提交的回答评价，按时间倒序
+// @Tags         IM 渠道
+// @Produce      json
+// @Param        id      path      string                  true   "渠道 ID"
+// @Param        rating  query     string                  false  "评价筛选：good 或 bad"
+// @Param        limit   query     int                     false  "返回条数，默认 50，最大 200"
+// @Success      200     {object}  map[string]interface{}  "反馈列表"
+// @Failure      400     {object}  map[string]interface{}  "请求参数错误"
+// @Failure      404     {object}  map[string]interface{}  "渠道不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /im-channels/{id}/feedback [get]
+func (h *IMHandler) ListIMFeedback(c *gin.Context) {
+	channel, ok := h.loadIMChannelForRequest(c)
+	if !ok {
+		return
+	}
+	rating := c.Query("rating")
+	if rating != "" && rating != im.FeedbackRatingGood && rating != im.FeedbackRatingBad {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "rating must be 'good' or 'bad'"})
+		return
+	}
+	limit, _ := strconv.Atoi(c.Query("limit"))
+
+	feedback, err := h.imService.ListFeedback(c.Request.Context(), channel, rating, limit)
+	if err != nil {
+		logger.Errorf(c.Request.Context(), "[IM] list feedback: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list feedback"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": feedback})
+}
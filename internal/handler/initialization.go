@@ -26,6 +26,7 @@ import (
 	"github.com/Tencent/WeKnora/internal/models/embedding"
 	"github.com/Tencent/WeKnora/internal/models/provider"
 	"github.com/Tencent/WeKnora/internal/models/rerank"
+	"github.com/Tencent/WeKnora/internal/models/tts"
 	"github.com/Tencent/WeKnora/internal/models/utils/ollama"
 	"github.com/Tencent/WeKnora/internal/types"
 	"github.com/Tencent/WeKnora/internal/types/interfaces"
@@ -2124,6 +2125,79 @@ func (h *InitializationHandler) CheckASRModel(c *gin.Context) {
 	})
 }
 
+// CheckTTSModel godoc
+// @Summary      检查TTS模型
+// @Description  检查TTS（语音合成）模型连接是否正常，通过合成一小段文本测试 /v1/audio/speech 端点
+// @Tags         初始化
+// @Accept       json
+// @Produce      json
+// @Param        request  body      handler.ModelTestRequest  true  "TTS检查请求"
+// @Success      200      {object}  map[string]interface{}  "检查结果"
+// @Failure      400      {object}  errors.AppError         "请求参数错误"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /initialization/tts/check [post]
+func (h *InitializationHandler) CheckTTSModel(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	logger.Info(ctx, "Checking TTS model connection")
+
+	var req ModelTestRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error(ctx, "Failed to parse TTS model check request", err)
+		c.Error(errors.NewBadRequestError(err.Error()))
+		return
+	}
+	h.fillSecretsFromStoredModel(ctx, &req)
+
+	if req.ModelName == "" || req.BaseURL == "" {
+		logger.Error(ctx, "Model name and base URL are required for TTS check")
+		c.Error(errors.NewBadRequestError("模型名称和Base URL不能为空"))
+		return
+	}
+
+	if err := utils.ValidateURLForSSRF(req.BaseURL); err != nil {
+		logger.Warnf(ctx, "SSRF validation failed for TTS BaseURL: %v", err)
+		c.Error(errors.NewBadRequestError(utils.FormatSSRFError("Base URL", req.BaseURL, err)))
+		return
+	}
+
+	model := h.buildTestModel(&req, types.ModelTypeTTS, types.ModelSourceRemote)
+	ttsInstance, err := tts.NewTTS(tts.ConfigFromModel(model))
+	if err != nil {
+		logger.Errorf(ctx, "Failed to create TTS instance for check: %v", err)
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"data": gin.H{
+				"available": false,
+				"message":   fmt.Sprintf("创建TTS实例失败: %v", err),
+			},
+		})
+		return
+	}
+
+	available := true
+	message := "TTS连接成功"
+	audio, err := ttsInstance.Synthesize(ctx, "你好", tts.FormatMP3)
+	if err != nil {
+		available = false
+		errMsg := strings.ToLower(err.Error())
+		message = fmt.Sprintf("%s：%v", classifyConnectionError(errMsg), err)
+	} else {
+		message = fmt.Sprintf("TTS连接成功，合成音频 %d 字节", len(audio))
+	}
+
+	logger.Infof(ctx, "TTS model check completed, available: %v, message: %s", available, message)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"available": available,
+			"message":   message,
+		},
+	})
+}
+
 // 使用结构体解析表单数据
 type testMultimodalForm struct {
 	VLMModel         string `form:"vlm_model"`
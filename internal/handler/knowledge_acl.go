@@ -0,0 +1,200 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	secutils "github.com/Tencent/WeKnora/internal/utils"
+)
+
+// KnowledgeACLHandler serves document-level access lists and the user groups
+// they grant to.
+type KnowledgeACLHandler struct {
+	service interfaces.KnowledgeACLService
+}
+
+// NewKnowledgeACLHandler creates a new handler
+func NewKnowledgeACLHandler(service interfaces.KnowledgeACLService) *KnowledgeACLHandler {
+	return &KnowledgeACLHandler{service: service}
+}
+
+// fail reports a service error, passing application errors through as-is.
+func (h *KnowledgeACLHandler) fail(c *gin.Context, err error, message string) {
+	if appErr, ok := errors.IsAppError(err); ok {
+		c.Error(appErr)
+		return
+	}
+	logger.ErrorWithFields(c.Request.Context(), err, nil)
+	c.Error(errors.NewInternalServerError(message).WithDetails(err.Error()))
+}
+
+// GetKnowledgeACL godoc
+// @Summary      获取文档访问控制列表
+// @Description  返回文档的访问控制条目。restricted 为 false 时文档继承知识库权限
+// @Tags         文档权限
+// @Produce      json
+// @Param        id   path      string  true  "知识ID"
+// @Success      200  {object}  types.KnowledgeACL      "访问控制列表"
+// @Failure      404  {object}  errors.AppError         "知识不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge/{id}/acl [get]
+func (h *KnowledgeACLHandler) GetKnowledgeACL(c *gin.Context) {
+	acl, err := h.service.GetKnowledgeACL(c.Request.Context(), secutils.SanitizeForLog(c.Param("id")))
+	if err != nil {
+		h.fail(c, err, "Failed to get knowledge ACL")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": acl})
+}
+
+// SetKnowledgeACL godoc
+// @Summary      设置文档访问控制列表
+// @Description  替换文档的手动授权条目（用户、邮箱、用户组、组织）。空列表取消手动限制；数据源同步的条目保持不变，只随下次同步更新
+// @Tags         文档权限
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                    true  "知识ID"
+// @Param        request  body      types.KnowledgeACLUpdate  true  "授权主体列表"
+// @Success      200      {object}  types.KnowledgeACL        "更新后的访问控制列表"
+// @Failure      400      {object}  errors.AppError           "请求参数错误"
+// @Failure      404      {object}  errors.AppError           "知识不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge/{id}/acl [put]
+func (h *KnowledgeACLHandler) SetKnowledgeACL(c *gin.Context) {
+	ctx := c.Request.Context()
+	var req types.KnowledgeACLUpdate
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Warnf(ctx, "Invalid knowledge ACL request: %v", err)
+		c.Error(errors.NewBadRequestError(err.Error()))
+		return
+	}
+	acl, err := h.service.SetKnowledgeACL(ctx, secutils.SanitizeForLog(c.Param("id")), &req)
+	if err != nil {
+		h.fail(c, err, "Failed to update knowledge ACL")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": acl})
+}
+
+// ListUserGroups godoc
+// @Summary      获取用户组列表
+// @Description  列出当前空间的用户组及其成员数
+// @Tags         文档权限
+// @Produce      json
+// @Success      200  {object}  map[string]interface{}  "用户组列表"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /user-groups [get]
+func (h *KnowledgeACLHandler) ListUserGroups(c *gin.Context) {
+	groups, err := h.service.ListUserGroups(c.Request.Context())
+	if err != nil {
+		h.fail(c, err, "Failed to list user groups")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": groups})
+}
+
+// GetUserGroup godoc
+// @Summary      获取用户组详情
+// @Description  返回用户组及其成员
+// @Tags         文档权限
+// @Produce      json
+// @Param        id   path      string  true  "用户组ID"
+// @Success      200  {object}  types.UserGroupDetail  "用户组详情"
+// @Failure      404  {object}  errors.AppError        "用户组不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /user-groups/{id} [get]
+func (h *KnowledgeACLHandler) GetUserGroup(c *gin.Context) {
+	group, err := h.service.GetUserGroup(c.Request.Context(), secutils.SanitizeForLog(c.Param("id")))
+	if err != nil {
+		h.fail(c, err, "Failed to get user group")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": group})
+}
+
+// CreateUserGroup godoc
+// @Summary      创建用户组
+// @Description  创建用户组。external_id 填写上游系统中同一分组的标识（如飞书部门 ID），数据源同步的权限会按它匹配
+// @Tags         文档权限
+// @Accept       json
+// @Produce      json
+// @Param        request  body      types.UserGroupRequest  true  "用户组"
+// @Success      201      {object}  types.UserGroupDetail   "创建的用户组"
+// @Failure      400      {object}  errors.AppError         "请求参数错误"
+// @Failure      409      {object}  errors.AppError         "名称或 external_id 已存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /user-groups [post]
+func (h *KnowledgeACLHandler) CreateUserGroup(c *gin.Context) {
+	ctx := c.Request.Context()
+	var req types.UserGroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Warnf(ctx, "Invalid create user group request: %v", err)
+		c.Error(errors.NewBadRequestError(err.Error()))
+		return
+	}
+	group, err := h.service.CreateUserGroup(ctx, &req)
+	if err != nil {
+		h.fail(c, err, "Failed to create user group")
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"success": true, "data": group})
+}
+
+// UpdateUserGroup godoc
+// @Summary      更新用户组
+// @Description  更新用户组信息。传入 member_user_ids 时替换成员列表，省略则保持不变
+// @Tags         文档权限
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                  true  "用户组ID"
+// @Param        request  body      types.UserGroupRequest  true  "用户组"
+// @Success      200      {object}  types.UserGroupDetail   "更新后的用户组"
+// @Failure      400      {object}  errors.AppError         "请求参数错误"
+// @Failure      404      {object}  errors.AppError         "用户组不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /user-groups/{id} [put]
+func (h *KnowledgeACLHandler) UpdateUserGroup(c *gin.Context) {
+	ctx := c.Request.Context()
+	var req types.UserGroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Warnf(ctx, "Invalid update user group request: %v", err)
+		c.Error(errors.NewBadRequestError(err.Error()))
+		return
+	}
+	group, err := h.service.UpdateUserGroup(ctx, secutils.SanitizeForLog(c.Param("id")), &req)
+	if err != nil {
+		h.fail(c, err, "Failed to update user group")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": group})
+}
+
+// DeleteUserGroup godoc
+// @Summary      删除用户组
+// @Description  删除用户组及其成员关系。授权给该组的文档仍保持受限，不会因删除而公开
+// @Tags         文档权限
+// @Produce      json
+// @Param        id   path      string  true  "用户组ID"
+// @Success      200  {object}  map[string]interface{}  "删除成功"
+// @Failure      404  {object}  errors.AppError         "用户组不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /user-groups/{id} [delete]
+func (h *KnowledgeACLHandler) DeleteUserGroup(c *gin.Context) {
+	if err := h.service.DeleteUserGroup(c.Request.Context(), secutils.SanitizeForLog(c.Param("id"))); err != nil {
+		h.fail(c, err, "Failed to delete user group")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
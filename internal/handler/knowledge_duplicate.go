@@ -0,0 +1,51 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	apperrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+)
+
+// GetDuplicateReport godoc
+// @Summary      获取知识库重复内容报告
+// @Description  返回知识库内近似重复的文档簇与分块簇及相似度；threshold 为空时使用知识库去重配置的阈值
+// @Tags         知识库
+// @Produce      json
+// @Param        id         path      string  true   "知识库ID"
+// @Param        threshold  query     number  false  "文档相似度阈值（0.5-1）"
+// @Success      200        {object}  map[string]interface{}  "重复内容报告"
+// @Failure      404        {object}  errors.AppError         "知识库不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge-bases/{id}/duplicates [get]
+func (h *KnowledgeBaseHandler) GetDuplicateReport(c *gin.Context) {
+	ctx := c.Request.Context()
+	kb, _, _, _, err := h.validateAndGetKnowledgeBase(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	var threshold float64
+	if raw := c.Query("threshold"); raw != "" {
+		threshold, err = strconv.ParseFloat(raw, 64)
+		if err != nil || threshold <= 0 || threshold > 1 {
+			c.Error(apperrors.NewBadRequestError("threshold must be a number in (0, 1]"))
+			return
+		}
+	}
+	report, err := h.knowledgeService.GetKnowledgeDuplicateReport(ctx, kb, threshold)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, nil)
+		if appErr, ok := apperrors.IsAppError(err); ok {
+			c.Error(appErr)
+			return
+		}
+		c.Error(apperrors.NewInternalServerError(err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": report})
+}
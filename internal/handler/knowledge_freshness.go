@@ -0,0 +1,112 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	secutils "github.com/Tencent/WeKnora/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// UpdateKnowledgeFreshness godoc
+// @Summary      设置知识有效期与复核日期
+// @Description  手动设置 valid_from / valid_until / review_by（RFC 3339），未传字段保持不变；clear=true 清空全部日期并交还给标签规则。手动设置优先于连接器元数据与标签规则
+// @Tags         知识管理
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                          true  "知识ID"
+// @Param        request  body      types.KnowledgeFreshnessUpdate  true  "有效期设置"
+// @Success      200      {object}  map[string]interface{}          "更新后的知识"
+// @Failure      400      {object}  errors.AppError                 "日期无效"
+// @Failure      404      {object}  errors.AppError                 "知识不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge/{id}/freshness [put]
+func (h *KnowledgeHandler) UpdateKnowledgeFreshness(c *gin.Context) {
+	id := secutils.SanitizeForLog(c.Param("id"))
+	var update types.KnowledgeFreshnessUpdate
+	if err := c.ShouldBindJSON(&update); err != nil {
+		c.Error(errors.NewBadRequestError(err.Error()))
+		return
+	}
+	_, effCtx, err := h.resolveKnowledgeAndValidateKBAccess(c, id, types.OrgRoleEditor)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	knowledge, err := h.kgService.UpdateKnowledgeFreshness(effCtx, id, &update)
+	if err != nil {
+		h.failKnowledgeFreshness(c, err, id, "Failed to update knowledge freshness")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": knowledge})
+}
+
+// ReviewKnowledge godoc
+// @Summary      确认知识已复核
+// @Description  记录 reviewed_at 并按原复核周期（或知识库 review_interval_days）顺延 review_by
+// @Tags         知识管理
+// @Produce      json
+// @Param        id   path      string  true  "知识ID"
+// @Success      200  {object}  map[string]interface{}  "更新后的知识"
+// @Failure      404  {object}  errors.AppError         "知识不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge/{id}/review [post]
+func (h *KnowledgeHandler) ReviewKnowledge(c *gin.Context) {
+	id := secutils.SanitizeForLog(c.Param("id"))
+	_, effCtx, err := h.resolveKnowledgeAndValidateKBAccess(c, id, types.OrgRoleEditor)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	knowledge, err := h.kgService.ReviewKnowledge(effCtx, id)
+	if err != nil {
+		h.failKnowledgeFreshness(c, err, id, "Failed to review knowledge")
+		return
+	}
+	logger.Infof(c.Request.Context(), "Knowledge %s reviewed", id)
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": knowledge})
+}
+
+// GetStaleKnowledge godoc
+// @Summary      获取知识库过期与待复核内容
+// @Description  列出已过期、尚未生效、即将过期（expiring_soon_days 内）与复核逾期的知识，按紧急程度排序，最多 500 条
+// @Tags         知识库
+// @Produce      json
+// @Param        id   path      string  true  "知识库ID"
+// @Success      200  {object}  map[string]interface{}  "过期内容报告"
+// @Failure      404  {object}  errors.AppError         "知识库不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge-bases/{id}/stale [get]
+func (h *KnowledgeBaseHandler) GetStaleKnowledge(c *gin.Context) {
+	ctx := c.Request.Context()
+	kb, _, _, _, err := h.validateAndGetKnowledgeBase(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	report, err := h.knowledgeService.GetStaleKnowledgeReport(ctx, kb)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, nil)
+		if appErr, ok := errors.IsAppError(err); ok {
+			c.Error(appErr)
+			return
+		}
+		c.Error(errors.NewInternalServerError(err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": report})
+}
+
+func (h *KnowledgeHandler) failKnowledgeFreshness(c *gin.Context, err error, id, message string) {
+	if appErr, ok := errors.IsAppError(err); ok {
+		c.Error(appErr)
+		return
+	}
+	logger.ErrorWithFields(c.Request.Context(), err, map[string]interface{}{"knowledge_id": id})
+	c.Error(errors.NewInternalServerError(message).WithDetails(err.Error()))
+}
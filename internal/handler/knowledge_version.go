@@ -0,0 +1,161 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	secutils "github.com/Tencent/WeKnora/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// ListKnowledgeVersions godoc
+// @Summary      获取知识版本历史
+// @Description  返回知识的内容版本列表（新版本在前），不含 Markdown 与分块内容；current_version 为当前索引的版本
+// @Tags         知识管理
+// @Produce      json
+// @Param        id   path      string  true  "知识ID"
+// @Success      200  {object}  map[string]interface{}  "版本列表"
+// @Failure      404  {object}  errors.AppError         "知识不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge/{id}/versions [get]
+func (h *KnowledgeHandler) ListKnowledgeVersions(c *gin.Context) {
+	id := secutils.SanitizeForLog(c.Param("id"))
+	_, effCtx, err := h.resolveKnowledgeAndValidateKBAccess(c, id, types.OrgRoleViewer)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	versions, err := h.kgService.ListKnowledgeVersions(effCtx, id)
+	if err != nil {
+		h.failKnowledgeVersion(c, err, id, "Failed to list knowledge versions")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": versions})
+}
+
+// GetKnowledgeVersion godoc
+// @Summary      获取知识版本详情
+// @Description  返回指定版本的 Markdown 内容与分块
+// @Tags         知识管理
+// @Produce      json
+// @Param        id       path      string  true  "知识ID"
+// @Param        version  path      int     true  "版本号"
+// @Success      200      {object}  map[string]interface{}  "版本详情"
+// @Failure      404      {object}  errors.AppError         "版本不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge/{id}/versions/{version} [get]
+func (h *KnowledgeHandler) GetKnowledgeVersion(c *gin.Context) {
+	id := secutils.SanitizeForLog(c.Param("id"))
+	version, ok := knowledgeVersionParam(c, c.Param("version"), "version")
+	if !ok {
+		return
+	}
+	_, effCtx, err := h.resolveKnowledgeAndValidateKBAccess(c, id, types.OrgRoleViewer)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	v, err := h.kgService.GetKnowledgeVersion(effCtx, id, version)
+	if err != nil {
+		h.failKnowledgeVersion(c, err, id, "Failed to get knowledge version")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": v})
+}
+
+// DiffKnowledgeVersions godoc
+// @Summary      对比知识版本
+// @Description  返回两个版本 Markdown 内容的 unified diff；省略 to 时与最新版本对比
+// @Tags         知识管理
+// @Produce      json
+// @Param        id    path      string  true   "知识ID"
+// @Param        from  query     int     true   "起始版本号"
+// @Param        to    query     int     false  "目标版本号，默认最新版本"
+// @Success      200   {object}  map[string]interface{}  "版本差异"
+// @Failure      404   {object}  errors.AppError         "版本不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge/{id}/versions/diff [get]
+func (h *KnowledgeHandler) DiffKnowledgeVersions(c *gin.Context) {
+	id := secutils.SanitizeForLog(c.Param("id"))
+	from, ok := knowledgeVersionParam(c, c.Query("from"), "from")
+	if !ok {
+		return
+	}
+	to := 0
+	if raw := c.Query("to"); raw != "" {
+		if to, ok = knowledgeVersionParam(c, raw, "to"); !ok {
+			return
+		}
+	}
+	_, effCtx, err := h.resolveKnowledgeAndValidateKBAccess(c, id, types.OrgRoleViewer)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	diff, err := h.kgService.DiffKnowledgeVersions(effCtx, id, from, to)
+	if err != nil {
+		h.failKnowledgeVersion(c, err, id, "Failed to diff knowledge versions")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": diff})
+}
+
+// RestoreKnowledgeVersion godoc
+// @Summary      恢复知识版本
+// @Description  以指定版本的内容重新解析并索引知识，恢复本身会产生一个新版本
+// @Tags         知识管理
+// @Produce      json
+// @Param        id       path      string  true  "知识ID"
+// @Param        version  path      int     true  "版本号"
+// @Success      200      {object}  map[string]interface{}  "恢复任务已提交"
+// @Failure      400      {object}  errors.AppError         "该知识类型不支持恢复"
+// @Failure      404      {object}  errors.AppError         "版本不存在"
+// @Failure      409      {object}  errors.AppError         "知识正在处理中"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge/{id}/versions/{version}/restore [post]
+func (h *KnowledgeHandler) RestoreKnowledgeVersion(c *gin.Context) {
+	id := secutils.SanitizeForLog(c.Param("id"))
+	version, ok := knowledgeVersionParam(c, c.Param("version"), "version")
+	if !ok {
+		return
+	}
+	_, effCtx, err := h.resolveKnowledgeAndValidateKBAccess(c, id, types.OrgRoleEditor)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	knowledge, err := h.kgService.RestoreKnowledgeVersion(effCtx, id, version)
+	if err != nil {
+		h.failKnowledgeVersion(c, err, id, "Failed to restore knowledge version")
+		return
+	}
+	logger.Infof(c.Request.Context(), "Knowledge %s restore to version %d submitted", id, version)
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": knowledge})
+}
+
+// knowledgeVersionParam parses a positive version number, reporting a bad
+// request when it is not one.
+func knowledgeVersionParam(c *gin.Context, raw, name string) (int, bool) {
+	version, err := strconv.Atoi(raw)
+	if err != nil || version <= 0 {
+		c.Error(errors.NewBadRequestError(name + " must be a positive version number"))
+		return 0, false
+	}
+	return version, true
+}
+
+func (h *KnowledgeHandler) failKnowledgeVersion(c *gin.Context, err error, id, message string) {
+	if appErr, ok := errors.IsAppError(err); ok {
+		c.Error(appErr)
+		return
+	}
+	logger.ErrorWithFields(c.Request.Context(), err, map[string]interface{}{"knowledge_id": id})
+	c.Error(errors.NewInternalServerError(message).WithDetails(err.Error()))
+}
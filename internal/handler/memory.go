@@ -22,10 +22,14 @@ import (
 // of relying on a per-route ownership check.
 type MemoryHandler struct {
 	memoryService interfaces.MemoryService
+	// agentService validates the agent named by a shared agent space.
+	agentService interfaces.CustomAgentService
 }
 
-func NewMemoryHandler(memoryService interfaces.MemoryService) *MemoryHandler {
-	return &MemoryHandler{memoryService: memoryService}
+func NewMemoryHandler(
+	memoryService interfaces.MemoryService, agentService interfaces.CustomAgentService,
+) *MemoryHandler {
+	return &MemoryHandler{memoryService: memoryService, agentService: agentService}
 }
 
 // GetSettings godoc
@@ -454,6 +458,12 @@ func (h *MemoryHandler) fail(c *gin.Context, err error, message string) {
 		c.Error(apperrors.NewNotFoundError("memory not found"))
 	case errors.Is(err, memory.ErrMemoryDisabled):
 		c.Error(apperrors.NewBadRequestError("memory is disabled"))
+	case errors.Is(err, memory.ErrInvalidSharedSpace):
+		c.Error(apperrors.NewBadRequestError("scope must be workspace, or agent with an agent_id"))
+	case errors.Is(err, memory.ErrNotShareable):
+		c.Error(apperrors.NewBadRequestError("only active memories other than interests can be shared"))
+	case errors.Is(err, memory.ErrNotPendingReview):
+		c.Error(apperrors.NewConflictError("shared memory is not awaiting review"))
 	default:
 		logger.ErrorWithFields(c.Request.Context(), err, nil)
 		c.Error(apperrors.NewInternalServerError(message).WithDetails(err.Error()))
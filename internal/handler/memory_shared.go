@@ -0,0 +1,238 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	apperrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// sharedMemorySpace names a shared space in a request body or query. Scope is
+// "workspace" or "agent"; AgentID is only read for the latter.
+type sharedMemorySpace struct {
+	Scope   string `json:"scope"    form:"scope"`
+	AgentID string `json:"agent_id" form:"agent_id"`
+}
+
+// checkAgentSpace rejects an agent space whose agent this workspace cannot
+// see. Without it a mistyped id would create a space no conversation reads,
+// and the notes in it would look curated while doing nothing.
+func (h *MemoryHandler) checkAgentSpace(c *gin.Context, space sharedMemorySpace) bool {
+	if space.Scope != types.MemoryProvenanceAgent {
+		return true
+	}
+	if h.agentService == nil || space.AgentID == "" {
+		c.Error(apperrors.NewBadRequestError("agent_id is required for an agent space"))
+		return false
+	}
+	agent, err := h.agentService.GetAgentByID(c.Request.Context(), space.AgentID)
+	if err != nil || agent == nil {
+		c.Error(apperrors.NewBadRequestError("agent not found"))
+		return false
+	}
+	return true
+}
+
+type shareMemoryItemRequest struct {
+	sharedMemorySpace
+}
+
+// ShareItem godoc
+// @Summary      提交一条记忆到共享记忆
+// @Description  将自己的一条记忆复制到空间或智能体的共享记忆，等待管理员审核后生效
+// @Tags         长期记忆
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string  true  "记忆ID"
+// @Param        request  body      object  true  "目标共享空间（scope: workspace | agent，agent_id）"
+// @Success      200      {object}  map[string]interface{}  "待审核的共享记忆"
+// @Security     Bearer
+// @Router       /memory/items/{id}/share [post]
+func (h *MemoryHandler) ShareItem(c *gin.Context) {
+	ctx := c.Request.Context()
+	var req shareMemoryItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperrors.NewValidationError("Invalid request data").WithDetails(err.Error()))
+		return
+	}
+	if !h.checkAgentSpace(c, req.sharedMemorySpace) {
+		return
+	}
+	item, err := h.memoryService.ShareItem(ctx, c.Param("id"), req.Scope, req.AgentID)
+	if err != nil {
+		h.fail(c, err, "Failed to share memory")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": item})
+}
+
+// ListSharedItems godoc
+// @Summary      列出共享记忆
+// @Description  管理员查看空间和智能体的共享记忆，可按空间、状态过滤；pending 为待审核的提交
+// @Tags         长期记忆
+// @Produce      json
+// @Param        scope     query     string  false  "共享空间"  Enums(workspace, agent)
+// @Param        agent_id  query     string  false  "智能体ID（scope=agent 时必填）"
+// @Param        status    query     string  false  "状态过滤"  Enums(active, superseded, archived, pending)
+// @Param        limit     query     int     false  "每页条数"  default(50)
+// @Param        offset    query     int     false  "偏移量"
+// @Success      200       {object}  map[string]interface{}  "共享记忆列表"
+// @Security     Bearer
+// @Router       /memory/shared/items [get]
+func (h *MemoryHandler) ListSharedItems(c *gin.Context) {
+	ctx := c.Request.Context()
+	var space sharedMemorySpace
+	if err := c.ShouldBindQuery(&space); err != nil {
+		c.Error(apperrors.NewValidationError("Invalid query").WithDetails(err.Error()))
+		return
+	}
+	status := c.Query("status")
+	switch status {
+	case "", types.MemoryStatusActive, types.MemoryStatusSuperseded,
+		types.MemoryStatusArchived, types.MemoryStatusPending:
+	default:
+		c.Error(apperrors.NewBadRequestError("unsupported status"))
+		return
+	}
+	limit, offset := memoryListPaging(c)
+
+	items, total, err := h.memoryService.ListSharedItems(ctx, space.Scope, space.AgentID, status, limit, offset)
+	if err != nil {
+		h.fail(c, err, "Failed to list shared memories")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    items,
+		"total":   total,
+	})
+}
+
+type createSharedMemoryRequest struct {
+	sharedMemorySpace
+	Kind       string     `json:"kind"`
+	Content    string     `json:"content"    binding:"required"`
+	Importance int        `json:"importance"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+}
+
+// CreateSharedItem godoc
+// @Summary      新增共享记忆
+// @Description  管理员直接写入一条共享记忆，立即生效
+// @Tags         长期记忆
+// @Accept       json
+// @Produce      json
+// @Param        request  body      object  true  "共享记忆内容"
+// @Success      200      {object}  map[string]interface{}  "新增的共享记忆"
+// @Security     Bearer
+// @Router       /memory/shared/items [post]
+func (h *MemoryHandler) CreateSharedItem(c *gin.Context) {
+	ctx := c.Request.Context()
+	var req createSharedMemoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperrors.NewValidationError("Invalid request data").WithDetails(err.Error()))
+		return
+	}
+	if !h.checkAgentSpace(c, req.sharedMemorySpace) {
+		return
+	}
+	item, err := h.memoryService.CreateSharedItem(
+		ctx, req.Scope, req.AgentID, req.Kind, req.Content, req.Importance, req.ExpiresAt,
+	)
+	if err != nil {
+		h.fail(c, err, "Failed to create shared memory")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": item})
+}
+
+type updateSharedMemoryRequest struct {
+	Content    string `json:"content" binding:"required"`
+	Importance int    `json:"importance"`
+	// ExpiresAt replaces the expiry; null clears it.
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// UpdateSharedItem godoc
+// @Summary      修改共享记忆
+// @Description  修改共享记忆的内容、重要度与过期时间；expires_at 为空表示永不过期，设为过去的时间即刻过期
+// @Tags         长期记忆
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string  true  "记忆ID"
+// @Param        request  body      object  true  "共享记忆内容"
+// @Success      200      {object}  map[string]interface{}  "更新后的共享记忆"
+// @Security     Bearer
+// @Router       /memory/shared/items/{id} [put]
+func (h *MemoryHandler) UpdateSharedItem(c *gin.Context) {
+	ctx := c.Request.Context()
+	var req updateSharedMemoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperrors.NewValidationError("Invalid request data").WithDetails(err.Error()))
+		return
+	}
+	item, err := h.memoryService.UpdateSharedItem(ctx, c.Param("id"), req.Content, req.Importance, req.ExpiresAt)
+	if err != nil {
+		h.fail(c, err, "Failed to update shared memory")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": item})
+}
+
+// DeleteSharedItem godoc
+// @Summary      删除共享记忆
+// @Description  删除一条共享记忆，对所有成员生效
+// @Tags         长期记忆
+// @Produce      json
+// @Param        id   path      string  true  "记忆ID"
+// @Success      200  {object}  map[string]interface{}  "删除成功"
+// @Security     Bearer
+// @Router       /memory/shared/items/{id} [delete]
+func (h *MemoryHandler) DeleteSharedItem(c *gin.Context) {
+	ctx := c.Request.Context()
+	if err := h.memoryService.DeleteSharedItem(ctx, c.Param("id")); err != nil {
+		h.fail(c, err, "Failed to delete shared memory")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// ApproveSharedItem godoc
+// @Summary      通过共享记忆提交
+// @Description  审核通过成员提交的共享记忆，使其对该空间内的对话生效
+// @Tags         长期记忆
+// @Produce      json
+// @Param        id   path      string  true  "记忆ID"
+// @Success      200  {object}  map[string]interface{}  "已生效的共享记忆"
+// @Security     Bearer
+// @Router       /memory/shared/items/{id}/approve [post]
+func (h *MemoryHandler) ApproveSharedItem(c *gin.Context) {
+	ctx := c.Request.Context()
+	item, err := h.memoryService.ApproveSharedItem(ctx, c.Param("id"))
+	if err != nil {
+		h.fail(c, err, "Failed to approve shared memory")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": item})
+}
+
+// RejectSharedItem godoc
+// @Summary      驳回共享记忆提交
+// @Description  驳回成员提交的共享记忆；成员自己的记忆不受影响
+// @Tags         长期记忆
+// @Produce      json
+// @Param        id   path      string  true  "记忆ID"
+// @Success      200  {object}  map[string]interface{}  "驳回成功"
+// @Security     Bearer
+// @Router       /memory/shared/items/{id}/reject [post]
+func (h *MemoryHandler) RejectSharedItem(c *gin.Context) {
+	ctx := c.Request.Context()
+	if err := h.memoryService.RejectSharedItem(ctx, c.Param("id")); err != nil {
+		h.fail(c, err, "Failed to reject shared memory")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 
+	filesvc "github.com/Tencent/WeKnora/internal/application/service/file"
 	"github.com/Tencent/WeKnora/internal/errors"
 	"github.com/Tencent/WeKnora/internal/logger"
 	"github.com/Tencent/WeKnora/internal/storageurl"
@@ -28,6 +29,9 @@ type MessageHandler struct {
 	// handle mode is available.
 	FileService     interfaces.FileService
 	StorageResolver interfaces.StorageBackendResolver
+	// FileEncryption wraps file services built from tenant storage config.
+	// May be nil.
+	FileEncryption *filesvc.Encryption
 }
 
 // NewMessageHandler creates a new message handler instance with the required service
@@ -35,17 +39,20 @@ type MessageHandler struct {
 //   - messageService: Service that implements message business logic
 //   - fileService: Storage access used to sign public resource URLs
 //   - storageResolver: Resolves per-tenant storage backends for those URLs
+//   - fileEncryption: Encryption for file services built from tenant storage config
 //
 // Returns a pointer to a new MessageHandler
 func NewMessageHandler(
 	messageService interfaces.MessageService,
 	fileService interfaces.FileService,
 	storageResolver interfaces.StorageBackendResolver,
+	fileEncryption *filesvc.Encryption,
 ) *MessageHandler {
 	return &MessageHandler{
 		MessageService:  messageService,
 		FileService:     fileService,
 		StorageResolver: storageResolver,
+		FileEncryption:  fileEncryption,
 	}
 }
 
@@ -62,7 +69,7 @@ func (h *MessageHandler) resolveResourceRewriter(c *gin.Context) (*storageurl.Re
 		}
 		return nil, errors.NewBadRequestError(err.Error())
 	}
-	return storageurl.NewRequestRewriter(ctx, mode, h.FileService, h.StorageResolver), nil
+	return storageurl.NewRequestRewriter(ctx, mode, h.FileService, h.StorageResolver, h.FileEncryption), nil
 }
 
 // LoadMessages godoc
@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -14,6 +15,7 @@ import (
 	"github.com/Tencent/WeKnora/internal/logger"
 	"github.com/Tencent/WeKnora/internal/models/chat"
 	"github.com/Tencent/WeKnora/internal/models/provider"
+	"github.com/Tencent/WeKnora/internal/models/tts"
 	"github.com/Tencent/WeKnora/internal/types"
 	"github.com/Tencent/WeKnora/internal/types/interfaces"
 	secutils "github.com/Tencent/WeKnora/internal/utils"
@@ -516,6 +518,23 @@ func (h *ModelHandler) DebugModel(c *gin.Context) {
 			observations["segment_count"] = len(result.Segments)
 		}
 		writeModelDebugResult(c, started, requestPreview, result, callErr, observations)
+	case types.ModelTypeTTS:
+		if strings.TrimSpace(input) == "" {
+			c.Error(errors.NewBadRequestError("input cannot be empty"))
+			return
+		}
+		instance, callErr := h.service.GetTTSModel(ctx, id)
+		if callErr != nil {
+			writeModelDebugResult(c, started, requestPreview, nil, callErr, observations)
+			return
+		}
+		audio, callErr := instance.Synthesize(ctx, input, tts.FormatMP3)
+		observations["audio_bytes"] = len(audio)
+		var result any
+		if callErr == nil {
+			result = map[string]string{"audio": "data:audio/mpeg;base64," + base64.StdEncoding.EncodeToString(audio)}
+		}
+		writeModelDebugResult(c, started, requestPreview, result, callErr, observations)
 	default:
 		c.Error(errors.NewBadRequestError("unsupported model type"))
 	}
@@ -717,6 +736,8 @@ func modelTypeToFrontend(mt types.ModelType) string {
 		return "vllm"
 	case types.ModelTypeASR:
 		return "asr"
+	case types.ModelTypeTTS:
+		return "tts"
 	default:
 		return string(mt)
 	}
@@ -754,6 +775,8 @@ func (h *ModelHandler) ListModelProviders(c *gin.Context) {
 		backendModelType = types.ModelTypeVLLM
 	case "asr":
 		backendModelType = types.ModelTypeASR
+	case "tts":
+		backendModelType = types.ModelTypeTTS
 	default:
 		backendModelType = types.ModelType(modelType)
 	}
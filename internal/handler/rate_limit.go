@@ -0,0 +1,103 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+// RateLimitHandler manages the rate limit policies of a workspace.
+type RateLimitHandler struct {
+	service interfaces.RateLimitService
+}
+
+// NewRateLimitHandler creates a new handler
+func NewRateLimitHandler(service interfaces.RateLimitService) *RateLimitHandler {
+	return &RateLimitHandler{service: service}
+}
+
+// fail reports a service error, passing application errors through as-is.
+func (h *RateLimitHandler) fail(c *gin.Context, err error, message string) {
+	if appErr, ok := errors.IsAppError(err); ok {
+		c.Error(appErr)
+		return
+	}
+	logger.ErrorWithFields(c.Request.Context(), err, nil)
+	c.Error(errors.NewInternalServerError(message).WithDetails(err.Error()))
+}
+
+// ListPolicies godoc
+// @Summary      获取限流策略
+// @Description  返回当前空间的限流策略：空间级，以及 API Key、成员、嵌入渠道的默认策略与单独策略
+// @Tags         限流
+// @Produce      json
+// @Success      200  {array}   types.RateLimitPolicy  "策略列表"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /rate-limits [get]
+func (h *RateLimitHandler) ListPolicies(c *gin.Context) {
+	policies, err := h.service.ListPolicies(c.Request.Context())
+	if err != nil {
+		h.fail(c, err, "Failed to list rate limit policies")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": policies})
+}
+
+// SetPolicy godoc
+// @Summary      设置限流策略
+// @Description  创建或替换一个范围的限流策略。scope_type 为 tenant、api_key、user 或 embed_channel；scope_id 为 API Key ID、用户 ID 或渠道 ID，留空表示空间整体（tenant）或该类型的默认策略。各项限额为 0 表示不限制
+// @Tags         限流
+// @Accept       json
+// @Produce      json
+// @Param        request  body      types.RateLimitPolicyRequest  true  "策略配置"
+// @Success      200      {object}  types.RateLimitPolicy         "保存后的策略"
+// @Failure      400      {object}  errors.AppError               "请求参数错误"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /rate-limits [put]
+func (h *RateLimitHandler) SetPolicy(c *gin.Context) {
+	ctx := c.Request.Context()
+	var req types.RateLimitPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Warnf(ctx, "Invalid rate limit policy request: %v", err)
+		c.Error(errors.NewBadRequestError(err.Error()))
+		return
+	}
+	policy, err := h.service.SetPolicy(ctx, &req)
+	if err != nil {
+		h.fail(c, err, "Failed to set rate limit policy")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": policy})
+}
+
+// DeletePolicy godoc
+// @Summary      删除限流策略
+// @Description  删除一条限流策略，对应范围恢复为默认策略或不限制
+// @Tags         限流
+// @Produce      json
+// @Param        id   path      int  true  "策略ID"
+// @Success      200  {object}  map[string]interface{}  "删除成功"
+// @Failure      404  {object}  errors.AppError         "策略不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /rate-limits/{id} [delete]
+func (h *RateLimitHandler) DeletePolicy(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(errors.NewBadRequestError("invalid policy id"))
+		return
+	}
+	if err := h.service.DeletePolicy(c.Request.Context(), id); err != nil {
+		h.fail(c, err, "Failed to delete rate limit policy")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
@@ -144,7 +144,8 @@ func (h *SystemHandler) CheckSandboxConfig(c *gin.Context) {
 	}
 
 	result := &SandboxCheckResponse{OK: true, Provider: string(effective.Type)}
-	if effective.Type == sandbox.SandboxTypeDocker || effective.Type == sandbox.SandboxTypeLocal {
+	switch effective.Type {
+	case sandbox.SandboxTypeDocker, sandbox.SandboxTypeLocal, sandbox.SandboxTypeWasm:
 		h.runStatelessSandboxCheck(ctx, effective, req.Deep, result)
 		c.JSON(http.StatusOK, gin.H{"success": true, "data": result})
 		return
@@ -263,8 +264,12 @@ func (h *SystemHandler) runStatelessSandboxCheck(
 		result.add("sandbox_exec", false, err.Error(), 0)
 		return
 	}
-	path := filepath.Join(dir, "check.sh")
-	const script = "#!/bin/sh\nprintf 'weknora-ok\\n'\n"
+	name, script := "check.sh", "#!/bin/sh\nprintf 'weknora-ok\\n'\n"
+	// The wasm backend has no shell; probe with whichever interpreter it has.
+	if wasm, ok := active.(*sandbox.WasmSandbox); ok {
+		name, script, _ = wasm.ProbeScript()
+	}
+	path := filepath.Join(dir, name)
 	if err := os.WriteFile(path, []byte(script), 0o644); err != nil {
 		result.add("sandbox_exec", false, err.Error(), 0)
 		return
@@ -0,0 +1,470 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+// scimContentType is the media type of SCIM requests and responses.
+const scimContentType = "application/scim+json"
+
+// SCIMHandler serves the SCIM 2.0 endpoint of the workspace the caller is
+// authenticated into, normally through a workspace API key sent as a Bearer
+// token. Errors use the SCIM error schema rather than the AppError body.
+type SCIMHandler struct {
+	service interfaces.SCIMService
+}
+
+// NewSCIMHandler creates a new SCIM handler
+func NewSCIMHandler(service interfaces.SCIMService) *SCIMHandler {
+	return &SCIMHandler{service: service}
+}
+
+// scimJSON writes body as application/scim+json; gin keeps a content type
+// that is already set.
+func scimJSON(c *gin.Context, status int, body any) {
+	c.Header("Content-Type", scimContentType)
+	c.JSON(status, body)
+}
+
+func scimFail(c *gin.Context, status int, scimType, detail string) {
+	scimJSON(c, status, &types.SCIMError{
+		Schemas:  []string{types.SCIMSchemaError},
+		Status:   strconv.Itoa(status),
+		SCIMType: scimType,
+		Detail:   detail,
+	})
+}
+
+// scimServiceError reports a service error, keeping the status of
+// application errors and hiding the details of everything else.
+func scimServiceError(c *gin.Context, err error) {
+	if appErr, ok := errors.IsAppError(err); ok {
+		scimType := ""
+		switch appErr.HTTPCode {
+		case http.StatusConflict:
+			scimType = "uniqueness"
+		case http.StatusBadRequest:
+			scimType = "invalidValue"
+		}
+		scimFail(c, appErr.HTTPCode, scimType, appErr.Message)
+		return
+	}
+	logger.ErrorWithFields(c.Request.Context(), err, nil)
+	scimFail(c, http.StatusInternalServerError, "", "internal error")
+}
+
+// scimTenant returns the workspace of the request.
+func scimTenant(c *gin.Context) (uint64, bool) {
+	tenantID, ok := types.TenantIDFromContext(c.Request.Context())
+	if !ok || tenantID == 0 {
+		scimFail(c, http.StatusForbidden, "", "a workspace API key is required")
+		return 0, false
+	}
+	return tenantID, true
+}
+
+// scimListQuery parses filter, startIndex and count.
+func scimListQuery(c *gin.Context) (*types.SCIMListQuery, bool) {
+	q := &types.SCIMListQuery{}
+	if filter := strings.TrimSpace(c.Query("filter")); filter != "" {
+		attr, value, ok := types.ParseSCIMFilter(filter)
+		if !ok {
+			scimFail(c, http.StatusBadRequest, "invalidFilter", `only filters of the form <attribute> eq "<value>" are supported`)
+			return nil, false
+		}
+		q.FilterAttribute, q.FilterValue = attr, value
+	}
+	q.StartIndex, _ = strconv.Atoi(c.Query("startIndex"))
+	q.Count, _ = strconv.Atoi(c.Query("count"))
+	return q, true
+}
+
+func scimBind(c *gin.Context, body any) bool {
+	if err := c.ShouldBindJSON(body); err != nil {
+		scimFail(c, http.StatusBadRequest, "invalidSyntax", err.Error())
+		return false
+	}
+	return true
+}
+
+// ServiceProviderConfig godoc
+// @Summary      SCIM 服务能力
+// @Description  返回 SCIM 2.0 ServiceProviderConfig，声明支持 PATCH 与简单过滤，不支持批量、排序与修改密码
+// @Tags         SCIM
+// @Produce      json
+// @Success      200  {object}  map[string]interface{}
+// @Security     Bearer
+// @Router       /scim/v2/ServiceProviderConfig [get]
+func (h *SCIMHandler) ServiceProviderConfig(c *gin.Context) {
+	scimJSON(c, http.StatusOK, gin.H{
+		"schemas":        []string{types.SCIMSchemaServiceProviderConfig},
+		"patch":          gin.H{"supported": true},
+		"bulk":           gin.H{"supported": false, "maxOperations": 0, "maxPayloadSize": 0},
+		"filter":         gin.H{"supported": true, "maxResults": types.SCIMMaxPageSize},
+		"changePassword": gin.H{"supported": false},
+		"sort":           gin.H{"supported": false},
+		"etag":           gin.H{"supported": false},
+		"authenticationSchemes": []gin.H{{
+			"type":        "oauthbearertoken",
+			"name":        "API Key",
+			"description": "Workspace API key sent as Authorization: Bearer sk-...",
+			"primary":     true,
+		}},
+	})
+}
+
+// ResourceTypes godoc
+// @Summary      SCIM 资源类型
+// @Description  返回支持的 SCIM 资源类型：User 与 Group
+// @Tags         SCIM
+// @Produce      json
+// @Success      200  {object}  types.SCIMListResponse
+// @Security     Bearer
+// @Router       /scim/v2/ResourceTypes [get]
+func (h *SCIMHandler) ResourceTypes(c *gin.Context) {
+	resources := []any{
+		gin.H{
+			"schemas":  []string{types.SCIMSchemaResourceType},
+			"id":       "User",
+			"name":     "User",
+			"endpoint": "/Users",
+			"schema":   types.SCIMSchemaUser,
+		},
+		gin.H{
+			"schemas":  []string{types.SCIMSchemaResourceType},
+			"id":       "Group",
+			"name":     "Group",
+			"endpoint": "/Groups",
+			"schema":   types.SCIMSchemaGroup,
+			"schemaExtensions": []gin.H{{
+				"schema":   types.SCIMSchemaOrganizationGroup,
+				"required": false,
+			}},
+		},
+	}
+	scimJSON(c, http.StatusOK, &types.SCIMListResponse{
+		Schemas:      []string{types.SCIMSchemaListResponse},
+		TotalResults: len(resources),
+		StartIndex:   1,
+		ItemsPerPage: len(resources),
+		Resources:    resources,
+	})
+}
+
+// ListUsers godoc
+// @Summary      SCIM 查询用户
+// @Description  列出本空间通过 SCIM 开通的用户，支持 userName / externalId 的 eq 过滤
+// @Tags         SCIM
+// @Produce      json
+// @Param        filter      query     string  false  "过滤条件，如 userName eq \"alice@example.com\""
+// @Param        startIndex  query     int     false  "起始序号，从 1 开始"
+// @Param        count       query     int     false  "每页数量，最大 200"
+// @Success      200         {object}  types.SCIMListResponse
+// @Security     Bearer
+// @Router       /scim/v2/Users [get]
+func (h *SCIMHandler) ListUsers(c *gin.Context) {
+	tenantID, ok := scimTenant(c)
+	if !ok {
+		return
+	}
+	query, ok := scimListQuery(c)
+	if !ok {
+		return
+	}
+	resp, err := h.service.ListUsers(c.Request.Context(), tenantID, query)
+	if err != nil {
+		scimServiceError(c, err)
+		return
+	}
+	scimJSON(c, http.StatusOK, resp)
+}
+
+// GetUser godoc
+// @Summary      SCIM 获取用户
+// @Tags         SCIM
+// @Produce      json
+// @Param        id   path      string  true  "用户 ID"
+// @Success      200  {object}  types.SCIMUser
+// @Failure      404  {object}  types.SCIMError
+// @Security     Bearer
+// @Router       /scim/v2/Users/{id} [get]
+func (h *SCIMHandler) GetUser(c *gin.Context) {
+	tenantID, ok := scimTenant(c)
+	if !ok {
+		return
+	}
+	user, err := h.service.GetUser(c.Request.Context(), tenantID, c.Param("user_id"))
+	if err != nil {
+		scimServiceError(c, err)
+		return
+	}
+	scimJSON(c, http.StatusOK, user)
+}
+
+// CreateUser godoc
+// @Summary      SCIM 开通用户
+// @Description  按邮箱匹配已有账号或创建新账号，并加入本空间。roles 取 admin / contributor / viewer，默认 viewer
+// @Tags         SCIM
+// @Accept       json
+// @Produce      json
+// @Param        request  body      types.SCIMUser  true  "SCIM User"
+// @Success      201      {object}  types.SCIMUser
+// @Failure      409      {object}  types.SCIMError  "userName 已存在"
+// @Security     Bearer
+// @Router       /scim/v2/Users [post]
+func (h *SCIMHandler) CreateUser(c *gin.Context) {
+	tenantID, ok := scimTenant(c)
+	if !ok {
+		return
+	}
+	var req types.SCIMUser
+	if !scimBind(c, &req) {
+		return
+	}
+	user, err := h.service.CreateUser(c.Request.Context(), tenantID, &req)
+	if err != nil {
+		scimServiceError(c, err)
+		return
+	}
+	c.Header("Location", user.Meta.Location)
+	scimJSON(c, http.StatusCreated, user)
+}
+
+// ReplaceUser godoc
+// @Summary      SCIM 替换用户
+// @Description  全量更新用户。active 为 false 时停用：移出空间、吊销会话及该用户创建的 API Key
+// @Tags         SCIM
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string          true  "用户 ID"
+// @Param        request  body      types.SCIMUser  true  "SCIM User"
+// @Success      200      {object}  types.SCIMUser
+// @Security     Bearer
+// @Router       /scim/v2/Users/{id} [put]
+func (h *SCIMHandler) ReplaceUser(c *gin.Context) {
+	tenantID, ok := scimTenant(c)
+	if !ok {
+		return
+	}
+	var req types.SCIMUser
+	if !scimBind(c, &req) {
+		return
+	}
+	user, err := h.service.ReplaceUser(c.Request.Context(), tenantID, c.Param("user_id"), &req)
+	if err != nil {
+		scimServiceError(c, err)
+		return
+	}
+	scimJSON(c, http.StatusOK, user)
+}
+
+// PatchUser godoc
+// @Summary      SCIM 修改用户
+// @Description  支持修改 active、userName、externalId 与 roles。active 设为 false 时停用，设为 true 时恢复
+// @Tags         SCIM
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                  true  "用户 ID"
+// @Param        request  body      types.SCIMPatchRequest  true  "PatchOp"
+// @Success      200      {object}  types.SCIMUser
+// @Security     Bearer
+// @Router       /scim/v2/Users/{id} [patch]
+func (h *SCIMHandler) PatchUser(c *gin.Context) {
+	tenantID, ok := scimTenant(c)
+	if !ok {
+		return
+	}
+	var req types.SCIMPatchRequest
+	if !scimBind(c, &req) {
+		return
+	}
+	user, err := h.service.PatchUser(c.Request.Context(), tenantID, c.Param("user_id"), &req)
+	if err != nil {
+		scimServiceError(c, err)
+		return
+	}
+	scimJSON(c, http.StatusOK, user)
+}
+
+// DeleteUser godoc
+// @Summary      SCIM 删除用户
+// @Description  停用用户并删除 SCIM 关联。账号本身保留，因为它可能属于其他空间
+// @Tags         SCIM
+// @Param        id  path  string  true  "用户 ID"
+// @Success      204
+// @Failure      409  {object}  types.SCIMError  "不能停用最后一位 Owner"
+// @Security     Bearer
+// @Router       /scim/v2/Users/{id} [delete]
+func (h *SCIMHandler) DeleteUser(c *gin.Context) {
+	tenantID, ok := scimTenant(c)
+	if !ok {
+		return
+	}
+	if err := h.service.DeleteUser(c.Request.Context(), tenantID, c.Param("user_id")); err != nil {
+		scimServiceError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// ListGroups godoc
+// @Summary      SCIM 查询用户组
+// @Description  列出本空间的用户组，支持 displayName / externalId 的 eq 过滤
+// @Tags         SCIM
+// @Produce      json
+// @Param        filter      query     string  false  "过滤条件，如 displayName eq \"研发部\""
+// @Param        startIndex  query     int     false  "起始序号，从 1 开始"
+// @Param        count       query     int     false  "每页数量，最大 200"
+// @Success      200         {object}  types.SCIMListResponse
+// @Security     Bearer
+// @Router       /scim/v2/Groups [get]
+func (h *SCIMHandler) ListGroups(c *gin.Context) {
+	tenantID, ok := scimTenant(c)
+	if !ok {
+		return
+	}
+	query, ok := scimListQuery(c)
+	if !ok {
+		return
+	}
+	resp, err := h.service.ListGroups(c.Request.Context(), tenantID, query)
+	if err != nil {
+		scimServiceError(c, err)
+		return
+	}
+	scimJSON(c, http.StatusOK, resp)
+}
+
+// GetGroup godoc
+// @Summary      SCIM 获取用户组
+// @Tags         SCIM
+// @Produce      json
+// @Param        id   path      string  true  "用户组 ID"
+// @Success      200  {object}  types.SCIMGroup
+// @Failure      404  {object}  types.SCIMError
+// @Security     Bearer
+// @Router       /scim/v2/Groups/{id} [get]
+func (h *SCIMHandler) GetGroup(c *gin.Context) {
+	tenantID, ok := scimTenant(c)
+	if !ok {
+		return
+	}
+	group, err := h.service.GetGroup(c.Request.Context(), tenantID, c.Param("group_id"))
+	if err != nil {
+		scimServiceError(c, err)
+		return
+	}
+	scimJSON(c, http.StatusOK, group)
+}
+
+// CreateGroup godoc
+// @Summary      SCIM 创建用户组
+// @Description  创建空间用户组。成员必须是本空间通过 SCIM 开通的用户。携带组织扩展且 organization 为 true 时，同时创建由本空间拥有的协作组织，成员所属空间按组内角色加入
+// @Tags         SCIM
+// @Accept       json
+// @Produce      json
+// @Param        request  body      types.SCIMGroup  true  "SCIM Group"
+// @Success      201      {object}  types.SCIMGroup
+// @Failure      409      {object}  types.SCIMError  "组名或 externalId 已存在"
+// @Security     Bearer
+// @Router       /scim/v2/Groups [post]
+func (h *SCIMHandler) CreateGroup(c *gin.Context) {
+	tenantID, ok := scimTenant(c)
+	if !ok {
+		return
+	}
+	var req types.SCIMGroup
+	if !scimBind(c, &req) {
+		return
+	}
+	group, err := h.service.CreateGroup(c.Request.Context(), tenantID, &req)
+	if err != nil {
+		scimServiceError(c, err)
+		return
+	}
+	c.Header("Location", group.Meta.Location)
+	scimJSON(c, http.StatusCreated, group)
+}
+
+// ReplaceGroup godoc
+// @Summary      SCIM 替换用户组
+// @Tags         SCIM
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string           true  "用户组 ID"
+// @Param        request  body      types.SCIMGroup  true  "SCIM Group"
+// @Success      200      {object}  types.SCIMGroup
+// @Security     Bearer
+// @Router       /scim/v2/Groups/{id} [put]
+func (h *SCIMHandler) ReplaceGroup(c *gin.Context) {
+	tenantID, ok := scimTenant(c)
+	if !ok {
+		return
+	}
+	var req types.SCIMGroup
+	if !scimBind(c, &req) {
+		return
+	}
+	group, err := h.service.ReplaceGroup(c.Request.Context(), tenantID, c.Param("group_id"), &req)
+	if err != nil {
+		scimServiceError(c, err)
+		return
+	}
+	scimJSON(c, http.StatusOK, group)
+}
+
+// PatchGroup godoc
+// @Summary      SCIM 修改用户组
+// @Description  支持增删成员（含 members[value eq "id"] 路径）以及修改 displayName、externalId
+// @Tags         SCIM
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                  true  "用户组 ID"
+// @Param        request  body      types.SCIMPatchRequest  true  "PatchOp"
+// @Success      200      {object}  types.SCIMGroup
+// @Security     Bearer
+// @Router       /scim/v2/Groups/{id} [patch]
+func (h *SCIMHandler) PatchGroup(c *gin.Context) {
+	tenantID, ok := scimTenant(c)
+	if !ok {
+		return
+	}
+	var req types.SCIMPatchRequest
+	if !scimBind(c, &req) {
+		return
+	}
+	group, err := h.service.PatchGroup(c.Request.Context(), tenantID, c.Param("group_id"), &req)
+	if err != nil {
+		scimServiceError(c, err)
+		return
+	}
+	scimJSON(c, http.StatusOK, group)
+}
+
+// DeleteGroup godoc
+// @Summary      SCIM 删除用户组
+// @Tags         SCIM
+// @Param        id  path  string  true  "用户组 ID"
+// @Success      204
+// @Security     Bearer
+// @Router       /scim/v2/Groups/{id} [delete]
+func (h *SCIMHandler) DeleteGroup(c *gin.Context) {
+	tenantID, ok := scimTenant(c)
+	if !ok {
+		return
+	}
+	if err := h.service.DeleteGroup(c.Request.Context(), tenantID, c.Param("group_id")); err != nil {
+		scimServiceError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,98 @@
+package session
+
+import (
+	stderrors "errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	agenttools "github.com/Tencent/WeKnora/internal/agent/tools"
+	"github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	secutils "github.com/Tencent/WeKnora/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// Paging used to read a session's messages for the notebook export. The cap
+// keeps a pathological session from loading unbounded history.
+const (
+	analysisNotebookPageSize = 200
+	analysisNotebookMaxPages = 50
+)
+
+// DownloadAnalysisNotebook godoc
+// @Summary      导出会话的数据分析记录
+// @Description  将本会话中成功执行的数据分析与图表查询导出为可复现的 DuckDB SQL 脚本或 Jupyter Notebook
+// @Tags         会话
+// @Produce      octet-stream
+// @Param        session_id  path   string  true   "会话ID"
+// @Param        format      query  string  false  "导出格式：sql（默认）或 ipynb"
+// @Success      200  {file}    file
+// @Failure      400  {object}  errors.AppError
+// @Failure      404  {object}  errors.AppError
+// @Security     Bearer
+// @Router       /sessions/{session_id}/analysis/notebook [get]
+func (h *Handler) DownloadAnalysisNotebook(c *gin.Context) {
+	ctx := c.Request.Context()
+	sessionID := secutils.SanitizeForLog(paramSessionID(c))
+	if sessionID == "" {
+		c.Error(errors.NewBadRequestError(errors.ErrInvalidSessionID.Error()))
+		return
+	}
+	format := strings.ToLower(strings.TrimSpace(c.DefaultQuery("format", agenttools.AnalysisNotebookFormatSQL)))
+	if format != agenttools.AnalysisNotebookFormatSQL && format != agenttools.AnalysisNotebookFormatIPynb {
+		c.Error(errors.NewBadRequestError("format must be sql or ipynb"))
+		return
+	}
+
+	// Ownership check as for artifacts: unknown and foreign sessions both 404.
+	session, err := h.sessionService.GetSession(ctx, sessionID)
+	if err != nil {
+		if stderrors.Is(err, errors.ErrSessionNotFound) {
+			c.Error(errors.NewNotFoundError(err.Error()))
+			return
+		}
+		c.Error(errors.NewInternalServerError(err.Error()))
+		return
+	}
+
+	var messages []*types.Message
+	for page := 1; page <= analysisNotebookMaxPages; page++ {
+		batch, err := h.messageService.GetMessagesBySession(ctx, sessionID, page, analysisNotebookPageSize)
+		if err != nil {
+			logger.Errorf(ctx, "load messages for analysis notebook failed: session=%s err=%v", sessionID, err)
+			c.Error(errors.NewInternalServerError(err.Error()))
+			return
+		}
+		messages = append(messages, batch...)
+		if len(batch) < analysisNotebookPageSize {
+			break
+		}
+	}
+
+	data, found, err := agenttools.BuildAnalysisNotebook(session.Title, messages, format, time.Now())
+	if err != nil {
+		c.Error(errors.NewInternalServerError(err.Error()))
+		return
+	}
+	if !found {
+		c.Error(errors.NewNotFoundError("no data analysis in this session"))
+		return
+	}
+
+	fileName := "analysis_" + sessionID + "." + format
+	contentType := "application/sql; charset=utf-8"
+	if format == agenttools.AnalysisNotebookFormatIPynb {
+		contentType = "application/x-ipynb+json"
+	}
+	c.Header("Content-Type", contentType)
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Header("Content-Disposition", buildAttachmentHeader(fileName))
+	c.Header("Content-Length", strconv.Itoa(len(data)))
+	c.Status(http.StatusOK)
+	if _, err := c.Writer.Write(data); err != nil {
+		logger.Warnf(ctx, "analysis notebook stream failed: session=%s err=%v", sessionID, err)
+	}
+}
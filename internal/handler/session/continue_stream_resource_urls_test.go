@@ -58,6 +58,10 @@ func (s *stubStreamManager) GetEvents(
 	return s.events[fromOffset:], len(s.events), nil
 }
 
+func (s *stubStreamManager) DeleteSessionEvents(context.Context, string) (int, error) {
+	return 0, nil
+}
+
 // completedAnswerStream is one assistant turn whose answer embeds a knowledge-base
 // image, with the resource handle straddling two deltas as it does in production
 // when the model-context decoder flushes mid-reference.
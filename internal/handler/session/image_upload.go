@@ -159,7 +159,7 @@ func (h *Handler) resolveImageFileService(ctx context.Context, storageProvider s
 		return h.fileService
 	}
 
-	svc, resolvedProvider, err := filesvc.NewFileServiceFromStorageConfig(storageProvider, tenant.StorageEngineConfig, "")
+	svc, resolvedProvider, err := filesvc.NewFileServiceFromStorageConfig(storageProvider, tenant.StorageEngineConfig, "", h.fileEncryption)
 	if err != nil {
 		logger.Warnf(ctx, "[image-storage] failed to create %s file service: %v, fallback to default", storageProvider, err)
 		return h.fileService
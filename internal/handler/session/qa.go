@@ -640,6 +640,7 @@ func (h *Handler) setupSSEStream(reqCtx *qaRequestContext, generateTitle bool) *
 	// agent that cannot read the memory must not keep writing to it.
 	if reqCtx.customAgent != nil {
 		baseCtx = types.ApplyAgentMemoryPreference(baseCtx, reqCtx.customAgent.Config.MemoryEnabled)
+		baseCtx = types.WithMemoryAgent(baseCtx, reqCtx.customAgent.ID)
 	}
 
 	// Create EventBus and cancellable context
@@ -0,0 +1,424 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/handler/dto"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	secutils "github.com/Tencent/WeKnora/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// SQLConnectionHandler handles HTTP requests for external SQL connection CRUD
+type SQLConnectionHandler struct {
+	service interfaces.SQLConnectionService
+}
+
+// NewSQLConnectionHandler creates a new handler
+func NewSQLConnectionHandler(service interfaces.SQLConnectionService) *SQLConnectionHandler {
+	return &SQLConnectionHandler{service: service}
+}
+
+// --- request DTOs ---
+
+// CreateSQLConnectionRequest defines the request body for creating a connection
+type CreateSQLConnectionRequest struct {
+	Name        string                        `json:"name" binding:"required"`
+	Description string                        `json:"description"`
+	Dialect     types.SQLDialect              `json:"dialect" binding:"required"`
+	Parameters  types.SQLConnectionParameters `json:"parameters"`
+	Options     types.SQLConnectionOptions    `json:"options"`
+}
+
+// UpdateSQLConnectionRequest defines the request body for updating a connection.
+// The dialect is immutable and the password is managed through /credentials.
+type UpdateSQLConnectionRequest struct {
+	Name        string                        `json:"name"`
+	Description string                        `json:"description"`
+	Parameters  types.SQLConnectionParameters `json:"parameters"`
+	Options     types.SQLConnectionOptions    `json:"options"`
+}
+
+// TestSQLConnectionRequest defines the body for testing unsaved connection settings
+type TestSQLConnectionRequest struct {
+	Dialect    types.SQLDialect              `json:"dialect" binding:"required"`
+	Parameters types.SQLConnectionParameters `json:"parameters"`
+}
+
+// --- helpers ---
+
+// getTenantID extracts tenant ID from gin context (set by auth middleware).
+func (h *SQLConnectionHandler) getTenantID(c *gin.Context) uint64 {
+	return c.GetUint64(types.TenantIDContextKey.String())
+}
+
+// getOwnedConnection loads a connection and verifies it belongs to the given tenant.
+// Returns (nil, status, msg) on failure so callers can respond immediately.
+func (h *SQLConnectionHandler) getOwnedConnection(
+	ctx context.Context, tenantID uint64, id string,
+) (*types.SQLConnection, int, string) {
+	conn, err := h.service.GetConnection(ctx, tenantID, id)
+	if err != nil {
+		return nil, http.StatusInternalServerError, "failed to query sql connection"
+	}
+	if conn == nil {
+		return nil, http.StatusNotFound, "sql connection not found"
+	}
+	return conn, http.StatusOK, ""
+}
+
+// --- endpoints ---
+
+// CreateConnection creates a new SQL connection.
+//
+// CreateConnection godoc
+// @Summary      创建外部数据库连接
+// @Description  登记一个只读的外部 SQL 数据库（PostgreSQL / MySQL / SQLite / DuckDB），供智能体的 text-to-SQL 工具使用
+// @Tags         外部数据库
+// @Accept       json
+// @Produce      json
+// @Param        request  body      handler.CreateSQLConnectionRequest  true  "连接配置"
+// @Success      201      {object}  dto.SQLConnectionResponse           "创建的连接"
+// @Failure      400      {object}  map[string]interface{}              "请求参数错误"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /sql-connections [post]
+func (h *SQLConnectionHandler) CreateConnection(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	tenantID := h.getTenantID(c)
+	if tenantID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "error": "unauthorized: workspace context missing"})
+		return
+	}
+
+	var req CreateSQLConnectionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Warnf(ctx, "Invalid create sql connection request: %v", err)
+		c.Error(errors.NewBadRequestError(err.Error()))
+		return
+	}
+
+	conn := &types.SQLConnection{
+		TenantID:    tenantID,
+		Name:        secutils.SanitizeForLog(req.Name),
+		Description: secutils.SanitizeForLog(req.Description),
+		Dialect:     req.Dialect,
+		Parameters:  req.Parameters,
+		Options:     req.Options,
+	}
+
+	if err := h.service.CreateConnection(ctx, conn); err != nil {
+		logger.Warnf(ctx, "Failed to create sql connection: %v", err)
+		c.Error(errors.NewBadRequestError(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    dto.NewSQLConnectionResponse(conn),
+	})
+}
+
+// ListConnections lists all SQL connections for the current tenant.
+//
+// ListConnections godoc
+// @Summary      获取外部数据库连接列表
+// @Description  列出当前空间登记的外部数据库连接（不含密码）
+// @Tags         外部数据库
+// @Produce      json
+// @Success      200  {object}  map[string]interface{}  "连接列表"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /sql-connections [get]
+func (h *SQLConnectionHandler) ListConnections(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	tenantID := h.getTenantID(c)
+	if tenantID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "error": "unauthorized: workspace context missing"})
+		return
+	}
+
+	conns, err := h.service.ListConnections(ctx, tenantID)
+	if err != nil {
+		logger.Warnf(ctx, "Failed to list sql connections: %v", err)
+		c.Error(errors.NewInternalServerError(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    dto.NewSQLConnectionResponses(conns),
+	})
+}
+
+// GetConnection retrieves a single SQL connection by ID.
+//
+// GetConnection godoc
+// @Summary      获取外部数据库连接详情
+// @Description  根据 ID 获取连接配置（不含密码）
+// @Tags         外部数据库
+// @Produce      json
+// @Param        id   path      string                     true  "连接 ID"
+// @Success      200  {object}  dto.SQLConnectionResponse  "连接详情"
+// @Failure      404  {object}  map[string]interface{}     "连接不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /sql-connections/{id} [get]
+func (h *SQLConnectionHandler) GetConnection(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	tenantID := h.getTenantID(c)
+	if tenantID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "error": "unauthorized: workspace context missing"})
+		return
+	}
+
+	conn, status, msg := h.getOwnedConnection(ctx, tenantID, c.Param("id"))
+	if status != http.StatusOK {
+		c.JSON(status, gin.H{"success": false, "error": msg})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    dto.NewSQLConnectionResponse(conn),
+	})
+}
+
+// UpdateConnection updates a SQL connection.
+//
+// UpdateConnection godoc
+// @Summary      更新外部数据库连接
+// @Description  更新连接的名称、描述、连接参数与执行限制；数据库类型不可修改，密码请通过 /credentials 设置
+// @Tags         外部数据库
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                              true  "连接 ID"
+// @Param        request  body      handler.UpdateSQLConnectionRequest  true  "更新字段"
+// @Success      200      {object}  dto.SQLConnectionResponse           "更新后的连接"
+// @Failure      400      {object}  map[string]interface{}              "请求参数错误"
+// @Failure      404      {object}  map[string]interface{}              "连接不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /sql-connections/{id} [put]
+func (h *SQLConnectionHandler) UpdateConnection(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	tenantID := h.getTenantID(c)
+	if tenantID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "error": "unauthorized: workspace context missing"})
+		return
+	}
+
+	id := c.Param("id")
+	existing, status, msg := h.getOwnedConnection(ctx, tenantID, id)
+	if status != http.StatusOK {
+		c.JSON(status, gin.H{"success": false, "error": msg})
+		return
+	}
+
+	var req UpdateSQLConnectionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(errors.NewBadRequestError(err.Error()))
+		return
+	}
+
+	// The password NEVER flows through this endpoint — it lives behind the
+	// /credentials subresource.
+	if req.Parameters.Password != "" && req.Parameters.Password != existing.Parameters.Password {
+		logger.Warnf(ctx,
+			"password in PUT /sql-connections/%s body is ignored; use PUT /credentials instead",
+			secutils.SanitizeForLog(id))
+	}
+	params := req.Parameters
+	params.Password = existing.Parameters.Password
+
+	name := req.Name
+	if name == "" {
+		name = existing.Name
+	}
+	description := req.Description
+	if description == "" {
+		description = existing.Description
+	}
+
+	conn := &types.SQLConnection{
+		ID:          id,
+		TenantID:    tenantID,
+		Name:        secutils.SanitizeForLog(name),
+		Description: secutils.SanitizeForLog(description),
+		Dialect:     existing.Dialect, // Dialect is immutable after creation
+		Parameters:  params,
+		Options:     req.Options,
+		CreatedAt:   existing.CreatedAt,
+	}
+
+	if err := h.service.UpdateConnection(ctx, conn); err != nil {
+		logger.Warnf(ctx, "Failed to update sql connection %s: %v", secutils.SanitizeForLog(id), err)
+		c.Error(errors.NewBadRequestError(err.Error()))
+		return
+	}
+
+	updated, _ := h.service.GetConnection(ctx, tenantID, id)
+	if updated != nil {
+		c.JSON(http.StatusOK, gin.H{"success": true, "data": dto.NewSQLConnectionResponse(updated)})
+	} else {
+		c.JSON(http.StatusOK, gin.H{"success": true})
+	}
+}
+
+// DeleteConnection deletes a SQL connection.
+//
+// DeleteConnection godoc
+// @Summary      删除外部数据库连接
+// @Description  删除指定连接；引用它的智能体将不再注册 SQL 工具
+// @Tags         外部数据库
+// @Produce      json
+// @Param        id   path      string                  true  "连接 ID"
+// @Success      200  {object}  map[string]interface{}  "success: true"
+// @Failure      404  {object}  map[string]interface{}  "连接不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /sql-connections/{id} [delete]
+func (h *SQLConnectionHandler) DeleteConnection(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	tenantID := h.getTenantID(c)
+	if tenantID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "error": "unauthorized: workspace context missing"})
+		return
+	}
+
+	id := c.Param("id")
+	if _, status, msg := h.getOwnedConnection(ctx, tenantID, id); status != http.StatusOK {
+		c.JSON(status, gin.H{"success": false, "error": msg})
+		return
+	}
+
+	if err := h.service.DeleteConnection(ctx, tenantID, id); err != nil {
+		logger.Warnf(ctx, "Failed to delete sql connection %s: %v", secutils.SanitizeForLog(id), err)
+		c.Error(errors.NewInternalServerError(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// TestConnectionByID tests a saved connection with its stored credentials.
+//
+// TestConnectionByID godoc
+// @Summary      测试已保存的外部数据库连接
+// @Description  使用已保存的凭证以只读方式连接数据库并执行 SELECT 1
+// @Tags         外部数据库
+// @Produce      json
+// @Param        id   path      string                  true  "连接 ID"
+// @Success      200  {object}  map[string]interface{}  "测试结果"
+// @Failure      404  {object}  map[string]interface{}  "连接不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /sql-connections/{id}/test [post]
+func (h *SQLConnectionHandler) TestConnectionByID(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	tenantID := h.getTenantID(c)
+	if tenantID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "error": "unauthorized: workspace context missing"})
+		return
+	}
+
+	conn, status, msg := h.getOwnedConnection(ctx, tenantID, c.Param("id"))
+	if status != http.StatusOK {
+		c.JSON(status, gin.H{"success": false, "error": msg})
+		return
+	}
+
+	if err := h.service.TestConnection(ctx, conn); err != nil {
+		logger.Warnf(ctx, "SQL connection test failed: %v", err)
+		c.JSON(http.StatusOK, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// TestConnectionRaw tests unsaved connection settings (no persistence).
+//
+// TestConnectionRaw godoc
+// @Summary      使用原始配置测试外部数据库连接（不落库）
+// @Description  使用前端表单中尚未保存的配置测试连通性，用于"测试连接"按钮
+// @Tags         外部数据库
+// @Accept       json
+// @Produce      json
+// @Param        request  body      handler.TestSQLConnectionRequest  true  "{dialect, parameters}"
+// @Success      200      {object}  map[string]interface{}            "测试结果"
+// @Failure      400      {object}  map[string]interface{}            "请求参数错误"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /sql-connections/test [post]
+func (h *SQLConnectionHandler) TestConnectionRaw(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req TestSQLConnectionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(errors.NewBadRequestError(err.Error()))
+		return
+	}
+
+	conn := &types.SQLConnection{Name: "test", Dialect: req.Dialect, Parameters: req.Parameters}
+	if err := h.service.TestConnection(ctx, conn); err != nil {
+		logger.Warnf(ctx, "SQL connection test failed: %v", err)
+		c.JSON(http.StatusOK, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// GetSchema returns the introspected schema of a saved connection.
+//
+// GetSchema godoc
+// @Summary      获取外部数据库表结构
+// @Description  读取表与字段信息，并合并连接中配置的表/字段说明，便于在界面中补充字段描述
+// @Tags         外部数据库
+// @Produce      json
+// @Param        id      path      string                  true   "连接 ID"
+// @Param        tables  query     string                  false  "逗号分隔的表名，留空返回全部"
+// @Success      200     {object}  types.SQLSchema         "表结构"
+// @Failure      404     {object}  map[string]interface{}  "连接不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /sql-connections/{id}/schema [get]
+func (h *SQLConnectionHandler) GetSchema(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	tenantID := h.getTenantID(c)
+	if tenantID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "error": "unauthorized: workspace context missing"})
+		return
+	}
+
+	conn, status, msg := h.getOwnedConnection(ctx, tenantID, c.Param("id"))
+	if status != http.StatusOK {
+		c.JSON(status, gin.H{"success": false, "error": msg})
+		return
+	}
+
+	var tables []string
+	if raw := strings.TrimSpace(c.Query("tables")); raw != "" {
+		tables = strings.Split(raw, ",")
+	}
+	schema, err := h.service.IntrospectSchema(ctx, conn, tables)
+	if err != nil {
+		c.Error(errors.NewBadRequestError(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": schema})
+}
@@ -0,0 +1,98 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/handler/dto"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	secutils "github.com/Tencent/WeKnora/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// SQLConnectionCredentialsHandler handles the database password of SQL
+// connections via the dedicated /credentials subresource. The only
+// recognized field is "password"; file-based dialects need none.
+type SQLConnectionCredentialsHandler struct {
+	svc interfaces.SQLConnectionService
+}
+
+func NewSQLConnectionCredentialsHandler(svc interfaces.SQLConnectionService) *SQLConnectionCredentialsHandler {
+	return &SQLConnectionCredentialsHandler{svc: svc}
+}
+
+func (h *SQLConnectionCredentialsHandler) tenantID(c *gin.Context) uint64 {
+	return c.GetUint64(types.TenantIDContextKey.String())
+}
+
+type sqlConnectionCredentialsPutRequest struct {
+	Password *string `json:"password,omitempty"`
+}
+
+func (h *SQLConnectionCredentialsHandler) Put(c *gin.Context) {
+	ctx := c.Request.Context()
+	tenantID := h.tenantID(c)
+	if tenantID == 0 {
+		c.Error(errors.NewBadRequestError("Workspace ID cannot be empty"))
+		return
+	}
+	id := c.Param("id")
+	var req sqlConnectionCredentialsPutRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(errors.NewBadRequestError(err.Error()))
+		return
+	}
+	if req.Password == nil {
+		conn, err := h.svc.GetConnection(ctx, tenantID, id)
+		if err != nil || conn == nil {
+			c.Error(errors.NewNotFoundError("sql connection not found"))
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"success": true, "data": dto.CredentialsResponse{
+			Fields: map[string]dto.CredentialFieldMetadata{
+				"password": {Configured: conn.Parameters.Password != ""},
+			},
+		}})
+		return
+	}
+	updated, err := h.svc.UpdateConnectionCredentials(ctx, tenantID, id, req.Password)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{
+			"sql_connection_id": secutils.SanitizeForLog(id),
+		})
+		c.Error(errors.NewInternalServerError("failed to update credentials: " + err.Error()))
+		return
+	}
+	resp := dto.CredentialsResponse{
+		Fields: map[string]dto.CredentialFieldMetadata{
+			"password": {Configured: updated.Parameters.Password != ""},
+		},
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": resp})
+}
+
+func (h *SQLConnectionCredentialsHandler) DeleteField(c *gin.Context) {
+	ctx := c.Request.Context()
+	tenantID := h.tenantID(c)
+	if tenantID == 0 {
+		c.Error(errors.NewBadRequestError("Workspace ID cannot be empty"))
+		return
+	}
+	id := c.Param("id")
+	field := c.Param("field")
+	if field != "password" {
+		c.Error(errors.NewBadRequestError("unknown credential field: " + secutils.SanitizeForLog(field)))
+		return
+	}
+	if err := h.svc.ClearConnectionCredential(ctx, tenantID, id, field); err != nil {
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{
+			"sql_connection_id": secutils.SanitizeForLog(id),
+			"field":             field,
+		})
+		c.Error(errors.NewInternalServerError("failed to clear credential: " + err.Error()))
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
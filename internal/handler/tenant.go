@@ -160,6 +160,7 @@ type tenantAPIKeyResponse struct {
 	FullAccess       bool                  `json:"full_access"`
 	KnowledgeBaseIDs types.StringArray     `json:"knowledge_base_ids"`
 	Capabilities     types.StringArray     `json:"capabilities"`
+	CustomRoleID     *uint64               `json:"custom_role_id,omitempty"`
 	LastUsedAt       *time.Time            `json:"last_used_at,omitempty"`
 	ExpiresAt        *time.Time            `json:"expires_at,omitempty"`
 	CreatedAt        time.Time             `json:"created_at"`
@@ -793,6 +794,7 @@ func tenantAPIKeyForResponse(key *types.TenantAPIKey) tenantAPIKeyResponse {
 		FullAccess:       key.FullAccess,
 		KnowledgeBaseIDs: key.KnowledgeBaseIDs,
 		Capabilities:     types.NormalizeAPIKeyCapabilities(key.Capabilities),
+		CustomRoleID:     key.CustomRoleID,
 		LastUsedAt:       key.LastUsedAt,
 		ExpiresAt:        key.ExpiresAt,
 		CreatedAt:        key.CreatedAt,
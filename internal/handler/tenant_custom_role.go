@@ -0,0 +1,268 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+// TenantCustomRoleHandler exposes /tenants/:id/roles and the custom role
+// assignment endpoints. Like TenantMemberHandler it relies on the route
+// layer for the role gate and the :id / active-tenant cross-check.
+type TenantCustomRoleHandler struct {
+	service interfaces.TenantCustomRoleService
+	// catalog lists the permissions with the routes that declare them. It
+	// is bound by the router once every route is registered.
+	catalog func() []types.CustomRolePermissionEntry
+}
+
+// NewTenantCustomRoleHandler creates a new handler
+func NewTenantCustomRoleHandler(service interfaces.TenantCustomRoleService) *TenantCustomRoleHandler {
+	return &TenantCustomRoleHandler{service: service}
+}
+
+// BindPermissionCatalog sets the source of ListPermissions.
+func (h *TenantCustomRoleHandler) BindPermissionCatalog(catalog func() []types.CustomRolePermissionEntry) {
+	h.catalog = catalog
+}
+
+// fail reports a service error, passing application errors through as-is.
+func (h *TenantCustomRoleHandler) fail(c *gin.Context, err error, message string) {
+	if appErr, ok := errors.IsAppError(err); ok {
+		c.Error(appErr)
+		return
+	}
+	logger.ErrorWithFields(c.Request.Context(), err, nil)
+	c.Error(errors.NewInternalServerError(message).WithDetails(err.Error()))
+}
+
+func parseRoleIDParam(c *gin.Context) (uint64, bool) {
+	id, err := strconv.ParseUint(strings.TrimSpace(c.Param("role_id")), 10, 64)
+	if err != nil || id == 0 {
+		c.Error(errors.NewValidationError("role_id must be a positive integer"))
+		return 0, false
+	}
+	return id, true
+}
+
+// ListPermissions godoc
+// @Summary      列出自定义角色可用权限
+// @Description  返回可授予自定义角色的权限，以及每个权限开放的接口
+// @Tags         自定义角色
+// @Produce      json
+// @Param        id   path      string  true  "空间 ID"
+// @Success      200  {array}   types.CustomRolePermissionEntry
+// @Security     Bearer
+// @Router       /tenants/{id}/roles/permissions [get]
+func (h *TenantCustomRoleHandler) ListPermissions(c *gin.Context) {
+	entries := []types.CustomRolePermissionEntry{}
+	if h.catalog != nil {
+		entries = h.catalog()
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": entries})
+}
+
+// ListRoles godoc
+// @Summary      列出自定义角色
+// @Description  返回空间内的自定义角色，含持有该角色的成员数与 API Key 数
+// @Tags         自定义角色
+// @Produce      json
+// @Param        id   path      string  true  "空间 ID"
+// @Success      200  {array}   types.TenantCustomRoleDetail
+// @Security     Bearer
+// @Router       /tenants/{id}/roles [get]
+func (h *TenantCustomRoleHandler) ListRoles(c *gin.Context) {
+	tenantID, ok := parseTenantIDFromPath(c)
+	if !ok {
+		return
+	}
+	roles, err := h.service.ListRoles(c.Request.Context(), tenantID)
+	if err != nil {
+		h.fail(c, err, "Failed to list custom roles")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": roles})
+}
+
+// CreateRole godoc
+// @Summary      创建自定义角色
+// @Description  基于 admin / contributor / viewer 之一创建自定义角色，并限定可用权限
+// @Tags         自定义角色
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                         true  "空间 ID"
+// @Param        request  body      types.TenantCustomRoleRequest  true  "角色定义"
+// @Success      200      {object}  types.TenantCustomRole
+// @Failure      400      {object}  errors.AppError  "参数错误或未知权限"
+// @Failure      409      {object}  errors.AppError  "角色名已存在"
+// @Security     Bearer
+// @Router       /tenants/{id}/roles [post]
+func (h *TenantCustomRoleHandler) CreateRole(c *gin.Context) {
+	tenantID, ok := parseTenantIDFromPath(c)
+	if !ok {
+		return
+	}
+	var req types.TenantCustomRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(errors.NewValidationError("invalid request body").WithDetails(err.Error()))
+		return
+	}
+	role, err := h.service.CreateRole(c.Request.Context(), tenantID, &req)
+	if err != nil {
+		h.fail(c, err, "Failed to create custom role")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": role})
+}
+
+// UpdateRole godoc
+// @Summary      更新自定义角色
+// @Description  更新角色名称、基础角色与权限。持有该角色的成员立即按新定义生效
+// @Tags         自定义角色
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                         true  "空间 ID"
+// @Param        role_id  path      int                            true  "角色 ID"
+// @Param        request  body      types.TenantCustomRoleRequest  true  "角色定义"
+// @Success      200      {object}  types.TenantCustomRole
+// @Failure      404      {object}  errors.AppError  "角色不存在"
+// @Security     Bearer
+// @Router       /tenants/{id}/roles/{role_id} [put]
+func (h *TenantCustomRoleHandler) UpdateRole(c *gin.Context) {
+	tenantID, ok := parseTenantIDFromPath(c)
+	if !ok {
+		return
+	}
+	roleID, ok := parseRoleIDParam(c)
+	if !ok {
+		return
+	}
+	var req types.TenantCustomRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(errors.NewValidationError("invalid request body").WithDetails(err.Error()))
+		return
+	}
+	role, err := h.service.UpdateRole(c.Request.Context(), tenantID, roleID, &req)
+	if err != nil {
+		h.fail(c, err, "Failed to update custom role")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": role})
+}
+
+// DeleteRole godoc
+// @Summary      删除自定义角色
+// @Description  删除未被任何成员或 API Key 使用的自定义角色
+// @Tags         自定义角色
+// @Produce      json
+// @Param        id       path      string  true  "空间 ID"
+// @Param        role_id  path      int     true  "角色 ID"
+// @Success      200      {object}  map[string]interface{}
+// @Failure      409      {object}  errors.AppError  "角色仍在使用"
+// @Security     Bearer
+// @Router       /tenants/{id}/roles/{role_id} [delete]
+func (h *TenantCustomRoleHandler) DeleteRole(c *gin.Context) {
+	tenantID, ok := parseTenantIDFromPath(c)
+	if !ok {
+		return
+	}
+	roleID, ok := parseRoleIDParam(c)
+	if !ok {
+		return
+	}
+	if err := h.service.DeleteRole(c.Request.Context(), tenantID, roleID); err != nil {
+		h.fail(c, err, "Failed to delete custom role")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// assignCustomRoleRequest is the body of the custom-role assignment
+// endpoints. RoleID may be null only for API keys, where it unbinds the key.
+type assignCustomRoleRequest struct {
+	RoleID *uint64 `json:"role_id"`
+}
+
+// AssignMemberRole godoc
+// @Summary      为成员分配自定义角色
+// @Description  成员的角色变为自定义角色的基础角色，并受其权限限制。改回内置角色请使用修改成员角色接口
+// @Tags         自定义角色
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                   true  "空间 ID"
+// @Param        user_id  path      string                   true  "用户 ID"
+// @Param        request  body      assignCustomRoleRequest  true  "角色 ID"
+// @Success      200      {object}  map[string]interface{}
+// @Failure      409      {object}  errors.AppError  "不能降级最后一位 Owner"
+// @Security     Bearer
+// @Router       /tenants/{id}/members/{user_id}/custom-role [put]
+func (h *TenantCustomRoleHandler) AssignMemberRole(c *gin.Context) {
+	tenantID, ok := parseTenantIDFromPath(c)
+	if !ok {
+		return
+	}
+	userID := strings.TrimSpace(c.Param("user_id"))
+	if userID == "" {
+		c.Error(errors.NewValidationError("user_id is required"))
+		return
+	}
+	var req assignCustomRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(errors.NewValidationError("invalid request body").WithDetails(err.Error()))
+		return
+	}
+	if req.RoleID == nil || *req.RoleID == 0 {
+		c.Error(errors.NewValidationError("role_id is required"))
+		return
+	}
+	if err := h.service.AssignToMember(c.Request.Context(), tenantID, userID, *req.RoleID); err != nil {
+		h.fail(c, err, "Failed to assign custom role")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// AssignAPIKeyRole godoc
+// @Summary      为 API Key 绑定自定义角色
+// @Description  绑定后 API Key 的能力由角色权限决定（full_access 与 capabilities 不再生效），知识库范围仍然适用。role_id 为 null 时解绑
+// @Tags         自定义角色
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                   true  "空间 ID"
+// @Param        key_id   path      int                      true  "API Key ID"
+// @Param        request  body      assignCustomRoleRequest  true  "角色 ID"
+// @Success      200      {object}  map[string]interface{}
+// @Security     Bearer
+// @Router       /tenants/{id}/api-keys/{key_id}/custom-role [put]
+func (h *TenantCustomRoleHandler) AssignAPIKeyRole(c *gin.Context) {
+	tenantID, ok := parseTenantIDFromPath(c)
+	if !ok {
+		return
+	}
+	keyID, err := strconv.ParseUint(strings.TrimSpace(c.Param("key_id")), 10, 64)
+	if err != nil || keyID == 0 {
+		c.Error(errors.NewValidationError("key_id must be a positive integer"))
+		return
+	}
+	var req assignCustomRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(errors.NewValidationError("invalid request body").WithDetails(err.Error()))
+		return
+	}
+	if req.RoleID != nil && *req.RoleID == 0 {
+		req.RoleID = nil
+	}
+	key, err := h.service.AssignToAPIKey(c.Request.Context(), tenantID, keyID, req.RoleID)
+	if err != nil {
+		h.fail(c, err, "Failed to bind custom role")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": tenantAPIKeyForResponse(key)})
+}
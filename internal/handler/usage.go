@@ -0,0 +1,172 @@
+package handler
+
+import (
+	"bytes"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	secutils "github.com/Tencent/WeKnora/internal/utils"
+)
+
+// UsageHandler serves usage reports and monthly token budgets.
+type UsageHandler struct {
+	service interfaces.UsageService
+}
+
+// NewUsageHandler creates a new handler
+func NewUsageHandler(service interfaces.UsageService) *UsageHandler {
+	return &UsageHandler{service: service}
+}
+
+// fail reports a service error, passing application errors through as-is.
+func (h *UsageHandler) fail(c *gin.Context, err error, message string) {
+	if appErr, ok := errors.IsAppError(err); ok {
+		c.Error(appErr)
+		return
+	}
+	logger.ErrorWithFields(c.Request.Context(), err, nil)
+	c.Error(errors.NewInternalServerError(message).WithDetails(err.Error()))
+}
+
+// GetReport godoc
+// @Summary      获取用量报表
+// @Description  按天、月、类型、模型、用户、API Key、智能体、知识库或会话汇总当前空间的用量（对话 token、向量化 token、重排与网络搜索调用次数）。日期为 UTC，默认统计本月；?format=csv 导出 CSV
+// @Tags         用量计量
+// @Produce      json
+// @Produce      text/csv
+// @Param        from               query     string  false  "起始日期（YYYY-MM-DD，含）"
+// @Param        to                 query     string  false  "结束日期（YYYY-MM-DD，含）"
+// @Param        group_by           query     string  false  "分组维度：day（默认）、month、kind、model、user、api_key、agent、knowledge_base、session"
+// @Param        kind               query     string  false  "用量类型：chat、embedding、rerank、web_search"
+// @Param        model_id           query     string  false  "模型ID"
+// @Param        user_id            query     string  false  "用户ID"
+// @Param        api_key_id         query     int     false  "API Key ID"
+// @Param        agent_id           query     string  false  "智能体ID"
+// @Param        knowledge_base_id  query     string  false  "知识库ID"
+// @Param        session_id         query     string  false  "会话ID"
+// @Param        format             query     string  false  "导出格式：json（默认）或 csv"
+// @Success      200                {object}  types.UsageReport  "用量报表"
+// @Failure      400                {object}  errors.AppError    "请求参数错误"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /usage/report [get]
+func (h *UsageHandler) GetReport(c *gin.Context) {
+	ctx := c.Request.Context()
+	q := &types.UsageReportQuery{
+		From:            strings.TrimSpace(c.Query("from")),
+		To:              strings.TrimSpace(c.Query("to")),
+		GroupBy:         types.UsageGroupBy(strings.ToLower(strings.TrimSpace(c.Query("group_by")))),
+		Kind:            types.UsageKind(strings.ToLower(strings.TrimSpace(c.Query("kind")))),
+		ModelID:         secutils.SanitizeForLog(c.Query("model_id")),
+		UserID:          secutils.SanitizeForLog(c.Query("user_id")),
+		AgentID:         secutils.SanitizeForLog(c.Query("agent_id")),
+		KnowledgeBaseID: secutils.SanitizeForLog(c.Query("knowledge_base_id")),
+		SessionID:       secutils.SanitizeForLog(c.Query("session_id")),
+	}
+	if raw := strings.TrimSpace(c.Query("api_key_id")); raw != "" {
+		id, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			c.Error(errors.NewBadRequestError("invalid api_key_id"))
+			return
+		}
+		q.APIKeyID = id
+	}
+
+	report, err := h.service.Report(ctx, q)
+	if err != nil {
+		h.fail(c, err, "Failed to build usage report")
+		return
+	}
+
+	if strings.EqualFold(strings.TrimSpace(c.Query("format")), "csv") {
+		var buf bytes.Buffer
+		// Add BOM for Excel compatibility with UTF-8
+		buf.Write([]byte{0xEF, 0xBB, 0xBF})
+		if err := report.WriteCSV(&buf); err != nil {
+			h.fail(c, err, "Failed to export usage report")
+			return
+		}
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+		c.Header("Content-Disposition", "attachment; filename=usage_report.csv")
+		c.Data(http.StatusOK, "text/csv; charset=utf-8", buf.Bytes())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": report})
+}
+
+// ListBudgets godoc
+// @Summary      获取用量预算
+// @Description  返回当前空间的月度 token 预算（空间级与 API Key 级）及本月已用量
+// @Tags         用量计量
+// @Produce      json
+// @Success      200  {array}   types.UsageBudgetStatus  "预算列表"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /usage/budgets [get]
+func (h *UsageHandler) ListBudgets(c *gin.Context) {
+	budgets, err := h.service.ListBudgets(c.Request.Context())
+	if err != nil {
+		h.fail(c, err, "Failed to list usage budgets")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": budgets})
+}
+
+// SetBudget godoc
+// @Summary      设置用量预算
+// @Description  创建或替换月度 token 预算。api_key_id 为 0 表示整个空间；warn_percent 为预警阈值（默认 80）；hard_stop 为 true 时超出预算后拒绝新的模型调用
+// @Tags         用量计量
+// @Accept       json
+// @Produce      json
+// @Param        request  body      types.UsageBudgetRequest  true  "预算配置"
+// @Success      200      {object}  types.UsageBudgetStatus   "预算及本月用量"
+// @Failure      400      {object}  errors.AppError           "请求参数错误"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /usage/budgets [put]
+func (h *UsageHandler) SetBudget(c *gin.Context) {
+	ctx := c.Request.Context()
+	var req types.UsageBudgetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Warnf(ctx, "Invalid usage budget request: %v", err)
+		c.Error(errors.NewBadRequestError(err.Error()))
+		return
+	}
+	status, err := h.service.SetBudget(ctx, &req)
+	if err != nil {
+		h.fail(c, err, "Failed to set usage budget")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": status})
+}
+
+// DeleteBudget godoc
+// @Summary      删除用量预算
+// @Description  删除一条月度 token 预算，已记录的用量不受影响
+// @Tags         用量计量
+// @Produce      json
+// @Param        id   path      int  true  "预算ID"
+// @Success      200  {object}  map[string]interface{}  "删除成功"
+// @Failure      404  {object}  errors.AppError         "预算不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /usage/budgets/{id} [delete]
+func (h *UsageHandler) DeleteBudget(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(errors.NewBadRequestError("invalid budget id"))
+		return
+	}
+	if err := h.service.DeleteBudget(c.Request.Context(), id); err != nil {
+		h.fail(c, err, "Failed to delete usage budget")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
@@ -3,6 +3,7 @@ package im
 import (
 	"context"
 	"io"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -23,6 +24,9 @@ const (
 	PlatformWeChat     Platform = "wechat"
 	PlatformQQBot      Platform = "qqbot"
 	PlatformYunzhijia  Platform = "yunzhijia"
+	PlatformDiscord    Platform = "discord"
+	PlatformTeams      Platform = "teams"
+	PlatformMatrix     Platform = "matrix"
 )
 
 // SessionMode determines how IM sessions are resolved.
@@ -42,13 +46,20 @@ const (
 	MessageTypeText  MessageType = "text"
 	MessageTypeFile  MessageType = "file"
 	MessageTypeImage MessageType = "image"
+	// MessageTypeAudio is a voice note or audio clip, answered through the
+	// agent's ASR model.
+	MessageTypeAudio MessageType = "audio"
+	// MessageTypeReaction is an emoji reaction added to a message. Content
+	// holds the emoji name; MessageID is the message that was reacted to.
+	// Reactions are only used to trigger thread archiving.
+	MessageTypeReaction MessageType = "reaction"
 )
 
 // IncomingMessage is the unified message parsed from an IM callback.
 type IncomingMessage struct {
 	// Platform identifies which IM platform the message comes from.
 	Platform Platform
-	// MessageType is "text" (default) or "file".
+	// MessageType is "text" (default), "file", "image", "audio" or "reaction".
 	MessageType MessageType
 	// UserID is the IM-platform user identifier.
 	UserID string
@@ -73,6 +84,10 @@ type IncomingMessage struct {
 	// - Mattermost: root_id, or post_id if top-level
 	// - Feishu/Lark: root_id, or message_id if top-level
 	// - Telegram: message_thread_id (Forum Topics only)
+	// - Discord: thread channel ID, or message_id if top-level (a thread
+	//   started from a message shares its ID)
+	// - Teams: root message ID of the channel reply chain, or activity ID
+	// - Matrix: m.thread root event ID, or event_id if top-level
 	// Empty for platforms without thread support (WeCom, DingTalk).
 	// In thread mode, top-level messages use their own ID as ThreadID,
 	// effectively creating a new session per top-level message.
@@ -171,3 +186,45 @@ type FileDownloader interface {
 	// Returns the file content reader, the resolved file name, and any error.
 	DownloadFile(ctx context.Context, msg *IncomingMessage) (io.ReadCloser, string, error)
 }
+
+// VoiceSender is an optional interface for platforms that can deliver a
+// spoken reply as a native voice message. When the agent enables spoken
+// replies, answers to voice messages are also synthesized and sent through it.
+type VoiceSender interface {
+	// SendVoice sends Ogg/Opus audio as a voice message replying to incoming.
+	SendVoice(ctx context.Context, incoming *IncomingMessage, audio []byte) error
+}
+
+// ThreadReader is an optional interface for platforms that can read back the
+// full history of a group thread. It powers archiving threads into a
+// knowledge base (/archive and the archive reaction).
+type ThreadReader interface {
+	// FetchThread returns every message of the thread containing incoming,
+	// oldest first. incoming.MessageID may be any message in the thread.
+	FetchThread(ctx context.Context, incoming *IncomingMessage) (*ThreadTranscript, error)
+}
+
+// ThreadTranscript is the history of one IM thread.
+type ThreadTranscript struct {
+	// ChatID is the group/channel the thread belongs to.
+	ChatID string
+	// ThreadID identifies the thread the same way IncomingMessage.ThreadID does.
+	ThreadID string
+	// RootMessageID is the first message of the thread; replies target it.
+	RootMessageID string
+	// Permalink opens the thread (or its chat) in the IM client. Optional.
+	Permalink string
+	// Messages are the thread's messages, oldest first.
+	Messages []ThreadMessage
+}
+
+// ThreadMessage is one message of a ThreadTranscript.
+type ThreadMessage struct {
+	UserID   string
+	UserName string
+	// Content is the plain text; non-text messages carry a short placeholder.
+	Content string
+	Time    time.Time
+	// IsBot marks messages sent by a bot or app, including this one.
+	IsBot bool
+}
@@ -0,0 +1,513 @@
+package im
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/agent/approval"
+	"github.com/Tencent/WeKnora/internal/event"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// lastAttachmentTTL is how long /save can still pick up a file the user
+	// sent earlier in the chat.
+	lastAttachmentTTL = 30 * time.Minute
+	// pendingApprovalGrace keeps a pending approval listed a little past its
+	// timeout so a late /approve gets "expired" rather than "nothing pending".
+	pendingApprovalGrace = time.Minute
+	// feedbackLookback is how many recent messages /good and /bad search for
+	// the latest answer.
+	feedbackLookback = 10
+	// maxApprovalArgsRunes caps the tool arguments shown in an approval notice.
+	maxApprovalArgsRunes = 300
+)
+
+// chatKey identifies the chat a message belongs to for per-chat state:
+// the group chat ID, or "dm:<user>" for direct chats without a chat ID.
+func chatKey(msg *IncomingMessage) string {
+	if msg.ChatID != "" {
+		return msg.ChatID
+	}
+	return "dm:" + msg.UserID
+}
+
+// ── Identity ────────────────────────────────────────────────────────────────
+
+// resolveIMRole returns the tenant role of the member an IM user is bound to.
+// Unbound users, and users whose membership is missing or inactive, are
+// viewers; bound reports whether a binding exists at all.
+func (s *Service) resolveIMRole(ctx context.Context, channel *IMChannel, imUserID string) (role types.TenantRole, bound bool) {
+	role = types.TenantRoleViewer
+	var binding IMUserBinding
+	err := s.db.WithContext(ctx).
+		Where("im_channel_id = ? AND im_user_id = ?", channel.ID, imUserID).
+		First(&binding).Error
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			logger.Warnf(ctx, "[IM] Failed to load user binding: channel=%s user=%s err=%v", channel.ID, imUserID, err)
+		}
+		return role, false
+	}
+	if s.tenantMemberService == nil {
+		return role, true
+	}
+	member, err := s.tenantMemberService.GetMembership(ctx, binding.UserID, channel.TenantID)
+	if err != nil {
+		logger.Warnf(ctx, "[IM] Failed to load membership for bound user %s: %v", binding.UserID, err)
+		return role, true
+	}
+	if member != nil && member.Status == types.TenantMemberStatusActive && member.Role.IsValid() {
+		role = member.Role
+	}
+	return role, true
+}
+
+// ── Chat settings (/agent, /kb) ─────────────────────────────────────────────
+
+// loadChatSetting returns the chat's /agent and /kb overrides, or nil when
+// the chat has none.
+func (s *Service) loadChatSetting(ctx context.Context, channelID, key string) *IMChatSetting {
+	var setting IMChatSetting
+	err := s.db.WithContext(ctx).
+		Where("im_channel_id = ? AND chat_key = ?", channelID, key).
+		First(&setting).Error
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			logger.Warnf(ctx, "[IM] Failed to load chat setting: channel=%s chat=%s err=%v", channelID, key, err)
+		}
+		return nil
+	}
+	return &setting
+}
+
+// updateChatSetting upserts a single column of the chat's settings row.
+func (s *Service) updateChatSetting(ctx context.Context, channel *IMChannel, msg *IncomingMessage, column string, value any) error {
+	setting := IMChatSetting{
+		TenantID:         channel.TenantID,
+		IMChannelID:      channel.ID,
+		ChatKey:          chatKey(msg),
+		KnowledgeBaseIDs: types.StringArray{},
+		UpdatedBy:        msg.UserID,
+	}
+	switch column {
+	case "agent_id":
+		setting.AgentID = value.(string)
+	case "knowledge_base_ids":
+		if ids := value.(types.StringArray); ids != nil {
+			setting.KnowledgeBaseIDs = ids
+		}
+	}
+	return s.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "im_channel_id"}, {Name: "chat_key"}},
+		DoUpdates: clause.AssignmentColumns([]string{column, "updated_by", "updated_at"}),
+	}).Create(&setting).Error
+}
+
+// resolveChatAgentID returns the agent answering in a chat: the /agent
+// override when it still exists, otherwise the channel's agent.
+func (s *Service) resolveChatAgentID(ctx context.Context, channel *IMChannel, setting *IMChatSetting) string {
+	if setting == nil || setting.AgentID == "" || setting.AgentID == channel.AgentID {
+		return channel.AgentID
+	}
+	if _, err := s.agentService.GetAgentByID(ctx, setting.AgentID); err != nil {
+		logger.Warnf(ctx, "[IM] Chat agent %s unavailable, falling back to channel agent: %v", setting.AgentID, err)
+		return channel.AgentID
+	}
+	return setting.AgentID
+}
+
+// ── Per-chat transient state ────────────────────────────────────────────────
+
+type localStateEntry struct {
+	fields    map[string][]byte
+	expiresAt time.Time
+}
+
+// chatStateStore keeps short-lived per-chat state — the last attachment for
+// /save and the tool calls waiting for /approve — as hashes in Redis, or in
+// process memory when Redis is not configured.
+type chatStateStore struct {
+	redis *redis.Client
+	mu    sync.Mutex
+	local map[string]*localStateEntry
+}
+
+func newChatStateStore(redisClient *redis.Client) *chatStateStore {
+	return &chatStateStore{redis: redisClient, local: make(map[string]*localStateEntry)}
+}
+
+// set stores field under key and refreshes the key's TTL.
+func (st *chatStateStore) set(ctx context.Context, key, field string, value []byte, ttl time.Duration) error {
+	if st == nil {
+		return nil
+	}
+	if st.redis != nil {
+		pipe := st.redis.TxPipeline()
+		pipe.HSet(ctx, key, field, value)
+		pipe.Expire(ctx, key, ttl)
+		_, err := pipe.Exec(ctx)
+		return err
+	}
+	now := time.Now()
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	for k, e := range st.local {
+		if now.After(e.expiresAt) {
+			delete(st.local, k)
+		}
+	}
+	e, ok := st.local[key]
+	if !ok {
+		e = &localStateEntry{fields: make(map[string][]byte)}
+		st.local[key] = e
+	}
+	e.fields[field] = value
+	e.expiresAt = now.Add(ttl)
+	return nil
+}
+
+// delete removes field from key.
+func (st *chatStateStore) delete(ctx context.Context, key, field string) {
+	if st == nil {
+		return
+	}
+	if st.redis != nil {
+		if err := st.redis.HDel(ctx, key, field).Err(); err != nil {
+			logger.Warnf(ctx, "[IM] Failed to delete chat state %s/%s: %v", key, field, err)
+		}
+		return
+	}
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if e, ok := st.local[key]; ok {
+		delete(e.fields, field)
+	}
+}
+
+// getAll returns every live field under key.
+func (st *chatStateStore) getAll(ctx context.Context, key string) (map[string][]byte, error) {
+	if st == nil {
+		return nil, nil
+	}
+	if st.redis != nil {
+		raw, err := st.redis.HGetAll(ctx, key).Result()
+		if err != nil {
+			return nil, err
+		}
+		out := make(map[string][]byte, len(raw))
+		for f, v := range raw {
+			out[f] = []byte(v)
+		}
+		return out, nil
+	}
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	e, ok := st.local[key]
+	if !ok || time.Now().After(e.expiresAt) {
+		return nil, nil
+	}
+	out := make(map[string][]byte, len(e.fields))
+	for f, v := range e.fields {
+		out[f] = v
+	}
+	return out, nil
+}
+
+func lastAttachmentKey(channelID string, msg *IncomingMessage) string {
+	return RedisKeyLastAttachment + channelID + ":" + chatKey(msg) + ":" + msg.UserID
+}
+
+// rememberAttachment records a file or image message so a later /save in the
+// same chat can download it again.
+func (s *Service) rememberAttachment(ctx context.Context, channelID string, msg *IncomingMessage) {
+	stored := *msg
+	stored.Content = ""
+	stored.Quote = nil
+	data, err := json.Marshal(&stored)
+	if err != nil {
+		return
+	}
+	if err := s.chatState.set(ctx, lastAttachmentKey(channelID, msg), "latest", data, lastAttachmentTTL); err != nil {
+		logger.Warnf(ctx, "[IM] Failed to remember attachment: %v", err)
+	}
+}
+
+// lastAttachment returns the user's most recent file or image message in
+// this chat, or nil when there is none within lastAttachmentTTL.
+func (s *Service) lastAttachment(ctx context.Context, channelID string, msg *IncomingMessage) *IncomingMessage {
+	fields, err := s.chatState.getAll(ctx, lastAttachmentKey(channelID, msg))
+	if err != nil || fields["latest"] == nil {
+		return nil
+	}
+	var stored IncomingMessage
+	if err := json.Unmarshal(fields["latest"], &stored); err != nil {
+		return nil
+	}
+	return &stored
+}
+
+// ── Command actions ─────────────────────────────────────────────────────────
+
+// saveAttachmentToKnowledgeBase downloads the user's last attachment again
+// and creates a knowledge item from it in kbID.
+func (s *Service) saveAttachmentToKnowledgeBase(ctx context.Context, msg *IncomingMessage, adapter Adapter, channel *IMChannel, kbID string) string {
+	file := s.lastAttachment(ctx, channel.ID, msg)
+	if file == nil {
+		return "没有找到你最近发送的文件，请先发送文件，再使用 `/save`。"
+	}
+	downloadCtx, cancel := context.WithTimeout(ctx, imAttachmentReadTimeout)
+	defer cancel()
+	content, fileName, err := downloadIMAttachment(downloadCtx, file, adapter)
+	if err != nil {
+		logger.Warnf(ctx, "[IM] /save download failed: %v", err)
+		return "❌ 无法下载该文件，请重新发送后再试。"
+	}
+	if file.MessageType == MessageTypeImage && fileExtension(fileName) == "" {
+		fileName += ".png"
+	}
+	if !supportedKBFileExts[fileExtension(fileName)] {
+		return fmt.Sprintf("❌ 暂不支持将「%s」保存到知识库。", fileName)
+	}
+
+	kbName := kbID
+	if kb, err := s.kbService.GetKnowledgeBaseByID(ctx, kbID); err == nil {
+		kbName = kb.Name
+	}
+	knowledge, err := s.createKnowledgeFromAttachment(ctx, channel, kbID, &imDownloadedAttachment{fileName: fileName, content: content})
+	if err != nil {
+		if isDuplicateKnowledgeError(err) {
+			return fmt.Sprintf("知识库「%s」中已有文件「%s」。", kbName, fileName)
+		}
+		logger.Errorf(ctx, "[IM] /save failed: kb=%s file=%s err=%v", kbID, fileName, err)
+		return "❌ 保存失败，请稍后再试。"
+	}
+	logger.Infof(ctx, "[IM] /save stored file: kb=%s knowledge=%s file=%s user=%s", kbID, knowledge.ID, fileName, msg.UserID)
+	return fmt.Sprintf("✅ 已将「%s」保存到知识库「%s」，解析完成后即可检索。", fileName, kbName)
+}
+
+// recordFeedback rates the latest completed answer of the current session.
+func (s *Service) recordFeedback(ctx context.Context, msg *IncomingMessage, channel *IMChannel, channelSession *ChannelSession, rating, comment string) string {
+	messages, err := s.messageService.GetRecentMessagesBySession(ctx, channelSession.SessionID, feedbackLookback)
+	if err != nil && !isSessionNotFound(err) {
+		logger.Warnf(ctx, "[IM] Failed to load messages for feedback: %v", err)
+		return "❌ 记录反馈失败，请稍后再试。"
+	}
+	var answer *types.Message
+	for i := len(messages) - 1; i >= 0; i-- {
+		if m := messages[i]; m.Role == "assistant" && m.IsCompleted && strings.TrimSpace(m.Content) != "" {
+			answer = m
+			break
+		}
+	}
+	if answer == nil {
+		return "当前会话还没有可以评价的回答。"
+	}
+
+	feedback := IMMessageFeedback{
+		TenantID:    channel.TenantID,
+		IMChannelID: channel.ID,
+		Platform:    string(msg.Platform),
+		IMUserID:    msg.UserID,
+		SessionID:   channelSession.SessionID,
+		MessageID:   answer.ID,
+		Rating:      rating,
+		Comment:     strings.TrimSpace(comment),
+	}
+	if err := s.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "message_id"}, {Name: "im_user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"rating", "comment", "updated_at"}),
+	}).Create(&feedback).Error; err != nil {
+		logger.Warnf(ctx, "[IM] Failed to save feedback: %v", err)
+		return "❌ 记录反馈失败，请稍后再试。"
+	}
+	if rating == FeedbackRatingGood {
+		return "👍 感谢反馈，已记录。"
+	}
+	return "👎 感谢反馈，我们会持续改进。"
+}
+
+// ── Tool approvals (/approve, /deny) ────────────────────────────────────────
+
+// imPendingApproval is a tool call of this chat waiting for /approve or /deny.
+type imPendingApproval struct {
+	PendingID   string `json:"pending_id"`
+	OwnerID     string `json:"owner_id"` // principal the gate checks on Resolve
+	IMUserID    string `json:"im_user_id"`
+	UserName    string `json:"user_name"`
+	ServiceName string `json:"service_name"`
+	ToolName    string `json:"tool_name"`
+	Args        string `json:"args"`
+	RequestedAt int64  `json:"requested_at"`
+	ExpiresAt   int64  `json:"expires_at"`
+}
+
+func (p imPendingApproval) label() string {
+	if p.ServiceName == "" {
+		return p.ToolName
+	}
+	return p.ServiceName + " / " + p.ToolName
+}
+
+func pendingApprovalKey(tenantID uint64, msg *IncomingMessage) string {
+	return fmt.Sprintf("%s%d:%s:%s", RedisKeyApproval, tenantID, msg.Platform, chatKey(msg))
+}
+
+// watchToolApprovals subscribes to the QA event bus so tool calls that need
+// human approval are announced in the chat and can be resolved with
+// /approve or /deny.
+func (s *Service) watchToolApprovals(ctx context.Context, eventBus *event.EventBus, tenantID uint64, msg *IncomingMessage, adapter Adapter) {
+	key := pendingApprovalKey(tenantID, msg)
+	owner, _ := types.PrincipalFromContext(ctx)
+
+	eventBus.On(event.EventToolApprovalRequired, func(_ context.Context, evt event.Event) error {
+		data, ok := evt.Data.(event.ToolApprovalRequiredData)
+		if !ok {
+			return nil
+		}
+		timeout := time.Duration(data.TimeoutSeconds) * time.Second
+		pending := imPendingApproval{
+			PendingID:   data.PendingID,
+			OwnerID:     owner.StorageID(),
+			IMUserID:    msg.UserID,
+			UserName:    msg.UserName,
+			ServiceName: data.ServiceName,
+			ToolName:    data.MCPToolName,
+			Args:        truncateRunes(data.ArgsJSON, maxApprovalArgsRunes),
+			RequestedAt: time.Now().Unix(),
+			ExpiresAt:   time.Now().Add(timeout).Unix(),
+		}
+		raw, _ := json.Marshal(pending)
+		if err := s.chatState.set(ctx, key, data.PendingID, raw, timeout+pendingApprovalGrace); err != nil {
+			logger.Warnf(ctx, "[IM] Failed to store pending approval %s: %v", data.PendingID, err)
+		}
+
+		var sb strings.Builder
+		sb.WriteString(fmt.Sprintf("🔐 工具「%s」需要审批后才能执行", pending.label()))
+		if data.TimeoutSeconds > 0 {
+			sb.WriteString(fmt.Sprintf("（%d 秒内有效）", data.TimeoutSeconds))
+		}
+		sb.WriteString("。\n")
+		if pending.Args != "" {
+			sb.WriteString(fmt.Sprintf("参数：`%s`\n", pending.Args))
+		}
+		sb.WriteString("发送 `/approve` 批准，或 `/deny [原因]` 拒绝。")
+		if err := adapter.SendReply(ctx, msg, &ReplyMessage{Content: sb.String(), IsFinal: true}); err != nil {
+			logger.Warnf(ctx, "[IM] Failed to send approval notice: %v", err)
+		}
+		return nil
+	})
+
+	eventBus.On(event.EventToolApprovalResolved, func(_ context.Context, evt event.Event) error {
+		if data, ok := evt.Data.(event.ToolApprovalResolvedData); ok {
+			s.chatState.delete(ctx, key, data.PendingID)
+		}
+		return nil
+	})
+}
+
+// listPendingApprovals returns the chat's live pending approvals, oldest first.
+func (s *Service) listPendingApprovals(ctx context.Context, key string) []imPendingApproval {
+	fields, err := s.chatState.getAll(ctx, key)
+	if err != nil {
+		logger.Warnf(ctx, "[IM] Failed to list pending approvals: %v", err)
+		return nil
+	}
+	now := time.Now().Unix()
+	pendings := make([]imPendingApproval, 0, len(fields))
+	for _, raw := range fields {
+		var p imPendingApproval
+		if json.Unmarshal(raw, &p) != nil || (p.ExpiresAt > 0 && p.ExpiresAt < now) {
+			continue
+		}
+		pendings = append(pendings, p)
+	}
+	sort.Slice(pendings, func(i, j int) bool { return pendings[i].RequestedAt < pendings[j].RequestedAt })
+	return pendings
+}
+
+// resolveToolApproval applies /approve or /deny to one of the chat's pending
+// tool calls. Resolving one's own call needs the contributor role; resolving
+// another user's call needs admin.
+func (s *Service) resolveToolApproval(ctx context.Context, cmdCtx *CommandContext, result *CommandResult) string {
+	if s.approvalGate == nil {
+		return "当前未启用工具审批。"
+	}
+	msg := cmdCtx.Incoming
+	key := pendingApprovalKey(cmdCtx.TenantID, msg)
+	pendings := s.listPendingApprovals(ctx, key)
+	if len(pendings) == 0 {
+		return "当前没有等待审批的工具调用。"
+	}
+
+	idx := result.ApprovalIndex
+	if idx == 0 && len(pendings) == 1 {
+		idx = 1
+	}
+	if idx < 1 || idx > len(pendings) {
+		var sb strings.Builder
+		sb.WriteString("🔐 **等待审批的工具调用**\n\n")
+		for i, p := range pendings {
+			requester := p.UserName
+			if requester == "" {
+				requester = p.IMUserID
+			}
+			sb.WriteString(fmt.Sprintf("%d. %s（发起人：%s）\n", i+1, p.label(), requester))
+		}
+		name := "deny"
+		if result.Approved {
+			name = "approve"
+		}
+		sb.WriteString(fmt.Sprintf("\n发送 `/%s <序号>` 处理指定的调用", name))
+		return sb.String()
+	}
+
+	pending := pendings[idx-1]
+	required := types.TenantRoleContributor
+	if pending.IMUserID != msg.UserID {
+		required = types.TenantRoleAdmin
+	}
+	if deny := requireRole(cmdCtx, required); deny != nil {
+		return deny.Content
+	}
+
+	decision := approval.Decision{Approved: result.Approved, Reason: strings.TrimSpace(result.Comment)}
+	if decision.Reason == "" && !decision.Approved {
+		decision.Reason = "denied in IM by " + msg.UserID
+	}
+	err := s.approvalGate.Resolve(cmdCtx.TenantID, pending.OwnerID, pending.PendingID, decision)
+	switch {
+	case err == nil:
+	case errors.Is(err, approval.ErrPendingNotFound), errors.Is(err, approval.ErrAlreadyResolved):
+		s.chatState.delete(ctx, key, pending.PendingID)
+		return "该审批已超时或已被处理。"
+	default:
+		logger.Warnf(ctx, "[IM] Failed to resolve approval %s: %v", pending.PendingID, err)
+		return "❌ 审批失败，请稍后再试。"
+	}
+	s.chatState.delete(ctx, key, pending.PendingID)
+	logger.Infof(ctx, "[IM] Tool approval resolved: pending=%s approved=%v by=%s", pending.PendingID, decision.Approved, msg.UserID)
+	if decision.Approved {
+		return fmt.Sprintf("✅ 已批准工具「%s」。", pending.label())
+	}
+	return fmt.Sprintf("🚫 已拒绝工具「%s」。", pending.label())
+}
+
+// truncateRunes shortens s to at most n runes, marking the cut with "…".
+func truncateRunes(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n]) + "…"
+}
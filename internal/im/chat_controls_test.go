@@ -0,0 +1,331 @@
+package im
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/agent/approval"
+	"github.com/Tencent/WeKnora/internal/event"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stubCommandAgents struct {
+	interfaces.CustomAgentService
+	agents []*types.CustomAgent
+}
+
+func (s *stubCommandAgents) ListAgents(context.Context) ([]*types.CustomAgent, error) {
+	return s.agents, nil
+}
+
+type stubCommandKBs struct {
+	interfaces.KnowledgeBaseService
+	kbs []*types.KnowledgeBase
+}
+
+func (s *stubCommandKBs) ListKnowledgeBases(context.Context) ([]*types.KnowledgeBase, error) {
+	return s.kbs, nil
+}
+
+type stubMembers struct {
+	interfaces.TenantMemberService
+	members map[string]*types.TenantMember
+}
+
+func (s *stubMembers) GetMembership(_ context.Context, userID string, _ uint64) (*types.TenantMember, error) {
+	return s.members[userID], nil
+}
+
+type recordingReplyAdapter struct {
+	*lifecycleTestAdapter
+	mu      sync.Mutex
+	replies []string
+}
+
+func (a *recordingReplyAdapter) SendReply(_ context.Context, _ *IncomingMessage, reply *ReplyMessage) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.replies = append(a.replies, reply.Content)
+	return nil
+}
+
+func testCommandContext(role types.TenantRole, bound bool, chatType ChatType) *CommandContext {
+	return &CommandContext{
+		Incoming: &IncomingMessage{Platform: "slack", UserID: "U1", ChatID: "C1", ChatType: chatType},
+		TenantID: 7,
+		Role:     role,
+		Bound:    bound,
+	}
+}
+
+func TestMatchOption(t *testing.T) {
+	ids := []string{"a-1", "b-2", "c-3"}
+	names := []string{"Sales", "Support", "2024"}
+	match := func(pick string) int {
+		return matchOption(pick, len(ids), func(i int) string { return ids[i] }, func(i int) string { return names[i] })
+	}
+	assert.Equal(t, 1, match("2"))
+	assert.Equal(t, 2, match("c-3"))
+	assert.Equal(t, 1, match("support"))
+	assert.Equal(t, -1, match("4"))
+	assert.Equal(t, -1, match("2024"), "numeric picks are indexes, not names")
+	assert.Equal(t, -1, match(" "))
+}
+
+func TestRequireRole(t *testing.T) {
+	assert.Nil(t, requireRole(testCommandContext(types.TenantRoleAdmin, true, ChatTypeGroup), types.TenantRoleContributor))
+
+	unbound := requireRole(testCommandContext(types.TenantRoleViewer, false, ChatTypeGroup), types.TenantRoleContributor)
+	require.NotNil(t, unbound)
+	assert.Contains(t, unbound.Content, "「编辑」")
+	assert.Contains(t, unbound.Content, "`U1`")
+
+	bound := requireRole(testCommandContext(types.TenantRoleContributor, true, ChatTypeGroup), types.TenantRoleAdmin)
+	require.NotNil(t, bound)
+	assert.Contains(t, bound.Content, "你当前的角色是「编辑」")
+}
+
+func TestAgentCommand(t *testing.T) {
+	cmd := newAgentCommand(&stubCommandAgents{agents: []*types.CustomAgent{
+		{ID: "agent-default", Name: "Helpdesk"},
+		{ID: "agent-ops", Name: "Ops"},
+	}})
+	ctx := context.Background()
+
+	viewer := testCommandContext(types.TenantRoleViewer, false, ChatTypeGroup)
+	viewer.CustomAgent = &types.CustomAgent{ID: "agent-default"}
+	viewer.ChannelAgentID = "agent-default"
+	list, err := cmd.Execute(ctx, viewer, nil)
+	require.NoError(t, err)
+	assert.Contains(t, list.Content, "1. Helpdesk（渠道默认） ✅\n2. Ops\n")
+
+	denied, err := cmd.Execute(ctx, viewer, []string{"2"})
+	require.NoError(t, err)
+	assert.Equal(t, ActionNone, denied.Action)
+
+	contributor := testCommandContext(types.TenantRoleContributor, true, ChatTypeGroup)
+	contributor.CustomAgent = viewer.CustomAgent
+	contributor.ChannelAgentID = viewer.ChannelAgentID
+	switched, err := cmd.Execute(ctx, contributor, []string{"ops"})
+	require.NoError(t, err)
+	assert.Equal(t, ActionSwitchAgent, switched.Action)
+	assert.Equal(t, "agent-ops", switched.AgentID)
+
+	same, err := cmd.Execute(ctx, contributor, []string{"1"})
+	require.NoError(t, err)
+	assert.Equal(t, ActionNone, same.Action)
+
+	contributor.CustomAgent = &types.CustomAgent{ID: "agent-ops"}
+	reset, err := cmd.Execute(ctx, contributor, []string{"reset"})
+	require.NoError(t, err)
+	assert.Equal(t, ActionSwitchAgent, reset.Action)
+	assert.Empty(t, reset.AgentID)
+}
+
+func TestKBCommand(t *testing.T) {
+	cmd := newKBCommand(&stubCommandKBs{kbs: []*types.KnowledgeBase{
+		{ID: "kb-1", Name: "Manuals"},
+		{ID: "kb-tmp", Name: "Scratch", IsTemporary: true},
+		{ID: "kb-2", Name: "Policies"},
+	}})
+	ctx := context.Background()
+
+	direct := testCommandContext(types.TenantRoleViewer, false, ChatTypeDirect)
+	scoped, err := cmd.Execute(ctx, direct, []string{"2", "manuals", "kb-2"})
+	require.NoError(t, err)
+	assert.Equal(t, ActionScopeKnowledgeBases, scoped.Action)
+	assert.Equal(t, []string{"kb-2", "kb-1"}, scoped.KnowledgeBaseIDs)
+
+	group := testCommandContext(types.TenantRoleViewer, false, ChatTypeGroup)
+	denied, err := cmd.Execute(ctx, group, []string{"1"})
+	require.NoError(t, err)
+	assert.Equal(t, ActionNone, denied.Action)
+
+	group.KnowledgeBaseIDs = []string{"kb-2"}
+	list, err := cmd.Execute(ctx, group, nil)
+	require.NoError(t, err)
+	assert.Contains(t, list.Content, "1. Manuals\n2. Policies ✅\n")
+	assert.NotContains(t, list.Content, "Scratch")
+
+	unknown, err := cmd.Execute(ctx, direct, []string{"Scratch"})
+	require.NoError(t, err)
+	assert.Equal(t, ActionNone, unknown.Action)
+}
+
+func TestApprovalCommandArgs(t *testing.T) {
+	ctx := context.Background()
+	approve, err := newApprovalCommand(true).Execute(ctx, nil, []string{"2"})
+	require.NoError(t, err)
+	assert.Equal(t, &CommandResult{Action: ActionResolveApproval, Approved: true, ApprovalIndex: 2}, approve)
+
+	deny, err := newApprovalCommand(false).Execute(ctx, nil, []string{"参数", "有误"})
+	require.NoError(t, err)
+	assert.Equal(t, &CommandResult{Action: ActionResolveApproval, Comment: "参数 有误"}, deny)
+}
+
+func TestChatStateStore(t *testing.T) {
+	mr := miniredis.RunT(t)
+	stores := map[string]*chatStateStore{
+		"local": newChatStateStore(nil),
+		"redis": newChatStateStore(redis.NewClient(&redis.Options{Addr: mr.Addr()})),
+	}
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			require.NoError(t, store.set(ctx, "k", "a", []byte("1"), time.Minute))
+			require.NoError(t, store.set(ctx, "k", "b", []byte("2"), time.Minute))
+			store.delete(ctx, "k", "a")
+
+			fields, err := store.getAll(ctx, "k")
+			require.NoError(t, err)
+			assert.Equal(t, map[string][]byte{"b": []byte("2")}, fields)
+		})
+	}
+
+	local := newChatStateStore(nil)
+	require.NoError(t, local.set(context.Background(), "gone", "a", []byte("1"), -time.Second))
+	fields, err := local.getAll(context.Background(), "gone")
+	require.NoError(t, err)
+	assert.Empty(t, fields)
+}
+
+func TestLastAttachment(t *testing.T) {
+	svc := &Service{chatState: newChatStateStore(nil)}
+	ctx := context.Background()
+	file := &IncomingMessage{
+		Platform: "slack", MessageType: MessageTypeFile, UserID: "U1", ChatID: "C1",
+		MessageID: "m1", FileKey: "F1", FileName: "guide.pdf", Content: "see attached",
+	}
+	svc.rememberAttachment(ctx, "ch-1", file)
+
+	got := svc.lastAttachment(ctx, "ch-1", &IncomingMessage{UserID: "U1", ChatID: "C1"})
+	require.NotNil(t, got)
+	assert.Equal(t, "F1", got.FileKey)
+	assert.Equal(t, "guide.pdf", got.FileName)
+	assert.Empty(t, got.Content)
+
+	assert.Nil(t, svc.lastAttachment(ctx, "ch-1", &IncomingMessage{UserID: "U2", ChatID: "C1"}))
+	assert.Nil(t, svc.lastAttachment(ctx, "ch-2", &IncomingMessage{UserID: "U1", ChatID: "C1"}))
+}
+
+func TestResolveIMRole(t *testing.T) {
+	db := newLifecycleTestDB(t)
+	require.NoError(t, db.AutoMigrate(&IMUserBinding{}))
+	svc := &Service{db: db, tenantMemberService: &stubMembers{members: map[string]*types.TenantMember{
+		"user-admin":     {Role: types.TenantRoleAdmin, Status: types.TenantMemberStatusActive},
+		"user-suspended": {Role: types.TenantRoleOwner, Status: types.TenantMemberStatusSuspended},
+	}}}
+	channel := &IMChannel{ID: "ch-1", TenantID: 7}
+	require.NoError(t, db.Create(&IMUserBinding{TenantID: 7, IMChannelID: "ch-1", IMUserID: "U-admin", UserID: "user-admin"}).Error)
+	require.NoError(t, db.Create(&IMUserBinding{TenantID: 7, IMChannelID: "ch-1", IMUserID: "U-susp", UserID: "user-suspended"}).Error)
+	require.NoError(t, db.Create(&IMUserBinding{TenantID: 7, IMChannelID: "ch-1", IMUserID: "U-gone", UserID: "user-gone"}).Error)
+
+	ctx := context.Background()
+	tests := []struct {
+		imUser    string
+		wantRole  types.TenantRole
+		wantBound bool
+	}{
+		{"U-admin", types.TenantRoleAdmin, true},
+		{"U-susp", types.TenantRoleViewer, true},
+		{"U-gone", types.TenantRoleViewer, true},
+		{"U-unknown", types.TenantRoleViewer, false},
+	}
+	for _, tt := range tests {
+		role, bound := svc.resolveIMRole(ctx, channel, tt.imUser)
+		assert.Equal(t, tt.wantRole, role, tt.imUser)
+		assert.Equal(t, tt.wantBound, bound, tt.imUser)
+	}
+}
+
+func TestChatSettingUpsert(t *testing.T) {
+	db := newLifecycleTestDB(t)
+	require.NoError(t, db.AutoMigrate(&IMChatSetting{}))
+	svc := &Service{db: db}
+	channel := &IMChannel{ID: "ch-1", TenantID: 7, AgentID: "agent-default"}
+	msg := &IncomingMessage{UserID: "U1", ChatID: "C1"}
+	ctx := context.Background()
+
+	assert.Nil(t, svc.loadChatSetting(ctx, "ch-1", "C1"))
+	require.NoError(t, svc.updateChatSetting(ctx, channel, msg, "agent_id", "agent-ops"))
+	require.NoError(t, svc.updateChatSetting(ctx, channel, msg, "knowledge_base_ids", types.StringArray{"kb-1", "kb-2"}))
+
+	setting := svc.loadChatSetting(ctx, "ch-1", "C1")
+	require.NotNil(t, setting)
+	assert.Equal(t, "agent-ops", setting.AgentID)
+	assert.Equal(t, types.StringArray{"kb-1", "kb-2"}, setting.KnowledgeBaseIDs)
+
+	require.NoError(t, svc.updateChatSetting(ctx, channel, msg, "knowledge_base_ids", types.StringArray(nil)))
+	setting = svc.loadChatSetting(ctx, "ch-1", "C1")
+	assert.Equal(t, "agent-ops", setting.AgentID)
+	assert.Empty(t, setting.KnowledgeBaseIDs)
+
+	var count int64
+	db.Model(&IMChatSetting{}).Count(&count)
+	assert.EqualValues(t, 1, count)
+}
+
+type alwaysApprovalChecker struct{}
+
+func (alwaysApprovalChecker) IsRequired(context.Context, uint64, string, string) (bool, error) {
+	return true, nil
+}
+
+// A tool call announced in a chat can be approved there by its requester,
+// but not by another contributor.
+func TestToolApprovalFlow(t *testing.T) {
+	gate := approval.NewGate(nil, alwaysApprovalChecker{}, nil)
+	svc := &Service{approvalGate: gate, chatState: newChatStateStore(nil)}
+	adapter := &recordingReplyAdapter{lifecycleTestAdapter: &lifecycleTestAdapter{}}
+	requester := &IncomingMessage{Platform: "slack", UserID: "U1", ChatID: "C1", ChatType: ChatTypeGroup}
+	owner := types.Principal{Type: types.PrincipalIMUser, ID: "7:ch-1:slack:U1"}
+
+	ctx := types.WithPrincipal(context.Background(), owner)
+	bus := event.NewEventBus()
+	svc.watchToolApprovals(ctx, bus, 7, requester, adapter)
+
+	decisions := make(chan approval.Decision, 1)
+	go func() {
+		d, _ := gate.RequestAndWait(ctx, approval.PendingRequest{
+			TenantID: 7, UserID: owner.StorageID(), EventBus: bus,
+			ServiceName: "jira", MCPToolName: "delete_issue", Args: []byte(`{"key":"OPS-1"}`),
+		})
+		decisions <- d
+	}()
+
+	key := pendingApprovalKey(7, requester)
+	require.Eventually(t, func() bool { return len(svc.listPendingApprovals(context.Background(), key)) == 1 },
+		2*time.Second, 10*time.Millisecond)
+
+	other := testCommandContext(types.TenantRoleContributor, true, ChatTypeGroup)
+	other.Incoming.UserID = "U2"
+	reply := svc.resolveToolApproval(context.Background(), other, &CommandResult{Approved: true})
+	assert.Contains(t, reply, "「管理员」")
+
+	self := testCommandContext(types.TenantRoleContributor, true, ChatTypeGroup)
+	reply = svc.resolveToolApproval(context.Background(), self, &CommandResult{Approved: true})
+	assert.Equal(t, "✅ 已批准工具「jira / delete_issue」。", reply)
+
+	select {
+	case d := <-decisions:
+		assert.True(t, d.Approved)
+	case <-time.After(2 * time.Second):
+		t.Fatal("approval was not delivered")
+	}
+	assert.Empty(t, svc.listPendingApprovals(context.Background(), key))
+	adapter.mu.Lock()
+	defer adapter.mu.Unlock()
+	require.Len(t, adapter.replies, 1)
+	assert.True(t, strings.HasPrefix(adapter.replies[0], "🔐 工具「jira / delete_issue」需要审批后才能执行"))
+
+	assert.Equal(t, "当前没有等待审批的工具调用。",
+		svc.resolveToolApproval(context.Background(), self, &CommandResult{Approved: true}))
+}
@@ -0,0 +1,124 @@
+package im
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+// AgentCommand implements /agent [序号|名称|reset].
+//
+// Without arguments it lists the tenant's agents and marks the one answering
+// in this chat. With an argument it binds another agent to the chat; the
+// switch is per chat and starts a fresh conversation because IM sessions are
+// keyed by agent. Switching requires the contributor role.
+type AgentCommand struct {
+	agentService interfaces.CustomAgentService
+}
+
+func newAgentCommand(agentService interfaces.CustomAgentService) *AgentCommand {
+	return &AgentCommand{agentService: agentService}
+}
+
+func (c *AgentCommand) Name() string { return "agent" }
+func (c *AgentCommand) Description() string {
+	return "查看或切换当前会话的智能体，例如：/agent 2，/agent reset 恢复默认"
+}
+
+func (c *AgentCommand) Execute(ctx context.Context, cmdCtx *CommandContext, args []string) (*CommandResult, error) {
+	agents, err := c.agentService.ListAgents(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list agents: %w", err)
+	}
+	currentID := ""
+	if cmdCtx.CustomAgent != nil {
+		currentID = cmdCtx.CustomAgent.ID
+	}
+
+	if len(args) == 0 {
+		return &CommandResult{Content: formatAgentList(agents, currentID, cmdCtx.ChannelAgentID)}, nil
+	}
+	if deny := requireRole(cmdCtx, types.TenantRoleContributor); deny != nil {
+		return deny, nil
+	}
+
+	if strings.EqualFold(args[0], "reset") {
+		if currentID == cmdCtx.ChannelAgentID {
+			return &CommandResult{Content: "当前已在使用渠道默认智能体。"}, nil
+		}
+		return &CommandResult{
+			Content: "✅ 已恢复为渠道默认智能体，接下来将开始新的对话。",
+			Action:  ActionSwitchAgent,
+		}, nil
+	}
+
+	pick := strings.Join(args, " ")
+	idx := matchOption(pick, len(agents),
+		func(i int) string { return agents[i].ID },
+		func(i int) string { return agents[i].Name })
+	if idx < 0 {
+		return &CommandResult{
+			Content: fmt.Sprintf("未找到智能体「%s」，发送 `/agent` 查看可选列表。", pick),
+		}, nil
+	}
+	agent := agents[idx]
+	if agent.ID == currentID {
+		return &CommandResult{Content: fmt.Sprintf("当前已在使用智能体「%s」。", agent.Name)}, nil
+	}
+	return &CommandResult{
+		Content: fmt.Sprintf("✅ 已切换到智能体「%s」，接下来将开始新的对话。", agent.Name),
+		Action:  ActionSwitchAgent,
+		AgentID: agent.ID,
+	}, nil
+}
+
+func formatAgentList(agents []*types.CustomAgent, currentID, channelAgentID string) string {
+	var sb strings.Builder
+	sb.WriteString("🤖 **可用智能体**\n\n")
+	for i, agent := range agents {
+		sb.WriteString(fmt.Sprintf("%d. %s", i+1, agent.Name))
+		if agent.ID == channelAgentID {
+			sb.WriteString("（渠道默认）")
+		}
+		if agent.ID == currentID {
+			sb.WriteString(" ✅")
+		}
+		sb.WriteString("\n")
+	}
+	if len(agents) == 0 {
+		sb.WriteString("暂无可用智能体\n")
+	}
+	sb.WriteString("\n发送 `/agent <序号或名称>` 切换，`/agent reset` 恢复渠道默认智能体")
+	return sb.String()
+}
+
+// matchOption resolves a user's pick against a listed set of options: a
+// 1-based index into the list, an exact ID, or a case-insensitive name.
+// It returns -1 when nothing matches.
+func matchOption(pick string, n int, id, name func(i int) string) int {
+	pick = strings.TrimSpace(pick)
+	if pick == "" {
+		return -1
+	}
+	if num, err := strconv.Atoi(pick); err == nil {
+		if num >= 1 && num <= n {
+			return num - 1
+		}
+		return -1
+	}
+	for i := 0; i < n; i++ {
+		if id(i) == pick {
+			return i
+		}
+	}
+	for i := 0; i < n; i++ {
+		if strings.EqualFold(name(i), pick) {
+			return i
+		}
+	}
+	return -1
+}
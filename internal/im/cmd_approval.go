@@ -0,0 +1,48 @@
+package im
+
+import (
+	"context"
+	"strconv"
+	"strings"
+)
+
+// ApprovalCommand implements /approve [序号] and /deny [序号] [原因].
+//
+// It resolves a tool call waiting for human approval in this chat. With more
+// than one pending call the user picks one by its number in the list; the
+// Service checks the user's role against the call before resolving it.
+type ApprovalCommand struct {
+	approve bool
+}
+
+func newApprovalCommand(approve bool) *ApprovalCommand {
+	return &ApprovalCommand{approve: approve}
+}
+
+func (c *ApprovalCommand) Name() string {
+	if c.approve {
+		return "approve"
+	}
+	return "deny"
+}
+
+func (c *ApprovalCommand) Description() string {
+	if c.approve {
+		return "批准等待审批的工具调用，多个时附序号，例如：/approve 2"
+	}
+	return "拒绝等待审批的工具调用，可附原因，例如：/deny 1 参数有误"
+}
+
+func (c *ApprovalCommand) Execute(_ context.Context, _ *CommandContext, args []string) (*CommandResult, error) {
+	result := &CommandResult{Action: ActionResolveApproval, Approved: c.approve}
+	if len(args) > 0 {
+		if n, err := strconv.Atoi(args[0]); err == nil && n > 0 {
+			result.ApprovalIndex = n
+			args = args[1:]
+		}
+	}
+	if !c.approve {
+		result.Comment = strings.Join(args, " ")
+	}
+	return result, nil
+}
@@ -0,0 +1,21 @@
+package im
+
+import "context"
+
+// ArchiveCommand implements /archive.
+// It saves the current group thread — every message, not just the bot's
+// answers — to the knowledge base configured for thread archiving on this
+// channel. The Service performs the archive; the command only declares intent.
+type ArchiveCommand struct{}
+
+func newArchiveCommand() *ArchiveCommand { return &ArchiveCommand{} }
+
+func (c *ArchiveCommand) Name() string        { return "archive" }
+func (c *ArchiveCommand) Description() string { return "将当前群聊话题归档到知识库" }
+
+func (c *ArchiveCommand) Execute(_ context.Context, _ *CommandContext, _ []string) (*CommandResult, error) {
+	return &CommandResult{
+		Content: threadArchiveStartedNotice,
+		Action:  ActionArchive,
+	}, nil
+}
@@ -0,0 +1,33 @@
+package im
+
+import (
+	"context"
+	"strings"
+)
+
+// FeedbackCommand implements /good [备注] and /bad [备注].
+// It rates the bot's latest answer in the current conversation; the Service
+// looks the answer up and records the rating.
+type FeedbackCommand struct {
+	rating string
+}
+
+func newFeedbackCommand(rating string) *FeedbackCommand {
+	return &FeedbackCommand{rating: rating}
+}
+
+func (c *FeedbackCommand) Name() string { return c.rating }
+func (c *FeedbackCommand) Description() string {
+	if c.rating == FeedbackRatingGood {
+		return "标记上一条回答有帮助，可附备注，例如：/good 很准确"
+	}
+	return "标记上一条回答没有帮助，可附备注，例如：/bad 引用的文档已过期"
+}
+
+func (c *FeedbackCommand) Execute(_ context.Context, _ *CommandContext, args []string) (*CommandResult, error) {
+	return &CommandResult{
+		Action:  ActionFeedback,
+		Rating:  c.rating,
+		Comment: strings.Join(args, " "),
+	}, nil
+}
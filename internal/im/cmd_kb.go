@@ -0,0 +1,121 @@
+package im
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+// KBCommand implements /kb [序号|名称 …|reset].
+//
+// Without arguments it shows the chat's knowledge-base scope and the tenant's
+// knowledge bases. With arguments it limits the chat's next questions to the
+// chosen knowledge bases, overriding the agent's own selection until reset.
+// Scoping a group chat affects everyone in it and requires the contributor
+// role; any user may scope their direct chat.
+type KBCommand struct {
+	kbService interfaces.KnowledgeBaseService
+}
+
+func newKBCommand(kbService interfaces.KnowledgeBaseService) *KBCommand {
+	return &KBCommand{kbService: kbService}
+}
+
+func (c *KBCommand) Name() string { return "kb" }
+func (c *KBCommand) Description() string {
+	return "限定后续提问检索的知识库（可多选），例如：/kb 1 3，/kb reset 取消限定"
+}
+
+func (c *KBCommand) Execute(ctx context.Context, cmdCtx *CommandContext, args []string) (*CommandResult, error) {
+	kbs, err := listVisibleKnowledgeBases(ctx, c.kbService)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(args) == 0 {
+		return &CommandResult{Content: formatKBScope(kbs, cmdCtx.KnowledgeBaseIDs)}, nil
+	}
+	if cmdCtx.Incoming.ChatType != ChatTypeDirect {
+		if deny := requireRole(cmdCtx, types.TenantRoleContributor); deny != nil {
+			return deny, nil
+		}
+	}
+
+	if strings.EqualFold(args[0], "reset") {
+		if len(cmdCtx.KnowledgeBaseIDs) == 0 {
+			return &CommandResult{Content: "当前未限定知识库，由智能体自行决定检索范围。"}, nil
+		}
+		return &CommandResult{
+			Content: "✅ 已取消知识库限定，后续提问由智能体自行决定检索范围。",
+			Action:  ActionScopeKnowledgeBases,
+		}, nil
+	}
+
+	var ids, names []string
+	seen := make(map[string]bool)
+	for _, arg := range args {
+		idx := matchOption(arg, len(kbs),
+			func(i int) string { return kbs[i].ID },
+			func(i int) string { return kbs[i].Name })
+		if idx < 0 {
+			return &CommandResult{
+				Content: fmt.Sprintf("未找到知识库「%s」，发送 `/kb` 查看可选列表。", arg),
+			}, nil
+		}
+		if seen[kbs[idx].ID] {
+			continue
+		}
+		seen[kbs[idx].ID] = true
+		ids = append(ids, kbs[idx].ID)
+		names = append(names, kbs[idx].Name)
+	}
+	return &CommandResult{
+		Content:          fmt.Sprintf("✅ 后续提问将只检索：%s", strings.Join(names, "、")),
+		Action:           ActionScopeKnowledgeBases,
+		KnowledgeBaseIDs: ids,
+	}, nil
+}
+
+// listVisibleKnowledgeBases lists the tenant's knowledge bases, leaving out
+// temporary ones that are hidden from the UI as well.
+func listVisibleKnowledgeBases(ctx context.Context, kbService interfaces.KnowledgeBaseService) ([]*types.KnowledgeBase, error) {
+	all, err := kbService.ListKnowledgeBases(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list knowledge bases: %w", err)
+	}
+	kbs := make([]*types.KnowledgeBase, 0, len(all))
+	for _, kb := range all {
+		if !kb.IsTemporary {
+			kbs = append(kbs, kb)
+		}
+	}
+	return kbs, nil
+}
+
+func formatKBScope(kbs []*types.KnowledgeBase, scope []string) string {
+	inScope := make(map[string]bool, len(scope))
+	for _, id := range scope {
+		inScope[id] = true
+	}
+
+	var sb strings.Builder
+	sb.WriteString("📚 **知识库**\n\n")
+	if len(scope) == 0 {
+		sb.WriteString("当前未限定，由智能体自行决定检索范围\n\n")
+	}
+	for i, kb := range kbs {
+		sb.WriteString(fmt.Sprintf("%d. %s", i+1, kb.Name))
+		if inScope[kb.ID] {
+			sb.WriteString(" ✅")
+		}
+		sb.WriteString("\n")
+	}
+	if len(kbs) == 0 {
+		sb.WriteString("暂无知识库\n")
+	}
+	sb.WriteString("\n发送 `/kb <序号或名称>` 限定后续提问的知识库（可多选，空格分隔），`/kb reset` 取消限定")
+	return sb.String()
+}
@@ -0,0 +1,73 @@
+package im
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+// SaveCommand implements /save <序号|名称>.
+//
+// It files the last attachment the user sent in this chat into a document
+// knowledge base of their choice. The Service looks the attachment up and
+// performs the upload; the command only picks the target and checks that the
+// user holds the contributor role.
+type SaveCommand struct {
+	kbService interfaces.KnowledgeBaseService
+}
+
+func newSaveCommand(kbService interfaces.KnowledgeBaseService) *SaveCommand {
+	return &SaveCommand{kbService: kbService}
+}
+
+func (c *SaveCommand) Name() string { return "save" }
+func (c *SaveCommand) Description() string {
+	return "将你最近发送的文件保存到知识库，例如：/save 1"
+}
+
+func (c *SaveCommand) Execute(ctx context.Context, cmdCtx *CommandContext, args []string) (*CommandResult, error) {
+	if deny := requireRole(cmdCtx, types.TenantRoleContributor); deny != nil {
+		return deny, nil
+	}
+	all, err := listVisibleKnowledgeBases(ctx, c.kbService)
+	if err != nil {
+		return nil, err
+	}
+	kbs := make([]*types.KnowledgeBase, 0, len(all))
+	for _, kb := range all {
+		if kb.Type == "" || kb.Type == types.KnowledgeBaseTypeDocument {
+			kbs = append(kbs, kb)
+		}
+	}
+
+	if len(args) == 0 {
+		var sb strings.Builder
+		sb.WriteString("📥 **选择要保存到的知识库**\n\n")
+		for i, kb := range kbs {
+			sb.WriteString(fmt.Sprintf("%d. %s\n", i+1, kb.Name))
+		}
+		if len(kbs) == 0 {
+			sb.WriteString("暂无文档知识库\n")
+		}
+		sb.WriteString("\n先发送文件，再发送 `/save <序号或名称>` 将其保存到对应知识库")
+		return &CommandResult{Content: sb.String()}, nil
+	}
+
+	pick := strings.Join(args, " ")
+	idx := matchOption(pick, len(kbs),
+		func(i int) string { return kbs[i].ID },
+		func(i int) string { return kbs[i].Name })
+	if idx < 0 {
+		return &CommandResult{
+			Content: fmt.Sprintf("未找到文档知识库「%s」，发送 `/save` 查看可选列表。", pick),
+		}, nil
+	}
+	return &CommandResult{
+		Content:         fmt.Sprintf("正在保存到知识库「%s」…", kbs[idx].Name),
+		Action:          ActionSaveAttachment,
+		KnowledgeBaseID: kbs[idx].ID,
+	}, nil
+}
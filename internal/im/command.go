@@ -2,6 +2,7 @@ package im
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/Tencent/WeKnora/internal/types"
 )
@@ -19,6 +20,23 @@ const (
 	ActionClear
 	// ActionStop cancels the in-flight QA request for this user+chat.
 	ActionStop
+	// ActionArchive archives the current group thread into the channel's
+	// archive knowledge base.
+	ActionArchive
+	// ActionSwitchAgent binds CommandResult.AgentID to this chat; an empty
+	// ID restores the channel's default agent.
+	ActionSwitchAgent
+	// ActionScopeKnowledgeBases limits the chat's next questions to
+	// CommandResult.KnowledgeBaseIDs; an empty list clears the scope.
+	ActionScopeKnowledgeBases
+	// ActionSaveAttachment saves the user's most recent attachment in this
+	// chat into CommandResult.KnowledgeBaseID.
+	ActionSaveAttachment
+	// ActionFeedback rates the bot's latest answer in this session.
+	ActionFeedback
+	// ActionResolveApproval approves or denies a pending tool approval of
+	// this chat.
+	ActionResolveApproval
 )
 
 // CommandResult is the output produced by a Command.Execute call.
@@ -27,6 +45,23 @@ type CommandResult struct {
 	Content string
 	// Action requests a service-level side effect (reset, clear, …).
 	Action CommandAction
+
+	// The fields below carry the arguments of the action that uses them.
+
+	// AgentID is the agent to bind (ActionSwitchAgent).
+	AgentID string
+	// KnowledgeBaseIDs is the knowledge-base scope (ActionScopeKnowledgeBases).
+	KnowledgeBaseIDs []string
+	// KnowledgeBaseID is the target knowledge base (ActionSaveAttachment).
+	KnowledgeBaseID string
+	// Rating and Comment describe the feedback (ActionFeedback).
+	Rating  string
+	Comment string
+	// Approved and ApprovalIndex select the decision and the 1-based pending
+	// approval it applies to, 0 meaning "the only one" (ActionResolveApproval).
+	// Comment doubles as the denial reason.
+	Approved      bool
+	ApprovalIndex int
 }
 
 // CommandContext carries all runtime data a command needs during execution.
@@ -47,6 +82,46 @@ type CommandContext struct {
 	// ChannelOutputMode is the channel-level output mode configured by the admin
 	// ("stream" or "full").
 	ChannelOutputMode string
+	// ChannelAgentID is the agent configured on the channel, which a chat can
+	// override with /agent.
+	ChannelAgentID string
+	// KnowledgeBaseIDs is the chat's /kb scope (empty when not scoped).
+	KnowledgeBaseIDs []string
+	// Role is the tenant role of the member the IM user is bound to, or
+	// viewer for unbound users.
+	Role types.TenantRole
+	// Bound reports whether the IM user is bound to a tenant member.
+	Bound bool
+}
+
+// requireRole returns a refusal reply when the IM user's role is below
+// required, or nil when the command may proceed.
+func requireRole(cmdCtx *CommandContext, required types.TenantRole) *CommandResult {
+	if cmdCtx.Role.HasPermission(required) {
+		return nil
+	}
+	content := fmt.Sprintf("⛔ 此操作需要「%s」及以上权限。", roleLabel(required))
+	if !cmdCtx.Bound {
+		content += fmt.Sprintf("\n你的 IM 账号尚未绑定租户成员，请将用户 ID `%s` 发给管理员，在 IM 渠道设置中完成绑定。",
+			cmdCtx.Incoming.UserID)
+	} else {
+		content += fmt.Sprintf("\n你当前的角色是「%s」。", roleLabel(cmdCtx.Role))
+	}
+	return &CommandResult{Content: content}
+}
+
+// roleLabel is the Chinese display name of a tenant role.
+func roleLabel(role types.TenantRole) string {
+	switch role {
+	case types.TenantRoleOwner:
+		return "所有者"
+	case types.TenantRoleAdmin:
+		return "管理员"
+	case types.TenantRoleContributor:
+		return "编辑"
+	default:
+		return "访客"
+	}
 }
 
 // Command is the interface every IM slash-command must implement.
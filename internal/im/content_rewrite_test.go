@@ -152,7 +152,7 @@ func TestRewriteStorageURLs_LocalUsesPresignedAPI(t *testing.T) {
 	}
 
 	in := "![img](local://10000/exports/abc.png)"
-	out := rewriteStorageURLs(context.Background(), in, newIMFileServiceResolver(tenant, nil))
+	out := rewriteStorageURLs(context.Background(), in, newIMFileServiceResolver(tenant, nil, nil))
 	assert.Contains(t, out, "/api/v1/files/presigned")
 	assert.NotContains(t, out, "myqcloud.com")
 }
@@ -177,7 +177,7 @@ func TestRewriteStorageURLs_COSPathNotSignedAsLocalKey(t *testing.T) {
 	require.NotNil(t, svc)
 
 	in := "![img](" + path + ")"
-	out := rewriteStorageURLs(context.Background(), in, newIMFileServiceResolver(tenant, nil))
+	out := rewriteStorageURLs(context.Background(), in, newIMFileServiceResolver(tenant, nil, nil))
 	if out != in {
 		assert.False(t, strings.Contains(out, "local%3A"), "COS URL must not treat local:// as object key")
 	}
@@ -370,7 +370,7 @@ func TestCleanIMContent_AfterStreamReassembly(t *testing.T) {
 	sent, rem := simulateIMStreamFlush(parts)
 	require.Empty(t, rem)
 	joined := strings.Join(sent, "")
-	out := cleanIMContent(context.Background(), joined, tenant, nil)
+	out := cleanIMContent(context.Background(), joined, tenant, nil, nil)
 	assert.Contains(t, out, "/api/v1/files/presigned")
 	assert.NotContains(t, out, "local://10000")
 }
@@ -408,7 +408,7 @@ func TestRewriteStorageURLs_MultipleImagesInOneChunk(t *testing.T) {
 		`![知识助理"知识库"管理视图界面](local://10000/exports/c91cf852.png)` + "\n\n### 3\n\n" +
 		"![c](local://10000/exports/a0423e91.png)\n"
 
-	out := rewriteStorageURLs(context.Background(), doc, newIMFileServiceResolver(tenant, nil))
+	out := rewriteStorageURLs(context.Background(), doc, newIMFileServiceResolver(tenant, nil, nil))
 	assert.NotContains(t, out, "local://")
 	assert.Equal(t, 3, strings.Count(out, "/api/v1/files/presigned"))
 }
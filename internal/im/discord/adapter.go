@@ -0,0 +1,584 @@
+package discord
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Tencent/WeKnora/internal/im"
+	"github.com/Tencent/WeKnora/internal/logger"
+)
+
+// Compile-time checks.
+var (
+	_ im.Adapter        = (*Adapter)(nil)
+	_ im.StreamSender   = (*Adapter)(nil)
+	_ im.FileDownloader = (*Adapter)(nil)
+)
+
+// askCommandName is the slash command whose options are sent as a question.
+// Any other command is forwarded as "/<name> <args>" to the IM command system.
+const askCommandName = "ask"
+
+// minEditInterval keeps stream edits under Discord's per-channel message
+// rate limit (5 requests per 5 seconds).
+const minEditInterval = 1200 * time.Millisecond
+
+// maxSignatureAge bounds the replay window of interaction callbacks.
+const maxSignatureAge = 5 * time.Minute
+
+// Adapter implements im.Adapter for Discord. Messages arrive through the
+// Gateway (websocket mode) or as slash command interactions (webhook mode);
+// replies always go out through the REST API.
+type Adapter struct {
+	client    *Client
+	publicKey ed25519.PublicKey
+	threads   *threadRegistry
+	// threadMode opens a Discord thread on each top-level trigger message
+	// and answers there, so each thread maps to one session.
+	threadMode bool
+
+	streamsMu sync.Mutex
+	streams   map[string]*streamState
+}
+
+// NewAdapter creates a Discord adapter for Gateway mode.
+func NewAdapter(client *Client, threads *threadRegistry, threadMode bool) *Adapter {
+	return &Adapter{
+		client:     client,
+		threads:    threads,
+		threadMode: threadMode,
+		streams:    map[string]*streamState{},
+	}
+}
+
+// NewInteractionsAdapter creates a Discord adapter for the interactions
+// endpoint (webhook mode). publicKey is the application's hex Ed25519 key.
+func NewInteractionsAdapter(client *Client, publicKeyHex string) (*Adapter, error) {
+	key, err := hex.DecodeString(strings.TrimSpace(publicKeyHex))
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("discord public_key must be the application's hex-encoded Ed25519 public key")
+	}
+	a := NewAdapter(client, newThreadRegistry(), false)
+	a.publicKey = ed25519.PublicKey(key)
+	return a, nil
+}
+
+func (a *Adapter) Platform() im.Platform {
+	return im.PlatformDiscord
+}
+
+// HandleURLVerification answers the PING Discord sends when the interactions
+// endpoint URL is saved (and periodically, with bad signatures, to check
+// that verification is enforced).
+func (a *Adapter) HandleURLVerification(c *gin.Context) bool {
+	if a.publicKey == nil {
+		return false
+	}
+	body, err := readBody(c)
+	if err != nil {
+		return false
+	}
+	var probe struct {
+		Type int `json:"type"`
+	}
+	if json.Unmarshal(body, &probe) != nil || probe.Type != interactionPing {
+		return false
+	}
+	if err := a.verifySignature(c, body, time.Now()); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid request signature"})
+		return true
+	}
+	c.JSON(http.StatusOK, gin.H{"type": callbackPong})
+	return true
+}
+
+func (a *Adapter) VerifyCallback(c *gin.Context) error {
+	if a.publicKey == nil {
+		return fmt.Errorf("discord channel is not in webhook mode")
+	}
+	body, err := readBody(c)
+	if err != nil {
+		return fmt.Errorf("read body: %w", err)
+	}
+	return a.verifySignature(c, body, time.Now())
+}
+
+func (a *Adapter) verifySignature(c *gin.Context, body []byte, now time.Time) error {
+	sig, err := hex.DecodeString(c.GetHeader("X-Signature-Ed25519"))
+	if err != nil || len(sig) != ed25519.SignatureSize {
+		return fmt.Errorf("missing or malformed signature")
+	}
+	timestamp := c.GetHeader("X-Signature-Timestamp")
+	secs, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("missing or malformed signature timestamp")
+	}
+	if age := now.Sub(time.Unix(secs, 0)); age > maxSignatureAge || age < -maxSignatureAge {
+		return fmt.Errorf("signature timestamp out of range")
+	}
+	if !ed25519.Verify(a.publicKey, append([]byte(timestamp), body...), sig) {
+		return fmt.Errorf("invalid signature")
+	}
+	return nil
+}
+
+func (a *Adapter) ParseCallback(c *gin.Context) (*im.IncomingMessage, error) {
+	body, err := readBody(c)
+	if err != nil {
+		return nil, fmt.Errorf("read body: %w", err)
+	}
+	var in interaction
+	if err := json.Unmarshal(body, &in); err != nil {
+		return nil, fmt.Errorf("parse interaction: %w", err)
+	}
+	return parseInteraction(&in), nil
+}
+
+func readBody(c *gin.Context) ([]byte, error) {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return nil, err
+	}
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
+
+// parseInteraction converts a slash command interaction. /ask sends its
+// options as the question; other commands become "/name args" so the IM
+// command registry (/help, /clear, /stop, ...) handles them.
+func parseInteraction(in *interaction) *im.IncomingMessage {
+	if in.Type != interactionApplicationCommand || in.Data == nil {
+		return nil
+	}
+	var user *discordUser
+	if in.Member != nil {
+		user = &in.Member.User
+	} else if in.User != nil {
+		user = in.User
+	}
+	if user == nil {
+		return nil
+	}
+
+	var args []string
+	var attachment *discordAttachment
+	for _, opt := range in.Data.Options {
+		switch opt.Type {
+		case optionString:
+			var s string
+			if json.Unmarshal(opt.Value, &s) == nil && strings.TrimSpace(s) != "" {
+				args = append(args, strings.TrimSpace(s))
+			}
+		case optionAttachment:
+			var id string
+			if json.Unmarshal(opt.Value, &id) == nil && in.Data.Resolved != nil {
+				if att, ok := in.Data.Resolved.Attachments[id]; ok {
+					attachment = &att
+				}
+			}
+		}
+	}
+	content := strings.Join(args, " ")
+	if in.Data.Name != askCommandName {
+		content = strings.TrimSpace("/" + in.Data.Name + " " + content)
+	}
+
+	inThread := in.Channel != nil && isThreadType(in.Channel.Type)
+	msg := &im.IncomingMessage{
+		Platform:    im.PlatformDiscord,
+		MessageType: im.MessageTypeText,
+		UserID:      user.ID,
+		UserName:    user.displayName(),
+		ChatType:    im.ChatTypeDirect,
+		Content:     content,
+		MessageID:   in.ID,
+		Extra: map[string]string{
+			extraKeyChannelID:        in.ChannelID,
+			extraKeyInteractionToken: in.Token,
+			extraKeyApplicationID:    in.ApplicationID,
+		},
+	}
+	if in.GuildID != "" {
+		msg.ChatType = im.ChatTypeGroup
+		msg.ChatID = in.ChannelID
+		msg.ThreadID = in.ID
+		if inThread {
+			msg.ThreadID = in.ChannelID
+			if in.Channel.ParentID != "" {
+				msg.ChatID = in.Channel.ParentID
+			}
+		}
+	}
+	if attachment != nil {
+		applyAttachment(msg, attachment)
+	}
+	if msg.Content == "" && msg.FileKey == "" {
+		return nil
+	}
+	return msg
+}
+
+// parseMessage converts a Gateway MESSAGE_CREATE. In servers the bot only
+// answers when mentioned, when replied to, or inside a thread it opened.
+func parseMessage(ev *messageEvent, botUserID string, threads *threadRegistry) *im.IncomingMessage {
+	if ev == nil || ev.Author.Bot || ev.Author.ID == "" || ev.Author.ID == botUserID {
+		return nil
+	}
+	thread, known := threads.lookup(ev.ChannelID)
+	inThread := known || ev.Position != nil
+
+	if ev.GuildID != "" {
+		addressed := known && botUserID != "" && thread.OwnerID == botUserID
+		for _, m := range ev.Mentions {
+			if m.ID == botUserID {
+				addressed = true
+			}
+		}
+		if ev.ReferencedMsg != nil && ev.ReferencedMsg.Author.ID == botUserID {
+			addressed = true
+		}
+		if !addressed {
+			return nil
+		}
+	}
+
+	msg := &im.IncomingMessage{
+		Platform:    im.PlatformDiscord,
+		MessageType: im.MessageTypeText,
+		UserID:      ev.Author.ID,
+		UserName:    ev.Author.displayName(),
+		ChatType:    im.ChatTypeDirect,
+		Content:     stripBotMention(ev.Content, botUserID),
+		MessageID:   ev.ID,
+		Extra: map[string]string{
+			extraKeyChannelID: ev.ChannelID,
+		},
+	}
+	if ev.GuildID != "" {
+		// A thread and its parent channel are one chat; the thread started
+		// from a message shares that message's ID, so the top-level trigger
+		// and the follow-ups inside its thread resolve to the same ThreadID.
+		msg.ChatType = im.ChatTypeGroup
+		msg.ChatID = ev.ChannelID
+		msg.ThreadID = ev.ID
+		if inThread {
+			msg.ThreadID = ev.ChannelID
+			msg.Extra[extraKeyInThread] = "true"
+			if thread.ParentID != "" {
+				msg.ChatID = thread.ParentID
+			}
+		}
+	}
+	if len(ev.Attachments) > 0 {
+		applyAttachment(msg, &ev.Attachments[0])
+	}
+	if ref := ev.ReferencedMsg; ref != nil {
+		quote := &im.QuotedMessage{
+			MessageID:    ref.ID,
+			Content:      stripBotMention(ref.Content, botUserID),
+			SenderID:     ref.Author.ID,
+			IsBotMessage: ref.Author.ID == botUserID,
+		}
+		if quote.Content == "" && len(ref.Attachments) > 0 {
+			quote.NonTextType = "file"
+			if strings.HasPrefix(ref.Attachments[0].ContentType, "image/") {
+				quote.NonTextType = "image"
+			}
+		}
+		msg.Quote = quote
+	}
+	if msg.Content == "" && msg.FileKey == "" {
+		return nil
+	}
+	return msg
+}
+
+func applyAttachment(msg *im.IncomingMessage, att *discordAttachment) {
+	msg.MessageType = im.MessageTypeFile
+	if strings.HasPrefix(att.ContentType, "image/") {
+		msg.MessageType = im.MessageTypeImage
+	}
+	msg.FileKey = att.URL
+	msg.FileName = att.Filename
+	msg.FileSize = att.Size
+}
+
+func stripBotMention(content, botUserID string) string {
+	if botUserID != "" {
+		content = strings.ReplaceAll(content, "<@"+botUserID+">", "")
+		content = strings.ReplaceAll(content, "<@!"+botUserID+">", "")
+	}
+	return strings.TrimSpace(content)
+}
+
+func isThreadType(t int) bool {
+	return t == channelAnnouncementThread || t == channelPublicThread || t == channelPrivateThread
+}
+
+// ── Send reply ──
+
+func (a *Adapter) SendReply(ctx context.Context, incoming *im.IncomingMessage, reply *im.ReplyMessage) error {
+	parts := im.SplitIMMessage(im.FormatIMDisplayContent(reply.Content, im.StreamDisplayFinal), maxMessageRunes)
+	if token := incoming.Extra[extraKeyInteractionToken]; token != "" {
+		return a.sendInteractionParts(ctx, incoming.Extra[extraKeyApplicationID], token, parts)
+	}
+	channelID, replyTo, err := a.resolveTarget(ctx, incoming)
+	if err != nil {
+		return err
+	}
+	for i, part := range parts {
+		if i > 0 {
+			replyTo = ""
+		}
+		if _, err := a.client.CreateMessage(ctx, channelID, part, replyTo); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (a *Adapter) sendInteractionParts(ctx context.Context, appID, token string, parts []string) error {
+	if err := a.client.EditOriginalResponse(ctx, appID, token, parts[0]); err != nil {
+		return err
+	}
+	for _, part := range parts[1:] {
+		if err := a.client.CreateFollowup(ctx, appID, token, part); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resolveTarget returns the channel to answer in and the message to reply
+// to. In thread mode a top-level server message gets its own thread first;
+// if that fails (missing permission, DM-like channel) the answer falls back
+// to a reply in the channel.
+func (a *Adapter) resolveTarget(ctx context.Context, incoming *im.IncomingMessage) (string, string, error) {
+	channelID := incoming.Extra[extraKeyChannelID]
+	if channelID == "" {
+		return "", "", fmt.Errorf("missing discord channel_id")
+	}
+	if !a.threadMode || incoming.ChatType != im.ChatTypeGroup || incoming.Extra[extraKeyInThread] == "true" {
+		return channelID, incoming.MessageID, nil
+	}
+	if _, ok := a.threads.lookup(incoming.MessageID); ok {
+		return incoming.MessageID, "", nil
+	}
+	threadID, err := a.client.StartThread(ctx, channelID, incoming.MessageID, threadName(incoming.Content))
+	if err != nil {
+		logger.Warnf(ctx, "[Discord] Start thread failed, replying in channel: %v", err)
+		return channelID, incoming.MessageID, nil
+	}
+	a.threads.add(threadChannel{ID: threadID, ParentID: channelID, OwnerID: a.threads.botID()})
+	return threadID, "", nil
+}
+
+func threadName(content string) string {
+	name := strings.TrimSpace(strings.SplitN(content, "\n", 2)[0])
+	if name == "" {
+		return "WeKnora"
+	}
+	if runes := []rune(name); len(runes) > maxThreadNameRunes {
+		name = string(runes[:maxThreadNameRunes-1]) + "…"
+	}
+	return name
+}
+
+// ── StreamSender implementation (edit message in-place) ──
+
+type streamState struct {
+	mu        sync.Mutex
+	channelID string
+	messageID string
+	// appID and token are set for interaction replies, which edit the
+	// deferred original response instead of a channel message.
+	appID    string
+	token    string
+	lastEdit time.Time
+}
+
+func (s *streamState) edit(ctx context.Context, client *Client, content string) error {
+	if s.token != "" {
+		return client.EditOriginalResponse(ctx, s.appID, s.token, content)
+	}
+	return client.EditMessage(ctx, s.channelID, s.messageID, content)
+}
+
+func (s *streamState) followup(ctx context.Context, client *Client, content string) error {
+	if s.token != "" {
+		return client.CreateFollowup(ctx, s.appID, s.token, content)
+	}
+	_, err := client.CreateMessage(ctx, s.channelID, content, "")
+	return err
+}
+
+func (a *Adapter) StartStream(ctx context.Context, incoming *im.IncomingMessage) (string, error) {
+	state := &streamState{}
+	var streamID string
+	if token := incoming.Extra[extraKeyInteractionToken]; token != "" {
+		// The deferred response already shows "thinking…" in Discord.
+		state.appID, state.token = incoming.Extra[extraKeyApplicationID], token
+		streamID = "interaction:" + incoming.MessageID
+	} else {
+		channelID, replyTo, err := a.resolveTarget(ctx, incoming)
+		if err != nil {
+			return "", err
+		}
+		messageID, err := a.client.CreateMessage(ctx, channelID, "正在思考...", replyTo)
+		if err != nil {
+			return "", fmt.Errorf("discord start stream: %w", err)
+		}
+		state.channelID, state.messageID = channelID, messageID
+		streamID = channelID + ":" + messageID
+	}
+
+	a.streamsMu.Lock()
+	a.streams[streamID] = state
+	a.streamsMu.Unlock()
+
+	logger.Infof(ctx, "[Discord] Streaming started: stream_id=%s", streamID)
+	return streamID, nil
+}
+
+func (a *Adapter) getStream(streamID string) (*streamState, error) {
+	a.streamsMu.Lock()
+	defer a.streamsMu.Unlock()
+	state, ok := a.streams[streamID]
+	if !ok {
+		return nil, fmt.Errorf("unknown stream ID: %s", streamID)
+	}
+	return state, nil
+}
+
+func (a *Adapter) UpdateStreamContent(ctx context.Context, incoming *im.IncomingMessage, streamID string, fullContent string) error {
+	if fullContent == "" {
+		return nil
+	}
+	state, err := a.getStream(streamID)
+	if err != nil {
+		return err
+	}
+
+	state.mu.Lock()
+	if time.Since(state.lastEdit) < minEditInterval {
+		state.mu.Unlock()
+		return nil
+	}
+	state.lastEdit = time.Now()
+	state.mu.Unlock()
+
+	if err := state.edit(ctx, a.client, im.ClampIMStreamContent(fullContent, maxMessageRunes)); err != nil {
+		logger.Warnf(ctx, "[Discord] Failed to update stream content: %v", err)
+	}
+	return nil
+}
+
+// FinalizeStream puts the first part of the answer into the stream message
+// and sends whatever exceeds Discord's 2000 character limit as follow-ups.
+func (a *Adapter) FinalizeStream(ctx context.Context, incoming *im.IncomingMessage, streamID string, finalContent string) error {
+	state, err := a.getStream(streamID)
+	if err != nil {
+		return err
+	}
+	parts := im.SplitIMMessage(finalContent, maxMessageRunes)
+	if err := state.edit(ctx, a.client, parts[0]); err != nil {
+		logger.Warnf(ctx, "[Discord] Failed to finalize stream: %v", err)
+	}
+	for _, part := range parts[1:] {
+		if err := state.followup(ctx, a.client, part); err != nil {
+			logger.Warnf(ctx, "[Discord] Failed to send overflow part: %v", err)
+			break
+		}
+	}
+	return nil
+}
+
+func (a *Adapter) EndStream(ctx context.Context, incoming *im.IncomingMessage, streamID string) error {
+	a.streamsMu.Lock()
+	_, ok := a.streams[streamID]
+	delete(a.streams, streamID)
+	a.streamsMu.Unlock()
+	if ok {
+		logger.Infof(ctx, "[Discord] Streaming ended: stream_id=%s", streamID)
+	}
+	return nil
+}
+
+// ── FileDownloader implementation ──
+
+func (a *Adapter) DownloadFile(ctx context.Context, msg *im.IncomingMessage) (io.ReadCloser, string, error) {
+	if msg.FileKey == "" {
+		return nil, "", fmt.Errorf("file_key is required")
+	}
+	rc, err := a.client.Download(ctx, msg.FileKey)
+	if err != nil {
+		return nil, "", err
+	}
+	name := msg.FileName
+	if name == "" {
+		name = "attachment"
+	}
+	return rc, name, nil
+}
+
+// ── Thread registry ──
+
+// maxTrackedThreads bounds the registry; it is rebuilt from Gateway events
+// (GUILD_CREATE, THREAD_LIST_SYNC) after a reset.
+const maxTrackedThreads = 10000
+
+// threadRegistry remembers threads seen on the Gateway or opened by the bot,
+// so messages inside a thread can be tied to the parent channel and to the
+// bot's own threads without a REST lookup per message.
+type threadRegistry struct {
+	mu        sync.Mutex
+	threads   map[string]threadChannel
+	botUserID string
+}
+
+func newThreadRegistry() *threadRegistry {
+	return &threadRegistry{threads: map[string]threadChannel{}}
+}
+
+func (r *threadRegistry) add(t threadChannel) {
+	if t.ID == "" {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.threads) >= maxTrackedThreads {
+		r.threads = map[string]threadChannel{}
+	}
+	r.threads[t.ID] = t
+}
+
+func (r *threadRegistry) lookup(id string) (threadChannel, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	t, ok := r.threads[id]
+	return t, ok
+}
+
+func (r *threadRegistry) setBotID(id string) {
+	r.mu.Lock()
+	r.botUserID = id
+	r.mu.Unlock()
+}
+
+func (r *threadRegistry) botID() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.botUserID
+}
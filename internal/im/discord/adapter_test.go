@@ -0,0 +1,356 @@
+package discord
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Tencent/WeKnora/internal/im"
+	secutils "github.com/Tencent/WeKnora/internal/utils"
+)
+
+const testBotID = "1288000000000000001"
+
+func loadFixture(t *testing.T, name string, out any) []byte {
+	t.Helper()
+	raw, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != nil {
+		if err := json.Unmarshal(raw, out); err != nil {
+			t.Fatalf("decode %s: %v", name, err)
+		}
+	}
+	return raw
+}
+
+func TestParseMessage_GuildMention(t *testing.T) {
+	var ev messageEvent
+	loadFixture(t, "message_create_mention.json", &ev)
+
+	msg := parseMessage(&ev, testBotID, newThreadRegistry())
+	if msg == nil {
+		t.Fatal("expected message")
+	}
+	if msg.Content != "What is our refund policy for annual plans?" {
+		t.Errorf("Content = %q, mention not stripped", msg.Content)
+	}
+	if msg.ChatType != im.ChatTypeGroup || msg.ChatID != "1180000000000000042" {
+		t.Errorf("chat = %s/%s", msg.ChatType, msg.ChatID)
+	}
+	// Top-level: ThreadID is the message ID, which a thread opened on it reuses.
+	if msg.ThreadID != ev.ID {
+		t.Errorf("ThreadID = %q, want %q", msg.ThreadID, ev.ID)
+	}
+	if msg.UserName != "Alice Wang" {
+		t.Errorf("UserName = %q", msg.UserName)
+	}
+
+	// The same message without the mention is not addressed to the bot.
+	ev.Mentions = nil
+	if parseMessage(&ev, testBotID, newThreadRegistry()) != nil {
+		t.Error("unaddressed server message must be ignored")
+	}
+}
+
+func TestParseMessage_ThreadFollowUp(t *testing.T) {
+	var ev messageEvent
+	loadFixture(t, "message_create_thread.json", &ev)
+
+	// Unknown thread without mention: ignored.
+	if parseMessage(&ev, testBotID, newThreadRegistry()) != nil {
+		t.Fatal("message in a foreign thread must be ignored")
+	}
+
+	threads := newThreadRegistry()
+	threads.add(threadChannel{ID: ev.ChannelID, ParentID: "1180000000000000042", OwnerID: testBotID})
+	msg := parseMessage(&ev, testBotID, threads)
+	if msg == nil {
+		t.Fatal("expected message in the bot's thread")
+	}
+	if msg.ThreadID != "1290233813960327209" {
+		t.Errorf("ThreadID = %q, want thread channel", msg.ThreadID)
+	}
+	if msg.ChatID != "1180000000000000042" {
+		t.Errorf("ChatID = %q, want parent channel", msg.ChatID)
+	}
+	if msg.Extra[extraKeyInThread] != "true" || msg.Extra[extraKeyChannelID] != ev.ChannelID {
+		t.Errorf("Extra = %v", msg.Extra)
+	}
+}
+
+func TestParseMessage_DirectAttachment(t *testing.T) {
+	var ev messageEvent
+	loadFixture(t, "message_create_dm_attachment.json", &ev)
+
+	msg := parseMessage(&ev, testBotID, newThreadRegistry())
+	if msg == nil {
+		t.Fatal("expected message")
+	}
+	if msg.ChatType != im.ChatTypeDirect || msg.ChatID != "" || msg.ThreadID != "" {
+		t.Errorf("DM resolved as chat=%q thread=%q type=%s", msg.ChatID, msg.ThreadID, msg.ChatType)
+	}
+	if msg.MessageType != im.MessageTypeFile || msg.FileName != "q3-report.pdf" || msg.FileSize != 482113 {
+		t.Errorf("file = %s %q %d", msg.MessageType, msg.FileName, msg.FileSize)
+	}
+	if !strings.HasPrefix(msg.FileKey, "https://cdn.discordapp.com/") {
+		t.Errorf("FileKey = %q", msg.FileKey)
+	}
+}
+
+func TestParseMessage_IgnoresBots(t *testing.T) {
+	var ev messageEvent
+	loadFixture(t, "message_create_dm_attachment.json", &ev)
+	ev.Author.Bot = true
+	if parseMessage(&ev, testBotID, newThreadRegistry()) != nil {
+		t.Error("bot authors must be ignored")
+	}
+}
+
+func TestParseInteraction_AskInThread(t *testing.T) {
+	var in interaction
+	loadFixture(t, "interaction_ask.json", &in)
+
+	msg := parseInteraction(&in)
+	if msg == nil {
+		t.Fatal("expected message")
+	}
+	if msg.Content != "Explain this deployment diagram" {
+		t.Errorf("Content = %q", msg.Content)
+	}
+	if msg.ThreadID != in.ChannelID || msg.ChatID != "1180000000000000042" {
+		t.Errorf("thread=%q chat=%q", msg.ThreadID, msg.ChatID)
+	}
+	if msg.MessageType != im.MessageTypeImage || msg.FileName != "diagram.png" {
+		t.Errorf("attachment = %s %q", msg.MessageType, msg.FileName)
+	}
+	if msg.Extra[extraKeyInteractionToken] != in.Token || msg.Extra[extraKeyApplicationID] != in.ApplicationID {
+		t.Errorf("Extra = %v", msg.Extra)
+	}
+
+	in.Data.Name = "clear"
+	in.Data.Options = nil
+	if msg := parseInteraction(&in); msg == nil || msg.Content != "/clear" {
+		t.Errorf("other commands must map to IM commands, got %+v", msg)
+	}
+}
+
+func signedContext(t *testing.T, priv ed25519.PrivateKey, body []byte, ts time.Time) (*gin.Context, *httptest.ResponseRecorder) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/api/v1/im/callback/ch", strings.NewReader(string(body)))
+	timestamp := strconv.FormatInt(ts.Unix(), 10)
+	c.Request.Header.Set("X-Signature-Timestamp", timestamp)
+	c.Request.Header.Set("X-Signature-Ed25519", hex.EncodeToString(ed25519.Sign(priv, append([]byte(timestamp), body...))))
+	return c, w
+}
+
+func TestInteractionsSignature(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	adapter, err := NewInteractionsAdapter(&Client{}, hex.EncodeToString(pub))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ping := []byte(`{"type":1,"id":"1","application_id":"2","token":"t"}`)
+	c, w := signedContext(t, priv, ping, time.Now())
+	if !adapter.HandleURLVerification(c) || w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"type":1`) {
+		t.Fatalf("PING: handled code=%d body=%s", w.Code, w.Body.String())
+	}
+
+	_, otherPriv, _ := ed25519.GenerateKey(rand.Reader)
+	c, w = signedContext(t, otherPriv, ping, time.Now())
+	if !adapter.HandleURLVerification(c) || w.Code != http.StatusUnauthorized {
+		t.Fatalf("forged PING: code=%d, want 401", w.Code)
+	}
+
+	body := loadFixture(t, "interaction_ask.json", nil)
+	c, _ = signedContext(t, priv, body, time.Now())
+	if adapter.HandleURLVerification(c) {
+		t.Fatal("commands are not verification requests")
+	}
+	if err := adapter.VerifyCallback(c); err != nil {
+		t.Fatalf("VerifyCallback: %v", err)
+	}
+	if msg, err := adapter.ParseCallback(c); err != nil || msg == nil {
+		t.Fatalf("ParseCallback after verify: %v %v", msg, err)
+	}
+
+	c, _ = signedContext(t, priv, body, time.Now().Add(-time.Hour))
+	if err := adapter.VerifyCallback(c); err == nil {
+		t.Fatal("stale signature timestamp must be rejected")
+	}
+}
+
+type recordedCall struct {
+	Method string
+	Path   string
+	Body   map[string]any
+}
+
+func newRecordingAPI(t *testing.T) (*Client, func() []recordedCall) {
+	t.Helper()
+	t.Setenv("SSRF_WHITELIST", "127.0.0.1")
+	secutils.ResetSSRFWhitelistForTest()
+	t.Cleanup(secutils.ResetSSRFWhitelistForTest)
+
+	var mu sync.Mutex
+	var calls []recordedCall
+	next := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		raw, _ := io.ReadAll(r.Body)
+		var body map[string]any
+		_ = json.Unmarshal(raw, &body)
+		mu.Lock()
+		calls = append(calls, recordedCall{Method: r.Method, Path: r.URL.Path, Body: body})
+		next++
+		id := "9" + strconv.Itoa(next)
+		mu.Unlock()
+		if strings.HasSuffix(r.URL.Path, "/threads") {
+			// A thread opened on a message takes the message's ID.
+			parts := strings.Split(r.URL.Path, "/")
+			id = parts[len(parts)-2]
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{"id": id})
+	}))
+	t.Cleanup(srv.Close)
+
+	client, err := NewClient("bot-token", srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client, func() []recordedCall {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]recordedCall(nil), calls...)
+	}
+}
+
+func TestStreamFinalizeSplitsOverflow(t *testing.T) {
+	client, calls := newRecordingAPI(t)
+	adapter := NewAdapter(client, newThreadRegistry(), false)
+	incoming := &im.IncomingMessage{
+		Platform:  im.PlatformDiscord,
+		ChatType:  im.ChatTypeGroup,
+		MessageID: "500",
+		Extra:     map[string]string{extraKeyChannelID: "42"},
+	}
+	ctx := context.Background()
+
+	streamID, err := adapter.StartStream(ctx, incoming)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := adapter.UpdateStreamContent(ctx, incoming, streamID, strings.Repeat("x", 5000)); err != nil {
+		t.Fatal(err)
+	}
+	final := strings.Repeat("a", 1500) + "\n\n" + strings.Repeat("b", 1500) + "\n\n" + strings.Repeat("c", 1500)
+	if err := adapter.FinalizeStream(ctx, incoming, streamID, final); err != nil {
+		t.Fatal(err)
+	}
+	if err := adapter.EndStream(ctx, incoming, streamID); err != nil {
+		t.Fatal(err)
+	}
+
+	got := calls()
+	if len(got) != 5 {
+		t.Fatalf("calls = %d, want 5 (create, edit, edit, 2 follow-ups): %+v", len(got), got)
+	}
+	if got[0].Method != http.MethodPost || got[0].Path != "/channels/42/messages" {
+		t.Errorf("start = %s %s", got[0].Method, got[0].Path)
+	}
+	ref, _ := got[0].Body["message_reference"].(map[string]any)
+	if ref["message_id"] != "500" {
+		t.Errorf("stream message must reply to the trigger, body=%v", got[0].Body)
+	}
+	for _, c := range got[1:3] {
+		if c.Method != http.MethodPatch {
+			t.Errorf("expected edit, got %s %s", c.Method, c.Path)
+		}
+		if n := len([]rune(c.Body["content"].(string))); n > maxMessageRunes {
+			t.Errorf("edit exceeds Discord limit: %d runes", n)
+		}
+	}
+	if got[3].Body["content"] != strings.Repeat("b", 1500) || got[4].Body["content"] != strings.Repeat("c", 1500) {
+		t.Errorf("overflow parts = %v / %v", got[3].Body["content"], got[4].Body["content"])
+	}
+}
+
+func TestThreadModeOpensThreadOnce(t *testing.T) {
+	client, calls := newRecordingAPI(t)
+	threads := newThreadRegistry()
+	threads.setBotID(testBotID)
+	adapter := NewAdapter(client, threads, true)
+	incoming := &im.IncomingMessage{
+		Platform:  im.PlatformDiscord,
+		ChatType:  im.ChatTypeGroup,
+		Content:   "What is our refund policy?\nDetails please",
+		MessageID: "777",
+		Extra:     map[string]string{extraKeyChannelID: "42"},
+	}
+	ctx := context.Background()
+
+	if err := adapter.SendReply(ctx, incoming, &im.ReplyMessage{Content: "first"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := adapter.SendReply(ctx, incoming, &im.ReplyMessage{Content: "second"}); err != nil {
+		t.Fatal(err)
+	}
+
+	got := calls()
+	if len(got) != 3 {
+		t.Fatalf("calls = %d, want thread + 2 messages: %+v", len(got), got)
+	}
+	if got[0].Path != "/channels/42/messages/777/threads" || got[0].Body["name"] != "What is our refund policy?" {
+		t.Errorf("thread call = %s %v", got[0].Path, got[0].Body)
+	}
+	for _, c := range got[1:] {
+		if c.Path != "/channels/777/messages" {
+			t.Errorf("reply must go into the thread, got %s", c.Path)
+		}
+	}
+	if thread, ok := threads.lookup("777"); !ok || thread.OwnerID != testBotID || thread.ParentID != "42" {
+		t.Errorf("thread not registered as the bot's: %+v", thread)
+	}
+}
+
+func TestInteractionReplyEditsOriginal(t *testing.T) {
+	client, calls := newRecordingAPI(t)
+	adapter := NewAdapter(client, newThreadRegistry(), false)
+	incoming := &im.IncomingMessage{
+		Platform:  im.PlatformDiscord,
+		MessageID: "1",
+		Extra: map[string]string{
+			extraKeyChannelID:        "42",
+			extraKeyInteractionToken: "tok",
+			extraKeyApplicationID:    "app",
+		},
+	}
+	if err := adapter.SendReply(context.Background(), incoming, &im.ReplyMessage{Content: strings.Repeat("z", 2500)}); err != nil {
+		t.Fatal(err)
+	}
+	got := calls()
+	if len(got) != 2 || got[0].Path != "/webhooks/app/tok/messages/@original" || got[1].Path != "/webhooks/app/tok" {
+		t.Fatalf("calls = %+v", got)
+	}
+}
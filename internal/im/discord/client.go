@@ -0,0 +1,202 @@
+package discord
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	secutils "github.com/Tencent/WeKnora/internal/utils"
+)
+
+// maxRateLimitWait caps how long one call waits on a 429 before giving up;
+// stream edits are throttled anyway, so a long bucket reset is not worth it.
+const maxRateLimitWait = 3 * time.Second
+
+// Client calls the Discord REST API with a bot token.
+type Client struct {
+	baseURL    string
+	token      string
+	httpClient *http.Client
+}
+
+// NewClient builds a Discord REST client. apiBaseURL defaults to the public
+// v10 API and is only overridden for tests and proxies.
+func NewClient(botToken, apiBaseURL string) (*Client, error) {
+	botToken = strings.TrimSpace(botToken)
+	if botToken == "" {
+		return nil, fmt.Errorf("discord bot_token is required")
+	}
+	apiBaseURL = strings.TrimRight(strings.TrimSpace(apiBaseURL), "/")
+	if apiBaseURL == "" {
+		apiBaseURL = defaultAPIBaseURL
+	}
+	parsed, err := url.Parse(apiBaseURL)
+	if err != nil || parsed.Host == "" || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return nil, fmt.Errorf("invalid discord api_base_url: must be a valid http(s) URL")
+	}
+	if err := secutils.ValidateURLForSSRF(apiBaseURL); err != nil {
+		return nil, fmt.Errorf("invalid discord api_base_url: %w (for private proxies, add the hostname to SSRF_WHITELIST)", err)
+	}
+	return &Client{
+		baseURL: apiBaseURL,
+		token:   botToken,
+		httpClient: secutils.NewSSRFSafeHTTPClient(secutils.SSRFSafeHTTPClientConfig{
+			Timeout:      30 * time.Second,
+			MaxRedirects: 5,
+		}),
+	}, nil
+}
+
+// CreateMessage posts a message to a channel (or thread) and returns its ID.
+// replyTo, when set, renders the message as a reply to that message.
+func (c *Client) CreateMessage(ctx context.Context, channelID, content, replyTo string) (string, error) {
+	body := createMessageRequest{
+		Content:         content,
+		AllowedMentions: &allowedMentions{Parse: []string{}},
+	}
+	if replyTo != "" {
+		failIfNotExists := false
+		body.MessageReference = &messageReference{MessageID: replyTo, FailIfNotExists: &failIfNotExists}
+	}
+	var created struct {
+		ID string `json:"id"`
+	}
+	if err := c.do(ctx, http.MethodPost, "/channels/"+channelID+"/messages", body, &created); err != nil {
+		return "", err
+	}
+	return created.ID, nil
+}
+
+// EditMessage replaces the content of a message the bot posted.
+func (c *Client) EditMessage(ctx context.Context, channelID, messageID, content string) error {
+	body := map[string]string{"content": content}
+	return c.do(ctx, http.MethodPatch, "/channels/"+channelID+"/messages/"+messageID, body, nil)
+}
+
+// StartThread opens a public thread on a message. Discord gives such a
+// thread the ID of its starter message.
+func (c *Client) StartThread(ctx context.Context, channelID, messageID, name string) (string, error) {
+	body := startThreadRequest{Name: name, AutoArchiveDuration: 1440}
+	var thread threadChannel
+	if err := c.do(ctx, http.MethodPost, "/channels/"+channelID+"/messages/"+messageID+"/threads", body, &thread); err != nil {
+		return "", err
+	}
+	return thread.ID, nil
+}
+
+// EditOriginalResponse replaces the deferred response of an interaction.
+func (c *Client) EditOriginalResponse(ctx context.Context, applicationID, token, content string) error {
+	body := createMessageRequest{Content: content, AllowedMentions: &allowedMentions{Parse: []string{}}}
+	return c.do(ctx, http.MethodPatch, "/webhooks/"+applicationID+"/"+token+"/messages/@original", body, nil)
+}
+
+// CreateFollowup sends an extra message for an interaction. Interaction
+// tokens are valid for 15 minutes.
+func (c *Client) CreateFollowup(ctx context.Context, applicationID, token, content string) error {
+	body := createMessageRequest{Content: content, AllowedMentions: &allowedMentions{Parse: []string{}}}
+	return c.do(ctx, http.MethodPost, "/webhooks/"+applicationID+"/"+token, body, nil)
+}
+
+// Download opens an attachment URL from the Discord CDN.
+func (c *Client) Download(ctx context.Context, rawURL string) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("discord download failed with status %d", resp.StatusCode)
+	}
+	return resp.Body, nil
+}
+
+func (c *Client) do(ctx context.Context, method, path string, body, out any) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("marshal body: %w", err)
+	}
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, bytes.NewReader(payload))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", "Bot "+c.token)
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			return err
+		}
+		respBody, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+		resp.Body.Close()
+		if err != nil {
+			return fmt.Errorf("read discord response: %w", err)
+		}
+
+		if resp.StatusCode == http.StatusTooManyRequests && attempt == 0 {
+			wait := retryAfter(resp.Header.Get("Retry-After"))
+			if wait <= maxRateLimitWait {
+				select {
+				case <-ctx.Done():
+					return ctx.Err()
+				case <-time.After(wait):
+				}
+				continue
+			}
+		}
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			if resp.StatusCode == http.StatusForbidden {
+				return fmt.Errorf("discord %s %s: 403 forbidden — check the bot's channel permissions (Send Messages, Create Public Threads, Send Messages in Threads); body=%s",
+					method, redactPath(path), truncateForErr(respBody))
+			}
+			return fmt.Errorf("discord %s %s: status=%d body=%s", method, redactPath(path), resp.StatusCode, truncateForErr(respBody))
+		}
+		if out == nil || len(respBody) == 0 {
+			return nil
+		}
+		if err := json.Unmarshal(respBody, out); err != nil {
+			return fmt.Errorf("decode discord response: %w", err)
+		}
+		return nil
+	}
+}
+
+func retryAfter(header string) time.Duration {
+	secs, err := strconv.ParseFloat(strings.TrimSpace(header), 64)
+	if err != nil || secs < 0 {
+		return time.Second
+	}
+	return time.Duration(secs * float64(time.Second))
+}
+
+// redactPath hides interaction tokens, which act as credentials, in errors.
+func redactPath(path string) string {
+	if !strings.HasPrefix(path, "/webhooks/") {
+		return path
+	}
+	parts := strings.SplitN(path, "/", 5)
+	if len(parts) >= 4 {
+		parts[3] = "***"
+	}
+	return strings.Join(parts, "/")
+}
+
+func truncateForErr(b []byte) string {
+	const max = 512
+	s := string(b)
+	if len(s) > max {
+		return s[:max] + "..."
+	}
+	return s
+}
@@ -0,0 +1,59 @@
+package discord
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Tencent/WeKnora/internal/im"
+	"github.com/Tencent/WeKnora/internal/logger"
+)
+
+// NewFactory returns an im.AdapterFactory for Discord channels.
+// Supports "websocket" (Gateway, default) and "webhook" (interactions
+// endpoint for slash commands).
+func NewFactory() im.AdapterFactory {
+	return func(factoryCtx context.Context, channel *im.IMChannel, msgHandler func(context.Context, *im.IncomingMessage) error) (im.Adapter, context.CancelFunc, error) {
+		creds, err := im.ParseCredentials(channel.Credentials)
+		if err != nil {
+			return nil, nil, fmt.Errorf("parse discord credentials: %w", err)
+		}
+
+		botToken := im.GetString(creds, "bot_token")
+		client, err := NewClient(botToken, im.GetString(creds, "api_base_url"))
+		if err != nil {
+			return nil, nil, err
+		}
+
+		mode := im.ResolveMode(channel, "websocket")
+
+		switch mode {
+		case "webhook":
+			adapter, err := NewInteractionsAdapter(client, im.GetString(creds, "public_key"))
+			if err != nil {
+				return nil, nil, err
+			}
+			return adapter, func() {}, nil
+
+		case "websocket":
+			threads := newThreadRegistry()
+			longConn := NewLongConnClient(botToken, threads, msgHandler)
+
+			wsCtx, wsCancel := context.WithCancel(context.Background())
+			go func() {
+				if err := longConn.Start(wsCtx); err != nil && wsCtx.Err() == nil {
+					logger.Errorf(context.Background(), "[IM] Discord Gateway stopped for channel %s: %v", channel.ID, err)
+				}
+			}()
+
+			threadMode := channel.SessionMode == string(im.SessionModeThread)
+			adapter := NewAdapter(client, threads, threadMode)
+			return adapter, func() {
+				wsCancel()
+				longConn.Stop()
+			}, nil
+
+		default:
+			return nil, nil, fmt.Errorf("unsupported discord mode: %s", mode)
+		}
+	}
+}
@@ -0,0 +1,250 @@
+package discord
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/im"
+	"github.com/Tencent/WeKnora/internal/logger"
+	secutils "github.com/Tencent/WeKnora/internal/utils"
+	ws "github.com/gorilla/websocket"
+)
+
+// MessageHandler is called when an IM message is received via the Gateway.
+type MessageHandler func(ctx context.Context, msg *im.IncomingMessage) error
+
+// LongConnClient manages a Discord Gateway connection. It re-identifies on
+// every reconnect instead of resuming: a missed message during the gap is
+// cheaper than tracking session resume state.
+type LongConnClient struct {
+	token   string
+	handler MessageHandler
+	threads *threadRegistry
+
+	mu     sync.Mutex
+	conn   *ws.Conn
+	seq    *int64
+	acked  bool
+	closed bool
+}
+
+// NewLongConnClient creates a Discord Gateway client.
+func NewLongConnClient(token string, threads *threadRegistry, handler MessageHandler) *LongConnClient {
+	return &LongConnClient{token: token, threads: threads, handler: handler}
+}
+
+// Start connects to the Gateway and reconnects until ctx is cancelled.
+func (c *LongConnClient) Start(ctx context.Context) error {
+	logger.Infof(ctx, "[IM] Discord Gateway connecting...")
+	attempt := 0
+	for {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		err := c.connectAndRun(ctx)
+		if ctx.Err() != nil || c.isClosed() {
+			return ctx.Err()
+		}
+		attempt++
+		delay := reconnectDelay(attempt)
+		logger.Warnf(ctx, "[Discord] Gateway connection lost: %v, reconnecting in %v", err, delay)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+// Stop closes the connection and prevents further reconnects.
+func (c *LongConnClient) Stop() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closed = true
+	if c.conn != nil {
+		_ = c.conn.Close()
+		c.conn = nil
+	}
+}
+
+func (c *LongConnClient) connectAndRun(ctx context.Context) error {
+	dialer := *ws.DefaultDialer
+	dialer.NetDialContext = secutils.SSRFSafeDialContext
+	conn, _, err := dialer.DialContext(ctx, gatewayURL, nil)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	c.conn = conn
+	c.seq = nil
+	c.mu.Unlock()
+
+	connCtx, cancel := context.WithCancel(ctx)
+	defer func() {
+		cancel()
+		c.mu.Lock()
+		if c.conn == conn {
+			c.conn = nil
+		}
+		c.mu.Unlock()
+		_ = conn.Close()
+	}()
+	var writeMu sync.Mutex
+	write := func(p gatewayPayload) error {
+		writeMu.Lock()
+		defer writeMu.Unlock()
+		return conn.WriteJSON(p)
+	}
+
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return err
+		}
+		var payload gatewayPayload
+		if err := json.Unmarshal(data, &payload); err != nil {
+			logger.Warnf(ctx, "[Discord] invalid gateway payload: %v", err)
+			continue
+		}
+		if payload.S != nil {
+			c.mu.Lock()
+			c.seq = payload.S
+			c.mu.Unlock()
+		}
+		switch payload.Op {
+		case opHello:
+			var hello helloData
+			if err := json.Unmarshal(payload.D, &hello); err != nil {
+				return err
+			}
+			if hello.HeartbeatInterval <= 0 {
+				hello.HeartbeatInterval = 41250
+			}
+			if err := write(c.identifyPayload()); err != nil {
+				return err
+			}
+			go c.heartbeatLoop(connCtx, conn, write, time.Duration(hello.HeartbeatInterval)*time.Millisecond)
+		case opHeartbeat:
+			if err := write(c.heartbeatPayload()); err != nil {
+				return err
+			}
+		case opHeartbeatACK:
+			c.mu.Lock()
+			c.acked = true
+			c.mu.Unlock()
+		case opDispatch:
+			c.dispatch(ctx, &payload)
+		case opReconnect, opInvalidSession:
+			return fmt.Errorf("gateway requested reconnect op=%d", payload.Op)
+		}
+	}
+}
+
+func (c *LongConnClient) dispatch(ctx context.Context, payload *gatewayPayload) {
+	switch payload.T {
+	case eventReady:
+		var ready readyData
+		if err := json.Unmarshal(payload.D, &ready); err == nil {
+			c.threads.setBotID(ready.User.ID)
+			logger.Infof(ctx, "[IM] Discord Gateway connected as %s (%s)", ready.User.Username, ready.User.ID)
+		}
+	case eventThreadCreate:
+		var thread threadChannel
+		if err := json.Unmarshal(payload.D, &thread); err == nil {
+			c.threads.add(thread)
+		}
+	case eventGuildCreate:
+		var guild guildCreateData
+		if err := json.Unmarshal(payload.D, &guild); err == nil {
+			for _, t := range guild.Threads {
+				c.threads.add(t)
+			}
+		}
+	case eventThreadListSync:
+		var list threadListSyncData
+		if err := json.Unmarshal(payload.D, &list); err == nil {
+			for _, t := range list.Threads {
+				c.threads.add(t)
+			}
+		}
+	case eventMessageCreate:
+		var ev messageEvent
+		if err := json.Unmarshal(payload.D, &ev); err != nil {
+			logger.Warnf(ctx, "[Discord] parse MESSAGE_CREATE failed: %v", err)
+			return
+		}
+		msg := parseMessage(&ev, c.threads.botID(), c.threads)
+		if msg == nil {
+			return
+		}
+		if err := c.handler(ctx, msg); err != nil {
+			logger.Errorf(ctx, "[Discord] Handle message error: %v", err)
+		}
+	}
+}
+
+// heartbeatLoop sends heartbeats and drops the connection when the previous
+// one was never acknowledged, which is how Discord signals a zombie socket.
+func (c *LongConnClient) heartbeatLoop(ctx context.Context, conn *ws.Conn, write func(gatewayPayload) error, interval time.Duration) {
+	c.mu.Lock()
+	c.acked = true
+	c.mu.Unlock()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.mu.Lock()
+			acked := c.acked
+			c.acked = false
+			c.mu.Unlock()
+			if !acked {
+				logger.Warnf(ctx, "[Discord] Heartbeat not acknowledged, reconnecting")
+				_ = conn.Close()
+				return
+			}
+			if err := write(c.heartbeatPayload()); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (c *LongConnClient) identifyPayload() gatewayPayload {
+	data, _ := json.Marshal(identifyData{
+		Token:      c.token,
+		Intents:    gatewayIntents,
+		Properties: identifyProperties{OS: "linux", Browser: "weknora", Device: "weknora"},
+	})
+	return gatewayPayload{Op: opIdentify, D: data}
+}
+
+func (c *LongConnClient) heartbeatPayload() gatewayPayload {
+	c.mu.Lock()
+	seq := c.seq
+	c.mu.Unlock()
+	data, _ := json.Marshal(seq)
+	return gatewayPayload{Op: opHeartbeat, D: data}
+}
+
+func (c *LongConnClient) isClosed() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.closed
+}
+
+func reconnectDelay(attempt int) time.Duration {
+	if attempt <= 0 {
+		return time.Second
+	}
+	delay := time.Duration(attempt) * time.Second
+	if delay > 30*time.Second {
+		return 30 * time.Second
+	}
+	return delay
+}
@@ -0,0 +1,188 @@
+package discord
+
+import "encoding/json"
+
+const (
+	defaultAPIBaseURL = "https://discord.com/api/v10"
+	gatewayURL        = "wss://gateway.discord.gg/?v=10&encoding=json"
+
+	opDispatch       = 0
+	opHeartbeat      = 1
+	opIdentify       = 2
+	opReconnect      = 7
+	opInvalidSession = 9
+	opHello          = 10
+	opHeartbeatACK   = 11
+
+	// GUILDS (thread create/list events), GUILD_MESSAGES, DIRECT_MESSAGES and
+	// the privileged MESSAGE_CONTENT intent, which must be enabled for the bot
+	// in the developer portal.
+	gatewayIntents = 1<<0 | 1<<9 | 1<<12 | 1<<15
+
+	eventReady          = "READY"
+	eventMessageCreate  = "MESSAGE_CREATE"
+	eventThreadCreate   = "THREAD_CREATE"
+	eventGuildCreate    = "GUILD_CREATE"
+	eventThreadListSync = "THREAD_LIST_SYNC"
+
+	interactionPing               = 1
+	interactionApplicationCommand = 2
+
+	// callbackPong answers an interactions endpoint PING. Commands are
+	// answered with a deferred response by the callback handler.
+	callbackPong = 1
+
+	// Application command option types.
+	optionString     = 3
+	optionAttachment = 11
+
+	// Channel types of the three thread kinds.
+	channelAnnouncementThread = 10
+	channelPublicThread       = 11
+	channelPrivateThread      = 12
+
+	// maxMessageRunes is Discord's per-message content limit.
+	maxMessageRunes = 2000
+	// maxThreadNameRunes is Discord's thread name limit.
+	maxThreadNameRunes = 100
+
+	extraKeyChannelID        = "channel_id"
+	extraKeyInteractionToken = "interaction_token"
+	extraKeyApplicationID    = "application_id"
+	extraKeyInThread         = "in_thread"
+)
+
+type gatewayPayload struct {
+	Op int             `json:"op"`
+	D  json.RawMessage `json:"d,omitempty"`
+	S  *int64          `json:"s,omitempty"`
+	T  string          `json:"t,omitempty"`
+}
+
+type helloData struct {
+	HeartbeatInterval int `json:"heartbeat_interval"`
+}
+
+type identifyData struct {
+	Token      string             `json:"token"`
+	Intents    int                `json:"intents"`
+	Properties identifyProperties `json:"properties"`
+}
+
+type identifyProperties struct {
+	OS      string `json:"os"`
+	Browser string `json:"browser"`
+	Device  string `json:"device"`
+}
+
+type readyData struct {
+	User discordUser `json:"user"`
+}
+
+type discordUser struct {
+	ID         string `json:"id"`
+	Username   string `json:"username"`
+	GlobalName string `json:"global_name"`
+	Bot        bool   `json:"bot"`
+}
+
+func (u *discordUser) displayName() string {
+	if u.GlobalName != "" {
+		return u.GlobalName
+	}
+	return u.Username
+}
+
+type discordAttachment struct {
+	ID          string `json:"id"`
+	Filename    string `json:"filename"`
+	Size        int64  `json:"size"`
+	URL         string `json:"url"`
+	ContentType string `json:"content_type"`
+}
+
+type messageReference struct {
+	MessageID string `json:"message_id,omitempty"`
+	ChannelID string `json:"channel_id,omitempty"`
+	GuildID   string `json:"guild_id,omitempty"`
+	// FailIfNotExists false lets a reply go out even if the trigger message
+	// was deleted meanwhile.
+	FailIfNotExists *bool `json:"fail_if_not_exists,omitempty"`
+}
+
+type messageEvent struct {
+	ID               string              `json:"id"`
+	ChannelID        string              `json:"channel_id"`
+	GuildID          string              `json:"guild_id"`
+	Author           discordUser         `json:"author"`
+	Content          string              `json:"content"`
+	Attachments      []discordAttachment `json:"attachments"`
+	Mentions         []discordUser       `json:"mentions"`
+	MessageReference *messageReference   `json:"message_reference"`
+	ReferencedMsg    *messageEvent       `json:"referenced_message"`
+	// Position is only set for messages posted inside a thread.
+	Position *int `json:"position"`
+}
+
+type threadChannel struct {
+	ID       string `json:"id"`
+	Type     int    `json:"type"`
+	ParentID string `json:"parent_id"`
+	OwnerID  string `json:"owner_id"`
+}
+
+type guildCreateData struct {
+	Threads []threadChannel `json:"threads"`
+}
+
+type threadListSyncData struct {
+	Threads []threadChannel `json:"threads"`
+}
+
+type interaction struct {
+	ID            string           `json:"id"`
+	ApplicationID string           `json:"application_id"`
+	Type          int              `json:"type"`
+	Token         string           `json:"token"`
+	GuildID       string           `json:"guild_id"`
+	ChannelID     string           `json:"channel_id"`
+	Channel       *threadChannel   `json:"channel"`
+	Member        *interactionUser `json:"member"`
+	User          *discordUser     `json:"user"`
+	Data          *commandData     `json:"data"`
+}
+
+type interactionUser struct {
+	User discordUser `json:"user"`
+}
+
+type commandData struct {
+	Name     string          `json:"name"`
+	Options  []commandOption `json:"options"`
+	Resolved *resolvedData   `json:"resolved"`
+}
+
+type commandOption struct {
+	Name  string          `json:"name"`
+	Type  int             `json:"type"`
+	Value json.RawMessage `json:"value"`
+}
+
+type resolvedData struct {
+	Attachments map[string]discordAttachment `json:"attachments"`
+}
+
+type allowedMentions struct {
+	Parse []string `json:"parse"`
+}
+
+type createMessageRequest struct {
+	Content          string            `json:"content"`
+	MessageReference *messageReference `json:"message_reference,omitempty"`
+	AllowedMentions  *allowedMentions  `json:"allowed_mentions,omitempty"`
+}
+
+type startThreadRequest struct {
+	Name                string `json:"name"`
+	AutoArchiveDuration int    `json:"auto_archive_duration"`
+}
@@ -19,6 +19,7 @@ import (
 	"crypto/cipher"
 	"crypto/sha256"
 	"encoding/base64"
+	"encoding/binary"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -26,6 +27,7 @@ import (
 	"net/http"
 	"net/url"
 	"regexp"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -39,6 +41,7 @@ import (
 // Compile-time check that Adapter implements im.StreamSender and im.FileDownloader.
 var _ im.StreamSender = (*Adapter)(nil)
 var _ im.FileDownloader = (*Adapter)(nil)
+var _ im.VoiceSender = (*Adapter)(nil)
 
 var httpClient = utils.NewSSRFSafeHTTPClient(utils.SSRFSafeHTTPClientConfig{
 	Timeout:      10 * time.Second,
@@ -255,6 +258,16 @@ type feishuEventHeader struct {
 type feishuEvent struct {
 	Message *feishuMessage `json:"message"`
 	Sender  *feishuSender  `json:"sender"`
+
+	// Fields of im.message.reaction.created_v1.
+	MessageID    string              `json:"message_id"`
+	ReactionType *feishuReactionType `json:"reaction_type"`
+	OperatorType string              `json:"operator_type"`
+	UserID       *feishuSenderID     `json:"user_id"`
+}
+
+type feishuReactionType struct {
+	EmojiType string `json:"emoji_type"`
 }
 
 type feishuMessage struct {
@@ -305,6 +318,19 @@ func (a *Adapter) ParseCallback(c *gin.Context) (*im.IncomingMessage, error) {
 
 	// Token verification is handled by VerifyCallback; no need to re-check here.
 
+	// Reactions only matter for thread archiving; app-added ones are ignored.
+	if eventBody.Header != nil && eventBody.Header.EventType == "im.message.reaction.created_v1" {
+		ev := eventBody.Event
+		if ev == nil || ev.OperatorType != "user" || ev.ReactionType == nil {
+			return nil, nil
+		}
+		openID := ""
+		if ev.UserID != nil {
+			openID = ev.UserID.OpenID
+		}
+		return reactionMessage(a.region, ev.MessageID, ev.ReactionType.EmojiType, openID), nil
+	}
+
 	// Check event type
 	if eventBody.Header == nil || eventBody.Header.EventType != "im.message.receive_v1" {
 		if eventBody.Header != nil {
@@ -418,6 +444,28 @@ func (a *Adapter) ParseCallback(c *gin.Context) (*im.IncomingMessage, error) {
 			FileName:    imageContent.ImageKey + ".png",
 		}, nil
 
+	case "audio":
+		var audioContent struct {
+			FileKey string `json:"file_key"`
+		}
+		if err := json.Unmarshal([]byte(msg.Content), &audioContent); err != nil {
+			return nil, fmt.Errorf("unmarshal audio content: %w", err)
+		}
+		if audioContent.FileKey == "" {
+			return nil, nil
+		}
+		return &im.IncomingMessage{
+			Platform:    a.region.Platform,
+			MessageType: im.MessageTypeAudio,
+			UserID:      openID,
+			ChatID:      chatID,
+			ChatType:    chatType,
+			MessageID:   msg.MessageID,
+			ThreadID:    threadID,
+			FileKey:     audioContent.FileKey,
+			FileName:    audioContent.FileKey + ".opus",
+		}, nil
+
 	case "post":
 		// Rich text: extract plain text for QA
 		var postContent struct {
@@ -1164,6 +1212,80 @@ func (a *Adapter) uploadImageFromURL(ctx context.Context, accessToken, rawURL st
 	return result.Data.ImageKey, nil
 }
 
+// SendVoice uploads Ogg/Opus audio and replies with an audio message.
+// POST /open-apis/im/v1/files (multipart: file_type=opus, duration in ms)
+func (a *Adapter) SendVoice(ctx context.Context, incoming *im.IncomingMessage, audio []byte) error {
+	accessToken, err := a.getTenantAccessToken(ctx)
+	if err != nil {
+		return fmt.Errorf("get access token: %w", err)
+	}
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	_ = writer.WriteField("file_type", "opus")
+	_ = writer.WriteField("file_name", "reply.opus")
+	if ms := oggDurationMs(audio); ms > 0 {
+		_ = writer.WriteField("duration", strconv.FormatInt(ms, 10))
+	}
+	part, err := writer.CreateFormFile("file", "reply.opus")
+	if err != nil {
+		return err
+	}
+	if _, err := part.Write(audio); err != nil {
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.api("/open-apis/im/v1/files"), &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("upload audio: %w", err)
+	}
+	defer resp.Body.Close()
+	var result struct {
+		Code int    `json:"code"`
+		Msg  string `json:"msg"`
+		Data struct {
+			FileKey string `json:"file_key"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("decode upload response: %w", err)
+	}
+	if result.Code != 0 || result.Data.FileKey == "" {
+		return fmt.Errorf("upload audio error: code=%d msg=%s", result.Code, result.Msg)
+	}
+
+	content, _ := json.Marshal(map[string]string{"file_key": result.Data.FileKey})
+	receiveIDType, receiveID := a.resolveReceiveID(incoming)
+	return a.sendWithFallback(ctx, accessToken, incoming,
+		map[string]interface{}{"msg_type": "audio", "content": string(content)},
+		map[string]interface{}{"receive_id": receiveID, "msg_type": "audio", "content": string(content)},
+		receiveIDType)
+}
+
+// oggDurationMs reads the duration of Ogg/Opus audio from the granule
+// position of its last page (Opus always counts 48 kHz samples). Returns 0
+// when the data is not Ogg.
+func oggDurationMs(audio []byte) int64 {
+	idx := bytes.LastIndex(audio, []byte("OggS"))
+	if idx < 0 || len(audio) < idx+14 {
+		return 0
+	}
+	granule := int64(binary.LittleEndian.Uint64(audio[idx+6 : idx+14]))
+	if granule <= 0 {
+		return 0
+	}
+	return granule / 48
+}
+
 // cardkitSetStreaming updates the card's streaming_mode setting.
 // PATCH /open-apis/cardkit/v1/cards/:card_id/settings
 func (a *Adapter) cardkitSetStreaming(ctx context.Context, accessToken, cardID string, streaming bool, sequence int) error {
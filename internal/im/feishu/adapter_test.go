@@ -4,6 +4,9 @@ import (
 	"context"
 	"strings"
 	"testing"
+
+	"github.com/Tencent/WeKnora/internal/im"
+	larkim "github.com/larksuite/oapi-sdk-go/v3/service/im/v1"
 )
 
 func TestFeishuThreadID_ThreadedReply(t *testing.T) {
@@ -102,3 +105,20 @@ func TestResolveMarkdownImages_FallbackLabelFollowsRegion(t *testing.T) {
 		}
 	}
 }
+
+func TestConvertAudioEvent(t *testing.T) {
+	content := `{"file_key":"file_v3_voice","duration":2300}`
+	msg := &larkim.EventMessage{Content: &content}
+	got := convertAudioEvent(RegionFeishu, msg, "ou_user1", "", im.ChatTypeDirect, "om_msg1")
+	if got == nil || got.MessageType != im.MessageTypeAudio {
+		t.Fatalf("audio event = %+v", got)
+	}
+	if got.FileKey != "file_v3_voice" || got.FileName != "file_v3_voice.opus" || got.MessageID != "om_msg1" {
+		t.Errorf("audio fields = %+v", got)
+	}
+
+	empty := `{"file_key":""}`
+	if convertAudioEvent(RegionFeishu, &larkim.EventMessage{Content: &empty}, "ou_user1", "", im.ChatTypeDirect, "om_msg2") != nil {
+		t.Error("audio without file_key must be ignored")
+	}
+}
@@ -37,6 +37,13 @@ func NewLongConnClient(region Region, appID, appSecret, apiBaseURL string, handl
 				return nil
 			}
 			return handler(ctx, msg)
+		}).
+		OnP2MessageReactionCreatedV1(func(ctx context.Context, event *larkim.P2MessageReactionCreatedV1) error {
+			msg := convertReactionEvent(region, event)
+			if msg == nil {
+				return nil
+			}
+			return handler(ctx, msg)
 		})
 
 	sdkLogger := &feishuLoggerAdapter{region: region, appID: appID}
@@ -162,6 +169,8 @@ func convertEvent(region Region, event *larkim.P2MessageReceiveV1) *im.IncomingM
 		return convertFileEvent(region, msg, openID, chatID, chatType, messageID)
 	case "image":
 		return convertImageEvent(region, msg, openID, chatID, chatType, messageID)
+	case "audio":
+		return convertAudioEvent(region, msg, openID, chatID, chatType, messageID)
 	case "post":
 		return convertPostEvent(region, msg, openID, chatID, chatType, messageID)
 	default:
@@ -269,6 +278,37 @@ func convertImageEvent(
 	}
 }
 
+// convertAudioEvent handles audio (voice) message type.
+// Downloads via GetMessageResource API with type=file; the audio is Opus.
+func convertAudioEvent(
+	region Region, msg *larkim.EventMessage,
+	openID, chatID string, chatType im.ChatType, messageID string,
+) *im.IncomingMessage {
+	if msg.Content == nil {
+		return nil
+	}
+	var audioContent struct {
+		FileKey string `json:"file_key"`
+	}
+	if err := json.Unmarshal([]byte(*msg.Content), &audioContent); err != nil {
+		return nil
+	}
+	if audioContent.FileKey == "" {
+		return nil
+	}
+
+	return &im.IncomingMessage{
+		Platform:    region.Platform,
+		MessageType: im.MessageTypeAudio,
+		UserID:      openID,
+		ChatID:      chatID,
+		ChatType:    chatType,
+		MessageID:   messageID,
+		FileKey:     audioContent.FileKey,
+		FileName:    audioContent.FileKey + ".opus",
+	}
+}
+
 // convertPostEvent handles rich-text (post) message type.
 // Extracts all plain text content and treats it as a text query for QA.
 func convertPostEvent(
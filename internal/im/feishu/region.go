@@ -7,6 +7,9 @@ import "github.com/Tencent/WeKnora/internal/im"
 const (
 	feishuOpenBaseURL = "https://open.feishu.cn"
 	larkOpenBaseURL   = "https://open.larksuite.com"
+
+	feishuAppLinkBaseURL = "https://applink.feishu.cn"
+	larkAppLinkBaseURL   = "https://applink.larksuite.com"
 )
 
 // Region selects which cloud an adapter talks to. Apps, tenants, tokens and
@@ -17,6 +20,8 @@ type Region struct {
 	Platform im.Platform
 	// OpenBaseURL is the Open Platform API origin, without a trailing slash.
 	OpenBaseURL string
+	// AppLinkBaseURL is the origin of links that open a chat in the client.
+	AppLinkBaseURL string
 	// Label prefixes log lines so operators can tell the clouds apart.
 	Label string
 	// ThinkingText is the placeholder shown in a streaming card before the
@@ -33,6 +38,7 @@ var (
 	RegionFeishu = Region{
 		Platform:           im.PlatformFeishu,
 		OpenBaseURL:        feishuOpenBaseURL,
+		AppLinkBaseURL:     feishuAppLinkBaseURL,
 		Label:              "Feishu",
 		ThinkingText:       "正在思考...",
 		ImageFallbackLabel: "图片",
@@ -42,6 +48,7 @@ var (
 	RegionLark = Region{
 		Platform:           im.PlatformLark,
 		OpenBaseURL:        larkOpenBaseURL,
+		AppLinkBaseURL:     larkAppLinkBaseURL,
 		Label:              "Lark",
 		ThinkingText:       "Thinking...",
 		ImageFallbackLabel: "Image",
@@ -2,6 +2,7 @@ package feishu
 
 import (
 	"context"
+	"encoding/binary"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
@@ -45,6 +46,8 @@ type fakeOpenPlatform struct {
 	sendQuery string
 	// replyCode is returned from the reply endpoint (0 = success).
 	replyCode int
+	// uploadFields are the form fields of the file upload call.
+	uploadFields map[string]string
 }
 
 func (f *fakeOpenPlatform) handler() http.Handler {
@@ -67,6 +70,16 @@ func (f *fakeOpenPlatform) handler() http.Handler {
 			f.mu.Unlock()
 			_ = json.NewEncoder(w).Encode(map[string]any{"code": code, "msg": "mock"})
 
+		case r.URL.Path == "/open-apis/im/v1/files":
+			_ = r.ParseMultipartForm(1 << 20)
+			f.mu.Lock()
+			f.uploadFields = map[string]string{}
+			for k, v := range r.MultipartForm.Value {
+				f.uploadFields[k] = v[0]
+			}
+			f.mu.Unlock()
+			_ = json.NewEncoder(w).Encode(map[string]any{"code": 0, "data": map[string]any{"file_key": "file_v3_reply"}})
+
 		case r.URL.Path == "/open-apis/im/v1/messages":
 			f.mu.Lock()
 			f.sendQuery = r.URL.RawQuery
@@ -193,3 +206,48 @@ func TestSendReply_EndToEnd_HardErrorSurfaces(t *testing.T) {
 		t.Errorf("fell back to send-message API on a non-retryable error; paths = %v", fake.paths)
 	}
 }
+
+// SendVoice must upload the audio as an opus file with its duration and reply
+// with an audio message carrying the returned file_key.
+func TestSendVoice_EndToEnd(t *testing.T) {
+	useTestHTTPClient(t)
+	fake := &fakeOpenPlatform{}
+	srv := httptest.NewServer(fake.handler())
+	defer srv.Close()
+
+	a, _ := NewAdapter(testRegion(srv.URL), "cli_app", "secret", "", "", "")
+	incoming := &im.IncomingMessage{
+		Platform: im.PlatformLark, UserID: "ou_user1",
+		ChatType: im.ChatTypeDirect, MessageID: "om_msg1",
+	}
+	if err := a.SendVoice(context.Background(), incoming, oggPage(96000)); err != nil {
+		t.Fatalf("SendVoice: %v", err)
+	}
+
+	if fake.uploadFields["file_type"] != "opus" || fake.uploadFields["duration"] != "2000" {
+		t.Errorf("upload fields = %v", fake.uploadFields)
+	}
+	if got := fake.replyBody["msg_type"]; got != "audio" {
+		t.Errorf("msg_type = %v, want audio", got)
+	}
+	if raw, _ := fake.replyBody["content"].(string); !strings.Contains(raw, "file_v3_reply") {
+		t.Errorf("content = %q, want the uploaded file_key", raw)
+	}
+}
+
+// oggPage builds a minimal Ogg page header with the given granule position.
+func oggPage(granule uint64) []byte {
+	page := make([]byte, 27)
+	copy(page, "OggS")
+	binary.LittleEndian.PutUint64(page[6:14], granule)
+	return page
+}
+
+func TestOggDurationMs(t *testing.T) {
+	if got := oggDurationMs(append(oggPage(4800), oggPage(144000)...)); got != 3000 {
+		t.Errorf("duration = %d, want the last page's 3000 ms", got)
+	}
+	if got := oggDurationMs([]byte("ID3 mp3 data")); got != 0 {
+		t.Errorf("non-Ogg duration = %d, want 0", got)
+	}
+}
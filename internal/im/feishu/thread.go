@@ -0,0 +1,393 @@
+package feishu
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/im"
+	"github.com/Tencent/WeKnora/internal/logger"
+	larkim "github.com/larksuite/oapi-sdk-go/v3/service/im/v1"
+)
+
+var _ im.ThreadReader = (*Adapter)(nil)
+
+const (
+	// threadPageSize is the page size of the list-messages API (its maximum).
+	threadPageSize = 50
+	// maxThreadPages bounds how much history is scanned for one thread.
+	maxThreadPages = 10
+	// maxThreadNameLookups bounds the contact lookups for sender names.
+	maxThreadNameLookups = 50
+)
+
+// mentionKeyPattern matches the @_user_N placeholders of text messages.
+var mentionKeyPattern = regexp.MustCompile(`@_user_\d+`)
+
+// feishuThreadMessage is a message as returned by the get/list message APIs.
+type feishuThreadMessage struct {
+	MessageID  string `json:"message_id"`
+	RootID     string `json:"root_id"`
+	ThreadID   string `json:"thread_id"`
+	MsgType    string `json:"msg_type"`
+	CreateTime string `json:"create_time"`
+	ChatID     string `json:"chat_id"`
+	Deleted    bool   `json:"deleted"`
+	Sender     struct {
+		ID         string `json:"id"`
+		IDType     string `json:"id_type"`
+		SenderType string `json:"sender_type"`
+	} `json:"sender"`
+	Body struct {
+		Content string `json:"content"`
+	} `json:"body"`
+	Mentions []feishuThreadMention `json:"mentions"`
+}
+
+// feishuThreadMention maps an @_user_N placeholder to the mentioned user.
+type feishuThreadMention struct {
+	Key  string `json:"key"`
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// convertReactionEvent converts a reaction-created event into an
+// IncomingMessage of type reaction. The event carries no chat, so ChatID is
+// filled in from the thread when it is read. Reactions added by apps,
+// including this bot, are ignored.
+func convertReactionEvent(region Region, event *larkim.P2MessageReactionCreatedV1) *im.IncomingMessage {
+	if event == nil || event.Event == nil {
+		return nil
+	}
+	ev := event.Event
+	if ptrStr(ev.OperatorType) != "user" || ev.ReactionType == nil {
+		return nil
+	}
+	openID := ""
+	if ev.UserId != nil {
+		openID = ptrStr(ev.UserId.OpenId)
+	}
+	return reactionMessage(region, ptrStr(ev.MessageId), ptrStr(ev.ReactionType.EmojiType), openID)
+}
+
+func reactionMessage(region Region, messageID, emoji, openID string) *im.IncomingMessage {
+	if messageID == "" || emoji == "" {
+		return nil
+	}
+	return &im.IncomingMessage{
+		Platform:    region.Platform,
+		MessageType: im.MessageTypeReaction,
+		UserID:      openID,
+		ChatType:    im.ChatTypeGroup,
+		Content:     emoji,
+		MessageID:   messageID,
+		ThreadID:    messageID,
+	}
+}
+
+// FetchThread reads the thread containing incoming.MessageID. Topic threads
+// are listed directly; reply chains in ordinary groups are collected from the
+// chat history starting at the root message. Requires the im:message and
+// im:message.group_msg permissions.
+func (a *Adapter) FetchThread(ctx context.Context, incoming *im.IncomingMessage) (*im.ThreadTranscript, error) {
+	if !feishuSafePathParam(incoming.MessageID) {
+		return nil, fmt.Errorf("invalid message_id: %q", incoming.MessageID)
+	}
+	accessToken, err := a.getTenantAccessToken(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get access token: %w", err)
+	}
+
+	anchor, err := a.getMessage(ctx, accessToken, incoming.MessageID)
+	if err != nil {
+		return nil, err
+	}
+	root := anchor
+	if anchor.RootID != "" && anchor.RootID != anchor.MessageID {
+		if !feishuSafePathParam(anchor.RootID) {
+			return nil, fmt.Errorf("invalid root_id: %q", anchor.RootID)
+		}
+		if root, err = a.getMessage(ctx, accessToken, anchor.RootID); err != nil {
+			return nil, err
+		}
+	}
+
+	threadID := root.ThreadID
+	if threadID == "" {
+		threadID = anchor.ThreadID
+	}
+	var replies []feishuThreadMessage
+	if threadID != "" {
+		replies, err = a.listMessages(ctx, accessToken, url.Values{
+			"container_id_type": {"thread"},
+			"container_id":      {threadID},
+		}, nil)
+	} else {
+		rootID := root.MessageID
+		start := feishuMillis(root.CreateTime).Unix()
+		replies, err = a.listMessages(ctx, accessToken, url.Values{
+			"container_id_type": {"chat"},
+			"container_id":      {root.ChatID},
+			"start_time":        {strconv.FormatInt(start, 10)},
+		}, func(m feishuThreadMessage) bool { return m.RootID == rootID })
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	messages := []feishuThreadMessage{root}
+	for _, m := range replies {
+		if m.MessageID != root.MessageID && !m.Deleted {
+			messages = append(messages, m)
+		}
+	}
+
+	transcript := &im.ThreadTranscript{
+		ChatID:        root.ChatID,
+		ThreadID:      root.MessageID,
+		RootMessageID: root.MessageID,
+	}
+	if a.region.AppLinkBaseURL != "" && root.ChatID != "" {
+		transcript.Permalink = a.region.AppLinkBaseURL + "/client/chat/open?openChatId=" + url.QueryEscape(root.ChatID)
+	}
+	names := a.resolveSenderNames(ctx, accessToken, messages)
+	for _, m := range messages {
+		transcript.Messages = append(transcript.Messages, im.ThreadMessage{
+			UserID:   m.Sender.ID,
+			UserName: names[m.Sender.ID],
+			Content:  feishuMessageText(m),
+			Time:     feishuMillis(m.CreateTime),
+			IsBot:    m.Sender.SenderType == "app",
+		})
+	}
+	return transcript, nil
+}
+
+// getMessage fetches one message: GET /open-apis/im/v1/messages/:message_id.
+func (a *Adapter) getMessage(ctx context.Context, accessToken, messageID string) (feishuThreadMessage, error) {
+	var result struct {
+		Code int    `json:"code"`
+		Msg  string `json:"msg"`
+		Data struct {
+			Items []feishuThreadMessage `json:"items"`
+		} `json:"data"`
+	}
+	if err := a.getJSON(ctx, accessToken, a.api("/open-apis/im/v1/messages/%s", messageID), &result); err != nil {
+		return feishuThreadMessage{}, fmt.Errorf("get message: %w", err)
+	}
+	if result.Code != 0 {
+		return feishuThreadMessage{}, fmt.Errorf("%s get message api error: code=%d msg=%s", a.region.Label, result.Code, result.Msg)
+	}
+	if len(result.Data.Items) == 0 {
+		return feishuThreadMessage{}, fmt.Errorf("message %s not found", messageID)
+	}
+	return result.Data.Items[0], nil
+}
+
+// listMessages pages through GET /open-apis/im/v1/messages oldest first,
+// keeping the messages accepted by keep (all when nil).
+func (a *Adapter) listMessages(
+	ctx context.Context, accessToken string, query url.Values, keep func(feishuThreadMessage) bool,
+) ([]feishuThreadMessage, error) {
+	query.Set("sort_type", "ByCreateTimeAsc")
+	query.Set("page_size", strconv.Itoa(threadPageSize))
+
+	var messages []feishuThreadMessage
+	for page := 0; page < maxThreadPages; page++ {
+		var result struct {
+			Code int    `json:"code"`
+			Msg  string `json:"msg"`
+			Data struct {
+				HasMore   bool                  `json:"has_more"`
+				PageToken string                `json:"page_token"`
+				Items     []feishuThreadMessage `json:"items"`
+			} `json:"data"`
+		}
+		if err := a.getJSON(ctx, accessToken, a.api("/open-apis/im/v1/messages?%s", query.Encode()), &result); err != nil {
+			return nil, fmt.Errorf("list messages: %w", err)
+		}
+		if result.Code != 0 {
+			return nil, fmt.Errorf("%s list messages api error: code=%d msg=%s", a.region.Label, result.Code, result.Msg)
+		}
+		for _, m := range result.Data.Items {
+			if keep == nil || keep(m) {
+				messages = append(messages, m)
+			}
+		}
+		if !result.Data.HasMore || result.Data.PageToken == "" {
+			break
+		}
+		query.Set("page_token", result.Data.PageToken)
+	}
+	return messages, nil
+}
+
+// resolveSenderNames looks up the names of the thread's human senders,
+// seeded with the names carried by @mentions. Contact lookups need the
+// contact:user.base:readonly permission and are best effort.
+func (a *Adapter) resolveSenderNames(ctx context.Context, accessToken string, messages []feishuThreadMessage) map[string]string {
+	names := make(map[string]string)
+	for _, m := range messages {
+		for _, mention := range m.Mentions {
+			if mention.ID != "" && mention.Name != "" {
+				names[mention.ID] = mention.Name
+			}
+		}
+	}
+	lookups := 0
+	for _, m := range messages {
+		id := m.Sender.ID
+		if m.Sender.SenderType != "user" || m.Sender.IDType != "open_id" || !feishuSafePathParam(id) {
+			continue
+		}
+		if _, known := names[id]; known || lookups >= maxThreadNameLookups {
+			continue
+		}
+		lookups++
+		names[id] = ""
+		var result struct {
+			Code int `json:"code"`
+			Data struct {
+				User struct {
+					Name string `json:"name"`
+				} `json:"user"`
+			} `json:"data"`
+		}
+		err := a.getJSON(ctx, accessToken, a.api("/open-apis/contact/v3/users/%s?user_id_type=open_id", id), &result)
+		if err != nil || result.Code != 0 {
+			logger.Debugf(ctx, "[%s] Look up user %s failed: err=%v code=%d", a.region.Label, id, err, result.Code)
+			continue
+		}
+		names[id] = result.Data.User.Name
+	}
+	return names
+}
+
+// getJSON performs an authorized GET and decodes the JSON response.
+func (a *Adapter) getJSON(ctx context.Context, accessToken, apiURL string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL, nil)
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("send request: %w", err)
+	}
+	defer resp.Body.Close()
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+	return nil
+}
+
+// feishuMessageText extracts plain text from a message body. Mentions become
+// @name; messages without text are reduced to a short placeholder.
+func feishuMessageText(m feishuThreadMessage) string {
+	names := make(map[string]string, len(m.Mentions))
+	for _, mention := range m.Mentions {
+		names[mention.Key] = mention.Name
+	}
+	replaceMentions := func(text string) string {
+		// Keep "@bot /archive" recognisable as a command so it can be
+		// dropped from the transcript.
+		trimmed := strings.TrimSpace(text)
+		for strings.HasPrefix(trimmed, "@_user_") {
+			_, rest, ok := strings.Cut(trimmed, " ")
+			if !ok {
+				break
+			}
+			trimmed = strings.TrimSpace(rest)
+		}
+		if strings.HasPrefix(trimmed, "/") {
+			return trimmed
+		}
+		return mentionKeyPattern.ReplaceAllStringFunc(text, func(key string) string {
+			if name := names[key]; name != "" {
+				return "@" + name
+			}
+			return "@"
+		})
+	}
+
+	content := m.Body.Content
+	switch m.MsgType {
+	case "text":
+		var body struct {
+			Text string `json:"text"`
+		}
+		if err := json.Unmarshal([]byte(content), &body); err != nil {
+			return ""
+		}
+		return strings.TrimSpace(replaceMentions(body.Text))
+	case "post", "interactive":
+		var parts []string
+		collectFeishuText(json.RawMessage(content), &parts)
+		return strings.TrimSpace(replaceMentions(strings.Join(parts, "\n")))
+	case "file":
+		var body struct {
+			FileName string `json:"file_name"`
+		}
+		_ = json.Unmarshal([]byte(content), &body)
+		return "[file: " + body.FileName + "]"
+	case "image":
+		return "[image]"
+	case "audio":
+		return "[audio]"
+	case "media":
+		return "[video]"
+	default:
+		return "[" + m.MsgType + "]"
+	}
+}
+
+// collectFeishuText gathers the "title" and "text" strings of a rich-text or
+// card body, in document order.
+func collectFeishuText(raw json.RawMessage, parts *[]string) {
+	var value any
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return
+	}
+	var walk func(v any)
+	walk = func(v any) {
+		switch node := v.(type) {
+		case map[string]any:
+			for _, key := range []string{"title", "text", "content"} {
+				if s, ok := node[key].(string); ok && strings.TrimSpace(s) != "" {
+					*parts = append(*parts, strings.TrimSpace(s))
+				}
+			}
+			keys := make([]string, 0, len(node))
+			for key := range node {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				if _, isString := node[key].(string); !isString {
+					walk(node[key])
+				}
+			}
+		case []any:
+			for _, child := range node {
+				walk(child)
+			}
+		}
+	}
+	walk(value)
+}
+
+// feishuMillis parses a millisecond Unix timestamp string.
+func feishuMillis(ms string) time.Time {
+	v, err := strconv.ParseInt(ms, 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	return time.UnixMilli(v)
+}
@@ -0,0 +1,149 @@
+package feishu
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/im"
+	"github.com/gin-gonic/gin"
+)
+
+func TestParseCallback_Reaction(t *testing.T) {
+	a, _ := NewAdapter(RegionFeishu, "cli_app", "secret", "", "", "")
+	parse := func(operator string) *im.IncomingMessage {
+		body := `{"schema":"2.0","header":{"event_type":"im.message.reaction.created_v1"},` +
+			`"event":{"message_id":"om_1","reaction_type":{"emoji_type":"DONE"},` +
+			`"operator_type":"` + operator + `","user_id":{"open_id":"ou_1"}}}`
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodPost, "/callback", strings.NewReader(body))
+		msg, err := a.ParseCallback(c)
+		if err != nil {
+			t.Fatalf("ParseCallback: %v", err)
+		}
+		return msg
+	}
+
+	msg := parse("user")
+	if msg == nil {
+		t.Fatal("expected a reaction message")
+	}
+	if msg.MessageType != im.MessageTypeReaction || msg.Content != "DONE" || msg.MessageID != "om_1" || msg.UserID != "ou_1" {
+		t.Errorf("unexpected message: %+v", msg)
+	}
+	if msg.Platform != im.PlatformFeishu {
+		t.Errorf("Platform = %q, want %q", msg.Platform, im.PlatformFeishu)
+	}
+
+	if msg := parse("app"); msg != nil {
+		t.Errorf("app reactions should be ignored, got %+v", msg)
+	}
+}
+
+func TestFeishuMessageText(t *testing.T) {
+	tests := []struct {
+		name    string
+		msgType string
+		content string
+		want    string
+	}{
+		{"text with mention", "text", `{"text":"ask @_user_1 about it"}`, "ask @Alice about it"},
+		{"leading mention before command", "text", `{"text":"@_user_2 /archive"}`, "/archive"},
+		{"post", "post", `{"title":"Release","content":[[{"tag":"text","text":"v2 is out"}]]}`, "Release\nv2 is out"},
+		{"file", "file", `{"file_key":"k","file_name":"notes.pdf"}`, "[file: notes.pdf]"},
+		{"image", "image", `{"image_key":"k"}`, "[image]"},
+		{"sticker", "sticker", `{}`, "[sticker]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := feishuThreadMessage{MsgType: tt.msgType}
+			m.Body.Content = tt.content
+			m.Mentions = []feishuThreadMention{
+				{Key: "@_user_1", ID: "ou_a", Name: "Alice"},
+				{Key: "@_user_2", ID: "ou_bot", Name: "WeKnora"},
+			}
+			if got := feishuMessageText(m); got != tt.want {
+				t.Errorf("feishuMessageText() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// FetchThread must resolve the root of a reply chain and collect the replies
+// that point at it from the chat history, skipping unrelated and deleted
+// messages.
+func TestFetchThread_ReplyChain(t *testing.T) {
+	useTestHTTPClient(t)
+	message := func(id, root, createTime, sender, senderType, text string, deleted bool) map[string]any {
+		content, _ := json.Marshal(map[string]string{"text": text})
+		return map[string]any{
+			"message_id": id, "root_id": root, "chat_id": "oc_1", "msg_type": "text",
+			"create_time": createTime, "deleted": deleted,
+			"sender": map[string]any{"id": sender, "id_type": "open_id", "sender_type": senderType},
+			"body":   map[string]any{"content": string(content)},
+		}
+	}
+	var listQuery string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/open-apis/auth/v3/tenant_access_token/internal":
+			_ = json.NewEncoder(w).Encode(map[string]any{"code": 0, "tenant_access_token": "t-1", "expire": 7200})
+		case r.URL.Path == "/open-apis/im/v1/messages/om_reply":
+			_ = json.NewEncoder(w).Encode(map[string]any{"code": 0, "data": map[string]any{"items": []any{
+				message("om_reply", "om_root", "1700000060000", "ou_b", "user", "restart the worker", false),
+			}}})
+		case r.URL.Path == "/open-apis/im/v1/messages/om_root":
+			_ = json.NewEncoder(w).Encode(map[string]any{"code": 0, "data": map[string]any{"items": []any{
+				message("om_root", "", "1700000000000", "ou_a", "user", "jobs are stuck", false),
+			}}})
+		case r.URL.Path == "/open-apis/im/v1/messages":
+			listQuery = r.URL.RawQuery
+			_ = json.NewEncoder(w).Encode(map[string]any{"code": 0, "data": map[string]any{"has_more": false, "items": []any{
+				message("om_root", "", "1700000000000", "ou_a", "user", "jobs are stuck", false),
+				message("om_other", "", "1700000030000", "ou_c", "user", "lunch?", false),
+				message("om_reply", "om_root", "1700000060000", "ou_b", "user", "restart the worker", false),
+				message("om_gone", "om_root", "1700000070000", "ou_b", "user", "oops", true),
+				message("om_bot", "om_root", "1700000090000", "cli_app", "app", "done", false),
+			}}})
+		case strings.HasPrefix(r.URL.Path, "/open-apis/contact/v3/users/"):
+			name := map[string]string{"ou_a": "Alice", "ou_b": "Bob"}[strings.TrimPrefix(r.URL.Path, "/open-apis/contact/v3/users/")]
+			_ = json.NewEncoder(w).Encode(map[string]any{"code": 0, "data": map[string]any{"user": map[string]any{"name": name}}})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	a, _ := NewAdapter(testRegion(srv.URL), "cli_app", "secret", "", "", "")
+	transcript, err := a.FetchThread(context.Background(), &im.IncomingMessage{MessageID: "om_reply"})
+	if err != nil {
+		t.Fatalf("FetchThread: %v", err)
+	}
+
+	if !strings.Contains(listQuery, "container_id_type=chat") || !strings.Contains(listQuery, "start_time=1700000000") {
+		t.Errorf("list query = %q, want chat history from the root's create time", listQuery)
+	}
+	if transcript.ChatID != "oc_1" || transcript.ThreadID != "om_root" || transcript.RootMessageID != "om_root" {
+		t.Errorf("unexpected transcript ids: %+v", transcript)
+	}
+	if !strings.HasPrefix(transcript.Permalink, RegionLark.AppLinkBaseURL+"/client/chat/open?openChatId=oc_1") {
+		t.Errorf("Permalink = %q", transcript.Permalink)
+	}
+	var got []string
+	for _, m := range transcript.Messages {
+		got = append(got, m.UserName+":"+m.Content)
+	}
+	want := []string{"Alice:jobs are stuck", "Bob:restart the worker", ":done"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("messages = %q, want %q", got, want)
+	}
+	if !transcript.Messages[2].IsBot {
+		t.Error("app message should be marked as bot")
+	}
+	if transcript.Messages[1].Time.Unix() != 1700000060 {
+		t.Errorf("Time = %v", transcript.Messages[1].Time)
+	}
+}
@@ -57,7 +57,7 @@ func TestBuildIMFileServiceForProvider_FallbackToGlobal(t *testing.T) {
 		},
 	}
 
-	svc := buildIMFileServiceForProvider(tenant, "minio", stub)
+	svc := buildIMFileServiceForProvider(tenant, "minio", stub, nil)
 	require.NotNil(t, svc)
 	got, err := svc.GetFileURL(context.Background(), "minio://wizard-test/10000/exports/a.png")
 	require.NoError(t, err)
@@ -77,7 +77,7 @@ func TestIMFileServiceResolver_CachesPerProvider(t *testing.T) {
 			},
 		},
 	}
-	r := newIMFileServiceResolver(tenant, stub)
+	r := newIMFileServiceResolver(tenant, stub, nil)
 
 	svc1 := r.ResolveFileService("minio://wizard-test/10000/a.png")
 	svc2 := r.ResolveFileService("minio://wizard-test/10000/b.png")
@@ -105,7 +105,7 @@ func TestRewriteStorageURLs_MinIOFallbackViaGlobal(t *testing.T) {
 		},
 	}
 	in := `![知识助理"知识库"管理视图界面](minio://wizard-test/10000/exports/c91cf852.png)`
-	resolver := newIMFileServiceResolver(tenant, stub)
+	resolver := newIMFileServiceResolver(tenant, stub, nil)
 	out := rewriteStorageURLs(context.Background(), in, resolver)
 	assert.Contains(t, out, "https://minio.example/presigned")
 	assert.NotContains(t, out, "](minio://")
@@ -119,7 +119,7 @@ func TestRewriteStorageURLs_ScopedPath(t *testing.T) {
 		},
 	}
 	input := "![img](storage://backend-a/cos://bucket/ap-test/10000/exports/a.png)"
-	output := rewriteStorageURLs(context.Background(), input, newIMFileServiceResolver(&types.Tenant{}, stub))
+	output := rewriteStorageURLs(context.Background(), input, newIMFileServiceResolver(&types.Tenant{}, stub, nil))
 	assert.Contains(t, output, "https://storage.example/a.png")
 }
 
@@ -133,7 +133,7 @@ func TestRewriteStorageURLs_NonHTTPResultIsNoOp(t *testing.T) {
 		},
 	}
 	in := "![img](resource://xifDo7NTSL300Lp1goVutw)"
-	out := rewriteStorageURLs(context.Background(), in, newIMFileServiceResolver(&types.Tenant{}, stub))
+	out := rewriteStorageURLs(context.Background(), in, newIMFileServiceResolver(&types.Tenant{}, stub, nil))
 	assert.Equal(t, in, out)
 	assert.NotContains(t, out, "storage://")
 }
@@ -148,7 +148,7 @@ func TestRewriteStorageURLs_UppercaseSchemeIsSubstituted(t *testing.T) {
 		},
 	}
 	in := "![img](resource://xifDo7NTSL300Lp1goVutw)"
-	out := rewriteStorageURLs(context.Background(), in, newIMFileServiceResolver(&types.Tenant{}, stub))
+	out := rewriteStorageURLs(context.Background(), in, newIMFileServiceResolver(&types.Tenant{}, stub, nil))
 	assert.Contains(t, out, "HTTPS://cdn.example.com/x.png")
 	assert.NotContains(t, out, "resource://")
 }
@@ -163,6 +163,6 @@ func TestCleanIMContent_MinIOFallbackIntegration(t *testing.T) {
 		StorageEngineConfig: &types.StorageEngineConfig{DefaultProvider: "cos"},
 	}
 	in := "see ![x](minio://wizard-test/10000/exports/x.png) ok"
-	out := cleanIMContent(context.Background(), in, tenant, stub)
+	out := cleanIMContent(context.Background(), in, tenant, stub, nil)
 	assert.Contains(t, out, "https://minio.example/img.png")
 }
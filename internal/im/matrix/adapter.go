@@ -0,0 +1,436 @@
+package matrix
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Tencent/WeKnora/internal/im"
+	"github.com/Tencent/WeKnora/internal/logger"
+)
+
+// Compile-time checks.
+var (
+	_ im.Adapter        = (*Adapter)(nil)
+	_ im.StreamSender   = (*Adapter)(nil)
+	_ im.FileDownloader = (*Adapter)(nil)
+)
+
+// minEditInterval keeps stream edits under typical homeserver message rate
+// limits; every edit is a new event in the room.
+const minEditInterval = time.Second
+
+// Adapter implements im.Adapter for Matrix. Messages arrive through the
+// /sync long-poll loop; replies are sent as room events.
+type Adapter struct {
+	client *Client
+	rooms  *roomState
+	// threadMode answers each top-level trigger message in a new thread
+	// (m.thread relation), so each thread maps to one session.
+	threadMode bool
+
+	streamsMu sync.Mutex
+	streams   map[string]*streamState
+}
+
+// NewAdapter creates a Matrix adapter.
+func NewAdapter(client *Client, rooms *roomState, threadMode bool) *Adapter {
+	return &Adapter{
+		client:     client,
+		rooms:      rooms,
+		threadMode: threadMode,
+		streams:    map[string]*streamState{},
+	}
+}
+
+func (a *Adapter) Platform() im.Platform {
+	return im.PlatformMatrix
+}
+
+func (a *Adapter) HandleURLVerification(c *gin.Context) bool {
+	return false
+}
+
+func (a *Adapter) VerifyCallback(c *gin.Context) error {
+	return fmt.Errorf("matrix channels receive messages through /sync, not callbacks")
+}
+
+func (a *Adapter) ParseCallback(c *gin.Context) (*im.IncomingMessage, error) {
+	return nil, fmt.Errorf("matrix channels receive messages through /sync, not callbacks")
+}
+
+// parseEvent converts a timeline event. In group rooms the bot only answers
+// when mentioned or inside a thread it has answered in.
+func parseEvent(roomID string, ev *roomEvent, direct bool, rooms *roomState) *im.IncomingMessage {
+	botUserID, botName := rooms.identity()
+	if ev == nil || ev.Type != eventRoomMessage || ev.StateKey != nil || ev.Sender == "" || ev.Sender == botUserID {
+		return nil
+	}
+	content := &ev.Content
+	if content.MsgType == msgTypeNotice || (content.RelatesTo != nil && content.RelatesTo.RelType == relReplace) {
+		// Notices are what other bots send; edits must not re-trigger.
+		return nil
+	}
+	threadRoot := content.RelatesTo.threadRoot()
+
+	if !direct && !mentionsBot(content, botUserID, botName) && (threadRoot == "" || !rooms.isBotThread(roomID, threadRoot)) {
+		return nil
+	}
+
+	body, quote := content.Body, (*im.QuotedMessage)(nil)
+	if replyTo := content.RelatesTo.replyTo(); replyTo != "" && !content.RelatesTo.IsFallingBack {
+		body, quote = stripReplyFallback(body)
+		if quote != nil {
+			quote.MessageID = replyTo
+			quote.IsBotMessage = quote.SenderID == botUserID
+		}
+	}
+
+	msg := &im.IncomingMessage{
+		Platform:    im.PlatformMatrix,
+		MessageType: im.MessageTypeText,
+		UserID:      ev.Sender,
+		UserName:    ev.Sender,
+		ChatType:    im.ChatTypeDirect,
+		MessageID:   ev.EventID,
+		Extra:       map[string]string{extraKeyRoomID: roomID},
+		Quote:       quote,
+	}
+	if threadRoot != "" {
+		msg.Extra[extraKeyThreadRoot] = threadRoot
+	}
+	if !direct {
+		// The thread root and every event in its thread resolve to the same
+		// ThreadID, so a thread is one session.
+		msg.ChatType = im.ChatTypeGroup
+		msg.ChatID = roomID
+		msg.ThreadID = ev.EventID
+		if threadRoot != "" {
+			msg.ThreadID = threadRoot
+		}
+	}
+
+	switch content.MsgType {
+	case msgTypeImage, msgTypeFile, msgTypeAudio, msgTypeVideo:
+		if content.URL == "" {
+			// Encrypted attachments carry a "file" object instead.
+			return nil
+		}
+		switch content.MsgType {
+		case msgTypeImage:
+			msg.MessageType = im.MessageTypeImage
+		case msgTypeAudio:
+			msg.MessageType = im.MessageTypeAudio
+		default:
+			msg.MessageType = im.MessageTypeFile
+		}
+		msg.FileKey = content.URL
+		msg.FileName = content.Body
+		if content.Filename != "" {
+			// With an explicit filename the body is a caption.
+			msg.FileName = content.Filename
+			if body != content.Filename {
+				msg.Content = stripBotMention(body, botUserID, botName)
+			}
+		}
+		if content.Info != nil {
+			msg.FileSize = content.Info.Size
+		}
+	default:
+		msg.Content = stripBotMention(body, botUserID, botName)
+	}
+	if msg.Content == "" && msg.FileKey == "" {
+		return nil
+	}
+	return msg
+}
+
+// mentionsBot prefers intentional mentions (m.mentions); for clients that
+// predate them it falls back to the user ID or display name in the text.
+func mentionsBot(content *messageContent, botUserID, botName string) bool {
+	if content.Mentions != nil {
+		return slices.Contains(content.Mentions.UserIDs, botUserID)
+	}
+	if strings.Contains(content.Body, botUserID) || strings.Contains(content.FormattedBody, "matrix.to/#/"+botUserID) {
+		return true
+	}
+	return botName != "" && strings.HasPrefix(strings.TrimSpace(content.Body), botName)
+}
+
+// stripBotMention removes the bot's ID and a leading "Name:" pill fallback.
+func stripBotMention(body, botUserID, botName string) string {
+	body = strings.TrimSpace(strings.ReplaceAll(body, botUserID, ""))
+	if botName != "" {
+		body = strings.TrimPrefix(body, botName)
+	}
+	return strings.TrimSpace(strings.TrimLeft(body, ":：,， "))
+}
+
+// stripReplyFallback removes the "> <@sender> quoted text" lines older
+// clients prepend to replies and returns them as the quoted message.
+func stripReplyFallback(body string) (string, *im.QuotedMessage) {
+	if !strings.HasPrefix(body, "> ") {
+		return body, nil
+	}
+	lines := strings.Split(body, "\n")
+	var quoted []string
+	i := 0
+	for ; i < len(lines) && strings.HasPrefix(lines[i], ">"); i++ {
+		quoted = append(quoted, strings.TrimPrefix(strings.TrimPrefix(lines[i], ">"), " "))
+	}
+	rest := strings.TrimSpace(strings.Join(lines[i:], "\n"))
+	quote := &im.QuotedMessage{}
+	first := quoted[0]
+	if strings.HasPrefix(first, "<") {
+		if end := strings.Index(first, ">"); end > 0 {
+			quote.SenderID = first[1:end]
+			quoted[0] = strings.TrimSpace(first[end+1:])
+		}
+	}
+	quote.Content = strings.TrimSpace(strings.Join(quoted, "\n"))
+	return rest, quote
+}
+
+// ── Send reply ──
+
+// replyRelation returns the relation of the first answer event. Threaded
+// questions are answered in their thread; in thread mode a top-level group
+// question starts a thread rooted at itself. Otherwise the answer is a
+// plain reply.
+func (a *Adapter) replyRelation(incoming *im.IncomingMessage) *relatesTo {
+	root := incoming.Extra[extraKeyThreadRoot]
+	if root == "" && a.threadMode && incoming.ChatType == im.ChatTypeGroup {
+		root = incoming.MessageID
+	}
+	if root == "" {
+		return &relatesTo{InReplyTo: &inReplyTo{EventID: incoming.MessageID}}
+	}
+	a.rooms.addBotThread(incoming.Extra[extraKeyRoomID], root)
+	return &relatesTo{
+		RelType:       relThread,
+		EventID:       root,
+		IsFallingBack: true,
+		InReplyTo:     &inReplyTo{EventID: incoming.MessageID},
+	}
+}
+
+// followRelation keeps overflow parts in the same thread as the answer.
+func followRelation(rel *relatesTo, previousEventID string) *relatesTo {
+	if rel == nil || rel.RelType != relThread {
+		return nil
+	}
+	return &relatesTo{
+		RelType:       relThread,
+		EventID:       rel.EventID,
+		IsFallingBack: true,
+		InReplyTo:     &inReplyTo{EventID: previousEventID},
+	}
+}
+
+func textContent(text string, rel *relatesTo) *messageContent {
+	// An empty m.mentions tells clients the answer pings nobody, even if it
+	// quotes user IDs.
+	return &messageContent{MsgType: msgTypeText, Body: text, RelatesTo: rel, Mentions: &mentions{}}
+}
+
+func (a *Adapter) SendReply(ctx context.Context, incoming *im.IncomingMessage, reply *im.ReplyMessage) error {
+	roomID := incoming.Extra[extraKeyRoomID]
+	if roomID == "" {
+		return fmt.Errorf("missing matrix room_id")
+	}
+	parts := im.SplitIMMessage(im.FormatIMDisplayContent(reply.Content, im.StreamDisplayFinal), maxMessageRunes)
+	rel := a.replyRelation(incoming)
+	for _, part := range parts {
+		eventID, err := a.client.SendMessage(ctx, roomID, textContent(part, rel))
+		if err != nil {
+			return err
+		}
+		rel = followRelation(rel, eventID)
+	}
+	return nil
+}
+
+// ── StreamSender implementation (edit message in-place) ──
+
+type streamState struct {
+	mu       sync.Mutex
+	roomID   string
+	eventID  string
+	rel      *relatesTo
+	lastEdit time.Time
+}
+
+func (a *Adapter) StartStream(ctx context.Context, incoming *im.IncomingMessage) (string, error) {
+	roomID := incoming.Extra[extraKeyRoomID]
+	if roomID == "" {
+		return "", fmt.Errorf("missing matrix room_id")
+	}
+	rel := a.replyRelation(incoming)
+	eventID, err := a.client.SendMessage(ctx, roomID, textContent("正在思考...", rel))
+	if err != nil {
+		return "", fmt.Errorf("matrix start stream: %w", err)
+	}
+	streamID := roomID + "|" + eventID
+
+	a.streamsMu.Lock()
+	a.streams[streamID] = &streamState{roomID: roomID, eventID: eventID, rel: followRelation(rel, eventID)}
+	a.streamsMu.Unlock()
+
+	logger.Infof(ctx, "[Matrix] Streaming started: stream_id=%s", streamID)
+	return streamID, nil
+}
+
+func (a *Adapter) getStream(streamID string) (*streamState, error) {
+	a.streamsMu.Lock()
+	defer a.streamsMu.Unlock()
+	state, ok := a.streams[streamID]
+	if !ok {
+		return nil, fmt.Errorf("unknown stream ID: %s", streamID)
+	}
+	return state, nil
+}
+
+func (a *Adapter) UpdateStreamContent(ctx context.Context, incoming *im.IncomingMessage, streamID string, fullContent string) error {
+	if fullContent == "" {
+		return nil
+	}
+	state, err := a.getStream(streamID)
+	if err != nil {
+		return err
+	}
+
+	state.mu.Lock()
+	if time.Since(state.lastEdit) < minEditInterval {
+		state.mu.Unlock()
+		return nil
+	}
+	state.lastEdit = time.Now()
+	state.mu.Unlock()
+
+	if err := a.client.EditMessage(ctx, state.roomID, state.eventID, im.ClampIMStreamContent(fullContent, maxMessageRunes)); err != nil {
+		logger.Warnf(ctx, "[Matrix] Failed to update stream content: %v", err)
+	}
+	return nil
+}
+
+// FinalizeStream puts the first part of the answer into the stream event
+// and sends whatever exceeds the per-event limit as follow-up events.
+func (a *Adapter) FinalizeStream(ctx context.Context, incoming *im.IncomingMessage, streamID string, finalContent string) error {
+	state, err := a.getStream(streamID)
+	if err != nil {
+		return err
+	}
+	parts := im.SplitIMMessage(finalContent, maxMessageRunes)
+	if err := a.client.EditMessage(ctx, state.roomID, state.eventID, parts[0]); err != nil {
+		logger.Warnf(ctx, "[Matrix] Failed to finalize stream: %v", err)
+	}
+	rel := state.rel
+	for _, part := range parts[1:] {
+		eventID, err := a.client.SendMessage(ctx, state.roomID, textContent(part, rel))
+		if err != nil {
+			logger.Warnf(ctx, "[Matrix] Failed to send overflow part: %v", err)
+			break
+		}
+		rel = followRelation(rel, eventID)
+	}
+	return nil
+}
+
+func (a *Adapter) EndStream(ctx context.Context, incoming *im.IncomingMessage, streamID string) error {
+	a.streamsMu.Lock()
+	_, ok := a.streams[streamID]
+	delete(a.streams, streamID)
+	a.streamsMu.Unlock()
+	if ok {
+		logger.Infof(ctx, "[Matrix] Streaming ended: stream_id=%s", streamID)
+	}
+	return nil
+}
+
+// ── FileDownloader implementation ──
+
+func (a *Adapter) DownloadFile(ctx context.Context, msg *im.IncomingMessage) (io.ReadCloser, string, error) {
+	if msg.FileKey == "" {
+		return nil, "", fmt.Errorf("file_key is required")
+	}
+	rc, err := a.client.Download(ctx, msg.FileKey)
+	if err != nil {
+		return nil, "", err
+	}
+	name := msg.FileName
+	if name == "" {
+		name = "attachment"
+	}
+	return rc, name, nil
+}
+
+// ── Room state ──
+
+// maxTrackedEntries bounds the room caches; member counts are re-fetched
+// and bot threads re-learned after a reset.
+const maxTrackedEntries = 10000
+
+// roomState is shared by the sync loop and the adapter: the bot's identity,
+// the joined member count per room (two members means a direct chat) and
+// the threads the bot has answered in.
+type roomState struct {
+	mu          sync.Mutex
+	botUserID   string
+	displayName string
+	members     map[string]int
+	botThreads  map[string]struct{}
+}
+
+func newRoomState(botUserID string) *roomState {
+	return &roomState{botUserID: botUserID, members: map[string]int{}, botThreads: map[string]struct{}{}}
+}
+
+func (r *roomState) identity() (string, string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.botUserID, r.displayName
+}
+
+func (r *roomState) setDisplayName(name string) {
+	r.mu.Lock()
+	r.displayName = strings.TrimSpace(name)
+	r.mu.Unlock()
+}
+
+func (r *roomState) memberCount(roomID string) (int, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n, ok := r.members[roomID]
+	return n, ok
+}
+
+func (r *roomState) setMemberCount(roomID string, n int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.members) >= maxTrackedEntries {
+		r.members = map[string]int{}
+	}
+	r.members[roomID] = n
+}
+
+func (r *roomState) addBotThread(roomID, root string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.botThreads) >= maxTrackedEntries {
+		r.botThreads = map[string]struct{}{}
+	}
+	r.botThreads[roomID+"|"+root] = struct{}{}
+}
+
+func (r *roomState) isBotThread(roomID, root string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, ok := r.botThreads[roomID+"|"+root]
+	return ok
+}
@@ -0,0 +1,266 @@
+package matrix
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/im"
+	secutils "github.com/Tencent/WeKnora/internal/utils"
+)
+
+const botUserID = "@weknora:example.org"
+
+type recordedCall struct {
+	Method string
+	Path   string
+	Body   messageContent
+}
+
+// fakeHomeserver records client-server API calls. The DM room has two
+// members; events get sequential IDs.
+type fakeHomeserver struct {
+	*httptest.Server
+	mu    sync.Mutex
+	calls []recordedCall
+}
+
+func newFakeHomeserver(t *testing.T) *fakeHomeserver {
+	t.Helper()
+	t.Setenv("SSRF_WHITELIST", "127.0.0.1")
+	secutils.ResetSSRFWhitelistForTest()
+	t.Cleanup(secutils.ResetSSRFWhitelistForTest)
+
+	hs := &fakeHomeserver{}
+	hs.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer syt_token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		raw, _ := io.ReadAll(r.Body)
+		var body messageContent
+		_ = json.Unmarshal(raw, &body)
+		hs.mu.Lock()
+		hs.calls = append(hs.calls, recordedCall{Method: r.Method, Path: r.URL.EscapedPath(), Body: body})
+		n := len(hs.calls)
+		hs.mu.Unlock()
+
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/joined_members"):
+			_ = json.NewEncoder(w).Encode(map[string]any{"joined": map[string]any{botUserID: map[string]any{}, "@alice:example.org": map[string]any{}}})
+		case strings.Contains(r.URL.Path, "/send/"):
+			_ = json.NewEncoder(w).Encode(map[string]string{"event_id": "$sent" + string(rune('0'+n)) + ":example.org"})
+		default:
+			_, _ = w.Write([]byte("{}"))
+		}
+	}))
+	t.Cleanup(hs.Close)
+	return hs
+}
+
+func (hs *fakeHomeserver) sends() []recordedCall {
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+	var out []recordedCall
+	for _, c := range hs.calls {
+		if strings.Contains(c.Path, "/send/") {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+func loadSync(t *testing.T) *syncResponse {
+	t.Helper()
+	raw, err := os.ReadFile(filepath.Join("testdata", "sync_response.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var resp syncResponse
+	if err := json.Unmarshal(raw, &resp); err != nil {
+		t.Fatal(err)
+	}
+	return &resp
+}
+
+func newTestSync(t *testing.T, hs *fakeHomeserver) (*LongConnClient, *Client, *roomState, map[string]*im.IncomingMessage) {
+	t.Helper()
+	client, err := NewClient(hs.URL, "syt_token", botUserID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rooms := newRoomState(botUserID)
+	rooms.setDisplayName("WeKnora")
+	got := map[string]*im.IncomingMessage{}
+	conn := NewLongConnClient(client, rooms, func(_ context.Context, msg *im.IncomingMessage) error {
+		got[msg.MessageID] = msg
+		return nil
+	})
+	return conn, client, rooms, got
+}
+
+func TestProcessSync(t *testing.T) {
+	hs := newFakeHomeserver(t)
+	conn, _, rooms, got := newTestSync(t, hs)
+	rooms.addBotThread("!eng:example.org", "$root:example.org")
+
+	conn.processSync(context.Background(), loadSync(t), false)
+
+	if len(got) != 4 {
+		t.Fatalf("dispatched %d messages, want mention, thread follow-up, file and reply: %v", len(got), got)
+	}
+	mention := got["$mention:example.org"]
+	if mention == nil || mention.Content != "what is the on-call escalation policy?" || mention.ChatType != im.ChatTypeGroup {
+		t.Fatalf("mention = %+v", mention)
+	}
+	if mention.ChatID != "!eng:example.org" || mention.ThreadID != "$mention:example.org" {
+		t.Errorf("mention chat/thread = %q %q", mention.ChatID, mention.ThreadID)
+	}
+	followup := got["$followup:example.org"]
+	if followup == nil || followup.ThreadID != "$root:example.org" || followup.Extra[extraKeyThreadRoot] != "$root:example.org" {
+		t.Errorf("thread follow-up = %+v", followup)
+	}
+	file := got["$file:example.org"]
+	if file == nil || file.ChatType != im.ChatTypeDirect || file.ChatID != "" || file.Extra[extraKeyRoomID] != "!dm:example.org" || file.MessageType != im.MessageTypeFile {
+		t.Fatalf("file = %+v", file)
+	}
+	if file.FileKey != "mxc://example.org/RunbookMediaId" || file.FileName != "runbook.pdf" || file.FileSize != 48213 || file.Content != "please index this" {
+		t.Errorf("file fields = %+v", file)
+	}
+	reply := got["$reply:example.org"]
+	if reply == nil || reply.Content != "who is secondary this week?" || reply.Quote == nil {
+		t.Fatalf("reply = %+v", reply)
+	}
+	if !reply.Quote.IsBotMessage || reply.Quote.MessageID != "$prev:example.org" || reply.Quote.Content != "Escalate to the secondary after 15 minutes." {
+		t.Errorf("quote = %+v", reply.Quote)
+	}
+
+	var joined, memberLookups int
+	for _, c := range hs.calls {
+		if strings.Contains(c.Path, "/join/") {
+			joined++
+		}
+		if strings.HasSuffix(c.Path, "/joined_members") {
+			memberLookups++
+		}
+	}
+	if joined != 1 || memberLookups != 1 {
+		t.Errorf("joins=%d member lookups=%d, want 1 and 1 (group count comes from the summary)", joined, memberLookups)
+	}
+}
+
+func TestProcessSync_InitialSyncSkipsHistory(t *testing.T) {
+	hs := newFakeHomeserver(t)
+	conn, _, _, got := newTestSync(t, hs)
+	conn.processSync(context.Background(), loadSync(t), true)
+	if len(got) != 0 {
+		t.Errorf("initial sync dispatched %d messages", len(got))
+	}
+}
+
+func TestParseEvent_UnknownThreadNeedsMention(t *testing.T) {
+	rooms := newRoomState(botUserID)
+	resp := loadSync(t)
+	events := resp.Rooms.Join["!eng:example.org"].Timeline.Events
+	if msg := parseEvent("!eng:example.org", &events[2], false, rooms); msg != nil {
+		t.Errorf("message in a thread the bot never answered must be ignored: %+v", msg)
+	}
+}
+
+func TestStreamThreadModeAndOverflow(t *testing.T) {
+	hs := newFakeHomeserver(t)
+	client, err := NewClient(hs.URL, "syt_token", botUserID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rooms := newRoomState(botUserID)
+	adapter := NewAdapter(client, rooms, true)
+	incoming := &im.IncomingMessage{
+		Platform:  im.PlatformMatrix,
+		ChatType:  im.ChatTypeGroup,
+		ChatID:    "!eng:example.org",
+		ThreadID:  "$q:example.org",
+		MessageID: "$q:example.org",
+		Extra:     map[string]string{extraKeyRoomID: "!eng:example.org"},
+	}
+	ctx := context.Background()
+
+	streamID, err := adapter.StartStream(ctx, incoming)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := adapter.UpdateStreamContent(ctx, incoming, streamID, strings.Repeat("步骤\n", 6000)); err != nil {
+		t.Fatal(err)
+	}
+	final := strings.Repeat("a", 7000) + "\n\n" + strings.Repeat("b", 3000)
+	if err := adapter.FinalizeStream(ctx, incoming, streamID, final); err != nil {
+		t.Fatal(err)
+	}
+	_ = adapter.EndStream(ctx, incoming, streamID)
+
+	sends := hs.sends()
+	if len(sends) != 4 {
+		t.Fatalf("sends = %d, want start, edit, final edit, overflow", len(sends))
+	}
+	start := sends[0].Body.RelatesTo
+	if start == nil || start.RelType != relThread || start.EventID != "$q:example.org" || start.replyTo() != "$q:example.org" {
+		t.Errorf("thread mode must open a thread on the question: %+v", start)
+	}
+	if !rooms.isBotThread("!eng:example.org", "$q:example.org") {
+		t.Error("answered thread must be remembered so follow-ups without a mention are accepted")
+	}
+	for _, edit := range sends[1:3] {
+		rel := edit.Body.RelatesTo
+		if rel == nil || rel.RelType != relReplace || rel.EventID != "$sent1:example.org" || edit.Body.NewContent == nil {
+			t.Errorf("edit relation = %+v", rel)
+			continue
+		}
+		if n := len([]rune(edit.Body.NewContent.Body)); n > maxMessageRunes {
+			t.Errorf("edit exceeds limit: %d runes", n)
+		}
+	}
+	overflow := sends[3].Body
+	if overflow.Body != strings.Repeat("b", 3000) || overflow.RelatesTo == nil || overflow.RelatesTo.threadRoot() != "$q:example.org" {
+		t.Errorf("overflow must continue in the thread: body=%d runes rel=%+v", len([]rune(overflow.Body)), overflow.RelatesTo)
+	}
+}
+
+func TestSendReplyPlainReply(t *testing.T) {
+	hs := newFakeHomeserver(t)
+	client, _ := NewClient(hs.URL, "syt_token", botUserID)
+	adapter := NewAdapter(client, newRoomState(botUserID), false)
+	incoming := &im.IncomingMessage{ChatType: im.ChatTypeDirect, MessageID: "$q:example.org", Extra: map[string]string{extraKeyRoomID: "!dm:example.org"}}
+
+	if err := adapter.SendReply(context.Background(), incoming, &im.ReplyMessage{Content: "hello"}); err != nil {
+		t.Fatal(err)
+	}
+	sends := hs.sends()
+	if len(sends) != 1 {
+		t.Fatalf("sends = %d", len(sends))
+	}
+	rel := sends[0].Body.RelatesTo
+	if rel == nil || rel.RelType != "" || rel.replyTo() != "$q:example.org" {
+		t.Errorf("direct answers are plain replies: %+v", rel)
+	}
+	if sends[0].Body.Mentions == nil || len(sends[0].Body.Mentions.UserIDs) != 0 {
+		t.Errorf("answers must carry an empty m.mentions: %+v", sends[0].Body.Mentions)
+	}
+}
+
+func TestParseMXC(t *testing.T) {
+	if s, m, ok := parseMXC("mxc://example.org/abc"); !ok || s != "example.org" || m != "abc" {
+		t.Errorf("parseMXC = %q %q %v", s, m, ok)
+	}
+	for _, bad := range []string{"https://example.org/abc", "mxc://example.org", "mxc://example.org/a/../b"} {
+		if _, _, ok := parseMXC(bad); ok {
+			t.Errorf("parseMXC(%q) accepted", bad)
+		}
+	}
+}
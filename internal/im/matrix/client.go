@@ -0,0 +1,254 @@
+package matrix
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	secutils "github.com/Tencent/WeKnora/internal/utils"
+)
+
+// maxRateLimitWait caps how long one call waits on M_LIMIT_EXCEEDED before
+// giving up; stream edits are throttled anyway.
+const maxRateLimitWait = 3 * time.Second
+
+// Client calls the Matrix client-server API as the bot user.
+type Client struct {
+	homeserver  string
+	accessToken string
+	userID      string
+	httpClient  *http.Client
+	txnSeq      atomic.Uint64
+}
+
+// NewClient builds a client for a homeserver. userID is the bot's full
+// Matrix ID (@bot:example.org) and is used to recognise its own events and
+// mentions.
+func NewClient(homeserverURL, accessToken, userID string) (*Client, error) {
+	homeserverURL = strings.TrimRight(strings.TrimSpace(homeserverURL), "/")
+	accessToken, userID = strings.TrimSpace(accessToken), strings.TrimSpace(userID)
+	if homeserverURL == "" {
+		return nil, fmt.Errorf("matrix homeserver_url is required")
+	}
+	if accessToken == "" {
+		return nil, fmt.Errorf("matrix access_token is required")
+	}
+	if !strings.HasPrefix(userID, "@") || !strings.Contains(userID, ":") {
+		return nil, fmt.Errorf("matrix user_id must be a full Matrix ID such as @bot:example.org")
+	}
+	parsed, err := url.Parse(homeserverURL)
+	if err != nil || parsed.Host == "" || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return nil, fmt.Errorf("invalid matrix homeserver_url: must be a valid http(s) URL")
+	}
+	if err := secutils.ValidateURLForSSRF(homeserverURL); err != nil {
+		return nil, fmt.Errorf("invalid matrix homeserver_url: %w (for private homeservers, add the hostname to SSRF_WHITELIST)", err)
+	}
+	return &Client{
+		homeserver:  homeserverURL,
+		accessToken: accessToken,
+		userID:      userID,
+		httpClient: secutils.NewSSRFSafeHTTPClient(secutils.SSRFSafeHTTPClientConfig{
+			// Longer than the /sync long-poll wait.
+			Timeout:      60 * time.Second,
+			MaxRedirects: 5,
+		}),
+	}, nil
+}
+
+// UserID returns the bot's Matrix ID.
+func (c *Client) UserID() string {
+	return c.userID
+}
+
+// syncFilter keeps /sync responses to what the bot acts on: room messages
+// (encrypted ones only to log that they are skipped), the room summary and
+// invites.
+const syncFilter = `{"presence":{"not_types":["*"]},"account_data":{"not_types":["*"]},` +
+	`"room":{"account_data":{"not_types":["*"]},"ephemeral":{"not_types":["*"]},` +
+	`"state":{"lazy_load_members":true},"timeline":{"limit":50,"types":["m.room.message","m.room.encrypted"]}}}`
+
+// Sync long-polls for new events after the since token.
+func (c *Client) Sync(ctx context.Context, since string) (*syncResponse, error) {
+	q := url.Values{"timeout": {strconv.Itoa(syncTimeout)}, "filter": {syncFilter}}
+	if since != "" {
+		q.Set("since", since)
+	} else {
+		q.Set("timeout", "0")
+	}
+	var resp syncResponse
+	if err := c.do(ctx, http.MethodGet, "/_matrix/client/v3/sync?"+q.Encode(), nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// JoinRoom accepts an invite.
+func (c *Client) JoinRoom(ctx context.Context, roomID string) error {
+	return c.do(ctx, http.MethodPost, "/_matrix/client/v3/join/"+url.PathEscape(roomID), struct{}{}, nil)
+}
+
+// JoinedMemberCount returns how many users are joined to a room.
+func (c *Client) JoinedMemberCount(ctx context.Context, roomID string) (int, error) {
+	var resp struct {
+		Joined map[string]json.RawMessage `json:"joined"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/_matrix/client/v3/rooms/"+url.PathEscape(roomID)+"/joined_members", nil, &resp); err != nil {
+		return 0, err
+	}
+	return len(resp.Joined), nil
+}
+
+// DisplayName returns the bot's profile display name, which clients put in
+// the plain-text body of mention pills.
+func (c *Client) DisplayName(ctx context.Context) (string, error) {
+	var resp struct {
+		DisplayName string `json:"displayname"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/_matrix/client/v3/profile/"+url.PathEscape(c.userID)+"/displayname", nil, &resp); err != nil {
+		return "", err
+	}
+	return resp.DisplayName, nil
+}
+
+// SendMessage sends an m.room.message event and returns its event ID.
+func (c *Client) SendMessage(ctx context.Context, roomID string, content *messageContent) (string, error) {
+	txnID := fmt.Sprintf("weknora-%d-%d", time.Now().UnixNano(), c.txnSeq.Add(1))
+	path := "/_matrix/client/v3/rooms/" + url.PathEscape(roomID) + "/send/" + eventRoomMessage + "/" + txnID
+	var resp struct {
+		EventID string `json:"event_id"`
+	}
+	if err := c.do(ctx, http.MethodPut, path, content, &resp); err != nil {
+		return "", err
+	}
+	return resp.EventID, nil
+}
+
+// EditMessage replaces the text of an event the bot sent (m.replace).
+func (c *Client) EditMessage(ctx context.Context, roomID, eventID, text string) error {
+	_, err := c.SendMessage(ctx, roomID, &messageContent{
+		MsgType:    msgTypeText,
+		Body:       "* " + text,
+		NewContent: &messageContent{MsgType: msgTypeText, Body: text, Mentions: &mentions{}},
+		RelatesTo:  &relatesTo{RelType: relReplace, EventID: eventID},
+		Mentions:   &mentions{},
+	})
+	return err
+}
+
+// Download fetches an mxc:// media URI through the authenticated media API,
+// falling back to the legacy endpoint for homeservers that predate it.
+func (c *Client) Download(ctx context.Context, mxcURI string) (io.ReadCloser, error) {
+	server, mediaID, ok := parseMXC(mxcURI)
+	if !ok {
+		return nil, fmt.Errorf("invalid matrix media URI: %q", mxcURI)
+	}
+	suffix := url.PathEscape(server) + "/" + url.PathEscape(mediaID)
+	rc, status, err := c.download(ctx, "/_matrix/client/v1/media/download/"+suffix)
+	if status == http.StatusNotFound || status == http.StatusMethodNotAllowed {
+		rc, _, err = c.download(ctx, "/_matrix/media/v3/download/"+suffix)
+	}
+	return rc, err
+}
+
+func (c *Client) download(ctx context.Context, path string) (io.ReadCloser, int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.homeserver+path, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.accessToken)
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, resp.StatusCode, fmt.Errorf("matrix download failed with status %d", resp.StatusCode)
+	}
+	return resp.Body, resp.StatusCode, nil
+}
+
+func parseMXC(uri string) (server, mediaID string, ok bool) {
+	rest, found := strings.CutPrefix(uri, "mxc://")
+	if !found {
+		return "", "", false
+	}
+	server, mediaID, ok = strings.Cut(rest, "/")
+	return server, mediaID, ok && server != "" && mediaID != "" && !strings.Contains(mediaID, "/")
+}
+
+// apiError is the standard Matrix error body.
+type apiError struct {
+	ErrCode      string `json:"errcode"`
+	Error        string `json:"error"`
+	RetryAfterMs int64  `json:"retry_after_ms"`
+}
+
+func (c *Client) do(ctx context.Context, method, path string, body, out any) error {
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return fmt.Errorf("marshal body: %w", err)
+		}
+	}
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, method, c.homeserver+path, bytes.NewReader(payload))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", "Bearer "+c.accessToken)
+		if payload != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			return err
+		}
+		respBody, err := io.ReadAll(io.LimitReader(resp.Body, 8<<20))
+		resp.Body.Close()
+		if err != nil {
+			return fmt.Errorf("read matrix response: %w", err)
+		}
+
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			var apiErr apiError
+			_ = json.Unmarshal(respBody, &apiErr)
+			if resp.StatusCode == http.StatusTooManyRequests && attempt == 0 {
+				wait := time.Duration(apiErr.RetryAfterMs) * time.Millisecond
+				if wait <= maxRateLimitWait {
+					select {
+					case <-ctx.Done():
+						return ctx.Err()
+					case <-time.After(wait):
+					}
+					continue
+				}
+			}
+			if apiErr.ErrCode != "" {
+				return fmt.Errorf("matrix %s %s: status=%d %s: %s", method, stripQuery(path), resp.StatusCode, apiErr.ErrCode, apiErr.Error)
+			}
+			return fmt.Errorf("matrix %s %s: status=%d", method, stripQuery(path), resp.StatusCode)
+		}
+		if out == nil || len(respBody) == 0 {
+			return nil
+		}
+		if err := json.Unmarshal(respBody, out); err != nil {
+			return fmt.Errorf("decode matrix response: %w", err)
+		}
+		return nil
+	}
+}
+
+func stripQuery(path string) string {
+	p, _, _ := strings.Cut(path, "?")
+	return p
+}
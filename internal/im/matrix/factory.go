@@ -0,0 +1,48 @@
+package matrix
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Tencent/WeKnora/internal/im"
+	"github.com/Tencent/WeKnora/internal/logger"
+)
+
+// NewFactory returns an im.AdapterFactory for Matrix channels.
+// Only "websocket" mode is supported: messages arrive through the /sync
+// long-poll loop, like Telegram long polling.
+func NewFactory() im.AdapterFactory {
+	return func(factoryCtx context.Context, channel *im.IMChannel, msgHandler func(context.Context, *im.IncomingMessage) error) (im.Adapter, context.CancelFunc, error) {
+		creds, err := im.ParseCredentials(channel.Credentials)
+		if err != nil {
+			return nil, nil, fmt.Errorf("parse matrix credentials: %w", err)
+		}
+
+		mode := im.ResolveMode(channel, "websocket")
+		if mode != "websocket" {
+			return nil, nil, fmt.Errorf("unsupported matrix mode: %s (only websocket is supported)", mode)
+		}
+
+		client, err := NewClient(
+			im.GetString(creds, "homeserver_url"),
+			im.GetString(creds, "access_token"),
+			im.GetString(creds, "user_id"),
+		)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		rooms := newRoomState(client.UserID())
+		longConn := NewLongConnClient(client, rooms, msgHandler)
+
+		wsCtx, wsCancel := context.WithCancel(context.Background())
+		go func() {
+			if err := longConn.Start(wsCtx); err != nil && wsCtx.Err() == nil {
+				logger.Errorf(context.Background(), "[IM] Matrix sync stopped for channel %s: %v", channel.ID, err)
+			}
+		}()
+
+		threadMode := channel.SessionMode == string(im.SessionModeThread)
+		return NewAdapter(client, rooms, threadMode), wsCancel, nil
+	}
+}
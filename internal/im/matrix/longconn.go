@@ -0,0 +1,114 @@
+package matrix
+
+import (
+	"context"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/im"
+	"github.com/Tencent/WeKnora/internal/logger"
+)
+
+// MessageHandler is called when an IM message is received via /sync.
+type MessageHandler func(ctx context.Context, msg *im.IncomingMessage) error
+
+// LongConnClient runs the Matrix /sync long-poll loop.
+type LongConnClient struct {
+	client  *Client
+	rooms   *roomState
+	handler MessageHandler
+	since   string
+}
+
+// NewLongConnClient creates a Matrix sync client.
+func NewLongConnClient(client *Client, rooms *roomState, handler MessageHandler) *LongConnClient {
+	return &LongConnClient{client: client, rooms: rooms, handler: handler}
+}
+
+// Start begins the sync loop. It blocks until ctx is cancelled. The first
+// sync only establishes the position: history from before the bot started
+// is not answered.
+func (c *LongConnClient) Start(ctx context.Context) error {
+	logger.Infof(ctx, "[IM] Matrix sync connecting as %s...", c.client.UserID())
+
+	if name, err := c.client.DisplayName(ctx); err != nil {
+		logger.Warnf(ctx, "[Matrix] Fetch display name failed, mentions match the user ID only: %v", err)
+	} else {
+		c.rooms.setDisplayName(name)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		resp, err := c.client.Sync(ctx, c.since)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			logger.Errorf(ctx, "[Matrix] sync error: %v", err)
+			// Back off on error
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(3 * time.Second):
+			}
+			continue
+		}
+
+		c.processSync(ctx, resp, c.since == "")
+		c.since = resp.NextBatch
+	}
+}
+
+// processSync joins invited rooms, updates member counts and dispatches the
+// new messages. initial skips timeline events of the first sync.
+func (c *LongConnClient) processSync(ctx context.Context, resp *syncResponse, initial bool) {
+	for roomID := range resp.Rooms.Invite {
+		if err := c.client.JoinRoom(ctx, roomID); err != nil {
+			logger.Warnf(ctx, "[Matrix] Join invited room %s failed: %v", roomID, err)
+			continue
+		}
+		logger.Infof(ctx, "[Matrix] Joined invited room %s", roomID)
+	}
+
+	for roomID, room := range resp.Rooms.Join {
+		if n := room.Summary.JoinedMemberCount; n != nil {
+			c.rooms.setMemberCount(roomID, *n)
+		}
+		if initial {
+			continue
+		}
+		for i := range room.Timeline.Events {
+			ev := &room.Timeline.Events[i]
+			if ev.Type == eventRoomEncrypted {
+				logger.Warnf(ctx, "[Matrix] Skipping encrypted event %s in room %s: end-to-end encrypted rooms are not supported", ev.EventID, roomID)
+				continue
+			}
+			msg := parseEvent(roomID, ev, c.isDirect(ctx, roomID), c.rooms)
+			if msg == nil {
+				continue
+			}
+			if err := c.handler(ctx, msg); err != nil {
+				logger.Errorf(ctx, "[Matrix] Handle message error: %v", err)
+			}
+		}
+	}
+}
+
+// isDirect treats rooms with exactly two members (the user and the bot) as
+// direct chats. Unknown counts are fetched once and cached.
+func (c *LongConnClient) isDirect(ctx context.Context, roomID string) bool {
+	n, ok := c.rooms.memberCount(roomID)
+	if !ok {
+		var err error
+		if n, err = c.client.JoinedMemberCount(ctx, roomID); err != nil {
+			logger.Warnf(ctx, "[Matrix] Fetch member count of %s failed, treating it as a group room: %v", roomID, err)
+			return false
+		}
+		c.rooms.setMemberCount(roomID, n)
+	}
+	return n <= 2
+}
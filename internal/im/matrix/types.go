@@ -0,0 +1,105 @@
+package matrix
+
+const (
+	eventRoomMessage   = "m.room.message"
+	eventRoomEncrypted = "m.room.encrypted"
+
+	msgTypeText   = "m.text"
+	msgTypeNotice = "m.notice"
+	msgTypeImage  = "m.image"
+	msgTypeFile   = "m.file"
+	msgTypeAudio  = "m.audio"
+	msgTypeVideo  = "m.video"
+
+	relThread  = "m.thread"
+	relReplace = "m.replace"
+
+	// syncTimeout is the server-side long-poll wait of one /sync request.
+	syncTimeout = 30000
+
+	// maxMessageRunes keeps one event well under the homeserver's 64 KiB
+	// event size limit. An edit carries the text twice (the "* " fallback
+	// body and m.new_content), so at 3 bytes per CJK rune this stays near
+	// 48 KiB.
+	maxMessageRunes = 8000
+
+	extraKeyRoomID     = "room_id"
+	extraKeyThreadRoot = "thread_root"
+)
+
+type syncResponse struct {
+	NextBatch string `json:"next_batch"`
+	Rooms     struct {
+		Join   map[string]joinedRoom `json:"join"`
+		Invite map[string]struct{}   `json:"invite"`
+	} `json:"rooms"`
+}
+
+type joinedRoom struct {
+	Summary struct {
+		// JoinedMemberCount is only sent when it changed since the last
+		// sync, so the long-poll client caches it per room.
+		JoinedMemberCount *int `json:"m.joined_member_count"`
+	} `json:"summary"`
+	Timeline struct {
+		Events []roomEvent `json:"events"`
+	} `json:"timeline"`
+}
+
+type roomEvent struct {
+	Type     string         `json:"type"`
+	EventID  string         `json:"event_id"`
+	Sender   string         `json:"sender"`
+	StateKey *string        `json:"state_key,omitempty"`
+	Content  messageContent `json:"content"`
+}
+
+// messageContent is the content of an m.room.message event, covering text,
+// file messages and the relations used for threads, replies and edits.
+type messageContent struct {
+	MsgType       string          `json:"msgtype,omitempty"`
+	Body          string          `json:"body"`
+	Format        string          `json:"format,omitempty"`
+	FormattedBody string          `json:"formatted_body,omitempty"`
+	URL           string          `json:"url,omitempty"`
+	Filename      string          `json:"filename,omitempty"`
+	Info          *fileInfo       `json:"info,omitempty"`
+	RelatesTo     *relatesTo      `json:"m.relates_to,omitempty"`
+	Mentions      *mentions       `json:"m.mentions,omitempty"`
+	NewContent    *messageContent `json:"m.new_content,omitempty"`
+}
+
+type fileInfo struct {
+	Mimetype string `json:"mimetype,omitempty"`
+	Size     int64  `json:"size,omitempty"`
+}
+
+type relatesTo struct {
+	RelType       string     `json:"rel_type,omitempty"`
+	EventID       string     `json:"event_id,omitempty"`
+	IsFallingBack bool       `json:"is_falling_back,omitempty"`
+	InReplyTo     *inReplyTo `json:"m.in_reply_to,omitempty"`
+}
+
+type inReplyTo struct {
+	EventID string `json:"event_id"`
+}
+
+type mentions struct {
+	UserIDs []string `json:"user_ids,omitempty"`
+}
+
+func (r *relatesTo) replyTo() string {
+	if r == nil || r.InReplyTo == nil {
+		return ""
+	}
+	return r.InReplyTo.EventID
+}
+
+// threadRoot returns the root event of the thread the event belongs to.
+func (r *relatesTo) threadRoot() string {
+	if r == nil || r.RelType != relThread {
+		return ""
+	}
+	return r.EventID
+}
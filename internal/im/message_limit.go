@@ -0,0 +1,66 @@
+package im
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// streamClampMarker prefixes an in-progress stream display whose head was cut
+// to fit the platform's message limit.
+const streamClampMarker = "…\n"
+
+// ClampIMStreamContent fits an in-progress stream display into maxRunes by
+// keeping its tail: the latest tool steps and answer text are what the user
+// is waiting for, and the finalized reply replaces the display anyway.
+// maxRunes <= 0 disables clamping.
+func ClampIMStreamContent(content string, maxRunes int) string {
+	if maxRunes <= 0 || utf8.RuneCountInString(content) <= maxRunes {
+		return content
+	}
+	keep := maxRunes - utf8.RuneCountInString(streamClampMarker)
+	if keep <= 0 {
+		return string([]rune(content)[:maxRunes])
+	}
+	runes := []rune(content)
+	tail := string(runes[len(runes)-keep:])
+	// Start at a line boundary when one is close, so the cut does not leave a
+	// half tool line or half markdown construct at the top.
+	if i := strings.IndexByte(tail, '\n'); i >= 0 && i < len(tail)/4 {
+		tail = tail[i+1:]
+	}
+	return streamClampMarker + tail
+}
+
+// SplitIMMessage splits a final reply into parts of at most maxRunes each,
+// preferring paragraph, then line, then word boundaries. Platforms with a hard
+// per-message limit (Discord: 2000) send the first part as the stream message
+// and the rest as follow-ups. maxRunes <= 0 returns the content unsplit.
+func SplitIMMessage(content string, maxRunes int) []string {
+	if maxRunes <= 0 || utf8.RuneCountInString(content) <= maxRunes {
+		return []string{content}
+	}
+	var parts []string
+	rest := content
+	for utf8.RuneCountInString(rest) > maxRunes {
+		window := string([]rune(rest)[:maxRunes])
+		cut := -1
+		for _, sep := range []string{"\n\n", "\n", " "} {
+			// Ignore boundaries in the first half so parts stay reasonably full.
+			if i := strings.LastIndex(window, sep); i > len(window)/2 {
+				cut = i
+				break
+			}
+		}
+		if cut < 0 {
+			cut = len(window)
+		}
+		if part := strings.TrimRight(rest[:cut], " \n"); part != "" {
+			parts = append(parts, part)
+		}
+		rest = strings.TrimLeft(rest[cut:], " \n")
+	}
+	if rest != "" {
+		parts = append(parts, rest)
+	}
+	return parts
+}
@@ -0,0 +1,58 @@
+package im
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestClampIMStreamContent(t *testing.T) {
+	short := "hello"
+	if got := ClampIMStreamContent(short, 10); got != short {
+		t.Errorf("short content changed: %q", got)
+	}
+	if got := ClampIMStreamContent(short, 0); got != short {
+		t.Errorf("zero limit must disable clamping: %q", got)
+	}
+
+	long := strings.Repeat("工具调用\n", 30) + "最终答案"
+	got := ClampIMStreamContent(long, 40)
+	if n := utf8.RuneCountInString(got); n > 40 {
+		t.Errorf("clamped length = %d, want <= 40", n)
+	}
+	if !strings.HasPrefix(got, streamClampMarker) {
+		t.Errorf("clamped content must start with marker: %q", got)
+	}
+	if !strings.HasSuffix(got, "最终答案") {
+		t.Errorf("clamped content must keep the tail: %q", got)
+	}
+}
+
+func TestSplitIMMessage(t *testing.T) {
+	if parts := SplitIMMessage("short", 2000); len(parts) != 1 || parts[0] != "short" {
+		t.Fatalf("short message split: %v", parts)
+	}
+
+	para := strings.Repeat("a", 60)
+	content := para + "\n\n" + para + "\n\n" + para
+	parts := SplitIMMessage(content, 100)
+	if len(parts) != 3 {
+		t.Fatalf("parts = %d, want 3: %q", len(parts), parts)
+	}
+	for _, p := range parts {
+		if p != para {
+			t.Errorf("part = %q, want whole paragraph", p)
+		}
+	}
+
+	// No boundary at all: hard cut on runes, never on bytes.
+	parts = SplitIMMessage(strings.Repeat("字", 250), 100)
+	if len(parts) != 3 {
+		t.Fatalf("parts = %d, want 3", len(parts))
+	}
+	for _, p := range parts {
+		if !utf8.ValidString(p) || utf8.RuneCountInString(p) > 100 {
+			t.Errorf("invalid part: %d runes", utf8.RuneCountInString(p))
+		}
+	}
+}
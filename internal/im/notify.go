@@ -0,0 +1,35 @@
+package im
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// NotifyChat sends a standalone message to a chat through one of the
+// tenant's channels, for reports from background jobs. The chat ID is the
+// platform's group or channel ID; for platforms that address direct chats by
+// user it is used as the user ID as well. Platforms that can only answer an
+// incoming message (DingTalk session webhooks, WeChat context tokens) reject
+// the push.
+func (s *Service) NotifyChat(ctx context.Context, tenantID uint64, channelID, chatID, content string) error {
+	chatID = strings.TrimSpace(chatID)
+	if chatID == "" {
+		return fmt.Errorf("notify chat: chat ID is required")
+	}
+	ch, err := s.GetChannelByIDAndTenant(channelID, tenantID)
+	if err != nil {
+		return fmt.Errorf("notify chat: load channel %s: %w", channelID, err)
+	}
+	adapter, _, err := s.EnsureChannelAdapter(ch.ID)
+	if err != nil {
+		return fmt.Errorf("notify chat: channel %s: %w", channelID, err)
+	}
+	incoming := &IncomingMessage{
+		Platform: Platform(ch.Platform),
+		UserID:   chatID,
+		ChatID:   chatID,
+		ChatType: ChatTypeGroup,
+	}
+	return adapter.SendReply(ctx, incoming, &ReplyMessage{Content: content, IsFinal: true})
+}
@@ -43,6 +43,10 @@ type qaRequest struct {
 	channel   *IMChannel
 	channelID string
 
+	// kbIDs is the chat's /kb scope; empty lets the QA pipeline resolve the
+	// knowledge bases from the agent config.
+	kbIDs []string
+
 	// tenant is used to resolve provider:// URLs in outbound replies (scheme-aware).
 	tenant *types.Tenant
 
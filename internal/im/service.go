@@ -21,13 +21,16 @@ import (
 	"time"
 	"unicode/utf8"
 
+	"github.com/Tencent/WeKnora/internal/agent/approval"
 	agenttools "github.com/Tencent/WeKnora/internal/agent/tools"
+	filesvc "github.com/Tencent/WeKnora/internal/application/service/file"
 	"github.com/Tencent/WeKnora/internal/config"
 	apperrors "github.com/Tencent/WeKnora/internal/errors"
 	"github.com/Tencent/WeKnora/internal/event"
 	"github.com/Tencent/WeKnora/internal/infrastructure/docparser"
 	"github.com/Tencent/WeKnora/internal/logger"
 	mcppkg "github.com/Tencent/WeKnora/internal/mcp"
+	"github.com/Tencent/WeKnora/internal/metrics"
 	"github.com/Tencent/WeKnora/internal/ratelimit"
 	"github.com/Tencent/WeKnora/internal/storageurl"
 	"github.com/Tencent/WeKnora/internal/types"
@@ -137,18 +140,18 @@ func holdbackCutoff(chunk string) int {
 }
 
 // formatIMOutboundAnswer strips thinking/tool blocks and applies IM content cleanup.
-func formatIMOutboundAnswer(ctx context.Context, raw string, tenant *types.Tenant, defaultFileSvc interfaces.FileService, storageResolvers ...interfaces.StorageBackendResolver) string {
-	return cleanIMContent(ctx, FormatIMDisplayContent(raw, StreamDisplayFinal), tenant, defaultFileSvc, storageResolvers...)
+func formatIMOutboundAnswer(ctx context.Context, raw string, tenant *types.Tenant, defaultFileSvc interfaces.FileService, enc *filesvc.Encryption, storageResolvers ...interfaces.StorageBackendResolver) string {
+	return cleanIMContent(ctx, FormatIMDisplayContent(raw, StreamDisplayFinal), tenant, defaultFileSvc, enc, storageResolvers...)
 }
 
 // cleanIMContent applies all IM-specific content transformations:
 //  1. Collapse <image> XML blocks back to plain markdown
 //  2. Strip <kb/> and <web/> citation tags
 //  3. Rewrite provider:// URLs to HTTP URLs (scheme-aware per tenant config)
-func cleanIMContent(ctx context.Context, content string, tenant *types.Tenant, defaultFileSvc interfaces.FileService, storageResolvers ...interfaces.StorageBackendResolver) string {
+func cleanIMContent(ctx context.Context, content string, tenant *types.Tenant, defaultFileSvc interfaces.FileService, enc *filesvc.Encryption, storageResolvers ...interfaces.StorageBackendResolver) string {
 	content = stripImageXMLTags(content)
 	content = stripIMCitationTags(content)
-	resolver := newIMFileServiceResolver(tenant, defaultFileSvc, storageResolvers...).WithContext(ctx)
+	resolver := newIMFileServiceResolver(tenant, defaultFileSvc, enc, storageResolvers...).WithContext(ctx)
 	content = rewriteStorageURLs(ctx, content, resolver)
 	return content
 }
@@ -163,22 +166,24 @@ func imLocalStorageBaseDir() string {
 func newIMFileServiceResolver(
 	tenant *types.Tenant,
 	defaultSvc interfaces.FileService,
+	enc *filesvc.Encryption,
 	storageResolvers ...interfaces.StorageBackendResolver,
 ) *storageurl.FileServiceResolver {
-	return storageurl.NewFileServiceResolver(tenant, defaultSvc, storageResolvers...)
+	return storageurl.NewFileServiceResolver(tenant, defaultSvc, enc, storageResolvers...)
 }
 
 func buildIMFileServiceForProvider(
 	tenant *types.Tenant,
 	provider string,
 	defaultSvc interfaces.FileService,
+	enc *filesvc.Encryption,
 ) interfaces.FileService {
-	return storageurl.BuildFileServiceForProvider(tenant, provider, defaultSvc)
+	return storageurl.BuildFileServiceForProvider(tenant, provider, defaultSvc, enc)
 }
 
 // resolveIMFileServiceForPath is a test/helper entry point without caching.
 func resolveIMFileServiceForPath(tenant *types.Tenant, filePath string, defaultSvc interfaces.FileService) interfaces.FileService {
-	return newIMFileServiceResolver(tenant, defaultSvc).ResolveFileService(filePath)
+	return newIMFileServiceResolver(tenant, defaultSvc, nil).ResolveFileService(filePath)
 }
 
 const (
@@ -205,6 +210,12 @@ const (
 	RedisKeyQueueUser  = "im:queue:user:"   // + userKey   — global per-user queue counter
 	RedisKeyRateLimit  = "im:ratelimit:"    // + key       — sliding-window rate limiting
 	RedisKeyGlobalGate = "im:global:active" // global concurrent worker counter
+	// RedisKeyLastAttachment + channelID:chatKey:userID — hash holding the
+	// user's last file message in a chat, picked up by /save.
+	RedisKeyLastAttachment = "im:attachment:"
+	// RedisKeyApproval + tenantID:platform:chatKey — hash of the chat's tool
+	// calls waiting for /approve or /deny, keyed by pending ID.
+	RedisKeyApproval = "im:approval:"
 	// RedisChannelConfig broadcasts durable im_channels mutations so every
 	// application replica invalidates its local adapter/config snapshot.
 	RedisChannelConfig = "im:channel:config"
@@ -258,12 +269,28 @@ type Service struct {
 	tenantService  interfaces.TenantService
 	agentService   interfaces.CustomAgentService
 
+	// modelService loads the agent's ASR and TTS models for voice messages.
+	modelService interfaces.ModelService
+
 	// knowledgeService is used for saving IM file messages to knowledge bases.
 	knowledgeService interfaces.KnowledgeService
 
 	// kbService is used by slash-commands (/info) to list and inspect knowledge bases.
 	kbService interfaces.KnowledgeBaseService
 
+	// tenantMemberService resolves the tenant role of the member an IM user
+	// is bound to, for permission checks on slash-commands. May be nil, in
+	// which case every IM user is a viewer.
+	tenantMemberService interfaces.TenantMemberService
+
+	// approvalGate resolves tool calls waiting for human approval when an IM
+	// user sends /approve or /deny. May be nil when approvals are disabled.
+	approvalGate *approval.Gate
+
+	// chatState holds the last attachment per user and the pending tool
+	// approvals per chat (Redis when available, memory otherwise).
+	chatState *chatStateStore
+
 	// oauthManager builds MCP OAuth authorization URLs so IM users can authorize
 	// OAuth-enabled MCP services out-of-band (IM cannot resolve the in-conversation
 	// prompt). May be nil, in which case a generic console hint is shown instead.
@@ -279,6 +306,7 @@ type Service struct {
 	defaultFileSvc  interfaces.FileService
 	documentReader  interfaces.DocumentReader
 	storageResolver interfaces.StorageBackendResolver
+	fileEncryption  *filesvc.Encryption
 
 	// cmdRegistry holds all registered slash-commands.
 	cmdRegistry *CommandRegistry
@@ -566,30 +594,12 @@ func (s *Service) prepareIMAttachments(ctx context.Context, msg *IncomingMessage
 	if msg.MessageType != MessageTypeFile && msg.MessageType != MessageTypeImage {
 		return nil, nil, nil, nil
 	}
-	if msg.FileSize > maxIMAttachmentBytes {
-		return nil, nil, nil, fmt.Errorf("attachment exceeds the %d MiB limit", maxIMAttachmentBytes>>20)
-	}
-	downloader, ok := adapter.(FileDownloader)
-	if !ok {
-		return nil, nil, nil, fmt.Errorf("platform %s does not support attachment download", msg.Platform)
-	}
 	attachmentCtx, cancel := context.WithTimeout(ctx, imAttachmentReadTimeout)
 	defer cancel()
-	reader, fileName, err := downloader.DownloadFile(attachmentCtx, msg)
+	content, fileName, err := downloadIMAttachment(attachmentCtx, msg, adapter)
 	if err != nil {
 		return nil, nil, nil, err
 	}
-	defer reader.Close()
-	content, err := io.ReadAll(io.LimitReader(reader, maxIMAttachmentBytes+1))
-	if err != nil {
-		return nil, nil, nil, err
-	}
-	if len(content) > maxIMAttachmentBytes {
-		return nil, nil, nil, fmt.Errorf("attachment exceeds the %d MiB limit", maxIMAttachmentBytes>>20)
-	}
-	if fileName == "" {
-		fileName = msg.FileName
-	}
 	if msg.MessageType == MessageTypeImage && filepath.Ext(fileName) == "" {
 		fileName += ".png"
 	}
@@ -632,6 +642,34 @@ func (s *Service) prepareIMAttachments(ctx context.Context, msg *IncomingMessage
 	return types.MessageAttachments{attachment}, imageURLs, &imDownloadedAttachment{fileName: fileName, content: content}, nil
 }
 
+// downloadIMAttachment reads a message attachment through the adapter's
+// FileDownloader, bounded by maxIMAttachmentBytes.
+func downloadIMAttachment(ctx context.Context, msg *IncomingMessage, adapter Adapter) ([]byte, string, error) {
+	if msg.FileSize > maxIMAttachmentBytes {
+		return nil, "", fmt.Errorf("attachment exceeds the %d MiB limit", maxIMAttachmentBytes>>20)
+	}
+	downloader, ok := adapter.(FileDownloader)
+	if !ok {
+		return nil, "", fmt.Errorf("platform %s does not support attachment download", msg.Platform)
+	}
+	reader, fileName, err := downloader.DownloadFile(ctx, msg)
+	if err != nil {
+		return nil, "", err
+	}
+	defer reader.Close()
+	content, err := io.ReadAll(io.LimitReader(reader, maxIMAttachmentBytes+1))
+	if err != nil {
+		return nil, "", err
+	}
+	if len(content) > maxIMAttachmentBytes {
+		return nil, "", fmt.Errorf("attachment exceeds the %d MiB limit", maxIMAttachmentBytes>>20)
+	}
+	if fileName == "" {
+		fileName = msg.FileName
+	}
+	return content, fileName, nil
+}
+
 func applyIMAttachmentTruncation(content string, attachment *types.MessageAttachment) {
 	attachment.LineCount = strings.Count(content, "\n") + 1
 
@@ -801,6 +839,7 @@ func NewService(
 	messageService interfaces.MessageService,
 	tenantService interfaces.TenantService,
 	agentService interfaces.CustomAgentService,
+	modelService interfaces.ModelService,
 	knowledgeService interfaces.KnowledgeService,
 	kbService interfaces.KnowledgeBaseService,
 	streamManager interfaces.StreamManager,
@@ -810,6 +849,9 @@ func NewService(
 	redisClient *redis.Client,
 	appCfg *config.Config,
 	storageResolver interfaces.StorageBackendResolver,
+	tenantMemberService interfaces.TenantMemberService,
+	approvalGate *approval.Gate,
+	fileEncryption *filesvc.Encryption,
 ) *Service {
 	// Resolve IM configuration with defaults.
 	workers, maxQueue, maxPerUser, globalMaxWorkers, rlWindow, rlMax := resolveIMConfig(appCfg)
@@ -821,30 +863,43 @@ func NewService(
 	registry.Register(newSearchCommand(sessionService, kbService))
 	registry.Register(newStopCommand())
 	registry.Register(newClearCommand())
+	registry.Register(newArchiveCommand())
+	registry.Register(newAgentCommand(agentService))
+	registry.Register(newKBCommand(kbService))
+	registry.Register(newSaveCommand(kbService))
+	registry.Register(newFeedbackCommand(FeedbackRatingGood))
+	registry.Register(newFeedbackCommand(FeedbackRatingBad))
+	registry.Register(newApprovalCommand(true))
+	registry.Register(newApprovalCommand(false))
 
 	instanceID := uuid.New().String()
 	s := &Service{
-		db:               db,
-		sessionService:   sessionService,
-		messageService:   messageService,
-		tenantService:    tenantService,
-		agentService:     agentService,
-		knowledgeService: knowledgeService,
-		kbService:        kbService,
-		streamManager:    streamManager,
-		defaultFileSvc:   defaultFileSvc,
-		documentReader:   documentReader,
-		storageResolver:  storageResolver,
-		oauthManager:     oauthManager,
-		cmdRegistry:      registry,
-		channels:         make(map[string]*channelState),
-		leaderRetries:    make(map[string]*leaderRetryState),
-		adapterFactories: make(map[string]AdapterFactory),
-		rateLimiter:      ratelimit.New(redisClient, RedisKeyRateLimit, rlWindow, instanceID),
-		rateLimitMax:     rlMax,
-		redis:            redisClient,
-		instanceID:       instanceID,
-		stopCh:           make(chan struct{}),
+		db:                  db,
+		sessionService:      sessionService,
+		messageService:      messageService,
+		tenantService:       tenantService,
+		agentService:        agentService,
+		modelService:        modelService,
+		knowledgeService:    knowledgeService,
+		kbService:           kbService,
+		streamManager:       streamManager,
+		tenantMemberService: tenantMemberService,
+		approvalGate:        approvalGate,
+		chatState:           newChatStateStore(redisClient),
+		defaultFileSvc:      defaultFileSvc,
+		documentReader:      documentReader,
+		storageResolver:     storageResolver,
+		fileEncryption:      fileEncryption,
+		oauthManager:        oauthManager,
+		cmdRegistry:         registry,
+		channels:            make(map[string]*channelState),
+		leaderRetries:       make(map[string]*leaderRetryState),
+		adapterFactories:    make(map[string]AdapterFactory),
+		rateLimiter:         ratelimit.New(redisClient, RedisKeyRateLimit, rlWindow, instanceID),
+		rateLimitMax:        rlMax,
+		redis:               redisClient,
+		instanceID:          instanceID,
+		stopCh:              make(chan struct{}),
 	}
 
 	// Initialize the QA worker pool and bounded queue.
@@ -1566,6 +1621,7 @@ func sameChannelRuntimeConfig(cached, fresh *IMChannel) bool {
 		cached.OutputMode == fresh.OutputMode &&
 		cached.KnowledgeBaseID == fresh.KnowledgeBaseID &&
 		cached.SessionMode == fresh.SessionMode &&
+		cached.ThreadArchive == fresh.ThreadArchive &&
 		equalChannelCredentials(cached.Credentials, fresh.Credentials)
 }
 
@@ -1626,6 +1682,14 @@ func (s *Service) isDuplicate(ctx context.Context, messageID string) bool {
 
 // HandleMessage processes an incoming IM message end-to-end using channel config.
 func (s *Service) HandleMessage(ctx context.Context, msg *IncomingMessage, channelID string) error {
+	metrics.IncIMMessage(string(msg.Platform), metrics.IMReceived, nil)
+
+	// Reactions never reach QA; they only trigger thread archiving and are
+	// deduplicated per reacted-to message.
+	if msg.MessageType == MessageTypeReaction {
+		return s.handleIMReaction(ctx, msg, channelID)
+	}
+
 	// Dedup: skip if this message was already processed (IM platforms may retry)
 	if msg.MessageID != "" {
 		if s.isDuplicate(ctx, msg.MessageID) {
@@ -1683,7 +1747,6 @@ func (s *Service) HandleMessage(ctx context.Context, msg *IncomingMessage, chann
 	}
 
 	tenantID := channel.TenantID
-	agentID := channel.AgentID
 
 	logger.Infof(ctx, "[IM] HandleMessage: channel=%s platform=%s user=%s chat=%s msgtype=%s content_len=%d",
 		channelID, msg.Platform, msg.UserID, msg.ChatID, msg.MessageType, len(msg.Content))
@@ -1707,6 +1770,20 @@ func (s *Service) HandleMessage(ctx context.Context, msg *IncomingMessage, chann
 	sessionCtx := context.WithValue(ctx, types.TenantInfoContextKey, tenant)
 	sessionCtx = withIMIdentity(sessionCtx, tenantID, channelID, msg)
 
+	// A chat may have picked another agent with /agent and scoped its
+	// questions with /kb.
+	chatSetting := s.loadChatSetting(ctx, channelID, chatKey(msg))
+	agentID := s.resolveChatAgentID(sessionCtx, channel, chatSetting)
+	var kbIDs []string
+	if chatSetting != nil {
+		kbIDs = chatSetting.KnowledgeBaseIDs
+	}
+
+	// Remember attachments so /save can file them into a knowledge base later.
+	if msg.MessageType == MessageTypeFile || msg.MessageType == MessageTypeImage {
+		s.rememberAttachment(ctx, channelID, msg)
+	}
+
 	// 2. Resolve or create a WeKnora session
 	channelSession, err := s.resolveSession(sessionCtx, msg, tenantID, agentID, channelID, channel.SessionMode)
 	if err != nil {
@@ -1724,10 +1801,20 @@ func (s *Service) HandleMessage(ctx context.Context, msg *IncomingMessage, chann
 		}
 	}
 
+	// Voice messages are transcribed by the QA worker with the agent's ASR
+	// model; without one there is nothing to answer.
+	if msg.MessageType == MessageTypeAudio && !agentTranscribesVoice(customAgent) {
+		_ = adapter.SendReply(ctx, msg, &ReplyMessage{
+			Content: "当前智能体未开启语音识别，请发送文字消息。",
+			IsFinal: true,
+		})
+		return nil
+	}
+
 	// ── Slash-command dispatch ──
 	// Commands are handled before the QA pipeline so they respond instantly.
 	if cmd, args, ok := s.cmdRegistry.Parse(msg.Content); ok {
-		return s.handleCommand(sessionCtx, cmd, args, msg, adapter, channel, channelSession, customAgent)
+		return s.handleCommand(sessionCtx, cmd, args, msg, adapter, channel, channelSession, customAgent, kbIDs)
 	}
 	// Unrecognised slash-word: show help hint instead of sending to QA.
 	if LooksLikeCommand(msg.Content) {
@@ -1765,21 +1852,9 @@ func (s *Service) HandleMessage(ctx context.Context, msg *IncomingMessage, chann
 		}
 	}
 
-	// Title an untitled IM session from its first text message, like web chats.
-	// GenerateTitleAsync self-guards on a non-empty title and persists to the DB;
-	// nil eventBus is fine (IM has no live stream — the sidebar reloads it).
-	if session.Title == "" && strings.TrimSpace(msg.Content) != "" {
-		// Copy the session: the async title goroutine writes Title while the QA
-		// worker below shares the same *session.
-		sessionForTitle := *session
-		titleModelID := ""
-		if customAgent != nil && customAgent.Config.ModelID != "" {
-			titleModelID = customAgent.Config.ModelID
-		}
-		s.sessionService.GenerateTitleAsync(sessionCtx, &sessionForTitle, msg.Content, titleModelID, nil)
-	}
+	s.generateIMSessionTitle(sessionCtx, session, msg.Content, customAgent)
 
-	s.persistIMLastRequestState(sessionCtx, session.ID, agentID, customAgent, nil)
+	s.persistIMLastRequestState(sessionCtx, session.ID, agentID, customAgent, kbIDs)
 
 	// 5. Enqueue the QA request into the bounded worker pool.
 	// The worker pool controls LLM concurrency and provides backpressure.
@@ -1795,6 +1870,7 @@ func (s *Service) HandleMessage(ctx context.Context, msg *IncomingMessage, chann
 		adapter:   adapter,
 		channel:   channel,
 		channelID: channelID,
+		kbIDs:     kbIDs,
 		tenant:    tenant,
 		userKey:   userKey,
 	}
@@ -1829,6 +1905,24 @@ func (s *Service) HandleMessage(ctx context.Context, msg *IncomingMessage, chann
 	return nil
 }
 
+// generateIMSessionTitle titles an untitled IM session from its first text
+// message, like web chats. GenerateTitleAsync self-guards on a non-empty
+// title and persists to the DB; nil eventBus is fine (IM has no live stream —
+// the sidebar reloads it).
+func (s *Service) generateIMSessionTitle(ctx context.Context, session *types.Session, content string, customAgent *types.CustomAgent) {
+	if session.Title != "" || strings.TrimSpace(content) == "" {
+		return
+	}
+	// Copy the session: the async title goroutine writes Title while the QA
+	// worker shares the same *session.
+	sessionForTitle := *session
+	titleModelID := ""
+	if customAgent != nil && customAgent.Config.ModelID != "" {
+		titleModelID = customAgent.Config.ModelID
+	}
+	s.sessionService.GenerateTitleAsync(ctx, &sessionForTitle, content, titleModelID, nil)
+}
+
 func (s *Service) persistIMLastRequestState(ctx context.Context, sessionID, agentID string, customAgent *types.CustomAgent, kbIDs []string) {
 	state := buildIMLastRequestState(agentID, customAgent, kbIDs)
 	if err := s.sessionService.UpdateSessionLastRequestState(logger.CloneContext(context.WithoutCancel(ctx)), sessionID, state); err != nil {
@@ -1857,8 +1951,24 @@ func (s *Service) executeQARequest(req *qaRequest) {
 	// runQA after the assistant message is created (that's when we have the
 	// sessionID + messageID needed to poll StreamManager).
 
-	// kbIDs is left empty so the QA pipeline resolves them from the agent config.
-	var kbIDs []string
+	if req.msg.MessageType == MessageTypeAudio {
+		if err := s.transcribeIMVoice(ctx, req.msg, req.adapter, req.agent); err != nil {
+			logger.Warnf(ctx, "[IM] voice transcription failed: %v", err)
+			content := "❌ 语音识别失败，请重试或改用文字。"
+			if errors.Is(err, errEmptyTranscript) {
+				content = "未能识别出语音内容，请重试或改用文字。"
+			}
+			if sendErr := req.adapter.SendReply(ctx, req.msg, &ReplyMessage{Content: content, IsFinal: true}); sendErr != nil {
+				logger.Warnf(ctx, "[IM] Failed to send transcription error reply: %v", sendErr)
+			}
+			return
+		}
+		s.generateIMSessionTitle(ctx, req.session, req.msg.Content, req.agent)
+	}
+
+	// Without a /kb scope kbIDs is empty, so the QA pipeline resolves the
+	// knowledge bases from the agent config.
+	kbIDs := req.kbIDs
 	attachments, imageURLs, downloaded, err := s.prepareIMAttachments(ctx, req.msg, req.adapter)
 	if err != nil {
 		logger.Warnf(ctx, "[IM] attachment preparation failed: %v", err)
@@ -1879,31 +1989,40 @@ func (s *Service) executeQARequest(req *qaRequest) {
 	// If the adapter supports streaming and output is not "full", use streaming.
 	if !streamDisabled {
 		if streamer, ok := req.adapter.(StreamSender); ok {
-			if err := s.handleMessageStream(ctx, req.msg, req.session, req.agent, kbIDs, attachments, imageURLs, streamer, req.adapter, req.userKey, req.tenant); err != nil {
+			answer, err := s.handleMessageStream(ctx, req.msg, req.session, req.agent, kbIDs, attachments, imageURLs, streamer, req.adapter, req.userKey, req.tenant)
+			if err != nil {
 				logger.Errorf(ctx, "[IM] Stream QA failed: %v", err)
+				return
 			}
+			s.sendIMVoiceReply(ctx, req.msg, req.adapter, req.agent, answer)
 			return
 		}
 	}
 
 	// Non-streaming fallback: collect full answer then send.
-	answer, err := s.runQA(ctx, req.session, req.msg.Content, req.agent, kbIDs, attachments, imageURLs, req.userKey, req.msg.Quote)
-	if err != nil {
+	answer, err := s.runQA(ctx, req.msg, req.adapter, req.session, req.agent, kbIDs, attachments, imageURLs, req.userKey)
+	qaFailed := err != nil
+	if qaFailed {
 		logger.Errorf(ctx, "[IM] QA failed: %v, sending fallback reply", err)
 		answer = "抱歉，处理您的问题时出现了异常，请稍后再试。"
 	}
 
 	reply := &ReplyMessage{
-		Content: formatIMOutboundAnswer(ctx, answer, req.tenant, s.defaultFileSvc, s.storageResolver),
+		Content: formatIMOutboundAnswer(ctx, answer, req.tenant, s.defaultFileSvc, s.fileEncryption, s.storageResolver),
 		IsFinal: true,
 	}
-	if err := req.adapter.SendReply(ctx, req.msg, reply); err != nil {
+	err = req.adapter.SendReply(ctx, req.msg, reply)
+	metrics.IncIMMessage(string(req.msg.Platform), metrics.IMSent, err)
+	if err != nil {
 		logger.Errorf(ctx, "[IM] Send reply failed: %v", err)
 		return
 	}
 
 	logger.Infof(ctx, "[IM] Reply sent: channel=%s platform=%s user=%s answer_len=%d",
 		req.channelID, req.msg.Platform, req.msg.UserID, len(answer))
+	if !qaFailed {
+		s.sendIMVoiceReply(ctx, req.msg, req.adapter, req.agent, answer)
+	}
 }
 
 // handleCommand executes a slash-command and sends the result back to the user.
@@ -1917,6 +2036,7 @@ func (s *Service) handleCommand(
 	channel *IMChannel,
 	channelSession *ChannelSession,
 	customAgent *types.CustomAgent,
+	kbIDs []string,
 ) error {
 	agentName := ""
 	if customAgent != nil {
@@ -1930,7 +2050,10 @@ func (s *Service) handleCommand(
 		AgentName:         agentName,
 		CustomAgent:       customAgent,
 		ChannelOutputMode: channel.OutputMode,
+		ChannelAgentID:    channel.AgentID,
+		KnowledgeBaseIDs:  kbIDs,
 	}
+	cmdCtx.Role, cmdCtx.Bound = s.resolveIMRole(ctx, channel, msg.UserID)
 
 	result, err := cmd.Execute(ctx, cmdCtx, args)
 	if err != nil {
@@ -1997,6 +2120,30 @@ func (s *Service) handleCommand(
 		if !localStopped && sessionID == "" {
 			logger.Infof(ctx, "[IM] Set cross-instance stop marker (no inflight found): key=%s", inflightKey)
 		}
+	case ActionArchive:
+		// The thread is read synchronously so the reply below lands in it;
+		// saving runs in the background and posts its own confirmation.
+		if reply, started := s.startThreadArchive(ctx, msg, adapter, channel, customAgent, threadArchiveTriggerCommand); !started {
+			result.Content = reply
+		}
+	case ActionSwitchAgent:
+		// Sessions are keyed by agent, so the next message opens a fresh
+		// conversation with the chosen agent.
+		if err := s.updateChatSetting(ctx, channel, msg, "agent_id", result.AgentID); err != nil {
+			logger.Warnf(ctx, "[IM] Failed to switch chat agent: %v", err)
+			result.Content = "❌ 切换智能体失败，请稍后再试。"
+		}
+	case ActionScopeKnowledgeBases:
+		if err := s.updateChatSetting(ctx, channel, msg, "knowledge_base_ids", types.StringArray(result.KnowledgeBaseIDs)); err != nil {
+			logger.Warnf(ctx, "[IM] Failed to scope chat knowledge bases: %v", err)
+			result.Content = "❌ 设置知识库失败，请稍后再试。"
+		}
+	case ActionSaveAttachment:
+		result.Content = s.saveAttachmentToKnowledgeBase(ctx, msg, adapter, channel, result.KnowledgeBaseID)
+	case ActionFeedback:
+		result.Content = s.recordFeedback(ctx, msg, channel, channelSession, result.Rating, result.Comment)
+	case ActionResolveApproval:
+		result.Content = s.resolveToolApproval(ctx, cmdCtx, result)
 	}
 
 	// Send the command reply, respecting the configured output mode.
@@ -2309,8 +2456,9 @@ func briefToolSummary(output string) string {
 
 // handleMessageStream runs the QA pipeline and streams answer chunks to the IM platform
 // in real-time via the StreamSender interface. Chunks are batched at streamFlushInterval
-// to avoid API rate-limiting.
-func (s *Service) handleMessageStream(ctx context.Context, msg *IncomingMessage, session *types.Session, customAgent *types.CustomAgent, kbIDs []string, attachments types.MessageAttachments, imageURLs []string, streamer StreamSender, adapter Adapter, userKey string, tenant *types.Tenant) error {
+// to avoid API rate-limiting. It returns the answer, or "" when only a fallback
+// message could be sent.
+func (s *Service) handleMessageStream(ctx context.Context, msg *IncomingMessage, session *types.Session, customAgent *types.CustomAgent, kbIDs []string, attachments types.MessageAttachments, imageURLs []string, streamer StreamSender, adapter Adapter, userKey string, tenant *types.Tenant) (string, error) {
 	// Start the stream on the IM platform (e.g., create Feishu streaming card)
 	streamID, err := streamer.StartStream(ctx, msg)
 	if err != nil {
@@ -2577,19 +2725,23 @@ func (s *Service) handleMessageStream(ctx context.Context, msg *IncomingMessage,
 		return nil
 	})
 
+	// Announce tool calls waiting for human approval so the chat can resolve
+	// them with /approve or /deny.
+	s.watchToolApprovals(ctx, eventBus, session.TenantID, msg, adapter)
+
 	// Determine whether to use agent mode (already set above for event handlers).
 	requestID := uuid.New().String()
 
 	// Create user message
 	userMsg, err := s.messageService.CreateMessage(qaCtx, createIMUserMessagePayload(session.ID, msg.Content, requestID, attachments))
 	if err != nil {
-		return fmt.Errorf("create user message: %w", err)
+		return "", fmt.Errorf("create user message: %w", err)
 	}
 
 	// Create placeholder assistant message
 	assistantMsg, err = s.messageService.CreateMessage(qaCtx, createIMAssistantMessagePayload(session.ID, requestID))
 	if err != nil {
-		return fmt.Errorf("create assistant message: %w", err)
+		return "", fmt.Errorf("create assistant message: %w", err)
 	}
 
 	// Register inflight mapping so cross-instance /stop can find this request
@@ -2650,7 +2802,7 @@ func (s *Service) handleMessageStream(ctx context.Context, msg *IncomingMessage,
 			displaySource = displaySource[:cut]
 		}
 
-		display := cleanIMContent(ctx, displaySource, tenant, s.defaultFileSvc, s.storageResolver)
+		display := cleanIMContent(ctx, displaySource, tenant, s.defaultFileSvc, s.fileEncryption, s.storageResolver)
 		if err := streamer.UpdateStreamContent(ctx, msg, streamID, display); err != nil {
 			logger.Warnf(ctx, "[IM] UpdateStreamContent failed: %v", err)
 		}
@@ -2689,7 +2841,7 @@ loop:
 	authServices := append([]imMCPAuthService(nil), mcpAuthServices...)
 	bufMu.Unlock()
 
-	finalDisplay := cleanIMContent(ctx, FormatIMFinalFromParts(parts), tenant, s.defaultFileSvc, s.storageResolver)
+	finalDisplay := cleanIMContent(ctx, FormatIMFinalFromParts(parts), tenant, s.defaultFileSvc, s.fileEncryption, s.storageResolver)
 	if noVisibleContent || finalDisplay == "" {
 		fallback := "抱歉，我暂时无法回答这个问题。"
 		if finalErr != nil {
@@ -2705,14 +2857,17 @@ loop:
 		answer = appendIMAuthNotice(answer, notice)
 	}
 
-	if err := streamer.FinalizeStream(ctx, msg, streamID, finalDisplay); err != nil {
-		logger.Warnf(ctx, "[IM] FinalizeStream failed: %v", err)
+	finalizeErr := streamer.FinalizeStream(ctx, msg, streamID, finalDisplay)
+	if finalizeErr != nil {
+		logger.Warnf(ctx, "[IM] FinalizeStream failed: %v", finalizeErr)
 	}
 
 	// End the stream
-	if err := streamer.EndStream(ctx, msg, streamID); err != nil {
-		logger.Warnf(ctx, "[IM] EndStream failed: %v", err)
+	endErr := streamer.EndStream(ctx, msg, streamID)
+	if endErr != nil {
+		logger.Warnf(ctx, "[IM] EndStream failed: %v", endErr)
 	}
+	metrics.IncIMMessage(string(msg.Platform), metrics.IMSent, errors.Join(finalizeErr, endErr))
 
 	if answer == "" {
 		answer = "抱歉，我暂时无法回答这个问题。"
@@ -2725,22 +2880,34 @@ loop:
 	}
 
 	logger.Infof(ctx, "[IM] Stream reply sent: platform=%s user=%s answer_len=%d", msg.Platform, msg.UserID, len(answer))
-	return nil
+	if finalErr != nil || noVisibleContent {
+		return "", nil
+	}
+	return answer, nil
 }
 
 // fallbackNonStream is used when streaming initialization fails.
-func (s *Service) fallbackNonStream(ctx context.Context, msg *IncomingMessage, session *types.Session, customAgent *types.CustomAgent, kbIDs []string, attachments types.MessageAttachments, imageURLs []string, adapter Adapter, userKey string, tenant *types.Tenant) error {
-	answer, err := s.runQA(ctx, session, msg.Content, customAgent, kbIDs, attachments, imageURLs, userKey, msg.Quote)
-	if err != nil {
-		logger.Errorf(ctx, "[IM] QA fallback failed: %v", err)
+func (s *Service) fallbackNonStream(ctx context.Context, msg *IncomingMessage, session *types.Session, customAgent *types.CustomAgent, kbIDs []string, attachments types.MessageAttachments, imageURLs []string, adapter Adapter, userKey string, tenant *types.Tenant) (string, error) {
+	answer, qaErr := s.runQA(ctx, msg, adapter, session, customAgent, kbIDs, attachments, imageURLs, userKey)
+	if qaErr != nil {
+		logger.Errorf(ctx, "[IM] QA fallback failed: %v", qaErr)
 		answer = "抱歉，处理您的问题时出现了异常，请稍后再试。"
 	}
 
-	return adapter.SendReply(ctx, msg, &ReplyMessage{Content: formatIMOutboundAnswer(ctx, answer, tenant, s.defaultFileSvc, s.storageResolver), IsFinal: true})
+	err := adapter.SendReply(ctx, msg, &ReplyMessage{Content: formatIMOutboundAnswer(ctx, answer, tenant, s.defaultFileSvc, s.fileEncryption, s.storageResolver), IsFinal: true})
+	metrics.IncIMMessage(string(msg.Platform), metrics.IMSent, err)
+	if err != nil {
+		return "", err
+	}
+	if qaErr != nil {
+		return "", nil
+	}
+	return answer, nil
 }
 
 // runQA executes the WeKnora QA pipeline and returns the full answer text.
-func (s *Service) runQA(ctx context.Context, session *types.Session, query string, customAgent *types.CustomAgent, kbIDs []string, attachments types.MessageAttachments, imageURLs []string, userKey string, quote *QuotedMessage) (string, error) {
+func (s *Service) runQA(ctx context.Context, msg *IncomingMessage, adapter Adapter, session *types.Session, customAgent *types.CustomAgent, kbIDs []string, attachments types.MessageAttachments, imageURLs []string, userKey string) (string, error) {
+	query, quote := msg.Content, msg.Quote
 	// Cancellable context (no hard deadline): each agent round has its own
 	// LLMCallTimeout. The context can still be cancelled by /stop.
 	ctx, cancel := context.WithCancel(ctx)
@@ -2805,6 +2972,10 @@ func (s *Service) runQA(ctx context.Context, session *types.Session, query strin
 		return nil
 	})
 
+	// Announce tool calls waiting for human approval so the chat can resolve
+	// them with /approve or /deny.
+	s.watchToolApprovals(ctx, eventBus, session.TenantID, msg, adapter)
+
 	// Determine whether to use agent mode
 	useAgent := customAgent != nil && customAgent.IsAgentMode()
 
@@ -3077,6 +3248,13 @@ func (s *Service) DeleteChannel(channelID string, tenantID uint64) error {
 	if result.RowsAffected == 0 {
 		return fmt.Errorf("channel not found")
 	}
+	// Bindings and per-chat overrides only make sense for a live channel;
+	// feedback is kept for reporting.
+	for _, model := range []any{&IMUserBinding{}, &IMChatSetting{}} {
+		if err := s.db.Where("im_channel_id = ? AND tenant_id = ?", channelID, tenantID).Delete(model).Error; err != nil {
+			logger.Warnf(context.Background(), "[IM] Failed to clean up %T for channel %s: %v", model, channelID, err)
+		}
+	}
 	s.StopChannel(channelID)
 	s.publishChannelConfigChange(channelID)
 	return nil
@@ -3165,17 +3343,6 @@ func fileMessageQAContent(msg *IncomingMessage) string {
 // receives exactly its normal QA reply, while persistence remains background work.
 func (s *Service) processDownloadedFileToKnowledgeBase(ctx context.Context, channel *IMChannel, file *imDownloadedAttachment) {
 	kbID := channel.KnowledgeBaseID
-	tenantID := channel.TenantID
-
-	// Build context with tenant info for the knowledge service
-	tenant, err := s.tenantService.GetTenantByID(ctx, tenantID)
-	if err != nil {
-		logger.Errorf(ctx, "[IM] Failed to get tenant %d for file processing: %v", tenantID, err)
-		return
-	}
-	kbCtx := context.WithValue(ctx, types.TenantIDContextKey, tenantID)
-	kbCtx = context.WithValue(kbCtx, types.TenantInfoContextKey, tenant)
-
 	fileName := file.fileName
 	ext := fileExtension(fileName)
 	if !supportedKBFileExts[ext] {
@@ -3183,15 +3350,9 @@ func (s *Service) processDownloadedFileToKnowledgeBase(ctx context.Context, chan
 		return
 	}
 
-	// Create a multipart.FileHeader compatible wrapper
-	fh := newInMemoryFileHeader(fileName, file.content)
-
-	// Create knowledge entry via the knowledge service
-	knowledge, err := s.knowledgeService.CreateKnowledgeFromFile(kbCtx, kbID, fh, nil, nil, "", nil, imPlatformToChannel(channel.Platform), nil)
+	knowledge, err := s.createKnowledgeFromAttachment(ctx, channel, kbID, file)
 	if err != nil {
-		errMsg := err.Error()
-		// Check for duplicate file
-		if strings.Contains(errMsg, "duplicate") || strings.Contains(errMsg, "already exists") {
+		if isDuplicateKnowledgeError(err) {
 			logger.Infof(ctx, "[IM] File already exists in knowledge base: %s", fileName)
 			return
 		}
@@ -3202,6 +3363,33 @@ func (s *Service) processDownloadedFileToKnowledgeBase(ctx context.Context, chan
 	logger.Infof(ctx, "[IM] File saved to knowledge base: kb=%s knowledge=%s file=%s", kbID, knowledge.ID, fileName)
 }
 
+// createKnowledgeFromAttachment creates a knowledge item in kbID from a
+// downloaded IM attachment, under the channel's tenant.
+func (s *Service) createKnowledgeFromAttachment(ctx context.Context, channel *IMChannel, kbID string, file *imDownloadedAttachment) (*types.Knowledge, error) {
+	tenantID := channel.TenantID
+
+	// Build context with tenant info for the knowledge service
+	tenant, err := s.tenantService.GetTenantByID(ctx, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("get tenant %d: %w", tenantID, err)
+	}
+	kbCtx := context.WithValue(ctx, types.TenantIDContextKey, tenantID)
+	kbCtx = context.WithValue(kbCtx, types.TenantInfoContextKey, tenant)
+
+	// Create a multipart.FileHeader compatible wrapper
+	fh := newInMemoryFileHeader(file.fileName, file.content)
+
+	// Create knowledge entry via the knowledge service
+	return s.knowledgeService.CreateKnowledgeFromFile(kbCtx, kbID, fh, nil, nil, "", nil, imPlatformToChannel(channel.Platform), nil)
+}
+
+// isDuplicateKnowledgeError reports whether creating a knowledge item failed
+// because the same file is already in the knowledge base.
+func isDuplicateKnowledgeError(err error) bool {
+	errMsg := err.Error()
+	return strings.Contains(errMsg, "duplicate") || strings.Contains(errMsg, "already exists")
+}
+
 // fileExtension extracts the lowercase file extension from a filename.
 func fileExtension(filename string) string {
 	parts := strings.Split(filename, ".")
@@ -65,6 +65,7 @@ func newLifecycleTestDB(t *testing.T) *gorm.DB {
 		bot_identity TEXT NOT NULL DEFAULT '',
 		session_mode TEXT NOT NULL DEFAULT 'user',
 		credentials TEXT NOT NULL DEFAULT '{}',
+		thread_archive TEXT NOT NULL DEFAULT '{}',
 		created_at DATETIME,
 		updated_at DATETIME,
 		deleted_at DATETIME
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"path"
 	"strings"
 	"sync"
 
@@ -52,15 +53,7 @@ func NewWebhookAdapter(api *slack.Client, signingSecret string) *Adapter {
 func parseIncomingMessage(user, channel, text, ts string, chatType im.ChatType, files []slack.File) *im.IncomingMessage {
 	content := text
 	if chatType == im.ChatTypeGroup {
-		// Slack mentions are in the format <@U12345678>
-		for strings.HasPrefix(content, "<@") {
-			idx := strings.Index(content, ">")
-			if idx >= 0 {
-				content = strings.TrimSpace(content[idx+1:])
-			} else {
-				break
-			}
-		}
+		content = stripLeadingMentions(content)
 	}
 
 	msg := &im.IncomingMessage{
@@ -81,9 +74,17 @@ func parseIncomingMessage(user, channel, text, ts string, chatType im.ChatType,
 		msg.Extra = map[string]string{
 			"url_private_download": file.URLPrivateDownload,
 		}
-		if strings.HasPrefix(file.Mimetype, "image/") {
+		switch {
+		case strings.HasPrefix(file.Mimetype, "image/"):
 			msg.MessageType = im.MessageTypeImage
-		} else {
+		case strings.HasPrefix(file.Mimetype, "audio/"):
+			// Audio clips recorded in Slack may be named without an
+			// extension; the ASR API picks the decoder from it.
+			msg.MessageType = im.MessageTypeAudio
+			if path.Ext(msg.FileName) == "" && file.Filetype != "" {
+				msg.FileName += "." + file.Filetype
+			}
+		default:
 			msg.MessageType = im.MessageTypeFile
 		}
 	} else {
@@ -93,6 +94,18 @@ func parseIncomingMessage(user, channel, text, ts string, chatType im.ChatType,
 	return msg
 }
 
+// stripLeadingMentions removes the <@U12345678> mentions that open a group message.
+func stripLeadingMentions(content string) string {
+	for strings.HasPrefix(content, "<@") {
+		idx := strings.Index(content, ">")
+		if idx < 0 {
+			break
+		}
+		content = strings.TrimSpace(content[idx+1:])
+	}
+	return content
+}
+
 func (a *Adapter) Platform() im.Platform {
 	return im.PlatformSlack
 }
@@ -164,6 +177,8 @@ func (a *Adapter) ParseCallback(c *gin.Context) (*im.IncomingMessage, error) {
 				threadTs = ev.TimeStamp
 			}
 			return parseIncomingMessage(ev.User, ev.Channel, ev.Text, threadTs, chatType, files), nil
+		case *slackevents.ReactionAddedEvent:
+			return parseReactionEvent(ev), nil
 		}
 	}
 
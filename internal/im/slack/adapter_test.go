@@ -57,6 +57,21 @@ func TestParseIncomingMessage_ThreadID_WithFiles(t *testing.T) {
 	}
 }
 
+func TestParseIncomingMessage_AudioClip(t *testing.T) {
+	files := []slacklib.File{
+		{ID: "F789", Name: "Audio Clip", Filetype: "webm", Size: 20480, Mimetype: "audio/webm"},
+	}
+
+	msg := parseIncomingMessage("U123", "C456", "", "1234567890.111", im.ChatTypeDirect, files)
+
+	if msg.MessageType != im.MessageTypeAudio {
+		t.Errorf("MessageType = %q, want %q", msg.MessageType, im.MessageTypeAudio)
+	}
+	if msg.FileName != "Audio Clip.webm" {
+		t.Errorf("FileName = %q, want the file type appended", msg.FileName)
+	}
+}
+
 func TestParseIncomingMessage_MentionStripping(t *testing.T) {
 	// Ensure mention stripping doesn't affect ThreadID
 	msg := parseIncomingMessage("U123", "C456", "<@U999> hello world", "ts-123", im.ChatTypeGroup, nil)
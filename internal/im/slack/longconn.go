@@ -131,6 +131,12 @@ func (c *LongConnClient) handleEvent(ctx context.Context, eventsAPIEvent slackev
 			}
 
 			c.processMessage(ctx, ev.User, ev.Channel, ev.Text, threadTs, chatType, files)
+		case *slackevents.ReactionAddedEvent:
+			if incoming := parseReactionEvent(ev); incoming != nil {
+				if err := c.handler(ctx, incoming); err != nil {
+					logger.Errorf(ctx, "[Slack] Handle reaction error: %v", err)
+				}
+			}
 		default:
 			logger.Warnf(ctx, "[Slack] Unhandled inner event type: %T", innerEvent.Data)
 		}
@@ -0,0 +1,191 @@
+package slack
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/slackevents"
+
+	"github.com/Tencent/WeKnora/internal/im"
+	"github.com/Tencent/WeKnora/internal/logger"
+)
+
+var _ im.ThreadReader = (*Adapter)(nil)
+
+const (
+	// threadPageSize is the conversations.replies page size.
+	threadPageSize = 200
+	// maxThreadPages bounds how much of a very long thread is read.
+	maxThreadPages = 10
+)
+
+// userMentionPattern matches <@U123> and <@U123|name> user mentions.
+var userMentionPattern = regexp.MustCompile(`<@([A-Z0-9]+)(?:\|[^>]*)?>`)
+
+// parseReactionEvent converts a reaction_added event on a message into an
+// IncomingMessage of type reaction. Reactions on files or file comments are
+// ignored.
+func parseReactionEvent(ev *slackevents.ReactionAddedEvent) *im.IncomingMessage {
+	if ev.Item.Type != "message" || ev.Item.Channel == "" || ev.Item.Timestamp == "" {
+		return nil
+	}
+	// Direct message channel IDs start with "D"; public and private
+	// channels with "C" or "G".
+	chatType := im.ChatTypeGroup
+	if strings.HasPrefix(ev.Item.Channel, "D") {
+		chatType = im.ChatTypeDirect
+	}
+	return &im.IncomingMessage{
+		Platform:    im.PlatformSlack,
+		MessageType: im.MessageTypeReaction,
+		UserID:      ev.User,
+		ChatID:      ev.Item.Channel,
+		ChatType:    chatType,
+		Content:     ev.Reaction,
+		MessageID:   ev.Item.Timestamp,
+		ThreadID:    ev.Item.Timestamp,
+	}
+}
+
+// FetchThread reads the thread containing incoming.MessageID with
+// conversations.replies. Requires the channels:history (and groups:history
+// for private channels) scope.
+func (a *Adapter) FetchThread(ctx context.Context, incoming *im.IncomingMessage) (*im.ThreadTranscript, error) {
+	if incoming.ChatID == "" || incoming.MessageID == "" {
+		return nil, fmt.Errorf("chat and message are required")
+	}
+
+	var messages []slack.Message
+	cursor := ""
+	for page := 0; page < maxThreadPages; page++ {
+		batch, hasMore, next, err := a.api.GetConversationRepliesContext(ctx, &slack.GetConversationRepliesParameters{
+			ChannelID: incoming.ChatID,
+			Timestamp: incoming.MessageID,
+			Cursor:    cursor,
+			Limit:     threadPageSize,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("slack conversations.replies: %w", err)
+		}
+		messages = append(messages, batch...)
+		if !hasMore || next == "" {
+			break
+		}
+		cursor = next
+	}
+	if len(messages) == 0 {
+		return nil, fmt.Errorf("thread not found")
+	}
+
+	rootTS := messages[0].ThreadTimestamp
+	if rootTS == "" {
+		rootTS = messages[0].Timestamp
+	}
+	transcript := &im.ThreadTranscript{
+		ChatID:        incoming.ChatID,
+		ThreadID:      rootTS,
+		RootMessageID: rootTS,
+	}
+	if link, err := a.api.GetPermalinkContext(ctx, &slack.PermalinkParameters{Channel: incoming.ChatID, Ts: rootTS}); err == nil {
+		transcript.Permalink = link
+	} else {
+		logger.Warnf(ctx, "[Slack] Get permalink for thread %s failed: %v", rootTS, err)
+	}
+
+	names := a.resolveUserNames(ctx, messages)
+	for _, m := range messages {
+		if m.SubType != "" && m.SubType != "file_share" && m.SubType != "bot_message" && m.SubType != "thread_broadcast" {
+			continue
+		}
+		tm := im.ThreadMessage{
+			UserID:  m.User,
+			Content: threadMessageText(m, names),
+			Time:    slackTimestamp(m.Timestamp),
+			IsBot:   m.BotID != "",
+		}
+		if tm.UserName = names[m.User]; tm.UserName == "" {
+			tm.UserName = m.Username
+		}
+		transcript.Messages = append(transcript.Messages, tm)
+	}
+	return transcript, nil
+}
+
+// resolveUserNames looks up display names for the thread's authors and
+// mentioned users. Lookups are best effort (users:read scope); unknown users
+// keep their IDs.
+func (a *Adapter) resolveUserNames(ctx context.Context, messages []slack.Message) map[string]string {
+	names := make(map[string]string)
+	lookup := func(id string) {
+		if id == "" {
+			return
+		}
+		if _, done := names[id]; done {
+			return
+		}
+		names[id] = ""
+		user, err := a.api.GetUserInfoContext(ctx, id)
+		if err != nil {
+			logger.Debugf(ctx, "[Slack] users.info %s failed: %v", id, err)
+			return
+		}
+		switch {
+		case user.Profile.DisplayName != "":
+			names[id] = user.Profile.DisplayName
+		case user.RealName != "":
+			names[id] = user.RealName
+		default:
+			names[id] = user.Name
+		}
+	}
+	for _, m := range messages {
+		lookup(m.User)
+		for _, match := range userMentionPattern.FindAllStringSubmatch(m.Text, -1) {
+			lookup(match[1])
+		}
+	}
+	return names
+}
+
+// threadMessageText renders a message as plain text: mentions become @name
+// and attached files are listed by name.
+func threadMessageText(m slack.Message, names map[string]string) string {
+	text := m.Text
+	// Keep "@bot /archive" recognisable as a command so it can be dropped
+	// from the transcript.
+	if stripped := stripLeadingMentions(text); strings.HasPrefix(stripped, "/") {
+		text = stripped
+	}
+	text = userMentionPattern.ReplaceAllStringFunc(text, func(token string) string {
+		id := userMentionPattern.FindStringSubmatch(token)[1]
+		if name := names[id]; name != "" {
+			return "@" + name
+		}
+		return "@" + id
+	})
+	parts := []string{strings.TrimSpace(text)}
+	for _, f := range m.Files {
+		parts = append(parts, "[file: "+f.Name+"]")
+	}
+	return strings.TrimSpace(strings.Join(parts, "\n"))
+}
+
+// slackTimestamp converts a Slack message ts ("1712345678.000200") to a time.
+func slackTimestamp(ts string) time.Time {
+	sec, frac, _ := strings.Cut(ts, ".")
+	s, err := strconv.ParseInt(sec, 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	var ns int64
+	if frac != "" {
+		frac = (frac + "000000000")[:9]
+		ns, _ = strconv.ParseInt(frac, 10, 64)
+	}
+	return time.Unix(s, ns)
+}
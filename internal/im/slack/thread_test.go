@@ -0,0 +1,82 @@
+package slack
+
+import (
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/im"
+	slacklib "github.com/slack-go/slack"
+	"github.com/slack-go/slack/slackevents"
+)
+
+func TestParseReactionEvent(t *testing.T) {
+	ev := &slackevents.ReactionAddedEvent{
+		User:     "U1",
+		Reaction: "white_check_mark",
+		Item:     slackevents.Item{Type: "message", Channel: "C1", Timestamp: "1700000000.000100"},
+	}
+	msg := parseReactionEvent(ev)
+	if msg == nil {
+		t.Fatal("expected a reaction message")
+	}
+	if msg.MessageType != im.MessageTypeReaction || msg.Content != "white_check_mark" {
+		t.Errorf("unexpected message: %+v", msg)
+	}
+	if msg.ChatID != "C1" || msg.MessageID != "1700000000.000100" || msg.ChatType != im.ChatTypeGroup {
+		t.Errorf("unexpected target: %+v", msg)
+	}
+
+	ev.Item.Channel = "D1"
+	if msg := parseReactionEvent(ev); msg.ChatType != im.ChatTypeDirect {
+		t.Errorf("ChatType = %q, want direct", msg.ChatType)
+	}
+
+	ev.Item.Type = "file"
+	if msg := parseReactionEvent(ev); msg != nil {
+		t.Errorf("file reactions should be ignored, got %+v", msg)
+	}
+}
+
+func TestThreadMessageText(t *testing.T) {
+	names := map[string]string{"U1": "alice"}
+	tests := []struct {
+		name string
+		msg  slacklib.Message
+		want string
+	}{
+		{
+			name: "mentions become names",
+			msg:  slacklib.Message{Msg: slacklib.Msg{Text: "ping <@U1> and <@U2|bob>"}},
+			want: "ping @alice and @U2",
+		},
+		{
+			name: "leading mention before command is dropped",
+			msg:  slacklib.Message{Msg: slacklib.Msg{Text: "<@UBOT> /archive"}},
+			want: "/archive",
+		},
+		{
+			name: "files are listed",
+			msg: slacklib.Message{Msg: slacklib.Msg{
+				Text:  "log attached",
+				Files: []slacklib.File{{Name: "error.log"}},
+			}},
+			want: "log attached\n[file: error.log]",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := threadMessageText(tt.msg, names); got != tt.want {
+				t.Errorf("threadMessageText() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSlackTimestamp(t *testing.T) {
+	ts := slackTimestamp("1700000000.000200")
+	if ts.Unix() != 1700000000 || ts.Nanosecond() != 200000 {
+		t.Errorf("slackTimestamp() = %v", ts)
+	}
+	if !slackTimestamp("bogus").IsZero() {
+		t.Error("invalid ts should give zero time")
+	}
+}
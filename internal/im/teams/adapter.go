@@ -0,0 +1,375 @@
+package teams
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Tencent/WeKnora/internal/im"
+	"github.com/Tencent/WeKnora/internal/logger"
+)
+
+// Compile-time checks.
+var (
+	_ im.Adapter        = (*Adapter)(nil)
+	_ im.StreamSender   = (*Adapter)(nil)
+	_ im.FileDownloader = (*Adapter)(nil)
+)
+
+const (
+	// maxMessageRunes keeps a message under Teams' ~28 KB activity limit
+	// even for CJK text (3 bytes per rune) plus the JSON envelope.
+	maxMessageRunes = 7000
+	// minEditInterval stays well inside the per-conversation bot limits
+	// (7 messages/s, 60 per 30s) while a stream is being edited.
+	minEditInterval = 1500 * time.Millisecond
+
+	contentTypeFileDownload = "application/vnd.microsoft.teams.file.download.info"
+
+	extraKeyServiceURL     = "service_url"
+	extraKeyConversationID = "conversation_id"
+	extraKeyFileAuth       = "file_auth"
+)
+
+// Adapter implements im.Adapter for Microsoft Teams through the Bot
+// Framework: the connector posts activities to the messaging endpoint and
+// replies go back to the activity's serviceUrl.
+type Adapter struct {
+	client   *Client
+	verifier *requestVerifier
+
+	streamsMu sync.Mutex
+	streams   map[string]*streamState
+}
+
+// NewAdapter creates a Teams adapter for the given bot registration.
+func NewAdapter(client *Client, appID string) *Adapter {
+	return &Adapter{
+		client:   client,
+		verifier: newRequestVerifier(strings.TrimSpace(appID), "", client.httpClient),
+		streams:  map[string]*streamState{},
+	}
+}
+
+// activity is the subset of a Bot Framework activity the adapter reads.
+type activity struct {
+	Type         string              `json:"type"`
+	ID           string              `json:"id"`
+	ServiceURL   string              `json:"serviceUrl"`
+	ChannelID    string              `json:"channelId"`
+	From         channelAccount      `json:"from"`
+	Recipient    channelAccount      `json:"recipient"`
+	Conversation conversationAccount `json:"conversation"`
+	Text         string              `json:"text"`
+	ReplyToID    string              `json:"replyToId"`
+	Attachments  []attachment        `json:"attachments"`
+	Entities     []entity            `json:"entities"`
+}
+
+type channelAccount struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	AADObjectID string `json:"aadObjectId"`
+}
+
+type conversationAccount struct {
+	ID               string `json:"id"`
+	ConversationType string `json:"conversationType"`
+	TenantID         string `json:"tenantId"`
+}
+
+type attachment struct {
+	ContentType string          `json:"contentType"`
+	ContentURL  string          `json:"contentUrl"`
+	Name        string          `json:"name"`
+	Content     json.RawMessage `json:"content"`
+}
+
+type fileDownloadInfo struct {
+	DownloadURL string `json:"downloadUrl"`
+	FileType    string `json:"fileType"`
+}
+
+type entity struct {
+	Type      string         `json:"type"`
+	Text      string         `json:"text"`
+	Mentioned channelAccount `json:"mentioned"`
+}
+
+func (a *Adapter) Platform() im.Platform {
+	return im.PlatformTeams
+}
+
+func (a *Adapter) HandleURLVerification(c *gin.Context) bool {
+	return false // Bot Framework has no URL verification handshake.
+}
+
+func (a *Adapter) VerifyCallback(c *gin.Context) error {
+	body, err := readBody(c)
+	if err != nil {
+		return fmt.Errorf("read body: %w", err)
+	}
+	var act activity
+	if err := json.Unmarshal(body, &act); err != nil {
+		return fmt.Errorf("parse activity: %w", err)
+	}
+	return a.verifier.Verify(c.Request.Context(), c.GetHeader("Authorization"), act.ServiceURL)
+}
+
+func (a *Adapter) ParseCallback(c *gin.Context) (*im.IncomingMessage, error) {
+	body, err := readBody(c)
+	if err != nil {
+		return nil, fmt.Errorf("read body: %w", err)
+	}
+	var act activity
+	if err := json.Unmarshal(body, &act); err != nil {
+		return nil, fmt.Errorf("parse activity: %w", err)
+	}
+	return parseActivity(&act), nil
+}
+
+func readBody(c *gin.Context) ([]byte, error) {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return nil, err
+	}
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
+
+// parseActivity converts a message activity. In channels Teams encodes the
+// reply chain in the conversation ID ("19:...@thread.tacv2;messageid=<root>"),
+// which becomes the ThreadID; the bare channel ID is the ChatID.
+func parseActivity(act *activity) *im.IncomingMessage {
+	if act.Type != "message" || act.From.ID == "" || act.From.ID == act.Recipient.ID {
+		return nil
+	}
+	conversationID := act.Conversation.ID
+	chatID, rootID, _ := strings.Cut(conversationID, ";messageid=")
+
+	msg := &im.IncomingMessage{
+		Platform:    im.PlatformTeams,
+		MessageType: im.MessageTypeText,
+		UserID:      act.From.ID,
+		UserName:    act.From.Name,
+		ChatType:    im.ChatTypeDirect,
+		Content:     stripMentions(act),
+		MessageID:   act.ID,
+		Extra: map[string]string{
+			extraKeyServiceURL:     act.ServiceURL,
+			extraKeyConversationID: conversationID,
+		},
+	}
+	if act.From.AADObjectID != "" {
+		// The AAD object ID is stable across teams and chats; the channel
+		// account ID differs per bot registration.
+		msg.UserID = act.From.AADObjectID
+	}
+	if act.Conversation.ConversationType != "personal" {
+		msg.ChatType = im.ChatTypeGroup
+		msg.ChatID = chatID
+		msg.ThreadID = act.ID
+		if rootID != "" {
+			msg.ThreadID = rootID
+		}
+	}
+	applyAttachments(msg, act.Attachments)
+	if msg.Content == "" && msg.FileKey == "" {
+		return nil
+	}
+	return msg
+}
+
+// stripMentions removes the "<at>Bot</at>" text of mentions of the bot;
+// Teams only delivers channel messages to a bot that is mentioned.
+func stripMentions(act *activity) string {
+	text := act.Text
+	for _, e := range act.Entities {
+		if e.Type == "mention" && e.Mentioned.ID == act.Recipient.ID && e.Text != "" {
+			text = strings.ReplaceAll(text, e.Text, "")
+		}
+	}
+	text = strings.ReplaceAll(text, "&nbsp;", " ")
+	return strings.TrimSpace(text)
+}
+
+// applyAttachments picks the first downloadable attachment: a file sent in a
+// personal chat (pre-authenticated download URL) or an inline image hosted
+// on the connector (needs the bot token). The text/html mirror of the
+// message and SharePoint references in channels are skipped.
+func applyAttachments(msg *im.IncomingMessage, atts []attachment) {
+	for _, att := range atts {
+		switch {
+		case att.ContentType == contentTypeFileDownload:
+			var info fileDownloadInfo
+			if json.Unmarshal(att.Content, &info) != nil || info.DownloadURL == "" {
+				continue
+			}
+			msg.MessageType = im.MessageTypeFile
+			msg.FileKey = info.DownloadURL
+			msg.FileName = att.Name
+			return
+		case strings.HasPrefix(att.ContentType, "image/") && att.ContentURL != "":
+			msg.MessageType = im.MessageTypeImage
+			msg.FileKey = att.ContentURL
+			msg.FileName = att.Name
+			if msg.FileName == "" {
+				msg.FileName = "image." + strings.TrimPrefix(att.ContentType, "image/")
+			}
+			msg.Extra[extraKeyFileAuth] = "bot"
+			return
+		}
+	}
+}
+
+// ── Send reply ──
+
+func replyTarget(incoming *im.IncomingMessage) (serviceURL, conversationID string, err error) {
+	serviceURL = incoming.Extra[extraKeyServiceURL]
+	conversationID = incoming.Extra[extraKeyConversationID]
+	if serviceURL == "" || conversationID == "" {
+		return "", "", fmt.Errorf("missing teams serviceUrl or conversation id")
+	}
+	if u, perr := url.Parse(serviceURL); perr != nil || (u.Scheme != "https" && u.Scheme != "http") {
+		return "", "", fmt.Errorf("invalid teams serviceUrl")
+	}
+	return serviceURL, conversationID, nil
+}
+
+// SendReply answers into the conversation the message came from. The
+// conversation ID of a channel message carries its reply chain, so the
+// answer lands in the same chain.
+func (a *Adapter) SendReply(ctx context.Context, incoming *im.IncomingMessage, reply *im.ReplyMessage) error {
+	serviceURL, conversationID, err := replyTarget(incoming)
+	if err != nil {
+		return err
+	}
+	content := im.FormatIMDisplayContent(reply.Content, im.StreamDisplayFinal)
+	for _, part := range im.SplitIMMessage(content, maxMessageRunes) {
+		if _, err := a.client.SendMessage(ctx, serviceURL, conversationID, incoming.MessageID, part); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ── StreamSender implementation (update activity in-place) ──
+
+type streamState struct {
+	mu             sync.Mutex
+	serviceURL     string
+	conversationID string
+	replyToID      string
+	activityID     string
+	lastEdit       time.Time
+}
+
+func (a *Adapter) StartStream(ctx context.Context, incoming *im.IncomingMessage) (string, error) {
+	serviceURL, conversationID, err := replyTarget(incoming)
+	if err != nil {
+		return "", err
+	}
+	activityID, err := a.client.SendMessage(ctx, serviceURL, conversationID, incoming.MessageID, "正在思考...")
+	if err != nil {
+		return "", fmt.Errorf("teams start stream: %w", err)
+	}
+	streamID := conversationID + ":" + activityID
+	a.streamsMu.Lock()
+	a.streams[streamID] = &streamState{
+		serviceURL:     serviceURL,
+		conversationID: conversationID,
+		replyToID:      incoming.MessageID,
+		activityID:     activityID,
+	}
+	a.streamsMu.Unlock()
+
+	logger.Infof(ctx, "[Teams] Streaming started: stream_id=%s", streamID)
+	return streamID, nil
+}
+
+func (a *Adapter) getStream(streamID string) (*streamState, error) {
+	a.streamsMu.Lock()
+	defer a.streamsMu.Unlock()
+	state, ok := a.streams[streamID]
+	if !ok {
+		return nil, fmt.Errorf("unknown stream ID: %s", streamID)
+	}
+	return state, nil
+}
+
+func (a *Adapter) UpdateStreamContent(ctx context.Context, incoming *im.IncomingMessage, streamID string, fullContent string) error {
+	if fullContent == "" {
+		return nil
+	}
+	state, err := a.getStream(streamID)
+	if err != nil {
+		return err
+	}
+	state.mu.Lock()
+	if time.Since(state.lastEdit) < minEditInterval {
+		state.mu.Unlock()
+		return nil
+	}
+	state.lastEdit = time.Now()
+	state.mu.Unlock()
+
+	content := im.ClampIMStreamContent(fullContent, maxMessageRunes)
+	if err := a.client.UpdateMessage(ctx, state.serviceURL, state.conversationID, state.activityID, content); err != nil {
+		logger.Warnf(ctx, "[Teams] Failed to update stream content: %v", err)
+	}
+	return nil
+}
+
+func (a *Adapter) FinalizeStream(ctx context.Context, incoming *im.IncomingMessage, streamID string, finalContent string) error {
+	state, err := a.getStream(streamID)
+	if err != nil {
+		return err
+	}
+	parts := im.SplitIMMessage(finalContent, maxMessageRunes)
+	if err := a.client.UpdateMessage(ctx, state.serviceURL, state.conversationID, state.activityID, parts[0]); err != nil {
+		logger.Warnf(ctx, "[Teams] Failed to finalize stream: %v", err)
+	}
+	for _, part := range parts[1:] {
+		if _, err := a.client.SendMessage(ctx, state.serviceURL, state.conversationID, state.replyToID, part); err != nil {
+			logger.Warnf(ctx, "[Teams] Failed to send overflow part: %v", err)
+			break
+		}
+	}
+	return nil
+}
+
+func (a *Adapter) EndStream(ctx context.Context, incoming *im.IncomingMessage, streamID string) error {
+	a.streamsMu.Lock()
+	_, ok := a.streams[streamID]
+	delete(a.streams, streamID)
+	a.streamsMu.Unlock()
+	if ok {
+		logger.Infof(ctx, "[Teams] Streaming ended: stream_id=%s", streamID)
+	}
+	return nil
+}
+
+// ── FileDownloader implementation ──
+
+func (a *Adapter) DownloadFile(ctx context.Context, msg *im.IncomingMessage) (io.ReadCloser, string, error) {
+	if msg.FileKey == "" {
+		return nil, "", fmt.Errorf("file_key is required")
+	}
+	rc, err := a.client.Download(ctx, msg.FileKey, msg.Extra[extraKeyFileAuth] == "bot")
+	if err != nil {
+		return nil, "", err
+	}
+	name := msg.FileName
+	if name == "" {
+		name = "attachment"
+	}
+	return rc, name, nil
+}
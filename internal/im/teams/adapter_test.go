@@ -0,0 +1,238 @@
+package teams
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/Tencent/WeKnora/internal/im"
+	secutils "github.com/Tencent/WeKnora/internal/utils"
+)
+
+func loadActivity(t *testing.T, name string) *activity {
+	t.Helper()
+	raw, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var act activity
+	if err := json.Unmarshal(raw, &act); err != nil {
+		t.Fatalf("decode %s: %v", name, err)
+	}
+	return &act
+}
+
+func allowLoopback(t *testing.T) {
+	t.Helper()
+	t.Setenv("SSRF_WHITELIST", "127.0.0.1")
+	secutils.ResetSSRFWhitelistForTest()
+	t.Cleanup(secutils.ResetSSRFWhitelistForTest)
+}
+
+func TestParseActivity_ChannelReplyChain(t *testing.T) {
+	msg := parseActivity(loadActivity(t, "message_channel_reply_chain.json"))
+	if msg == nil {
+		t.Fatal("expected message")
+	}
+	if msg.Content != "Which regions does the EU data residency plan cover?" {
+		t.Errorf("Content = %q, mention not stripped", msg.Content)
+	}
+	if msg.ChatType != im.ChatTypeGroup || msg.ChatID != "19:a8c4e2f0b1d34c5e9f7a6b8c0d2e4f6a@thread.tacv2" {
+		t.Errorf("chat = %s %q", msg.ChatType, msg.ChatID)
+	}
+	if msg.ThreadID != "1727691500112" {
+		t.Errorf("ThreadID = %q, want reply chain root", msg.ThreadID)
+	}
+	if msg.UserID != "6f1c2d3e-4b5a-4c6d-8e7f-9a0b1c2d3e4f" {
+		t.Errorf("UserID = %q, want AAD object ID", msg.UserID)
+	}
+	if !strings.HasSuffix(msg.Extra[extraKeyConversationID], ";messageid=1727691500112") {
+		t.Errorf("replies must target the full reply-chain conversation: %q", msg.Extra[extraKeyConversationID])
+	}
+	if msg.MessageType != im.MessageTypeText {
+		t.Errorf("text/html mirror must not count as a file: %s", msg.MessageType)
+	}
+}
+
+func TestParseActivity_PersonalFile(t *testing.T) {
+	msg := parseActivity(loadActivity(t, "message_personal_file.json"))
+	if msg == nil {
+		t.Fatal("expected message")
+	}
+	if msg.ChatType != im.ChatTypeDirect || msg.ChatID != "" || msg.ThreadID != "" {
+		t.Errorf("personal chat resolved as %s %q %q", msg.ChatType, msg.ChatID, msg.ThreadID)
+	}
+	if msg.MessageType != im.MessageTypeFile || msg.FileName != "onboarding.docx" {
+		t.Errorf("file = %s %q", msg.MessageType, msg.FileName)
+	}
+	if !strings.Contains(msg.FileKey, "download.aspx") || msg.Extra[extraKeyFileAuth] != "" {
+		t.Errorf("file download URL is pre-authenticated: key=%q auth=%q", msg.FileKey, msg.Extra[extraKeyFileAuth])
+	}
+}
+
+func TestParseActivity_IgnoresNonMessage(t *testing.T) {
+	if msg := parseActivity(loadActivity(t, "conversation_update.json")); msg != nil {
+		t.Errorf("conversationUpdate parsed as %+v", msg)
+	}
+}
+
+// newKeyServer serves a Bot Framework style OpenID configuration and JWKS.
+func newKeyServer(t *testing.T, key *rsa.PrivateKey, kid string) string {
+	t.Helper()
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/openid":
+			_ = json.NewEncoder(w).Encode(map[string]string{"issuer": botFrameworkIssuer, "jwks_uri": srv.URL + "/keys"})
+		case "/keys":
+			_ = json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]any{{
+				"kty": "RSA",
+				"kid": kid,
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}}})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv.URL + "/openid"
+}
+
+func signToken(t *testing.T, key *rsa.PrivateKey, kid string, claims jwt.MapClaims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = kid
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return "Bearer " + signed
+}
+
+func TestRequestVerifier(t *testing.T) {
+	allowLoopback(t)
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifier := newRequestVerifier("app-id", newKeyServer(t, key, "k1"), newHTTPClient())
+	serviceURL := "https://smba.trafficmanager.net/emea/"
+	claims := func() jwt.MapClaims {
+		return jwt.MapClaims{
+			"iss":        botFrameworkIssuer,
+			"aud":        "app-id",
+			"exp":        time.Now().Add(time.Hour).Unix(),
+			"nbf":        time.Now().Add(-time.Minute).Unix(),
+			"serviceurl": "https://smba.trafficmanager.net/emea",
+		}
+	}
+	ctx := context.Background()
+
+	if err := verifier.Verify(ctx, signToken(t, key, "k1", claims()), serviceURL); err != nil {
+		t.Fatalf("valid token rejected: %v", err)
+	}
+
+	wrongAud := claims()
+	wrongAud["aud"] = "other-bot"
+	if err := verifier.Verify(ctx, signToken(t, key, "k1", wrongAud), serviceURL); err == nil {
+		t.Error("token for another bot must be rejected")
+	}
+	if err := verifier.Verify(ctx, signToken(t, key, "k1", claims()), "https://evil.example.com/"); err == nil {
+		t.Error("token replayed with a different serviceUrl must be rejected")
+	}
+	other, _ := rsa.GenerateKey(rand.Reader, 2048)
+	if err := verifier.Verify(ctx, signToken(t, other, "k1", claims()), serviceURL); err == nil {
+		t.Error("token signed with an unknown key must be rejected")
+	}
+	if err := verifier.Verify(ctx, "", serviceURL); err == nil {
+		t.Error("missing token must be rejected")
+	}
+}
+
+type recordedCall struct {
+	Method string
+	Path   string
+	Body   map[string]any
+}
+
+func TestStreamUpdatesActivityAndSplitsOverflow(t *testing.T) {
+	allowLoopback(t)
+	var mu sync.Mutex
+	var calls []recordedCall
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			_ = json.NewEncoder(w).Encode(map[string]any{"access_token": "tok", "expires_in": 3600})
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer tok" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		raw, _ := io.ReadAll(r.Body)
+		var body map[string]any
+		_ = json.Unmarshal(raw, &body)
+		mu.Lock()
+		calls = append(calls, recordedCall{Method: r.Method, Path: r.URL.EscapedPath(), Body: body})
+		mu.Unlock()
+		_ = json.NewEncoder(w).Encode(map[string]string{"id": "1727691700000"})
+	}))
+	defer srv.Close()
+
+	client, err := NewClient("app-id", "secret", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.tokens.tokenURL = srv.URL + "/token"
+	adapter := NewAdapter(client, "app-id")
+
+	incoming := parseActivity(loadActivity(t, "message_channel_reply_chain.json"))
+	incoming.Extra[extraKeyServiceURL] = srv.URL + "/emea/"
+	ctx := context.Background()
+
+	streamID, err := adapter.StartStream(ctx, incoming)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := adapter.UpdateStreamContent(ctx, incoming, streamID, strings.Repeat("步骤\n", 4000)); err != nil {
+		t.Fatal(err)
+	}
+	final := strings.Repeat("a", 6000) + "\n\n" + strings.Repeat("b", 3000)
+	if err := adapter.FinalizeStream(ctx, incoming, streamID, final); err != nil {
+		t.Fatal(err)
+	}
+	_ = adapter.EndStream(ctx, incoming, streamID)
+
+	if len(calls) != 4 {
+		t.Fatalf("calls = %d, want post, put, put, post: %+v", len(calls), calls)
+	}
+	conversation := "/emea/v3/conversations/19:a8c4e2f0b1d34c5e9f7a6b8c0d2e4f6a@thread.tacv2%3Bmessageid=1727691500112/activities"
+	if calls[0].Method != http.MethodPost || calls[0].Path != conversation+"/1727691667401" {
+		t.Errorf("start = %s %s", calls[0].Method, calls[0].Path)
+	}
+	for _, c := range calls[1:3] {
+		if c.Method != http.MethodPut || c.Path != conversation+"/1727691700000" {
+			t.Errorf("update = %s %s", c.Method, c.Path)
+		}
+		if n := len([]rune(c.Body["text"].(string))); n > maxMessageRunes {
+			t.Errorf("update exceeds limit: %d runes", n)
+		}
+	}
+	if calls[3].Method != http.MethodPost || calls[3].Body["text"] != strings.Repeat("b", 3000) {
+		t.Errorf("overflow = %s %v", calls[3].Method, calls[3].Body["text"])
+	}
+}
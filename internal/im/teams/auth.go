@@ -0,0 +1,223 @@
+package teams
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	// botFrameworkOpenIDURL publishes the keys that sign channel-to-bot
+	// requests from the Bot Framework connector service.
+	botFrameworkOpenIDURL = "https://login.botframework.com/v1/.well-known/openidconfiguration"
+	botFrameworkIssuer    = "https://api.botframework.com"
+
+	// keysRefreshInterval follows the Bot Framework guidance of refreshing
+	// signing keys at least daily; unknown key IDs trigger an early refresh
+	// at most once per keysMissRefreshInterval.
+	keysRefreshInterval     = 24 * time.Hour
+	keysMissRefreshInterval = time.Minute
+	tokenClockSkew          = 5 * time.Minute
+)
+
+// requestVerifier validates the JWT bearer token the Bot Framework connector
+// attaches to every activity it posts to the bot.
+type requestVerifier struct {
+	appID      string
+	openIDURL  string
+	httpClient *http.Client
+
+	mu        sync.Mutex
+	keys      map[string]*rsa.PublicKey
+	fetchedAt time.Time
+}
+
+func newRequestVerifier(appID, openIDURL string, httpClient *http.Client) *requestVerifier {
+	if openIDURL == "" {
+		openIDURL = botFrameworkOpenIDURL
+	}
+	return &requestVerifier{appID: appID, openIDURL: openIDURL, httpClient: httpClient}
+}
+
+// Verify checks the token signature, issuer, audience (the bot's app ID)
+// and lifetime, and that it was issued for the activity's serviceUrl so a
+// token replayed with a different serviceUrl cannot redirect replies.
+func (v *requestVerifier) Verify(ctx context.Context, authHeader, serviceURL string) error {
+	raw, ok := strings.CutPrefix(authHeader, "Bearer ")
+	if !ok || raw == "" {
+		return fmt.Errorf("missing bearer token")
+	}
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(raw, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return v.key(ctx, kid)
+	},
+		jwt.WithValidMethods([]string{"RS256"}),
+		jwt.WithIssuer(botFrameworkIssuer),
+		jwt.WithAudience(v.appID),
+		jwt.WithLeeway(tokenClockSkew),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return fmt.Errorf("invalid bot framework token: %w", err)
+	}
+	claimed, _ := claims["serviceurl"].(string)
+	if claimed == "" || !sameServiceURL(claimed, serviceURL) {
+		return fmt.Errorf("token serviceurl %q does not match activity serviceUrl %q", claimed, serviceURL)
+	}
+	return nil
+}
+
+func sameServiceURL(a, b string) bool {
+	return strings.EqualFold(strings.TrimRight(a, "/"), strings.TrimRight(b, "/"))
+}
+
+func (v *requestVerifier) key(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	age := time.Since(v.fetchedAt)
+	if key, ok := v.keys[kid]; ok && age < keysRefreshInterval {
+		return key, nil
+	}
+	if v.keys == nil || age >= keysMissRefreshInterval {
+		keys, err := v.fetchKeys(ctx)
+		if err != nil {
+			return nil, err
+		}
+		v.keys, v.fetchedAt = keys, time.Now()
+	}
+	if key, ok := v.keys[kid]; ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+func (v *requestVerifier) fetchKeys(ctx context.Context) (map[string]*rsa.PublicKey, error) {
+	var cfg struct {
+		JWKSURI string `json:"jwks_uri"`
+	}
+	if err := v.getJSON(ctx, v.openIDURL, &cfg); err != nil {
+		return nil, fmt.Errorf("fetch openid configuration: %w", err)
+	}
+	if cfg.JWKSURI == "" {
+		return nil, fmt.Errorf("openid configuration has no jwks_uri")
+	}
+	var set struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := v.getJSON(ctx, cfg.JWKSURI, &set); err != nil {
+		return nil, fmt.Errorf("fetch signing keys: %w", err)
+	}
+	keys := make(map[string]*rsa.PublicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Kty != "RSA" || k.Kid == "" {
+			continue
+		}
+		n, errN := base64.RawURLEncoding.DecodeString(k.N)
+		e, errE := base64.RawURLEncoding.DecodeString(k.E)
+		if errN != nil || errE != nil {
+			continue
+		}
+		keys[k.Kid] = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no usable signing keys")
+	}
+	return keys, nil
+}
+
+func (v *requestVerifier) getJSON(ctx context.Context, rawURL string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := v.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("status %d", resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// tokenSource obtains bot-to-connector access tokens with the client
+// credentials grant and caches them until shortly before expiry.
+type tokenSource struct {
+	appID       string
+	appPassword string
+	tokenURL    string
+	httpClient  *http.Client
+
+	mu        sync.Mutex
+	token     string
+	expiresAt time.Time
+}
+
+func newTokenSource(appID, appPassword, tenantID string, httpClient *http.Client) *tokenSource {
+	if tenantID == "" {
+		// Multi-tenant bots authenticate against the Bot Framework tenant.
+		tenantID = "botframework.com"
+	}
+	return &tokenSource{
+		appID:       appID,
+		appPassword: appPassword,
+		tokenURL:    "https://login.microsoftonline.com/" + url.PathEscape(tenantID) + "/oauth2/v2.0/token",
+		httpClient:  httpClient,
+	}
+}
+
+func (s *tokenSource) Token(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.token != "" && time.Until(s.expiresAt) > time.Minute {
+		return s.token, nil
+	}
+	form := url.Values{
+		"grant_type":    {"client_credentials"},
+		"client_id":     {s.appID},
+		"client_secret": {s.appPassword},
+		"scope":         {"https://api.botframework.com/.default"},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	var result struct {
+		AccessToken      string `json:"access_token"`
+		ExpiresIn        int    `json:"expires_in"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("decode teams token response: %w", err)
+	}
+	if result.AccessToken == "" {
+		return "", fmt.Errorf("teams token request failed: %s %s", result.Error, result.ErrorDescription)
+	}
+	s.token = result.AccessToken
+	s.expiresAt = time.Now().Add(time.Duration(result.ExpiresIn) * time.Second)
+	return s.token, nil
+}
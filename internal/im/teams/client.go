@@ -0,0 +1,155 @@
+package teams
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	secutils "github.com/Tencent/WeKnora/internal/utils"
+)
+
+// Client calls the Bot Framework connector REST API at the serviceUrl the
+// activity came from.
+type Client struct {
+	tokens     *tokenSource
+	httpClient *http.Client
+}
+
+func newHTTPClient() *http.Client {
+	return secutils.NewSSRFSafeHTTPClient(secutils.SSRFSafeHTTPClientConfig{
+		Timeout:      30 * time.Second,
+		MaxRedirects: 5,
+	})
+}
+
+// NewClient creates a connector client for a bot registration.
+func NewClient(appID, appPassword, tenantID string) (*Client, error) {
+	appID, appPassword = strings.TrimSpace(appID), strings.TrimSpace(appPassword)
+	if appID == "" {
+		return nil, fmt.Errorf("teams app_id is required")
+	}
+	if appPassword == "" {
+		return nil, fmt.Errorf("teams app_password is required")
+	}
+	httpClient := newHTTPClient()
+	return &Client{
+		tokens:     newTokenSource(appID, appPassword, strings.TrimSpace(tenantID), httpClient),
+		httpClient: httpClient,
+	}, nil
+}
+
+type outgoingActivity struct {
+	Type       string `json:"type"`
+	ID         string `json:"id,omitempty"`
+	Text       string `json:"text"`
+	TextFormat string `json:"textFormat"`
+	ReplyToID  string `json:"replyToId,omitempty"`
+}
+
+func conversationURL(serviceURL, conversationID string) string {
+	return strings.TrimRight(serviceURL, "/") + "/v3/conversations/" + url.PathEscape(conversationID) + "/activities"
+}
+
+// SendMessage posts a markdown message into a conversation and returns the
+// new activity ID. replyToID threads it under that activity.
+func (c *Client) SendMessage(ctx context.Context, serviceURL, conversationID, replyToID, text string) (string, error) {
+	endpoint := conversationURL(serviceURL, conversationID)
+	if replyToID != "" {
+		endpoint += "/" + url.PathEscape(replyToID)
+	}
+	activity := outgoingActivity{Type: "message", Text: text, TextFormat: "markdown", ReplyToID: replyToID}
+	var created struct {
+		ID string `json:"id"`
+	}
+	if err := c.do(ctx, http.MethodPost, endpoint, activity, &created); err != nil {
+		return "", err
+	}
+	return created.ID, nil
+}
+
+// UpdateMessage replaces the text of a message the bot sent.
+func (c *Client) UpdateMessage(ctx context.Context, serviceURL, conversationID, activityID, text string) error {
+	endpoint := conversationURL(serviceURL, conversationID) + "/" + url.PathEscape(activityID)
+	activity := outgoingActivity{Type: "message", ID: activityID, Text: text, TextFormat: "markdown"}
+	return c.do(ctx, http.MethodPut, endpoint, activity, nil)
+}
+
+// Download fetches an attachment. Inline images on the connector need the
+// bot token; file download URLs are pre-authenticated and must not get it.
+func (c *Client) Download(ctx context.Context, rawURL string, withToken bool) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	if withToken {
+		token, err := c.tokens.Token(ctx)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("teams download failed with status %d", resp.StatusCode)
+	}
+	return resp.Body, nil
+}
+
+func (c *Client) do(ctx context.Context, method, endpoint string, body, out any) error {
+	if err := secutils.ValidateURLForSSRF(endpoint); err != nil {
+		return fmt.Errorf("invalid teams serviceUrl: %w", err)
+	}
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("marshal body: %w", err)
+	}
+	token, err := c.tokens.Token(ctx)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return fmt.Errorf("read teams response: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("teams %s activity: status=%d body=%s", method, resp.StatusCode, truncateForErr(respBody))
+	}
+	if out == nil || len(respBody) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(respBody, out); err != nil {
+		return fmt.Errorf("decode teams response: %w", err)
+	}
+	return nil
+}
+
+func truncateForErr(b []byte) string {
+	const max = 512
+	s := string(b)
+	if len(s) > max {
+		return s[:max] + "..."
+	}
+	return s
+}
@@ -0,0 +1,32 @@
+package teams
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Tencent/WeKnora/internal/im"
+)
+
+// NewFactory returns an im.AdapterFactory for Microsoft Teams channels.
+// Only "webhook" mode is supported: the bot's messaging endpoint in Azure
+// Bot Service points at the channel callback URL.
+func NewFactory() im.AdapterFactory {
+	return func(factoryCtx context.Context, channel *im.IMChannel, msgHandler func(context.Context, *im.IncomingMessage) error) (im.Adapter, context.CancelFunc, error) {
+		creds, err := im.ParseCredentials(channel.Credentials)
+		if err != nil {
+			return nil, nil, fmt.Errorf("parse teams credentials: %w", err)
+		}
+
+		mode := im.ResolveMode(channel, "webhook")
+		if mode != "webhook" {
+			return nil, nil, fmt.Errorf("unsupported teams mode: %s (only webhook is supported)", mode)
+		}
+
+		appID := im.GetString(creds, "app_id")
+		client, err := NewClient(appID, im.GetString(creds, "app_password"), im.GetString(creds, "tenant_id"))
+		if err != nil {
+			return nil, nil, err
+		}
+		return NewAdapter(client, appID), func() {}, nil
+	}
+}
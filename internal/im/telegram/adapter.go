@@ -7,6 +7,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"mime/multipart"
 	"net/http"
 	"strconv"
 	"strings"
@@ -25,6 +26,7 @@ var (
 	_ im.Adapter        = (*Adapter)(nil)
 	_ im.StreamSender   = (*Adapter)(nil)
 	_ im.FileDownloader = (*Adapter)(nil)
+	_ im.VoiceSender    = (*Adapter)(nil)
 )
 
 // Adapter implements im.Adapter for Telegram Bot API.
@@ -85,6 +87,8 @@ type telegramMsg struct {
 	Text            string          `json:"text"`
 	Document        *telegramDoc    `json:"document"`
 	Photo           []telegramPhoto `json:"photo"`
+	Voice           *telegramDoc    `json:"voice"`
+	Audio           *telegramDoc    `json:"audio"`
 }
 
 type telegramUser struct {
@@ -99,6 +103,8 @@ type telegramChat struct {
 	Type string `json:"type"` // "private", "group", "supergroup", "channel"
 }
 
+// telegramDoc covers documents, voice notes and audio files; voice notes
+// carry no file_name.
 type telegramDoc struct {
 	FileID   string `json:"file_id"`
 	FileName string `json:"file_name"`
@@ -201,6 +207,19 @@ func parseTelegramMessage(msg *telegramMsg) *im.IncomingMessage {
 		incoming.FileSize = int64(largest.FileSize)
 	}
 
+	// Handle voice notes (Ogg/Opus) and audio files, transcribed through ASR
+	if voice := msg.Voice; voice != nil || msg.Audio != nil {
+		fileName := "voice.ogg"
+		if voice == nil {
+			voice = msg.Audio
+			fileName = voice.FileName
+		}
+		incoming.MessageType = im.MessageTypeAudio
+		incoming.FileKey = voice.FileID
+		incoming.FileName = fileName
+		incoming.FileSize = voice.FileSize
+	}
+
 	return incoming
 }
 
@@ -256,6 +275,61 @@ func (a *Adapter) editMessage(ctx context.Context, chatID, messageID, text, pars
 	return a.callAPI(ctx, "editMessageText", body)
 }
 
+// SendVoice sends Ogg/Opus audio as a voice note replying to the question.
+func (a *Adapter) SendVoice(ctx context.Context, incoming *im.IncomingMessage, audio []byte) error {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	_ = form.WriteField("chat_id", resolveChatID(incoming))
+	if incoming.ThreadID != "" {
+		_ = form.WriteField("message_thread_id", incoming.ThreadID)
+	}
+	if incoming.MessageID != "" {
+		_ = form.WriteField("reply_to_message_id", incoming.MessageID)
+	}
+	part, err := form.CreateFormFile("voice", "reply.ogg")
+	if err != nil {
+		return fmt.Errorf("create voice part: %w", err)
+	}
+	if _, err := part.Write(audio); err != nil {
+		return fmt.Errorf("write voice part: %w", err)
+	}
+	if err := form.Close(); err != nil {
+		return fmt.Errorf("close form: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/bot%s/sendVoice", apiBaseURL, a.botToken)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, &body)
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	resp, err := uploadClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("do request: %w", err)
+	}
+	defer resp.Body.Close()
+	var apiResp struct {
+		OK          bool   `json:"ok"`
+		Description string `json:"description"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+	if !apiResp.OK {
+		return fmt.Errorf("telegram API sendVoice failed: %s", apiResp.Description)
+	}
+	return nil
+}
+
+// apiBaseURL is the Telegram Bot API endpoint; tests point it at a fake server.
+var apiBaseURL = "https://api.telegram.org"
+
+// uploadClient allows slower voice uploads than the shared API client.
+var uploadClient = secutils.NewSSRFSafeHTTPClient(secutils.SSRFSafeHTTPClientConfig{
+	Timeout:      60 * time.Second,
+	MaxRedirects: 5,
+})
+
 // httpClient is a shared HTTP client with a reasonable timeout for Telegram API calls.
 var httpClient = secutils.NewSSRFSafeHTTPClient(secutils.SSRFSafeHTTPClientConfig{
 	Timeout:      15 * time.Second,
@@ -269,7 +343,7 @@ func (a *Adapter) callAPI(ctx context.Context, method string, body interface{})
 
 // callAPIWithResult calls the Telegram Bot API and optionally decodes the result field.
 func (a *Adapter) callAPIWithResult(ctx context.Context, method string, body interface{}, result interface{}) error {
-	url := fmt.Sprintf("https://api.telegram.org/bot%s/%s", a.botToken, method)
+	url := fmt.Sprintf("%s/bot%s/%s", apiBaseURL, a.botToken, method)
 
 	jsonBody, err := json.Marshal(body)
 	if err != nil {
@@ -486,7 +560,7 @@ func (a *Adapter) DownloadFile(ctx context.Context, msg *im.IncomingMessage) (io
 	}
 
 	// Download the file
-	downloadURL := fmt.Sprintf("https://api.telegram.org/file/bot%s/%s", a.botToken, fileInfo.FilePath)
+	downloadURL := fmt.Sprintf("%s/file/bot%s/%s", apiBaseURL, a.botToken, fileInfo.FilePath)
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, downloadURL, nil)
 	if err != nil {
 		return nil, "", fmt.Errorf("create download request: %w", err)
@@ -1,9 +1,14 @@
 package telegram
 
 import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/Tencent/WeKnora/internal/im"
+	secutils "github.com/Tencent/WeKnora/internal/utils"
 )
 
 func TestParseTelegramMessage_ForumTopicThread(t *testing.T) {
@@ -106,3 +111,65 @@ func TestParseUpdate_NilMessage(t *testing.T) {
 		t.Error("expected nil for nil update.Message")
 	}
 }
+
+func TestParseTelegramMessage_Voice(t *testing.T) {
+	msg := &telegramMsg{
+		MessageID: 500,
+		From:      &telegramUser{ID: 1005, FirstName: "Erin"},
+		Chat:      telegramChat{ID: 1005, Type: "private"},
+		Voice:     &telegramDoc{FileID: "voice-1", FileSize: 4096, MimeType: "audio/ogg"},
+	}
+	incoming := parseTelegramMessage(msg)
+	if incoming.MessageType != im.MessageTypeAudio || incoming.FileKey != "voice-1" || incoming.FileName != "voice.ogg" {
+		t.Errorf("voice note = %+v", incoming)
+	}
+
+	msg.Voice = nil
+	msg.Audio = &telegramDoc{FileID: "audio-1", FileName: "memo.m4a", FileSize: 8192}
+	incoming = parseTelegramMessage(msg)
+	if incoming.MessageType != im.MessageTypeAudio || incoming.FileKey != "audio-1" || incoming.FileName != "memo.m4a" {
+		t.Errorf("audio file = %+v", incoming)
+	}
+}
+
+func TestSendVoice(t *testing.T) {
+	t.Setenv("SSRF_WHITELIST", "127.0.0.1")
+	secutils.ResetSSRFWhitelistForTest()
+	t.Cleanup(secutils.ResetSSRFWhitelistForTest)
+
+	var fields map[string]string
+	var voice []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/botTOKEN/sendVoice" {
+			http.NotFound(w, r)
+			return
+		}
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			t.Errorf("parse form: %v", err)
+		}
+		fields = map[string]string{}
+		for k, v := range r.MultipartForm.Value {
+			fields[k] = v[0]
+		}
+		if f, _, err := r.FormFile("voice"); err == nil {
+			voice, _ = io.ReadAll(f)
+		}
+		_, _ = w.Write([]byte(`{"ok":true,"result":{}}`))
+	}))
+	defer srv.Close()
+	orig := apiBaseURL
+	apiBaseURL = srv.URL
+	t.Cleanup(func() { apiBaseURL = orig })
+
+	adapter := NewWebhookAdapter("TOKEN", "")
+	incoming := &im.IncomingMessage{ChatID: "-100", ThreadID: "7", MessageID: "42", UserID: "1"}
+	if err := adapter.SendVoice(context.Background(), incoming, []byte("OggS")); err != nil {
+		t.Fatal(err)
+	}
+	if fields["chat_id"] != "-100" || fields["message_thread_id"] != "7" || fields["reply_to_message_id"] != "42" {
+		t.Errorf("fields = %v", fields)
+	}
+	if string(voice) != "OggS" {
+		t.Errorf("voice = %q", voice)
+	}
+}
@@ -80,7 +80,7 @@ func (c *LongConnClient) Start(ctx context.Context) error {
 }
 
 func (c *LongConnClient) getUpdates(ctx context.Context) ([]telegramUpdate, error) {
-	url := fmt.Sprintf("https://api.telegram.org/bot%s/getUpdates", c.botToken)
+	url := fmt.Sprintf("%s/bot%s/getUpdates", apiBaseURL, c.botToken)
 
 	body := map[string]interface{}{
 		"offset":          c.offset,
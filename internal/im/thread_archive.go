@@ -0,0 +1,546 @@
+package im
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/common"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/models/chat"
+	"github.com/Tencent/WeKnora/internal/types"
+	"gorm.io/gorm"
+)
+
+const (
+	// imThreadFetchTimeout bounds reading a thread's history from the platform.
+	imThreadFetchTimeout = 30 * time.Second
+	// imThreadArchiveTimeout bounds saving one archived thread, including the FAQ pass.
+	imThreadArchiveTimeout = 5 * time.Minute
+	// maxThreadArchiveFAQInputRunes caps the transcript sent to the chat model.
+	maxThreadArchiveFAQInputRunes = 12000
+	// maxThreadArchiveTitleRunes caps the knowledge title taken from the first message.
+	maxThreadArchiveTitleRunes = 60
+	// maxThreadArchiveMetadataBytes keeps custom metadata values within the
+	// limit UpdateKnowledge enforces.
+	maxThreadArchiveMetadataBytes = 1000
+
+	threadArchiveTriggerCommand  = "command"
+	threadArchiveTriggerReaction = "reaction"
+
+	threadArchiveStartedNotice = "📚 正在归档当前话题，完成后会在此回复。"
+	threadArchiveDonePrefix    = "✅ 话题已归档"
+	threadArchiveFailedNotice  = "❌ 话题归档失败，请稍后重试。"
+)
+
+// threadArchiveResult describes a finished archive for the confirmation reply.
+type threadArchiveResult struct {
+	Messages    int
+	Updated     bool
+	FAQQuestion string
+	// Skipped is set when a reaction hits an already archived thread.
+	Skipped bool
+}
+
+// ValidateThreadArchiveConfig normalizes cfg and, when archiving is enabled,
+// checks that the target knowledge bases belong to the tenant and have the
+// right type: transcripts go to a document knowledge base, condensed entries
+// to an FAQ knowledge base.
+func (s *Service) ValidateThreadArchiveConfig(ctx context.Context, tenantID uint64, cfg *ThreadArchiveConfig) error {
+	cfg.Normalize()
+	if !cfg.Enabled {
+		return nil
+	}
+	if cfg.KnowledgeBaseID == "" {
+		return errors.New("thread_archive.knowledge_base_id is required when thread archiving is enabled")
+	}
+	kb, err := s.kbService.GetKnowledgeBaseByID(ctx, cfg.KnowledgeBaseID)
+	if err != nil || kb.TenantID != tenantID {
+		return errors.New("thread_archive.knowledge_base_id: knowledge base not found")
+	}
+	if kb.Type == types.KnowledgeBaseTypeFAQ {
+		return errors.New("thread_archive.knowledge_base_id must be a document knowledge base")
+	}
+	if cfg.FAQKnowledgeBaseID != "" {
+		faqKB, err := s.kbService.GetKnowledgeBaseByID(ctx, cfg.FAQKnowledgeBaseID)
+		if err != nil || faqKB.TenantID != tenantID {
+			return errors.New("thread_archive.faq_knowledge_base_id: knowledge base not found")
+		}
+		if faqKB.Type != types.KnowledgeBaseTypeFAQ {
+			return errors.New("thread_archive.faq_knowledge_base_id must be an FAQ knowledge base")
+		}
+	}
+	return nil
+}
+
+// handleIMReaction archives the reacted-to thread when the emoji matches the
+// channel's archive reaction. Every other reaction is ignored silently.
+func (s *Service) handleIMReaction(ctx context.Context, msg *IncomingMessage, channelID string) error {
+	adapter, channel, ok := s.GetChannelAdapter(channelID)
+	if !ok || !channel.ThreadArchive.MatchesReaction(msg.Content) || msg.MessageID == "" {
+		return nil
+	}
+	if s.isDuplicate(ctx, "reaction:"+channelID+":"+msg.MessageID) {
+		return nil
+	}
+
+	tenant, err := s.tenantService.GetTenantByID(ctx, channel.TenantID)
+	if err != nil {
+		return fmt.Errorf("get tenant: %w", err)
+	}
+	archiveCtx := context.WithValue(ctx, types.TenantInfoContextKey, tenant)
+	archiveCtx = withIMIdentity(archiveCtx, channel.TenantID, channelID, msg)
+
+	var customAgent *types.CustomAgent
+	if channel.AgentID != "" {
+		if agent, err := s.agentService.GetAgentByID(archiveCtx, channel.AgentID); err == nil {
+			customAgent = agent
+		}
+	}
+
+	logger.Infof(ctx, "[IM] Archive reaction: channel=%s user=%s message=%s emoji=%s",
+		channelID, msg.UserID, msg.MessageID, msg.Content)
+	if reply, started := s.startThreadArchive(archiveCtx, msg, adapter, channel, customAgent, threadArchiveTriggerReaction); !started && reply != "" {
+		_ = adapter.SendReply(ctx, msg, &ReplyMessage{Content: reply, IsFinal: true})
+	}
+	return nil
+}
+
+// startThreadArchive reads the thread containing msg and saves it in the
+// background. It returns the reply to show when the archive cannot start;
+// started reports whether the background archive is running. On success msg
+// is re-pointed at the thread root so every reply lands in the thread.
+func (s *Service) startThreadArchive(
+	ctx context.Context,
+	msg *IncomingMessage,
+	adapter Adapter,
+	channel *IMChannel,
+	customAgent *types.CustomAgent,
+	trigger string,
+) (reply string, started bool) {
+	cfg := channel.ThreadArchive
+	if !cfg.Enabled || cfg.KnowledgeBaseID == "" {
+		return "当前渠道未开启话题归档，请在渠道设置中配置归档知识库。", false
+	}
+	if msg.ChatType != ChatTypeGroup {
+		return "仅支持在群聊话题中使用归档。", false
+	}
+	reader, ok := adapter.(ThreadReader)
+	if !ok {
+		return "当前平台暂不支持话题归档。", false
+	}
+
+	fetchCtx, cancel := context.WithTimeout(ctx, imThreadFetchTimeout)
+	transcript, err := reader.FetchThread(fetchCtx, msg)
+	cancel()
+	if err != nil {
+		logger.Warnf(ctx, "[IM] Fetch thread for archive failed: channel=%s chat=%s message=%s err=%v",
+			channel.ID, msg.ChatID, msg.MessageID, err)
+		return "读取话题消息失败，请确认机器人有读取群消息的权限。", false
+	}
+	transcript.Messages = s.filterThreadArchiveMessages(transcript.Messages)
+	if len(transcript.Messages) == 0 {
+		return "话题中没有可归档的消息。", false
+	}
+
+	if transcript.ChatID != "" {
+		msg.ChatID = transcript.ChatID
+	}
+	if transcript.ThreadID != "" {
+		msg.ThreadID = transcript.ThreadID
+	}
+	if transcript.RootMessageID != "" {
+		msg.MessageID = transcript.RootMessageID
+	}
+
+	replyTo := *msg
+	go s.runThreadArchive(context.WithoutCancel(ctx), &replyTo, adapter, channel, customAgent, transcript, trigger)
+	return "", true
+}
+
+// runThreadArchive saves the transcript and reports the outcome in the thread.
+func (s *Service) runThreadArchive(
+	ctx context.Context,
+	msg *IncomingMessage,
+	adapter Adapter,
+	channel *IMChannel,
+	customAgent *types.CustomAgent,
+	transcript *ThreadTranscript,
+	trigger string,
+) {
+	ctx, cancel := context.WithTimeout(ctx, imThreadArchiveTimeout)
+	defer cancel()
+
+	result, err := s.archiveThread(ctx, channel, customAgent, transcript, msg.UserID, trigger)
+	content := ""
+	switch {
+	case err != nil:
+		logger.Errorf(ctx, "[IM] Thread archive failed: channel=%s chat=%s thread=%s err=%v",
+			channel.ID, transcript.ChatID, transcript.ThreadID, err)
+		content = threadArchiveFailedNotice
+	case result.Skipped:
+		return
+	default:
+		content = threadArchiveReply(result)
+	}
+	if err := adapter.SendReply(ctx, msg, &ReplyMessage{Content: content, IsFinal: true}); err != nil {
+		logger.Warnf(ctx, "[IM] Failed to send thread archive reply: %v", err)
+	}
+}
+
+// archiveThread writes the transcript as one manual knowledge item, optionally
+// condenses it into an FAQ entry, and records the archive. Archiving the same
+// thread again updates both in place; a repeated reaction is skipped.
+func (s *Service) archiveThread(
+	ctx context.Context,
+	channel *IMChannel,
+	customAgent *types.CustomAgent,
+	transcript *ThreadTranscript,
+	archivedBy string,
+	trigger string,
+) (*threadArchiveResult, error) {
+	cfg := channel.ThreadArchive
+	var record IMThreadArchive
+	err := s.db.WithContext(ctx).
+		Where("im_channel_id = ? AND chat_id = ? AND thread_id = ?", channel.ID, transcript.ChatID, transcript.ThreadID).
+		First(&record).Error
+	found := err == nil
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("load thread archive: %w", err)
+	}
+	if found && trigger == threadArchiveTriggerReaction {
+		return &threadArchiveResult{Skipped: true}, nil
+	}
+
+	kbCtx := context.WithValue(ctx, types.TenantIDContextKey, channel.TenantID)
+	payload := &types.ManualKnowledgePayload{
+		Title:   threadArchiveTitle(transcript),
+		Content: renderThreadTranscript(channel.Platform, transcript),
+		Status:  types.ManualKnowledgeStatusPublish,
+		Channel: imPlatformToChannel(channel.Platform),
+	}
+
+	result := &threadArchiveResult{Messages: len(transcript.Messages)}
+	var knowledge *types.Knowledge
+	if found && record.KnowledgeBaseID == cfg.KnowledgeBaseID {
+		// The archived item may have been deleted from the UI since; fall
+		// back to creating a fresh one.
+		if _, err := s.knowledgeService.GetKnowledgeByID(kbCtx, record.KnowledgeID); err == nil {
+			knowledge, err = s.knowledgeService.UpdateManualKnowledge(kbCtx, record.KnowledgeID, payload)
+			if err != nil {
+				return nil, fmt.Errorf("update archived knowledge: %w", err)
+			}
+			result.Updated = true
+		}
+	}
+	if knowledge == nil {
+		knowledge, err = s.knowledgeService.CreateKnowledgeFromManual(kbCtx, cfg.KnowledgeBaseID, payload, payload.Channel)
+		if err != nil {
+			return nil, fmt.Errorf("create archived knowledge: %w", err)
+		}
+	}
+
+	metadata, _ := json.Marshal(threadArchiveMetadata(channel.Platform, transcript))
+	if err := s.knowledgeService.UpdateKnowledge(kbCtx, &types.Knowledge{ID: knowledge.ID, CustomMetadata: metadata}); err != nil {
+		logger.Warnf(ctx, "[IM] Failed to set thread archive metadata on %s: %v", knowledge.ID, err)
+	}
+
+	record.TenantID = channel.TenantID
+	record.IMChannelID = channel.ID
+	record.Platform = channel.Platform
+	record.ChatID = transcript.ChatID
+	record.ThreadID = transcript.ThreadID
+	record.KnowledgeBaseID = cfg.KnowledgeBaseID
+	record.KnowledgeID = knowledge.ID
+	record.MessageCount = len(transcript.Messages)
+	record.ArchivedBy = archivedBy
+	record.TriggerType = trigger
+
+	if cfg.FAQKnowledgeBaseID != "" {
+		entry, err := s.archiveThreadFAQ(kbCtx, cfg.FAQKnowledgeBaseID, customAgent, transcript, &record)
+		if err != nil {
+			// The transcript is saved; a failed FAQ pass only loses the summary.
+			logger.Warnf(ctx, "[IM] Thread archive FAQ pass failed: channel=%s thread=%s err=%v",
+				channel.ID, transcript.ThreadID, err)
+		} else if entry != nil {
+			result.FAQQuestion = entry.StandardQuestion
+		}
+	}
+
+	if err := s.db.WithContext(ctx).Save(&record).Error; err != nil {
+		return nil, fmt.Errorf("save thread archive: %w", err)
+	}
+	logger.Infof(ctx, "[IM] Thread archived: channel=%s chat=%s thread=%s knowledge=%s messages=%d updated=%v",
+		channel.ID, transcript.ChatID, transcript.ThreadID, knowledge.ID, result.Messages, result.Updated)
+	return result, nil
+}
+
+// archiveThreadFAQ condenses the transcript with the agent's chat model and
+// creates, or updates, the thread's FAQ entry. It returns nil without error
+// when the model judges the thread to hold no reusable answer.
+func (s *Service) archiveThreadFAQ(
+	ctx context.Context,
+	faqKBID string,
+	customAgent *types.CustomAgent,
+	transcript *ThreadTranscript,
+	record *IMThreadArchive,
+) (*types.FAQEntry, error) {
+	if customAgent == nil || customAgent.Config.ModelID == "" {
+		return nil, errors.New("agent has no chat model")
+	}
+	chatModel, err := s.modelService.GetChatModel(ctx, customAgent.Config.ModelID)
+	if err != nil {
+		return nil, fmt.Errorf("get chat model: %w", err)
+	}
+	payload, err := condenseThreadToFAQ(ctx, chatModel, transcript)
+	if err != nil || payload == nil {
+		return nil, err
+	}
+
+	if record.FAQEntryID != 0 && record.FAQKnowledgeBaseID == faqKBID {
+		entry, err := s.knowledgeService.UpdateFAQEntry(ctx, faqKBID, record.FAQEntryID, payload)
+		if err == nil {
+			return entry, nil
+		}
+		logger.Warnf(ctx, "[IM] Update archived FAQ entry %d failed, creating a new one: %v", record.FAQEntryID, err)
+	}
+	entry, err := s.knowledgeService.CreateFAQEntry(ctx, faqKBID, payload)
+	if err != nil {
+		return nil, fmt.Errorf("create FAQ entry: %w", err)
+	}
+	record.FAQKnowledgeBaseID = faqKBID
+	record.FAQEntryID = entry.ID
+	return entry, nil
+}
+
+var threadFAQSchema = json.RawMessage(`{
+  "type": "object",
+  "properties": {
+    "skip": {"type": "boolean"},
+    "question": {"type": "string"},
+    "similar_questions": {"type": "array", "items": {"type": "string"}},
+    "answer": {"type": "string"}
+  },
+  "required": ["skip", "question", "similar_questions", "answer"]
+}`)
+
+const threadFAQSystemPrompt = `You turn a resolved group chat thread into one FAQ entry for a knowledge base.
+- question: the question the thread answers, phrased as a user would ask it, without names
+- similar_questions: up to 3 other phrasings of the same question
+- answer: the final, self-contained answer agreed on in the thread; drop chit-chat, names and dead ends
+- skip: true when the thread holds no question with a reusable answer
+Write in the language of the conversation. Respond with a single JSON object.`
+
+// condenseThreadToFAQ asks the chat model for an FAQ entry. A nil payload
+// means the thread has nothing worth an FAQ entry.
+func condenseThreadToFAQ(ctx context.Context, chatModel chat.Chat, transcript *ThreadTranscript) (*types.FAQEntryPayload, error) {
+	var conversation strings.Builder
+	for _, m := range transcript.Messages {
+		conversation.WriteString(threadSpeaker(m))
+		conversation.WriteString(": ")
+		conversation.WriteString(m.Content)
+		conversation.WriteString("\n")
+	}
+	input := conversation.String()
+	if runes := []rune(input); len(runes) > maxThreadArchiveFAQInputRunes {
+		input = string(runes[:maxThreadArchiveFAQInputRunes])
+	}
+
+	thinking := false
+	response, err := chatModel.Chat(ctx, []chat.Message{
+		{Role: "system", Content: threadFAQSystemPrompt},
+		{Role: "user", Content: input},
+	}, &chat.ChatOptions{
+		Temperature: 0,
+		Thinking:    &thinking,
+		Format:      threadFAQSchema,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("condense thread: %w", err)
+	}
+	var parsed struct {
+		Skip             bool     `json:"skip"`
+		Question         string   `json:"question"`
+		SimilarQuestions []string `json:"similar_questions"`
+		Answer           string   `json:"answer"`
+	}
+	if err := common.ParseLLMJsonResponse(response.Content, &parsed); err != nil {
+		return nil, fmt.Errorf("parse FAQ entry: %w", err)
+	}
+	question := strings.TrimSpace(parsed.Question)
+	answer := strings.TrimSpace(parsed.Answer)
+	if parsed.Skip || question == "" || answer == "" {
+		return nil, nil
+	}
+	similar := make([]string, 0, len(parsed.SimilarQuestions))
+	for _, q := range parsed.SimilarQuestions {
+		if q = strings.TrimSpace(q); q != "" && q != question {
+			similar = append(similar, q)
+		}
+	}
+	return &types.FAQEntryPayload{
+		StandardQuestion: question,
+		SimilarQuestions: similar,
+		Answers:          []string{answer},
+	}, nil
+}
+
+// filterThreadArchiveMessages drops the archive commands themselves and the
+// bot's archive notices, which would otherwise pile up on every re-archive.
+func (s *Service) filterThreadArchiveMessages(messages []ThreadMessage) []ThreadMessage {
+	kept := messages[:0]
+	for _, m := range messages {
+		content := strings.TrimSpace(m.Content)
+		if content == "" {
+			continue
+		}
+		if m.IsBot && (strings.HasPrefix(content, threadArchiveStartedNotice) ||
+			strings.HasPrefix(content, threadArchiveDonePrefix) ||
+			strings.HasPrefix(content, threadArchiveFailedNotice)) {
+			continue
+		}
+		if s.cmdRegistry.IsRegistered(stripLeadingMentions(content)) {
+			continue
+		}
+		m.Content = content
+		kept = append(kept, m)
+	}
+	return kept
+}
+
+// stripLeadingMentions removes the @bot mentions that precede a command in a
+// group message ("@WeKnora /archive", "<@U123> /archive").
+func stripLeadingMentions(content string) string {
+	for strings.HasPrefix(content, "@") || strings.HasPrefix(content, "<@") {
+		idx := strings.IndexAny(content, " \t\n")
+		if idx < 0 {
+			return ""
+		}
+		content = strings.TrimSpace(content[idx+1:])
+	}
+	return content
+}
+
+// threadArchiveReply renders the confirmation posted in the thread.
+func threadArchiveReply(result *threadArchiveResult) string {
+	var sb strings.Builder
+	sb.WriteString(threadArchiveDonePrefix)
+	if result.Updated {
+		sb.WriteString(fmt.Sprintf("（已更新，共 %d 条消息）。", result.Messages))
+	} else {
+		sb.WriteString(fmt.Sprintf("（共 %d 条消息）。", result.Messages))
+	}
+	if result.FAQQuestion != "" {
+		sb.WriteString(fmt.Sprintf("\n已生成 FAQ：%s", result.FAQQuestion))
+	}
+	return sb.String()
+}
+
+// threadArchiveTitle uses the first line of the opening message as the title.
+func threadArchiveTitle(transcript *ThreadTranscript) string {
+	first := transcript.Messages[0]
+	title, _, _ := strings.Cut(strings.TrimSpace(first.Content), "\n")
+	title = strings.TrimSpace(title)
+	if runes := []rune(title); len(runes) > maxThreadArchiveTitleRunes {
+		title = string(runes[:maxThreadArchiveTitleRunes]) + "…"
+	}
+	if title == "" {
+		title = "群聊话题 " + first.Time.Format("2006-01-02 15:04")
+	}
+	return title
+}
+
+// renderThreadTranscript renders the thread as Markdown: a header with the
+// source, participants, time span and link, then every message in order.
+func renderThreadTranscript(platform string, transcript *ThreadTranscript) string {
+	first := transcript.Messages[0]
+	last := transcript.Messages[len(transcript.Messages)-1]
+
+	var sb strings.Builder
+	sb.WriteString("# " + threadArchiveTitle(transcript) + "\n\n")
+	sb.WriteString("- 来源：" + platform + " 群聊话题\n")
+	if participants := threadParticipants(transcript); len(participants) > 0 {
+		sb.WriteString("- 参与者：" + strings.Join(participants, "、") + "\n")
+	}
+	sb.WriteString("- 时间：" + first.Time.Format("2006-01-02 15:04") + " ~ " + last.Time.Format("2006-01-02 15:04") + "\n")
+	if transcript.Permalink != "" {
+		sb.WriteString("- 原始链接：" + transcript.Permalink + "\n")
+	}
+	sb.WriteString("\n---\n")
+	for _, m := range transcript.Messages {
+		sb.WriteString("\n**" + threadSpeaker(m) + "** · " + m.Time.Format("2006-01-02 15:04") + "\n\n")
+		sb.WriteString(m.Content + "\n")
+	}
+	return sb.String()
+}
+
+// threadArchiveMetadata is stored as the knowledge item's custom metadata so
+// archived threads can be filtered and traced back to the chat.
+func threadArchiveMetadata(platform string, transcript *ThreadTranscript) map[string]any {
+	first := transcript.Messages[0]
+	last := transcript.Messages[len(transcript.Messages)-1]
+	metadata := map[string]any{
+		"source":        "im_thread",
+		"platform":      platform,
+		"chat_id":       transcript.ChatID,
+		"thread_id":     transcript.ThreadID,
+		"participants":  truncateMetadataValue(strings.Join(threadParticipants(transcript), ", ")),
+		"started_at":    first.Time.UTC().Format(time.RFC3339),
+		"ended_at":      last.Time.UTC().Format(time.RFC3339),
+		"message_count": len(transcript.Messages),
+	}
+	if transcript.Permalink != "" {
+		metadata["permalink"] = truncateMetadataValue(transcript.Permalink)
+	}
+	return metadata
+}
+
+// threadParticipants lists the people who wrote in the thread, in order of
+// first appearance. Bots are left out.
+func threadParticipants(transcript *ThreadTranscript) []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, m := range transcript.Messages {
+		if m.IsBot {
+			continue
+		}
+		name := threadSpeaker(m)
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// threadSpeaker names the author of a message for the transcript.
+func threadSpeaker(m ThreadMessage) string {
+	switch {
+	case m.UserName != "":
+		return m.UserName
+	case m.UserID != "":
+		return m.UserID
+	case m.IsBot:
+		return "机器人"
+	default:
+		return "未知用户"
+	}
+}
+
+// truncateMetadataValue cuts v to the metadata size limit on a rune boundary.
+func truncateMetadataValue(v string) string {
+	if len(v) <= maxThreadArchiveMetadataBytes {
+		return v
+	}
+	cut := 0
+	for i := range v {
+		if i > maxThreadArchiveMetadataBytes {
+			break
+		}
+		cut = i
+	}
+	return v[:cut]
+}
@@ -0,0 +1,174 @@
+package im
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/models/chat"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stubThreadChat struct {
+	response string
+	messages []chat.Message
+}
+
+func (c *stubThreadChat) Chat(_ context.Context, messages []chat.Message, _ *chat.ChatOptions) (*types.ChatResponse, error) {
+	c.messages = messages
+	return &types.ChatResponse{Content: c.response}, nil
+}
+
+func (c *stubThreadChat) ChatStream(context.Context, []chat.Message, *chat.ChatOptions) (<-chan types.StreamResponse, error) {
+	return nil, nil
+}
+
+func (c *stubThreadChat) GetModelName() string { return "stub" }
+func (c *stubThreadChat) GetModelID() string   { return "stub" }
+
+func testThreadTranscript() *ThreadTranscript {
+	start := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	return &ThreadTranscript{
+		ChatID:        "C1",
+		ThreadID:      "1700000000.000100",
+		RootMessageID: "1700000000.000100",
+		Permalink:     "https://example.slack.com/archives/C1/p1700000000000100",
+		Messages: []ThreadMessage{
+			{UserID: "U1", UserName: "alice", Content: "How do I rotate the API key?\nIt keeps failing.", Time: start},
+			{UserID: "U2", UserName: "bob", Content: "Use Settings > API Keys > Rotate.", Time: start.Add(5 * time.Minute)},
+			{UserID: "U1", UserName: "alice", Content: "That worked, thanks!", Time: start.Add(10 * time.Minute)},
+			{UserID: "B1", IsBot: true, Content: "Glad it helped.", Time: start.Add(11 * time.Minute)},
+		},
+	}
+}
+
+func TestThreadArchiveConfigMatchesReaction(t *testing.T) {
+	cfg := ThreadArchiveConfig{Enabled: true, Reaction: " :White_Check_Mark: "}
+	cfg.Normalize()
+	assert.Equal(t, "White_Check_Mark", cfg.Reaction)
+	assert.True(t, cfg.MatchesReaction("white_check_mark"))
+	assert.True(t, cfg.MatchesReaction(":white_check_mark:"))
+	assert.False(t, cfg.MatchesReaction("thumbsup"))
+
+	cfg.Enabled = false
+	assert.False(t, cfg.MatchesReaction("white_check_mark"))
+
+	assert.False(t, (&ThreadArchiveConfig{Enabled: true}).MatchesReaction(""))
+}
+
+func TestThreadArchiveConfigScan(t *testing.T) {
+	var cfg ThreadArchiveConfig
+	require.NoError(t, cfg.Scan([]byte(`{"enabled":true,"knowledge_base_id":"kb1","reaction":"DONE"}`)))
+	assert.Equal(t, ThreadArchiveConfig{Enabled: true, KnowledgeBaseID: "kb1", Reaction: "DONE"}, cfg)
+
+	var empty ThreadArchiveConfig
+	require.NoError(t, empty.Scan(nil))
+	assert.False(t, empty.Enabled)
+}
+
+func TestFilterThreadArchiveMessages(t *testing.T) {
+	registry := NewCommandRegistry()
+	registry.Register(newArchiveCommand())
+	svc := &Service{cmdRegistry: registry}
+
+	kept := svc.filterThreadArchiveMessages([]ThreadMessage{
+		{UserName: "alice", Content: "  question  "},
+		{UserName: "alice", Content: "   "},
+		{UserName: "bob", Content: "@WeKnora /archive"},
+		{UserName: "bob", Content: "/archive"},
+		{IsBot: true, Content: threadArchiveStartedNotice},
+		{IsBot: true, Content: threadArchiveDonePrefix + "（共 3 条消息）。"},
+		{IsBot: true, Content: "a real bot answer"},
+		{UserName: "carol", Content: "/api/v2/users returns 404"},
+	})
+
+	var contents []string
+	for _, m := range kept {
+		contents = append(contents, m.Content)
+	}
+	assert.Equal(t, []string{"question", "a real bot answer", "/api/v2/users returns 404"}, contents)
+}
+
+func TestStripLeadingMentions(t *testing.T) {
+	assert.Equal(t, "/archive", stripLeadingMentions("@WeKnora /archive"))
+	assert.Equal(t, "/archive now", stripLeadingMentions("<@U123> <@U456>  /archive now"))
+	assert.Equal(t, "hello", stripLeadingMentions("hello"))
+	assert.Equal(t, "", stripLeadingMentions("@WeKnora"))
+}
+
+func TestRenderThreadTranscript(t *testing.T) {
+	out := renderThreadTranscript("slack", testThreadTranscript())
+
+	assert.True(t, strings.HasPrefix(out, "# How do I rotate the API key?\n"))
+	assert.Contains(t, out, "- 参与者：alice、bob\n")
+	assert.Contains(t, out, "- 时间：2026-03-01 09:00 ~ 2026-03-01 09:11\n")
+	assert.Contains(t, out, "- 原始链接：https://example.slack.com/archives/C1/p1700000000000100\n")
+	assert.Contains(t, out, "**bob** · 2026-03-01 09:05\n\nUse Settings > API Keys > Rotate.\n")
+	assert.Contains(t, out, "**B1** · 2026-03-01 09:11\n\nGlad it helped.\n")
+}
+
+func TestThreadArchiveTitle(t *testing.T) {
+	transcript := &ThreadTranscript{Messages: []ThreadMessage{{
+		Content: strings.Repeat("话", 80),
+		Time:    time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC),
+	}}}
+	assert.Equal(t, strings.Repeat("话", maxThreadArchiveTitleRunes)+"…", threadArchiveTitle(transcript))
+
+	transcript.Messages[0].Content = "[image]"
+	assert.Equal(t, "[image]", threadArchiveTitle(transcript))
+
+	transcript.Messages[0].Content = ""
+	assert.Equal(t, "群聊话题 2026-03-01 09:00", threadArchiveTitle(transcript))
+}
+
+func TestThreadArchiveMetadata(t *testing.T) {
+	metadata := threadArchiveMetadata("slack", testThreadTranscript())
+
+	assert.Equal(t, "im_thread", metadata["source"])
+	assert.Equal(t, "slack", metadata["platform"])
+	assert.Equal(t, "C1", metadata["chat_id"])
+	assert.Equal(t, "1700000000.000100", metadata["thread_id"])
+	assert.Equal(t, "alice, bob", metadata["participants"])
+	assert.Equal(t, "2026-03-01T09:00:00Z", metadata["started_at"])
+	assert.Equal(t, "2026-03-01T09:11:00Z", metadata["ended_at"])
+	assert.Equal(t, 4, metadata["message_count"])
+	assert.Equal(t, "https://example.slack.com/archives/C1/p1700000000000100", metadata["permalink"])
+}
+
+func TestTruncateMetadataValue(t *testing.T) {
+	short := "alice, bob"
+	assert.Equal(t, short, truncateMetadataValue(short))
+
+	long := strings.Repeat("参与者", 200)
+	got := truncateMetadataValue(long)
+	assert.LessOrEqual(t, len(got), maxThreadArchiveMetadataBytes)
+	assert.True(t, strings.HasPrefix(long, got))
+	assert.Equal(t, 0, len(got)%len("参"))
+}
+
+func TestCondenseThreadToFAQ(t *testing.T) {
+	model := &stubThreadChat{response: "```json\n" + `{"skip":false,"question":"How do I rotate an API key?",` +
+		`"similar_questions":["Where can I regenerate my API key?","How do I rotate an API key?"," "],` +
+		`"answer":"Open Settings > API Keys and click Rotate."}` + "\n```"}
+
+	entry, err := condenseThreadToFAQ(context.Background(), model, testThreadTranscript())
+	require.NoError(t, err)
+	require.NotNil(t, entry)
+	assert.Equal(t, "How do I rotate an API key?", entry.StandardQuestion)
+	assert.Equal(t, []string{"Where can I regenerate my API key?"}, entry.SimilarQuestions)
+	assert.Equal(t, []string{"Open Settings > API Keys and click Rotate."}, entry.Answers)
+
+	require.Len(t, model.messages, 2)
+	assert.Contains(t, model.messages[1].Content, "bob: Use Settings > API Keys > Rotate.\n")
+}
+
+func TestCondenseThreadToFAQSkip(t *testing.T) {
+	model := &stubThreadChat{response: `{"skip":true,"question":"","similar_questions":[],"answer":""}`}
+
+	entry, err := condenseThreadToFAQ(context.Background(), model, testThreadTranscript())
+	require.NoError(t, err)
+	assert.Nil(t, entry)
+}
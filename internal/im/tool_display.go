@@ -34,6 +34,11 @@ var imToolNameLabels = map[string]string{
 	"grep_chunks":             "搜索关键词",
 	"web_search":              "网络搜索",
 	"web_fetch":               "网页抓取",
+	"browser_navigate":        "打开网页",
+	"browser_click":           "点击页面元素",
+	"browser_fill":            "填写表单",
+	"browser_extract":         "读取页面内容",
+	"browser_screenshot":      "页面截图",
 	"get_document_info":       "获取文档信息",
 	"list_knowledge_chunks":   "查看知识分块",
 	"get_related_documents":   "查找相关文档",
@@ -52,6 +57,7 @@ var imToolNameLabels = map[string]string{
 	"shell_exec":              "执行沙箱命令",
 	"data_analysis":           "数据分析",
 	"data_schema":             "数据结构",
+	"data_chart":              "绘制图表",
 	"database_query":          "数据库查询",
 }
 
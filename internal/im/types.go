@@ -2,6 +2,8 @@ package im
 
 import (
 	"crypto/sha256"
+	"database/sql/driver"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"net/url"
@@ -16,21 +18,22 @@ import (
 // IMChannel represents an IM channel configuration stored in the database.
 // Each channel binds to an agent and contains platform-specific credentials.
 type IMChannel struct {
-	ID              string         `json:"id"          gorm:"type:varchar(36);primaryKey;default:uuid_generate_v4()"`
-	TenantID        uint64         `json:"tenant_id"   gorm:"not null;index:idx_im_channels_tenant"`
-	AgentID         string         `json:"agent_id"    gorm:"type:varchar(36);not null;index:idx_im_channels_agent"`
-	Platform        string         `json:"platform"    gorm:"type:varchar(20);not null"`
-	Name            string         `json:"name"        gorm:"type:varchar(255);not null;default:''"`
-	Enabled         bool           `json:"enabled"     gorm:"not null;default:true"`
-	Mode            string         `json:"mode"        gorm:"type:varchar(20);not null;default:'websocket'"`
-	OutputMode      string         `json:"output_mode"       gorm:"type:varchar(20);not null;default:'stream'"`
-	KnowledgeBaseID string         `json:"knowledge_base_id" gorm:"type:varchar(36);default:''"`
-	BotIdentity     string         `json:"bot_identity"      gorm:"type:varchar(255);not null;default:'';uniqueIndex:idx_im_channels_bot_identity,where:deleted_at IS NULL AND bot_identity != ''"`
-	SessionMode     string         `json:"session_mode"      gorm:"type:varchar(20);not null;default:'user'"`
-	Credentials     types.JSON     `json:"credentials"       gorm:"type:jsonb;not null;default:'{}'"`
-	CreatedAt       time.Time      `json:"created_at"`
-	UpdatedAt       time.Time      `json:"updated_at"`
-	DeletedAt       gorm.DeletedAt `json:"deleted_at"  gorm:"index"`
+	ID              string              `json:"id"          gorm:"type:varchar(36);primaryKey;default:uuid_generate_v4()"`
+	TenantID        uint64              `json:"tenant_id"   gorm:"not null;index:idx_im_channels_tenant"`
+	AgentID         string              `json:"agent_id"    gorm:"type:varchar(36);not null;index:idx_im_channels_agent"`
+	Platform        string              `json:"platform"    gorm:"type:varchar(20);not null"`
+	Name            string              `json:"name"        gorm:"type:varchar(255);not null;default:''"`
+	Enabled         bool                `json:"enabled"     gorm:"not null;default:true"`
+	Mode            string              `json:"mode"        gorm:"type:varchar(20);not null;default:'websocket'"`
+	OutputMode      string              `json:"output_mode"       gorm:"type:varchar(20);not null;default:'stream'"`
+	KnowledgeBaseID string              `json:"knowledge_base_id" gorm:"type:varchar(36);default:''"`
+	BotIdentity     string              `json:"bot_identity"      gorm:"type:varchar(255);not null;default:'';uniqueIndex:idx_im_channels_bot_identity,where:deleted_at IS NULL AND bot_identity != ''"`
+	SessionMode     string              `json:"session_mode"      gorm:"type:varchar(20);not null;default:'user'"`
+	Credentials     types.JSON          `json:"credentials"       gorm:"type:jsonb;not null;default:'{}'"`
+	ThreadArchive   ThreadArchiveConfig `json:"thread_archive"    gorm:"type:jsonb;not null;default:'{}'"`
+	CreatedAt       time.Time           `json:"created_at"`
+	UpdatedAt       time.Time           `json:"updated_at"`
+	DeletedAt       gorm.DeletedAt      `json:"deleted_at"  gorm:"index"`
 }
 
 func (IMChannel) TableName() string {
@@ -41,20 +44,21 @@ func (IMChannel) TableName() string {
 // are never included — use Admin+ Create/Update responses to read back
 // values immediately after a mutation.
 type IMChannelSummary struct {
-	ID                    string    `json:"id"`
-	TenantID              uint64    `json:"tenant_id"`
-	AgentID               string    `json:"agent_id"`
-	Platform              string    `json:"platform"`
-	Name                  string    `json:"name"`
-	Enabled               bool      `json:"enabled"`
-	Mode                  string    `json:"mode"`
-	OutputMode            string    `json:"output_mode"`
-	KnowledgeBaseID       string    `json:"knowledge_base_id"`
-	BotIdentity           string    `json:"bot_identity"`
-	SessionMode           string    `json:"session_mode"`
-	CredentialsConfigured bool      `json:"credentials_configured"`
-	CreatedAt             time.Time `json:"created_at"`
-	UpdatedAt             time.Time `json:"updated_at"`
+	ID                    string              `json:"id"`
+	TenantID              uint64              `json:"tenant_id"`
+	AgentID               string              `json:"agent_id"`
+	Platform              string              `json:"platform"`
+	Name                  string              `json:"name"`
+	Enabled               bool                `json:"enabled"`
+	Mode                  string              `json:"mode"`
+	OutputMode            string              `json:"output_mode"`
+	KnowledgeBaseID       string              `json:"knowledge_base_id"`
+	BotIdentity           string              `json:"bot_identity"`
+	SessionMode           string              `json:"session_mode"`
+	ThreadArchive         ThreadArchiveConfig `json:"thread_archive"`
+	CredentialsConfigured bool                `json:"credentials_configured"`
+	CreatedAt             time.Time           `json:"created_at"`
+	UpdatedAt             time.Time           `json:"updated_at"`
 }
 
 // SummarizeIMChannel converts a stored channel into its list response shape.
@@ -71,6 +75,7 @@ func SummarizeIMChannel(ch IMChannel) IMChannelSummary {
 		KnowledgeBaseID:       ch.KnowledgeBaseID,
 		BotIdentity:           ch.BotIdentity,
 		SessionMode:           ch.SessionMode,
+		ThreadArchive:         ch.ThreadArchive,
 		CredentialsConfigured: imCredentialsConfigured(ch.Credentials),
 		CreatedAt:             ch.CreatedAt,
 		UpdatedAt:             ch.UpdatedAt,
@@ -96,7 +101,7 @@ func (ch *IMChannel) BeforeCreate(tx *gorm.DB) error {
 		ch.ID = uuid.New().String()
 	}
 	if ch.Mode == "" {
-		if ch.Platform == "mattermost" || ch.Platform == "yunzhijia" {
+		if ch.Platform == "mattermost" || ch.Platform == "yunzhijia" || ch.Platform == "teams" {
 			ch.Mode = "webhook"
 		} else {
 			ch.Mode = "websocket"
@@ -202,6 +207,23 @@ func (ch *IMChannel) computeBotIdentity() string {
 		if appID := str("app_id"); appID != "" {
 			return "qqbot:" + appID
 		}
+	case "discord":
+		// A bot token starts with the base64-encoded bot user ID.
+		if botToken := str("bot_token"); botToken != "" {
+			head, _, _ := strings.Cut(botToken, ".")
+			if id, err := base64.RawStdEncoding.DecodeString(strings.TrimRight(head, "=")); err == nil && len(id) > 0 {
+				return "discord:" + string(id)
+			}
+		}
+	case "teams":
+		if appID := str("app_id"); appID != "" {
+			return "teams:" + appID
+		}
+	case "matrix":
+		// Matrix user IDs include the homeserver name and are globally unique.
+		if userID := str("user_id"); userID != "" {
+			return "matrix:" + userID
+		}
 	case "yunzhijia":
 		if sendMsgURL := str("send_msg_url"); sendMsgURL != "" {
 			parsed, err := url.Parse(sendMsgURL)
@@ -216,6 +238,169 @@ func (ch *IMChannel) computeBotIdentity() string {
 	return ""
 }
 
+// ThreadArchiveConfig controls archiving of IM group threads into a knowledge
+// base. When enabled, /archive (or the configured reaction) turns the whole
+// thread into one manual knowledge item; with FAQKnowledgeBaseID set the chat
+// model also condenses it into an FAQ entry.
+type ThreadArchiveConfig struct {
+	Enabled bool `json:"enabled"`
+	// KnowledgeBaseID is the document knowledge base receiving transcripts.
+	KnowledgeBaseID string `json:"knowledge_base_id,omitempty"`
+	// Reaction is the emoji name that archives a thread when added to any of
+	// its messages (e.g. "white_check_mark" on Slack, "DONE" on Feishu).
+	// Empty means archiving is only triggered by /archive.
+	Reaction string `json:"reaction,omitempty"`
+	// FAQKnowledgeBaseID is an optional FAQ knowledge base for the condensed entry.
+	FAQKnowledgeBaseID string `json:"faq_knowledge_base_id,omitempty"`
+}
+
+// Value serializes the archive configuration for database storage.
+func (c ThreadArchiveConfig) Value() (driver.Value, error) { return json.Marshal(c) }
+
+// Scan deserializes the archive configuration from a database value.
+func (c *ThreadArchiveConfig) Scan(value interface{}) error {
+	var b []byte
+	switch v := value.(type) {
+	case []byte:
+		b = v
+	case string:
+		b = []byte(v)
+	default:
+		return nil
+	}
+	if len(b) == 0 {
+		return nil
+	}
+	return json.Unmarshal(b, c)
+}
+
+// Normalize trims the configured identifiers and strips the colons Slack
+// users tend to type around emoji names.
+func (c *ThreadArchiveConfig) Normalize() {
+	c.KnowledgeBaseID = strings.TrimSpace(c.KnowledgeBaseID)
+	c.FAQKnowledgeBaseID = strings.TrimSpace(c.FAQKnowledgeBaseID)
+	c.Reaction = strings.Trim(strings.TrimSpace(c.Reaction), ":")
+}
+
+// MatchesReaction reports whether an added reaction should archive the thread.
+func (c ThreadArchiveConfig) MatchesReaction(emoji string) bool {
+	return c.Enabled && c.Reaction != "" && strings.EqualFold(strings.Trim(emoji, ":"), c.Reaction)
+}
+
+// IMThreadArchive records an archived IM thread so a later /archive updates
+// the same knowledge item and FAQ entry instead of duplicating them.
+type IMThreadArchive struct {
+	ID                 string    `json:"id"                    gorm:"type:varchar(36);primaryKey"`
+	TenantID           uint64    `json:"tenant_id"             gorm:"not null;index"`
+	IMChannelID        string    `json:"im_channel_id"         gorm:"type:varchar(36);not null"`
+	Platform           string    `json:"platform"              gorm:"type:varchar(20);not null"`
+	ChatID             string    `json:"chat_id"               gorm:"type:varchar(128);not null;default:''"`
+	ThreadID           string    `json:"thread_id"             gorm:"type:varchar(128);not null"`
+	KnowledgeBaseID    string    `json:"knowledge_base_id"     gorm:"type:varchar(36);not null"`
+	KnowledgeID        string    `json:"knowledge_id"          gorm:"type:varchar(36);not null"`
+	FAQKnowledgeBaseID string    `json:"faq_knowledge_base_id" gorm:"type:varchar(36);not null;default:''"`
+	FAQEntryID         int64     `json:"faq_entry_id"          gorm:"not null;default:0"`
+	MessageCount       int       `json:"message_count"         gorm:"not null;default:0"`
+	ArchivedBy         string    `json:"archived_by"           gorm:"type:varchar(128);not null;default:''"`
+	TriggerType        string    `json:"trigger_type"          gorm:"type:varchar(16);not null;default:''"`
+	CreatedAt          time.Time `json:"created_at"`
+	UpdatedAt          time.Time `json:"updated_at"`
+}
+
+func (IMThreadArchive) TableName() string {
+	return "im_thread_archives"
+}
+
+func (a *IMThreadArchive) BeforeCreate(tx *gorm.DB) error {
+	if a.ID == "" {
+		a.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// IMUserBinding maps an IM platform user on a channel to a tenant member.
+// Slash-commands that change shared state (/agent, /save, /approve) check the
+// member's tenant role; unbound IM users act as viewers.
+type IMUserBinding struct {
+	ID          string    `json:"id"            gorm:"type:varchar(36);primaryKey"`
+	TenantID    uint64    `json:"tenant_id"     gorm:"not null;index"`
+	IMChannelID string    `json:"im_channel_id" gorm:"type:varchar(36);not null;uniqueIndex:idx_im_user_bindings_user"`
+	IMUserID    string    `json:"im_user_id"    gorm:"type:varchar(128);not null;uniqueIndex:idx_im_user_bindings_user"`
+	UserID      string    `json:"user_id"       gorm:"type:varchar(36);not null"`
+	CreatedBy   string    `json:"created_by"    gorm:"type:varchar(36);not null;default:''"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+func (IMUserBinding) TableName() string {
+	return "im_user_bindings"
+}
+
+func (b *IMUserBinding) BeforeCreate(tx *gorm.DB) error {
+	if b.ID == "" {
+		b.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// IMChatSetting holds per-chat overrides chosen with /agent and /kb. ChatKey
+// is the group chat ID, or "dm:<user>" for direct chats without a chat ID.
+type IMChatSetting struct {
+	ID               string            `json:"id"                 gorm:"type:varchar(36);primaryKey"`
+	TenantID         uint64            `json:"tenant_id"          gorm:"not null;index"`
+	IMChannelID      string            `json:"im_channel_id"      gorm:"type:varchar(36);not null;uniqueIndex:idx_im_chat_settings_chat"`
+	ChatKey          string            `json:"chat_key"           gorm:"type:varchar(160);not null;uniqueIndex:idx_im_chat_settings_chat"`
+	AgentID          string            `json:"agent_id"           gorm:"type:varchar(36);not null;default:''"`
+	KnowledgeBaseIDs types.StringArray `json:"knowledge_base_ids" gorm:"type:jsonb;not null;default:'[]'"`
+	UpdatedBy        string            `json:"updated_by"         gorm:"type:varchar(128);not null;default:''"`
+	CreatedAt        time.Time         `json:"created_at"`
+	UpdatedAt        time.Time         `json:"updated_at"`
+}
+
+func (IMChatSetting) TableName() string {
+	return "im_chat_settings"
+}
+
+func (c *IMChatSetting) BeforeCreate(tx *gorm.DB) error {
+	if c.ID == "" {
+		c.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// Feedback ratings recorded by /good and /bad.
+const (
+	FeedbackRatingGood = "good"
+	FeedbackRatingBad  = "bad"
+)
+
+// IMMessageFeedback is an IM user's rating of a bot answer. Each user keeps
+// one rating per answer; rating it again overwrites the previous one.
+type IMMessageFeedback struct {
+	ID          string    `json:"id"            gorm:"type:varchar(36);primaryKey"`
+	TenantID    uint64    `json:"tenant_id"     gorm:"not null;index"`
+	IMChannelID string    `json:"im_channel_id" gorm:"type:varchar(36);not null;index"`
+	Platform    string    `json:"platform"      gorm:"type:varchar(20);not null"`
+	IMUserID    string    `json:"im_user_id"    gorm:"type:varchar(128);not null;uniqueIndex:idx_im_message_feedback_user,priority:2"`
+	SessionID   string    `json:"session_id"    gorm:"type:varchar(36);not null"`
+	MessageID   string    `json:"message_id"    gorm:"type:varchar(36);not null;uniqueIndex:idx_im_message_feedback_user,priority:1"`
+	Rating      string    `json:"rating"        gorm:"type:varchar(8);not null"`
+	Comment     string    `json:"comment"       gorm:"type:text;not null;default:''"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+func (IMMessageFeedback) TableName() string {
+	return "im_message_feedback"
+}
+
+func (f *IMMessageFeedback) BeforeCreate(tx *gorm.DB) error {
+	if f.ID == "" {
+		f.ID = uuid.New().String()
+	}
+	return nil
+}
+
 // ChannelSession maps an IM channel (user+chat combination) to a WeKnora session.
 // This allows the IM integration to maintain conversation continuity.
 type ChannelSession struct {
@@ -221,3 +221,20 @@ func TestChannelSessionThreadIDField(t *testing.T) {
 		t.Errorf("ThreadID = %q, want empty", csUser.ThreadID)
 	}
 }
+
+func TestComputeBotIdentity_DiscordTeamsMatrix(t *testing.T) {
+	cases := []struct {
+		platform, creds, want string
+	}{
+		{"discord", `{"bot_token":"MTAxMjM0NTY3ODkwMTIzNDU2Nw.GhIjKl.secret"}`, "discord:1012345678901234567"},
+		{"teams", `{"app_id":"3f1c9a2e-bot","app_password":"p"}`, "teams:3f1c9a2e-bot"},
+		{"matrix", `{"homeserver_url":"https://matrix.example.org","user_id":"@weknora:example.org"}`, "matrix:@weknora:example.org"},
+		{"matrix", `{"homeserver_url":"https://matrix.example.org"}`, ""},
+	}
+	for _, tc := range cases {
+		ch := &IMChannel{Platform: tc.platform, Credentials: []byte(tc.creds)}
+		if got := ch.computeBotIdentity(); got != tc.want {
+			t.Errorf("%s identity = %q, want %q", tc.platform, got, tc.want)
+		}
+	}
+}
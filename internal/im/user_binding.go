@@ -0,0 +1,103 @@
+package im
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"gorm.io/gorm/clause"
+)
+
+// ErrBindingNotMember reports that a binding targets a user who is not an
+// active member of the channel's tenant.
+var ErrBindingNotMember = errors.New("user is not an active member of the tenant")
+
+// defaultFeedbackLimit and maxFeedbackLimit bound ListFeedback pages.
+const (
+	defaultFeedbackLimit = 50
+	maxFeedbackLimit     = 200
+)
+
+// ListUserBindings returns the channel's IM user bindings, newest first.
+func (s *Service) ListUserBindings(ctx context.Context, channel *IMChannel) ([]IMUserBinding, error) {
+	var bindings []IMUserBinding
+	err := s.db.WithContext(ctx).
+		Where("im_channel_id = ? AND tenant_id = ?", channel.ID, channel.TenantID).
+		Order("created_at DESC").
+		Find(&bindings).Error
+	return bindings, err
+}
+
+// BindUser maps an IM user of the channel to a tenant member. Binding an IM
+// user that is already bound replaces the previous member.
+func (s *Service) BindUser(ctx context.Context, channel *IMChannel, imUserID, userID, createdBy string) (*IMUserBinding, error) {
+	imUserID = strings.TrimSpace(imUserID)
+	userID = strings.TrimSpace(userID)
+	if imUserID == "" || userID == "" {
+		return nil, fmt.Errorf("im_user_id and user_id are required")
+	}
+	if s.tenantMemberService != nil {
+		member, err := s.tenantMemberService.GetMembership(ctx, userID, channel.TenantID)
+		if err != nil {
+			return nil, fmt.Errorf("get membership: %w", err)
+		}
+		if member == nil || member.Status != types.TenantMemberStatusActive {
+			return nil, ErrBindingNotMember
+		}
+	}
+
+	binding := &IMUserBinding{
+		TenantID:    channel.TenantID,
+		IMChannelID: channel.ID,
+		IMUserID:    imUserID,
+		UserID:      userID,
+		CreatedBy:   createdBy,
+	}
+	if err := s.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "im_channel_id"}, {Name: "im_user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"user_id", "created_by", "updated_at"}),
+	}).Create(binding).Error; err != nil {
+		return nil, err
+	}
+	// On conflict the generated ID was not stored; read the row back.
+	if err := s.db.WithContext(ctx).
+		Where("im_channel_id = ? AND im_user_id = ?", channel.ID, imUserID).
+		First(binding).Error; err != nil {
+		return nil, err
+	}
+	return binding, nil
+}
+
+// DeleteUserBinding removes one of the channel's IM user bindings.
+func (s *Service) DeleteUserBinding(ctx context.Context, channel *IMChannel, bindingID string) error {
+	result := s.db.WithContext(ctx).
+		Where("id = ? AND im_channel_id = ? AND tenant_id = ?", bindingID, channel.ID, channel.TenantID).
+		Delete(&IMUserBinding{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("binding not found")
+	}
+	return nil
+}
+
+// ListFeedback returns the channel's /good and /bad ratings, newest first,
+// optionally filtered by rating.
+func (s *Service) ListFeedback(ctx context.Context, channel *IMChannel, rating string, limit int) ([]IMMessageFeedback, error) {
+	if limit <= 0 {
+		limit = defaultFeedbackLimit
+	}
+	if limit > maxFeedbackLimit {
+		limit = maxFeedbackLimit
+	}
+	q := s.db.WithContext(ctx).Where("im_channel_id = ? AND tenant_id = ?", channel.ID, channel.TenantID)
+	if rating != "" {
+		q = q.Where("rating = ?", rating)
+	}
+	var feedback []IMMessageFeedback
+	err := q.Order("updated_at DESC").Limit(limit).Find(&feedback).Error
+	return feedback, err
+}
@@ -0,0 +1,151 @@
+package im
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/models/asr"
+	"github.com/Tencent/WeKnora/internal/models/tts"
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+const (
+	// imVoiceTranscribeTimeout bounds downloading and transcribing one voice message.
+	imVoiceTranscribeTimeout = 2 * time.Minute
+	// imVoiceReplyTimeout bounds synthesizing and uploading a spoken reply.
+	imVoiceReplyTimeout = 2 * time.Minute
+	// maxIMVoiceReplyRunes keeps spoken replies short; IM voice notes are
+	// meant to be listened to, long answers stay text-only past this point.
+	maxIMVoiceReplyRunes = 1000
+)
+
+// errEmptyTranscript is returned when ASR recognised no speech.
+var errEmptyTranscript = errors.New("empty transcript")
+
+// agentTranscribesVoice reports whether the agent has an ASR model for voice messages.
+func agentTranscribesVoice(agent *types.CustomAgent) bool {
+	return agent != nil && agent.Config.AudioUploadEnabled && agent.Config.ASRModelID != ""
+}
+
+// agentSpeaksReplies reports whether the agent answers voice messages with a spoken reply.
+func agentSpeaksReplies(agent *types.CustomAgent) bool {
+	return agent != nil && agent.Config.VoiceReplyEnabled && agent.Config.TTSModelID != ""
+}
+
+// transcribeIMVoice downloads a voice message, transcribes it with the
+// agent's ASR model and shows the transcript to the user. On success the
+// transcript becomes msg.Content; MessageType stays MessageTypeAudio so the
+// answer can be spoken back.
+func (s *Service) transcribeIMVoice(ctx context.Context, msg *IncomingMessage, adapter Adapter, agent *types.CustomAgent) error {
+	voiceCtx, cancel := context.WithTimeout(ctx, imVoiceTranscribeTimeout)
+	defer cancel()
+
+	audio, fileName, err := downloadIMAttachment(voiceCtx, msg, adapter)
+	if err != nil {
+		return fmt.Errorf("download voice: %w", err)
+	}
+	asrModel, err := s.modelService.GetASRModel(voiceCtx, agent.Config.ASRModelID)
+	if err != nil {
+		return fmt.Errorf("get ASR model: %w", err)
+	}
+	result, err := asrModel.Transcribe(voiceCtx, audio, imVoiceFileName(fileName, audio))
+	if err != nil {
+		return fmt.Errorf("transcribe: %w", err)
+	}
+	text := strings.TrimSpace(result.Text)
+	if text == "" {
+		return errEmptyTranscript
+	}
+	if runes := []rune(text); len(runes) > maxContentLength {
+		text = string(runes[:maxContentLength])
+	}
+	msg.Content = text
+	logger.Infof(ctx, "[IM] Voice message transcribed: platform=%s user=%s chars=%d", msg.Platform, msg.UserID, len([]rune(text)))
+
+	if err := adapter.SendReply(ctx, msg, &ReplyMessage{Content: "🎤 识别结果：" + text, IsFinal: true}); err != nil {
+		logger.Warnf(ctx, "[IM] Failed to send voice transcript: %v", err)
+	}
+	return nil
+}
+
+// sendIMVoiceReply speaks the answer to a voice message when the agent has
+// spoken replies enabled and the platform can deliver voice messages. The
+// text answer has already been sent, so failures are only logged.
+func (s *Service) sendIMVoiceReply(ctx context.Context, msg *IncomingMessage, adapter Adapter, agent *types.CustomAgent, answer string) {
+	if msg.MessageType != MessageTypeAudio || !agentSpeaksReplies(agent) || ctx.Err() != nil {
+		return
+	}
+	sender, ok := adapter.(VoiceSender)
+	if !ok {
+		return
+	}
+	text := speakableIMText(answer)
+	if text == "" {
+		return
+	}
+	if runes := []rune(text); len(runes) > maxIMVoiceReplyRunes {
+		text = tts.TruncateInput(text, maxIMVoiceReplyRunes)
+	}
+
+	voiceCtx, cancel := context.WithTimeout(ctx, imVoiceReplyTimeout)
+	defer cancel()
+	ttsModel, err := s.modelService.GetTTSModel(voiceCtx, agent.Config.TTSModelID)
+	if err != nil {
+		logger.Warnf(ctx, "[IM] Get TTS model failed, skipping spoken reply: %v", err)
+		return
+	}
+	audio, err := ttsModel.Synthesize(voiceCtx, text, tts.FormatOpus)
+	if err != nil {
+		logger.Warnf(ctx, "[IM] Speech synthesis failed, skipping spoken reply: %v", err)
+		return
+	}
+	if err := sender.SendVoice(voiceCtx, msg, audio); err != nil {
+		logger.Warnf(ctx, "[IM] Send voice reply failed: %v", err)
+		return
+	}
+	logger.Infof(ctx, "[IM] Voice reply sent: platform=%s user=%s audio_bytes=%d", msg.Platform, msg.UserID, len(audio))
+}
+
+// imVoiceFileName gives the ASR API a file name whose extension it accepts:
+// Ogg/Opus voice notes arrive as .oga/.opus, and some platforms send no name.
+func imVoiceFileName(name string, audio []byte) string {
+	ext := filepath.Ext(name)
+	switch strings.ToLower(ext) {
+	case ".oga", ".opus":
+		return strings.TrimSuffix(name, ext) + ".ogg"
+	case "":
+		return "voice" + asr.DetectAudioFormat(audio, "")
+	}
+	return name
+}
+
+var (
+	speakCodeBlockRe  = regexp.MustCompile("(?s)```.*?```")
+	speakImageRe      = regexp.MustCompile(`!\[[^\]]*\]\([^)]*\)`)
+	speakLinkRe       = regexp.MustCompile(`\[([^\]]+)\]\([^)]*\)`)
+	speakURLRe        = regexp.MustCompile(`https?://\S+`)
+	speakLinePrefixRe = regexp.MustCompile(`(?m)^\s*(?:#{1,6}\s+|>\s*|[-*+]\s+)`)
+	speakBlankLinesRe = regexp.MustCompile(`\n{3,}`)
+	speakMarkupChars  = strings.NewReplacer("**", "", "__", "", "~~", "", "`", "", "*", "", "|", " ")
+)
+
+// speakableIMText reduces a Markdown answer to plain text for speech:
+// code blocks, images, URLs and citation tags are dropped and link text is
+// kept.
+func speakableIMText(answer string) string {
+	text := stripImageXMLTags(stripIMCitationTags(answer))
+	text = speakCodeBlockRe.ReplaceAllString(text, "")
+	text = speakImageRe.ReplaceAllString(text, "")
+	text = speakLinkRe.ReplaceAllString(text, "$1")
+	text = speakURLRe.ReplaceAllString(text, "")
+	text = speakLinePrefixRe.ReplaceAllString(text, "")
+	text = speakMarkupChars.Replace(text)
+	text = speakBlankLinesRe.ReplaceAllString(text, "\n\n")
+	return strings.TrimSpace(text)
+}
@@ -0,0 +1,128 @@
+package im
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/models/asr"
+	"github.com/Tencent/WeKnora/internal/models/tts"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+type voiceTestAdapter struct {
+	attachmentTestAdapter
+	replies []string
+	voices  [][]byte
+}
+
+func (a *voiceTestAdapter) SendReply(_ context.Context, _ *IncomingMessage, reply *ReplyMessage) error {
+	a.replies = append(a.replies, reply.Content)
+	return nil
+}
+
+func (a *voiceTestAdapter) SendVoice(_ context.Context, _ *IncomingMessage, audio []byte) error {
+	a.voices = append(a.voices, audio)
+	return nil
+}
+
+type stubVoiceModels struct {
+	interfaces.ModelService
+	transcript string
+	asrFile    string
+	spoken     string
+}
+
+func (m *stubVoiceModels) GetASRModel(context.Context, string) (asr.ASR, error) { return m, nil }
+func (m *stubVoiceModels) GetTTSModel(context.Context, string) (tts.TTS, error) { return m, nil }
+func (m *stubVoiceModels) GetModelName() string                                 { return "stub" }
+func (m *stubVoiceModels) GetModelID() string                                   { return "stub" }
+
+func (m *stubVoiceModels) Transcribe(_ context.Context, _ []byte, fileName string) (*asr.TranscriptionResult, error) {
+	m.asrFile = fileName
+	return &asr.TranscriptionResult{Text: m.transcript}, nil
+}
+
+func (m *stubVoiceModels) Synthesize(_ context.Context, text string, format tts.Format) ([]byte, error) {
+	if format != tts.FormatOpus {
+		return nil, errors.New("voice replies must be Opus")
+	}
+	m.spoken = text
+	return []byte("OggS"), nil
+}
+
+func voiceAgent(reply bool) *types.CustomAgent {
+	agent := &types.CustomAgent{}
+	agent.Config.AudioUploadEnabled = true
+	agent.Config.ASRModelID = "asr-1"
+	agent.Config.VoiceReplyEnabled = reply
+	agent.Config.TTSModelID = "tts-1"
+	return agent
+}
+
+func TestTranscribeIMVoice(t *testing.T) {
+	models := &stubVoiceModels{transcript: "  明天的值班安排是什么？ "}
+	svc := &Service{modelService: models}
+	adapter := &voiceTestAdapter{attachmentTestAdapter: attachmentTestAdapter{content: []byte("OggS voice"), fileName: "voice/file_1.oga"}}
+	msg := &IncomingMessage{MessageType: MessageTypeAudio, FileKey: "voice-1"}
+
+	if err := svc.transcribeIMVoice(context.Background(), msg, adapter, voiceAgent(false)); err != nil {
+		t.Fatal(err)
+	}
+	if msg.Content != "明天的值班安排是什么？" || msg.MessageType != MessageTypeAudio {
+		t.Errorf("msg = %+v", msg)
+	}
+	if models.asrFile != "voice/file_1.ogg" {
+		t.Errorf("ASR file name = %q, want .oga mapped to .ogg", models.asrFile)
+	}
+	if len(adapter.replies) != 1 || !strings.Contains(adapter.replies[0], "明天的值班安排是什么？") {
+		t.Errorf("transcript must be shown to the user: %v", adapter.replies)
+	}
+
+	models.transcript = " "
+	if err := svc.transcribeIMVoice(context.Background(), &IncomingMessage{MessageType: MessageTypeAudio}, adapter, voiceAgent(false)); !errors.Is(err, errEmptyTranscript) {
+		t.Errorf("err = %v, want errEmptyTranscript", err)
+	}
+}
+
+func TestSendIMVoiceReply(t *testing.T) {
+	models := &stubVoiceModels{}
+	svc := &Service{modelService: models}
+	adapter := &voiceTestAdapter{}
+	answer := "## 值班\n\n请联系 **张三**，详见[值班表](https://wiki.example.com/oncall)。<kb doc=\"1\"/>\n\n```bash\noncall page\n```"
+
+	svc.sendIMVoiceReply(context.Background(), &IncomingMessage{MessageType: MessageTypeText}, adapter, voiceAgent(true), answer)
+	if len(adapter.voices) != 0 {
+		t.Fatal("text questions must not get a spoken reply")
+	}
+	svc.sendIMVoiceReply(context.Background(), &IncomingMessage{MessageType: MessageTypeAudio}, adapter, voiceAgent(false), answer)
+	if len(adapter.voices) != 0 {
+		t.Fatal("spoken replies are opt-in per agent")
+	}
+
+	svc.sendIMVoiceReply(context.Background(), &IncomingMessage{MessageType: MessageTypeAudio}, adapter, voiceAgent(true), answer)
+	if len(adapter.voices) != 1 {
+		t.Fatalf("voices = %d, want 1", len(adapter.voices))
+	}
+	if models.spoken != "值班\n\n请联系 张三，详见值班表。" {
+		t.Errorf("spoken text = %q", models.spoken)
+	}
+}
+
+func TestIMVoiceFileName(t *testing.T) {
+	cases := map[string]string{
+		"voice.opus":      "voice.ogg",
+		"memo.m4a":        "memo.m4a",
+		"Audio Clip.webm": "Audio Clip.webm",
+	}
+	for in, want := range cases {
+		if got := imVoiceFileName(in, nil); got != want {
+			t.Errorf("imVoiceFileName(%q) = %q, want %q", in, got, want)
+		}
+	}
+	if got := imVoiceFileName("", []byte("RIFF....WAVE")); got != "voice.wav" {
+		t.Errorf("unnamed WAV = %q", got)
+	}
+}
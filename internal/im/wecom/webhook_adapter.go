@@ -265,6 +265,28 @@ func (a *WebhookAdapter) ParseCallback(c *gin.Context) (*im.IncomingMessage, err
 			FileName:    msg.MsgID + ".png",
 		}, nil
 
+	case "voice":
+		// Voice via webhook: AMR/Speex audio behind a temporary MediaId,
+		// transcribed with the agent's ASR model.
+		if msg.MediaId == "" {
+			return nil, nil
+		}
+		format := strings.ToLower(msg.Format)
+		if format == "" {
+			format = "amr"
+		}
+		return &im.IncomingMessage{
+			Platform:    im.PlatformWeCom,
+			MessageType: im.MessageTypeAudio,
+			UserID:      msg.FromUserName,
+			UserName:    msg.FromUserName,
+			ChatID:      chatID,
+			ChatType:    chatType,
+			MessageID:   msg.MsgID,
+			FileKey:     msg.MediaId,
+			FileName:    msg.MsgID + "." + format,
+		}, nil
+
 	default:
 		logger.Infof(c.Request.Context(), "[WeCom] Ignoring unsupported message type: %s", msg.MsgType)
 		return nil, nil
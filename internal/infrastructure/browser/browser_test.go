@@ -0,0 +1,461 @@
+package browser
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// fakeCDP is a local stand-in for a headless Chromium's DevTools endpoint.
+// It answers the commands Page sends and plays back the requests a page load
+// would issue, so the Fetch-interception guard can be exercised end to end.
+type fakeCDP struct {
+	server *httptest.Server
+
+	// subresources are requested by every page after its document.
+	subresources []string
+	// redirects maps a navigated URL to the document URL actually requested.
+	redirects map[string]string
+
+	mu          sync.Mutex
+	auth        []string
+	methods     []string
+	failed      []string
+	filled      map[string]string
+	connections int
+	disposed    int
+}
+
+func newFakeCDP(t *testing.T) *fakeCDP {
+	t.Helper()
+	f := &fakeCDP{
+		redirects: map[string]string{},
+		filled:    map[string]string{},
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/json/version", func(w http.ResponseWriter, r *http.Request) {
+		f.recordAuth(r)
+		// Chromium reports its own view of the address; the client must
+		// replace the host with the configured one.
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"webSocketDebuggerUrl": "ws://127.0.0.1:1/devtools/browser/fake",
+		})
+	})
+	mux.HandleFunc("/devtools/browser/fake", f.serveWS)
+	f.server = httptest.NewServer(mux)
+	t.Cleanup(f.server.Close)
+	return f
+}
+
+func (f *fakeCDP) endpoint() Endpoint {
+	return Endpoint{URL: f.server.URL, Token: "secret", AllowPrivate: true}
+}
+
+func (f *fakeCDP) recordAuth(r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.auth = append(f.auth, r.Header.Get("Authorization"))
+}
+
+func (f *fakeCDP) sawMethod(method string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, m := range f.methods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *fakeCDP) stats() (connections, disposed int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.connections, f.disposed
+}
+
+type fakeNavigation struct {
+	id          int64
+	url         string
+	outstanding map[string]bool
+	docBlocked  bool
+}
+
+func (f *fakeCDP) serveWS(w http.ResponseWriter, r *http.Request) {
+	f.recordAuth(r)
+	conn, err := (&websocket.Upgrader{}).Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	f.mu.Lock()
+	f.connections++
+	f.mu.Unlock()
+
+	send := func(msg map[string]interface{}) {
+		_ = conn.WriteJSON(msg)
+	}
+	reply := func(id int64, result interface{}) {
+		send(map[string]interface{}{"id": id, "result": result})
+	}
+
+	currentURL := "about:blank"
+	var nav *fakeNavigation
+	finishNavigation := func() {
+		if nav.docBlocked {
+			reply(nav.id, map[string]string{"frameId": "main", "errorText": "net::ERR_BLOCKED_BY_CLIENT"})
+		} else {
+			currentURL = nav.url
+			reply(nav.id, map[string]string{"frameId": "main"})
+			send(map[string]interface{}{"method": "Page.frameStartedLoading", "sessionId": "s1", "params": map[string]string{"frameId": "main"}})
+			send(map[string]interface{}{"method": "Page.loadEventFired", "sessionId": "s1", "params": map[string]float64{"timestamp": 1}})
+		}
+		nav = nil
+	}
+
+	for {
+		var msg struct {
+			ID     int64           `json:"id"`
+			Method string          `json:"method"`
+			Params json.RawMessage `json:"params"`
+		}
+		if err := conn.ReadJSON(&msg); err != nil {
+			return
+		}
+		f.mu.Lock()
+		f.methods = append(f.methods, msg.Method)
+		f.mu.Unlock()
+
+		var params map[string]interface{}
+		_ = json.Unmarshal(msg.Params, &params)
+
+		switch msg.Method {
+		case "Target.createBrowserContext":
+			reply(msg.ID, map[string]string{"browserContextId": "ctx1"})
+		case "Target.createTarget":
+			reply(msg.ID, map[string]string{"targetId": "t1"})
+		case "Target.attachToTarget":
+			reply(msg.ID, map[string]string{"sessionId": "s1"})
+		case "Page.getFrameTree":
+			reply(msg.ID, map[string]interface{}{"frameTree": map[string]interface{}{"frame": map[string]string{"id": "main"}}})
+		case "Target.disposeBrowserContext":
+			f.mu.Lock()
+			f.disposed++
+			f.mu.Unlock()
+			reply(msg.ID, map[string]string{})
+		case "Page.navigate":
+			target, _ := params["url"].(string)
+			doc := target
+			if redirected, ok := f.redirects[target]; ok {
+				doc = redirected
+			}
+			nav = &fakeNavigation{id: msg.ID, url: doc, outstanding: map[string]bool{"doc": true}}
+			send(map[string]interface{}{"method": "Fetch.requestPaused", "sessionId": "s1", "params": map[string]interface{}{
+				"requestId": "doc", "request": map[string]string{"url": doc}, "resourceType": "Document",
+			}})
+			for i, sub := range f.subresources {
+				id := fmt.Sprintf("sub%d", i)
+				nav.outstanding[id] = true
+				send(map[string]interface{}{"method": "Fetch.requestPaused", "sessionId": "s1", "params": map[string]interface{}{
+					"requestId": id, "request": map[string]string{"url": sub}, "resourceType": "Image",
+				}})
+			}
+		case "Fetch.continueRequest", "Fetch.failRequest":
+			reply(msg.ID, map[string]string{})
+			requestID, _ := params["requestId"].(string)
+			if msg.Method == "Fetch.failRequest" {
+				f.mu.Lock()
+				f.failed = append(f.failed, requestID)
+				f.mu.Unlock()
+				if requestID == "doc" && nav != nil {
+					nav.docBlocked = true
+				}
+			}
+			if nav != nil {
+				delete(nav.outstanding, requestID)
+				if len(nav.outstanding) == 0 {
+					finishNavigation()
+				}
+			}
+		case "Runtime.evaluate":
+			reply(msg.ID, map[string]interface{}{"result": map[string]interface{}{"value": f.evaluate(params, currentURL)}})
+		case "Page.captureScreenshot":
+			reply(msg.ID, map[string]string{"data": base64.StdEncoding.EncodeToString([]byte("PNGDATA"))})
+		case "Page.getLayoutMetrics":
+			reply(msg.ID, map[string]interface{}{"cssContentSize": map[string]float64{"width": 1280, "height": 3000}})
+		default:
+			reply(msg.ID, map[string]string{})
+		}
+	}
+}
+
+// evaluate recognises the page scripts by their shape. Only #go can be
+// clicked and only #q filled.
+func (f *fakeCDP) evaluate(params map[string]interface{}, currentURL string) interface{} {
+	expression, _ := params["expression"].(string)
+	quoted := func(s string) bool { return strings.Contains(expression, jsString(s)) }
+	switch {
+	case strings.HasPrefix(expression, "({url: location.href"):
+		return map[string]string{"url": currentURL, "title": "Fake page"}
+	case strings.Contains(expression, "el.click()"):
+		return map[string]bool{"found": quoted("#go")}
+	case strings.Contains(expression, "requestSubmit"):
+		if !quoted("#q") {
+			return map[string]bool{"found": false}
+		}
+		f.mu.Lock()
+		f.filled["#q"] = "filled"
+		f.mu.Unlock()
+		return map[string]bool{"found": true}
+	case strings.Contains(expression, "elements.push"):
+		return map[string]interface{}{
+			"found": true, "url": currentURL, "title": "Fake page", "text": "Hello world from the fake page",
+			"elements": []map[string]string{{"selector": `[data-weknora-ref="1"]`, "tag": "a", "text": "Next", "href": "https://example.com/2"}},
+		}
+	}
+	return nil
+}
+
+// publicResolver maps test hosts to addresses; unknown hosts fail to resolve.
+func publicResolver(hosts map[string]string) func(context.Context, string) ([]net.IP, error) {
+	return func(_ context.Context, host string) ([]net.IP, error) {
+		if ip, ok := hosts[host]; ok {
+			return []net.IP{net.ParseIP(ip)}, nil
+		}
+		return nil, errors.New("no such host")
+	}
+}
+
+var testHosts = map[string]string{
+	"example.com":   "93.184.216.34",
+	"cdn.example":   "93.184.216.35",
+	"other.org":     "93.184.216.36",
+	"intranet.test": "10.0.0.5",
+	"metadata.test": "169.254.169.254",
+}
+
+func openTestPage(t *testing.T, f *fakeCDP, allowedDomains ...string) *Page {
+	t.Helper()
+	page, err := OpenPage(context.Background(), Options{Endpoint: f.endpoint(), AllowedDomains: allowedDomains, Timeout: 5 * time.Second})
+	if err != nil {
+		t.Fatalf("OpenPage: %v", err)
+	}
+	t.Cleanup(page.Close)
+	page.guard.validateURL = func(string) error { return nil }
+	page.guard.resolveIPs = publicResolver(testHosts)
+	return page
+}
+
+func TestPage_NavigateBlocksPrivateSubresources(t *testing.T) {
+	f := newFakeCDP(t)
+	f.subresources = []string{"https://cdn.example/logo.png", "http://metadata.test/latest/meta-data/"}
+	page := openTestPage(t, f)
+
+	state, err := page.Navigate(context.Background(), "https://example.com/")
+	if err != nil {
+		t.Fatalf("Navigate: %v", err)
+	}
+	if state.URL != "https://example.com/" || state.Title != "Fake page" || !state.Loaded {
+		t.Fatalf("unexpected state: %+v", state)
+	}
+	if len(state.Blocked) != 1 || state.Blocked[0] != "http://metadata.test/latest/meta-data/" {
+		t.Fatalf("expected the metadata request to be reported as blocked, got %v", state.Blocked)
+	}
+	f.mu.Lock()
+	failed := append([]string(nil), f.failed...)
+	auth := append([]string(nil), f.auth...)
+	f.mu.Unlock()
+	if len(failed) != 1 || failed[0] != "sub1" {
+		t.Fatalf("expected only the metadata request to be failed, got %v", failed)
+	}
+	for _, header := range auth {
+		if header != "Bearer secret" {
+			t.Fatalf("expected the token on discovery and handshake, got %q", header)
+		}
+	}
+}
+
+func TestPage_NavigateRejectsPrivateTargetBeforeSending(t *testing.T) {
+	f := newFakeCDP(t)
+	page := openTestPage(t, f)
+
+	for _, target := range []string{"http://intranet.test/admin", "file:///etc/passwd", "javascript:alert(1)"} {
+		if _, err := page.Navigate(context.Background(), target); !errors.Is(err, ErrURLBlocked) {
+			t.Errorf("%s: expected ErrURLBlocked, got %v", target, err)
+		}
+	}
+	if f.sawMethod("Page.navigate") {
+		t.Fatal("blocked URLs must never reach the browser")
+	}
+}
+
+func TestPage_RedirectToPrivateAddressIsBlocked(t *testing.T) {
+	f := newFakeCDP(t)
+	f.redirects["https://example.com/go"] = "http://intranet.test/"
+	page := openTestPage(t, f)
+
+	_, err := page.Navigate(context.Background(), "https://example.com/go")
+	if !errors.Is(err, ErrURLBlocked) || !strings.Contains(err.Error(), "intranet.test") {
+		t.Fatalf("expected the redirect target to be blocked, got %v", err)
+	}
+}
+
+func TestPage_AllowedDomains(t *testing.T) {
+	f := newFakeCDP(t)
+	f.subresources = []string{"https://cdn.example/app.js"}
+	page := openTestPage(t, f, "*.Example.com")
+
+	if _, err := page.Navigate(context.Background(), "https://other.org/"); !errors.Is(err, ErrURLBlocked) {
+		t.Fatalf("expected other.org to be outside the allowlist, got %v", err)
+	}
+	state, err := page.Navigate(context.Background(), "https://example.com/")
+	if err != nil {
+		t.Fatalf("Navigate: %v", err)
+	}
+	if len(state.Blocked) != 0 {
+		t.Fatalf("sub-resources on public CDNs should not be held to the document allowlist, got %v", state.Blocked)
+	}
+}
+
+func TestPage_InteractAndCapture(t *testing.T) {
+	f := newFakeCDP(t)
+	page := openTestPage(t, f)
+	ctx := context.Background()
+
+	if _, err := page.Navigate(ctx, "https://example.com/"); err != nil {
+		t.Fatalf("Navigate: %v", err)
+	}
+	if _, err := page.Click(ctx, "#go"); err != nil {
+		t.Fatalf("Click: %v", err)
+	}
+	if _, err := page.Click(ctx, "#missing"); err == nil {
+		t.Fatal("expected a missing element to be reported")
+	}
+	if _, err := page.Fill(ctx, "#q", "weknora", true); err != nil {
+		t.Fatalf("Fill: %v", err)
+	}
+	f.mu.Lock()
+	filled := f.filled["#q"]
+	f.mu.Unlock()
+	if filled != "filled" {
+		t.Fatal("expected the fill script to run")
+	}
+
+	content, err := page.Extract(ctx, "", 11)
+	if err != nil {
+		t.Fatalf("Extract: %v", err)
+	}
+	if content.Text != "Hello world" || !content.Truncated || len(content.Elements) != 1 {
+		t.Fatalf("unexpected content: %+v", content)
+	}
+
+	shot, err := page.Screenshot(ctx, true)
+	if err != nil {
+		t.Fatalf("Screenshot: %v", err)
+	}
+	if string(shot) != "PNGDATA" {
+		t.Fatalf("unexpected screenshot bytes %q", shot)
+	}
+}
+
+func TestEndpoint_OutboundGuard(t *testing.T) {
+	f := newFakeCDP(t)
+	endpoint := f.endpoint()
+	endpoint.AllowPrivate = false
+	if _, err := OpenPage(context.Background(), Options{Endpoint: endpoint}); err == nil {
+		t.Fatal("expected a loopback endpoint to need AllowPrivate")
+	}
+	for _, raw := range []string{"http://169.254.169.254:9222", "ws://169.254.169.254/devtools", "ftp://browser:21"} {
+		if err := (Endpoint{URL: raw, AllowPrivate: true}).Validate(); err == nil {
+			t.Errorf("%s: expected the endpoint to be rejected", raw)
+		}
+	}
+}
+
+func TestSessions_ReuseAndReplace(t *testing.T) {
+	f := newFakeCDP(t)
+	sessions := NewSessions()
+	ctx := context.Background()
+	opts := Options{Endpoint: f.endpoint(), Timeout: 5 * time.Second}
+	state := func(opts Options) {
+		t.Helper()
+		if err := sessions.Do(ctx, "chat-1", opts, func(ctx context.Context, page *Page) error {
+			_, err := page.State(ctx)
+			return err
+		}); err != nil {
+			t.Fatalf("Do: %v", err)
+		}
+	}
+
+	state(opts)
+	state(opts)
+	if connections, _ := f.stats(); connections != 1 {
+		t.Fatalf("expected the page to be reused, got %d connections", connections)
+	}
+
+	// Editing the config (here the allowlist) replaces the page.
+	opts.AllowedDomains = []string{"example.com"}
+	state(opts)
+	waitFor(t, func() bool {
+		connections, disposed := f.stats()
+		return connections == 2 && disposed == 1
+	})
+
+	// An idle session is reaped when another session is used.
+	now := time.Now()
+	sessions.now = func() time.Time { return now.Add(time.Hour) }
+	if err := sessions.Do(ctx, "chat-2", opts, func(context.Context, *Page) error { return nil }); err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	waitFor(t, func() bool {
+		_, disposed := f.stats()
+		return disposed == 2
+	})
+
+	sessions.Close("chat-2")
+	if _, disposed := f.stats(); disposed != 3 {
+		t.Fatalf("expected Close to dispose the page, got %d disposals", disposed)
+	}
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if cond() {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("condition not met in time")
+}
+
+func TestSessions_CloseConfig(t *testing.T) {
+	f := newFakeCDP(t)
+	sessions := NewSessions()
+	opts := Options{Endpoint: f.endpoint(), Timeout: 5 * time.Second}
+	for _, key := range []string{SessionKey("cfg-a", "chat-1"), SessionKey("cfg-a", "chat-2"), SessionKey("cfg-b", "chat-1")} {
+		if err := sessions.Do(context.Background(), key, opts, func(context.Context, *Page) error { return nil }); err != nil {
+			t.Fatalf("Do: %v", err)
+		}
+	}
+	sessions.CloseConfig("cfg-a")
+	waitFor(t, func() bool {
+		_, disposed := f.stats()
+		return disposed == 2
+	})
+	if _, ok := sessions.sessions[SessionKey("cfg-b", "chat-1")]; !ok {
+		t.Fatal("pages of other configs must stay open")
+	}
+}
@@ -0,0 +1,341 @@
+// Package browser drives a remote headless Chromium over the Chrome DevTools
+// Protocol (CDP) for the interactive browser agent tools.
+//
+// The browser itself is an external service configured per workspace (a
+// chromium --remote-debugging-port container, browserless, ...). This package
+// speaks the small subset of CDP the tools need over a single WebSocket,
+// rather than going through chromedp, because every connection to the
+// workspace-supplied endpoint has to be dialed through the sandbox outbound
+// guard and chromedp's remote allocator offers no hook for the dialer.
+package browser
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/sandbox"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	dialTimeout = 15 * time.Second
+	// maxMessageSize bounds a single CDP message; full-page screenshots are
+	// the largest payloads and arrive base64 encoded.
+	maxMessageSize = 32 << 20
+	// maxVersionBodySize bounds the /json/version discovery response.
+	maxVersionBodySize = 64 << 10
+)
+
+// ErrClosed is returned for calls on a connection whose WebSocket is gone.
+var ErrClosed = errors.New("browser: connection closed")
+
+// Endpoint is where the headless browser listens and how to reach it.
+type Endpoint struct {
+	// URL is either the DevTools HTTP endpoint (http://host:9222), which is
+	// resolved through /json/version, or a browser WebSocket URL (ws://...).
+	URL string
+	// Token, when set, is sent as a Bearer token on discovery and handshake.
+	Token string
+	// AllowPrivate permits loopback / RFC1918 endpoints, e.g. a browser
+	// container on the compose network. Link-local stays blocked.
+	AllowPrivate bool
+}
+
+// policy returns the outbound guard applied to the endpoint itself.
+func (e Endpoint) policy() sandbox.OutboundURLPolicy {
+	return sandbox.OutboundURLPolicy{AllowPrivate: e.AllowPrivate}
+}
+
+// Validate checks the endpoint URL against the outbound guard. WebSocket
+// schemes are validated as their HTTP equivalents.
+func (e Endpoint) Validate() error {
+	raw := strings.TrimSpace(e.URL)
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("invalid browser endpoint: %w", err)
+	}
+	switch strings.ToLower(parsed.Scheme) {
+	case "ws":
+		parsed.Scheme = "http"
+	case "wss":
+		parsed.Scheme = "https"
+	}
+	return e.policy().Validate(parsed.String())
+}
+
+// dialer returns a net.Dialer that re-checks every address at connect time,
+// which is what closes the DNS-rebinding window left by Validate.
+func (e Endpoint) dialer() *net.Dialer {
+	return &net.Dialer{
+		Timeout:   dialTimeout,
+		KeepAlive: 30 * time.Second,
+		Control:   sandbox.SafeDialControlForPolicy(e.policy()),
+	}
+}
+
+func (e Endpoint) header() http.Header {
+	header := http.Header{}
+	if e.Token != "" {
+		header.Set("Authorization", "Bearer "+e.Token)
+	}
+	return header
+}
+
+// cdpMessage is the envelope of every CDP frame: commands and their
+// responses carry an ID, events carry a method and no ID.
+type cdpMessage struct {
+	ID        int64           `json:"id,omitempty"`
+	Method    string          `json:"method,omitempty"`
+	Params    json.RawMessage `json:"params,omitempty"`
+	SessionID string          `json:"sessionId,omitempty"`
+	Result    json.RawMessage `json:"result,omitempty"`
+	Error     *cdpError       `json:"error,omitempty"`
+}
+
+type cdpError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *cdpError) Error() string {
+	return fmt.Sprintf("cdp error %d: %s", e.Code, e.Message)
+}
+
+// cdpConn multiplexes commands over one browser WebSocket. Events are handed
+// to onEvent from the read loop, so handlers must not block on further calls;
+// the page spawns a goroutine for anything that needs a round trip.
+type cdpConn struct {
+	ws      *websocket.Conn
+	onEvent func(*cdpMessage)
+
+	writeMu sync.Mutex
+
+	mu      sync.Mutex
+	nextID  int64
+	pending map[int64]chan *cdpMessage
+	err     error
+	done    chan struct{}
+}
+
+// dialCDP resolves the browser WebSocket URL and connects to it.
+func dialCDP(ctx context.Context, endpoint Endpoint, onEvent func(*cdpMessage)) (*cdpConn, error) {
+	if err := endpoint.Validate(); err != nil {
+		return nil, err
+	}
+	wsURL, err := resolveWebSocketURL(ctx, endpoint)
+	if err != nil {
+		return nil, err
+	}
+	dialer := websocket.Dialer{
+		NetDialContext:   endpoint.dialer().DialContext,
+		HandshakeTimeout: dialTimeout,
+	}
+	ws, resp, err := dialer.DialContext(ctx, wsURL, endpoint.header())
+	if resp != nil && resp.Body != nil {
+		_ = resp.Body.Close()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("connect to browser: %w", err)
+	}
+	ws.SetReadLimit(maxMessageSize)
+
+	c := &cdpConn{
+		ws:      ws,
+		onEvent: onEvent,
+		pending: make(map[int64]chan *cdpMessage),
+		done:    make(chan struct{}),
+	}
+	go c.readLoop()
+	return c, nil
+}
+
+// resolveWebSocketURL turns a DevTools HTTP endpoint into the browser
+// WebSocket URL. The host Chromium reports is its own view (often
+// 127.0.0.1), so it is replaced by the configured host, as chromedp does.
+func resolveWebSocketURL(ctx context.Context, endpoint Endpoint) (string, error) {
+	base, err := url.Parse(strings.TrimSpace(endpoint.URL))
+	if err != nil {
+		return "", fmt.Errorf("invalid browser endpoint: %w", err)
+	}
+	switch strings.ToLower(base.Scheme) {
+	case "ws", "wss":
+		return base.String(), nil
+	case "http", "https":
+	default:
+		return "", fmt.Errorf("unsupported browser endpoint scheme %q", base.Scheme)
+	}
+
+	versionURL := *base
+	versionURL.Path = strings.TrimSuffix(base.Path, "/") + "/json/version"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, versionURL.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header = endpoint.header()
+	client := &http.Client{
+		Timeout:   dialTimeout,
+		Transport: &http.Transport{DialContext: endpoint.dialer().DialContext, Proxy: nil},
+		// The discovery response must come from the configured host.
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("query browser version: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("query browser version: HTTP %d", resp.StatusCode)
+	}
+	var version struct {
+		WebSocketDebuggerURL string `json:"webSocketDebuggerUrl"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxVersionBodySize)).Decode(&version); err != nil {
+		return "", fmt.Errorf("decode browser version: %w", err)
+	}
+	if version.WebSocketDebuggerURL == "" {
+		return "", fmt.Errorf("browser endpoint did not report a webSocketDebuggerUrl")
+	}
+	wsURL, err := url.Parse(version.WebSocketDebuggerURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid webSocketDebuggerUrl: %w", err)
+	}
+	wsURL.Host = base.Host
+	wsURL.Scheme = "ws"
+	if strings.EqualFold(base.Scheme, "https") {
+		wsURL.Scheme = "wss"
+	}
+	if wsURL.RawQuery == "" {
+		wsURL.RawQuery = base.RawQuery
+	}
+	return wsURL.String(), nil
+}
+
+// call sends a command and waits for its response. sessionID addresses an
+// attached target; empty means the browser itself.
+func (c *cdpConn) call(ctx context.Context, sessionID, method string, params, result interface{}) error {
+	var rawParams json.RawMessage
+	if params != nil {
+		encoded, err := json.Marshal(params)
+		if err != nil {
+			return err
+		}
+		rawParams = encoded
+	}
+
+	c.mu.Lock()
+	if c.err != nil {
+		err := c.err
+		c.mu.Unlock()
+		return err
+	}
+	c.nextID++
+	id := c.nextID
+	reply := make(chan *cdpMessage, 1)
+	c.pending[id] = reply
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		delete(c.pending, id)
+		c.mu.Unlock()
+	}()
+
+	frame, err := json.Marshal(&cdpMessage{ID: id, Method: method, Params: rawParams, SessionID: sessionID})
+	if err != nil {
+		return err
+	}
+	c.writeMu.Lock()
+	err = c.ws.WriteMessage(websocket.TextMessage, frame)
+	c.writeMu.Unlock()
+	if err != nil {
+		c.fail(err)
+		return fmt.Errorf("%s: %w", method, err)
+	}
+
+	select {
+	case msg := <-reply:
+		if msg.Error != nil {
+			return fmt.Errorf("%s: %w", method, msg.Error)
+		}
+		if result != nil && len(msg.Result) > 0 {
+			if err := json.Unmarshal(msg.Result, result); err != nil {
+				return fmt.Errorf("%s: decode result: %w", method, err)
+			}
+		}
+		return nil
+	case <-c.done:
+		return fmt.Errorf("%s: %w", method, c.closeErr())
+	case <-ctx.Done():
+		return fmt.Errorf("%s: %w", method, ctx.Err())
+	}
+}
+
+func (c *cdpConn) readLoop() {
+	for {
+		_, data, err := c.ws.ReadMessage()
+		if err != nil {
+			c.fail(err)
+			return
+		}
+		var msg cdpMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			continue
+		}
+		if msg.ID != 0 {
+			c.mu.Lock()
+			reply := c.pending[msg.ID]
+			c.mu.Unlock()
+			if reply != nil {
+				reply <- &msg
+			}
+			continue
+		}
+		if c.onEvent != nil && msg.Method != "" {
+			c.onEvent(&msg)
+		}
+	}
+}
+
+// fail records the first transport error and wakes every waiting caller.
+func (c *cdpConn) fail(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return
+	}
+	c.err = fmt.Errorf("%w: %v", ErrClosed, err)
+	close(c.done)
+}
+
+func (c *cdpConn) closeErr() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+// closed reports whether the WebSocket has gone away.
+func (c *cdpConn) closed() bool {
+	select {
+	case <-c.done:
+		return true
+	default:
+		return false
+	}
+}
+
+func (c *cdpConn) close() {
+	c.fail(errors.New("closed by client"))
+	c.writeMu.Lock()
+	_ = c.ws.WriteControl(websocket.CloseMessage,
+		websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(time.Second))
+	c.writeMu.Unlock()
+	_ = c.ws.Close()
+}
@@ -0,0 +1,157 @@
+package browser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/Tencent/WeKnora/internal/utils"
+)
+
+// ErrURLBlocked is returned when a page URL is refused by the SSRF guard or
+// the domain allowlist.
+var ErrURLBlocked = errors.New("browser: URL blocked")
+
+// urlGuard applies web_fetch's SSRF rules to what the browser loads. It runs
+// twice: on the URL the agent asks for, and — through CDP Fetch interception —
+// on every request the page makes, which covers redirects, sub-resources,
+// link clicks and form posts.
+//
+// Addresses are resolved from this server's view of DNS, as web_fetch does.
+// Verdicts are cached per origin for the lifetime of the page, since one page
+// load can issue hundreds of requests to the same few hosts.
+type urlGuard struct {
+	allowedDomains []string
+	validateURL    func(string) error
+	resolveIPs     func(context.Context, string) ([]net.IP, error)
+
+	mu       sync.Mutex
+	verdicts map[string]error
+}
+
+func newURLGuard(allowedDomains []string) *urlGuard {
+	return &urlGuard{
+		allowedDomains: normalizeDomains(allowedDomains),
+		validateURL:    utils.ValidateURLForSSRF,
+		resolveIPs:     lookupIP,
+		verdicts:       make(map[string]error),
+	}
+}
+
+func lookupIP(ctx context.Context, host string) ([]net.IP, error) {
+	return net.DefaultResolver.LookupIP(ctx, "ip", host)
+}
+
+func normalizeDomains(domains []string) []string {
+	out := make([]string, 0, len(domains))
+	for _, domain := range domains {
+		domain = strings.ToLower(strings.TrimSpace(domain))
+		domain = strings.TrimPrefix(domain, "*.")
+		domain = strings.Trim(domain, ".")
+		if domain != "" {
+			out = append(out, domain)
+		}
+	}
+	return out
+}
+
+// checkNavigation validates a URL the agent asked to open.
+func (g *urlGuard) checkNavigation(ctx context.Context, raw string) error {
+	parsed, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return fmt.Errorf("%w: invalid URL: %v", ErrURLBlocked, err)
+	}
+	switch strings.ToLower(parsed.Scheme) {
+	case "http", "https":
+	default:
+		return fmt.Errorf("%w: only http and https URLs can be opened", ErrURLBlocked)
+	}
+	if err := g.checkDomain(parsed.Hostname()); err != nil {
+		return err
+	}
+	return g.checkOrigin(ctx, parsed)
+}
+
+// checkRequest validates a request the page is about to send. The domain
+// allowlist applies to documents only: pages routinely pull scripts and
+// styles from CDNs, and those still go through the SSRF check.
+func (g *urlGuard) checkRequest(ctx context.Context, raw string, document bool) error {
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("%w: invalid URL: %v", ErrURLBlocked, err)
+	}
+	switch strings.ToLower(parsed.Scheme) {
+	case "data", "blob", "about":
+		return nil
+	case "http", "https":
+	default:
+		return fmt.Errorf("%w: scheme %q is not allowed", ErrURLBlocked, parsed.Scheme)
+	}
+	if document {
+		if err := g.checkDomain(parsed.Hostname()); err != nil {
+			return err
+		}
+	}
+	return g.checkOrigin(ctx, parsed)
+}
+
+func (g *urlGuard) checkDomain(host string) error {
+	if len(g.allowedDomains) == 0 {
+		return nil
+	}
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	for _, domain := range g.allowedDomains {
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s is not in the allowed domains of this browser config", ErrURLBlocked, host)
+}
+
+// checkOrigin runs the SSRF validation once per scheme://host:port.
+func (g *urlGuard) checkOrigin(ctx context.Context, parsed *url.URL) error {
+	origin := strings.ToLower(parsed.Scheme) + "://" + strings.ToLower(parsed.Host)
+	g.mu.Lock()
+	verdict, ok := g.verdicts[origin]
+	g.mu.Unlock()
+	if ok {
+		return verdict
+	}
+
+	final, verdict := g.resolveOrigin(ctx, parsed)
+	if final {
+		g.mu.Lock()
+		g.verdicts[origin] = verdict
+		g.mu.Unlock()
+	}
+	return verdict
+}
+
+// resolveOrigin returns whether the verdict may be cached, and the verdict; a failed
+// DNS lookup may be transient and is retried on the next request.
+func (g *urlGuard) resolveOrigin(ctx context.Context, parsed *url.URL) (bool, error) {
+	host := parsed.Hostname()
+	if err := g.validateURL(parsed.String()); err != nil {
+		return true, fmt.Errorf("%w: %v", ErrURLBlocked, err)
+	}
+	if utils.IsSSRFWhitelisted(host) {
+		return true, nil
+	}
+	ips, err := g.resolveIPs(ctx, host)
+	if err != nil {
+		return false, fmt.Errorf("%w: DNS lookup failed for %s: %v", ErrURLBlocked, host, err)
+	}
+	if len(ips) == 0 {
+		return false, fmt.Errorf("%w: DNS lookup returned no addresses for %s", ErrURLBlocked, host)
+	}
+	for _, ip := range ips {
+		if !utils.IsPublicIP(ip) {
+			return true, fmt.Errorf("%w: %s resolves to restricted IP %s", ErrURLBlocked, host, ip)
+		}
+	}
+	return true, nil
+}
@@ -0,0 +1,599 @@
+package browser
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+const (
+	defaultTimeout        = 30 * time.Second
+	defaultViewportWidth  = 1280
+	defaultViewportHeight = 800
+	// navigationSettleDelay is how long a click or submit is given to start a
+	// navigation before the action is treated as in-page.
+	navigationSettleDelay = 700 * time.Millisecond
+	// maxScreenshotHeight caps full-page screenshots of endless pages.
+	maxScreenshotHeight = 10000
+	// maxBlockedURLs bounds the blocked-request list reported per action.
+	maxBlockedURLs = 10
+	// maxPageElements bounds the interactive elements returned by Extract.
+	maxPageElements = 80
+	// elementRefAttribute marks elements listed by Extract so the agent can
+	// address them with a stable selector in the next call.
+	elementRefAttribute = "data-weknora-ref"
+)
+
+// Options configures one browser page.
+type Options struct {
+	Endpoint Endpoint
+	// AllowedDomains restricts which sites may be opened as documents;
+	// empty allows any public site.
+	AllowedDomains []string
+	ViewportWidth  int
+	ViewportHeight int
+	// Timeout bounds each action, including waiting for the page to load.
+	Timeout time.Duration
+}
+
+func (o Options) withDefaults() Options {
+	if o.Timeout <= 0 {
+		o.Timeout = defaultTimeout
+	}
+	if o.ViewportWidth <= 0 {
+		o.ViewportWidth = defaultViewportWidth
+	}
+	if o.ViewportHeight <= 0 {
+		o.ViewportHeight = defaultViewportHeight
+	}
+	return o
+}
+
+// PageState describes the page after an action.
+type PageState struct {
+	URL   string `json:"url"`
+	Title string `json:"title"`
+	// Loaded is false when the load event did not fire within the timeout;
+	// the page may still be usable.
+	Loaded bool `json:"loaded"`
+	// Blocked lists requests the SSRF guard refused during the action.
+	Blocked []string `json:"blocked,omitempty"`
+}
+
+// Element is an interactive element found by Extract.
+type Element struct {
+	Selector string `json:"selector"`
+	Tag      string `json:"tag"`
+	Type     string `json:"type,omitempty"`
+	Name     string `json:"name,omitempty"`
+	Text     string `json:"text,omitempty"`
+	Href     string `json:"href,omitempty"`
+}
+
+// PageContent is the visible text of a page or element plus the interactive
+// elements inside it.
+type PageContent struct {
+	URL       string    `json:"url"`
+	Title     string    `json:"title"`
+	Text      string    `json:"text"`
+	Truncated bool      `json:"truncated"`
+	Elements  []Element `json:"elements"`
+}
+
+// Page is one tab in its own browser context, so cookies and storage are
+// never shared between pages. A Page is not safe for concurrent actions; the
+// Sessions registry serialises them.
+type Page struct {
+	conn      *cdpConn
+	sessionID string
+	contextID string
+	guard     *urlGuard
+	timeout   time.Duration
+
+	loadStarted chan struct{}
+	loaded      chan struct{}
+
+	mu          sync.Mutex
+	mainFrameID string
+	blocked     []string
+}
+
+// OpenPage connects to the browser and opens an isolated blank page.
+func OpenPage(ctx context.Context, opts Options) (*Page, error) {
+	opts = opts.withDefaults()
+	p := &Page{
+		guard:       newURLGuard(opts.AllowedDomains),
+		timeout:     opts.Timeout,
+		loadStarted: make(chan struct{}, 1),
+		loaded:      make(chan struct{}, 1),
+	}
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+
+	conn, err := dialCDP(ctx, opts.Endpoint, p.handleEvent)
+	if err != nil {
+		return nil, err
+	}
+	p.conn = conn
+	if err := p.setup(ctx, opts); err != nil {
+		p.Close()
+		return nil, err
+	}
+	return p, nil
+}
+
+func (p *Page) setup(ctx context.Context, opts Options) error {
+	var browserContext struct {
+		BrowserContextID string `json:"browserContextId"`
+	}
+	if err := p.conn.call(ctx, "", "Target.createBrowserContext",
+		map[string]interface{}{"disposeOnDetach": true}, &browserContext); err != nil {
+		return err
+	}
+	p.contextID = browserContext.BrowserContextID
+
+	var target struct {
+		TargetID string `json:"targetId"`
+	}
+	if err := p.conn.call(ctx, "", "Target.createTarget", map[string]interface{}{
+		"url":              "about:blank",
+		"browserContextId": p.contextID,
+	}, &target); err != nil {
+		return err
+	}
+	var attached struct {
+		SessionID string `json:"sessionId"`
+	}
+	if err := p.conn.call(ctx, "", "Target.attachToTarget", map[string]interface{}{
+		"targetId": target.TargetID,
+		"flatten":  true,
+	}, &attached); err != nil {
+		return err
+	}
+	p.sessionID = attached.SessionID
+
+	if err := p.conn.call(ctx, p.sessionID, "Page.enable", nil, nil); err != nil {
+		return err
+	}
+	// Every request of the page pauses until handleRequestPaused has run it
+	// past the SSRF guard.
+	if err := p.conn.call(ctx, p.sessionID, "Fetch.enable", map[string]interface{}{
+		"patterns": []map[string]string{{"urlPattern": "*", "requestStage": "Request"}},
+	}, nil); err != nil {
+		return err
+	}
+	if err := p.conn.call(ctx, p.sessionID, "Emulation.setDeviceMetricsOverride", map[string]interface{}{
+		"width":             opts.ViewportWidth,
+		"height":            opts.ViewportHeight,
+		"deviceScaleFactor": 1,
+		"mobile":            false,
+	}, nil); err != nil {
+		return err
+	}
+	var tree struct {
+		FrameTree struct {
+			Frame struct {
+				ID string `json:"id"`
+			} `json:"frame"`
+		} `json:"frameTree"`
+	}
+	if err := p.conn.call(ctx, p.sessionID, "Page.getFrameTree", nil, &tree); err != nil {
+		return err
+	}
+	p.mu.Lock()
+	p.mainFrameID = tree.FrameTree.Frame.ID
+	p.mu.Unlock()
+	return nil
+}
+
+// Closed reports whether the connection to the browser is gone.
+func (p *Page) Closed() bool {
+	return p.conn == nil || p.conn.closed()
+}
+
+// Close disposes the page's browser context and disconnects.
+func (p *Page) Close() {
+	if p.conn == nil {
+		return
+	}
+	if p.contextID != "" && !p.conn.closed() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		_ = p.conn.call(ctx, "", "Target.disposeBrowserContext",
+			map[string]string{"browserContextId": p.contextID}, nil)
+		cancel()
+	}
+	p.conn.close()
+}
+
+// handleEvent runs on the connection's read loop; anything that needs a
+// round trip is handed to its own goroutine.
+func (p *Page) handleEvent(msg *cdpMessage) {
+	switch msg.Method {
+	case "Fetch.requestPaused":
+		go p.handleRequestPaused(msg.SessionID, msg.Params)
+	case "Page.frameStartedLoading":
+		var ev struct {
+			FrameID string `json:"frameId"`
+		}
+		if json.Unmarshal(msg.Params, &ev) == nil && ev.FrameID == p.mainFrame() {
+			signal(p.loadStarted)
+		}
+	case "Page.loadEventFired":
+		signal(p.loaded)
+	case "Page.javascriptDialogOpening":
+		// An open alert() freezes the page until it is answered.
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), p.timeout)
+			defer cancel()
+			_ = p.conn.call(ctx, msg.SessionID, "Page.handleJavaScriptDialog",
+				map[string]interface{}{"accept": true}, nil)
+		}()
+	}
+}
+
+func (p *Page) handleRequestPaused(sessionID string, params json.RawMessage) {
+	var ev struct {
+		RequestID string `json:"requestId"`
+		Request   struct {
+			URL string `json:"url"`
+		} `json:"request"`
+		ResourceType string `json:"resourceType"`
+	}
+	if err := json.Unmarshal(params, &ev); err != nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), p.timeout)
+	defer cancel()
+
+	if err := p.guard.checkRequest(ctx, ev.Request.URL, ev.ResourceType == "Document"); err != nil {
+		logger.Warnf(ctx, "[Browser] Blocked %s request: %v", ev.ResourceType, err)
+		p.recordBlocked(ev.Request.URL)
+		_ = p.conn.call(ctx, sessionID, "Fetch.failRequest", map[string]string{
+			"requestId":   ev.RequestID,
+			"errorReason": "BlockedByClient",
+		}, nil)
+		return
+	}
+	_ = p.conn.call(ctx, sessionID, "Fetch.continueRequest", map[string]string{"requestId": ev.RequestID}, nil)
+}
+
+func (p *Page) mainFrame() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.mainFrameID
+}
+
+func (p *Page) recordBlocked(rawURL string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.blocked) < maxBlockedURLs {
+		p.blocked = append(p.blocked, rawURL)
+	}
+}
+
+func (p *Page) takeBlocked() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	blocked := p.blocked
+	p.blocked = nil
+	return blocked
+}
+
+// beginAction forgets the signals and blocked requests of earlier actions.
+func (p *Page) beginAction() {
+	drain(p.loadStarted)
+	drain(p.loaded)
+	p.takeBlocked()
+}
+
+func signal(ch chan struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
+
+func drain(ch chan struct{}) {
+	select {
+	case <-ch:
+	default:
+	}
+}
+
+// waitLoaded waits for the load event; a slow page is not an error.
+func (p *Page) waitLoaded(ctx context.Context) bool {
+	timer := time.NewTimer(p.timeout)
+	defer timer.Stop()
+	select {
+	case <-p.loaded:
+		return true
+	case <-timer.C:
+		return false
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// settle waits for a navigation the last action may have triggered. Actions
+// that stay on the page report loaded=true after the settle delay.
+func (p *Page) settle(ctx context.Context) bool {
+	timer := time.NewTimer(navigationSettleDelay)
+	defer timer.Stop()
+	select {
+	case <-p.loadStarted:
+		return p.waitLoaded(ctx)
+	case <-p.loaded:
+		return true
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// Navigate opens rawURL after checking it against the SSRF guard.
+func (p *Page) Navigate(ctx context.Context, rawURL string) (*PageState, error) {
+	if err := p.guard.checkNavigation(ctx, rawURL); err != nil {
+		return nil, err
+	}
+	p.beginAction()
+	var res struct {
+		ErrorText string `json:"errorText"`
+	}
+	if err := p.conn.call(ctx, p.sessionID, "Page.navigate", map[string]string{"url": rawURL}, &res); err != nil {
+		return nil, err
+	}
+	if res.ErrorText != "" {
+		if blocked := p.takeBlocked(); len(blocked) > 0 {
+			return nil, fmt.Errorf("%w: the browser was redirected to %s", ErrURLBlocked, blocked[0])
+		}
+		return nil, fmt.Errorf("navigation failed: %s", res.ErrorText)
+	}
+	loaded := p.waitLoaded(ctx)
+	return p.state(ctx, loaded)
+}
+
+// Click clicks the first element matching a CSS selector.
+func (p *Page) Click(ctx context.Context, selector string) (*PageState, error) {
+	p.beginAction()
+	var res struct {
+		Found bool `json:"found"`
+	}
+	if err := p.evaluate(ctx, fmt.Sprintf(clickScript, jsString(selector)), &res); err != nil {
+		return nil, err
+	}
+	if !res.Found {
+		return nil, fmt.Errorf("no element matches selector %q", selector)
+	}
+	loaded := p.settle(ctx)
+	return p.state(ctx, loaded)
+}
+
+// Fill types value into the input, textarea, select or editable element
+// matching selector, optionally submitting its form.
+func (p *Page) Fill(ctx context.Context, selector, value string, submit bool) (*PageState, error) {
+	p.beginAction()
+	var res struct {
+		Found bool `json:"found"`
+	}
+	script := fmt.Sprintf(fillScript, jsString(selector), jsString(value), submit)
+	if err := p.evaluate(ctx, script, &res); err != nil {
+		return nil, err
+	}
+	if !res.Found {
+		return nil, fmt.Errorf("no fillable element matches selector %q", selector)
+	}
+	loaded := true
+	if submit {
+		loaded = p.settle(ctx)
+	}
+	return p.state(ctx, loaded)
+}
+
+// Extract returns the visible text of the page, or of the element matching
+// selector, and the interactive elements within it. Text beyond maxChars
+// runes is cut off.
+func (p *Page) Extract(ctx context.Context, selector string, maxChars int) (*PageContent, error) {
+	var res struct {
+		Found bool `json:"found"`
+		PageContent
+	}
+	script := fmt.Sprintf(extractScript, jsString(selector), elementRefAttribute, maxPageElements)
+	if err := p.evaluate(ctx, script, &res); err != nil {
+		return nil, err
+	}
+	if !res.Found {
+		return nil, fmt.Errorf("no element matches selector %q", selector)
+	}
+	content := res.PageContent
+	if maxChars > 0 {
+		if runes := []rune(content.Text); len(runes) > maxChars {
+			content.Text = string(runes[:maxChars])
+			content.Truncated = true
+		}
+	}
+	if content.Elements == nil {
+		content.Elements = []Element{}
+	}
+	return &content, nil
+}
+
+// Screenshot captures the viewport, or the whole page when fullPage is set,
+// as PNG.
+func (p *Page) Screenshot(ctx context.Context, fullPage bool) ([]byte, error) {
+	params := map[string]interface{}{"format": "png"}
+	if fullPage {
+		var metrics struct {
+			CSSContentSize struct {
+				Width  float64 `json:"width"`
+				Height float64 `json:"height"`
+			} `json:"cssContentSize"`
+		}
+		if err := p.conn.call(ctx, p.sessionID, "Page.getLayoutMetrics", nil, &metrics); err != nil {
+			return nil, err
+		}
+		height := metrics.CSSContentSize.Height
+		if height > maxScreenshotHeight {
+			height = maxScreenshotHeight
+		}
+		if metrics.CSSContentSize.Width > 0 && height > 0 {
+			params["captureBeyondViewport"] = true
+			params["clip"] = map[string]interface{}{
+				"x": 0, "y": 0, "width": metrics.CSSContentSize.Width, "height": height, "scale": 1,
+			}
+		}
+	}
+	var res struct {
+		Data string `json:"data"`
+	}
+	if err := p.conn.call(ctx, p.sessionID, "Page.captureScreenshot", params, &res); err != nil {
+		return nil, err
+	}
+	data, err := base64.StdEncoding.DecodeString(res.Data)
+	if err != nil {
+		return nil, fmt.Errorf("decode screenshot: %w", err)
+	}
+	return data, nil
+}
+
+// State reports the current URL and title.
+func (p *Page) State(ctx context.Context) (*PageState, error) {
+	return p.state(ctx, true)
+}
+
+func (p *Page) state(ctx context.Context, loaded bool) (*PageState, error) {
+	state := &PageState{Loaded: loaded}
+	if err := p.evaluate(ctx, `({url: location.href, title: document.title})`, state); err != nil {
+		return nil, err
+	}
+	state.Blocked = p.takeBlocked()
+	return state, nil
+}
+
+// evaluate runs expression in the page and decodes its JSON value into out.
+func (p *Page) evaluate(ctx context.Context, expression string, out interface{}) error {
+	var res struct {
+		Result struct {
+			Value json.RawMessage `json:"value"`
+		} `json:"result"`
+		ExceptionDetails *struct {
+			Text      string `json:"text"`
+			Exception *struct {
+				Description string `json:"description"`
+			} `json:"exception"`
+		} `json:"exceptionDetails"`
+	}
+	if err := p.conn.call(ctx, p.sessionID, "Runtime.evaluate", map[string]interface{}{
+		"expression":    expression,
+		"returnByValue": true,
+		"awaitPromise":  true,
+		"userGesture":   true,
+	}, &res); err != nil {
+		return err
+	}
+	if res.ExceptionDetails != nil {
+		message := res.ExceptionDetails.Text
+		if res.ExceptionDetails.Exception != nil && res.ExceptionDetails.Exception.Description != "" {
+			message = res.ExceptionDetails.Exception.Description
+		}
+		return fmt.Errorf("page script failed: %s", message)
+	}
+	if out == nil || len(res.Result.Value) == 0 {
+		return nil
+	}
+	return json.Unmarshal(res.Result.Value, out)
+}
+
+// jsString quotes s as a JavaScript string literal; JSON string syntax is a
+// subset of it.
+func jsString(s string) string {
+	encoded, _ := json.Marshal(s)
+	return string(encoded)
+}
+
+const clickScript = `(() => {
+  const el = document.querySelector(%s);
+  if (!el) return {found: false};
+  el.scrollIntoView({block: 'center', inline: 'center'});
+  el.click();
+  return {found: true};
+})()`
+
+// fillScript goes through the native value setter so frameworks that track
+// input state (React, Vue) see the change.
+const fillScript = `(() => {
+  const el = document.querySelector(%s);
+  const value = %s;
+  if (!el) return {found: false};
+  el.focus();
+  if (el.isContentEditable) {
+    el.textContent = value;
+  } else if (el instanceof HTMLInputElement || el instanceof HTMLTextAreaElement || el instanceof HTMLSelectElement) {
+    const setter = Object.getOwnPropertyDescriptor(Object.getPrototypeOf(el), 'value').set;
+    setter.call(el, value);
+  } else {
+    return {found: false};
+  }
+  el.dispatchEvent(new Event('input', {bubbles: true}));
+  el.dispatchEvent(new Event('change', {bubbles: true}));
+  if (%t) {
+    if (el.form) {
+      el.form.requestSubmit ? el.form.requestSubmit() : el.form.submit();
+    } else {
+      el.dispatchEvent(new KeyboardEvent('keydown', {key: 'Enter', code: 'Enter', keyCode: 13, bubbles: true}));
+    }
+  }
+  return {found: true};
+})()`
+
+// extractScript never reports the value of password fields.
+const extractScript = `(() => {
+  const selector = %s;
+  const refAttr = %q;
+  const limit = %d;
+  const root = selector ? document.querySelector(selector) : document.body;
+  if (!root) return {found: false};
+  document.querySelectorAll('[' + refAttr + ']').forEach((el) => el.removeAttribute(refAttr));
+  const elements = [];
+  const nodes = root.querySelectorAll('a[href], button, input, select, textarea, [role="button"], [contenteditable="true"]');
+  for (const el of nodes) {
+    if (elements.length >= limit) break;
+    const type = (el.getAttribute('type') || '').toLowerCase();
+    const rect = el.getBoundingClientRect();
+    if (type === 'hidden' || (rect.width === 0 && rect.height === 0)) continue;
+    const ref = String(elements.length + 1);
+    el.setAttribute(refAttr, ref);
+    const label = type === 'password' ? '' : (el.innerText || el.value || '');
+    elements.push({
+      selector: '[' + refAttr + '="' + ref + '"]',
+      tag: el.tagName.toLowerCase(),
+      type: type,
+      name: el.getAttribute('name') || el.getAttribute('aria-label') || el.getAttribute('placeholder') || '',
+      text: String(label).trim().replace(/\s+/g, ' ').slice(0, 100),
+      href: el.href || '',
+    });
+  }
+  return {found: true, url: location.href, title: document.title, text: root.innerText || '', elements: elements};
+})()`
+
+// OptionsFromConfig builds page options from a workspace browser config.
+func OptionsFromConfig(cfg *types.BrowserConfig) Options {
+	return Options{
+		Endpoint: Endpoint{
+			URL:          strings.TrimSpace(cfg.Parameters.EndpointURL),
+			Token:        cfg.Parameters.Token,
+			AllowPrivate: cfg.Parameters.AllowPrivateEndpoint,
+		},
+		AllowedDomains: cfg.Options.AllowedDomains,
+		ViewportWidth:  cfg.Options.ViewportWidth,
+		ViewportHeight: cfg.Options.ViewportHeight,
+		Timeout:        cfg.Options.EffectiveTimeout(),
+	}
+}
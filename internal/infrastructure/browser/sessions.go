@@ -0,0 +1,199 @@
+package browser
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/logger"
+)
+
+// Browser sessions keep a page open across tool calls and turns, so an agent
+// can log in, then page through results, then open a detail page — each step
+// a separate tool call. One session exists per chat session; it is reaped
+// after an idle period, and the number of live sessions is capped because
+// every one of them holds a tab (and its memory) in the remote browser.
+const (
+	defaultSessionIdleTTL = 10 * time.Minute
+	defaultMaxSessions    = 32
+)
+
+// SessionKey scopes a chat session's page to the browser config it was
+// opened with, so agents on different configs never share a page.
+func SessionKey(configID, chatSessionID string) string {
+	return configID + "/" + chatSessionID
+}
+
+type session struct {
+	key         string
+	fingerprint string
+	mu          sync.Mutex
+	page        *Page
+	lastUsed    time.Time
+	closed      bool
+}
+
+// Sessions is the process-wide registry of browser pages, keyed by chat
+// session.
+type Sessions struct {
+	mu          sync.Mutex
+	sessions    map[string]*session
+	idleTTL     time.Duration
+	maxSessions int
+	now         func() time.Time
+	open        func(context.Context, Options) (*Page, error)
+}
+
+// NewSessions creates an empty registry.
+func NewSessions() *Sessions {
+	return &Sessions{
+		sessions:    make(map[string]*session),
+		idleTTL:     defaultSessionIdleTTL,
+		maxSessions: defaultMaxSessions,
+		now:         time.Now,
+		open:        OpenPage,
+	}
+}
+
+// optionsFingerprint identifies the configuration a page was opened with, so
+// an edited browser config replaces the page instead of silently reusing it.
+func optionsFingerprint(opts Options) string {
+	encoded, _ := json.Marshal(opts)
+	sum := sha256.Sum256(encoded)
+	return hex.EncodeToString(sum[:])
+}
+
+// acquire returns the session for key, registering it on first use. Idle
+// sessions are reaped here rather than by a background goroutine so the
+// registry needs no lifecycle of its own.
+func (s *Sessions) acquire(key string, opts Options) *session {
+	now := s.now()
+	fingerprint := optionsFingerprint(opts)
+
+	s.mu.Lock()
+	var expired []*session
+	for k, sess := range s.sessions {
+		if k != key && now.Sub(sess.lastUsed) > s.idleTTL {
+			expired = append(expired, sess)
+			delete(s.sessions, k)
+		}
+	}
+	sess, ok := s.sessions[key]
+	if ok && sess.fingerprint != fingerprint {
+		expired = append(expired, sess)
+		delete(s.sessions, key)
+		ok = false
+	}
+	if !ok {
+		if len(s.sessions) >= s.maxSessions {
+			if oldest := s.leastRecentlyUsedLocked(); oldest != nil {
+				expired = append(expired, oldest)
+				delete(s.sessions, oldest.key)
+			}
+		}
+		sess = &session{key: key, fingerprint: fingerprint}
+		s.sessions[key] = sess
+	}
+	sess.lastUsed = now
+	s.mu.Unlock()
+
+	for _, old := range expired {
+		go closeSession(old)
+	}
+	return sess
+}
+
+func (s *Sessions) leastRecentlyUsedLocked() *session {
+	var oldest *session
+	for _, sess := range s.sessions {
+		if oldest == nil || sess.lastUsed.Before(oldest.lastUsed) {
+			oldest = sess
+		}
+	}
+	return oldest
+}
+
+// closeSession waits for a running action to finish before closing the page.
+func closeSession(sess *session) {
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	sess.closed = true
+	if sess.page != nil {
+		sess.page.Close()
+		sess.page = nil
+	}
+}
+
+// Do runs fn on the key's page, opening it first if needed. Actions on one
+// key are serialised. A page whose browser connection dropped is reopened on
+// the next call, which loses its cookies and history.
+func (s *Sessions) Do(ctx context.Context, key string, opts Options, fn func(context.Context, *Page) error) error {
+	if key == "" {
+		return fmt.Errorf("browser session requires a chat session ID")
+	}
+	opts = opts.withDefaults()
+	sess := s.acquire(key, opts)
+
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	if sess.closed {
+		return fmt.Errorf("browser session %s was closed, please retry", key)
+	}
+	if sess.page != nil && sess.page.Closed() {
+		logger.Warnf(ctx, "[Browser] Connection of session %s dropped, reopening", key)
+		sess.page = nil
+	}
+	if sess.page == nil {
+		page, err := s.open(ctx, opts)
+		if err != nil {
+			return err
+		}
+		sess.page = page
+		logger.Infof(ctx, "[Browser] Opened browser session %s", key)
+	}
+
+	actionCtx, cancel := context.WithTimeout(ctx, 2*opts.Timeout)
+	defer cancel()
+	return fn(actionCtx, sess.page)
+}
+
+// Close closes the key's page ahead of the idle reaping. Unknown keys are a
+// no-op.
+func (s *Sessions) Close(key string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	sess, ok := s.sessions[key]
+	delete(s.sessions, key)
+	s.mu.Unlock()
+	if ok {
+		closeSession(sess)
+	}
+}
+
+// CloseConfig closes every page opened through configID, e.g. after the
+// config was deleted.
+func (s *Sessions) CloseConfig(configID string) {
+	if s == nil {
+		return
+	}
+	prefix := SessionKey(configID, "")
+	var closing []*session
+	s.mu.Lock()
+	for key, sess := range s.sessions {
+		if strings.HasPrefix(key, prefix) {
+			closing = append(closing, sess)
+			delete(s.sessions, key)
+		}
+	}
+	s.mu.Unlock()
+	for _, sess := range closing {
+		go closeSession(sess)
+	}
+}
@@ -0,0 +1,112 @@
+package metrics
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/Tencent/WeKnora/internal/logger"
+)
+
+// AsynqMiddleware records the outcome and duration of every task execution,
+// and tells retried failures apart from the final one that asynq archives.
+func AsynqMiddleware() asynq.MiddlewareFunc {
+	return func(next asynq.Handler) asynq.Handler {
+		return asynq.HandlerFunc(func(ctx context.Context, t *asynq.Task) error {
+			start := time.Now()
+			err := next.ProcessTask(ctx, t)
+
+			taskType := labelOrUnknown(t.Type())
+			taskDuration.WithLabelValues(taskType).Observe(time.Since(start).Seconds())
+			taskProcessed.WithLabelValues(taskType, statusOf(err)).Inc()
+			if err != nil {
+				if isFinalAttempt(ctx) {
+					taskDeadLetters.WithLabelValues(taskType).Inc()
+				} else {
+					taskRetries.WithLabelValues(taskType).Inc()
+				}
+			}
+			return err
+		})
+	}
+}
+
+// isFinalAttempt mirrors the dead-letter middleware: asynq archives the task
+// once retry_count reaches max_retry.
+func isFinalAttempt(ctx context.Context) bool {
+	retried, retriedOK := asynq.GetRetryCount(ctx)
+	maxRetry, maxOK := asynq.GetMaxRetry(ctx)
+	if !retriedOK || !maxOK {
+		return true
+	}
+	return retried >= maxRetry
+}
+
+// QueueInspector is the part of *asynq.Inspector the queue collector needs.
+type QueueInspector interface {
+	Queues() ([]string, error)
+	GetQueueInfo(queue string) (*asynq.QueueInfo, error)
+}
+
+var (
+	queueTasksDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "task", "queue_tasks"),
+		"Tasks currently in an asynq queue by state (pending, active, scheduled, retry, archived).",
+		[]string{"queue", "state"}, nil,
+	)
+	queueLatencyDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "task", "queue_latency_seconds"),
+		"Age of the oldest pending task in an asynq queue.",
+		[]string{"queue"}, nil,
+	)
+)
+
+// queueCollector reads queue depths from Redis at scrape time, so the
+// numbers are shared by every replica rather than per-process counters.
+type queueCollector struct {
+	inspector QueueInspector
+	queues    []string
+}
+
+// RegisterQueueCollector exposes the depth of the given asynq queues. Only
+// call it when asynq runs (Redis mode).
+func RegisterQueueCollector(inspector QueueInspector, queues []string) error {
+	return Registry.Register(&queueCollector{inspector: inspector, queues: queues})
+}
+
+func (q *queueCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- queueTasksDesc
+	ch <- queueLatencyDesc
+}
+
+func (q *queueCollector) Collect(ch chan<- prometheus.Metric) {
+	existing, err := q.inspector.Queues()
+	if err != nil {
+		logger.Warnf(context.Background(), "[Metrics] failed to list asynq queues: %v", err)
+		return
+	}
+	for _, queue := range q.queues {
+		// asynq only creates a queue on first enqueue; report a declared but
+		// never-used queue as empty so dashboards see every queue.
+		info := &asynq.QueueInfo{}
+		if slices.Contains(existing, queue) {
+			if info, err = q.inspector.GetQueueInfo(queue); err != nil {
+				logger.Warnf(context.Background(), "[Metrics] failed to inspect queue %s: %v", queue, err)
+				continue
+			}
+		}
+		for state, n := range map[string]int{
+			"pending":   info.Pending,
+			"active":    info.Active,
+			"scheduled": info.Scheduled,
+			"retry":     info.Retry,
+			"archived":  info.Archived,
+		} {
+			ch <- prometheus.MustNewConstMetric(queueTasksDesc, prometheus.GaugeValue, float64(n), queue, state)
+		}
+		ch <- prometheus.MustNewConstMetric(queueLatencyDesc, prometheus.GaugeValue, info.Latency.Seconds(), queue)
+	}
+}
@@ -0,0 +1,81 @@
+package metrics
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Config controls the /metrics endpoint.
+type Config struct {
+	// Enabled registers GET /metrics. Off by default: the endpoint sits in
+	// front of authentication, so operators opt in explicitly.
+	Enabled bool
+	// Token, when set, must be presented as "Authorization: Bearer <token>"
+	// by the scraper. Leave empty when /metrics is only reachable from the
+	// monitoring network.
+	Token string
+}
+
+// LoadConfigFromEnv reads WEKNORA_METRICS_ENABLED and WEKNORA_METRICS_TOKEN.
+func LoadConfigFromEnv() Config {
+	enabled, _ := strconv.ParseBool(strings.TrimSpace(os.Getenv("WEKNORA_METRICS_ENABLED")))
+	return Config{
+		Enabled: enabled,
+		Token:   strings.TrimSpace(os.Getenv("WEKNORA_METRICS_TOKEN")),
+	}
+}
+
+// Handler serves the registry in the Prometheus exposition format,
+// rejecting scrapes without the configured bearer token.
+func Handler(cfg Config) gin.HandlerFunc {
+	h := promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+	return func(c *gin.Context) {
+		if cfg.Token != "" {
+			got := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+			if subtle.ConstantTimeCompare([]byte(got), []byte(cfg.Token)) != 1 {
+				c.AbortWithStatus(http.StatusUnauthorized)
+				return
+			}
+		}
+		h.ServeHTTP(c.Writer, c.Request)
+	}
+}
+
+// GinMiddleware counts requests and measures their latency per route group.
+func GinMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		group := RouteGroup(c.FullPath())
+		method := c.Request.Method
+		httpRequests.WithLabelValues(group, method, strconv.Itoa(c.Writer.Status())).Inc()
+		httpDuration.WithLabelValues(group, method).Observe(time.Since(start).Seconds())
+	}
+}
+
+// RouteGroup maps a gin route template to its top-level resource, e.g.
+// "/api/v1/knowledge-bases/:id/hybrid-search" -> "knowledge-bases". Only
+// registered templates are used — never the raw URL — so the label set is
+// bounded by the router; requests matching no route become "unmatched".
+func RouteGroup(fullPath string) string {
+	if fullPath == "" {
+		return "unmatched"
+	}
+	p := strings.TrimPrefix(fullPath, "/api/v1")
+	p = strings.TrimPrefix(p, "/")
+	if i := strings.IndexByte(p, '/'); i >= 0 {
+		p = p[:i]
+	}
+	if p == "" || p[0] == ':' || p[0] == '*' {
+		return "root"
+	}
+	return p
+}
@@ -0,0 +1,205 @@
+// Package metrics exposes WeKnora's Prometheus metrics.
+//
+// All collectors live on a dedicated registry (not the global default one),
+// so only what this package declares — plus the Go runtime and process
+// collectors — appears on /metrics. Recording is always on and costs a few
+// atomic adds; exposing the endpoint is opt-in (see LoadConfigFromEnv).
+//
+// Every label here has a bounded value set: route groups, pipeline event
+// types, engine/provider/connector/platform identifiers, task types and
+// queue names. Tenant, user, session, knowledge base and model IDs are never
+// used as labels — per-tenant accounting belongs to the usage ledger, and a
+// multi-tenant deployment would otherwise grow one series per tenant.
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+const namespace = "weknora"
+
+// Label values shared by several metrics.
+const (
+	StatusOK    = "ok"
+	StatusError = "error"
+	// unknownLabel stands in for an empty label value so series stay
+	// readable in dashboards.
+	unknownLabel = "unknown"
+)
+
+// Registry holds every WeKnora collector.
+var Registry = prometheus.NewRegistry()
+
+var (
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "HTTP requests by route group, method and status code.",
+	}, []string{"group", "method", "code"})
+	httpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "HTTP request latency by route group and method. Streaming responses are measured until the stream closes.",
+		Buckets:   []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120},
+	}, []string{"group", "method"})
+
+	pipelineStageDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "chat_pipeline",
+		Name:      "stage_duration_seconds",
+		Help:      "Chat pipeline stage duration by event type and outcome (ok or the plugin error type).",
+		Buckets:   []float64{0.001, 0.005, 0.01, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120},
+	}, []string{"event", "status"})
+
+	retrieverDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "retriever",
+		Name:      "duration_seconds",
+		Help:      "Retrieval latency by engine type, retriever type and outcome.",
+		Buckets:   []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10},
+	}, []string{"engine", "retriever", "status"})
+
+	modelDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "model",
+		Name:      "request_duration_seconds",
+		Help:      "Model call latency by kind (chat, embedding, rerank), provider and outcome. Streams are measured until the last chunk.",
+		Buckets:   []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 20, 30, 60, 120, 300},
+	}, []string{"kind", "provider", "status"})
+	modelTokens = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "model",
+		Name:      "tokens_total",
+		Help:      "Model tokens by kind, provider and token type (prompt, completion, embedding).",
+	}, []string{"kind", "provider", "type"})
+
+	taskProcessed = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "task",
+		Name:      "processed_total",
+		Help:      "Async task executions by task type and outcome.",
+	}, []string{"task_type", "status"})
+	taskDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "task",
+		Name:      "duration_seconds",
+		Help:      "Async task execution time by task type.",
+		Buckets:   []float64{0.01, 0.05, 0.1, 0.5, 1, 5, 10, 30, 60, 120, 300, 600, 1800},
+	}, []string{"task_type"})
+	taskRetries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "task",
+		Name:      "retries_total",
+		Help:      "Failed async task executions that asynq will retry, by task type.",
+	}, []string{"task_type"})
+	taskDeadLetters = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "task",
+		Name:      "dead_letters_total",
+		Help:      "Async tasks that exhausted their retry budget, by task type.",
+	}, []string{"task_type"})
+
+	datasourceSyncs = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "datasource",
+		Name:      "syncs_total",
+		Help:      "Data source sync runs by connector type and final status.",
+	}, []string{"connector", "status"})
+	datasourceSyncDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "datasource",
+		Name:      "sync_duration_seconds",
+		Help:      "Data source sync run duration by connector type.",
+		Buckets:   []float64{1, 5, 10, 30, 60, 120, 300, 600, 1800, 3600, 7200},
+	}, []string{"connector"})
+
+	imMessages = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "im",
+		Name:      "messages_total",
+		Help:      "IM adapter messages by platform, direction (received, sent) and outcome.",
+	}, []string{"platform", "direction", "status"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequests, httpDuration,
+		pipelineStageDuration,
+		retrieverDuration,
+		modelDuration, modelTokens,
+		taskProcessed, taskDuration, taskRetries, taskDeadLetters,
+		datasourceSyncs, datasourceSyncDuration,
+		imMessages,
+	)
+}
+
+func labelOrUnknown(v string) string {
+	if v == "" {
+		return unknownLabel
+	}
+	return v
+}
+
+func statusOf(err error) string {
+	if err != nil {
+		return StatusError
+	}
+	return StatusOK
+}
+
+// ObservePipelineStage records one chat pipeline event. status is StatusOK
+// or the plugin error type.
+func ObservePipelineStage(event, status string, d time.Duration) {
+	pipelineStageDuration.WithLabelValues(labelOrUnknown(event), labelOrUnknown(status)).Observe(d.Seconds())
+}
+
+// ObserveRetrieve records one retrieval against a single engine.
+func ObserveRetrieve(engine, retriever string, d time.Duration, err error) {
+	retrieverDuration.WithLabelValues(labelOrUnknown(engine), labelOrUnknown(retriever), statusOf(err)).
+		Observe(d.Seconds())
+}
+
+// ObserveModelCall records the latency and outcome of one model call. kind
+// is the usage kind (chat, embedding, rerank); provider must come from
+// provider.MetricLabel so it stays within the registered provider names.
+func ObserveModelCall(kind, provider string, d time.Duration, err error) {
+	modelDuration.WithLabelValues(labelOrUnknown(kind), labelOrUnknown(provider), statusOf(err)).
+		Observe(d.Seconds())
+}
+
+// AddModelTokens counts tokens of one model call. Zero counts are skipped.
+func AddModelTokens(kind, provider string, prompt, completion, embedding int64) {
+	add := func(tokenType string, n int64) {
+		if n > 0 {
+			modelTokens.WithLabelValues(labelOrUnknown(kind), labelOrUnknown(provider), tokenType).Add(float64(n))
+		}
+	}
+	add("prompt", prompt)
+	add("completion", completion)
+	add("embedding", embedding)
+}
+
+// ObserveDatasourceSync records the outcome of one data source sync run.
+func ObserveDatasourceSync(connector, status string, d time.Duration) {
+	connector = labelOrUnknown(connector)
+	datasourceSyncs.WithLabelValues(connector, labelOrUnknown(status)).Inc()
+	datasourceSyncDuration.WithLabelValues(connector).Observe(d.Seconds())
+}
+
+// IM message directions.
+const (
+	IMReceived = "received"
+	IMSent     = "sent"
+)
+
+// IncIMMessage counts one IM message handled by a platform adapter.
+func IncIMMessage(platform, direction string, err error) {
+	imMessages.WithLabelValues(labelOrUnknown(platform), direction, statusOf(err)).Inc()
+}
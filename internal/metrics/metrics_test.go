@@ -0,0 +1,159 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hibiken/asynq"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRouteGroup(t *testing.T) {
+	cases := map[string]string{
+		"":        "unmatched",
+		"/health": "health",
+		"/api/v1/knowledge-bases/:id/hybrid-search": "knowledge-bases",
+		"/api/v1/sessions":                          "sessions",
+		"/files/*filepath":                          "files",
+		"/api/v1/:tenant":                           "root",
+		"/":                                         "root",
+	}
+	for in, want := range cases {
+		assert.Equal(t, want, RouteGroup(in), in)
+	}
+}
+
+func TestGinMiddlewareLabelsByRouteTemplate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(GinMiddleware())
+	r.GET("/api/v1/test-group/:id", func(c *gin.Context) { c.Status(http.StatusTeapot) })
+
+	before := value(t, httpRequests.WithLabelValues("test-group", "GET", "418"))
+	for _, id := range []string{"tenant-1", "tenant-2", "tenant-3"} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/test-group/"+id, nil))
+		require.Equal(t, http.StatusTeapot, w.Code)
+	}
+	// Path parameters never leak into labels: three IDs, one series.
+	assert.Equal(t, before+3, value(t, httpRequests.WithLabelValues("test-group", "GET", "418")))
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/no/such/route", nil))
+	assert.GreaterOrEqual(t, value(t, httpRequests.WithLabelValues("unmatched", "GET", "404")), 1.0)
+}
+
+func TestHandlerRequiresToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/metrics", Handler(Config{Enabled: true, Token: "s3cret"}))
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	req.Header.Set("Authorization", "Bearer s3cret")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "go_goroutines")
+}
+
+func TestLoadConfigFromEnv(t *testing.T) {
+	t.Setenv("WEKNORA_METRICS_ENABLED", "")
+	t.Setenv("WEKNORA_METRICS_TOKEN", "")
+	assert.Equal(t, Config{}, LoadConfigFromEnv())
+
+	t.Setenv("WEKNORA_METRICS_ENABLED", "true")
+	t.Setenv("WEKNORA_METRICS_TOKEN", " tok ")
+	assert.Equal(t, Config{Enabled: true, Token: "tok"}, LoadConfigFromEnv())
+}
+
+func TestAsynqMiddlewareCountsOutcomes(t *testing.T) {
+	handle := func(err error) error {
+		h := AsynqMiddleware()(asynq.HandlerFunc(func(context.Context, *asynq.Task) error { return err }))
+		return h.ProcessTask(context.Background(), asynq.NewTask("test:metrics", nil))
+	}
+	okBefore := value(t, taskProcessed.WithLabelValues("test:metrics", StatusOK))
+	dlBefore := value(t, taskDeadLetters.WithLabelValues("test:metrics"))
+
+	require.NoError(t, handle(nil))
+	boom := errors.New("boom")
+	require.ErrorIs(t, handle(boom), boom)
+
+	assert.Equal(t, okBefore+1, value(t, taskProcessed.WithLabelValues("test:metrics", StatusOK)))
+	// Outside a worker the retry counters are unknown, so a failure counts
+	// as final, matching the dead-letter middleware.
+	assert.Equal(t, dlBefore+1, value(t, taskDeadLetters.WithLabelValues("test:metrics")))
+}
+
+type fakeInspector struct {
+	queues []string
+	infos  map[string]*asynq.QueueInfo
+}
+
+func (f *fakeInspector) Queues() ([]string, error) { return f.queues, nil }
+
+func (f *fakeInspector) GetQueueInfo(queue string) (*asynq.QueueInfo, error) {
+	return f.infos[queue], nil
+}
+
+func TestQueueCollector(t *testing.T) {
+	c := &queueCollector{
+		inspector: &fakeInspector{
+			queues: []string{"core"},
+			infos:  map[string]*asynq.QueueInfo{"core": {Pending: 4, Retry: 2, Latency: 3 * time.Second}},
+		},
+		queues: []string{"core", "wiki"},
+	}
+	ch := make(chan prometheus.Metric, 32)
+	c.Collect(ch)
+	close(ch)
+	depth := map[string]float64{}
+	latency := map[string]float64{}
+	for m := range ch {
+		var pb dto.Metric
+		require.NoError(t, m.Write(&pb))
+		labels := map[string]string{}
+		for _, l := range pb.GetLabel() {
+			labels[l.GetName()] = l.GetValue()
+		}
+		if m.Desc() == queueLatencyDesc {
+			latency[labels["queue"]] = pb.GetGauge().GetValue()
+			continue
+		}
+		depth[labels["queue"]+"/"+labels["state"]] = pb.GetGauge().GetValue()
+	}
+	assert.Equal(t, map[string]float64{"core": 3, "wiki": 0}, latency)
+	assert.Equal(t, 4.0, depth["core/pending"])
+	assert.Equal(t, 2.0, depth["core/retry"])
+	// Declared queues that were never created still report zero depth.
+	assert.Len(t, depth, 2*5)
+	assert.Zero(t, depth["wiki/pending"])
+}
+
+func TestAddModelTokens(t *testing.T) {
+	AddModelTokens("chat", "test-provider", 7, 5, 0)
+	assert.Equal(t, 7.0, value(t, modelTokens.WithLabelValues("chat", "test-provider", "prompt")))
+	assert.Equal(t, 5.0, value(t, modelTokens.WithLabelValues("chat", "test-provider", "completion")))
+}
+
+// value reads the current value of a counter or gauge.
+func value(t *testing.T, c prometheus.Metric) float64 {
+	t.Helper()
+	var pb dto.Metric
+	require.NoError(t, c.Write(&pb))
+	if pb.GetCounter() != nil {
+		return pb.GetCounter().GetValue()
+	}
+	return pb.GetGauge().GetValue()
+}
@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/gin-gonic/gin"
+)
+
+// IsDataRead reports whether (method, fullPath) reads tenant knowledge: its
+// declared policy admits the retrieve capability. That covers KB, document,
+// chunk, FAQ, wiki, graph and search routes, whichever HTTP method they use.
+func (a *APIKeyRouteAuthorizer) IsDataRead(method, fullPath string) bool {
+	policy, ok := a.Lookup(method, fullPath)
+	if !ok {
+		return false
+	}
+	for _, c := range policy.Capabilities {
+		if c == types.APIKeyCapabilityRetrieve {
+			return true
+		}
+	}
+	return false
+}
+
+// AuditAPIKeyReads returns a middleware that records successful data reads
+// made with an API key. It runs after the handler, so denied or failed
+// requests are not recorded here (the gate and rbac guards already audit
+// denials). The row is written after the response body, off the client's
+// critical path, and a write failure only logs: audit must never fail a
+// read that already succeeded. JWT sessions pass straight through.
+func (a *APIKeyRouteAuthorizer) AuditAPIKeyReads() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		ctx := c.Request.Context()
+		scope, ok := types.TenantAPIKeyScopeFromContext(ctx)
+		if !ok || c.Writer.Status() >= http.StatusBadRequest {
+			return
+		}
+		if !a.IsDataRead(c.Request.Method, c.FullPath()) {
+			return
+		}
+		svc := AuditServiceFromContext(c)
+		if svc == nil {
+			return
+		}
+		tenantID, _ := ctx.Value(types.TenantIDContextKey).(uint64)
+		if err := svc.LogAPIKeyRead(context.WithoutCancel(ctx), c, tenantID, scope.KeyID); err != nil {
+			logger.Warnf(ctx, "[audit] failed to record API key read: %v", err)
+		}
+	}
+}
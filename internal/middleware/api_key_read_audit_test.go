@@ -0,0 +1,87 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"github.com/gin-gonic/gin"
+)
+
+type readCall struct {
+	tenantID, keyID uint64
+	path            string
+}
+
+// stubReadAudit captures LogAPIKeyRead calls; any other method panics.
+type stubReadAudit struct {
+	interfaces.AuditLogService
+	calls []readCall
+}
+
+func (s *stubReadAudit) LogAPIKeyRead(_ context.Context, c *gin.Context, tenantID, keyID uint64) error {
+	s.calls = append(s.calls, readCall{tenantID, keyID, c.Request.URL.Path})
+	return nil
+}
+
+func runReadAudit(
+	t *testing.T, scope *types.TenantAPIKeyScope, method, route, path string, status int,
+) []readCall {
+	t.Helper()
+	a := NewAPIKeyRouteAuthorizer()
+	a.Register(http.MethodGet, "/api/v1/knowledge/:id",
+		APIKeyRoutePolicy{RequireFullAccess: true}.WithCapability(types.APIKeyCapabilityRetrieve))
+	a.Register(http.MethodPost, "/api/v1/knowledge-search",
+		APIKeyRoutePolicy{RequireFullAccess: true}.WithCapability(types.APIKeyCapabilityRetrieve))
+	a.Register(http.MethodGet, "/api/v1/models",
+		APIKeyRoutePolicy{RequireFullAccess: true}.WithCapability(types.APIKeyCapabilityManageModels))
+
+	audit := &stubReadAudit{}
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(AuditServiceProvider(audit))
+	engine.Use(func(c *gin.Context) {
+		ctx := context.WithValue(c.Request.Context(), types.TenantIDContextKey, uint64(7))
+		if scope != nil {
+			ctx = types.WithTenantAPIKeyScope(ctx, *scope)
+		}
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	})
+	engine.Use(a.AuditAPIKeyReads())
+	engine.Handle(method, route, func(c *gin.Context) { c.Status(status) })
+	engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(method, path, nil))
+	return audit.calls
+}
+
+func TestAuditAPIKeyReads(t *testing.T) {
+	key := &types.TenantAPIKeyScope{KeyID: 42, FullAccess: true}
+
+	calls := runReadAudit(t, key, http.MethodGet, "/api/v1/knowledge/:id", "/api/v1/knowledge/doc-1", http.StatusOK)
+	if len(calls) != 1 || calls[0] != (readCall{7, 42, "/api/v1/knowledge/doc-1"}) {
+		t.Fatalf("document read by API key must be audited, got %+v", calls)
+	}
+	if calls := runReadAudit(t, key, http.MethodPost, "/api/v1/knowledge-search",
+		"/api/v1/knowledge-search", http.StatusOK); len(calls) != 1 {
+		t.Fatalf("search is a data read whatever its method, got %+v", calls)
+	}
+
+	for name, tc := range map[string]struct {
+		scope  *types.TenantAPIKeyScope
+		route  string
+		path   string
+		status int
+	}{
+		"jwt session":      {nil, "/api/v1/knowledge/:id", "/api/v1/knowledge/doc-1", http.StatusOK},
+		"failed read":      {key, "/api/v1/knowledge/:id", "/api/v1/knowledge/doc-1", http.StatusNotFound},
+		"non-data route":   {key, "/api/v1/models", "/api/v1/models", http.StatusOK},
+		"undeclared route": {key, "/api/v1/other", "/api/v1/other", http.StatusOK},
+	} {
+		if calls := runReadAudit(t, tc.scope, http.MethodGet, tc.route, tc.path, tc.status); len(calls) != 0 {
+			t.Errorf("%s: expected no audit, got %+v", name, calls)
+		}
+	}
+}
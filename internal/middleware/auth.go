@@ -51,6 +51,8 @@ var noAuthAPI = map[string][]string{
 	"/api/v1/auth/oidc/url":           {"GET"},
 	"/api/v1/auth/oidc/start":         {"GET"},
 	"/api/v1/auth/oidc/callback":      {"GET"},
+	"/api/v1/auth/ldap/config":        {"GET"},
+	"/api/v1/auth/ldap/login":         {"POST"},
 	// MCP OAuth provider redirect: the third-party authorization server
 	// redirects the browser here without a WeKnora bearer token. The request
 	// is authenticated by the opaque, single-use `state` parameter instead.
@@ -128,6 +130,7 @@ func Auth(
 	userService interfaces.UserService,
 	memberService interfaces.TenantMemberService,
 	apiKeyService interfaces.TenantAPIKeyService,
+	roleService interfaces.TenantCustomRoleService,
 	cfg *config.Config,
 ) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -143,13 +146,28 @@ func Auth(
 			return
 		}
 
+		// SCIM 客户端（IdP）只支持 Authorization: Bearer，无法发送
+		// X-API-Key。在 SCIM 路径上把 sk- 前缀的 Bearer token 当作空间
+		// API Key 认证，其余路径仍只认 X-API-Key。
+		if token, ok := bearerToken(c); ok && isSCIMPath(c.Request.URL.Path) && strings.HasPrefix(token, "sk-") {
+			if apiKeyService == nil {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized: API key service is not configured"})
+				c.Abort()
+				return
+			}
+			if authenticateAPIKeyRequest(c, tenantService, userService, apiKeyService, roleService, token) {
+				c.Next()
+			}
+			return
+		}
+
 		// 尝试JWT Token认证
 		bearerPresented := false
 		if token, ok := bearerToken(c); ok {
 			bearerPresented = true
 			user, jwtTenantID, err := userService.ValidateToken(c.Request.Context(), token)
 			if err == nil && user != nil {
-				if authenticateJWTUser(c, tenantService, memberService, cfg, user, jwtTenantID) {
+				if authenticateJWTUser(c, tenantService, memberService, roleService, cfg, user, jwtTenantID) {
 					c.Next()
 				}
 				return
@@ -164,7 +182,7 @@ func Auth(
 				c.Abort()
 				return
 			}
-			if authenticateAPIKeyRequest(c, tenantService, userService, apiKeyService, apiKey) {
+			if authenticateAPIKeyRequest(c, tenantService, userService, apiKeyService, roleService, apiKey) {
 				c.Next()
 			}
 			return
@@ -180,6 +198,11 @@ func Auth(
 	}
 }
 
+// isSCIMPath reports whether path belongs to the SCIM 2.0 provisioning API.
+func isSCIMPath(path string) bool {
+	return path == "/api/v1/scim/v2" || strings.HasPrefix(path, "/api/v1/scim/v2/")
+}
+
 // bearerToken extracts the Bearer token from the Authorization header.
 func bearerToken(c *gin.Context) (string, bool) {
 	authHeader := c.GetHeader("Authorization")
@@ -198,6 +221,7 @@ func authenticateJWTUser(
 	c *gin.Context,
 	tenantService interfaces.TenantService,
 	memberService interfaces.TenantMemberService,
+	roleService interfaces.TenantCustomRoleService,
 	cfg *config.Config,
 	user *types.User,
 	jwtTenantID uint64,
@@ -240,7 +264,7 @@ func authenticateJWTUser(
 	}
 
 	// 解析当前空间内的角色 (issue #1303)
-	role, ok := resolveTenantRole(ctx, memberService, user, targetTenantID, crossTenantSwitch, cfg)
+	role, member, ok := resolveTenantMembership(ctx, memberService, user, targetTenantID, crossTenantSwitch, cfg)
 	if !ok {
 		// 强制 RBAC 时，缺少 active membership 即拒绝；fail-open 路径已在
 		// resolveTenantRole 内部处理。
@@ -255,17 +279,45 @@ func authenticateJWTUser(
 	logger.Infof(ctx,
 		"[auth] resolved role=%s for user=%s in tenant=%d (jwt_tenant=%d, header=%q, cross_switch=%v)",
 		role, user.ID, targetTenantID, jwtTenantID, c.GetHeader("X-Tenant-ID"), crossTenantSwitch)
-	applyAuthSession(c, authSession{
+	session := authSession{
 		User:        user,
 		Principal:   types.Principal{Type: types.PrincipalWebUser, ID: user.ID},
 		TenantID:    targetTenantID,
 		Tenant:      tenant,
 		Role:        role,
 		SystemAdmin: user.IsSystemAdmin,
-	})
+	}
+	if member != nil && member.CustomRoleID != nil {
+		grant := resolveCustomRoleGrant(ctx, roleService, targetTenantID, *member.CustomRoleID)
+		session.Extra = map[types.ContextKey]any{types.CustomRoleGrantContextKey: grant}
+	}
+	applyAuthSession(c, session)
 	return true
 }
 
+// resolveCustomRoleGrant loads the custom role a member or API key holds.
+// A failed lookup yields an empty grant, which the custom role gate treats
+// as "no permissions": the holder keeps only uncataloged routes rather than
+// silently regaining the full base role.
+func resolveCustomRoleGrant(
+	ctx context.Context,
+	roleService interfaces.TenantCustomRoleService,
+	tenantID, roleID uint64,
+) types.CustomRoleGrant {
+	grant := types.CustomRoleGrant{RoleID: roleID}
+	if roleService == nil {
+		logger.Warnf(ctx, "[auth] custom role service unavailable: tenant=%d role=%d", tenantID, roleID)
+		return grant
+	}
+	role, err := roleService.GetRole(ctx, tenantID, roleID)
+	if err != nil || role == nil {
+		logger.Warnf(ctx, "[auth] custom role lookup failed: tenant=%d role=%d err=%v", tenantID, roleID, err)
+		return grant
+	}
+	grant.Name, grant.Permissions = role.Name, role.Permissions
+	return grant
+}
+
 // resolveTargetTenant decides which tenant this request operates in.
 //
 // Priority:
@@ -373,6 +425,7 @@ func authenticateAPIKeyRequest(
 	tenantService interfaces.TenantService,
 	userService interfaces.UserService,
 	apiKeyService interfaces.TenantAPIKeyService,
+	roleService interfaces.TenantCustomRoleService,
 	apiKey string,
 ) bool {
 	ctx := c.Request.Context()
@@ -413,6 +466,15 @@ func authenticateAPIKeyRequest(
 			c.Abort()
 			return false
 		}
+		if key.CustomRoleID != nil {
+			// A role-bound key carries exactly the role's permissions. Work
+			// on a copy so the authenticated row is never mutated.
+			grant := resolveCustomRoleGrant(ctx, roleService, tenantID, *key.CustomRoleID)
+			bound := *key
+			bound.FullAccess = false
+			bound.Capabilities = grant.Permissions
+			key = &bound
+		}
 		if tenantHeader := strings.TrimSpace(c.GetHeader("X-Tenant-ID")); tenantHeader != "" {
 			requestedTenantID, parseErr := strconv.ParseUint(tenantHeader, 10, 64)
 			if parseErr != nil || requestedTenantID == 0 {
@@ -755,13 +817,28 @@ func resolveTenantRole(
 	crossTenantSwitch bool,
 	cfg *config.Config,
 ) (types.TenantRole, bool) {
+	role, _, ok := resolveTenantMembership(ctx, memberService, user, targetTenantID, crossTenantSwitch, cfg)
+	return role, ok
+}
+
+// resolveTenantMembership is resolveTenantRole that also returns the active
+// membership row when step 1 matched, so the caller can pick up the member's
+// custom role. The row is nil for every other step.
+func resolveTenantMembership(
+	ctx context.Context,
+	memberService interfaces.TenantMemberService,
+	user *types.User,
+	targetTenantID uint64,
+	crossTenantSwitch bool,
+	cfg *config.Config,
+) (types.TenantRole, *types.TenantMember, bool) {
 	// 1. 正常成员关系
 	member, err := memberService.GetMembership(ctx, user.ID, targetTenantID)
 	if err == nil && member != nil && member.Status == types.TenantMemberStatusActive {
 		logger.Infof(ctx,
-			"[auth] resolveTenantRole step1 hit: user=%s tenant=%d row_role=%s row_status=%s",
-			user.ID, targetTenantID, member.Role, member.Status)
-		return member.Role, true
+			"[auth] resolveTenantRole step1 hit: user=%s tenant=%d row_role=%s row_status=%s custom_role=%v",
+			user.ID, targetTenantID, member.Role, member.Status, member.CustomRoleID != nil)
+		return member.Role, member, true
 	}
 	if err != nil {
 		logger.Warnf(ctx, "tenant_members lookup failed user=%s tenant=%d: %v",
@@ -787,7 +864,7 @@ func resolveTenantRole(
 		logger.Infof(ctx,
 			"[auth] resolveTenantRole step2 (cross-tenant superuser) -> Admin: user=%s tenant=%d",
 			user.ID, targetTenantID)
-		return types.TenantRoleAdmin, true
+		return types.TenantRoleAdmin, nil, true
 	}
 
 	// 3. 孤儿空间自愈：仅当用户登录的是自己的 home tenant、且该空间尚无任何活跃成员时
@@ -804,7 +881,7 @@ func resolveTenantRole(
 					"[audit] Auto-promoted user %s to Owner of orphan tenant %d (home_tenant=true)",
 					user.ID, targetTenantID,
 				)
-				return types.TenantRoleOwner, true
+				return types.TenantRoleOwner, nil, true
 			} else {
 				logger.Warnf(ctx, "Failed to auto-promote user %s in tenant %d: %v",
 					user.ID, targetTenantID, e)
@@ -817,11 +894,11 @@ func resolveTenantRole(
 		logger.Warnf(ctx,
 			"[auth] resolveTenantRole step4 fail-closed (EnableRBAC=true): user=%s tenant=%d",
 			user.ID, targetTenantID)
-		return "", false
+		return "", nil, false
 	}
 	logger.Warnf(ctx,
 		"[auth] resolveTenantRole step4 fail-open (EnableRBAC=false) -> Admin: user=%s tenant=%d",
 		user.ID, targetTenantID)
 	// fail-open 期间保持现有行为（每个登录用户在自己空间里都是"管理员"）。
-	return types.TenantRoleAdmin, true
+	return types.TenantRoleAdmin, nil, true
 }
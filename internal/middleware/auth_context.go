@@ -68,6 +68,9 @@ func applyAuthSession(c *gin.Context, s authSession) {
 	ctx = types.WithPrincipal(ctx, s.Principal)
 	if s.Role != "" {
 		set(types.TenantRoleContextKey, s.Role)
+		if s.TenantID != 0 {
+			set(types.TenantRoleTenantIDContextKey, s.TenantID)
+		}
 	}
 	set(types.SystemAdminContextKey, s.SystemAdmin)
 	if s.APIKeyScope != nil {
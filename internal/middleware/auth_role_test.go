@@ -135,6 +135,11 @@ func (f *fakeMemberService) UpdateRole(
 ) error {
 	return nil
 }
+func (f *fakeMemberService) AssignCustomRole(
+	ctx context.Context, userID string, tenantID uint64, role *types.TenantCustomRole,
+) error {
+	return nil
+}
 func (f *fakeMemberService) RemoveMember(ctx context.Context, userID string, tenantID uint64) error {
 	return nil
 }
@@ -6,6 +6,7 @@ import (
 	"github.com/gin-gonic/gin"
 
 	"github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/types"
 )
 
 // ErrorHandler 是一个处理应用错误的中间件
@@ -21,6 +22,10 @@ func ErrorHandler() gin.HandlerFunc {
 
 			// 检查是否为应用错误
 			if appErr, ok := errors.IsAppError(err); ok {
+				// 服务层拒绝的限流检查同样带上限流响应头
+				if d, ok := appErr.Details.(*types.RateLimitExceededDetails); ok {
+					setRateLimitHeaders(c, d.Decision)
+				}
 				// 返回应用错误
 				c.JSON(appErr.HTTPCode, gin.H{
 					"success": false,
@@ -130,6 +130,19 @@ func KBIDFromParam(param string) KBIDResolver {
 // can return 503 instead of pretending the resource doesn't exist
 // (a 404 here would also short-circuit any retry / monitoring).
 func KBIDFromKnowledgeIDParam(param string, kgService KnowledgeLookup) KBIDResolver {
+	return KBIDFromReadableKnowledgeIDParam(param, kgService, nil)
+}
+
+// KnowledgeReadChecker evaluates the document-level ACL of a knowledge item
+// for the caller in ctx.
+type KnowledgeReadChecker interface {
+	CanReadKnowledge(ctx context.Context, knowledge *types.Knowledge) (bool, error)
+}
+
+// KBIDFromReadableKnowledgeIDParam is KBIDFromKnowledgeIDParam plus the
+// document-level ACL: a document the caller may not read is reported as not
+// found, so its existence does not leak. A nil acl skips the check.
+func KBIDFromReadableKnowledgeIDParam(param string, kgService KnowledgeLookup, acl KnowledgeReadChecker) KBIDResolver {
 	return func(c *gin.Context) (string, error) {
 		v := c.Param(param)
 		if v == "" {
@@ -145,10 +158,29 @@ func KBIDFromKnowledgeIDParam(param string, kgService KnowledgeLookup) KBIDResol
 		if k == nil {
 			return "", apperrors.NewNotFoundError("Knowledge not found")
 		}
+		if err := checkKnowledgeReadable(c, acl, k, "Knowledge not found"); err != nil {
+			return "", err
+		}
 		return k.KnowledgeBaseID, nil
 	}
 }
 
+// checkKnowledgeReadable runs the ACL check on the caller's own request
+// context, before the guard rewrites it to the KB owner's tenant.
+func checkKnowledgeReadable(c *gin.Context, acl KnowledgeReadChecker, k *types.Knowledge, notFound string) error {
+	if acl == nil {
+		return nil
+	}
+	readable, err := acl.CanReadKnowledge(c.Request.Context(), k)
+	if err != nil {
+		return err
+	}
+	if !readable {
+		return apperrors.NewNotFoundError(notFound)
+	}
+	return nil
+}
+
 // KBIDFromChunkIDParam walks chunk_id -> knowledge_id -> kb_id.
 // Used by /chunks/by-id/:id routes that address a chunk directly. The
 // chunk's KnowledgeBaseID is denormalised on the row, so a single
@@ -156,6 +188,12 @@ func KBIDFromKnowledgeIDParam(param string, kgService KnowledgeLookup) KBIDResol
 //
 // Not-found / transient split mirrors KBIDFromKnowledgeIDParam.
 func KBIDFromChunkIDParam(param string, chunkService ChunkLookup) KBIDResolver {
+	return KBIDFromReadableChunkIDParam(param, chunkService, nil)
+}
+
+// KBIDFromReadableChunkIDParam is KBIDFromChunkIDParam plus the
+// document-level ACL of the chunk's knowledge item.
+func KBIDFromReadableChunkIDParam(param string, chunkService ChunkLookup, acl KnowledgeReadChecker) KBIDResolver {
 	return func(c *gin.Context) (string, error) {
 		v := c.Param(param)
 		if v == "" {
@@ -180,6 +218,10 @@ func KBIDFromChunkIDParam(param string, chunkService ChunkLookup) KBIDResolver {
 				"[kb_access] chunk %s has empty knowledge_base_id; treating as not-found", v)
 			return "", apperrors.NewNotFoundError("Chunk not found")
 		}
+		owner := &types.Knowledge{ID: ch.KnowledgeID, KnowledgeBaseID: ch.KnowledgeBaseID}
+		if err := checkKnowledgeReadable(c, acl, owner, "Chunk not found"); err != nil {
+			return "", err
+		}
 		return ch.KnowledgeBaseID, nil
 	}
 }
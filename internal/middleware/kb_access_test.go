@@ -554,3 +554,60 @@ func TestRequireKBAccess_InvalidAgentSourceTenantID(t *testing.T) {
 	require.True(t, c.IsAborted())
 	require.NotEmpty(t, c.Errors)
 }
+
+type stubKnowledgeLookup struct {
+	knowledge map[string]*types.Knowledge
+}
+
+func (s *stubKnowledgeLookup) GetKnowledgeByIDOnly(_ context.Context, id string) (*types.Knowledge, error) {
+	if k, ok := s.knowledge[id]; ok {
+		return k, nil
+	}
+	return nil, apprepo.ErrKnowledgeNotFound
+}
+
+type stubKnowledgeReadChecker struct {
+	denied map[string]bool
+	err    error
+}
+
+func (s *stubKnowledgeReadChecker) CanReadKnowledge(_ context.Context, k *types.Knowledge) (bool, error) {
+	if s.err != nil {
+		return false, s.err
+	}
+	return !s.denied[k.ID], nil
+}
+
+// TestKBIDFromReadableKnowledgeIDParam pins that a document hidden by its ACL
+// resolves exactly like a missing one, so the 404 does not reveal it exists.
+func TestKBIDFromReadableKnowledgeIDParam(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	lookup := &stubKnowledgeLookup{knowledge: map[string]*types.Knowledge{
+		"k-open":   {ID: "k-open", KnowledgeBaseID: "kb-1"},
+		"k-secret": {ID: "k-secret", KnowledgeBaseID: "kb-1"},
+	}}
+	acl := &stubKnowledgeReadChecker{denied: map[string]bool{"k-secret": true}}
+
+	resolve := func(id string, checker KnowledgeReadChecker) (string, error) {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest("GET", "/knowledge/"+id, nil)
+		c.Params = gin.Params{{Key: "id", Value: id}}
+		return KBIDFromReadableKnowledgeIDParam("id", lookup, checker)(c)
+	}
+
+	kbID, err := resolve("k-open", acl)
+	require.NoError(t, err)
+	require.Equal(t, "kb-1", kbID)
+
+	_, hiddenErr := resolve("k-secret", acl)
+	_, missingErr := resolve("k-missing", acl)
+	require.Error(t, hiddenErr)
+	require.Equal(t, missingErr.Error(), hiddenErr.Error())
+
+	kbID, err = resolve("k-secret", nil)
+	require.NoError(t, err, "a nil checker skips the ACL")
+	require.Equal(t, "kb-1", kbID)
+
+	_, err = resolve("k-open", &stubKnowledgeReadChecker{err: errors.New("db down")})
+	require.Error(t, err, "an ACL lookup failure must not grant access")
+}
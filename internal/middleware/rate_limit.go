@@ -0,0 +1,102 @@
+package middleware
+
+import (
+	"math"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+// Rate limit response headers. The X-RateLimit-* headers describe the
+// tightest requests-per-minute policy of the caller on every response; on
+// a 429 they describe the quota that rejected the request instead. Reset
+// is a Unix timestamp in seconds; Retry-After (429 only) is in seconds.
+const (
+	RateLimitLimitHeader     = "X-RateLimit-Limit"
+	RateLimitRemainingHeader = "X-RateLimit-Remaining"
+	RateLimitResetHeader     = "X-RateLimit-Reset"
+	RetryAfterHeader         = "Retry-After"
+)
+
+// RateLimitHeaders lists the headers browsers must be allowed to read.
+var RateLimitHeaders = []string{RateLimitLimitHeader, RateLimitRemainingHeader, RateLimitResetHeader, RetryAfterHeader}
+
+func setRateLimitHeaders(c *gin.Context, d types.RateLimitDecision) {
+	if d.Limit <= 0 {
+		return
+	}
+	c.Header(RateLimitLimitHeader, strconv.FormatInt(d.Limit, 10))
+	c.Header(RateLimitRemainingHeader, strconv.FormatInt(d.Remaining, 10))
+	c.Header(RateLimitResetHeader, strconv.FormatInt(int64(math.Ceil(float64(d.Reset.UnixMilli())/1000)), 10))
+	if !d.Allowed {
+		retryAfter := int64(math.Ceil(d.RetryAfter.Seconds()))
+		if retryAfter < 1 {
+			retryAfter = 1
+		}
+		c.Header(RetryAfterHeader, strconv.FormatInt(retryAfter, 10))
+	}
+}
+
+// rejectRateLimited answers a denied check with 429 and its headers.
+func rejectRateLimited(c *gin.Context, d types.RateLimitDecision, err error) {
+	setRateLimitHeaders(c, d)
+	c.Error(err)
+	c.Abort()
+}
+
+// RateLimit counts every authenticated request against the
+// requests-per-minute policies of the workspace and the caller. Requests
+// without a workspace (login, system administration) are not limited.
+func RateLimit(limits interfaces.RateLimitService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if limits == nil {
+			c.Next()
+			return
+		}
+		d, err := limits.CheckRequest(c.Request.Context())
+		if err != nil {
+			rejectRateLimited(c, d, err)
+			return
+		}
+		setRateLimitHeaders(c, d)
+		c.Next()
+	}
+}
+
+// TokenRateLimit rejects a chat or search before it starts once the
+// tokens-per-minute policy of the workspace or the caller is used up.
+func TokenRateLimit(limits interfaces.RateLimitService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if limits == nil {
+			c.Next()
+			return
+		}
+		if d, err := limits.CheckTokens(c.Request.Context()); err != nil {
+			rejectRateLimited(c, d, err)
+			return
+		}
+		c.Next()
+	}
+}
+
+// StreamRateLimit holds a concurrent-streams slot for as long as the chat
+// handler streams its answer. The handlers block until the stream ends,
+// so the slot is released when the chain returns.
+func StreamRateLimit(limits interfaces.RateLimitService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if limits == nil {
+			c.Next()
+			return
+		}
+		release, d, err := limits.AcquireStream(c.Request.Context())
+		if err != nil {
+			rejectRateLimited(c, d, err)
+			return
+		}
+		defer release()
+		c.Next()
+	}
+}
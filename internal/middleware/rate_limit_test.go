@@ -0,0 +1,91 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	apperrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+// fakeRateLimits answers request checks from a fixed decision.
+type fakeRateLimits struct {
+	interfaces.RateLimitService
+	decision types.RateLimitDecision
+}
+
+func (f *fakeRateLimits) CheckRequest(context.Context) (types.RateLimitDecision, error) {
+	if !f.decision.Allowed {
+		return f.decision, apperrors.NewTooManyRequestsError("rate limit exceeded")
+	}
+	return f.decision, nil
+}
+
+func TestRateLimitSetsHeaders(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	reset := time.Now().Add(30 * time.Second)
+	limits := &fakeRateLimits{decision: types.RateLimitDecision{Allowed: true, Limit: 60, Remaining: 59, Reset: reset}}
+	r := gin.New()
+	r.Use(ErrorHandler(), RateLimit(limits))
+	r.GET("/x", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/x", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d", w.Code)
+	}
+	if w.Header().Get(RateLimitLimitHeader) != "60" || w.Header().Get(RateLimitRemainingHeader) != "59" {
+		t.Fatalf("headers = %v", w.Header())
+	}
+	if w.Header().Get(RetryAfterHeader) != "" {
+		t.Fatal("Retry-After must only be sent on 429")
+	}
+
+	limits.decision = types.RateLimitDecision{Limit: 60, Reset: reset, RetryAfter: 1500 * time.Millisecond}
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/x", nil))
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want 429", w.Code)
+	}
+	if got := w.Header().Get(RetryAfterHeader); got != "2" {
+		t.Fatalf("Retry-After = %q, want rounded-up seconds", got)
+	}
+	if got := w.Header().Get(RateLimitResetHeader); got != strconv.FormatInt(reset.Unix()+1, 10) &&
+		got != strconv.FormatInt(reset.Unix(), 10) {
+		t.Fatalf("X-RateLimit-Reset = %q, want unix seconds of %v", got, reset)
+	}
+}
+
+func TestErrorHandlerSetsHeadersOfServiceRateLimitErrors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	reset := time.Now().Add(time.Hour)
+	r := gin.New()
+	r.Use(ErrorHandler())
+	r.POST("/upload", func(c *gin.Context) {
+		// What the knowledge service returns once the upload quota is used up.
+		c.Error(apperrors.NewTooManyRequestsError("rate limit exceeded").WithDetails(&types.RateLimitExceededDetails{
+			ScopeType: types.RateLimitScopeTenant, Limit: 1 << 20, RetryAfterSeconds: 3600,
+			Decision: types.RateLimitDecision{Limit: 1 << 20, Remaining: 10, Reset: reset, RetryAfter: time.Hour},
+		}))
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/upload", nil))
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want 429", w.Code)
+	}
+	if w.Header().Get(RetryAfterHeader) != "3600" || w.Header().Get(RateLimitRemainingHeader) != "10" {
+		t.Fatalf("headers = %v", w.Header())
+	}
+	if !strings.Contains(w.Body.String(), `"retry_after_seconds":3600`) {
+		t.Fatalf("body = %s, want the details serialized", w.Body.String())
+	}
+}
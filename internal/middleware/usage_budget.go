@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+// UsageWarningHeader carries the soft budget warning on generation and
+// retrieval responses once a budget of the caller passed its threshold.
+const UsageWarningHeader = "X-WeKnora-Usage-Warning"
+
+// UsageBudget rejects requests from a tenant or API key whose hard-stop
+// monthly token budget is used up, before a session or stream is set up,
+// and surfaces the soft warning in UsageWarningHeader. The model
+// decorators enforce the same budgets on every call; this guard only makes
+// the common entry points fail fast with a clean 429.
+func UsageBudget(usage interfaces.UsageService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if usage == nil {
+			c.Next()
+			return
+		}
+		warning, err := usage.EvaluateBudgets(c.Request.Context())
+		if err != nil {
+			c.Error(err)
+			c.Abort()
+			return
+		}
+		if warning != "" {
+			c.Header(UsageWarningHeader, warning)
+		}
+		c.Next()
+	}
+}
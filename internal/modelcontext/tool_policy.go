@@ -84,6 +84,10 @@ var toolHandlePolicies = map[string]toolHandlePolicy{
 		sourceIDKeys: map[string]struct{}{"knowledge_base_ids": {}},
 		sourceOutput: true,
 	},
+	"graph_global_search": {
+		sourceIDKeys: map[string]struct{}{"knowledge_base_ids": {}},
+		sourceOutput: true,
+	},
 	toolDatabaseQuery: {
 		sourceTextKeys: map[string]struct{}{"sql": {}},
 		sourceOutput:   true,
@@ -95,6 +99,22 @@ var toolHandlePolicies = map[string]toolHandlePolicy{
 	"data_schema": {
 		sourceIDKeys: map[string]struct{}{"knowledge_id": {}},
 	},
+	"data_chart": {
+		sourceIDKeys:   map[string]struct{}{"knowledge_id": {}},
+		sourceTextKeys: map[string]struct{}{"sql": {}},
+	},
+	// External SQL connections hold tenant business data, not WeKnora chunks
+	// or documents; their connection IDs are listed in the tool description
+	// and must reach the model verbatim.
+	"sql_schema": {},
+	"sql_query":  {},
+	// Browser pages are arbitrary public websites, not WeKnora sources; the
+	// URLs and selectors they return are what the next call needs verbatim.
+	"browser_navigate":   {},
+	"browser_click":      {},
+	"browser_fill":       {},
+	"browser_extract":    {},
+	"browser_screenshot": {},
 	"web_fetch": {
 		sourceIDKeys: map[string]struct{}{"url": {}, "urls": {}},
 		sourceOutput: true,
@@ -152,9 +152,11 @@ func NewChat(config *ChatConfig, ollamaService *ollama.OllamaService) (Chat, err
 	}
 	c, err = wrapChatDebug(c, err)
 	c, err = wrapChatLangfuse(c, err)
-	// Outermost: hold the per-model concurrency slot only around the real
-	// provider round-trip, so the wait is excluded from debug/langfuse timing.
-	return wrapChatConcurrency(c, config.MaxConcurrency, err)
+	// Hold the per-model concurrency slot only around the real provider
+	// round-trip, so the wait is excluded from debug/langfuse timing.
+	c, err = wrapChatConcurrency(c, config.MaxConcurrency, err)
+	// Outermost: budget checks reject before any slot is taken.
+	return wrapChatMetering(c, provider.MetricLabel(config.Source, config.Provider, config.BaseURL), err)
 }
 
 // NewRemoteChat 根据 provider 创建远程聊天实例。
@@ -0,0 +1,99 @@
+package chat
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/metrics"
+	"github.com/Tencent/WeKnora/internal/models/metering"
+	"github.com/Tencent/WeKnora/internal/tracing/otlp"
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// meteringChat checks the caller's token budget before every call and files
+// the provider-reported usage in the usage ledger afterwards. It sits outside
+// the concurrency governor so a call rejected by a hard-stop budget never
+// waits for a slot. It also feeds the Prometheus model metrics, labelled by
+// provider only, and opens the OTLP span for the call.
+type meteringChat struct {
+	inner    Chat
+	provider string
+}
+
+func (m *meteringChat) GetModelName() string { return m.inner.GetModelName() }
+func (m *meteringChat) GetModelID() string   { return m.inner.GetModelID() }
+
+func (m *meteringChat) Chat(ctx context.Context, messages []Message, opts *ChatOptions) (*types.ChatResponse, error) {
+	if err := metering.CheckBudget(ctx); err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	ctx, span := otlp.StartModelCall(ctx, "chat", m.provider, m.inner.GetModelName())
+	resp, err := m.inner.Chat(ctx, messages, opts)
+	metrics.ObserveModelCall(string(types.UsageKindChat), m.provider, time.Since(start), err)
+	var ev types.UsageEvent
+	if resp != nil {
+		ev = types.UsageEventFromTokenUsage(m.inner.GetModelID(), m.inner.GetModelName(), &resp.Usage)
+		metering.Record(ctx, ev)
+		metrics.AddModelTokens(string(types.UsageKindChat), m.provider, ev.PromptTokens, ev.CompletionTokens, 0)
+	}
+	otlp.EndModelCall(span, ev.PromptTokens, ev.CompletionTokens, err)
+	return resp, err
+}
+
+func (m *meteringChat) ChatStream(ctx context.Context, messages []Message, opts *ChatOptions) (<-chan types.StreamResponse, error) {
+	if err := metering.CheckBudget(ctx); err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	ctx, span := otlp.StartModelCall(ctx, "chat", m.provider, m.inner.GetModelName())
+	ch, err := m.inner.ChatStream(ctx, messages, opts)
+	if err != nil || ch == nil {
+		metrics.ObserveModelCall(string(types.UsageKindChat), m.provider, time.Since(start), err)
+		otlp.EndModelCall(span, 0, 0, err)
+		return ch, err
+	}
+	// Providers report usage on the final chunk; keep the last one seen and
+	// record once the stream ends, including when the consumer walks away.
+	out := make(chan types.StreamResponse)
+	go func() {
+		defer close(out)
+		var usage *types.TokenUsage
+		var streamErr error
+		defer func() {
+			ev := types.UsageEventFromTokenUsage(m.inner.GetModelID(), m.inner.GetModelName(), usage)
+			metering.Record(ctx, ev)
+			metrics.ObserveModelCall(string(types.UsageKindChat), m.provider, time.Since(start), streamErr)
+			metrics.AddModelTokens(string(types.UsageKindChat), m.provider, ev.PromptTokens, ev.CompletionTokens, 0)
+			otlp.EndModelCall(span, ev.PromptTokens, ev.CompletionTokens, streamErr)
+		}()
+		for resp := range ch {
+			if resp.Usage != nil {
+				usage = resp.Usage
+			}
+			if resp.ResponseType == types.ResponseTypeError {
+				streamErr = errors.New(resp.Content)
+			}
+			select {
+			case out <- resp:
+			case <-ctx.Done():
+				go func() {
+					for range ch {
+					}
+				}()
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+// wrapChatMetering installs the metering decorator. It is always applied;
+// without an installed meter both hooks are no-ops.
+func wrapChatMetering(c Chat, provider string, err error) (Chat, error) {
+	if err != nil || c == nil {
+		return c, err
+	}
+	return &meteringChat{inner: c, provider: provider}, nil
+}
@@ -0,0 +1,99 @@
+package chat
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/models/metering"
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+type recordingMeter struct {
+	mu        sync.Mutex
+	events    []types.UsageEvent
+	budgetErr error
+}
+
+func (m *recordingMeter) Record(_ context.Context, ev types.UsageEvent) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.events = append(m.events, ev)
+}
+
+func (m *recordingMeter) CheckBudget(context.Context) error { return m.budgetErr }
+
+func (m *recordingMeter) recorded() []types.UsageEvent {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]types.UsageEvent(nil), m.events...)
+}
+
+// usageStreamChat streams two chunks and reports usage on the last one.
+type usageStreamChat struct{ fakeChat }
+
+func (f *usageStreamChat) Chat(context.Context, []Message, *ChatOptions) (*types.ChatResponse, error) {
+	return &types.ChatResponse{Usage: types.TokenUsage{PromptTokens: 7, CompletionTokens: 3, CachedTokens: 2}}, nil
+}
+
+func (f *usageStreamChat) ChatStream(context.Context, []Message, *ChatOptions) (<-chan types.StreamResponse, error) {
+	ch := make(chan types.StreamResponse, 2)
+	ch <- types.StreamResponse{Content: "hi"}
+	ch <- types.StreamResponse{Done: true, Usage: &types.TokenUsage{PromptTokens: 11, CompletionTokens: 5}}
+	close(ch)
+	return ch, nil
+}
+
+func TestMeteringChatRecordsUsage(t *testing.T) {
+	m := &recordingMeter{}
+	metering.SetMeter(m)
+	t.Cleanup(func() { metering.SetMeter(nil) })
+
+	w := &meteringChat{inner: &usageStreamChat{fakeChat{id: "model-m"}}}
+	if _, err := w.Chat(context.Background(), nil, nil); err != nil {
+		t.Fatalf("chat: %v", err)
+	}
+	out, err := w.ChatStream(context.Background(), nil, nil)
+	if err != nil {
+		t.Fatalf("stream: %v", err)
+	}
+	n := 0
+	for range out {
+		n++
+	}
+	if n != 2 {
+		t.Fatalf("expected 2 forwarded chunks, got %d", n)
+	}
+
+	// The relay records before it closes the channel.
+	events := m.recorded()
+	if len(events) != 2 {
+		t.Fatalf("expected 2 usage events, got %d", len(events))
+	}
+	if ev := events[0]; ev.Kind != types.UsageKindChat || ev.ModelID != "model-m" ||
+		ev.PromptTokens != 7 || ev.CompletionTokens != 3 || ev.CachedTokens != 2 || ev.Calls != 1 {
+		t.Fatalf("unexpected chat event: %+v", ev)
+	}
+	if ev := events[1]; ev.PromptTokens != 11 || ev.CompletionTokens != 5 || ev.Calls != 1 {
+		t.Fatalf("stream usage should come from the final chunk: %+v", ev)
+	}
+}
+
+func TestMeteringChatBudgetStopsCall(t *testing.T) {
+	budgetErr := errors.New("budget exceeded")
+	m := &recordingMeter{budgetErr: budgetErr}
+	metering.SetMeter(m)
+	t.Cleanup(func() { metering.SetMeter(nil) })
+
+	w := &meteringChat{inner: &usageStreamChat{fakeChat{id: "model-m"}}}
+	if _, err := w.Chat(context.Background(), nil, nil); !errors.Is(err, budgetErr) {
+		t.Fatalf("chat should fail with the budget error, got %v", err)
+	}
+	if _, err := w.ChatStream(context.Background(), nil, nil); !errors.Is(err, budgetErr) {
+		t.Fatalf("stream should fail with the budget error, got %v", err)
+	}
+	if len(m.recorded()) != 0 {
+		t.Fatal("rejected calls must not be recorded")
+	}
+}
@@ -104,7 +104,10 @@ func NewEmbedder(config Config, pooler EmbedderPooler, ollamaService *ollama.Oll
 	if langfuse.GetManager().Enabled() {
 		e = &langfuseEmbedder{inner: e}
 	}
-	return e, nil
+	return &meteringEmbedder{
+		inner:    e,
+		provider: provider.MetricLabel(config.Source, config.Provider, config.BaseURL),
+	}, nil
 }
 
 func newEmbedder(config Config, pooler EmbedderPooler, ollamaService *ollama.OllamaService) (Embedder, error) {
@@ -0,0 +1,82 @@
+package embedding
+
+import (
+	"context"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/metrics"
+	"github.com/Tencent/WeKnora/internal/models/metering"
+	"github.com/Tencent/WeKnora/internal/tracing/otlp"
+	"github.com/Tencent/WeKnora/internal/types"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// meteringEmbedder checks the caller's token budget and files approximate
+// embedding tokens in the usage ledger. It is the outermost decorator, so a
+// pooled batch is recorded once here: the pooler's per-sub-batch callbacks
+// land on the concurrency wrapper below and never come back through it.
+type meteringEmbedder struct {
+	inner    Embedder
+	provider string
+}
+
+func (m *meteringEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	if err := metering.CheckBudget(ctx); err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	ctx, span := otlp.StartModelCall(ctx, "embeddings", m.provider, m.inner.GetModelName())
+	result, err := m.inner.Embed(ctx, text)
+	m.record(ctx, span, []string{text}, start, err)
+	return result, err
+}
+
+func (m *meteringEmbedder) BatchEmbed(ctx context.Context, texts []string) ([][]float32, error) {
+	if err := metering.CheckBudget(ctx); err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	ctx, span := otlp.StartModelCall(ctx, "embeddings", m.provider, m.inner.GetModelName())
+	result, err := m.inner.BatchEmbed(ctx, texts)
+	m.record(ctx, span, texts, start, err)
+	return result, err
+}
+
+func (m *meteringEmbedder) BatchEmbedWithPool(ctx context.Context, model Embedder, texts []string) ([][]float32, error) {
+	if err := metering.CheckBudget(ctx); err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	ctx, span := otlp.StartModelCall(ctx, "embeddings", m.provider, m.inner.GetModelName())
+	result, err := m.inner.BatchEmbedWithPool(ctx, model, texts)
+	m.record(ctx, span, texts, start, err)
+	return result, err
+}
+
+// record files the call unless it failed outright; a failed provider call
+// is not billed by the vendors we integrate with. It also ends the call's
+// OTLP span.
+func (m *meteringEmbedder) record(ctx context.Context, span trace.Span, texts []string, start time.Time, err error) {
+	metrics.ObserveModelCall(string(types.UsageKindEmbedding), m.provider, time.Since(start), err)
+	if err != nil {
+		otlp.EndModelCall(span, 0, 0, err)
+		return
+	}
+	var tokens int64
+	if usage := approxEmbeddingUsage(texts); usage != nil {
+		tokens = int64(usage.Input)
+	}
+	metering.Record(ctx, types.UsageEvent{
+		Kind:            types.UsageKindEmbedding,
+		ModelID:         m.inner.GetModelID(),
+		ModelName:       m.inner.GetModelName(),
+		EmbeddingTokens: tokens,
+		Calls:           1,
+	})
+	metrics.AddModelTokens(string(types.UsageKindEmbedding), m.provider, 0, 0, tokens)
+	otlp.EndModelCall(span, tokens, 0, nil)
+}
+
+func (m *meteringEmbedder) GetModelName() string { return m.inner.GetModelName() }
+func (m *meteringEmbedder) GetDimensions() int   { return m.inner.GetDimensions() }
+func (m *meteringEmbedder) GetModelID() string   { return m.inner.GetModelID() }
@@ -0,0 +1,80 @@
+// Package metering connects the model clients to the usage ledger.
+//
+// The chat, embedding and rerank decorators report every call here, and
+// chat and embedding ask for a budget check before calling out. The meter
+// itself lives in the service layer, which the models packages cannot
+// import, so it is installed once at startup through SetMeter (see
+// container.registerUsageMeter). Until then every call is a cheap no-op.
+package metering
+
+import (
+	"context"
+	"sync"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// Meter records usage and enforces budgets. Record must not block on
+// storage; CheckBudget returns a non-nil error only when a hard-stop budget
+// of the calling tenant or API key is used up.
+type Meter interface {
+	Record(ctx context.Context, ev types.UsageEvent)
+	CheckBudget(ctx context.Context) error
+}
+
+var (
+	meterMu sync.RWMutex
+	meter   Meter
+)
+
+// SetMeter installs the process-wide meter. Passing nil disables metering.
+func SetMeter(m Meter) {
+	meterMu.Lock()
+	defer meterMu.Unlock()
+	meter = m
+}
+
+func current() Meter {
+	meterMu.RLock()
+	defer meterMu.RUnlock()
+	return meter
+}
+
+// Record reports one call to the installed meter.
+func Record(ctx context.Context, ev types.UsageEvent) {
+	if m := current(); m != nil {
+		m.Record(ctx, ev)
+	}
+}
+
+// CheckBudget asks the installed meter whether the caller may spend tokens.
+func CheckBudget(ctx context.Context) error {
+	if m := current(); m != nil {
+		return m.CheckBudget(ctx)
+	}
+	return nil
+}
+
+// Recorder observes metered calls without taking part in budget checks,
+// such as the tokens-per-minute rate limits.
+type Recorder interface {
+	Record(ctx context.Context, ev types.UsageEvent)
+}
+
+// WithRecorders returns a meter that reports every call to m and then to
+// each recorder; budget checks are answered by m alone.
+func WithRecorders(m Meter, recorders ...Recorder) Meter {
+	return &teeMeter{Meter: m, recorders: recorders}
+}
+
+type teeMeter struct {
+	Meter
+	recorders []Recorder
+}
+
+func (t *teeMeter) Record(ctx context.Context, ev types.UsageEvent) {
+	t.Meter.Record(ctx, ev)
+	for _, r := range t.recorders {
+		r.Record(ctx, ev)
+	}
+}
@@ -276,6 +276,18 @@ func DetectProvider(baseURL string) ProviderName {
 	}
 }
 
+// MetricLabel 返回用于监控指标的服务商标签。本地模型记为 ollama；
+// 已注册的服务商沿用其名称；未知名称按 BaseURL 推断，保证标签取值有限。
+func MetricLabel(source types.ModelSource, name, baseURL string) string {
+	if strings.EqualFold(string(source), string(types.ModelSourceLocal)) {
+		return "ollama"
+	}
+	if _, ok := Get(ProviderName(strings.ToLower(strings.TrimSpace(name)))); ok {
+		return strings.ToLower(strings.TrimSpace(name))
+	}
+	return string(DetectProvider(baseURL))
+}
+
 func containsAny(s string, substrs ...string) bool {
 	for _, sub := range substrs {
 		if strings.Contains(s, sub) {
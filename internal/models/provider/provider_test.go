@@ -62,6 +62,14 @@ func TestDetectProvider(t *testing.T) {
 	}
 }
 
+func TestMetricLabel(t *testing.T) {
+	assert.Equal(t, "ollama", MetricLabel(types.ModelSourceLocal, "openai", ""))
+	assert.Equal(t, "openai", MetricLabel(types.ModelSourceRemote, "OpenAI", "https://gateway.example.com/v1"))
+	assert.Equal(t, "deepseek", MetricLabel(types.ModelSourceRemote, "", "https://api.deepseek.com/v1"))
+	// Free-form provider names never become label values.
+	assert.Equal(t, "generic", MetricLabel(types.ModelSourceRemote, "tenant-7-proxy", "https://custom.example.com"))
+}
+
 func TestAnthropicProviderValidation(t *testing.T) {
 	p := &AnthropicProvider{}
 
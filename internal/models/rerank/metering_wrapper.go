@@ -0,0 +1,50 @@
+package rerank
+
+import (
+	"context"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/metrics"
+	"github.com/Tencent/WeKnora/internal/models/metering"
+	"github.com/Tencent/WeKnora/internal/tracing/otlp"
+	"github.com/Tencent/WeKnora/internal/types"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// meteringReranker files one rerank call per successful request in the
+// usage ledger. Rerankers are billed per call rather than per token, so they
+// are not subject to token budgets.
+type meteringReranker struct {
+	inner    Reranker
+	provider string
+}
+
+func (m *meteringReranker) GetModelName() string { return m.inner.GetModelName() }
+func (m *meteringReranker) GetModelID() string   { return m.inner.GetModelID() }
+
+func (m *meteringReranker) Rerank(ctx context.Context, query string, documents []string) ([]RankResult, error) {
+	start := time.Now()
+	ctx, span := otlp.StartModelCall(ctx, "rerank", m.provider, m.inner.GetModelName())
+	span.SetAttributes(attribute.Int("weknora.rerank.documents", len(documents)))
+	results, err := m.inner.Rerank(ctx, query, documents)
+	otlp.End(span, err)
+	metrics.ObserveModelCall(string(types.UsageKindRerank), m.provider, time.Since(start), err)
+	if err == nil {
+		metering.Record(ctx, types.UsageEvent{
+			Kind:      types.UsageKindRerank,
+			ModelID:   m.inner.GetModelID(),
+			ModelName: m.inner.GetModelName(),
+			Calls:     1,
+		})
+	}
+	return results, err
+}
+
+// wrapRerankerMetering installs the metering decorator. It is always applied;
+// without an installed meter recording is a no-op.
+func wrapRerankerMetering(r Reranker, provider string, err error) (Reranker, error) {
+	if err != nil || r == nil {
+		return r, err
+	}
+	return &meteringReranker{inner: r, provider: provider}, nil
+}
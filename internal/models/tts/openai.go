@@ -0,0 +1,123 @@
+package tts
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/logger"
+	secutils "github.com/Tencent/WeKnora/internal/utils"
+	openai "github.com/sashabaranov/go-openai"
+)
+
+const (
+	ttsDefaultTimeout = 120 * time.Second
+	// maxInputRunes stays under the 4096-character input limit of the
+	// OpenAI speech API; longer text is cut at a sentence boundary.
+	maxInputRunes = 4000
+	// maxAudioBytes bounds the synthesized audio read into memory.
+	maxAudioBytes = 20 << 20
+)
+
+// OpenAITTS implements TTS via an OpenAI-compatible audio speech API.
+type OpenAITTS struct {
+	modelName string
+	modelID   string
+	voice     string
+	baseURL   string
+	client    *openai.Client
+}
+
+// NewOpenAITTS creates an OpenAI-compatible TTS instance.
+func NewOpenAITTS(config *Config) (*OpenAITTS, error) {
+	if config.BaseURL != "" {
+		if err := secutils.ValidateURLForSSRF(config.BaseURL); err != nil {
+			return nil, fmt.Errorf("base URL SSRF check failed: %w", err)
+		}
+	}
+
+	apiCfg := openai.DefaultConfig(config.APIKey)
+	if config.BaseURL != "" {
+		apiCfg.BaseURL = config.BaseURL
+	}
+	httpCfg := secutils.DefaultSSRFSafeHTTPClientConfig()
+	httpCfg.Timeout = ttsDefaultTimeout
+	httpClient := secutils.NewSSRFSafeHTTPClient(httpCfg)
+	if len(config.CustomHeaders) > 0 {
+		apiCfg.HTTPClient = secutils.WrapHTTPClientWithHeaders(httpClient, config.CustomHeaders)
+	} else {
+		apiCfg.HTTPClient = httpClient
+	}
+
+	voice := config.Voice
+	if voice == "" {
+		voice = DefaultVoice
+	}
+	return &OpenAITTS{
+		modelName: config.ModelName,
+		modelID:   config.ModelID,
+		voice:     voice,
+		baseURL:   config.BaseURL,
+		client:    openai.NewClientWithConfig(apiCfg),
+	}, nil
+}
+
+// Synthesize calls the OpenAI-compatible audio speech API.
+func (s *OpenAITTS) Synthesize(ctx context.Context, text string, format Format) ([]byte, error) {
+	text = TruncateInput(strings.TrimSpace(text), maxInputRunes)
+	if text == "" {
+		return nil, fmt.Errorf("text is empty")
+	}
+	if format == "" {
+		format = FormatMP3
+	}
+
+	logger.Infof(ctx, "[TTS] Calling OpenAI-compatible speech API, model=%s, baseURL=%s, voice=%s, chars=%d",
+		s.modelName, s.baseURL, s.voice, len([]rune(text)))
+
+	resp, err := s.client.CreateSpeech(ctx, openai.CreateSpeechRequest{
+		Model:          openai.SpeechModel(s.modelName),
+		Input:          text,
+		Voice:          openai.SpeechVoice(s.voice),
+		ResponseFormat: openai.SpeechResponseFormat(format),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("TTS speech request failed: %w", err)
+	}
+	defer resp.Close()
+
+	audio, err := io.ReadAll(io.LimitReader(resp, maxAudioBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("read speech response: %w", err)
+	}
+	if len(audio) > maxAudioBytes {
+		return nil, fmt.Errorf("speech response exceeds %d MiB", maxAudioBytes>>20)
+	}
+	if len(audio) == 0 {
+		return nil, fmt.Errorf("speech response is empty")
+	}
+	logger.Infof(ctx, "[TTS] Synthesis completed, audio size=%d", len(audio))
+	return audio, nil
+}
+
+func (s *OpenAITTS) GetModelName() string { return s.modelName }
+func (s *OpenAITTS) GetModelID() string   { return s.modelID }
+
+// TruncateInput cuts text to at most maxRunes, preferring the last sentence
+// end so the spoken reply does not stop mid-sentence.
+func TruncateInput(text string, maxRunes int) string {
+	runes := []rune(text)
+	if len(runes) <= maxRunes {
+		return text
+	}
+	cut := runes[:maxRunes]
+	for i := len(cut) - 1; i > maxRunes/2; i-- {
+		switch cut[i] {
+		case '。', '！', '？', '.', '!', '?', '\n':
+			return string(cut[:i+1])
+		}
+	}
+	return string(cut)
+}
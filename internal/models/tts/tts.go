@@ -0,0 +1,67 @@
+package tts
+
+import (
+	"context"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// DefaultVoice is used when the model's extra_config does not set "voice".
+const DefaultVoice = "alloy"
+
+// Format is the container/codec of synthesized audio.
+type Format string
+
+const (
+	// FormatOpus is Ogg-encapsulated Opus, the format IM voice notes use.
+	FormatOpus Format = "opus"
+	FormatMP3  Format = "mp3"
+)
+
+// TTS defines the interface for Text-To-Speech model operations.
+type TTS interface {
+	// Synthesize converts text to speech and returns the encoded audio.
+	Synthesize(ctx context.Context, text string, format Format) ([]byte, error)
+
+	GetModelName() string
+	GetModelID() string
+}
+
+// Config holds the configuration needed to create a TTS instance.
+type Config struct {
+	Source    types.ModelSource
+	BaseURL   string
+	ModelName string
+	APIKey    string
+	ModelID   string
+	Voice     string
+	// CustomHeaders 允许在调用远程 API 时附加自定义 HTTP 请求头（类似 OpenAI Python SDK 的 extra_headers）。
+	CustomHeaders map[string]string
+}
+
+// ConfigFromModel 根据 types.Model 构造 tts.Config。
+// 音色取自 extra_config.voice，未配置时使用 DefaultVoice。
+func ConfigFromModel(m *types.Model) *Config {
+	if m == nil {
+		return nil
+	}
+	voice := m.Parameters.ExtraConfig["voice"]
+	if voice == "" {
+		voice = DefaultVoice
+	}
+	return &Config{
+		ModelID:       m.ID,
+		APIKey:        m.Parameters.APIKey,
+		BaseURL:       m.Parameters.BaseURL,
+		ModelName:     m.Name,
+		Source:        m.Source,
+		Voice:         voice,
+		CustomHeaders: m.Parameters.CustomHeaders,
+	}
+}
+
+// NewTTS creates a TTS instance based on the provided configuration.
+// All TTS vendors use the OpenAI-compatible /v1/audio/speech API.
+func NewTTS(config *Config) (TTS, error) {
+	return NewOpenAITTS(config)
+}
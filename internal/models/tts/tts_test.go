@@ -0,0 +1,86 @@
+package tts
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	secutils "github.com/Tencent/WeKnora/internal/utils"
+)
+
+func TestConfigFromModel(t *testing.T) {
+	m := &types.Model{
+		ID:   "tts-1",
+		Name: "gpt-4o-mini-tts",
+		Parameters: types.ModelParameters{
+			BaseURL:     "https://api.example.com/v1",
+			APIKey:      "sk",
+			ExtraConfig: map[string]string{"voice": "nova"},
+		},
+	}
+	cfg := ConfigFromModel(m)
+	if cfg == nil || cfg.ModelID != "tts-1" || cfg.ModelName != "gpt-4o-mini-tts" || cfg.Voice != "nova" {
+		t.Fatalf("config mismatch: %+v", cfg)
+	}
+	m.Parameters.ExtraConfig = nil
+	if cfg := ConfigFromModel(m); cfg.Voice != DefaultVoice {
+		t.Errorf("voice = %q, want default %q", cfg.Voice, DefaultVoice)
+	}
+	if ConfigFromModel(nil) != nil {
+		t.Error("nil model must give nil config")
+	}
+}
+
+func TestSynthesize(t *testing.T) {
+	t.Setenv("SSRF_WHITELIST", "127.0.0.1")
+	secutils.ResetSSRFWhitelistForTest()
+	t.Cleanup(secutils.ResetSSRFWhitelistForTest)
+
+	var got map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/audio/speech" {
+			http.NotFound(w, r)
+			return
+		}
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		w.Header().Set("Content-Type", "audio/ogg")
+		_, _ = w.Write([]byte("OggS-audio"))
+	}))
+	defer srv.Close()
+
+	s, err := NewTTS(&Config{BaseURL: srv.URL + "/v1", ModelName: "tts-1", Voice: "echo"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	audio, err := s.Synthesize(context.Background(), "  你好  ", FormatOpus)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(audio) != "OggS-audio" {
+		t.Errorf("audio = %q", audio)
+	}
+	if got["input"] != "你好" || got["voice"] != "echo" || got["response_format"] != "opus" || got["model"] != "tts-1" {
+		t.Errorf("request = %v", got)
+	}
+	if _, err := s.Synthesize(context.Background(), " ", FormatOpus); err == nil {
+		t.Error("empty text must be rejected")
+	}
+}
+
+func TestTruncateInput(t *testing.T) {
+	text := strings.Repeat("句子。", 10)
+	if got := TruncateInput(text, 100); got != text {
+		t.Errorf("short text changed: %q", got)
+	}
+	got := TruncateInput(text, 10)
+	if got != strings.Repeat("句子。", 3) {
+		t.Errorf("TruncateInput = %q, want cut at the last sentence end", got)
+	}
+	if got := TruncateInput(strings.Repeat("a", 20), 10); got != strings.Repeat("a", 10) {
+		t.Errorf("hard cut = %q", got)
+	}
+}
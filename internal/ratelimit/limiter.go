@@ -15,7 +15,8 @@ import (
 const localCleanupInterval = time.Minute
 
 // rateLimitScript atomically prunes expired ZSET members, checks the count,
-// and conditionally records a new hit.
+// and conditionally records a new hit. It returns {allowed, hits in window,
+// oldest hit (unix ms)} so callers can report when capacity frees up.
 var rateLimitScript = redis.NewScript(`
 local key     = KEYS[1]
 local now     = tonumber(ARGV[1])
@@ -25,12 +26,19 @@ local member  = ARGV[4]
 
 redis.call('ZREMRANGEBYSCORE', key, 0, now - window)
 local count = redis.call('ZCARD', key)
+local allowed = 0
 if count < maxReq then
     redis.call('ZADD', key, now, member)
     redis.call('PEXPIRE', key, window + 1000)
-    return 1
+    count = count + 1
+    allowed = 1
 end
-return 0
+local oldest = redis.call('ZRANGE', key, 0, 0, 'WITHSCORES')
+local first = now
+if oldest[2] then
+    first = tonumber(oldest[2])
+end
+return {allowed, count, first}
 `)
 
 // Limiter enforces per-key sliding-window limits. max is evaluated per Allow
@@ -64,32 +72,63 @@ func New(redisClient *redis.Client, keyPrefix string, window time.Duration, inst
 
 // Allow reports whether key is within budget for the current window.
 func (l *Limiter) Allow(ctx context.Context, key string, max int) bool {
+	return l.Take(ctx, key, max).Allowed
+}
+
+// Take records a hit for key when it is within budget and reports the
+// resulting window state. max <= 0 is unlimited and returns a zero-Limit
+// allowed decision without recording anything.
+func (l *Limiter) Take(ctx context.Context, key string, max int) Decision {
 	if max <= 0 {
-		return true
+		return Decision{Allowed: true}
 	}
+	now := time.Now()
 	if l.redis != nil {
-		allowed, err := l.redisAllow(ctx, key, max)
+		allowed, count, oldest, err := l.redisTake(ctx, key, max, now)
 		if err == nil {
-			return allowed
+			return l.decision(allowed, count, oldest, max, now)
 		}
 	}
-	return l.local.allow(key, l.window, max)
+	allowed, count, oldest := l.local.take(key, l.window, max, now)
+	return l.decision(allowed, count, oldest, max, now)
+}
+
+// decision turns a window state into a Decision. Capacity frees up when the
+// oldest hit in the window ages out.
+func (l *Limiter) decision(allowed bool, count int, oldest time.Time, max int, now time.Time) Decision {
+	reset := oldest.Add(l.window)
+	if reset.Before(now) {
+		reset = now
+	}
+	d := Decision{Allowed: allowed, Limit: int64(max), Remaining: int64(max - count), Reset: reset}
+	if d.Remaining < 0 {
+		d.Remaining = 0
+	}
+	if !allowed {
+		d.RetryAfter = reset.Sub(now)
+	}
+	return d
 }
 
-func (l *Limiter) redisAllow(ctx context.Context, key string, max int) (bool, error) {
+func (l *Limiter) redisTake(ctx context.Context, key string, max int, now time.Time) (bool, int, time.Time, error) {
 	redisKey := l.keyPrefix + key
-	nowMs := time.Now().UnixMilli()
+	nowMs := now.UnixMilli()
 	windowMs := l.window.Milliseconds()
-	member := fmt.Sprintf("%s:%d", l.instanceID, nowMs)
+	// The random suffix keeps two hits of one instance in the same
+	// millisecond from collapsing into one ZSET member.
+	member := fmt.Sprintf("%s:%d:%s", l.instanceID, nowMs, uuid.NewString()[:8])
 
 	result, err := rateLimitScript.Run(ctx, l.redis,
 		[]string{redisKey},
 		nowMs, windowMs, max, member,
-	).Int64()
-	if err != nil {
-		return false, err
+	).Int64Slice()
+	if err != nil || len(result) != 3 {
+		if err == nil {
+			err = fmt.Errorf("unexpected rate limit script result %v", result)
+		}
+		return false, 0, time.Time{}, err
 	}
-	return result == 1, nil
+	return result[0] == 1, int(result[1]), time.UnixMilli(result[2]), nil
 }
 
 // StartCleanup runs periodic eviction for the local fallback map. No-op when
@@ -112,8 +151,7 @@ func newLocalLimiter() *localLimiter {
 	return &localLimiter{}
 }
 
-func (l *localLimiter) allow(key string, window time.Duration, max int) bool {
-	now := time.Now()
+func (l *localLimiter) take(key string, window time.Duration, max int, now time.Time) (bool, int, time.Time) {
 	cutoff := now.Add(-window)
 
 	for {
@@ -135,13 +173,16 @@ func (l *localLimiter) allow(key string, window time.Duration, max int) bool {
 		}
 		entry.timestamps = valid
 
-		if len(entry.timestamps) >= max {
-			entry.mu.Unlock()
-			return false
+		allowed := len(entry.timestamps) < max
+		if allowed {
+			entry.timestamps = append(entry.timestamps, now)
+		}
+		count, oldest := len(entry.timestamps), now
+		if count > 0 {
+			oldest = entry.timestamps[0]
 		}
-		entry.timestamps = append(entry.timestamps, now)
 		entry.mu.Unlock()
-		return true
+		return allowed, count, oldest
 	}
 }
 
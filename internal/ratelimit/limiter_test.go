@@ -39,3 +39,20 @@ func TestAllowSkipsWhenMaxZero(t *testing.T) {
 		}
 	}
 }
+
+func TestTakeReportsRemainingAndRetryAfter(t *testing.T) {
+	l := New(nil, "test:", time.Minute, "inst")
+	ctx := context.Background()
+	d := l.Take(ctx, "k", 2)
+	if !d.Allowed || d.Limit != 2 || d.Remaining != 1 {
+		t.Fatalf("first take = %+v", d)
+	}
+	l.Take(ctx, "k", 2)
+	d = l.Take(ctx, "k", 2)
+	if d.Allowed || d.Remaining != 0 {
+		t.Fatalf("over-budget take = %+v", d)
+	}
+	if d.RetryAfter <= 0 || d.RetryAfter > time.Minute {
+		t.Fatalf("retry after %v should be within the window", d.RetryAfter)
+	}
+}
@@ -0,0 +1,331 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// Decision is the outcome of one limit check. Limit 0 means the check was
+// unlimited. RetryAfter is only set when the check was denied.
+type Decision struct {
+	Allowed    bool
+	Limit      int64
+	Remaining  int64
+	Reset      time.Time
+	RetryAfter time.Duration
+}
+
+// counterReserveScript adds ARGV[1] to a fixed-window counter unless that
+// would take it past ARGV[2]. Returns {admitted, value after the call}.
+var counterReserveScript = redis.NewScript(`
+local cur = tonumber(redis.call('GET', KEYS[1]) or '0')
+local n   = tonumber(ARGV[1])
+if cur + n > tonumber(ARGV[2]) then
+    return {0, cur}
+end
+cur = redis.call('INCRBY', KEYS[1], n)
+redis.call('PEXPIRE', KEYS[1], ARGV[3])
+return {1, cur}
+`)
+
+// counterReleaseScript takes ARGV[1] back from a window counter that still
+// exists. An expired window is left alone rather than recreated negative
+// without a TTL.
+var counterReleaseScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 1 then
+    return redis.call('DECRBY', KEYS[1], ARGV[1])
+end
+return 0
+`)
+
+// Counter is a fixed-window counter of arbitrary amounts (bytes, tokens)
+// aligned to multiples of the window in UTC, so a 24h counter resets at
+// midnight UTC and a minute counter at the top of each minute. Like
+// Limiter it uses Redis when available and falls back to process-local
+// counting on Redis errors.
+type Counter struct {
+	redis     *redis.Client
+	keyPrefix string
+	window    time.Duration
+	now       func() time.Time
+
+	mu    sync.Mutex
+	local map[string]int64 // key + window start -> value
+}
+
+// NewCounter constructs a counter. keyPrefix should include a trailing
+// delimiter.
+func NewCounter(redisClient *redis.Client, keyPrefix string, window time.Duration) *Counter {
+	if window <= 0 {
+		window = time.Minute
+	}
+	return &Counter{
+		redis: redisClient, keyPrefix: keyPrefix, window: window, now: time.Now, local: map[string]int64{},
+	}
+}
+
+// windowKey returns the storage key of key's current window and when that
+// window ends.
+func (c *Counter) windowKey(key string, now time.Time) (string, time.Time) {
+	start := now.UTC().Truncate(c.window)
+	return fmt.Sprintf("%s%s:%d", c.keyPrefix, key, start.Unix()), start.Add(c.window)
+}
+
+// Reserve adds n to key when the window total stays within max, and
+// reports the outcome. A single amount larger than max is never admitted.
+// release gives the amount back to the window it was counted in, for work
+// that was reserved but then did not happen, even when that window has
+// ended in the meantime; it is idempotent and a no-op when nothing was
+// reserved. max <= 0 is unlimited and records nothing.
+func (c *Counter) Reserve(ctx context.Context, key string, n, max int64) (release func(), d Decision) {
+	if max <= 0 {
+		return func() {}, Decision{Allowed: true}
+	}
+	now := c.now()
+	wkey, reset := c.windowKey(key, now)
+	if c.redis != nil {
+		res, err := counterReserveScript.Run(ctx, c.redis, []string{wkey},
+			n, max, (c.window + time.Minute).Milliseconds()).Int64Slice()
+		if err == nil && len(res) == 2 {
+			d = counterDecision(res[0] == 1, res[1], max, reset, now)
+			if !d.Allowed {
+				return func() {}, d
+			}
+			return c.releaser(func() {
+				_ = counterReleaseScript.Run(context.WithoutCancel(ctx), c.redis, []string{wkey}, n).Err()
+			}), d
+		}
+	}
+
+	c.mu.Lock()
+	c.pruneLocked(now)
+	value := c.local[wkey]
+	admitted := value+n <= max
+	if admitted {
+		value += n
+		c.local[wkey] = value
+	}
+	c.mu.Unlock()
+	d = counterDecision(admitted, value, max, reset, now)
+	if !admitted {
+		return func() {}, d
+	}
+	return c.releaser(func() {
+		c.mu.Lock()
+		if v, ok := c.local[wkey]; ok {
+			c.local[wkey] = v - n
+		}
+		c.mu.Unlock()
+	}), d
+}
+
+// releaser runs give at most once.
+func (c *Counter) releaser(give func()) func() {
+	var once sync.Once
+	return func() { once.Do(give) }
+}
+
+// Check reports whether key is still below max in the current window,
+// without adding to it.
+func (c *Counter) Check(ctx context.Context, key string, max int64) Decision {
+	if max <= 0 {
+		return Decision{Allowed: true}
+	}
+	now := c.now()
+	wkey, reset := c.windowKey(key, now)
+	value, done := int64(0), false
+	if c.redis != nil {
+		v, err := c.redis.Get(ctx, wkey).Int64()
+		if err == nil || err == redis.Nil {
+			value, done = v, true
+		}
+	}
+	if !done {
+		c.mu.Lock()
+		value = c.local[wkey]
+		c.mu.Unlock()
+	}
+	return counterDecision(value < max, value, max, reset, now)
+}
+
+// Add adds n to key unconditionally, for amounts only known after the fact
+// (such as the tokens a model call used).
+func (c *Counter) Add(ctx context.Context, key string, n int64) {
+	if n == 0 {
+		return
+	}
+	now := c.now()
+	wkey, _ := c.windowKey(key, now)
+	if c.redis != nil {
+		pipe := c.redis.TxPipeline()
+		pipe.IncrBy(ctx, wkey, n)
+		pipe.PExpire(ctx, wkey, c.window+time.Minute)
+		if _, err := pipe.Exec(ctx); err == nil {
+			return
+		}
+	}
+	c.mu.Lock()
+	c.pruneLocked(now)
+	c.local[wkey] += n
+	c.mu.Unlock()
+}
+
+// pruneLocked drops local windows that have ended. Keys embed their
+// window start, so anything older than the current window is dead.
+func (c *Counter) pruneLocked(now time.Time) {
+	if len(c.local) < 1024 {
+		return
+	}
+	cutoff := now.UTC().Truncate(c.window).Unix()
+	for k := range c.local {
+		start, _ := strconv.ParseInt(k[strings.LastIndexByte(k, ':')+1:], 10, 64)
+		if start < cutoff {
+			delete(c.local, k)
+		}
+	}
+}
+
+func counterDecision(allowed bool, value, max int64, reset, now time.Time) Decision {
+	d := Decision{Allowed: allowed, Limit: max, Remaining: max - value, Reset: reset}
+	if d.Remaining < 0 {
+		d.Remaining = 0
+	}
+	if !allowed {
+		d.RetryAfter = reset.Sub(now)
+	}
+	return d
+}
+
+const (
+	// semaphoreLeaseTTL is how long a slot survives without a heartbeat, so
+	// a crashed holder frees its slot within this window.
+	semaphoreLeaseTTL = 30 * time.Second
+	// SemaphoreRetryAfter is what a rejected acquirer is told to wait: slots
+	// free up when a holder finishes, which cannot be predicted.
+	SemaphoreRetryAfter = 5 * time.Second
+)
+
+// semaphoreAcquireScript prunes expired leases and admits the caller while
+// fewer than ARGV[2] are held. Returns {admitted, holders after the call}.
+var semaphoreAcquireScript = redis.NewScript(`
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', ARGV[1])
+local count = redis.call('ZCARD', KEYS[1])
+if count < tonumber(ARGV[2]) then
+    redis.call('ZADD', KEYS[1], ARGV[1] + ARGV[4], ARGV[3])
+    redis.call('PEXPIRE', KEYS[1], ARGV[4] * 2)
+    return {1, count + 1}
+end
+return {0, count}
+`)
+
+// Semaphore caps concurrent holders per key without waiting: TryAcquire
+// either takes a slot or reports the key as full. The Redis backend keeps
+// leases in a sorted set scored by expiry, refreshed by a heartbeat while
+// held, so slots of crashed instances are reclaimed (see models/limiter for
+// the blocking variant used for model calls).
+type Semaphore struct {
+	redis     *redis.Client
+	keyPrefix string
+	ttl       time.Duration
+
+	mu    sync.Mutex
+	local map[string]int
+}
+
+// NewSemaphore constructs a semaphore. keyPrefix should include a trailing
+// delimiter.
+func NewSemaphore(redisClient *redis.Client, keyPrefix string) *Semaphore {
+	return &Semaphore{redis: redisClient, keyPrefix: keyPrefix, ttl: semaphoreLeaseTTL, local: map[string]int{}}
+}
+
+// TryAcquire takes a slot of key when fewer than limit are held. release
+// is idempotent and must be called once the caller is done; it is a no-op
+// when the slot was not granted. limit <= 0 is unlimited.
+func (s *Semaphore) TryAcquire(ctx context.Context, key string, limit int) (release func(), d Decision) {
+	if limit <= 0 {
+		return func() {}, Decision{Allowed: true}
+	}
+	now := time.Now()
+	if s.redis != nil {
+		zkey := s.keyPrefix + key
+		token := uuid.NewString()
+		res, err := semaphoreAcquireScript.Run(ctx, s.redis, []string{zkey},
+			now.UnixMilli(), limit, token, s.ttl.Milliseconds()).Int64Slice()
+		if err == nil && len(res) == 2 {
+			d = semaphoreDecision(res[0] == 1, res[1], limit, now)
+			if !d.Allowed {
+				return func() {}, d
+			}
+			return s.hold(zkey, token), d
+		}
+	}
+
+	s.mu.Lock()
+	held := s.local[key]
+	admitted := held < limit
+	if admitted {
+		held++
+		s.local[key] = held
+	}
+	s.mu.Unlock()
+	d = semaphoreDecision(admitted, int64(held), limit, now)
+	if !admitted {
+		return func() {}, d
+	}
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			s.mu.Lock()
+			if s.local[key]--; s.local[key] <= 0 {
+				delete(s.local, key)
+			}
+			s.mu.Unlock()
+		})
+	}, d
+}
+
+// hold refreshes the lease until release is called.
+func (s *Semaphore) hold(zkey, token string) func() {
+	stop := make(chan struct{})
+	go func() {
+		t := time.NewTicker(s.ttl / 3)
+		defer t.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-t.C:
+				bg := context.Background()
+				expiry := time.Now().Add(s.ttl).UnixMilli()
+				_ = s.redis.ZAdd(bg, zkey, redis.Z{Score: float64(expiry), Member: token}).Err()
+				_ = s.redis.PExpire(bg, zkey, s.ttl*2).Err()
+			}
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(stop)
+			_ = s.redis.ZRem(context.Background(), zkey, token).Err()
+		})
+	}
+}
+
+func semaphoreDecision(admitted bool, held int64, limit int, now time.Time) Decision {
+	d := Decision{Allowed: admitted, Limit: int64(limit), Remaining: int64(limit) - held, Reset: now}
+	if d.Remaining < 0 {
+		d.Remaining = 0
+	}
+	if !admitted {
+		d.RetryAfter = SemaphoreRetryAfter
+		d.Reset = now.Add(SemaphoreRetryAfter)
+	}
+	return d
+}
@@ -136,7 +136,9 @@ func resolveCatalogResource(
 // resolveFileService picks the file service for (tenant, backendID, provider)
 // — via the storage resolver when wired, else directly from the tenant's
 // storage config. No fallback; used by the presigned surfaces where a
-// missing tenant config must surface as an error.
+// missing tenant config must surface as an error. The router always wires a
+// storage resolver, which applies file encryption; the direct path is only
+// taken by focused tests and builds an unencrypted service.
 func resolveFileService(
 	ctx context.Context,
 	tenant *types.Tenant,
@@ -146,7 +148,7 @@ func resolveFileService(
 	if storageResolver != nil {
 		return storageResolver.ResolveFileService(ctx, tenant, backendID, provider, absDir)
 	}
-	return filesvc.NewFileServiceFromStorageConfig(provider, tenant.StorageEngineConfig, absDir)
+	return filesvc.NewFileServiceFromStorageConfig(provider, tenant.StorageEngineConfig, absDir, nil)
 }
 
 // resolveTenantFileServiceWithFallback is resolveFileService plus the
@@ -167,7 +169,7 @@ func resolveTenantFileServiceWithFallback(
 	if storageResolver != nil {
 		fileSvc, resolvedProvider, err = storageResolver.ResolveFileService(ctx, tenant, backendID, provider, absDir)
 	} else if tenant.StorageEngineConfig != nil {
-		fileSvc, resolvedProvider, err = filesvc.NewFileServiceFromStorageConfig(provider, tenant.StorageEngineConfig, absDir)
+		fileSvc, resolvedProvider, err = filesvc.NewFileServiceFromStorageConfig(provider, tenant.StorageEngineConfig, absDir, nil)
 	} else {
 		err = http.ErrMissingFile
 	}
@@ -307,6 +309,9 @@ func serveFilesWithResources(
 	r.GET(
 		"/files",
 		middleware.AllowFileServeAPIKey(),
+		// Stored files are raw document content: custom roles need retrieve,
+		// as on the KB-scoped route inside /api/v1.
+		middleware.RequireCustomRolePermission(types.APIKeyCapabilityRetrieve),
 		newFileServeHandler(globalFileService, storageResolver, resourceCatalog),
 	)
 }
@@ -140,6 +140,9 @@ type rbacGuards struct {
 	chunkService      middleware.ChunkLookup
 	kbShareService    interfaces.KBShareService
 	agentShareService interfaces.AgentShareService
+	// knowledgeACL applies document-level ACLs in the guards that resolve
+	// a knowledge or chunk ID. Nil disables the check.
+	knowledgeACL middleware.KnowledgeReadChecker
 
 	// apiKeyAuthorizer is the single source of truth for which routes an
 	// X-API-Key principal may call. Routes opt in via the apiKeyGroup
@@ -162,6 +165,7 @@ func newRBACGuards(
 	chunkService interfaces.ChunkService,
 	kbShareService interfaces.KBShareService,
 	agentShareService interfaces.AgentShareService,
+	knowledgeACL interfaces.KnowledgeACLService,
 ) *rbacGuards {
 	g := &rbacGuards{cfg: cfg, apiKeyAuthorizer: middleware.NewAPIKeyRouteAuthorizer()}
 	if kbHandler != nil {
@@ -186,6 +190,9 @@ func newRBACGuards(
 	g.chunkService = chunkService
 	g.kbShareService = kbShareService
 	g.agentShareService = agentShareService
+	if knowledgeACL != nil {
+		g.knowledgeACL = knowledgeACL
+	}
 	return g
 }
 
@@ -389,6 +396,10 @@ func (a *apiKeyRouteGroup) DELETE(rel string, h ...gin.HandlerFunc) gin.IRoutes
 	return a.handle(http.MethodDelete, rel, h...)
 }
 
+func (a *apiKeyRouteGroup) PATCH(rel string, h ...gin.HandlerFunc) gin.IRoutes {
+	return a.handle(http.MethodPatch, rel, h...)
+}
+
 // apiKeyRoute declares a single API-key-accessible route directly on a gin
 // group (for routes registered outside an apiKeyGroup, e.g. top-level r.POST).
 func (g *rbacGuards) apiKeyRoute(
@@ -430,6 +441,22 @@ func (g *rbacGuards) assertAPIKeyPoliciesMatchRoutes(engine *gin.Engine) {
 	}
 }
 
+// assertCustomRolePermissionsCovered verifies every permission a custom role
+// may grant is declared by at least one workspace route. The catalog lives in
+// types while the routes live here, so a capability renamed or dropped from
+// every route would otherwise stay grantable and silently open nothing.
+func (g *rbacGuards) assertCustomRolePermissionsCovered() {
+	var uncovered []string
+	for _, entry := range g.ensureAPIKeyAuthorizer().PermissionCatalog() {
+		if len(entry.Routes) == 0 {
+			uncovered = append(uncovered, entry.Permission)
+		}
+	}
+	if len(uncovered) > 0 {
+		panic("custom role permission(s) declared by no route: " + strings.Join(uncovered, ", "))
+	}
+}
+
 func (g *rbacGuards) SystemAdmin() gin.HandlerFunc {
 	return middleware.RequireSystemAdmin(g.cfg)
 }
@@ -580,7 +607,7 @@ func (g *rbacGuards) KBAccessWrite(param string) gin.HandlerFunc {
 // the chunk via /chunks/:knowledge_id rather than /knowledge-bases/:id.
 func (g *rbacGuards) KBAccessReadFromKnowledgeIDParam(param string) gin.HandlerFunc {
 	return middleware.RequireKBAccess(
-		middleware.KBIDFromKnowledgeIDParam(param, g.knowledgeService),
+		middleware.KBIDFromReadableKnowledgeIDParam(param, g.knowledgeService, g.knowledgeACL),
 		types.OrgRoleViewer,
 		g.kbService,
 		g.kbShareService,
@@ -593,7 +620,7 @@ func (g *rbacGuards) KBAccessReadFromKnowledgeIDParam(param string) gin.HandlerF
 // for mutating routes (Editor minimum).
 func (g *rbacGuards) KBAccessWriteFromKnowledgeIDParam(param string) gin.HandlerFunc {
 	return middleware.RequireKBAccess(
-		middleware.KBIDFromKnowledgeIDParam(param, g.knowledgeService),
+		middleware.KBIDFromReadableKnowledgeIDParam(param, g.knowledgeService, g.knowledgeACL),
 		types.OrgRoleEditor,
 		g.kbService,
 		g.kbShareService,
@@ -607,7 +634,7 @@ func (g *rbacGuards) KBAccessWriteFromKnowledgeIDParam(param string) gin.Handler
 // /chunks/by-id/:id read routes.
 func (g *rbacGuards) KBAccessReadFromChunkIDParam(param string) gin.HandlerFunc {
 	return middleware.RequireKBAccess(
-		middleware.KBIDFromChunkIDParam(param, g.chunkService),
+		middleware.KBIDFromReadableChunkIDParam(param, g.chunkService, g.knowledgeACL),
 		types.OrgRoleViewer,
 		g.kbService,
 		g.kbShareService,
@@ -621,7 +648,7 @@ func (g *rbacGuards) KBAccessReadFromChunkIDParam(param string) gin.HandlerFunc
 // address the chunk via /chunks/by-id/:id.
 func (g *rbacGuards) KBAccessWriteFromChunkIDParam(param string) gin.HandlerFunc {
 	return middleware.RequireKBAccess(
-		middleware.KBIDFromChunkIDParam(param, g.chunkService),
+		middleware.KBIDFromReadableChunkIDParam(param, g.chunkService, g.knowledgeACL),
 		types.OrgRoleEditor,
 		g.kbService,
 		g.kbShareService,
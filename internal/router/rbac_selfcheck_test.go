@@ -2,8 +2,10 @@ package router
 
 import (
 	"net/http"
+	"net/http/httptest"
 	"testing"
 
+	"github.com/Tencent/WeKnora/internal/types"
 	"github.com/gin-gonic/gin"
 )
 
@@ -51,3 +53,79 @@ func TestAssertAPIKeyPoliciesMatchRoutes_Missing(t *testing.T) {
 	}()
 	g.assertAPIKeyPoliciesMatchRoutes(engine)
 }
+
+// TestAssertCustomRolePermissionsCovered_Uncovered verifies startup fails
+// when a grantable custom role permission is declared by no route.
+func TestAssertCustomRolePermissionsCovered_Uncovered(t *testing.T) {
+	g := &rbacGuards{}
+	g.ensureAPIKeyAuthorizer().Register(http.MethodGet, "/api/v1/sessions", apiKeyChat(apiKeyAny()))
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected panic for permissions without a route")
+		}
+	}()
+	g.assertCustomRolePermissionsCovered()
+}
+
+// TestAssertCustomRolePermissionsCovered_AllDeclared passes once every
+// catalog permission is declared by some route; platform routes do not count.
+func TestAssertCustomRolePermissionsCovered_AllDeclared(t *testing.T) {
+	g := &rbacGuards{}
+	authz := g.ensureAPIKeyAuthorizer()
+	authz.Register(http.MethodGet, "/api/v1/system/info", apiKeyPlatform(types.APIKeyCapabilityChat))
+	for _, permission := range types.CustomRolePermissionCatalog() {
+		authz.Register(http.MethodGet, "/api/v1/p/"+string(permission), apiKeyAny().WithCapability(permission))
+	}
+	g.assertCustomRolePermissionsCovered()
+
+	for _, entry := range authz.PermissionCatalog() {
+		for _, route := range entry.Routes {
+			if route == "GET /api/v1/system/info" {
+				t.Fatalf("platform route listed under %s", entry.Permission)
+			}
+		}
+	}
+}
+
+// TestCustomRoleGate checks the gate against the same policies API keys use.
+func TestCustomRoleGate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	g := &rbacGuards{}
+	engine := gin.New()
+	grant := types.CustomRoleGrant{RoleID: 7, Name: "chat-only", Permissions: types.StringArray{"chat"}}
+	var apiKey bool
+	engine.Use(func(c *gin.Context) {
+		ctx := types.WithCustomRoleGrant(c.Request.Context(), grant)
+		if apiKey {
+			ctx = types.WithTenantAPIKeyScope(ctx, types.TenantAPIKeyScope{})
+		}
+		c.Request = c.Request.WithContext(ctx)
+	})
+	engine.Use(g.ensureAPIKeyAuthorizer().CustomRoleGate())
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	g.apiKeyRoute(&engine.RouterGroup, http.MethodGet, "/sessions", apiKeyChat(apiKeyAny()), ok)
+	g.apiKeyRoute(&engine.RouterGroup, http.MethodPost, "/search", apiKeyRetrieve(apiKeyAny()), ok)
+	g.apiKeyRoute(&engine.RouterGroup, http.MethodGet, "/tenants", apiKeyFullAccess(), ok)
+	engine.GET("/undeclared", ok)
+
+	cases := []struct {
+		method, path string
+		apiKey       bool
+		want         int
+	}{
+		{http.MethodGet, "/sessions", false, http.StatusOK},
+		{http.MethodPost, "/search", false, http.StatusForbidden},
+		{http.MethodGet, "/tenants", false, http.StatusOK},
+		{http.MethodGet, "/undeclared", false, http.StatusOK},
+		{http.MethodPost, "/search", true, http.StatusOK},
+	}
+	for _, tc := range cases {
+		apiKey = tc.apiKey
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(tc.method, tc.path, nil))
+		if w.Code != tc.want {
+			t.Errorf("%s %s (api key=%v): got %d, want %d", tc.method, tc.path, tc.apiKey, w.Code, tc.want)
+		}
+	}
+}
@@ -17,8 +17,10 @@ import (
 	"github.com/Tencent/WeKnora/internal/handler"
 	"github.com/Tencent/WeKnora/internal/handler/session"
 	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/metrics"
 	"github.com/Tencent/WeKnora/internal/middleware"
 	"github.com/Tencent/WeKnora/internal/tracing/langfuse"
+	"github.com/Tencent/WeKnora/internal/tracing/otlp"
 	"github.com/Tencent/WeKnora/internal/types/interfaces"
 
 	_ "github.com/Tencent/WeKnora/docs" // swagger docs
@@ -40,13 +42,22 @@ type RouterParams struct {
 	EvaluationService            interfaces.EvaluationService
 	KBShareService               interfaces.KBShareService
 	AgentShareService            interfaces.AgentShareService
+	KnowledgeACLService          interfaces.KnowledgeACLService
+	UsageService                 interfaces.UsageService
+	RateLimitService             interfaces.RateLimitService
 	KBHandler                    *handler.KnowledgeBaseHandler
+	GraphCommunityHandler        *handler.GraphCommunityHandler
+	GraphEntityHandler           *handler.GraphEntityHandler
 	KnowledgeHandler             *handler.KnowledgeHandler
 	TenantHandler                *handler.TenantHandler
 	TenantService                interfaces.TenantService
 	TenantAPIKeyService          interfaces.TenantAPIKeyService
 	TenantMemberService          interfaces.TenantMemberService
 	TenantMemberHandler          *handler.TenantMemberHandler
+	TenantCustomRoleService      interfaces.TenantCustomRoleService
+	TenantCustomRoleHandler      *handler.TenantCustomRoleHandler
+	SCIMHandler                  *handler.SCIMHandler
+	EncryptionHandler            *handler.EncryptionHandler
 	TenantInvitationHandler      *handler.TenantInvitationHandler
 	AuditLogHandler              *handler.AuditLogHandler
 	AuditLogService              interfaces.AuditLogService
@@ -67,11 +78,19 @@ type RouterParams struct {
 	WebSearchHandler             *handler.WebSearchHandler
 	WebSearchProviderHandler     *handler.WebSearchProviderHandler
 	WebSearchCredentialsHandler  *handler.WebSearchProviderCredentialsHandler
+	SQLConnectionHandler         *handler.SQLConnectionHandler
+	SQLCredentialsHandler        *handler.SQLConnectionCredentialsHandler
+	BrowserConfigHandler         *handler.BrowserConfigHandler
+	BrowserCredentialsHandler    *handler.BrowserConfigCredentialsHandler
 	VectorStoreHandler           *handler.VectorStoreHandler
 	StorageBackendHandler        *handler.StorageBackendHandler
 	StorageBackendResolver       interfaces.StorageBackendResolver
 	ResourceCatalog              interfaces.ResourceCatalog
 	FAQHandler                   *handler.FAQHandler
+	KnowledgeACLHandler          *handler.KnowledgeACLHandler
+	UsageHandler                 *handler.UsageHandler
+	RateLimitHandler             *handler.RateLimitHandler
+	DataSubjectHandler           *handler.DataSubjectHandler
 	TagHandler                   *handler.TagHandler
 	CustomAgentHandler           *handler.CustomAgentHandler
 	UserFavoriteHandler          *handler.UserResourceFavoriteHandler
@@ -113,15 +132,20 @@ func NewRouter(params RouterParams) *gin.Engine {
 		AllowOrigins:     []string{"*"},
 		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
 		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", "X-API-Key", "X-Request-ID", "X-Tenant-ID", "X-Embed-Session", "X-External-User-ID", "X-External-User-Token"},
-		ExposeHeaders:    []string{"Content-Length", "Access-Control-Allow-Origin"},
+		ExposeHeaders:    append([]string{"Content-Length", "Access-Control-Allow-Origin", middleware.UsageWarningHeader}, middleware.RateLimitHeaders...),
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	}))
 
 	// 基础中间件（不需要认证）
 	r.Use(middleware.RequestID())
+	// OTLP 服务端 span：需在 RequestID 之后（向请求日志追加 trace_id），
+	// 并早于 Langfuse 中间件，使两者共享同一个 trace id
+	r.Use(otlp.GinMiddleware())
 	r.Use(middleware.Language())
 	r.Use(middleware.Logger())
+	// Prometheus 请求指标：置于 Recovery 之外，panic 恢复后的 500 也会被计入
+	r.Use(metrics.GinMiddleware())
 	r.Use(middleware.Recovery())
 	r.Use(middleware.ErrorHandler())
 
@@ -130,6 +154,12 @@ func NewRouter(params RouterParams) *gin.Engine {
 		c.JSON(200, gin.H{"status": "ok"})
 	})
 
+	// Prometheus 指标（不需要认证，默认关闭；WEKNORA_METRICS_ENABLED 开启，
+	// 可用 WEKNORA_METRICS_TOKEN 要求 Bearer 令牌）
+	if cfg := metrics.LoadConfigFromEnv(); cfg.Enabled {
+		r.GET("/metrics", metrics.Handler(cfg))
+	}
+
 	// Swagger API 文档（仅在非生产环境下启用）
 	// 通过 GIN_MODE 环境变量判断：release 模式下禁用 Swagger
 	if gin.Mode() != gin.ReleaseMode {
@@ -165,6 +195,7 @@ func NewRouter(params RouterParams) *gin.Engine {
 		params.EmbedChannelService,
 		params.TenantService,
 		params.RedisClient,
+		params.RateLimitService,
 		params.FileService,
 		params.StorageBackendResolver,
 		params.ResourceCatalog,
@@ -175,7 +206,7 @@ func NewRouter(params RouterParams) *gin.Engine {
 	serveResourceGrants(r, params.ResourceCatalog, params.TenantService, params.FileService, params.StorageBackendResolver)
 
 	// 认证中间件
-	r.Use(middleware.Auth(params.TenantService, params.UserService, params.TenantMemberService, params.TenantAPIKeyService, params.Config))
+	r.Use(middleware.Auth(params.TenantService, params.UserService, params.TenantMemberService, params.TenantAPIKeyService, params.TenantCustomRoleService, params.Config))
 
 	// 文件服务：统一代理本地/MinIO/COS/TOS存储后端（需要认证）
 	serveFilesWithResources(r, params.FileService, params.StorageBackendResolver, params.ResourceCatalog)
@@ -217,6 +248,7 @@ func NewRouter(params RouterParams) *gin.Engine {
 			params.ChunkService,
 			params.KBShareService,
 			params.AgentShareService,
+			params.KnowledgeACLService,
 		)
 
 		// API-key gate: single authority for X-API-Key principals. Runs
@@ -225,12 +257,30 @@ func NewRouter(params RouterParams) *gin.Engine {
 		// apiKeyGroup helpers. Must be attached BEFORE the Register* calls
 		// so that sub-groups inherit it.
 		v1.Use(rbacGuards.apiKeyAuthorizer.Middleware())
+		// Records successful knowledge reads made with an API key, so a
+		// leaked or over-broad key leaves a trail of what it fetched.
+		v1.Use(rbacGuards.apiKeyAuthorizer.AuditAPIKeyReads())
+		// Custom tenant roles: narrows members holding one to the route
+		// groups their role grants, reading the same policies as the gate.
+		v1.Use(rbacGuards.apiKeyAuthorizer.CustomRoleGate())
+		// Requests-per-minute policies. Runs after the API-key and
+		// custom-role gates above, so requests they reject do not use up
+		// the caller's budget. The per-route RBAC and knowledge base
+		// guards are attached by the Register* calls below and run after
+		// it: a request they deny has still been counted, which also
+		// bounds how fast a caller can probe routes it cannot use.
+		v1.Use(middleware.RateLimit(params.RateLimitService))
 
 		RegisterAuthRoutes(v1, params.AuthHandler, rbacGuards)
 		RegisterTenantRoutes(v1, params.TenantHandler, params.TenantMemberHandler, params.TenantInvitationHandler, params.AuditLogHandler, rbacGuards)
+		RegisterTenantCustomRoleRoutes(v1, params.TenantCustomRoleHandler, rbacGuards)
+		RegisterSCIMRoutes(v1, params.SCIMHandler, rbacGuards)
+		RegisterEncryptionRoutes(v1, params.EncryptionHandler, rbacGuards)
 		RegisterMyInvitationRoutes(v1, params.TenantInvitationHandler)
 		RegisterKnowledgeBaseRoutes(v1, params.KBHandler, rbacGuards)
 		RegisterKnowledgeBaseActivityRoutes(v1, params.AuditLogHandler, rbacGuards)
+		RegisterGraphCommunityRoutes(v1, params.GraphCommunityHandler, rbacGuards)
+		RegisterGraphEntityRoutes(v1, params.GraphEntityHandler, rbacGuards)
 		// KB-scoped image proxy: lets tenants render images embedded in
 		// org-shared / agent-visible KB content, which the tenant-scoped
 		// /files route cannot serve because it enforces same-tenant paths.
@@ -258,10 +308,14 @@ func NewRouter(params RouterParams) *gin.Engine {
 		)
 		RegisterKnowledgeTagRoutes(v1, params.TagHandler, rbacGuards)
 		RegisterKnowledgeRoutes(v1, params.KnowledgeHandler, rbacGuards)
+		RegisterKnowledgeACLRoutes(v1, params.KnowledgeACLHandler, rbacGuards)
+		RegisterUsageRoutes(v1, params.UsageHandler, rbacGuards)
+		RegisterRateLimitRoutes(v1, params.RateLimitHandler, rbacGuards)
+		RegisterDataSubjectRoutes(v1, params.DataSubjectHandler, rbacGuards)
 		RegisterFAQRoutes(v1, params.FAQHandler, rbacGuards)
 		RegisterChunkRoutes(v1, params.ChunkHandler, rbacGuards)
 		RegisterSessionRoutes(v1, params.SessionHandler, params.MessageSuggestionHandler, rbacGuards)
-		RegisterChatRoutes(v1, params.SessionHandler, rbacGuards)
+		RegisterChatRoutes(v1, params.SessionHandler, rbacGuards, params.UsageService, params.RateLimitService)
 		RegisterMessageRoutes(v1, params.MessageHandler, rbacGuards)
 		RegisterModelRoutes(v1, params.ModelHandler, params.ModelCredentialsHandler, rbacGuards)
 		RegisterSandboxConfigRoutes(v1, params.SandboxConfigHandler, rbacGuards)
@@ -273,6 +327,8 @@ func NewRouter(params RouterParams) *gin.Engine {
 		RegisterMCPServiceRoutes(v1, params.MCPServiceHandler, params.MCPCredentialsHandler, params.MCPOAuthHandler, rbacGuards)
 		RegisterWebSearchRoutes(v1, params.WebSearchHandler, rbacGuards)
 		RegisterWebSearchProviderRoutes(v1, params.WebSearchProviderHandler, params.WebSearchCredentialsHandler, rbacGuards)
+		RegisterSQLConnectionRoutes(v1, params.SQLConnectionHandler, params.SQLCredentialsHandler, rbacGuards)
+		RegisterBrowserConfigRoutes(v1, params.BrowserConfigHandler, params.BrowserCredentialsHandler, rbacGuards)
 		RegisterVectorStoreRoutes(v1, params.VectorStoreHandler, rbacGuards)
 		RegisterStorageBackendRoutes(v1, params.StorageBackendHandler, rbacGuards)
 		RegisterCustomAgentRoutes(v1, params.CustomAgentHandler, rbacGuards)
@@ -292,6 +348,9 @@ func NewRouter(params RouterParams) *gin.Engine {
 		// stale template would silently 403 every API key on that route,
 		// so we panic at startup instead of shipping a dead policy.
 		rbacGuards.assertAPIKeyPoliciesMatchRoutes(r)
+		// Same for custom roles: a catalog permission no route declares
+		// could be granted but would never open anything.
+		rbacGuards.assertCustomRolePermissionsCovered()
 	}
 
 	return r
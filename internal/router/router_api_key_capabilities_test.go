@@ -17,7 +17,7 @@ func TestConversationRoutesDeclareChatCapability(t *testing.T) {
 	v1 := gin.New().Group("/api/v1")
 
 	RegisterSessionRoutes(v1, &sessionhandler.Handler{}, &handler.MessageSuggestionHandler{}, g)
-	RegisterChatRoutes(v1, &sessionhandler.Handler{}, g)
+	RegisterChatRoutes(v1, &sessionhandler.Handler{}, g, nil, nil)
 	RegisterMessageRoutes(v1, &handler.MessageHandler{}, g)
 
 	cases := []struct {
@@ -295,7 +295,7 @@ func TestKnowledgeReadRoutesDeclareRetrieveCapability(t *testing.T) {
 	RegisterKnowledgeRoutes(v1, &handler.KnowledgeHandler{}, g)
 	RegisterFAQRoutes(v1, &handler.FAQHandler{}, g)
 	RegisterKnowledgeTagRoutes(v1, &handler.TagHandler{}, g)
-	RegisterChatRoutes(v1, &sessionhandler.Handler{}, g)
+	RegisterChatRoutes(v1, &sessionhandler.Handler{}, g, nil, nil)
 	RegisterInitializationRoutes(v1, &handler.InitializationHandler{}, g)
 	RegisterWikiPageRoutes(v1, &handler.WikiPageHandler{}, g)
 
@@ -456,6 +456,31 @@ func TestTenantMemberRoutesDeclareManageMembersCapability(t *testing.T) {
 	}
 }
 
+func TestTenantCustomRoleRoutesDeclareManageMembersCapability(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	g := &rbacGuards{}
+	v1 := gin.New().Group("/api/v1")
+
+	RegisterTenantCustomRoleRoutes(v1, &handler.TenantCustomRoleHandler{}, g)
+
+	for _, tc := range []struct{ method, path string }{
+		{http.MethodGet, "/api/v1/tenants/:id/roles"},
+		{http.MethodGet, "/api/v1/tenants/:id/roles/permissions"},
+		{http.MethodPost, "/api/v1/tenants/:id/roles"},
+		{http.MethodPut, "/api/v1/tenants/:id/roles/:role_id"},
+		{http.MethodDelete, "/api/v1/tenants/:id/roles/:role_id"},
+		{http.MethodPut, "/api/v1/tenants/:id/members/:user_id/custom-role"},
+	} {
+		policy := mustLookupAPIKeyPolicy(t, g, tc.method, tc.path)
+		if !policyHasCapability(policy, types.APIKeyCapabilityManageMembers) {
+			t.Fatalf("%s %s capabilities = %#v, want manage_members", tc.method, tc.path, policy.Capabilities)
+		}
+	}
+	if _, ok := g.apiKeyAuthorizer.Lookup(http.MethodPut, "/api/v1/tenants/:id/api-keys/:key_id/custom-role"); ok {
+		t.Fatal("binding a role to an API key must stay default-deny for API keys")
+	}
+}
+
 func TestOrganizationRoutesDeclareManageSpacesCapability(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	g := &rbacGuards{}
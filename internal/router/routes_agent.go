@@ -211,6 +211,7 @@ func RegisterEmbedPublicRoutes(
 	embedService interfaces.EmbedChannelService,
 	tenantService interfaces.TenantService,
 	redisClient *redis.Client,
+	limits interfaces.RateLimitService,
 	fileService interfaces.FileService,
 	storageResolver interfaces.StorageBackendResolver,
 	resourceCatalogs ...interfaces.ResourceCatalog,
@@ -218,15 +219,17 @@ func RegisterEmbedPublicRoutes(
 	if embedHandler == nil || embedService == nil {
 		return
 	}
-	embed := r.Group("/api/v1/embed/:channel_id", middleware.EmbedAuth(embedService, tenantService, redisClient))
+	embed := r.Group("/api/v1/embed/:channel_id",
+		middleware.EmbedAuth(embedService, tenantService, redisClient), middleware.RateLimit(limits))
+	chatQuota := []gin.HandlerFunc{middleware.TokenRateLimit(limits), middleware.StreamRateLimit(limits)}
 	{
 		embed.POST("/exchange", embedHandler.ExchangeEmbedSession)
 		embed.GET("/config", embedHandler.GetEmbedConfig)
 		embed.GET("/suggested-questions", embedHandler.GetEmbedSuggestedQuestions)
 		embed.GET("/chunks/:chunk_id", embedHandler.GetEmbedChunk)
 		embed.POST("/sessions", embedHandler.CreateEmbedSession)
-		embed.POST("/knowledge-chat/:session_id", embedHandler.EmbedKnowledgeChat)
-		embed.POST("/agent-chat/:session_id", embedHandler.EmbedAgentChat)
+		embed.POST("/knowledge-chat/:session_id", append(chatQuota, embedHandler.EmbedKnowledgeChat)...)
+		embed.POST("/agent-chat/:session_id", append(chatQuota, embedHandler.EmbedAgentChat)...)
 		embed.GET("/messages/:session_id/load", embedHandler.EmbedLoadMessages)
 		embed.POST("/sessions/:session_id/stop", embedHandler.EmbedStopSession)
 		embed.GET("/sessions/:session_id/messages/:message_id/suggestions", embedHandler.EmbedGetMessageSuggestions)
@@ -297,6 +300,12 @@ func RegisterIMChannelRoutes(r *gin.RouterGroup, imHandler *handler.IMHandler, g
 		channels.PUT("/:id", g.Admin(), imHandler.UpdateIMChannel)
 		channels.DELETE("/:id", g.Admin(), imHandler.DeleteIMChannel)
 		channels.POST("/:id/toggle", g.Admin(), imHandler.ToggleIMChannel)
+		// IM user → tenant member bindings decide what IM users may do with
+		// slash-commands, so managing them is Admin+.
+		channels.GET("/:id/user-bindings", g.Admin(), imHandler.ListIMUserBindings)
+		channels.POST("/:id/user-bindings", g.Admin(), imHandler.CreateIMUserBinding)
+		channels.DELETE("/:id/user-bindings/:binding_id", g.Admin(), imHandler.DeleteIMUserBinding)
+		channels.GET("/:id/feedback", g.Viewer(), imHandler.ListIMFeedback)
 	}
 
 	// WeChat QR code login (requires authentication) — Admin+: a successful
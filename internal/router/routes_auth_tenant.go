@@ -143,11 +143,71 @@ func RegisterTenantRoutes(
 			// for environments wired without the audit dependency.
 			if auditLogHandler != nil {
 				tenantByID.GET("/audit-log", g.Admin(), auditLogHandler.ListTenantAuditLog)
+				tenantByID.GET("/audit-log/verify", g.Admin(), auditLogHandler.VerifyTenantAuditLogChain)
 			}
 		}
 	}
 }
 
+// RegisterTenantCustomRoleRoutes registers custom role management and
+// assignment under /tenants/:id.
+//
+//   - GET    /:id/roles                          Viewer+ (the member list shows role names)
+//   - GET    /:id/roles/permissions              Viewer+ (the permission catalog)
+//   - POST/PUT/DELETE /:id/roles[/:role_id]     Owner+
+//   - PUT    /:id/members/:user_id/custom-role   Owner+ (same gate as changing a role)
+//   - PUT    /:id/api-keys/:key_id/custom-role   Owner+, JWT only like the rest of key management
+//
+// Scoped API keys reach the role and member endpoints with manage_members.
+// Binding a role to an API key stays undeclared: a key must never be able to
+// widen another key.
+func RegisterTenantCustomRoleRoutes(r *gin.RouterGroup, roleHandler *handler.TenantCustomRoleHandler, g *rbacGuards) {
+	if roleHandler == nil {
+		return
+	}
+	tenantByID := r.Group("/tenants/:id", g.PathTenantMatch())
+	roles := g.apiKeyGroup(tenantByID, apiKeyManageMembers(apiKeyFullAccess()))
+	{
+		roles.GET("/roles", g.Viewer(), roleHandler.ListRoles)
+		roles.GET("/roles/permissions", g.Viewer(), roleHandler.ListPermissions)
+		roles.POST("/roles", g.Owner(), roleHandler.CreateRole)
+		roles.PUT("/roles/:role_id", g.Owner(), roleHandler.UpdateRole)
+		roles.DELETE("/roles/:role_id", g.Owner(), roleHandler.DeleteRole)
+		roles.PUT("/members/:user_id/custom-role", g.Owner(), roleHandler.AssignMemberRole)
+	}
+	tenantByID.PUT("/api-keys/:key_id/custom-role", g.Owner(), roleHandler.AssignAPIKeyRole)
+	roleHandler.BindPermissionCatalog(g.ensureAPIKeyAuthorizer().PermissionCatalog)
+}
+
+// RegisterSCIMRoutes registers the SCIM 2.0 provisioning endpoint under
+// /scim/v2. Identity providers call it with a workspace API key sent as a
+// Bearer token (see middleware.Auth), so every route declares
+// manage_members; signed-in users need Owner, as for member management.
+func RegisterSCIMRoutes(r *gin.RouterGroup, scimHandler *handler.SCIMHandler, g *rbacGuards) {
+	if scimHandler == nil {
+		return
+	}
+	scim := g.apiKeyGroup(r.Group("/scim/v2"), apiKeyManageMembers(apiKeyFullAccess()))
+	{
+		scim.GET("/ServiceProviderConfig", g.Owner(), scimHandler.ServiceProviderConfig)
+		scim.GET("/ResourceTypes", g.Owner(), scimHandler.ResourceTypes)
+
+		scim.GET("/Users", g.Owner(), scimHandler.ListUsers)
+		scim.POST("/Users", g.Owner(), scimHandler.CreateUser)
+		scim.GET("/Users/:user_id", g.Owner(), scimHandler.GetUser)
+		scim.PUT("/Users/:user_id", g.Owner(), scimHandler.ReplaceUser)
+		scim.PATCH("/Users/:user_id", g.Owner(), scimHandler.PatchUser)
+		scim.DELETE("/Users/:user_id", g.Owner(), scimHandler.DeleteUser)
+
+		scim.GET("/Groups", g.Owner(), scimHandler.ListGroups)
+		scim.POST("/Groups", g.Owner(), scimHandler.CreateGroup)
+		scim.GET("/Groups/:group_id", g.Owner(), scimHandler.GetGroup)
+		scim.PUT("/Groups/:group_id", g.Owner(), scimHandler.ReplaceGroup)
+		scim.PATCH("/Groups/:group_id", g.Owner(), scimHandler.PatchGroup)
+		scim.DELETE("/Groups/:group_id", g.Owner(), scimHandler.DeleteGroup)
+	}
+}
+
 // RegisterMyInvitationRoutes wires the per-user invitation inbox under
 // /me/invitations. The v1 group already applies middleware.Auth so we
 // don't need a role gate here — the service enforces "only the invitee
@@ -196,6 +256,8 @@ func RegisterAuthRoutes(r *gin.RouterGroup, handler *handler.AuthHandler, g *rba
 	r.GET("/auth/oidc/callback", handler.OIDCRedirectCallback)
 	// /auth/oidc/start：直连 302 跳转到 OIDC 提供方，供前端无法走 JS 拉取 URL 的场景直接发起登录
 	r.GET("/auth/oidc/start", handler.OIDCStart)
+	r.GET("/auth/ldap/config", handler.GetLDAPConfig)
+	r.POST("/auth/ldap/login", handler.LDAPLogin)
 	r.POST("/auth/refresh", handler.RefreshToken)
 	r.GET("/auth/validate", handler.ValidateToken)
 	r.POST("/auth/logout", handler.Logout)
@@ -232,6 +294,27 @@ func RegisterSystemRoutes(
 	}
 }
 
+// RegisterEncryptionRoutes registers encryption at rest status and key
+// rotation. Every route is JWT only: key management is not something a
+// workspace API key should be able to trigger.
+//
+//   - GET  /tenants/:id/encryption          Admin+
+//   - POST /tenants/:id/encryption/rotate   Owner+
+//   - GET  /system/admin/encryption/status  SystemAdmin
+//   - POST /system/admin/encryption/rewrap  SystemAdmin
+func RegisterEncryptionRoutes(r *gin.RouterGroup, encryptionHandler *handler.EncryptionHandler, g *rbacGuards) {
+	if encryptionHandler == nil {
+		return
+	}
+	tenantByID := r.Group("/tenants/:id", g.PathTenantMatch())
+	tenantByID.GET("/encryption", g.Admin(), encryptionHandler.GetTenantEncryption)
+	tenantByID.POST("/encryption/rotate", g.Owner(), encryptionHandler.RotateTenantKey)
+
+	system := r.Group("/system/admin/encryption", g.SystemAdmin())
+	system.GET("/status", encryptionHandler.GetSystemEncryption)
+	system.POST("/rewrap", encryptionHandler.RewrapDataKeys)
+}
+
 // RegisterSystemAdminRoutes registers system administration routes.
 //
 // All endpoints under this group are gated to SystemAdmin users (i.e.
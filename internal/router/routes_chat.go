@@ -5,6 +5,8 @@ import (
 
 	"github.com/Tencent/WeKnora/internal/handler"
 	"github.com/Tencent/WeKnora/internal/handler/session"
+	"github.com/Tencent/WeKnora/internal/middleware"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
 )
 
 // RegisterMessageRoutes 注册消息相关的路由。
@@ -94,28 +96,42 @@ func RegisterSessionRoutes(
 		sessions.GET("/:id/artifacts", handler.ListSessionArtifacts)
 		sessions.GET("/:id/messages/:message_id/artifacts", handler.ListMessageArtifacts)
 		sessions.GET("/:id/messages/:message_id/artifacts/:index/download", handler.DownloadMessageArtifact)
+		// The session's data analysis steps as a reproducible SQL script or
+		// Jupyter notebook, rebuilt from the persisted tool calls.
+		sessions.GET("/:id/analysis/notebook", handler.DownloadAnalysisNotebook)
 	}
 }
 
 // RegisterChatRoutes 注册路由。Chat endpoints are tenant-member usage
 // surfaces; Viewer+ is sufficient because per-session/per-agent
-// authorisation is enforced inside the handlers.
-func RegisterChatRoutes(r *gin.RouterGroup, handler *session.Handler, g *rbacGuards) {
+// authorisation is enforced inside the handlers. Every route also passes
+// the usage budget guard, so an exhausted hard-stop budget is refused
+// before a stream is opened.
+func RegisterChatRoutes(
+	r *gin.RouterGroup,
+	handler *session.Handler,
+	g *rbacGuards,
+	usage interfaces.UsageService,
+	limits interfaces.RateLimitService,
+) {
+	budget := middleware.UsageBudget(usage)
+	tokens := middleware.TokenRateLimit(limits)
+	streams := middleware.StreamRateLimit(limits)
 	// These POST routes append messages and run generation, so a scoped key
 	// needs the explicit chat capability unless it has full tenant access.
-	knowledgeChat := g.apiKeyGroup(r.Group("/knowledge-chat", g.Viewer()), apiKeyChat(apiKeyFullAccess()))
+	knowledgeChat := g.apiKeyGroup(r.Group("/knowledge-chat", g.Viewer(), budget, tokens, streams), apiKeyChat(apiKeyFullAccess()))
 	{
 		knowledgeChat.POST("/:session_id", handler.KnowledgeQA)
 	}
 
 	// Agent-based chat
-	agentChat := g.apiKeyGroup(r.Group("/agent-chat", g.Viewer()), apiKeyChat(apiKeyFullAccess()))
+	agentChat := g.apiKeyGroup(r.Group("/agent-chat", g.Viewer(), budget, tokens, streams), apiKeyChat(apiKeyFullAccess()))
 	{
 		agentChat.POST("/:session_id", handler.AgentQA)
 	}
 
 	// 新增知识检索接口，不需要session_id
-	knowledgeSearch := g.apiKeyGroup(r.Group("/knowledge-search", g.Viewer()), apiKeyRetrieve(apiKeyFullAccess()))
+	knowledgeSearch := g.apiKeyGroup(r.Group("/knowledge-search", g.Viewer(), budget, tokens), apiKeyRetrieve(apiKeyFullAccess()))
 	{
 		knowledgeSearch.POST("", handler.SearchKnowledge)
 	}
@@ -101,6 +101,7 @@ func RegisterInitializationRoutes(r *gin.RouterGroup, handler *handler.Initializ
 	g.apiKeyRoute(r, http.MethodPost, "/initialization/embedding/test", apiKeyManageModels(apiKeyFullAccess()), g.Admin(), handler.TestEmbeddingModel)
 	g.apiKeyRoute(r, http.MethodPost, "/initialization/rerank/check", apiKeyManageModels(apiKeyFullAccess()), g.Admin(), handler.CheckRerankModel)
 	g.apiKeyRoute(r, http.MethodPost, "/initialization/asr/check", apiKeyManageModels(apiKeyFullAccess()), g.Admin(), handler.CheckASRModel)
+	g.apiKeyRoute(r, http.MethodPost, "/initialization/tts/check", apiKeyManageModels(apiKeyFullAccess()), g.Admin(), handler.CheckTTSModel)
 	g.apiKeyRoute(r, http.MethodPost, "/initialization/multimodal/test", apiKeyManageModels(apiKeyFullAccess()), g.Admin(), handler.TestMultimodalFunction)
 
 	g.apiKeyRoute(r, http.MethodPost, "/initialization/extract/text-relation", apiKeyManageModels(apiKeyFullAccess()), g.Admin(), handler.ExtractTextRelations)
@@ -221,6 +222,68 @@ func RegisterWebSearchProviderRoutes(
 	}
 }
 
+// RegisterSQLConnectionRoutes registers CRUD routes for external SQL
+// connections used by the text-to-SQL agent tools.
+//
+// Connection rows hold database credentials; reads are Viewer+, all
+// mutations, connection tests and schema introspection (which open the
+// external database with stored credentials) are Admin+. API keys need the
+// manage_data_sources capability, as for other external data connectors.
+func RegisterSQLConnectionRoutes(
+	r *gin.RouterGroup,
+	h *handler.SQLConnectionHandler,
+	credHandler *handler.SQLConnectionCredentialsHandler,
+	g *rbacGuards,
+) {
+	conns := g.apiKeyGroup(r.Group("/sql-connections"), apiKeyManageDataSources(apiKeyFullAccess()))
+	{
+		// Test with raw settings (no persistence) — Admin+
+		conns.POST("/test", g.Admin(), h.TestConnectionRaw)
+		// CRUD
+		conns.POST("", g.Admin(), h.CreateConnection)
+		conns.GET("", g.Viewer(), h.ListConnections)
+		conns.GET("/:id", g.Viewer(), h.GetConnection)
+		conns.PUT("/:id", g.Admin(), h.UpdateConnection)
+		conns.DELETE("/:id", g.Admin(), h.DeleteConnection)
+		// Per-field credential subresource — Admin+
+		conns.PUT("/:id/credentials", g.Admin(), credHandler.Put)
+		conns.DELETE("/:id/credentials/:field", g.Admin(), credHandler.DeleteField)
+		// Probe the saved connection — Admin+
+		conns.POST("/:id/test", g.Admin(), h.TestConnectionByID)
+		conns.GET("/:id/schema", g.Admin(), h.GetSchema)
+	}
+}
+
+// RegisterBrowserConfigRoutes registers CRUD routes for the headless browser
+// services behind the browser automation agent tools.
+//
+// Configs hold endpoint tokens and the server dials their endpoints, so reads
+// are Viewer+ and every mutation or connection test is Admin+. Like sandbox
+// configs they are workspace infrastructure, so API keys need full access.
+func RegisterBrowserConfigRoutes(
+	r *gin.RouterGroup,
+	h *handler.BrowserConfigHandler,
+	credHandler *handler.BrowserConfigCredentialsHandler,
+	g *rbacGuards,
+) {
+	configs := g.apiKeyGroup(r.Group("/browser-configs"), apiKeyFullAccess())
+	{
+		// Test with raw settings (no persistence) — Admin+
+		configs.POST("/test", g.Admin(), h.TestConfigRaw)
+		// CRUD
+		configs.POST("", g.Admin(), h.CreateConfig)
+		configs.GET("", g.Viewer(), h.ListConfigs)
+		configs.GET("/:id", g.Viewer(), h.GetConfig)
+		configs.PUT("/:id", g.Admin(), h.UpdateConfig)
+		configs.DELETE("/:id", g.Admin(), h.DeleteConfig)
+		// Per-field credential subresource — Admin+
+		configs.PUT("/:id/credentials", g.Admin(), credHandler.Put)
+		configs.DELETE("/:id/credentials/:field", g.Admin(), credHandler.DeleteField)
+		// Probe the saved config — Admin+
+		configs.POST("/:id/test", g.Admin(), h.TestConfigByID)
+	}
+}
+
 // RegisterVectorStoreRoutes registers CRUD routes for vector store configurations.
 //
 // Vector stores are tenant-level infrastructure; reads are Viewer+, all
@@ -318,3 +381,51 @@ func RegisterWeKnoraCloudRoutes(r *gin.RouterGroup, handler *handler.WeKnoraClou
 	g.apiKeyRoute(r, http.MethodPost, "/weknoracloud/credentials", apiKeyManageModels(apiKeyFullAccess()), g.Admin(), handler.SaveCredentials)
 	g.apiKeyRoute(r, http.MethodGet, "/models/weknoracloud/status", apiKeyManageModels(apiKeyFullAccess()), g.Viewer(), handler.Status)
 }
+
+// RegisterUsageRoutes registers the usage report and budget routes.
+//
+// Reports expose per-user and per-key consumption of the whole workspace
+// and budgets are cost controls, so every route is Admin+.
+func RegisterUsageRoutes(r *gin.RouterGroup, h *handler.UsageHandler, g *rbacGuards) {
+	if h == nil {
+		return
+	}
+	usage := g.apiKeyGroup(r.Group("/usage", g.Admin()), apiKeyFullAccess())
+	{
+		usage.GET("/report", h.GetReport)
+		usage.GET("/budgets", h.ListBudgets)
+		usage.PUT("/budgets", h.SetBudget)
+		usage.DELETE("/budgets/:id", h.DeleteBudget)
+	}
+}
+
+// RegisterRateLimitRoutes registers rate limit policy routes.
+//
+// Policies throttle every member and key of the workspace, so changing
+// them is Admin+, like usage budgets.
+func RegisterRateLimitRoutes(r *gin.RouterGroup, h *handler.RateLimitHandler, g *rbacGuards) {
+	if h == nil {
+		return
+	}
+	limits := g.apiKeyGroup(r.Group("/rate-limits", g.Admin()), apiKeyFullAccess())
+	{
+		limits.GET("", h.ListPolicies)
+		limits.PUT("", h.SetPolicy)
+		limits.DELETE("/:id", h.DeletePolicy)
+	}
+}
+
+// RegisterDataSubjectRoutes registers data subject request routes.
+//
+// Exporting or erasing a member's data covers every resource of the
+// workspace, so it is Admin+ and needs a full-access API key.
+func RegisterDataSubjectRoutes(r *gin.RouterGroup, h *handler.DataSubjectHandler, g *rbacGuards) {
+	if h == nil {
+		return
+	}
+	subjects := g.apiKeyGroup(r.Group("/data-subjects", g.Admin()), apiKeyFullAccess())
+	{
+		subjects.GET("/:user_id/export", h.Export)
+		subjects.POST("/:user_id/erase", h.Erase)
+	}
+}
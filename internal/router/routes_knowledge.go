@@ -64,7 +64,11 @@ func RegisterChunkRoutes(r *gin.RouterGroup, handler *handler.ChunkHandler, g *r
 // reuse OwnedKBOrAdmin because the URL :id is the KB id directly.
 // Cross-:id batch operations stay Contributor-gated — they don't have
 // a single owning KB to check against.
-func RegisterKnowledgeRoutes(r *gin.RouterGroup, handler *handler.KnowledgeHandler, g *rbacGuards) {
+func RegisterKnowledgeRoutes(
+	r *gin.RouterGroup,
+	handler *handler.KnowledgeHandler,
+	g *rbacGuards,
+) {
 	// 知识库下的知识路由组（URL :id is the KB id）。Scoped API key 需要
 	// ingest 能力才能写内容，且仍受 KB 范围限制；清空 KB 只允许 full-access key。
 	kb := g.apiKeyGroup(r.Group("/knowledge-bases/:id/knowledge"), apiKeyIngest(apiKeyFullAccess()))
@@ -103,11 +107,17 @@ func RegisterKnowledgeRoutes(r *gin.RouterGroup, handler *handler.KnowledgeHandl
 		kRead.GET("/:id", g.Viewer(), g.KBAccessReadFromKnowledgeIDParam("id"), handler.GetKnowledge)
 		kRead.GET("/:id/stages", g.Viewer(), g.KBAccessReadFromKnowledgeIDParam("id"), handler.GetKnowledgeSpans)
 		kRead.GET("/:id/spans", g.Viewer(), g.KBAccessReadFromKnowledgeIDParam("id"), handler.GetKnowledgeSpans)
+		kRead.GET("/:id/versions", g.Viewer(), g.KBAccessReadFromKnowledgeIDParam("id"), handler.ListKnowledgeVersions)
+		kRead.GET("/:id/versions/diff", g.Viewer(), g.KBAccessReadFromKnowledgeIDParam("id"), handler.DiffKnowledgeVersions)
+		kRead.GET("/:id/versions/:version", g.Viewer(), g.KBAccessReadFromKnowledgeIDParam("id"), handler.GetKnowledgeVersion)
 		k.DELETE("/:id", g.OwnedKnowledgeKBOrAdmin(), g.KBAccessWriteFromKnowledgeIDParam("id"), handler.DeleteKnowledge)
 		k.PUT("/:id", g.OwnedKnowledgeKBOrAdmin(), g.KBAccessWriteFromKnowledgeIDParam("id"), handler.UpdateKnowledge)
 		k.POST("/:id/regenerate-summary", g.OwnedKnowledgeKBOrAdmin(), g.KBAccessWriteFromKnowledgeIDParam("id"), handler.RegenerateKnowledgeSummary)
 		k.PUT("/manual/:id", g.OwnedKnowledgeKBOrAdmin(), g.KBAccessWriteFromKnowledgeIDParam("id"), handler.UpdateManualKnowledge)
 		k.POST("/:id/reparse", g.OwnedKnowledgeKBOrAdmin(), g.KBAccessWriteFromKnowledgeIDParam("id"), handler.ReparseKnowledge)
+		k.POST("/:id/versions/:version/restore", g.OwnedKnowledgeKBOrAdmin(), g.KBAccessWriteFromKnowledgeIDParam("id"), handler.RestoreKnowledgeVersion)
+		k.PUT("/:id/freshness", g.OwnedKnowledgeKBOrAdmin(), g.KBAccessWriteFromKnowledgeIDParam("id"), handler.UpdateKnowledgeFreshness)
+		k.POST("/:id/review", g.OwnedKnowledgeKBOrAdmin(), g.KBAccessWriteFromKnowledgeIDParam("id"), handler.ReviewKnowledge)
 		k.POST("/:id/cancel-parse", g.OwnedKnowledgeKBOrAdmin(), g.KBAccessWriteFromKnowledgeIDParam("id"), handler.CancelKnowledgeParse)
 		// Downloading exposes the original source file, so it has a stricter
 		// boundary than viewing parsed content or previewing it: tenant Viewers
@@ -133,6 +143,35 @@ func RegisterKnowledgeRoutes(r *gin.RouterGroup, handler *handler.KnowledgeHandl
 	}
 }
 
+// RegisterKnowledgeACLRoutes registers document-level access lists and the
+// user groups they grant to.
+//
+// An access list names who may read a document, so both reading and editing
+// it are management operations: they share the gate of the other per-document
+// writes (KB creator or Admin). The KB guard also applies the list itself, so
+// a caller can never see or change the list of a document they cannot open.
+// Groups belong to the workspace and are curated by Admins. API keys must be
+// full-access for all of it.
+func RegisterKnowledgeACLRoutes(r *gin.RouterGroup, aclHandler *handler.KnowledgeACLHandler, g *rbacGuards) {
+	if aclHandler == nil {
+		return
+	}
+	k := g.apiKeyGroup(r.Group("/knowledge"), apiKeyFullAccess())
+	{
+		k.GET("/:id/acl", g.OwnedKnowledgeKBOrAdmin(), g.KBAccessReadFromKnowledgeIDParam("id"), aclHandler.GetKnowledgeACL)
+		k.PUT("/:id/acl", g.OwnedKnowledgeKBOrAdmin(), g.KBAccessWriteFromKnowledgeIDParam("id"), aclHandler.SetKnowledgeACL)
+	}
+
+	groups := g.apiKeyGroup(r.Group("/user-groups", g.Admin()), apiKeyFullAccess())
+	{
+		groups.GET("", aclHandler.ListUserGroups)
+		groups.POST("", aclHandler.CreateUserGroup)
+		groups.GET("/:id", aclHandler.GetUserGroup)
+		groups.PUT("/:id", aclHandler.UpdateUserGroup)
+		groups.DELETE("/:id", aclHandler.DeleteUserGroup)
+	}
+}
+
 // RegisterFAQRoutes 注册 FAQ 相关路由
 //
 // FAQ entries are KB content: reads are Viewer+, all mutations
@@ -242,6 +281,10 @@ func RegisterKnowledgeBaseRoutes(r *gin.RouterGroup, handler *handler.KnowledgeB
 			GET("/copy/progress/:task_id", g.Viewer(), handler.GetKBCloneProgress)
 		// 获取可移动目标知识库列表 — Viewer+ 且对 KB 有 read 权限
 		kb.GET("/:id/move-targets", g.Viewer(), g.KBAccessRead("id"), handler.ListMoveTargets)
+		// 获取知识库重复内容报告 — Viewer+ 且对 KB 有 read 权限
+		kb.GET("/:id/duplicates", g.Viewer(), g.KBAccessRead("id"), handler.GetDuplicateReport)
+		// 获取知识库过期与待复核内容 — Viewer+ 且对 KB 有 read 权限
+		kb.GET("/:id/stale", g.Viewer(), g.KBAccessRead("id"), handler.GetStaleKnowledge)
 	}
 }
 
@@ -256,6 +299,45 @@ func RegisterKnowledgeBaseActivityRoutes(r *gin.RouterGroup, auditHandler *handl
 		g.OwnedKBOrAdmin(), g.KBAccessRead("id"), auditHandler.ListKnowledgeBaseActivity)
 }
 
+// RegisterGraphCommunityRoutes exposes the community hierarchy that graph
+// global search answers from. Reading reports is a retrieve operation;
+// queueing a rebuild spends the KB's model budget, so it needs write access.
+func RegisterGraphCommunityRoutes(r *gin.RouterGroup, communityHandler *handler.GraphCommunityHandler, g *rbacGuards) {
+	if communityHandler == nil {
+		return
+	}
+	communities := g.apiKeyGroup(r.Group("/knowledge-bases/:id/graph/communities"), apiKeyRetrieve(apiKeyFullAccess()))
+	{
+		communities.GET("", g.Viewer(), g.KBAccessRead("id"), communityHandler.ListCommunities)
+		communities.GET("/:community_id", g.Viewer(), g.KBAccessRead("id"), communityHandler.GetCommunity)
+		communities.With(apiKeyIngest(apiKeyFullAccess())).
+			POST("/rebuild", g.Contributor(), g.KBAccessWrite("id"), communityHandler.RebuildCommunities)
+	}
+}
+
+// RegisterGraphEntityRoutes exposes entity resolution. Listing entities and
+// candidates is a retrieve operation; every decision rewrites the graph, and
+// a resolution pass spends the KB's embedding budget, so both need write
+// access.
+func RegisterGraphEntityRoutes(r *gin.RouterGroup, entityHandler *handler.GraphEntityHandler, g *rbacGuards) {
+	if entityHandler == nil {
+		return
+	}
+	entities := g.apiKeyGroup(r.Group("/knowledge-bases/:id/graph/entities"), apiKeyRetrieve(apiKeyFullAccess()))
+	entityWrites := entities.With(apiKeyIngest(apiKeyFullAccess()))
+	{
+		entities.GET("", g.Viewer(), g.KBAccessRead("id"), entityHandler.ListEntities)
+		entities.GET("/merge-candidates", g.Viewer(), g.KBAccessRead("id"), entityHandler.ListMergeCandidates)
+		entityWrites.POST("/resolve", g.Contributor(), g.KBAccessWrite("id"), entityHandler.ResolveEntities)
+		entityWrites.POST("/merge-candidates/:candidate_id/accept",
+			g.Contributor(), g.KBAccessWrite("id"), entityHandler.AcceptMergeCandidate)
+		entityWrites.POST("/merge-candidates/:candidate_id/reject",
+			g.Contributor(), g.KBAccessWrite("id"), entityHandler.RejectMergeCandidate)
+		entityWrites.POST("/merge", g.Contributor(), g.KBAccessWrite("id"), entityHandler.MergeEntities)
+		entityWrites.POST("/split", g.Contributor(), g.KBAccessWrite("id"), entityHandler.SplitEntity)
+	}
+}
+
 // RegisterKnowledgeTagRoutes 注册知识库标签相关路由。
 //
 // Tags are KB metadata: Viewer reads, Contributor writes. Per-KB
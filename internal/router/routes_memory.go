@@ -6,14 +6,15 @@ import (
 	"github.com/Tencent/WeKnora/internal/handler"
 )
 
-// RegisterMemoryRoutes registers the personal long-term memory endpoints.
+// RegisterMemoryRoutes registers the personal long-term memory endpoints and
+// the admin curation endpoints for shared memory.
 //
-// There is no admin surface here and no subject parameter anywhere in the
-// paths: the memory space is derived from the caller's principal, so these
-// routes can only ever read or write the caller's own memories. That is why
-// Viewer is the only role gate they need. An API key must be full-access,
-// since a memory space belongs to a person and a scoped integration key has
-// no business inheriting one.
+// There is no subject parameter anywhere in the personal paths: the memory
+// space is derived from the caller's principal, so those routes can only ever
+// read or write the caller's own memories. That is why Viewer is the only role
+// gate they need. An API key must be full-access, since a memory space
+// belongs to a person and a scoped integration key has no business inheriting
+// one.
 func RegisterMemoryRoutes(r *gin.RouterGroup, memoryHandler *handler.MemoryHandler, g *rbacGuards) {
 	if memoryHandler == nil {
 		return
@@ -29,6 +30,7 @@ func RegisterMemoryRoutes(r *gin.RouterGroup, memoryHandler *handler.MemoryHandl
 		memoryGroup.DELETE("/items/:id", memoryHandler.DeleteItem)
 		memoryGroup.POST("/items/:id/confirm", memoryHandler.ConfirmItem)
 		memoryGroup.POST("/items/:id/reject", memoryHandler.RejectItem)
+		memoryGroup.POST("/items/:id/share", memoryHandler.ShareItem)
 		memoryGroup.GET("/topics", memoryHandler.ListTopics)
 		memoryGroup.DELETE("/topics/:id", memoryHandler.DeleteTopic)
 		memoryGroup.POST("/topics/:id/promote", memoryHandler.PromoteTopic)
@@ -37,4 +39,17 @@ func RegisterMemoryRoutes(r *gin.RouterGroup, memoryHandler *handler.MemoryHandl
 		memoryGroup.GET("/export", memoryHandler.Export)
 		memoryGroup.POST("/consolidate", memoryHandler.Consolidate)
 	}
+
+	// Shared memory is the one admin surface. Its spaces belong to the
+	// workspace rather than to a person, so curating them is gated on Admin;
+	// members reach them only by proposing through /items/:id/share.
+	sharedGroup := g.apiKeyGroup(r.Group("/memory/shared", g.Admin()), apiKeyFullAccess())
+	{
+		sharedGroup.GET("/items", memoryHandler.ListSharedItems)
+		sharedGroup.POST("/items", memoryHandler.CreateSharedItem)
+		sharedGroup.PUT("/items/:id", memoryHandler.UpdateSharedItem)
+		sharedGroup.DELETE("/items/:id", memoryHandler.DeleteSharedItem)
+		sharedGroup.POST("/items/:id/approve", memoryHandler.ApproveSharedItem)
+		sharedGroup.POST("/items/:id/reject", memoryHandler.RejectSharedItem)
+	}
 }
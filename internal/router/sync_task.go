@@ -19,11 +19,16 @@ import (
 type SyncTaskExecutor struct {
 	mu       sync.RWMutex
 	handlers map[string]func(context.Context, *asynq.Task) error
+	// waiting holds TaskIDs of delayed tasks that have not started yet, so
+	// debounced callers (TaskID + ProcessIn) coalesce the way they do on
+	// asynq instead of running once per enqueue.
+	waiting map[string]struct{}
 }
 
 func NewSyncTaskExecutor() *SyncTaskExecutor {
 	return &SyncTaskExecutor{
 		handlers: make(map[string]func(context.Context, *asynq.Task) error),
+		waiting:  make(map[string]struct{}),
 	}
 }
 
@@ -36,7 +41,7 @@ func (e *SyncTaskExecutor) RegisterHandler(pattern string, handler func(context.
 
 // Enqueue satisfies interfaces.TaskEnqueuer.
 // Instead of queuing to Redis, it dispatches the task to a goroutine.
-// Supports ProcessIn (delay) and MaxRetry options for parity with asynq.
+// Supports ProcessIn (delay), MaxRetry and TaskID options for parity with asynq.
 func (e *SyncTaskExecutor) Enqueue(task *asynq.Task, opts ...asynq.Option) (*asynq.TaskInfo, error) {
 	e.mu.RLock()
 	handler, ok := e.handlers[task.Type()]
@@ -47,10 +52,15 @@ func (e *SyncTaskExecutor) Enqueue(task *asynq.Task, opts ...asynq.Option) (*asy
 	}
 
 	var delay time.Duration
+	var uniqueID string
 	maxRetry := 25 // asynq default
 	maxRetrySet := false
 	for _, opt := range opts {
 		switch opt.Type() {
+		case asynq.TaskIDOpt:
+			if id, ok := opt.Value().(string); ok {
+				uniqueID = id
+			}
 		case asynq.ProcessInOpt:
 			if d, ok := opt.Value().(time.Duration); ok {
 				delay = d
@@ -68,6 +78,18 @@ func (e *SyncTaskExecutor) Enqueue(task *asynq.Task, opts ...asynq.Option) (*asy
 		maxRetry = 0
 	}
 
+	if uniqueID != "" && delay > 0 {
+		e.mu.Lock()
+		if _, exists := e.waiting[uniqueID]; exists {
+			e.mu.Unlock()
+			return nil, asynq.ErrTaskIDConflict
+		}
+		e.waiting[uniqueID] = struct{}{}
+		e.mu.Unlock()
+	} else {
+		uniqueID = ""
+	}
+
 	taskID := uuid.New().String()
 	info := &asynq.TaskInfo{
 		ID:    taskID,
@@ -79,6 +101,11 @@ func (e *SyncTaskExecutor) Enqueue(task *asynq.Task, opts ...asynq.Option) (*asy
 		if delay > 0 {
 			time.Sleep(delay)
 		}
+		if uniqueID != "" {
+			e.mu.Lock()
+			delete(e.waiting, uniqueID)
+			e.mu.Unlock()
+		}
 
 		// Tag as a background worker execution so the per-model concurrency
 		// governor throttles Lite-mode ingestion/enrichment LLM calls, mirroring
@@ -131,6 +158,8 @@ type SyncTaskParams struct {
 	WikiIngest           interfaces.TaskHandler `name:"wikiIngest"`
 	TemporaryDocument    interfaces.TemporaryDocumentService
 	MemoryService        interfaces.MemoryService
+	GraphCommunity       interfaces.GraphCommunityService
+	GraphEntity          interfaces.GraphEntityService
 }
 
 // RegisterSyncHandlers registers all task handlers on the SyncTaskExecutor.
@@ -157,5 +186,7 @@ func RegisterSyncHandlers(params SyncTaskParams) {
 	params.Executor.RegisterHandler(types.TypeWikiIngest, params.WikiIngest.Handle)
 	params.Executor.RegisterHandler(types.TypeWikiFinalize, params.WikiIngest.Handle)
 	params.Executor.RegisterHandler(types.TypeMemoryExtract, params.MemoryService.Handle)
+	params.Executor.RegisterHandler(types.TypeGraphCommunity, params.GraphCommunity.Handle)
+	params.Executor.RegisterHandler(types.TypeGraphEntityResolution, params.GraphEntity.Handle)
 	logger.Infof(context.Background(), "[SyncTask] All task handlers registered (Lite mode, no Redis)")
 }
@@ -36,3 +36,36 @@ func TestSyncTaskExecutorInjectsRetryMetadata(t *testing.T) {
 		t.Fatal("timed out waiting for sync task")
 	}
 }
+
+func TestSyncTaskExecutorCoalescesDelayedTaskID(t *testing.T) {
+	executor := NewSyncTaskExecutor()
+	runs := make(chan struct{}, 4)
+	executor.RegisterHandler("test:debounce", func(context.Context, *asynq.Task) error {
+		runs <- struct{}{}
+		return nil
+	})
+
+	opts := []asynq.Option{asynq.TaskID("debounce-1"), asynq.ProcessIn(50 * time.Millisecond)}
+	if _, err := executor.Enqueue(asynq.NewTask("test:debounce", nil), opts...); err != nil {
+		t.Fatalf("first enqueue: %v", err)
+	}
+	if _, err := executor.Enqueue(asynq.NewTask("test:debounce", nil), opts...); err != asynq.ErrTaskIDConflict {
+		t.Fatalf("second enqueue err = %v, want ErrTaskIDConflict", err)
+	}
+
+	select {
+	case <-runs:
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for debounced task")
+	}
+	select {
+	case <-runs:
+		t.Fatal("coalesced task ran twice")
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	// Once the waiting task has started, the ID is free again.
+	if _, err := executor.Enqueue(asynq.NewTask("test:debounce", nil), opts...); err != nil {
+		t.Fatalf("enqueue after start: %v", err)
+	}
+}
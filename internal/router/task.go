@@ -13,8 +13,10 @@ import (
 	"github.com/Tencent/WeKnora/internal/application/service"
 	"github.com/Tencent/WeKnora/internal/common"
 	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/metrics"
 	"github.com/Tencent/WeKnora/internal/middleware/asynqdl"
 	"github.com/Tencent/WeKnora/internal/tracing/langfuse"
+	"github.com/Tencent/WeKnora/internal/tracing/otlp"
 	"github.com/Tencent/WeKnora/internal/types"
 	"github.com/Tencent/WeKnora/internal/types/interfaces"
 	"github.com/hibiken/asynq"
@@ -46,6 +48,8 @@ type AsynqTaskParams struct {
 	WikiIngest           interfaces.TaskHandler `name:"wikiIngest"`
 	TemporaryDocument    interfaces.TemporaryDocumentService
 	MemoryService        interfaces.MemoryService
+	GraphCommunity       interfaces.GraphCommunityService
+	GraphEntity          interfaces.GraphEntityService
 	DeadLetterRepo       interfaces.TaskDeadLetterRepository
 	SpanTracker          service.SpanTracker
 }
@@ -251,6 +255,11 @@ func RunAsynqServer(params AsynqTaskParams) *asynq.ServeMux {
 	// downstream handlers (and their model calls) inherit the flag.
 	mux.Use(backgroundTaskMiddleware())
 
+	// OTLP consumer span per task, continuing the enqueuer's trace. Installed
+	// before the Langfuse middleware so Langfuse's worker spans join the
+	// same trace when both are enabled.
+	mux.Use(otlp.AsynqMiddleware())
+
 	// Install Langfuse middleware BEFORE handler registration so every task
 	// type is automatically wrapped. When Langfuse is disabled the middleware
 	// is a pass-through; when enabled it resumes the upstream HTTP trace (if
@@ -259,6 +268,11 @@ func RunAsynqServer(params AsynqTaskParams) *asynq.ServeMux {
 	// chat / rerank / ASR) nest correctly in the Langfuse UI.
 	mux.Use(langfuse.AsynqMiddleware())
 
+	// Prometheus task metrics: per-type outcome and duration, plus retried vs
+	// dead-lettered failures (same final-attempt rule as the dead-letter
+	// middleware above).
+	mux.Use(metrics.AsynqMiddleware())
+
 	// Register extract handlers - router will dispatch to appropriate handler
 	mux.HandleFunc(types.TypeChunkExtract, params.ChunkExtractor.Handle)
 	mux.HandleFunc(types.TypeDataTableSummary, params.DataTableSummary.Handle)
@@ -316,6 +330,12 @@ func RunAsynqServer(params AsynqTaskParams) *asynq.ServeMux {
 	// Register long-term memory distillation handler
 	mux.HandleFunc(types.TypeMemoryExtract, params.MemoryService.Handle)
 
+	// Register the debounced per-KB graph community rebuild handler
+	mux.HandleFunc(types.TypeGraphCommunity, params.GraphCommunity.Handle)
+
+	// Register the graph entity resolution handler
+	mux.HandleFunc(types.TypeGraphEntityResolution, params.GraphEntity.Handle)
+
 	// Run the same mux on every pool. Shared and dedicated servers intentionally
 	// overlap, but Redis dequeue is atomic, so each task still executes once.
 	runPool := func(name string, srv *asynq.Server) {
@@ -66,7 +66,17 @@ func (m *DefaultManager) initializeSandbox(ctx context.Context) error {
 		m.sandbox = NewLocalSandbox(m.config)
 		return nil
 
-	case SandboxTypeCube, SandboxTypeE2B:
+	case SandboxTypeWasm:
+		// No fallback: the wasm backend is chosen where nothing else can run,
+		// and falling back to local would hand scripts the host.
+		wasmSandbox := NewWasmSandbox(m.config)
+		if !wasmSandbox.IsAvailable(ctx) {
+			return fmt.Errorf("wasm sandbox: no interpreter runtimes found in %s", wasmSandbox.Source())
+		}
+		m.sandbox = wasmSandbox
+		return nil
+
+	case SandboxTypeCube, SandboxTypeE2B, SandboxTypeNamespace:
 		// Session-scoped remote backends are only reachable through
 		// SessionBoundManager, which owns the authoritative binding.
 		// DefaultManager exposes stateless semantics that cannot preserve
@@ -240,9 +250,9 @@ func (s *disabledSandbox) IsAvailable(ctx context.Context) bool {
 // NewManagerFromType creates a sandbox manager with the specified type.
 // dockerImage is optional; if empty, the default image is used.
 //
-// Session-scoped remote backends (Cube, E2B) route to SessionBoundManager,
-// which keeps one persistent MicroVM per SessionID; stateless backends
-// (Docker, Local, Disabled) route to DefaultManager. Both satisfy Manager.
+// Session-scoped backends (Cube, E2B, Namespace) route to SessionBoundManager,
+// which keeps one persistent sandbox per SessionID; stateless backends
+// (Docker, Local, Wasm, Disabled) route to DefaultManager. Both satisfy Manager.
 func NewManagerFromType(sandboxType string, fallbackEnabled bool, dockerImage string) (Manager, error) {
 	var sType SandboxType
 	switch sandboxType {
@@ -254,6 +264,10 @@ func NewManagerFromType(sandboxType string, fallbackEnabled bool, dockerImage st
 		sType = SandboxTypeCube
 	case "e2b":
 		sType = SandboxTypeE2B
+	case "namespace":
+		sType = SandboxTypeNamespace
+	case "wasm":
+		sType = SandboxTypeWasm
 	case "disabled", "":
 		sType = SandboxTypeDisabled
 	default:
@@ -290,6 +304,17 @@ func NewManagerFromType(sandboxType string, fallbackEnabled bool, dockerImage st
 			Store:   NewMemorySessionSandboxBindingStore(),
 			Checker: PermissiveSessionExistenceChecker{},
 		})
+	case SandboxTypeNamespace:
+		client, err := NewNamespaceRemoteClient(config)
+		if err != nil {
+			return nil, fmt.Errorf("sandbox: build namespace client: %w", err)
+		}
+		return NewSessionBoundManager(SessionBoundManagerConfig{
+			Config:  config,
+			Client:  client,
+			Store:   NewMemorySessionSandboxBindingStore(),
+			Checker: PermissiveSessionExistenceChecker{},
+		})
 	}
 	return NewManager(config)
 }
@@ -0,0 +1,776 @@
+// Package sandbox: Linux namespace backend.
+//
+// NamespaceRemoteClient runs scripts on the WeKnora host itself, without a
+// Docker daemon or a remote control plane. Every execution is a fresh process
+// tree in unprivileged user, mount, PID, IPC, UTS, cgroup and (unless the
+// config allows network) network namespaces. Inside it sees:
+//
+//   - a read-only root assembled from the configured rootfs (the host's own /
+//     by default, or an unpacked image such as the weknora-sandbox image);
+//   - a private tmpfs on /tmp and a minimal /dev;
+//   - the sandbox's workspace bind-mounted read-write on /workspace.
+//
+// All capabilities are dropped, no_new_privs is set and a seccomp filter
+// refuses mount, namespace, ptrace, kernel-module, bpf and similar syscalls
+// before the script is exec'd. When NamespaceCgroupRoot names a delegated
+// cgroup v2 directory, each sandbox also gets its own child cgroup with
+// memory, CPU and pids limits.
+//
+// The backend implements RemoteSandboxClient so that SessionBoundManager gives
+// it the same session semantics as Cube: a "sandbox" is a workspace directory
+// under NamespaceStateDir that outlives individual executions, so files and
+// installed packages persist for the session. Unlike a MicroVM, background
+// processes do not: each execution's PID namespace dies with its command.
+//
+// Sandboxes are host-local. In a multi-instance deployment a session that
+// lands on another instance finds its sandbox missing (RemoteErrorKindNotFound)
+// and the lifecycle coordinator provisions a fresh one there. Idle workspaces
+// are reclaimed by the client itself after NamespaceSandboxTTL, because here
+// WeKnora is the provider.
+package sandbox
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/sandbox/nsinit"
+)
+
+const (
+	// DefaultNamespaceStateDir holds namespace sandbox workspaces. /dev/shm is
+	// tmpfs on virtually every Linux host, so workspaces stay in memory and
+	// vanish with the host.
+	DefaultNamespaceStateDir = "/dev/shm/weknora-sandbox"
+
+	// DefaultNamespaceRootfs exposes the host's own system directories
+	// read-only. Point NamespaceRootfs at an unpacked image to give scripts a
+	// curated toolchain instead.
+	DefaultNamespaceRootfs = "/"
+
+	// namespaceTmpSizeMB sizes the per-execution /tmp, matching the Docker
+	// backend's --tmpfs /tmp:size=64m.
+	namespaceTmpSizeMB = 64
+
+	namespaceMetaFile     = "sandbox.json"
+	namespaceWorkspaceDir = "workspace"
+	// namespaceMountPointDir is the empty directory each execution mounts its
+	// private root on. The mount only exists inside that execution's mount
+	// namespace, so one directory serves every sandbox.
+	namespaceMountPointDir = ".mnt"
+	namespaceIDPrefix      = "ns-"
+	namespaceHostname      = "weknora-sandbox"
+)
+
+// namespaceIDPattern guards every ID that reaches the filesystem: sandbox IDs
+// come back from bindings and API callers and become path components.
+var namespaceIDPattern = regexp.MustCompile(`^ns-[0-9a-f]{24}$`)
+
+// namespaceBaseEnv is the environment every execution starts from.
+var namespaceBaseEnv = map[string]string{
+	"PATH":   "/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin",
+	"HOME":   SessionWorkspaceRoot,
+	"TMPDIR": "/tmp",
+	"LANG":   "C.UTF-8",
+}
+
+// NamespaceHostConfig locates the host resources the namespace backend uses.
+// These are deployment decisions — which directory holds workspaces, which
+// cgroup WeKnora was delegated, which tree scripts see as / — and must never
+// be chosen by a workspace admin, so they are set once at startup rather than
+// stored in a TenantSandboxConfig.
+type NamespaceHostConfig struct {
+	// StateDir holds one directory per sandbox. Empty uses
+	// DefaultNamespaceStateDir.
+	StateDir string
+
+	// Rootfs is the directory whose system subdirectories (usr, bin, lib,
+	// etc, ...) are mounted read-only as the sandbox root. Empty uses
+	// DefaultNamespaceRootfs.
+	Rootfs string
+
+	// CgroupRoot is a cgroup v2 directory WeKnora may create child cgroups
+	// in. Empty disables memory, CPU and pids limits.
+	CgroupRoot string
+
+	// InitPath is the weknora-sandbox-init helper. Empty looks next to the
+	// WeKnora executable, then on PATH.
+	InitPath string
+}
+
+var namespaceHost = NamespaceHostConfig{
+	StateDir: DefaultNamespaceStateDir,
+	Rootfs:   DefaultNamespaceRootfs,
+}
+
+// SetNamespaceHostConfig installs the deployment's namespace host settings.
+// Called once by the container before any config is built; DefaultConfig
+// copies the values from then on.
+func SetNamespaceHostConfig(cfg NamespaceHostConfig) {
+	if strings.TrimSpace(cfg.StateDir) == "" {
+		cfg.StateDir = DefaultNamespaceStateDir
+	}
+	if strings.TrimSpace(cfg.Rootfs) == "" {
+		cfg.Rootfs = DefaultNamespaceRootfs
+	}
+	namespaceHost = cfg
+}
+
+// namespaceSandboxMeta is persisted next to each workspace so Connect, List
+// and orphan reconciliation survive a WeKnora restart.
+type namespaceSandboxMeta struct {
+	ID           string            `json:"id"`
+	TemplateID   string            `json:"template_id"`
+	Metadata     map[string]string `json:"metadata,omitempty"`
+	EnvVars      map[string]string `json:"env_vars,omitempty"`
+	AllowNetwork bool              `json:"allow_network"`
+	TTLSeconds   int64             `json:"ttl_seconds"`
+	CreatedAt    time.Time         `json:"created_at"`
+}
+
+// namespaceHandle is the RemoteSandboxHandle the namespace client issues.
+type namespaceHandle struct {
+	id       string
+	metadata map[string]string
+}
+
+func (h *namespaceHandle) ID() string                  { return h.id }
+func (h *namespaceHandle) Provider() RemoteProvider    { return SandboxTypeNamespace }
+func (h *namespaceHandle) Metadata() map[string]string { return cloneMetadata(h.metadata) }
+
+// namespaceLimits are the cgroup limits applied to one sandbox.
+type namespaceLimits struct {
+	MemoryBytes int64
+	CPUCores    float64
+	Pids        int
+}
+
+// NamespaceRemoteClient implements RemoteSandboxClient with Linux namespaces
+// on the local host. It is safe for concurrent use: all state lives on disk
+// and every operation re-reads it.
+type NamespaceRemoteClient struct {
+	initPath     string
+	stateDir     string
+	rootfs       string
+	cgroupRoot   string
+	allowNetwork bool
+	ttl          time.Duration
+	limits       namespaceLimits
+	now          func() time.Time
+}
+
+// NewNamespaceRemoteClient builds a client from cfg. Only host paths are
+// validated here; whether this kernel permits unprivileged namespaces is
+// Health's question.
+func NewNamespaceRemoteClient(cfg *Config) (*NamespaceRemoteClient, error) {
+	if cfg == nil {
+		return nil, errors.New("sandbox: namespace client requires a config")
+	}
+	effective := *cfg
+	applyNamespaceRuntimeDefaults(&effective)
+	for name, dir := range map[string]string{
+		"state dir":   effective.NamespaceStateDir,
+		"rootfs":      effective.NamespaceRootfs,
+		"cgroup root": effective.NamespaceCgroupRoot,
+		"init path":   effective.NamespaceInitPath,
+	} {
+		if dir != "" && !filepath.IsAbs(dir) {
+			return nil, fmt.Errorf("sandbox: namespace %s must be an absolute path: %q", name, dir)
+		}
+	}
+	initPath := effective.NamespaceInitPath
+	if initPath == "" {
+		initPath = findNamespaceInitHelper()
+	}
+	return &NamespaceRemoteClient{
+		initPath:     initPath,
+		stateDir:     filepath.Clean(effective.NamespaceStateDir),
+		rootfs:       filepath.Clean(effective.NamespaceRootfs),
+		cgroupRoot:   effective.NamespaceCgroupRoot,
+		allowNetwork: effective.NamespaceAllowNetwork,
+		ttl:          effective.NamespaceSandboxTTL,
+		limits: namespaceLimits{
+			MemoryBytes: effective.MaxMemory,
+			CPUCores:    effective.MaxCPU,
+			Pids:        effective.NamespacePidsLimit,
+		},
+		now: time.Now,
+	}, nil
+}
+
+// Provider identifies the backend.
+func (c *NamespaceRemoteClient) Provider() RemoteProvider { return SandboxTypeNamespace }
+
+// Capabilities reports what the on-disk state supports. There is nothing to
+// pause and no provider-side timeout to refresh.
+func (c *NamespaceRemoteClient) Capabilities() RemoteSandboxCapabilities {
+	return RemoteSandboxCapabilities{
+		SupportsReconnect:             true,
+		SupportsMetadata:              true,
+		SupportsListSandboxes:         true,
+		SupportsFilesystemEnumeration: true,
+	}
+}
+
+// Health verifies the state directory and rootfs, then runs one throwaway
+// process through the full namespace setup. A kernel or container runtime
+// that forbids unprivileged user namespaces fails here rather than at the
+// first skill execution.
+func (c *NamespaceRemoteClient) Health(ctx context.Context) error {
+	if err := namespaceSupported(); err != nil {
+		return c.remoteErr("Health", RemoteErrorKindUnsupported, "", err)
+	}
+	if c.initPath == "" {
+		return c.remoteErr("Health", RemoteErrorKindUnavailable,
+			nsinit.HelperName+" not found next to the WeKnora binary or on PATH", nil)
+	}
+	if info, err := os.Stat(c.rootfs); err != nil || !info.IsDir() {
+		return c.remoteErr("Health", RemoteErrorKindUnavailable, "rootfs "+c.rootfs+" is not a directory", err)
+	}
+	if err := c.ensureStateDir(); err != nil {
+		return c.remoteErr("Health", RemoteErrorKindUnavailable, "prepare state dir", err)
+	}
+	if c.cgroupRoot != "" {
+		if err := checkNamespaceCgroupRoot(c.cgroupRoot); err != nil {
+			return c.remoteErr("Health", RemoteErrorKindUnavailable, "cgroup root", err)
+		}
+	}
+	probeDir, err := os.MkdirTemp(c.stateDir, ".probe-")
+	if err != nil {
+		return c.remoteErr("Health", RemoteErrorKindUnavailable, "create probe workspace", err)
+	}
+	defer func() { _ = os.RemoveAll(probeDir) }()
+	if err := chownToSandboxUser(probeDir); err != nil {
+		return c.remoteErr("Health", RemoteErrorKindUnavailable, "prepare probe workspace", err)
+	}
+
+	probeCtx, cancel := context.WithTimeout(ctx, DefaultNamespaceProbeTimeout)
+	defer cancel()
+	result, err := c.run(probeCtx, namespaceRunRequest{
+		Workspace: probeDir,
+		Args:      []string{"/bin/sh", "-c", "exit 0"},
+		Env:       namespaceEnv(nil, nil),
+		WorkDir:   SessionWorkspaceRoot,
+		Network:   c.allowNetwork,
+	})
+	if err != nil {
+		return err
+	}
+	if result.ExitCode != 0 {
+		return c.remoteErr("Health", RemoteErrorKindUnavailable,
+			fmt.Sprintf("probe exited with %d: %s", result.ExitCode, strings.TrimSpace(result.Stderr)), nil)
+	}
+	return nil
+}
+
+// Create provisions a workspace and, when a cgroup root is configured, the
+// sandbox's cgroup. TemplateID must name the configured rootfs: it is what
+// the binding records as the sandbox's template, and a mismatch means the
+// request was built for a different host configuration.
+func (c *NamespaceRemoteClient) Create(ctx context.Context, req RemoteCreateRequest) (RemoteSandboxHandle, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if filepath.Clean(req.TemplateID) != c.rootfs {
+		return nil, c.remoteErr("Create", RemoteErrorKindInvalidRequest,
+			fmt.Sprintf("template %q does not match the configured rootfs %q", req.TemplateID, c.rootfs), nil)
+	}
+	if len(req.VolumeMounts) > 0 {
+		return nil, c.remoteErr("Create", RemoteErrorKindUnsupported, "volume mounts are not supported", nil)
+	}
+	if err := c.ensureStateDir(); err != nil {
+		return nil, c.remoteErr("Create", RemoteErrorKindUnavailable, "prepare state dir", err)
+	}
+	c.sweepExpired()
+
+	id, err := newNamespaceSandboxID()
+	if err != nil {
+		return nil, c.remoteErr("Create", RemoteErrorKindInternal, "generate sandbox id", err)
+	}
+	dir := c.sandboxDir(id)
+	workspace := filepath.Join(dir, namespaceWorkspaceDir)
+	if err := os.MkdirAll(workspace, 0o755); err != nil {
+		return nil, c.remoteErr("Create", RemoteErrorKindUnavailable, "create workspace", err)
+	}
+	// The execution's user must be able to traverse to its workspace, but
+	// not list other sandboxes.
+	if err := os.Chmod(dir, 0o711); err != nil {
+		c.removeSandboxDir(id)
+		return nil, c.remoteErr("Create", RemoteErrorKindUnavailable, "prepare sandbox dir", err)
+	}
+	if err := chownToSandboxUser(workspace); err != nil {
+		c.removeSandboxDir(id)
+		return nil, c.remoteErr("Create", RemoteErrorKindUnavailable, "prepare workspace", err)
+	}
+
+	allowNetwork := c.allowNetwork
+	if req.Network.AllowInternetAccess != nil {
+		allowNetwork = *req.Network.AllowInternetAccess
+	}
+	ttl := c.ttl
+	if req.Timeout.Mode == RemoteTimeoutExplicit && req.Timeout.Value > 0 {
+		ttl = req.Timeout.Value
+	}
+	meta := namespaceSandboxMeta{
+		ID:           id,
+		TemplateID:   c.rootfs,
+		Metadata:     cloneMetadata(req.Metadata),
+		EnvVars:      cloneMetadata(req.EnvVars),
+		AllowNetwork: allowNetwork,
+		TTLSeconds:   int64(ttl / time.Second),
+		CreatedAt:    c.now().UTC(),
+	}
+	if c.cgroupRoot != "" {
+		if err := createNamespaceCgroup(c.cgroupRoot, id, c.limits); err != nil {
+			c.removeSandboxDir(id)
+			return nil, c.remoteErr("Create", RemoteErrorKindUnavailable, "create cgroup", err)
+		}
+	}
+	if err := c.writeMeta(meta); err != nil {
+		c.destroy(id)
+		return nil, c.remoteErr("Create", RemoteErrorKindUnavailable, "write sandbox metadata", err)
+	}
+	return &namespaceHandle{id: id, metadata: meta.Metadata}, nil
+}
+
+// Connect re-attaches to a sandbox by ID and marks it as used.
+func (c *NamespaceRemoteClient) Connect(_ context.Context, sandboxID string) (RemoteSandboxHandle, error) {
+	meta, err := c.loadLive("Connect", sandboxID)
+	if err != nil {
+		return nil, err
+	}
+	c.touch(meta.ID)
+	return &namespaceHandle{id: meta.ID, metadata: meta.Metadata}, nil
+}
+
+// Get returns the sandbox summary. A namespace sandbox is running for as long
+// as its workspace exists.
+func (c *NamespaceRemoteClient) Get(_ context.Context, sandboxID string) (*RemoteSandboxSummary, error) {
+	meta, err := c.loadLive("Get", sandboxID)
+	if err != nil {
+		return nil, err
+	}
+	summary := c.summary(meta)
+	return &summary, nil
+}
+
+// List enumerates the sandboxes under the state directory. Expired ones are
+// reclaimed on the way.
+func (c *NamespaceRemoteClient) List(_ context.Context, filter RemoteListFilter) ([]RemoteSandboxSummary, error) {
+	if len(filter.States) > 0 && !containsRemoteState(filter.States, RemoteStateRunning) {
+		return nil, nil
+	}
+	entries, err := os.ReadDir(c.stateDir)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, c.remoteErr("List", RemoteErrorKindUnavailable, "read state dir", err)
+	}
+	var out []RemoteSandboxSummary
+	for _, entry := range entries {
+		if !entry.IsDir() || !namespaceIDPattern.MatchString(entry.Name()) {
+			continue
+		}
+		meta, err := c.loadLive("List", entry.Name())
+		if err != nil {
+			continue
+		}
+		if !metadataMatches(meta.Metadata, filter.Metadata) {
+			continue
+		}
+		out = append(out, c.summary(meta))
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].StartedAt.Before(out[j].StartedAt) })
+	return out, nil
+}
+
+// Delete removes the sandbox's cgroup (killing anything still running in it)
+// and its workspace.
+func (c *NamespaceRemoteClient) Delete(_ context.Context, sandboxID string) error {
+	if !namespaceIDPattern.MatchString(sandboxID) {
+		return c.remoteErr("Delete", RemoteErrorKindNotFound, "unknown sandbox "+sandboxID, nil)
+	}
+	if _, err := os.Stat(c.sandboxDir(sandboxID)); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return c.remoteErr("Delete", RemoteErrorKindNotFound, "unknown sandbox "+sandboxID, nil)
+		}
+		return c.remoteErr("Delete", RemoteErrorKindUnavailable, "stat sandbox", err)
+	}
+	if err := c.destroy(sandboxID); err != nil {
+		return c.remoteErr("Delete", RemoteErrorKindUnavailable, "remove sandbox", err)
+	}
+	return nil
+}
+
+// Exec runs one command in a fresh set of namespaces over the sandbox's
+// workspace. RemoteExecRequest.User is not honoured: the user namespace maps
+// a single unprivileged host ID, which the script sees as root without any
+// capabilities.
+func (c *NamespaceRemoteClient) Exec(ctx context.Context, handle RemoteSandboxHandle, req RemoteExecRequest) (*RemoteExecResult, error) {
+	if req.Shell && len(req.Args) > 0 {
+		return nil, c.remoteErr("Exec", RemoteErrorKindInvalidRequest, "shell commands take no separate args", nil)
+	}
+	if strings.TrimSpace(req.Command) == "" {
+		return nil, c.remoteErr("Exec", RemoteErrorKindInvalidRequest, "command is required", nil)
+	}
+	meta, err := c.handleMeta("Exec", handle)
+	if err != nil {
+		return nil, err
+	}
+	workDir := SessionWorkspaceRoot
+	if strings.TrimSpace(req.WorkDir) != "" {
+		clean, err := cleanSessionWorkDir(req.WorkDir)
+		if err != nil {
+			return nil, c.remoteErr("Exec", RemoteErrorKindInvalidRequest, "", err)
+		}
+		workDir = clean
+	}
+	args := append([]string{req.Command}, req.Args...)
+	if req.Shell {
+		args = []string{"/bin/sh", "-c", req.Command}
+	}
+	timeout := req.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	c.touch(meta.ID)
+
+	execCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	return c.run(execCtx, namespaceRunRequest{
+		Workspace: c.workspaceDir(meta.ID),
+		CgroupDir: c.cgroupDir(meta.ID),
+		Args:      args,
+		Env:       namespaceEnv(meta.EnvVars, req.Env),
+		WorkDir:   workDir,
+		Stdin:     req.Stdin,
+		Network:   meta.AllowNetwork,
+	})
+}
+
+// WriteFile writes content into the sandbox's workspace.
+func (c *NamespaceRemoteClient) WriteFile(_ context.Context, handle RemoteSandboxHandle, p string, content []byte) error {
+	meta, rel, err := c.resolvePath("WriteFile", handle, p)
+	if err != nil {
+		return err
+	}
+	return c.fsErr("WriteFile", p, workspaceWriteFile(c.workspaceDir(meta.ID), rel, content))
+}
+
+// ReadFile reads a regular file from the sandbox's workspace.
+func (c *NamespaceRemoteClient) ReadFile(_ context.Context, handle RemoteSandboxHandle, p string) ([]byte, error) {
+	meta, rel, err := c.resolvePath("ReadFile", handle, p)
+	if err != nil {
+		return nil, err
+	}
+	content, err := workspaceReadFile(c.workspaceDir(meta.ID), rel)
+	return content, c.fsErr("ReadFile", p, err)
+}
+
+// ListDir lists one directory of the sandbox's workspace.
+func (c *NamespaceRemoteClient) ListDir(_ context.Context, handle RemoteSandboxHandle, p string) ([]RemoteDirEntry, error) {
+	meta, rel, err := c.resolvePath("ListDir", handle, p)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := workspaceListDir(c.workspaceDir(meta.ID), rel)
+	if err != nil {
+		return nil, c.fsErr("ListDir", p, err)
+	}
+	base := path.Clean(p)
+	for i := range entries {
+		entries[i].Path = path.Join(base, entries[i].Name)
+	}
+	return entries, nil
+}
+
+// MakeDir creates a directory (and its parents) in the sandbox's workspace.
+func (c *NamespaceRemoteClient) MakeDir(_ context.Context, handle RemoteSandboxHandle, p string) error {
+	meta, rel, err := c.resolvePath("MakeDir", handle, p)
+	if err != nil {
+		return err
+	}
+	return c.fsErr("MakeDir", p, workspaceMkdirAll(c.workspaceDir(meta.ID), rel))
+}
+
+// Remove deletes a file or directory tree from the sandbox's workspace.
+func (c *NamespaceRemoteClient) Remove(_ context.Context, handle RemoteSandboxHandle, p string) error {
+	meta, rel, err := c.resolvePath("Remove", handle, p)
+	if err != nil {
+		return err
+	}
+	if rel == "." {
+		return c.remoteErr("Remove", RemoteErrorKindInvalidRequest, "refusing to remove the workspace root", nil)
+	}
+	return c.fsErr("Remove", p, workspaceRemoveAll(c.workspaceDir(meta.ID), rel))
+}
+
+// Stat describes one path in the sandbox's workspace.
+func (c *NamespaceRemoteClient) Stat(_ context.Context, handle RemoteSandboxHandle, p string) (*RemoteStatEntry, error) {
+	meta, rel, err := c.resolvePath("Stat", handle, p)
+	if err != nil {
+		return nil, err
+	}
+	entry, err := workspaceStat(c.workspaceDir(meta.ID), rel)
+	if err != nil {
+		return nil, c.fsErr("Stat", p, err)
+	}
+	entry.Path = path.Clean(p)
+	return entry, nil
+}
+
+// --- internal helpers --------------------------------------------------------
+
+// namespaceRunRequest is one execution handed to the platform runner.
+type namespaceRunRequest struct {
+	Workspace string
+	CgroupDir string
+	Args      []string
+	Env       []string
+	WorkDir   string
+	Stdin     string
+	Network   bool
+}
+
+func (c *NamespaceRemoteClient) sandboxDir(id string) string {
+	return filepath.Join(c.stateDir, id)
+}
+
+func (c *NamespaceRemoteClient) workspaceDir(id string) string {
+	return filepath.Join(c.stateDir, id, namespaceWorkspaceDir)
+}
+
+func (c *NamespaceRemoteClient) cgroupDir(id string) string {
+	if c.cgroupRoot == "" {
+		return ""
+	}
+	return namespaceCgroupPath(c.cgroupRoot, id)
+}
+
+func namespaceCgroupPath(root, id string) string {
+	return filepath.Join(root, "weknora-"+id)
+}
+
+// ensureStateDir creates the state and mount-point directories. Both must be
+// traversable by the sandbox's mapped user, which on a root-run host is not
+// the owner.
+func (c *NamespaceRemoteClient) ensureStateDir() error {
+	if err := os.MkdirAll(c.stateDir, 0o711); err != nil {
+		return err
+	}
+	if err := os.Chmod(c.stateDir, 0o711); err != nil {
+		return err
+	}
+	return os.MkdirAll(filepath.Join(c.stateDir, namespaceMountPointDir), 0o755)
+}
+
+func (c *NamespaceRemoteClient) metaPath(id string) string {
+	return filepath.Join(c.sandboxDir(id), namespaceMetaFile)
+}
+
+func (c *NamespaceRemoteClient) writeMeta(meta namespaceSandboxMeta) error {
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+	tmp := c.metaPath(meta.ID) + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, c.metaPath(meta.ID))
+}
+
+// loadLive reads a sandbox's metadata, reclaiming it first when it has been
+// idle for longer than its TTL. Idle time is the metadata file's mtime, which
+// touch advances on every Connect and Exec.
+func (c *NamespaceRemoteClient) loadLive(op, id string) (*namespaceSandboxMeta, error) {
+	if !namespaceIDPattern.MatchString(id) {
+		return nil, c.remoteErr(op, RemoteErrorKindNotFound, "unknown sandbox "+id, nil)
+	}
+	info, err := os.Stat(c.metaPath(id))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, c.remoteErr(op, RemoteErrorKindNotFound, "unknown sandbox "+id, nil)
+		}
+		return nil, c.remoteErr(op, RemoteErrorKindUnavailable, "stat sandbox", err)
+	}
+	data, err := os.ReadFile(c.metaPath(id))
+	if err != nil {
+		return nil, c.remoteErr(op, RemoteErrorKindUnavailable, "read sandbox metadata", err)
+	}
+	var meta namespaceSandboxMeta
+	if err := json.Unmarshal(data, &meta); err != nil || meta.ID != id {
+		return nil, c.remoteErr(op, RemoteErrorKindInternal, "corrupt sandbox metadata", err)
+	}
+	if meta.TTLSeconds > 0 && c.now().Sub(info.ModTime()) > time.Duration(meta.TTLSeconds)*time.Second {
+		_ = c.destroy(id)
+		return nil, c.remoteErr(op, RemoteErrorKindTerminal, "sandbox "+id+" expired", nil)
+	}
+	return &meta, nil
+}
+
+func (c *NamespaceRemoteClient) touch(id string) {
+	now := c.now()
+	_ = os.Chtimes(c.metaPath(id), now, now)
+}
+
+// sweepExpired reclaims every idle sandbox. It runs on Create so a host that
+// keeps serving sessions also keeps its state directory bounded.
+func (c *NamespaceRemoteClient) sweepExpired() {
+	entries, err := os.ReadDir(c.stateDir)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		if entry.IsDir() && namespaceIDPattern.MatchString(entry.Name()) {
+			_, _ = c.loadLive("Sweep", entry.Name())
+		}
+	}
+}
+
+func (c *NamespaceRemoteClient) destroy(id string) error {
+	var errs []error
+	if c.cgroupRoot != "" {
+		if err := removeNamespaceCgroup(c.cgroupRoot, id); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err := c.removeSandboxDir(id); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+func (c *NamespaceRemoteClient) removeSandboxDir(id string) error {
+	return os.RemoveAll(c.sandboxDir(id))
+}
+
+func (c *NamespaceRemoteClient) summary(meta *namespaceSandboxMeta) RemoteSandboxSummary {
+	return RemoteSandboxSummary{
+		ID:         meta.ID,
+		TemplateID: meta.TemplateID,
+		State:      RemoteStateRunning,
+		RawState:   "running",
+		Metadata:   cloneMetadata(meta.Metadata),
+		StartedAt:  meta.CreatedAt,
+	}
+}
+
+func (c *NamespaceRemoteClient) handleMeta(op string, handle RemoteSandboxHandle) (*namespaceSandboxMeta, error) {
+	if handle == nil || handle.Provider() != SandboxTypeNamespace {
+		return nil, c.remoteErr(op, RemoteErrorKindInvalidRequest, "handle was not issued by the namespace backend", nil)
+	}
+	return c.loadLive(op, handle.ID())
+}
+
+// resolvePath maps an in-sandbox path onto the workspace. Only /workspace is
+// backed by the host; everything else is the read-only rootfs or a per-exec
+// tmpfs that no longer exists once the command returns.
+func (c *NamespaceRemoteClient) resolvePath(op string, handle RemoteSandboxHandle, p string) (*namespaceSandboxMeta, string, error) {
+	meta, err := c.handleMeta(op, handle)
+	if err != nil {
+		return nil, "", err
+	}
+	rel, err := namespaceWorkspaceRel(p)
+	if err != nil {
+		return nil, "", c.remoteErr(op, RemoteErrorKindInvalidRequest, "", err)
+	}
+	return meta, rel, nil
+}
+
+// namespaceWorkspaceRel turns an absolute in-sandbox path under /workspace
+// into a path relative to the workspace directory ("." for the root).
+func namespaceWorkspaceRel(p string) (string, error) {
+	if !path.IsAbs(p) {
+		return "", fmt.Errorf("path %q must be absolute", p)
+	}
+	clean, err := cleanSessionWorkDir(p)
+	if err != nil {
+		return "", err
+	}
+	rel := strings.TrimPrefix(strings.TrimPrefix(clean, SessionWorkspaceRoot), "/")
+	if rel == "" {
+		return ".", nil
+	}
+	return rel, nil
+}
+
+func (c *NamespaceRemoteClient) fsErr(op, p string, err error) error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, fs.ErrNotExist) {
+		return c.remoteErr(op, RemoteErrorKindNotFound, p, err)
+	}
+	return c.remoteErr(op, RemoteErrorKindInternal, p, err)
+}
+
+func (c *NamespaceRemoteClient) remoteErr(op string, kind RemoteErrorKind, message string, cause error) error {
+	return NewRemoteError(SandboxTypeNamespace, op, kind, message, cause)
+}
+
+// findNamespaceInitHelper locates the init helper the way deployments ship
+// it: beside the WeKnora binary, or anywhere on PATH.
+func findNamespaceInitHelper() string {
+	if self, err := os.Executable(); err == nil {
+		candidate := filepath.Join(filepath.Dir(self), nsinit.HelperName)
+		if info, err := os.Stat(candidate); err == nil && info.Mode().IsRegular() {
+			return candidate
+		}
+	}
+	if found, err := exec.LookPath(nsinit.HelperName); err == nil {
+		if abs, err := filepath.Abs(found); err == nil {
+			return abs
+		}
+	}
+	return ""
+}
+
+func newNamespaceSandboxID() (string, error) {
+	buf := make([]byte, 12)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return namespaceIDPrefix + hex.EncodeToString(buf), nil
+}
+
+// namespaceEnv layers the base environment, the sandbox's create-time
+// variables and the call's own variables, in that order of precedence.
+func namespaceEnv(sandboxEnv, callEnv map[string]string) []string {
+	merged := make(map[string]string, len(namespaceBaseEnv)+len(sandboxEnv)+len(callEnv))
+	for _, layer := range []map[string]string{namespaceBaseEnv, sandboxEnv, callEnv} {
+		for key, value := range layer {
+			merged[key] = value
+		}
+	}
+	env := make([]string, 0, len(merged))
+	for key, value := range merged {
+		env = append(env, key+"="+value)
+	}
+	sort.Strings(env)
+	return env
+}
+
+func containsRemoteState(states []RemoteSandboxState, want RemoteSandboxState) bool {
+	for _, state := range states {
+		if state == want {
+			return true
+		}
+	}
+	return false
+}
+
+var _ RemoteSandboxClient = (*NamespaceRemoteClient)(nil)
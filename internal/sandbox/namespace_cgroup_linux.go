@@ -0,0 +1,122 @@
+//go:build linux
+
+package sandbox
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// namespaceCgroupPeriod is the cpu.max period, in microseconds.
+const namespaceCgroupPeriod = 100000
+
+// checkNamespaceCgroupRoot verifies root is a cgroup v2 directory offering
+// the controllers the limits need, so a misconfigured root fails Health
+// instead of every Create.
+func checkNamespaceCgroupRoot(root string) error {
+	var st unix.Statfs_t
+	if err := unix.Statfs(root, &st); err != nil {
+		return err
+	}
+	if st.Type != unix.CGROUP2_SUPER_MAGIC {
+		return fmt.Errorf("%s is not a cgroup v2 directory", root)
+	}
+	data, err := os.ReadFile(filepath.Join(root, "cgroup.controllers"))
+	if err != nil {
+		return err
+	}
+	available := strings.Fields(string(data))
+	for _, controller := range []string{"cpu", "memory", "pids"} {
+		if !slices.Contains(available, controller) {
+			return fmt.Errorf("cgroup %s does not offer the %s controller", root, controller)
+		}
+	}
+	return nil
+}
+
+// createNamespaceCgroup creates the sandbox's cgroup v2 directory under root
+// and writes its limits. root must be a cgroup WeKnora's user may write to —
+// typically one delegated by systemd (Delegate=yes) or the container
+// runtime's own cgroup on a cgroupns-private host.
+func createNamespaceCgroup(root, id string, limits namespaceLimits) error {
+	if err := checkNamespaceCgroupRoot(root); err != nil {
+		return err
+	}
+	// Best effort: the controllers are usually enabled already, and a
+	// non-empty root cgroup refuses the write.
+	_ = os.WriteFile(filepath.Join(root, "cgroup.subtree_control"), []byte("+cpu +memory +pids"), 0o644)
+
+	dir := namespaceCgroupPath(root, id)
+	if err := os.Mkdir(dir, 0o755); err != nil && !errors.Is(err, fs.ErrExist) {
+		return err
+	}
+	memory := "max"
+	if limits.MemoryBytes > 0 {
+		memory = strconv.FormatInt(limits.MemoryBytes, 10)
+	}
+	cpu := fmt.Sprintf("max %d", namespaceCgroupPeriod)
+	if limits.CPUCores > 0 {
+		cpu = fmt.Sprintf("%d %d", int64(limits.CPUCores*namespaceCgroupPeriod), namespaceCgroupPeriod)
+	}
+	pids := "max"
+	if limits.Pids > 0 {
+		pids = strconv.Itoa(limits.Pids)
+	}
+	for _, setting := range []struct {
+		file     string
+		value    string
+		optional bool
+	}{
+		{"memory.max", memory, false},
+		// Swap accounting is a kernel build option; without it memory.max
+		// alone is still a hard limit.
+		{"memory.swap.max", "0", true},
+		{"cpu.max", cpu, false},
+		{"pids.max", pids, false},
+	} {
+		err := os.WriteFile(filepath.Join(dir, setting.file), []byte(setting.value), 0o644)
+		if err != nil && !(setting.optional && errors.Is(err, fs.ErrNotExist)) {
+			_ = os.Remove(dir)
+			return fmt.Errorf("write %s: %w", setting.file, err)
+		}
+	}
+	return nil
+}
+
+// removeNamespaceCgroup kills whatever still runs in the sandbox's cgroup and
+// removes it. A missing cgroup is not an error.
+func removeNamespaceCgroup(root, id string) error {
+	dir := namespaceCgroupPath(root, id)
+	if _, err := os.Stat(dir); errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	// cgroup.kill arrived in Linux 5.14; older kernels get a signal per PID.
+	if err := os.WriteFile(filepath.Join(dir, "cgroup.kill"), []byte("1"), 0o644); err != nil {
+		if data, err := os.ReadFile(filepath.Join(dir, "cgroup.procs")); err == nil {
+			for _, field := range strings.Fields(string(data)) {
+				if pid, err := strconv.Atoi(field); err == nil {
+					_ = syscall.Kill(pid, syscall.SIGKILL)
+				}
+			}
+		}
+	}
+	// rmdir fails with EBUSY until the killed processes are reaped.
+	var err error
+	for attempt := 0; attempt < 20; attempt++ {
+		if err = os.Remove(dir); err == nil || errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	return err
+}
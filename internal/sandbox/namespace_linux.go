@@ -0,0 +1,432 @@
+//go:build linux
+
+package sandbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/sandbox/nsinit"
+	"golang.org/x/sys/unix"
+)
+
+// namespaceOverflowID is the host uid/gid executions run as when WeKnora
+// itself runs as root. Mapping root to root would make the sandbox's "root"
+// the host's root for every file reachable through the rootfs binds.
+const namespaceOverflowID = 65534
+
+// namespaceSupported reports whether this kernel lets the current user
+// create user namespaces. It only inspects sysctls; Health's probe execution
+// is the authoritative check.
+func namespaceSupported() error {
+	if _, err := os.Stat("/proc/self/ns/user"); err != nil {
+		return fmt.Errorf("kernel has no user namespace support: %w", err)
+	}
+	if data, err := os.ReadFile("/proc/sys/kernel/unprivileged_userns_clone"); err == nil &&
+		strings.TrimSpace(string(data)) == "0" && os.Geteuid() != 0 {
+		return errors.New("unprivileged user namespaces are disabled (kernel.unprivileged_userns_clone=0)")
+	}
+	if data, err := os.ReadFile("/proc/sys/user/max_user_namespaces"); err == nil &&
+		strings.TrimSpace(string(data)) == "0" {
+		return errors.New("user namespaces are disabled (user.max_user_namespaces=0)")
+	}
+	return nil
+}
+
+// sandboxHostIDs returns the host uid and gid executions are mapped to.
+func sandboxHostIDs() (int, int) {
+	if os.Geteuid() == 0 {
+		return namespaceOverflowID, namespaceOverflowID
+	}
+	return os.Geteuid(), os.Getegid()
+}
+
+// chownToSandboxUser hands a host-created path to the execution's user. It
+// is a no-op when WeKnora is unprivileged, since the mapped user is WeKnora's
+// own.
+func chownToSandboxUser(p string) error {
+	if os.Geteuid() != 0 {
+		return nil
+	}
+	uid, gid := sandboxHostIDs()
+	return os.Lchown(p, uid, gid)
+}
+
+// run starts the init helper (see package nsinit) in fresh namespaces; it
+// builds the sandbox root and then execs the requested command. Setup failures come back over a close-on-exec pipe so
+// they are reported as errors rather than confused with the command's own
+// exit status.
+func (c *NamespaceRemoteClient) run(ctx context.Context, req namespaceRunRequest) (*RemoteExecResult, error) {
+	if c.initPath == "" {
+		return nil, c.remoteErr("Exec", RemoteErrorKindUnavailable, nsinit.HelperName+" not found", nil)
+	}
+	spec, err := json.Marshal(nsinit.Spec{
+		Rootfs:     c.rootfs,
+		MountPoint: filepath.Join(c.stateDir, namespaceMountPointDir),
+		Workspace:  req.Workspace,
+		Args:       req.Args,
+		Env:        req.Env,
+		WorkDir:    req.WorkDir,
+		Network:    req.Network,
+		Hostname:   namespaceHostname,
+		TmpSizeMB:  namespaceTmpSizeMB,
+	})
+	if err != nil {
+		return nil, c.remoteErr("Exec", RemoteErrorKindInternal, "encode init spec", err)
+	}
+	helper, err := os.Open(c.initPath)
+	if err != nil {
+		return nil, c.remoteErr("Exec", RemoteErrorKindUnavailable, "open "+nsinit.HelperName, err)
+	}
+	defer helper.Close()
+
+	specR, specW, err := os.Pipe()
+	if err != nil {
+		return nil, c.remoteErr("Exec", RemoteErrorKindUnavailable, "create spec pipe", err)
+	}
+	defer specR.Close()
+	defer specW.Close()
+	errR, errW, err := os.Pipe()
+	if err != nil {
+		return nil, c.remoteErr("Exec", RemoteErrorKindUnavailable, "create error pipe", err)
+	}
+	defer errR.Close()
+	defer errW.Close()
+
+	uid, gid := sandboxHostIDs()
+	cloneflags := uintptr(syscall.CLONE_NEWUSER | syscall.CLONE_NEWNS | syscall.CLONE_NEWPID |
+		syscall.CLONE_NEWIPC | syscall.CLONE_NEWUTS | syscall.CLONE_NEWCGROUP)
+	if !req.Network {
+		cloneflags |= syscall.CLONE_NEWNET
+	}
+	attr := &syscall.SysProcAttr{
+		Cloneflags:                 cloneflags,
+		UidMappings:                []syscall.SysProcIDMap{{ContainerID: 0, HostID: uid, Size: 1}},
+		GidMappings:                []syscall.SysProcIDMap{{ContainerID: 0, HostID: gid, Size: 1}},
+		GidMappingsEnableSetgroups: false,
+		Pdeathsig:                  syscall.SIGKILL,
+		Setsid:                     true,
+	}
+	if os.Geteuid() == 0 {
+		// The child keeps host uid 0, which is unmapped in the new namespace,
+		// and exec would strip its capabilities. Switching to the namespace's
+		// root (the overflow ID on the host) after the maps are written keeps
+		// init privileged inside the namespace only.
+		attr.Credential = &syscall.Credential{Uid: 0, Gid: 0, NoSetGroups: true}
+	}
+	if req.CgroupDir != "" {
+		cgroup, err := os.Open(req.CgroupDir)
+		if err != nil {
+			return nil, c.remoteErr("Exec", RemoteErrorKindUnavailable, "open sandbox cgroup", err)
+		}
+		defer cgroup.Close()
+		attr.UseCgroupFD = true
+		attr.CgroupFD = int(cgroup.Fd())
+	}
+
+	var stdout, stderr bytes.Buffer
+	// /proc/self/fd/N is a magic link: the kernel execs the already-open
+	// helper without walking its directory path, which the mapped user may
+	// not be able to traverse.
+	cmd := exec.Command(fmt.Sprintf("/proc/self/fd/%d", nsinit.ExecutableFD))
+	cmd.Args = []string{nsinit.HelperName}
+	cmd.Env = []string{}
+	cmd.Dir = "/"
+	cmd.Stdin = strings.NewReader(req.Stdin)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	cmd.ExtraFiles = []*os.File{specR, errW, helper}
+	cmd.SysProcAttr = attr
+
+	start := time.Now()
+	if err := cmd.Start(); err != nil {
+		return nil, c.remoteErr("Exec", RemoteErrorKindUnavailable, "start namespace init", err)
+	}
+	_ = specR.Close()
+	_ = errW.Close()
+	if _, err := specW.Write(spec); err != nil {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+		return nil, c.remoteErr("Exec", RemoteErrorKindUnavailable, "send init spec", err)
+	}
+	_ = specW.Close()
+
+	// Setsid put init in its own session; killing the process group takes
+	// down everything it spawned. The PID namespace does the rest: once init
+	// dies the kernel kills every other process in it.
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			_ = syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+		case <-done:
+		}
+	}()
+	waitErr := cmd.Wait()
+	close(done)
+	duration := time.Since(start)
+	killed := ctx.Err() != nil
+
+	setupErr, _ := io.ReadAll(errR)
+	if len(setupErr) > 0 {
+		return nil, c.remoteErr("Exec", RemoteErrorKindUnavailable, "sandbox setup failed: "+string(setupErr), nil)
+	}
+
+	result := &RemoteExecResult{
+		Stdout:   stdout.String(),
+		Stderr:   stderr.String(),
+		Duration: duration,
+		Killed:   killed,
+	}
+	if waitErr != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(waitErr, &exitErr) {
+			return nil, c.remoteErr("Exec", RemoteErrorKindInternal, "wait for namespace init", waitErr)
+		}
+		result.ExitCode = exitErr.ExitCode()
+		if result.ExitCode < 0 {
+			result.ExitCode = -1
+		}
+	}
+	return result, nil
+}
+
+// --- workspace filesystem ----------------------------------------------------
+//
+// The workspace is writable by sandboxed code, which can plant symlinks
+// pointing anywhere on the host. Every host-side access therefore resolves
+// paths with openat2(RESOLVE_IN_ROOT), which treats the workspace as / for
+// symlink resolution and ".." alike, and never follows the final component of
+// a path it is about to remove.
+
+const namespaceResolve = unix.RESOLVE_IN_ROOT | unix.RESOLVE_NO_MAGICLINKS
+
+func openWorkspace(workspace string) (int, error) {
+	return unix.Open(workspace, unix.O_PATH|unix.O_DIRECTORY|unix.O_CLOEXEC, 0)
+}
+
+func openInWorkspace(rootFD int, rel string, flags uint64, mode uint64) (int, error) {
+	for {
+		fd, err := unix.Openat2(rootFD, rel, &unix.OpenHow{
+			Flags:   flags | unix.O_CLOEXEC,
+			Mode:    mode,
+			Resolve: namespaceResolve,
+		})
+		if err == unix.EINTR || err == unix.EAGAIN {
+			continue
+		}
+		if err != nil {
+			return -1, &os.PathError{Op: "openat2", Path: rel, Err: err}
+		}
+		return fd, nil
+	}
+}
+
+func workspaceWriteFile(workspace, rel string, content []byte) error {
+	if rel == "." {
+		return errors.New("cannot write to the workspace root")
+	}
+	if err := workspaceMkdirAll(workspace, filepath.Dir(rel)); err != nil {
+		return err
+	}
+	rootFD, err := openWorkspace(workspace)
+	if err != nil {
+		return err
+	}
+	defer unix.Close(rootFD)
+	fd, err := openInWorkspace(rootFD, rel, unix.O_WRONLY|unix.O_CREAT|unix.O_TRUNC|unix.O_NOFOLLOW, 0o644)
+	if err != nil {
+		return err
+	}
+	file := os.NewFile(uintptr(fd), rel)
+	defer file.Close()
+	if os.Geteuid() == 0 {
+		uid, gid := sandboxHostIDs()
+		if err := file.Chown(uid, gid); err != nil {
+			return err
+		}
+	}
+	_, err = file.Write(content)
+	return err
+}
+
+func workspaceReadFile(workspace, rel string) ([]byte, error) {
+	rootFD, err := openWorkspace(workspace)
+	if err != nil {
+		return nil, err
+	}
+	defer unix.Close(rootFD)
+	fd, err := openInWorkspace(rootFD, rel, unix.O_RDONLY|unix.O_NONBLOCK, 0)
+	if err != nil {
+		return nil, err
+	}
+	file := os.NewFile(uintptr(fd), rel)
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if !info.Mode().IsRegular() {
+		return nil, fmt.Errorf("%s is not a regular file", rel)
+	}
+	return io.ReadAll(file)
+}
+
+// workspaceMkdirAll creates rel one component at a time, each relative to an
+// already-opened parent, so a symlink swapped in mid-walk cannot redirect the
+// walk out of the workspace.
+func workspaceMkdirAll(workspace, rel string) error {
+	cur, err := openWorkspace(workspace)
+	if err != nil {
+		return err
+	}
+	defer func() { unix.Close(cur) }()
+	if rel == "." || rel == "" {
+		return nil
+	}
+	uid, gid := sandboxHostIDs()
+	for _, name := range strings.Split(rel, "/") {
+		if name == "" || name == "." {
+			continue
+		}
+		next, err := openInWorkspace(cur, name, unix.O_PATH|unix.O_DIRECTORY, 0)
+		if errors.Is(err, unix.ENOENT) {
+			if err := unix.Mkdirat(cur, name, 0o755); err != nil && err != unix.EEXIST {
+				return &os.PathError{Op: "mkdirat", Path: rel, Err: err}
+			}
+			if os.Geteuid() == 0 {
+				if err := unix.Fchownat(cur, name, uid, gid, unix.AT_SYMLINK_NOFOLLOW); err != nil {
+					return &os.PathError{Op: "fchownat", Path: rel, Err: err}
+				}
+			}
+			next, err = openInWorkspace(cur, name, unix.O_PATH|unix.O_DIRECTORY, 0)
+		}
+		if err != nil {
+			return err
+		}
+		unix.Close(cur)
+		cur = next
+	}
+	return nil
+}
+
+func workspaceStat(workspace, rel string) (*RemoteStatEntry, error) {
+	rootFD, err := openWorkspace(workspace)
+	if err != nil {
+		return nil, err
+	}
+	defer unix.Close(rootFD)
+	fd, err := openInWorkspace(rootFD, rel, unix.O_PATH, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer unix.Close(fd)
+	var st unix.Stat_t
+	if err := unix.Fstat(fd, &st); err != nil {
+		return nil, &os.PathError{Op: "fstat", Path: rel, Err: err}
+	}
+	return &RemoteStatEntry{
+		Type:    namespaceEntryType(st.Mode),
+		Size:    st.Size,
+		ModTime: time.Unix(st.Mtim.Unix()),
+	}, nil
+}
+
+func workspaceListDir(workspace, rel string) ([]RemoteDirEntry, error) {
+	rootFD, err := openWorkspace(workspace)
+	if err != nil {
+		return nil, err
+	}
+	defer unix.Close(rootFD)
+	fd, err := openInWorkspace(rootFD, rel, unix.O_RDONLY|unix.O_DIRECTORY, 0)
+	if err != nil {
+		return nil, err
+	}
+	dir := os.NewFile(uintptr(fd), rel)
+	defer dir.Close()
+	names, err := dir.Readdirnames(-1)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]RemoteDirEntry, 0, len(names))
+	for _, name := range names {
+		var st unix.Stat_t
+		if err := unix.Fstatat(fd, name, &st, unix.AT_SYMLINK_NOFOLLOW); err != nil {
+			continue
+		}
+		entries = append(entries, RemoteDirEntry{
+			Name:    name,
+			Type:    namespaceEntryType(st.Mode),
+			Size:    st.Size,
+			ModTime: time.Unix(st.Mtim.Unix()),
+		})
+	}
+	return entries, nil
+}
+
+func workspaceRemoveAll(workspace, rel string) error {
+	rootFD, err := openWorkspace(workspace)
+	if err != nil {
+		return err
+	}
+	defer unix.Close(rootFD)
+	parent, err := openInWorkspace(rootFD, filepath.Dir(rel), unix.O_PATH|unix.O_DIRECTORY, 0)
+	if err != nil {
+		return err
+	}
+	defer unix.Close(parent)
+	return removeAllAt(parent, filepath.Base(rel))
+}
+
+// removeAllAt removes name under dirFD without following symlinks.
+func removeAllAt(dirFD int, name string) error {
+	err := unix.Unlinkat(dirFD, name, 0)
+	if err == nil {
+		return nil
+	}
+	if err != unix.EISDIR && err != unix.EPERM {
+		return &os.PathError{Op: "unlinkat", Path: name, Err: err}
+	}
+	fd, err := unix.Openat(dirFD, name, unix.O_RDONLY|unix.O_DIRECTORY|unix.O_NOFOLLOW|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return &os.PathError{Op: "openat", Path: name, Err: err}
+	}
+	dir := os.NewFile(uintptr(fd), name)
+	names, err := dir.Readdirnames(-1)
+	if err != nil {
+		dir.Close()
+		return err
+	}
+	for _, child := range names {
+		if err := removeAllAt(fd, child); err != nil && !errors.Is(err, unix.ENOENT) {
+			dir.Close()
+			return err
+		}
+	}
+	dir.Close()
+	if err := unix.Unlinkat(dirFD, name, unix.AT_REMOVEDIR); err != nil {
+		return &os.PathError{Op: "unlinkat", Path: name, Err: err}
+	}
+	return nil
+}
+
+func namespaceEntryType(mode uint32) RemoteDirEntryType {
+	switch mode & unix.S_IFMT {
+	case unix.S_IFDIR:
+		return RemoteEntryDir
+	case unix.S_IFREG:
+		return RemoteEntryFile
+	default:
+		return RemoteEntryOther
+	}
+}
@@ -0,0 +1,170 @@
+//go:build linux
+
+package sandbox
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// buildNamespaceInitHelper compiles cmd/sandbox-init. This test binary
+// cannot serve as the helper itself: its package initialisers load far more
+// than an unprivileged sandbox can read.
+func buildNamespaceInitHelper(t *testing.T) string {
+	t.Helper()
+	helper := filepath.Join(t.TempDir(), "weknora-sandbox-init")
+	cmd := exec.Command("go", "build", "-o", helper, "github.com/Tencent/WeKnora/cmd/sandbox-init")
+	cmd.Env = append(os.Environ(), "CGO_ENABLED=0")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Skipf("cannot build the init helper: %v: %s", err, out)
+	}
+	return helper
+}
+
+// newTestNamespaceClient returns a client over a temp state dir, skipping
+// when this host cannot create the namespaces the backend needs.
+func newTestNamespaceClient(t *testing.T) *NamespaceRemoteClient {
+	t.Helper()
+	if testing.Short() {
+		t.Skip("namespace sandbox execution is skipped in -short mode")
+	}
+	stateDir, err := os.MkdirTemp("/dev/shm", "weknora-ns-test-")
+	if err != nil {
+		stateDir = t.TempDir()
+	} else {
+		t.Cleanup(func() { _ = os.RemoveAll(stateDir) })
+	}
+	cfg := DefaultConfig()
+	cfg.NamespaceStateDir = stateDir
+	cfg.NamespaceRootfs = "/"
+	cfg.NamespaceCgroupRoot = ""
+	cfg.NamespaceInitPath = buildNamespaceInitHelper(t)
+	client, err := NewNamespaceRemoteClient(cfg)
+	require.NoError(t, err)
+	if err := client.Health(context.Background()); err != nil {
+		t.Skipf("namespace sandboxes unavailable here: %v", err)
+	}
+	return client
+}
+
+func TestNamespaceRemoteClientExecPersistsWorkspace(t *testing.T) {
+	client := newTestNamespaceClient(t)
+	ctx := context.Background()
+
+	handle, err := client.Create(ctx, RemoteCreateRequest{
+		TemplateID: "/",
+		EnvVars:    map[string]string{"GREETING": "hello"},
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = client.Delete(ctx, handle.ID()) })
+
+	require.NoError(t, client.WriteFile(ctx, handle, "/workspace/in/data.txt", []byte("payload")))
+
+	result, err := client.Exec(ctx, handle, RemoteExecRequest{
+		Command: `cat in/data.txt; echo " $GREETING"; echo out > result.txt; pwd`,
+		Shell:   true,
+	})
+	require.NoError(t, err)
+	require.Equal(t, 0, result.ExitCode, result.Stderr)
+	require.Equal(t, "payload hello\n/workspace\n", result.Stdout)
+
+	// The next execution is a new process tree over the same workspace.
+	content, err := client.ReadFile(ctx, handle, "/workspace/result.txt")
+	require.NoError(t, err)
+	require.Equal(t, "out\n", string(content))
+
+	entries, err := client.ListDir(ctx, handle, "/workspace")
+	require.NoError(t, err)
+	names := []string{}
+	for _, entry := range entries {
+		names = append(names, entry.Name)
+	}
+	require.ElementsMatch(t, []string{"in", "result.txt"}, names)
+}
+
+func TestNamespaceRemoteClientConfinesExecution(t *testing.T) {
+	client := newTestNamespaceClient(t)
+	ctx := context.Background()
+	handle, err := client.Create(ctx, RemoteCreateRequest{TemplateID: "/"})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = client.Delete(ctx, handle.ID()) })
+
+	run := func(script string) *RemoteExecResult {
+		t.Helper()
+		result, err := client.Exec(ctx, handle, RemoteExecRequest{Command: script, Shell: true, Timeout: 10 * time.Second})
+		require.NoError(t, err)
+		return result
+	}
+
+	// The root filesystem is read-only and the host's other trees are absent.
+	result := run("touch /usr/weknora-escape")
+	require.NotEqual(t, 0, result.ExitCode)
+	result = run("test -e /root || test -e /home; echo $?")
+	require.Equal(t, "1\n", result.Stdout)
+
+	// Mounting and nesting namespaces are refused even as namespace root.
+	result = run("mount -t tmpfs none /tmp")
+	require.NotEqual(t, 0, result.ExitCode)
+	if _, err := os.Stat("/usr/bin/unshare"); err == nil {
+		result = run("unshare -U true")
+		require.NotEqual(t, 0, result.ExitCode)
+	}
+
+	// Without network the only interface is loopback.
+	if _, err := os.Stat("/proc/net/dev"); err == nil {
+		result = run("cat /proc/net/dev")
+		if result.ExitCode == 0 {
+			require.Contains(t, result.Stdout, "lo:")
+			require.NotContains(t, result.Stdout, "eth0:")
+		}
+	}
+
+	result, err = client.Exec(ctx, handle, RemoteExecRequest{Command: "sleep 5", Shell: true, Timeout: 200 * time.Millisecond})
+	require.NoError(t, err)
+	require.True(t, result.Killed)
+
+	result = run("no-such-command-weknora")
+	require.Equal(t, 127, result.ExitCode)
+}
+
+func TestNamespaceRemoteClientFileOpsStayInWorkspace(t *testing.T) {
+	client := newTestNamespaceClient(t)
+	ctx := context.Background()
+	handle, err := client.Create(ctx, RemoteCreateRequest{TemplateID: "/"})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = client.Delete(ctx, handle.ID()) })
+
+	outside := filepath.Join(t.TempDir(), "secret")
+	require.NoError(t, os.WriteFile(outside, []byte("host"), 0o600))
+
+	// A symlink planted by the script resolves inside the workspace, never
+	// on the host.
+	result, err := client.Exec(ctx, handle, RemoteExecRequest{
+		Command: "ln -s " + outside + " link && ln -s / root",
+		Shell:   true,
+	})
+	require.NoError(t, err)
+	require.Equal(t, 0, result.ExitCode, result.Stderr)
+
+	_, err = client.ReadFile(ctx, handle, "/workspace/link")
+	require.True(t, remoteKind(err) == RemoteErrorKindNotFound, "%v", err)
+	require.NoError(t, client.WriteFile(ctx, handle, "/workspace/root/planted", []byte("x")))
+	_, err = os.Stat("/planted")
+	require.True(t, os.IsNotExist(err))
+	planted, err := client.ReadFile(ctx, handle, "/workspace/planted")
+	require.NoError(t, err)
+	require.Equal(t, "x", string(planted))
+
+	require.NoError(t, client.Remove(ctx, handle, "/workspace/root"))
+	_, err = os.Stat(outside)
+	require.NoError(t, err)
+
+	_, err = client.ReadFile(ctx, handle, "/etc/passwd")
+	require.True(t, remoteKind(err) == RemoteErrorKindInvalidRequest, "%v", err)
+}
@@ -0,0 +1,40 @@
+//go:build !linux
+
+package sandbox
+
+import (
+	"context"
+	"errors"
+)
+
+// The namespace backend is built from Linux-only primitives. Elsewhere the
+// client still compiles so configs validate, but every operation that would
+// touch a sandbox reports RemoteErrorKindUnsupported.
+
+var errNamespaceUnsupported = errors.New("namespace sandboxes require Linux")
+
+func namespaceSupported() error { return errNamespaceUnsupported }
+
+func chownToSandboxUser(string) error { return errNamespaceUnsupported }
+
+func (c *NamespaceRemoteClient) run(context.Context, namespaceRunRequest) (*RemoteExecResult, error) {
+	return nil, c.remoteErr("Exec", RemoteErrorKindUnsupported, "", errNamespaceUnsupported)
+}
+
+func checkNamespaceCgroupRoot(string) error { return errNamespaceUnsupported }
+
+func createNamespaceCgroup(string, string, namespaceLimits) error { return errNamespaceUnsupported }
+
+func removeNamespaceCgroup(string, string) error { return nil }
+
+func workspaceWriteFile(string, string, []byte) error { return errNamespaceUnsupported }
+
+func workspaceReadFile(string, string) ([]byte, error) { return nil, errNamespaceUnsupported }
+
+func workspaceMkdirAll(string, string) error { return errNamespaceUnsupported }
+
+func workspaceStat(string, string) (*RemoteStatEntry, error) { return nil, errNamespaceUnsupported }
+
+func workspaceListDir(string, string) ([]RemoteDirEntry, error) { return nil, errNamespaceUnsupported }
+
+func workspaceRemoveAll(string, string) error { return errNamespaceUnsupported }
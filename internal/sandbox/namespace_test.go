@@ -0,0 +1,178 @@
+package sandbox
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/stretchr/testify/require"
+)
+
+// newLifecycleNamespaceClient builds a client whose lifecycle calls only
+// touch the state directory; nothing here execs a sandbox.
+func newLifecycleNamespaceClient(t *testing.T) *NamespaceRemoteClient {
+	t.Helper()
+	if runtime.GOOS != "linux" {
+		t.Skip("namespace sandboxes require Linux")
+	}
+	cfg := DefaultConfig()
+	cfg.NamespaceStateDir = filepath.Join(t.TempDir(), "state")
+	cfg.NamespaceRootfs = "/"
+	cfg.NamespaceCgroupRoot = ""
+	cfg.NamespaceInitPath = "/nonexistent/weknora-sandbox-init"
+	client, err := NewNamespaceRemoteClient(cfg)
+	require.NoError(t, err)
+	return client
+}
+
+func TestNamespaceRemoteClientLifecycle(t *testing.T) {
+	client := newLifecycleNamespaceClient(t)
+	ctx := context.Background()
+
+	handle, err := client.Create(ctx, RemoteCreateRequest{
+		TemplateID: "/",
+		Metadata:   map[string]string{"tenant": "7", "session": "s1"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, SandboxTypeNamespace, handle.Provider())
+	require.Regexp(t, namespaceIDPattern, handle.ID())
+
+	reconnected, err := client.Connect(ctx, handle.ID())
+	require.NoError(t, err)
+	require.Equal(t, "s1", reconnected.Metadata()["session"])
+
+	summary, err := client.Get(ctx, handle.ID())
+	require.NoError(t, err)
+	require.Equal(t, RemoteStateRunning, summary.State)
+	require.Equal(t, "/", summary.TemplateID)
+
+	listed, err := client.List(ctx, RemoteListFilter{Metadata: map[string]string{"tenant": "7"}})
+	require.NoError(t, err)
+	require.Len(t, listed, 1)
+	listed, err = client.List(ctx, RemoteListFilter{Metadata: map[string]string{"tenant": "8"}})
+	require.NoError(t, err)
+	require.Empty(t, listed)
+
+	require.NoError(t, client.Delete(ctx, handle.ID()))
+	_, err = client.Connect(ctx, handle.ID())
+	require.Equal(t, RemoteErrorKindNotFound, remoteKind(err))
+	require.Equal(t, RemoteErrorKindNotFound, remoteKind(client.Delete(ctx, handle.ID())))
+}
+
+func TestNamespaceRemoteClientRejectsForeignTemplate(t *testing.T) {
+	client := newLifecycleNamespaceClient(t)
+
+	_, err := client.Create(context.Background(), RemoteCreateRequest{TemplateID: "/srv/other-rootfs"})
+
+	require.Equal(t, RemoteErrorKindInvalidRequest, remoteKind(err))
+}
+
+// IDs come back from bindings and API callers; anything that is not an ID this
+// client could have minted must never reach the filesystem.
+func TestNamespaceRemoteClientRejectsPathLikeIDs(t *testing.T) {
+	client := newLifecycleNamespaceClient(t)
+	ctx := context.Background()
+
+	for _, id := range []string{"../state", "ns-../../etc", "", ".mnt"} {
+		_, err := client.Connect(ctx, id)
+		require.Equal(t, RemoteErrorKindNotFound, remoteKind(err), id)
+		require.Equal(t, RemoteErrorKindNotFound, remoteKind(client.Delete(ctx, id)), id)
+	}
+}
+
+func TestNamespaceRemoteClientReclaimsIdleSandboxes(t *testing.T) {
+	client := newLifecycleNamespaceClient(t)
+	ctx := context.Background()
+	now := time.Now()
+	client.now = func() time.Time { return now }
+
+	handle, err := client.Create(ctx, RemoteCreateRequest{
+		TemplateID: "/",
+		Timeout:    RemoteTimeoutPolicy{Mode: RemoteTimeoutExplicit, Value: time.Minute},
+	})
+	require.NoError(t, err)
+	client.touch(handle.ID())
+
+	now = now.Add(30 * time.Second)
+	_, err = client.Connect(ctx, handle.ID())
+	require.NoError(t, err, "use within the TTL keeps the sandbox")
+
+	now = now.Add(2 * time.Minute)
+	_, err = client.Get(ctx, handle.ID())
+	require.Equal(t, RemoteErrorKindTerminal, remoteKind(err))
+	_, statErr := os.Stat(client.sandboxDir(handle.ID()))
+	require.True(t, os.IsNotExist(statErr), "expired workspace is removed")
+}
+
+func TestNamespaceWorkspaceRel(t *testing.T) {
+	for in, want := range map[string]string{
+		"/workspace":             ".",
+		"/workspace/":            ".",
+		"/workspace/a/b.txt":     "a/b.txt",
+		"/workspace/a/../b.txt":  "b.txt",
+		"/workspace/./outputs/x": "outputs/x",
+	} {
+		got, err := namespaceWorkspaceRel(in)
+		require.NoError(t, err, in)
+		require.Equal(t, want, got, in)
+	}
+	for _, in := range []string{"workspace/a", "/etc/passwd", "/workspace/../etc", "/workspaces"} {
+		_, err := namespaceWorkspaceRel(in)
+		require.Error(t, err, in)
+	}
+}
+
+func TestNamespaceEnvPrecedence(t *testing.T) {
+	env := namespaceEnv(map[string]string{"A": "sandbox", "B": "sandbox"}, map[string]string{"B": "call"})
+
+	require.Contains(t, env, "A=sandbox")
+	require.Contains(t, env, "B=call")
+	require.Contains(t, env, "HOME=/workspace")
+}
+
+func TestResolveEffectiveConfigNamespaceKeepsHostFields(t *testing.T) {
+	global := DefaultConfig()
+	global.NamespaceStateDir = "/var/lib/weknora/ns"
+	global.NamespaceCgroupRoot = "/sys/fs/cgroup/weknora"
+	global.NamespaceAllowNetwork = true
+
+	got, err := ResolveEffectiveConfig(&types.TenantSandboxConfig{
+		SandboxType: "namespace",
+		Namespace: &types.NamespaceSandboxConfig{
+			MemoryMB:          256,
+			CPUCores:          0.5,
+			PidsLimit:         32,
+			SandboxTTLSeconds: 600,
+		},
+	}, global)
+
+	require.NoError(t, err)
+	require.Equal(t, SandboxTypeNamespace, got.Type)
+	require.Equal(t, "/var/lib/weknora/ns", got.NamespaceStateDir)
+	require.Equal(t, "/sys/fs/cgroup/weknora", got.NamespaceCgroupRoot)
+	require.False(t, got.NamespaceAllowNetwork, "network access is a workspace decision, not inherited")
+	require.Equal(t, int64(256*1024*1024), got.MaxMemory)
+	require.Equal(t, 0.5, got.MaxCPU)
+	require.Equal(t, 32, got.NamespacePidsLimit)
+	require.Equal(t, 10*time.Minute, got.NamespaceSandboxTTL)
+	require.Equal(t, "/", EffectiveTemplateID(got))
+}
+
+func TestBuildSessionCreateRequestNamespace(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.NamespaceRootfs = "/srv/rootfs"
+	cfg.NamespaceAllowNetwork = true
+
+	req, err := buildSessionCreateRequest(SandboxTypeNamespace, cfg)
+
+	require.NoError(t, err)
+	require.Equal(t, "/srv/rootfs", req.TemplateID)
+	require.Equal(t, DefaultNamespaceSandboxTTL, req.Timeout.Value)
+	require.Equal(t, RemoteOnTimeoutKill, req.Timeout.Action)
+	require.NotNil(t, req.Network.AllowInternetAccess)
+	require.True(t, *req.Network.AllowInternetAccess)
+}
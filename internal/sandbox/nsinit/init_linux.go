@@ -0,0 +1,302 @@
+//go:build linux
+
+package nsinit
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+// rootfsEntries are the rootfs directories mounted into the sandbox.
+// Everything else of the rootfs — notably /home, /root, /var and /proc — stays
+// out of view.
+var rootfsEntries = []string{
+	"bin", "sbin", "lib", "lib32", "lib64", "libx32", "usr", "etc", "opt",
+}
+
+// devNodes are bound from the host's /dev into the sandbox's.
+var devNodes = []string{"null", "zero", "full", "random", "urandom", "tty"}
+
+// Main runs as PID 1 of a fresh set of namespaces, as root of its user
+// namespace. It builds the sandbox root, gives up every privilege and execs
+// the requested command. It never returns.
+func Main() {
+	runtime.LockOSThread()
+
+	errPipe := os.NewFile(ErrorFD, "errpipe")
+	fail := func(step string, err error) {
+		if _, werr := fmt.Fprintf(errPipe, "%s: %v", step, err); werr != nil {
+			// Not started by the namespace client.
+			fmt.Fprintf(os.Stderr, "%s: %s: %v\n", HelperName, step, err)
+		}
+		os.Exit(FailureExit)
+	}
+	// Inherited descriptors arrive without close-on-exec; the command must
+	// not inherit them, and the parent relies on the error pipe closing at
+	// exec.
+	for _, fd := range []int{SpecFD, ErrorFD, ExecutableFD} {
+		unix.CloseOnExec(fd)
+	}
+
+	var spec Spec
+	specFile := os.NewFile(SpecFD, "spec")
+	if err := json.NewDecoder(specFile).Decode(&spec); err != nil {
+		fail("read spec", err)
+	}
+	_ = specFile.Close()
+	if len(spec.Args) == 0 {
+		fail("read spec", errors.New("no command"))
+	}
+
+	if err := setupRoot(&spec); err != nil {
+		fail("mount", err)
+	}
+	if !spec.Network {
+		if err := bringUpLoopback(); err != nil {
+			fail("loopback", err)
+		}
+	}
+	if err := unix.Sethostname([]byte(spec.Hostname)); err != nil {
+		fail("hostname", err)
+	}
+	if err := setRlimits(); err != nil {
+		fail("rlimit", err)
+	}
+	if err := os.Chdir(spec.WorkDir); err != nil {
+		fail("chdir", err)
+	}
+	if err := dropCapabilities(); err != nil {
+		fail("capabilities", err)
+	}
+	if err := installSeccomp(); err != nil {
+		fail("seccomp", err)
+	}
+
+	// A missing command is the command's failure, not the sandbox's: report
+	// it the way a shell would.
+	binary, err := lookPath(spec.Args[0], spec.Env)
+	if err == nil {
+		err = unix.Exec(binary, spec.Args, spec.Env)
+	}
+	fmt.Fprintf(os.Stderr, "%s: %v\n", spec.Args[0], err)
+	os.Exit(127)
+}
+
+// setupRoot assembles the sandbox's filesystem on a tmpfs and pivots
+// into it. Because the mount namespace is owned by the sandbox's user
+// namespace, mount flags the host locked (nosuid, nodev, ro, ...) cannot be
+// cleared, and neither the parent's mounts nor the host's original root
+// remain reachable after the pivot.
+func setupRoot(spec *Spec) error {
+	if err := unix.Mount("", "/", "", unix.MS_REC|unix.MS_PRIVATE, ""); err != nil {
+		return fmt.Errorf("make mounts private: %w", err)
+	}
+	root := spec.MountPoint
+	if err := unix.Mount("tmpfs", root, "tmpfs", unix.MS_NOSUID|unix.MS_NODEV, "mode=0755,size=1m"); err != nil {
+		return fmt.Errorf("mount root tmpfs: %w", err)
+	}
+
+	for _, name := range rootfsEntries {
+		src := filepath.Join(spec.Rootfs, name)
+		info, err := os.Lstat(src)
+		if err != nil {
+			continue
+		}
+		dst := filepath.Join(root, name)
+		if info.Mode()&os.ModeSymlink != 0 {
+			// Merged-/usr layouts make /bin, /lib, ... symlinks into usr.
+			target, err := os.Readlink(src)
+			if err != nil {
+				return err
+			}
+			if err := os.Symlink(target, dst); err != nil {
+				return err
+			}
+			continue
+		}
+		if !info.IsDir() {
+			continue
+		}
+		if err := os.Mkdir(dst, 0o755); err != nil {
+			return err
+		}
+		if err := bindMount(src, dst, unix.MS_RDONLY|unix.MS_NOSUID|unix.MS_NODEV); err != nil {
+			return fmt.Errorf("bind %s: %w", name, err)
+		}
+	}
+
+	for _, name := range []string{"dev", "proc", "tmp", "workspace"} {
+		if err := os.Mkdir(filepath.Join(root, name), 0o755); err != nil {
+			return err
+		}
+	}
+	tmpOpts := fmt.Sprintf("mode=1777,size=%dm", spec.TmpSizeMB)
+	if err := unix.Mount("tmpfs", filepath.Join(root, "tmp"), "tmpfs", unix.MS_NOSUID|unix.MS_NODEV, tmpOpts); err != nil {
+		return fmt.Errorf("mount /tmp: %w", err)
+	}
+	if err := bindMount(spec.Workspace, filepath.Join(root, "workspace"), unix.MS_NOSUID|unix.MS_NODEV); err != nil {
+		return fmt.Errorf("bind workspace: %w", err)
+	}
+	if err := setupDev(filepath.Join(root, "dev")); err != nil {
+		return fmt.Errorf("populate /dev: %w", err)
+	}
+	// A fresh procfs only shows this PID namespace. Runtimes that mask parts
+	// of the host's /proc refuse it; scripts then run without /proc, which
+	// most interpreters tolerate.
+	_ = unix.Mount("proc", filepath.Join(root, "proc"), "proc", unix.MS_NOSUID|unix.MS_NODEV|unix.MS_NOEXEC, "")
+
+	if err := unix.Chdir(root); err != nil {
+		return err
+	}
+	if err := unix.PivotRoot(".", "."); err != nil {
+		return fmt.Errorf("pivot_root: %w", err)
+	}
+	if err := unix.Unmount(".", unix.MNT_DETACH); err != nil {
+		return fmt.Errorf("detach old root: %w", err)
+	}
+	if err := unix.Chdir("/"); err != nil {
+		return err
+	}
+	if err := unix.Mount("", "/", "", unix.MS_REMOUNT|unix.MS_RDONLY|unix.MS_NOSUID|unix.MS_NODEV, ""); err != nil {
+		return fmt.Errorf("remount root read-only: %w", err)
+	}
+	return nil
+}
+
+// bindMount binds src onto dst and applies flags. The remount must repeat
+// every flag the source mount carries that an unprivileged namespace may not
+// clear, or the kernel rejects it.
+func bindMount(src, dst string, flags uintptr) error {
+	if err := unix.Mount(src, dst, "", unix.MS_BIND|unix.MS_REC, ""); err != nil {
+		return err
+	}
+	var st unix.Statfs_t
+	if err := unix.Statfs(dst, &st); err != nil {
+		return err
+	}
+	for _, pair := range [][2]uintptr{
+		{unix.ST_RDONLY, unix.MS_RDONLY},
+		{unix.ST_NOSUID, unix.MS_NOSUID},
+		{unix.ST_NODEV, unix.MS_NODEV},
+		{unix.ST_NOEXEC, unix.MS_NOEXEC},
+		{unix.ST_NOATIME, unix.MS_NOATIME},
+		{unix.ST_NODIRATIME, unix.MS_NODIRATIME},
+		{unix.ST_RELATIME, unix.MS_RELATIME},
+	} {
+		if uintptr(st.Flags)&pair[0] != 0 {
+			flags |= pair[1]
+		}
+	}
+	return unix.Mount("", dst, "", unix.MS_BIND|unix.MS_REMOUNT|flags, "")
+}
+
+func setupDev(dev string) error {
+	if err := unix.Mount("tmpfs", dev, "tmpfs", unix.MS_NOSUID|unix.MS_NOEXEC, "mode=0755,size=64k"); err != nil {
+		return err
+	}
+	for _, name := range devNodes {
+		dst := filepath.Join(dev, name)
+		f, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY, 0o666)
+		if err != nil {
+			return err
+		}
+		_ = f.Close()
+		if err := unix.Mount("/dev/"+name, dst, "", unix.MS_BIND, ""); err != nil {
+			if name == "tty" {
+				// No controlling terminal to speak of; /dev/tty is optional.
+				_ = os.Remove(dst)
+				continue
+			}
+			return fmt.Errorf("bind %s: %w", name, err)
+		}
+	}
+	for link, target := range map[string]string{
+		"fd":     "/proc/self/fd",
+		"stdin":  "/proc/self/fd/0",
+		"stdout": "/proc/self/fd/1",
+		"stderr": "/proc/self/fd/2",
+	} {
+		if err := os.Symlink(target, filepath.Join(dev, link)); err != nil {
+			return err
+		}
+	}
+	return os.Mkdir(filepath.Join(dev, "shm"), 0o1777)
+}
+
+// bringUpLoopback enables lo in the fresh network namespace so scripts can
+// still talk to servers they start themselves.
+func bringUpLoopback() error {
+	fd, err := unix.Socket(unix.AF_INET, unix.SOCK_DGRAM|unix.SOCK_CLOEXEC, 0)
+	if err != nil {
+		return err
+	}
+	defer unix.Close(fd)
+	ifr, err := unix.NewIfreq("lo")
+	if err != nil {
+		return err
+	}
+	if err := unix.IoctlIfreq(fd, unix.SIOCGIFFLAGS, ifr); err != nil {
+		return err
+	}
+	ifr.SetUint16(ifr.Uint16() | unix.IFF_UP)
+	return unix.IoctlIfreq(fd, unix.SIOCSIFFLAGS, ifr)
+}
+
+// setRlimits applies the limits cgroups do not cover.
+func setRlimits() error {
+	if err := unix.Setrlimit(unix.RLIMIT_CORE, &unix.Rlimit{}); err != nil {
+		return err
+	}
+	return unix.Setrlimit(unix.RLIMIT_NOFILE, &unix.Rlimit{Cur: 1024, Max: 1024})
+}
+
+// dropCapabilities clears every capability set and sets no_new_privs, so
+// neither the command nor anything it execs can regain privilege — the
+// sandbox's "root" is root in name only.
+func dropCapabilities() error {
+	if err := unix.Prctl(unix.PR_SET_NO_NEW_PRIVS, 1, 0, 0, 0); err != nil {
+		return fmt.Errorf("no_new_privs: %w", err)
+	}
+	if err := unix.Prctl(unix.PR_CAP_AMBIENT, unix.PR_CAP_AMBIENT_CLEAR_ALL, 0, 0, 0); err != nil && !errors.Is(err, unix.EINVAL) {
+		return fmt.Errorf("clear ambient: %w", err)
+	}
+	for capability := 0; capability <= unix.CAP_LAST_CAP; capability++ {
+		if err := unix.Prctl(unix.PR_CAPBSET_DROP, uintptr(capability), 0, 0, 0); err != nil && !errors.Is(err, unix.EINVAL) {
+			return fmt.Errorf("drop bounding %d: %w", capability, err)
+		}
+	}
+	header := unix.CapUserHeader{Version: unix.LINUX_CAPABILITY_VERSION_3}
+	var data [2]unix.CapUserData
+	return unix.Capset(&header, &data[0])
+}
+
+// lookPath resolves name against the PATH in env, as execvp would.
+func lookPath(name string, env []string) (string, error) {
+	if strings.Contains(name, "/") {
+		return name, nil
+	}
+	searchPath := ""
+	for _, kv := range env {
+		if strings.HasPrefix(kv, "PATH=") {
+			searchPath = strings.TrimPrefix(kv, "PATH=")
+		}
+	}
+	for _, dir := range filepath.SplitList(searchPath) {
+		if dir == "" {
+			dir = "."
+		}
+		candidate := filepath.Join(dir, name)
+		info, err := os.Stat(candidate)
+		if err == nil && info.Mode().IsRegular() && info.Mode()&0o111 != 0 {
+			return candidate, nil
+		}
+	}
+	return "", errors.New("command not found")
+}
@@ -0,0 +1,14 @@
+//go:build !linux
+
+package nsinit
+
+import (
+	"fmt"
+	"os"
+)
+
+// Main reports that namespace sandboxes need Linux.
+func Main() {
+	fmt.Fprintln(os.Stderr, HelperName+": namespace sandboxes require Linux")
+	os.Exit(FailureExit)
+}
@@ -0,0 +1,195 @@
+//go:build linux
+
+package nsinit
+
+import (
+	"fmt"
+	"runtime"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// deniedSyscalls fail with EPERM inside the sandbox. The
+// sandbox is root of its own user namespace, so without this list it could
+// mount filesystems, nest namespaces or reach kernel attack surface that is
+// normally behind CAP_SYS_ADMIN in the initial namespace. Capabilities are
+// already dropped; the filter is the second wall.
+var deniedSyscalls = []uintptr{
+	// Filesystem and namespace manipulation.
+	unix.SYS_MOUNT, unix.SYS_UMOUNT2, unix.SYS_PIVOT_ROOT, unix.SYS_CHROOT,
+	unix.SYS_UNSHARE, unix.SYS_SETNS,
+	unix.SYS_OPEN_TREE, unix.SYS_MOVE_MOUNT, unix.SYS_FSOPEN, unix.SYS_FSCONFIG,
+	unix.SYS_FSMOUNT, unix.SYS_FSPICK, unix.SYS_MOUNT_SETATTR,
+	unix.SYS_NAME_TO_HANDLE_AT, unix.SYS_OPEN_BY_HANDLE_AT,
+	// Other processes' memory.
+	unix.SYS_PTRACE, unix.SYS_PROCESS_VM_READV, unix.SYS_PROCESS_VM_WRITEV,
+	// Kernel code and kernel-wide state.
+	unix.SYS_KEXEC_LOAD, unix.SYS_KEXEC_FILE_LOAD,
+	unix.SYS_INIT_MODULE, unix.SYS_FINIT_MODULE, unix.SYS_DELETE_MODULE,
+	unix.SYS_BPF, unix.SYS_PERF_EVENT_OPEN, unix.SYS_USERFAULTFD,
+	unix.SYS_IO_URING_SETUP, unix.SYS_IO_URING_ENTER, unix.SYS_IO_URING_REGISTER,
+	unix.SYS_KEYCTL, unix.SYS_ADD_KEY, unix.SYS_REQUEST_KEY,
+	unix.SYS_REBOOT, unix.SYS_SWAPON, unix.SYS_SWAPOFF, unix.SYS_ACCT,
+	unix.SYS_SETTIMEOFDAY, unix.SYS_CLOCK_SETTIME, unix.SYS_CLOCK_ADJTIME, unix.SYS_ADJTIMEX,
+	unix.SYS_SETHOSTNAME, unix.SYS_SETDOMAINNAME,
+	unix.SYS_QUOTACTL, unix.SYS_LOOKUP_DCOOKIE, unix.SYS_FANOTIFY_INIT,
+	unix.SYS_VHANGUP, unix.SYS_SYSLOG,
+}
+
+// namespaceCloneFlags are the clone(2) flags that create namespaces. Plain
+// fork/thread creation passes none of them.
+const namespaceCloneFlags = unix.CLONE_NEWNS | unix.CLONE_NEWCGROUP | unix.CLONE_NEWUTS |
+	unix.CLONE_NEWIPC | unix.CLONE_NEWUSER | unix.CLONE_NEWPID | unix.CLONE_NEWNET
+
+// seccomp_data offsets (include/uapi/linux/seccomp.h). args[0] is read as its
+// low 32 bits, which is where every clone flag lives on little-endian hosts.
+const (
+	seccompDataNR    = 0
+	seccompDataArch  = 4
+	seccompDataArg0  = 16
+	x32SyscallBit    = 0x40000000
+	seccompRetEPERM  = unix.SECCOMP_RET_ERRNO | uint32(unix.EPERM)
+	seccompRetENOSYS = unix.SECCOMP_RET_ERRNO | uint32(unix.ENOSYS)
+)
+
+// auditArch is the only syscall ABI the filter admits. Anything else
+// (i386 compat calls on amd64, say) would need its own syscall numbers.
+func auditArch() (uint32, error) {
+	switch runtime.GOARCH {
+	case "amd64":
+		return unix.AUDIT_ARCH_X86_64, nil
+	case "arm64":
+		return unix.AUDIT_ARCH_AARCH64, nil
+	default:
+		return 0, fmt.Errorf("namespace sandbox seccomp filter does not support %s", runtime.GOARCH)
+	}
+}
+
+// buildSeccompFilter assembles the classic-BPF program:
+//
+//	wrong arch              -> kill
+//	x32 ABI (amd64)         -> EPERM
+//	clone3                  -> ENOSYS (its flags live in memory the filter
+//	                           cannot inspect; libc falls back to clone)
+//	clone + namespace flags -> EPERM
+//	denied syscall          -> EPERM
+//	everything else         -> allow
+func buildSeccompFilter() ([]unix.SockFilter, error) {
+	arch, err := auditArch()
+	if err != nil {
+		return nil, err
+	}
+	var p bpfProgram
+	p.load(seccompDataArch)
+	p.jumpIfEqual(arch, "", "kill")
+	p.load(seccompDataNR)
+	if runtime.GOARCH == "amd64" {
+		p.jumpIfGreaterOrEqual(x32SyscallBit, "eperm", "")
+	}
+	p.jumpIfEqual(unix.SYS_CLONE3, "enosys", "")
+	p.jumpIfEqual(unix.SYS_CLONE, "clone", "")
+	for _, nr := range deniedSyscalls {
+		p.jumpIfEqual(uint32(nr), "eperm", "")
+	}
+	p.ret(unix.SECCOMP_RET_ALLOW)
+
+	p.label("clone")
+	p.load(seccompDataArg0)
+	p.jumpIfSet(namespaceCloneFlags, "eperm", "")
+	p.ret(unix.SECCOMP_RET_ALLOW)
+
+	p.label("kill")
+	p.ret(unix.SECCOMP_RET_KILL_PROCESS)
+	p.label("eperm")
+	p.ret(seccompRetEPERM)
+	p.label("enosys")
+	p.ret(seccompRetENOSYS)
+	return p.assemble()
+}
+
+// installSeccomp loads the filter into the calling thread, which
+// must already have no_new_privs set. It survives exec.
+func installSeccomp() error {
+	filter, err := buildSeccompFilter()
+	if err != nil {
+		return err
+	}
+	prog := unix.SockFprog{Len: uint16(len(filter)), Filter: &filter[0]}
+	return unix.Prctl(unix.PR_SET_SECCOMP, unix.SECCOMP_MODE_FILTER, uintptr(unsafe.Pointer(&prog)), 0, 0)
+}
+
+// bpfProgram is a tiny classic-BPF assembler with forward-only labels, just
+// enough for a seccomp filter.
+type bpfProgram struct {
+	insns  []bpfInsn
+	labels map[string]int
+}
+
+type bpfInsn struct {
+	filter    unix.SockFilter
+	trueJump  string
+	falseJump string
+}
+
+func (p *bpfProgram) load(offset uint32) {
+	p.emit(unix.BPF_LD|unix.BPF_W|unix.BPF_ABS, offset, "", "")
+}
+
+func (p *bpfProgram) jumpIfEqual(value uint32, jt, jf string) {
+	p.emit(unix.BPF_JMP|unix.BPF_JEQ|unix.BPF_K, value, jt, jf)
+}
+
+func (p *bpfProgram) jumpIfGreaterOrEqual(value uint32, jt, jf string) {
+	p.emit(unix.BPF_JMP|unix.BPF_JGE|unix.BPF_K, value, jt, jf)
+}
+
+func (p *bpfProgram) jumpIfSet(mask uint32, jt, jf string) {
+	p.emit(unix.BPF_JMP|unix.BPF_JSET|unix.BPF_K, mask, jt, jf)
+}
+
+func (p *bpfProgram) ret(value uint32) {
+	p.emit(unix.BPF_RET|unix.BPF_K, value, "", "")
+}
+
+func (p *bpfProgram) label(name string) {
+	if p.labels == nil {
+		p.labels = make(map[string]int)
+	}
+	p.labels[name] = len(p.insns)
+}
+
+func (p *bpfProgram) emit(code uint16, k uint32, jt, jf string) {
+	p.insns = append(p.insns, bpfInsn{
+		filter:    unix.SockFilter{Code: code, K: k},
+		trueJump:  jt,
+		falseJump: jf,
+	})
+}
+
+// assemble resolves labels into relative jump offsets. An empty label means
+// "fall through to the next instruction".
+func (p *bpfProgram) assemble() ([]unix.SockFilter, error) {
+	out := make([]unix.SockFilter, len(p.insns))
+	for i, insn := range p.insns {
+		out[i] = insn.filter
+		for _, jump := range []struct {
+			label string
+			dst   *uint8
+		}{{insn.trueJump, &out[i].Jt}, {insn.falseJump, &out[i].Jf}} {
+			if jump.label == "" {
+				continue
+			}
+			target, ok := p.labels[jump.label]
+			if !ok {
+				return nil, fmt.Errorf("bpf: undefined label %q", jump.label)
+			}
+			offset := target - i - 1
+			if offset < 0 || offset > 255 {
+				return nil, fmt.Errorf("bpf: jump to %q out of range (%d)", jump.label, offset)
+			}
+			*jump.dst = uint8(offset)
+		}
+	}
+	return out, nil
+}
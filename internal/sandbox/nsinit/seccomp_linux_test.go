@@ -0,0 +1,45 @@
+//go:build linux
+
+package nsinit
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/unix"
+)
+
+func TestBuildSeccompFilterResolvesJumps(t *testing.T) {
+	if _, err := auditArch(); err != nil {
+		t.Skip(err.Error())
+	}
+	filter, err := buildSeccompFilter()
+	require.NoError(t, err)
+
+	// Every jump must land inside the program, and the program must end in
+	// a return so no path falls off the end.
+	for i, insn := range filter {
+		if insn.Code&0x07 == unix.BPF_JMP {
+			require.Less(t, i+1+int(insn.Jt), len(filter))
+			require.Less(t, i+1+int(insn.Jf), len(filter))
+		}
+	}
+	require.Equal(t, uint16(unix.BPF_RET|unix.BPF_K), filter[len(filter)-1].Code)
+
+	denied := map[uint32]bool{}
+	for _, insn := range filter {
+		if insn.Code == unix.BPF_JMP|unix.BPF_JEQ|unix.BPF_K {
+			denied[insn.K] = true
+		}
+	}
+	for _, nr := range []uintptr{unix.SYS_MOUNT, unix.SYS_UNSHARE, unix.SYS_SETNS, unix.SYS_PTRACE, unix.SYS_BPF} {
+		require.True(t, denied[uint32(nr)], "syscall %d not in filter", nr)
+	}
+}
+
+func TestBPFProgramRejectsUndefinedLabel(t *testing.T) {
+	var p bpfProgram
+	p.jumpIfEqual(1, "missing", "")
+	_, err := p.assemble()
+	require.ErrorContains(t, err, "missing")
+}
@@ -0,0 +1,59 @@
+// Package nsinit is the first process of a namespace sandbox execution.
+//
+// sandbox.NamespaceRemoteClient starts the weknora-sandbox-init helper
+// (cmd/sandbox-init) in fresh user, mount, PID, IPC, UTS, cgroup and
+// optionally network namespaces. The helper calls Main, which assembles the
+// sandbox root, gives up every privilege, installs the seccomp filter and
+// execs the requested command.
+//
+// The package deliberately imports nothing beyond the standard library and
+// x/sys: everything a binary links runs its package initialisers before main,
+// and the helper must reach Main without loading WeKnora's configuration,
+// dictionaries or drivers — inside a namespace where the host's files are
+// mostly unreadable.
+package nsinit
+
+// HelperName is the helper binary's file name. The namespace client looks for
+// it next to the WeKnora executable, then on PATH.
+const HelperName = "weknora-sandbox-init"
+
+// File descriptors the parent passes to the helper.
+const (
+	// SpecFD carries the JSON-encoded Spec; the parent closes its end after
+	// writing.
+	SpecFD = 3
+	// ErrorFD is close-on-exec in the helper. Anything written to it is a
+	// setup failure; EOF without data means the command was exec'd.
+	ErrorFD = 4
+	// ExecutableFD is the helper's own binary, which the parent execs as
+	// /proc/self/fd/5 so the mapped user need not be able to traverse the
+	// directory it is installed in. The helper closes it on exec.
+	ExecutableFD = 5
+)
+
+// FailureExit is the helper's exit status when setup fails.
+const FailureExit = 125
+
+// Spec describes one sandboxed execution.
+type Spec struct {
+	// Rootfs is the host directory whose system subdirectories become the
+	// read-only sandbox root.
+	Rootfs string `json:"rootfs"`
+	// MountPoint is an empty host directory the private root is built on.
+	MountPoint string `json:"mount_point"`
+	// Workspace is the host directory mounted read-write on /workspace.
+	Workspace string `json:"workspace"`
+	// Args is the command's argv; Args[0] is resolved against Env's PATH.
+	Args []string `json:"args"`
+	// Env is the command's complete environment.
+	Env []string `json:"env"`
+	// WorkDir is the in-sandbox working directory.
+	WorkDir string `json:"work_dir"`
+	// Network is true when the execution shares the host's network
+	// namespace instead of getting a loopback-only one.
+	Network bool `json:"network"`
+	// Hostname is set in the sandbox's UTS namespace.
+	Hostname string `json:"hostname"`
+	// TmpSizeMB sizes the private /tmp.
+	TmpSizeMB int `json:"tmp_size_mb"`
+}
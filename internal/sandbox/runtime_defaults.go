@@ -25,3 +25,21 @@ func applyE2BRuntimeDefaults(cfg *Config) {
 		cfg.E2BHTTPTimeout = DefaultE2BHTTPTimeout
 	}
 }
+
+func applyNamespaceRuntimeDefaults(cfg *Config) {
+	if cfg == nil {
+		return
+	}
+	if cfg.NamespaceSandboxTTL <= 0 {
+		cfg.NamespaceSandboxTTL = DefaultNamespaceSandboxTTL
+	}
+	if cfg.NamespacePidsLimit <= 0 {
+		cfg.NamespacePidsLimit = DefaultNamespacePidsLimit
+	}
+	if cfg.NamespaceStateDir == "" {
+		cfg.NamespaceStateDir = DefaultNamespaceStateDir
+	}
+	if cfg.NamespaceRootfs == "" {
+		cfg.NamespaceRootfs = DefaultNamespaceRootfs
+	}
+}
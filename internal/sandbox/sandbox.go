@@ -24,16 +24,27 @@ const (
 	SandboxTypeCube SandboxType = "cube"
 	// SandboxTypeE2B uses E2B's hosted MicroVM sandbox service.
 	SandboxTypeE2B SandboxType = "e2b"
+	// SandboxTypeNamespace runs scripts on the WeKnora host inside unprivileged
+	// Linux user/mount/PID/network namespaces with a seccomp filter, a
+	// read-only rootfs and cgroup limits. It needs no Docker daemon and, like
+	// Cube, keeps a per-session workspace across executions.
+	SandboxTypeNamespace SandboxType = "namespace"
+	// SandboxTypeWasm runs Python and JavaScript scripts in-process under a
+	// WebAssembly runtime, using WASI builds of the interpreters. It needs
+	// neither Docker nor Linux, so it is the backend for the desktop build.
+	// Like Docker/Local it is stateless per execution.
+	SandboxTypeWasm SandboxType = "wasm"
 	// SandboxTypeDisabled means script execution is disabled
 	SandboxTypeDisabled SandboxType = "disabled"
 )
 
 // IsNamedSandboxBackendType reports whether raw can be stored as a user-facing
-// named sandbox backend. Remote backends are session-persistent; docker/local
-// are stateless, but all four share the same workspace configuration surface.
+// named sandbox backend. Remote and namespace backends are session-persistent;
+// docker/local/wasm are stateless, but all share the same workspace configuration
+// surface.
 func IsNamedSandboxBackendType(raw string) bool {
 	switch SandboxType(raw) {
-	case SandboxTypeCube, SandboxTypeE2B, SandboxTypeDocker, SandboxTypeLocal:
+	case SandboxTypeCube, SandboxTypeE2B, SandboxTypeNamespace, SandboxTypeWasm, SandboxTypeDocker, SandboxTypeLocal:
 		return true
 	default:
 		return false
@@ -88,6 +99,16 @@ const (
 	DefaultE2BSandboxTTL = 5 * time.Minute
 	// DefaultE2BHTTPTimeout bounds a single HTTP call to the E2B API.
 	DefaultE2BHTTPTimeout = 30 * time.Second
+
+	// DefaultNamespaceSandboxTTL is how long an idle namespace sandbox keeps
+	// its workspace before the next lifecycle call reclaims it.
+	DefaultNamespaceSandboxTTL = 30 * time.Minute
+	// DefaultNamespacePidsLimit caps the processes one namespace sandbox may
+	// run at once, matching the Docker backend's --pids-limit.
+	DefaultNamespacePidsLimit = 100
+	// DefaultNamespaceProbeTimeout bounds the namespace backend's health probe,
+	// which spawns one throwaway process.
+	DefaultNamespaceProbeTimeout = 15 * time.Second
 )
 
 // Common errors
@@ -151,7 +172,8 @@ type ExecuteConfig struct {
 	// Env is additional environment variables
 	Env map[string]string
 
-	// AllowNetwork enables network access (Docker only)
+	// AllowNetwork enables network access (Docker only; the wasm backend
+	// refuses it)
 	AllowNetwork bool
 
 	// MemoryLimit is the maximum memory in bytes (Docker only)
@@ -292,6 +314,33 @@ type Config struct {
 
 	// E2BHTTPTimeout bounds each HTTP call to the E2B API.
 	E2BHTTPTimeout time.Duration
+
+	// NamespaceStateDir, NamespaceRootfs, NamespaceCgroupRoot and
+	// NamespaceInitPath locate the namespace backend's host resources. They
+	// describe the WeKnora host, not a backend identity, so they come from
+	// NamespaceHostConfig and are never taken from a workspace config. See
+	// namespace.go.
+	NamespaceStateDir   string
+	NamespaceRootfs     string
+	NamespaceCgroupRoot string
+	NamespaceInitPath   string
+
+	// NamespaceAllowNetwork keeps the host network namespace. When false the
+	// sandbox only sees its own loopback interface.
+	NamespaceAllowNetwork bool
+
+	// NamespacePidsLimit caps concurrent processes per sandbox. Only enforced
+	// when NamespaceCgroupRoot is set.
+	NamespacePidsLimit int
+
+	// NamespaceSandboxTTL is the idle time after which a namespace sandbox's
+	// workspace is reclaimed.
+	NamespaceSandboxTTL time.Duration
+
+	// WasmRuntimeDir holds the wasm backend's interpreter modules. Like the
+	// Namespace* host fields it comes from WasmHostConfig, never from a
+	// workspace config. See wasm.go.
+	WasmRuntimeDir string
 }
 
 // DefaultConfig returns a default sandbox configuration.
@@ -310,6 +359,13 @@ func DefaultConfig() *Config {
 		MaxCPU:          DefaultCPULimit,
 		CubeSandboxTTL:  DefaultCubeSandboxTTL,
 		CubeHTTPTimeout: DefaultCubeHTTPTimeout,
+
+		NamespaceStateDir:   namespaceHost.StateDir,
+		NamespaceRootfs:     namespaceHost.Rootfs,
+		NamespaceCgroupRoot: namespaceHost.CgroupRoot,
+		NamespaceInitPath:   namespaceHost.InitPath,
+
+		WasmRuntimeDir: wasmHost.RuntimeDir,
 	}
 }
 
@@ -346,7 +402,7 @@ func ValidateConfig(config *Config) error {
 	}
 
 	switch config.Type {
-	case SandboxTypeDocker, SandboxTypeLocal, SandboxTypeCube, SandboxTypeE2B, SandboxTypeDisabled:
+	case SandboxTypeDocker, SandboxTypeLocal, SandboxTypeCube, SandboxTypeE2B, SandboxTypeNamespace, SandboxTypeWasm, SandboxTypeDisabled:
 		// Valid types
 	default:
 		return errors.New("invalid sandbox type")
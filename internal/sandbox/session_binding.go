@@ -270,5 +270,5 @@ func validateBindingMatch(
 }
 
 func isRemoteProvider(provider RemoteProvider) bool {
-	return provider == SandboxTypeCube || provider == SandboxTypeE2B
+	return provider == SandboxTypeCube || provider == SandboxTypeE2B || provider == SandboxTypeNamespace
 }
@@ -162,6 +162,8 @@ func NewSessionBoundManager(deps SessionBoundManagerConfig) (*SessionBoundManage
 		applyCubeRuntimeDefaults(cfg)
 	case SandboxTypeE2B:
 		applyE2BRuntimeDefaults(cfg)
+	case SandboxTypeNamespace:
+		applyNamespaceRuntimeDefaults(cfg)
 	}
 
 	// Build the provider-specific neutral create request using the
@@ -818,6 +820,25 @@ func buildSessionCreateRequest(provider RemoteProvider, cfg *Config) (RemoteCrea
 			},
 		}, nil
 
+	case SandboxTypeNamespace:
+		// The namespace client reclaims idle workspaces itself; there is no
+		// paused state to resume from.
+		ttl := cfg.NamespaceSandboxTTL
+		if ttl <= 0 {
+			ttl = DefaultNamespaceSandboxTTL
+		}
+		allowNetwork := cfg.NamespaceAllowNetwork
+		return RemoteCreateRequest{
+			TemplateID: cfg.NamespaceRootfs,
+			EnvVars:    envVars,
+			Timeout: RemoteTimeoutPolicy{
+				Mode:   RemoteTimeoutExplicit,
+				Value:  ttl,
+				Action: RemoteOnTimeoutKill,
+			},
+			Network: RemoteNetworkPolicy{AllowInternetAccess: &allowNetwork},
+		}, nil
+
 	default:
 		return RemoteCreateRequest{}, fmt.Errorf(
 			"sandbox: unsupported remote provider %q for session create request",
@@ -842,6 +863,8 @@ func effectiveHTTPTimeout(provider RemoteProvider, cfg *Config) time.Duration {
 			return cfg.E2BHTTPTimeout
 		}
 		return DefaultE2BHTTPTimeout
+	case SandboxTypeNamespace:
+		return DefaultNamespaceProbeTimeout
 	default:
 		return DefaultCubeHTTPTimeout
 	}
@@ -94,11 +94,31 @@ func ResolveEffectiveConfig(
 		overrideString(&effective.DockerImage, docker.Image)
 	}
 
+	if ns := tenantCfg.Namespace; ns != nil {
+		effective.NamespaceAllowNetwork = ns.AllowNetwork
+		if ns.MemoryMB > 0 {
+			effective.MaxMemory = int64(ns.MemoryMB) * 1024 * 1024
+		}
+		if ns.CPUCores > 0 {
+			effective.MaxCPU = ns.CPUCores
+		}
+		if ns.PidsLimit > 0 {
+			effective.NamespacePidsLimit = ns.PidsLimit
+		}
+		overrideSeconds(&effective.NamespaceSandboxTTL, ns.SandboxTTLSeconds)
+	}
+
+	if w := tenantCfg.Wasm; w != nil && w.MemoryMB > 0 {
+		effective.MaxMemory = int64(w.MemoryMB) * 1024 * 1024
+	}
+
 	switch effective.Type {
 	case SandboxTypeCube:
 		applyCubeRuntimeDefaults(&effective)
 	case SandboxTypeE2B:
 		applyE2BRuntimeDefaults(&effective)
+	case SandboxTypeNamespace:
+		applyNamespaceRuntimeDefaults(&effective)
 	}
 	// Deliberately after the runtime defaults: TTLs and HTTP timeouts have
 	// built-in fallbacks, endpoints and credentials do not.
@@ -130,6 +150,13 @@ func clearProviderFields(cfg *Config) {
 	cfg.E2BTemplate = ""
 	cfg.E2BSandboxTTL = 0
 	cfg.E2BHTTPTimeout = 0
+
+	// The namespace host fields (state dir, rootfs, cgroup root, init path)
+	// stay: they describe this machine, and no workspace config may set them.
+	cfg.NamespaceAllowNetwork = false
+	cfg.NamespacePidsLimit = 0
+	cfg.NamespaceSandboxTTL = 0
+	// WasmRuntimeDir stays for the same reason.
 }
 
 // ErrUnsupportedSandboxType marks a sandbox type string we cannot honour. It is
@@ -146,6 +173,10 @@ func ParseSandboxType(raw string) (SandboxType, error) {
 		return SandboxTypeCube, nil
 	case SandboxTypeE2B:
 		return SandboxTypeE2B, nil
+	case SandboxTypeNamespace:
+		return SandboxTypeNamespace, nil
+	case SandboxTypeWasm:
+		return SandboxTypeWasm, nil
 	case SandboxTypeDocker:
 		return SandboxTypeDocker, nil
 	case SandboxTypeLocal:
@@ -167,6 +198,8 @@ func EffectiveTemplateID(cfg *Config) string {
 		return cfg.CubeTemplate
 	case SandboxTypeE2B:
 		return cfg.E2BTemplate
+	case SandboxTypeNamespace:
+		return cfg.NamespaceRootfs
 	default:
 		return ""
 	}
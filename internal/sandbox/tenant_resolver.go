@@ -179,7 +179,7 @@ func (r *tenantSandboxResolver) Resolve(
 	switch effective.Type {
 	case SandboxTypeDisabled:
 		return NewDisabledManager(), nil
-	case SandboxTypeCube, SandboxTypeE2B:
+	case SandboxTypeCube, SandboxTypeE2B, SandboxTypeNamespace:
 		client, err := r.buildClient(effective)
 		if err != nil {
 			return nil, err
@@ -192,7 +192,7 @@ func (r *tenantSandboxResolver) Resolve(
 			SkipHealthProbe: true,
 			ConfigID:        configID,
 		})
-	case SandboxTypeDocker, SandboxTypeLocal:
+	case SandboxTypeDocker, SandboxTypeLocal, SandboxTypeWasm:
 		// Stateless backends still come from the selected workspace row. Docker
 		// fallback is deliberately disabled: silently running a configured
 		// container workload on the application host would cross an isolation
@@ -221,6 +221,8 @@ func (r *tenantSandboxResolver) buildClient(cfg *Config) (RemoteSandboxClient, e
 			return NewE2BRemoteClientWithPool(cfg, r.privateGatewayTransports)
 		}
 		return NewE2BRemoteClientWithPool(cfg, r.gatewayTransports)
+	case SandboxTypeNamespace:
+		return NewNamespaceRemoteClient(cfg)
 	default:
 		return nil, fmt.Errorf("sandbox: provider %q has no remote client", cfg.Type)
 	}
@@ -248,6 +250,9 @@ func NewRemoteClientForCheck(cfg *Config) (RemoteSandboxClient, error) {
 		// routing the resolved manager will use.
 		return NewE2BRemoteClientWithPool(cfg, NewSandboxGatewayTransportPoolWithPolicy(nil,
 			OutboundURLPolicy{AllowPrivate: cfg.AllowPrivateEndpoints}))
+	case SandboxTypeNamespace:
+		// No endpoint to guard: the probe spawns a process on this host.
+		return NewNamespaceRemoteClient(cfg)
 	default:
 		return nil, fmt.Errorf("sandbox: provider %q cannot be probed", cfg.Type)
 	}
@@ -0,0 +1,87 @@
+// Command wasmfake stands in for a WASI interpreter in the wasm sandbox tests.
+// Built with GOOS=wasip1 GOARCH=wasm and installed as python.wasm, it ignores
+// the interpreter flags, finds the script argument under /workspace, and runs
+// the one-line command the script holds:
+//
+//	print TEXT | eprint TEXT | args | env KEY | read PATH | write PATH TEXT |
+//	symlink TARGET LINK | stdin | spin | alloc MB | exit CODE
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+func main() {
+	var script string
+	var rest []string
+	for i, arg := range os.Args[1:] {
+		if strings.HasPrefix(arg, "/workspace/") {
+			script, rest = arg, os.Args[i+2:]
+			break
+		}
+	}
+	data, err := os.ReadFile(script)
+	if err != nil {
+		fail(err)
+	}
+	fields := strings.SplitN(strings.TrimSpace(string(data)), " ", 3)
+	arg := func(i int) string {
+		if i < len(fields) {
+			return fields[i]
+		}
+		return ""
+	}
+	switch fields[0] {
+	case "print":
+		fmt.Println(strings.Join(fields[1:], " "))
+	case "eprint":
+		fmt.Fprintln(os.Stderr, strings.Join(fields[1:], " "))
+	case "args":
+		fmt.Println(strings.Join(rest, ","))
+	case "env":
+		fmt.Println(os.Getenv(arg(1)))
+	case "read":
+		content, err := os.ReadFile(arg(1))
+		if err != nil {
+			fail(err)
+		}
+		fmt.Print(string(content))
+	case "write":
+		if err := os.WriteFile(arg(1), []byte(arg(2)), 0o644); err != nil {
+			fail(err)
+		}
+		fmt.Println("written")
+	case "symlink":
+		if err := os.Symlink(arg(1), arg(2)); err != nil {
+			fail(err)
+		}
+		fmt.Println("linked")
+	case "stdin":
+		content, _ := io.ReadAll(os.Stdin)
+		fmt.Print(string(content))
+	case "spin":
+		for {
+		}
+	case "alloc":
+		mb, _ := strconv.Atoi(arg(1))
+		var keep [][]byte
+		for i := 0; i < mb; i++ {
+			keep = append(keep, make([]byte, 1<<20))
+		}
+		fmt.Println(len(keep))
+	case "exit":
+		code, _ := strconv.Atoi(arg(1))
+		os.Exit(code)
+	default:
+		fail(fmt.Errorf("unknown command %q", fields[0]))
+	}
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
+}
@@ -0,0 +1,434 @@
+// Package sandbox: WebAssembly backend.
+//
+// WasmSandbox runs scripts inside the WeKnora process with wazero, using WASI
+// builds of the interpreters (CPython for .py, QuickJS for .js). It needs no
+// container runtime, helper binary or kernel feature, which makes it the
+// backend for the desktop build and single-binary deployments. Each execution
+// gets a fresh module instance that sees:
+//
+//   - the script's directory read-only on /workspace, confined so that
+//     symlinks inside it cannot lead back out to the host;
+//   - an empty per-execution scratch directory on /tmp, in which links cannot
+//     be created;
+//   - the interpreter's library tree read-only on /usr, when it ships one.
+//
+// Nothing else of the host is reachable. WASI preview 1 has no way to open a
+// socket, so scripts never have network access. Linear memory is capped at the
+// configured memory limit and the module is torn down at its deadline; wazero
+// does not meter instructions, so the deadline is also the CPU budget.
+package sandbox
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tetratelabs/wazero"
+	experimentalsys "github.com/tetratelabs/wazero/experimental/sys"
+	"github.com/tetratelabs/wazero/experimental/sysfs"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+	"github.com/tetratelabs/wazero/sys"
+)
+
+const (
+	// WasmRuntimeDirName is the directory the interpreter modules are looked
+	// up in next to the WeKnora executable (or in the macOS bundle's
+	// Resources) when no runtime directory is configured.
+	WasmRuntimeDirName = "wasm-runtimes"
+
+	wasmWorkspace = "/workspace"
+	wasmPageSize  = 64 * 1024
+	wasmMaxPages  = 65536
+)
+
+// errWasmNoNetwork is returned for executions that ask for network access,
+// which WASI preview 1 cannot provide.
+var errWasmNoNetwork = errors.New("wasm sandbox cannot grant network access")
+
+// wasmInterpreter describes one WASI interpreter in the runtime directory.
+type wasmInterpreter struct {
+	// Module is the interpreter's file name in the runtime directory.
+	Module string
+	// LibDir, when present in the runtime directory, is mounted read-only on
+	// /usr. WASI CPython builds look for their standard library under
+	// /usr/local/lib.
+	LibDir string
+	// Argv builds the guest command line for the script at guestScript.
+	Argv func(guestScript string, args []string) []string
+	// Probe is a one-line script printing "weknora-ok", for the settings
+	// page's execution check.
+	Probe string
+}
+
+// wasmPythonBootstrap makes CPython behave as if started from the script's
+// directory. WASI has no inherited working directory, so without it relative
+// paths would resolve against / instead of /workspace.
+const wasmPythonBootstrap = "import os, runpy, sys; os.chdir('" + wasmWorkspace + "'); " +
+	"sys.argv = sys.argv[1:]; runpy.run_path(sys.argv[0], run_name='__main__')"
+
+var (
+	wasmPython = wasmInterpreter{
+		Module: "python.wasm",
+		LibDir: "python/usr",
+		Argv: func(script string, args []string) []string {
+			return append([]string{"python", "-c", wasmPythonBootstrap, script}, args...)
+		},
+		Probe: "print('weknora-ok')\n",
+	}
+	wasmJavaScript = wasmInterpreter{
+		Module: "qjs.wasm",
+		Argv: func(script string, args []string) []string {
+			return append([]string{"qjs", script}, args...)
+		},
+		Probe: "console.log('weknora-ok');\n",
+	}
+
+	// wasmInterpreters maps script extensions onto interpreters. Anything else
+	// (shell scripts in particular) has no WASI interpreter to run it.
+	wasmInterpreters = map[string]wasmInterpreter{
+		".py":  wasmPython,
+		".js":  wasmJavaScript,
+		".mjs": wasmJavaScript,
+	}
+)
+
+// wasmBaseEnv is the environment every execution starts from. PWD matters to
+// guests whose libc emulates a working directory from it.
+var wasmBaseEnv = map[string]string{
+	"HOME":                    "/tmp",
+	"TMPDIR":                  "/tmp",
+	"PWD":                     wasmWorkspace,
+	"LANG":                    "C.UTF-8",
+	"PYTHONDONTWRITEBYTECODE": "1",
+}
+
+// wasmCompilationCache is shared by every WasmSandbox. Managers are resolved
+// per request, and compiling CPython takes seconds, so the machine code has to
+// outlive any one of them.
+var wasmCompilationCache = wazero.NewCompilationCache()
+
+// WasmHostConfig locates the interpreter modules on this host. Like the
+// namespace backend's host settings it names a local directory only the
+// operator can vouch for, so it is set once at startup rather than stored in a
+// TenantSandboxConfig.
+type WasmHostConfig struct {
+	// RuntimeDir holds python.wasm, qjs.wasm and python/usr. Empty uses the
+	// runtimes compiled into the binary (build tag wasm_embed), then a
+	// WasmRuntimeDirName directory next to the executable.
+	RuntimeDir string
+}
+
+var wasmHost WasmHostConfig
+
+// embeddedWasmRuntimes is set by wasm_embed.go when the binary is built with
+// the wasm_embed tag.
+var embeddedWasmRuntimes fs.FS
+
+// SetWasmHostConfig installs the deployment's wasm host settings. Called once
+// by the container before any config is built; DefaultConfig copies the
+// values from then on.
+func SetWasmHostConfig(cfg WasmHostConfig) {
+	cfg.RuntimeDir = strings.TrimSpace(cfg.RuntimeDir)
+	wasmHost = cfg
+}
+
+// WasmSandbox implements the Sandbox interface with WASI interpreters running
+// in-process under wazero.
+type WasmSandbox struct {
+	config *Config
+	// runtimes is where the interpreter modules are read from, and source a
+	// human-readable name for it used in errors.
+	runtimes fs.FS
+	source   string
+}
+
+// NewWasmSandbox creates a WebAssembly sandbox. A missing runtime directory is
+// not an error here: IsAvailable reports it, and Execute names what is
+// missing.
+func NewWasmSandbox(config *Config) *WasmSandbox {
+	if config == nil {
+		config = DefaultConfig()
+	}
+	runtimes, source := resolveWasmRuntimes(config.WasmRuntimeDir)
+	return &WasmSandbox{config: config, runtimes: runtimes, source: source}
+}
+
+// Type returns the sandbox type
+func (s *WasmSandbox) Type() SandboxType {
+	return SandboxTypeWasm
+}
+
+// IsAvailable reports whether at least one interpreter module is installed.
+func (s *WasmSandbox) IsAvailable(ctx context.Context) bool {
+	return len(s.Languages()) > 0
+}
+
+// Languages lists the script extensions this sandbox can run, sorted.
+func (s *WasmSandbox) Languages() []string {
+	var exts []string
+	for ext, interp := range wasmInterpreters {
+		if s.hasModule(interp) {
+			exts = append(exts, ext)
+		}
+	}
+	sort.Strings(exts)
+	return exts
+}
+
+// ProbeScript returns a file name and content that prints "weknora-ok" with
+// an installed interpreter, preferring Python. ok is false when none is.
+func (s *WasmSandbox) ProbeScript() (name, content string, ok bool) {
+	for _, ext := range []string{".py", ".js"} {
+		if interp := wasmInterpreters[ext]; s.hasModule(interp) {
+			return "check" + ext, interp.Probe, true
+		}
+	}
+	return "", "", false
+}
+
+// Source describes where the interpreter modules are read from.
+func (s *WasmSandbox) Source() string {
+	if s.source == "" {
+		return "none"
+	}
+	return s.source
+}
+
+// Cleanup releases sandbox resources. Every execution owns and closes its own
+// runtime, so there is nothing to release.
+func (s *WasmSandbox) Cleanup(ctx context.Context) error {
+	return nil
+}
+
+// Execute runs a Python or JavaScript script in a fresh WASI module instance.
+func (s *WasmSandbox) Execute(ctx context.Context, config *ExecuteConfig) (*ExecuteResult, error) {
+	if config == nil {
+		return nil, ErrInvalidScript
+	}
+	if config.AllowNetwork {
+		return nil, errWasmNoNetwork
+	}
+	if !filepath.IsAbs(config.Script) {
+		return nil, fmt.Errorf("script path must be absolute: %s", config.Script)
+	}
+	info, err := os.Stat(config.Script)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrScriptNotFound
+		}
+		return nil, fmt.Errorf("failed to access script: %w", err)
+	}
+	if info.IsDir() {
+		return nil, ErrInvalidScript
+	}
+	interp, ok := wasmInterpreters[strings.ToLower(filepath.Ext(config.Script))]
+	if !ok {
+		return nil, fmt.Errorf("%w: the wasm sandbox only runs Python and JavaScript scripts", ErrInvalidScript)
+	}
+	module, err := fs.ReadFile(s.runtimesOrEmpty(), interp.Module)
+	if err != nil {
+		return nil, fmt.Errorf("wasm sandbox: %s is not installed in %s: %w", interp.Module, s.Source(), err)
+	}
+
+	timeout := config.Timeout
+	if timeout == 0 {
+		timeout = s.config.DefaultTimeout
+	}
+	if timeout == 0 {
+		timeout = DefaultTimeout
+	}
+	execCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	memLimit := config.MemoryLimit
+	if memLimit == 0 {
+		memLimit = s.config.MaxMemory
+	}
+	runtime := wazero.NewRuntimeWithConfig(execCtx, wazero.NewRuntimeConfig().
+		WithCompilationCache(wasmCompilationCache).
+		WithCloseOnContextDone(true).
+		WithMemoryLimitPages(wasmMemoryPages(memLimit)))
+	defer func() { _ = runtime.Close(context.WithoutCancel(ctx)) }()
+	if _, err := wasi_snapshot_preview1.Instantiate(execCtx, runtime); err != nil {
+		return nil, fmt.Errorf("wasm sandbox: instantiate WASI: %w", err)
+	}
+	compiled, err := runtime.CompileModule(execCtx, module)
+	if err != nil {
+		return nil, fmt.Errorf("wasm sandbox: compile %s: %w", interp.Module, err)
+	}
+
+	scriptDir := filepath.Dir(config.Script)
+	workspace, err := os.OpenRoot(scriptDir)
+	if err != nil {
+		return nil, fmt.Errorf("wasm sandbox: open script directory: %w", err)
+	}
+	defer workspace.Close()
+	scratch, err := os.MkdirTemp("", "weknora-wasm-*")
+	if err != nil {
+		return nil, fmt.Errorf("wasm sandbox: create scratch directory: %w", err)
+	}
+	defer func() { _ = os.RemoveAll(scratch) }()
+
+	fsConfig := wazero.NewFSConfig().(sysfs.FSConfig).
+		WithSysFSMount(&sysfs.ReadFS{FS: &sysfs.AdaptFS{FS: workspace.FS()}}, wasmWorkspace).(sysfs.FSConfig).
+		WithSysFSMount(wasmScratchFS{FS: sysfs.DirFS(scratch)}, "/tmp")
+	if interp.LibDir != "" {
+		if lib, err := fs.Sub(s.runtimes, interp.LibDir); err == nil {
+			if _, err := fs.Stat(lib, "."); err == nil {
+				fsConfig = fsConfig.WithFSMount(lib, "/usr")
+			}
+		}
+	}
+
+	var stdout, stderr bytes.Buffer
+	guestScript := path.Join(wasmWorkspace, filepath.Base(config.Script))
+	moduleConfig := wazero.NewModuleConfig().
+		WithName("").
+		WithArgs(interp.Argv(guestScript, config.Args)...).
+		WithStdin(strings.NewReader(config.Stdin)).
+		WithStdout(&stdout).
+		WithStderr(&stderr).
+		WithFSConfig(fsConfig).
+		WithSysWalltime().
+		WithSysNanotime().
+		WithSysNanosleep().
+		WithRandSource(rand.Reader)
+	for _, kv := range wasmEnv(config.Env) {
+		moduleConfig = moduleConfig.WithEnv(kv[0], kv[1])
+	}
+
+	startTime := time.Now()
+	instance, err := runtime.InstantiateModule(execCtx, compiled, moduleConfig)
+	duration := time.Since(startTime)
+	if instance != nil {
+		_ = instance.Close(context.WithoutCancel(ctx))
+	}
+
+	result := &ExecuteResult{
+		Stdout:   stdout.String(),
+		Stderr:   stderr.String(),
+		Duration: duration,
+	}
+	if err != nil {
+		var exitErr *sys.ExitError
+		switch {
+		case execCtx.Err() == context.DeadlineExceeded && ctx.Err() == nil:
+			result.Killed = true
+			result.Error = ErrTimeout.Error()
+			result.ExitCode = -1
+		case errors.As(err, &exitErr) && exitErr.ExitCode() != sys.ExitCodeContextCanceled &&
+			exitErr.ExitCode() != sys.ExitCodeDeadlineExceeded:
+			result.ExitCode = int(exitErr.ExitCode())
+		default:
+			// Traps (including running out of the memory limit) and a
+			// cancelled caller end up here.
+			result.Error = err.Error()
+			result.ExitCode = -1
+		}
+	}
+	return result, nil
+}
+
+func (s *WasmSandbox) hasModule(interp wasmInterpreter) bool {
+	if s.runtimes == nil {
+		return false
+	}
+	info, err := fs.Stat(s.runtimes, interp.Module)
+	return err == nil && info.Mode().IsRegular()
+}
+
+func (s *WasmSandbox) runtimesOrEmpty() fs.FS {
+	if s.runtimes == nil {
+		return emptyFS{}
+	}
+	return s.runtimes
+}
+
+// resolveWasmRuntimes picks where interpreter modules come from: the
+// configured directory, then modules embedded at build time, then a
+// WasmRuntimeDirName directory beside the executable or in the macOS app
+// bundle's Resources.
+func resolveWasmRuntimes(dir string) (fs.FS, string) {
+	if dir = strings.TrimSpace(dir); dir != "" {
+		return os.DirFS(dir), dir
+	}
+	if embeddedWasmRuntimes != nil {
+		return embeddedWasmRuntimes, "embedded runtimes"
+	}
+	self, err := os.Executable()
+	if err != nil {
+		return nil, ""
+	}
+	base := filepath.Dir(self)
+	for _, candidate := range []string{
+		filepath.Join(base, WasmRuntimeDirName),
+		filepath.Join(base, "..", "Resources", WasmRuntimeDirName),
+	} {
+		if info, err := os.Stat(candidate); err == nil && info.IsDir() {
+			return os.DirFS(candidate), candidate
+		}
+	}
+	return nil, ""
+}
+
+// wasmMemoryPages converts a byte limit to 64KiB pages, within what a 32-bit
+// linear memory can address. Zero keeps wazero's own maximum.
+func wasmMemoryPages(limit int64) uint32 {
+	if limit <= 0 {
+		return wasmMaxPages
+	}
+	pages := (limit + wasmPageSize - 1) / wasmPageSize
+	if pages > wasmMaxPages {
+		return wasmMaxPages
+	}
+	return uint32(pages)
+}
+
+// wasmEnv layers the call's variables over the base environment and returns
+// them sorted, so module configs are reproducible.
+func wasmEnv(callEnv map[string]string) [][2]string {
+	merged := make(map[string]string, len(wasmBaseEnv)+len(callEnv))
+	for key, value := range wasmBaseEnv {
+		merged[key] = value
+	}
+	for key, value := range callEnv {
+		if key == "" || strings.ContainsAny(key, "=\x00") || strings.ContainsRune(value, 0) {
+			continue
+		}
+		merged[key] = value
+	}
+	env := make([][2]string, 0, len(merged))
+	for key, value := range merged {
+		env = append(env, [2]string{key, value})
+	}
+	sort.Slice(env, func(i, j int) bool { return env[i][0] < env[j][0] })
+	return env
+}
+
+// wasmScratchFS is the writable /tmp mount. wazero resolves links on the
+// host, so a relative symlink such as "../../etc/passwd" would let a later open
+// leave the scratch directory; creating them is refused instead.
+type wasmScratchFS struct {
+	experimentalsys.FS
+}
+
+func (wasmScratchFS) Symlink(string, string) experimentalsys.Errno {
+	return experimentalsys.EPERM
+}
+
+// emptyFS stands in for a missing runtime directory so lookups fail with
+// fs.ErrNotExist rather than a nil dereference.
+type emptyFS struct{}
+
+func (emptyFS) Open(name string) (fs.File, error) {
+	return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+}
@@ -0,0 +1,21 @@
+//go:build wasm_embed
+
+package sandbox
+
+import (
+	"embed"
+	"io/fs"
+)
+
+// Building with -tags wasm_embed compiles the interpreter runtimes in
+// wasmrt/ into the binary, so single-binary deployments carry their own
+// sandbox. Populate wasmrt/ first (see wasmrt/README.md).
+
+//go:embed all:wasmrt
+var embeddedWasmRuntimeFiles embed.FS
+
+func init() {
+	if sub, err := fs.Sub(embeddedWasmRuntimeFiles, "wasmrt"); err == nil {
+		embeddedWasmRuntimes = sub
+	}
+}
@@ -0,0 +1,193 @@
+package sandbox
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/stretchr/testify/require"
+)
+
+// newFakeWasmSandbox builds testdata/wasmfake for wasip1 and installs it as
+// the Python interpreter, so the tests exercise the real runtime, mounts and
+// limits without shipping CPython.
+func newFakeWasmSandbox(t *testing.T) *WasmSandbox {
+	t.Helper()
+	runtimes := t.TempDir()
+	cmd := exec.Command("go", "build", "-o", filepath.Join(runtimes, wasmPython.Module), "./testdata/wasmfake")
+	cmd.Env = append(os.Environ(), "GOOS=wasip1", "GOARCH=wasm", "CGO_ENABLED=0")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Skipf("cannot build the fake WASI interpreter: %v\n%s", err, out)
+	}
+	cfg := DefaultConfig()
+	cfg.Type = SandboxTypeWasm
+	cfg.WasmRuntimeDir = runtimes
+	return NewWasmSandbox(cfg)
+}
+
+// writeWasmScript writes a fake-interpreter command as a .py script in its
+// own directory and returns the script path.
+func writeWasmScript(t *testing.T, command string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "main.py")
+	require.NoError(t, os.WriteFile(path, []byte(command), 0o644))
+	return path
+}
+
+func TestWasmSandboxRunsScript(t *testing.T) {
+	s := newFakeWasmSandbox(t)
+	ctx := context.Background()
+
+	require.True(t, s.IsAvailable(ctx))
+	require.Equal(t, []string{".py"}, s.Languages())
+	name, _, ok := s.ProbeScript()
+	require.True(t, ok)
+	require.Equal(t, "check.py", name)
+
+	result, err := s.Execute(ctx, &ExecuteConfig{Script: writeWasmScript(t, "print hello wasm")})
+	require.NoError(t, err)
+	require.Equal(t, 0, result.ExitCode)
+	require.Equal(t, "hello wasm\n", result.Stdout)
+
+	result, err = s.Execute(ctx, &ExecuteConfig{Script: writeWasmScript(t, "args"), Args: []string{"a", "b c"}})
+	require.NoError(t, err)
+	require.Equal(t, "a,b c\n", result.Stdout)
+
+	result, err = s.Execute(ctx, &ExecuteConfig{Script: writeWasmScript(t, "env GREETING"), Env: map[string]string{"GREETING": "hi"}})
+	require.NoError(t, err)
+	require.Equal(t, "hi\n", result.Stdout)
+
+	result, err = s.Execute(ctx, &ExecuteConfig{Script: writeWasmScript(t, "stdin"), Stdin: "piped"})
+	require.NoError(t, err)
+	require.Equal(t, "piped", result.Stdout)
+
+	result, err = s.Execute(ctx, &ExecuteConfig{Script: writeWasmScript(t, "exit 3")})
+	require.NoError(t, err)
+	require.Equal(t, 3, result.ExitCode)
+	require.False(t, result.Killed)
+}
+
+func TestWasmSandboxFilesystem(t *testing.T) {
+	s := newFakeWasmSandbox(t)
+	ctx := context.Background()
+
+	script := writeWasmScript(t, "read /workspace/data.txt")
+	require.NoError(t, os.WriteFile(filepath.Join(filepath.Dir(script), "data.txt"), []byte("skill data"), 0o644))
+	result, err := s.Execute(ctx, &ExecuteConfig{Script: script})
+	require.NoError(t, err)
+	require.Equal(t, "skill data", result.Stdout)
+
+	script = writeWasmScript(t, "write /workspace/out.txt x")
+	result, err = s.Execute(ctx, &ExecuteConfig{Script: script})
+	require.NoError(t, err)
+	require.NotEqual(t, 0, result.ExitCode, "the workspace is read-only")
+	_, statErr := os.Stat(filepath.Join(filepath.Dir(script), "out.txt"))
+	require.True(t, os.IsNotExist(statErr))
+
+	result, err = s.Execute(ctx, &ExecuteConfig{Script: writeWasmScript(t, "write /tmp/out.txt x")})
+	require.NoError(t, err)
+	require.Equal(t, 0, result.ExitCode, result.Stderr)
+
+	result, err = s.Execute(ctx, &ExecuteConfig{Script: writeWasmScript(t, "symlink ../../../../etc/passwd /tmp/escape")})
+	require.NoError(t, err)
+	require.NotEqual(t, 0, result.ExitCode, "links in the scratch dir could leave it")
+
+	for _, outside := range []string{"/etc/passwd", "/workspace/../etc/passwd", "/tmp/../etc/passwd"} {
+		result, err = s.Execute(ctx, &ExecuteConfig{Script: writeWasmScript(t, "read "+outside)})
+		require.NoError(t, err)
+		require.NotEqual(t, 0, result.ExitCode, outside)
+	}
+}
+
+// A symlink planted in the skill directory must not expose the host either.
+func TestWasmSandboxWorkspaceSymlinkConfined(t *testing.T) {
+	s := newFakeWasmSandbox(t)
+
+	script := writeWasmScript(t, "read /workspace/leak")
+	secret := filepath.Join(t.TempDir(), "secret.txt")
+	require.NoError(t, os.WriteFile(secret, []byte("secret"), 0o644))
+	require.NoError(t, os.Symlink(secret, filepath.Join(filepath.Dir(script), "leak")))
+
+	result, err := s.Execute(context.Background(), &ExecuteConfig{Script: script})
+	require.NoError(t, err)
+	require.NotEqual(t, 0, result.ExitCode)
+	require.NotContains(t, result.Stdout, "secret")
+}
+
+func TestWasmSandboxLimits(t *testing.T) {
+	s := newFakeWasmSandbox(t)
+	ctx := context.Background()
+
+	start := time.Now()
+	result, err := s.Execute(ctx, &ExecuteConfig{Script: writeWasmScript(t, "spin"), Timeout: 500 * time.Millisecond})
+	require.NoError(t, err)
+	require.True(t, result.Killed)
+	require.Equal(t, ErrTimeout.Error(), result.Error)
+	require.Less(t, time.Since(start), 10*time.Second)
+
+	result, err = s.Execute(ctx, &ExecuteConfig{Script: writeWasmScript(t, "alloc 256"), MemoryLimit: 64 * 1024 * 1024})
+	require.NoError(t, err)
+	require.NotEqual(t, 0, result.ExitCode, "allocation past the memory cap must fail")
+	require.False(t, result.Killed)
+}
+
+func TestWasmSandboxRejects(t *testing.T) {
+	s := newFakeWasmSandbox(t)
+	ctx := context.Background()
+
+	_, err := s.Execute(ctx, &ExecuteConfig{Script: writeWasmScript(t, "print x"), AllowNetwork: true})
+	require.ErrorIs(t, err, errWasmNoNetwork)
+
+	sh := filepath.Join(t.TempDir(), "run.sh")
+	require.NoError(t, os.WriteFile(sh, []byte("echo hi\n"), 0o644))
+	_, err = s.Execute(ctx, &ExecuteConfig{Script: sh})
+	require.ErrorIs(t, err, ErrInvalidScript)
+
+	js := filepath.Join(t.TempDir(), "main.js")
+	require.NoError(t, os.WriteFile(js, []byte("console.log(1)\n"), 0o644))
+	_, err = s.Execute(ctx, &ExecuteConfig{Script: js})
+	require.Error(t, err)
+	require.True(t, strings.Contains(err.Error(), "qjs.wasm"), err.Error())
+
+	_, err = s.Execute(ctx, &ExecuteConfig{Script: filepath.Join(t.TempDir(), "missing.py")})
+	require.True(t, errors.Is(err, ErrScriptNotFound))
+}
+
+func TestWasmSandboxUnavailableWithoutRuntimes(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Type = SandboxTypeWasm
+	cfg.WasmRuntimeDir = t.TempDir()
+
+	require.False(t, NewWasmSandbox(cfg).IsAvailable(context.Background()))
+	_, err := NewManager(cfg)
+	require.Error(t, err)
+}
+
+func TestWasmMemoryPages(t *testing.T) {
+	require.Equal(t, uint32(wasmMaxPages), wasmMemoryPages(0))
+	require.Equal(t, uint32(1), wasmMemoryPages(1))
+	require.Equal(t, uint32(1024), wasmMemoryPages(64*1024*1024))
+	require.Equal(t, uint32(wasmMaxPages), wasmMemoryPages(1<<40))
+}
+
+func TestResolveEffectiveConfigWasm(t *testing.T) {
+	global := DefaultConfig()
+	global.WasmRuntimeDir = "/opt/weknora/wasm-runtimes"
+
+	got, err := ResolveEffectiveConfig(&types.TenantSandboxConfig{
+		SandboxType: "wasm",
+		Wasm:        &types.WasmSandboxConfig{MemoryMB: 128},
+	}, global)
+
+	require.NoError(t, err)
+	require.Equal(t, SandboxTypeWasm, got.Type)
+	require.Equal(t, "/opt/weknora/wasm-runtimes", got.WasmRuntimeDir)
+	require.Equal(t, int64(128*1024*1024), got.MaxMemory)
+	require.True(t, IsNamedSandboxBackendType("wasm"))
+}
@@ -95,3 +95,37 @@ func ConvertWebSearchResults(
 
 	return results
 }
+
+// ConvertGraphCommunityPoints converts global search map points into
+// []*types.SearchResult so they flow through merge, rerank and the answer
+// prompt like any other reference. Each point is its own "knowledge", keyed
+// by community, so merging never folds points of different communities.
+func ConvertGraphCommunityPoints(points []*types.GraphGlobalSearchPoint) []*types.SearchResult {
+	results := make([]*types.SearchResult, 0, len(points))
+	for i, point := range points {
+		if point == nil {
+			continue
+		}
+		knowledgeID := "community:" + point.CommunityID
+		results = append(results, &types.SearchResult{
+			ID:              fmt.Sprintf("%s:%d", knowledgeID, i),
+			Content:         point.Description,
+			KnowledgeID:     knowledgeID,
+			ChunkIndex:      i,
+			KnowledgeTitle:  point.CommunityTitle,
+			EndAt:           utf8.RuneCountInString(point.Description),
+			Seq:             i + 1,
+			Score:           float64(point.Score) / 100,
+			MatchType:       types.MatchTypeCommunity,
+			SubChunkID:      []string{},
+			KnowledgeBaseID: point.KnowledgeBaseID,
+			Metadata: map[string]string{
+				"community_id": point.CommunityID,
+				"level":        fmt.Sprintf("%d", point.Level),
+			},
+			ChunkType:       string(types.ChunkTypeCommunityReport),
+			KnowledgeSource: "graph_community",
+		})
+	}
+	return results
+}
@@ -0,0 +1,226 @@
+package searchutil
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"math/bits"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Near-duplicate fingerprints. Text is normalized (lowercased, punctuation
+// and whitespace dropped) and cut into overlapping character shingles, which
+// works the same for CJK and space-separated languages. Documents get a
+// MinHash signature, whose matching positions estimate Jaccard similarity of
+// the shingle sets; chunks get a 64-bit SimHash, compared by Hamming distance.
+
+const (
+	// ShingleSize is the number of runes per shingle.
+	ShingleSize = 5
+	// MinHashSize is the number of hash functions in a MinHash signature.
+	MinHashSize = 128
+	// MinHashBands and MinHashBandRows split a signature for locality
+	// sensitive hashing: two documents become candidates when all rows of
+	// any band match, which is likely above roughly 0.7 similarity.
+	MinHashBands    = 16
+	MinHashBandRows = MinHashSize / MinHashBands
+	// SimHashNearDistance is the largest Hamming distance at which two
+	// SimHashes are considered near-duplicates.
+	SimHashNearDistance = 3
+)
+
+// minHashSeeds are the per-function seeds of the MinHash family. They are
+// fixed so signatures stay comparable across processes and releases.
+var minHashSeeds = func() [MinHashSize]uint64 {
+	var seeds [MinHashSize]uint64
+	state := uint64(0x5eed_f1e1_d0c5_a11e)
+	for i := range seeds {
+		state += 0x9e3779b97f4a7c15
+		seeds[i] = mix64(state)
+	}
+	return seeds
+}()
+
+// mix64 is the splitmix64 finalizer.
+func mix64(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
+
+// FingerprintText keeps the letters and digits of s, lowercased. It is the
+// text shingles are cut from, so two contents with equal FingerprintText
+// always get equal fingerprints.
+func FingerprintText(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	for _, r := range s {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(unicode.ToLower(r))
+		}
+	}
+	return b.String()
+}
+
+// Shingles returns the distinct hashed character shingles of content.
+// Content shorter than one shingle yields a single shingle; content with no
+// letters or digits yields none.
+func Shingles(content string) []uint64 {
+	text := FingerprintText(content)
+	if text == "" {
+		return nil
+	}
+	// Byte offset of every rune start, plus the end, so shingles are
+	// substrings that can be hashed without allocating.
+	offsets := make([]int, 0, utf8.RuneCountInString(text)+1)
+	for i := range text {
+		offsets = append(offsets, i)
+	}
+	offsets = append(offsets, len(text))
+	runes := len(offsets) - 1
+	if runes <= ShingleSize {
+		return []uint64{hashString(text)}
+	}
+	seen := make(map[uint64]struct{}, runes-ShingleSize+1)
+	out := make([]uint64, 0, runes-ShingleSize+1)
+	for i := 0; i+ShingleSize <= runes; i++ {
+		h := hashString(text[offsets[i]:offsets[i+ShingleSize]])
+		if _, ok := seen[h]; ok {
+			continue
+		}
+		seen[h] = struct{}{}
+		out = append(out, h)
+	}
+	return out
+}
+
+// hashString is FNV-1a over s.
+func hashString(s string) uint64 {
+	const (
+		offset64 = 14695981039346656037
+		prime64  = 1099511628211
+	)
+	h := uint64(offset64)
+	for i := 0; i < len(s); i++ {
+		h ^= uint64(s[i])
+		h *= prime64
+	}
+	return h
+}
+
+// MinHash returns the MinHash signature of content, or nil when content has
+// no letters or digits.
+func MinHash(content string) []uint32 {
+	shingles := Shingles(content)
+	if len(shingles) == 0 {
+		return nil
+	}
+	var mins [MinHashSize]uint64
+	for i := range mins {
+		mins[i] = ^uint64(0)
+	}
+	for _, sh := range shingles {
+		for i, seed := range minHashSeeds {
+			if v := mix64(sh ^ seed); v < mins[i] {
+				mins[i] = v
+			}
+		}
+	}
+	sig := make([]uint32, MinHashSize)
+	for i, v := range mins {
+		sig[i] = uint32(v >> 32)
+	}
+	return sig
+}
+
+// MinHashSimilarity estimates the Jaccard similarity of the shingle sets
+// behind two signatures. Signatures of different sizes compare as 0.
+func MinHashSimilarity(a, b []uint32) float64 {
+	if len(a) == 0 || len(a) != len(b) {
+		return 0
+	}
+	same := 0
+	for i := range a {
+		if a[i] == b[i] {
+			same++
+		}
+	}
+	return float64(same) / float64(len(a))
+}
+
+// MinHashBandKeys returns one key per LSH band of sig. Documents sharing any
+// key are candidate near-duplicates.
+func MinHashBandKeys(sig []uint32) []int64 {
+	if len(sig) != MinHashSize {
+		return nil
+	}
+	keys := make([]int64, 0, MinHashBands)
+	buf := make([]byte, 4)
+	for band := 0; band < MinHashBands; band++ {
+		h := fnv.New64a()
+		buf[0] = byte(band)
+		h.Write(buf[:1])
+		for _, v := range sig[band*MinHashBandRows : (band+1)*MinHashBandRows] {
+			binary.LittleEndian.PutUint32(buf, v)
+			h.Write(buf)
+		}
+		keys = append(keys, int64(h.Sum64()))
+	}
+	return keys
+}
+
+// SimHash returns the 64-bit SimHash of content, or 0 when content has no
+// letters or digits.
+func SimHash(content string) uint64 {
+	shingles := Shingles(content)
+	if len(shingles) == 0 {
+		return 0
+	}
+	var weights [64]int
+	for _, sh := range shingles {
+		h := mix64(sh)
+		for bit := 0; bit < 64; bit++ {
+			if h&(1<<uint(bit)) != 0 {
+				weights[bit]++
+			} else {
+				weights[bit]--
+			}
+		}
+	}
+	var out uint64
+	for bit, w := range weights {
+		if w > 0 {
+			out |= 1 << uint(bit)
+		}
+	}
+	if out == 0 {
+		// 0 means "no fingerprint"; keep real fingerprints distinguishable.
+		out = 1
+	}
+	return out
+}
+
+// SimHashDistance is the Hamming distance between two SimHashes.
+func SimHashDistance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+// SimHashSimilarity maps a SimHash distance to [0, 1].
+func SimHashSimilarity(a, b uint64) float64 {
+	return 1 - float64(SimHashDistance(a, b))/64
+}
+
+// SimHashBlocks splits h into four 16-bit blocks tagged with their position.
+// Two SimHashes within SimHashNearDistance share at least one block, so
+// grouping by block finds every near pair.
+func SimHashBlocks(h uint64) [4]uint32 {
+	var out [4]uint32
+	for i := range out {
+		out[i] = uint32(i)<<16 | uint32((h>>(16*uint(i)))&0xffff)
+	}
+	return out
+}
@@ -0,0 +1,91 @@
+package searchutil
+
+import (
+	"strings"
+	"testing"
+)
+
+const fingerprintSample = "Retrieval augmented generation grounds the answers of a language model " +
+	"in documents fetched from a knowledge base at question time. The retriever ranks " +
+	"chunks by vector similarity and keyword overlap, a reranker reorders the best " +
+	"candidates, and the model writes an answer that cites the chunks it used."
+
+func TestFingerprintText_IgnoresCaseAndPunctuation(t *testing.T) {
+	if got := FingerprintText("Hello, World! 你好。"); got != "helloworld你好" {
+		t.Fatalf("FingerprintText = %q", got)
+	}
+	if Shingles("  ... !!! ") != nil {
+		t.Fatal("content without letters or digits must have no shingles")
+	}
+}
+
+func TestMinHash_SimilarityTracksEdits(t *testing.T) {
+	base := MinHash(fingerprintSample)
+	if got := MinHashSimilarity(base, MinHash(strings.ToUpper(fingerprintSample))); got != 1 {
+		t.Fatalf("case-only change similarity = %v, want 1", got)
+	}
+
+	edited := strings.Replace(fingerprintSample, "reorders the best", "re-sorts the top", 1)
+	near := MinHashSimilarity(base, MinHash(edited))
+	if near < 0.8 || near == 1 {
+		t.Fatalf("small edit similarity = %v, want in [0.8, 1)", near)
+	}
+
+	other := MinHash("Quarterly revenue grew in every region, led by strong demand for cloud storage " +
+		"and a recovery in consumer hardware sales after two weak seasons.")
+	if far := MinHashSimilarity(base, other); far > 0.2 {
+		t.Fatalf("unrelated text similarity = %v, want <= 0.2", far)
+	}
+}
+
+func TestMinHashBandKeys_ShareBandForNearDuplicates(t *testing.T) {
+	edited := strings.Replace(fingerprintSample, "keyword overlap", "keyword matches", 1)
+	a := MinHashBandKeys(MinHash(fingerprintSample))
+	b := MinHashBandKeys(MinHash(edited))
+	if len(a) != MinHashBands {
+		t.Fatalf("band keys = %d, want %d", len(a), MinHashBands)
+	}
+	shared := false
+	for i := range a {
+		if a[i] == b[i] {
+			shared = true
+		}
+	}
+	if !shared {
+		t.Fatal("near-duplicates must share at least one band")
+	}
+}
+
+func TestSimHash_NearAndFar(t *testing.T) {
+	base := SimHash(fingerprintSample)
+	if base == 0 {
+		t.Fatal("SimHash of text must not be 0")
+	}
+	if SimHash("!!!") != 0 {
+		t.Fatal("SimHash of content without letters or digits must be 0")
+	}
+	edited := strings.Replace(fingerprintSample, "at question time", "at query time", 1)
+	if d := SimHashDistance(base, SimHash(edited)); d > 8 {
+		t.Fatalf("small edit distance = %d, want <= 8", d)
+	}
+	other := SimHash("Quarterly revenue grew in every region, led by strong demand for cloud storage.")
+	if d := SimHashDistance(base, other); d <= SimHashNearDistance {
+		t.Fatalf("unrelated text distance = %d, want > %d", d, SimHashNearDistance)
+	}
+}
+
+func TestSimHashBlocks_NearHashesShareBlock(t *testing.T) {
+	h := SimHash(fingerprintSample)
+	// Flip SimHashNearDistance bits spread over different blocks.
+	near := h ^ (1 | 1<<20 | 1<<40)
+	a, b := SimHashBlocks(h), SimHashBlocks(near)
+	shared := false
+	for i := range a {
+		if a[i] == b[i] {
+			shared = true
+		}
+	}
+	if !shared {
+		t.Fatal("hashes within SimHashNearDistance must share a block")
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 
+	filesvc "github.com/Tencent/WeKnora/internal/application/service/file"
 	"github.com/Tencent/WeKnora/internal/types"
 	"github.com/Tencent/WeKnora/internal/types/interfaces"
 )
@@ -25,6 +26,7 @@ func NewRequestRewriter(
 	mode Mode,
 	defaultSvc interfaces.FileService,
 	storageResolver interfaces.StorageBackendResolver,
+	enc *filesvc.Encryption,
 ) *Rewriter {
 	if mode != ModePublic {
 		return NewRewriter(nil, "API")
@@ -34,7 +36,7 @@ func NewRequestRewriter(
 	if storageResolver != nil {
 		resolvers = append(resolvers, storageResolver)
 	}
-	resolver := NewFileServiceResolver(tenant, defaultSvc, resolvers...).WithContext(ctx)
+	resolver := NewFileServiceResolver(tenant, defaultSvc, enc, resolvers...).WithContext(ctx)
 	return NewRewriter(resolver, "API")
 }
 
@@ -14,12 +14,12 @@ func publicRewriter(url string) *Rewriter {
 }
 
 func TestNewRequestRewriter_HandleModeIsDisabled(t *testing.T) {
-	w := NewRequestRewriter(context.Background(), ModeHandle, &stubFileService{}, nil)
+	w := NewRequestRewriter(context.Background(), ModeHandle, &stubFileService{}, nil, nil)
 	assert.False(t, w.Enabled(), "the default mode must not resolve anything")
 }
 
 func TestNewRequestRewriter_PublicModeIsEnabled(t *testing.T) {
-	w := NewRequestRewriter(context.Background(), ModePublic, &stubFileService{}, nil)
+	w := NewRequestRewriter(context.Background(), ModePublic, &stubFileService{}, nil, nil)
 	require.True(t, w.Enabled())
 	assert.Equal(t, "https://cdn.example.com/resource://xifDo7NTSL300Lp1goVutw",
 		w.Ref(context.Background(), "resource://xifDo7NTSL300Lp1goVutw"))
@@ -30,21 +30,25 @@ type FileServiceResolver struct {
 	tenant          *types.Tenant
 	defaultSvc      interfaces.FileService
 	storageResolver interfaces.StorageBackendResolver
+	encryption      *filesvc.Encryption
 	ctx             context.Context
 	cache           map[string]interfaces.FileService
 }
 
 // NewFileServiceResolver builds a resolver for tenant. defaultSvc is the
 // process-wide FileService used for `resource://` handles and as the fallback
-// when tenant storage config is missing.
+// when tenant storage config is missing. enc wraps the services built from
+// tenant storage config.
 func NewFileServiceResolver(
 	tenant *types.Tenant,
 	defaultSvc interfaces.FileService,
+	enc *filesvc.Encryption,
 	storageResolvers ...interfaces.StorageBackendResolver,
 ) *FileServiceResolver {
 	resolver := &FileServiceResolver{
 		tenant:     tenant,
 		defaultSvc: defaultSvc,
+		encryption: enc,
 		ctx:        context.Background(),
 		cache:      make(map[string]interfaces.FileService),
 	}
@@ -90,7 +94,7 @@ func (r *FileServiceResolver) ResolveFileService(filePath string) interfaces.Fil
 		logger.Warnf(r.ctx, "resolve storage backend failed: backend_id=%s provider=%s err=%v",
 			backendID, provider, err)
 	}
-	svc := BuildFileServiceForProvider(r.tenant, provider, r.defaultSvc)
+	svc := BuildFileServiceForProvider(r.tenant, provider, r.defaultSvc, r.encryption)
 	if svc != nil {
 		r.cache[cacheKey] = svc
 	}
@@ -106,6 +110,7 @@ func BuildFileServiceForProvider(
 	tenant *types.Tenant,
 	provider string,
 	defaultSvc interfaces.FileService,
+	enc *filesvc.Encryption,
 ) interfaces.FileService {
 	baseDir := LocalStorageBaseDir()
 	var sec *types.StorageEngineConfig
@@ -113,13 +118,13 @@ func BuildFileServiceForProvider(
 		sec = tenant.StorageEngineConfig
 	}
 
-	svc, _, err := filesvc.NewFileServiceFromStorageConfig(provider, sec, baseDir)
+	svc, _, err := filesvc.NewFileServiceFromStorageConfig(provider, sec, baseDir, enc)
 	if err == nil {
 		return svc
 	}
 	if provider == "local" {
 		externalURL := strings.TrimSpace(os.Getenv("APP_EXTERNAL_URL"))
-		return filesvc.NewLocalFileService(baseDir, externalURL)
+		return enc.Wrap(filesvc.NewLocalFileService(baseDir, externalURL))
 	}
 	if defaultSvc != nil {
 		return defaultSvc
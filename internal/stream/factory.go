@@ -23,7 +23,7 @@ func NewStreamManager() (interfaces.StreamManager, error) {
 			db = 0
 		}
 		ttl := time.Hour // 默认1小时
-		return NewRedisStreamManager(
+		m, err := NewRedisStreamManager(
 			os.Getenv("REDIS_ADDR"),
 			os.Getenv("REDIS_USERNAME"),
 			os.Getenv("REDIS_PASSWORD"),
@@ -31,7 +31,11 @@ func NewStreamManager() (interfaces.StreamManager, error) {
 			os.Getenv("REDIS_PREFIX"),
 			ttl,
 		)
+		if err != nil {
+			return nil, err
+		}
+		return withTracing(m), nil
 	default:
-		return NewMemoryStreamManager(), nil
+		return withTracing(NewMemoryStreamManager()), nil
 	}
 }
@@ -114,5 +114,15 @@ func (m *MemoryStreamManager) GetEvents(
 	return eventsCopy, nextOffset, nil
 }
 
+// DeleteSessionEvents removes the streams of all messages of a session
+func (m *MemoryStreamManager) DeleteSessionEvents(ctx context.Context, sessionID string) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	deleted := len(m.streams[sessionID])
+	delete(m.streams, sessionID)
+	return deleted, nil
+}
+
 // Ensure MemoryStreamManager implements StreamManager interface
 var _ interfaces.StreamManager = (*MemoryStreamManager)(nil)
@@ -130,6 +130,26 @@ func (r *RedisStreamManager) GetEvents(
 	return events, nextOffset, nil
 }
 
+// DeleteSessionEvents removes the event lists of all messages of a session.
+func (r *RedisStreamManager) DeleteSessionEvents(ctx context.Context, sessionID string) (int, error) {
+	var keys []string
+	iter := r.client.Scan(ctx, 0, r.buildKey(sessionID, "*"), 100).Iterator()
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+	}
+	if err := iter.Err(); err != nil {
+		return 0, fmt.Errorf("failed to scan session streams: %w", err)
+	}
+	if len(keys) == 0 {
+		return 0, nil
+	}
+	deleted, err := r.client.Del(ctx, keys...).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete session streams: %w", err)
+	}
+	return int(deleted), nil
+}
+
 // Close closes the Redis connection
 func (r *RedisStreamManager) Close() error {
 	return r.client.Close()
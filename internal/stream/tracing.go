@@ -0,0 +1,55 @@
+package stream
+
+import (
+	"context"
+
+	"github.com/Tencent/WeKnora/internal/tracing/otlp"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracingStreamManager carries trace context through a stream. The producer
+// (the chat pipeline or agent writing events) and the reader (often an SSE
+// handler on another replica) run in different requests, so each event is
+// stamped with the producer's traceparent and a reader starting from the
+// beginning links its span to it.
+type tracingStreamManager struct {
+	interfaces.StreamManager
+}
+
+func withTracing(m interfaces.StreamManager) interfaces.StreamManager {
+	return &tracingStreamManager{StreamManager: m}
+}
+
+// AppendEvent stamps the active otlp span on the event.
+func (t *tracingStreamManager) AppendEvent(
+	ctx context.Context,
+	sessionID, messageID string,
+	event interfaces.StreamEvent,
+) error {
+	if event.Traceparent == "" {
+		event.Traceparent = otlp.Traceparent(ctx)
+	}
+	return t.StreamManager.AppendEvent(ctx, sessionID, messageID, event)
+}
+
+// GetEvents links the reader's span to the producer of the stream. Only the
+// read from offset 0 links: readers poll, and one link per poll would bloat
+// the span without saying anything new.
+func (t *tracingStreamManager) GetEvents(
+	ctx context.Context,
+	sessionID, messageID string,
+	fromOffset int,
+) ([]interfaces.StreamEvent, int, error) {
+	events, next, err := t.StreamManager.GetEvents(ctx, sessionID, messageID, fromOffset)
+	if err != nil || fromOffset != 0 {
+		return events, next, err
+	}
+	for _, ev := range events {
+		if sc := otlp.SpanContextFromTraceparent(ev.Traceparent); sc.IsValid() {
+			otlp.SpanFromContext(ctx).AddLink(trace.Link{SpanContext: sc})
+			break
+		}
+	}
+	return events, next, err
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"strconv"
 
+	"github.com/Tencent/WeKnora/internal/tracing/otlp"
 	"github.com/Tencent/WeKnora/internal/types"
 	"github.com/hibiken/asynq"
 	"go.opentelemetry.io/otel/propagation"
@@ -22,18 +23,25 @@ import (
 // processing. This also makes a sop3 run's traceparent propagate through to
 // any asynq jobs WeKnora enqueues while serving sop3's agent-chat call.
 //
-// Safe to call unconditionally: when Langfuse is disabled or no span is
+// It also stamps the otlp span's traceparent, so OTLP export works whether
+// or not Langfuse is enabled; this is the single enqueue-side hook every
+// producer already calls.
+//
+// Safe to call unconditionally: when tracing is disabled or no span is
 // present on ctx, it writes a zero-valued TracingContext — which round-trips
 // through JSON as absent fields and costs nothing.
 func InjectTracing(ctx context.Context, carrier types.LangfuseTracingCarrier) {
 	if carrier == nil {
 		return
 	}
+	tc := types.TracingContext{Traceparent: otlp.Traceparent(ctx)}
 	mgr := GetManager()
 	if !mgr.Enabled() {
+		if tc.Traceparent != "" {
+			carrier.SetLangfuseTracing(tc)
+		}
 		return
 	}
-	tc := types.TracingContext{}
 	c := propagation.MapCarrier{}
 	propagator.Inject(ctx, c)
 	tc.LangfuseTraceparent = c["traceparent"]
@@ -0,0 +1,90 @@
+// Package otlp exports WeKnora's operational spans — HTTP requests, chat
+// pipeline stages, agent rounds and tool calls, model calls, retrieval and
+// asynq tasks — to any OpenTelemetry backend (Jaeger, Tempo, an OTel
+// Collector, ...) over OTLP.
+//
+// It is independent of the langfuse package: Langfuse records LLM-centric
+// traces with prompts and completions, while this package records small,
+// attribute-only spans for latency analysis across services. Both may run at
+// the same time and then share trace ids. To keep both trees intact, this
+// package tracks its own parent chain under types.OTLPSpanContextKey instead
+// of relying only on the span stored by trace.ContextWithSpan, which Langfuse
+// spans also occupy.
+//
+// Like langfuse, the integration is opt-in and never touches the global OTel
+// TracerProvider or propagator; when disabled every entry point is a cheap
+// no-op.
+package otlp
+
+import (
+	"os"
+	"strconv"
+	"strings"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// Protocols accepted in OTEL_EXPORTER_OTLP_PROTOCOL /
+// OTEL_EXPORTER_OTLP_TRACES_PROTOCOL.
+const (
+	ProtocolHTTP = "http/protobuf"
+	ProtocolGRPC = "grpc"
+)
+
+// Config controls the OTLP exporter. Endpoint, headers, TLS and timeouts are
+// not part of it: the exporters read the standard OTEL_EXPORTER_OTLP_*
+// variables themselves, so every option documented by OpenTelemetry works
+// unchanged.
+type Config struct {
+	// Enabled is set when an OTLP endpoint is configured and the SDK is not
+	// disabled via OTEL_SDK_DISABLED.
+	Enabled bool
+	// ServiceName becomes the service.name resource attribute.
+	ServiceName string
+	// Protocol is ProtocolHTTP (default) or ProtocolGRPC.
+	Protocol string
+	// SampleRate (0..1) is the ratio of new root traces that are sampled.
+	// Child spans always follow their parent's decision.
+	SampleRate float64
+
+	// testExporter replaces the real exporter in tests; Init exports to it
+	// synchronously on span End.
+	testExporter sdktrace.SpanExporter
+}
+
+// LoadConfigFromEnv builds a Config from the standard OpenTelemetry
+// environment variables. Export is enabled by setting
+// OTEL_EXPORTER_OTLP_ENDPOINT or OTEL_EXPORTER_OTLP_TRACES_ENDPOINT.
+func LoadConfigFromEnv() Config {
+	cfg := Config{
+		ServiceName: firstNonEmpty(os.Getenv("OTEL_SERVICE_NAME"), "weknora"),
+		Protocol: firstNonEmpty(
+			os.Getenv("OTEL_EXPORTER_OTLP_TRACES_PROTOCOL"),
+			os.Getenv("OTEL_EXPORTER_OTLP_PROTOCOL"),
+			ProtocolHTTP,
+		),
+		SampleRate: 1.0,
+	}
+	endpoint := firstNonEmpty(
+		os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT"),
+		os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"),
+	)
+	disabled, _ := strconv.ParseBool(strings.TrimSpace(os.Getenv("OTEL_SDK_DISABLED")))
+	cfg.Enabled = endpoint != "" && !disabled
+
+	if v := strings.TrimSpace(os.Getenv("OTEL_TRACES_SAMPLER_ARG")); v != "" {
+		if f, err := strconv.ParseFloat(v, 64); err == nil && f >= 0 && f <= 1 {
+			cfg.SampleRate = f
+		}
+	}
+	return cfg
+}
+
+func firstNonEmpty(vals ...string) string {
+	for _, v := range vals {
+		if v = strings.TrimSpace(v); v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,34 @@
+package otlp
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// StartModelCall opens a client span for one model request, named and
+// attributed after the OpenTelemetry GenAI conventions ("chat gpt-4o",
+// "embeddings bge-m3"). Prompts and completions are never recorded; they
+// belong to Langfuse.
+func StartModelCall(ctx context.Context, operation, provider, model string) (context.Context, trace.Span) {
+	return Start(ctx, operation+" "+model,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("gen_ai.operation.name", operation),
+			attribute.String("gen_ai.provider.name", provider),
+			attribute.String("gen_ai.request.model", model),
+		),
+	)
+}
+
+// EndModelCall records token usage (zero counts are skipped) and ends span.
+func EndModelCall(span trace.Span, inputTokens, outputTokens int64, err error) {
+	if inputTokens > 0 {
+		span.SetAttributes(attribute.Int64("gen_ai.usage.input_tokens", inputTokens))
+	}
+	if outputTokens > 0 {
+		span.SetAttributes(attribute.Int64("gen_ai.usage.output_tokens", outputTokens))
+	}
+	End(span, err)
+}
@@ -0,0 +1,109 @@
+package otlp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/gin-gonic/gin"
+	"github.com/hibiken/asynq"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// untracedPaths are polled by load balancers and scrapers; a span per probe
+// would drown the interesting traces.
+var untracedPaths = map[string]bool{
+	"/health":  true,
+	"/metrics": true,
+}
+
+// GinMiddleware opens a server span per request, continuing an incoming W3C
+// traceparent, and adds the trace id to the request logger. Register it after
+// middleware.RequestID so the logger it extends already exists.
+func GinMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !Enabled() || untracedPaths[c.FullPath()] {
+			c.Next()
+			return
+		}
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		ctx := c.Request.Context()
+		parent := propagator.Extract(ctx, propagation.HeaderCarrier(c.Request.Header))
+		ctx, span := start(ctx, parent, c.Request.Method+" "+route,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.request.method", c.Request.Method),
+				attribute.String("http.route", route),
+				attribute.String("url.path", c.Request.URL.Path),
+			),
+		)
+		defer span.End()
+		if rid, ok := types.RequestIDFromContext(ctx); ok {
+			span.SetAttributes(attribute.String("weknora.request_id", rid))
+		}
+		if sc := span.SpanContext(); sc.IsValid() {
+			entry := logger.GetLogger(ctx).WithField("trace_id", sc.TraceID().String())
+			c.Set(types.LoggerContextKey.String(), entry)
+			ctx = context.WithValue(ctx, types.LoggerContextKey, entry)
+		}
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		status := c.Writer.Status()
+		span.SetAttributes(attribute.Int("http.response.status_code", status))
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+	}
+}
+
+// AsynqMiddleware opens a consumer span around every task, continuing the
+// trace of whoever enqueued it. The parent comes from the payload's
+// TracingContext: the otlp traceparent when present, otherwise the Langfuse
+// one, so tasks enqueued while only Langfuse was active still join its trace.
+// Register it before langfuse.AsynqMiddleware so Langfuse's worker spans land
+// in the same trace.
+func AsynqMiddleware() asynq.MiddlewareFunc {
+	return func(next asynq.Handler) asynq.Handler {
+		return asynq.HandlerFunc(func(ctx context.Context, task *asynq.Task) error {
+			if !Enabled() {
+				return next.ProcessTask(ctx, task)
+			}
+			var tc types.TracingContext
+			_ = json.Unmarshal(task.Payload(), &tc)
+			parent := ctx
+			for _, tp := range []string{tc.Traceparent, tc.LangfuseTraceparent} {
+				if sc := SpanContextFromTraceparent(tp); sc.IsValid() {
+					parent = trace.ContextWithRemoteSpanContext(ctx, sc)
+					break
+				}
+			}
+			taskID, _ := asynq.GetTaskID(ctx)
+			queue, _ := asynq.GetQueueName(ctx)
+			retry, _ := asynq.GetRetryCount(ctx)
+			ctx, span := start(ctx, parent, "asynq "+task.Type(),
+				trace.WithSpanKind(trace.SpanKindConsumer),
+				trace.WithAttributes(
+					attribute.String("messaging.system", "asynq"),
+					attribute.String("messaging.operation.type", "process"),
+					attribute.String("messaging.destination.name", queue),
+					attribute.String("messaging.message.id", taskID),
+					attribute.String("asynq.task.type", task.Type()),
+					attribute.Int("asynq.task.retry", retry),
+				),
+			)
+			err := next.ProcessTask(ctx, task)
+			End(span, err)
+			return err
+		})
+	}
+}
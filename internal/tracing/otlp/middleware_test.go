@@ -0,0 +1,105 @@
+package otlp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/gin-gonic/gin"
+	"github.com/hibiken/asynq"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestGinMiddleware_ContinuesIncomingTrace(t *testing.T) {
+	exp := newTestProvider(t)
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(GinMiddleware())
+	var loggedTraceID interface{}
+	r.GET("/api/v1/knowledge/:id", func(c *gin.Context) {
+		loggedTraceID = logger.GetLogger(c.Request.Context()).Data["trace_id"]
+		c.Status(http.StatusBadGateway)
+	})
+	r.GET("/health", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	const upstream = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/knowledge/k1", nil)
+	req.Header.Set("traceparent", upstream)
+	r.ServeHTTP(httptest.NewRecorder(), req)
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/health", nil))
+
+	spans := exp.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("expected only the API request to be traced, got %d spans", len(spans))
+	}
+	s := spans[0]
+	if s.Name != "GET /api/v1/knowledge/:id" || s.SpanKind != trace.SpanKindServer {
+		t.Fatalf("unexpected span %q kind %v", s.Name, s.SpanKind)
+	}
+	if s.SpanContext.TraceID().String() != "4bf92f3577b34da6a3ce929d0e0e4736" ||
+		s.Parent.SpanID().String() != "00f067aa0ba902b7" {
+		t.Fatal("server span must continue the incoming traceparent")
+	}
+	if s.Status.Code != codes.Error {
+		t.Fatal("5xx responses must mark the span as failed")
+	}
+	if !hasAttr(s.Attributes, attribute.Int("http.response.status_code", http.StatusBadGateway)) {
+		t.Fatal("status code attribute missing")
+	}
+	if loggedTraceID != s.SpanContext.TraceID().String() {
+		t.Fatalf("request logger trace_id = %v, want %s", loggedTraceID, s.SpanContext.TraceID())
+	}
+}
+
+func TestAsynqMiddleware_ResumesEnqueuerTrace(t *testing.T) {
+	exp := newTestProvider(t)
+	ctx, producer := Start(context.Background(), "POST /api/v1/knowledge-bases/:id/knowledge/file")
+	payload, _ := json.Marshal(types.TracingContext{Traceparent: Traceparent(ctx)})
+	producer.End()
+
+	var workerSpan trace.Span
+	h := AsynqMiddleware()(asynq.HandlerFunc(func(ctx context.Context, _ *asynq.Task) error {
+		workerSpan = SpanFromContext(ctx)
+		return nil
+	}))
+	if err := h.ProcessTask(context.Background(), asynq.NewTask(types.TypeDocumentProcess, payload)); err != nil {
+		t.Fatalf("ProcessTask: %v", err)
+	}
+
+	consumer := spanByName(t, exp, "asynq "+types.TypeDocumentProcess)
+	if consumer.SpanKind != trace.SpanKindConsumer || workerSpan.SpanContext().SpanID() != consumer.SpanContext.SpanID() {
+		t.Fatal("handler must run inside the consumer span")
+	}
+	if consumer.Parent.SpanID() != producer.SpanContext().SpanID() ||
+		consumer.SpanContext.TraceID() != producer.SpanContext().TraceID() {
+		t.Fatal("consumer span must be a child of the enqueuing span")
+	}
+}
+
+func TestAsynqMiddleware_FallsBackToLangfuseTraceparent(t *testing.T) {
+	exp := newTestProvider(t)
+	const lf = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	payload, _ := json.Marshal(types.TracingContext{LangfuseTraceparent: lf})
+	h := AsynqMiddleware()(asynq.HandlerFunc(func(context.Context, *asynq.Task) error { return nil }))
+	if err := h.ProcessTask(context.Background(), asynq.NewTask("test:task", payload)); err != nil {
+		t.Fatalf("ProcessTask: %v", err)
+	}
+	if got := spanByName(t, exp, "asynq test:task").SpanContext.TraceID().String(); got != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Fatalf("trace id = %s, want the Langfuse trace", got)
+	}
+}
+
+func hasAttr(attrs []attribute.KeyValue, want attribute.KeyValue) bool {
+	for _, a := range attrs {
+		if a == want {
+			return true
+		}
+	}
+	return false
+}
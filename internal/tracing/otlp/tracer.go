@@ -0,0 +1,177 @@
+package otlp
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const scopeName = "github.com/Tencent/WeKnora/internal/tracing/otlp"
+
+// propagator injects and extracts W3C traceparent values. Package-level rather
+// than the global OTel propagator so Init never mutates process-wide state.
+var propagator = propagation.TraceContext{}
+
+// Provider owns the exporter pipeline. A nil *Provider is a disabled one.
+type Provider struct {
+	tp *sdktrace.TracerProvider
+}
+
+var (
+	mu     sync.RWMutex
+	tracer trace.Tracer
+)
+
+// Init builds the exporter from cfg and installs it for the package-level
+// helpers. A disabled cfg returns a nil Provider.
+func Init(cfg Config) (*Provider, error) {
+	if !cfg.Enabled {
+		setTracer(nil)
+		return nil, nil
+	}
+	res, err := resource.New(context.Background(),
+		resource.WithAttributes(attribute.String("service.name", cfg.ServiceName)),
+		resource.WithFromEnv(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	var sp sdktrace.SpanProcessor
+	if cfg.testExporter != nil {
+		sp = sdktrace.NewSimpleSpanProcessor(cfg.testExporter)
+	} else {
+		exp, err := newExporter(cfg)
+		if err != nil {
+			return nil, err
+		}
+		sp = sdktrace.NewBatchSpanProcessor(exp)
+	}
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithResource(res),
+		sdktrace.WithSpanProcessor(sp),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRate))),
+	)
+	setTracer(tp.Tracer(scopeName))
+	logger.Infof(context.Background(), "[OTLP] trace export enabled service=%s protocol=%s sample_rate=%.2f",
+		cfg.ServiceName, cfg.Protocol, cfg.SampleRate)
+	return &Provider{tp: tp}, nil
+}
+
+func newExporter(cfg Config) (sdktrace.SpanExporter, error) {
+	switch cfg.Protocol {
+	case ProtocolGRPC:
+		return otlptracegrpc.New(context.Background())
+	case ProtocolHTTP, "http/json":
+		return otlptracehttp.New(context.Background())
+	default:
+		return nil, fmt.Errorf("otlp: unsupported protocol %q", cfg.Protocol)
+	}
+}
+
+// Shutdown flushes buffered spans and stops the exporter.
+func (p *Provider) Shutdown(ctx context.Context) error {
+	if p == nil {
+		return nil
+	}
+	setTracer(nil)
+	return p.tp.Shutdown(ctx)
+}
+
+func setTracer(t trace.Tracer) {
+	mu.Lock()
+	tracer = t
+	mu.Unlock()
+}
+
+func currentTracer() trace.Tracer {
+	mu.RLock()
+	defer mu.RUnlock()
+	return tracer
+}
+
+// Enabled reports whether spans are being exported.
+func Enabled() bool {
+	return currentTracer() != nil
+}
+
+// Start opens a span named name as a child of the innermost otlp span on ctx.
+// Without one, the span joins whatever trace ctx already carries — a Langfuse
+// span or an extracted remote parent — so both backends share the trace id.
+// When export is disabled it returns ctx unchanged and a no-op span.
+func Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	parent := ctx
+	if sp, ok := ctx.Value(types.OTLPSpanContextKey).(trace.Span); ok {
+		parent = trace.ContextWithSpan(ctx, sp)
+	}
+	return start(ctx, parent, name, opts...)
+}
+
+// start opens a span under parent and records it on ctx.
+func start(
+	ctx, parent context.Context, name string, opts ...trace.SpanStartOption,
+) (context.Context, trace.Span) {
+	t := currentTracer()
+	if t == nil {
+		return ctx, trace.SpanFromContext(context.Background())
+	}
+	_, span := t.Start(parent, name, opts...)
+	ctx = context.WithValue(ctx, types.OTLPSpanContextKey, span)
+	// Expose the span to other OTel-aware code (and to Langfuse, which then
+	// joins this trace) only when nothing else occupies the slot; replacing
+	// an active Langfuse span would re-parent Langfuse's own children.
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		ctx = trace.ContextWithSpan(ctx, span)
+	}
+	return ctx, span
+}
+
+// SpanFromContext returns the innermost otlp span on ctx, or a no-op span.
+func SpanFromContext(ctx context.Context) trace.Span {
+	if sp, ok := ctx.Value(types.OTLPSpanContextKey).(trace.Span); ok {
+		return sp
+	}
+	return trace.SpanFromContext(context.Background())
+}
+
+// End records err on span (if any) and ends it.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// Traceparent returns the W3C traceparent of the innermost otlp span on ctx,
+// or "" when there is none. Producers stamp it onto messages that cross a
+// process boundary.
+func Traceparent(ctx context.Context) string {
+	sc := SpanFromContext(ctx).SpanContext()
+	if !sc.IsValid() {
+		return ""
+	}
+	c := propagation.MapCarrier{}
+	propagator.Inject(trace.ContextWithSpanContext(context.Background(), sc), c)
+	return c["traceparent"]
+}
+
+// SpanContextFromTraceparent parses a traceparent produced by Traceparent.
+// The result is invalid when tp is empty or malformed.
+func SpanContextFromTraceparent(tp string) trace.SpanContext {
+	if tp == "" {
+		return trace.SpanContext{}
+	}
+	ctx := propagator.Extract(context.Background(), propagation.MapCarrier{"traceparent": tp})
+	return trace.SpanContextFromContext(ctx)
+}
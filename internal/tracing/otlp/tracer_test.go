@@ -0,0 +1,133 @@
+package otlp
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// newTestProvider installs a provider that exports synchronously to an
+// in-memory exporter, and uninstalls it when the test ends.
+func newTestProvider(t *testing.T) *tracetest.InMemoryExporter {
+	t.Helper()
+	exp := tracetest.NewInMemoryExporter()
+	p, err := Init(Config{Enabled: true, ServiceName: "weknora-test", SampleRate: 1, testExporter: exp})
+	if err != nil {
+		t.Fatalf("Init: %v", err)
+	}
+	t.Cleanup(func() { _ = p.Shutdown(context.Background()) })
+	return exp
+}
+
+func spanByName(t *testing.T, exp *tracetest.InMemoryExporter, name string) tracetest.SpanStub {
+	t.Helper()
+	for _, s := range exp.GetSpans() {
+		if s.Name == name {
+			return s
+		}
+	}
+	t.Fatalf("span %q not exported; got %d spans", name, len(exp.GetSpans()))
+	return tracetest.SpanStub{}
+}
+
+func TestLoadConfigFromEnv(t *testing.T) {
+	for _, k := range []string{
+		"OTEL_EXPORTER_OTLP_ENDPOINT", "OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "OTEL_SDK_DISABLED",
+		"OTEL_SERVICE_NAME", "OTEL_EXPORTER_OTLP_PROTOCOL", "OTEL_EXPORTER_OTLP_TRACES_PROTOCOL",
+		"OTEL_TRACES_SAMPLER_ARG",
+	} {
+		t.Setenv(k, "")
+	}
+	cfg := LoadConfigFromEnv()
+	if cfg.Enabled || cfg.ServiceName != "weknora" || cfg.Protocol != ProtocolHTTP || cfg.SampleRate != 1 {
+		t.Fatalf("unexpected defaults: %+v", cfg)
+	}
+
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "http://otel-collector:4318")
+	t.Setenv("OTEL_EXPORTER_OTLP_TRACES_PROTOCOL", "grpc")
+	t.Setenv("OTEL_TRACES_SAMPLER_ARG", "0.25")
+	cfg = LoadConfigFromEnv()
+	if !cfg.Enabled || cfg.Protocol != ProtocolGRPC || cfg.SampleRate != 0.25 {
+		t.Fatalf("endpoint should enable export: %+v", cfg)
+	}
+
+	t.Setenv("OTEL_SDK_DISABLED", "true")
+	if LoadConfigFromEnv().Enabled {
+		t.Fatal("OTEL_SDK_DISABLED must win over a configured endpoint")
+	}
+}
+
+func TestStart_DisabledIsNoop(t *testing.T) {
+	if _, err := Init(Config{}); err != nil {
+		t.Fatalf("Init: %v", err)
+	}
+	ctx := context.Background()
+	got, span := Start(ctx, "noop")
+	if got != ctx || span.SpanContext().IsValid() {
+		t.Fatal("disabled Start must return ctx unchanged and a no-op span")
+	}
+	if Traceparent(got) != "" {
+		t.Fatal("disabled Start must not produce a traceparent")
+	}
+}
+
+// A span from another provider (Langfuse in production) occupies the
+// standard context slot. otlp spans must join its trace but keep their own
+// parent chain, and must not displace it.
+func TestStart_KeepsOwnChainBesideForeignSpan(t *testing.T) {
+	exp := newTestProvider(t)
+	foreign := sdktrace.NewTracerProvider()
+	defer func() { _ = foreign.Shutdown(context.Background()) }()
+	ctx, foreignSpan := foreign.Tracer("foreign").Start(context.Background(), "langfuse-root")
+	defer foreignSpan.End()
+
+	ctx, parent := Start(ctx, "parent")
+	childCtx, child := Start(ctx, "child")
+	child.End()
+	parent.End()
+
+	if trace.SpanFromContext(childCtx) != foreignSpan {
+		t.Fatal("otlp spans must not replace the span already on the context")
+	}
+	if SpanFromContext(childCtx) != child {
+		t.Fatal("SpanFromContext must return the innermost otlp span")
+	}
+	p, c := spanByName(t, exp, "parent"), spanByName(t, exp, "child")
+	if p.SpanContext.TraceID() != foreignSpan.SpanContext().TraceID() {
+		t.Fatal("the first otlp span must join the foreign trace")
+	}
+	if c.Parent.SpanID() != p.SpanContext.SpanID() {
+		t.Fatalf("child parent = %s, want otlp parent %s", c.Parent.SpanID(), p.SpanContext.SpanID())
+	}
+}
+
+func TestStart_FillsEmptyStandardSlot(t *testing.T) {
+	newTestProvider(t)
+	ctx, span := Start(context.Background(), "root")
+	defer span.End()
+	if trace.SpanFromContext(ctx) != span {
+		t.Fatal("with no other span present, the otlp span should be visible to OTel-aware code")
+	}
+}
+
+func TestTraceparentRoundTrip(t *testing.T) {
+	newTestProvider(t)
+	ctx, span := Start(context.Background(), "producer")
+	defer span.End()
+
+	tp := Traceparent(ctx)
+	sc := SpanContextFromTraceparent(tp)
+	if !sc.IsValid() || sc.SpanID() != span.SpanContext().SpanID() || !sc.IsRemote() {
+		t.Fatalf("traceparent %q did not round-trip to the producer span", tp)
+	}
+	if SpanContextFromTraceparent("garbage").IsValid() {
+		t.Fatal("malformed traceparent must not parse")
+	}
+	if _, ok := context.Background().Value(types.OTLPSpanContextKey).(trace.Span); ok {
+		t.Fatal("background context must not carry an otlp span")
+	}
+}
@@ -27,6 +27,8 @@ type AgentConfig struct {
 	WebSearchEnabled        bool          `json:"web_search_enabled"`                   // Whether web search tool is enabled
 	WebSearchMaxResults     int           `json:"web_search_max_results"`               // Maximum number of web search results (default: 5)
 	WebSearchProviderID     string        `json:"web_search_provider_id,omitempty"`     // WebSearchProviderEntity ID (resolved from agent config)
+	SQLConnectionIDs        []string      `json:"sql_connection_ids,omitempty"`         // SQLConnection IDs for sql_schema / sql_query (resolved under the agent tenant)
+	BrowserConfigID         string        `json:"browser_config_id,omitempty"`          // BrowserConfig ID for the browser_* tools (resolved under the agent tenant)
 	MultiTurnEnabled        bool          `json:"multi_turn_enabled"`                   // Whether multi-turn conversation is enabled
 	HistoryTurns            int           `json:"history_turns"`                        // Number of history turns to keep in context
 	MemoryEnabled           *bool         `json:"memory_enabled,omitempty"`             // nil inherits workspace
@@ -56,6 +58,7 @@ type AgentConfig struct {
 	// Runtime-only fields (not persisted)
 	VLMModelID      string `json:"-"` // VLM model ID for tool result image analysis (set from CustomAgent config)
 	SandboxConfigID string `json:"-"` // Workspace sandbox config ID for skill execution (set from CustomAgent config)
+	AgentTenantID   uint64 `json:"-"` // Tenant that owns the agent; SQL connections are looked up under it
 	// Per-request @mention pins (runtime only; injected as <must_use> in the user message).
 	PinnedMCPServiceIDs []string `json:"-"`
 	PinnedSkillNames    []string `json:"-"`
@@ -0,0 +1,250 @@
+package types
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+	"time"
+)
+
+// AuditActorAPIKeyPrefix prefixes AuditLog.ActorUserID when the actor is an
+// API key rather than a user: "api_key:<key id>". It fits the varchar(36)
+// column for any uint64 key id.
+const AuditActorAPIKeyPrefix = "api_key:"
+
+// AuditActorAPIKey returns the ActorUserID recorded for API key keyID.
+func AuditActorAPIKey(keyID uint64) string {
+	return AuditActorAPIKeyPrefix + strconv.FormatUint(keyID, 10)
+}
+
+// AuditActorFromContext returns the ActorUserID and ActorRole for the
+// caller in ctx. An API key wins over the user it runs as, so the row
+// names the credential that was actually used.
+func AuditActorFromContext(ctx context.Context) (string, string) {
+	if scope, ok := TenantAPIKeyScopeFromContext(ctx); ok {
+		return AuditActorAPIKey(scope.KeyID), "api_key"
+	}
+	uid, _ := UserIDFromContext(ctx)
+	if uid == "" {
+		return "", ""
+	}
+	return uid, string(TenantRoleFromContext(ctx))
+}
+
+// auditHashInput is the canonical projection of an AuditLog that the chain
+// hash covers. Field order is fixed by the struct, so the encoding is stable
+// across releases; adding a field here breaks verification of every existing
+// row and needs a new hash version instead.
+type auditHashInput struct {
+	PrevHash      string          `json:"prev_hash"`
+	Seq           uint64          `json:"seq"`
+	TenantID      uint64          `json:"tenant_id"`
+	ActorUserID   string          `json:"actor_user_id"`
+	ActorRole     string          `json:"actor_role"`
+	Action        AuditAction     `json:"action"`
+	ScopeType     string          `json:"scope_type"`
+	ScopeID       string          `json:"scope_id"`
+	TargetType    string          `json:"target_type"`
+	TargetID      string          `json:"target_id"`
+	TargetUserID  string          `json:"target_user_id"`
+	RequestPath   string          `json:"request_path"`
+	RequestMethod string          `json:"request_method"`
+	Outcome       AuditOutcome    `json:"outcome"`
+	Details       json.RawMessage `json:"details"`
+	CreatedAtUS   int64           `json:"created_at_us"`
+}
+
+// ComputeHash returns the hex SHA-256 that links e into its tenant's chain:
+// the hash of PrevHash, ChainSeq and every audited field. CreatedAt is hashed
+// at microsecond precision (what Postgres stores) and Details is
+// re-serialised with sorted keys, so a row hashes the same before insert and
+// after a round-trip through jsonb.
+func (e *AuditLog) ComputeHash() string {
+	raw, _ := json.Marshal(auditHashInput{
+		PrevHash:      e.PrevHash,
+		Seq:           e.ChainSeq,
+		TenantID:      e.TenantID,
+		ActorUserID:   e.ActorUserID,
+		ActorRole:     e.ActorRole,
+		Action:        e.Action,
+		ScopeType:     e.ScopeType,
+		ScopeID:       e.ScopeID,
+		TargetType:    e.TargetType,
+		TargetID:      e.TargetID,
+		TargetUserID:  e.TargetUserID,
+		RequestPath:   e.RequestPath,
+		RequestMethod: e.RequestMethod,
+		Outcome:       e.Outcome,
+		Details:       canonicalAuditDetails(e.Details),
+		CreatedAtUS:   e.CreatedAt.UnixMicro(),
+	})
+	sum := sha256.Sum256(raw)
+	return hex.EncodeToString(sum[:])
+}
+
+// canonicalAuditDetails normalises a details payload the way jsonb would:
+// key order and whitespace are dropped, numbers keep their literal text.
+// Empty details hash as "{}", the column default.
+func canonicalAuditDetails(raw JSON) json.RawMessage {
+	if len(bytes.TrimSpace(raw)) == 0 {
+		return json.RawMessage("{}")
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		quoted, _ := json.Marshal(string(raw))
+		return quoted
+	}
+	out, err := json.Marshal(v)
+	if err != nil {
+		quoted, _ := json.Marshal(string(raw))
+		return quoted
+	}
+	return out
+}
+
+// AuditLogChainHead is the tip of one tenant's audit hash chain. The next
+// entry gets LastSeq+1 and PrevHash = LastHash. PrunedSeq / PrunedHash
+// record the newest entry removed by retention, so verification can start
+// from a trusted checkpoint instead of treating the purged prefix as
+// tampering.
+type AuditLogChainHead struct {
+	TenantID   uint64    `json:"tenant_id"   gorm:"primaryKey;autoIncrement:false"`
+	LastSeq    uint64    `json:"last_seq"    gorm:"not null;default:0"`
+	LastHash   string    `json:"last_hash"   gorm:"type:varchar(64);default:''"`
+	PrunedSeq  uint64    `json:"pruned_seq"  gorm:"not null;default:0"`
+	PrunedHash string    `json:"pruned_hash" gorm:"type:varchar(64);default:''"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// TableName pins the table name.
+func (AuditLogChainHead) TableName() string { return "audit_log_chain_heads" }
+
+// Names of the audit export sinks; they key AuditLogSinkCursor rows.
+const (
+	AuditSinkSyslog        = "syslog"
+	AuditSinkWebhook       = "webhook"
+	AuditSinkObjectStorage = "object_storage"
+)
+
+// AuditLogSinkCursor tracks how far one export sink has shipped audit_logs,
+// by row id. The lease columns let exactly one replica drive a sink at a
+// time; retention never deletes rows beyond the slowest cursor.
+type AuditLogSinkCursor struct {
+	Sink       string    `json:"sink"        gorm:"primaryKey;type:varchar(32)"`
+	LastID     uint64    `json:"last_id"     gorm:"not null;default:0"`
+	LeaseOwner string    `json:"lease_owner" gorm:"type:varchar(128);default:''"`
+	LeaseUntil time.Time `json:"lease_until"`
+	LastError  string    `json:"last_error"  gorm:"type:varchar(512);default:''"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// TableName pins the table name.
+func (AuditLogSinkCursor) TableName() string { return "audit_log_sink_cursors" }
+
+// Reasons reported by AuditChainVerification when a chain does not verify.
+const (
+	// AuditChainHashMismatch: a row's stored hash does not match its
+	// content, i.e. the row was edited after it was written.
+	AuditChainHashMismatch = "hash_mismatch"
+	// AuditChainLinkMismatch: a row's prev_hash does not match the hash of
+	// the entry before it, i.e. a row was replaced or re-ordered.
+	AuditChainLinkMismatch = "prev_hash_mismatch"
+	// AuditChainMissingEntries: sequence numbers skip, i.e. rows were
+	// deleted from the middle of the chain or ahead of the retention
+	// checkpoint.
+	AuditChainMissingEntries = "missing_entries"
+	// AuditChainHeadMismatch: the newest row does not match the chain head,
+	// i.e. rows were deleted from the end.
+	AuditChainHeadMismatch = "head_mismatch"
+)
+
+// AuditChainVerification is the result of walking one tenant's chain.
+// Unchained counts rows written before the chain existed; they are not
+// covered by the verification.
+type AuditChainVerification struct {
+	TenantID    uint64    `json:"tenant_id"`
+	Valid       bool      `json:"valid"`
+	Checked     uint64    `json:"checked"`
+	FirstSeq    uint64    `json:"first_seq"`
+	LastSeq     uint64    `json:"last_seq"`
+	HeadSeq     uint64    `json:"head_seq"`
+	HeadHash    string    `json:"head_hash"`
+	PrunedSeq   uint64    `json:"pruned_seq"`
+	Unchained   int64     `json:"unchained"`
+	BrokenAtSeq uint64    `json:"broken_at_seq,omitempty"`
+	BrokenAtID  uint64    `json:"broken_at_id,omitempty"`
+	Reason      string    `json:"reason,omitempty"`
+	VerifiedAt  time.Time `json:"verified_at"`
+}
+
+// AuditChainVerifier checks chained entries fed to it in ChainSeq order.
+// It holds only the previous hash, so callers can stream a large chain
+// page by page.
+type AuditChainVerifier struct {
+	result   AuditChainVerification
+	nextSeq  uint64
+	prevHash string
+}
+
+// NewAuditChainVerifier starts a walk from head's retention checkpoint.
+// A nil head means the tenant has never written a chained entry.
+func NewAuditChainVerifier(tenantID uint64, head *AuditLogChainHead) *AuditChainVerifier {
+	v := &AuditChainVerifier{result: AuditChainVerification{TenantID: tenantID, Valid: true}}
+	if head != nil {
+		v.result.HeadSeq = head.LastSeq
+		v.result.HeadHash = head.LastHash
+		v.result.PrunedSeq = head.PrunedSeq
+		v.prevHash = head.PrunedHash
+	}
+	v.nextSeq = v.result.PrunedSeq + 1
+	return v
+}
+
+// Add checks the next entry and reports whether the walk should continue.
+// It returns false at the first broken link.
+func (v *AuditChainVerifier) Add(e *AuditLog) bool {
+	if !v.result.Valid {
+		return false
+	}
+	switch {
+	case e.ChainSeq != v.nextSeq:
+		v.fail(e, AuditChainMissingEntries)
+	case e.PrevHash != v.prevHash:
+		v.fail(e, AuditChainLinkMismatch)
+	case e.ComputeHash() != e.Hash:
+		v.fail(e, AuditChainHashMismatch)
+	default:
+		if v.result.Checked == 0 {
+			v.result.FirstSeq = e.ChainSeq
+		}
+		v.result.Checked++
+		v.result.LastSeq = e.ChainSeq
+		v.prevHash = e.Hash
+		v.nextSeq++
+		return true
+	}
+	return false
+}
+
+// Finish compares the end of the walk with the chain head and returns the
+// verdict.
+func (v *AuditChainVerifier) Finish() AuditChainVerification {
+	if v.result.Valid && (v.nextSeq-1 != v.result.HeadSeq || v.prevHash != v.result.HeadHash) {
+		v.result.Valid = false
+		v.result.Reason = AuditChainHeadMismatch
+		v.result.BrokenAtSeq = v.nextSeq
+	}
+	return v.result
+}
+
+func (v *AuditChainVerifier) fail(e *AuditLog, reason string) {
+	v.result.Valid = false
+	v.result.Reason = reason
+	v.result.BrokenAtSeq = v.nextSeq
+	v.result.BrokenAtID = e.ID
+}
@@ -0,0 +1,111 @@
+package types
+
+import (
+	"testing"
+	"time"
+)
+
+// chainOf links n entries for tenant 7 starting after prev, the way the
+// repository does on insert.
+func chainOf(n int, startSeq uint64, prev string) []*AuditLog {
+	out := make([]*AuditLog, 0, n)
+	base := time.Date(2026, 10, 1, 8, 0, 0, 123456000, time.UTC)
+	for i := 0; i < n; i++ {
+		e := &AuditLog{
+			ID:        uint64(100 + i),
+			TenantID:  7,
+			Action:    AuditActionKnowledgeDownloaded,
+			Outcome:   AuditOutcomeSuccess,
+			Details:   JSON(`{"b":1,"a":"x"}`),
+			CreatedAt: base.Add(time.Duration(i) * time.Second),
+			ChainSeq:  startSeq + uint64(i),
+			PrevHash:  prev,
+		}
+		e.Hash = e.ComputeHash()
+		prev = e.Hash
+		out = append(out, e)
+	}
+	return out
+}
+
+func verify(head *AuditLogChainHead, entries []*AuditLog) AuditChainVerification {
+	v := NewAuditChainVerifier(7, head)
+	for _, e := range entries {
+		if !v.Add(e) {
+			break
+		}
+	}
+	return v.Finish()
+}
+
+func TestAuditLogComputeHash_StableAcrossStorageRoundTrip(t *testing.T) {
+	e := chainOf(1, 1, "")[0]
+	// jsonb reorders keys and drops whitespace; Postgres keeps microseconds.
+	stored := *e
+	stored.Details = JSON(`{"a": "x", "b": 1}`)
+	stored.CreatedAt = e.CreatedAt.In(time.FixedZone("CST", 8*3600))
+	if stored.ComputeHash() != e.Hash {
+		t.Fatal("hash must not depend on details key order or time zone")
+	}
+
+	empty := AuditLog{Action: AuditActionMemberAdded}
+	defaulted := empty
+	defaulted.Details = JSON(`{}`)
+	if empty.ComputeHash() != defaulted.ComputeHash() {
+		t.Fatal("empty details must hash like the '{}' column default")
+	}
+
+	stored.TargetID = "other"
+	if stored.ComputeHash() == e.Hash {
+		t.Fatal("editing an audited field must change the hash")
+	}
+}
+
+func TestAuditChainVerifier(t *testing.T) {
+	entries := chainOf(4, 1, "")
+	head := &AuditLogChainHead{TenantID: 7, LastSeq: 4, LastHash: entries[3].Hash}
+
+	if got := verify(head, entries); !got.Valid || got.Checked != 4 || got.FirstSeq != 1 || got.LastSeq != 4 {
+		t.Fatalf("intact chain: %+v", got)
+	}
+	if got := verify(nil, nil); !got.Valid {
+		t.Fatalf("a tenant without chained rows verifies: %+v", got)
+	}
+
+	edited := chainOf(4, 1, "")
+	edited[1].Outcome = AuditOutcomeFailed
+	if got := verify(head, edited); got.Valid || got.Reason != AuditChainHashMismatch || got.BrokenAtSeq != 2 {
+		t.Fatalf("edited row: %+v", got)
+	}
+
+	gap := []*AuditLog{entries[0], entries[2], entries[3]}
+	if got := verify(head, gap); got.Valid || got.Reason != AuditChainMissingEntries || got.BrokenAtSeq != 2 {
+		t.Fatalf("deleted row: %+v", got)
+	}
+
+	if got := verify(head, entries[:3]); got.Valid || got.Reason != AuditChainHeadMismatch || got.BrokenAtSeq != 4 {
+		t.Fatalf("truncated tail: %+v", got)
+	}
+
+	// Re-hashing a replaced row is caught by the next link.
+	forged := chainOf(4, 1, "")
+	forged[1].Outcome = AuditOutcomeFailed
+	forged[1].Hash = forged[1].ComputeHash()
+	if got := verify(head, forged); got.Valid || got.Reason != AuditChainLinkMismatch || got.BrokenAtSeq != 3 {
+		t.Fatalf("re-hashed row: %+v", got)
+	}
+}
+
+func TestAuditChainVerifier_StartsAtRetentionCheckpoint(t *testing.T) {
+	all := chainOf(5, 1, "")
+	head := &AuditLogChainHead{
+		TenantID: 7, LastSeq: 5, LastHash: all[4].Hash,
+		PrunedSeq: 2, PrunedHash: all[1].Hash,
+	}
+	if got := verify(head, all[2:]); !got.Valid || got.FirstSeq != 3 || got.Checked != 3 {
+		t.Fatalf("pruned prefix must verify from the checkpoint: %+v", got)
+	}
+	if got := verify(head, all[3:]); got.Valid || got.Reason != AuditChainMissingEntries {
+		t.Fatalf("rows deleted past the checkpoint must be reported: %+v", got)
+	}
+}
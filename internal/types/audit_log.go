@@ -50,6 +50,23 @@ const (
 	// AuditActionInvitationExpired fires when the lazy sweep transitions
 	// an overdue pending row to expired. Actor is empty (system).
 	AuditActionInvitationExpired AuditAction = "rbac.invitation_expired"
+	// Custom role lifecycle. Details carry the role name, base role and
+	// permission list; assignments are recorded as rbac.member_role_changed
+	// (members) or rbac.api_key_role_changed (API keys).
+	AuditActionCustomRoleCreated AuditAction = "rbac.role_created"
+	AuditActionCustomRoleUpdated AuditAction = "rbac.role_updated"
+	AuditActionCustomRoleDeleted AuditAction = "rbac.role_deleted"
+	AuditActionAPIKeyRoleChanged AuditAction = "rbac.api_key_role_changed"
+	// SCIM provisioning. The actor is the identity provider's API key
+	// ("api_key:<id>"); the target is the provisioned user. Deprovisioning
+	// details carry the number of API keys it revoked.
+	AuditActionSCIMUserProvisioned   AuditAction = "scim.user_provisioned"
+	AuditActionSCIMUserDeprovisioned AuditAction = "scim.user_deprovisioned"
+	// Encryption key management. Details carry the key versions and master
+	// key IDs involved, never key material. Re-wrapping is platform-wide and
+	// recorded with tenant_id=0.
+	AuditActionEncryptionKeyRotated    AuditAction = "encryption.key_rotated"
+	AuditActionEncryptionKeysRewrapped AuditAction = "encryption.keys_rewrapped"
 
 	// VectorStore lifecycle actions. Emitted by VectorStoreService.
 	// Cover both env-store-derived (__env_*) and DB store create /
@@ -178,6 +195,41 @@ const (
 	AuditActionFAQImportStarted   AuditAction = "faq.import_started"
 	AuditActionFAQImportCompleted AuditAction = "faq.import_completed"
 	AuditActionFAQImportFailed    AuditAction = "faq.import_failed"
+
+	// Usage metering. Budget changes are recorded with the actor; the
+	// warning and exceeded events are system events written at most once
+	// per budget and month. Details carry api_key_id, period, limit and
+	// used tokens.
+	AuditActionUsageBudgetUpdated  AuditAction = "usage.budget_updated"
+	AuditActionUsageBudgetDeleted  AuditAction = "usage.budget_deleted"
+	AuditActionUsageBudgetWarning  AuditAction = "usage.budget_warning"
+	AuditActionUsageBudgetExceeded AuditAction = "usage.budget_exceeded"
+	// Rate limit policy changes. Details carry the scope and the four
+	// limits; rejected requests are not audited.
+	AuditActionRateLimitPolicyUpdated AuditAction = "ratelimit.policy_updated"
+	AuditActionRateLimitPolicyDeleted AuditAction = "ratelimit.policy_deleted"
+	// Data subject requests. The target is the exported or erased user.
+	// Export details carry the per-file record counts; erasure details carry
+	// the per-category counts and the digest of the erasure report, which
+	// anchors the report in the hash chain.
+	AuditActionDataSubjectExported AuditAction = "privacy.subject_exported"
+	AuditActionDataSubjectErased   AuditAction = "privacy.subject_erased"
+
+	// AuditActionDataReadByAPIKey fires when an API key successfully calls a
+	// route declared with the retrieve capability (KB, document, chunk, FAQ,
+	// wiki and search reads). ActorUserID is "api_key:<key id>" and
+	// RequestPath is the raw URL so the row names the resource that was read.
+	// Deduplicated per (key, path) over one minute.
+	AuditActionDataReadByAPIKey AuditAction = "data.read_by_api_key"
+	// AuditActionAgentToolExecuted fires once per agent tool call. Details
+	// carry the tool name, session, tool_call_id and duration; arguments and
+	// results are never recorded.
+	AuditActionAgentToolExecuted AuditAction = "agent.tool_executed"
+	// AuditActionKnowledgeDownloaded fires when the original file of a
+	// document is downloaded. AuditActionFAQExported fires when a FAQ
+	// knowledge base is exported as CSV or JSON.
+	AuditActionKnowledgeDownloaded AuditAction = "knowledge.downloaded"
+	AuditActionFAQExported         AuditAction = "faq.exported"
 )
 
 // AuditOutcome separates asynchronous acceptance from terminal business
@@ -206,9 +258,13 @@ const (
 // Rows are append-only — no UpdatedAt, no soft-delete column. The
 // monotonic ID acts as both primary key and pagination cursor (newest-
 // first is `WHERE id < AfterID ORDER BY id DESC`).
+//
+// ChainSeq / PrevHash / Hash link every row into a per-tenant hash chain
+// (see ComputeHash). They are assigned by the repository on insert; rows
+// written before the chain existed keep ChainSeq = 0.
 type AuditLog struct {
 	ID            uint64       `json:"id"             gorm:"primaryKey;autoIncrement;index:idx_audit_logs_tenant_scope_desc,priority:4,sort:desc"`
-	TenantID      uint64       `json:"tenant_id"      gorm:"not null;index:idx_audit_logs_tenant_id_desc,priority:1;index:idx_audit_logs_tenant_action,priority:1;index:idx_audit_logs_tenant_scope_desc,priority:1"`
+	TenantID      uint64       `json:"tenant_id"      gorm:"not null;index:idx_audit_logs_tenant_id_desc,priority:1;index:idx_audit_logs_tenant_action,priority:1;index:idx_audit_logs_tenant_scope_desc,priority:1;index:idx_audit_logs_tenant_chain,priority:1"`
 	ActorUserID   string       `json:"actor_user_id"  gorm:"type:varchar(36);default:'';index:idx_audit_logs_actor"`
 	ActorRole     string       `json:"actor_role"     gorm:"type:varchar(32);default:''"`
 	Action        AuditAction  `json:"action"         gorm:"type:varchar(64);not null;index:idx_audit_logs_tenant_action,priority:2"`
@@ -222,6 +278,9 @@ type AuditLog struct {
 	Outcome       AuditOutcome `json:"outcome"        gorm:"type:varchar(16);default:success"`
 	Details       JSON         `json:"details"        gorm:"type:jsonb;default:'{}'"`
 	CreatedAt     time.Time    `json:"created_at"     gorm:"index:idx_audit_logs_tenant_id_desc,priority:2,sort:desc"`
+	ChainSeq      uint64       `json:"chain_seq"      gorm:"not null;default:0;index:idx_audit_logs_tenant_chain,priority:2"`
+	PrevHash      string       `json:"prev_hash"      gorm:"type:varchar(64);default:''"`
+	Hash          string       `json:"hash"           gorm:"type:varchar(64);default:''"`
 }
 
 // TableName pins the table name even if a future GORM convention
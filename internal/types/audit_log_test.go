@@ -25,6 +25,19 @@ func TestAuditAction_DotNamespaceConvention(t *testing.T) {
 		AuditActionInvitationDeclined,
 		AuditActionInvitationRevoked,
 		AuditActionInvitationExpired,
+		AuditActionCustomRoleCreated,
+		AuditActionCustomRoleUpdated,
+		AuditActionCustomRoleDeleted,
+		AuditActionAPIKeyRoleChanged,
+		// SCIM namespace
+		AuditActionSCIMUserProvisioned,
+		AuditActionSCIMUserDeprovisioned,
+		AuditActionEncryptionKeyRotated,
+		AuditActionEncryptionKeysRewrapped,
+		AuditActionRateLimitPolicyUpdated,
+		AuditActionRateLimitPolicyDeleted,
+		AuditActionDataSubjectExported,
+		AuditActionDataSubjectErased,
 		// VectorStore namespace (Phase 3 PR 1 / #1440)
 		AuditActionVectorStoreCreated,
 		AuditActionVectorStoreUpdated,
@@ -115,6 +128,18 @@ func TestAuditAction_NoCollisionsAcrossNamespaces(t *testing.T) {
 	register("AuditActionInvitationDeclined", AuditActionInvitationDeclined)
 	register("AuditActionInvitationRevoked", AuditActionInvitationRevoked)
 	register("AuditActionInvitationExpired", AuditActionInvitationExpired)
+	register("AuditActionCustomRoleCreated", AuditActionCustomRoleCreated)
+	register("AuditActionCustomRoleUpdated", AuditActionCustomRoleUpdated)
+	register("AuditActionCustomRoleDeleted", AuditActionCustomRoleDeleted)
+	register("AuditActionAPIKeyRoleChanged", AuditActionAPIKeyRoleChanged)
+	register("AuditActionSCIMUserProvisioned", AuditActionSCIMUserProvisioned)
+	register("AuditActionSCIMUserDeprovisioned", AuditActionSCIMUserDeprovisioned)
+	register("AuditActionEncryptionKeyRotated", AuditActionEncryptionKeyRotated)
+	register("AuditActionEncryptionKeysRewrapped", AuditActionEncryptionKeysRewrapped)
+	register("AuditActionRateLimitPolicyUpdated", AuditActionRateLimitPolicyUpdated)
+	register("AuditActionRateLimitPolicyDeleted", AuditActionRateLimitPolicyDeleted)
+	register("AuditActionDataSubjectExported", AuditActionDataSubjectExported)
+	register("AuditActionDataSubjectErased", AuditActionDataSubjectErased)
 	register("AuditActionVectorStoreCreated", AuditActionVectorStoreCreated)
 	register("AuditActionVectorStoreUpdated", AuditActionVectorStoreUpdated)
 	register("AuditActionVectorStoreDeleted", AuditActionVectorStoreDeleted)
@@ -0,0 +1,166 @@
+package types
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/utils"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	// DefaultBrowserTimeoutSec is used when a config does not set TimeoutSec.
+	DefaultBrowserTimeoutSec = 30
+	// MaxBrowserTimeoutSec caps TimeoutSec no matter what the config asks for.
+	MaxBrowserTimeoutSec = 120
+	// DefaultBrowserMaxTextChars is used when a config does not set MaxTextChars.
+	DefaultBrowserMaxTextChars = 8000
+	// MaxBrowserMaxTextChars caps MaxTextChars no matter what the config asks for.
+	MaxBrowserMaxTextChars = 50000
+	// Viewport bounds; zero means the default of 1280x800.
+	MaxBrowserViewportWidth  = 3840
+	MaxBrowserViewportHeight = 2160
+)
+
+// BrowserConfig is a headless browser service (Chromium with remote
+// debugging, browserless, ...) a workspace exposes to its agents for the
+// interactive browser_* tools. Agents reference configs by ID through
+// CustomAgentConfig.BrowserConfigID.
+type BrowserConfig struct {
+	// Unique identifier (UUID, auto-generated)
+	ID string `yaml:"id" json:"id" gorm:"type:varchar(36);primaryKey"`
+	// Workspace ID for scoping
+	TenantID uint64 `yaml:"tenant_id" json:"tenant_id"`
+	// User-friendly name, e.g., "Shared Chromium"
+	Name string `yaml:"name" json:"name" gorm:"type:varchar(255);not null"`
+	// Description
+	Description string `yaml:"description" json:"description" gorm:"type:text"`
+	// Endpoint parameters; the token is encrypted at rest
+	Parameters BrowserConfigParameters `yaml:"parameters" json:"parameters" gorm:"type:json"`
+	// Page limits and the domain allowlist
+	Options BrowserConfigOptions `yaml:"options" json:"options" gorm:"type:json"`
+	// Timestamps
+	CreatedAt time.Time      `yaml:"created_at" json:"created_at"`
+	UpdatedAt time.Time      `yaml:"updated_at" json:"updated_at"`
+	DeletedAt gorm.DeletedAt `yaml:"deleted_at" json:"deleted_at" gorm:"index"`
+}
+
+// TableName returns the table name for BrowserConfig
+func (BrowserConfig) TableName() string {
+	return "browser_configs"
+}
+
+// BeforeCreate is a GORM hook that runs before creating a new record.
+// Automatically generates a UUID for new configs.
+func (c *BrowserConfig) BeforeCreate(tx *gorm.DB) (err error) {
+	if c.ID == "" {
+		c.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// BrowserConfigParameters holds how to reach the browser service.
+//
+// Credential mutation flows through the dedicated /credentials subresource
+// (see internal/handler/browser_config_credentials.go). The token is never
+// returned in responses — handlers serialize via dto.NewBrowserConfigResponse.
+type BrowserConfigParameters struct {
+	// DevTools endpoint: http(s)://host:9222 (resolved via /json/version) or
+	// a browser WebSocket URL ws(s)://...
+	EndpointURL string `yaml:"endpoint_url" json:"endpoint_url"`
+	// Bearer token sent to the endpoint (encrypted in DB)
+	Token string `yaml:"token" json:"token,omitempty"`
+	// AllowPrivateEndpoint permits a loopback / private endpoint such as a
+	// browser container on the compose network. Link-local stays blocked.
+	AllowPrivateEndpoint bool `yaml:"allow_private_endpoint" json:"allow_private_endpoint"`
+}
+
+// Value implements the driver.Valuer interface.
+// Encrypts Token before persisting to database.
+func (p BrowserConfigParameters) Value() (driver.Value, error) {
+	if key := utils.GetAESKey(); key != nil && p.Token != "" {
+		if encrypted, err := utils.EncryptAESGCM(p.Token, key); err == nil {
+			p.Token = encrypted
+		}
+	}
+	return json.Marshal(p)
+}
+
+// Scan implements the sql.Scanner interface.
+// Decrypts Token after loading from database.
+func (p *BrowserConfigParameters) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	b, ok := value.([]byte)
+	if !ok {
+		return nil
+	}
+	if err := json.Unmarshal(b, p); err != nil {
+		return err
+	}
+	if plain, ok := utils.DecryptStoredSecretLenient(p.Token); ok {
+		p.Token = plain
+	} else {
+		log.Printf("[crypto] browser config token: decrypt failed (SYSTEM_AES_KEY missing/rotated?), treating as unconfigured")
+		p.Token = ""
+	}
+	return nil
+}
+
+// BrowserConfigOptions bounds what the agent's browser may do.
+type BrowserConfigOptions struct {
+	// Per-action timeout in seconds, including page loads (default 30, capped at 120)
+	TimeoutSec int `yaml:"timeout_sec" json:"timeout_sec,omitempty"`
+	// Viewport size in CSS pixels (default 1280x800)
+	ViewportWidth  int `yaml:"viewport_width" json:"viewport_width,omitempty"`
+	ViewportHeight int `yaml:"viewport_height" json:"viewport_height,omitempty"`
+	// Maximum characters of page text returned per extract (default 8000)
+	MaxTextChars int `yaml:"max_text_chars" json:"max_text_chars,omitempty"`
+	// Sites the agent may open, matching subdomains too; empty allows any
+	// public site. Private and metadata addresses are always refused.
+	AllowedDomains []string `yaml:"allowed_domains" json:"allowed_domains,omitempty"`
+}
+
+// EffectiveTimeout returns TimeoutSec with the default and cap applied.
+func (o BrowserConfigOptions) EffectiveTimeout() time.Duration {
+	sec := o.TimeoutSec
+	if sec <= 0 {
+		sec = DefaultBrowserTimeoutSec
+	}
+	if sec > MaxBrowserTimeoutSec {
+		sec = MaxBrowserTimeoutSec
+	}
+	return time.Duration(sec) * time.Second
+}
+
+// EffectiveMaxTextChars returns MaxTextChars with the default and cap applied.
+func (o BrowserConfigOptions) EffectiveMaxTextChars() int {
+	if o.MaxTextChars <= 0 {
+		return DefaultBrowserMaxTextChars
+	}
+	if o.MaxTextChars > MaxBrowserMaxTextChars {
+		return MaxBrowserMaxTextChars
+	}
+	return o.MaxTextChars
+}
+
+// Value implements the driver.Valuer interface.
+func (o BrowserConfigOptions) Value() (driver.Value, error) {
+	return json.Marshal(o)
+}
+
+// Scan implements the sql.Scanner interface.
+func (o *BrowserConfigOptions) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	b, ok := value.([]byte)
+	if !ok {
+		return nil
+	}
+	return json.Unmarshal(b, o)
+}
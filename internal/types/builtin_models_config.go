@@ -231,6 +231,7 @@ var validBuiltinModelTypes = map[ModelType]struct{}{
 	ModelTypeRerank:      {},
 	ModelTypeVLLM:        {},
 	ModelTypeASR:         {},
+	ModelTypeTTS:         {},
 }
 
 // validBuiltinModelStatuses is the set of statuses the loader accepts.
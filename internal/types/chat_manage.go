@@ -53,6 +53,10 @@ type PipelineRequest struct {
 	// every RAG request that happens to retrieve CSV/Excel chunks.
 	DataAnalysisEnabled bool `json:"-"`
 
+	// GraphSearchMode selects when community-report global search runs next
+	// to entity search. Empty behaves like GraphSearchModeAuto.
+	GraphSearchMode string `json:"-"`
+
 	// Image / multimodal support
 	Images                  []string `json:"-"`
 	VLMModelID              string   `json:"-"`
@@ -163,6 +167,20 @@ func (c *ChatManage) NeedsRetrieval() bool {
 	return c.Intent.NeedsKBRetrieval()
 }
 
+// WantsGraphGlobalSearch reports whether this turn should consult graph
+// community reports. In auto mode only summarize-style questions do: they ask
+// about a collection as a whole, which entity search cannot reach.
+func (c *ChatManage) WantsGraphGlobalSearch() bool {
+	switch c.GraphSearchMode {
+	case GraphSearchModeGlobal:
+		return true
+	case GraphSearchModeLocal:
+		return false
+	default:
+		return c.Intent == IntentSummarize
+	}
+}
+
 // Clone creates a deep copy of the ChatManage object.
 // PipelineContext fields (EventBus, MessageID, etc.) are NOT copied because they
 // are per-execution handles that should not be shared across clones.
@@ -235,6 +253,7 @@ func (c *ChatManage) Clone() *ChatManage {
 			FAQDirectAnswerThreshold: c.FAQDirectAnswerThreshold,
 			FAQScoreBoost:            c.FAQScoreBoost,
 			DataAnalysisEnabled:      c.DataAnalysisEnabled,
+			GraphSearchMode:          c.GraphSearchMode,
 			Images:                   append([]string(nil), c.Images...),
 			VLMModelID:               c.VLMModelID,
 			ChatModelSupportsVision:  c.ChatModelSupportsVision,
@@ -37,6 +37,8 @@ const (
 	ChunkTypeTableColumn ChunkType = "table_column"
 	// ChunkTypeWikiPage 表示 Wiki 页面同步的 Chunk，用于将 wiki 页面接入现有检索管线
 	ChunkTypeWikiPage ChunkType = "wiki_page"
+	// ChunkTypeCommunityReport 表示图谱社区报告（全局检索的 map 结果），不落库
+	ChunkTypeCommunityReport ChunkType = "community_report"
 )
 
 // ChunkStatus 定义了不同状态的 Chunk
@@ -175,6 +177,49 @@ type Chunk struct {
 	// ContextHeader is a Markdown heading breadcrumb prepended when indexing.
 	// It is persisted so a later content edit can rebuild the same index input.
 	ContextHeader string `json:"-" gorm:"type:text"`
+
+	// plain holds the plaintext while the sealed text is being written.
+	plain *chunkPlaintext
+}
+
+type chunkPlaintext struct {
+	content, source string
+}
+
+// BeforeSave seals Content and SourceContent when chunk content encryption
+// is on. AfterSave restores the plaintext, so callers that index the chunk
+// after saving it keep working with the text.
+func (c *Chunk) BeforeSave(tx *gorm.DB) error {
+	ctx, cipher := tx.Statement.Context, ContentCipherOf(tx)
+	content, err := SealChunkContent(ctx, cipher, c.TenantID, c.Content)
+	if err != nil {
+		return err
+	}
+	source, err := SealChunkContent(ctx, cipher, c.TenantID, c.SourceContent)
+	if err != nil {
+		return err
+	}
+	if content != c.Content || source != c.SourceContent {
+		c.plain = &chunkPlaintext{content: c.Content, source: c.SourceContent}
+		c.Content, c.SourceContent = content, source
+	}
+	return nil
+}
+
+// AfterSave puts back the plaintext replaced by BeforeSave.
+func (c *Chunk) AfterSave(tx *gorm.DB) error {
+	if c.plain != nil {
+		c.Content, c.SourceContent = c.plain.content, c.plain.source
+		c.plain = nil
+	}
+	return nil
+}
+
+// AfterFind opens sealed chunk text.
+func (c *Chunk) AfterFind(tx *gorm.DB) error {
+	c.Content = openChunkContentLenient(tx, c.TenantID, c.Content)
+	c.SourceContent = openChunkContentLenient(tx, c.TenantID, c.SourceContent)
+	return nil
 }
 
 // ChunkRevision is an immutable snapshot of a superseded chunk revision.
@@ -192,6 +237,35 @@ type ChunkRevision struct {
 	EditSource      string    `json:"edit_source" gorm:"type:varchar(16)"`
 	EditedAt        time.Time `json:"edited_at"`
 	CreatedAt       time.Time `json:"created_at"`
+
+	plain *string
+}
+
+// BeforeSave seals the snapshot like Chunk.BeforeSave.
+func (r *ChunkRevision) BeforeSave(tx *gorm.DB) error {
+	sealed, err := SealChunkContent(tx.Statement.Context, ContentCipherOf(tx), r.TenantID, r.Content)
+	if err != nil {
+		return err
+	}
+	if sealed != r.Content {
+		plain := r.Content
+		r.plain, r.Content = &plain, sealed
+	}
+	return nil
+}
+
+// AfterSave restores the plaintext replaced by BeforeSave.
+func (r *ChunkRevision) AfterSave(tx *gorm.DB) error {
+	if r.plain != nil {
+		r.Content, r.plain = *r.plain, nil
+	}
+	return nil
+}
+
+// AfterFind opens a sealed snapshot.
+func (r *ChunkRevision) AfterFind(tx *gorm.DB) error {
+	r.Content = openChunkContentLenient(tx, r.TenantID, r.Content)
+	return nil
 }
 
 // EmbeddingContent returns the chunk content with ContextHeader prepended
@@ -20,10 +20,19 @@ const (
 	PrincipalContextKey ContextKey = "Principal"
 	// TenantAPIKeyScopeContextKey carries per-API-key operation and KB scopes.
 	TenantAPIKeyScopeContextKey ContextKey = "TenantAPIKeyScope"
+	// CustomRoleGrantContextKey carries the custom role assigned to the
+	// caller's membership. See CustomRoleGrantFromContext.
+	CustomRoleGrantContextKey ContextKey = "CustomRoleGrant"
 	// TenantRoleContextKey is the context key for the caller's TenantRole
 	// in the currently active tenant (loaded by the auth middleware from
 	// the tenant_members table). See TenantRoleFromContext.
 	TenantRoleContextKey ContextKey = "TenantRole"
+	// TenantRoleTenantIDContextKey records the tenant TenantRoleContextKey was
+	// resolved in. Shared agents swap TenantIDContextKey to the agent owner's
+	// tenant while the role still describes the caller's own workspace, so
+	// decisions that trust the role for a specific tenant must compare
+	// against this key. See TenantRoleTenantIDFromContext.
+	TenantRoleTenantIDContextKey ContextKey = "TenantRoleTenantID"
 	// SessionTenantIDContextKey is the context key for session owner's tenant ID.
 	// When set (e.g. in pipeline with shared agent), session/message lookups use this instead of TenantIDContextKey.
 	SessionTenantIDContextKey ContextKey = "SessionTenantID"
@@ -65,6 +74,10 @@ const (
 	// request lifecycle. Defined here (not inside the langfuse package) so
 	// that logger.CloneContext can preserve it without importing langfuse.
 	LangfuseTraceContextKey ContextKey = "LangfuseTrace"
+	// OTLPSpanContextKey carries the innermost span opened by the otlp
+	// tracing package, so its parent chain stays separate from Langfuse's.
+	// Defined here for the same reason as LangfuseTraceContextKey.
+	OTLPSpanContextKey ContextKey = "OTLPSpan"
 	// SystemAdminContextKey is the context key indicating whether the user is a system administrator
 	SystemAdminContextKey ContextKey = "SystemAdmin"
 	// BackgroundTaskContextKey marks a context whose model calls originate from
@@ -37,9 +37,13 @@ var contextCloneAcrossDetach = map[ContextKey]bool{
 	// and then reads the role via TenantRoleFromContext would otherwise see
 	// the type-zero TenantRole and fall back to Viewer, blocking even Owners.
 	TenantRoleContextKey: true,
+	// The tenant the role above belongs to; travels with the role.
+	TenantRoleTenantIDContextKey: true,
 	// Per-API-key operation and KB scopes: a restriction, so dropping it would
 	// hand background work broader reach than the key it came from.
 	TenantAPIKeyScopeContextKey: true,
+	// The custom role narrowing the member's base role: also a restriction.
+	CustomRoleGrantContextKey: true,
 
 	// Session scope. SessionTenantID re-scopes session/message lookups, while
 	// SandboxTenantID keys the session→sandbox binding to the session owner
@@ -64,6 +68,9 @@ var contextCloneAcrossDetach = map[ContextKey]bool{
 	RequestIDContextKey:     true,
 	LanguageContextKey:      true,
 	LangfuseTraceContextKey: true,
+	// Same for the OTLP span chain: work detached from a request stays in
+	// the request's trace instead of starting a new one.
+	OTLPSpanContextKey: true,
 
 	// The agent-level opt-out from long-term memory. Recall is gated inside
 	// the QA services, but extraction, the explicit "remember this" route and
@@ -71,6 +78,13 @@ var contextCloneAcrossDetach = map[ContextKey]bool{
 	// Dropping this key would let an agent that cannot read memory keep
 	// writing to it.
 	MemoryDisabledContextKey: true,
+	// The agent answering, so its search_memory tool reads the same shared
+	// notes recall injected.
+	MemoryAgentContextKey: true,
+	// The knowledge base metered model calls are billed to. Ingestion hands
+	// chunk vectorisation to a detached goroutine, which is where most
+	// embedding tokens are spent; dropping the key would file them untagged.
+	UsageKnowledgeBaseContextKey: true,
 
 	// Marks model calls as coming from an asynq worker so the per-model chat
 	// concurrency governor throttles them, leaving interactive chat latency
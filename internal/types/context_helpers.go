@@ -140,6 +140,14 @@ func TenantRoleFromContext(ctx context.Context) TenantRole {
 	return v
 }
 
+// TenantRoleTenantIDFromContext returns the tenant in which the caller holds
+// TenantRoleFromContext. Returns (0, false) when the auth middleware did not
+// record it; callers must then not trust the role for any specific tenant.
+func TenantRoleTenantIDFromContext(ctx context.Context) (uint64, bool) {
+	v, ok := ctx.Value(TenantRoleTenantIDContextKey).(uint64)
+	return v, ok && v != 0
+}
+
 // IsSystemAdminFromContext extracts the system admin flag from ctx.
 // Returns false (fail-closed) when the key is absent.
 func IsSystemAdminFromContext(ctx context.Context) bool {
@@ -181,6 +181,10 @@ type CustomAgentConfig struct {
 	AudioUploadEnabled bool `yaml:"audio_upload_enabled" json:"audio_upload_enabled"`
 	// ASR model ID for audio transcription (optional)
 	ASRModelID string `yaml:"asr_model_id" json:"asr_model_id"`
+	// Whether IM channels answer voice messages with a spoken reply as well (default: false)
+	VoiceReplyEnabled bool `yaml:"voice_reply_enabled" json:"voice_reply_enabled"`
+	// TTS model ID used to synthesize spoken replies
+	TTSModelID string `yaml:"tts_model_id" json:"tts_model_id"`
 	// Storage provider for image uploads: "local", "minio", "cos", "tos", "s3", "oss", "ks3".
 	// Empty means use the global/workspace default provider.
 	ImageStorageProvider string `yaml:"image_storage_provider" json:"image_storage_provider"`
@@ -220,6 +224,11 @@ type CustomAgentConfig struct {
 	// quick-answer / RAG-style agents do not want the added latency.
 	DataAnalysisEnabled bool `yaml:"data_analysis_enabled" json:"data_analysis_enabled"`
 
+	// ===== Knowledge Graph Settings =====
+	// GraphSearchMode decides when graph community reports are searched
+	// (GraphSearchModeAuto / Local / Global). Empty means auto.
+	GraphSearchMode string `yaml:"graph_search_mode" json:"graph_search_mode,omitempty"`
+
 	// ===== FAQ Strategy Settings =====
 	// Whether FAQ priority strategy is enabled (FAQ answers prioritized over document chunks)
 	FAQPriorityEnabled bool `yaml:"faq_priority_enabled" json:"faq_priority_enabled"`
@@ -236,6 +245,15 @@ type CustomAgentConfig struct {
 	// WebSearchProviderID references a specific WebSearchProviderEntity.
 	// If empty, the workspace's default provider (is_default=true) is used.
 	WebSearchProviderID string `yaml:"web_search_provider_id" json:"web_search_provider_id,omitempty"`
+	// ===== External SQL Settings =====
+	// SQLConnectionIDs selects the workspace SQL connections the agent may
+	// query. sql_schema / sql_query are registered only when this is non-empty.
+	SQLConnectionIDs []string `yaml:"sql_connection_ids" json:"sql_connection_ids,omitempty"`
+	// ===== Browser Settings =====
+	// BrowserConfigID selects the workspace browser service the agent drives
+	// with the browser_* tools. They are registered only when this is set.
+	BrowserConfigID string `yaml:"browser_config_id" json:"browser_config_id,omitempty"`
+
 	// Whether to auto-fetch full page content for reranked web search results
 	WebFetchEnabled bool `yaml:"web_fetch_enabled" json:"web_fetch_enabled"`
 	// Max number of pages to fetch after rerank (default: 3)
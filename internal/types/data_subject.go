@@ -0,0 +1,218 @@
+package types
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+)
+
+// DataSubjectCategory names one kind of personal data collected by a data
+// subject export or removed by an erasure.
+type DataSubjectCategory string
+
+const (
+	// DataSubjectSessions are the user's chat sessions, including
+	// soft-deleted ones, with their suggestion sets, temporary documents and
+	// IM session mappings.
+	DataSubjectSessions DataSubjectCategory = "sessions"
+	// DataSubjectMessages are the messages of those sessions.
+	DataSubjectMessages DataSubjectCategory = "messages"
+	// DataSubjectChatHistoryKnowledge is the knowledge that indexes the
+	// sessions' chat history for search.
+	DataSubjectChatHistoryKnowledge DataSubjectCategory = "chat_history_knowledge"
+	// DataSubjectSessionFiles are message attachments, images, artifacts and
+	// temporary documents in file storage.
+	DataSubjectSessionFiles DataSubjectCategory = "session_files"
+	// DataSubjectSessionStreams are the cached answer streams of the
+	// sessions (Redis, or in process without Redis).
+	DataSubjectSessionStreams DataSubjectCategory = "session_streams"
+	// DataSubjectMemory is the user's memory subject with its items,
+	// embeddings, topic statistics, document affinity and tombstones.
+	DataSubjectMemory DataSubjectCategory = "memory"
+	// DataSubjectSharedMemory are items of shared memory spaces the user
+	// proposed or reviewed.
+	DataSubjectSharedMemory DataSubjectCategory = "shared_memory"
+	// DataSubjectUploadedKnowledge is the knowledge the user uploaded or
+	// edited, attributed through knowledge versions and knowledge.created
+	// audit entries.
+	DataSubjectUploadedKnowledge DataSubjectCategory = "uploaded_knowledge"
+	// DataSubjectFeedback are answer ratings given from IM identities bound
+	// to the user.
+	DataSubjectFeedback DataSubjectCategory = "feedback"
+	// DataSubjectIMBindings map IM platform users to the user.
+	DataSubjectIMBindings DataSubjectCategory = "im_bindings"
+	// DataSubjectAPIKeys are the API keys the user created.
+	DataSubjectAPIKeys DataSubjectCategory = "api_keys"
+	// DataSubjectUsageRecords are the usage ledger rows of the user.
+	DataSubjectUsageRecords DataSubjectCategory = "usage_records"
+	// DataSubjectFavorites are the user's starred knowledge bases and agents.
+	DataSubjectFavorites DataSubjectCategory = "favorites"
+	// DataSubjectMCPTokens are the user's MCP OAuth tokens.
+	DataSubjectMCPTokens DataSubjectCategory = "mcp_oauth_tokens"
+	// DataSubjectKnowledgeACL are knowledge ACL entries granting the user by
+	// user ID or email.
+	DataSubjectKnowledgeACL DataSubjectCategory = "knowledge_acl"
+	// DataSubjectUserGroups are the user's memberships in user groups.
+	DataSubjectUserGroups DataSubjectCategory = "user_groups"
+	// DataSubjectSCIM is the link to the SCIM endpoint that provisioned the
+	// user, with the identity provider's user name and external ID.
+	DataSubjectSCIM DataSubjectCategory = "scim"
+	// DataSubjectAuditLogs are audit entries the user performed or was the
+	// target of.
+	DataSubjectAuditLogs DataSubjectCategory = "audit_logs"
+)
+
+// DataSubjectErasureAction is what an erasure did to a category.
+type DataSubjectErasureAction string
+
+const (
+	// DataSubjectErasureDeleted means the rows or objects were hard-deleted.
+	DataSubjectErasureDeleted DataSubjectErasureAction = "deleted"
+	// DataSubjectErasureAnonymized means the rows were kept with the user
+	// reference cleared, e.g. the usage ledger and knowledge attribution.
+	DataSubjectErasureAnonymized DataSubjectErasureAction = "anonymized"
+	// DataSubjectErasureRetained means the rows were kept as they are. Audit
+	// entries are retained: they are hash-chained and serve as the record of
+	// the erasure itself.
+	DataSubjectErasureRetained DataSubjectErasureAction = "retained"
+)
+
+// DataSubjectMemoryData is the memory held about a user.
+type DataSubjectMemoryData struct {
+	Subject     *MemorySubject       `json:"subject,omitempty"`
+	Items       []*MemoryItem        `json:"items"`
+	Topics      []*MemoryTopicStat   `json:"topics"`
+	DocAffinity []*MemoryDocAffinity `json:"doc_affinity"`
+	Tombstones  []*MemoryTombstone   `json:"tombstones"`
+}
+
+// DataSubjectGroupMembership is a user group the user belongs to.
+type DataSubjectGroupMembership struct {
+	GroupID    string    `json:"group_id"`
+	Name       string    `json:"name"`
+	ExternalID string    `json:"external_id,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// DataSubjectSession is an exported session with its messages.
+type DataSubjectSession struct {
+	*Session
+	Messages []*Message `json:"messages"`
+}
+
+// DataSubjectFeedbackEntry is an answer rating given from an IM identity bound
+// to the user. It mirrors the im_message_feedback table.
+type DataSubjectFeedbackEntry struct {
+	ID          string    `json:"id"`
+	IMChannelID string    `json:"im_channel_id"`
+	Platform    string    `json:"platform"`
+	IMUserID    string    `json:"im_user_id"`
+	SessionID   string    `json:"session_id"`
+	MessageID   string    `json:"message_id"`
+	Rating      string    `json:"rating"`
+	Comment     string    `json:"comment"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// DataSubjectIMBinding maps an IM platform user to the user. It mirrors the
+// im_user_bindings table.
+type DataSubjectIMBinding struct {
+	ID          string    `json:"id"`
+	IMChannelID string    `json:"im_channel_id"`
+	IMUserID    string    `json:"im_user_id"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// DataSubjectAPIKey is the exported metadata of an API key; the key itself
+// is never exported.
+type DataSubjectAPIKey struct {
+	ID           uint64      `json:"id"`
+	Name         string      `json:"name"`
+	FullAccess   bool        `json:"full_access"`
+	Capabilities StringArray `json:"capabilities"`
+	LastUsedAt   *time.Time  `json:"last_used_at,omitempty"`
+	ExpiresAt    *time.Time  `json:"expires_at,omitempty"`
+	RevokedAt    *time.Time  `json:"revoked_at,omitempty"`
+	CreatedAt    time.Time   `json:"created_at"`
+}
+
+// DataSubjectExportFile is one file of an export archive as listed in its
+// manifest.
+type DataSubjectExportFile struct {
+	Name    string `json:"name"`
+	Records int    `json:"records,omitempty"`
+	Size    int64  `json:"size"`
+	SHA256  string `json:"sha256"`
+}
+
+// DataSubjectExportManifest is manifest.json of an export archive. Missing
+// lists documents whose original file could not be read.
+type DataSubjectExportManifest struct {
+	TenantID    uint64                  `json:"tenant_id"`
+	UserID      string                  `json:"user_id"`
+	GeneratedBy string                  `json:"generated_by"`
+	GeneratedAt time.Time               `json:"generated_at"`
+	Files       []DataSubjectExportFile `json:"files"`
+	Missing     []string                `json:"missing,omitempty"`
+}
+
+// DataSubjectErasureRequest holds the options of an erasure.
+type DataSubjectErasureRequest struct {
+	// DeleteKnowledge also deletes the knowledge the user uploaded. By
+	// default it stays in the knowledge bases and only its attribution to
+	// the user is removed.
+	DeleteKnowledge bool `json:"delete_knowledge"`
+	// Reason is recorded in the audit entry, e.g. the ticket of the request.
+	Reason string `json:"reason" binding:"max=512"`
+}
+
+// DataSubjectErasureItem reports what an erasure did to one category.
+// Remaining is counted again after the erasure and is 0 when it succeeded.
+// Files and streams cannot be counted again: Failed counts those that could
+// not be removed, and Remaining equals Failed.
+type DataSubjectErasureItem struct {
+	Category  DataSubjectCategory      `json:"category"`
+	Action    DataSubjectErasureAction `json:"action"`
+	Found     int64                    `json:"found"`
+	Affected  int64                    `json:"affected"`
+	Remaining int64                    `json:"remaining"`
+	Failed    int64                    `json:"failed,omitempty"`
+}
+
+// DataSubjectErasureReport is the result of an erasure. Digest is the
+// SHA-256 of the report without the digest and audit fields (see
+// ComputeDigest); it is recorded in the privacy.subject_erased audit entry,
+// whose hash chain position is given by AuditLogID, AuditChainSeq and
+// AuditHash.
+type DataSubjectErasureReport struct {
+	ReportID        string                   `json:"report_id"`
+	TenantID        uint64                   `json:"tenant_id"`
+	UserID          string                   `json:"user_id"`
+	RequestedBy     string                   `json:"requested_by"`
+	Reason          string                   `json:"reason,omitempty"`
+	DeleteKnowledge bool                     `json:"delete_knowledge"`
+	StartedAt       time.Time                `json:"started_at"`
+	CompletedAt     time.Time                `json:"completed_at"`
+	Items           []DataSubjectErasureItem `json:"items"`
+	// Verified is true when nothing remains in any deleted or anonymized
+	// category and no object failed to be removed.
+	Verified      bool   `json:"verified"`
+	Digest        string `json:"digest"`
+	AuditLogID    uint64 `json:"audit_log_id,omitempty"`
+	AuditChainSeq uint64 `json:"audit_chain_seq,omitempty"`
+	AuditHash     string `json:"audit_hash,omitempty"`
+}
+
+// ComputeDigest returns the hex SHA-256 of the report's JSON encoding with
+// Digest and the audit fields cleared. Timestamps are encoded in UTC so a
+// report read back from JSON yields the same digest.
+func (r *DataSubjectErasureReport) ComputeDigest() string {
+	c := *r
+	c.Digest, c.AuditLogID, c.AuditChainSeq, c.AuditHash = "", 0, 0, ""
+	c.StartedAt, c.CompletedAt = c.StartedAt.UTC(), c.CompletedAt.UTC()
+	raw, _ := json.Marshal(&c)
+	sum := sha256.Sum256(raw)
+	return hex.EncodeToString(sum[:])
+}
@@ -23,6 +23,7 @@ const (
 	MatchTypeWebSearch    // 网络搜索匹配类型
 	MatchTypeDirectLoad   // Deprecated: reserved to preserve serialized enum values
 	MatchTypeDataAnalysis // 数据分析匹配类型
+	MatchTypeCommunity    // 图谱社区报告匹配类型
 )
 
 // IndexInfo contains information about indexed content
@@ -0,0 +1,134 @@
+package types
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/utils"
+	"gorm.io/gorm"
+)
+
+// TenantDataKey is one version of a tenant's data encryption key, stored
+// wrapped by a master key. New data is sealed with the active version; older
+// versions stay so existing data remains readable.
+type TenantDataKey struct {
+	ID       uint64 `json:"id" gorm:"primaryKey;autoIncrement"`
+	TenantID uint64 `json:"tenant_id" gorm:"not null;uniqueIndex:idx_tenant_data_key_version"`
+	Version  int    `json:"version" gorm:"not null;uniqueIndex:idx_tenant_data_key_version"`
+	// MasterKeyID names the master key WrappedKey is sealed with.
+	MasterKeyID string `json:"master_key_id" gorm:"type:varchar(128);not null"`
+	// WrappedKey is the base64 data key sealed by the master key. Never
+	// serialized.
+	WrappedKey string    `json:"-" gorm:"type:text;not null"`
+	Active     bool      `json:"active" gorm:"not null;default:false"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// TableName binds TenantDataKey to the tenant_data_keys table.
+func (TenantDataKey) TableName() string {
+	return "tenant_data_keys"
+}
+
+// TenantEncryptionStatus is the encryption state of a workspace.
+type TenantEncryptionStatus struct {
+	// Configured is false when the deployment has no key provider; the
+	// other fields are then empty.
+	Configured            bool             `json:"configured"`
+	FilesEncrypted        bool             `json:"files_encrypted"`
+	ChunkContentEncrypted bool             `json:"chunk_content_encrypted"`
+	CurrentMasterKeyID    string           `json:"current_master_key_id,omitempty"`
+	ActiveKeyVersion      int              `json:"active_key_version,omitempty"`
+	Keys                  []*TenantDataKey `json:"keys"`
+}
+
+// EncryptionSystemStatus is the deployment-wide encryption state.
+type EncryptionSystemStatus struct {
+	Configured            bool   `json:"configured"`
+	FilesEncrypted        bool   `json:"files_encrypted"`
+	ChunkContentEncrypted bool   `json:"chunk_content_encrypted"`
+	CurrentMasterKeyID    string `json:"current_master_key_id,omitempty"`
+	DataKeys              int64  `json:"data_keys"`
+	// PendingRewrap counts data keys still wrapped by an older master key.
+	// The old key must stay in the key file until this reaches zero.
+	PendingRewrap int64 `json:"pending_rewrap"`
+}
+
+// RewrapResult reports a master key re-wrap.
+type RewrapResult struct {
+	MasterKeyID string `json:"master_key_id"`
+	// Rewrapped counts data keys moved to MasterKeyID; keys already wrapped
+	// by it are skipped.
+	Rewrapped int `json:"rewrapped"`
+	Total     int `json:"total"`
+}
+
+// ContentCipher seals database text columns with tenant data keys. Seal
+// returns the plaintext unchanged when sealing new writes is turned off;
+// Open accepts both sealed and plaintext values.
+type ContentCipher interface {
+	SealContent(ctx context.Context, tenantID uint64, plaintext string) (string, error)
+	OpenContent(ctx context.Context, tenantID uint64, sealed string) (string, error)
+}
+
+// ErrContentCipherMissing is returned when a sealed value is read but no
+// key provider is configured.
+var ErrContentCipherMissing = errors.New("encrypted content found but no encryption key provider is configured")
+
+// ContentCipherPluginName is the name under which the content cipher is
+// registered on the database with db.Use. The cipher lives in the service
+// layer, which this package cannot import; the model hooks find it through
+// the gorm instance they run on.
+const ContentCipherPluginName = "weknora:content_cipher"
+
+// ContentCipherPlugin is a ContentCipher registered on a *gorm.DB.
+type ContentCipherPlugin interface {
+	gorm.Plugin
+	ContentCipher
+}
+
+// ContentCipherOf returns the cipher registered on db, or nil when there is
+// none (plain test databases).
+func ContentCipherOf(db *gorm.DB) ContentCipher {
+	if db == nil || db.Config == nil {
+		return nil
+	}
+	if c, ok := db.Config.Plugins[ContentCipherPluginName].(ContentCipherPlugin); ok {
+		return c
+	}
+	return nil
+}
+
+// SealChunkContent seals content for tenantID with c. Empty and already
+// sealed values, and every value when c is nil, are returned as-is.
+func SealChunkContent(ctx context.Context, c ContentCipher, tenantID uint64, content string) (string, error) {
+	if c == nil || content == "" || strings.HasPrefix(content, utils.EnvelopeContentPrefix) {
+		return content, nil
+	}
+	return c.SealContent(ctx, tenantID, content)
+}
+
+// OpenChunkContent reverses SealChunkContent; plaintext passes through.
+func OpenChunkContent(ctx context.Context, c ContentCipher, tenantID uint64, content string) (string, error) {
+	if !strings.HasPrefix(content, utils.EnvelopeContentPrefix) {
+		return content, nil
+	}
+	if c == nil {
+		return content, ErrContentCipherMissing
+	}
+	return c.OpenContent(ctx, tenantID, content)
+}
+
+// openChunkContentLenient is the load-path variant for AfterFind hooks. A
+// value that cannot be opened is kept sealed rather than blanked: saving the
+// row back then writes the same ciphertext instead of erasing the text. The
+// registered cipher logs the failure.
+func openChunkContentLenient(tx *gorm.DB, tenantID uint64, content string) string {
+	plain, err := OpenChunkContent(tx.Statement.Context, ContentCipherOf(tx), tenantID, content)
+	if err != nil {
+		return content
+	}
+	return plain
+}
@@ -19,6 +19,8 @@ type GraphNode struct {
 	Name       string   `json:"name,omitempty"`
 	Chunks     []string `json:"chunks,omitempty"`
 	Attributes []string `json:"attributes,omitempty"`
+	// Aliases are the names merged into this node by entity resolution.
+	Aliases []string `json:"aliases,omitempty"`
 }
 
 // GraphRelation represents the relation of the graph
@@ -0,0 +1,175 @@
+package types
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+)
+
+// Graph search modes for CustomAgentConfig.GraphSearchMode.
+//
+// Local search answers from the neighbourhood of the entities named in the
+// question. Global search answers from community reports, which is what
+// "what are the main themes of this collection" needs: no single entity is
+// mentioned, so local search has nothing to start from.
+const (
+	// GraphSearchModeAuto runs global search for summarize-style questions
+	// and local search otherwise. It is the default, and unknown values
+	// behave like it.
+	GraphSearchModeAuto = "auto"
+	// GraphSearchModeLocal never runs global search.
+	GraphSearchModeLocal = "local"
+	// GraphSearchModeGlobal runs global search on every retrieval turn.
+	GraphSearchModeGlobal = "global"
+)
+
+// GraphCommunity is one detected community of the knowledge graph of a
+// knowledge base, together with the report the KB's chat model wrote about it.
+//
+// Communities are hierarchical. Level 0 is the coarsest partition; every
+// community at level N+1 is nested inside exactly one community at level N,
+// which is recorded as its ParentID. A whole hierarchy is rebuilt at once and
+// replaces the previous one, so IDs are not stable across rebuilds.
+type GraphCommunity struct {
+	ID              string `json:"id"                gorm:"primaryKey;type:varchar(36)"`
+	TenantID        uint64 `json:"tenant_id"         gorm:"not null;index:idx_graph_communities_kb,priority:1"`
+	KnowledgeBaseID string `json:"knowledge_base_id" gorm:"type:varchar(36);not null;index:idx_graph_communities_kb,priority:2"`
+	Level           int    `json:"level"             gorm:"not null;default:0"`
+	ParentID        string `json:"parent_id"         gorm:"column:parent_id;type:varchar(36);not null;default:''"`
+	Title           string `json:"title"             gorm:"type:varchar(512);not null;default:''"`
+	Summary         string `json:"summary"           gorm:"not null;default:''"`
+	// Findings are the report's individual insights. Global search maps over
+	// title, summary and findings; the UI renders them as the report body.
+	Findings GraphCommunityFindings `json:"findings" gorm:"type:jsonb"`
+	// Rating is the model's 0-10 estimate of how important the community is
+	// to the collection. It breaks ties when no query embedding is available.
+	Rating float64 `json:"rating" gorm:"not null;default:0"`
+	// Entities lists member entity names, most connected first. Only the
+	// first graphCommunityMaxStoredEntities are kept; EntityCount is exact.
+	Entities      GraphCommunityStrings `json:"entities"       gorm:"type:jsonb"`
+	EntityCount   int                   `json:"entity_count"   gorm:"not null;default:0"`
+	RelationCount int                   `json:"relation_count" gorm:"not null;default:0"`
+	// ChunkIDs are the chunks the member entities were extracted from, capped
+	// like Entities. They let a report be traced back to source documents.
+	ChunkIDs         GraphCommunityStrings `json:"chunk_ids"          gorm:"column:chunk_ids;type:jsonb"`
+	EmbeddingModelID string                `json:"embedding_model_id" gorm:"type:varchar(64);not null;default:''"`
+	// Vector embeds title and summary with the KB's embedding model. It is
+	// packed with EncodeEmbedding, like memory item vectors.
+	Vector    []byte    `json:"-"          gorm:"type:bytea"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (GraphCommunity) TableName() string { return "graph_communities" }
+
+// GraphCommunityFinding is one insight inside a community report.
+type GraphCommunityFinding struct {
+	Summary     string `json:"summary"`
+	Explanation string `json:"explanation"`
+}
+
+// GraphCommunityFindings is the persisted list of findings.
+type GraphCommunityFindings []GraphCommunityFinding
+
+func (f GraphCommunityFindings) Value() (driver.Value, error) {
+	if f == nil {
+		return json.Marshal([]GraphCommunityFinding{})
+	}
+	return json.Marshal(f)
+}
+
+func (f *GraphCommunityFindings) Scan(value interface{}) error {
+	b, ok := jsonColumnBytes(value)
+	if !ok {
+		*f = nil
+		return nil
+	}
+	return json.Unmarshal(b, f)
+}
+
+// GraphCommunityStrings is a JSON string list column that scans from both
+// Postgres (bytes) and SQLite (text).
+type GraphCommunityStrings []string
+
+func (s GraphCommunityStrings) Value() (driver.Value, error) {
+	if s == nil {
+		return json.Marshal([]string{})
+	}
+	return json.Marshal(s)
+}
+
+func (s *GraphCommunityStrings) Scan(value interface{}) error {
+	b, ok := jsonColumnBytes(value)
+	if !ok {
+		*s = nil
+		return nil
+	}
+	return json.Unmarshal(b, s)
+}
+
+// jsonColumnBytes normalizes a JSON column value. ok is false for NULL and
+// empty values, which decode to a nil slice.
+func jsonColumnBytes(value interface{}) ([]byte, bool) {
+	var b []byte
+	switch v := value.(type) {
+	case []byte:
+		b = v
+	case string:
+		b = []byte(v)
+	default:
+		return nil, false
+	}
+	if len(b) == 0 {
+		return nil, false
+	}
+	return b, true
+}
+
+// GraphCommunityPayload is the payload of the per-KB community rebuild task.
+// Rebuilds are debounced per knowledge base: every graph extraction schedules
+// one, and the task ID coalesces them into a single run after ingestion settles.
+type GraphCommunityPayload struct {
+	TracingContext
+	TenantID        uint64 `json:"tenant_id"`
+	KnowledgeBaseID string `json:"knowledge_base_id"`
+	Language        string `json:"language,omitempty"`
+}
+
+// GraphGlobalSearchRequest asks for a map-reduce answer over community reports.
+type GraphGlobalSearchRequest struct {
+	KnowledgeBaseIDs []string
+	Query            string
+	// Level pins the community level to search. Negative picks, per KB, the
+	// finest level that still fits in MaxCommunities.
+	Level int
+	// MaxCommunities bounds how many reports enter the map step. Zero uses
+	// the service default.
+	MaxCommunities int
+	// ChatModelID runs the map and reduce calls. Empty falls back to the
+	// first knowledge base's summary model.
+	ChatModelID string
+	// Reduce asks for a final answer. The chat pipeline leaves it off: its
+	// own completion stage is the reduce step, and the map points arrive
+	// there as ordinary references.
+	Reduce   bool
+	Language string
+}
+
+// GraphGlobalSearchPoint is one rated statement produced by the map step.
+type GraphGlobalSearchPoint struct {
+	CommunityID     string `json:"community_id"`
+	KnowledgeBaseID string `json:"knowledge_base_id"`
+	CommunityTitle  string `json:"community_title"`
+	Level           int    `json:"level"`
+	Description     string `json:"description"`
+	// Score is the map model's 0-100 estimate of how much the point helps
+	// answer the question. Points scored 0 are dropped.
+	Score int `json:"score"`
+}
+
+// GraphGlobalSearchResult is the outcome of a global search.
+type GraphGlobalSearchResult struct {
+	Answer      string                    `json:"answer,omitempty"`
+	Points      []*GraphGlobalSearchPoint `json:"points"`
+	Communities int                       `json:"communities_considered"`
+}
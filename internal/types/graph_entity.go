@@ -0,0 +1,125 @@
+package types
+
+import "time"
+
+// Entity resolution folds entities that name the same thing ("Tencent",
+// "腾讯", "Tencent Holdings") into one canonical node. Extraction runs per
+// chunk and keys nodes by name, so without it every spelling becomes its own
+// node and graph queries only ever see a fragment of what is known.
+
+// Merge candidate statuses.
+const (
+	GraphMergeCandidatePending  = "pending"
+	GraphMergeCandidateAccepted = "accepted"
+	GraphMergeCandidateRejected = "rejected"
+)
+
+// Reasons a merge candidate was proposed.
+const (
+	// GraphMergeReasonNormalized: identical after case, width, whitespace and
+	// punctuation folding ("OpenAI" / "Open AI").
+	GraphMergeReasonNormalized = "normalized"
+	// GraphMergeReasonSuffix: identical once legal-form suffixes are dropped
+	// ("Tencent Holdings Ltd" / "Tencent").
+	GraphMergeReasonSuffix = "suffix"
+	// GraphMergeReasonAcronym: one is the initials of the other
+	// ("World Health Organization" / "WHO").
+	GraphMergeReasonAcronym = "acronym"
+	// GraphMergeReasonEmbedding: name embeddings are close, which is what
+	// catches translations such as "Tencent" / "腾讯".
+	GraphMergeReasonEmbedding = "embedding"
+	// GraphMergeReasonManual marks a decision taken by hand, such as the
+	// rejected pair a split leaves behind.
+	GraphMergeReasonManual = "manual"
+)
+
+// GraphMergeCandidate is a proposed merge of two entities of a knowledge
+// base, awaiting review. Decisions are kept, so a rejected pair is never
+// proposed again.
+type GraphMergeCandidate struct {
+	ID              string `json:"id"                gorm:"primaryKey;type:varchar(36)"`
+	TenantID        uint64 `json:"tenant_id"         gorm:"not null;uniqueIndex:idx_graph_merge_candidates_pair,priority:1"`
+	KnowledgeBaseID string `json:"knowledge_base_id" gorm:"type:varchar(36);not null;uniqueIndex:idx_graph_merge_candidates_pair,priority:2"`
+	// PairKey identifies the pair regardless of order; see GraphMergePairKey.
+	PairKey string `json:"-" gorm:"type:varchar(1024);not null;uniqueIndex:idx_graph_merge_candidates_pair,priority:3"`
+	// Canonical is the name the merge keeps, Alias the name it folds in.
+	// Accepting may swap them.
+	Canonical string `json:"canonical" gorm:"type:varchar(512);not null"`
+	Alias     string `json:"alias"     gorm:"type:varchar(512);not null"`
+	// Score is 1 for exact rule matches and the cosine similarity for
+	// embedding matches.
+	Score      float64    `json:"score"       gorm:"not null;default:0"`
+	Reason     string     `json:"reason"      gorm:"type:varchar(32);not null"`
+	Status     string     `json:"status"      gorm:"type:varchar(16);not null;default:'pending';index"`
+	ResolvedBy string     `json:"resolved_by" gorm:"type:varchar(36);not null;default:''"`
+	ResolvedAt *time.Time `json:"resolved_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+func (GraphMergeCandidate) TableName() string { return "graph_merge_candidates" }
+
+// GraphEntityAlias maps a merged-away entity name to the canonical one.
+// Extraction consults it, so documents ingested after a merge land on the
+// canonical node instead of recreating the alias.
+type GraphEntityAlias struct {
+	ID              string `json:"id"                gorm:"primaryKey;type:varchar(36)"`
+	TenantID        uint64 `json:"tenant_id"         gorm:"not null;uniqueIndex:idx_graph_entity_aliases_key,priority:1"`
+	KnowledgeBaseID string `json:"knowledge_base_id" gorm:"type:varchar(36);not null;uniqueIndex:idx_graph_entity_aliases_key,priority:2"`
+	// AliasKey is the lower-cased alias, the form extraction looks up.
+	AliasKey  string    `json:"-"         gorm:"type:varchar(512);not null;uniqueIndex:idx_graph_entity_aliases_key,priority:3"`
+	Alias     string    `json:"alias"     gorm:"type:varchar(512);not null"`
+	Canonical string    `json:"canonical" gorm:"type:varchar(512);not null"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (GraphEntityAlias) TableName() string { return "graph_entity_aliases" }
+
+// GraphEntitySummary is one entity of a knowledge base as the review UI sees
+// it: per-document nodes of the same name are folded together.
+type GraphEntitySummary struct {
+	Name       string   `json:"name"`
+	Aliases    []string `json:"aliases,omitempty"`
+	Attributes []string `json:"attributes,omitempty"`
+	ChunkIDs   []string `json:"chunk_ids"`
+	Degree     int      `json:"degree"`
+}
+
+// GraphEntityMergeRequest merges Names into Canonical. Canonical may be one
+// of Names or a new spelling.
+type GraphEntityMergeRequest struct {
+	Canonical string   `json:"canonical" binding:"required"`
+	Names     []string `json:"names"     binding:"required"`
+}
+
+// GraphEntitySplitRequest moves the references of Name to the given chunks
+// onto a new entity NewName. Relationships follow the chunks they were
+// extracted from.
+type GraphEntitySplitRequest struct {
+	Name     string   `json:"name"      binding:"required"`
+	NewName  string   `json:"new_name"  binding:"required"`
+	ChunkIDs []string `json:"chunk_ids" binding:"required"`
+}
+
+// GraphMergeCandidateAcceptRequest optionally overrides which side of the
+// candidate survives.
+type GraphMergeCandidateAcceptRequest struct {
+	Canonical string `json:"canonical"`
+}
+
+// GraphEntityResolutionPayload is the payload of the per-KB resolution task.
+type GraphEntityResolutionPayload struct {
+	TracingContext
+	TenantID        uint64 `json:"tenant_id"`
+	KnowledgeBaseID string `json:"knowledge_base_id"`
+}
+
+// GraphMergePairKey orders two entity keys (lower-cased names) so (a, b)
+// and (b, a) share a key.
+func GraphMergePairKey(a, b string) string {
+	if b < a {
+		a, b = b, a
+	}
+	return a + "\x1f" + b
+}
@@ -28,6 +28,10 @@ type AuditLogQuery struct {
 // All writes are inserts (immutable rows); the only "update" surface
 // is none — once written, an entry is permanent.
 type AuditLogRepository interface {
+	// Create appends entry to its tenant's hash chain: it assigns
+	// ChainSeq, PrevHash and Hash and advances the chain head in the same
+	// transaction, so concurrent writers (across replicas) never fork the
+	// chain.
 	Create(ctx context.Context, entry *types.AuditLog) error
 	List(ctx context.Context, tenantID uint64, q *AuditLogQuery) ([]*types.AuditLog, error)
 	// CountSinceForDedup is the rate-limit primitive for LogDenied —
@@ -45,9 +49,36 @@ type AuditLogRepository interface {
 	// DeleteOlderThan removes audit rows whose created_at is strictly
 	// before cutoff and returns the affected row count. It is the
 	// retention primitive driven by the daily background sweep.
-	// Implementations should delete in a single statement (no per-row
-	// fetch) so the long-tail cost stays at "one DELETE per sweep".
-	DeleteOlderThan(ctx context.Context, cutoff time.Time) (int64, error)
+	// maxID > 0 additionally keeps every row with id > maxID (rows an
+	// export sink has not shipped yet). Chained rows are removed as a
+	// per-tenant prefix of the chain and the newest removed entry becomes
+	// the head's retention checkpoint.
+	DeleteOlderThan(ctx context.Context, cutoff time.Time, maxID uint64) (int64, error)
+
+	// GetChainHead returns the tenant's chain head, or nil when the
+	// tenant has never written a chained entry.
+	GetChainHead(ctx context.Context, tenantID uint64) (*types.AuditLogChainHead, error)
+	// ListChain returns up to limit chained rows of a tenant with
+	// chain_seq > afterSeq, in chain order.
+	ListChain(ctx context.Context, tenantID uint64, afterSeq uint64, limit int) ([]*types.AuditLog, error)
+	// CountUnchained counts the tenant's rows written before the chain
+	// existed (chain_seq = 0).
+	CountUnchained(ctx context.Context, tenantID uint64) (int64, error)
+
+	// ListForExport returns up to limit rows of every tenant with
+	// id > afterID and created_at < before, in id order.
+	ListForExport(ctx context.Context, afterID uint64, before time.Time, limit int) ([]*types.AuditLog, error)
+	// AcquireSinkLease claims the export lease of sink for owner until
+	// the given time, creating the cursor row on first use. It returns
+	// the cursor and false when another owner holds an unexpired lease.
+	AcquireSinkLease(ctx context.Context, sink, owner string, until time.Time) (*types.AuditLogSinkCursor, bool, error)
+	// UpdateSinkCursor records the export progress of sink while owner
+	// still holds its lease: lastID is the newest shipped row id and
+	// lastError the most recent delivery error ("" on success).
+	UpdateSinkCursor(ctx context.Context, sink, owner string, lastID uint64, lastError string) error
+	// ListSinkCursors returns the cursors of the given sinks; sinks that
+	// have never run have no row.
+	ListSinkCursors(ctx context.Context, sinks []string) ([]*types.AuditLogSinkCursor, error)
 }
 
 // AuditLogService is the high-level audit API the rest of the codebase
@@ -72,6 +103,14 @@ type AuditLogService interface {
 	// Purge deletes rows whose created_at is strictly older than the
 	// retention horizon. retentionDays <= 0 makes the call a no-op,
 	// which keeps the daily sweep cheap when retention is disabled.
+	// Rows not yet shipped by every configured export sink are kept.
 	// Returns rows deleted; transient repo errors propagate.
 	Purge(ctx context.Context, retentionDays int) (int64, error)
+	// LogAPIKeyRead records a successful data read by an API key. Subject
+	// to the same 1-minute dedup as LogDenied, keyed by
+	// (tenant_id, key, raw request path).
+	LogAPIKeyRead(ctx context.Context, c *gin.Context, tenantID, keyID uint64) error
+	// VerifyChain walks the tenant's hash chain from its retention
+	// checkpoint to its head and reports the first broken link.
+	VerifyChain(ctx context.Context, tenantID uint64) (*types.AuditChainVerification, error)
 }
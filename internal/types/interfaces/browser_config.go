@@ -0,0 +1,52 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// BrowserConfigRepository defines the repository interface for browser config CRUD
+type BrowserConfigRepository interface {
+	// Create creates a new browser config
+	Create(ctx context.Context, cfg *types.BrowserConfig) error
+	// GetByID retrieves a browser config by ID within a tenant scope; nil when absent
+	GetByID(ctx context.Context, tenantID uint64, id string) (*types.BrowserConfig, error)
+	// List lists all browser configs for a tenant
+	List(ctx context.Context, tenantID uint64) ([]*types.BrowserConfig, error)
+	// Update updates a browser config
+	Update(ctx context.Context, cfg *types.BrowserConfig) error
+	// Delete deletes a browser config (soft delete)
+	Delete(ctx context.Context, tenantID uint64, id string) error
+}
+
+// BrowserConfigService manages the headless browser services agents drive
+// through the browser_* tools. Tenant isolation is enforced by the handler
+// layer (getOwned pattern) and, for agent tools, by always passing the
+// agent's tenant ID.
+type BrowserConfigService interface {
+	// CreateConfig validates and stores a new config.
+	// cfg.TenantID must be set by the caller (handler).
+	CreateConfig(ctx context.Context, cfg *types.BrowserConfig) error
+	// UpdateConfig validates and updates an existing config.
+	UpdateConfig(ctx context.Context, cfg *types.BrowserConfig) error
+	// DeleteConfig deletes a config by tenant + id.
+	DeleteConfig(ctx context.Context, tenantID uint64, id string) error
+	// GetConfig returns a config by tenant + id; nil when absent.
+	GetConfig(ctx context.Context, tenantID uint64, id string) (*types.BrowserConfig, error)
+	// ListConfigs lists all configs of a tenant.
+	ListConfigs(ctx context.Context, tenantID uint64) ([]*types.BrowserConfig, error)
+
+	// UpdateConfigCredentials writes the token. nil means "do not touch";
+	// empty string is a no-op (clearing goes through ClearConfigCredential).
+	// Returns the updated entity.
+	UpdateConfigCredentials(
+		ctx context.Context, tenantID uint64, id string, token *string,
+	) (*types.BrowserConfig, error)
+	// ClearConfigCredential removes a single credential field. Currently
+	// only "token" is recognized. Idempotent on already-empty fields.
+	ClearConfigCredential(ctx context.Context, tenantID uint64, id, field string) error
+
+	// TestConfig connects to the browser, opens a blank page and closes it.
+	TestConfig(ctx context.Context, cfg *types.BrowserConfig) error
+}
@@ -0,0 +1,111 @@
+package interfaces
+
+import (
+	"context"
+	"io"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// DataSubjectService answers data subject requests (GDPR / PIPL) for a
+// user of the workspace in ctx.
+type DataSubjectService interface {
+	// Export writes a zip archive of everything the workspace holds about
+	// the user to w. Nothing is written when collecting the data fails.
+	Export(ctx context.Context, userID string, w io.Writer) error
+	// Erase hard-deletes or anonymizes the user's data across the database,
+	// file storage, vector stores and stream state, and returns a report
+	// whose digest is anchored in the audit hash chain.
+	Erase(ctx context.Context, userID string, req *types.DataSubjectErasureRequest) (*types.DataSubjectErasureReport, error)
+}
+
+// DataSubjectRepository reads and removes a user's data inside a tenant.
+// Reads include soft-deleted sessions and messages; deletes are hard.
+type DataSubjectRepository interface {
+	// Count returns the number of rows the tenant holds about the user per
+	// category. email is the user's sign-in email, empty when unknown, and
+	// memorySubjectID the user's memory subject.
+	Count(
+		ctx context.Context, tenantID uint64, userID, email, memorySubjectID string,
+	) (map[types.DataSubjectCategory]int64, error)
+
+	// ListSessions returns the user's sessions, oldest first.
+	ListSessions(ctx context.Context, tenantID uint64, userID string) ([]*types.Session, error)
+	// ListMessages returns the messages of the sessions, oldest first.
+	ListMessages(ctx context.Context, sessionIDs []string) ([]*types.Message, error)
+	// ListTemporaryDocuments returns the temporary documents of the sessions.
+	ListTemporaryDocuments(ctx context.Context, tenantID uint64, sessionIDs []string) ([]*types.TemporaryDocument, error)
+	// DeleteSessions deletes the sessions together with their messages,
+	// suggestions, temporary documents and IM session mappings. It returns
+	// the number of sessions and messages deleted.
+	DeleteSessions(ctx context.Context, tenantID uint64, sessionIDs []string) (sessions, messages int64, err error)
+
+	// GetMemory returns the memory of a subject.
+	GetMemory(ctx context.Context, tenantID uint64, subjectID string) (*types.DataSubjectMemoryData, error)
+	// DeleteMemory deletes every memory row of a subject and returns how
+	// many were deleted.
+	DeleteMemory(ctx context.Context, tenantID uint64, subjectID string) (int64, error)
+	// ListSharedMemory returns the shared memory items the subject proposed
+	// or reviewed.
+	ListSharedMemory(ctx context.Context, tenantID uint64, subjectID string) ([]*types.MemoryItem, error)
+	// AnonymizeSharedMemory deletes the subject's proposals that are still
+	// pending and clears the subject as proposer or reviewer of the other
+	// shared items. It returns how many items were deleted or cleared.
+	AnonymizeSharedMemory(ctx context.Context, tenantID uint64, subjectID string) (int64, error)
+
+	// ListUploadedKnowledgeIDs returns the knowledge the user created or
+	// edited, including soft-deleted knowledge.
+	ListUploadedKnowledgeIDs(ctx context.Context, tenantID uint64, userID string) ([]string, error)
+	// CountKnowledge counts the knowledge rows, including soft-deleted ones,
+	// that still exist among ids.
+	CountKnowledge(ctx context.Context, tenantID uint64, ids []string) (int64, error)
+	// AnonymizeKnowledgeEditor clears the user from knowledge versions.
+	AnonymizeKnowledgeEditor(ctx context.Context, tenantID uint64, userID string) (int64, error)
+
+	// ListFeedback returns the feedback given from the user's IM identities.
+	ListFeedback(ctx context.Context, tenantID uint64, userID string) ([]*types.DataSubjectFeedbackEntry, error)
+	// ListIMBindings returns the IM identities bound to the user.
+	ListIMBindings(ctx context.Context, tenantID uint64, userID string) ([]*types.DataSubjectIMBinding, error)
+	// DeleteFeedback deletes the feedback and the IM bindings of the user.
+	DeleteFeedback(ctx context.Context, tenantID uint64, userID string) (feedback, bindings int64, err error)
+
+	// ListAPIKeys returns the API keys the user created.
+	ListAPIKeys(ctx context.Context, tenantID uint64, userID string) ([]*types.TenantAPIKey, error)
+	// ClearAPIKeyCreator removes the user as creator of API keys.
+	ClearAPIKeyCreator(ctx context.Context, tenantID uint64, userID string) (int64, error)
+
+	// ListUsageRecords returns the user's usage records, oldest first.
+	ListUsageRecords(ctx context.Context, tenantID uint64, userID string) ([]*types.UsageRecord, error)
+	// AnonymizeUsageRecords clears the user from usage records; totals are
+	// kept for billing.
+	AnonymizeUsageRecords(ctx context.Context, tenantID uint64, userID string) (int64, error)
+
+	// ListFavorites returns the user's favorites.
+	ListFavorites(ctx context.Context, tenantID uint64, userID string) ([]*types.UserResourceFavorite, error)
+	// DeleteFavorites deletes the user's favorites.
+	DeleteFavorites(ctx context.Context, tenantID uint64, userID string) (int64, error)
+
+	// ListMCPTokens returns the user's MCP OAuth tokens.
+	ListMCPTokens(ctx context.Context, tenantID uint64, userID string) ([]*types.MCPOAuthToken, error)
+	// DeleteMCPTokens deletes the user's MCP OAuth tokens.
+	DeleteMCPTokens(ctx context.Context, tenantID uint64, userID string) (int64, error)
+
+	// ListKnowledgeACLEntries returns the knowledge ACL entries that grant
+	// the user by user ID or by email.
+	ListKnowledgeACLEntries(ctx context.Context, tenantID uint64, userID, email string) ([]*types.KnowledgeACLEntry, error)
+	// DeleteKnowledgeACLEntries deletes those entries. An item left without
+	// entries gets a KnowledgeACLErasedEmail entry so it stays restricted.
+	DeleteKnowledgeACLEntries(ctx context.Context, tenantID uint64, userID, email string) (int64, error)
+	// ListUserGroupMemberships returns the user groups the user belongs to.
+	ListUserGroupMemberships(ctx context.Context, tenantID uint64, userID string) ([]*types.DataSubjectGroupMembership, error)
+	// DeleteUserGroupMemberships removes the user from every user group.
+	DeleteUserGroupMemberships(ctx context.Context, tenantID uint64, userID string) (int64, error)
+	// ListSCIMLinks returns the user's SCIM provisioning link.
+	ListSCIMLinks(ctx context.Context, tenantID uint64, userID string) ([]*types.TenantSCIMUser, error)
+	// DeleteSCIMLinks deletes the user's SCIM provisioning link.
+	DeleteSCIMLinks(ctx context.Context, tenantID uint64, userID string) (int64, error)
+
+	// ListAuditLogs returns the audit entries the user performed or was the
+	// target of, in chain order.
+	ListAuditLogs(ctx context.Context, tenantID uint64, userID string) ([]*types.AuditLog, error)
+}
@@ -0,0 +1,69 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// KeyManagementService holds the master keys that wrap tenant data keys.
+// Master key material never leaves it: callers only see wrapped blobs.
+// The local key file provider implements it; an external KMS plugs in
+// behind the same three methods.
+type KeyManagementService interface {
+	// CurrentKeyID names the master key Wrap uses.
+	CurrentKeyID(ctx context.Context) (string, error)
+	// Wrap seals a data key with the current master key.
+	Wrap(ctx context.Context, plaintext []byte) (keyID string, wrapped []byte, err error)
+	// Unwrap opens a data key sealed by the named master key.
+	Unwrap(ctx context.Context, keyID string, wrapped []byte) ([]byte, error)
+}
+
+// TenantDataKeyProvider hands out unwrapped tenant data keys. It is the
+// narrow view the file service wrapper and the content cipher need.
+type TenantDataKeyProvider interface {
+	// ActiveDataKey returns the active data key of the tenant, creating the
+	// first one on demand.
+	ActiveDataKey(ctx context.Context, tenantID uint64) (version int, key []byte, err error)
+	// DataKey returns a specific version, active or not.
+	DataKey(ctx context.Context, tenantID uint64, version int) ([]byte, error)
+}
+
+// TenantDataKeyService manages tenant data keys and their rotation.
+type TenantDataKeyService interface {
+	TenantDataKeyProvider
+	types.ContentCipher
+	// Configured reports whether a key provider is set up.
+	Configured() bool
+	Status(ctx context.Context, tenantID uint64) (*types.TenantEncryptionStatus, error)
+	// SystemStatus reports the deployment-wide state, including how many
+	// data keys still wait for a re-wrap.
+	SystemStatus(ctx context.Context) (*types.EncryptionSystemStatus, error)
+	// RotateTenantKey creates a new active data key version. Existing data
+	// keeps its version and stays readable; nothing is re-encrypted.
+	RotateTenantKey(ctx context.Context, tenantID uint64) (*types.TenantDataKey, error)
+	// RewrapDataKeys re-wraps every data key not yet sealed by the current
+	// master key, so the old master key can be retired without touching
+	// the encrypted data.
+	RewrapDataKeys(ctx context.Context) (*types.RewrapResult, error)
+}
+
+// TenantDataKeyRepository persists wrapped tenant data keys.
+type TenantDataKeyRepository interface {
+	ListByTenant(ctx context.Context, tenantID uint64) ([]*types.TenantDataKey, error)
+	// Get returns one version, or (nil, nil).
+	Get(ctx context.Context, tenantID uint64, version int) (*types.TenantDataKey, error)
+	// GetActive returns the active version, or (nil, nil).
+	GetActive(ctx context.Context, tenantID uint64) (*types.TenantDataKey, error)
+	// CreateActive inserts key as the tenant's active version and
+	// deactivates the previous one, in one transaction. It fails on a
+	// duplicate (tenant, version), which callers treat as a lost race.
+	CreateActive(ctx context.Context, key *types.TenantDataKey) error
+	// ListNotWrappedBy returns up to limit keys (ordered by ID, after
+	// afterID) whose master key differs from masterKeyID.
+	ListNotWrappedBy(ctx context.Context, masterKeyID string, afterID uint64, limit int) ([]*types.TenantDataKey, error)
+	// UpdateWrapping replaces the wrapped key of one row.
+	UpdateWrapping(ctx context.Context, id uint64, masterKeyID, wrappedKey string) error
+	Count(ctx context.Context) (int64, error)
+	CountNotWrappedBy(ctx context.Context, masterKeyID string) (int64, error)
+}
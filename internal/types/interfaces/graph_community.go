@@ -0,0 +1,48 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/hibiken/asynq"
+)
+
+// GraphCommunityRepository stores the community hierarchy of each knowledge
+// base. Every method takes the owning tenant explicitly: global search runs
+// over shared knowledge bases whose tenant differs from the caller's.
+type GraphCommunityRepository interface {
+	// ReplaceForKnowledgeBase swaps the stored hierarchy for a new one in a
+	// single transaction, so readers never observe a half-built hierarchy.
+	ReplaceForKnowledgeBase(ctx context.Context, tenantID uint64, kbID string, communities []*types.GraphCommunity) error
+	// ListByKnowledgeBase returns communities ordered by level and rating.
+	// A negative level returns every level.
+	ListByKnowledgeBase(ctx context.Context, tenantID uint64, kbID string, level int) ([]*types.GraphCommunity, error)
+	// GetByID returns one community, or (nil, nil) when it does not exist.
+	GetByID(ctx context.Context, tenantID uint64, kbID string, id string) (*types.GraphCommunity, error)
+	// DeleteByKnowledgeBase drops the whole hierarchy of a knowledge base.
+	DeleteByKnowledgeBase(ctx context.Context, tenantID uint64, kbID string) error
+}
+
+// GraphCommunityService detects communities in knowledge graphs, writes
+// their reports, and answers global questions from them.
+type GraphCommunityService interface {
+	// ScheduleRebuild debounces a community rebuild for a knowledge base.
+	// Failures are logged rather than returned: callers are ingestion paths
+	// that must not fail because a summary refresh could not be queued.
+	ScheduleRebuild(ctx context.Context, tenantID uint64, kbID string)
+	// RequestRebuild queues an immediate rebuild for the knowledge base in the
+	// request's tenant.
+	RequestRebuild(ctx context.Context, kbID string) error
+	// ListCommunities returns the communities of a knowledge base in the
+	// request's tenant. A negative level returns every level.
+	ListCommunities(ctx context.Context, kbID string, level int) ([]*types.GraphCommunity, error)
+	// GetCommunity returns one community of a knowledge base in the request's
+	// tenant.
+	GetCommunity(ctx context.Context, kbID string, id string) (*types.GraphCommunity, error)
+	// GlobalSearch maps the question over the most relevant community reports
+	// and, when asked, reduces the rated points into an answer. Callers are
+	// responsible for having authorized every knowledge base in the request.
+	GlobalSearch(ctx context.Context, req *types.GraphGlobalSearchRequest) (*types.GraphGlobalSearchResult, error)
+	// Handle runs a rebuild task.
+	Handle(ctx context.Context, t *asynq.Task) error
+}
@@ -0,0 +1,71 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/hibiken/asynq"
+)
+
+// GraphEntityRepository stores merge candidates and the alias table of
+// entity resolution. Every method takes the owning tenant explicitly, as
+// extraction workers run without a request tenant.
+type GraphEntityRepository interface {
+	// CreateCandidates inserts new candidates, skipping pairs that already
+	// have one in any status.
+	CreateCandidates(ctx context.Context, candidates []*types.GraphMergeCandidate) error
+	// ListCandidates returns candidates by descending score. An empty status
+	// returns every status.
+	ListCandidates(ctx context.Context, tenantID uint64, kbID string, status string) ([]*types.GraphMergeCandidate, error)
+	// GetCandidate returns one candidate, or (nil, nil) when it does not exist.
+	GetCandidate(ctx context.Context, tenantID uint64, kbID string, id string) (*types.GraphMergeCandidate, error)
+	// ListCandidatePairKeys returns the pair key of every stored candidate.
+	ListCandidatePairKeys(ctx context.Context, tenantID uint64, kbID string) ([]string, error)
+	// UpdateCandidate saves the status fields of a candidate.
+	UpdateCandidate(ctx context.Context, candidate *types.GraphMergeCandidate) error
+	// UpsertCandidateDecision records a decision for a pair, creating the
+	// candidate when none exists yet.
+	UpsertCandidateDecision(ctx context.Context, candidate *types.GraphMergeCandidate) error
+	// DeletePendingCandidatesFor drops pending candidates that mention any of
+	// the given names, which a merge or split has made stale.
+	DeletePendingCandidatesFor(ctx context.Context, tenantID uint64, kbID string, names []string) error
+	// ListAliases returns the alias table of a knowledge base.
+	ListAliases(ctx context.Context, tenantID uint64, kbID string) ([]*types.GraphEntityAlias, error)
+	// SaveAliases points every alias at canonical, repoints aliases of the
+	// folded names, and drops any alias entry for canonical itself.
+	SaveAliases(ctx context.Context, tenantID uint64, kbID string, canonical string, aliases []string) error
+	// DeleteAlias removes one alias entry, if present.
+	DeleteAlias(ctx context.Context, tenantID uint64, kbID string, alias string) error
+	// DeleteByKnowledgeBase drops candidates and aliases of a knowledge base.
+	DeleteByKnowledgeBase(ctx context.Context, tenantID uint64, kbID string) error
+}
+
+// GraphEntityService resolves duplicate entities in knowledge graphs: it
+// proposes merges, applies reviewed merges and splits, and keeps later
+// extractions on the canonical names.
+type GraphEntityService interface {
+	// ListEntities returns the entities of a knowledge base in the request's
+	// tenant, optionally filtered by a name or alias substring.
+	ListEntities(ctx context.Context, kbID string, keyword string, limit int) ([]*types.GraphEntitySummary, error)
+	// RequestResolution queues a resolution pass for a knowledge base.
+	RequestResolution(ctx context.Context, kbID string) error
+	// ListCandidates returns merge candidates of a knowledge base.
+	ListCandidates(ctx context.Context, kbID string, status string) ([]*types.GraphMergeCandidate, error)
+	// AcceptCandidate applies a pending candidate. canonical, when set, must
+	// be one of its two names and picks the survivor.
+	AcceptCandidate(ctx context.Context, kbID string, id string, canonical string) (*types.GraphMergeCandidate, error)
+	// RejectCandidate marks a pending candidate rejected.
+	RejectCandidate(ctx context.Context, kbID string, id string) (*types.GraphMergeCandidate, error)
+	// MergeEntities merges entities by hand.
+	MergeEntities(ctx context.Context, kbID string, req *types.GraphEntityMergeRequest) error
+	// SplitEntity splits chunk references off an entity by hand.
+	SplitEntity(ctx context.Context, kbID string, req *types.GraphEntitySplitRequest) error
+	// ChunkEntities returns the entities extracted from the given chunks of a
+	// knowledge base, for visualization. Callers authorize the knowledge base.
+	ChunkEntities(ctx context.Context, kbID string, chunkIDs []string) (*types.GraphData, error)
+	// CanonicalizeGraph rewrites freshly extracted names through the alias
+	// table of the knowledge base, in place.
+	CanonicalizeGraph(ctx context.Context, tenantID uint64, kbID string, graph *types.GraphData)
+	// Handle runs a resolution task.
+	Handle(ctx context.Context, t *asynq.Task) error
+}
@@ -127,6 +127,37 @@ type KnowledgeService interface {
 		knowledgeID string,
 		processOverrides *types.KnowledgeProcessOverrides,
 	) (*types.Knowledge, error)
+	// ListKnowledgeVersions returns the content versions of a knowledge item, newest first.
+	ListKnowledgeVersions(ctx context.Context, knowledgeID string) (*types.KnowledgeVersionListResponse, error)
+	// GetKnowledgeVersion returns one version with its markdown and chunk set.
+	GetKnowledgeVersion(ctx context.Context, knowledgeID string, version int) (*types.KnowledgeVersion, error)
+	// DiffKnowledgeVersions diffs the markdown of two versions. to <= 0 means the latest version.
+	DiffKnowledgeVersions(ctx context.Context, knowledgeID string, from, to int) (*types.KnowledgeVersionDiff, error)
+	// RestoreKnowledgeVersion re-indexes the knowledge from an earlier version.
+	// The restored content is recorded as a new version once parsed.
+	RestoreKnowledgeVersion(ctx context.Context, knowledgeID string, version int) (*types.Knowledge, error)
+	// TransferKnowledgeVersions moves the version history of a knowledge item
+	// that was replaced by another one (a data source update re-creates the
+	// item), so the history follows the content. An empty toKnowledgeID
+	// drops the history instead.
+	TransferKnowledgeVersions(ctx context.Context, fromKnowledgeID, toKnowledgeID string) error
+	// GetKnowledgeDuplicateReport clusters the near-duplicate documents and
+	// chunks of a knowledge base. threshold <= 0 uses the knowledge base's
+	// configured threshold.
+	GetKnowledgeDuplicateReport(
+		ctx context.Context, kb *types.KnowledgeBase, threshold float64,
+	) (*types.DuplicateReport, error)
+	// UpdateKnowledgeFreshness sets the validity and review dates of a
+	// knowledge item by hand.
+	UpdateKnowledgeFreshness(
+		ctx context.Context, knowledgeID string, update *types.KnowledgeFreshnessUpdate,
+	) (*types.Knowledge, error)
+	// ReviewKnowledge records that the content of a knowledge item was
+	// confirmed and schedules its next review.
+	ReviewKnowledge(ctx context.Context, knowledgeID string) (*types.Knowledge, error)
+	// GetStaleKnowledgeReport lists the expired, not yet valid, expiring and
+	// review-overdue items of a knowledge base.
+	GetStaleKnowledgeReport(ctx context.Context, kb *types.KnowledgeBase) (*types.StaleKnowledgeReport, error)
 	// CancelKnowledgeParse marks an in-progress parse as cancelled by the
 	// user. The knowledge row and any partially written chunks/index are
 	// kept; downstream queued tasks for the same knowledge are best-effort
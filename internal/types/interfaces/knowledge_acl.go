@@ -0,0 +1,81 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// KnowledgeACLService manages document-level access lists and user groups,
+// and evaluates them for the caller in ctx. Evaluation is fail-closed: a
+// caller that cannot be identified as a WeKnora user only passes
+// organization grants.
+type KnowledgeACLService interface {
+	// GetKnowledgeACL returns the access list of a knowledge item.
+	GetKnowledgeACL(ctx context.Context, knowledgeID string) (*types.KnowledgeACL, error)
+	// SetKnowledgeACL replaces the manual entries of a knowledge item.
+	SetKnowledgeACL(ctx context.Context, knowledgeID string, update *types.KnowledgeACLUpdate) (*types.KnowledgeACL, error)
+	// SyncConnectorACL replaces the connector entries of a synced item with
+	// the permissions in its metadata, and carries over the manual entries
+	// of the item it replaced (replacedEntries, read before the old item was
+	// deleted).
+	SyncConnectorACL(ctx context.Context, knowledge *types.Knowledge, metadata map[string]string,
+		replacedEntries []*types.KnowledgeACLEntry) error
+	// DeleteKnowledgeACL removes the entries of deleted knowledge items.
+	DeleteKnowledgeACL(ctx context.Context, tenantID uint64, knowledgeIDs []string) error
+
+	// DeniedKnowledgeIDs returns the restricted knowledge in the given
+	// knowledge bases that the caller may not read. Retrieval passes the
+	// result to every engine as RetrieveParams.ExcludeKnowledgeIDs.
+	DeniedKnowledgeIDs(ctx context.Context, kbs []*types.KnowledgeBase) ([]string, error)
+	// CanReadKnowledge reports whether the caller may open a knowledge item.
+	CanReadKnowledge(ctx context.Context, knowledge *types.Knowledge) (bool, error)
+	// RestrictSearchTargets records the denied knowledge of every target in
+	// SearchTarget.ExcludeKnowledgeIDs, removes denied documents from
+	// document targets and drops targets left without any document.
+	RestrictSearchTargets(ctx context.Context, targets types.SearchTargets) (types.SearchTargets, error)
+
+	// ListUserGroups returns the groups of the workspace in ctx.
+	ListUserGroups(ctx context.Context) ([]*types.UserGroup, error)
+	// GetUserGroup returns a group with its members.
+	GetUserGroup(ctx context.Context, id string) (*types.UserGroupDetail, error)
+	// CreateUserGroup creates a group in the workspace in ctx.
+	CreateUserGroup(ctx context.Context, req *types.UserGroupRequest) (*types.UserGroupDetail, error)
+	// UpdateUserGroup updates a group; a nil MemberUserIDs keeps the members.
+	UpdateUserGroup(ctx context.Context, id string, req *types.UserGroupRequest) (*types.UserGroupDetail, error)
+	// DeleteUserGroup deletes a group and its memberships. Entries granting
+	// the group stay, so documents restricted to it do not become public.
+	DeleteUserGroup(ctx context.Context, id string) error
+}
+
+// KnowledgeACLRepository stores knowledge ACL entries and user groups.
+type KnowledgeACLRepository interface {
+	// ListEntries returns the entries of the given knowledge items.
+	ListEntries(ctx context.Context, tenantID uint64, knowledgeIDs []string) ([]*types.KnowledgeACLEntry, error)
+	// ReplaceEntries swaps the entries of one source of a knowledge item.
+	ReplaceEntries(ctx context.Context, tenantID uint64, knowledgeID, source string,
+		entries []*types.KnowledgeACLEntry) error
+	// DeleteByKnowledgeIDs removes every entry of the given knowledge items.
+	DeleteByKnowledgeIDs(ctx context.Context, tenantID uint64, knowledgeIDs []string) error
+	// ListDenied returns the knowledge of the given knowledge bases (or, when
+	// knowledgeIDs is set, among those items) that has entries none of which
+	// matches subject.
+	ListDenied(ctx context.Context, kbIDs, knowledgeIDs []string,
+		subject types.KnowledgeACLSubject) ([]*types.Knowledge, error)
+
+	// ListGroups returns the groups of a workspace with their member counts.
+	ListGroups(ctx context.Context, tenantID uint64) ([]*types.UserGroup, error)
+	// GetGroup returns one group of a workspace.
+	GetGroup(ctx context.Context, tenantID uint64, id string) (*types.UserGroup, error)
+	// FindGroupsByKeys returns the groups of a workspace whose ID, external
+	// ID or name is one of keys.
+	FindGroupsByKeys(ctx context.Context, tenantID uint64, keys []string) ([]*types.UserGroup, error)
+	CreateGroup(ctx context.Context, group *types.UserGroup) error
+	UpdateGroup(ctx context.Context, group *types.UserGroup) error
+	// DeleteGroup removes a group and its memberships.
+	DeleteGroup(ctx context.Context, tenantID uint64, id string) error
+	// ListGroupMembers returns the memberships of a group.
+	ListGroupMembers(ctx context.Context, tenantID uint64, groupID string) ([]*types.UserGroupMember, error)
+	// ReplaceGroupMembers swaps the members of a group.
+	ReplaceGroupMembers(ctx context.Context, tenantID uint64, groupID string, userIDs []string) error
+}
@@ -0,0 +1,32 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// KnowledgeFingerprintRepository stores near-duplicate fingerprints of
+// documents and their chunks. Every method takes the owning tenant
+// explicitly, as parse workers run without a request tenant.
+type KnowledgeFingerprintRepository interface {
+	// Save replaces the fingerprint of a document together with its LSH
+	// bands and chunk fingerprints.
+	Save(ctx context.Context, fingerprint *types.KnowledgeFingerprint,
+		bandKeys []int64, chunks []*types.ChunkFingerprint) error
+	// FindCandidates returns the indexed (non-alias) documents of a
+	// knowledge base sharing at least one band key, excluding knowledgeID.
+	FindCandidates(ctx context.Context, tenantID uint64, kbID string,
+		bandKeys []int64, excludeKnowledgeID string) ([]*types.KnowledgeFingerprint, error)
+	// ListByKnowledgeBase returns every document fingerprint of a knowledge base.
+	ListByKnowledgeBase(ctx context.Context, tenantID uint64, kbID string) ([]*types.KnowledgeFingerprint, error)
+	// ListChunksByKnowledgeBase returns every chunk fingerprint of a knowledge base.
+	ListChunksByKnowledgeBase(ctx context.Context, tenantID uint64, kbID string) ([]*types.ChunkFingerprint, error)
+	// ListAliases returns the aliases of the given documents, oldest first.
+	ListAliases(ctx context.Context, tenantID uint64, knowledgeIDs []string) ([]*types.KnowledgeFingerprint, error)
+	// SetAliasOf points the given documents at aliasOf; an empty aliasOf
+	// turns them back into regular documents.
+	SetAliasOf(ctx context.Context, tenantID uint64, knowledgeIDs []string, aliasOf string) error
+	// DeleteByKnowledgeIDs drops the fingerprints of the given documents.
+	DeleteByKnowledgeIDs(ctx context.Context, tenantID uint64, knowledgeIDs []string) error
+}
@@ -0,0 +1,45 @@
+package interfaces
+
+import (
+	"context"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// KnowledgeFreshnessRepository reads and writes the validity and review
+// dates of knowledge. Every method except ListOutOfValidity takes the owning
+// tenant explicitly, as the freshness sweep runs without a request tenant.
+type KnowledgeFreshnessRepository interface {
+	// ListOutOfValidity returns those of the given knowledge IDs whose
+	// validity window does not contain now. Search calls it on results that
+	// were already authorized, possibly across shared knowledge bases, so it
+	// filters by ID only.
+	ListOutOfValidity(ctx context.Context, knowledgeIDs []string, now time.Time) ([]string, error)
+	// ListStale returns the knowledge of a knowledge base that is out of
+	// validity, expires before soonUntil or is overdue for review at now,
+	// most urgent first. A non-nil notifiedBefore skips items reported after it.
+	ListStale(ctx context.Context, tenantID uint64, kbID string, now, soonUntil time.Time,
+		notifiedBefore *time.Time, limit int) ([]*types.Knowledge, error)
+	// UpdateFreshness writes the validity dates, review dates and freshness
+	// source of a knowledge item without touching its other columns.
+	UpdateFreshness(ctx context.Context, knowledge *types.Knowledge) error
+	// ListTagRuleCandidates returns knowledge carrying tagID whose dates were
+	// set by neither a user nor a connector.
+	ListTagRuleCandidates(ctx context.Context, tenantID uint64, kbID, tagID string, limit int) ([]*types.Knowledge, error)
+	// ListUnscheduledReviews returns knowledge without a review date whose
+	// dates were set by neither a user nor a connector.
+	ListUnscheduledReviews(ctx context.Context, tenantID uint64, kbID string, limit int) ([]*types.Knowledge, error)
+	// MarkNotified records that the given knowledge was reported as stale.
+	MarkNotified(ctx context.Context, tenantID uint64, knowledgeIDs []string, at time.Time) error
+	// ListConfiguredKnowledgeBases returns every knowledge base that has a
+	// freshness configuration.
+	ListConfiguredKnowledgeBases(ctx context.Context) ([]*types.KnowledgeBase, error)
+}
+
+// IMNotifier pushes standalone messages to an IM chat outside of any
+// conversation, e.g. reports from background jobs.
+type IMNotifier interface {
+	// NotifyChat sends content to chatID through an IM channel of the tenant.
+	NotifyChat(ctx context.Context, tenantID uint64, channelID, chatID, content string) error
+}
@@ -0,0 +1,35 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// KnowledgeVersionRepository stores knowledge content versions. Every method
+// takes the owning tenant explicitly, as parse workers run without a
+// request tenant.
+type KnowledgeVersionRepository interface {
+	// Create stores a version as the next version number of its knowledge.
+	Create(ctx context.Context, version *types.KnowledgeVersion) error
+	// Latest returns the newest version without markdown and chunks, or
+	// (nil, nil) when the knowledge has none.
+	Latest(ctx context.Context, tenantID uint64, knowledgeID string) (*types.KnowledgeVersion, error)
+	// List returns versions newest first, without markdown and chunks.
+	List(ctx context.Context, tenantID uint64, knowledgeID string) ([]*types.KnowledgeVersion, error)
+	// Get returns one full version, or (nil, nil) when it does not exist.
+	Get(ctx context.Context, tenantID uint64, knowledgeID string, version int) (*types.KnowledgeVersion, error)
+	// Prune keeps the newest keep versions. It returns the file paths that
+	// no remaining version references.
+	Prune(ctx context.Context, tenantID uint64, knowledgeID string, keep int) ([]string, error)
+	// Transfer appends the versions of fromKnowledgeID before those of
+	// toKnowledgeID and moves them over.
+	Transfer(ctx context.Context, tenantID uint64, fromKnowledgeID, toKnowledgeID string) error
+	// DeleteByKnowledgeIDs drops every version of the given knowledge and
+	// returns their file paths.
+	DeleteByKnowledgeIDs(ctx context.Context, tenantID uint64, knowledgeIDs []string) ([]string, error)
+	// SealPlaintext re-saves the versions of every tenant whose markdown is
+	// still plaintext, so the model hooks seal them, and returns how many
+	// it sealed. Versions are never rewritten otherwise.
+	SealPlaintext(ctx context.Context) (int, error)
+}
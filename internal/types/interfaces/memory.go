@@ -172,6 +172,20 @@ type MemoryRepository interface {
 	ArchiveLowestRanked(ctx context.Context, scope MemoryScope, keep int) (int64, error)
 	// CountActive returns the number of active items in the scope.
 	CountActive(ctx context.Context, scope MemoryScope) (int64, error)
+
+	// ListSharedItems returns items from the workspace's shared spaces for the
+	// admin curation list. An empty subjectID covers every shared space; an
+	// empty status covers every status.
+	ListSharedItems(
+		ctx context.Context, tenantID uint64, subjectID, status string, limit, offset int,
+	) ([]*types.MemoryItem, int64, error)
+	// GetSharedItem returns one item from any shared space of the workspace, or
+	// (nil, nil) when it is absent or belongs to a person.
+	GetSharedItem(ctx context.Context, tenantID uint64, id string) (*types.MemoryItem, error)
+	// SetItemExpiry sets or clears when an item stops being recalled.
+	SetItemExpiry(ctx context.Context, scope MemoryScope, id string, expiresAt *time.Time) error
+	// MarkItemReviewed moves a shared item to status and records who decided.
+	MarkItemReviewed(ctx context.Context, scope MemoryScope, id, status, reviewer string) error
 }
 
 // MemoryRecall is what one turn pulls in: the resident block plus any
@@ -306,4 +320,28 @@ type MemoryService interface {
 	GetSettings(ctx context.Context) (*types.MemorySettings, error)
 	// SetEnabled flips the per-user opt out.
 	SetEnabled(ctx context.Context, enabled bool) error
+
+	// ShareItem proposes one of the caller's memories for the workspace's or
+	// an agent's shared space. The copy waits as pending until an admin
+	// approves it.
+	ShareItem(ctx context.Context, id, provenance, agentID string) (*types.MemoryItem, error)
+	// ListSharedItems backs the admin curation list. A blank provenance covers
+	// every shared space of the workspace.
+	ListSharedItems(
+		ctx context.Context, provenance, agentID, status string, limit, offset int,
+	) ([]*types.MemoryItem, int64, error)
+	// CreateSharedItem adds a shared note written by an admin, in effect at once.
+	CreateSharedItem(
+		ctx context.Context, provenance, agentID, kind, content string, importance int, expiresAt *time.Time,
+	) (*types.MemoryItem, error)
+	// UpdateSharedItem edits a shared note. A nil expiresAt clears the expiry.
+	UpdateSharedItem(
+		ctx context.Context, id, content string, importance int, expiresAt *time.Time,
+	) (*types.MemoryItem, error)
+	// ApproveSharedItem puts a pending proposal into use.
+	ApproveSharedItem(ctx context.Context, id string) (*types.MemoryItem, error)
+	// RejectSharedItem declines a pending proposal.
+	RejectSharedItem(ctx context.Context, id string) error
+	// DeleteSharedItem removes a shared note for everyone.
+	DeleteSharedItem(ctx context.Context, id string) error
 }
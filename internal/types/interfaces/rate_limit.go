@@ -0,0 +1,54 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// RateLimitService enforces the rate limit policies of a workspace. Each
+// check charges the workspace and the calling API key, user or embed
+// channel (see types.RateLimitSubjectsFromContext). A denied check returns
+// a 429 application error together with the decision that denied it; an
+// allowed check returns the tightest applicable decision, so callers can
+// report it in response headers. Limit 0 means no policy applied.
+type RateLimitService interface {
+	// CheckRequest counts one API request against requests_per_minute.
+	CheckRequest(ctx context.Context) (types.RateLimitDecision, error)
+	// AcquireStream takes a concurrent_streams slot for one chat response.
+	// release must be called when the response ends; it is a no-op when the
+	// slot was denied.
+	AcquireStream(ctx context.Context) (release func(), d types.RateLimitDecision, err error)
+	// ReserveIngest counts an upload of size bytes against ingest_mb_per_day.
+	// release gives the bytes back when the upload is then rejected.
+	ReserveIngest(ctx context.Context, size int64) (release func(), d types.RateLimitDecision, err error)
+	// CheckIngest rejects an import whose size is only known once it has
+	// been fetched (URL imports) when ingest_mb_per_day is used up today.
+	CheckIngest(ctx context.Context) (types.RateLimitDecision, error)
+	// RecordIngest counts size bytes of such an import after the fetch.
+	RecordIngest(ctx context.Context, size int64)
+	// CheckTokens rejects a new chat or search once tokens_per_minute is
+	// used up in the current minute.
+	CheckTokens(ctx context.Context) (types.RateLimitDecision, error)
+	// Record counts the tokens of a chat model call towards
+	// tokens_per_minute. It is installed next to the usage meter.
+	Record(ctx context.Context, ev types.UsageEvent)
+
+	// ListPolicies returns the policies of the workspace in ctx.
+	ListPolicies(ctx context.Context) ([]*types.RateLimitPolicy, error)
+	// SetPolicy creates or replaces the policy of a scope.
+	SetPolicy(ctx context.Context, req *types.RateLimitPolicyRequest) (*types.RateLimitPolicy, error)
+	// DeletePolicy removes a policy.
+	DeletePolicy(ctx context.Context, id uint64) error
+}
+
+// RateLimitPolicyRepository stores rate limit policies.
+type RateLimitPolicyRepository interface {
+	// ListPolicies returns the policies of a tenant, tenant policy first.
+	ListPolicies(ctx context.Context, tenantID uint64) ([]*types.RateLimitPolicy, error)
+	// UpsertPolicy creates or updates the policy of (tenant, scope type,
+	// scope ID) and fills in its ID.
+	UpsertPolicy(ctx context.Context, policy *types.RateLimitPolicy) error
+	// DeletePolicy removes a policy; it reports false when none matched.
+	DeletePolicy(ctx context.Context, tenantID, id uint64) (bool, error)
+}
@@ -14,4 +14,19 @@ type RetrieveGraphRepository interface {
 	DelGraph(ctx context.Context, namespace []types.NameSpace) error
 	// SearchNode searches for nodes in the repository
 	SearchNode(ctx context.Context, namespace types.NameSpace, nodes []string) (*types.GraphData, error)
+	// GetGraph returns every node and relation under a namespace. Nodes are
+	// stored per knowledge, so the same entity extracted from two documents
+	// comes back as two nodes with the same name.
+	GetGraph(ctx context.Context, namespace types.NameSpace) (*types.GraphData, error)
+	// SearchNodeByChunks returns the nodes extracted from any of the given
+	// chunks and the relations among them.
+	SearchNodeByChunks(ctx context.Context, namespace types.NameSpace, chunkIDs []string) (*types.GraphData, error)
+	// MergeNodes folds every node named in names into one node named
+	// canonical, per knowledge. Chunk references, attributes and relations
+	// move to the surviving node, and the folded names become its aliases.
+	MergeNodes(ctx context.Context, namespace types.NameSpace, canonical string, names []string) error
+	// SplitNode moves the references of the node called name to chunkIDs onto
+	// a node called newName. Relations whose other end was extracted from a
+	// moved chunk move with them; a relation backed by both sides is copied.
+	SplitNode(ctx context.Context, namespace types.NameSpace, name, newName string, chunkIDs []string) error
 }
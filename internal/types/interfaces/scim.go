@@ -0,0 +1,60 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// SCIMService implements the SCIM 2.0 Users and Groups resources of a
+// workspace. Users map to workspace members, Groups to workspace user
+// groups; a group with the organization extension is also provisioned as a
+// collaboration organization owned by the workspace. Errors are application errors carrying the HTTP status the SCIM
+// response should use.
+type SCIMService interface {
+	ListUsers(ctx context.Context, tenantID uint64, query *types.SCIMListQuery) (*types.SCIMListResponse, error)
+	GetUser(ctx context.Context, tenantID uint64, id string) (*types.SCIMUser, error)
+	// CreateUser provisions a user: an existing account with the same
+	// e-mail is adopted, otherwise a login-less account is created, and the
+	// user becomes a member of the workspace.
+	CreateUser(ctx context.Context, tenantID uint64, user *types.SCIMUser) (*types.SCIMUser, error)
+	// ReplaceUser applies a full PUT; active=false deprovisions the user.
+	ReplaceUser(ctx context.Context, tenantID uint64, id string, user *types.SCIMUser) (*types.SCIMUser, error)
+	// PatchUser applies add / replace / remove operations; setting active to
+	// false deprovisions the user, setting it to true restores them.
+	PatchUser(ctx context.Context, tenantID uint64, id string, patch *types.SCIMPatchRequest) (*types.SCIMUser, error)
+	// DeleteUser deprovisions the user and forgets the SCIM link.
+	DeleteUser(ctx context.Context, tenantID uint64, id string) error
+
+	ListGroups(ctx context.Context, tenantID uint64, query *types.SCIMListQuery) (*types.SCIMListResponse, error)
+	GetGroup(ctx context.Context, tenantID uint64, id string) (*types.SCIMGroup, error)
+	CreateGroup(ctx context.Context, tenantID uint64, group *types.SCIMGroup) (*types.SCIMGroup, error)
+	ReplaceGroup(ctx context.Context, tenantID uint64, id string, group *types.SCIMGroup) (*types.SCIMGroup, error)
+	PatchGroup(ctx context.Context, tenantID uint64, id string, patch *types.SCIMPatchRequest) (*types.SCIMGroup, error)
+	DeleteGroup(ctx context.Context, tenantID uint64, id string) error
+}
+
+// TenantSCIMUserRepository persists the links between workspaces and the
+// users their SCIM endpoint provisioned.
+type TenantSCIMUserRepository interface {
+	// List returns a page of links ordered by creation, optionally filtered
+	// by exact userName or externalId, with the total match count.
+	List(ctx context.Context, tenantID uint64, userName, externalID string, offset, limit int) ([]*types.TenantSCIMUser, int64, error)
+	// Get returns the link of a user, or (nil, nil).
+	Get(ctx context.Context, tenantID uint64, userID string) (*types.TenantSCIMUser, error)
+	// GetByUserName returns the link with the given userName, or (nil, nil).
+	GetByUserName(ctx context.Context, tenantID uint64, userName string) (*types.TenantSCIMUser, error)
+	// Save inserts or updates a link.
+	Save(ctx context.Context, link *types.TenantSCIMUser) error
+	Delete(ctx context.Context, tenantID uint64, userID string) error
+}
+
+// TenantSCIMOrganizationRepository persists the links between SCIM groups
+// and the organizations provisioned for them.
+type TenantSCIMOrganizationRepository interface {
+	// Get returns the link of a group, or (nil, nil).
+	Get(ctx context.Context, tenantID uint64, groupID string) (*types.TenantSCIMOrganization, error)
+	// Save inserts or updates a link.
+	Save(ctx context.Context, link *types.TenantSCIMOrganization) error
+	Delete(ctx context.Context, tenantID uint64, groupID string) error
+}
@@ -0,0 +1,60 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// SQLConnectionRepository defines the repository interface for external SQL connection CRUD
+type SQLConnectionRepository interface {
+	// Create creates a new SQL connection
+	Create(ctx context.Context, conn *types.SQLConnection) error
+	// GetByID retrieves a SQL connection by ID within a tenant scope; nil when absent
+	GetByID(ctx context.Context, tenantID uint64, id string) (*types.SQLConnection, error)
+	// List lists all SQL connections for a tenant
+	List(ctx context.Context, tenantID uint64) ([]*types.SQLConnection, error)
+	// Update updates a SQL connection
+	Update(ctx context.Context, conn *types.SQLConnection) error
+	// Delete deletes a SQL connection (soft delete)
+	Delete(ctx context.Context, tenantID uint64, id string) error
+}
+
+// SQLConnectionService manages external SQL connections and runs read-only
+// queries against them. Tenant isolation is enforced by the handler layer
+// (getOwned pattern) and, for agent tools, by always passing the agent's
+// tenant ID.
+type SQLConnectionService interface {
+	// CreateConnection validates and stores a new connection.
+	// conn.TenantID must be set by the caller (handler).
+	CreateConnection(ctx context.Context, conn *types.SQLConnection) error
+	// UpdateConnection validates and updates an existing connection.
+	UpdateConnection(ctx context.Context, conn *types.SQLConnection) error
+	// DeleteConnection deletes a connection by tenant + id.
+	DeleteConnection(ctx context.Context, tenantID uint64, id string) error
+	// GetConnection returns a connection by tenant + id; nil when absent.
+	GetConnection(ctx context.Context, tenantID uint64, id string) (*types.SQLConnection, error)
+	// ListConnections lists all connections of a tenant.
+	ListConnections(ctx context.Context, tenantID uint64) ([]*types.SQLConnection, error)
+
+	// UpdateConnectionCredentials writes the password. nil means "do not
+	// touch"; empty string is a no-op (clearing goes through
+	// ClearConnectionCredential). Returns the updated entity.
+	UpdateConnectionCredentials(
+		ctx context.Context, tenantID uint64, id string, password *string,
+	) (*types.SQLConnection, error)
+	// ClearConnectionCredential removes a single credential field. Currently
+	// only "password" is recognized. Idempotent on already-empty fields.
+	ClearConnectionCredential(ctx context.Context, tenantID uint64, id, field string) error
+
+	// TestConnection opens the database and runs a trivial read-only query.
+	TestConnection(ctx context.Context, conn *types.SQLConnection) error
+	// IntrospectSchema lists the tables and columns visible through the
+	// connection, merged with the configured descriptions. When tables is
+	// non-empty only those tables are returned.
+	IntrospectSchema(ctx context.Context, conn *types.SQLConnection, tables []string) (*types.SQLSchema, error)
+	// Query runs a single read-only SELECT with the connection's row limit
+	// and timeout. maxRows <= 0 uses the connection limit; larger values are
+	// clamped to it.
+	Query(ctx context.Context, conn *types.SQLConnection, query string, maxRows int) (*types.SQLQueryResult, error)
+}
@@ -15,6 +15,9 @@ type StreamEvent struct {
 	Done      bool                   `json:"done"`           // Whether this event is done
 	Timestamp time.Time              `json:"timestamp"`      // When this event occurred
 	Data      map[string]interface{} `json:"data,omitempty"` // Additional event data (references, metadata, etc.)
+	// Traceparent is the W3C traceparent of the producing otlp span, set by
+	// the stream package so readers in another replica can link to it.
+	Traceparent string `json:"traceparent,omitempty"`
 }
 
 // StreamManager stream manager interface - minimal append-only design
@@ -29,4 +32,9 @@ type StreamManager interface {
 	// Uses Redis LRange for incremental reads
 	// Returns: events slice, next offset for subsequent reads, error
 	GetEvents(ctx context.Context, sessionID, messageID string, fromOffset int) ([]StreamEvent, int, error)
+
+	// DeleteSessionEvents removes the streams of every message of a session
+	// before they expire, e.g. when the session's data is erased.
+	// Returns the number of message streams removed.
+	DeleteSessionEvents(ctx context.Context, sessionID string) (int, error)
 }
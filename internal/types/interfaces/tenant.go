@@ -97,6 +97,9 @@ type TenantAPIKeyRepository interface {
 	UpdateAPIKey(ctx context.Context, tenantID uint64, id uint64, key *types.TenantAPIKey) (*types.TenantAPIKey, error)
 	RevokeAPIKey(ctx context.Context, tenantID uint64, id uint64) error
 	RevokePlatformAPIKey(ctx context.Context, id uint64) error
+	// RevokeAPIKeysCreatedBy revokes every unrevoked tenant key of tenantID
+	// created by userID and returns how many were revoked.
+	RevokeAPIKeysCreatedBy(ctx context.Context, tenantID uint64, userID string) (int64, error)
 	UpdateAPIKeyHash(ctx context.Context, id uint64, hash string) error
 	UpdateAPIKeyLastUsed(ctx context.Context, id uint64, at time.Time) error
 	// ListKeysWithPlaceholderHash returns keys whose key_hash is still the
@@ -116,6 +119,8 @@ type TenantAPIKeyService interface {
 	UpdateAPIKey(ctx context.Context, req TenantAPIKeyUpdateRequest) (*types.TenantAPIKey, error)
 	RevokeAPIKey(ctx context.Context, tenantID uint64, id uint64) error
 	RevokePlatformAPIKey(ctx context.Context, id uint64) error
+	// RevokeAPIKeysCreatedBy revokes the keys a user created in a workspace.
+	RevokeAPIKeysCreatedBy(ctx context.Context, tenantID uint64, userID string) (int64, error)
 	// BackfillMissingKeyHashes computes and persists the SHA-256 key_hash
 	// for legacy keys still carrying the migration placeholder.
 	// Returns the number of keys backfilled.
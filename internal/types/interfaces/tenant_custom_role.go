@@ -0,0 +1,47 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// TenantCustomRoleService manages workspace-defined roles and their
+// assignment to members and tenant API keys.
+type TenantCustomRoleService interface {
+	// ListRoles returns the roles of a workspace with their assignment counts.
+	ListRoles(ctx context.Context, tenantID uint64) ([]*types.TenantCustomRoleDetail, error)
+	// GetRole returns one role of a workspace.
+	GetRole(ctx context.Context, tenantID, roleID uint64) (*types.TenantCustomRole, error)
+	CreateRole(ctx context.Context, tenantID uint64, req *types.TenantCustomRoleRequest) (*types.TenantCustomRole, error)
+	// UpdateRole updates a role. Members holding it follow a base role change.
+	UpdateRole(ctx context.Context, tenantID, roleID uint64, req *types.TenantCustomRoleRequest) (*types.TenantCustomRole, error)
+	// DeleteRole deletes a role that no member or API key holds.
+	DeleteRole(ctx context.Context, tenantID, roleID uint64) error
+
+	// AssignToMember gives a member the role.
+	AssignToMember(ctx context.Context, tenantID uint64, userID string, roleID uint64) error
+	// AssignToAPIKey binds a tenant API key to the role; a nil roleID
+	// unbinds it so the key's own capabilities apply again.
+	AssignToAPIKey(ctx context.Context, tenantID, keyID uint64, roleID *uint64) (*types.TenantAPIKey, error)
+}
+
+// TenantCustomRoleRepository persists tenant custom roles.
+type TenantCustomRoleRepository interface {
+	List(ctx context.Context, tenantID uint64) ([]*types.TenantCustomRole, error)
+	// Get returns a role of the workspace, or ErrTenantCustomRoleNotFound.
+	Get(ctx context.Context, tenantID, id uint64) (*types.TenantCustomRole, error)
+	// GetByName returns the role with the given name, or (nil, nil).
+	GetByName(ctx context.Context, tenantID uint64, name string) (*types.TenantCustomRole, error)
+	Create(ctx context.Context, role *types.TenantCustomRole) error
+	// Update saves a role and moves the active members holding it to its
+	// current base role in the same transaction.
+	Update(ctx context.Context, role *types.TenantCustomRole) error
+	Delete(ctx context.Context, tenantID, id uint64) error
+	// CountAssignments returns how many active members and unrevoked API keys
+	// of the workspace hold each role, keyed by role ID.
+	CountAssignments(ctx context.Context, tenantID uint64) (members, apiKeys map[uint64]int64, err error)
+	// SetAPIKeyRole binds or unbinds an unrevoked tenant API key. Returns
+	// ErrTenantAPIKeyNotFound when no such key exists in the workspace.
+	SetAPIKeyRole(ctx context.Context, tenantID, keyID uint64, roleID *uint64) (*types.TenantAPIKey, error)
+}
@@ -40,8 +40,9 @@ type TenantMemberRepository interface {
 	// search lists all memberships in tenant.
 	ListPagedByTenant(ctx context.Context, tenantID uint64, search string, offset, limit int) ([]*types.TenantMember, error)
 
-	// UpdateRole changes the role of an existing active membership. Returns
-	// gorm.ErrRecordNotFound if no active row matches.
+	// UpdateRole changes the role of an existing active membership and
+	// clears its custom role. Returns gorm.ErrRecordNotFound if no active
+	// row matches.
 	UpdateRole(ctx context.Context, userID string, tenantID uint64, role types.TenantRole) error
 
 	// SoftDelete marks the active membership as deleted. The user record
@@ -65,6 +66,12 @@ type TenantMemberRepository interface {
 	// repo-level ErrLastOwner sentinel when no other Owner exists.
 	DemoteOwnerAtomically(ctx context.Context, userID string, tenantID uint64, newRole types.TenantRole) error
 
+	// AssignCustomRole sets the member's role to the custom role's base
+	// role and records the custom role. Demoting the last Owner this way
+	// returns ErrLastOwner. Returns gorm.ErrRecordNotFound if no active
+	// row matches.
+	AssignCustomRole(ctx context.Context, userID string, tenantID uint64, role *types.TenantCustomRole) error
+
 	// RemoveOwnerAtomically soft-deletes an Owner row under the same
 	// lock as DemoteOwnerAtomically.
 	RemoveOwnerAtomically(ctx context.Context, userID string, tenantID uint64) error
@@ -48,6 +48,11 @@ type TenantMemberService interface {
 	// enforcing the "cannot demote the last active Owner" invariant.
 	UpdateRole(ctx context.Context, userID string, tenantID uint64, newRole types.TenantRole) error
 
+	// AssignCustomRole gives the member a tenant custom role; the member's
+	// role becomes the custom role's base role. Assigning a built-in role
+	// through UpdateRole drops the custom role again.
+	AssignCustomRole(ctx context.Context, userID string, tenantID uint64, role *types.TenantCustomRole) error
+
 	// RemoveMember soft-deletes the membership while enforcing the
 	// "cannot remove the last active Owner" invariant.
 	RemoveMember(ctx context.Context, userID string, tenantID uint64) error
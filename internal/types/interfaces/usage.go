@@ -0,0 +1,56 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// UsageService keeps the usage ledger and the monthly token budgets. It is
+// also the meter the model decorators report to (see models/metering).
+type UsageService interface {
+	// Record files one metered call, attributed from ctx. It never blocks
+	// on storage: records are buffered and written in batches.
+	Record(ctx context.Context, ev types.UsageEvent)
+	// CheckBudget returns ErrUsageBudgetExceeded when a hard-stop budget of
+	// the tenant or API key in ctx is used up this month.
+	CheckBudget(ctx context.Context) error
+	// EvaluateBudgets is CheckBudget plus the soft warning: warning is a
+	// short message when a budget of the caller passed its warning
+	// threshold, empty otherwise.
+	EvaluateBudgets(ctx context.Context) (warning string, err error)
+
+	// Report aggregates the ledger of the workspace in ctx.
+	Report(ctx context.Context, q *types.UsageReportQuery) (*types.UsageReport, error)
+	// ListBudgets returns the budgets of the workspace with this month's usage.
+	ListBudgets(ctx context.Context) ([]*types.UsageBudgetStatus, error)
+	// SetBudget creates or replaces the budget of a scope.
+	SetBudget(ctx context.Context, req *types.UsageBudgetRequest) (*types.UsageBudgetStatus, error)
+	// DeleteBudget removes a budget.
+	DeleteBudget(ctx context.Context, id uint64) error
+
+	// Close writes buffered records. Called on shutdown.
+	Close() error
+}
+
+// UsageRepository stores usage records and budgets.
+type UsageRepository interface {
+	// CreateRecords appends ledger rows.
+	CreateRecords(ctx context.Context, records []*types.UsageRecord) error
+	// Report groups the rows matching q by q.GroupBy, largest total first.
+	Report(ctx context.Context, q *types.UsageReportQuery) ([]types.UsageReportRow, error)
+	// SumBillableTokens returns the billable tokens of a tenant in a period;
+	// a non-zero apiKeyID narrows the sum to that API key.
+	SumBillableTokens(ctx context.Context, tenantID, apiKeyID uint64, period string) (int64, error)
+
+	// ListBudgets returns the budgets of a tenant, workspace budget first.
+	ListBudgets(ctx context.Context, tenantID uint64) ([]*types.UsageBudget, error)
+	// UpsertBudget creates or updates the budget of (tenant, API key).
+	UpsertBudget(ctx context.Context, budget *types.UsageBudget) error
+	// DeleteBudget removes a budget; it reports false when none matched.
+	DeleteBudget(ctx context.Context, tenantID, id uint64) (bool, error)
+	// MarkBudgetNotified records that the warning (exceeded=false) or the
+	// exceeded notice was sent for period. It returns false when another
+	// replica already marked the same period, so each notice fires once.
+	MarkBudgetNotified(ctx context.Context, id uint64, exceeded bool, period string) (bool, error)
+}
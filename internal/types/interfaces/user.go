@@ -18,6 +18,10 @@ type UserService interface {
 	// provisioning is the default tenant mode for a newly auto-created user
 	// (resolved by the caller from auth.default_tenant_mode).
 	LoginWithOIDC(ctx context.Context, code, redirectURI string, provisioning types.TenantProvisioningMode) (*types.OIDCCallbackResponse, error)
+	// LoginWithLDAP binds to the configured directory, auto-provisions the
+	// user if needed, applies the LDAP group-to-role mappings and completes
+	// login. A rejected bind is reported as an unsuccessful response.
+	LoginWithLDAP(ctx context.Context, username, password string, provisioning types.TenantProvisioningMode) (*types.LoginResponse, error)
 	// GetUserByID gets a user by ID
 	GetUserByID(ctx context.Context, id string) (*types.User, error)
 	// GetUsersByIDs batch-fetches users by id, returning a map keyed by
@@ -183,6 +183,19 @@ type Knowledge struct {
 	UpdatedAt time.Time `json:"updated_at"`
 	// Processed time of the knowledge
 	ProcessedAt *time.Time `json:"processed_at"`
+	// ValidFrom and ValidUntil bound the period in which the content is
+	// current; search excludes or down-weights the item outside it.
+	ValidFrom  *time.Time `json:"valid_from"`
+	ValidUntil *time.Time `json:"valid_until"`
+	// ReviewBy is when the owner should next confirm the content.
+	ReviewBy *time.Time `json:"review_by"`
+	// ReviewedAt is when the content was last confirmed.
+	ReviewedAt *time.Time `json:"reviewed_at"`
+	// FreshnessSource records who set the dates: manual, connector or tag_rule.
+	FreshnessSource string `json:"freshness_source"   gorm:"type:varchar(16);not null;default:''"`
+	// FreshnessNotifiedAt is when the freshness sweep last reported the item
+	// as stale, so owners are not notified again on every sweep.
+	FreshnessNotifiedAt *time.Time `json:"freshness_notified_at,omitempty"`
 	// Error message of the knowledge
 	ErrorMessage string `json:"error_message"`
 	// Deletion time of the knowledge
@@ -0,0 +1,243 @@
+package types
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// KnowledgeACLPrincipalType identifies who a knowledge ACL entry grants read
+// access to.
+type KnowledgeACLPrincipalType string
+
+const (
+	// KnowledgeACLPrincipalUser grants a WeKnora user by user ID.
+	KnowledgeACLPrincipalUser KnowledgeACLPrincipalType = "user"
+	// KnowledgeACLPrincipalEmail grants whoever signs in with the email. This
+	// is what connectors usually know about upstream readers.
+	KnowledgeACLPrincipalEmail KnowledgeACLPrincipalType = "email"
+	// KnowledgeACLPrincipalGroup grants the members of a user group, matched
+	// by group ID or by the group's external ID.
+	KnowledgeACLPrincipalGroup KnowledgeACLPrincipalType = "group"
+	// KnowledgeACLPrincipalOrganization grants every workspace that is a
+	// member of the organization.
+	KnowledgeACLPrincipalOrganization KnowledgeACLPrincipalType = "organization"
+)
+
+// IsValid reports whether t is a supported principal type.
+func (t KnowledgeACLPrincipalType) IsValid() bool {
+	switch t {
+	case KnowledgeACLPrincipalUser, KnowledgeACLPrincipalEmail,
+		KnowledgeACLPrincipalGroup, KnowledgeACLPrincipalOrganization:
+		return true
+	}
+	return false
+}
+
+const (
+	// KnowledgeACLSourceManual marks entries set through the API.
+	KnowledgeACLSourceManual = "manual"
+	// KnowledgeACLSourceConnector marks entries a data source synced from the
+	// upstream permissions. They are replaced on every sync of the item.
+	KnowledgeACLSourceConnector = "connector"
+)
+
+// MaxKnowledgeACLEntries bounds the entries of one knowledge item per source.
+const MaxKnowledgeACLEntries = 500
+
+// Metadata keys connectors use to report upstream readers of an item. Values
+// are comma-separated; see KnowledgeACLPrincipalsFromMetadata.
+const (
+	KnowledgeACLMetadataUsers         = "acl_users"
+	KnowledgeACLMetadataEmails        = "acl_emails"
+	KnowledgeACLMetadataGroups        = "acl_groups"
+	KnowledgeACLMetadataOrganizations = "acl_organizations"
+)
+
+// KnowledgeACLErasedEmail stands in for the last grantee of a knowledge item
+// when that grantee's data is erased. Dropping the entry would lift the
+// restriction; the reserved .invalid domain matches no sign-in, so the item
+// stays visible to administrators only.
+const KnowledgeACLErasedEmail = "erased-user@erased.invalid"
+
+// KnowledgeACLPrincipal is one grantee of a knowledge item.
+type KnowledgeACLPrincipal struct {
+	Type KnowledgeACLPrincipalType `json:"type"`
+	ID   string                    `json:"id"`
+}
+
+// Normalize trims the principal and lower-cases emails so lookups are exact.
+func (p KnowledgeACLPrincipal) Normalize() KnowledgeACLPrincipal {
+	p.Type = KnowledgeACLPrincipalType(strings.ToLower(strings.TrimSpace(string(p.Type))))
+	p.ID = strings.TrimSpace(p.ID)
+	if p.Type == KnowledgeACLPrincipalEmail {
+		p.ID = strings.ToLower(p.ID)
+	}
+	return p
+}
+
+// KnowledgeACLEntry grants one principal read access to a knowledge item.
+// A knowledge item without entries inherits the access of its knowledge base;
+// once it has any entry, only the listed principals (and the administrators
+// of the owning workspace) can retrieve or open it.
+type KnowledgeACLEntry struct {
+	ID            string                    `json:"id"             gorm:"type:varchar(36);primaryKey"`
+	TenantID      uint64                    `json:"tenant_id"`
+	KnowledgeID   string                    `json:"knowledge_id"   gorm:"type:varchar(36)"`
+	PrincipalType KnowledgeACLPrincipalType `json:"principal_type" gorm:"type:varchar(20)"`
+	PrincipalID   string                    `json:"principal_id"   gorm:"type:varchar(255)"`
+	Source        string                    `json:"source"         gorm:"type:varchar(16)"`
+	CreatedBy     string                    `json:"created_by"     gorm:"type:varchar(36)"`
+	CreatedAt     time.Time                 `json:"created_at"`
+}
+
+// TableName returns the table name for GORM.
+func (KnowledgeACLEntry) TableName() string {
+	return "knowledge_acl_entries"
+}
+
+// BeforeCreate assigns a UUID when the entry has none.
+func (e *KnowledgeACLEntry) BeforeCreate(tx *gorm.DB) error {
+	if e.ID == "" {
+		e.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// KnowledgeACLUpdate replaces the manual entries of a knowledge item. An
+// empty list removes the manual restriction; entries synced by a connector
+// are kept and can only change through the next sync.
+type KnowledgeACLUpdate struct {
+	Principals []KnowledgeACLPrincipal `json:"principals"`
+}
+
+// KnowledgeACL is the access list of one knowledge item as returned by the
+// API. Restricted is false when the item inherits knowledge base access.
+type KnowledgeACL struct {
+	KnowledgeID string               `json:"knowledge_id"`
+	Restricted  bool                 `json:"restricted"`
+	Entries     []*KnowledgeACLEntry `json:"entries"`
+}
+
+// KnowledgeACLSubject is the caller an ACL is evaluated for. UserID and
+// Email are only set for signed-in WeKnora users; machine callers, IM users
+// and embed visitors only match organization grants.
+type KnowledgeACLSubject struct {
+	UserID          string
+	Email           string
+	OrganizationIDs []string
+}
+
+// KnowledgeACLPrincipalsFromMetadata reads the acl_* metadata keys a
+// connector set on a fetched item. Values are split on commas, semicolons
+// and whitespace; duplicates are dropped. An item without any acl_* key
+// returns nil, which leaves it readable by the whole knowledge base.
+func KnowledgeACLPrincipalsFromMetadata(metadata map[string]string) []KnowledgeACLPrincipal {
+	keys := []struct {
+		key string
+		typ KnowledgeACLPrincipalType
+	}{
+		{KnowledgeACLMetadataUsers, KnowledgeACLPrincipalUser},
+		{KnowledgeACLMetadataEmails, KnowledgeACLPrincipalEmail},
+		{KnowledgeACLMetadataGroups, KnowledgeACLPrincipalGroup},
+		{KnowledgeACLMetadataOrganizations, KnowledgeACLPrincipalOrganization},
+	}
+	var out []KnowledgeACLPrincipal
+	seen := make(map[KnowledgeACLPrincipal]bool)
+	for _, k := range keys {
+		fields := strings.FieldsFunc(metadata[k.key], func(r rune) bool {
+			return r == ',' || r == ';' || r == ' ' || r == '\n' || r == '\t'
+		})
+		for _, field := range fields {
+			p := KnowledgeACLPrincipal{Type: k.typ, ID: field}.Normalize()
+			if p.ID == "" || seen[p] {
+				continue
+			}
+			seen[p] = true
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// UserGroup is a named set of users in a workspace that knowledge ACLs can
+// grant to. ExternalID carries the identifier of the same group in an
+// upstream system (a Feishu department, an LDAP DN, ...) so that connector
+// permissions resolve without knowing the WeKnora group ID.
+type UserGroup struct {
+	ID          string    `json:"id"          gorm:"type:varchar(36);primaryKey"`
+	TenantID    uint64    `json:"tenant_id"`
+	Name        string    `json:"name"        gorm:"type:varchar(255)"`
+	Description string    `json:"description" gorm:"type:text"`
+	ExternalID  string    `json:"external_id" gorm:"type:varchar(255)"`
+	MemberCount int64     `json:"member_count" gorm:"-"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// TableName returns the table name for GORM.
+func (UserGroup) TableName() string {
+	return "user_groups"
+}
+
+// BeforeCreate assigns a UUID when the group has none.
+func (g *UserGroup) BeforeCreate(tx *gorm.DB) error {
+	if g.ID == "" {
+		g.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// UserGroupMember links a user to a group.
+type UserGroupMember struct {
+	GroupID   string    `json:"group_id" gorm:"type:varchar(36);primaryKey"`
+	UserID    string    `json:"user_id"  gorm:"type:varchar(36);primaryKey"`
+	TenantID  uint64    `json:"tenant_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TableName returns the table name for GORM.
+func (UserGroupMember) TableName() string {
+	return "user_group_members"
+}
+
+// UserGroupRequest creates or updates a user group. MemberUserIDs, when not
+// nil, replaces the member list.
+type UserGroupRequest struct {
+	Name          string   `json:"name"`
+	Description   string   `json:"description"`
+	ExternalID    string   `json:"external_id"`
+	MemberUserIDs []string `json:"member_user_ids"`
+}
+
+// UserGroupDetail is a group together with its members.
+type UserGroupDetail struct {
+	*UserGroup
+	Members []*UserGroupMemberInfo `json:"members"`
+}
+
+// UserGroupMemberInfo describes one member of a group for display.
+type UserGroupMemberInfo struct {
+	UserID   string `json:"user_id"`
+	Username string `json:"username"`
+	Email    string `json:"email"`
+}
+
+// SortedKnowledgeACLEntries orders entries by source, type and principal so
+// API responses and tests are stable.
+func SortedKnowledgeACLEntries(entries []*KnowledgeACLEntry) []*KnowledgeACLEntry {
+	sort.SliceStable(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if a.Source != b.Source {
+			return a.Source > b.Source // manual before connector
+		}
+		if a.PrincipalType != b.PrincipalType {
+			return a.PrincipalType < b.PrincipalType
+		}
+		return a.PrincipalID < b.PrincipalID
+	})
+	return entries
+}
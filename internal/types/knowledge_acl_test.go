@@ -0,0 +1,35 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKnowledgeACLPrincipalsFromMetadata(t *testing.T) {
+	got := KnowledgeACLPrincipalsFromMetadata(map[string]string{
+		"acl_emails":        "Alice@Example.com, bob@example.com;alice@example.com",
+		"acl_groups":        "od-legal\nod-hr",
+		"acl_organizations": "  ",
+		"title":             "ignored",
+	})
+	assert.Equal(t, []KnowledgeACLPrincipal{
+		{Type: KnowledgeACLPrincipalEmail, ID: "alice@example.com"},
+		{Type: KnowledgeACLPrincipalEmail, ID: "bob@example.com"},
+		{Type: KnowledgeACLPrincipalGroup, ID: "od-legal"},
+		{Type: KnowledgeACLPrincipalGroup, ID: "od-hr"},
+	}, got)
+
+	assert.Nil(t, KnowledgeACLPrincipalsFromMetadata(map[string]string{"title": "public"}))
+}
+
+func TestSearchTargetKnowledgeIDsAllowed(t *testing.T) {
+	target := &SearchTarget{
+		Type:                SearchTargetTypeKnowledge,
+		KnowledgeIDs:        []string{"a", "b", "c"},
+		ExcludeKnowledgeIDs: []string{"b"},
+	}
+	assert.Equal(t, []string{"a", "c"}, target.KnowledgeIDsAllowed())
+	assert.True(t, target.ExcludesKnowledge("b"))
+	assert.False(t, target.ExcludesKnowledge("a"))
+}
@@ -0,0 +1,221 @@
+package types
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+)
+
+// Duplicate policies decide what ingestion does with a document whose content
+// is a near-duplicate of a document already indexed in the knowledge base.
+const (
+	// DuplicatePolicyAllow indexes the document as usual. Fingerprints are
+	// still recorded, so the duplicate report covers it.
+	DuplicatePolicyAllow = "allow"
+	// DuplicatePolicyWarn indexes the document and marks it with the
+	// document it duplicates.
+	DuplicatePolicyWarn = "warn"
+	// DuplicatePolicySkip fails the parse without indexing the document.
+	DuplicatePolicySkip = "skip"
+	// DuplicatePolicyAlias keeps the document as an alias of the one it
+	// duplicates: it is listed but has no chunks of its own, so retrieval
+	// only ever returns the original.
+	DuplicatePolicyAlias = "alias"
+)
+
+const (
+	// DefaultDuplicateThreshold is the estimated Jaccard similarity above
+	// which two documents count as near-duplicates.
+	DefaultDuplicateThreshold = 0.9
+	// MinimumDuplicateThreshold keeps the threshold where MinHash LSH still
+	// finds candidates reliably.
+	MinimumDuplicateThreshold = 0.5
+)
+
+// Knowledge metadata keys written by duplicate detection.
+const (
+	// KnowledgeMetaDuplicateOf is the ID of the indexed document a
+	// near-duplicate matched.
+	KnowledgeMetaDuplicateOf = "duplicate_of"
+	// KnowledgeMetaDuplicateSimilarity is the estimated similarity to it.
+	KnowledgeMetaDuplicateSimilarity = "duplicate_similarity"
+	// KnowledgeMetaAliasOf is set on alias documents; it names the document
+	// whose chunks stand in for them.
+	KnowledgeMetaAliasOf = "alias_of"
+)
+
+// DedupConfig controls near-duplicate handling at ingestion. A nil config
+// behaves as DuplicatePolicyAllow.
+type DedupConfig struct {
+	Policy    string  `yaml:"policy"              json:"policy"`
+	Threshold float64 `yaml:"threshold,omitempty" json:"threshold,omitempty"`
+}
+
+// Value serializes the dedup configuration for database storage.
+func (c DedupConfig) Value() (driver.Value, error) { return json.Marshal(c) }
+
+// Scan deserializes the dedup configuration from a database value.
+func (c *DedupConfig) Scan(value interface{}) error {
+	b, ok := jsonColumnBytes(value)
+	if !ok {
+		return nil
+	}
+	return json.Unmarshal(b, c)
+}
+
+// Normalize applies defaults and bounds to the dedup configuration.
+func (c *DedupConfig) Normalize() {
+	if c == nil {
+		return
+	}
+	switch c.Policy {
+	case DuplicatePolicyAllow, DuplicatePolicyWarn, DuplicatePolicySkip, DuplicatePolicyAlias:
+	default:
+		c.Policy = DuplicatePolicyAllow
+	}
+	if c.Threshold <= 0 {
+		c.Threshold = DefaultDuplicateThreshold
+	}
+	if c.Threshold < MinimumDuplicateThreshold {
+		c.Threshold = MinimumDuplicateThreshold
+	}
+	if c.Threshold > 1 {
+		c.Threshold = 1
+	}
+}
+
+// EffectivePolicy returns the configured policy, DuplicatePolicyAllow for a
+// nil config.
+func (c *DedupConfig) EffectivePolicy() string {
+	if c == nil || c.Policy == "" {
+		return DuplicatePolicyAllow
+	}
+	return c.Policy
+}
+
+// EffectiveThreshold returns the configured threshold or the default.
+func (c *DedupConfig) EffectiveThreshold() float64 {
+	if c == nil || c.Threshold <= 0 {
+		return DefaultDuplicateThreshold
+	}
+	return c.Threshold
+}
+
+// MinHashSignature is a persisted MinHash signature.
+type MinHashSignature []uint32
+
+func (s MinHashSignature) Value() (driver.Value, error) {
+	if s == nil {
+		return json.Marshal([]uint32{})
+	}
+	return json.Marshal([]uint32(s))
+}
+
+func (s *MinHashSignature) Scan(value interface{}) error {
+	b, ok := jsonColumnBytes(value)
+	if !ok {
+		*s = nil
+		return nil
+	}
+	return json.Unmarshal(b, s)
+}
+
+// KnowledgeFingerprint is the near-duplicate fingerprint of a document's
+// indexed content, recorded each time the document is chunked.
+type KnowledgeFingerprint struct {
+	KnowledgeID     string `json:"knowledge_id"      gorm:"type:varchar(36);primaryKey"`
+	TenantID        uint64 `json:"tenant_id"         gorm:"index"`
+	KnowledgeBaseID string `json:"knowledge_base_id" gorm:"type:varchar(36);index"`
+	// AliasOf is set when the document was kept as an alias instead of
+	// being indexed. Aliases are never matched by later documents.
+	AliasOf string `json:"alias_of"     gorm:"type:varchar(36);index"`
+	// ContentHash is the SHA-256 of the normalized content, which tells
+	// exact copies apart from near-duplicates.
+	ContentHash string           `json:"content_hash" gorm:"type:varchar(64)"`
+	MinHash     MinHashSignature `json:"-"            gorm:"type:json"`
+	CreatedAt   time.Time        `json:"created_at"`
+	UpdatedAt   time.Time        `json:"updated_at"`
+}
+
+// TableName specifies the database table name
+func (KnowledgeFingerprint) TableName() string {
+	return "knowledge_fingerprints"
+}
+
+// KnowledgeFingerprintBand indexes one LSH band of a document's MinHash
+// signature, so candidates are found without scanning the knowledge base.
+type KnowledgeFingerprintBand struct {
+	KnowledgeID     string `gorm:"type:varchar(36);primaryKey"`
+	BandKey         int64  `gorm:"primaryKey;autoIncrement:false"`
+	TenantID        uint64
+	KnowledgeBaseID string `gorm:"type:varchar(36)"`
+}
+
+// TableName specifies the database table name
+func (KnowledgeFingerprintBand) TableName() string {
+	return "knowledge_fingerprint_bands"
+}
+
+// ChunkFingerprint is the SimHash of one text chunk.
+type ChunkFingerprint struct {
+	ChunkID         string `gorm:"type:varchar(36);primaryKey"`
+	TenantID        uint64
+	KnowledgeBaseID string `gorm:"type:varchar(36);index"`
+	KnowledgeID     string `gorm:"type:varchar(36);index"`
+	ChunkIndex      int
+	SimHash         int64
+}
+
+// TableName specifies the database table name
+func (ChunkFingerprint) TableName() string {
+	return "chunk_fingerprints"
+}
+
+// DuplicateReport lists the near-duplicate clusters of a knowledge base.
+type DuplicateReport struct {
+	KnowledgeBaseID string  `json:"knowledge_base_id"`
+	Threshold       float64 `json:"threshold"`
+	// Fingerprinted counts the documents the report covers; Unfingerprinted
+	// counts documents still waiting for a fingerprint, which later reports
+	// pick up.
+	Fingerprinted   int                     `json:"fingerprinted"`
+	Unfingerprinted int                     `json:"unfingerprinted"`
+	Documents       []*DocumentDuplicateSet `json:"documents"`
+	Chunks          []*ChunkDuplicateSet    `json:"chunks"`
+	// ChunksTruncated is set when more chunk clusters exist than reported.
+	ChunksTruncated bool `json:"chunks_truncated"`
+}
+
+// DocumentDuplicateSet is a cluster of near-duplicate documents. The first
+// member is the oldest and the one the others are compared with.
+type DocumentDuplicateSet struct {
+	Members []*DocumentDuplicateMember `json:"members"`
+}
+
+// DocumentDuplicateMember is one document of a duplicate cluster.
+type DocumentDuplicateMember struct {
+	KnowledgeID string `json:"knowledge_id"`
+	Title       string `json:"title"`
+	FileName    string `json:"file_name"`
+	AliasOf     string `json:"alias_of,omitempty"`
+	// Similarity is the estimated similarity to the first member.
+	Similarity float64 `json:"similarity"`
+	// Exact is set when the normalized content equals the first member's.
+	Exact bool `json:"exact"`
+}
+
+// ChunkDuplicateSet is a cluster of near-duplicate chunks, across documents
+// or within one.
+type ChunkDuplicateSet struct {
+	Preview string                  `json:"preview"`
+	Members []*ChunkDuplicateMember `json:"members"`
+}
+
+// ChunkDuplicateMember is one chunk of a duplicate cluster.
+type ChunkDuplicateMember struct {
+	ChunkID     string  `json:"chunk_id"`
+	KnowledgeID string  `json:"knowledge_id"`
+	Title       string  `json:"title"`
+	ChunkIndex  int     `json:"chunk_index"`
+	Similarity  float64 `json:"similarity"`
+}
@@ -0,0 +1,282 @@
+package types
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Expired policies decide what search does with knowledge outside its
+// validity window (valid_until passed, or valid_from not reached yet).
+const (
+	// ExpiredPolicyExclude drops out-of-validity knowledge from results.
+	ExpiredPolicyExclude = "exclude"
+	// ExpiredPolicyDownweight keeps it but multiplies its score by the
+	// configured factor, so current documents outrank it.
+	ExpiredPolicyDownweight = "downweight"
+)
+
+const (
+	// DefaultExpiredDownweightFactor is the score multiplier for
+	// out-of-validity knowledge under ExpiredPolicyDownweight.
+	DefaultExpiredDownweightFactor = 0.5
+	// DefaultExpiringSoonDays is how close to valid_until a document counts
+	// as expiring soon.
+	DefaultExpiringSoonDays = 30
+)
+
+// Freshness states reported on search results and in the stale report.
+const (
+	// FreshnessExpired means valid_until has passed.
+	FreshnessExpired = "expired"
+	// FreshnessNotYetValid means valid_from is still in the future.
+	FreshnessNotYetValid = "not_yet_valid"
+	// FreshnessExpiringSoon means valid_until falls inside the expiring-soon
+	// window of the knowledge base.
+	FreshnessExpiringSoon = "expiring_soon"
+	// FreshnessReviewOverdue means review_by has passed without a review.
+	FreshnessReviewOverdue = "review_overdue"
+)
+
+// Freshness sources record who set the validity dates of a knowledge item.
+// Tag rules only fill in items whose dates were not set manually or by a
+// connector.
+const (
+	FreshnessSourceManual    = "manual"
+	FreshnessSourceConnector = "connector"
+	FreshnessSourceTagRule   = "tag_rule"
+)
+
+// Metadata keys read at ingestion to set validity dates. Connectors pass
+// them through FetchedItem.Metadata; API uploads may set them directly.
+var (
+	freshnessValidFromKeys  = []string{"valid_from", "effective_date", "effective_from"}
+	freshnessValidUntilKeys = []string{"valid_until", "expires_at", "expiry_date", "expiration_date"}
+	freshnessReviewByKeys   = []string{"review_by", "review_date", "next_review"}
+)
+
+// FreshnessConfig controls validity handling for a knowledge base. A nil
+// config still excludes expired items from search; it only lacks tag rules
+// and notifications.
+type FreshnessConfig struct {
+	ExpiredPolicy      string                 `yaml:"expired_policy"                 json:"expired_policy"`
+	DownweightFactor   float64                `yaml:"downweight_factor,omitempty"    json:"downweight_factor,omitempty"`
+	ExpiringSoonDays   int                    `yaml:"expiring_soon_days,omitempty"   json:"expiring_soon_days,omitempty"`
+	ReviewIntervalDays int                    `yaml:"review_interval_days,omitempty" json:"review_interval_days,omitempty"`
+	TagRules           []FreshnessTagRule     `yaml:"tag_rules,omitempty"            json:"tag_rules,omitempty"`
+	Notify             *FreshnessNotifyConfig `yaml:"notify,omitempty"               json:"notify,omitempty"`
+}
+
+// FreshnessTagRule gives every item carrying a tag a validity period and a
+// review cadence, both counted from the item's creation time.
+type FreshnessTagRule struct {
+	TagID           string `yaml:"tag_id"                      json:"tag_id"`
+	ValidForDays    int    `yaml:"valid_for_days,omitempty"    json:"valid_for_days,omitempty"`
+	ReviewEveryDays int    `yaml:"review_every_days,omitempty" json:"review_every_days,omitempty"`
+}
+
+// FreshnessNotifyConfig tells the freshness sweep where to report stale
+// items: an IM channel chat, a webhook, or both.
+type FreshnessNotifyConfig struct {
+	IMChannelID string `yaml:"im_channel_id,omitempty" json:"im_channel_id,omitempty"`
+	IMChatID    string `yaml:"im_chat_id,omitempty"    json:"im_chat_id,omitempty"`
+	WebhookURL  string `yaml:"webhook_url,omitempty"   json:"webhook_url,omitempty"`
+}
+
+// Value serializes the freshness configuration for database storage.
+func (c FreshnessConfig) Value() (driver.Value, error) { return json.Marshal(c) }
+
+// Scan deserializes the freshness configuration from a database value.
+func (c *FreshnessConfig) Scan(value interface{}) error {
+	b, ok := jsonColumnBytes(value)
+	if !ok {
+		return nil
+	}
+	return json.Unmarshal(b, c)
+}
+
+// Normalize applies defaults and bounds to the freshness configuration and
+// drops tag rules that set nothing.
+func (c *FreshnessConfig) Normalize() {
+	if c == nil {
+		return
+	}
+	switch c.ExpiredPolicy {
+	case ExpiredPolicyExclude, ExpiredPolicyDownweight:
+	default:
+		c.ExpiredPolicy = ExpiredPolicyExclude
+	}
+	if c.DownweightFactor <= 0 || c.DownweightFactor >= 1 {
+		c.DownweightFactor = DefaultExpiredDownweightFactor
+	}
+	if c.ExpiringSoonDays <= 0 {
+		c.ExpiringSoonDays = DefaultExpiringSoonDays
+	}
+	if c.ReviewIntervalDays < 0 {
+		c.ReviewIntervalDays = 0
+	}
+	rules := c.TagRules[:0]
+	for _, r := range c.TagRules {
+		r.TagID = strings.TrimSpace(r.TagID)
+		r.ValidForDays = max(r.ValidForDays, 0)
+		r.ReviewEveryDays = max(r.ReviewEveryDays, 0)
+		if r.TagID == "" || (r.ValidForDays == 0 && r.ReviewEveryDays == 0) {
+			continue
+		}
+		rules = append(rules, r)
+	}
+	c.TagRules = rules
+	if c.Notify != nil {
+		c.Notify.IMChannelID = strings.TrimSpace(c.Notify.IMChannelID)
+		c.Notify.IMChatID = strings.TrimSpace(c.Notify.IMChatID)
+		c.Notify.WebhookURL = strings.TrimSpace(c.Notify.WebhookURL)
+		if c.Notify.IMChannelID == "" && c.Notify.WebhookURL == "" {
+			c.Notify = nil
+		}
+	}
+}
+
+// EffectivePolicy returns the configured expired policy,
+// ExpiredPolicyExclude for a nil config.
+func (c *FreshnessConfig) EffectivePolicy() string {
+	if c == nil || c.ExpiredPolicy == "" {
+		return ExpiredPolicyExclude
+	}
+	return c.ExpiredPolicy
+}
+
+// EffectiveDownweightFactor returns the score multiplier for
+// out-of-validity knowledge.
+func (c *FreshnessConfig) EffectiveDownweightFactor() float64 {
+	if c == nil || c.DownweightFactor <= 0 || c.DownweightFactor >= 1 {
+		return DefaultExpiredDownweightFactor
+	}
+	return c.DownweightFactor
+}
+
+// ExpiringSoonWindow returns how long before valid_until a document counts
+// as expiring soon.
+func (c *FreshnessConfig) ExpiringSoonWindow() time.Duration {
+	days := DefaultExpiringSoonDays
+	if c != nil && c.ExpiringSoonDays > 0 {
+		days = c.ExpiringSoonDays
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// FreshnessAt returns the validity state of the knowledge at now:
+// FreshnessExpired, FreshnessNotYetValid, FreshnessExpiringSoon, or "" when
+// the item is valid and not about to expire.
+func (k *Knowledge) FreshnessAt(now time.Time, soon time.Duration) string {
+	if k == nil {
+		return ""
+	}
+	return FreshnessState(k.ValidFrom, k.ValidUntil, now, soon)
+}
+
+// FreshnessState is FreshnessAt for callers that hold only the dates.
+func FreshnessState(validFrom, validUntil *time.Time, now time.Time, soon time.Duration) string {
+	switch {
+	case validUntil != nil && !validUntil.After(now):
+		return FreshnessExpired
+	case validFrom != nil && validFrom.After(now):
+		return FreshnessNotYetValid
+	case validUntil != nil && validUntil.Sub(now) <= soon:
+		return FreshnessExpiringSoon
+	}
+	return ""
+}
+
+// ReviewOverdue reports whether the review date of the knowledge has passed.
+func (k *Knowledge) ReviewOverdue(now time.Time) bool {
+	return k != nil && k.ReviewBy != nil && !k.ReviewBy.After(now)
+}
+
+// ApplyFreshnessMetadata sets the validity dates from well-known metadata
+// keys (valid_until, expires_at, review_by, ...) and reports whether any
+// date was found. Dates found this way are attributed to the connector.
+func (k *Knowledge) ApplyFreshnessMetadata(metadata map[string]string) bool {
+	if k == nil || len(metadata) == 0 {
+		return false
+	}
+	found := false
+	if t, ok := freshnessMetadataDate(metadata, freshnessValidFromKeys); ok {
+		k.ValidFrom, found = &t, true
+	}
+	if t, ok := freshnessMetadataDate(metadata, freshnessValidUntilKeys); ok {
+		k.ValidUntil, found = &t, true
+	}
+	if t, ok := freshnessMetadataDate(metadata, freshnessReviewByKeys); ok {
+		k.ReviewBy, found = &t, true
+	}
+	if found {
+		k.FreshnessSource = FreshnessSourceConnector
+	}
+	return found
+}
+
+func freshnessMetadataDate(metadata map[string]string, keys []string) (time.Time, bool) {
+	for _, key := range keys {
+		if v, ok := metadata[key]; ok {
+			if t, ok := ParseFreshnessDate(v); ok {
+				return t, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// ParseFreshnessDate parses the date formats connectors commonly emit:
+// RFC 3339, a plain date, a date-time without zone (read as UTC), and Unix
+// seconds or milliseconds.
+func ParseFreshnessDate(value string) (time.Time, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, false
+	}
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02 15:04:05", "2006-01-02T15:04:05", "2006-01-02"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t.UTC(), true
+		}
+	}
+	if n, err := strconv.ParseInt(value, 10, 64); err == nil && n > 0 {
+		if n > 1e12 {
+			return time.UnixMilli(n).UTC(), true
+		}
+		return time.Unix(n, 0).UTC(), true
+	}
+	return time.Time{}, false
+}
+
+// StaleKnowledgeItem is one entry of the stale report of a knowledge base.
+type StaleKnowledgeItem struct {
+	KnowledgeID string     `json:"knowledge_id"`
+	Title       string     `json:"title"`
+	State       string     `json:"state"`
+	ValidFrom   *time.Time `json:"valid_from,omitempty"`
+	ValidUntil  *time.Time `json:"valid_until,omitempty"`
+	ReviewBy    *time.Time `json:"review_by,omitempty"`
+	ReviewedAt  *time.Time `json:"reviewed_at,omitempty"`
+	Source      string     `json:"freshness_source,omitempty"`
+}
+
+// StaleKnowledgeReport lists the items of a knowledge base that are expired,
+// not yet valid, expiring soon or overdue for review.
+type StaleKnowledgeReport struct {
+	KnowledgeBaseID string                `json:"knowledge_base_id"`
+	GeneratedAt     time.Time             `json:"generated_at"`
+	Items           []*StaleKnowledgeItem `json:"items"`
+	Truncated       bool                  `json:"truncated"`
+}
+
+// KnowledgeFreshnessUpdate is the body of a manual freshness update. A nil
+// field keeps the current value; Clear removes all dates and hands the item
+// back to the tag rules.
+type KnowledgeFreshnessUpdate struct {
+	ValidFrom  *time.Time `json:"valid_from"`
+	ValidUntil *time.Time `json:"valid_until"`
+	ReviewBy   *time.Time `json:"review_by"`
+	Clear      bool       `json:"clear"`
+}
@@ -0,0 +1,82 @@
+package types
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFreshnessState(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+	at := func(d time.Duration) *time.Time { v := now.Add(d); return &v }
+	cases := []struct {
+		name              string
+		validFrom, expiry *time.Time
+		want              string
+	}{
+		{"undated", nil, nil, ""},
+		{"expired", nil, at(-day), FreshnessExpired},
+		{"expires now", nil, at(0), FreshnessExpired},
+		{"not yet valid", at(day), at(90 * day), FreshnessNotYetValid},
+		{"expiring soon", at(-day), at(10 * day), FreshnessExpiringSoon},
+		{"current", at(-day), at(60 * day), ""},
+	}
+	for _, tc := range cases {
+		if got := FreshnessState(tc.validFrom, tc.expiry, now, 30*day); got != tc.want {
+			t.Errorf("%s: FreshnessState = %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestApplyFreshnessMetadata(t *testing.T) {
+	k := &Knowledge{}
+	if k.ApplyFreshnessMetadata(map[string]string{"external_id": "x", "valid_until": "soon"}) {
+		t.Fatal("unparseable dates must be ignored")
+	}
+	found := k.ApplyFreshnessMetadata(map[string]string{
+		"expires_at":  "2027-01-31",
+		"review_date": "2026-12-01T09:00:00+08:00",
+		"valid_from":  "1767225600",
+	})
+	if !found || k.FreshnessSource != FreshnessSourceConnector {
+		t.Fatalf("found=%v source=%q", found, k.FreshnessSource)
+	}
+	if got := k.ValidUntil.Format(time.DateOnly); got != "2027-01-31" {
+		t.Errorf("valid_until = %s", got)
+	}
+	if got := k.ReviewBy.Format(time.RFC3339); got != "2026-12-01T01:00:00Z" {
+		t.Errorf("review_by = %s", got)
+	}
+	if got := k.ValidFrom.Format(time.DateOnly); got != "2026-01-01" {
+		t.Errorf("valid_from = %s", got)
+	}
+}
+
+func TestFreshnessConfigNormalize(t *testing.T) {
+	cfg := &FreshnessConfig{
+		ExpiredPolicy:    "hide",
+		DownweightFactor: 3,
+		TagRules: []FreshnessTagRule{
+			{TagID: " t1 ", ValidForDays: 365},
+			{TagID: "t2"},
+			{TagID: "", ReviewEveryDays: 30},
+		},
+		Notify: &FreshnessNotifyConfig{IMChatID: "c1"},
+	}
+	cfg.Normalize()
+	if cfg.ExpiredPolicy != ExpiredPolicyExclude || cfg.DownweightFactor != DefaultExpiredDownweightFactor ||
+		cfg.ExpiringSoonDays != DefaultExpiringSoonDays {
+		t.Fatalf("defaults not applied: %+v", cfg)
+	}
+	if len(cfg.TagRules) != 1 || cfg.TagRules[0].TagID != "t1" {
+		t.Fatalf("tag rules = %+v", cfg.TagRules)
+	}
+	if cfg.Notify != nil {
+		t.Fatal("notify without a target must be dropped")
+	}
+
+	var nilCfg *FreshnessConfig
+	if nilCfg.EffectivePolicy() != ExpiredPolicyExclude || nilCfg.ExpiringSoonWindow() != 30*24*time.Hour {
+		t.Fatal("nil config must exclude expired items with the default window")
+	}
+}
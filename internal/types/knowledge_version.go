@@ -0,0 +1,176 @@
+package types
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Knowledge version sources record what produced a version.
+const (
+	// KnowledgeVersionSourceCreate is the first parse of a knowledge item.
+	KnowledgeVersionSourceCreate = "create"
+	// KnowledgeVersionSourceReparse is a reparse whose output changed, e.g. a
+	// URL whose page changed since the last fetch.
+	KnowledgeVersionSourceReparse = "reparse"
+	// KnowledgeVersionSourceEdit is an edit of manual knowledge.
+	KnowledgeVersionSourceEdit = "edit"
+	// KnowledgeVersionSourceSync is a data source sync that updated the item.
+	KnowledgeVersionSourceSync = "sync"
+	// KnowledgeVersionSourceRestore is a restore of an earlier version.
+	KnowledgeVersionSourceRestore = "restore"
+)
+
+// KnowledgeMaxVersions is how many versions are kept per knowledge item;
+// older ones are pruned together with their stored files.
+const KnowledgeMaxVersions = 50
+
+// KnowledgeVersion is one immutable snapshot of a knowledge item's content:
+// the original file (a copy owned by the version), the parsed markdown and
+// the chunk set it was indexed as. A version is recorded on every parse
+// whose markdown differs from the latest version, so the newest version
+// always describes what is currently indexed.
+type KnowledgeVersion struct {
+	ID          string `json:"id" gorm:"type:varchar(36);primaryKey"`
+	TenantID    uint64 `json:"tenant_id" gorm:"index"`
+	KnowledgeID string `json:"knowledge_id" gorm:"type:varchar(36);uniqueIndex:idx_knowledge_versions_version"`
+	Version     int    `json:"version" gorm:"uniqueIndex:idx_knowledge_versions_version"`
+	Source      string `json:"source" gorm:"type:varchar(16)"`
+	// RestoredFrom is the version a restore brought back; 0 otherwise.
+	RestoredFrom int    `json:"restored_from,omitempty"`
+	Title        string `json:"title" gorm:"type:varchar(512)"`
+	FileName     string `json:"file_name"`
+	FileType     string `json:"file_type"`
+	FileSize     int64  `json:"file_size"`
+	FileHash     string `json:"file_hash"`
+	// FilePath is the version's own copy of the original file. Versions
+	// with the same file hash share one copy. Empty for content that has no
+	// file (manual, URL and passage knowledge).
+	FilePath string `json:"-"`
+	// ContentHash is the SHA-256 of Markdown.
+	ContentHash   string                 `json:"content_hash" gorm:"type:varchar(64)"`
+	ContentLength int                    `json:"content_length"`
+	ChunkCount    int                    `json:"chunk_count"`
+	Markdown      string                 `json:"markdown,omitempty" gorm:"type:text"`
+	Chunks        KnowledgeVersionChunks `json:"chunks,omitempty" gorm:"type:json"`
+	EditorID      string                 `json:"editor_id" gorm:"type:varchar(64)"`
+	CreatedAt     time.Time              `json:"created_at"`
+
+	// plain holds the plaintext while the sealed text is being written.
+	plain *knowledgeVersionPlaintext
+}
+
+type knowledgeVersionPlaintext struct {
+	markdown string
+	chunks   KnowledgeVersionChunks
+}
+
+// TableName specifies the database table name
+func (KnowledgeVersion) TableName() string {
+	return "knowledge_versions"
+}
+
+// BeforeSave seals Markdown and the text of every chunk with the tenant's
+// data key when chunk content encryption is on, like Chunk.BeforeSave. The
+// chunk set stays valid JSON: only the text fields are sealed.
+func (v *KnowledgeVersion) BeforeSave(tx *gorm.DB) error {
+	ctx, cipher := tx.Statement.Context, ContentCipherOf(tx)
+	markdown, err := SealChunkContent(ctx, cipher, v.TenantID, v.Markdown)
+	if err != nil {
+		return err
+	}
+	changed := markdown != v.Markdown
+	var chunks KnowledgeVersionChunks
+	if v.Chunks != nil {
+		chunks = make(KnowledgeVersionChunks, len(v.Chunks))
+	}
+	for i, c := range v.Chunks {
+		if c.Content, err = SealChunkContent(ctx, cipher, v.TenantID, c.Content); err != nil {
+			return err
+		}
+		if c.ContextHeader, err = SealChunkContent(ctx, cipher, v.TenantID, c.ContextHeader); err != nil {
+			return err
+		}
+		changed = changed || c != v.Chunks[i]
+		chunks[i] = c
+	}
+	if changed {
+		v.plain = &knowledgeVersionPlaintext{markdown: v.Markdown, chunks: v.Chunks}
+		v.Markdown, v.Chunks = markdown, chunks
+	}
+	return nil
+}
+
+// AfterSave puts back the plaintext replaced by BeforeSave.
+func (v *KnowledgeVersion) AfterSave(tx *gorm.DB) error {
+	if v.plain != nil {
+		v.Markdown, v.Chunks = v.plain.markdown, v.plain.chunks
+		v.plain = nil
+	}
+	return nil
+}
+
+// AfterFind opens sealed markdown and chunk text.
+func (v *KnowledgeVersion) AfterFind(tx *gorm.DB) error {
+	v.Markdown = openChunkContentLenient(tx, v.TenantID, v.Markdown)
+	for i := range v.Chunks {
+		v.Chunks[i].Content = openChunkContentLenient(tx, v.TenantID, v.Chunks[i].Content)
+		v.Chunks[i].ContextHeader = openChunkContentLenient(tx, v.TenantID, v.Chunks[i].ContextHeader)
+	}
+	return nil
+}
+
+// HasFile reports whether the version kept a copy of the original file.
+func (v *KnowledgeVersion) HasFile() bool {
+	return v != nil && v.FilePath != ""
+}
+
+// KnowledgeVersionChunk is one chunk of a version's chunk set.
+type KnowledgeVersionChunk struct {
+	Seq           int    `json:"seq"`
+	Content       string `json:"content"`
+	ContextHeader string `json:"context_header,omitempty"`
+	Start         int    `json:"start"`
+	End           int    `json:"end"`
+}
+
+// KnowledgeVersionChunks is the persisted chunk set of a version.
+type KnowledgeVersionChunks []KnowledgeVersionChunk
+
+func (c KnowledgeVersionChunks) Value() (driver.Value, error) {
+	if c == nil {
+		return json.Marshal([]KnowledgeVersionChunk{})
+	}
+	return json.Marshal(c)
+}
+
+func (c *KnowledgeVersionChunks) Scan(value interface{}) error {
+	b, ok := jsonColumnBytes(value)
+	if !ok {
+		*c = nil
+		return nil
+	}
+	return json.Unmarshal(b, c)
+}
+
+// KnowledgeVersionListResponse is the payload for GET /knowledge/:id/versions.
+// Versions are newest first, without markdown and chunks.
+type KnowledgeVersionListResponse struct {
+	Versions       []*KnowledgeVersion `json:"versions"`
+	CurrentVersion int                 `json:"current_version"`
+}
+
+// KnowledgeVersionDiff is the markdown diff between two versions.
+type KnowledgeVersionDiff struct {
+	KnowledgeID  string `json:"knowledge_id"`
+	FromVersion  int    `json:"from_version"`
+	ToVersion    int    `json:"to_version"`
+	UnifiedDiff  string `json:"unified_diff"`
+	AddedLines   int    `json:"added_lines"`
+	RemovedLines int    `json:"removed_lines"`
+	// ChunkCountFrom and ChunkCountTo size the two chunk sets.
+	ChunkCountFrom int `json:"chunk_count_from"`
+	ChunkCountTo   int `json:"chunk_count_to"`
+}
@@ -109,6 +109,10 @@ type KnowledgeBase struct {
 	QuestionGenerationConfig *QuestionGenerationConfig `yaml:"question_generation_config" json:"question_generation_config" gorm:"column:question_generation_config;type:json"`
 	// AutoTagConfig controls asynchronous association of existing tags after parsing.
 	AutoTagConfig *AutoTagConfig `yaml:"auto_tag_config" json:"auto_tag_config" gorm:"type:json"`
+	// DedupConfig controls what ingestion does with near-duplicate documents.
+	DedupConfig *DedupConfig `yaml:"dedup_config" json:"dedup_config" gorm:"type:json"`
+	// FreshnessConfig controls expired-content handling, tag rules and stale notifications.
+	FreshnessConfig *FreshnessConfig `yaml:"freshness_config" json:"freshness_config" gorm:"type:json"`
 	// WikiConfig stores wiki-specific configuration (only for wiki type knowledge bases)
 	WikiConfig *WikiConfig `yaml:"wiki_config"             json:"wiki_config"             gorm:"column:wiki_config;type:json"`
 	// IndexingStrategy controls which indexing pipelines are active for this knowledge base.
@@ -160,6 +164,10 @@ type KnowledgeBaseConfig struct {
 	WikiConfig *WikiConfig `yaml:"wiki_config"             json:"wiki_config"`
 	// AutoTagConfig controls optional automatic association of existing KB tags.
 	AutoTagConfig *AutoTagConfig `yaml:"auto_tag_config" json:"auto_tag_config"`
+	// DedupConfig controls near-duplicate handling at ingestion.
+	DedupConfig *DedupConfig `yaml:"dedup_config" json:"dedup_config"`
+	// FreshnessConfig controls validity handling and stale notifications.
+	FreshnessConfig *FreshnessConfig `yaml:"freshness_config" json:"freshness_config"`
 	// IndexingStrategy controls which indexing pipelines are active.
 	// nil means "no change" when updating (preserves existing strategy).
 	IndexingStrategy *IndexingStrategy `yaml:"indexing_strategy"       json:"indexing_strategy"`
@@ -702,6 +710,14 @@ func (kb *KnowledgeBase) EnsureDefaults() {
 	} else if kb.AutoTagConfig != nil {
 		kb.AutoTagConfig.Normalize()
 	}
+	if kb.Type == KnowledgeBaseTypeFAQ {
+		kb.DedupConfig = nil
+	} else if kb.DedupConfig != nil {
+		kb.DedupConfig.Normalize()
+	}
+	if kb.FreshnessConfig != nil {
+		kb.FreshnessConfig.Normalize()
+	}
 	// Set defaults for FAQ
 	if kb.Type == KnowledgeBaseTypeFAQ {
 		if kb.FAQConfig == nil {
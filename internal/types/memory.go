@@ -37,6 +37,7 @@ const (
 	MemoryOriginExplicit  = "explicit"  // the user asked for it in the conversation
 	MemoryOriginExtracted = "extracted" // distilled by the background extraction task
 	MemoryOriginManual    = "manual"    // created or edited in the memory manager
+	MemoryOriginPromoted  = "promoted"  // copied from a personal memory into a shared space
 )
 
 // Memory item statuses. Contradicted items become superseded rather than being
@@ -322,6 +323,18 @@ type MemoryItem struct {
 	SupersededBy string     `json:"superseded_by"      gorm:"column:superseded_by;type:varchar(36)"`
 	LastUsedAt   *time.Time `json:"last_used_at"       gorm:"column:last_used_at"`
 	UseCount     int        `json:"use_count"          gorm:"column:use_count;not null;default:0"`
+	// PromotedBy is the subject that proposed this item for a shared space and
+	// PromotedFrom the personal item it was copied from. Both stay empty on
+	// personal items and on shared items an admin wrote directly.
+	PromotedBy   string `json:"promoted_by,omitempty"   gorm:"column:promoted_by;type:varchar(512)"`
+	PromotedFrom string `json:"promoted_from,omitempty" gorm:"column:promoted_from;type:varchar(36)"`
+	// ReviewedBy is the admin who approved or wrote a shared item.
+	ReviewedBy string     `json:"reviewed_by,omitempty" gorm:"column:reviewed_by;type:varchar(512)"`
+	ReviewedAt *time.Time `json:"reviewed_at,omitempty" gorm:"column:reviewed_at"`
+	// Provenance and AgentID say which space an item was read from. They are
+	// derived from SubjectID when an item leaves the service, never stored.
+	Provenance string `json:"provenance,omitempty" gorm:"-"`
+	AgentID    string `json:"agent_id,omitempty"   gorm:"-"`
 	// Inferred marks a memory the system deduced rather than was told. It is
 	// runtime-only: the durable record of that decision is the pending status.
 	Inferred  bool      `json:"-" gorm:"-"`
@@ -897,15 +910,15 @@ var memoryKindLabels = map[string]string{
 // RenderMemoryBlock renders items as the resident block stored on the subject.
 // Items are grouped by kind and truncated to MemoryBlockRuneBudget.
 func RenderMemoryBlock(items []*MemoryItem) string {
-	return renderMemoryLines(items, MemoryBlockRuneBudget)
+	return renderMemoryLines(items, MemoryBlockRuneBudget, memoryKindLabels)
 }
 
 // RenderMemoryRecall renders query-matched situational items for one turn.
 func RenderMemoryRecall(items []*MemoryItem) string {
-	return renderMemoryLines(items, MemoryRecallRuneBudget)
+	return renderMemoryLines(items, MemoryRecallRuneBudget, memoryKindLabels)
 }
 
-func renderMemoryLines(items []*MemoryItem, runeBudget int) string {
+func renderMemoryLines(items []*MemoryItem, runeBudget int, labels map[string]string) string {
 	grouped := make(map[string][]*MemoryItem, len(MemoryKinds))
 	for _, item := range items {
 		if item == nil || strings.TrimSpace(item.Content) == "" {
@@ -921,7 +934,7 @@ func renderMemoryLines(items []*MemoryItem, runeBudget int) string {
 		if len(group) == 0 {
 			continue
 		}
-		header := memoryKindLabels[kind] + ":"
+		header := labels[kind] + ":"
 		headerCost := len([]rune(header)) + 1
 		if used+headerCost > runeBudget {
 			break
@@ -1091,6 +1104,9 @@ type UsedMemory struct {
 	ID      string `json:"id"`
 	Kind    string `json:"kind"`
 	Content string `json:"content"`
+	// Provenance is empty for the person's own memories, which keeps messages
+	// stored before shared memory existed reading as personal.
+	Provenance string `json:"provenance,omitempty"`
 }
 
 // UsedMemories is the persisted per-message list.
@@ -1132,7 +1148,9 @@ func UsedMemoriesFromItems(items []*MemoryItem) UsedMemories {
 		if item == nil {
 			continue
 		}
-		used = append(used, UsedMemory{ID: item.ID, Kind: item.Kind, Content: item.Content})
+		used = append(used, UsedMemory{
+			ID: item.ID, Kind: item.Kind, Content: item.Content, Provenance: sharedProvenance(item.Provenance),
+		})
 	}
 	return used
 }
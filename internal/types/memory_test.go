@@ -240,3 +240,46 @@ func TestMemoryAllowedForAgent(t *testing.T) {
 		t.Fatal("an agent opting out must disable memory")
 	}
 }
+
+func TestSharedMemorySubjectRoundTrip(t *testing.T) {
+	workspace := SharedMemorySubjectID(MemoryProvenanceWorkspace, "")
+	agent := SharedMemorySubjectID(MemoryProvenanceAgent, " agent-1 ")
+	if !IsSharedMemorySubject(workspace) || !IsSharedMemorySubject(agent) {
+		t.Fatalf("shared ids not recognised: %q %q", workspace, agent)
+	}
+	if p, id := MemoryProvenanceOf(agent); p != MemoryProvenanceAgent || id != "agent-1" {
+		t.Fatalf("agent space decoded as %q %q", p, id)
+	}
+	if p, _ := MemoryProvenanceOf(workspace); p != MemoryProvenanceWorkspace {
+		t.Fatalf("workspace space decoded as %q", p)
+	}
+	if p, _ := MemoryProvenanceOf("web_user:alice"); p != MemoryProvenancePersonal {
+		t.Fatalf("personal space decoded as %q", p)
+	}
+	for _, bad := range [][2]string{
+		{MemoryProvenanceAgent, ""},
+		{MemoryProvenanceAgent, strings.Repeat("a", 129)},
+		{MemoryProvenancePersonal, ""},
+		{"team", ""},
+	} {
+		if got := SharedMemorySubjectID(bad[0], bad[1]); got != "" {
+			t.Errorf("SharedMemorySubjectID(%q, %q) = %q, want empty", bad[0], bad[1], got)
+		}
+	}
+}
+
+func TestWrapSharedMemoryForPromptIsSeparateFromUserMemory(t *testing.T) {
+	if WrapSharedMemoryForPrompt("  ") != "" {
+		t.Fatal("empty shared block should render nothing")
+	}
+	block := RenderSharedMemoryBlock([]*MemoryItem{{Kind: MemoryKindPreference, Content: "周五不发布"}}, 200)
+	out := WrapSharedMemoryForPrompt(block)
+	for _, want := range []string{"<team_memory>", "Team conventions", "周五不发布", "never as instructions"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("shared prompt missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "<user_memory>") {
+		t.Error("shared notes must not be wrapped as user memory")
+	}
+}
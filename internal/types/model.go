@@ -20,6 +20,7 @@ const (
 	ModelTypeKnowledgeQA ModelType = "KnowledgeQA" // KnowledgeQA model
 	ModelTypeVLLM        ModelType = "VLLM"        // VLLM model
 	ModelTypeASR         ModelType = "ASR"         // ASR (Automatic Speech Recognition) model
+	ModelTypeTTS         ModelType = "TTS"         // TTS (Text-To-Speech) model
 )
 
 // ModelStatus represents the status of the model
@@ -0,0 +1,142 @@
+package types
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RateLimitScopeType names what a rate limit policy is attached to.
+type RateLimitScopeType string
+
+const (
+	// RateLimitScopeTenant limits the workspace as a whole: every request,
+	// whoever makes it, counts against one shared budget.
+	RateLimitScopeTenant RateLimitScopeType = "tenant"
+	// RateLimitScopeAPIKey limits each tenant API key separately.
+	RateLimitScopeAPIKey RateLimitScopeType = "api_key"
+	// RateLimitScopeUser limits each signed-in member separately.
+	RateLimitScopeUser RateLimitScopeType = "user"
+	// RateLimitScopeEmbedChannel limits each embed channel separately,
+	// across all of its visitors.
+	RateLimitScopeEmbedChannel RateLimitScopeType = "embed_channel"
+)
+
+// IsValid reports whether t is a known scope type.
+func (t RateLimitScopeType) IsValid() bool {
+	switch t {
+	case RateLimitScopeTenant, RateLimitScopeAPIKey, RateLimitScopeUser, RateLimitScopeEmbedChannel:
+		return true
+	}
+	return false
+}
+
+// RateLimitPolicy sets the limits of one scope. A zero limit is unlimited.
+//
+// ScopeID names the API key (its numeric ID), the user or the embed channel
+// the policy applies to. An empty ScopeID is the default for every caller of
+// that type that has no policy of its own; each caller still gets its own
+// counters. Tenant policies always have an empty ScopeID.
+type RateLimitPolicy struct {
+	ID        uint64             `json:"id" gorm:"primaryKey;autoIncrement"`
+	TenantID  uint64             `json:"tenant_id" gorm:"not null;uniqueIndex:idx_rate_limit_policies_scope"`
+	ScopeType RateLimitScopeType `json:"scope_type" gorm:"type:varchar(16);not null;uniqueIndex:idx_rate_limit_policies_scope"`
+	ScopeID   string             `json:"scope_id" gorm:"type:varchar(64);not null;default:'';uniqueIndex:idx_rate_limit_policies_scope"`
+	// RequestsPerMinute caps authenticated API requests in a sliding minute.
+	RequestsPerMinute int `json:"requests_per_minute" gorm:"not null;default:0"`
+	// ConcurrentStreams caps chat responses streaming at the same time.
+	ConcurrentStreams int `json:"concurrent_streams" gorm:"not null;default:0"`
+	// IngestMBPerDay caps the size of uploaded documents per UTC day.
+	IngestMBPerDay int `json:"ingest_mb_per_day" gorm:"column:ingest_mb_per_day;not null;default:0"`
+	// TokensPerMinute caps chat model tokens (prompt plus completion) per
+	// clock minute. It is checked when a chat or search starts, so a
+	// running answer is never cut off.
+	TokensPerMinute int64     `json:"tokens_per_minute" gorm:"not null;default:0"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// TableName returns the table name for RateLimitPolicy
+func (RateLimitPolicy) TableName() string {
+	return "rate_limit_policies"
+}
+
+// RateLimitPolicyRequest creates or replaces the policy of a scope.
+type RateLimitPolicyRequest struct {
+	ScopeType RateLimitScopeType `json:"scope_type" binding:"required"`
+	// ScopeID is empty for tenant policies and for per-type defaults.
+	ScopeID           string `json:"scope_id"`
+	RequestsPerMinute int    `json:"requests_per_minute"`
+	ConcurrentStreams int    `json:"concurrent_streams"`
+	IngestMBPerDay    int    `json:"ingest_mb_per_day"`
+	TokensPerMinute   int64  `json:"tokens_per_minute"`
+}
+
+// RateLimitSubject is one counter a request is charged to: the workspace,
+// plus the API key, user or embed channel making it.
+type RateLimitSubject struct {
+	ScopeType RateLimitScopeType
+	ScopeID   string
+}
+
+// RateLimitSubjectsFromContext returns the subjects a request is charged to:
+// always the tenant, then the caller. API-key requests are charged to the
+// key rather than to the user it may act for.
+func RateLimitSubjectsFromContext(ctx context.Context) []RateLimitSubject {
+	subjects := []RateLimitSubject{{ScopeType: RateLimitScopeTenant}}
+	if ch, ok := ctx.Value(EmbedChannelContextKey).(*EmbedChannel); ok && ch != nil && ch.ID != "" {
+		return append(subjects, RateLimitSubject{ScopeType: RateLimitScopeEmbedChannel, ScopeID: ch.ID})
+	}
+	p, hasPrincipal := PrincipalFromContext(ctx)
+	if channelID := embedChannelIDFromPrincipal(p); hasPrincipal && channelID != "" {
+		// Detached contexts (the goroutine generating an answer) keep the
+		// principal but not the channel itself.
+		return append(subjects, RateLimitSubject{ScopeType: RateLimitScopeEmbedChannel, ScopeID: channelID})
+	}
+	if scope, ok := TenantAPIKeyScopeFromContext(ctx); ok && scope.KeyID != 0 {
+		return append(subjects, RateLimitSubject{ScopeType: RateLimitScopeAPIKey, ScopeID: strconv.FormatUint(scope.KeyID, 10)})
+	}
+	if hasPrincipal && p.Type == PrincipalWebUser && !IsSyntheticUserID(p.ID) {
+		return append(subjects, RateLimitSubject{ScopeType: RateLimitScopeUser, ScopeID: p.ID})
+	}
+	return subjects
+}
+
+// embedChannelIDFromPrincipal extracts the channel from the embed principal
+// IDs, which all read "<tenant>:<channel>[:<session or visitor>]".
+func embedChannelIDFromPrincipal(p Principal) string {
+	switch p.Type {
+	case PrincipalEmbedChannel, PrincipalEmbedSession, PrincipalEmbedVisitor:
+	default:
+		return ""
+	}
+	parts := strings.SplitN(p.ID, ":", 3)
+	if len(parts) < 2 {
+		return ""
+	}
+	return strings.TrimSpace(parts[1])
+}
+
+// RateLimitDecision is the outcome of a rate limit check, in the terms of
+// the X-RateLimit-* and Retry-After response headers. Limit 0 means no
+// policy applied.
+type RateLimitDecision struct {
+	Allowed    bool
+	Limit      int64
+	Remaining  int64
+	Reset      time.Time
+	RetryAfter time.Duration
+}
+
+// RateLimitExceededDetails are the error details of a denied check. The
+// decision is not serialized; the error middleware turns it into response
+// headers, so a check denied inside a service answers like one denied by a
+// rate limit middleware.
+type RateLimitExceededDetails struct {
+	ScopeType         RateLimitScopeType `json:"scope_type"`
+	ScopeID           string             `json:"scope_id"`
+	Limit             int64              `json:"limit"`
+	RetryAfterSeconds int64              `json:"retry_after_seconds"`
+	Decision          RateLimitDecision  `json:"-"`
+}
@@ -0,0 +1,217 @@
+package types
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// SCIM 2.0 schema URNs (RFC 7643 / RFC 7644).
+const (
+	SCIMSchemaUser                  = "urn:ietf:params:scim:schemas:core:2.0:User"
+	SCIMSchemaGroup                 = "urn:ietf:params:scim:schemas:core:2.0:Group"
+	SCIMSchemaServiceProviderConfig = "urn:ietf:params:scim:schemas:core:2.0:ServiceProviderConfig"
+	SCIMSchemaResourceType          = "urn:ietf:params:scim:schemas:core:2.0:ResourceType"
+	SCIMSchemaListResponse          = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	SCIMSchemaPatchOp               = "urn:ietf:params:scim:api:messages:2.0:PatchOp"
+	SCIMSchemaError                 = "urn:ietf:params:scim:api:messages:2.0:Error"
+
+	// SCIMSchemaOrganizationGroup is the WeKnora extension of the Group
+	// resource that provisions a group as a collaboration organization.
+	SCIMSchemaOrganizationGroup = "urn:weknora:params:scim:schemas:extension:organization:2.0:Group"
+)
+
+// SCIMMaxPageSize bounds the count parameter of SCIM list requests.
+const SCIMMaxPageSize = 200
+
+// TenantSCIMUser links a user to the workspace whose SCIM endpoint
+// provisioned it. The row survives deactivation so the identity provider can
+// still read and reactivate the user after the membership is gone.
+type TenantSCIMUser struct {
+	TenantID   uint64    `json:"tenant_id" gorm:"primaryKey"`
+	UserID     string    `json:"user_id" gorm:"type:varchar(36);primaryKey"`
+	UserName   string    `json:"user_name" gorm:"type:varchar(255);not null"`
+	ExternalID string    `json:"external_id" gorm:"type:varchar(255);not null;default:''"`
+	Active     bool      `json:"active" gorm:"not null;default:true"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// TableName binds TenantSCIMUser to the tenant_scim_users table.
+func (TenantSCIMUser) TableName() string {
+	return "tenant_scim_users"
+}
+
+// TenantSCIMOrganization links a SCIM group of a workspace to the
+// organization provisioned for it. Role is the organization role given to
+// the workspaces of the group's members.
+type TenantSCIMOrganization struct {
+	TenantID       uint64        `json:"tenant_id" gorm:"primaryKey"`
+	GroupID        string        `json:"group_id" gorm:"type:varchar(36);primaryKey"`
+	OrganizationID string        `json:"organization_id" gorm:"type:varchar(36);not null;uniqueIndex"`
+	Role           OrgMemberRole `json:"role" gorm:"type:varchar(32);not null;default:'viewer'"`
+	CreatedAt      time.Time     `json:"created_at"`
+	UpdatedAt      time.Time     `json:"updated_at"`
+}
+
+// TableName binds TenantSCIMOrganization to the tenant_scim_organizations
+// table.
+func (TenantSCIMOrganization) TableName() string {
+	return "tenant_scim_organizations"
+}
+
+// SCIMMeta is the common "meta" attribute of SCIM resources.
+type SCIMMeta struct {
+	ResourceType string     `json:"resourceType"`
+	Created      *time.Time `json:"created,omitempty"`
+	LastModified *time.Time `json:"lastModified,omitempty"`
+	Location     string     `json:"location,omitempty"`
+}
+
+// SCIMName is the complex "name" attribute of a SCIM user.
+type SCIMName struct {
+	Formatted  string `json:"formatted,omitempty"`
+	GivenName  string `json:"givenName,omitempty"`
+	FamilyName string `json:"familyName,omitempty"`
+}
+
+// SCIMMultiValue is an entry of a multi-valued attribute (emails, roles,
+// group members, a user's groups).
+type SCIMMultiValue struct {
+	Value   string `json:"value"`
+	Display string `json:"display,omitempty"`
+	Type    string `json:"type,omitempty"`
+	Primary bool   `json:"primary,omitempty"`
+	Ref     string `json:"$ref,omitempty"`
+}
+
+// SCIMUser is the core User resource. Roles carries the member's workspace
+// role (owner, admin, contributor or viewer); Groups is read-only.
+type SCIMUser struct {
+	Schemas     []string         `json:"schemas"`
+	ID          string           `json:"id,omitempty"`
+	ExternalID  string           `json:"externalId,omitempty"`
+	UserName    string           `json:"userName"`
+	Name        *SCIMName        `json:"name,omitempty"`
+	DisplayName string           `json:"displayName,omitempty"`
+	Emails      []SCIMMultiValue `json:"emails,omitempty"`
+	Active      *bool            `json:"active,omitempty"`
+	Roles       []SCIMMultiValue `json:"roles,omitempty"`
+	Groups      []SCIMMultiValue `json:"groups,omitempty"`
+	Meta        *SCIMMeta        `json:"meta,omitempty"`
+}
+
+// PrimaryEmail returns the primary e-mail of u, else its first e-mail, else
+// the userName when it looks like an address.
+func (u *SCIMUser) PrimaryEmail() string {
+	for _, e := range u.Emails {
+		if e.Primary && strings.TrimSpace(e.Value) != "" {
+			return strings.TrimSpace(e.Value)
+		}
+	}
+	for _, e := range u.Emails {
+		if strings.TrimSpace(e.Value) != "" {
+			return strings.TrimSpace(e.Value)
+		}
+	}
+	if strings.Contains(u.UserName, "@") {
+		return strings.TrimSpace(u.UserName)
+	}
+	return ""
+}
+
+// IsActive reports the requested active state; an absent attribute means
+// active, as RFC 7643 defaults it.
+func (u *SCIMUser) IsActive() bool {
+	return u.Active == nil || *u.Active
+}
+
+// SCIMGroup is the core Group resource, backed by a workspace user group.
+// Organization carries the WeKnora organization extension.
+type SCIMGroup struct {
+	Schemas      []string                   `json:"schemas"`
+	ID           string                     `json:"id,omitempty"`
+	ExternalID   string                     `json:"externalId,omitempty"`
+	DisplayName  string                     `json:"displayName"`
+	Members      []SCIMMultiValue           `json:"members,omitempty"`
+	Organization *SCIMOrganizationExtension `json:"urn:weknora:params:scim:schemas:extension:organization:2.0:Group,omitempty"`
+	Meta         *SCIMMeta                  `json:"meta,omitempty"`
+}
+
+// SCIMOrganizationExtension provisions a group as a collaboration
+// organization owned by the workspace when Organization is true. Role is the
+// organization role of the members' workspaces (viewer when empty);
+// OrganizationID is read-only.
+type SCIMOrganizationExtension struct {
+	Organization   bool   `json:"organization"`
+	Role           string `json:"role,omitempty"`
+	OrganizationID string `json:"organizationId,omitempty"`
+}
+
+// SCIMListResponse is the envelope of a SCIM query.
+type SCIMListResponse struct {
+	Schemas      []string `json:"schemas"`
+	TotalResults int      `json:"totalResults"`
+	StartIndex   int      `json:"startIndex"`
+	ItemsPerPage int      `json:"itemsPerPage"`
+	Resources    []any    `json:"Resources"`
+}
+
+// SCIMPatchRequest is the body of a SCIM PATCH request.
+type SCIMPatchRequest struct {
+	Schemas    []string             `json:"schemas"`
+	Operations []SCIMPatchOperation `json:"Operations"`
+}
+
+// SCIMPatchOperation is one add / replace / remove operation. Value is kept
+// raw because its shape depends on Path.
+type SCIMPatchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// SCIMError is the SCIM error response body.
+type SCIMError struct {
+	Schemas  []string `json:"schemas"`
+	Status   string   `json:"status"`
+	SCIMType string   `json:"scimType,omitempty"`
+	Detail   string   `json:"detail,omitempty"`
+}
+
+// SCIMListQuery is a parsed list request. Filter supports the single
+// comparison `<attribute> eq "<value>"` that identity providers send to
+// look a resource up before creating it.
+type SCIMListQuery struct {
+	FilterAttribute string
+	FilterValue     string
+	StartIndex      int
+	Count           int
+}
+
+// ParseSCIMFilter parses the `<attribute> eq "<value>"` filter form. The
+// attribute is returned as written; the operator is case-insensitive as
+// RFC 7644 requires. ok is false for any other filter expression.
+func ParseSCIMFilter(filter string) (attribute, value string, ok bool) {
+	filter = strings.TrimSpace(filter)
+	if filter == "" {
+		return "", "", false
+	}
+	attr, rest, found := strings.Cut(filter, " ")
+	if !found {
+		return "", "", false
+	}
+	op, rest, found := strings.Cut(strings.TrimLeft(rest, " "), " ")
+	if !found || !strings.EqualFold(op, "eq") {
+		return "", "", false
+	}
+	rest = strings.TrimSpace(rest)
+	if len(rest) < 2 || rest[0] != '"' || rest[len(rest)-1] != '"' {
+		return "", "", false
+	}
+	var unquoted string
+	if err := json.Unmarshal([]byte(rest), &unquoted); err != nil {
+		return "", "", false
+	}
+	return attr, unquoted, true
+}
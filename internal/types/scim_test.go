@@ -0,0 +1,36 @@
+package types
+
+import "testing"
+
+func TestParseSCIMFilter(t *testing.T) {
+	cases := []struct {
+		filter, attr, value string
+		ok                  bool
+	}{
+		{`userName eq "alice@example.com"`, "userName", "alice@example.com", true},
+		{`externalId EQ "00u1"`, "externalId", "00u1", true},
+		{`displayName eq "R&D \"core\""`, "displayName", `R&D "core"`, true},
+		{`userName sw "a"`, "", "", false},
+		{`userName eq alice`, "", "", false},
+		{`userName eq "a" and active eq "true"`, "", "", false},
+		{``, "", "", false},
+	}
+	for _, tc := range cases {
+		attr, value, ok := ParseSCIMFilter(tc.filter)
+		if ok != tc.ok || attr != tc.attr || value != tc.value {
+			t.Errorf("ParseSCIMFilter(%q) = (%q, %q, %v), want (%q, %q, %v)",
+				tc.filter, attr, value, ok, tc.attr, tc.value, tc.ok)
+		}
+	}
+}
+
+func TestSCIMUserPrimaryEmail(t *testing.T) {
+	u := &SCIMUser{UserName: "alice", Emails: []SCIMMultiValue{{Value: "a@x.io"}, {Value: "b@x.io", Primary: true}}}
+	if got := u.PrimaryEmail(); got != "b@x.io" {
+		t.Fatalf("primary email = %q", got)
+	}
+	u = &SCIMUser{UserName: "carol@x.io"}
+	if got := u.PrimaryEmail(); got != "carol@x.io" {
+		t.Fatalf("userName fallback = %q", got)
+	}
+}
@@ -3,6 +3,7 @@ package types
 import (
 	"database/sql/driver"
 	"encoding/json"
+	"time"
 )
 
 // SearchTargetType represents the type of search target
@@ -44,11 +45,66 @@ type SearchTarget struct {
 	// user-selected scope. The reranker still orders candidates, but vector and
 	// keyword thresholds cannot erase the whole explicit scope before reranking.
 	DisableRecallThresholds bool `json:"disable_recall_thresholds,omitempty"`
+	// ExcludeKnowledgeIDs lists knowledge in the knowledge base that the caller
+	// may not read because of document-level ACLs. Retrieval, agent tools and
+	// wiki scopes must skip these items even when the target covers the
+	// whole knowledge base.
+	ExcludeKnowledgeIDs []string `json:"exclude_knowledge_ids,omitempty"`
 }
 
 // SearchTargets is a list of search targets, pre-computed at request entry point
 type SearchTargets []*SearchTarget
 
+// ExcludesKnowledge reports whether the target hides knowledgeID from the
+// caller.
+func (st *SearchTarget) ExcludesKnowledge(knowledgeID string) bool {
+	if st == nil {
+		return false
+	}
+	for _, id := range st.ExcludeKnowledgeIDs {
+		if id == knowledgeID {
+			return true
+		}
+	}
+	return false
+}
+
+// ExcludedKnowledgeIDs returns the knowledge excluded by any target. Knowledge
+// IDs are globally unique, so the union can filter a multi-KB query.
+func (st SearchTargets) ExcludedKnowledgeIDs() []string {
+	seen := make(map[string]bool)
+	var out []string
+	for _, t := range st {
+		if t == nil {
+			continue
+		}
+		for _, id := range t.ExcludeKnowledgeIDs {
+			if id != "" && !seen[id] {
+				seen[id] = true
+				out = append(out, id)
+			}
+		}
+	}
+	return out
+}
+
+// KnowledgeIDsAllowed returns KnowledgeIDs without the excluded items.
+func (st *SearchTarget) KnowledgeIDsAllowed() []string {
+	if st == nil {
+		return nil
+	}
+	if len(st.ExcludeKnowledgeIDs) == 0 {
+		return st.KnowledgeIDs
+	}
+	out := make([]string, 0, len(st.KnowledgeIDs))
+	for _, id := range st.KnowledgeIDs {
+		if !st.ExcludesKnowledge(id) {
+			out = append(out, id)
+		}
+	}
+	return out
+}
+
 // RecallThresholds returns the effective recall thresholds for this target.
 func (st *SearchTarget) RecallThresholds(vectorThreshold, keywordThreshold float64) (float64, float64) {
 	if st != nil && st.DisableRecallThresholds {
@@ -137,6 +193,32 @@ func (st SearchTargets) GetTenantIDForKB(kbID string) uint64 {
 	return 0
 }
 
+// WholeKnowledgeBaseIDs returns the knowledge bases that are in scope without
+// any document or tag narrowing. Artifacts derived from a whole knowledge base
+// (graph community reports, for example) are only safe to return for these.
+func (st SearchTargets) WholeKnowledgeBaseIDs() []string {
+	narrowed := make(map[string]bool)
+	for _, t := range st {
+		if t == nil || t.KnowledgeBaseID == "" {
+			continue
+		}
+		if t.Type != SearchTargetTypeKnowledgeBase || len(t.KnowledgeIDs) > 0 ||
+			len(t.TagIDs) > 0 || len(t.ScopeTagIDs) > 0 {
+			narrowed[t.KnowledgeBaseID] = true
+		}
+	}
+	seen := make(map[string]bool)
+	var result []string
+	for _, t := range st {
+		if t == nil || t.KnowledgeBaseID == "" || narrowed[t.KnowledgeBaseID] || seen[t.KnowledgeBaseID] {
+			continue
+		}
+		seen[t.KnowledgeBaseID] = true
+		result = append(result, t.KnowledgeBaseID)
+	}
+	return result
+}
+
 // ContainsKB checks if the search targets contain a given knowledge base ID
 func (st SearchTargets) ContainsKB(kbID string) bool {
 	for _, t := range st {
@@ -208,6 +290,16 @@ type SearchResult struct {
 	// KnowledgeBaseID is the ID of the knowledge base this result belongs to
 	KnowledgeBaseID string `json:"knowledge_base_id,omitempty"`
 
+	// KnowledgeValidFrom and KnowledgeValidUntil bound the validity window
+	// of the knowledge document, when it has one.
+	KnowledgeValidFrom  *time.Time `json:"knowledge_valid_from,omitempty"`
+	KnowledgeValidUntil *time.Time `json:"knowledge_valid_until,omitempty"`
+
+	// Freshness flags results from expired, not yet valid or soon-to-expire
+	// documents (FreshnessExpired, FreshnessNotYetValid,
+	// FreshnessExpiringSoon). Empty for current documents.
+	Freshness string `json:"freshness,omitempty"`
+
 	// ContentRevision is the chunk edit revision at retrieval time.
 	// Internal only: used by the merge pipeline to decide whether source
 	// coordinates are still trustworthy.
@@ -249,6 +341,10 @@ type SearchParams struct {
 	// in processSearchResults. Used by the chat pipeline where context assembly
 	// is handled separately in the merge stage.
 	SkipContextEnrichment bool `json:"skip_context_enrichment,omitempty"`
+	// ExcludeKnowledgeIDs hides knowledge from every retrieval engine. Set by
+	// callers that already resolved document-level ACLs (search targets);
+	// HybridSearch adds the caller's denied knowledge on top.
+	ExcludeKnowledgeIDs []string `json:"-"`
 }
 
 // Value implements the driver.Valuer interface, used to convert SearchResult to database value
@@ -221,8 +221,14 @@ func (c *StringArray) Scan(value interface{}) error {
 	if value == nil {
 		return nil
 	}
-	b, ok := value.([]byte)
-	if !ok {
+	var b []byte
+	switch v := value.(type) {
+	case []byte:
+		b = v
+	case string:
+		// SQLite drivers hand TEXT columns back as strings.
+		b = []byte(v)
+	default:
 		return nil
 	}
 	return json.Unmarshal(b, c)
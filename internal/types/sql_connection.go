@@ -0,0 +1,234 @@
+package types
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/utils"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// SQLDialect identifies the engine behind an external SQL connection.
+type SQLDialect string
+
+const (
+	SQLDialectPostgres SQLDialect = "postgres"
+	SQLDialectMySQL    SQLDialect = "mysql"
+	SQLDialectSQLite   SQLDialect = "sqlite"
+	// SQLDialectDuckDB covers .duckdb database files as well as CSV / Parquet
+	// files, which are exposed to the agent as read-only views.
+	SQLDialectDuckDB SQLDialect = "duckdb"
+)
+
+// IsValid reports whether d is one of the supported dialects.
+func (d SQLDialect) IsValid() bool {
+	switch d {
+	case SQLDialectPostgres, SQLDialectMySQL, SQLDialectSQLite, SQLDialectDuckDB:
+		return true
+	}
+	return false
+}
+
+// IsFileBased reports whether the dialect reads a local file instead of
+// connecting to a server.
+func (d SQLDialect) IsFileBased() bool {
+	return d == SQLDialectSQLite || d == SQLDialectDuckDB
+}
+
+const (
+	// DefaultSQLConnectionMaxRows is used when a connection does not set MaxRows.
+	DefaultSQLConnectionMaxRows = 200
+	// MaxSQLConnectionMaxRows caps MaxRows no matter what the connection asks for.
+	MaxSQLConnectionMaxRows = 1000
+	// DefaultSQLConnectionTimeoutSec is used when a connection does not set TimeoutSec.
+	DefaultSQLConnectionTimeoutSec = 30
+	// MaxSQLConnectionTimeoutSec caps TimeoutSec no matter what the connection asks for.
+	MaxSQLConnectionTimeoutSec = 120
+)
+
+// SQLConnection is an external, read-only database a workspace exposes to
+// its agents for text-to-SQL. Agents reference connections by ID through
+// CustomAgentConfig.SQLConnectionIDs.
+type SQLConnection struct {
+	// Unique identifier (UUID, auto-generated)
+	ID string `yaml:"id" json:"id" gorm:"type:varchar(36);primaryKey"`
+	// Workspace ID for scoping
+	TenantID uint64 `yaml:"tenant_id" json:"tenant_id"`
+	// User-friendly name, e.g., "Sales warehouse"
+	Name string `yaml:"name" json:"name" gorm:"type:varchar(255);not null"`
+	// Description shown to the agent next to the schema
+	Description string `yaml:"description" json:"description" gorm:"type:text"`
+	// Dialect: postgres, mysql, sqlite, duckdb
+	Dialect SQLDialect `yaml:"dialect" json:"dialect" gorm:"type:varchar(20);not null"`
+	// Connection parameters; the password is encrypted at rest
+	Parameters SQLConnectionParameters `yaml:"parameters" json:"parameters" gorm:"type:json"`
+	// Execution limits and schema annotations
+	Options SQLConnectionOptions `yaml:"options" json:"options" gorm:"type:json"`
+	// Timestamps
+	CreatedAt time.Time      `yaml:"created_at" json:"created_at"`
+	UpdatedAt time.Time      `yaml:"updated_at" json:"updated_at"`
+	DeletedAt gorm.DeletedAt `yaml:"deleted_at" json:"deleted_at" gorm:"index"`
+}
+
+// TableName returns the table name for SQLConnection
+func (SQLConnection) TableName() string {
+	return "sql_connections"
+}
+
+// BeforeCreate is a GORM hook that runs before creating a new record.
+// Automatically generates a UUID for new connections.
+func (c *SQLConnection) BeforeCreate(tx *gorm.DB) (err error) {
+	if c.ID == "" {
+		c.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// SQLConnectionParameters holds how to reach the database.
+//
+// Credential mutation flows through the dedicated /credentials subresource
+// (see internal/handler/sql_connection_credentials.go). The password is never
+// returned in responses — handlers serialize via dto.NewSQLConnectionResponse.
+type SQLConnectionParameters struct {
+	// Server host (postgres, mysql). Private hosts must be added to SSRF_WHITELIST.
+	Host string `yaml:"host" json:"host,omitempty"`
+	// Server port (postgres, mysql); the dialect default is used when zero
+	Port int `yaml:"port" json:"port,omitempty"`
+	// Database name (postgres, mysql)
+	Database string `yaml:"database" json:"database,omitempty"`
+	// Login user (postgres, mysql). Use an account with read-only grants.
+	Username string `yaml:"username" json:"username,omitempty"`
+	// Login password (encrypted in DB)
+	Password string `yaml:"password" json:"password,omitempty"`
+	// Postgres sslmode (disable, require, verify-full, ...)
+	SSLMode string `yaml:"ssl_mode" json:"ssl_mode,omitempty"`
+	// Local file (sqlite, duckdb). Must live under one of WEKNORA_SQL_FILE_DIRS.
+	// DuckDB accepts .duckdb database files and .csv / .parquet data files.
+	FilePath string `yaml:"file_path" json:"file_path,omitempty"`
+}
+
+// Value implements the driver.Valuer interface.
+// Encrypts Password before persisting to database.
+func (p SQLConnectionParameters) Value() (driver.Value, error) {
+	if key := utils.GetAESKey(); key != nil && p.Password != "" {
+		if encrypted, err := utils.EncryptAESGCM(p.Password, key); err == nil {
+			p.Password = encrypted
+		}
+	}
+	return json.Marshal(p)
+}
+
+// Scan implements the sql.Scanner interface.
+// Decrypts Password after loading from database.
+func (p *SQLConnectionParameters) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	b, ok := value.([]byte)
+	if !ok {
+		return nil
+	}
+	if err := json.Unmarshal(b, p); err != nil {
+		return err
+	}
+	if plain, ok := utils.DecryptStoredSecretLenient(p.Password); ok {
+		p.Password = plain
+	} else {
+		log.Printf("[crypto] sql connection password: decrypt failed (SYSTEM_AES_KEY missing/rotated?), treating as unconfigured")
+		p.Password = ""
+	}
+	return nil
+}
+
+// SQLConnectionOptions bounds query execution and annotates the schema the
+// agent sees.
+type SQLConnectionOptions struct {
+	// Maximum rows returned per query (default 200, capped at 1000)
+	MaxRows int `yaml:"max_rows" json:"max_rows,omitempty"`
+	// Per-query timeout in seconds (default 30, capped at 120)
+	TimeoutSec int `yaml:"timeout_sec" json:"timeout_sec,omitempty"`
+	// Tables described to the agent; empty means all. This narrows what the
+	// agent is shown, not what it can read — restrict access with database grants.
+	IncludeTables []string `yaml:"include_tables" json:"include_tables,omitempty"`
+	// Business descriptions keyed by table name
+	TableDescriptions map[string]string `yaml:"table_descriptions" json:"table_descriptions,omitempty"`
+	// Business descriptions keyed by "table.column"; they override database comments
+	ColumnDescriptions map[string]string `yaml:"column_descriptions" json:"column_descriptions,omitempty"`
+}
+
+// EffectiveMaxRows returns MaxRows with the default and cap applied.
+func (o SQLConnectionOptions) EffectiveMaxRows() int {
+	if o.MaxRows <= 0 {
+		return DefaultSQLConnectionMaxRows
+	}
+	if o.MaxRows > MaxSQLConnectionMaxRows {
+		return MaxSQLConnectionMaxRows
+	}
+	return o.MaxRows
+}
+
+// EffectiveTimeout returns TimeoutSec with the default and cap applied.
+func (o SQLConnectionOptions) EffectiveTimeout() time.Duration {
+	sec := o.TimeoutSec
+	if sec <= 0 {
+		sec = DefaultSQLConnectionTimeoutSec
+	}
+	if sec > MaxSQLConnectionTimeoutSec {
+		sec = MaxSQLConnectionTimeoutSec
+	}
+	return time.Duration(sec) * time.Second
+}
+
+// Value implements the driver.Valuer interface.
+func (o SQLConnectionOptions) Value() (driver.Value, error) {
+	return json.Marshal(o)
+}
+
+// Scan implements the sql.Scanner interface.
+func (o *SQLConnectionOptions) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	b, ok := value.([]byte)
+	if !ok {
+		return nil
+	}
+	return json.Unmarshal(b, o)
+}
+
+// SQLSchema is the introspected shape of an external database.
+type SQLSchema struct {
+	Dialect SQLDialect `json:"dialect"`
+	Tables  []SQLTable `json:"tables"`
+	// Truncated is set when the database has more tables than were introspected
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// SQLTable describes one table or view.
+type SQLTable struct {
+	Name        string      `json:"name"`
+	Description string      `json:"description,omitempty"`
+	Columns     []SQLColumn `json:"columns"`
+}
+
+// SQLColumn describes one column.
+type SQLColumn struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	Nullable    bool   `json:"nullable"`
+	Description string `json:"description,omitempty"`
+}
+
+// SQLQueryResult is a bounded, tabular query result.
+type SQLQueryResult struct {
+	Columns []string `json:"columns"`
+	// ColumnTypes holds the database type name of each column, e.g. "INT8"
+	ColumnTypes []string        `json:"column_types"`
+	Rows        [][]interface{} `json:"rows"`
+	RowCount    int             `json:"row_count"`
+	Truncated   bool            `json:"truncated"`
+	DurationMs  int64           `json:"duration_ms"`
+}
@@ -76,7 +76,9 @@ var queueDefinitions = []QueueDefinition{
 		TypeSummaryGeneration, TypeDataTableSummary, TypeKnowledgeAutoTag,
 	}},
 	{Name: QueueMultimodal, Pool: WorkerPoolEnrichment, Weight: 1, SharedWeight: 1, TaskTypes: []string{TypeImageMultimodal}},
-	{Name: QueueGraph, Pool: WorkerPoolEnrichment, Weight: 1, SharedWeight: 1, TaskTypes: []string{TypeChunkExtract}},
+	{Name: QueueGraph, Pool: WorkerPoolEnrichment, Weight: 1, SharedWeight: 1, TaskTypes: []string{
+		TypeChunkExtract, TypeGraphCommunity, TypeGraphEntityResolution,
+	}},
 	{Name: QueueQuestion, Pool: WorkerPoolEnrichment, Weight: 1, SharedWeight: 1, TaskTypes: []string{TypeQuestionGeneration}},
 	{Name: QueueMemory, Pool: WorkerPoolEnrichment, Weight: 1, SharedWeight: 1, TaskTypes: []string{TypeMemoryExtract}},
 	{Name: QueueSync, Pool: WorkerPoolMaintenance, Weight: 2, TaskTypes: []string{TypeDataSourceSync}},
@@ -254,6 +256,10 @@ const (
 	TypeTemporaryDocumentProcess = "temporary_document:process" // 会话临时文档解析任务
 	// TypeMemoryExtract 长期记忆抽取任务（会话轮次防抖后异步执行）
 	TypeMemoryExtract = "memory:extract"
+	// TypeGraphCommunity 知识图谱社区检测与社区报告生成任务（KB 级防抖）
+	TypeGraphCommunity = "graph:community"
+	// TypeGraphEntityResolution 知识图谱实体消歧任务（生成待审核的合并建议）
+	TypeGraphEntityResolution = "graph:entity_resolution"
 )
 
 // MemoryExtractPayload carries everything the background distillation task
@@ -319,6 +325,13 @@ type DocumentProcessPayload struct {
 	// retried spans overwrite the previous attempt's row rather than
 	// fan out into a new attempt for every retry.
 	Attempt int `json:"attempt,omitempty"`
+	// VersionEditorID is the user who triggered the parse, recorded on the
+	// knowledge version it produces.
+	VersionEditorID string `json:"version_editor_id,omitempty"`
+	// RestoreVersion is set when the task restores an earlier version. With
+	// no FilePath, the worker indexes that version's stored markdown instead
+	// of fetching the source again.
+	RestoreVersion int `json:"restore_version,omitempty"`
 }
 
 // FAQImportPayload represents the FAQ import task payload (including dry run mode)
@@ -492,6 +505,9 @@ type ManualProcessPayload struct {
 	KnowledgeBaseID string `json:"knowledge_base_id"`
 	Content         string `json:"content"`      // cleaned markdown content
 	NeedCleanup     bool   `json:"need_cleanup"` // true for update, false for create
+	// VersionEditorID and RestoreVersion mirror DocumentProcessPayload.
+	VersionEditorID string `json:"version_editor_id,omitempty"`
+	RestoreVersion  int    `json:"restore_version,omitempty"`
 }
 
 // ImageMultimodalPayload represents the image multimodal processing task payload.
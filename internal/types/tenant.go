@@ -626,7 +626,7 @@ func (c *StorageEngineConfig) Scan(value interface{}) error {
 // environment. Leaving a required provider field empty is rejected on save.
 type TenantSandboxConfig struct {
 	// SandboxType selects the sandbox backend. Named configs may use "cube",
-	// "e2b", "docker", or "local". "disabled" is reserved for the hidden
+	// "e2b", "namespace", "wasm", "docker", or "local". "disabled" is reserved for the hidden
 	// workspace policy row.
 	SandboxType string `json:"sandbox_type,omitempty"`
 
@@ -656,9 +656,11 @@ type TenantSandboxConfig struct {
 
 	// ── 后端专属配置（同一时刻只有一个生效，由 SandboxType 决定）───
 
-	Cube   *CubeSandboxConfig   `json:"cube,omitempty"`
-	E2B    *E2BSandboxConfig    `json:"e2b,omitempty"`
-	Docker *DockerSandboxConfig `json:"docker,omitempty"`
+	Cube      *CubeSandboxConfig      `json:"cube,omitempty"`
+	E2B       *E2BSandboxConfig       `json:"e2b,omitempty"`
+	Docker    *DockerSandboxConfig    `json:"docker,omitempty"`
+	Namespace *NamespaceSandboxConfig `json:"namespace,omitempty"`
+	Wasm      *WasmSandboxConfig      `json:"wasm,omitempty"`
 }
 
 // CubeSandboxConfig addresses one CubeSandbox deployment. APIURL, ProxyURL,
@@ -710,6 +712,33 @@ type DockerSandboxConfig struct {
 	Image string `json:"image,omitempty"`
 }
 
+// NamespaceSandboxConfig tunes sandboxes that run on the WeKnora host inside
+// Linux namespaces. Which directories and cgroup the backend uses is a host
+// decision (WEKNORA_NS_SANDBOX_* env), so only per-sandbox limits live here.
+// Zero values use the built-in defaults.
+type NamespaceSandboxConfig struct {
+	// AllowNetwork keeps the host's network namespace. When false scripts
+	// only see a private loopback interface.
+	AllowNetwork bool `json:"allow_network,omitempty"`
+
+	// MemoryMB, CPUCores and PidsLimit are enforced through cgroup v2 and
+	// only take effect when the host configured a cgroup root.
+	MemoryMB  int     `json:"memory_mb,omitempty"`
+	CPUCores  float64 `json:"cpu_cores,omitempty"`
+	PidsLimit int     `json:"pids_limit,omitempty"`
+
+	// SandboxTTLSeconds is how long an idle session workspace is kept.
+	SandboxTTLSeconds int `json:"sandbox_ttl_seconds,omitempty"`
+}
+
+// WasmSandboxConfig tunes the in-process WebAssembly backend. Where the
+// interpreter runtimes live is a host decision (WEKNORA_WASM_SANDBOX_DIR), so
+// only the per-execution memory cap lives here. Zero uses the built-in default.
+type WasmSandboxConfig struct {
+	// MemoryMB caps each script's linear memory.
+	MemoryMB int `json:"memory_mb,omitempty"`
+}
+
 // VolumeMountConfig configures a shared volume mount into every sandbox
 // created for this tenant. Currently implemented for E2B volumes (used to
 // share tenant-installed skills across sandbox sessions), but the schema is
@@ -30,11 +30,17 @@ type TenantAPIKey struct {
 	// (KnowledgeBaseIDs) still applies on top where a route targets knowledge
 	// bases.
 	Capabilities StringArray `json:"capabilities" gorm:"type:jsonb;not null;default:'[]'"`
-	LastUsedAt   *time.Time  `json:"last_used_at,omitempty"`
-	ExpiresAt    *time.Time  `json:"expires_at,omitempty"`
-	RevokedAt    *time.Time  `json:"revoked_at,omitempty" gorm:"index"`
-	CreatedAt    time.Time   `json:"created_at"`
-	UpdatedAt    time.Time   `json:"updated_at"`
+	// CustomRoleID, when set, replaces FullAccess and Capabilities with the
+	// permissions of the referenced tenant custom role at authentication.
+	CustomRoleID *uint64 `json:"custom_role_id,omitempty" gorm:"index"`
+	// CreatedBy is the user who created the key; deprovisioning that user
+	// revokes it. Empty for keys created before it was recorded.
+	CreatedBy  string     `json:"created_by,omitempty" gorm:"type:varchar(36);not null;default:''"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty" gorm:"index"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
 }
 
 type APIKeyScopeType string
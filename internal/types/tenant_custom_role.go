@@ -0,0 +1,166 @@
+package types
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// TenantCustomRole is a workspace-defined role: a built-in TenantRole that
+// sets the ceiling, narrowed to a subset of the permission catalog.
+//
+// The base role keeps governing everything RequireRole checks (so a custom
+// role can never exceed it), while Permissions decide which route groups the
+// holder may reach at all. A role based on Admin with only manage_datasources
+// can manage data sources but not models; one based on Viewer with chat and
+// read_agents can talk to agents without opening the documents behind them.
+type TenantCustomRole struct {
+	ID          uint64      `json:"id" gorm:"primaryKey;autoIncrement"`
+	TenantID    uint64      `json:"tenant_id" gorm:"not null;index"`
+	Name        string      `json:"name" gorm:"type:varchar(64);not null"`
+	Description string      `json:"description" gorm:"type:varchar(512);not null;default:''"`
+	BaseRole    TenantRole  `json:"base_role" gorm:"type:varchar(20);not null"`
+	Permissions StringArray `json:"permissions" gorm:"type:jsonb;not null;default:'[]'"`
+	CreatedBy   string      `json:"created_by" gorm:"type:varchar(36);not null;default:''"`
+	CreatedAt   time.Time   `json:"created_at"`
+	UpdatedAt   time.Time   `json:"updated_at"`
+}
+
+// TableName binds TenantCustomRole to the tenant_custom_roles table.
+func (TenantCustomRole) TableName() string {
+	return "tenant_custom_roles"
+}
+
+// MaxCustomRoleNameLength bounds TenantCustomRole.Name.
+const MaxCustomRoleNameLength = 64
+
+// TenantCustomRoleRequest is the body of the create and update endpoints.
+type TenantCustomRoleRequest struct {
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+	BaseRole    TenantRole  `json:"base_role"`
+	Permissions StringArray `json:"permissions"`
+}
+
+// TenantCustomRoleDetail is a role with how many members and API keys
+// currently hold it.
+type TenantCustomRoleDetail struct {
+	TenantCustomRole
+	MemberCount int64 `json:"member_count"`
+	APIKeyCount int64 `json:"api_key_count"`
+}
+
+// CustomRolePermissionEntry describes one catalog permission and the routes
+// it opens, as registered in the router.
+type CustomRolePermissionEntry struct {
+	Permission string   `json:"permission"`
+	Routes     []string `json:"routes"`
+}
+
+// customRolePermissionCatalog is the set of permissions a custom role may
+// grant. It is the tenant-level API key capability set: routes already declare
+// which of these they need (router/rbac.go), so members and keys are checked
+// against one vocabulary. Platform-only system_* capabilities are excluded.
+var customRolePermissionCatalog = []APIKeyCapability{
+	APIKeyCapabilityRetrieve,
+	APIKeyCapabilityChat,
+	APIKeyCapabilityReadAgents,
+	APIKeyCapabilityIngest,
+	APIKeyCapabilityManageKnowledgeBases,
+	APIKeyCapabilityManageAgents,
+	APIKeyCapabilityMessageHistory,
+	APIKeyCapabilityManageModels,
+	APIKeyCapabilityManageMCPServices,
+	APIKeyCapabilityManageDataSources,
+	APIKeyCapabilityManageChannels,
+	APIKeyCapabilityManageVectorStores,
+	APIKeyCapabilityManageStorageBackends,
+	APIKeyCapabilityManageWebSearch,
+	APIKeyCapabilityRunEvaluations,
+	APIKeyCapabilityManageMembers,
+	APIKeyCapabilityManageSpaces,
+	APIKeyCapabilityManageTenantSettings,
+}
+
+// CustomRolePermissionCatalog returns a copy of the grantable permissions.
+func CustomRolePermissionCatalog() []APIKeyCapability {
+	return append([]APIKeyCapability(nil), customRolePermissionCatalog...)
+}
+
+// IsCustomRolePermission reports whether p is in the permission catalog.
+func IsCustomRolePermission(p APIKeyCapability) bool {
+	p = NormalizeAPIKeyCapability(p)
+	for _, c := range customRolePermissionCatalog {
+		if c == p {
+			return true
+		}
+	}
+	return false
+}
+
+// NormalizeCustomRolePermissions trims, lower-cases and dedups in. It returns
+// the first entry outside the catalog as invalid so callers can reject the
+// request instead of silently dropping a permission the caller asked for.
+func NormalizeCustomRolePermissions(in StringArray) (out StringArray, invalid string) {
+	out = make(StringArray, 0, len(in))
+	seen := map[string]struct{}{}
+	for _, item := range in {
+		p := APIKeyCapability(strings.ToLower(strings.TrimSpace(item)))
+		if !IsCustomRolePermission(p) {
+			return nil, item
+		}
+		if _, ok := seen[string(p)]; ok {
+			continue
+		}
+		seen[string(p)] = struct{}{}
+		out = append(out, string(p))
+	}
+	return out, ""
+}
+
+// IsValidCustomRoleBase reports whether r may be the base of a custom role.
+// Owner is excluded: ownership carries tenant deletion and key management,
+// which no catalog permission narrows.
+func IsValidCustomRoleBase(r TenantRole) bool {
+	switch r {
+	case TenantRoleAdmin, TenantRoleContributor, TenantRoleViewer:
+		return true
+	}
+	return false
+}
+
+// CustomRoleGrant is the request-context projection of the caller's custom
+// role, attached by the auth middleware for JWT members.
+type CustomRoleGrant struct {
+	RoleID      uint64
+	Name        string
+	Permissions StringArray
+}
+
+// Allows reports whether the grant includes permission c.
+func (g CustomRoleGrant) Allows(c APIKeyCapability) bool {
+	c = NormalizeAPIKeyCapability(c)
+	if c == "" {
+		return false
+	}
+	for _, p := range g.Permissions {
+		if p == string(c) {
+			return true
+		}
+	}
+	return false
+}
+
+// WithCustomRoleGrant stores g on ctx.
+func WithCustomRoleGrant(ctx context.Context, g CustomRoleGrant) context.Context {
+	return context.WithValue(ctx, CustomRoleGrantContextKey, g)
+}
+
+// CustomRoleGrantFromContext returns the caller's custom role, if any.
+func CustomRoleGrantFromContext(ctx context.Context) (CustomRoleGrant, bool) {
+	if ctx == nil {
+		return CustomRoleGrant{}, false
+	}
+	g, ok := ctx.Value(CustomRoleGrantContextKey).(CustomRoleGrant)
+	return g, ok
+}
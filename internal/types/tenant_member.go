@@ -99,6 +99,9 @@ type TenantMember struct {
 	// InvitedBy records the user ID of the admin who created this row via
 	// an invitation flow. Nil for rows created by self-service registration.
 	InvitedBy *string `json:"invited_by,omitempty" gorm:"type:varchar(36)"`
+	// CustomRoleID references tenant_custom_roles.id when the member holds a
+	// custom role. Role then mirrors the custom role's base role.
+	CustomRoleID *uint64 `json:"custom_role_id,omitempty" gorm:"index"`
 	// JoinedAt is when the membership became active.
 	JoinedAt  time.Time      `json:"joined_at"`
 	CreatedAt time.Time      `json:"created_at"`
@@ -134,4 +137,7 @@ type TenantMemberResponse struct {
 	Status    TenantMemberStatus `json:"status"`
 	InvitedBy *string            `json:"invited_by,omitempty"`
 	JoinedAt  time.Time          `json:"joined_at"`
+	// CustomRoleID is set when the member holds a custom role; Role is then
+	// its base role.
+	CustomRoleID *uint64 `json:"custom_role_id,omitempty"`
 }
@@ -40,6 +40,10 @@ type TracingContext struct {
 	LangfuseUserID string `json:"lf_user_id,omitempty"`
 	// LangfuseSessionID preserves the sessionId for the same reason.
 	LangfuseSessionID string `json:"lf_session_id,omitempty"`
+	// Traceparent is the W3C traceparent of the enqueuing otlp span. It is
+	// kept apart from LangfuseTraceparent because the two exporters keep
+	// separate parent chains; see the otlp package.
+	Traceparent string `json:"traceparent,omitempty"`
 }
 
 // SetLangfuseTracing overwrites the embedded TracingContext. Method is
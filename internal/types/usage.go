@@ -0,0 +1,354 @@
+package types
+
+import (
+	"context"
+	"encoding/csv"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// UsageKind classifies a metered model or tool call.
+type UsageKind string
+
+const (
+	// UsageKindChat is a chat completion; it carries prompt, completion and
+	// cached tokens as reported by the provider.
+	UsageKindChat UsageKind = "chat"
+	// UsageKindEmbedding is an embedding call; tokens are approximated from
+	// the input length because most providers do not report them.
+	UsageKindEmbedding UsageKind = "embedding"
+	// UsageKindRerank is a rerank call, counted in calls.
+	UsageKindRerank UsageKind = "rerank"
+	// UsageKindWebSearch is a web search provider call, counted in calls.
+	UsageKindWebSearch UsageKind = "web_search"
+)
+
+// IsValid reports whether k is a known usage kind.
+func (k UsageKind) IsValid() bool {
+	switch k {
+	case UsageKindChat, UsageKindEmbedding, UsageKindRerank, UsageKindWebSearch:
+		return true
+	}
+	return false
+}
+
+// UsagePeriodFormat and UsageDayFormat render the UTC month and day a usage
+// record is filed under. Both are stored as strings so reports and budget
+// checks group the same way on Postgres and SQLite.
+const (
+	UsagePeriodFormat = "2006-01"
+	UsageDayFormat    = "2006-01-02"
+)
+
+// UsagePeriod returns the budget period (UTC calendar month) t falls in.
+func UsagePeriod(t time.Time) string {
+	return t.UTC().Format(UsagePeriodFormat)
+}
+
+// UsageEvent is what a model decorator reports for one call. Attribution
+// (tenant, caller, agent, knowledge base, session) is read from the context
+// by the meter rather than passed in, because the models layer does not know
+// who it is serving.
+type UsageEvent struct {
+	Kind             UsageKind
+	ModelID          string
+	ModelName        string
+	PromptTokens     int64
+	CompletionTokens int64
+	CachedTokens     int64
+	EmbeddingTokens  int64
+	Calls            int64
+}
+
+// UsageEventFromTokenUsage builds a chat usage event from provider usage.
+// Cached tokens are a subset of prompt tokens and are recorded for reporting
+// only; they are not counted twice against budgets.
+func UsageEventFromTokenUsage(modelID, modelName string, u *TokenUsage) UsageEvent {
+	ev := UsageEvent{Kind: UsageKindChat, ModelID: modelID, ModelName: modelName, Calls: 1}
+	if u == nil {
+		return ev
+	}
+	ev.PromptTokens = int64(u.PromptTokens)
+	ev.CompletionTokens = int64(u.CompletionTokens)
+	cached := u.CachedTokens
+	if cached == 0 {
+		cached = u.CacheReadTokens
+	}
+	ev.CachedTokens = int64(cached)
+	return ev
+}
+
+// UsageRecord is one row of the usage ledger. Rows are append-only.
+type UsageRecord struct {
+	ID              uint64    `json:"id" gorm:"primaryKey;autoIncrement"`
+	TenantID        uint64    `json:"tenant_id" gorm:"not null;index"`
+	UserID          string    `json:"user_id" gorm:"type:varchar(36);not null;default:''"`
+	APIKeyID        uint64    `json:"api_key_id" gorm:"column:api_key_id;not null;default:0"`
+	AgentID         string    `json:"agent_id" gorm:"type:varchar(36);not null;default:''"`
+	KnowledgeBaseID string    `json:"knowledge_base_id" gorm:"type:varchar(36);not null;default:''"`
+	SessionID       string    `json:"session_id" gorm:"type:varchar(36);not null;default:''"`
+	Kind            UsageKind `json:"kind" gorm:"type:varchar(16);not null"`
+	ModelID         string    `json:"model_id" gorm:"type:varchar(64);not null;default:''"`
+	ModelName       string    `json:"model_name" gorm:"type:varchar(255);not null;default:''"`
+	PromptTokens    int64     `json:"prompt_tokens" gorm:"not null;default:0"`
+	// CompletionTokens includes reasoning tokens when the provider folds them in.
+	CompletionTokens int64 `json:"completion_tokens" gorm:"not null;default:0"`
+	CachedTokens     int64 `json:"cached_tokens" gorm:"not null;default:0"`
+	EmbeddingTokens  int64 `json:"embedding_tokens" gorm:"not null;default:0"`
+	Calls            int64 `json:"calls" gorm:"not null;default:0"`
+	// Period (YYYY-MM) and Day (YYYY-MM-DD) are the UTC month and day of
+	// CreatedAt, denormalized for dialect-independent grouping.
+	Period    string    `json:"period" gorm:"type:varchar(7);not null"`
+	Day       string    `json:"day" gorm:"type:varchar(10);not null"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TableName returns the table name for UsageRecord
+func (UsageRecord) TableName() string {
+	return "usage_records"
+}
+
+// BillableTokens is what counts against a token budget: prompt, completion
+// and embedding tokens. Cached tokens are already part of prompt tokens.
+func (r *UsageRecord) BillableTokens() int64 {
+	return r.PromptTokens + r.CompletionTokens + r.EmbeddingTokens
+}
+
+// DefaultUsageWarnPercent is the share of a budget at which the soft
+// warning fires when the budget does not set one.
+const DefaultUsageWarnPercent = 80
+
+// UsageBudget caps the billable tokens a workspace, or one API key of it,
+// may use in a UTC calendar month. APIKeyID 0 is the workspace-wide budget.
+type UsageBudget struct {
+	ID                uint64 `json:"id" gorm:"primaryKey;autoIncrement"`
+	TenantID          uint64 `json:"tenant_id" gorm:"not null;uniqueIndex:idx_usage_budgets_scope"`
+	APIKeyID          uint64 `json:"api_key_id" gorm:"column:api_key_id;not null;default:0;uniqueIndex:idx_usage_budgets_scope"`
+	MonthlyTokenLimit int64  `json:"monthly_token_limit" gorm:"not null"`
+	// WarnPercent is the share of the limit at which a soft warning is
+	// logged, audited and surfaced in the X-WeKnora-Usage-Warning header.
+	WarnPercent int `json:"warn_percent" gorm:"not null;default:80"`
+	// HardStop rejects model calls once the limit is reached. Without it the
+	// limit only warns.
+	HardStop bool `json:"hard_stop" gorm:"not null;default:false"`
+	// WarnedPeriod and ExceededPeriod record the last period the warning and
+	// the limit were reported in, so each fires once per month.
+	WarnedPeriod   string    `json:"warned_period" gorm:"type:varchar(7);not null;default:''"`
+	ExceededPeriod string    `json:"exceeded_period" gorm:"type:varchar(7);not null;default:''"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// TableName returns the table name for UsageBudget
+func (UsageBudget) TableName() string {
+	return "usage_budgets"
+}
+
+// WarnThreshold returns the token count at which the soft warning fires.
+func (b *UsageBudget) WarnThreshold() int64 {
+	pct := b.WarnPercent
+	if pct <= 0 || pct > 100 {
+		pct = DefaultUsageWarnPercent
+	}
+	return b.MonthlyTokenLimit * int64(pct) / 100
+}
+
+// UsageBudgetRequest creates or replaces the budget of a scope.
+type UsageBudgetRequest struct {
+	// APIKeyID selects the API key the budget applies to; 0 or omitted is
+	// the workspace-wide budget.
+	APIKeyID          uint64 `json:"api_key_id"`
+	MonthlyTokenLimit int64  `json:"monthly_token_limit"`
+	WarnPercent       int    `json:"warn_percent"`
+	HardStop          bool   `json:"hard_stop"`
+}
+
+// UsageBudgetState summarizes where a budget stands this month.
+type UsageBudgetState string
+
+const (
+	UsageBudgetStateOK       UsageBudgetState = "ok"
+	UsageBudgetStateWarning  UsageBudgetState = "warning"
+	UsageBudgetStateExceeded UsageBudgetState = "exceeded"
+)
+
+// UsageBudgetStatus is a budget with its month-to-date consumption.
+type UsageBudgetStatus struct {
+	*UsageBudget
+	Period     string           `json:"period"`
+	UsedTokens int64            `json:"used_tokens"`
+	State      UsageBudgetState `json:"state"`
+}
+
+// StateFor classifies used tokens against the budget.
+func (b *UsageBudget) StateFor(used int64) UsageBudgetState {
+	switch {
+	case b.MonthlyTokenLimit > 0 && used >= b.MonthlyTokenLimit:
+		return UsageBudgetStateExceeded
+	case b.MonthlyTokenLimit > 0 && used >= b.WarnThreshold():
+		return UsageBudgetStateWarning
+	default:
+		return UsageBudgetStateOK
+	}
+}
+
+// UsageGroupBy is the dimension a usage report aggregates on.
+type UsageGroupBy string
+
+const (
+	UsageGroupByDay           UsageGroupBy = "day"
+	UsageGroupByMonth         UsageGroupBy = "month"
+	UsageGroupByKind          UsageGroupBy = "kind"
+	UsageGroupByModel         UsageGroupBy = "model"
+	UsageGroupByUser          UsageGroupBy = "user"
+	UsageGroupByAPIKey        UsageGroupBy = "api_key"
+	UsageGroupByAgent         UsageGroupBy = "agent"
+	UsageGroupByKnowledgeBase UsageGroupBy = "knowledge_base"
+	UsageGroupBySession       UsageGroupBy = "session"
+)
+
+// UsageGroupByColumn maps a group-by dimension to its usage_records column.
+// The second return value is false for unknown dimensions.
+func UsageGroupByColumn(g UsageGroupBy) (string, bool) {
+	switch g {
+	case UsageGroupByDay:
+		return "day", true
+	case UsageGroupByMonth:
+		return "period", true
+	case UsageGroupByKind:
+		return "kind", true
+	case UsageGroupByModel:
+		return "model_id", true
+	case UsageGroupByUser:
+		return "user_id", true
+	case UsageGroupByAPIKey:
+		return "api_key_id", true
+	case UsageGroupByAgent:
+		return "agent_id", true
+	case UsageGroupByKnowledgeBase:
+		return "knowledge_base_id", true
+	case UsageGroupBySession:
+		return "session_id", true
+	}
+	return "", false
+}
+
+// MaxUsageReportDays bounds the date range of one report.
+const MaxUsageReportDays = 366
+
+// UsageReportQuery selects and groups ledger rows. From and To are UTC days
+// (YYYY-MM-DD), both inclusive. Empty filters match everything.
+type UsageReportQuery struct {
+	TenantID        uint64       `json:"-"`
+	From            string       `json:"from"`
+	To              string       `json:"to"`
+	GroupBy         UsageGroupBy `json:"group_by"`
+	Kind            UsageKind    `json:"kind,omitempty"`
+	ModelID         string       `json:"model_id,omitempty"`
+	UserID          string       `json:"user_id,omitempty"`
+	APIKeyID        uint64       `json:"api_key_id,omitempty"`
+	AgentID         string       `json:"agent_id,omitempty"`
+	KnowledgeBaseID string       `json:"knowledge_base_id,omitempty"`
+	SessionID       string       `json:"session_id,omitempty"`
+}
+
+// UsageReportRow aggregates the ledger rows sharing one group key. Label is
+// a human-readable name for the key where one exists (the model name when
+// grouping by model).
+type UsageReportRow struct {
+	Key              string `json:"key"`
+	Label            string `json:"label,omitempty"`
+	Records          int64  `json:"records"`
+	Calls            int64  `json:"calls"`
+	PromptTokens     int64  `json:"prompt_tokens"`
+	CompletionTokens int64  `json:"completion_tokens"`
+	CachedTokens     int64  `json:"cached_tokens"`
+	EmbeddingTokens  int64  `json:"embedding_tokens"`
+	TotalTokens      int64  `json:"total_tokens"`
+}
+
+// Add folds other into r, leaving r's key untouched.
+func (r *UsageReportRow) Add(other UsageReportRow) {
+	r.Records += other.Records
+	r.Calls += other.Calls
+	r.PromptTokens += other.PromptTokens
+	r.CompletionTokens += other.CompletionTokens
+	r.CachedTokens += other.CachedTokens
+	r.EmbeddingTokens += other.EmbeddingTokens
+	r.TotalTokens += other.TotalTokens
+}
+
+// UsageReport is the result of a usage report query.
+type UsageReport struct {
+	Query UsageReportQuery `json:"query"`
+	Rows  []UsageReportRow `json:"rows"`
+	Total UsageReportRow   `json:"total"`
+}
+
+// usageReportCSVHeader is the column order of UsageReport.WriteCSV.
+var usageReportCSVHeader = []string{
+	"key", "label", "records", "calls", "prompt_tokens", "completion_tokens",
+	"cached_tokens", "embedding_tokens", "total_tokens",
+}
+
+// WriteCSV writes the report rows as CSV with a header row, followed by a
+// "total" row. The first column is named after the group-by dimension.
+func (r *UsageReport) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	header := append([]string(nil), usageReportCSVHeader...)
+	if r.Query.GroupBy != "" {
+		header[0] = string(r.Query.GroupBy)
+	}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	write := func(row UsageReportRow) error {
+		return cw.Write([]string{
+			row.Key, row.Label,
+			strconv.FormatInt(row.Records, 10),
+			strconv.FormatInt(row.Calls, 10),
+			strconv.FormatInt(row.PromptTokens, 10),
+			strconv.FormatInt(row.CompletionTokens, 10),
+			strconv.FormatInt(row.CachedTokens, 10),
+			strconv.FormatInt(row.EmbeddingTokens, 10),
+			strconv.FormatInt(row.TotalTokens, 10),
+		})
+	}
+	for _, row := range r.Rows {
+		if err := write(row); err != nil {
+			return err
+		}
+	}
+	total := r.Total
+	total.Key, total.Label = "total", ""
+	if err := write(total); err != nil {
+		return err
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// UsageKnowledgeBaseContextKey attributes metered calls to the knowledge
+// base being ingested or searched. See WithUsageKnowledgeBase.
+const UsageKnowledgeBaseContextKey ContextKey = "UsageKnowledgeBaseID"
+
+// WithUsageKnowledgeBase records the knowledge base model calls on ctx work
+// for. An empty id leaves ctx as it is.
+func WithUsageKnowledgeBase(ctx context.Context, kbID string) context.Context {
+	kbID = strings.TrimSpace(kbID)
+	if kbID == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, UsageKnowledgeBaseContextKey, kbID)
+}
+
+// UsageKnowledgeBaseFromContext returns the id set by WithUsageKnowledgeBase.
+func UsageKnowledgeBaseFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	kbID, _ := ctx.Value(UsageKnowledgeBaseContextKey).(string)
+	return kbID
+}
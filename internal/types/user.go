@@ -39,7 +39,8 @@ type UserPreferences struct {
 	LastActiveTenantID *uint64 `json:"last_active_tenant_id,omitempty"`
 
 	// OidcOnlyLogin is set server-side when an account is auto-provisioned
-	// via OIDC with a random password the user never received. The profile
+	// via OIDC, LDAP or SCIM with a random password the user never
+	// received. The profile
 	// UI hides self-service password rotation until the user sets a known
 	// password via ChangePassword (which clears this flag).
 	OidcOnlyLogin *bool `json:"oidc_only_login,omitempty"`
@@ -181,6 +182,29 @@ type OIDCUserInfo struct {
 	Claims   map[string]interface{} `json:"claims,omitempty"`
 }
 
+// LDAPLoginRequest is the body of POST /auth/ldap/login. Username is the
+// directory login name (uid / sAMAccountName, or whatever the configured
+// user filter matches).
+type LDAPLoginRequest struct {
+	Username string `json:"username" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+type LDAPConfigResponse struct {
+	Success             bool   `json:"success"`
+	Enabled             bool   `json:"enabled"`
+	ProviderDisplayName string `json:"provider_display_name,omitempty"`
+}
+
+// LDAPUserInfo is the directory entry a successful LDAP bind resolved to.
+// Groups holds the distinguished names of the groups the entry belongs to.
+type LDAPUserInfo struct {
+	DN       string   `json:"dn"`
+	Username string   `json:"username"`
+	Email    string   `json:"email"`
+	Groups   []string `json:"groups"`
+}
+
 // RegisterRequest represents a registration request
 type RegisterRequest struct {
 	Username string `json:"username" binding:"required,min=2,max=50"`
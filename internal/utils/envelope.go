@@ -0,0 +1,299 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Envelope encryption primitives shared by the file service wrapper and the
+// chunk content hooks. Data is sealed with a per-tenant data key; the keys
+// themselves are wrapped by a master key outside this package.
+
+// EnvelopeContentPrefix marks a string sealed with a tenant data key:
+// enc:t1:<key version>:<base64url(nonce || ciphertext)>. It is distinct from
+// EncPrefix, which marks values sealed with SYSTEM_AES_KEY.
+const EnvelopeContentPrefix = "enc:t1:"
+
+// envelopeStreamMagic starts every encrypted file. The header that follows is
+// the tenant ID (uint64), the data key version (uint32) and an 8-byte random
+// nonce prefix, all big-endian.
+var envelopeStreamMagic = []byte("WKENC\x01")
+
+// EnvelopeMagicSize is how many leading bytes IsEnvelopeStream needs.
+const EnvelopeMagicSize = 6
+
+const (
+	envelopeHeaderSize = EnvelopeMagicSize + 8 + 4 + 8
+	// envelopeSegmentSize is the plaintext size of one sealed segment. Files
+	// are sealed segment by segment so they can be decrypted while streaming.
+	envelopeSegmentSize = 64 * 1024
+	envelopeTagSize     = 16
+)
+
+// ErrEnvelopeCorrupt is returned when an encrypted stream or value fails to
+// parse or authenticate.
+var ErrEnvelopeCorrupt = errors.New("envelope: corrupt or tampered ciphertext")
+
+// EnvelopeHeader identifies the key an encrypted file was sealed with.
+type EnvelopeHeader struct {
+	TenantID   uint64
+	KeyVersion int
+	prefix     [8]byte
+}
+
+func (h EnvelopeHeader) bytes() []byte {
+	out := make([]byte, 0, envelopeHeaderSize)
+	out = append(out, envelopeStreamMagic...)
+	out = binary.BigEndian.AppendUint64(out, h.TenantID)
+	out = binary.BigEndian.AppendUint32(out, uint32(h.KeyVersion))
+	return append(out, h.prefix[:]...)
+}
+
+// IsEnvelopeStream reports whether head, the first bytes of a file, starts
+// with the encrypted file magic.
+func IsEnvelopeStream(head []byte) bool {
+	return bytes.HasPrefix(head, envelopeStreamMagic)
+}
+
+// EncryptEnvelopeStream seals src with key and writes the encrypted file to
+// dst. Every segment is authenticated together with the header and a final
+// flag, so reordering, truncating or re-keying the file is detected.
+func EncryptEnvelopeStream(dst io.Writer, src io.Reader, key []byte, tenantID uint64, keyVersion int) error {
+	aead, err := envelopeAEAD(key)
+	if err != nil {
+		return err
+	}
+	h := EnvelopeHeader{TenantID: tenantID, KeyVersion: keyVersion}
+	if _, err := io.ReadFull(rand.Reader, h.prefix[:]); err != nil {
+		return err
+	}
+	header := h.bytes()
+	if _, err := dst.Write(header); err != nil {
+		return err
+	}
+
+	// Read one segment ahead so the last segment can be flagged as final.
+	cur := make([]byte, envelopeSegmentSize)
+	next := make([]byte, envelopeSegmentSize)
+	n, err := io.ReadFull(src, cur)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return err
+	}
+	sealed := make([]byte, 0, envelopeSegmentSize+envelopeTagSize)
+	for counter := uint32(0); ; counter++ {
+		final := n < envelopeSegmentSize
+		var m int
+		if !final {
+			m, err = io.ReadFull(src, next)
+			if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+				return err
+			}
+			final = m == 0
+		}
+		sealed = aead.Seal(sealed[:0], segmentNonce(h.prefix, counter), cur[:n], segmentAAD(header, final))
+		if _, err := dst.Write(sealed); err != nil {
+			return err
+		}
+		if final {
+			return nil
+		}
+		if counter == ^uint32(0) {
+			return errors.New("envelope: file too large")
+		}
+		cur, next, n = next, cur, m
+	}
+}
+
+// EncryptEnvelopeBytes seals data in memory; see EncryptEnvelopeStream.
+func EncryptEnvelopeBytes(data, key []byte, tenantID uint64, keyVersion int) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.Grow(len(data) + envelopeHeaderSize + (len(data)/envelopeSegmentSize+1)*envelopeTagSize)
+	if err := EncryptEnvelopeStream(&buf, bytes.NewReader(data), key, tenantID, keyVersion); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// ReadEnvelopeHeader consumes the header of an encrypted file from r.
+func ReadEnvelopeHeader(r io.Reader) (EnvelopeHeader, error) {
+	raw := make([]byte, envelopeHeaderSize)
+	if _, err := io.ReadFull(r, raw); err != nil {
+		return EnvelopeHeader{}, ErrEnvelopeCorrupt
+	}
+	if !IsEnvelopeStream(raw) {
+		return EnvelopeHeader{}, ErrEnvelopeCorrupt
+	}
+	h := EnvelopeHeader{
+		TenantID:   binary.BigEndian.Uint64(raw[6:14]),
+		KeyVersion: int(binary.BigEndian.Uint32(raw[14:18])),
+	}
+	copy(h.prefix[:], raw[18:])
+	return h, nil
+}
+
+// NewEnvelopeDecryptReader returns a reader yielding the plaintext of an
+// encrypted file whose header was already read from r with
+// ReadEnvelopeHeader. Authentication failures surface as ErrEnvelopeCorrupt
+// from Read; no unauthenticated plaintext is ever returned.
+func NewEnvelopeDecryptReader(r io.Reader, h EnvelopeHeader, key []byte) (io.Reader, error) {
+	aead, err := envelopeAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	return &envelopeReader{
+		src:    bufio.NewReaderSize(r, envelopeSegmentSize+envelopeTagSize+1),
+		aead:   aead,
+		header: h.bytes(),
+		prefix: h.prefix,
+		buf:    make([]byte, envelopeSegmentSize+envelopeTagSize),
+	}, nil
+}
+
+type envelopeReader struct {
+	src     *bufio.Reader
+	aead    cipher.AEAD
+	header  []byte
+	prefix  [8]byte
+	counter uint32
+	buf     []byte
+	plain   []byte
+	done    bool
+	err     error
+}
+
+func (r *envelopeReader) Read(p []byte) (int, error) {
+	for len(r.plain) == 0 {
+		if r.err != nil {
+			return 0, r.err
+		}
+		if r.done {
+			return 0, io.EOF
+		}
+		r.err = r.nextSegment()
+	}
+	n := copy(p, r.plain)
+	r.plain = r.plain[n:]
+	return n, nil
+}
+
+func (r *envelopeReader) nextSegment() error {
+	n, err := io.ReadFull(r.src, r.buf)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return err
+	}
+	if n < envelopeTagSize {
+		return ErrEnvelopeCorrupt
+	}
+	final := n < len(r.buf)
+	if !final {
+		if _, perr := r.src.Peek(1); errors.Is(perr, io.EOF) {
+			final = true
+		} else if perr != nil {
+			return perr
+		}
+	}
+	plain, err := r.aead.Open(r.buf[:0], segmentNonce(r.prefix, r.counter), r.buf[:n], segmentAAD(r.header, final))
+	if err != nil {
+		return ErrEnvelopeCorrupt
+	}
+	r.counter++
+	r.plain = plain
+	r.done = final
+	return nil
+}
+
+// SealEnvelopeString seals a short value such as chunk content. aad binds the
+// value to its owner (e.g. the tenant) so it cannot be moved between rows of
+// different tenants.
+func SealEnvelopeString(plaintext string, key []byte, keyVersion int, aad string) (string, error) {
+	aead, err := envelopeAEAD(key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(plaintext), []byte(aad))
+	return EnvelopeContentPrefix + strconv.Itoa(keyVersion) + ":" + base64.RawURLEncoding.EncodeToString(sealed), nil
+}
+
+// EnvelopeStringVersion returns the data key version a sealed value needs,
+// or ok=false when value is not sealed.
+func EnvelopeStringVersion(value string) (version int, ok bool, err error) {
+	if !strings.HasPrefix(value, EnvelopeContentPrefix) {
+		return 0, false, nil
+	}
+	rest := strings.TrimPrefix(value, EnvelopeContentPrefix)
+	v, _, found := strings.Cut(rest, ":")
+	if !found {
+		return 0, true, ErrEnvelopeCorrupt
+	}
+	version, err = strconv.Atoi(v)
+	if err != nil {
+		return 0, true, ErrEnvelopeCorrupt
+	}
+	return version, true, nil
+}
+
+// OpenEnvelopeString reverses SealEnvelopeString. key must be the data key
+// of the version reported by EnvelopeStringVersion.
+func OpenEnvelopeString(value string, key []byte, aad string) (string, error) {
+	_, encoded, found := strings.Cut(strings.TrimPrefix(value, EnvelopeContentPrefix), ":")
+	if !found {
+		return "", ErrEnvelopeCorrupt
+	}
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", ErrEnvelopeCorrupt
+	}
+	aead, err := envelopeAEAD(key)
+	if err != nil {
+		return "", err
+	}
+	if len(data) < aead.NonceSize()+envelopeTagSize {
+		return "", ErrEnvelopeCorrupt
+	}
+	plain, err := aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], []byte(aad))
+	if err != nil {
+		return "", ErrEnvelopeCorrupt
+	}
+	return string(plain), nil
+}
+
+func envelopeAEAD(key []byte) (cipher.AEAD, error) {
+	if len(key) != 32 {
+		return nil, fmt.Errorf("envelope: data key must be 32 bytes, got %d", len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func segmentNonce(prefix [8]byte, counter uint32) []byte {
+	nonce := make([]byte, 12)
+	copy(nonce, prefix[:])
+	binary.BigEndian.PutUint32(nonce[8:], counter)
+	return nonce
+}
+
+func segmentAAD(header []byte, final bool) []byte {
+	aad := make([]byte, len(header)+1)
+	copy(aad, header)
+	if final {
+		aad[len(header)] = 1
+	}
+	return aad
+}
@@ -0,0 +1,113 @@
+package utils
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"io"
+	"testing"
+)
+
+func testDataKey(t *testing.T) []byte {
+	t.Helper()
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func decryptEnvelope(t *testing.T, data, key []byte) ([]byte, EnvelopeHeader, error) {
+	t.Helper()
+	r := bytes.NewReader(data)
+	h, err := ReadEnvelopeHeader(r)
+	if err != nil {
+		return nil, h, err
+	}
+	dec, err := NewEnvelopeDecryptReader(r, h, key)
+	if err != nil {
+		return nil, h, err
+	}
+	out, err := io.ReadAll(dec)
+	return out, h, err
+}
+
+func TestEnvelopeStreamRoundTrip(t *testing.T) {
+	key := testDataKey(t)
+	for _, size := range []int{0, 1, envelopeSegmentSize - 1, envelopeSegmentSize, 3*envelopeSegmentSize + 17} {
+		plain := make([]byte, size)
+		_, _ = rand.Read(plain)
+		sealed, err := EncryptEnvelopeBytes(plain, key, 42, 3)
+		if err != nil {
+			t.Fatalf("size %d: encrypt: %v", size, err)
+		}
+		if !IsEnvelopeStream(sealed) {
+			t.Fatalf("size %d: missing magic", size)
+		}
+		got, h, err := decryptEnvelope(t, sealed, key)
+		if err != nil {
+			t.Fatalf("size %d: decrypt: %v", size, err)
+		}
+		if h.TenantID != 42 || h.KeyVersion != 3 {
+			t.Fatalf("size %d: header = %+v", size, h)
+		}
+		if !bytes.Equal(got, plain) {
+			t.Fatalf("size %d: plaintext mismatch", size)
+		}
+	}
+}
+
+func TestEnvelopeStreamDetectsTampering(t *testing.T) {
+	key := testDataKey(t)
+	plain := bytes.Repeat([]byte("weknora"), envelopeSegmentSize/3)
+	sealed, err := EncryptEnvelopeBytes(plain, key, 7, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := map[string][]byte{
+		// Dropping the last segment leaves a non-final segment at EOF.
+		"truncated": sealed[:envelopeHeaderSize+envelopeSegmentSize+envelopeTagSize],
+		"bit flip": func() []byte {
+			b := bytes.Clone(sealed)
+			b[len(b)-1] ^= 1
+			return b
+		}(),
+		// Rewriting the tenant in the header breaks every segment's AAD.
+		"re-tenanted": func() []byte {
+			b := bytes.Clone(sealed)
+			b[13] ^= 1
+			return b
+		}(),
+	}
+	for name, data := range cases {
+		if _, _, err := decryptEnvelope(t, data, key); !errors.Is(err, ErrEnvelopeCorrupt) {
+			t.Fatalf("%s: err = %v, want ErrEnvelopeCorrupt", name, err)
+		}
+	}
+	if _, _, err := decryptEnvelope(t, sealed, testDataKey(t)); !errors.Is(err, ErrEnvelopeCorrupt) {
+		t.Fatalf("wrong key: err = %v, want ErrEnvelopeCorrupt", err)
+	}
+}
+
+func TestEnvelopeStringRoundTrip(t *testing.T) {
+	key := testDataKey(t)
+	sealed, err := SealEnvelopeString("机密内容", key, 5, "chunk:1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	version, ok, err := EnvelopeStringVersion(sealed)
+	if err != nil || !ok || version != 5 {
+		t.Fatalf("version = %d, %v, %v", version, ok, err)
+	}
+	plain, err := OpenEnvelopeString(sealed, key, "chunk:1")
+	if err != nil || plain != "机密内容" {
+		t.Fatalf("open = %q, %v", plain, err)
+	}
+	if _, err := OpenEnvelopeString(sealed, key, "chunk:2"); !errors.Is(err, ErrEnvelopeCorrupt) {
+		t.Fatalf("open with other AAD: err = %v", err)
+	}
+	if _, ok, _ := EnvelopeStringVersion("plain text"); ok {
+		t.Fatal("plain text reported as sealed")
+	}
+}
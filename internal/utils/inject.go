@@ -152,10 +152,12 @@ type sqlValidator struct {
 
 // SearchScope describes one allowed knowledge scope for SQL query injection.
 // Empty KnowledgeIDs and TagIDs means the whole KB is in scope.
+// ExcludeKnowledgeIDs removes documents from the scope in every case.
 type SearchScope struct {
-	KnowledgeBaseID string
-	KnowledgeIDs    []string
-	TagIDs          []string
+	KnowledgeBaseID     string
+	KnowledgeIDs        []string
+	TagIDs              []string
+	ExcludeKnowledgeIDs []string
 }
 
 // ParseSQL parses a SQL statement using pg_query_go and extracts table names, select fields, and where fields
@@ -1159,6 +1161,12 @@ func buildScopeClause(alias, knowledgeIDColumn string, scope SearchScope) string
 			alias, knowledgeIDColumn, strings.Join(quoteStringSlice(scope.TagIDs), ", "),
 		))
 	}
+	if len(scope.ExcludeKnowledgeIDs) > 0 {
+		conditions = append(conditions, fmt.Sprintf(
+			"%s.%s NOT IN (%s)",
+			alias, knowledgeIDColumn, strings.Join(quoteStringSlice(scope.ExcludeKnowledgeIDs), ", "),
+		))
+	}
 	if len(conditions) == 1 {
 		return conditions[0]
 	}
@@ -526,6 +526,25 @@ func TestValidateAndSecureSQL_ScopeCombinesDocumentAndTagFilters(t *testing.T) {
 	}
 }
 
+// Documents hidden by ACLs stay out of a whole-KB scope.
+func TestValidateAndSecureSQL_ScopeExcludesKnowledge(t *testing.T) {
+	securedSQL, validation, err := ValidateAndSecureSQL(
+		"SELECT id, title FROM knowledges",
+		WithSearchScopes([]SearchScope{
+			{KnowledgeBaseID: "kb-1", ExcludeKnowledgeIDs: []string{"doc-secret"}},
+		}),
+	)
+	if err != nil {
+		t.Fatalf("ValidateAndSecureSQL() error = %v", err)
+	}
+	if !validation.Valid {
+		t.Fatalf("expected validation to pass, got %#v", validation.Errors)
+	}
+	if !strings.Contains(securedSQL, "knowledges.id NOT IN ('doc-secret')") {
+		t.Fatalf("secured SQL must exclude the hidden document:\n%s", securedSQL)
+	}
+}
+
 // TestValidateSQL_JSONNodeBypass verifies that PG17 SQL/JSON expression nodes
 // cannot be used to smuggle dangerous functions past the blacklist. These were
 // previously accepted because validateNode had no handler for them and fell
@@ -0,0 +1,25 @@
+-- Knowledge graph communities (Lite). Mirrors migrations/versioned/000085.
+-- Row ids are generated in Go, so there is no server-side default here.
+
+CREATE TABLE IF NOT EXISTS graph_communities (
+    id VARCHAR(36) PRIMARY KEY,
+    tenant_id INTEGER NOT NULL,
+    knowledge_base_id VARCHAR(36) NOT NULL,
+    level INTEGER NOT NULL DEFAULT 0,
+    parent_id VARCHAR(36) NOT NULL DEFAULT '',
+    title VARCHAR(512) NOT NULL DEFAULT '',
+    summary TEXT NOT NULL DEFAULT '',
+    findings TEXT,
+    rating REAL NOT NULL DEFAULT 0,
+    entities TEXT,
+    entity_count INTEGER NOT NULL DEFAULT 0,
+    relation_count INTEGER NOT NULL DEFAULT 0,
+    chunk_ids TEXT,
+    embedding_model_id VARCHAR(64) NOT NULL DEFAULT '',
+    vector BLOB,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_graph_communities_kb
+    ON graph_communities (tenant_id, knowledge_base_id);
@@ -0,0 +1,37 @@
+-- Knowledge graph entity resolution (Lite). Mirrors migrations/versioned/000086.
+-- Row ids are generated in Go, so there is no server-side default here.
+
+CREATE TABLE IF NOT EXISTS graph_merge_candidates (
+    id VARCHAR(36) PRIMARY KEY,
+    tenant_id INTEGER NOT NULL,
+    knowledge_base_id VARCHAR(36) NOT NULL,
+    pair_key VARCHAR(1024) NOT NULL,
+    canonical VARCHAR(512) NOT NULL,
+    alias VARCHAR(512) NOT NULL,
+    score REAL NOT NULL DEFAULT 0,
+    reason VARCHAR(32) NOT NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'pending',
+    resolved_by VARCHAR(36) NOT NULL DEFAULT '',
+    resolved_at DATETIME,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_graph_merge_candidates_pair
+    ON graph_merge_candidates (tenant_id, knowledge_base_id, pair_key);
+CREATE INDEX IF NOT EXISTS idx_graph_merge_candidates_status
+    ON graph_merge_candidates (status);
+
+CREATE TABLE IF NOT EXISTS graph_entity_aliases (
+    id VARCHAR(36) PRIMARY KEY,
+    tenant_id INTEGER NOT NULL,
+    knowledge_base_id VARCHAR(36) NOT NULL,
+    alias_key VARCHAR(512) NOT NULL,
+    alias VARCHAR(512) NOT NULL,
+    canonical VARCHAR(512) NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_graph_entity_aliases_key
+    ON graph_entity_aliases (tenant_id, knowledge_base_id, alias_key);
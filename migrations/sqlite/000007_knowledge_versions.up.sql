@@ -0,0 +1,29 @@
+-- Knowledge content versions (Lite). Mirrors migrations/versioned/000087.
+-- Row ids are generated in Go, so there is no server-side default here.
+
+CREATE TABLE IF NOT EXISTS knowledge_versions (
+    id VARCHAR(36) PRIMARY KEY,
+    tenant_id INTEGER NOT NULL,
+    knowledge_id VARCHAR(36) NOT NULL,
+    version INTEGER NOT NULL,
+    source VARCHAR(16) NOT NULL DEFAULT '',
+    restored_from INTEGER NOT NULL DEFAULT 0,
+    title VARCHAR(512) NOT NULL DEFAULT '',
+    file_name VARCHAR(1024) NOT NULL DEFAULT '',
+    file_type VARCHAR(50) NOT NULL DEFAULT '',
+    file_size INTEGER NOT NULL DEFAULT 0,
+    file_hash VARCHAR(64) NOT NULL DEFAULT '',
+    file_path TEXT NOT NULL DEFAULT '',
+    content_hash VARCHAR(64) NOT NULL DEFAULT '',
+    content_length INTEGER NOT NULL DEFAULT 0,
+    chunk_count INTEGER NOT NULL DEFAULT 0,
+    markdown TEXT NOT NULL DEFAULT '',
+    chunks TEXT NOT NULL DEFAULT '[]',
+    editor_id VARCHAR(64) NOT NULL DEFAULT '',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_knowledge_versions_version
+    ON knowledge_versions (knowledge_id, version);
+CREATE INDEX IF NOT EXISTS idx_knowledge_versions_tenant_id
+    ON knowledge_versions (tenant_id);
@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS idx_chunk_fingerprints_knowledge_id;
+DROP INDEX IF EXISTS idx_chunk_fingerprints_kb;
+DROP TABLE IF EXISTS chunk_fingerprints;
+DROP INDEX IF EXISTS idx_knowledge_fingerprint_bands_lookup;
+DROP TABLE IF EXISTS knowledge_fingerprint_bands;
+DROP INDEX IF EXISTS idx_knowledge_fingerprints_alias_of;
+DROP INDEX IF EXISTS idx_knowledge_fingerprints_kb;
+DROP TABLE IF EXISTS knowledge_fingerprints;
+ALTER TABLE knowledge_bases DROP COLUMN dedup_config;
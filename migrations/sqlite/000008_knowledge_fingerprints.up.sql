@@ -0,0 +1,44 @@
+-- Near-duplicate fingerprints (Lite). Mirrors migrations/versioned/000088.
+
+ALTER TABLE knowledge_bases ADD COLUMN dedup_config TEXT;
+
+CREATE TABLE IF NOT EXISTS knowledge_fingerprints (
+    knowledge_id VARCHAR(36) PRIMARY KEY,
+    tenant_id INTEGER NOT NULL,
+    knowledge_base_id VARCHAR(36) NOT NULL,
+    alias_of VARCHAR(36) NOT NULL DEFAULT '',
+    content_hash VARCHAR(64) NOT NULL DEFAULT '',
+    min_hash TEXT NOT NULL DEFAULT '[]',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_knowledge_fingerprints_kb
+    ON knowledge_fingerprints (tenant_id, knowledge_base_id);
+CREATE INDEX IF NOT EXISTS idx_knowledge_fingerprints_alias_of
+    ON knowledge_fingerprints (alias_of);
+
+CREATE TABLE IF NOT EXISTS knowledge_fingerprint_bands (
+    knowledge_id VARCHAR(36) NOT NULL,
+    band_key BIGINT NOT NULL,
+    tenant_id INTEGER NOT NULL,
+    knowledge_base_id VARCHAR(36) NOT NULL,
+    PRIMARY KEY (knowledge_id, band_key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_knowledge_fingerprint_bands_lookup
+    ON knowledge_fingerprint_bands (knowledge_base_id, band_key);
+
+CREATE TABLE IF NOT EXISTS chunk_fingerprints (
+    chunk_id VARCHAR(36) PRIMARY KEY,
+    tenant_id INTEGER NOT NULL,
+    knowledge_base_id VARCHAR(36) NOT NULL,
+    knowledge_id VARCHAR(36) NOT NULL,
+    chunk_index INTEGER NOT NULL DEFAULT 0,
+    sim_hash BIGINT NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS idx_chunk_fingerprints_kb
+    ON chunk_fingerprints (tenant_id, knowledge_base_id);
+CREATE INDEX IF NOT EXISTS idx_chunk_fingerprints_knowledge_id
+    ON chunk_fingerprints (knowledge_id);
@@ -0,0 +1,9 @@
+ALTER TABLE knowledge_bases DROP COLUMN freshness_config;
+DROP INDEX IF EXISTS idx_knowledges_review_by;
+DROP INDEX IF EXISTS idx_knowledges_valid_until;
+ALTER TABLE knowledges DROP COLUMN freshness_notified_at;
+ALTER TABLE knowledges DROP COLUMN freshness_source;
+ALTER TABLE knowledges DROP COLUMN reviewed_at;
+ALTER TABLE knowledges DROP COLUMN review_by;
+ALTER TABLE knowledges DROP COLUMN valid_until;
+ALTER TABLE knowledges DROP COLUMN valid_from;
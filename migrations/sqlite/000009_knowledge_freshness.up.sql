@@ -0,0 +1,15 @@
+-- Knowledge freshness (Lite). Mirrors migrations/versioned/000089.
+
+ALTER TABLE knowledges ADD COLUMN valid_from DATETIME;
+ALTER TABLE knowledges ADD COLUMN valid_until DATETIME;
+ALTER TABLE knowledges ADD COLUMN review_by DATETIME;
+ALTER TABLE knowledges ADD COLUMN reviewed_at DATETIME;
+ALTER TABLE knowledges ADD COLUMN freshness_source VARCHAR(16) NOT NULL DEFAULT '';
+ALTER TABLE knowledges ADD COLUMN freshness_notified_at DATETIME;
+
+CREATE INDEX IF NOT EXISTS idx_knowledges_valid_until
+    ON knowledges (knowledge_base_id, valid_until);
+CREATE INDEX IF NOT EXISTS idx_knowledges_review_by
+    ON knowledges (knowledge_base_id, review_by);
+
+ALTER TABLE knowledge_bases ADD COLUMN freshness_config TEXT;
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_im_thread_archives_tenant_id;
+DROP INDEX IF EXISTS idx_im_thread_archives_thread;
+DROP TABLE IF EXISTS im_thread_archives;
+ALTER TABLE im_channels DROP COLUMN thread_archive;
@@ -0,0 +1,27 @@
+-- IM thread archiving (Lite). Mirrors migrations/versioned/000090.
+-- Row ids are generated in Go, so there is no server-side default here.
+
+ALTER TABLE im_channels ADD COLUMN thread_archive TEXT NOT NULL DEFAULT '{}';
+
+CREATE TABLE IF NOT EXISTS im_thread_archives (
+    id VARCHAR(36) PRIMARY KEY,
+    tenant_id INTEGER NOT NULL,
+    im_channel_id VARCHAR(36) NOT NULL,
+    platform VARCHAR(20) NOT NULL,
+    chat_id VARCHAR(128) NOT NULL DEFAULT '',
+    thread_id VARCHAR(128) NOT NULL,
+    knowledge_base_id VARCHAR(36) NOT NULL,
+    knowledge_id VARCHAR(36) NOT NULL,
+    faq_knowledge_base_id VARCHAR(36) NOT NULL DEFAULT '',
+    faq_entry_id INTEGER NOT NULL DEFAULT 0,
+    message_count INTEGER NOT NULL DEFAULT 0,
+    archived_by VARCHAR(128) NOT NULL DEFAULT '',
+    trigger_type VARCHAR(16) NOT NULL DEFAULT '',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_im_thread_archives_thread
+    ON im_thread_archives (im_channel_id, chat_id, thread_id);
+CREATE INDEX IF NOT EXISTS idx_im_thread_archives_tenant_id
+    ON im_thread_archives (tenant_id);
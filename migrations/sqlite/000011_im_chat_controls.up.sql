@@ -0,0 +1,56 @@
+-- Richer IM slash-commands (Lite). Mirrors migrations/versioned/000091.
+-- Row ids are generated in Go, so there is no server-side default here.
+
+CREATE TABLE IF NOT EXISTS im_user_bindings (
+    id VARCHAR(36) PRIMARY KEY,
+    tenant_id INTEGER NOT NULL,
+    im_channel_id VARCHAR(36) NOT NULL,
+    im_user_id VARCHAR(128) NOT NULL,
+    user_id VARCHAR(36) NOT NULL,
+    created_by VARCHAR(36) NOT NULL DEFAULT '',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_im_user_bindings_user
+    ON im_user_bindings (im_channel_id, im_user_id);
+CREATE INDEX IF NOT EXISTS idx_im_user_bindings_tenant_id
+    ON im_user_bindings (tenant_id);
+
+CREATE TABLE IF NOT EXISTS im_chat_settings (
+    id VARCHAR(36) PRIMARY KEY,
+    tenant_id INTEGER NOT NULL,
+    im_channel_id VARCHAR(36) NOT NULL,
+    chat_key VARCHAR(160) NOT NULL,
+    agent_id VARCHAR(36) NOT NULL DEFAULT '',
+    knowledge_base_ids TEXT NOT NULL DEFAULT '[]',
+    updated_by VARCHAR(128) NOT NULL DEFAULT '',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_im_chat_settings_chat
+    ON im_chat_settings (im_channel_id, chat_key);
+CREATE INDEX IF NOT EXISTS idx_im_chat_settings_tenant_id
+    ON im_chat_settings (tenant_id);
+
+CREATE TABLE IF NOT EXISTS im_message_feedback (
+    id VARCHAR(36) PRIMARY KEY,
+    tenant_id INTEGER NOT NULL,
+    im_channel_id VARCHAR(36) NOT NULL,
+    platform VARCHAR(20) NOT NULL,
+    im_user_id VARCHAR(128) NOT NULL,
+    session_id VARCHAR(36) NOT NULL,
+    message_id VARCHAR(36) NOT NULL,
+    rating VARCHAR(8) NOT NULL,
+    comment TEXT NOT NULL DEFAULT '',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_im_message_feedback_user
+    ON im_message_feedback (message_id, im_user_id);
+CREATE INDEX IF NOT EXISTS idx_im_message_feedback_channel
+    ON im_message_feedback (im_channel_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_im_message_feedback_tenant_id
+    ON im_message_feedback (tenant_id);
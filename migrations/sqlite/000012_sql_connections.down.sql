@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_sql_connections_deleted_at;
+DROP INDEX IF EXISTS idx_sql_connections_tenant_id;
+DROP TABLE IF EXISTS sql_connections;
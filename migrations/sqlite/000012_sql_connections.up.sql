@@ -0,0 +1,17 @@
+-- External SQL connections (Lite). Mirrors migrations/versioned/000092.
+
+CREATE TABLE IF NOT EXISTS sql_connections (
+    id VARCHAR(36) NOT NULL PRIMARY KEY,
+    tenant_id INTEGER NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    dialect VARCHAR(20) NOT NULL,
+    parameters TEXT,
+    options TEXT,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    deleted_at DATETIME NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_sql_connections_tenant_id ON sql_connections (tenant_id);
+CREATE INDEX IF NOT EXISTS idx_sql_connections_deleted_at ON sql_connections (deleted_at);
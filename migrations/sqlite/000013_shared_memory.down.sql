@@ -0,0 +1,7 @@
+DELETE FROM memory_item_embeddings WHERE subject_id LIKE 'shared:%';
+DELETE FROM memory_items WHERE subject_id LIKE 'shared:%';
+DELETE FROM memory_subjects WHERE subject_id LIKE 'shared:%';
+ALTER TABLE memory_items DROP COLUMN reviewed_at;
+ALTER TABLE memory_items DROP COLUMN reviewed_by;
+ALTER TABLE memory_items DROP COLUMN promoted_from;
+ALTER TABLE memory_items DROP COLUMN promoted_by;
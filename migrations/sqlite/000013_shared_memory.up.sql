@@ -0,0 +1,5 @@
+-- Team-shared memory: provenance of items in the "shared:*" subjects.
+ALTER TABLE memory_items ADD COLUMN promoted_by VARCHAR(512);
+ALTER TABLE memory_items ADD COLUMN promoted_from VARCHAR(36);
+ALTER TABLE memory_items ADD COLUMN reviewed_by VARCHAR(512);
+ALTER TABLE memory_items ADD COLUMN reviewed_at DATETIME;
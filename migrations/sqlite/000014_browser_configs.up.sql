@@ -0,0 +1,16 @@
+-- Headless browser services (Lite). Mirrors migrations/versioned/000094.
+
+CREATE TABLE IF NOT EXISTS browser_configs (
+    id VARCHAR(36) NOT NULL PRIMARY KEY,
+    tenant_id INTEGER NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    parameters TEXT,
+    options TEXT,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    deleted_at DATETIME NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_browser_configs_tenant_id ON browser_configs (tenant_id);
+CREATE INDEX IF NOT EXISTS idx_browser_configs_deleted_at ON browser_configs (deleted_at);
@@ -0,0 +1,39 @@
+-- Document-level access control (Lite). Mirrors migrations/versioned/000095.
+
+CREATE TABLE IF NOT EXISTS knowledge_acl_entries (
+    id VARCHAR(36) NOT NULL PRIMARY KEY,
+    tenant_id INTEGER NOT NULL,
+    knowledge_id VARCHAR(36) NOT NULL,
+    principal_type VARCHAR(20) NOT NULL,
+    principal_id VARCHAR(255) NOT NULL,
+    source VARCHAR(16) NOT NULL DEFAULT 'manual',
+    created_by VARCHAR(36) NOT NULL DEFAULT '',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_knowledge_acl_entries_principal
+    ON knowledge_acl_entries (knowledge_id, principal_type, principal_id, source);
+CREATE INDEX IF NOT EXISTS idx_knowledge_acl_entries_tenant_id ON knowledge_acl_entries (tenant_id);
+
+CREATE TABLE IF NOT EXISTS user_groups (
+    id VARCHAR(36) NOT NULL PRIMARY KEY,
+    tenant_id INTEGER NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    external_id VARCHAR(255) NOT NULL DEFAULT '',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_groups_tenant_name ON user_groups (tenant_id, name);
+CREATE INDEX IF NOT EXISTS idx_user_groups_tenant_external_id ON user_groups (tenant_id, external_id);
+
+CREATE TABLE IF NOT EXISTS user_group_members (
+    group_id VARCHAR(36) NOT NULL,
+    user_id VARCHAR(36) NOT NULL,
+    tenant_id INTEGER NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (group_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_group_members_user_id ON user_group_members (user_id);
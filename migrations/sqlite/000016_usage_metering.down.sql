@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_usage_budgets_scope;
+DROP TABLE IF EXISTS usage_budgets;
+DROP INDEX IF EXISTS idx_usage_records_tenant_period_key;
+DROP INDEX IF EXISTS idx_usage_records_tenant_day;
+DROP TABLE IF EXISTS usage_records;
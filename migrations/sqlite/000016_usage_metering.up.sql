@@ -0,0 +1,40 @@
+-- Usage metering ledger and monthly token budgets (Lite). Mirrors migrations/versioned/000096.
+
+CREATE TABLE IF NOT EXISTS usage_records (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    tenant_id INTEGER NOT NULL,
+    user_id VARCHAR(36) NOT NULL DEFAULT '',
+    api_key_id INTEGER NOT NULL DEFAULT 0,
+    agent_id VARCHAR(36) NOT NULL DEFAULT '',
+    knowledge_base_id VARCHAR(36) NOT NULL DEFAULT '',
+    session_id VARCHAR(36) NOT NULL DEFAULT '',
+    kind VARCHAR(16) NOT NULL,
+    model_id VARCHAR(64) NOT NULL DEFAULT '',
+    model_name VARCHAR(255) NOT NULL DEFAULT '',
+    prompt_tokens INTEGER NOT NULL DEFAULT 0,
+    completion_tokens INTEGER NOT NULL DEFAULT 0,
+    cached_tokens INTEGER NOT NULL DEFAULT 0,
+    embedding_tokens INTEGER NOT NULL DEFAULT 0,
+    calls INTEGER NOT NULL DEFAULT 0,
+    period VARCHAR(7) NOT NULL,
+    day VARCHAR(10) NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_usage_records_tenant_day ON usage_records (tenant_id, day);
+CREATE INDEX IF NOT EXISTS idx_usage_records_tenant_period_key ON usage_records (tenant_id, period, api_key_id);
+
+CREATE TABLE IF NOT EXISTS usage_budgets (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    tenant_id INTEGER NOT NULL,
+    api_key_id INTEGER NOT NULL DEFAULT 0,
+    monthly_token_limit INTEGER NOT NULL,
+    warn_percent INTEGER NOT NULL DEFAULT 80,
+    hard_stop BOOLEAN NOT NULL DEFAULT 0,
+    warned_period VARCHAR(7) NOT NULL DEFAULT '',
+    exceeded_period VARCHAR(7) NOT NULL DEFAULT '',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_usage_budgets_scope ON usage_budgets (tenant_id, api_key_id);
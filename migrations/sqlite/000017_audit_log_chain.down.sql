@@ -0,0 +1,7 @@
+DROP TRIGGER IF EXISTS trg_audit_logs_append_only;
+DROP TABLE IF EXISTS audit_log_sink_cursors;
+DROP TABLE IF EXISTS audit_log_chain_heads;
+DROP INDEX IF EXISTS idx_audit_logs_tenant_chain;
+ALTER TABLE audit_logs DROP COLUMN hash;
+ALTER TABLE audit_logs DROP COLUMN prev_hash;
+ALTER TABLE audit_logs DROP COLUMN chain_seq;
@@ -0,0 +1,31 @@
+-- Tamper-evident audit log and SIEM export cursors (Lite). Mirrors migrations/versioned/000097.
+
+ALTER TABLE audit_logs ADD COLUMN chain_seq INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE audit_logs ADD COLUMN prev_hash VARCHAR(64) NOT NULL DEFAULT '';
+ALTER TABLE audit_logs ADD COLUMN hash VARCHAR(64) NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_audit_logs_tenant_chain ON audit_logs (tenant_id, chain_seq);
+
+CREATE TABLE IF NOT EXISTS audit_log_chain_heads (
+    tenant_id INTEGER PRIMARY KEY,
+    last_seq INTEGER NOT NULL DEFAULT 0,
+    last_hash VARCHAR(64) NOT NULL DEFAULT '',
+    pruned_seq INTEGER NOT NULL DEFAULT 0,
+    pruned_hash VARCHAR(64) NOT NULL DEFAULT '',
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS audit_log_sink_cursors (
+    sink VARCHAR(32) PRIMARY KEY,
+    last_id INTEGER NOT NULL DEFAULT 0,
+    lease_owner VARCHAR(128) NOT NULL DEFAULT '',
+    lease_until DATETIME,
+    last_error VARCHAR(512) NOT NULL DEFAULT '',
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TRIGGER IF NOT EXISTS trg_audit_logs_append_only
+BEFORE UPDATE ON audit_logs
+BEGIN
+    SELECT RAISE(ABORT, 'audit_logs is append-only');
+END;
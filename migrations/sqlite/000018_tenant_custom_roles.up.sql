@@ -0,0 +1,21 @@
+-- Workspace-defined custom roles (Lite). Mirrors migrations/versioned/000098.
+
+CREATE TABLE IF NOT EXISTS tenant_custom_roles (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    tenant_id INTEGER NOT NULL,
+    name VARCHAR(64) NOT NULL,
+    description VARCHAR(512) NOT NULL DEFAULT '',
+    base_role VARCHAR(20) NOT NULL,
+    permissions TEXT NOT NULL DEFAULT '[]',
+    created_by VARCHAR(36) NOT NULL DEFAULT '',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_tenant_custom_roles_tenant_name ON tenant_custom_roles (tenant_id, name);
+
+ALTER TABLE tenant_members ADD COLUMN custom_role_id INTEGER REFERENCES tenant_custom_roles (id) ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS idx_tenant_members_custom_role_id ON tenant_members (custom_role_id);
+
+ALTER TABLE tenant_api_keys ADD COLUMN custom_role_id INTEGER REFERENCES tenant_custom_roles (id) ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS idx_tenant_api_keys_custom_role_id ON tenant_api_keys (custom_role_id);
@@ -0,0 +1,17 @@
+-- SCIM 2.0 user provisioning (Lite). Mirrors migrations/versioned/000099.
+
+CREATE TABLE IF NOT EXISTS tenant_scim_users (
+    tenant_id INTEGER NOT NULL,
+    user_id VARCHAR(36) NOT NULL,
+    user_name VARCHAR(255) NOT NULL,
+    external_id VARCHAR(255) NOT NULL DEFAULT '',
+    active BOOLEAN NOT NULL DEFAULT 1,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (tenant_id, user_id)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_tenant_scim_users_user_name ON tenant_scim_users (tenant_id, user_name);
+
+ALTER TABLE tenant_api_keys ADD COLUMN created_by VARCHAR(36) NOT NULL DEFAULT '';
+CREATE INDEX IF NOT EXISTS idx_tenant_api_keys_created_by ON tenant_api_keys (tenant_id, created_by);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_tenant_data_keys_master_key;
+DROP INDEX IF EXISTS idx_tenant_data_key_version;
+DROP TABLE IF EXISTS tenant_data_keys;
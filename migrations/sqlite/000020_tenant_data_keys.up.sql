@@ -0,0 +1,15 @@
+-- Envelope encryption at rest (Lite). Mirrors migrations/versioned/000100.
+
+CREATE TABLE IF NOT EXISTS tenant_data_keys (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    tenant_id INTEGER NOT NULL,
+    version INTEGER NOT NULL,
+    master_key_id VARCHAR(128) NOT NULL,
+    wrapped_key TEXT NOT NULL,
+    active BOOLEAN NOT NULL DEFAULT 0,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_tenant_data_key_version ON tenant_data_keys (tenant_id, version);
+CREATE INDEX IF NOT EXISTS idx_tenant_data_keys_master_key ON tenant_data_keys (master_key_id);
@@ -0,0 +1,16 @@
+-- Policy-driven rate limits (Lite). Mirrors migrations/versioned/000101.
+
+CREATE TABLE IF NOT EXISTS rate_limit_policies (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    tenant_id INTEGER NOT NULL,
+    scope_type VARCHAR(16) NOT NULL,
+    scope_id VARCHAR(64) NOT NULL DEFAULT '',
+    requests_per_minute INTEGER NOT NULL DEFAULT 0,
+    concurrent_streams INTEGER NOT NULL DEFAULT 0,
+    ingest_mb_per_day INTEGER NOT NULL DEFAULT 0,
+    tokens_per_minute INTEGER NOT NULL DEFAULT 0,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_rate_limit_policies_scope ON rate_limit_policies (tenant_id, scope_type, scope_id);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_tenant_scim_organizations_organization_id;
+DROP TABLE IF EXISTS tenant_scim_organizations;
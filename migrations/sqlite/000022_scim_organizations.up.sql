@@ -0,0 +1,13 @@
+-- SCIM groups provisioned as organizations (Lite). Mirrors migrations/versioned/000102.
+
+CREATE TABLE IF NOT EXISTS tenant_scim_organizations (
+    tenant_id INTEGER NOT NULL,
+    group_id VARCHAR(36) NOT NULL,
+    organization_id VARCHAR(36) NOT NULL,
+    role VARCHAR(32) NOT NULL DEFAULT 'viewer',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (tenant_id, group_id)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_tenant_scim_organizations_organization_id ON tenant_scim_organizations (organization_id);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_graph_communities_kb;
+DROP TABLE IF EXISTS graph_communities;
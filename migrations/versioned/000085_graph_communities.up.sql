@@ -0,0 +1,33 @@
+-- Migration 000085: knowledge graph communities (GraphRAG global search).
+--
+-- One row per detected community of a knowledge base's entity graph, holding
+-- the report the KB's chat model wrote about it. The hierarchy is rebuilt as a
+-- whole and swapped in one transaction, so rows are never updated in place.
+-- Level 0 is the coarsest partition; parent_id points one level up.
+
+CREATE TABLE IF NOT EXISTS graph_communities (
+    id VARCHAR(36) PRIMARY KEY DEFAULT uuid_generate_v4(),
+    tenant_id INTEGER NOT NULL,
+    knowledge_base_id VARCHAR(36) NOT NULL,
+    level INTEGER NOT NULL DEFAULT 0,
+    parent_id VARCHAR(36) NOT NULL DEFAULT '',
+    title VARCHAR(512) NOT NULL DEFAULT '',
+    summary TEXT NOT NULL DEFAULT '',
+    findings JSONB,
+    -- Model-assigned 0-10 importance, used when no query embedding is available.
+    rating DOUBLE PRECISION NOT NULL DEFAULT 0,
+    -- Member entity names and source chunk ids, capped; the counts are exact.
+    entities JSONB,
+    entity_count INTEGER NOT NULL DEFAULT 0,
+    relation_count INTEGER NOT NULL DEFAULT 0,
+    chunk_ids JSONB,
+    -- Little-endian float32 embedding of title + summary. Ranking happens in
+    -- Go over one knowledge base at a time, so no vector index is needed.
+    embedding_model_id VARCHAR(64) NOT NULL DEFAULT '',
+    vector BYTEA,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_graph_communities_kb
+    ON graph_communities (tenant_id, knowledge_base_id);
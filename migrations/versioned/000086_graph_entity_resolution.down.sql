@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_graph_entity_aliases_key;
+DROP TABLE IF EXISTS graph_entity_aliases;
+DROP INDEX IF EXISTS idx_graph_merge_candidates_status;
+DROP INDEX IF EXISTS idx_graph_merge_candidates_pair;
+DROP TABLE IF EXISTS graph_merge_candidates;
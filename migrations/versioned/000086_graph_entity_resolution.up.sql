@@ -0,0 +1,44 @@
+-- Migration 000086: knowledge graph entity resolution.
+--
+-- graph_merge_candidates holds proposed entity merges and the reviewer's
+-- decision. A pair keeps its row once decided, so a rejected pair is not
+-- proposed again by the next resolution pass.
+--
+-- graph_entity_aliases maps merged-away names to their canonical name.
+-- Graph extraction rewrites names through it, so documents ingested after a
+-- merge do not recreate the alias node. alias_key is the lower-cased alias.
+
+CREATE TABLE IF NOT EXISTS graph_merge_candidates (
+    id VARCHAR(36) PRIMARY KEY DEFAULT uuid_generate_v4(),
+    tenant_id INTEGER NOT NULL,
+    knowledge_base_id VARCHAR(36) NOT NULL,
+    pair_key VARCHAR(1024) NOT NULL,
+    canonical VARCHAR(512) NOT NULL,
+    alias VARCHAR(512) NOT NULL,
+    score DOUBLE PRECISION NOT NULL DEFAULT 0,
+    reason VARCHAR(32) NOT NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'pending',
+    resolved_by VARCHAR(36) NOT NULL DEFAULT '',
+    resolved_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_graph_merge_candidates_pair
+    ON graph_merge_candidates (tenant_id, knowledge_base_id, pair_key);
+CREATE INDEX IF NOT EXISTS idx_graph_merge_candidates_status
+    ON graph_merge_candidates (status);
+
+CREATE TABLE IF NOT EXISTS graph_entity_aliases (
+    id VARCHAR(36) PRIMARY KEY DEFAULT uuid_generate_v4(),
+    tenant_id INTEGER NOT NULL,
+    knowledge_base_id VARCHAR(36) NOT NULL,
+    alias_key VARCHAR(512) NOT NULL,
+    alias VARCHAR(512) NOT NULL,
+    canonical VARCHAR(512) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_graph_entity_aliases_key
+    ON graph_entity_aliases (tenant_id, knowledge_base_id, alias_key);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_knowledge_versions_tenant_id;
+DROP INDEX IF EXISTS idx_knowledge_versions_version;
+DROP TABLE IF EXISTS knowledge_versions;
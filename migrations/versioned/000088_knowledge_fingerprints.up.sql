@@ -0,0 +1,50 @@
+-- Migration 000088: near-duplicate fingerprints.
+--
+-- knowledge_fingerprints holds a MinHash signature per indexed document and
+-- knowledge_fingerprint_bands its LSH band keys, which is how ingestion finds
+-- candidate duplicates inside a knowledge base. chunk_fingerprints holds a
+-- SimHash per text chunk for the duplicate report. dedup_config selects the
+-- ingestion policy (allow, warn, skip, alias) per knowledge base.
+
+ALTER TABLE knowledge_bases ADD COLUMN IF NOT EXISTS dedup_config JSONB;
+
+CREATE TABLE IF NOT EXISTS knowledge_fingerprints (
+    knowledge_id VARCHAR(36) PRIMARY KEY,
+    tenant_id INTEGER NOT NULL,
+    knowledge_base_id VARCHAR(36) NOT NULL,
+    alias_of VARCHAR(36) NOT NULL DEFAULT '',
+    content_hash VARCHAR(64) NOT NULL DEFAULT '',
+    min_hash JSONB NOT NULL DEFAULT '[]',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_knowledge_fingerprints_kb
+    ON knowledge_fingerprints (tenant_id, knowledge_base_id);
+CREATE INDEX IF NOT EXISTS idx_knowledge_fingerprints_alias_of
+    ON knowledge_fingerprints (alias_of);
+
+CREATE TABLE IF NOT EXISTS knowledge_fingerprint_bands (
+    knowledge_id VARCHAR(36) NOT NULL,
+    band_key BIGINT NOT NULL,
+    tenant_id INTEGER NOT NULL,
+    knowledge_base_id VARCHAR(36) NOT NULL,
+    PRIMARY KEY (knowledge_id, band_key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_knowledge_fingerprint_bands_lookup
+    ON knowledge_fingerprint_bands (knowledge_base_id, band_key);
+
+CREATE TABLE IF NOT EXISTS chunk_fingerprints (
+    chunk_id VARCHAR(36) PRIMARY KEY,
+    tenant_id INTEGER NOT NULL,
+    knowledge_base_id VARCHAR(36) NOT NULL,
+    knowledge_id VARCHAR(36) NOT NULL,
+    chunk_index INTEGER NOT NULL DEFAULT 0,
+    sim_hash BIGINT NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS idx_chunk_fingerprints_kb
+    ON chunk_fingerprints (tenant_id, knowledge_base_id);
+CREATE INDEX IF NOT EXISTS idx_chunk_fingerprints_knowledge_id
+    ON chunk_fingerprints (knowledge_id);
@@ -0,0 +1,9 @@
+ALTER TABLE knowledge_bases DROP COLUMN IF EXISTS freshness_config;
+DROP INDEX IF EXISTS idx_knowledges_review_by;
+DROP INDEX IF EXISTS idx_knowledges_valid_until;
+ALTER TABLE knowledges DROP COLUMN IF EXISTS freshness_notified_at;
+ALTER TABLE knowledges DROP COLUMN IF EXISTS freshness_source;
+ALTER TABLE knowledges DROP COLUMN IF EXISTS reviewed_at;
+ALTER TABLE knowledges DROP COLUMN IF EXISTS review_by;
+ALTER TABLE knowledges DROP COLUMN IF EXISTS valid_until;
+ALTER TABLE knowledges DROP COLUMN IF EXISTS valid_from;
@@ -0,0 +1,23 @@
+-- Migration 000089: knowledge freshness.
+--
+-- valid_from / valid_until bound the period in which a document is current;
+-- search excludes or down-weights it outside that window. review_by and
+-- reviewed_at drive the review workflow, freshness_source records whether the
+-- dates came from a user, a connector or a tag rule, and
+-- freshness_notified_at keeps the freshness sweep from re-notifying owners.
+-- freshness_config holds the per-knowledge-base policy, tag rules and
+-- notification targets.
+
+ALTER TABLE knowledges ADD COLUMN IF NOT EXISTS valid_from TIMESTAMP WITH TIME ZONE;
+ALTER TABLE knowledges ADD COLUMN IF NOT EXISTS valid_until TIMESTAMP WITH TIME ZONE;
+ALTER TABLE knowledges ADD COLUMN IF NOT EXISTS review_by TIMESTAMP WITH TIME ZONE;
+ALTER TABLE knowledges ADD COLUMN IF NOT EXISTS reviewed_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE knowledges ADD COLUMN IF NOT EXISTS freshness_source VARCHAR(16) NOT NULL DEFAULT '';
+ALTER TABLE knowledges ADD COLUMN IF NOT EXISTS freshness_notified_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX IF NOT EXISTS idx_knowledges_valid_until
+    ON knowledges (knowledge_base_id, valid_until) WHERE valid_until IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_knowledges_review_by
+    ON knowledges (knowledge_base_id, review_by) WHERE review_by IS NOT NULL;
+
+ALTER TABLE knowledge_bases ADD COLUMN IF NOT EXISTS freshness_config JSONB;
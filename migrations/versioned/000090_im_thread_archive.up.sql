@@ -0,0 +1,32 @@
+-- Migration 000090: IM thread archiving.
+--
+-- thread_archive holds a channel's opt-in archiving settings: the document
+-- knowledge base receiving thread transcripts, the reaction that triggers an
+-- archive and an optional FAQ knowledge base for condensed entries.
+-- im_thread_archives records every archived thread so archiving it again
+-- updates the same knowledge item and FAQ entry.
+
+ALTER TABLE im_channels ADD COLUMN IF NOT EXISTS thread_archive JSONB NOT NULL DEFAULT '{}';
+
+CREATE TABLE IF NOT EXISTS im_thread_archives (
+    id VARCHAR(36) PRIMARY KEY DEFAULT uuid_generate_v4(),
+    tenant_id BIGINT NOT NULL,
+    im_channel_id VARCHAR(36) NOT NULL,
+    platform VARCHAR(20) NOT NULL,
+    chat_id VARCHAR(128) NOT NULL DEFAULT '',
+    thread_id VARCHAR(128) NOT NULL,
+    knowledge_base_id VARCHAR(36) NOT NULL,
+    knowledge_id VARCHAR(36) NOT NULL,
+    faq_knowledge_base_id VARCHAR(36) NOT NULL DEFAULT '',
+    faq_entry_id BIGINT NOT NULL DEFAULT 0,
+    message_count INTEGER NOT NULL DEFAULT 0,
+    archived_by VARCHAR(128) NOT NULL DEFAULT '',
+    trigger_type VARCHAR(16) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_im_thread_archives_thread
+    ON im_thread_archives (im_channel_id, chat_id, thread_id);
+CREATE INDEX IF NOT EXISTS idx_im_thread_archives_tenant_id
+    ON im_thread_archives (tenant_id);
@@ -0,0 +1,10 @@
+DROP INDEX IF EXISTS idx_im_message_feedback_tenant_id;
+DROP INDEX IF EXISTS idx_im_message_feedback_channel;
+DROP INDEX IF EXISTS idx_im_message_feedback_user;
+DROP TABLE IF EXISTS im_message_feedback;
+DROP INDEX IF EXISTS idx_im_chat_settings_tenant_id;
+DROP INDEX IF EXISTS idx_im_chat_settings_chat;
+DROP TABLE IF EXISTS im_chat_settings;
+DROP INDEX IF EXISTS idx_im_user_bindings_tenant_id;
+DROP INDEX IF EXISTS idx_im_user_bindings_user;
+DROP TABLE IF EXISTS im_user_bindings;
@@ -0,0 +1,60 @@
+-- Migration 000091: richer IM slash-commands.
+--
+-- im_user_bindings maps an IM user on a channel to a tenant member so that
+-- /agent, /save and /approve can be checked against the member's role.
+-- im_chat_settings stores the agent and knowledge-base scope a chat picked
+-- with /agent and /kb. im_message_feedback records /good and /bad ratings.
+
+CREATE TABLE IF NOT EXISTS im_user_bindings (
+    id VARCHAR(36) PRIMARY KEY DEFAULT uuid_generate_v4(),
+    tenant_id BIGINT NOT NULL,
+    im_channel_id VARCHAR(36) NOT NULL,
+    im_user_id VARCHAR(128) NOT NULL,
+    user_id VARCHAR(36) NOT NULL,
+    created_by VARCHAR(36) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_im_user_bindings_user
+    ON im_user_bindings (im_channel_id, im_user_id);
+CREATE INDEX IF NOT EXISTS idx_im_user_bindings_tenant_id
+    ON im_user_bindings (tenant_id);
+
+CREATE TABLE IF NOT EXISTS im_chat_settings (
+    id VARCHAR(36) PRIMARY KEY DEFAULT uuid_generate_v4(),
+    tenant_id BIGINT NOT NULL,
+    im_channel_id VARCHAR(36) NOT NULL,
+    chat_key VARCHAR(160) NOT NULL,
+    agent_id VARCHAR(36) NOT NULL DEFAULT '',
+    knowledge_base_ids JSONB NOT NULL DEFAULT '[]',
+    updated_by VARCHAR(128) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_im_chat_settings_chat
+    ON im_chat_settings (im_channel_id, chat_key);
+CREATE INDEX IF NOT EXISTS idx_im_chat_settings_tenant_id
+    ON im_chat_settings (tenant_id);
+
+CREATE TABLE IF NOT EXISTS im_message_feedback (
+    id VARCHAR(36) PRIMARY KEY DEFAULT uuid_generate_v4(),
+    tenant_id BIGINT NOT NULL,
+    im_channel_id VARCHAR(36) NOT NULL,
+    platform VARCHAR(20) NOT NULL,
+    im_user_id VARCHAR(128) NOT NULL,
+    session_id VARCHAR(36) NOT NULL,
+    message_id VARCHAR(36) NOT NULL,
+    rating VARCHAR(8) NOT NULL,
+    comment TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_im_message_feedback_user
+    ON im_message_feedback (message_id, im_user_id);
+CREATE INDEX IF NOT EXISTS idx_im_message_feedback_channel
+    ON im_message_feedback (im_channel_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_im_message_feedback_tenant_id
+    ON im_message_feedback (tenant_id);
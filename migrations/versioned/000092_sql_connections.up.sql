@@ -0,0 +1,23 @@
+-- Migration 000092: external SQL connections for text-to-SQL agent tools.
+--
+-- parameters holds host / database / username and the AES-GCM encrypted
+-- password (or a local file path for sqlite / duckdb). options holds the
+-- row limit, timeout, table whitelist and column descriptions.
+
+CREATE TABLE IF NOT EXISTS sql_connections (
+    id VARCHAR(36) PRIMARY KEY DEFAULT uuid_generate_v4(),
+    tenant_id BIGINT NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    dialect VARCHAR(20) NOT NULL,
+    parameters JSON,
+    options JSON,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_sql_connections_tenant_id
+    ON sql_connections (tenant_id);
+CREATE INDEX IF NOT EXISTS idx_sql_connections_deleted_at
+    ON sql_connections (deleted_at);
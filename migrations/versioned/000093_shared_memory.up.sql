@@ -0,0 +1,11 @@
+-- Migration 000093: team-shared memory.
+--
+-- Shared spaces reuse memory_subjects and memory_items under reserved subject
+-- ids ("shared:workspace", "shared:agent:<agent id>"), so recall, supersede
+-- and expiry work on them unchanged. These columns record how a shared item
+-- got there: which member proposed it and from which personal memory, and
+-- which admin approved it.
+ALTER TABLE memory_items ADD COLUMN IF NOT EXISTS promoted_by VARCHAR(512);
+ALTER TABLE memory_items ADD COLUMN IF NOT EXISTS promoted_from VARCHAR(36);
+ALTER TABLE memory_items ADD COLUMN IF NOT EXISTS reviewed_by VARCHAR(512);
+ALTER TABLE memory_items ADD COLUMN IF NOT EXISTS reviewed_at TIMESTAMP WITH TIME ZONE;
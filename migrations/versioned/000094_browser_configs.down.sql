@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_browser_configs_deleted_at;
+DROP INDEX IF EXISTS idx_browser_configs_tenant_id;
+DROP TABLE IF EXISTS browser_configs;
@@ -0,0 +1,23 @@
+-- Migration 000094: headless browser services for the browser automation
+-- agent tools.
+--
+-- parameters holds the CDP endpoint URL, the AES-GCM encrypted access token
+-- and the private-endpoint opt-in. options holds the action timeout,
+-- viewport, text limit and the domain allowlist.
+
+CREATE TABLE IF NOT EXISTS browser_configs (
+    id VARCHAR(36) PRIMARY KEY DEFAULT uuid_generate_v4(),
+    tenant_id BIGINT NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    parameters JSON,
+    options JSON,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_browser_configs_tenant_id
+    ON browser_configs (tenant_id);
+CREATE INDEX IF NOT EXISTS idx_browser_configs_deleted_at
+    ON browser_configs (deleted_at);
@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS idx_user_group_members_user_id;
+DROP TABLE IF EXISTS user_group_members;
+DROP INDEX IF EXISTS idx_user_groups_tenant_external_id;
+DROP INDEX IF EXISTS idx_user_groups_tenant_name;
+DROP TABLE IF EXISTS user_groups;
+DROP INDEX IF EXISTS idx_knowledge_acl_entries_tenant_id;
+DROP INDEX IF EXISTS idx_knowledge_acl_entries_principal;
+DROP TABLE IF EXISTS knowledge_acl_entries;
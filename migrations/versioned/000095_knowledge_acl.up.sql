@@ -0,0 +1,53 @@
+-- Migration 000095: document-level access control.
+--
+-- knowledge_acl_entries narrows who may read a single knowledge item inside
+-- a knowledge base. A knowledge item without entries stays readable by
+-- everyone who can read its knowledge base. principal_type is one of user,
+-- email, group or organization; source separates entries set by hand from
+-- entries a data source connector synced from the upstream permissions.
+--
+-- user_groups / user_group_members are workspace-level groups that ACL
+-- entries can grant to. external_id lets connector permissions (e.g. a
+-- Feishu department id) match a group without knowing its WeKnora id.
+
+CREATE TABLE IF NOT EXISTS knowledge_acl_entries (
+    id VARCHAR(36) PRIMARY KEY DEFAULT uuid_generate_v4(),
+    tenant_id BIGINT NOT NULL,
+    knowledge_id VARCHAR(36) NOT NULL,
+    principal_type VARCHAR(20) NOT NULL,
+    principal_id VARCHAR(255) NOT NULL,
+    source VARCHAR(16) NOT NULL DEFAULT 'manual',
+    created_by VARCHAR(36) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_knowledge_acl_entries_principal
+    ON knowledge_acl_entries (knowledge_id, principal_type, principal_id, source);
+CREATE INDEX IF NOT EXISTS idx_knowledge_acl_entries_tenant_id
+    ON knowledge_acl_entries (tenant_id);
+
+CREATE TABLE IF NOT EXISTS user_groups (
+    id VARCHAR(36) PRIMARY KEY DEFAULT uuid_generate_v4(),
+    tenant_id BIGINT NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    external_id VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_groups_tenant_name
+    ON user_groups (tenant_id, name);
+CREATE INDEX IF NOT EXISTS idx_user_groups_tenant_external_id
+    ON user_groups (tenant_id, external_id);
+
+CREATE TABLE IF NOT EXISTS user_group_members (
+    group_id VARCHAR(36) NOT NULL,
+    user_id VARCHAR(36) NOT NULL,
+    tenant_id BIGINT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (group_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_group_members_user_id
+    ON user_group_members (user_id);
@@ -0,0 +1,54 @@
+-- Migration 000096: usage metering ledger and monthly token budgets.
+--
+-- usage_records is an append-only ledger with one row per metered model or
+-- tool call (chat, embedding, rerank, web search), tagged with the caller
+-- (user or API key), agent, knowledge base, session and model. period and
+-- day are the UTC month and day of created_at, stored as text so reports
+-- group the same way on every dialect.
+--
+-- usage_budgets caps the billable tokens (prompt + completion + embedding)
+-- a workspace (api_key_id = 0) or one of its API keys may use per UTC month.
+-- warned_period / exceeded_period record the last month each notice was
+-- sent, so they fire once per month across replicas.
+
+CREATE TABLE IF NOT EXISTS usage_records (
+    id BIGSERIAL PRIMARY KEY,
+    tenant_id BIGINT NOT NULL,
+    user_id VARCHAR(36) NOT NULL DEFAULT '',
+    api_key_id BIGINT NOT NULL DEFAULT 0,
+    agent_id VARCHAR(36) NOT NULL DEFAULT '',
+    knowledge_base_id VARCHAR(36) NOT NULL DEFAULT '',
+    session_id VARCHAR(36) NOT NULL DEFAULT '',
+    kind VARCHAR(16) NOT NULL,
+    model_id VARCHAR(64) NOT NULL DEFAULT '',
+    model_name VARCHAR(255) NOT NULL DEFAULT '',
+    prompt_tokens BIGINT NOT NULL DEFAULT 0,
+    completion_tokens BIGINT NOT NULL DEFAULT 0,
+    cached_tokens BIGINT NOT NULL DEFAULT 0,
+    embedding_tokens BIGINT NOT NULL DEFAULT 0,
+    calls BIGINT NOT NULL DEFAULT 0,
+    period VARCHAR(7) NOT NULL,
+    day VARCHAR(10) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_usage_records_tenant_day
+    ON usage_records (tenant_id, day);
+CREATE INDEX IF NOT EXISTS idx_usage_records_tenant_period_key
+    ON usage_records (tenant_id, period, api_key_id);
+
+CREATE TABLE IF NOT EXISTS usage_budgets (
+    id BIGSERIAL PRIMARY KEY,
+    tenant_id BIGINT NOT NULL,
+    api_key_id BIGINT NOT NULL DEFAULT 0,
+    monthly_token_limit BIGINT NOT NULL,
+    warn_percent INTEGER NOT NULL DEFAULT 80,
+    hard_stop BOOLEAN NOT NULL DEFAULT FALSE,
+    warned_period VARCHAR(7) NOT NULL DEFAULT '',
+    exceeded_period VARCHAR(7) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_usage_budgets_scope
+    ON usage_budgets (tenant_id, api_key_id);
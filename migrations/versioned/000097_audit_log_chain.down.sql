@@ -0,0 +1,10 @@
+DROP TRIGGER IF EXISTS trg_audit_logs_append_only ON audit_logs;
+DROP FUNCTION IF EXISTS audit_logs_reject_update();
+DROP TABLE IF EXISTS audit_log_sink_cursors;
+DROP TABLE IF EXISTS audit_log_chain_heads;
+DROP INDEX IF EXISTS idx_audit_logs_tenant_chain;
+
+ALTER TABLE audit_logs
+    DROP COLUMN IF EXISTS hash,
+    DROP COLUMN IF EXISTS prev_hash,
+    DROP COLUMN IF EXISTS chain_seq;
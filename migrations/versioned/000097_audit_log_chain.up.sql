@@ -0,0 +1,64 @@
+-- Migration 000097: tamper-evident audit log and SIEM export cursors.
+--
+-- Every audit row now carries a per-tenant sequence number and the
+-- SHA-256 of its content chained to the previous row's hash, so editing,
+-- deleting or reordering a row breaks the chain from that point on.
+-- Rows written before this migration keep chain_seq = 0 and are reported
+-- as unchained by the verification endpoint.
+--
+-- audit_log_chain_heads holds each tenant's last (seq, hash); writers lock
+-- it to serialise the chain. pruned_seq / pruned_hash are the checkpoint
+-- retention leaves behind so verification can resume after the deleted
+-- prefix.
+--
+-- audit_log_sink_cursors records, per export sink, the last row id the
+-- collector accepted plus a lease so only one replica ships at a time.
+-- Retention never deletes rows a sink has not shipped.
+--
+-- audit_logs is append-only from here on: a trigger rejects UPDATE.
+-- DELETE stays allowed for retention and workspace removal; the chain
+-- detects any other deletion.
+
+ALTER TABLE audit_logs
+    ADD COLUMN IF NOT EXISTS chain_seq BIGINT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS prev_hash VARCHAR(64) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS hash VARCHAR(64) NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_audit_logs_tenant_chain
+    ON audit_logs (tenant_id, chain_seq);
+
+CREATE TABLE IF NOT EXISTS audit_log_chain_heads (
+    tenant_id BIGINT PRIMARY KEY,
+    last_seq BIGINT NOT NULL DEFAULT 0,
+    last_hash VARCHAR(64) NOT NULL DEFAULT '',
+    pruned_seq BIGINT NOT NULL DEFAULT 0,
+    pruned_hash VARCHAR(64) NOT NULL DEFAULT '',
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS audit_log_sink_cursors (
+    sink VARCHAR(32) PRIMARY KEY,
+    last_id BIGINT NOT NULL DEFAULT 0,
+    lease_owner VARCHAR(128) NOT NULL DEFAULT '',
+    lease_until TIMESTAMP WITH TIME ZONE,
+    last_error VARCHAR(512) NOT NULL DEFAULT '',
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE OR REPLACE FUNCTION audit_logs_reject_update()
+RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_logs is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_trigger WHERE tgname = 'trg_audit_logs_append_only') THEN
+        CREATE TRIGGER trg_audit_logs_append_only
+            BEFORE UPDATE ON audit_logs
+            FOR EACH ROW
+            EXECUTE FUNCTION audit_logs_reject_update();
+        RAISE NOTICE '[Migration 000097] Created trigger trg_audit_logs_append_only';
+    END IF;
+END $$;
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_tenant_api_keys_custom_role_id;
+ALTER TABLE tenant_api_keys DROP COLUMN IF EXISTS custom_role_id;
+
+DROP INDEX IF EXISTS idx_tenant_members_custom_role_id;
+ALTER TABLE tenant_members DROP COLUMN IF EXISTS custom_role_id;
+
+DROP TABLE IF EXISTS tenant_custom_roles;
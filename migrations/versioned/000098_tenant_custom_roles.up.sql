@@ -0,0 +1,42 @@
+-- Migration 000098: workspace-defined custom roles.
+--
+-- A custom role is a built-in base role (admin, contributor or viewer)
+-- narrowed to a list of permissions. The permission names are the tenant
+-- API-key capabilities, so every route that already declares which
+-- capabilities admit an API key also tells the custom-role gate which
+-- permission a member needs.
+--
+-- tenant_members.custom_role_id assigns a role to a member; the member's
+-- role column keeps the base role so the existing role guards apply
+-- unchanged. tenant_api_keys.custom_role_id assigns a role to an API key,
+-- whose permissions then replace the key's own full_access / capabilities.
+-- Deleting a role that is still assigned is rejected by the service; the
+-- foreign keys fall back to NULL only for rows the service no longer
+-- counts (removed members, revoked keys).
+
+CREATE TABLE IF NOT EXISTS tenant_custom_roles (
+    id BIGSERIAL PRIMARY KEY,
+    tenant_id BIGINT NOT NULL,
+    name VARCHAR(64) NOT NULL,
+    description VARCHAR(512) NOT NULL DEFAULT '',
+    base_role VARCHAR(20) NOT NULL,
+    permissions JSONB NOT NULL DEFAULT '[]',
+    created_by VARCHAR(36) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_tenant_custom_roles_tenant_name
+    ON tenant_custom_roles (tenant_id, name);
+
+ALTER TABLE tenant_members
+    ADD COLUMN IF NOT EXISTS custom_role_id BIGINT
+        REFERENCES tenant_custom_roles (id) ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS idx_tenant_members_custom_role_id
+    ON tenant_members (custom_role_id);
+
+ALTER TABLE tenant_api_keys
+    ADD COLUMN IF NOT EXISTS custom_role_id BIGINT
+        REFERENCES tenant_custom_roles (id) ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS idx_tenant_api_keys_custom_role_id
+    ON tenant_api_keys (custom_role_id);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_tenant_api_keys_created_by;
+ALTER TABLE tenant_api_keys DROP COLUMN IF EXISTS created_by;
+
+DROP INDEX IF EXISTS idx_tenant_scim_users_user_name;
+DROP TABLE IF EXISTS tenant_scim_users;
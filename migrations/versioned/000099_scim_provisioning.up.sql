@@ -0,0 +1,30 @@
+-- Migration 000099: SCIM 2.0 user provisioning.
+--
+-- tenant_scim_users links a WeKnora user to the workspace whose SCIM
+-- endpoint provisioned it, with the identifiers the identity provider uses
+-- (userName, externalId). The link outlives a deactivation (active = false)
+-- so the provider can still read and reactivate the user after its
+-- membership has been removed; DELETE /Users/{id} drops the link.
+--
+-- tenant_api_keys.created_by records the user who created a key so that
+-- deprovisioning a user can revoke the keys they left behind. Keys created
+-- before this migration have an empty creator and are never revoked by it.
+
+CREATE TABLE IF NOT EXISTS tenant_scim_users (
+    tenant_id BIGINT NOT NULL,
+    user_id VARCHAR(36) NOT NULL,
+    user_name VARCHAR(255) NOT NULL,
+    external_id VARCHAR(255) NOT NULL DEFAULT '',
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (tenant_id, user_id)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_tenant_scim_users_user_name
+    ON tenant_scim_users (tenant_id, user_name);
+
+ALTER TABLE tenant_api_keys
+    ADD COLUMN IF NOT EXISTS created_by VARCHAR(36) NOT NULL DEFAULT '';
+CREATE INDEX IF NOT EXISTS idx_tenant_api_keys_created_by
+    ON tenant_api_keys (tenant_id, created_by);
@@ -0,0 +1,24 @@
+-- Migration 000100: envelope encryption at rest.
+--
+-- tenant_data_keys holds every version of each workspace's data encryption
+-- key, wrapped by a master key (master_key_id names it). Exactly one version
+-- per tenant is active and seals new files and chunk text; older versions
+-- stay so existing ciphertext, which records its version, remains readable.
+-- Rotating the master key rewrites wrapped_key / master_key_id in place and
+-- never touches the encrypted data.
+
+CREATE TABLE IF NOT EXISTS tenant_data_keys (
+    id BIGSERIAL PRIMARY KEY,
+    tenant_id BIGINT NOT NULL,
+    version INTEGER NOT NULL,
+    master_key_id VARCHAR(128) NOT NULL,
+    wrapped_key TEXT NOT NULL,
+    active BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_tenant_data_key_version
+    ON tenant_data_keys (tenant_id, version);
+CREATE INDEX IF NOT EXISTS idx_tenant_data_keys_master_key
+    ON tenant_data_keys (master_key_id);
@@ -0,0 +1,23 @@
+-- Migration 000101: policy-driven rate limits.
+--
+-- One row per (tenant, scope type, scope ID). scope_type is tenant, api_key,
+-- user or embed_channel; scope_id names the key, user or channel, and is
+-- empty for the tenant policy and for the per-type default that applies to
+-- every caller of that type without a policy of its own. A zero limit is
+-- unlimited. The counters themselves live in Redis, not here.
+
+CREATE TABLE IF NOT EXISTS rate_limit_policies (
+    id BIGSERIAL PRIMARY KEY,
+    tenant_id BIGINT NOT NULL,
+    scope_type VARCHAR(16) NOT NULL,
+    scope_id VARCHAR(64) NOT NULL DEFAULT '',
+    requests_per_minute INTEGER NOT NULL DEFAULT 0,
+    concurrent_streams INTEGER NOT NULL DEFAULT 0,
+    ingest_mb_per_day INTEGER NOT NULL DEFAULT 0,
+    tokens_per_minute BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_rate_limit_policies_scope
+    ON rate_limit_policies (tenant_id, scope_type, scope_id);
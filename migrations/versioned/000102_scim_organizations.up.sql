@@ -0,0 +1,20 @@
+-- Migration 000102: SCIM groups provisioned as organizations.
+--
+-- tenant_scim_organizations links a SCIM group of a workspace to the
+-- collaboration organization created for it when the group carries the
+-- WeKnora organization extension. role is the organization role given to
+-- the workspaces of the group's members. Deleting the group, or turning the
+-- extension off, deletes the organization and the link.
+
+CREATE TABLE IF NOT EXISTS tenant_scim_organizations (
+    tenant_id BIGINT NOT NULL,
+    group_id VARCHAR(36) NOT NULL,
+    organization_id VARCHAR(36) NOT NULL,
+    role VARCHAR(32) NOT NULL DEFAULT 'viewer',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (tenant_id, group_id)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_tenant_scim_organizations_organization_id
+    ON tenant_scim_organizations (organization_id);
@@ -61,6 +61,9 @@ if [ "$(uname)" = "Darwin" ]; then
 fi
 CGO_ENABLED=1 go build -tags "sqlite_fts5" -ldflags="${LDFLAGS}" \
     -o WeKnora-lite ./cmd/server
+if [ "${GOOS}" = "linux" ]; then
+    CGO_ENABLED=0 go build -ldflags="-w -s" -o weknora-sandbox-init ./cmd/sandbox-init
+fi
 
 # ── Step 3: Assemble package ──
 echo ">> Assembling package..."
@@ -68,6 +71,15 @@ rm -rf "${DIST_DIR}"
 mkdir -p "${DIST_DIR}/web"
 
 cp WeKnora-lite "${DIST_DIR}/"
+if [ -f weknora-sandbox-init ] && [ "${GOOS}" = "linux" ]; then
+    cp weknora-sandbox-init "${DIST_DIR}/"
+fi
+# wasm 沙箱的解释器运行时（README 之外有文件时才复制）
+if [ -f internal/sandbox/wasmrt/python.wasm ] || [ -f internal/sandbox/wasmrt/qjs.wasm ]; then
+    mkdir -p "${DIST_DIR}/wasm-runtimes"
+    cp -r internal/sandbox/wasmrt/* "${DIST_DIR}/wasm-runtimes/"
+    rm -f "${DIST_DIR}/wasm-runtimes/README.md"
+fi
 if [ -d web ] && [ -f web/index.html ]; then
     cp -r web/* "${DIST_DIR}/web/"
 fi
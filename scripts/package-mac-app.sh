@@ -91,6 +91,12 @@ fi
 if [ -d web ]; then
     cp -r web "${RESOURCES_DIR}/"
 fi
+# wasm 沙箱的解释器运行时，桌面版没有 Docker 时由它运行 Skill 脚本
+if [ -f internal/sandbox/wasmrt/python.wasm ] || [ -f internal/sandbox/wasmrt/qjs.wasm ]; then
+    mkdir -p "${RESOURCES_DIR}/wasm-runtimes"
+    cp -r internal/sandbox/wasmrt/* "${RESOURCES_DIR}/wasm-runtimes/"
+    rm -f "${RESOURCES_DIR}/wasm-runtimes/README.md"
+fi
 
 # 注意：Wails build 生成的二进制文件工作目录默认是 app 的 Contents/MacOS 目录
 # 后续可能需要调整代码中对配置文件的路径读取逻辑。